@@ -2,19 +2,35 @@
 //
 // go-ffmpeg-hls-swarm is a load testing tool that orchestrates a swarm of FFmpeg
 // processes to stress-test HLS (HTTP Live Streaming) infrastructure.
+//
+// Exit codes, for CI pipelines to branch on (see orchestrator.ExitCode):
+//
+//	0  success
+//	1  other failure
+//	2  threshold violated (-slo missed)
+//	3  safety abort (-client-cpu-limit)
+//	4  configuration error
+//	5  environment failure (preflight, FFmpeg, metrics port, origin)
 package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/logging"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/orchestrator"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
 )
 
 // version is set at build time via ldflags:
@@ -32,15 +48,20 @@ func run() int {
 		arg := os.Args[1]
 		if arg == "-version" || arg == "--version" || arg == "version" {
 			fmt.Printf("go-ffmpeg-hls-swarm %s\n", version)
-			return 0
+			return orchestrator.ExitOK
+		}
+		if arg == "runs" {
+			return runRuns(os.Args[2:])
 		}
 	}
 
 	// Parse command-line flags
 	cfg, err := config.ParseFlags()
+	if errors.Is(err, flag.ErrHelp) {
+		return orchestrator.ExitOK
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing flags: %v\n", err)
-		return 1
+		return orchestrator.ExitConfig // The flag package printed it, and the usage
 	}
 
 	// Initialize logger
@@ -52,12 +73,23 @@ func run() int {
 	} else {
 		logger = logging.NewLogger(cfg.LogFormat, "info", cfg.Verbose)
 	}
+	// Every record carries the run's ID and phase, to correlate the logs
+	// of one run once shipped elsewhere
+	run := logging.NewRun()
+	logger = logging.WithRun(logger, run)
+	// Outermost, so the suppression summaries carry run_id and phase too
+	logger, dedup := logging.WithDedup(logger, cfg.LogDedupWindow)
+	defer dedup.Flush()
 	logging.SetDefault(logger)
 
 	// Validate configuration
 	if err := config.Validate(cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
-		return 1
+		return orchestrator.ExitConfig
+	}
+	for _, w := range config.Warnings(cfg) {
+		fmt.Fprintf(os.Stderr, "Configuration warning: %s\n", w)
+		logger.Warn("config_warning", "field", w.Field, "message", w.Message, "suggestion", w.Suggestion)
 	}
 
 	// Apply --check mode modifications
@@ -69,7 +101,34 @@ func run() int {
 	// Handle --print-cmd mode
 	if cfg.PrintCmd {
 		printFFmpegCommand(cfg)
-		return 0
+		return orchestrator.ExitOK
+	}
+
+	// Handle --plan mode (superset of --print-cmd, launches nothing)
+	if cfg.Plan {
+		plan, err := orchestrator.BuildPlan(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return orchestrator.ExitCode(err)
+		}
+		plan.Print(os.Stdout)
+		return orchestrator.ExitOK
+	}
+
+	// With -stats-stdout, stdout carries only snapshots: everything printed
+	// for humans (banner, preflight, TUI, summary) goes to stderr instead
+	out := io.Writer(os.Stdout)
+	if cfg.StatsStdout != "" {
+		out = os.Stderr
+	}
+
+	// Handle --conn-probe and --playlist-stress modes (no FFmpeg clients
+	// at all)
+	if cfg.ConnProbe {
+		return runConnProbe(out, cfg, logger)
+	}
+	if cfg.PlaylistStress {
+		return runPlaylistStress(cfg, logger)
 	}
 
 	// Log startup
@@ -79,52 +138,157 @@ func run() int {
 		"ramp_rate", cfg.RampRate,
 		"stream_url", cfg.StreamURL,
 		"variant", cfg.Variant,
-		"metrics_addr", cfg.MetricsAddr,
+		"metrics_addrs", cfg.MetricsAddrs,
 	)
 
-	// Print startup banner
-	printBanner(cfg)
-
-	// Create and run orchestrator
+	// Create the orchestrator and bind the metrics port first, so a port
+	// conflict fails fast and the banner shows the real (possibly random) port
 	orch := orchestrator.New(cfg, logger)
-	if err := orch.Run(context.Background()); err != nil {
-		logger.Error("orchestrator_failed", "error", err)
-		return 1
+	orch.SetOutput(out)
+	orch.SetStatsOutput(os.Stdout)
+	orch.SetRun(run)
+	if err := orch.StartMetricsServer(); err != nil {
+		logger.Error("metrics_server_failed", "error", err)
+		return orchestrator.ExitCode(err)
 	}
 
-	return 0
+	// Print startup banner
+	printBanner(out, cfg)
+
+	err = orch.Run(context.Background())
+	if err != nil {
+		logger.Error("orchestrator_failed", "error", err, "exit_code", orchestrator.ExitCode(err))
+	}
+	return orchestrator.ExitCode(err)
 }
 
-// printBanner prints the startup banner.
-func printBanner(cfg *config.Config) {
-	fmt.Println()
-	fmt.Println("╔═══════════════════════════════════════════════════════════════════╗")
-	fmt.Println("║                     go-ffmpeg-hls-swarm                           ║")
-	fmt.Println("║     HLS Load Testing with FFmpeg Process Orchestration            ║")
-	fmt.Println("╚═══════════════════════════════════════════════════════════════════╝")
-	fmt.Println()
-	fmt.Printf("  Target:      %d clients at %d/sec\n", cfg.Clients, cfg.RampRate)
-	fmt.Printf("  Stream:      %s\n", cfg.StreamURL)
-	fmt.Printf("  Variant:     %s\n", cfg.Variant)
-	fmt.Printf("  Metrics:     http://%s/metrics\n", cfg.MetricsAddr)
+// printBanner prints the startup banner to w.
+func printBanner(w io.Writer, cfg *config.Config) {
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "╔═══════════════════════════════════════════════════════════════════╗")
+	fmt.Fprintln(w, "║                     go-ffmpeg-hls-swarm                           ║")
+	fmt.Fprintln(w, "║     HLS Load Testing with FFmpeg Process Orchestration            ║")
+	fmt.Fprintln(w, "╚═══════════════════════════════════════════════════════════════════╝")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Target:      %d clients at %d/sec\n", cfg.Clients, cfg.RampRate)
+	fmt.Fprintf(w, "  Stream:      %s\n", cfg.StreamURL)
+	fmt.Fprintf(w, "  Variant:     %s\n", cfg.Variant)
+	if cfg.Renditions != "" {
+		fmt.Fprintf(w, "  Renditions:  %s (with the variant)\n", cfg.Renditions)
+	}
+	for _, t := range cfg.Tenants {
+		quota := "no quota"
+		if t.MaxRPS > 0 {
+			quota = fmt.Sprintf("max %.0f req/s", t.MaxRPS)
+		}
+		fmt.Fprintf(w, "  Tenant:      %s, %d clients, %s\n", t.Name, t.Clients, quota)
+	}
+	for _, g := range cfg.Geos {
+		fmt.Fprintf(w, "  Geo:         %s, weight %d, %s\n", g.Name, g.Weight, strings.Join(g.Headers, "; "))
+	}
+	for _, addr := range cfg.MetricsAddrs {
+		fmt.Fprintf(w, "  Metrics:     %s\n", metrics.Endpoint(addr))
+	}
+	if cfg.PushgatewayURL != "" {
+		fmt.Fprintf(w, "  Pushgateway: %s (job=%s, at exit)\n", cfg.PushgatewayURL, cfg.PushgatewayJob)
+	}
 	if cfg.NoCache {
-		fmt.Println("  Cache:       BYPASS (no-cache headers)")
+		fmt.Fprintln(w, "  Cache:       BYPASS (no-cache headers)")
+	}
+	if cfg.TokenURL != "" {
+		if cfg.TokenTTL > 0 {
+			fmt.Fprintf(w, "  Tokens:      %s (new token per client start, re-auth on 401 and every %s)\n", cfg.TokenURL, cfg.TokenTTL)
+		} else {
+			fmt.Fprintf(w, "  Tokens:      %s (new token per client start, re-auth on 401)\n", cfg.TokenURL)
+		}
+	}
+	if cfg.PcapDir != "" {
+		fmt.Fprintf(w, "  Capture:     %s (%d sampled clients, ring of %d × %d MB)\n",
+			cfg.PcapDir, cfg.PcapClients, cfg.PcapFiles, cfg.PcapFileMB)
+	}
+	if cfg.FlapInterval > 0 {
+		fmt.Fprintf(w, "  Flaps:       %d client(s) paused for %s every %s\n", cfg.FlapClients, cfg.FlapDuration, cfg.FlapInterval)
+	}
+	if cfg.SessionDuration != "" {
+		fmt.Fprintf(w, "  Sessions:    %s (each ended, then replaced)\n", cfg.SessionDuration)
+	}
+	if cfg.VODStart != "" && cfg.VODStart != "start" {
+		fmt.Fprintf(w, "  VOD start:   %s (drawn at every client start)\n", cfg.VODStart)
+	}
+	switch cfg.VODEnd {
+	case config.VODEndStop:
+		fmt.Fprintf(w, "  VOD end:     stop (the run ends once every client has finished)\n")
+	case config.VODEndLoop:
+		fmt.Fprintf(w, "  VOD end:     loop (clients play the asset again)\n")
+	}
+	for _, b := range cfg.Bursts {
+		fmt.Fprintf(w, "  Burst:       %s\n", b)
+	}
+	if cfg.SocketStats {
+		fmt.Fprintf(w, "  Sockets:     kernel tcp_info every %s\n", cfg.StatsAggregateInterval)
+	}
+	if cfg.Prefetch >= 0 {
+		fmt.Fprintf(w, "  Prefetch:    %d segment(s) ahead\n", cfg.Prefetch)
+	}
+	if cfg.AcceptEncoding != "" {
+		fmt.Fprintf(w, "  Encoding:    Accept-Encoding: %s\n", cfg.AcceptEncoding)
 	}
 	if cfg.ResolveIP != "" {
-		fmt.Printf("  Resolve:     %s (⚠️  TLS verification disabled)\n", cfg.ResolveIP)
+		fmt.Fprintf(w, "  Resolve:     %s (⚠️  TLS verification disabled)\n", cfg.ResolveIP)
 	}
-	fmt.Println()
-	fmt.Println("Press Ctrl+C to stop.")
-	fmt.Println()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Press Ctrl+C to stop.")
+	fmt.Fprintln(w)
+}
+
+// runConnProbe runs --conn-probe until the origin refuses, -conn-probe-max
+// or Ctrl+C, and prints the connection ceiling to w.
+func runConnProbe(w io.Writer, cfg *config.Config, logger *slog.Logger) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(w, "Probing the connection limit of %s: +%d connections every %s, up to %d (Ctrl+C to stop)...\n\n",
+		cfg.StreamURL, cfg.ConnProbeStep, cfg.ConnProbeStepDuration, cfg.ConnProbeMax)
+	summary, err := orchestrator.ProbeConnections(ctx, cfg, logger)
+	fmt.Fprint(w, stats.FormatConnLimit(summary))
+	if err != nil {
+		logger.Error("conn_probe_failed", "error", err)
+		return orchestrator.ExitCode(err)
+	}
+	return orchestrator.ExitOK
+}
+
+// runPlaylistStress runs --playlist-stress for -duration or until Ctrl+C,
+// and prints the playlist rate the origin served.
+func runPlaylistStress(cfg *config.Config, logger *slog.Logger) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rate := "as fast as the origin answers"
+	if cfg.PlaylistStressRate > 0 {
+		rate = fmt.Sprintf("%g/s in all", cfg.PlaylistStressRate)
+	}
+	fmt.Printf("Stressing the playlists of %s: %d workers, %s (Ctrl+C to stop)...\n\n",
+		cfg.StreamURL, cfg.PlaylistStressWorkers, rate)
+	summary, err := orchestrator.StressPlaylists(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("playlist_stress_failed", "error", err)
+		return orchestrator.ExitCode(err)
+	}
+	fmt.Print(stats.FormatPlaylistStress(summary))
+	return orchestrator.ExitOK
 }
 
 // printFFmpegCommand prints the FFmpeg command that would be generated.
 func printFFmpegCommand(cfg *config.Config) {
 	// Create a runner to generate the command
+	audio, subs, _ := config.ParseRenditions(cfg.Renditions)
 	ffmpegConfig := &process.FFmpegConfig{
 		BinaryPath:        cfg.FFmpegPath,
 		StreamURL:         cfg.StreamURL,
 		Variant:           process.VariantSelection(cfg.Variant),
+		AudioRendition:    audio,
+		SubtitleRendition: subs,
 		UserAgent:         cfg.UserAgent,
 		Timeout:           cfg.Timeout,
 		Reconnect:         cfg.Reconnect,
@@ -134,7 +298,9 @@ func printFFmpegCommand(cfg *config.Config) {
 		ResolveIP:         cfg.ResolveIP,
 		DangerousMode:     cfg.DangerousMode,
 		NoCache:           cfg.NoCache,
+		Prefetch:          cfg.Prefetch,
 		Headers:           cfg.Headers,
+		AcceptEncoding:    cfg.AcceptEncoding,
 		ProgramID:         -1,
 		// Stats collection
 		StatsEnabled:  cfg.StatsEnabled,
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/orchestrator"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+const runsUsage = `Usage: go-ffmpeg-hls-swarm runs [-runs-file PATH] <command>
+
+Browse the history written by -save-run.
+
+Commands:
+  list                 One line per run
+  show [ID]            Everything recorded for a run (default: the latest)
+  compare ID [ID]      Two runs side by side (default second run: the latest)
+  trend [flags]        One metric across runs at the same client count,
+                       flagging a latest run significantly worse than the rest
+                       (-metric segment-p95, -clients N, -last 10; exits 2,
+                       threshold violated, on a regression)
+
+`
+
+// runRuns implements the "runs" subcommand.
+func runRuns(args []string) int {
+	fs := flag.NewFlagSet("runs", flag.ContinueOnError)
+	path := fs.String("runs-file", config.DefaultRunsFile(), "Run history file")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, runsUsage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return orchestrator.ExitConfig
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return orchestrator.ExitConfig
+	}
+
+	runs, err := stats.LoadRuns(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading run history: %v\n", err)
+		return orchestrator.ExitFailure
+	}
+
+	cmd, rest := fs.Arg(0), fs.Args()[1:]
+	switch cmd {
+	case "list":
+		fmt.Print(stats.FormatRunList(runs))
+		return orchestrator.ExitOK
+
+	case "show":
+		if len(rest) > 1 {
+			fs.Usage()
+			return orchestrator.ExitConfig
+		}
+		r, err := pickRun(runs, rest, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return orchestrator.ExitFailure
+		}
+		fmt.Print(stats.FormatRun(r))
+		return orchestrator.ExitOK
+
+	case "compare":
+		if len(rest) == 0 || len(rest) > 2 {
+			fs.Usage()
+			return orchestrator.ExitConfig
+		}
+		a, err := pickRun(runs, rest, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return orchestrator.ExitFailure
+		}
+		b, err := pickRun(runs, rest, 1)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return orchestrator.ExitFailure
+		}
+		fmt.Print(stats.FormatRunCompare(a, b))
+		return orchestrator.ExitOK
+
+	case "trend":
+		return runsTrend(runs, rest)
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown runs command %q\n\n", cmd)
+		fs.Usage()
+		return orchestrator.ExitConfig
+	}
+}
+
+// runsTrend implements "runs trend". Exits with ExitThreshold on a
+// regression so CI can gate on it.
+func runsTrend(runs []stats.RunRecord, args []string) int {
+	fs := flag.NewFlagSet("runs trend", flag.ContinueOnError)
+	metric := fs.String("metric", "segment-p95", "Metric to follow: "+strings.Join(stats.RunMetricNames(), ", "))
+	clients := fs.Int("clients", 0, "Target client count to compare at (default: the latest run's)")
+	last := fs.Int("last", 10, "Number of most recent matching runs")
+	if err := fs.Parse(args); err != nil {
+		return orchestrator.ExitConfig
+	}
+	if len(runs) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no runs recorded yet (run with -save-run)")
+		return orchestrator.ExitFailure
+	}
+	if *clients == 0 {
+		*clients = runs[len(runs)-1].TargetClients
+	}
+	if *last < 2 {
+		fmt.Fprintln(os.Stderr, "Error: -last must be at least 2")
+		return orchestrator.ExitConfig
+	}
+
+	trend, err := stats.NewRunTrend(runs, *metric, *clients, *last)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return orchestrator.ExitFailure
+	}
+	fmt.Print(trend.Format())
+	if trend.Regression {
+		return orchestrator.ExitThreshold
+	}
+	return orchestrator.ExitOK
+}
+
+// pickRun returns the run whose ID is args[i], or the latest run if there
+// is no such argument.
+func pickRun(runs []stats.RunRecord, args []string, i int) (*stats.RunRecord, error) {
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no runs recorded yet (run with -save-run)")
+	}
+	if i >= len(args) {
+		return &runs[len(runs)-1], nil
+	}
+	id, err := strconv.Atoi(args[i])
+	if err != nil {
+		return nil, fmt.Errorf("run ID %q is not a number", args[i])
+	}
+	r, ok := stats.FindRun(runs, id)
+	if !ok {
+		return nil, fmt.Errorf("no run #%d (see: go-ffmpeg-hls-swarm runs list)", id)
+	}
+	return r, nil
+}
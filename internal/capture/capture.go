@@ -0,0 +1,432 @@
+// Package capture records packet captures of a sampled subset of the
+// swarm's clients with tcpdump, for deep debugging of failures found at
+// scale.
+//
+// tcpdump can't filter by process, so one capture covers the swarm's
+// traffic to the origin, written to a ring of files (tcpdump -C/-W) so it
+// never outgrows its disk budget. Meanwhile the sampled clients' FFmpeg
+// sockets are polled to learn their local ports, and at exit each sampled
+// client's packets are cut out of the ring into a pcap of its own.
+package capture
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ringName is the ring file base name; tcpdump appends the file number.
+const ringName = "swarm.pcap"
+
+// startTimeout bounds how long tcpdump may take to start listening.
+const startTimeout = 5 * time.Second
+
+// Config configures a Capturer.
+type Config struct {
+	Dir       string        // Output directory
+	Filter    string        // BPF filter for the ring, see OriginFilter
+	SnapLen   int           // Bytes kept per packet (0 = whole packets)
+	FileMB    int           // Ring file size before rotating
+	Files     int           // Ring files kept
+	ClientIDs []int         // Sampled clients, see SampleClients
+	Tcpdump   string        // tcpdump binary ("" = from PATH)
+	Interval  time.Duration // Socket poll interval (0 = 1s)
+	Logger    *slog.Logger
+}
+
+// Conn is one TCP connection of a sampled client.
+type Conn struct {
+	LocalPort int
+	Remote    string // host:port
+}
+
+// ClientCapture is one sampled client's share of the capture.
+type ClientCapture struct {
+	ClientID int
+	Conns    []Conn // Every connection seen, in order
+	File     string // Its own pcap ("" if it made no connections or splitting failed)
+}
+
+// Filter returns a Wireshark display filter for the client's packets.
+func (c ClientCapture) Filter() string {
+	ports := make([]string, len(c.Conns))
+	for i, conn := range c.Conns {
+		ports[i] = strconv.Itoa(conn.LocalPort)
+	}
+	return "tcp.port in {" + strings.Join(ports, " ") + "}"
+}
+
+// Summary describes a finished capture.
+type Summary struct {
+	Dir       string
+	RingFiles []string // Oldest first
+	RingBytes int64
+	Clients   []ClientCapture
+}
+
+// Capturer runs tcpdump and tracks the sampled clients' connections.
+type Capturer struct {
+	cfg     Config
+	tcpdump string
+	ring    string
+	logger  *slog.Logger
+
+	cmd      *exec.Cmd
+	exited   chan struct{} // Closed when tcpdump exits
+	cancel   context.CancelFunc
+	pollDone chan struct{}
+
+	mu      sync.Mutex
+	pids    map[int]int          // Sampled client -> current FFmpeg PID
+	conns   map[int][]Conn       // Sampled client -> connections seen
+	seen    map[int]map[int]bool // Sampled client -> local ports seen
+	summary *Summary             // Set by Stop
+}
+
+// New checks that capturing is possible here and returns a Capturer.
+func New(cfg Config) (*Capturer, error) {
+	if !socketsSupported {
+		return nil, errors.New("per-client packet capture needs Linux (/proc sockets)")
+	}
+	name := cfg.Tcpdump
+	if name == "" {
+		name = "tcpdump"
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("tcpdump not found: %w", err)
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	c := &Capturer{
+		cfg:     cfg,
+		tcpdump: path,
+		ring:    filepath.Join(cfg.Dir, ringName),
+		logger:  logger,
+		pids:    make(map[int]int),
+		conns:   make(map[int][]Conn),
+		seen:    make(map[int]map[int]bool),
+	}
+	for _, id := range cfg.ClientIDs {
+		c.seen[id] = make(map[int]bool)
+	}
+	return c, nil
+}
+
+// SampleClients picks n of clients client IDs, spread evenly.
+func SampleClients(clients, n int) []int {
+	n = min(n, clients)
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i * clients / n
+	}
+	return ids
+}
+
+// OriginFilter returns a BPF filter for the traffic to the stream's origin:
+// the -resolve IP if given, else the URL's host, on the URL's port.
+func OriginFilter(streamURL, resolveIP string) (string, error) {
+	u, err := url.Parse(streamURL)
+	if err != nil || u.Hostname() == "" {
+		return "", fmt.Errorf("stream URL %q has no host", streamURL)
+	}
+	host := u.Hostname()
+	if resolveIP != "" {
+		host = resolveIP
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return fmt.Sprintf("tcp and host %s and port %s", host, port), nil
+}
+
+// Start runs tcpdump and returns once it is capturing, or with its error
+// (typically missing capture privileges).
+func (c *Capturer) Start(ctx context.Context) error {
+	args := []string{
+		"-i", "any", "-n", "-U",
+		"-s", strconv.Itoa(c.cfg.SnapLen),
+		"-C", strconv.Itoa(c.cfg.FileMB),
+		"-W", strconv.Itoa(c.cfg.Files),
+		"-w", c.ring,
+		c.cfg.Filter,
+	}
+	c.cmd = exec.Command(c.tcpdump, args...)
+	stderr, err := c.cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return fmt.Errorf("start tcpdump: %w", err)
+	}
+
+	// tcpdump reports "listening on <interface>" once it is capturing
+	lines := bufio.NewScanner(stderr)
+	listening := make(chan error, 1)
+	stderrDone := make(chan struct{}) // Wait must not run before reads finish
+	go func() {
+		defer close(stderrDone)
+		var last string
+		for lines.Scan() {
+			last = lines.Text()
+			if strings.HasPrefix(last, "listening on") {
+				listening <- nil
+				break
+			}
+		}
+		if last == "" || !strings.HasPrefix(last, "listening on") {
+			listening <- fmt.Errorf("tcpdump exited: %s", last)
+			return
+		}
+		for lines.Scan() {
+			c.logger.Debug("tcpdump", "line", lines.Text())
+		}
+	}()
+
+	select {
+	case err = <-listening:
+	case <-time.After(startTimeout):
+		err = errors.New("tcpdump did not start listening")
+	}
+	if err != nil {
+		_ = c.cmd.Process.Kill()
+		<-stderrDone
+		_ = c.cmd.Wait()
+		return err
+	}
+
+	c.exited = make(chan struct{})
+	go func() {
+		<-stderrDone
+		_ = c.cmd.Wait()
+		close(c.exited)
+	}()
+
+	pollCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.pollDone = make(chan struct{})
+	go c.pollLoop(pollCtx)
+	return nil
+}
+
+// ClientStarted notes a client's new FFmpeg process; only sampled clients
+// are tracked.
+func (c *Capturer) ClientStarted(clientID, pid int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, sampled := c.seen[clientID]; sampled {
+		c.pids[clientID] = pid
+	}
+}
+
+func (c *Capturer) pollLoop(ctx context.Context) {
+	defer close(c.pollDone)
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll records any new connections of the sampled clients. A process that
+// has exited simply has no sockets left.
+func (c *Capturer) poll() {
+	c.mu.Lock()
+	pids := make(map[int]int, len(c.pids))
+	for id, pid := range c.pids {
+		pids[id] = pid
+	}
+	c.mu.Unlock()
+
+	for id, pid := range pids {
+		conns, err := processSockets(pid)
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		for _, conn := range conns {
+			if !c.seen[id][conn.LocalPort] {
+				c.seen[id][conn.LocalPort] = true
+				c.conns[id] = append(c.conns[id], conn)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Stop ends the capture and writes each sampled client's pcap. Safe to
+// call more than once; later calls return the same summary.
+func (c *Capturer) Stop() *Summary {
+	c.mu.Lock()
+	if c.summary != nil {
+		defer c.mu.Unlock()
+		return c.summary
+	}
+	c.mu.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+		<-c.pollDone
+		c.poll() // Connections opened since the last tick
+	}
+	if c.cmd != nil && c.exited != nil {
+		// SIGINT makes tcpdump flush and close the current ring file
+		_ = c.cmd.Process.Signal(syscall.SIGINT)
+		select {
+		case <-c.exited:
+		case <-time.After(startTimeout):
+			_ = c.cmd.Process.Kill()
+			<-c.exited
+		}
+	}
+
+	s := &Summary{Dir: c.cfg.Dir}
+	s.RingFiles, s.RingBytes = c.ringFiles()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range c.cfg.ClientIDs {
+		cc := ClientCapture{ClientID: id, Conns: c.conns[id]}
+		if len(cc.Conns) > 0 && len(s.RingFiles) > 0 {
+			file, err := c.split(s.RingFiles, cc)
+			if err != nil {
+				c.logger.Warn("pcap_split_failed", "client_id", id, "error", err)
+			}
+			cc.File = file
+		}
+		s.Clients = append(s.Clients, cc)
+	}
+	c.summary = s
+	return s
+}
+
+// ringFiles returns the ring's files, oldest first, and their total size.
+func (c *Capturer) ringFiles() ([]string, int64) {
+	matches, _ := filepath.Glob(c.ring + "*")
+	type file struct {
+		path string
+		mod  time.Time
+		size int64
+	}
+	var files []file
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, file{path, info.ModTime(), info.Size()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+
+	paths := make([]string, len(files))
+	var total int64
+	for i, f := range files {
+		paths[i] = f.path
+		total += f.size
+	}
+	return paths, total
+}
+
+// split writes a client's packets from the ring files to client-<id>.pcap.
+func (c *Capturer) split(ring []string, cc ClientCapture) (string, error) {
+	list := filepath.Join(c.cfg.Dir, fmt.Sprintf("client-%d.files", cc.ClientID))
+	if err := os.WriteFile(list, []byte(strings.Join(ring, "\n")+"\n"), 0o644); err != nil {
+		return "", err
+	}
+	defer os.Remove(list)
+
+	ports := make([]string, len(cc.Conns))
+	for i, conn := range cc.Conns {
+		ports[i] = "port " + strconv.Itoa(conn.LocalPort)
+	}
+	out := filepath.Join(c.cfg.Dir, fmt.Sprintf("client-%d.pcap", cc.ClientID))
+	filter := "tcp and (" + strings.Join(ports, " or ") + ")"
+	if msg, err := exec.Command(c.tcpdump, "-V", list, "-w", out, filter).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("tcpdump -V: %w: %s", err, strings.TrimSpace(string(msg)))
+	}
+	return out, nil
+}
+
+// parseProcNetTCP parses /proc/net/tcp or tcp6, returning the connections
+// by socket inode. Listening sockets are skipped.
+func parseProcNetTCP(data []byte) map[uint64]Conn {
+	conns := make(map[uint64]Conn)
+	for i, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if i == 0 || len(fields) < 10 || fields[3] == tcpListen {
+			continue
+		}
+		_, localPort, err := parseHexAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		remoteIP, remotePort, err := parseHexAddr(fields[2])
+		if err != nil {
+			continue
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+		conns[inode] = Conn{
+			LocalPort: localPort,
+			Remote:    net.JoinHostPort(remoteIP.String(), strconv.Itoa(remotePort)),
+		}
+	}
+	return conns
+}
+
+// tcpListen is the TCP_LISTEN state in /proc/net/tcp.
+const tcpListen = "0A"
+
+// parseHexAddr parses a /proc/net/tcp address: the IP as 32-bit words in
+// host (little-endian) order, then the port, all hex.
+func parseHexAddr(s string) (net.IP, int, error) {
+	ipHex, portHex, ok := strings.Cut(s, ":")
+	if !ok || (len(ipHex) != 8 && len(ipHex) != 32) {
+		return nil, 0, fmt.Errorf("bad address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("bad port in %q", s)
+	}
+	ip := make(net.IP, len(ipHex)/2)
+	for w := 0; w < len(ip)/4; w++ {
+		word, err := strconv.ParseUint(ipHex[w*8:w*8+8], 16, 32)
+		if err != nil {
+			return nil, 0, fmt.Errorf("bad IP in %q", s)
+		}
+		for b := 0; b < 4; b++ {
+			ip[w*4+b] = byte(word >> (8 * b))
+		}
+	}
+	return ip, int(port), nil
+}
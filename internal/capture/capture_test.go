@@ -0,0 +1,208 @@
+package capture
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampleClients(t *testing.T) {
+	tests := []struct {
+		clients, n int
+		want       []int
+	}{
+		{100, 3, []int{0, 33, 66}},
+		{10, 1, []int{0}},
+		{2, 5, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		got := SampleClients(tt.clients, tt.n)
+		if len(got) != len(tt.want) {
+			t.Fatalf("SampleClients(%d, %d) = %v, want %v", tt.clients, tt.n, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("SampleClients(%d, %d) = %v, want %v", tt.clients, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestOriginFilter(t *testing.T) {
+	tests := []struct {
+		url, resolve string
+		want         string
+	}{
+		{"http://cdn.example.com/live.m3u8", "", "tcp and host cdn.example.com and port 80"},
+		{"https://cdn.example.com/live.m3u8", "", "tcp and host cdn.example.com and port 443"},
+		{"https://cdn.example.com:8443/live.m3u8", "10.0.0.5", "tcp and host 10.0.0.5 and port 8443"},
+	}
+	for _, tt := range tests {
+		if got, err := OriginFilter(tt.url, tt.resolve); err != nil || got != tt.want {
+			t.Errorf("OriginFilter(%q, %q) = %q, %v; want %q", tt.url, tt.resolve, got, err, tt.want)
+		}
+	}
+	if _, err := OriginFilter("/just/a/path.m3u8", ""); err == nil {
+		t.Error("OriginFilter() without a host = nil, want error")
+	}
+}
+
+func TestParseProcNetTCP(t *testing.T) {
+	data := []byte(`  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1111 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:C350 0100007F:1F90 01 00000000:00000000 00:00000000 00000000  1000        0 2222 1 0000000000000000 20 4 30 10 -1
+   2: 0100007F:C351 0100007F:1F90 06 00000000:00000000 03:00000000 00000000     0        0 0 3 0000000000000000
+`)
+	conns := parseProcNetTCP(data)
+	if len(conns) != 1 {
+		t.Fatalf("conns = %+v, want only the established connection", conns)
+	}
+	if c := conns[2222]; c.LocalPort != 0xC350 || c.Remote != "127.0.0.1:8080" {
+		t.Errorf("conn = %+v, want local port 50000 to 127.0.0.1:8080", c)
+	}
+
+	ip, port, err := parseHexAddr("0000000000000000FFFF00000100007F:01BB")
+	if err != nil || ip.String() != "127.0.0.1" || port != 443 {
+		t.Errorf("parseHexAddr(v4-mapped v6) = %v, %d, %v", ip, port, err)
+	}
+	if _, _, err := parseHexAddr("0100007F"); err == nil {
+		t.Error("parseHexAddr() without a port = nil, want error")
+	}
+}
+
+func TestClientCapture_Filter(t *testing.T) {
+	cc := ClientCapture{Conns: []Conn{{LocalPort: 50000}, {LocalPort: 50123}}}
+	if got := cc.Filter(); got != "tcp.port in {50000 50123}" {
+		t.Errorf("Filter() = %q", got)
+	}
+}
+
+// fakeTcpdump writes a tcpdump stand-in: capture mode announces it is
+// listening, creates two ring files and waits for SIGINT; -V mode writes
+// its -w file. Every invocation's arguments are appended to args.log.
+func fakeTcpdump(t *testing.T, dir string) string {
+	t.Helper()
+	script := `#!/bin/sh
+echo "$@" >> "` + dir + `/args.log"
+out=""; prev=""; read=""
+for arg in "$@"; do
+	[ "$prev" = "-w" ] && out="$arg"
+	[ "$prev" = "-V" ] && read="$arg"
+	prev="$arg"
+done
+if [ -n "$read" ]; then
+	cat "$read" > "$out"
+	exit 0
+fi
+echo "listening on any, link-type LINUX_SLL2" >&2
+echo old > "${out}0"
+sleep 0.05
+echo new > "${out}1"
+trap 'exit 0' INT
+while :; do sleep 0.05; done
+`
+	path := filepath.Join(dir, "tcpdump")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCapturer(t *testing.T) {
+	if !socketsSupported {
+		t.Skip("needs /proc sockets")
+	}
+	dir := t.TempDir()
+
+	// This test process stands in for a sampled client's FFmpeg
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	localPort := conn.LocalAddr().(*net.TCPAddr).Port
+
+	c, err := New(Config{
+		Dir:       dir,
+		Filter:    "tcp and host 127.0.0.1 and port 80",
+		SnapLen:   512,
+		FileMB:    10,
+		Files:     2,
+		ClientIDs: []int{0, 5},
+		Tcpdump:   fakeTcpdump(t, dir),
+		Interval:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	c.ClientStarted(5, os.Getpid())
+	c.ClientStarted(7, os.Getpid()) // Not sampled
+	time.Sleep(50 * time.Millisecond)
+
+	s := c.Stop()
+	if len(s.RingFiles) != 2 || !strings.HasSuffix(s.RingFiles[0], "swarm.pcap0") {
+		t.Errorf("RingFiles = %v, want swarm.pcap0 then swarm.pcap1", s.RingFiles)
+	}
+	if len(s.Clients) != 2 || len(s.Clients[0].Conns) != 0 || s.Clients[0].File != "" {
+		t.Fatalf("Clients = %+v, want client 0 without connections", s.Clients)
+	}
+
+	client := s.Clients[1]
+	found := false
+	for _, conn := range client.Conns {
+		found = found || conn.LocalPort == localPort
+	}
+	if !found {
+		t.Errorf("client 5 conns = %+v, want local port %d", client.Conns, localPort)
+	}
+	if client.File != filepath.Join(dir, "client-5.pcap") {
+		t.Fatalf("client 5 file = %q", client.File)
+	}
+	if data, _ := os.ReadFile(client.File); !strings.Contains(string(data), "swarm.pcap0") {
+		t.Errorf("client pcap was not cut from the ring files: %q", data)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args.log"))
+	for _, want := range []string{"-s 512 -C 10 -W 2", "tcp and host 127.0.0.1 and port 80", "port " + strconv.Itoa(localPort)} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("tcpdump args missing %q:\n%s", want, args)
+		}
+	}
+
+	if again := c.Stop(); again != s {
+		t.Error("second Stop() returned a different summary")
+	}
+}
+
+func TestCapturer_StartFailure(t *testing.T) {
+	if !socketsSupported {
+		t.Skip("needs /proc sockets")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tcpdump")
+	script := "#!/bin/sh\necho 'tcpdump: any: You don'\"'\"'t have permission to capture on that device' >&2\nexit 1\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Config{Dir: dir, Filter: "tcp", FileMB: 1, Files: 1, Tcpdump: path})
+	if err != nil {
+		t.Fatalf("New() = %v", err)
+	}
+	if err := c.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "permission") {
+		t.Errorf("Start() = %v, want tcpdump's permission error", err)
+	}
+}
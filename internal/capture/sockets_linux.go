@@ -0,0 +1,48 @@
+//go:build linux
+
+package capture
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const socketsSupported = true
+
+// processSockets returns the TCP connections a process has open, matching
+// its socket file descriptors against its network namespace's tables.
+func processSockets(pid int) ([]Conn, error) {
+	dir := fmt.Sprintf("/proc/%d", pid)
+	fds, err := os.ReadDir(dir + "/fd")
+	if err != nil {
+		return nil, err
+	}
+	inodes := make(map[uint64]bool)
+	for _, fd := range fds {
+		link, err := os.Readlink(dir + "/fd/" + fd.Name())
+		if err != nil {
+			continue
+		}
+		if s, ok := strings.CutPrefix(link, "socket:["); ok {
+			if inode, err := strconv.ParseUint(strings.TrimSuffix(s, "]"), 10, 64); err == nil {
+				inodes[inode] = true
+			}
+		}
+	}
+
+	var conns []Conn
+	for _, table := range []string{"/net/tcp", "/net/tcp6"} {
+		data, err := os.ReadFile(dir + table)
+		if err != nil {
+			continue // No IPv6
+		}
+		for inode, conn := range parseProcNetTCP(data) {
+			if inodes[inode] {
+				conns = append(conns, conn)
+			}
+		}
+	}
+	return conns, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package capture
+
+import "errors"
+
+const socketsSupported = false
+
+func processSockets(pid int) ([]Conn, error) {
+	return nil, errors.New("process sockets are only available on Linux")
+}
@@ -3,6 +3,12 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -13,6 +19,45 @@ type Config struct {
 	RampRate   int           `json:"ramp_rate"`
 	RampJitter time.Duration `json:"ramp_jitter"`
 	Duration   time.Duration `json:"duration"` // 0 = forever
+	CoolDown   time.Duration `json:"cool_down"` // Probe the origin this long after clients stop (0 = off)
+
+	// CPU pinning for FFmpeg processes: "none", "core", "numa"
+	CPUAffinity string `json:"cpu_affinity"`
+
+	// FFmpeg environment and scheduling priority (-geo cohorts can add to
+	// the environment and override the priority)
+	ClientEnv []string `json:"client_env"` // KEY=VALUE, templated like -header
+	Nice      int      `json:"nice"`       // 0 = the swarm's
+	IONice    string   `json:"ionice"`     // class[:level], "" = the swarm's
+
+	// How fast clients read the stream: "fast", "realtime" or
+	// "buffer:DURATION" (see ParsePacing; "" = fast). -geo cohorts can
+	// override it.
+	Pacing string `json:"pacing"`
+
+	// Viewer sessions: each client's session ends cleanly after a length
+	// drawn from this distribution and a new one replaces it, for the
+	// connection churn of real viewers (see ParseSessionDuration; "" =
+	// sessions last the whole run)
+	SessionDuration string `json:"session_duration"`
+
+	// VOD start positions: where in the asset each client starts playing,
+	// so the segment requests spread over the whole asset (see
+	// ParseVODStart; "" = all from the start)
+	VODStart string `json:"vod_start"`
+
+	// What a client does when it plays a VOD asset to the end: VODEndStop
+	// or VODEndLoop, with the plays' completion tracked ("" = restart
+	// after backoff like any other exit, untracked)
+	VODEnd string `json:"vod_end"`
+
+	// Multi-tenant runs: named subsets of the clients with their own
+	// request-rate quota and report (nil = one anonymous tenant)
+	Tenants []Tenant `json:"tenants"`
+
+	// Scheduled start (multi-host bursts without a coordinator)
+	StartAt   time.Time `json:"start_at"`   // Zero = start immediately
+	NTPServer string    `json:"ntp_server"` // Clock offset source for StartAt ("" = trust local clock)
 
 	// FFmpeg
 	FFmpegPath        string        `json:"ffmpeg_path"`
@@ -24,26 +69,145 @@ type Config struct {
 	ReconnectDelayMax int           `json:"reconnect_delay_max"`
 	SegMaxRetry       int           `json:"seg_max_retry"`
 	LogLevel          string        `json:"ffmpeg_log_level"`
+	FFmpegExtraArgs   []string      `json:"ffmpeg_extra_args"` // Input options passed through, before -i
+
+	// Alternate renditions (#EXT-X-MEDIA) fetched with the variant, as a
+	// player does: audio=LANG|all,subs=LANG|all (see ParseRenditions; "" =
+	// the variant's own streams only)
+	Renditions string `json:"renditions"`
+
+	// Device mix: clients spread over the master playlist's variants by
+	// weight (nil = Variant picks one for every client)
+	VariantMix []VariantShare `json:"variant_mix"`
+
+	// A/B origin comparison: CompareSplit percent of the clients fetch the
+	// same stream from CompareURL instead, reported side by side ("" = off)
+	CompareURL   string `json:"compare_url"`
+	CompareSplit int    `json:"compare_split"`
+
+	// Red/black comparison: request options (KEY=VALUE, see
+	// ParseCompareOpt) the B clients use instead of the shared ones. Without
+	// CompareURL both cohorts fetch the stream URL.
+	CompareOpts []string `json:"compare_opts"`
 
 	// Network
 	ResolveIP     string   `json:"resolve_ip"`
 	DangerousMode bool     `json:"dangerous_mode"`
 	NoCache       bool     `json:"no_cache"`
+	NoKeepAlive   bool     `json:"no_keepalive"` // New TCP connection per request
 	Headers       []string `json:"headers"`
 
+	// Segments each client fetches ahead of the one it is reading: 0 or 1
+	// (-1 = FFmpeg's default, on for HTTP/1.1 origins)
+	Prefetch int `json:"prefetch"`
+
+	// Accept-Encoding for playlist and segment requests ("" = client default)
+	AcceptEncoding string `json:"accept_encoding"`
+
+	// Session tokens: fetched from TokenURL on every client process start
+	// for {token} in Headers; a 401 restarts the client with a new one
+	TokenURL string `json:"token_url"`
+
+	// Forced token expiry: each client restarts with a new token this long
+	// after its process started (0 = only on 401)
+	TokenTTL time.Duration `json:"token_ttl"`
+
+	// CDN mapping tests: client cohorts that emulate geos/ISPs with their
+	// own request headers (e.g. X-Forwarded-For), reported per geo
+	Geos []Geo `json:"geos"`
+
 	// Health / Stall Detection
 	TargetDuration time.Duration `json:"target_duration"`
 	RestartOnStall bool          `json:"restart_on_stall"`
 
+	// Segment wall time P95 at which the origin counts as saturated, for
+	// the capacity projected during the ramp (0 = TargetDuration)
+	CapacityP95 time.Duration `json:"capacity_p95"`
+
+	// Segment latency SLO as TARGET:THRESHOLD ("99:1s" = 99% of segments
+	// under 1s; "" = off), tracked with its error budget burn rate
+	SLO string `json:"slo"`
+
 	// Observability
-	MetricsAddr string `json:"metrics_addr"`
-	Verbose     bool   `json:"verbose"`
-	LogFormat   string `json:"log_format"` // json, text
+	MetricsAddrs []string `json:"metrics_addrs"` // host:port or unix:/path, all serve /metrics
+	Verbose      bool     `json:"verbose"`
+	LogFormat    string   `json:"log_format"` // json, logfmt, text
+
+	// Repeated warnings and errors (same level and message) are logged once
+	// per window, then summarized with a count (0 = log every one)
+	LogDedupWindow time.Duration `json:"log_dedup_window"`
+
+	// Pushgateway (final metrics pushed at exit, for short CI runs)
+	PushgatewayURL    string   `json:"pushgateway_url"`    // Empty = disabled
+	PushgatewayJob    string   `json:"pushgateway_job"`    // job label of the pushed group
+	PushgatewayLabels []string `json:"pushgateway_labels"` // Extra grouping labels, key=value
+
+	// Event streaming: the clients' parsed debug events and lifecycle
+	// events, sent to Loki or to Kafka (through a Kafka REST Proxy) as
+	// they happen
+	EventSink       string   `json:"event_sink"`        // "loki", "kafka" or "" (off)
+	EventSinkURL    string   `json:"event_sink_url"`    // Loki or REST Proxy base URL
+	EventSinkTopic  string   `json:"event_sink_topic"`  // Kafka topic
+	EventSinkLabels []string `json:"event_sink_labels"` // Extra labels, key=value
+	EventSinkDebug  bool     `json:"event_sink_debug"`  // Debug events too, not only lifecycle ones
+
+	// Run history (see the "runs" subcommand)
+	SaveRun  bool   `json:"save_run"`  // Append the run summary to RunsFile at exit
+	RunsFile string `json:"runs_file"` // JSON lines, one run per line
 
 	// Diagnostic modes
 	PrintCmd      bool `json:"print_cmd"`
+	Plan          bool `json:"plan"`
 	Check         bool `json:"check"`
 	SkipPreflight bool `json:"skip_preflight"`
+	KillOrphans   bool `json:"kill_orphans"` // Kill FFmpegs left by crashed runs at startup
+	Strict        bool `json:"strict"`       // Configuration warnings are errors (see Warnings)
+
+	// Connection-limit discovery (--conn-probe): instead of the swarm,
+	// short requests on new connections, ramped until the origin refuses
+	ConnProbe             bool          `json:"conn_probe"`
+	ConnProbeStep         int           `json:"conn_probe_step"`          // Concurrent connections added per step
+	ConnProbeMax          int           `json:"conn_probe_max"`           // Stop at this many
+	ConnProbeStepDuration time.Duration `json:"conn_probe_step_duration"` // How long each step lasts
+
+	// Playlist stress (--playlist-stress): instead of the swarm, native
+	// workers reload the playlists only, for -duration
+	PlaylistStress        bool    `json:"playlist_stress"`
+	PlaylistStressWorkers int     `json:"playlist_stress_workers"`
+	PlaylistStressRate    float64 `json:"playlist_stress_rate"` // Requests/sec over all workers (0 = as fast as they can)
+
+	// Demux-only guard: a client using more CPU than this is decoding
+	ClientCPULimit  float64 `json:"client_cpu_limit"`  // Percent of one core (0 = off)
+	ClientCPUPolicy string  `json:"client_cpu_policy"` // "warn" or "fail"
+
+	// Memory guard: above this RSS the swarm sheds memory-hungry features
+	MaxMemoryMB int `json:"max_memory_mb"` // Megabytes (0 = off)
+
+	// Packet capture of a sampled subset of the clients (tcpdump, Linux)
+	PcapDir     string `json:"pcap_dir"`     // "" = off
+	PcapClients int    `json:"pcap_clients"` // Clients sampled
+	PcapSnapLen int    `json:"pcap_snaplen"` // Bytes kept per packet (0 = whole packets)
+	PcapFileMB  int    `json:"pcap_file_mb"` // Ring file size before rotating
+	PcapFiles   int    `json:"pcap_files"`   // Ring files kept
+
+	// Origin log join: a unique run tag in every User-Agent, and after the
+	// run the origin's access log (a file, or a Loki server) searched for
+	// it, to set the origin's request times against the clients'
+	TagRequests    bool   `json:"tag_requests"`
+	OriginLog      string `json:"origin_log"`       // Path or Loki base URL ("" = off; tags requests)
+	OriginLogQuery string `json:"origin_log_query"` // Loki stream selector, e.g. {job="nginx"}
+
+	// Network flaps: clients paused with SIGSTOP, then resumed
+	FlapInterval time.Duration `json:"flap_interval"` // Between flaps (0 = off)
+	FlapDuration time.Duration `json:"flap_duration"` // How long a flap pauses its clients
+	FlapClients  int           `json:"flap_clients"`  // Clients paused per flap
+
+	// Traffic bursts: extra clients layered on the baseline for a while,
+	// every so often
+	Bursts []Burst `json:"bursts"`
+
+	// Planning
+	ExpectedBitrate int `json:"expected_bitrate_kbps"` // Per-client bitrate for --plan bandwidth estimate (0 = unknown)
 
 	// Restart policy
 	MaxRestarts     int           `json:"max_restarts"` // 0 = unlimited
@@ -51,24 +215,86 @@ type Config struct {
 	BackoffMax      time.Duration `json:"backoff_max"`
 	BackoffMultiply float64       `json:"backoff_multiply"`
 
+	// Named restart policies: BackoffPreset replaces backoff_initial/max/
+	// multiply, BackoffOn picks a policy by why a client exited
+	BackoffPreset string            `json:"backoff_preset"` // "" = backoff_initial etc.
+	BackoffOn     map[string]string `json:"backoff_on"`     // Exit cause -> preset
+
+	// Quarantine: a client failing QuarantineFailures times within
+	// QuarantineWindow is parked for QuarantineCooldown
+	QuarantineFailures int           `json:"quarantine_failures"` // 0 = off
+	QuarantineWindow   time.Duration `json:"quarantine_window"`
+	QuarantineCooldown time.Duration `json:"quarantine_cooldown"`
+
+	// Stop policy: FFmpeg gets StopSignal, then SIGKILL after StopGrace
+	StopSignal string        `json:"stop_signal"` // "TERM", "INT", "QUIT" or "HUP"
+	StopGrace  time.Duration `json:"stop_grace"`
+
 	// Probe failure policy
 	ProbeFailurePolicy string `json:"probe_failure_policy"` // "fail" or "fallback"
 
+	// Load estimate (manifest probe before ramp-up)
+	EstimateLoad   bool    `json:"estimate_load"`
+	LoadBudgetRPS  float64 `json:"load_budget_rps"`  // Warn above this many origin req/s (0 = no budget)
+	LoadBudgetMbps float64 `json:"load_budget_mbps"` // Warn above this origin bandwidth (0 = no budget)
+
+	// Playlist validation (RFC 8216 compliance while under load)
+	ValidatePlaylists        bool          `json:"validate_playlists"`
+	ValidatePlaylistInterval time.Duration `json:"validate_playlist_interval"` // Reload interval per rendition
+
+	// Playlist refresh override: each client's media playlists are fetched
+	// every PlaylistRefresh x the target duration, FFmpeg's own reloads
+	// topped up by the swarm (0 = FFmpeg's cadence only)
+	PlaylistRefresh float64 `json:"playlist_refresh"`
+
+	// ConditionalPlaylists makes the swarm's refreshes revalidate like a
+	// player with a cache: If-None-Match / If-Modified-Since from each
+	// client's last response, so unchanged playlists come back 304
+	ConditionalPlaylists bool `json:"conditional_playlists"`
+
 	// Stats collection (metrics enhancement)
-	StatsEnabled       bool    `json:"stats_enabled"`        // Enable FFmpeg output parsing
-	StatsLogLevel      string  `json:"stats_log_level"`      // FFmpeg loglevel: "verbose" or "debug"
-	StatsBufferSize    int     `json:"stats_buffer_size"`    // Lines to buffer per client pipeline
-	StatsDropThreshold float64 `json:"stats_drop_threshold"` // Degradation threshold (0.01 = 1%)
+	StatsEnabled           bool          `json:"stats_enabled"`            // Enable FFmpeg output parsing
+	StatsLogLevel          string        `json:"stats_log_level"`          // FFmpeg loglevel: "verbose" or "debug"
+	FFmpegLogDialect       string        `json:"ffmpeg_log_dialect"`       // "auto", "ffmpeg6" or "ffmpeg7": how FFmpeg's log lines are laid out
+	ProgressMode           string        `json:"progress_mode"`            // "pipe", "socket" or "off": how -progress output reaches the parser
+	StatsBufferSize        int           `json:"stats_buffer_size"`        // Lines to buffer per client pipeline
+	StatsDropThreshold     float64       `json:"stats_drop_threshold"`     // Degradation threshold (0.01 = 1%)
+	StatsMaxLineLength     int           `json:"stats_max_line_length"`    // Longer FFmpeg output lines are truncated (bytes)
+	StatsRetention         int           `json:"stats_retention"`          // Max history samples in memory before downsampling
+	StatsSpillDir          string        `json:"stats_spill_dir"`          // Full-resolution history on disk ("" = off)
+	StatsSpillCompress     bool          `json:"stats_spill_compress"`     // Gzip the StatsSpillDir history
+	StatsStdout            string        `json:"stats_stdout"`             // Periodic snapshots on stdout: "" (off) or "ndjson"
+	StatsInterval          time.Duration `json:"stats_interval"`           // Snapshot interval for StatsStdout and the SaveRun timeline
+	StatsAggregateInterval time.Duration `json:"stats_aggregate_interval"` // How often per-client stats are aggregated
+	SlowRequestLog         time.Duration `json:"slow_request_log"`         // Log downloads at least this slow (0 = off)
+	SocketStats            bool          `json:"socket_stats"`             // Sample kernel tcp_info of the clients' connections (Linux)
+	CDNDetect              bool          `json:"cdn_detect"`               // Classify responses as CDN edge or origin from their headers
+	OriginHitAlert         float64       `json:"origin_hit_alert"`         // Flag origin-served responses above this percentage (0 = off)
 
 	// FD mode (file descriptor for progress, no filesystem files)
 	// Always enabled when stats are enabled - provides clean separation from stderr
 	DebugLogging bool `json:"debug_logging"` // Enable -loglevel debug (safe with FD mode)
 
+	// DebugSample runs this percentage of the clients, picked at random,
+	// at debug loglevel with every parsed event logged, and the rest at
+	// verbose (0 = off)
+	DebugSample float64 `json:"debug_sample"`
+
 	// TUI (Terminal User Interface)
-	TUIEnabled bool `json:"tui_enabled"` // Enable live terminal dashboard
+	TUIEnabled         bool          `json:"tui_enabled"`          // Enable live terminal dashboard
+	TUIPanels          []string      `json:"tui_panels"`           // Sections to render (nil = saved prefs, else all)
+	TUIPrefsPath       string        `json:"tui_prefs_path"`       // Layout saved on exit ("" = don't persist)
+	TUITheme           string        `json:"tui_theme"`            // "default", "high-contrast", "monochrome", "ascii"
+	TUIRefreshInterval time.Duration `json:"tui_refresh_interval"` // Dashboard redraw interval
+
+	// Headless status line (replaces the TUI)
+	StatusLine     bool          `json:"status_line"`
+	StatusInterval time.Duration `json:"status_interval"` // 0 = 1s on a terminal, 10s otherwise
 
 	// Prometheus
-	PromClientMetrics bool `json:"prom_client_metrics"` // Enable per-client Prometheus metrics (high cardinality)
+	PromClientMetrics     bool          `json:"prom_client_metrics"`     // Enable per-client Prometheus metrics (high cardinality)
+	PromClientMetricsMax  int           `json:"prom_client_metrics_max"` // Above this many clients, bucket by client_id (0 = no cap)
+	MetricsUpdateInterval time.Duration `json:"metrics_update_interval"` // How often aggregated stats are copied into Prometheus
 
 	// Origin Metrics (Defect F: TUI_DEFECTS.md)
 	OriginMetricsURL      string        `json:"origin_metrics_url"`       // node_exporter URL (e.g., http://10.177.0.10:9100/metrics)
@@ -95,6 +321,12 @@ func DefaultConfig() *Config {
 		RampJitter: 200 * time.Millisecond,
 		Duration:   0, // Forever
 
+		// CPU pinning
+		CPUAffinity: "none",
+
+		// Scheduled start
+		NTPServer: "pool.ntp.org",
+
 		// FFmpeg
 		FFmpegPath:        "ffmpeg",
 		Variant:           "all",
@@ -104,15 +336,49 @@ func DefaultConfig() *Config {
 		ReconnectDelayMax: 5,
 		SegMaxRetry:       3,
 		LogLevel:          "info",
+		CompareSplit:      50,
+		Prefetch:          -1,
 
 		// Health
 		TargetDuration: 6 * time.Second,
 		RestartOnStall: false,
 
 		// Observability
-		MetricsAddr: "0.0.0.0:17091",  // See docs/PORTS.md
-		Verbose:     false,
-		LogFormat:   "json",
+		MetricsAddrs: []string{"0.0.0.0:17091"}, // See docs/PORTS.md
+		Verbose:      false,
+		LogFormat:    "json",
+
+		LogDedupWindow: 10 * time.Second,
+
+		// Pushgateway
+		PushgatewayURL: "", // Disabled by default
+		PushgatewayJob: "hls_swarm",
+
+		// Event streaming
+		EventSinkTopic: "hls-swarm-events",
+		EventSinkDebug: true,
+
+		// Run history
+		SaveRun:  false,
+		RunsFile: DefaultRunsFile(),
+
+		// Packet capture
+		PcapClients: 3,
+		PcapSnapLen: 512, // TCP/IP headers, TLS records, HTTP request lines
+		PcapFileMB:  100,
+		PcapFiles:   10,
+
+		// Network flaps
+		FlapDuration: 10 * time.Second,
+		FlapClients:  1,
+
+		// Connection-limit discovery
+		ConnProbeStep:         50,
+		ConnProbeMax:          5000,
+		ConnProbeStepDuration: 5 * time.Second,
+
+		// Playlist stress
+		PlaylistStressWorkers: 200,
 
 		// Restart policy
 		MaxRestarts:     0, // Unlimited
@@ -120,23 +386,54 @@ func DefaultConfig() *Config {
 		BackoffMax:      5 * time.Second,
 		BackoffMultiply: 1.7,
 
+		// Quarantine (off until -quarantine-after)
+		QuarantineWindow:   time.Minute,
+		QuarantineCooldown: 5 * time.Minute,
+
+		// Demux-only guard
+		ClientCPULimit:  25,
+		ClientCPUPolicy: "warn",
+
+		// Stop policy
+		StopSignal: "TERM",
+		StopGrace:  5 * time.Second,
+
 		// Probe
 		ProbeFailurePolicy: "fallback",
 
+		// Load estimate
+		EstimateLoad: true,
+
+		// Playlist validation
+		ValidatePlaylists:        false,
+		ValidatePlaylistInterval: 1 * time.Second,
+
 		// Stats collection
-		StatsEnabled:       true,
-		StatsLogLevel:      "debug", // Default to debug to capture manifest refreshes
-		StatsBufferSize:    1000,
-		StatsDropThreshold: 0.01, // 1% drop rate = degraded
+		StatsEnabled:           true,
+		OriginHitAlert:         20,
+		StatsLogLevel:          "debug", // Default to debug to capture manifest refreshes
+		FFmpegLogDialect:       "auto",
+		ProgressMode:           "pipe",
+		StatsBufferSize:        1000,
+		StatsDropThreshold:     0.01, // 1% drop rate = degraded
+		StatsMaxLineLength:     64 * 1024,
+		StatsRetention:         10000,
+		StatsInterval:          5 * time.Second,
+		StatsAggregateInterval: time.Second,
 
 		// FD mode (always enabled when stats are enabled)
 		DebugLogging: false, // Disabled by default
 
 		// TUI
-		TUIEnabled: true, // Enabled by default (use -no-tui to disable)
+		TUIEnabled:         true, // Enabled by default (use -no-tui to disable)
+		TUIPrefsPath:       defaultTUIPrefsPath(),
+		TUITheme:           "default",
+		TUIRefreshInterval: 500 * time.Millisecond,
 
 		// Prometheus
-		PromClientMetrics: false, // Disabled by default (high cardinality)
+		PromClientMetrics:     false, // Disabled by default (high cardinality)
+		PromClientMetricsMax:  200,
+		MetricsUpdateInterval: time.Second,
 
 		// Origin Metrics
 		OriginMetricsURL:       "",               // Disabled by default
@@ -160,6 +457,17 @@ func (c *Config) OriginMetricsEnabled() bool {
 	return c.OriginMetricsURL != "" || c.NginxMetricsURL != "" || c.OriginMetricsHost != ""
 }
 
+// OriginLogLoki reports whether -origin-log is a Loki server rather than
+// a file.
+func (c *Config) OriginLogLoki() bool {
+	return strings.HasPrefix(c.OriginLog, "http://") || strings.HasPrefix(c.OriginLog, "https://")
+}
+
+// RunTagged reports whether requests carry a run tag.
+func (c *Config) RunTagged() bool {
+	return c.TagRequests || c.OriginLog != ""
+}
+
 // ResolveOriginMetricsURLs resolves origin metrics URLs from explicit URLs or host+port combination.
 // Returns node_exporter URL and nginx_exporter URL.
 func (c *Config) ResolveOriginMetricsURLs() (nodeURL, nginxURL string) {
@@ -206,3 +514,628 @@ func (c *Config) ResolveSegmentSizesURL() string {
 	}
 	return ""
 }
+
+// Tenant is a named subset of the clients, see -tenants.
+type Tenant struct {
+	Name    string  `json:"name"`
+	Clients int     `json:"clients"`
+	MaxRPS  float64 `json:"max_rps"` // Manifest + segment requests/sec quota (0 = unlimited)
+}
+
+// ParseTenant parses a -tenants entry: name:clients[:max_rps].
+func ParseTenant(spec string) (Tenant, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Tenant{}, fmt.Errorf("tenant %q: want name:clients[:max_rps]", spec)
+	}
+	clients, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Tenant{}, fmt.Errorf("tenant %q: clients must be a number", spec)
+	}
+	t := Tenant{Name: parts[0], Clients: clients}
+	if len(parts) == 3 {
+		if t.MaxRPS, err = strconv.ParseFloat(parts[2], 64); err != nil {
+			return Tenant{}, fmt.Errorf("tenant %q: max_rps must be a number", spec)
+		}
+	}
+	return t, nil
+}
+
+// VariantShare is one entry of -variant-mix: a rendition, by resolution,
+// and its relative share of the clients.
+type VariantShare struct {
+	Variant string `json:"variant"` // "720p" (height) or "1280x720"
+	Weight  int    `json:"weight"`
+}
+
+// ParseVariantMix parses -variant-mix, comma-separated variant=weight
+// entries ("1080p=60,720p=30,480p=10").
+func ParseVariantMix(spec string) ([]VariantShare, error) {
+	var mix []VariantShare
+	for _, entry := range strings.Split(spec, ",") {
+		variant, weightStr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("variant mix %q: want variant=weight", entry)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil {
+			return nil, fmt.Errorf("variant mix %q: weight must be a number", entry)
+		}
+		mix = append(mix, VariantShare{Variant: strings.TrimSpace(variant), Weight: weight})
+	}
+	return mix, nil
+}
+
+// RenditionsAll picks every rendition of a type in ParseRenditions.
+const RenditionsAll = "all"
+
+// ParseRenditions parses -renditions, comma-separated type=selection
+// entries for the alternate renditions a player fetches with its
+// variant: "audio=en,subs=en". A selection is a LANGUAGE as the master
+// playlist gives it, or "all"; a type left out adds nothing (audio: the
+// variant's first audio stream, subtitles: none).
+func ParseRenditions(spec string) (audio, subs string, err error) {
+	if spec == "" {
+		return "", "", nil
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		kind, sel, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		sel = strings.TrimSpace(sel)
+		if !ok || !validRenditionSelection(sel) {
+			return "", "", fmt.Errorf("renditions %q: want audio=LANG, subs=LANG or all, e.g. audio=en,subs=en", entry)
+		}
+		switch strings.TrimSpace(kind) {
+		case "audio":
+			audio = sel
+		case "subs", "subtitles":
+			subs = sel
+		default:
+			return "", "", fmt.Errorf("renditions %q: type must be audio or subs", entry)
+		}
+	}
+	return audio, subs, nil
+}
+
+// validRenditionSelection reports whether sel is "all" or looks like a
+// language tag ("en", "pt-BR"): it goes into an FFmpeg stream specifier,
+// so no colons.
+func validRenditionSelection(sel string) bool {
+	if sel == "" {
+		return false
+	}
+	for _, c := range sel {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseVariantResolution parses a VariantShare variant: "720p" gives
+// height 720 (any width), "1280x720" both.
+func ParseVariantResolution(variant string) (width, height int, err error) {
+	v := strings.ToLower(variant)
+	if h, ok := strings.CutSuffix(v, "p"); ok {
+		if height, err := strconv.Atoi(h); err == nil && height > 0 {
+			return 0, height, nil
+		}
+	} else if w, h, ok := strings.Cut(v, "x"); ok {
+		width, errW := strconv.Atoi(w)
+		height, errH := strconv.Atoi(h)
+		if errW == nil && errH == nil && width > 0 && height > 0 {
+			return width, height, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("variant %q: want a height (720p) or a resolution (1280x720)", variant)
+}
+
+// Geo is a client cohort emulating one geo or ISP, see -geo.
+type Geo struct {
+	Name    string   `json:"name"`
+	Weight  int      `json:"weight"`  // Relative share of the clients
+	Headers []string `json:"headers"` // Sent by its clients, after -header
+	Env     []string `json:"env"`     // Set for its clients, after -client-env
+	Nice    int      `json:"nice"`    // 0 = -nice
+	IONice  string   `json:"ionice"`  // "" = -ionice
+	Pacing  string   `json:"pacing"`  // "" = -pacing
+}
+
+// AddGeo adds a -geo entry, name[:weight]=Header: value, to geos. Naming
+// an existing geo adds the header to it (and sets its weight, if given).
+func AddGeo(geos []Geo, spec string) ([]Geo, error) {
+	key, header, ok := strings.Cut(spec, "=")
+	if !ok {
+		return nil, fmt.Errorf("geo %q: want name[:weight]=Header: value", spec)
+	}
+	name, weightStr, hasWeight := strings.Cut(strings.TrimSpace(key), ":")
+	weight := 0
+	if hasWeight {
+		var err error
+		if weight, err = strconv.Atoi(weightStr); err != nil {
+			return nil, fmt.Errorf("geo %q: weight must be a number", spec)
+		}
+	}
+
+	for i := range geos {
+		if geos[i].Name == name {
+			geos[i].Headers = append(geos[i].Headers, strings.TrimSpace(header))
+			if hasWeight {
+				geos[i].Weight = weight
+			}
+			return geos, nil
+		}
+	}
+	if !hasWeight {
+		weight = 1
+	}
+	return append(geos, Geo{Name: name, Weight: weight, Headers: []string{strings.TrimSpace(header)}}), nil
+}
+
+// Burst is a -burst schedule: every Every, Clients more clients for For.
+type Burst struct {
+	Every   time.Duration `json:"every"`
+	Clients int           `json:"clients"`
+	For     time.Duration `json:"for"`
+}
+
+// String returns the burst in -burst syntax.
+func (b Burst) String() string {
+	return fmt.Sprintf("every %s: +%d clients for %s", b.Every, b.Clients, b.For)
+}
+
+// ParseBurst parses a -burst schedule, "every 5m: +200 clients for 30s".
+func ParseBurst(spec string) (Burst, error) {
+	bad := fmt.Errorf("burst %q: want \"every <interval>: +<n> clients for <duration>\"", spec)
+	head, tail, ok := strings.Cut(strings.ToLower(spec), ":")
+	if !ok {
+		return Burst{}, bad
+	}
+	h, t := strings.Fields(head), strings.Fields(tail)
+	if len(h) != 2 || h[0] != "every" || len(t) != 4 || !strings.HasPrefix(t[0], "+") ||
+		(t[1] != "clients" && t[1] != "client") || t[2] != "for" {
+		return Burst{}, bad
+	}
+
+	var b Burst
+	var err error
+	if b.Every, err = time.ParseDuration(h[1]); err != nil {
+		return Burst{}, fmt.Errorf("burst %q: interval: %w", spec, err)
+	}
+	if b.Clients, err = strconv.Atoi(t[0][1:]); err != nil {
+		return Burst{}, fmt.Errorf("burst %q: clients must be a number", spec)
+	}
+	if b.For, err = time.ParseDuration(t[3]); err != nil {
+		return Burst{}, fmt.Errorf("burst %q: duration: %w", spec, err)
+	}
+	return b, nil
+}
+
+// compareVariants are the -compare-opt variant selections: the highest
+// and lowest programs are probed for -variant only.
+var compareVariants = []string{"all", "first"}
+
+// ParseCompareOpt splits a -compare-opt, KEY=VALUE, and checks it. Keys:
+// keepalive (on or off), user-agent (the User-Agent base, any text) and
+// variant (all or first).
+func ParseCompareOpt(opt string) (key, value string, err error) {
+	key, value, ok := strings.Cut(opt, "=")
+	if !ok {
+		return "", "", fmt.Errorf("compare-opt %q: want KEY=VALUE", opt)
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	switch key {
+	case "keepalive":
+		if value != "on" && value != "off" {
+			return "", "", fmt.Errorf("compare-opt %q: keepalive must be on or off", opt)
+		}
+	case "user-agent":
+		if value == "" {
+			return "", "", fmt.Errorf("compare-opt %q: empty user-agent", opt)
+		}
+	case "variant":
+		if !slices.Contains(compareVariants, value) {
+			return "", "", fmt.Errorf("compare-opt %q: variant must be one of %s", opt, strings.Join(compareVariants, ", "))
+		}
+	default:
+		return "", "", fmt.Errorf("compare-opt %q: unknown option %q (want keepalive, user-agent or variant)", opt, key)
+	}
+	return key, value, nil
+}
+
+// BackoffPresetNames are the restart policies -backoff-preset and
+// -backoff-on accept (as in supervisor.BackoffPresets).
+var BackoffPresetNames = []string{"default", "aggressive", "gentle", "none"}
+
+// backoffNetworkCauses are the network failures -backoff-on accepts, as
+// reported by FFmpeg's debug output (see parser.DebugEvent.FailReason).
+var backoffNetworkCauses = []string{"refused", "timeout", "reset", "dns", "tls"}
+
+// ValidBackoffCause reports whether cause is a -backoff-on exit cause: an
+// HTTP status ("http-404") or class ("http-5xx"), a network failure
+// ("reset", "refused", "timeout", "dns", "tls") or an FFmpeg exit code
+// ("exit-1").
+func ValidBackoffCause(cause string) bool {
+	if slices.Contains(backoffNetworkCauses, cause) {
+		return true
+	}
+	if status, ok := strings.CutPrefix(cause, "http-"); ok {
+		if len(status) == 3 && status[0] >= '1' && status[0] <= '5' && status[1:] == "xx" {
+			return true
+		}
+		code, err := strconv.Atoi(status)
+		return err == nil && code >= 100 && code <= 599
+	}
+	if code, ok := strings.CutPrefix(cause, "exit-"); ok {
+		n, err := strconv.Atoi(code)
+		return err == nil && n >= 0 && n <= 255
+	}
+	return false
+}
+
+// AddBackoffOn adds a -backoff-on entry, CAUSE=PRESET.
+func AddBackoffOn(cfg *Config, spec string) error {
+	cause, preset, ok := strings.Cut(spec, "=")
+	if !ok {
+		return fmt.Errorf("backoff-on %q: want CAUSE=PRESET", spec)
+	}
+	if cfg.BackoffOn == nil {
+		cfg.BackoffOn = make(map[string]string)
+	}
+	cfg.BackoffOn[strings.TrimSpace(cause)] = strings.TrimSpace(preset)
+	return nil
+}
+
+// ParsePercent parses a percentage given with or without the % sign
+// ("1%", "0.5").
+func ParsePercent(s string) (float64, error) {
+	p, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("percentage %q: want a number such as 1%% or 0.5", s)
+	}
+	return p, nil
+}
+
+// ParseSLO parses a -slo spec, TARGET:THRESHOLD with the target a
+// percentage: "99:1s" or "99.9%:500ms". target is returned as a fraction
+// (0.99).
+func ParseSLO(spec string) (target float64, threshold time.Duration, err error) {
+	pct, value, ok := strings.Cut(spec, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("slo %q: want TARGET:THRESHOLD, e.g. 99:1s", spec)
+	}
+	p, err := ParsePercent(pct)
+	if err != nil || p <= 0 || p >= 100 {
+		return 0, 0, fmt.Errorf("slo %q: the target must be a percentage between 0 and 100 (exclusive)", spec)
+	}
+	threshold, err = time.ParseDuration(value)
+	if err != nil || threshold <= 0 {
+		return 0, 0, fmt.Errorf("slo %q: the threshold must be a positive duration", spec)
+	}
+	return p / 100, threshold, nil
+}
+
+// stopSignals are the signals -stop-signal accepts. FFmpeg handles all of
+// them by finishing the current write and exiting.
+var stopSignals = map[string]syscall.Signal{
+	"INT":  syscall.SIGINT,
+	"TERM": syscall.SIGTERM,
+	"QUIT": syscall.SIGQUIT,
+	"HUP":  syscall.SIGHUP,
+}
+
+// ParseStopSignal parses a -stop-signal name, with or without the SIG
+// prefix ("TERM", "sigint").
+func ParseStopSignal(name string) (syscall.Signal, error) {
+	sig, ok := stopSignals[strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("stop signal %q: want TERM, INT, QUIT or HUP", name)
+	}
+	return sig, nil
+}
+
+// cohortGeo returns the geo named name, adding it (with weight 1 and no
+// headers) if it isn't there yet, so -geo and the per-cohort settings can
+// come in any order.
+func cohortGeo(cfg *Config, name string) *Geo {
+	for i := range cfg.Geos {
+		if cfg.Geos[i].Name == name {
+			return &cfg.Geos[i]
+		}
+	}
+	cfg.Geos = append(cfg.Geos, Geo{Name: name, Weight: 1})
+	return &cfg.Geos[len(cfg.Geos)-1]
+}
+
+// AddClientEnv adds a -client-env entry: KEY=VALUE for every client, or
+// geo:KEY=VALUE for a -geo cohort.
+func AddClientEnv(cfg *Config, spec string) error {
+	key, value, ok := strings.Cut(spec, "=")
+	if !ok {
+		return fmt.Errorf("client env %q: want [geo:]KEY=VALUE", spec)
+	}
+	geo, key, hasGeo := strings.Cut(key, ":")
+	if !hasGeo {
+		key, geo = geo, ""
+	}
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return fmt.Errorf("client env %q: bad variable name %q", spec, key)
+	}
+	if hasGeo {
+		g := cohortGeo(cfg, geo)
+		g.Env = append(g.Env, key+"="+value)
+	} else {
+		cfg.ClientEnv = append(cfg.ClientEnv, key+"="+value)
+	}
+	return nil
+}
+
+// SetNice sets -nice: N for every client, or geo:N for a -geo cohort.
+func SetNice(cfg *Config, spec string) error {
+	geo, value, hasGeo := strings.Cut(spec, ":")
+	if !hasGeo {
+		value = geo
+	}
+	nice, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("nice %q: want [geo:]N", spec)
+	}
+	if hasGeo {
+		cohortGeo(cfg, geo).Nice = nice
+	} else {
+		cfg.Nice = nice
+	}
+	return nil
+}
+
+// IO scheduling classes for -ionice (as in ioprio_set(2) and
+// supervisor.IOClass*).
+var ioClasses = map[string]int{
+	"realtime":    1,
+	"rt":          1,
+	"best-effort": 2,
+	"be":          2,
+	"idle":        3,
+}
+
+// defaultIOLevel is the kernel's level for a class given without one.
+const defaultIOLevel = 4
+
+// ParseIONice parses an -ionice value, class[:level]: "idle", or
+// "best-effort" / "realtime" with a level from 0 (highest) to 7.
+func ParseIONice(spec string) (class, level int, err error) {
+	name, levelStr, hasLevel := strings.Cut(spec, ":")
+	class, ok := ioClasses[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("ionice %q: class must be idle, best-effort or realtime", spec)
+	}
+	if class == ioClasses["idle"] {
+		if hasLevel {
+			return 0, 0, fmt.Errorf("ionice %q: the idle class has no levels", spec)
+		}
+		return class, 0, nil
+	}
+	level = defaultIOLevel
+	if hasLevel {
+		if level, err = strconv.Atoi(levelStr); err != nil || level < 0 || level > 7 {
+			return 0, 0, fmt.Errorf("ionice %q: level must be 0-7", spec)
+		}
+	}
+	return class, level, nil
+}
+
+// SetIONice sets -ionice: class[:level] for every client, or
+// geo:class[:level] for a -geo cohort.
+func SetIONice(cfg *Config, spec string) error {
+	value := spec
+	geo, rest, hasGeo := strings.Cut(spec, ":")
+	if _, isClass := ioClasses[geo]; isClass {
+		hasGeo = false
+	} else if hasGeo {
+		value = rest
+	}
+	if _, _, err := ParseIONice(value); err != nil {
+		return err
+	}
+	if hasGeo {
+		cohortGeo(cfg, geo).IONice = value
+	} else {
+		cfg.IONice = value
+	}
+	return nil
+}
+
+// Pacing models for -pacing: how fast a client reads segments, which
+// shapes the load it puts on the origin.
+const (
+	PacingFast     = "fast"     // As fast as FFmpeg can (its default)
+	PacingRealtime = "realtime" // At the stream's own rate
+	PacingBuffer   = "buffer"   // A buffer's worth ahead at full speed, then real time
+)
+
+// Session length distributions for -session-duration.
+const (
+	SessionFixed     = "fixed"
+	SessionUniform   = "uniform"
+	SessionLognormal = "lognormal"
+)
+
+// SessionDist is a parsed -session-duration distribution.
+type SessionDist struct {
+	Kind     string
+	Min, Max time.Duration // fixed: Min; uniform: Min to Max
+	Median   time.Duration // lognormal
+	Sigma    float64       // lognormal: standard deviation of the log length
+}
+
+// ParseSessionDuration parses a -session-duration distribution:
+// "fixed:10m" (or just "10m"), "uniform:5m-30m", or "lognormal:10m,0.8"
+// with the median length and the sigma of its logarithm (about 1 for
+// typical viewing: most sessions short, a few very long).
+func ParseSessionDuration(spec string) (SessionDist, error) {
+	kind, value, ok := strings.Cut(spec, ":")
+	if !ok {
+		kind, value = SessionFixed, spec
+	}
+	positive := func(s string) (time.Duration, bool) {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		return d, err == nil && d > 0
+	}
+
+	switch kind {
+	case SessionFixed:
+		if d, ok := positive(value); ok {
+			return SessionDist{Kind: SessionFixed, Min: d, Max: d}, nil
+		}
+	case SessionUniform:
+		lo, hi, _ := strings.Cut(value, "-")
+		minD, okMin := positive(lo)
+		maxD, okMax := positive(hi)
+		if okMin && okMax && minD <= maxD {
+			return SessionDist{Kind: SessionUniform, Min: minD, Max: maxD}, nil
+		}
+		return SessionDist{}, fmt.Errorf("session duration %q: want uniform:MIN-MAX, e.g. uniform:5m-30m", spec)
+	case SessionLognormal:
+		median, sigmaStr, _ := strings.Cut(value, ",")
+		m, okMedian := positive(median)
+		sigma, err := strconv.ParseFloat(strings.TrimSpace(sigmaStr), 64)
+		if okMedian && err == nil && sigma > 0 && sigma <= 3 {
+			return SessionDist{Kind: SessionLognormal, Median: m, Sigma: sigma}, nil
+		}
+		return SessionDist{}, fmt.Errorf("session duration %q: want lognormal:MEDIAN,SIGMA with SIGMA in (0, 3], e.g. lognormal:10m,0.8", spec)
+	}
+	return SessionDist{}, fmt.Errorf("session duration %q: want a length (10m), fixed:10m, uniform:5m-30m or lognormal:10m,0.8", spec)
+}
+
+// VOD start position distributions for -vod-start.
+const (
+	VODStartFixed       = "fixed"
+	VODStartUniform     = "uniform"
+	VODStartExponential = "exponential"
+)
+
+// VODStartDist is a parsed -vod-start distribution. Offsets past the end
+// of the asset are cut to its last segment.
+type VODStartDist struct {
+	Kind     string
+	Min, Max time.Duration // fixed: Min; uniform: Min to Max (Max 0 = the end)
+	Mean     time.Duration // exponential
+}
+
+// ParseVODStart parses a -vod-start distribution: "start" (or "", every
+// client from the beginning), a fixed offset ("10m" or "fixed:10m"),
+// "uniform" (anywhere in the asset), "uniform:MIN-MAX", or
+// "exponential:MEAN" (most near the start, as when viewers give up early
+// on a title).
+func ParseVODStart(spec string) (VODStartDist, error) {
+	if spec == "" || spec == "start" {
+		return VODStartDist{Kind: VODStartFixed}, nil
+	}
+	if spec == VODStartUniform {
+		return VODStartDist{Kind: VODStartUniform}, nil
+	}
+	kind, value, ok := strings.Cut(spec, ":")
+	if !ok {
+		kind, value = VODStartFixed, spec
+	}
+	offset := func(s string) (time.Duration, bool) {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		return d, err == nil && d >= 0
+	}
+
+	switch kind {
+	case VODStartFixed:
+		if d, ok := offset(value); ok {
+			return VODStartDist{Kind: VODStartFixed, Min: d, Max: d}, nil
+		}
+	case VODStartUniform:
+		lo, hi, _ := strings.Cut(value, "-")
+		minD, okMin := offset(lo)
+		maxD, okMax := offset(hi)
+		if okMin && okMax && minD < maxD {
+			return VODStartDist{Kind: VODStartUniform, Min: minD, Max: maxD}, nil
+		}
+		return VODStartDist{}, fmt.Errorf("vod start %q: want uniform or uniform:MIN-MAX, e.g. uniform:0s-30m", spec)
+	case VODStartExponential:
+		if d, ok := offset(value); ok && d > 0 {
+			return VODStartDist{Kind: VODStartExponential, Mean: d}, nil
+		}
+		return VODStartDist{}, fmt.Errorf("vod start %q: want exponential:MEAN with a positive mean, e.g. exponential:5m", spec)
+	}
+	return VODStartDist{}, fmt.Errorf("vod start %q: want start, an offset (10m), uniform, uniform:MIN-MAX or exponential:MEAN", spec)
+}
+
+// -vod-end modes.
+const (
+	VODEndStop = "stop" // The client is done; the run ends once all are
+	VODEndLoop = "loop" // The client plays the asset again at once
+)
+
+// ParsePacing parses a -pacing model: "fast" (or ""), "realtime", or
+// "buffer:DURATION" with the buffer target, e.g. "buffer:10s".
+func ParsePacing(spec string) (model string, buffer time.Duration, err error) {
+	name, value, hasValue := strings.Cut(spec, ":")
+	switch {
+	case spec == "" || spec == PacingFast:
+		return PacingFast, 0, nil
+	case spec == PacingRealtime:
+		return PacingRealtime, 0, nil
+	case name == PacingBuffer && hasValue:
+		buffer, err = time.ParseDuration(value)
+		if err != nil || buffer <= 0 {
+			return "", 0, fmt.Errorf("pacing %q: the buffer target must be a positive duration, e.g. buffer:10s", spec)
+		}
+		return PacingBuffer, buffer, nil
+	}
+	return "", 0, fmt.Errorf("pacing %q: want fast, realtime or buffer:DURATION", spec)
+}
+
+// SetPacing sets -pacing: a model for every client, or geo:model for a
+// -geo cohort.
+func SetPacing(cfg *Config, spec string) error {
+	value := spec
+	geo, rest, hasGeo := strings.Cut(spec, ":")
+	switch geo {
+	case PacingFast, PacingRealtime, PacingBuffer:
+		hasGeo = false
+	default:
+		if hasGeo {
+			value = rest
+		}
+	}
+	if _, _, err := ParsePacing(value); err != nil {
+		return err
+	}
+	if hasGeo {
+		cohortGeo(cfg, geo).Pacing = value
+	} else {
+		cfg.Pacing = value
+	}
+	return nil
+}
+
+// ContentCodings are the Accept-Encoding codings -accept-encoding accepts.
+var ContentCodings = []string{"gzip", "deflate", "br", "identity", "*"}
+
+// TUIPanelNames are the dashboard sections accepted by -tui-panels,
+// in render order (mirrors tui.AllPanels).
+var TUIPanelNames = []string{"progress", "requests", "latency", "health", "origin", "hls", "http", "tcp", "compare"}
+
+// defaultTUIPrefsPath returns $XDG_CONFIG_HOME/go-ffmpeg-hls-swarm/tui.json
+// (or the platform equivalent), or "" if there is no user config directory.
+// DefaultRunsFile returns the run history path shared by -save-run and the
+// "runs" subcommand, or "" if there is no user config directory.
+func DefaultRunsFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-ffmpeg-hls-swarm", "runs.jsonl")
+}
+
+func defaultTUIPrefsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-ffmpeg-hls-swarm", "tui.json")
+}
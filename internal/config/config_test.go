@@ -2,7 +2,13 @@ package config
 
 import (
 	"flag"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -113,8 +119,8 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.TUIEnabled != true {
 		t.Error("TUIEnabled should be true by default")
 	}
-	if cfg.MetricsAddr != "0.0.0.0:17091" {
-		t.Errorf("MetricsAddr = %q, want %q", cfg.MetricsAddr, "0.0.0.0:17091")
+	if !slices.Equal(cfg.MetricsAddrs, []string{"0.0.0.0:17091"}) {
+		t.Errorf("MetricsAddrs = %q, want [0.0.0.0:17091]", cfg.MetricsAddrs)
 	}
 	if cfg.BackoffMultiply < 1.0 {
 		t.Errorf("BackoffMultiply = %f, should be >= 1.0", cfg.BackoffMultiply)
@@ -315,6 +321,11 @@ func TestValidate_InvalidLogFormat(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for invalid log_format")
 	}
+
+	cfg.LogFormat = "logfmt"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() with logfmt = %v, want nil", err)
+	}
 }
 
 func TestValidate_InvalidTimeout(t *testing.T) {
@@ -606,4 +617,1806 @@ func TestValidationError_Error(t *testing.T) {
 	if errStr != "test_field: test message" {
 		t.Errorf("Error string = %q, want %q", errStr, "test_field: test message")
 	}
+
+	err.Suggestion = "try this"
+	if got, want := err.Error(), "test_field: test message (try this)"; got != want {
+		t.Errorf("Error string = %q, want %q", got, want)
+	}
+}
+
+func TestValidate_CPUAffinity(t *testing.T) {
+	for _, policy := range []string{"none", "core", "numa"} {
+		cfg := DefaultConfig()
+		cfg.StreamURL = "http://example.com/stream.m3u8"
+		cfg.CPUAffinity = policy
+		if err := Validate(cfg); err != nil {
+			t.Errorf("Validate(cpu_affinity=%q) = %v, want nil", policy, err)
+		}
+	}
+
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	cfg.CPUAffinity = "socket"
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for invalid cpu_affinity")
+	}
+}
+
+func TestValidate_PlanAllowsNoURL(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Plan = true
+
+	if err := Validate(cfg); err != nil {
+		t.Errorf("--plan without URL should be valid: %v", err)
+	}
+
+	cfg.ExpectedBitrate = -1
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for negative expected_bitrate")
+	}
+}
+
+func TestValidate_PlaylistInterval(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	cfg.ValidatePlaylistInterval = 0
+
+	// Interval only matters when validation is on
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.ValidatePlaylists = true
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for zero validate_playlist_interval")
+	}
+}
+
+func TestValidate_PlaylistRefresh(t *testing.T) {
+	for _, tt := range []struct {
+		factor  float64
+		wantErr bool
+	}{
+		{0, false},
+		{0.5, false},
+		{0.99, false},
+		{1, true}, // FFmpeg's own cadence already
+		{2, true}, // FFmpeg can't be slowed down
+		{-0.5, true},
+	} {
+		cfg := DefaultConfig()
+		cfg.StreamURL = "http://example.com/stream.m3u8"
+		cfg.PlaylistRefresh = tt.factor
+		if err := Validate(cfg); (err != nil) != tt.wantErr {
+			t.Errorf("PlaylistRefresh %v: Validate() = %v, wantErr %v", tt.factor, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_ConditionalPlaylists(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	cfg.ConditionalPlaylists = true
+
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "-playlist-refresh") {
+		t.Errorf("Validate() = %v, want an error for conditional_playlists without -playlist-refresh", err)
+	}
+
+	cfg.PlaylistRefresh = 0.5
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.NoCache = true
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "-no-cache") {
+		t.Errorf("Validate() = %v, want an error for conditional_playlists with -no-cache", err)
+	}
+}
+
+func TestValidate_CapacityP95(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.CapacityP95 = -time.Second
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for negative capacity_p95")
+	}
+
+	for _, d := range []time.Duration{0, 2 * time.Second} {
+		cfg.CapacityP95 = d
+		if err := Validate(cfg); err != nil {
+			t.Errorf("CapacityP95 %v: unexpected error: %v", d, err)
+		}
+	}
+}
+
+func TestValidate_StatsRetention(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.StatsRetention = 99
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for stats_retention < 100")
+	}
+
+	cfg.StatsRetention = 100
+	cfg.StatsSpillDir = t.TempDir()
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.StatsSpillDir = filepath.Join(cfg.StatsSpillDir, "missing")
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for missing stats_spill_dir")
+	}
+}
+
+func TestValidate_TUIPanels(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.TUIPanels = []string{"progress", "hls"}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.TUIPanels = []string{"progress", "quic"}
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "quic") {
+		t.Errorf("Validate() = %v, want unknown panel error", err)
+	}
+}
+
+func TestValidate_TUITheme(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	for _, theme := range []string{"default", "high-contrast", "monochrome", "ascii"} {
+		cfg.TUITheme = theme
+		if err := Validate(cfg); err != nil {
+			t.Errorf("theme %q: Validate() = %v, want nil", theme, err)
+		}
+	}
+
+	cfg.TUITheme = "solarized"
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for unknown tui_theme")
+	}
+}
+
+func TestValidate_StatusInterval(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	cfg.StatusLine = true
+
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil (0 = auto)", err)
+	}
+
+	cfg.StatusInterval = -time.Second
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for negative status_interval")
+	}
+}
+
+func TestValidate_StatsStdout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.StatsStdout = "ndjson"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate(ndjson) = %v, want nil", err)
+	}
+
+	cfg.StatsStdout = "csv"
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for unknown stats_stdout format")
+	}
+
+	cfg.StatsStdout = "ndjson"
+	cfg.StatsInterval = 0
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for zero stats_interval")
+	}
+}
+
+func TestValidate_CoolDown(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.CoolDown = 2 * time.Minute
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.CoolDown = -time.Second
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for negative cool_down")
+	}
+}
+
+func TestValidate_MetricsAddrs(t *testing.T) {
+	tests := []struct {
+		name    string
+		addrs   []string
+		wantErr bool
+	}{
+		{"default", []string{"0.0.0.0:17091"}, false},
+		{"random port", []string{":0"}, false},
+		{"localhost and pod IP", []string{"127.0.0.1:17091", "10.0.0.5:17091"}, false},
+		{"unix socket", []string{"127.0.0.1:17091", "unix:/run/swarm/metrics.sock"}, false},
+		{"none", nil, true},
+		{"missing port", []string{"127.0.0.1"}, true},
+		{"empty socket path", []string{"unix:"}, true},
+		{"duplicate", []string{":17091", ":17091"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.MetricsAddrs = tt.addrs
+
+			err := Validate(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_PromClientMetricsMax(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	if cfg.PromClientMetricsMax != 200 {
+		t.Errorf("default PromClientMetricsMax = %d, want 200", cfg.PromClientMetricsMax)
+	}
+
+	cfg.PromClientMetricsMax = 0 // No cap
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate(0) = %v, want nil", err)
+	}
+
+	cfg.PromClientMetricsMax = -1
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate(-1) = nil, want error")
+	}
+}
+
+func TestValidate_StatsIntervals(t *testing.T) {
+	fields := map[string]func(*Config) *time.Duration{
+		"stats_aggregate_interval": func(c *Config) *time.Duration { return &c.StatsAggregateInterval },
+		"metrics_update_interval":  func(c *Config) *time.Duration { return &c.MetricsUpdateInterval },
+		"tui_refresh_interval":     func(c *Config) *time.Duration { return &c.TUIRefreshInterval },
+	}
+	for field, ptr := range fields {
+		t.Run(field, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+
+			*ptr(cfg) = 250 * time.Millisecond
+			if err := Validate(cfg); err != nil {
+				t.Errorf("Validate(250ms) = %v, want nil", err)
+			}
+
+			*ptr(cfg) = 0
+			if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), field) {
+				t.Errorf("Validate(0) = %v, want %s error", err, field)
+			}
+		})
+	}
+}
+
+func TestValidate_LogDedupWindow(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	if cfg.LogDedupWindow != 10*time.Second {
+		t.Errorf("default LogDedupWindow = %v, want 10s", cfg.LogDedupWindow)
+	}
+
+	cfg.LogDedupWindow = 0
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate(0) = %v, want nil", err)
+	}
+
+	cfg.LogDedupWindow = -time.Second
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "log_dedup_window") {
+		t.Errorf("Validate(-1s) = %v, want log_dedup_window error", err)
+	}
+}
+
+func TestValidate_SaveRun(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	if cfg.SaveRun {
+		t.Error("SaveRun should be off by default")
+	}
+	if !strings.HasSuffix(cfg.RunsFile, "runs.jsonl") {
+		t.Errorf("default RunsFile = %q, want .../runs.jsonl", cfg.RunsFile)
+	}
+
+	cfg.SaveRun = true
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.RunsFile = ""
+	if err := Validate(cfg); err == nil {
+		t.Error("Validate() with -save-run and no -runs-file = nil, want error")
+	}
+
+	// -stats-interval paces the saved timeline
+	cfg.RunsFile = "runs.jsonl"
+	cfg.StatsInterval = 0
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "stats_interval") {
+		t.Errorf("Validate() with -save-run and no -stats-interval = %v, want stats_interval error", err)
+	}
+}
+
+func TestValidate_Pushgateway(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		job     string
+		labels  []string
+		wantErr bool
+	}{
+		{"disabled", "", "hls_swarm", nil, false},
+		{"enabled", "http://pushgateway:9091", "hls_swarm", nil, false},
+		{"with labels", "http://pushgateway:9091", "hls_swarm", []string{"run=42", "branch_name=main"}, false},
+		{"bad scheme", "ftp://pushgateway:9091", "hls_swarm", nil, true},
+		{"no job", "http://pushgateway:9091", "", nil, true},
+		{"labels without url", "", "hls_swarm", []string{"run=42"}, true},
+		{"label missing value", "http://pushgateway:9091", "hls_swarm", []string{"run"}, true},
+		{"label bad name", "http://pushgateway:9091", "hls_swarm", []string{"1run=42"}, true},
+		{"label dash", "http://pushgateway:9091", "hls_swarm", []string{"ci-run=42"}, true},
+		{"job label", "http://pushgateway:9091", "hls_swarm", []string{"job=other"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.PushgatewayURL = tt.url
+			cfg.PushgatewayJob = tt.job
+			cfg.PushgatewayLabels = tt.labels
+
+			err := Validate(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_StatsMaxLineLength(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	if cfg.StatsMaxLineLength != 64*1024 {
+		t.Errorf("default StatsMaxLineLength = %d, want %d", cfg.StatsMaxLineLength, 64*1024)
+	}
+
+	cfg.StatsMaxLineLength = 1 << 20
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.StatsMaxLineLength = 512
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for stats_max_line_length below 1024")
+	}
+}
+
+func TestValidate_SlowRequestLog(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.SlowRequestLog = time.Second
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.SlowRequestLog = -time.Second
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for negative slow_request_log")
+	}
+
+	cfg.SlowRequestLog = time.Second
+	cfg.StatsEnabled = false
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for slow_request_log without stats")
+	}
+}
+
+func TestParseSLO(t *testing.T) {
+	for _, tt := range []struct {
+		spec      string
+		target    float64
+		threshold time.Duration
+	}{
+		{"99:1s", 0.99, time.Second},
+		{"99.9%:500ms", 0.999, 500 * time.Millisecond},
+		{"50:2m", 0.5, 2 * time.Minute},
+	} {
+		target, threshold, err := ParseSLO(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSLO(%q): unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if math.Abs(target-tt.target) > 1e-9 || threshold != tt.threshold {
+			t.Errorf("ParseSLO(%q) = %v, %v, want %v, %v", tt.spec, target, threshold, tt.target, tt.threshold)
+		}
+	}
+
+	for _, spec := range []string{"", "99", "1s", "0:1s", "100:1s", "abc:1s", "99:fast", "99:0s", "99:-1s"} {
+		if _, _, err := ParseSLO(spec); err == nil {
+			t.Errorf("ParseSLO(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestValidate_SLO(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.SLO = "99:1s"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.SLO = "99"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "slo") {
+		t.Errorf("Validate() = %v, want an slo error", err)
+	}
+
+	cfg.SLO = "99:1s"
+	cfg.StatsEnabled = false
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for slo without stats")
+	}
+}
+
+func TestValidate_FFmpegLogDialect(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	for _, dialect := range []string{"auto", "ffmpeg6", "ffmpeg7"} {
+		cfg.FFmpegLogDialect = dialect
+		if err := Validate(cfg); err != nil {
+			t.Errorf("Validate() with %q = %v, want nil", dialect, err)
+		}
+	}
+
+	cfg.FFmpegLogDialect = "7"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "ffmpeg_log_dialect") {
+		t.Errorf("Validate() = %v, want an ffmpeg_log_dialect error", err)
+	}
+}
+
+func TestValidate_ProgressMode(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	for _, mode := range []string{"pipe", "socket", "off"} {
+		cfg.ProgressMode = mode
+		if err := Validate(cfg); err != nil {
+			t.Errorf("Validate() with %q = %v, want nil", mode, err)
+		}
+	}
+
+	cfg.ProgressMode = "stdout"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "progress_mode") {
+		t.Errorf("Validate() = %v, want a progress_mode error", err)
+	}
+}
+
+func TestValidate_CDNDetect(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.CDNDetect = true
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	cfg.StatsEnabled = false
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for cdn_detect without stats")
+	}
+
+	cfg.StatsEnabled = true
+	for _, alert := range []float64{-1, 101} {
+		cfg.OriginHitAlert = alert
+		if err := Validate(cfg); err == nil {
+			t.Errorf("Expected error for origin_hit_alert %v", alert)
+		}
+	}
+}
+
+func TestValidate_DebugSample(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+
+	cfg.DebugSample = 1
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	for _, p := range []float64{-1, 101} {
+		cfg.DebugSample = p
+		if err := Validate(cfg); err == nil {
+			t.Errorf("Expected error for debug_sample %v", p)
+		}
+	}
+
+	cfg.DebugSample = 1
+	cfg.StatsEnabled = false
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for debug_sample without stats")
+	}
+
+	cfg.StatsEnabled = true
+	cfg.DebugLogging = true
+	if err := Validate(cfg); err == nil {
+		t.Error("Expected error for debug_sample with -ffmpeg-debug")
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    float64
+		wantErr bool
+	}{
+		{"1%", 1, false},
+		{"0.5", 0.5, false},
+		{" 25% ", 25, false},
+		{"%", 0, true},
+		{"one", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePercent(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePercent(%q) = %v, %v; want %v, error %v", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseTenant(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Tenant
+		wantErr bool
+	}{
+		{"alpha:10", Tenant{Name: "alpha", Clients: 10}, false},
+		{"beta:5:25.5", Tenant{Name: "beta", Clients: 5, MaxRPS: 25.5}, false},
+		{"alpha", Tenant{}, true},
+		{"alpha:ten", Tenant{}, true},
+		{"alpha:10:fast", Tenant{}, true},
+		{"alpha:10:5:extra", Tenant{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTenant(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTenant(%q) = %+v, %v; want %+v, error %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_Tenants(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenants []Tenant
+		stats   bool
+		wantErr string
+	}{
+		{"valid", []Tenant{{Name: "a", Clients: 6, MaxRPS: 20}, {Name: "b", Clients: 4}}, true, ""},
+		{"sum mismatch", []Tenant{{Name: "a", Clients: 6}, {Name: "b", Clients: 3}}, true, "add up to 9"},
+		{"duplicate", []Tenant{{Name: "a", Clients: 5}, {Name: "a", Clients: 5}}, true, "duplicate"},
+		{"empty name", []Tenant{{Clients: 10}}, true, "name is empty"},
+		{"no clients", []Tenant{{Name: "a", Clients: 10}, {Name: "b"}}, true, "at least 1"},
+		{"negative quota", []Tenant{{Name: "a", Clients: 10, MaxRPS: -1}}, true, "max_rps"},
+		{"stats disabled", []Tenant{{Name: "a", Clients: 10}}, false, "-stats"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.Clients = 10
+			cfg.Tenants = tt.tenants
+			cfg.StatsEnabled = tt.stats
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_AcceptEncoding(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false},
+		{"gzip", false},
+		{"gzip, deflate, br", false},
+		{"br;q=1.0, gzip;q=0.8, *;q=0", false},
+		{"IDENTITY", false},
+		{"zstd", true},
+		{"gzip;q=2", true},
+		{"gzip;level=9", true},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.StreamURL = "http://example.com/stream.m3u8"
+		cfg.AcceptEncoding = tt.value
+		if err := Validate(cfg); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(accept_encoding=%q) = %v, want error %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_FFmpegExtraArgs(t *testing.T) {
+	tests := []struct {
+		args    string
+		wantErr string
+	}{
+		{"", ""},
+		{"-http_seekable 0 -max_reload 100", ""},
+		{"-reconnect_at_eof 1 -live_start_index -3", ""},
+		{"-hwaccel cuda", "hardware decoding"},
+		{"-c:v h264_cuvid", "-c:v not allowed"},
+		{"-vf scale=1:1", "filters decode"},
+		{"-i http://other/live.m3u8", "adds an input"},
+		{"-loglevel quiet", "-stats-loglevel"},
+		{"-user_agent curl", "-user-agent"},
+		{"-protocol_whitelist file,http", "local files"},
+		{"out.ts -max_reload 1", "not an option"},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.StreamURL = "http://example.com/stream.m3u8"
+		cfg.FFmpegExtraArgs = strings.Fields(tt.args)
+		err := Validate(cfg)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Validate(ffmpeg_extra_args=%q) = %v, want nil", tt.args, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Validate(ffmpeg_extra_args=%q) = %v, want error containing %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseVariantMix(t *testing.T) {
+	mix, err := ParseVariantMix("1080p=60, 720p=30,854x480=10")
+	want := []VariantShare{{"1080p", 60}, {"720p", 30}, {"854x480", 10}}
+	if err != nil || !slices.Equal(mix, want) {
+		t.Errorf("ParseVariantMix() = %v, %v; want %v", mix, err, want)
+	}
+	for _, bad := range []string{"1080p", "1080p=lots", ""} {
+		if _, err := ParseVariantMix(bad); err == nil {
+			t.Errorf("ParseVariantMix(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseVariantResolution(t *testing.T) {
+	tests := []struct {
+		variant       string
+		width, height int
+		wantErr       bool
+	}{
+		{"720p", 0, 720, false},
+		{"1080P", 0, 1080, false},
+		{"1280x720", 1280, 720, false},
+		{"hd", 0, 0, true},
+		{"0p", 0, 0, true},
+		{"1280x", 0, 0, true},
+	}
+	for _, tt := range tests {
+		w, h, err := ParseVariantResolution(tt.variant)
+		if w != tt.width || h != tt.height || (err != nil) != tt.wantErr {
+			t.Errorf("ParseVariantResolution(%q) = %d, %d, %v", tt.variant, w, h, err)
+		}
+	}
+}
+
+func TestValidate_VariantMix(t *testing.T) {
+	mix := []VariantShare{{"1080p", 60}, {"720p", 40}}
+	tests := []struct {
+		name    string
+		mix     []VariantShare
+		variant string
+		clients int
+		stats   bool
+		wantErr string
+	}{
+		{"valid", mix, "all", 10, true, ""},
+		{"bad variant", []VariantShare{{"hd", 1}}, "all", 10, true, "want a height"},
+		{"zero weight", []VariantShare{{"720p", 0}}, "all", 10, true, "at least 1"},
+		{"duplicate", []VariantShare{{"720p", 1}, {"720P", 2}}, "all", 10, true, "listed twice"},
+		{"too few clients", mix, "all", 1, true, "only 1 clients"},
+		{"with -variant", mix, "highest", 10, true, "drop -variant highest"},
+		{"stats disabled", mix, "all", 10, false, "requires stats"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.VariantMix = tt.mix
+			cfg.Variant = tt.variant
+			cfg.Clients = tt.clients
+			cfg.StatsEnabled = tt.stats
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Compare(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(*Config) {}, ""},
+		{"bad url", func(c *Config) { c.CompareURL = "ftp://new/stream.m3u8" }, "compare_url"},
+		{"same url", func(c *Config) { c.CompareURL = c.StreamURL }, "is the stream URL"},
+		{"split 0", func(c *Config) { c.CompareSplit = 0 }, "between 1 and 99"},
+		{"split 100", func(c *Config) { c.CompareSplit = 100 }, "between 1 and 99"},
+		{"one client", func(c *Config) { c.Clients = 1 }, "at least 2 clients"},
+		{"variant mix", func(c *Config) { c.VariantMix = []VariantShare{{"720p", 1}} }, "-variant-mix"},
+		{"playlist refresh", func(c *Config) { c.PlaylistRefresh = 0.5 }, "-playlist-refresh"},
+		{"resolve", func(c *Config) { c.ResolveIP = "10.0.0.1"; c.DangerousMode = true }, "-resolve"},
+		{"stats disabled", func(c *Config) { c.StatsEnabled = false }, "requires stats"},
+		{"opts with second origin", func(c *Config) { c.CompareOpts = []string{"keepalive=off"} }, ""},
+		{"opts on one origin", func(c *Config) { c.CompareURL = ""; c.CompareOpts = []string{"user-agent=Safari/17"} }, ""},
+		{"opts with the stream URL", func(c *Config) { c.CompareURL = c.StreamURL; c.CompareOpts = []string{"keepalive=off"} }, ""},
+		{"bad opt", func(c *Config) { c.CompareOpts = []string{"keepalive=maybe"} }, "compare_opts"},
+		{"opts one client", func(c *Config) { c.CompareURL = ""; c.CompareOpts = []string{"keepalive=off"}; c.Clients = 1 }, "at least 2 clients"},
+		{"opts without stats", func(c *Config) {
+			c.CompareURL = ""
+			c.CompareOpts = []string{"keepalive=off"}
+			c.StatsEnabled = false
+		}, "requires stats"},
+		{"one origin with resolve", func(c *Config) {
+			c.CompareURL = ""
+			c.CompareOpts = []string{"keepalive=off"}
+			c.ResolveIP = "10.0.0.1"
+			c.DangerousMode = true
+		}, ""},
+		{"variant opt with variant mix", func(c *Config) {
+			c.CompareURL = ""
+			c.CompareOpts = []string{"variant=first"}
+			c.VariantMix = []VariantShare{{"720p", 1}}
+		}, "variant can't be combined with -variant-mix"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://old.example.com/stream.m3u8"
+			cfg.CompareURL = "http://new.example.com/stream.m3u8"
+			cfg.Clients = 10
+			cfg.StatsEnabled = true
+			tt.modify(cfg)
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_OriginLog(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(logFile, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"off", func(*Config) {}, ""},
+		{"tag only", func(c *Config) { c.TagRequests = true }, ""},
+		{"file", func(c *Config) { c.OriginLog = logFile }, ""},
+		{"missing file", func(c *Config) { c.OriginLog = logFile + ".1" }, "can't be read"},
+		{"file with query", func(c *Config) { c.OriginLog = logFile; c.OriginLogQuery = `{job="nginx"}` }, "not a file"},
+		{"loki", func(c *Config) { c.OriginLog = "http://loki:3100"; c.OriginLogQuery = `{job="nginx"}` }, ""},
+		{"loki without query", func(c *Config) { c.OriginLog = "https://loki:3100" }, "stream selector"},
+		{"loki with a bad query", func(c *Config) { c.OriginLog = "http://loki:3100"; c.OriginLogQuery = `job="nginx"` }, "stream selector"},
+		{"loki without host", func(c *Config) { c.OriginLog = "http://"; c.OriginLogQuery = `{job="nginx"}` }, "not a Loki URL"},
+		{"query without loki", func(c *Config) { c.OriginLogQuery = `{job="nginx"}` }, "needs a Loki -origin-log"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			tt.modify(cfg)
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_RunTagged(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.RunTagged() {
+		t.Error("RunTagged() by default, want false")
+	}
+	cfg.OriginLog = "/var/log/nginx/access.log"
+	if !cfg.RunTagged() || cfg.OriginLogLoki() {
+		t.Errorf("file -origin-log: RunTagged() = %v, OriginLogLoki() = %v", cfg.RunTagged(), cfg.OriginLogLoki())
+	}
+	cfg.OriginLog = "https://loki.example.com"
+	if !cfg.OriginLogLoki() {
+		t.Error("OriginLogLoki() for an https URL, want true")
+	}
+	cfg.OriginLog, cfg.TagRequests = "", true
+	if !cfg.RunTagged() {
+		t.Error("RunTagged() with -tag-requests, want true")
+	}
+}
+
+func TestParseCompareOpt(t *testing.T) {
+	tests := []struct {
+		opt        string
+		key, value string
+		wantErr    bool
+	}{
+		{opt: "keepalive=off", key: "keepalive", value: "off"},
+		{opt: " keepalive = on ", key: "keepalive", value: "on"},
+		{opt: "user-agent=Mozilla/5.0 (iPhone)", key: "user-agent", value: "Mozilla/5.0 (iPhone)"},
+		{opt: "variant=first", key: "variant", value: "first"},
+		{opt: "keepalive", wantErr: true},
+		{opt: "keepalive=no", wantErr: true},
+		{opt: "user-agent=", wantErr: true},
+		{opt: "variant=highest", wantErr: true},
+		{opt: "cookie=a", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, value, err := ParseCompareOpt(tt.opt)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCompareOpt(%q) error = %v, wantErr %v", tt.opt, err, tt.wantErr)
+			continue
+		}
+		if key != tt.key || value != tt.value {
+			t.Errorf("ParseCompareOpt(%q) = %q, %q, want %q, %q", tt.opt, key, value, tt.key, tt.value)
+		}
+	}
+}
+
+func TestValidate_TokenURL(t *testing.T) {
+	bearer := []string{"Authorization: Bearer {token}"}
+	tests := []struct {
+		name    string
+		url     string
+		headers []string
+		stats   bool
+		wantErr bool
+	}{
+		{"templated url", "https://auth.example.com/token?client={client_id}", bearer, true, false},
+		{"not http", "ftp://auth.example.com/token", bearer, true, true},
+		{"no host", "http:///token", bearer, true, true},
+		{"token in url", "http://auth.example.com/token?old={token}", bearer, true, true},
+		{"no header uses token", "http://auth.example.com/token", []string{"X-Client: {client_id}"}, true, true},
+		{"stats disabled", "http://auth.example.com/token", bearer, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.TokenURL = tt.url
+			cfg.Headers = tt.headers
+			cfg.StatsEnabled = tt.stats
+			if err := Validate(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_TokenTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		tokenURL string
+		wantErr  bool
+	}{
+		{"off", 0, "", false},
+		{"with token url", 5 * time.Minute, "http://auth.example.com/token", false},
+		{"negative", -time.Second, "http://auth.example.com/token", true},
+		{"no token url", 5 * time.Minute, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.TokenURL = tt.tokenURL
+			cfg.TokenTTL = tt.ttl
+			cfg.Headers = []string{"Authorization: Bearer {token}"}
+			cfg.StatsEnabled = true
+			if err := Validate(cfg); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddGeo(t *testing.T) {
+	var geos []Geo
+	var err error
+	for _, spec := range []string{
+		"eu:2=X-Forwarded-For: 81.2.69.{client_id}",
+		"us=X-Forwarded-For: 8.8.{client_id}.1",
+		"eu=X-Geo-Country: DE",
+		"us:3=X-Geo-Country: US",
+	} {
+		if geos, err = AddGeo(geos, spec); err != nil {
+			t.Fatalf("AddGeo(%q) = %v", spec, err)
+		}
+	}
+
+	if len(geos) != 2 {
+		t.Fatalf("geos = %+v, want eu and us", geos)
+	}
+	if eu := geos[0]; eu.Name != "eu" || eu.Weight != 2 || len(eu.Headers) != 2 || eu.Headers[1] != "X-Geo-Country: DE" {
+		t.Errorf("eu = %+v", eu)
+	}
+	if us := geos[1]; us.Weight != 3 || us.Headers[0] != "X-Forwarded-For: 8.8.{client_id}.1" {
+		t.Errorf("us = %+v, want weight 3 set by a later entry", us)
+	}
+
+	for _, spec := range []string{"eu", "eu:heavy=X-A: 1"} {
+		if _, err := AddGeo(nil, spec); err == nil {
+			t.Errorf("AddGeo(%q) = nil, want error", spec)
+		}
+	}
+}
+
+func TestValidate_Geos(t *testing.T) {
+	tests := []struct {
+		name    string
+		geos    []Geo
+		stats   bool
+		wantErr string
+	}{
+		{"valid", []Geo{{Name: "eu", Weight: 2, Headers: []string{"X-Forwarded-For: 81.2.69.160"}}}, true, ""},
+		{"empty name", []Geo{{Weight: 1, Headers: []string{"X-A: 1"}}}, true, "name is empty"},
+		{"zero weight", []Geo{{Name: "eu", Headers: []string{"X-A: 1"}}}, true, "weight"},
+		{"bad header", []Geo{{Name: "eu", Weight: 1, Headers: []string{"X Forwarded: 1"}}}, true, "Name: value"},
+		{"no colon", []Geo{{Name: "eu", Weight: 1, Headers: []string{"81.2.69.160"}}}, true, "Name: value"},
+		{"more geos than clients", []Geo{
+			{Name: "a", Weight: 1, Headers: []string{"X-A: 1"}},
+			{Name: "b", Weight: 1, Headers: []string{"X-A: 2"}},
+			{Name: "c", Weight: 1, Headers: []string{"X-A: 3"}},
+		}, true, "3 geos"},
+		{"stats disabled", []Geo{{Name: "eu", Weight: 1, Headers: []string{"X-A: 1"}}}, false, "-stats"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.Clients = 2
+			cfg.Geos = tt.geos
+			cfg.StatsEnabled = tt.stats
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Pcap(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"missing dir", func(c *Config) { c.PcapDir = filepath.Join(dir, "missing") }, "not a directory"},
+		{"more samples than clients", func(c *Config) { c.PcapClients = 11 }, "pcap_clients"},
+		{"no samples", func(c *Config) { c.PcapClients = 0 }, "pcap_clients"},
+		{"negative snaplen", func(c *Config) { c.PcapSnapLen = -1 }, "pcap_snaplen"},
+		{"zero file size", func(c *Config) { c.PcapFileMB = 0 }, "pcap_file_mb"},
+		{"zero files", func(c *Config) { c.PcapFiles = 0 }, "pcap_files"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.Clients = 10
+			cfg.PcapDir = dir
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Flaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"off", func(c *Config) { c.FlapInterval = 0; c.StatsEnabled = false }, ""},
+		{"negative interval", func(c *Config) { c.FlapInterval = -time.Second }, "flap_interval"},
+		{"zero duration", func(c *Config) { c.FlapDuration = 0 }, "flap_duration"},
+		{"more clients than -clients", func(c *Config) { c.FlapClients = 11 }, "flap_clients"},
+		{"no clients", func(c *Config) { c.FlapClients = 0 }, "flap_clients"},
+		{"without stats", func(c *Config) { c.StatsEnabled = false }, "requires stats collection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.Clients = 10
+			cfg.StatsEnabled = true
+			cfg.FlapInterval = 30 * time.Second
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseStopSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{"TERM", syscall.SIGTERM, false},
+		{"SIGINT", syscall.SIGINT, false},
+		{"quit", syscall.SIGQUIT, false},
+		{" sighup ", syscall.SIGHUP, false},
+		{"KILL", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseStopSignal(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseStopSignal(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_StopPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"SIGINT", func(c *Config) { c.StopSignal = "SIGINT" }, ""},
+		{"unknown signal", func(c *Config) { c.StopSignal = "KILL" }, "stop_signal"},
+		{"zero grace", func(c *Config) { c.StopGrace = 0 }, "stop_grace"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientProcessFlags(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, set := range []struct {
+		fn   func(*Config, string) error
+		spec string
+	}{
+		{AddClientEnv, "TZ=UTC"},
+		{AddClientEnv, "eu:LANG=de_DE.UTF-8"},
+		{AddClientEnv, "OPTS=a=b:c"},
+		{SetNice, "10"},
+		{SetNice, "eu:15"},
+		{SetIONice, "idle"},
+		{SetIONice, "eu:best-effort:7"},
+		{SetPacing, "realtime"},
+		{SetPacing, "eu:buffer:10s"},
+	} {
+		if err := set.fn(cfg, set.spec); err != nil {
+			t.Fatalf("%q: %v", set.spec, err)
+		}
+	}
+	// -geo after the cohort settings adds to the same geo
+	geos, err := AddGeo(cfg.Geos, "eu:3=X-Geo: DE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Geos = geos
+
+	if want := []string{"TZ=UTC", "OPTS=a=b:c"}; !slices.Equal(cfg.ClientEnv, want) {
+		t.Errorf("ClientEnv = %q, want %q", cfg.ClientEnv, want)
+	}
+	if cfg.Nice != 10 || cfg.IONice != "idle" || cfg.Pacing != "realtime" {
+		t.Errorf("Nice, IONice, Pacing = %d, %q, %q; want 10, idle, realtime", cfg.Nice, cfg.IONice, cfg.Pacing)
+	}
+	if len(cfg.Geos) != 1 {
+		t.Fatalf("Geos = %+v, want just eu", cfg.Geos)
+	}
+	eu := cfg.Geos[0]
+	if eu.Weight != 3 || !slices.Equal(eu.Env, []string{"LANG=de_DE.UTF-8"}) || eu.Nice != 15 || eu.IONice != "best-effort:7" || eu.Pacing != "buffer:10s" || len(eu.Headers) != 1 {
+		t.Errorf("eu = %+v", eu)
+	}
+
+	for _, bad := range []struct {
+		fn   func(*Config, string) error
+		spec string
+	}{
+		{AddClientEnv, "TZ"},
+		{AddClientEnv, "=x"},
+		{AddClientEnv, "eu:=x"},
+		{SetNice, "low"},
+		{SetNice, "eu:low"},
+		{SetIONice, "lazy"},
+		{SetIONice, "eu:idle:3"},
+		{SetIONice, "be:8"},
+		{SetPacing, "slow"},
+		{SetPacing, "buffer"},
+		{SetPacing, "eu:buffer:soon"},
+	} {
+		if err := bad.fn(DefaultConfig(), bad.spec); err == nil {
+			t.Errorf("%q: want error", bad.spec)
+		}
+	}
+}
+
+func TestParseIONice(t *testing.T) {
+	tests := []struct {
+		spec         string
+		class, level int
+		wantErr      bool
+	}{
+		{"idle", 3, 0, false},
+		{"best-effort", 2, 4, false},
+		{"be:0", 2, 0, false},
+		{"realtime:7", 1, 7, false},
+		{"rt", 1, 4, false},
+		{"idle:1", 0, 0, true},
+		{"be:-1", 0, 0, true},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		class, level, err := ParseIONice(tt.spec)
+		if (err != nil) != tt.wantErr || class != tt.class || level != tt.level {
+			t.Errorf("ParseIONice(%q) = %d, %d, %v; want %d, %d, error %v", tt.spec, class, level, err, tt.class, tt.level, tt.wantErr)
+		}
+	}
+}
+
+func TestParsePacing(t *testing.T) {
+	tests := []struct {
+		spec    string
+		model   string
+		buffer  time.Duration
+		wantErr bool
+	}{
+		{"", PacingFast, 0, false},
+		{"fast", PacingFast, 0, false},
+		{"realtime", PacingRealtime, 0, false},
+		{"buffer:10s", PacingBuffer, 10 * time.Second, false},
+		{"buffer:1m30s", PacingBuffer, 90 * time.Second, false},
+		{"buffer", "", 0, true},
+		{"buffer:0s", "", 0, true},
+		{"buffer:-5s", "", 0, true},
+		{"realtime:2", "", 0, true},
+		{"paced", "", 0, true},
+	}
+	for _, tt := range tests {
+		model, buffer, err := ParsePacing(tt.spec)
+		if (err != nil) != tt.wantErr || model != tt.model || buffer != tt.buffer {
+			t.Errorf("ParsePacing(%q) = %q, %v, %v; want %q, %v, error %v", tt.spec, model, buffer, err, tt.model, tt.buffer, tt.wantErr)
+		}
+	}
+}
+
+func TestValidBackoffCause(t *testing.T) {
+	for _, cause := range []string{"http-404", "http-599", "http-5xx", "http-1xx", "reset", "refused", "timeout", "dns", "tls", "exit-0", "exit-255"} {
+		if !ValidBackoffCause(cause) {
+			t.Errorf("ValidBackoffCause(%q) = false, want true", cause)
+		}
+	}
+	for _, cause := range []string{"", "404", "http-", "http-99", "http-600", "http-6xx", "http-4XX", "exit-", "exit--1", "exit-256", "error", "Reset"} {
+		if ValidBackoffCause(cause) {
+			t.Errorf("ValidBackoffCause(%q) = true, want false", cause)
+		}
+	}
+}
+
+func TestAddBackoffOn(t *testing.T) {
+	cfg := DefaultConfig()
+	for _, spec := range []string{"http-404=gentle", "reset = aggressive", "http-404=none"} {
+		if err := AddBackoffOn(cfg, spec); err != nil {
+			t.Fatalf("AddBackoffOn(%q) = %v", spec, err)
+		}
+	}
+	want := map[string]string{"http-404": "none", "reset": "aggressive"}
+	if !maps.Equal(cfg.BackoffOn, want) {
+		t.Errorf("BackoffOn = %v, want %v", cfg.BackoffOn, want)
+	}
+	if err := AddBackoffOn(cfg, "http-404"); err == nil {
+		t.Error("AddBackoffOn without a preset: want error")
+	}
+}
+
+func TestValidate_Backoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"preset", func(c *Config) { c.BackoffPreset = "gentle" }, ""},
+		{"unknown preset", func(c *Config) { c.BackoffPreset = "slow" }, "backoff_preset"},
+		{"overrides", func(c *Config) {
+			c.BackoffOn = map[string]string{"http-404": "gentle", "reset": "aggressive", "exit-1": "none"}
+		}, ""},
+		{"unknown cause", func(c *Config) { c.BackoffOn = map[string]string{"404": "gentle"} }, `unknown exit cause "404"`},
+		{"unknown override preset", func(c *Config) { c.BackoffOn = map[string]string{"reset": "fast"} }, "reset: preset must be one of"},
+		{"quarantine", func(c *Config) { c.QuarantineFailures = 5 }, ""},
+		{"negative quarantine", func(c *Config) { c.QuarantineFailures = -1 }, "quarantine_failures"},
+		{"quarantine without window", func(c *Config) { c.QuarantineFailures = 5; c.QuarantineWindow = 0 }, "quarantine_window"},
+		{"quarantine without cooldown", func(c *Config) { c.QuarantineFailures = 5; c.QuarantineCooldown = 0 }, "quarantine_cooldown"},
+		{"quarantine off, no window", func(c *Config) { c.QuarantineWindow = 0 }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_ClientProcess(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"defaults", func(c *Config) {}, ""},
+		{"valid", func(c *Config) { c.ClientEnv = []string{"TZ=UTC"}; c.Nice = 19; c.IONice = "idle" }, ""},
+		{"nice too low", func(c *Config) { c.Nice = -21 }, "nice"},
+		{"geo nice too high", func(c *Config) {
+			c.Geos = []Geo{{Name: "eu", Weight: 1, Nice: 20}}
+		}, `geo "eu": must be between -20 and 19`},
+		{"bad ionice", func(c *Config) { c.IONice = "slow" }, "ionice"},
+		{"bad env", func(c *Config) { c.ClientEnv = []string{"TZ"} }, "client_env"},
+		{"pacing", func(c *Config) { c.Pacing = "buffer:10s" }, ""},
+		{"geo bad pacing", func(c *Config) {
+			c.Geos = []Geo{{Name: "eu", Weight: 1, Headers: []string{"X-Geo: DE"}, Pacing: "slow"}}
+		}, `geo "eu": pacing "slow"`},
+		{"CPU guard off", func(c *Config) { c.ClientCPULimit = 0 }, ""},
+		{"negative CPU limit", func(c *Config) { c.ClientCPULimit = -1 }, "client_cpu_limit"},
+		{"CPU fail policy", func(c *Config) { c.ClientCPUPolicy = "fail" }, ""},
+		{"unknown CPU policy", func(c *Config) { c.ClientCPUPolicy = "kill" }, "client_cpu_policy"},
+		{"memory guard", func(c *Config) { c.MaxMemoryMB = 2048 }, ""},
+		{"negative memory limit", func(c *Config) { c.MaxMemoryMB = -1 }, "max_memory_mb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.StatsEnabled = true
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*Config)
+		wantField string // "" = no warning
+	}{
+		{"defaults", func(*Config) {}, ""},
+		{"per-client metrics, few clients", func(c *Config) { c.PromClientMetrics = true; c.Clients = 200 }, ""},
+		{"per-client metrics, capped", func(c *Config) { c.PromClientMetrics = true; c.Clients = 400 }, ""},
+		{"per-client metrics, uncapped", func(c *Config) {
+			c.PromClientMetrics = true
+			c.PromClientMetricsMax = 0
+			c.Clients = 201
+		}, "prom_client_metrics"},
+		{"per-client metrics, high cap", func(c *Config) {
+			c.PromClientMetrics = true
+			c.PromClientMetricsMax = 400
+			c.Clients = 400
+		}, "prom_client_metrics"},
+		{"debug loglevel, 500 clients", func(c *Config) { c.Clients = 500 }, ""},
+		{"debug loglevel, 501 clients", func(c *Config) { c.Clients = 501 }, "stats_loglevel"},
+		{"verbose loglevel", func(c *Config) { c.Clients = 1000; c.StatsLogLevel = "verbose" }, ""},
+		{"ffmpeg debug", func(c *Config) { c.Clients = 1000; c.StatsLogLevel = "verbose"; c.DebugLogging = true }, "stats_loglevel"},
+		{"debug loglevel, stats off", func(c *Config) { c.Clients = 1000; c.StatsEnabled = false }, ""},
+		{"debug sample", func(c *Config) { c.Clients = 1000; c.DebugSample = 1 }, ""},
+		{"cdn detect", func(c *Config) { c.Clients = 1000; c.StatsLogLevel = "verbose"; c.CDNDetect = true }, "stats_loglevel"},
+		{"reconnect without delay", func(c *Config) { c.ReconnectDelayMax = 0 }, "reconnect_delay_max"},
+		{"no reconnect, no delay", func(c *Config) { c.Reconnect = false; c.ReconnectDelayMax = 0 }, ""},
+		{"reconnect, long timeout", func(c *Config) { c.Timeout = 2 * time.Minute }, "timeout"},
+		{"no reconnect, long timeout", func(c *Config) { c.Reconnect = false; c.Timeout = 2 * time.Minute }, ""},
+		{"backoff on 404", func(c *Config) { c.BackoffOn = map[string]string{"http-404": "gentle"} }, ""},
+		{"backoff on 404, stats off", func(c *Config) {
+			c.StatsEnabled = false
+			c.BackoffOn = map[string]string{"http-404": "gentle"}
+		}, "backoff_on"},
+		{"backoff on exit code, stats off", func(c *Config) {
+			c.StatsEnabled = false
+			c.BackoffOn = map[string]string{"exit-1": "gentle"}
+		}, ""},
+		{"origin log", func(c *Config) { c.OriginLog = "http://loki:3100" }, ""},
+		{"origin log, stats off", func(c *Config) { c.OriginLog = "http://loki:3100"; c.StatsEnabled = false }, "origin_log"},
+		{"event sink, stats off", func(c *Config) { c.EventSink = "loki"; c.StatsEnabled = false }, "event_sink_debug"},
+		{"lifecycle event sink, stats off", func(c *Config) {
+			c.EventSink = "loki"
+			c.EventSinkDebug = false
+			c.StatsEnabled = false
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.Reconnect = true
+			tt.modify(cfg)
+			warnings := Warnings(cfg)
+			if tt.wantField == "" {
+				if len(warnings) > 0 {
+					t.Errorf("Warnings() = %v, want none", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || warnings[0].Field != tt.wantField || warnings[0].Suggestion == "" {
+				t.Errorf("Warnings() = %v, want one on %s with a suggestion", warnings, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestValidate_Strict(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	cfg.Reconnect = true
+	cfg.ReconnectDelayMax = 0
+	if err := Validate(cfg); err != nil {
+		t.Fatalf("Validate() = %v, want warnings only", err)
+	}
+
+	cfg.Strict = true
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("Validate() with -strict = nil, want the warning as an error")
+	}
+	for _, want := range []string{"reconnect_delay_max", "[-strict]", "set -reconnect-delay"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestValidate_ConnProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"off", func(c *Config) { c.ConnProbe = false; c.ConnProbeStep = 0 }, ""},
+		{"no step", func(c *Config) { c.ConnProbeStep = 0 }, "conn_probe_step"},
+		{"max under step", func(c *Config) { c.ConnProbeMax = 10 }, "conn_probe_max"},
+		{"zero step duration", func(c *Config) { c.ConnProbeStepDuration = 0 }, "conn_probe_step_duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.ConnProbe = true
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_PlaylistStress(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"rate limited", func(c *Config) { c.PlaylistStressRate = 5000 }, ""},
+		{"off", func(c *Config) { c.PlaylistStress = false; c.PlaylistStressWorkers = 0 }, ""},
+		{"no workers", func(c *Config) { c.PlaylistStressWorkers = 0 }, "playlist_stress_workers"},
+		{"negative rate", func(c *Config) { c.PlaylistStressRate = -1 }, "playlist_stress_rate"},
+		{"with conn probe", func(c *Config) { c.ConnProbe = true }, "can't be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.PlaylistStress = true
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_EventSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"loki", func(c *Config) {}, ""},
+		{"loki with labels", func(c *Config) { c.EventSinkLabels = []string{"env=staging", "job=swarm"} }, ""},
+		{"kafka", func(c *Config) { c.EventSink = "kafka"; c.EventSinkURL = "http://kafka-rest:8082" }, ""},
+		{"off", func(c *Config) { c.EventSink = ""; c.EventSinkURL = "" }, ""},
+		{"unknown sink", func(c *Config) { c.EventSink = "syslog" }, "must be 'loki' or 'kafka'"},
+		{"no url", func(c *Config) { c.EventSinkURL = "" }, "event_sink_url"},
+		{"bad url", func(c *Config) { c.EventSinkURL = "loki:3100" }, "event_sink_url"},
+		{"kafka without topic", func(c *Config) { c.EventSink = "kafka"; c.EventSinkTopic = "" }, "event_sink_topic"},
+		{"bad label", func(c *Config) { c.EventSinkLabels = []string{"env"} }, "want key=value"},
+		{"per-event label", func(c *Config) { c.EventSinkLabels = []string{"event=x"} }, "set per event"},
+		{"url without sink", func(c *Config) { c.EventSink = "" }, "require -event-sink"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.EventSink = "loki"
+			cfg.EventSinkURL = "http://loki:3100"
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseBurst(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Burst
+		wantErr bool
+	}{
+		{"every 5m: +200 clients for 30s", Burst{Every: 5 * time.Minute, Clients: 200, For: 30 * time.Second}, false},
+		{"Every 90s:+1 client for 10s", Burst{Every: 90 * time.Second, Clients: 1, For: 10 * time.Second}, false},
+		{"every 5m +200 clients for 30s", Burst{}, true},
+		{"every 5m: 200 clients for 30s", Burst{}, true},
+		{"every five: +200 clients for 30s", Burst{}, true},
+		{"every 5m: +many clients for 30s", Burst{}, true},
+		{"every 5m: +200 clients for ever", Burst{}, true},
+		{"every 5m: +200 viewers for 30s", Burst{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBurst(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseBurst(%q) = %+v, %v; want %+v, error %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	b := Burst{Every: 5 * time.Minute, Clients: 200, For: 30 * time.Second}
+	if got, err := ParseBurst(b.String()); err != nil || got != b {
+		t.Errorf("ParseBurst(%q) = %+v, %v; want %+v", b.String(), got, err, b)
+	}
+}
+
+func TestValidate_Bursts(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"layered", func(c *Config) {
+			c.Bursts = append(c.Bursts, Burst{Every: time.Hour, Clients: 1000, For: 5 * time.Minute})
+		}, ""},
+		{"never ends", func(c *Config) { c.Bursts[0].For = c.Bursts[0].Every }, "never ends"},
+		{"zero interval", func(c *Config) { c.Bursts[0].Every = 0 }, "must be > 0"},
+		{"no clients", func(c *Config) { c.Bursts[0].Clients = 0 }, "at least 1 client"},
+		{"with tenants", func(c *Config) {
+			c.Tenants = []Tenant{{Name: "a", Clients: 10}}
+			c.StatsEnabled = true
+		}, "-tenants"},
+		{"with geos", func(c *Config) { c.Geos = []Geo{{Name: "eu", Weight: 1, Headers: []string{"X-Geo: eu"}}} }, "-geo"},
+		{"with compare", func(c *Config) { c.CompareURL = "http://b.example.com/stream.m3u8" }, "-compare-url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.StreamURL = "http://example.com/stream.m3u8"
+			cfg.Clients = 10
+			cfg.Bursts = []Burst{{Every: 5 * time.Minute, Clients: 200, For: 30 * time.Second}}
+			tt.modify(cfg)
+
+			err := Validate(cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSessionDuration(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    SessionDist
+		wantErr bool
+	}{
+		{"10m", SessionDist{Kind: SessionFixed, Min: 10 * time.Minute, Max: 10 * time.Minute}, false},
+		{"fixed:90s", SessionDist{Kind: SessionFixed, Min: 90 * time.Second, Max: 90 * time.Second}, false},
+		{"uniform:5m-30m", SessionDist{Kind: SessionUniform, Min: 5 * time.Minute, Max: 30 * time.Minute}, false},
+		{"lognormal:10m,0.8", SessionDist{Kind: SessionLognormal, Median: 10 * time.Minute, Sigma: 0.8}, false},
+		{"0s", SessionDist{}, true},
+		{"fixed:-1m", SessionDist{}, true},
+		{"uniform:30m-5m", SessionDist{}, true},
+		{"uniform:5m", SessionDist{}, true},
+		{"lognormal:10m", SessionDist{}, true},
+		{"lognormal:10m,0", SessionDist{}, true},
+		{"lognormal:10m,4", SessionDist{}, true},
+		{"pareto:10m,1", SessionDist{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSessionDuration(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSessionDuration(%q) = %+v, %v; want %+v, error %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_SessionDuration(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/stream.m3u8"
+	cfg.SessionDuration = "lognormal:10m,0.8"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	cfg.SessionDuration = "uniform:30m-5m"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "session_duration") {
+		t.Errorf("Validate() = %v, want a session_duration error", err)
+	}
+}
+
+func TestParseVODStart(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    VODStartDist
+		wantErr bool
+	}{
+		{"", VODStartDist{Kind: VODStartFixed}, false},
+		{"start", VODStartDist{Kind: VODStartFixed}, false},
+		{"10m", VODStartDist{Kind: VODStartFixed, Min: 10 * time.Minute, Max: 10 * time.Minute}, false},
+		{"fixed:0s", VODStartDist{Kind: VODStartFixed}, false},
+		{"uniform", VODStartDist{Kind: VODStartUniform}, false},
+		{"uniform:0s-30m", VODStartDist{Kind: VODStartUniform, Max: 30 * time.Minute}, false},
+		{"exponential:5m", VODStartDist{Kind: VODStartExponential, Mean: 5 * time.Minute}, false},
+		{"-1m", VODStartDist{}, true},
+		{"uniform:30m-5m", VODStartDist{}, true},
+		{"uniform:5m", VODStartDist{}, true},
+		{"exponential:0s", VODStartDist{}, true},
+		{"middle", VODStartDist{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseVODStart(tt.spec)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseVODStart(%q) = %+v, %v; want %+v, error %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_VODEnd(t *testing.T) {
+	for _, mode := range []string{"", VODEndStop, VODEndLoop} {
+		cfg := DefaultConfig()
+		cfg.StreamURL = "http://example.com/vod.m3u8"
+		cfg.VODEnd = mode
+		if err := Validate(cfg); err != nil {
+			t.Errorf("Validate() with -vod-end %q = %v", mode, err)
+		}
+	}
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/vod.m3u8"
+	cfg.VODEnd = "rewind"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "vod_end") {
+		t.Errorf("Validate() = %v, want a vod_end error", err)
+	}
+}
+
+func TestParseRenditions(t *testing.T) {
+	tests := []struct {
+		spec        string
+		audio, subs string
+		wantErr     bool
+	}{
+		{"", "", "", false},
+		{"audio=en", "en", "", false},
+		{"audio=en, subs=pt-BR", "en", "pt-BR", false},
+		{"subtitles=all", "", "all", false},
+		{"audio=all,subs=fr", "all", "fr", false},
+		{"audio", "", "", true},
+		{"audio=", "", "", true},
+		{"audio=en:x", "", "", true},
+		{"video=en", "", "", true},
+	}
+	for _, tt := range tests {
+		audio, subs, err := ParseRenditions(tt.spec)
+		if (err != nil) != tt.wantErr || audio != tt.audio || subs != tt.subs {
+			t.Errorf("ParseRenditions(%q) = %q, %q, %v; want %q, %q, error %v", tt.spec, audio, subs, err, tt.audio, tt.subs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_Renditions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StreamURL = "http://example.com/master.m3u8"
+	cfg.Renditions = "audio=en,subs=en"
+	cfg.Variant = "highest"
+	if err := Validate(cfg); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	// Every rendition is fetched with -variant all already
+	cfg.Variant = "all"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "renditions") {
+		t.Errorf("Validate() with -variant all = %v, want a renditions error", err)
+	}
+}
+
+func TestValidate_Prefetch(t *testing.T) {
+	for _, depth := range []int{-1, 0, 1} {
+		cfg := DefaultConfig()
+		cfg.StreamURL = "http://example.com/live.m3u8"
+		cfg.Prefetch = depth
+		if err := Validate(cfg); err != nil {
+			t.Errorf("Validate() with -prefetch %d = %v", depth, err)
+		}
+	}
+	for _, depth := range []int{-2, 2, 3} {
+		cfg := DefaultConfig()
+		cfg.StreamURL = "http://example.com/live.m3u8"
+		cfg.Prefetch = depth
+		if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), "prefetch") {
+			t.Errorf("Validate() with -prefetch %d = %v, want a prefetch error", depth, err)
+		}
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 // headerList is a custom flag type for repeatable -header flags.
@@ -31,35 +32,60 @@ func ParseFlags() (*Config, error) {
 
 Usage:
   go-ffmpeg-hls-swarm [flags] <HLS_URL>
+  go-ffmpeg-hls-swarm runs list|show|compare    (history saved by -save-run)
 
 Orchestration Flags:
 `)
 		// Print flags by category
-		printFlagCategory([]string{"clients", "ramp-rate", "ramp-jitter", "duration"})
+		printFlagCategory([]string{"clients", "ramp-rate", "ramp-jitter", "duration", "cool-down", "cpu-affinity", "client-env", "nice", "ionice", "pacing", "session-duration", "vod-start", "vod-end", "start-at", "ntp-server", "tenants"})
 
 		fmt.Fprintf(os.Stderr, "\nVariant Selection:\n")
-		printFlagCategory([]string{"variant", "probe-failure-policy"})
+		printFlagCategory([]string{"variant", "variant-mix", "renditions", "probe-failure-policy"})
+
+		fmt.Fprintf(os.Stderr, "\nOrigin Comparison:\n")
+		printFlagCategory([]string{"compare-url", "compare-split", "compare-opt"})
+
+		fmt.Fprintf(os.Stderr, "\nLoad Estimate:\n")
+		printFlagCategory([]string{"estimate-load", "load-budget-rps", "load-budget-mbps"})
+
+		fmt.Fprintf(os.Stderr, "\nPlaylists:\n")
+		printFlagCategory([]string{"validate-playlists", "validate-playlist-interval", "playlist-refresh"})
 
 		fmt.Fprintf(os.Stderr, "\nNetwork / Testing:\n")
-		printFlagCategory([]string{"resolve", "no-cache", "header"})
+		printFlagCategory([]string{"resolve", "no-cache", "no-keepalive", "prefetch", "header", "accept-encoding", "token-url", "token-ttl", "geo"})
 
 		fmt.Fprintf(os.Stderr, "\nSafety & Diagnostics:\n")
-		printFlagCategory([]string{"dangerous", "print-cmd", "check", "skip-preflight"})
+		printFlagCategory([]string{"dangerous", "print-cmd", "plan", "expected-bitrate", "check", "strict", "conn-probe", "conn-probe-step", "conn-probe-max", "conn-probe-step-duration", "playlist-stress", "playlist-stress-workers", "playlist-stress-rate", "skip-preflight", "kill-orphans", "client-cpu-limit", "client-cpu-policy", "max-memory"})
+
+		fmt.Fprintf(os.Stderr, "\nPacket Capture:\n")
+		printFlagCategory([]string{"pcap-dir", "pcap-clients", "pcap-snaplen", "pcap-file-mb", "pcap-files"})
+
+		fmt.Fprintf(os.Stderr, "\nOrigin Logs:\n")
+		printFlagCategory([]string{"tag-requests", "origin-log", "origin-log-query"})
+
+		fmt.Fprintf(os.Stderr, "\nFault Injection:\n")
+		printFlagCategory([]string{"flap-interval", "flap-duration", "flap-clients"})
+
+		fmt.Fprintf(os.Stderr, "\nTraffic Bursts:\n")
+		printFlagCategory([]string{"burst"})
 
 		fmt.Fprintf(os.Stderr, "\nObservability:\n")
-		printFlagCategory([]string{"metrics", "v", "log-format"})
+		printFlagCategory([]string{"metrics", "v", "log-format", "log-dedup-window", "pushgateway-url", "pushgateway-job", "pushgateway-labels", "event-sink", "event-sink-url", "event-sink-topic", "event-sink-labels", "event-sink-debug", "save-run", "runs-file"})
 
 		fmt.Fprintf(os.Stderr, "\nFFmpeg:\n")
-		printFlagCategory([]string{"ffmpeg", "user-agent", "timeout", "reconnect", "reconnect-delay", "seg-retry"})
+		printFlagCategory([]string{"ffmpeg", "user-agent", "timeout", "reconnect", "reconnect-delay", "seg-retry", "ffmpeg-extra-args", "stop-signal", "stop-grace"})
 
 		fmt.Fprintf(os.Stderr, "\nHealth / Stall Detection:\n")
-		printFlagCategory([]string{"target-duration", "restart-on-stall"})
+		printFlagCategory([]string{"target-duration", "restart-on-stall", "capacity-p95", "slo"})
+
+		fmt.Fprintf(os.Stderr, "\nRestarts:\n")
+		printFlagCategory([]string{"backoff-preset", "backoff-on", "quarantine-after", "quarantine-window", "quarantine-cooldown"})
 
 		fmt.Fprintf(os.Stderr, "\nStats Collection:\n")
-		printFlagCategory([]string{"stats", "stats-loglevel", "stats-buffer", "progress-socket", "ffmpeg-debug"})
+		printFlagCategory([]string{"stats", "stats-loglevel", "ffmpeg-log-dialect", "stats-buffer", "stats-max-line", "stats-retention", "stats-spill-dir", "stats-spill-compress", "stats-stdout", "stats-interval", "stats-aggregate-interval", "slow-request-log", "socket-stats", "progress-mode", "ffmpeg-debug", "debug-sample"})
 
 		fmt.Fprintf(os.Stderr, "\nDashboard:\n")
-		printFlagCategory([]string{"tui", "prom-client-metrics"})
+		printFlagCategory([]string{"tui", "tui-panels", "tui-prefs", "tui-theme", "tui-refresh", "status-line", "status-interval", "prom-client-metrics", "prom-client-metrics-max", "metrics-update-interval"})
 
 		fmt.Fprintf(os.Stderr, "\nOrigin Metrics:\n")
 		printFlagCategory([]string{"origin-metrics", "nginx-metrics", "origin-metrics-interval", "origin-metrics-window"})
@@ -72,6 +98,14 @@ Flag Convention:
   Single-dash flags (-clients, -resolve) are normal options.
   Double-dash flags (--dangerous, --check) are safety gates or diagnostic modes.
 
+Exit Codes:
+  0  Success
+  1  Other failure (e.g. clients giving up, a slow shutdown)
+  2  Threshold violated: the run missed its -slo
+  3  Safety abort: a guard stopped the run (-client-cpu-limit)
+  4  Configuration error: invalid flags, or flags the stream doesn't fit
+  5  Environment failure: preflight, FFmpeg, the metrics port or the origin
+
 Examples:
   # Quick smoke test
   go-ffmpeg-hls-swarm -clients 5 https://test-streams.mux.dev/x36xhzz/x36xhzz.m3u8
@@ -79,6 +113,22 @@ Examples:
   # Stress test with cache bypass
   go-ffmpeg-hls-swarm -clients 100 -no-cache https://cdn.example.com/live/master.m3u8
 
+  # Review what a large run would do before pointing it at production
+  go-ffmpeg-hls-swarm -clients 500 -ramp-rate 20 -expected-bitrate 5000 --plan https://cdn.example.com/live/master.m3u8
+
+  # Coordinated burst: run on every load host, all ramp at the same instant
+  go-ffmpeg-hls-swarm -clients 200 -start-at 2026-05-01T12:00:00Z https://cdn.example.com/live/master.m3u8
+
+  # Live stats for a wrapper script (one JSON object per line on stdout)
+  go-ffmpeg-hls-swarm -clients 100 -stats-stdout ndjson -stats-interval 5s https://cdn.example.com/live/master.m3u8 | jq .segment_rate
+
+  # 10 minutes of load, then 2 minutes watching the origin recover
+  go-ffmpeg-hls-swarm -clients 300 -duration 10m -cool-down 2m https://cdn.example.com/live/master.m3u8
+
+  # Keep a history of capacity tests on this box; compare run #1 with the latest
+  go-ffmpeg-hls-swarm -clients 500 -duration 10m -save-run https://cdn.example.com/live/master.m3u8
+  go-ffmpeg-hls-swarm runs compare 1
+
   # Test specific server by IP
   go-ffmpeg-hls-swarm -clients 50 -resolve 192.168.1.100 --dangerous https://cdn.example.com/live/master.m3u8
 
@@ -90,26 +140,186 @@ Examples:
 	flag.IntVar(&cfg.RampRate, "ramp-rate", cfg.RampRate, "Clients to start per second")
 	flag.DurationVar(&cfg.RampJitter, "ramp-jitter", cfg.RampJitter, "Random jitter per client start")
 	flag.DurationVar(&cfg.Duration, "duration", cfg.Duration, "Run duration (0 = forever)")
+	flag.DurationVar(&cfg.CoolDown, "cool-down", cfg.CoolDown,
+		"After the run, stop clients but keep probing the origin this long and report its recovery to baseline latency (0 = off)")
+	flag.StringVar(&cfg.CPUAffinity, "cpu-affinity", cfg.CPUAffinity,
+		`Pin FFmpeg processes to CPUs: "none", "core" (one CPU each), "numa" (one node each). Linux only`)
+	flag.Func("client-env", `Set an environment variable for FFmpeg: [geo:]KEY=VALUE (can repeat; values may use the -header templates; a geo: prefix sets it for that -geo cohort only)`, func(s string) error {
+		return AddClientEnv(cfg, s)
+	})
+	flag.Func("nice", `Niceness of FFmpeg processes, -20..19: [geo:]N (can repeat for -geo cohorts; below the swarm's needs CAP_SYS_NICE). Linux only`, func(s string) error {
+		return SetNice(cfg, s)
+	})
+	flag.Func("ionice", `IO priority of FFmpeg processes: [geo:]idle, [geo:]best-effort[:0-7] or [geo:]realtime[:0-7] (can repeat for -geo cohorts; realtime needs CAP_SYS_ADMIN). Linux only`, func(s string) error {
+		return SetIONice(cfg, s)
+	})
+	flag.Func("pacing", `How fast clients read segments: [geo:]fast (as fast as FFmpeg can), [geo:]realtime (at the stream's rate) or [geo:]buffer:DURATION (that far ahead at full speed, then real time, like a player's buffer target); each shapes the origin load differently (can repeat for -geo cohorts; realtime and buffer need FFmpeg 6.1+)`, func(s string) error {
+		return SetPacing(cfg, s)
+	})
+	flag.StringVar(&cfg.SessionDuration, "session-duration", cfg.SessionDuration, `End each client's session after a length drawn from a distribution and start a new one in its place, for viewer churn at a steady concurrency: 10m (fixed), uniform:5m-30m or lognormal:MEDIAN,SIGMA such as lognormal:10m,0.8 ("" = sessions last the whole run)`)
+	flag.StringVar(&cfg.VODStart, "vod-start", cfg.VODStart, `Where in a VOD asset clients start playing (seeking with -ss), so requests cover the whole asset rather than its first segments: start, an offset such as 10m, uniform (anywhere), uniform:MIN-MAX or exponential:MEAN (most near the start); drawn at every client start`)
+	flag.StringVar(&cfg.VODEnd, "vod-end", cfg.VODEnd, `What a client does when it plays a VOD asset to the end, with each play's completion tracked: stop (the run ends once every client has finished) or loop (play it again at once); "" = restart after backoff like any other exit`)
+	flag.Func("start-at", "Wait until this RFC 3339 time (e.g. 2026-05-01T12:00:00Z) before ramping", func(s string) error {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("expected RFC 3339 time: %w", err)
+		}
+		cfg.StartAt = t
+		return nil
+	})
+	flag.StringVar(&cfg.NTPServer, "ntp-server", cfg.NTPServer, `NTP server used to correct -start-at for local clock skew ("" = trust local clock)`)
+	flag.Func("tenants", "Comma-separated name:clients[:max_rps] client subsets, each with an optional request-rate quota and its own report; the clients must add up to -clients", func(s string) error {
+		cfg.Tenants = nil
+		for _, spec := range strings.Split(s, ",") {
+			if spec = strings.TrimSpace(spec); spec == "" {
+				continue
+			}
+			t, err := ParseTenant(spec)
+			if err != nil {
+				return err
+			}
+			cfg.Tenants = append(cfg.Tenants, t)
+		}
+		return nil
+	})
 
 	// Variant selection
 	flag.StringVar(&cfg.Variant, "variant", cfg.Variant, `Bitrate selection: "all", "highest", "lowest", "first"`)
+	flag.StringVar(&cfg.Renditions, "renditions", cfg.Renditions, `Alternate renditions (#EXT-X-MEDIA) to fetch with the variant, as a player does: audio=LANG, subs=LANG or all, e.g. "audio=en,subs=en"; needs a single -variant. Requests are reported per rendition`)
+	flag.Func("variant-mix", `Spread clients over the master playlist's variants by device mix: variant=weight,... with variants by height or resolution, e.g. "1080p=60,720p=30,480p=10". Requests are reported per variant`, func(s string) error {
+		mix, err := ParseVariantMix(s)
+		if err != nil {
+			return err
+		}
+		cfg.VariantMix = mix
+		return nil
+	})
+	// Origin comparison
+	flag.StringVar(&cfg.CompareURL, "compare-url", cfg.CompareURL, "Fetch the same stream from a second origin (B) with -compare-split of the clients and compare both origins side by side, e.g. a new origin build against the current one")
+	flag.IntVar(&cfg.CompareSplit, "compare-split", cfg.CompareSplit, "Percent of the clients on the -compare-url origin or with the -compare-opt options (1-99)")
+	flag.Func("compare-opt", `Request option for the B clients, KEY=VALUE: keepalive=on|off, user-agent=UA or variant=all|first; without -compare-url both cohorts fetch the stream URL, e.g. -compare-opt keepalive=off to see what keep-alive buys (can repeat)`, func(s string) error {
+		if _, _, err := ParseCompareOpt(s); err != nil {
+			return err
+		}
+		cfg.CompareOpts = append(cfg.CompareOpts, s)
+		return nil
+	})
 	flag.StringVar(&cfg.ProbeFailurePolicy, "probe-failure-policy", cfg.ProbeFailurePolicy, `Behavior if ffprobe fails: "fallback", "fail"`)
 
+	// Load estimate
+	flag.BoolVar(&cfg.EstimateLoad, "estimate-load", cfg.EstimateLoad, "Probe the manifest before ramp-up and print the expected origin load")
+	flag.Float64Var(&cfg.LoadBudgetRPS, "load-budget-rps", cfg.LoadBudgetRPS, "Warn if estimated origin requests/sec exceed this")
+	flag.Float64Var(&cfg.LoadBudgetMbps, "load-budget-mbps", cfg.LoadBudgetMbps, "Warn if estimated origin bandwidth (Mbps) exceeds this")
+
+	// Playlist validation
+	flag.BoolVar(&cfg.ValidatePlaylists, "validate-playlists", cfg.ValidatePlaylists, "Reload media playlists during the run and count RFC 8216 violations")
+	flag.DurationVar(&cfg.ValidatePlaylistInterval, "validate-playlist-interval", cfg.ValidatePlaylistInterval, "Playlist reload interval for -validate-playlists")
+	flag.Float64Var(&cfg.PlaylistRefresh, "playlist-refresh", cfg.PlaylistRefresh, "Fetch each client's media playlists every N x the target duration (0 < N < 1, e.g. 0.5) to load playlists harder than segments; FFmpeg reloads once per target duration and the swarm adds the rest")
+	flag.BoolVar(&cfg.ConditionalPlaylists, "conditional-playlists", cfg.ConditionalPlaylists,
+		"Make the -playlist-refresh fetches conditional (If-None-Match / If-Modified-Since), as players with a cache reload, and count the 304s")
+
 	// Network / Testing
 	flag.StringVar(&cfg.ResolveIP, "resolve", cfg.ResolveIP, "Connect to this IP (requires --dangerous)")
 	flag.BoolVar(&cfg.NoCache, "no-cache", cfg.NoCache, "Add no-cache headers (bypass CDN cache)")
-	flag.Var(&headers, "header", "Add custom HTTP header (can repeat)")
+	flag.BoolVar(&cfg.NoKeepAlive, "no-keepalive", cfg.NoKeepAlive, "Open a new connection for every request (no HTTP keep-alive)")
+	flag.IntVar(&cfg.Prefetch, "prefetch", cfg.Prefetch, "Segments each client downloads ahead of the one it is reading, on a second connection: 0 (one at a time) or 1, as aggressive players do (-1 = FFmpeg's default: 1 for HTTP/1.1 origins)")
+	flag.StringVar(&cfg.TokenURL, "token-url", cfg.TokenURL, "Fetch a session token from this URL (may use {client_id}, {seq}, {uuid}) on every client start, for {token} in -header values; HTTP 401 restarts the client with a new token")
+	flag.DurationVar(&cfg.TokenTTL, "token-ttl", cfg.TokenTTL, "Expire each client's session token this long after its process started: the client restarts with a new one, as on a 401 (0 = only on 401; needs -token-url)")
+	flag.StringVar(&cfg.AcceptEncoding, "accept-encoding", cfg.AcceptEncoding, `Accept-Encoding to send, e.g. "gzip", "gzip, deflate, br" or "identity" ("" = client default); -validate-playlists reports what the origin serves`)
+	flag.Var(&headers, "header", "Add custom HTTP header (can repeat); values may use {client_id}, {seq}, {uuid} and {timestamp}, set each time a client process starts")
+	flag.Func("geo", `Emulate clients from a geo/ISP: name[:weight]=Header: value (can repeat; repeat a name for more headers, e.g. -geo "eu:2=X-Forwarded-For: 81.2.69.{client_id}"). Clients are spread over the geos by weight and latency/errors are reported per geo`, func(s string) error {
+		geos, err := AddGeo(cfg.Geos, s)
+		if err != nil {
+			return err
+		}
+		cfg.Geos = geos
+		return nil
+	})
 
 	// Safety & Diagnostics (double-dash convention)
 	flag.BoolVar(&cfg.DangerousMode, "dangerous", cfg.DangerousMode, "Required for -resolve (disables TLS verification)")
 	flag.BoolVar(&cfg.PrintCmd, "print-cmd", cfg.PrintCmd, "Print FFmpeg command and exit")
+	flag.BoolVar(&cfg.Plan, "plan", cfg.Plan, "Print the full launch plan (ramp, per-client args, expected load) and exit")
+	flag.IntVar(&cfg.ExpectedBitrate, "expected-bitrate", cfg.ExpectedBitrate, "Assumed per-client bitrate in kbps for --plan bandwidth estimates")
 	flag.BoolVar(&cfg.Check, "check", cfg.Check, "Validate config and run 1 client for 10 seconds")
+	flag.BoolVar(&cfg.Strict, "strict", cfg.Strict, "Treat configuration warnings (settings that likely hurt the run) as errors")
+	flag.BoolVar(&cfg.ConnProbe, "conn-probe", cfg.ConnProbe, "Instead of the swarm, find the origin's connection limit: ramp concurrent short requests, each on a new connection, and report where the origin starts refusing them, then exit")
+	flag.IntVar(&cfg.ConnProbeStep, "conn-probe-step", cfg.ConnProbeStep, "Concurrent connections added per --conn-probe step")
+	flag.IntVar(&cfg.ConnProbeMax, "conn-probe-max", cfg.ConnProbeMax, "Concurrent connections --conn-probe stops at if the origin hasn't refused by then")
+	flag.DurationVar(&cfg.ConnProbeStepDuration, "conn-probe-step-duration", cfg.ConnProbeStepDuration, "How long each --conn-probe step lasts")
+	flag.BoolVar(&cfg.PlaylistStress, "playlist-stress", cfg.PlaylistStress, "Instead of the swarm, size playlist serving: lightweight workers reload the media playlists only, for -duration (or until Ctrl+C), each with its own connection and an X-Swarm-Worker header (or -header's {client_id}), then exit")
+	flag.IntVar(&cfg.PlaylistStressWorkers, "playlist-stress-workers", cfg.PlaylistStressWorkers, "Workers for --playlist-stress")
+	flag.Float64Var(&cfg.PlaylistStressRate, "playlist-stress-rate", cfg.PlaylistStressRate, "Playlist requests per second over all --playlist-stress workers (0 = as fast as the origin answers)")
 	flag.BoolVar(&cfg.SkipPreflight, "skip-preflight", cfg.SkipPreflight, "Skip preflight checks")
+	flag.Float64Var(&cfg.ClientCPULimit, "client-cpu-limit", cfg.ClientCPULimit, "CPU use, in percent of one core, above which a client counts as decoding rather than just demuxing (0 = don't check). Linux only")
+	flag.StringVar(&cfg.ClientCPUPolicy, "client-cpu-policy", cfg.ClientCPUPolicy, `What to do when a client exceeds -client-cpu-limit: "warn" or "fail" (stop the run)`)
+	flag.IntVar(&cfg.MaxMemoryMB, "max-memory", cfg.MaxMemoryMB, "Swarm RSS in MB above which it sheds features in order: per-client metrics, parser sample rings, in-memory history (0 = off). Linux only")
+	flag.BoolVar(&cfg.KillOrphans, "kill-orphans", cfg.KillOrphans, "Kill FFmpeg processes left behind by crashed runs at startup (they are always reported)")
+
+	// Packet capture
+	flag.StringVar(&cfg.PcapDir, "pcap-dir", cfg.PcapDir, `Capture origin traffic with tcpdump into this directory and write one pcap per sampled client at exit ("" = off; Linux, needs capture privileges)`)
+	flag.IntVar(&cfg.PcapClients, "pcap-clients", cfg.PcapClients, "Clients sampled for -pcap-dir, spread evenly over the client IDs")
+	flag.IntVar(&cfg.PcapSnapLen, "pcap-snaplen", cfg.PcapSnapLen, "Bytes captured per packet (0 = whole packets)")
+	flag.IntVar(&cfg.PcapFileMB, "pcap-file-mb", cfg.PcapFileMB, "Rotate the capture ring file at this size (MB)")
+	flag.IntVar(&cfg.PcapFiles, "pcap-files", cfg.PcapFiles, "Capture ring files kept; older traffic is overwritten")
+
+	// Fault injection
+	flag.DurationVar(&cfg.FlapInterval, "flap-interval", cfg.FlapInterval,
+		"Every interval, pause -flap-clients random clients (SIGSTOP) for -flap-duration to emulate a network flap, then measure their catch-up (0 = off; needs -stats)")
+	flag.DurationVar(&cfg.FlapDuration, "flap-duration", cfg.FlapDuration, "How long a flap pauses its clients")
+	flag.IntVar(&cfg.FlapClients, "flap-clients", cfg.FlapClients, "Clients paused per flap")
+
+	// Traffic bursts
+	flag.Func("burst", `Layer a traffic burst on the baseline: "every 5m: +200 clients for 30s" (can repeat). The extra clients start at -ramp-rate and are stopped when the burst ends; burst windows are annotated in metrics, -save-run and the exit summary`, func(s string) error {
+		b, err := ParseBurst(s)
+		if err != nil {
+			return err
+		}
+		cfg.Bursts = append(cfg.Bursts, b)
+		return nil
+	})
 
 	// Observability
-	flag.StringVar(&cfg.MetricsAddr, "metrics", cfg.MetricsAddr, "Prometheus metrics address")
+	flag.Func("metrics", `Comma-separated Prometheus listen addresses: host:port (":0" = random port, shown in the banner) or unix:/path (default `+strings.Join(cfg.MetricsAddrs, ",")+`)`, func(s string) error {
+		cfg.MetricsAddrs = nil
+		for _, addr := range strings.Split(s, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				cfg.MetricsAddrs = append(cfg.MetricsAddrs, addr)
+			}
+		}
+		return nil
+	})
 	flag.BoolVar(&cfg.Verbose, "v", cfg.Verbose, "Verbose logging")
-	flag.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, `Log format: "json" or "text"`)
+	flag.StringVar(&cfg.LogFormat, "log-format", cfg.LogFormat, `Log format: "json", "logfmt" or "text"; every record carries run_id and phase`)
+	flag.DurationVar(&cfg.LogDedupWindow, "log-dedup-window", cfg.LogDedupWindow,
+		"Log a repeated warning or error once per window, then a count of the suppressed repeats (0 = log every one)")
+	flag.StringVar(&cfg.PushgatewayURL, "pushgateway-url", cfg.PushgatewayURL, "Push final metrics to this Prometheus Pushgateway at exit (e.g., http://pushgateway:9091)")
+	flag.StringVar(&cfg.PushgatewayJob, "pushgateway-job", cfg.PushgatewayJob, "Job label for -pushgateway-url")
+	flag.Func("pushgateway-labels", "Comma-separated key=value grouping labels for -pushgateway-url, e.g. run=$CI_PIPELINE_ID (instance defaults to the hostname)", func(s string) error {
+		cfg.PushgatewayLabels = nil
+		for _, label := range strings.Split(s, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				cfg.PushgatewayLabels = append(cfg.PushgatewayLabels, label)
+			}
+		}
+		return nil
+	})
+	flag.StringVar(&cfg.EventSink, "event-sink", cfg.EventSink,
+		`Stream the clients' parsed debug events and lifecycle events (starts, exits, restarts, phases) as they happen: "loki" or "kafka" (through a Kafka REST Proxy)`)
+	flag.StringVar(&cfg.EventSinkURL, "event-sink-url", cfg.EventSinkURL, "Base URL of -event-sink: Loki (e.g., http://loki:3100) or the Kafka REST Proxy (e.g., http://kafka-rest:8082)")
+	flag.StringVar(&cfg.EventSinkTopic, "event-sink-topic", cfg.EventSinkTopic, "Kafka topic for -event-sink kafka")
+	flag.Func("event-sink-labels", "Comma-separated key=value labels for -event-sink: Loki stream labels (job defaults to hls_swarm, instance to the hostname), or \"labels\" of each Kafka record", func(s string) error {
+		cfg.EventSinkLabels = nil
+		for _, label := range strings.Split(s, ",") {
+			if label = strings.TrimSpace(label); label != "" {
+				cfg.EventSinkLabels = append(cfg.EventSinkLabels, label)
+			}
+		}
+		return nil
+	})
+	flag.BoolVar(&cfg.EventSinkDebug, "event-sink-debug", cfg.EventSinkDebug, "Stream the parsed debug events with -event-sink, not only lifecycle events (needs -stats)")
+	flag.BoolVar(&cfg.SaveRun, "save-run", cfg.SaveRun, "Append this run's summary to -runs-file at exit (browse with: go-ffmpeg-hls-swarm runs list)")
+	flag.StringVar(&cfg.RunsFile, "runs-file", cfg.RunsFile, "Run history file for -save-run (gzip-compressed if it ends in .gz)")
 
 	// FFmpeg
 	flag.StringVar(&cfg.FFmpegPath, "ffmpeg", cfg.FFmpegPath, "Path to FFmpeg binary")
@@ -118,28 +328,99 @@ Examples:
 	flag.BoolVar(&cfg.Reconnect, "reconnect", cfg.Reconnect, "Enable FFmpeg reconnect flags")
 	flag.IntVar(&cfg.ReconnectDelayMax, "reconnect-delay", cfg.ReconnectDelayMax, "Max reconnect delay in seconds")
 	flag.IntVar(&cfg.SegMaxRetry, "seg-retry", cfg.SegMaxRetry, "Segment download retry count")
+	flag.Func("ffmpeg-extra-args", `Extra FFmpeg input options, e.g. "-http_seekable 0 -max_reload 100" (split on spaces; can repeat). Options that decode, use the GPU or duplicate a swarm flag are refused`, func(s string) error {
+		cfg.FFmpegExtraArgs = append(cfg.FFmpegExtraArgs, strings.Fields(s)...)
+		return nil
+	})
+	flag.StringVar(&cfg.StopSignal, "stop-signal", cfg.StopSignal, `Signal that stops FFmpeg: "TERM", "INT", "QUIT" or "HUP"`)
+	flag.DurationVar(&cfg.StopGrace, "stop-grace", cfg.StopGrace, "Time FFmpeg gets to exit after -stop-signal before it is killed (SIGKILL)")
 
 	// Health / Stall Detection
 	flag.DurationVar(&cfg.TargetDuration, "target-duration", cfg.TargetDuration, "Expected HLS segment duration for stall detection")
 	flag.BoolVar(&cfg.RestartOnStall, "restart-on-stall", cfg.RestartOnStall, "Kill and restart stalled clients")
+	flag.DurationVar(&cfg.CapacityP95, "capacity-p95", cfg.CapacityP95, "Segment wall time P95 at which the origin counts as saturated; the ramp projects the client count that reaches it (0 = -target-duration; needs -stats)")
+	flag.StringVar(&cfg.SLO, "slo", cfg.SLO,
+		`Segment latency SLO as TARGET:THRESHOLD, e.g. "99:1s" = 99% of segments under 1s; shows compliance and error budget burn rate live and in the report; a run missing it fails (needs -stats)`)
+
+	// Restarts
+	flag.StringVar(&cfg.BackoffPreset, "backoff-preset", cfg.BackoffPreset,
+		"Restart backoff of failed clients: "+strings.Join(BackoffPresetNames, ", ")+` ("default": 250ms growing to 5s; "aggressive": 50ms to 1s; "gentle": 2s to 1m; "none": immediately)`)
+	flag.Func("backoff-on", `Restart backoff by why a client failed: CAUSE=PRESET, CAUSE being http-404, http-5xx (any status or class), reset, refused, timeout, dns, tls or exit-N; e.g. -backoff-on http-404=gentle -backoff-on reset=aggressive (can repeat; HTTP and network causes need -stats)`, func(s string) error {
+		return AddBackoffOn(cfg, s)
+	})
+	flag.IntVar(&cfg.QuarantineFailures, "quarantine-after", cfg.QuarantineFailures,
+		"Park a client that fails this many times within -quarantine-window for -quarantine-cooldown, instead of restarting it endlessly (0 = off)")
+	flag.DurationVar(&cfg.QuarantineWindow, "quarantine-window", cfg.QuarantineWindow, "Window in which -quarantine-after failures quarantine a client")
+	flag.DurationVar(&cfg.QuarantineCooldown, "quarantine-cooldown", cfg.QuarantineCooldown, "How long a quarantined client is parked before it is restarted")
 
 	// Stats Collection
 	flag.BoolVar(&cfg.StatsEnabled, "stats", cfg.StatsEnabled, "Enable FFmpeg output parsing for detailed stats")
 	flag.StringVar(&cfg.StatsLogLevel, "stats-loglevel", cfg.StatsLogLevel, `FFmpeg loglevel for stats: "verbose" or "debug"`)
+	flag.StringVar(&cfg.FFmpegLogDialect, "ffmpeg-log-dialect", cfg.FFmpegLogDialect,
+		`How FFmpeg lays out its log lines: "ffmpeg6" (6.x and older, no timestamps), "ffmpeg7" (7.x and later) or "auto" (from ffmpeg -version and the log)`)
+	flag.StringVar(&cfg.ProgressMode, "progress-mode", cfg.ProgressMode,
+		`Where FFmpeg's -progress output is read from: "pipe" (FD 3), "socket" (a Unix socket per client, in TMPDIR) or "off" (no speed, drift or progress bytes; stderr events are still parsed)`)
 	flag.IntVar(&cfg.StatsBufferSize, "stats-buffer", cfg.StatsBufferSize, "Lines to buffer per client (increase if seeing drops)")
+	flag.IntVar(&cfg.StatsMaxLineLength, "stats-max-line", cfg.StatsMaxLineLength, "Longest FFmpeg output line parsed, in bytes; longer lines are truncated and counted")
+	flag.IntVar(&cfg.StatsRetention, "stats-retention", cfg.StatsRetention, "Max history samples (client uptimes) kept in memory; older ones are downsampled")
+	flag.StringVar(&cfg.StatsSpillDir, "stats-spill-dir", cfg.StatsSpillDir, "Write full-resolution history here so long soaks get exit-summary percentiles over every sample (near-exact, through a t-digest) rather than a downsampled set")
+	flag.BoolVar(&cfg.StatsSpillCompress, "stats-spill-compress", cfg.StatsSpillCompress, "Gzip the -stats-spill-dir history as it is written (a fraction of the disk, more CPU for exit-summary percentiles)")
+	flag.StringVar(&cfg.StatsStdout, "stats-stdout", cfg.StatsStdout,
+		`Write aggregate snapshots to stdout: "ndjson" (one JSON object per interval; other output moves to stderr)`)
+	flag.DurationVar(&cfg.StatsInterval, "stats-interval", cfg.StatsInterval, "Snapshot interval for -stats-stdout, and of the metrics timeline -save-run records")
+	flag.DurationVar(&cfg.StatsAggregateInterval, "stats-aggregate-interval", cfg.StatsAggregateInterval,
+		"How often per-client stats are aggregated; the dashboard and Prometheus read the latest aggregate")
+	flag.DurationVar(&cfg.SlowRequestLog, "slow-request-log", cfg.SlowRequestLog, "Log and count segment/manifest downloads taking at least this long (0 = off)")
+	flag.BoolVar(&cfg.SocketStats, "socket-stats", cfg.SocketStats,
+		"Sample RTT, retransmits and throughput of the clients' TCP connections from the kernel every -stats-aggregate-interval (Linux)")
+	flag.BoolVar(&cfg.CDNDetect, "cdn-detect", cfg.CDNDetect,
+		"Tell CDN edge from origin responses by their headers (Via, Server, X-Cache...) and break down the metrics by which served them (logs FFmpeg at trace level)")
+	flag.Float64Var(&cfg.OriginHitAlert, "origin-hit-alert", cfg.OriginHitAlert,
+		"With -cdn-detect, flag the run when more than this percentage of responses behind a CDN came from the origin (0 = off)")
 	// Note: stats-drop-threshold is intentionally not documented (hidden advanced flag)
 	flag.Float64Var(&cfg.StatsDropThreshold, "stats-drop-threshold", cfg.StatsDropThreshold, "")
 
+	// Origin Logs
+	flag.BoolVar(&cfg.TagRequests, "tag-requests", cfg.TagRequests,
+		`Add a unique run tag to every User-Agent ("go-ffmpeg-hls-swarm/1.0/run-1a2b3c4d/client-42") so the origin's logs can tell this run's requests apart`)
+	flag.StringVar(&cfg.OriginLog, "origin-log", cfg.OriginLog,
+		"After the run, read the origin's access log (a file, or a Loki URL such as http://loki:3100) for this run's tagged requests and report the origin's status and request times against the clients' latency (implies -tag-requests; needs $request_time in the log)")
+	flag.StringVar(&cfg.OriginLogQuery, "origin-log-query", cfg.OriginLogQuery, `Loki stream selector of the origin's access log, e.g. {job="nginx"} (with a Loki -origin-log)`)
+
 	// Debug logging (FD mode is always enabled when stats are enabled)
 	flag.BoolVar(&cfg.DebugLogging, "ffmpeg-debug", cfg.DebugLogging,
 		"Enable FFmpeg -loglevel debug for detailed segment timing (safe with FD-based progress)")
+	flag.Func("debug-sample", "Run this percentage of the clients (e.g. 1%), picked at random, at FFmpeg debug loglevel with every parsed event logged, and the rest at verbose: full traces of a few clients without the cost of debug output from all (needs -stats)", func(s string) error {
+		p, err := ParsePercent(s)
+		cfg.DebugSample = p
+		return err
+	})
 
 	// TUI (Terminal User Interface)
 	flag.BoolVar(&cfg.TUIEnabled, "tui", cfg.TUIEnabled, "Enable live terminal dashboard (default: true, use -tui=false to disable)")
+	flag.Func("tui-panels", "Comma-separated TUI sections: "+strings.Join(TUIPanelNames, ",")+" (default: last saved layout)", func(s string) error {
+		cfg.TUIPanels = nil
+		for _, name := range strings.Split(s, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.TUIPanels = append(cfg.TUIPanels, name)
+			}
+		}
+		return nil
+	})
+	flag.StringVar(&cfg.TUITheme, "tui-theme", cfg.TUITheme,
+		`TUI theme: "default", "high-contrast" (color-blind safe), "monochrome", "ascii" (no emoji/box glyphs)`)
+	flag.DurationVar(&cfg.TUIRefreshInterval, "tui-refresh", cfg.TUIRefreshInterval, "TUI redraw interval (raise on slow terminals; does not affect stats collection)")
+	flag.BoolVar(&cfg.StatusLine, "status-line", cfg.StatusLine,
+		"Print a compact one-line status instead of the TUI (rewritten in place on a terminal, appended in CI logs)")
+	flag.DurationVar(&cfg.StatusInterval, "status-interval", cfg.StatusInterval, "Status line interval (0 = 1s on a terminal, 10s otherwise)")
+	flag.StringVar(&cfg.TUIPrefsPath, "tui-prefs", cfg.TUIPrefsPath, `TUI layout file, saved on exit and restored on start ("" = don't persist)`)
 
 	// Prometheus
 	flag.BoolVar(&cfg.PromClientMetrics, "prom-client-metrics", cfg.PromClientMetrics,
-		"Enable per-client Prometheus metrics (WARNING: high cardinality, use with <200 clients)")
+		"Enable per-client Prometheus metrics (WARNING: high cardinality; capped by -prom-client-metrics-max)")
+	flag.IntVar(&cfg.PromClientMetricsMax, "prom-client-metrics-max", cfg.PromClientMetricsMax,
+		"Above this many clients, per-client metrics are aggregated into this many buckets by client_id (0 = no cap)")
+	flag.DurationVar(&cfg.MetricsUpdateInterval, "metrics-update-interval", cfg.MetricsUpdateInterval, "How often aggregated stats are copied into the Prometheus metrics")
 
 	// Origin Metrics
 	flag.StringVar(&cfg.OriginMetricsURL, "origin-metrics", cfg.OriginMetricsURL,
@@ -180,12 +461,23 @@ Examples:
 		"Number of recent segments to keep in cache. "+
 			"Keeps exactly N segments [highest-N+1, highest]. Default: 300.")
 
-	// Parse
-	flag.Parse()
+	// Parse. Not flag.Parse, whose ExitOnError exits with 2 on a bad
+	// flag: that is the threshold exit code, a bad flag is a config error.
+	// -help returns flag.ErrHelp.
+	flag.CommandLine.Init(flag.CommandLine.Name(), flag.ContinueOnError)
+	if err := flag.CommandLine.Parse(os.Args[1:]); err != nil {
+		return nil, err
+	}
 
 	// Copy headers
 	cfg.Headers = headers
 
+	// The status line and NDJSON snapshots are alternative renderers:
+	// never draw the TUI over them
+	if cfg.StatusLine || cfg.StatsStdout != "" {
+		cfg.TUIEnabled = false
+	}
+
 	// Positional argument: stream URL
 	args := flag.Args()
 	if len(args) >= 1 {
@@ -219,8 +511,9 @@ func flagType(f *flag.Flag) string {
 		return ""
 	}
 
-	// Check if it looks like a duration
-	if strings.HasSuffix(f.DefValue, "s") || strings.HasSuffix(f.DefValue, "m") || strings.HasSuffix(f.DefValue, "h") {
+	// Check if it's a duration, not just ending in s, m or h as names
+	// like hls_swarm do
+	if _, err := time.ParseDuration(f.DefValue); err == nil && f.DefValue != "0" {
 		return "duration"
 	}
 
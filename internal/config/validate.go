@@ -3,28 +3,41 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net/url"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
 )
 
 // ValidationError represents a configuration validation error.
 type ValidationError struct {
 	Field   string
 	Message string
+
+	// How to fix it, when Message doesn't already say ("" = none)
+	Suggestion string
 }
 
 func (e ValidationError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.Field, e.Message, e.Suggestion)
+	}
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
 // Validate checks the configuration for errors and inconsistencies.
-// Returns nil if valid, or an error describing the problem.
+// Returns nil if valid, or an error describing the problem. With -strict,
+// Warnings are errors too.
 func Validate(cfg *Config) error {
 	var errs []error
 
-	// Stream URL is required (unless --print-cmd without URL)
-	if cfg.StreamURL == "" && !cfg.PrintCmd {
+	// Stream URL is required (unless --print-cmd/--plan without URL)
+	if cfg.StreamURL == "" && !cfg.PrintCmd && !cfg.Plan {
 		errs = append(errs, ValidationError{
 			Field:   "stream_url",
 			Message: "HLS stream URL is required",
@@ -49,6 +62,13 @@ func Validate(cfg *Config) error {
 		})
 	}
 
+	if cfg.CoolDown < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "cool_down",
+			Message: "must be >= 0",
+		})
+	}
+
 	// Ramp rate must be positive
 	if cfg.RampRate < 1 {
 		errs = append(errs, ValidationError{
@@ -68,6 +88,26 @@ func Validate(cfg *Config) error {
 		})
 	}
 
+	switch cfg.FFmpegLogDialect {
+	case "auto", "ffmpeg6", "ffmpeg7":
+	default:
+		errs = append(errs, ValidationError{
+			Field:      "ffmpeg_log_dialect",
+			Message:    fmt.Sprintf("must be one of: auto, ffmpeg6, ffmpeg7 (got %q)", cfg.FFmpegLogDialect),
+			Suggestion: "use ffmpeg6 for FFmpeg 6.x and older, ffmpeg7 for 7.x and later",
+		})
+	}
+
+	switch cfg.ProgressMode {
+	case "pipe", "socket", "off":
+	default:
+		errs = append(errs, ValidationError{
+			Field:      "progress_mode",
+			Message:    fmt.Sprintf("must be one of: pipe, socket, off (got %q)", cfg.ProgressMode),
+			Suggestion: "use off to skip progress parsing and keep only the stderr events",
+		})
+	}
+
 	// -resolve requires --dangerous
 	if cfg.ResolveIP != "" && !cfg.DangerousMode {
 		errs = append(errs, ValidationError{
@@ -95,12 +135,360 @@ func Validate(cfg *Config) error {
 		})
 	}
 
+	// CPU affinity policy must be valid
+	validAffinity := map[string]bool{"none": true, "core": true, "numa": true}
+	if !validAffinity[cfg.CPUAffinity] {
+		errs = append(errs, ValidationError{
+			Field:   "cpu_affinity",
+			Message: fmt.Sprintf("must be one of: none, core, numa (got %q)", cfg.CPUAffinity),
+		})
+	}
+
+	// TUI panel names must be known (see tui.AllPanels)
+	for _, name := range cfg.TUIPanels {
+		if !slices.Contains(TUIPanelNames, name) {
+			errs = append(errs, ValidationError{
+				Field:   "tui_panels",
+				Message: fmt.Sprintf("unknown panel %q (valid: %s)", name, strings.Join(TUIPanelNames, ", ")),
+			})
+		}
+	}
+
+	// TUI theme must be known (see tui.ValidThemes)
+	validThemes := map[string]bool{"default": true, "high-contrast": true, "monochrome": true, "ascii": true}
+	if !validThemes[cfg.TUITheme] {
+		errs = append(errs, ValidationError{
+			Field:   "tui_theme",
+			Message: fmt.Sprintf("must be one of: default, high-contrast, monochrome, ascii (got %q)", cfg.TUITheme),
+		})
+	}
+
+	if cfg.StatusInterval < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "status_interval",
+			Message: "must be >= 0",
+		})
+	}
+
+	if cfg.StatsStdout != "" {
+		if cfg.StatsStdout != "ndjson" {
+			errs = append(errs, ValidationError{
+				Field:   "stats_stdout",
+				Message: fmt.Sprintf("must be 'ndjson' or empty (got %q)", cfg.StatsStdout),
+			})
+		}
+	}
+
+	// Paces both -stats-stdout snapshots and the -save-run timeline
+	if (cfg.StatsStdout != "" || cfg.SaveRun) && cfg.StatsInterval <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "stats_interval",
+			Message: "must be > 0",
+		})
+	}
+
+	if cfg.SlowRequestLog < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "slow_request_log",
+			Message: "must be >= 0",
+		})
+	}
+	if cfg.SlowRequestLog > 0 && !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{
+			Field:   "slow_request_log",
+			Message: "requires stats collection (-stats)",
+		})
+	}
+
+	if cfg.CDNDetect && !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{
+			Field:   "cdn_detect",
+			Message: "requires stats collection (-stats)",
+		})
+	}
+	if cfg.DebugSample < 0 || cfg.DebugSample > 100 {
+		errs = append(errs, ValidationError{
+			Field:   "debug_sample",
+			Message: "must be between 0 and 100",
+		})
+	}
+	if cfg.DebugSample > 0 && !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{
+			Field:   "debug_sample",
+			Message: "requires stats collection (-stats)",
+		})
+	}
+	if cfg.DebugSample > 0 && cfg.DebugLogging {
+		errs = append(errs, ValidationError{
+			Field:      "debug_sample",
+			Message:    "conflicts with -ffmpeg-debug, which runs every client at debug",
+			Suggestion: "drop -ffmpeg-debug to run only the sample at debug",
+		})
+	}
+	if cfg.OriginHitAlert < 0 || cfg.OriginHitAlert > 100 {
+		errs = append(errs, ValidationError{
+			Field:   "origin_hit_alert",
+			Message: "must be between 0 and 100",
+		})
+	}
+
+	// Metrics listeners: at least one, each a host:port or unix:/path
+	if len(cfg.MetricsAddrs) == 0 {
+		errs = append(errs, ValidationError{
+			Field:   "metrics_addrs",
+			Message: "at least one address is required",
+		})
+	}
+	for i, addr := range cfg.MetricsAddrs {
+		if err := metrics.ValidateAddr(addr); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   "metrics_addrs",
+				Message: fmt.Sprintf("%q: %v", addr, err),
+			})
+		} else if slices.Contains(cfg.MetricsAddrs[:i], addr) {
+			errs = append(errs, ValidationError{
+				Field:   "metrics_addrs",
+				Message: fmt.Sprintf("%q listed twice", addr),
+			})
+		}
+	}
+
+	errs = append(errs, validateTenants(cfg)...)
+	errs = append(errs, validateGeos(cfg)...)
+	errs = append(errs, validateVariantMix(cfg)...)
+	errs = append(errs, validateRenditions(cfg)...)
+	errs = append(errs, validateCompare(cfg)...)
+	errs = append(errs, validatePcap(cfg)...)
+	errs = append(errs, validateOriginLog(cfg)...)
+	errs = append(errs, validateFlaps(cfg)...)
+	errs = append(errs, validateBursts(cfg)...)
+	errs = append(errs, validateConnProbe(cfg)...)
+	errs = append(errs, validatePlaylistStress(cfg)...)
+	errs = append(errs, validateEventSink(cfg)...)
+	errs = append(errs, validateClientProcess(cfg)...)
+
+	// Stats pipeline intervals: each runs on its own ticker
+	for _, iv := range []struct {
+		field string
+		value time.Duration
+	}{
+		{"stats_aggregate_interval", cfg.StatsAggregateInterval},
+		{"metrics_update_interval", cfg.MetricsUpdateInterval},
+		{"tui_refresh_interval", cfg.TUIRefreshInterval},
+	} {
+		if iv.value <= 0 {
+			errs = append(errs, ValidationError{
+				Field:   iv.field,
+				Message: "must be > 0",
+			})
+		}
+	}
+
+	if cfg.LogDedupWindow < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "log_dedup_window",
+			Message: "must be >= 0 (0 = no deduplication)",
+		})
+	}
+
+	if cfg.PromClientMetricsMax < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "prom_client_metrics_max",
+			Message: "must be >= 0 (0 = no cap)",
+		})
+	}
+
+	if cfg.SaveRun && cfg.RunsFile == "" {
+		errs = append(errs, ValidationError{
+			Field:   "runs_file",
+			Message: "is required with -save-run (no default: user config directory unknown)",
+		})
+	}
+
+	// Pushgateway: a valid URL, a job name and key=value grouping labels
+	if cfg.PushgatewayURL != "" {
+		if err := validateURL(cfg.PushgatewayURL); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   "pushgateway_url",
+				Message: err.Error(),
+			})
+		}
+		if cfg.PushgatewayJob == "" {
+			errs = append(errs, ValidationError{
+				Field:   "pushgateway_job",
+				Message: "is required with -pushgateway-url",
+			})
+		}
+	} else if len(cfg.PushgatewayLabels) > 0 {
+		errs = append(errs, ValidationError{
+			Field:   "pushgateway_labels",
+			Message: "requires -pushgateway-url",
+		})
+	}
+	for _, label := range cfg.PushgatewayLabels {
+		if err := validatePushgatewayLabel(label); err != nil {
+			errs = append(errs, ValidationError{
+				Field:   "pushgateway_labels",
+				Message: fmt.Sprintf("%q: %v", label, err),
+			})
+		}
+	}
+
 	// Log format must be valid
-	validFormats := map[string]bool{"json": true, "text": true}
+	validFormats := map[string]bool{"json": true, "logfmt": true, "text": true}
 	if !validFormats[cfg.LogFormat] {
 		errs = append(errs, ValidationError{
 			Field:   "log_format",
-			Message: fmt.Sprintf("must be 'json' or 'text' (got %q)", cfg.LogFormat),
+			Message: fmt.Sprintf("must be 'json', 'logfmt' or 'text' (got %q)", cfg.LogFormat),
+		})
+	}
+
+	// Load budgets
+	if cfg.LoadBudgetRPS < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "load_budget_rps",
+			Message: "must be >= 0",
+		})
+	}
+	if cfg.LoadBudgetMbps < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "load_budget_mbps",
+			Message: "must be >= 0",
+		})
+	}
+
+	// Playlist validation reloads continuously; a zero interval would spin
+	if cfg.ValidatePlaylists && cfg.ValidatePlaylistInterval <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "validate_playlist_interval",
+			Message: "must be positive when playlist validation is enabled",
+		})
+	}
+
+	// FFmpeg's own reloads can't be slowed down, only topped up
+	if cfg.PlaylistRefresh < 0 || cfg.PlaylistRefresh >= 1 {
+		errs = append(errs, ValidationError{
+			Field:   "playlist_refresh",
+			Message: fmt.Sprintf("must be between 0 and 1 (a multiple of the target duration, e.g. 0.5), got %g", cfg.PlaylistRefresh),
+		})
+	}
+	if cfg.ConditionalPlaylists {
+		if cfg.PlaylistRefresh == 0 {
+			errs = append(errs, ValidationError{
+				Field:      "conditional_playlists",
+				Message:    "applies to the swarm's own playlist fetches, which need -playlist-refresh",
+				Suggestion: "add -playlist-refresh 0.5",
+			})
+		}
+		if cfg.NoCache {
+			// -no-cache plays a player without a cache: nothing to revalidate
+			errs = append(errs, ValidationError{
+				Field:   "conditional_playlists",
+				Message: "can't be combined with -no-cache (a player that doesn't cache has nothing to revalidate)",
+			})
+		}
+	}
+
+	if cfg.CapacityP95 < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "capacity_p95",
+			Message: fmt.Sprintf("must be non-negative (0 = the target duration), got %s", cfg.CapacityP95),
+		})
+	}
+	if cfg.SessionDuration != "" {
+		if _, err := ParseSessionDuration(cfg.SessionDuration); err != nil {
+			errs = append(errs, ValidationError{
+				Field:      "session_duration",
+				Message:    err.Error(),
+				Suggestion: "-session-duration lognormal:10m,0.8 = sessions of 10m median, most shorter, a few much longer",
+			})
+		}
+	}
+	if _, err := ParseVODStart(cfg.VODStart); err != nil {
+		errs = append(errs, ValidationError{
+			Field:      "vod_start",
+			Message:    err.Error(),
+			Suggestion: "-vod-start uniform = clients start anywhere in the asset",
+		})
+	}
+	if cfg.VODEnd != "" && cfg.VODEnd != VODEndStop && cfg.VODEnd != VODEndLoop {
+		errs = append(errs, ValidationError{
+			Field:      "vod_end",
+			Message:    fmt.Sprintf("must be 'stop' or 'loop' (got %q)", cfg.VODEnd),
+			Suggestion: "-vod-end loop = clients play the asset again each time they finish it",
+		})
+	}
+	if cfg.SLO != "" {
+		if _, _, err := ParseSLO(cfg.SLO); err != nil {
+			errs = append(errs, ValidationError{
+				Field:      "slo",
+				Message:    err.Error(),
+				Suggestion: "-slo 99:1s = 99% of segments under 1s",
+			})
+		} else if !cfg.StatsEnabled {
+			errs = append(errs, ValidationError{
+				Field:   "slo",
+				Message: "requires stats collection (-stats)",
+			})
+		}
+	}
+
+	if cfg.TokenURL != "" {
+		errs = append(errs, validateTokenURL(cfg)...)
+	}
+	if cfg.TokenTTL < 0 {
+		errs = append(errs, ValidationError{Field: "token_ttl", Message: "must be >= 0"})
+	} else if cfg.TokenTTL > 0 && cfg.TokenURL == "" {
+		errs = append(errs, ValidationError{Field: "token_ttl", Message: "requires -token-url"})
+	}
+
+	// FFmpeg's HLS demuxer opens at most the next segment early
+	// (-http_multiple), so deeper prefetch can't be modelled
+	if cfg.Prefetch < -1 || cfg.Prefetch > 1 {
+		errs = append(errs, ValidationError{
+			Field:      "prefetch",
+			Message:    fmt.Sprintf("must be 0 or 1, got %d", cfg.Prefetch),
+			Suggestion: "FFmpeg fetches at most one segment ahead; use -1 for its default",
+		})
+	}
+
+	if err := validateAcceptEncoding(cfg.AcceptEncoding); err != nil {
+		errs = append(errs, ValidationError{Field: "accept_encoding", Message: err.Error()})
+	}
+
+	if err := validateFFmpegExtraArgs(cfg.FFmpegExtraArgs); err != nil {
+		errs = append(errs, ValidationError{Field: "ffmpeg_extra_args", Message: err.Error()})
+	}
+
+	// Ordinary debug lines (long URLs, headers) run to a few hundred bytes
+	if cfg.StatsMaxLineLength < 1024 {
+		errs = append(errs, ValidationError{
+			Field:   "stats_max_line_length",
+			Message: "must be >= 1024",
+		})
+	}
+
+	// Retention below a few hundred samples makes P99 meaningless
+	if cfg.StatsRetention < 100 {
+		errs = append(errs, ValidationError{
+			Field:   "stats_retention",
+			Message: "must be >= 100",
+		})
+	}
+	if cfg.StatsSpillDir != "" {
+		if info, err := os.Stat(cfg.StatsSpillDir); err != nil || !info.IsDir() {
+			errs = append(errs, ValidationError{
+				Field:   "stats_spill_dir",
+				Message: fmt.Sprintf("%q is not a directory", cfg.StatsSpillDir),
+			})
+		}
+	}
+
+	// Expected bitrate is only an estimate input, but negative is nonsense
+	if cfg.ExpectedBitrate < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "expected_bitrate",
+			Message: "must be >= 0",
 		})
 	}
 
@@ -131,6 +519,84 @@ func Validate(cfg *Config) error {
 			Message: "must be >= 1.0",
 		})
 	}
+	if cfg.QuarantineFailures < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "quarantine_failures",
+			Message: "must be >= 0",
+		})
+	}
+	if cfg.QuarantineFailures > 0 {
+		if cfg.QuarantineWindow <= 0 {
+			errs = append(errs, ValidationError{
+				Field:   "quarantine_window",
+				Message: "must be positive",
+			})
+		}
+		if cfg.QuarantineCooldown <= 0 {
+			errs = append(errs, ValidationError{
+				Field:   "quarantine_cooldown",
+				Message: "must be positive",
+			})
+		}
+	}
+	presets := strings.Join(BackoffPresetNames, ", ")
+	if cfg.BackoffPreset != "" && !slices.Contains(BackoffPresetNames, cfg.BackoffPreset) {
+		errs = append(errs, ValidationError{
+			Field:   "backoff_preset",
+			Message: fmt.Sprintf("must be one of %s (got %q)", presets, cfg.BackoffPreset),
+		})
+	}
+	for _, cause := range slices.Sorted(maps.Keys(cfg.BackoffOn)) {
+		if !ValidBackoffCause(cause) {
+			errs = append(errs, ValidationError{
+				Field:      "backoff_on",
+				Message:    fmt.Sprintf("unknown exit cause %q", cause),
+				Suggestion: "use http-404, http-5xx, reset, refused, timeout, dns, tls or exit-N",
+			})
+		}
+		if preset := cfg.BackoffOn[cause]; !slices.Contains(BackoffPresetNames, preset) {
+			errs = append(errs, ValidationError{
+				Field:   "backoff_on",
+				Message: fmt.Sprintf("%s: preset must be one of %s (got %q)", cause, presets, preset),
+			})
+		}
+	}
+
+	// Demux-only guard
+	if cfg.ClientCPULimit < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "client_cpu_limit",
+			Message: "must be >= 0",
+		})
+	}
+	if cfg.ClientCPUPolicy != "warn" && cfg.ClientCPUPolicy != "fail" {
+		errs = append(errs, ValidationError{
+			Field:   "client_cpu_policy",
+			Message: fmt.Sprintf("must be 'warn' or 'fail' (got %q)", cfg.ClientCPUPolicy),
+		})
+	}
+
+	// Memory guard
+	if cfg.MaxMemoryMB < 0 {
+		errs = append(errs, ValidationError{
+			Field:   "max_memory_mb",
+			Message: "must be >= 0",
+		})
+	}
+
+	// Stop policy
+	if _, err := ParseStopSignal(cfg.StopSignal); err != nil {
+		errs = append(errs, ValidationError{
+			Field:   "stop_signal",
+			Message: "must be TERM, INT, QUIT or HUP",
+		})
+	}
+	if cfg.StopGrace <= 0 {
+		errs = append(errs, ValidationError{
+			Field:   "stop_grace",
+			Message: "must be positive",
+		})
+	}
 
 	// Origin metrics window validation (if origin metrics are enabled)
 	if cfg.OriginMetricsURL != "" || cfg.NginxMetricsURL != "" {
@@ -157,6 +623,16 @@ func Validate(cfg *Config) error {
 		}
 	}
 
+	if cfg.Strict {
+		for _, w := range Warnings(cfg) {
+			errs = append(errs, ValidationError{
+				Field:      w.Field,
+				Message:    w.Message + " [-strict]",
+				Suggestion: w.Suggestion,
+			})
+		}
+	}
+
 	// Return combined errors
 	if len(errs) > 0 {
 		return errors.Join(errs...)
@@ -210,3 +686,586 @@ func ApplyCheckMode(cfg *Config) {
 	cfg.Duration = 10 * 1e9 // 10 seconds in nanoseconds
 	cfg.Verbose = true
 }
+
+// validateTenants checks -tenants: unique non-empty names, positive client
+// counts adding up to -clients, and non-negative quotas. Per-tenant
+// reporting needs stats collection.
+func validateTenants(cfg *Config) []error {
+	if len(cfg.Tenants) == 0 {
+		return nil
+	}
+
+	var errs []error
+	seen := make(map[string]bool, len(cfg.Tenants))
+	total := 0
+	for _, t := range cfg.Tenants {
+		switch {
+		case t.Name == "":
+			errs = append(errs, ValidationError{Field: "tenants", Message: "tenant name is empty"})
+		case seen[t.Name]:
+			errs = append(errs, ValidationError{Field: "tenants", Message: fmt.Sprintf("duplicate tenant %q", t.Name)})
+		}
+		seen[t.Name] = true
+		if t.Clients < 1 {
+			errs = append(errs, ValidationError{Field: "tenants", Message: fmt.Sprintf("tenant %q: clients must be at least 1", t.Name)})
+		}
+		if t.MaxRPS < 0 {
+			errs = append(errs, ValidationError{Field: "tenants", Message: fmt.Sprintf("tenant %q: max_rps must be >= 0", t.Name)})
+		}
+		total += t.Clients
+	}
+	if total != cfg.Clients {
+		errs = append(errs, ValidationError{
+			Field:   "tenants",
+			Message: fmt.Sprintf("tenant clients add up to %d but -clients is %d", total, cfg.Clients),
+		})
+	}
+	if !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{Field: "tenants", Message: "requires stats collection (-stats)"})
+	}
+	return errs
+}
+
+// validateGeos checks -geo: non-empty names, positive weights, "Name: value"
+// headers, and no more geos than clients. Per-geo reporting needs stats
+// collection.
+func validateGeos(cfg *Config) []error {
+	if len(cfg.Geos) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, g := range cfg.Geos {
+		if g.Name == "" {
+			errs = append(errs, ValidationError{Field: "geos", Message: "geo name is empty"})
+		}
+		if g.Weight < 1 {
+			errs = append(errs, ValidationError{Field: "geos", Message: fmt.Sprintf("geo %q: weight must be at least 1", g.Name)})
+		}
+		for _, h := range g.Headers {
+			if name, _, ok := strings.Cut(h, ":"); !ok || strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t") {
+				errs = append(errs, ValidationError{Field: "geos", Message: fmt.Sprintf("geo %q: header %q is not \"Name: value\"", g.Name, h)})
+			}
+		}
+	}
+	if len(cfg.Geos) > cfg.Clients {
+		errs = append(errs, ValidationError{
+			Field:   "geos",
+			Message: fmt.Sprintf("%d geos but only %d clients", len(cfg.Geos), cfg.Clients),
+		})
+	}
+	if !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{Field: "geos", Message: "requires stats collection (-stats)"})
+	}
+	return errs
+}
+
+// validateVariantMix checks -variant-mix: parseable, distinct variants with
+// positive weights, and no more variants than clients. It replaces -variant
+// and, like -geo, needs stats collection for the per-variant report.
+func validateVariantMix(cfg *Config) []error {
+	if len(cfg.VariantMix) == 0 {
+		return nil
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for _, s := range cfg.VariantMix {
+		if _, _, err := ParseVariantResolution(s.Variant); err != nil {
+			errs = append(errs, ValidationError{Field: "variant_mix", Message: err.Error()})
+		}
+		if s.Weight < 1 {
+			errs = append(errs, ValidationError{Field: "variant_mix", Message: fmt.Sprintf("variant %q: weight must be at least 1", s.Variant)})
+		}
+		if seen[strings.ToLower(s.Variant)] {
+			errs = append(errs, ValidationError{Field: "variant_mix", Message: fmt.Sprintf("variant %q listed twice", s.Variant)})
+		}
+		seen[strings.ToLower(s.Variant)] = true
+	}
+	if len(cfg.VariantMix) > cfg.Clients {
+		errs = append(errs, ValidationError{
+			Field:   "variant_mix",
+			Message: fmt.Sprintf("%d variants but only %d clients", len(cfg.VariantMix), cfg.Clients),
+		})
+	}
+	if cfg.Variant != "all" {
+		errs = append(errs, ValidationError{Field: "variant_mix", Message: fmt.Sprintf("picks each client's variant; drop -variant %s", cfg.Variant)})
+	}
+	if !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{Field: "variant_mix", Message: "requires stats collection (-stats)"})
+	}
+	return errs
+}
+
+// validateRenditions checks -renditions: parseable, and with one variant
+// from the master playlist. -variant all fetches every rendition anyway,
+// and -variant-mix points clients at media playlists, which have none.
+func validateRenditions(cfg *Config) []error {
+	if cfg.Renditions == "" {
+		return nil
+	}
+	var errs []error
+	if _, _, err := ParseRenditions(cfg.Renditions); err != nil {
+		errs = append(errs, ValidationError{
+			Field:      "renditions",
+			Message:    err.Error(),
+			Suggestion: "-renditions audio=en,subs=en = English audio and subtitles with the video",
+		})
+	}
+	if len(cfg.VariantMix) > 0 {
+		errs = append(errs, ValidationError{Field: "renditions", Message: "can't be combined with -variant-mix, whose clients fetch media playlists"})
+	} else if cfg.Variant == "all" {
+		errs = append(errs, ValidationError{
+			Field:      "renditions",
+			Message:    "-variant all already fetches every rendition",
+			Suggestion: "pick the variant with -variant highest, lowest or first",
+		})
+	}
+	return errs
+}
+
+// validateCompare checks -compare-url and -compare-opt: a second URL for
+// the same stream or B request options, and a split that leaves clients
+// in both cohorts. Features tied to the stream URL's origin (its variant
+// playlists, a -resolve IP) can't be combined with a second origin, and
+// the comparison needs stats collection.
+func validateCompare(cfg *Config) []error {
+	if cfg.CompareURL == "" && len(cfg.CompareOpts) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, opt := range cfg.CompareOpts {
+		key, _, err := ParseCompareOpt(opt)
+		if err != nil {
+			errs = append(errs, ValidationError{Field: "compare_opts", Message: err.Error()})
+		} else if key == "variant" && len(cfg.VariantMix) > 0 {
+			errs = append(errs, ValidationError{Field: "compare_opts", Message: "variant can't be combined with -variant-mix (it picks each client's variant)"})
+		}
+	}
+	secondOrigin := cfg.CompareURL != "" && cfg.CompareURL != cfg.StreamURL
+	if cfg.CompareURL != "" {
+		if err := validateURL(cfg.CompareURL); err != nil {
+			errs = append(errs, ValidationError{Field: "compare_url", Message: err.Error()})
+		} else if !secondOrigin && len(cfg.CompareOpts) == 0 {
+			errs = append(errs, ValidationError{
+				Field:      "compare_url",
+				Message:    "is the stream URL; compare against another origin",
+				Suggestion: "to compare request options on one origin, drop -compare-url and use -compare-opt",
+			})
+		}
+	}
+	if cfg.CompareSplit < 1 || cfg.CompareSplit > 99 {
+		errs = append(errs, ValidationError{
+			Field:   "compare_split",
+			Message: fmt.Sprintf("must be between 1 and 99 (percent of clients in cohort B), got %d", cfg.CompareSplit),
+		})
+	}
+	if cfg.Clients < 2 {
+		errs = append(errs, ValidationError{Field: "compare_url", Message: "needs at least 2 clients, one per cohort"})
+	}
+	if secondOrigin {
+		if len(cfg.VariantMix) > 0 {
+			errs = append(errs, ValidationError{Field: "compare_url", Message: "can't be combined with -variant-mix (its variants are the stream URL's)"})
+		}
+		if cfg.PlaylistRefresh > 0 {
+			errs = append(errs, ValidationError{Field: "compare_url", Message: "can't be combined with -playlist-refresh (it fetches the stream URL's playlists)"})
+		}
+		if cfg.ResolveIP != "" {
+			errs = append(errs, ValidationError{Field: "compare_url", Message: "can't be combined with -resolve (it pins both origins to one IP)"})
+		}
+	}
+	if !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{Field: "compare_url", Message: "requires stats collection (-stats)"})
+	}
+	return errs
+}
+
+// validatePcap checks -pcap-dir and its ring and sampling settings.
+func validatePcap(cfg *Config) []error {
+	if cfg.PcapDir == "" {
+		return nil
+	}
+
+	var errs []error
+	if info, err := os.Stat(cfg.PcapDir); err != nil || !info.IsDir() {
+		errs = append(errs, ValidationError{
+			Field:   "pcap_dir",
+			Message: fmt.Sprintf("%q is not a directory", cfg.PcapDir),
+		})
+	}
+	if cfg.PcapClients < 1 || cfg.PcapClients > cfg.Clients {
+		errs = append(errs, ValidationError{
+			Field:   "pcap_clients",
+			Message: fmt.Sprintf("must be between 1 and -clients (%d)", cfg.Clients),
+		})
+	}
+	if cfg.PcapSnapLen < 0 {
+		errs = append(errs, ValidationError{Field: "pcap_snaplen", Message: "must be >= 0"})
+	}
+	if cfg.PcapFileMB < 1 {
+		errs = append(errs, ValidationError{Field: "pcap_file_mb", Message: "must be >= 1"})
+	}
+	if cfg.PcapFiles < 1 {
+		errs = append(errs, ValidationError{Field: "pcap_files", Message: "must be >= 1"})
+	}
+	return errs
+}
+
+// validateOriginLog checks -origin-log: a readable file, or a Loki URL
+// with a stream selector to search.
+func validateOriginLog(cfg *Config) []error {
+	if cfg.OriginLog == "" {
+		if cfg.OriginLogQuery != "" {
+			return []error{ValidationError{Field: "origin_log_query", Message: "needs a Loki -origin-log"}}
+		}
+		return nil
+	}
+
+	if !cfg.OriginLogLoki() {
+		f, err := os.Open(cfg.OriginLog)
+		if err != nil {
+			return []error{ValidationError{Field: "origin_log", Message: fmt.Sprintf("can't be read: %v", err)}}
+		}
+		f.Close()
+		if cfg.OriginLogQuery != "" {
+			return []error{ValidationError{Field: "origin_log_query", Message: "is for a Loki -origin-log, not a file"}}
+		}
+		return nil
+	}
+
+	var errs []error
+	if u, err := url.Parse(cfg.OriginLog); err != nil || u.Host == "" {
+		errs = append(errs, ValidationError{Field: "origin_log", Message: fmt.Sprintf("%q is not a Loki URL", cfg.OriginLog)})
+	}
+	if q := strings.TrimSpace(cfg.OriginLogQuery); !strings.HasPrefix(q, "{") || !strings.HasSuffix(q, "}") {
+		errs = append(errs, ValidationError{
+			Field:      "origin_log_query",
+			Message:    fmt.Sprintf("must be a Loki stream selector, got %q", cfg.OriginLogQuery),
+			Suggestion: `e.g. -origin-log-query '{job="nginx"}'`,
+		})
+	}
+	return errs
+}
+
+// validateBursts checks -burst: each burst must end before the next one
+// starts. Burst clients are added beyond -clients, which tenant quotas and
+// cohort assignments don't allow (see Orchestrator.Scale).
+func validateBursts(cfg *Config) []error {
+	if len(cfg.Bursts) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, b := range cfg.Bursts {
+		switch {
+		case b.Every <= 0 || b.For <= 0:
+			errs = append(errs, ValidationError{Field: "bursts", Message: fmt.Sprintf("%q: interval and duration must be > 0", b)})
+		case b.For >= b.Every:
+			errs = append(errs, ValidationError{
+				Field:      "bursts",
+				Message:    fmt.Sprintf("%q: lasts at least as long as its interval, so it never ends", b),
+				Suggestion: "make the duration shorter than the interval",
+			})
+		}
+		if b.Clients < 1 {
+			errs = append(errs, ValidationError{Field: "bursts", Message: fmt.Sprintf("%q: must add at least 1 client", b)})
+		}
+	}
+	for _, c := range []struct {
+		flag string
+		set  bool
+	}{
+		{"-tenants", len(cfg.Tenants) > 0},
+		{"-geo", len(cfg.Geos) > 0},
+		{"-variant-mix", len(cfg.VariantMix) > 0},
+		{"-compare-url", cfg.CompareURL != ""},
+	} {
+		if c.set {
+			errs = append(errs, ValidationError{
+				Field:   "bursts",
+				Message: "can't be combined with " + c.flag + ", which assigns a fixed set of clients",
+			})
+		}
+	}
+	return errs
+}
+
+// validateFlaps checks the -flap-* network flap settings.
+func validateFlaps(cfg *Config) []error {
+	if cfg.FlapInterval < 0 {
+		return []error{ValidationError{Field: "flap_interval", Message: "must be >= 0"}}
+	}
+	if cfg.FlapInterval == 0 {
+		return nil
+	}
+
+	var errs []error
+	if cfg.FlapDuration <= 0 {
+		errs = append(errs, ValidationError{Field: "flap_duration", Message: "must be > 0"})
+	}
+	if cfg.FlapClients < 1 || cfg.FlapClients > cfg.Clients {
+		errs = append(errs, ValidationError{
+			Field:   "flap_clients",
+			Message: fmt.Sprintf("must be between 1 and -clients (%d)", cfg.Clients),
+		})
+	}
+	if !cfg.StatsEnabled {
+		// Catch-up is measured from the clients' FFmpeg output
+		errs = append(errs, ValidationError{
+			Field:   "flap_interval",
+			Message: "requires stats collection (-stats)",
+		})
+	}
+	return errs
+}
+
+// validateConnProbe checks the --conn-probe settings.
+func validateConnProbe(cfg *Config) []error {
+	if !cfg.ConnProbe {
+		return nil
+	}
+
+	var errs []error
+	if cfg.ConnProbeStep < 1 {
+		errs = append(errs, ValidationError{Field: "conn_probe_step", Message: "must be at least 1"})
+	}
+	if cfg.ConnProbeMax < cfg.ConnProbeStep {
+		errs = append(errs, ValidationError{
+			Field:   "conn_probe_max",
+			Message: fmt.Sprintf("must be at least -conn-probe-step (%d)", cfg.ConnProbeStep),
+		})
+	}
+	if cfg.ConnProbeStepDuration <= 0 {
+		errs = append(errs, ValidationError{Field: "conn_probe_step_duration", Message: "must be > 0"})
+	}
+	return errs
+}
+
+// validatePlaylistStress checks the --playlist-stress settings.
+func validatePlaylistStress(cfg *Config) []error {
+	if !cfg.PlaylistStress {
+		return nil
+	}
+
+	var errs []error
+	if cfg.ConnProbe {
+		errs = append(errs, ValidationError{Field: "playlist_stress", Message: "can't be combined with --conn-probe"})
+	}
+	if cfg.PlaylistStressWorkers < 1 {
+		errs = append(errs, ValidationError{Field: "playlist_stress_workers", Message: "must be at least 1"})
+	}
+	if cfg.PlaylistStressRate < 0 {
+		errs = append(errs, ValidationError{
+			Field:      "playlist_stress_rate",
+			Message:    "must be >= 0",
+			Suggestion: "0 lets every worker reload as fast as the origin answers",
+		})
+	}
+	return errs
+}
+
+// validateEventSink checks -event-sink: a known sink at a valid URL, a
+// Kafka topic, and key=value labels. kind and event are the per-stream
+// Loki labels of each event.
+func validateEventSink(cfg *Config) []error {
+	if cfg.EventSink == "" {
+		if cfg.EventSinkURL != "" || len(cfg.EventSinkLabels) > 0 {
+			return []error{ValidationError{Field: "event_sink", Message: "-event-sink-url and -event-sink-labels require -event-sink"}}
+		}
+		return nil
+	}
+
+	var errs []error
+	switch cfg.EventSink {
+	case "loki", "kafka":
+	default:
+		errs = append(errs, ValidationError{
+			Field:      "event_sink",
+			Message:    fmt.Sprintf("must be 'loki' or 'kafka' (got %q)", cfg.EventSink),
+			Suggestion: "Kafka is reached through a Kafka REST Proxy",
+		})
+	}
+	if cfg.EventSinkURL == "" {
+		errs = append(errs, ValidationError{Field: "event_sink_url", Message: "is required with -event-sink"})
+	} else if err := validateURL(cfg.EventSinkURL); err != nil {
+		errs = append(errs, ValidationError{Field: "event_sink_url", Message: err.Error()})
+	}
+	if cfg.EventSink == "kafka" && cfg.EventSinkTopic == "" {
+		errs = append(errs, ValidationError{Field: "event_sink_topic", Message: "is required with -event-sink kafka"})
+	}
+	for _, label := range cfg.EventSinkLabels {
+		key, err := validateLabel(label)
+		if err == nil && (key == "kind" || key == "event") {
+			err = errors.New("set per event")
+		}
+		if err != nil {
+			errs = append(errs, ValidationError{
+				Field:   "event_sink_labels",
+				Message: fmt.Sprintf("%q: %v", label, err),
+			})
+		}
+	}
+	return errs
+}
+
+// validateClientProcess checks the FFmpeg environment, priority and
+// pacing, global and per -geo cohort.
+func validateClientProcess(cfg *Config) []error {
+	var errs []error
+	check := func(who string, env []string, nice int, ionice, pacing string) {
+		for _, kv := range env {
+			if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+				errs = append(errs, ValidationError{Field: "client_env", Message: fmt.Sprintf("%s%q is not KEY=VALUE", who, kv)})
+			}
+		}
+		if nice < -20 || nice > 19 {
+			errs = append(errs, ValidationError{Field: "nice", Message: fmt.Sprintf("%smust be between -20 and 19 (got %d)", who, nice)})
+		}
+		if ionice != "" {
+			if _, _, err := ParseIONice(ionice); err != nil {
+				errs = append(errs, ValidationError{Field: "ionice", Message: who + err.Error()})
+			}
+		}
+		if _, _, err := ParsePacing(pacing); err != nil {
+			errs = append(errs, ValidationError{Field: "pacing", Message: who + err.Error()})
+		}
+	}
+
+	check("", cfg.ClientEnv, cfg.Nice, cfg.IONice, cfg.Pacing)
+	for _, g := range cfg.Geos {
+		check(fmt.Sprintf("geo %q: ", g.Name), g.Env, g.Nice, g.IONice, g.Pacing)
+	}
+	return errs
+}
+
+// validateTokenURL checks -token-url: an http(s) URL whose token some
+// header uses. Re-auth on 401 is driven by the FFmpeg debug events, so it
+// needs stats collection.
+func validateTokenURL(cfg *Config) []error {
+	var errs []error
+	if u, err := url.Parse(cfg.TokenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, ValidationError{Field: "token_url", Message: "must be an http:// or https:// URL"})
+	}
+	if strings.Contains(cfg.TokenURL, "{token}") {
+		errs = append(errs, ValidationError{Field: "token_url", Message: "can't use {token}"})
+	}
+	used := false
+	for _, h := range cfg.Headers {
+		used = used || strings.Contains(h, "{token}")
+	}
+	if !used {
+		errs = append(errs, ValidationError{Field: "token_url", Message: `no -header uses {token} (e.g. "Authorization: Bearer {token}")`})
+	}
+	if !cfg.StatsEnabled {
+		errs = append(errs, ValidationError{Field: "token_url", Message: "requires stats collection (-stats) to see 401s"})
+	}
+	return errs
+}
+
+// validateAcceptEncoding checks an Accept-Encoding value: a comma-separated
+// list of known codings, each with an optional ;q= weight.
+func validateAcceptEncoding(value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if !slices.Contains(ContentCodings, coding) {
+			return fmt.Errorf("unknown coding %q (want %s)", coding, strings.Join(ContentCodings, ", "))
+		}
+		if params == "" {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if w, err := strconv.ParseFloat(q, 64); !ok || err != nil || w < 0 || w > 1 {
+			return fmt.Errorf("coding %q: weight must be q=0..1", coding)
+		}
+	}
+	return nil
+}
+
+// deniedFFmpegArgs are the options -ffmpeg-extra-args refuses, with why.
+// The swarm must stay GPU-free and demux-only, keep its one input and null
+// output, and own the options its stats parsing and flags depend on.
+var deniedFFmpegArgs = map[string]string{
+	"-hwaccel":               "hardware decoding; the swarm only demuxes",
+	"-hwaccel_device":        "hardware decoding; the swarm only demuxes",
+	"-hwaccel_output_format": "hardware decoding; the swarm only demuxes",
+	"-init_hw_device":        "hardware decoding; the swarm only demuxes",
+	"-filter_hw_device":      "hardware decoding; the swarm only demuxes",
+	"-vaapi_device":          "hardware decoding; the swarm only demuxes",
+	"-qsv_device":            "hardware decoding; the swarm only demuxes",
+	"-c":                     "selects a decoder; the swarm only demuxes",
+	"-codec":                 "selects a decoder; the swarm only demuxes",
+	"-vcodec":                "selects a decoder; the swarm only demuxes",
+	"-acodec":                "selects a decoder; the swarm only demuxes",
+	"-scodec":                "selects a decoder; the swarm only demuxes",
+	"-vf":                    "filters decode; the swarm only demuxes",
+	"-af":                    "filters decode; the swarm only demuxes",
+	"-filter":                "filters decode; the swarm only demuxes",
+	"-filter_complex":        "filters decode; the swarm only demuxes",
+	"-lavfi":                 "filters decode; the swarm only demuxes",
+	"-i":                     "adds an input",
+	"-map":                   "changes stream selection (use -variant)",
+	"-protocol_whitelist":    "lets playlists open local files",
+	"-dump_attachment":       "writes files",
+	"-report":                "writes a log file per client",
+	"-loglevel":              "breaks stats parsing (use -stats-loglevel)",
+	"-v":                     "breaks stats parsing (use -stats-loglevel)",
+	"-progress":              "breaks stats parsing",
+	"-stats_period":          "breaks stats parsing",
+	"-nostats":               "breaks stats parsing",
+	"-user_agent":            "set by -user-agent",
+	"-headers":               "set by -header",
+	"-rw_timeout":            "set by -timeout",
+	"-seg_max_retry":         "set by -seg-retry",
+	"-http_persistent":       "set by -no-keepalive",
+	"-http_multiple":         "set by -prefetch",
+	"-tls_verify":            "set by -dangerous",
+}
+
+// validateFFmpegExtraArgs checks -ffmpeg-extra-args against
+// deniedFFmpegArgs. Stream specifiers (-c:v) don't get around it.
+func validateFFmpegExtraArgs(args []string) error {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return fmt.Errorf("%q is not an option (FFmpeg would take it as an output file)", args[0])
+	}
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, ":")
+		if reason, ok := deniedFFmpegArgs[name]; ok {
+			return fmt.Errorf("%s not allowed: %s", arg, reason)
+		}
+	}
+	return nil
+}
+
+// validatePushgatewayLabel checks a key=value grouping label. The key must
+// be a Prometheus label name; "job" is set by -pushgateway-job instead.
+func validatePushgatewayLabel(label string) error {
+	key, err := validateLabel(label)
+	if err == nil && key == "job" {
+		return errors.New("use -pushgateway-job to set the job label")
+	}
+	return err
+}
+
+// validateLabel checks a key=value label whose key must be a Prometheus
+// (and Loki) label name, and returns the key.
+func validateLabel(label string) (string, error) {
+	key, value, ok := strings.Cut(label, "=")
+	if !ok || value == "" {
+		return "", errors.New("want key=value")
+	}
+	if key == "" {
+		return "", errors.New("empty label name")
+	}
+	for i, r := range key {
+		letter := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return "", fmt.Errorf("invalid label name %q", key)
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Warning is a configuration that is valid but likely to hurt the run or
+// its measurements, with a suggestion to fix it. -strict makes Validate
+// report warnings as errors.
+type Warning struct {
+	Field      string
+	Message    string
+	Suggestion string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s (%s)", w.Field, w.Message, w.Suggestion)
+}
+
+// Thresholds of the warning rules
+const (
+	// Per-client series stay usable in Prometheus up to about this many
+	// clients (the default -prom-client-metrics-max)
+	perClientMetricsWarnClients = 200
+
+	// FFmpeg debug output is several lines per request; above this many
+	// clients parsing it competes with the clients for CPU
+	debugLogWarnClients = 500
+
+	// A stalled read is only reconnected after -timeout: with more target
+	// durations than this, the client's buffer has long run dry
+	reconnectTimeoutWarnSegments = 10
+)
+
+// warningRule checks one thing; it returns the Warning and true if cfg
+// trips it.
+type warningRule func(cfg *Config) (Warning, bool)
+
+// warningRules are run by Warnings, in order.
+var warningRules = []warningRule{
+	warnPerClientMetrics,
+	warnDebugLogLevel,
+	warnReconnectDelay,
+	warnReconnectTimeout,
+	warnBackoffOnStats,
+	warnOriginLogStats,
+	warnEventSinkStats,
+}
+
+// Warnings returns the warnings cfg trips, in rule order. It assumes cfg
+// has no errors; Validate reports those.
+func Warnings(cfg *Config) []Warning {
+	var warnings []Warning
+	for _, rule := range warningRules {
+		if w, ok := rule(cfg); ok {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+func warnPerClientMetrics(cfg *Config) (Warning, bool) {
+	if !cfg.PromClientMetrics || cfg.Clients <= perClientMetricsWarnClients {
+		return Warning{}, false
+	}
+	if cfg.PromClientMetricsMax > 0 && cfg.PromClientMetricsMax <= perClientMetricsWarnClients {
+		return Warning{}, false // Aggregated into buckets
+	}
+	series := cfg.Clients
+	if cfg.PromClientMetricsMax > 0 {
+		series = cfg.PromClientMetricsMax
+	}
+	return Warning{
+		Field:      "prom_client_metrics",
+		Message:    fmt.Sprintf("per-client metrics for %d clients add %d series of each per-client metric", cfg.Clients, series),
+		Suggestion: fmt.Sprintf("use -prom-client-metrics-max %d or leave -prom-client-metrics off", perClientMetricsWarnClients),
+	}, true
+}
+
+func warnDebugLogLevel(cfg *Config) (Warning, bool) {
+	if !cfg.StatsEnabled || cfg.Clients <= debugLogWarnClients {
+		return Warning{}, false
+	}
+	lean := cfg.StatsLogLevel != "debug" || cfg.DebugSample > 0 // -debug-sample: the rest run verbose
+	if lean && !cfg.DebugLogging && !cfg.CDNDetect {
+		return Warning{}, false
+	}
+	level, fix := "debug", "-stats-loglevel verbose"
+	switch {
+	case cfg.CDNDetect:
+		level, fix = "trace", "-cdn-detect on a smaller run, or -stats-loglevel verbose without it,"
+	case cfg.DebugLogging:
+		fix = "-stats-loglevel verbose without -ffmpeg-debug"
+	}
+	return Warning{
+		Field:      "stats_loglevel",
+		Message:    fmt.Sprintf("parsing FFmpeg %s output of %d clients costs this host CPU and may drop lines", level, cfg.Clients),
+		Suggestion: "use " + fix + " if segment timings aren't needed, or raise -stats-buffer and watch for dropped lines",
+	}, true
+}
+
+func warnReconnectDelay(cfg *Config) (Warning, bool) {
+	if !cfg.Reconnect || cfg.ReconnectDelayMax > 0 {
+		return Warning{}, false
+	}
+	return Warning{
+		Field:      "reconnect_delay_max",
+		Message:    "FFmpeg reconnects without delay, so a failing origin is retried in a tight loop by every client",
+		Suggestion: "set -reconnect-delay to a few seconds (default 5)",
+	}, true
+}
+
+func warnBackoffOnStats(cfg *Config) (Warning, bool) {
+	if cfg.StatsEnabled {
+		return Warning{}, false
+	}
+	var unseen []string
+	for _, cause := range slices.Sorted(maps.Keys(cfg.BackoffOn)) {
+		if !strings.HasPrefix(cause, "exit-") {
+			unseen = append(unseen, cause)
+		}
+	}
+	if len(unseen) == 0 {
+		return Warning{}, false
+	}
+	return Warning{
+		Field:      "backoff_on",
+		Message:    fmt.Sprintf("%s: HTTP and network failures are read from FFmpeg's output, which isn't parsed without -stats", strings.Join(unseen, ", ")),
+		Suggestion: "enable -stats, or key the overrides on exit codes (exit-N)",
+	}, true
+}
+
+func warnOriginLogStats(cfg *Config) (Warning, bool) {
+	if cfg.OriginLog == "" || cfg.StatsEnabled {
+		return Warning{}, false
+	}
+	return Warning{
+		Field:      "origin_log",
+		Message:    "the clients' latency is read from FFmpeg's output, which isn't parsed without -stats, so the origin's request times have nothing to be compared with",
+		Suggestion: "enable -stats",
+	}, true
+}
+
+func warnEventSinkStats(cfg *Config) (Warning, bool) {
+	if cfg.EventSink == "" || !cfg.EventSinkDebug || cfg.StatsEnabled {
+		return Warning{}, false
+	}
+	return Warning{
+		Field:      "event_sink_debug",
+		Message:    "debug events are parsed from FFmpeg's output, which isn't parsed without -stats, so -event-sink streams only lifecycle events",
+		Suggestion: "enable -stats, or set -event-sink-debug=false",
+	}, true
+}
+
+func warnReconnectTimeout(cfg *Config) (Warning, bool) {
+	limit := reconnectTimeoutWarnSegments * cfg.TargetDuration
+	if !cfg.Reconnect || cfg.TargetDuration <= 0 || cfg.Timeout <= limit {
+		return Warning{}, false
+	}
+	return Warning{
+		Field: "timeout",
+		Message: fmt.Sprintf("a stalled connection is only reconnected once -timeout expires, %.0f segments of %s later",
+			float64(cfg.Timeout)/float64(cfg.TargetDuration), cfg.TargetDuration),
+		Suggestion: fmt.Sprintf("lower -timeout to at most %s", limit.Round(time.Second)),
+	}, true
+}
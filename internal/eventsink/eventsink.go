@@ -0,0 +1,249 @@
+// Package eventsink streams the swarm's events, the clients' parsed debug
+// events and their lifecycle (starts, exits, restarts, phases), to an
+// observability pipeline while the run goes on: a Loki server, or Kafka
+// through its REST proxy.
+package eventsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of events.
+const (
+	KindDebug     = "debug"     // A client's parsed FFmpeg debug event
+	KindLifecycle = "lifecycle" // A client or the swarm changing state
+)
+
+// Sink types (-event-sink).
+const (
+	TypeLoki  = "loki"
+	TypeKafka = "kafka"
+)
+
+// Streamer defaults.
+const (
+	// DefaultBuffer is how many events wait for the sink before more are
+	// dropped.
+	DefaultBuffer = 10000
+
+	// DefaultBatchSize is the most events sent in one request.
+	DefaultBatchSize = 1000
+
+	// DefaultFlushInterval is the longest an event waits for its batch.
+	DefaultFlushInterval = time.Second
+
+	// sendTimeout bounds one request to the sink.
+	sendTimeout = 10 * time.Second
+)
+
+// Event is one event streamed to a sink.
+type Event struct {
+	Time     time.Time
+	Kind     string         // KindDebug or KindLifecycle
+	Name     string         // e.g. "http_error", "client_exit", "phase"
+	ClientID int            // -1 for events of the swarm as a whole
+	Fields   map[string]any // Event-specific values
+}
+
+// MarshalJSON renders the event as one flat object: ts, kind, event,
+// client_id (unless swarm-wide) and the fields.
+func (e Event) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(e.Fields)+4)
+	maps.Copy(m, e.Fields)
+	m["ts"] = e.Time.UTC().Format(time.RFC3339Nano)
+	m["kind"] = e.Kind
+	m["event"] = e.Name
+	if e.ClientID >= 0 {
+		m["client_id"] = e.ClientID
+	}
+	return json.Marshal(m)
+}
+
+// Sink delivers batches of events. Send is called from one goroutine at
+// a time.
+type Sink interface {
+	Send(ctx context.Context, events []Event) error
+}
+
+// New returns the sink of type typ (TypeLoki or TypeKafka) at url. labels
+// are the Loki stream labels, or go with each Kafka record; topic is the
+// Kafka topic.
+func New(typ, url, topic string, labels map[string]string, client *http.Client) (Sink, error) {
+	switch typ {
+	case TypeLoki:
+		return NewLoki(url, labels, client), nil
+	case TypeKafka:
+		return NewKafka(url, topic, labels, client), nil
+	default:
+		return nil, fmt.Errorf("unknown event sink %q", typ)
+	}
+}
+
+// StreamerConfig tunes a Streamer (zero values = the defaults).
+type StreamerConfig struct {
+	Buffer        int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// Streamer feeds a sink in batches from a bounded buffer. Emit never
+// blocks: the clients' parsers call it, and a slow sink must not stall
+// them, so events that don't fit are dropped and counted instead.
+type Streamer struct {
+	sink      Sink
+	logger    *slog.Logger
+	batchSize int
+	interval  time.Duration
+
+	mu     sync.RWMutex // Guards closed against Emit's send on ch
+	closed bool
+	ch     chan Event
+	done   chan struct{}
+
+	sent    atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64 // Lost to failed sends
+}
+
+// NewStreamer starts streaming to sink. Close it to flush what's left.
+func NewStreamer(sink Sink, cfg StreamerConfig, logger *slog.Logger) *Streamer {
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = DefaultBuffer
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+	s := &Streamer{
+		sink:      sink,
+		logger:    logger,
+		batchSize: cfg.BatchSize,
+		interval:  cfg.FlushInterval,
+		ch:        make(chan Event, cfg.Buffer),
+		done:      make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Emit queues ev for the sink, or drops it if the buffer is full or the
+// streamer closed.
+func (s *Streamer) Emit(ev Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.ch <- ev:
+	default:
+		if s.dropped.Add(1) == 1 {
+			s.logger.Warn("event_sink_dropping", "buffer", cap(s.ch))
+		}
+	}
+}
+
+// Close stops taking events and sends the buffered ones, giving up when
+// ctx is done.
+func (s *Streamer) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event sink: %d events not sent: %w", len(s.ch), ctx.Err())
+	}
+}
+
+// Stats returns the events sent, dropped for want of buffer and lost to
+// failed sends so far.
+func (s *Streamer) Stats() (sent, dropped, failed int64) {
+	return s.sent.Load(), s.dropped.Load(), s.failed.Load()
+}
+
+// run sends a batch when it's full or has waited the flush interval,
+// until the channel is closed and drained.
+func (s *Streamer) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, s.batchSize)
+	for {
+		select {
+		case ev, ok := <-s.ch:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			if batch = append(batch, ev); len(batch) >= s.batchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush sends batch. A failed batch is counted and logged, not retried:
+// by the time the sink is back the events are stale, and retrying would
+// back the buffer up into drops anyway.
+func (s *Streamer) flush(batch []Event) {
+	if len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	if err := s.sink.Send(ctx, batch); err != nil {
+		s.failed.Add(int64(len(batch)))
+		s.logger.Warn("event_sink_send_failed", "events", len(batch), "error", err)
+		return
+	}
+	s.sent.Add(int64(len(batch)))
+}
+
+// post sends body to url as contentType and checks for a 2xx answer,
+// whose body the caller reads and closes. Errors carry the start of the
+// server's explanation.
+func post(ctx context.Context, client *http.Client, name, url, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%s request: %w", name, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s push: %w", name, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if msg := strings.TrimSpace(string(msg)); msg != "" {
+			return nil, fmt.Errorf("%s push: HTTP %d: %s", name, resp.StatusCode, msg)
+		}
+		return nil, fmt.Errorf("%s push: HTTP %d", name, resp.StatusCode)
+	}
+	return resp, nil
+}
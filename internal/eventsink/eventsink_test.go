@@ -0,0 +1,163 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink keeps the batches it's sent, failing them while fail is set.
+type recordingSink struct {
+	mu      sync.Mutex
+	batches [][]Event
+	fail    bool
+	block   chan struct{} // Send waits on it, if set
+}
+
+func (r *recordingSink) Send(ctx context.Context, events []Event) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail {
+		return errors.New("sink down")
+	}
+	r.batches = append(r.batches, append([]Event(nil), events...))
+	return nil
+}
+
+func (r *recordingSink) events() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, b := range r.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestEvent_MarshalJSON(t *testing.T) {
+	ts := time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC)
+	b, err := json.Marshal(Event{Time: ts, Kind: KindDebug, Name: "http_error", ClientID: 7, Fields: map[string]any{"http_code": 503}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"client_id":7,"event":"http_error","http_code":503,"kind":"debug","ts":"2026-01-15T09:30:00Z"}`
+	if string(b) != want {
+		t.Errorf("got  %s\nwant %s", b, want)
+	}
+
+	b, _ = json.Marshal(Event{Time: ts, Kind: KindLifecycle, Name: "phase", ClientID: -1})
+	if want := `{"event":"phase","kind":"lifecycle","ts":"2026-01-15T09:30:00Z"}`; string(b) != want {
+		t.Errorf("swarm-wide: got %s, want %s", b, want)
+	}
+}
+
+func TestStreamer_Batches(t *testing.T) {
+	sink := &recordingSink{}
+	s := NewStreamer(sink, StreamerConfig{BatchSize: 10, FlushInterval: time.Hour}, discardLogger())
+	for i := range 25 {
+		s.Emit(Event{Name: "segment_complete", ClientID: i})
+	}
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	var sizes []int
+	for _, b := range sink.batches {
+		sizes = append(sizes, len(b))
+	}
+	if len(sizes) != 3 || sizes[0] != 10 || sizes[1] != 10 || sizes[2] != 5 {
+		t.Errorf("batch sizes = %v, want [10 10 5]", sizes)
+	}
+	if sent, dropped, failed := s.Stats(); sent != 25 || dropped != 0 || failed != 0 {
+		t.Errorf("Stats() = %d, %d, %d, want 25, 0, 0", sent, dropped, failed)
+	}
+
+	// Closed: dropped, not a panic
+	s.Emit(Event{Name: "late"})
+	if _, dropped, _ := s.Stats(); dropped != 1 {
+		t.Errorf("dropped after Close = %d, want 1", dropped)
+	}
+}
+
+func TestStreamer_FlushInterval(t *testing.T) {
+	sink := &recordingSink{}
+	s := NewStreamer(sink, StreamerConfig{FlushInterval: 10 * time.Millisecond}, discardLogger())
+	defer s.Close(context.Background())
+
+	s.Emit(Event{Name: "client_start"})
+	deadline := time.Now().Add(2 * time.Second)
+	for sink.events() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("event not sent within the flush interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamer_DropsWhenFull(t *testing.T) {
+	sink := &recordingSink{block: make(chan struct{})}
+	s := NewStreamer(sink, StreamerConfig{Buffer: 5, BatchSize: 1, FlushInterval: time.Hour}, discardLogger())
+
+	// One event held by the blocked Send, five buffered, the rest dropped
+	for range 20 {
+		s.Emit(Event{Name: "segment_complete"})
+	}
+	close(sink.block)
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	sent, dropped, _ := s.Stats()
+	if sent+dropped != 20 || dropped < 14 {
+		t.Errorf("sent %d, dropped %d, want 20 in all with at least 14 dropped", sent, dropped)
+	}
+}
+
+func TestStreamer_SendFailure(t *testing.T) {
+	sink := &recordingSink{fail: true}
+	s := NewStreamer(sink, StreamerConfig{}, discardLogger())
+	s.Emit(Event{Name: "client_exit"})
+	s.Emit(Event{Name: "client_exit"})
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if sent, _, failed := s.Stats(); sent != 0 || failed != 2 {
+		t.Errorf("sent %d, failed %d, want 0, 2", sent, failed)
+	}
+}
+
+func TestStreamer_CloseTimeout(t *testing.T) {
+	sink := &recordingSink{block: make(chan struct{})}
+	defer close(sink.block)
+	s := NewStreamer(sink, StreamerConfig{BatchSize: 1}, discardLogger())
+	s.Emit(Event{Name: "client_start"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() with a stuck sink = %v, want deadline exceeded", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(TypeLoki, "http://loki:3100", "", nil, nil); err != nil {
+		t.Errorf("New(loki) = %v", err)
+	}
+	if _, err := New(TypeKafka, "http://kafka-rest:8082", "events", nil, nil); err != nil {
+		t.Errorf("New(kafka) = %v", err)
+	}
+	if _, err := New("syslog", "http://x", "", nil, nil); err == nil {
+		t.Error("New(syslog) = nil error")
+	}
+}
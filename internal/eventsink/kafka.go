@@ -0,0 +1,107 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// kafkaContentType is the Kafka REST Proxy v2 embedded-JSON format.
+const kafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// kafkaRecord is one record of a REST Proxy produce request.
+type kafkaRecord struct {
+	Key   string          `json:"key,omitempty"` // The client ID: a client's events stay in order on one partition
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaResponse is the part of a produce response that is read: one
+// offset per record, with an error if that record wasn't written.
+type kafkaResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Kafka produces events to a Kafka topic through a Kafka REST Proxy (v2
+// API), one record per event: the event's JSON, with the labels under
+// "labels", keyed by client ID.
+type Kafka struct {
+	url    string
+	labels map[string]string
+	client *http.Client
+}
+
+// NewKafka returns a sink producing to topic through the REST Proxy at
+// baseURL (e.g. http://kafka-rest:8082).
+func NewKafka(baseURL, topic string, labels map[string]string, client *http.Client) *Kafka {
+	return &Kafka{
+		url:    strings.TrimSuffix(baseURL, "/") + "/topics/" + url.PathEscape(topic),
+		labels: labels,
+		client: client,
+	}
+}
+
+// Send produces events in one request. The proxy answers 200 even if
+// some records failed, so their errors are read from the offsets.
+func (k *Kafka) Send(ctx context.Context, events []Event) error {
+	body, err := k.payload(events)
+	if err != nil {
+		return err
+	}
+	resp, err := post(ctx, k.client, "kafka", k.url, kafkaContentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var produced kafkaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("kafka response: %w", err)
+	}
+	failed, first := 0, ""
+	for _, o := range produced.Offsets {
+		if o.ErrorCode != nil {
+			if failed++; first == "" {
+				first = o.Error
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("kafka: %d of %d records failed: %s", failed, len(events), first)
+	}
+	return nil
+}
+
+// payload renders events as a produce request.
+func (k *Kafka) payload(events []Event) ([]byte, error) {
+	records := make([]kafkaRecord, len(events))
+	for i, ev := range events {
+		if len(k.labels) > 0 {
+			ev.Fields = withLabels(ev.Fields, k.labels)
+		}
+		value, err := json.Marshal(ev)
+		if err != nil {
+			return nil, err
+		}
+		records[i].Value = value
+		if ev.ClientID >= 0 {
+			records[i].Key = strconv.Itoa(ev.ClientID)
+		}
+	}
+	return json.Marshal(map[string]any{"records": records})
+}
+
+// withLabels returns fields plus labels, leaving fields as they were.
+func withLabels(fields map[string]any, labels map[string]string) map[string]any {
+	m := make(map[string]any, len(fields)+1)
+	maps.Copy(m, fields)
+	m["labels"] = labels
+	return m
+}
@@ -0,0 +1,91 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKafka_Send(t *testing.T) {
+	var got struct {
+		Records []struct {
+			Key   *string        `json:"key"`
+			Value map[string]any `json:"value"`
+		} `json:"records"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/hls-events" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); ct != kafkaContentType {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding produce request: %v", err)
+		}
+		fmt.Fprint(w, `{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null},{"partition":0,"offset":2,"error_code":null,"error":null}]}`)
+	}))
+	defer srv.Close()
+
+	events := []Event{
+		{Time: time.Now(), Kind: KindDebug, Name: "segment_complete", ClientID: 4, Fields: map[string]any{"url": "/seg_1.ts"}},
+		{Time: time.Now(), Kind: KindLifecycle, Name: "phase", ClientID: -1, Fields: map[string]any{"phase": "steady"}},
+	}
+	k := NewKafka(srv.URL, "hls-events", map[string]string{"instance": "host-a"}, srv.Client())
+	if err := k.Send(context.Background(), events); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	if len(got.Records) != 2 {
+		t.Fatalf("got %d records, want 2", len(got.Records))
+	}
+	seg, phase := got.Records[0], got.Records[1]
+	if seg.Key == nil || *seg.Key != "4" {
+		t.Errorf("client event key = %v, want 4", seg.Key)
+	}
+	if phase.Key != nil {
+		t.Errorf("swarm-wide event key = %q, want none", *phase.Key)
+	}
+	if seg.Value["event"] != "segment_complete" || seg.Value["url"] != "/seg_1.ts" {
+		t.Errorf("value = %v", seg.Value)
+	}
+	if labels, _ := seg.Value["labels"].(map[string]any); labels["instance"] != "host-a" {
+		t.Errorf("labels = %v, want instance host-a", seg.Value["labels"])
+	}
+	if _, ok := events[0].Fields["labels"]; ok {
+		t.Error("Send added the labels to the caller's fields")
+	}
+}
+
+func TestKafka_SendRecordErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null},{"error_code":50003,"error":"Kafka error: broker not available"}]}`)
+	}))
+	defer srv.Close()
+
+	k := NewKafka(srv.URL, "hls-events", nil, srv.Client())
+	events := []Event{{Name: "a", ClientID: 1}, {Name: "b", ClientID: 2}}
+	err := k.Send(context.Background(), events)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 records failed: Kafka error: broker not available") {
+		t.Errorf("Send() = %v, want the failed record reported", err)
+	}
+}
+
+func TestKafka_SendUnknownTopic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error_code":40401,"message":"Topic not found."}`)
+	}))
+	defer srv.Close()
+
+	err := NewKafka(srv.URL, "missing", nil, srv.Client()).Send(context.Background(), []Event{{Name: "a"}})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 404") || !strings.Contains(err.Error(), "Topic not found") {
+		t.Errorf("Send() = %v, want the HTTP 404 with the proxy's message", err)
+	}
+}
@@ -0,0 +1,86 @@
+package eventsink
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// lokiStream is one stream of a Loki push: its labels and lines.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"` // [unix nanoseconds, line]
+}
+
+// Loki pushes events to a Loki server's push API, one stream per kind and
+// event name (the client ID goes in the line: as a label it would make a
+// stream per client). Lines are the events' JSON.
+type Loki struct {
+	url    string
+	labels map[string]string
+	client *http.Client
+}
+
+// NewLoki returns a sink pushing to the Loki server at baseURL (e.g.
+// http://loki:3100) with labels on every stream, job defaulting to
+// hls_swarm.
+func NewLoki(baseURL string, labels map[string]string, client *http.Client) *Loki {
+	l := &Loki{
+		url:    strings.TrimSuffix(baseURL, "/") + "/loki/api/v1/push",
+		labels: map[string]string{"job": "hls_swarm"},
+		client: client,
+	}
+	maps.Copy(l.labels, labels)
+	return l
+}
+
+// Send pushes events in one request.
+func (l *Loki) Send(ctx context.Context, events []Event) error {
+	body, err := l.payload(events)
+	if err != nil {
+		return err
+	}
+	resp, err := post(ctx, l.client, "loki", l.url, "application/json", body)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// payload groups events into streams, each in time order.
+func (l *Loki) payload(events []Event) ([]byte, error) {
+	type streamKey struct{ kind, name string }
+	index := make(map[streamKey]int)
+	var streams []lokiStream
+	for _, ev := range events {
+		line, err := json.Marshal(ev)
+		if err != nil {
+			return nil, err
+		}
+		key := streamKey{ev.Kind, ev.Name}
+		i, ok := index[key]
+		if !ok {
+			labels := maps.Clone(l.labels)
+			labels["kind"], labels["event"] = ev.Kind, ev.Name
+			i = len(streams)
+			index[key] = i
+			streams = append(streams, lokiStream{Stream: labels})
+		}
+		streams[i].Values = append(streams[i].Values, [2]string{strconv.FormatInt(ev.Time.UnixNano(), 10), string(line)})
+	}
+	for _, s := range streams {
+		slices.SortStableFunc(s.Values, func(a, b [2]string) int {
+			ta, _ := strconv.ParseInt(a[0], 10, 64)
+			tb, _ := strconv.ParseInt(b[0], 10, 64)
+			return cmp.Compare(ta, tb)
+		})
+	}
+	return json.Marshal(map[string]any{"streams": streams})
+}
@@ -0,0 +1,80 @@
+package eventsink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoki_Send(t *testing.T) {
+	var got struct {
+		Streams []lokiStream `json:"streams"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding push: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	t0 := time.Unix(1_700_000_000, 0)
+	events := []Event{
+		{Time: t0.Add(2 * time.Second), Kind: KindDebug, Name: "http_error", ClientID: 1, Fields: map[string]any{"http_code": 503}},
+		{Time: t0, Kind: KindLifecycle, Name: "client_start", ClientID: 1},
+		{Time: t0.Add(time.Second), Kind: KindDebug, Name: "http_error", ClientID: 2, Fields: map[string]any{"http_code": 404}},
+	}
+	l := NewLoki(srv.URL+"/", map[string]string{"instance": "host-a"}, srv.Client())
+	if err := l.Send(context.Background(), events); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	if len(got.Streams) != 2 {
+		t.Fatalf("got %d streams, want 2 (one per kind and event)", len(got.Streams))
+	}
+	errs := got.Streams[0]
+	want := map[string]string{"job": "hls_swarm", "instance": "host-a", "kind": "debug", "event": "http_error"}
+	for k, v := range want {
+		if errs.Stream[k] != v {
+			t.Errorf("label %s = %q, want %q", k, errs.Stream[k], v)
+		}
+	}
+	if len(errs.Stream) != len(want) {
+		t.Errorf("labels = %v, want only %v", errs.Stream, want)
+	}
+
+	// In time order, the line the event's JSON
+	if len(errs.Values) != 2 || errs.Values[0][0] != "1700000001000000000" {
+		t.Fatalf("values = %v, want the 404 at 1700000001000000000 first", errs.Values)
+	}
+	if !strings.Contains(errs.Values[0][1], `"http_code":404`) || !strings.Contains(errs.Values[0][1], `"client_id":2`) {
+		t.Errorf("line = %s", errs.Values[0][1])
+	}
+}
+
+func TestLoki_SendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry too far behind", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	l := NewLoki(srv.URL, map[string]string{"job": "swarm"}, srv.Client())
+	if l.labels["job"] != "swarm" {
+		t.Errorf("job label = %q, want swarm", l.labels["job"])
+	}
+	err := l.Send(context.Background(), []Event{{Time: time.Now(), Kind: KindLifecycle, Name: "phase", ClientID: -1}})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 400: entry too far behind") {
+		t.Errorf("Send() = %v, want the HTTP 400 with Loki's message", err)
+	}
+}
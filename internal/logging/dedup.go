@@ -0,0 +1,156 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DedupMinLevel is the lowest level Dedup holds back: warnings and errors
+// are what storms are made of, while info records (phases, ramp progress)
+// are few and each one matters.
+const DedupMinLevel = slog.LevelWarn
+
+// Dedup rate-limits repeated log records so an error storm (hundreds of
+// clients failing the same way) leaves a readable log. Records with the
+// same level and message are one key: the first passes and opens a window,
+// later ones in the window are counted rather than logged, and when the
+// window ends one "log_suppressed" record reports how many there were.
+// The next record of the key passes again and opens a new window.
+type Dedup struct {
+	window time.Duration
+	root   slog.Handler // Receives the summaries, without any WithAttrs
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    map[dedupKey]*dedupEntry
+	stopped bool
+}
+
+type dedupKey struct {
+	level slog.Level
+	msg   string
+}
+
+type dedupEntry struct {
+	start      time.Time
+	suppressed int
+	timer      *time.Timer // Emits the summary; nil until a record is suppressed
+}
+
+// WithDedup returns a logger that deduplicates logger's warnings and
+// errors over window, and the Dedup to Flush at exit. A window <= 0
+// turns deduplication off: the logger is returned as is, with a nil Dedup.
+func WithDedup(logger *slog.Logger, window time.Duration) (*slog.Logger, *Dedup) {
+	if window <= 0 {
+		return logger, nil
+	}
+	d := &Dedup{
+		window: window,
+		root:   logger.Handler(),
+		now:    time.Now,
+		keys:   make(map[dedupKey]*dedupEntry),
+	}
+	return slog.New(&dedupHandler{inner: d.root, dedup: d}), d
+}
+
+// allow reports whether a record of key should be logged, counting it if
+// not.
+func (d *Dedup) allow(key dedupKey) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return true
+	}
+	now := d.now()
+	e := d.keys[key]
+	if e == nil || now.Sub(e.start) >= d.window {
+		// The window of a key with suppressed records ends with its
+		// summary, which deletes it, so an expired entry has none
+		d.keys[key] = &dedupEntry{start: now}
+		return true
+	}
+	e.suppressed++
+	if e.timer == nil {
+		e.timer = time.AfterFunc(e.start.Add(d.window).Sub(now), func() { d.emit(key) })
+	}
+	return false
+}
+
+// emit ends key's window, logging its summary.
+func (d *Dedup) emit(key dedupKey) {
+	d.mu.Lock()
+	e := d.keys[key]
+	if e == nil || e.suppressed == 0 {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.keys, key)
+	d.mu.Unlock()
+	d.summarize(key, e)
+}
+
+func (d *Dedup) summarize(key dedupKey, e *dedupEntry) {
+	ctx := context.Background()
+	if !d.root.Enabled(ctx, key.level) {
+		return
+	}
+	r := slog.NewRecord(d.now(), key.level, "log_suppressed", 0)
+	r.AddAttrs(
+		slog.String("message", key.msg),
+		slog.Int("suppressed", e.suppressed),
+		slog.String("window", d.window.String()),
+	)
+	_ = d.root.Handle(ctx, r) // Nowhere to report a failed log
+}
+
+// Flush logs the summaries of all open windows and stops deduplicating:
+// later records pass as they are. Call it at exit, so the last storm's
+// count isn't lost. Safe on a nil Dedup.
+func (d *Dedup) Flush() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	keys := d.keys
+	d.keys = make(map[dedupKey]*dedupEntry)
+	d.stopped = true
+	d.mu.Unlock()
+
+	for key, e := range keys {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		if e.suppressed > 0 {
+			d.summarize(key, e)
+		}
+	}
+}
+
+// dedupHandler passes records through Dedup. Loggers derived with
+// WithAttrs share their parent's Dedup, so a storm from many clients'
+// loggers (each With its client_id) is still one key.
+type dedupHandler struct {
+	inner slog.Handler
+	dedup *Dedup
+}
+
+func (h *dedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *dedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= DedupMinLevel && !h.dedup.allow(dedupKey{level: r.Level, msg: r.Message}) {
+		return nil
+	}
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &dedupHandler{inner: h.inner.WithAttrs(attrs), dedup: h.dedup}
+}
+
+func (h *dedupHandler) WithGroup(name string) slog.Handler {
+	return &dedupHandler{inner: h.inner.WithGroup(name), dedup: h.dedup}
+}
@@ -0,0 +1,165 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for the summary timers' goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// records returns the JSON records written so far.
+func (b *syncBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var recs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("record %q: %v", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestWithDedup(t *testing.T) {
+	var buf syncBuffer
+	logger, d := WithDedup(NewLoggerWithWriter(&buf, "json", "info"), time.Hour)
+
+	for i := range 5 {
+		logger.With("client_id", i).Warn("client_restart_scheduled")
+	}
+	logger.Error("client_restart_scheduled") // Another level: another key
+	logger.Warn("other")
+	for range 3 {
+		logger.Info("phase") // Below DedupMinLevel: never held back
+	}
+
+	recs := buf.records(t)
+	var msgs []string
+	for _, r := range recs {
+		msgs = append(msgs, r["msg"].(string))
+	}
+	want := "client_restart_scheduled client_restart_scheduled other phase phase phase"
+	if got := strings.Join(msgs, " "); got != want {
+		t.Errorf("records = %q, want %q", got, want)
+	}
+	if recs[0]["client_id"] != float64(0) {
+		t.Errorf("first record client_id = %v, want 0", recs[0]["client_id"])
+	}
+
+	d.Flush()
+	recs = buf.records(t)
+	last := recs[len(recs)-1]
+	if last["msg"] != "log_suppressed" || last["message"] != "client_restart_scheduled" ||
+		last["suppressed"] != float64(4) || last["level"] != "WARN" || last["window"] != "1h0m0s" {
+		t.Errorf("summary = %v, want 4 suppressed WARN client_restart_scheduled over 1h", last)
+	}
+	if len(recs) != 7 {
+		t.Errorf("got %d records after Flush, want 7 (one summary)", len(recs))
+	}
+
+	// After Flush, records pass as they are
+	logger.Warn("other")
+	if got := len(buf.records(t)); got != 8 {
+		t.Errorf("got %d records after a post-Flush warning, want 8", got)
+	}
+}
+
+func TestWithDedup_WindowSummary(t *testing.T) {
+	var buf syncBuffer
+	logger, d := WithDedup(NewLoggerWithWriter(&buf, "json", "info"), 50*time.Millisecond)
+	defer d.Flush()
+
+	logger.Warn("storm")
+	logger.Warn("storm")
+	logger.Warn("storm")
+
+	// The summary comes at the end of the window, without another record
+	deadline := time.Now().Add(5 * time.Second)
+	for len(buf.records(t)) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("no summary at the end of the window")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	recs := buf.records(t)
+	if recs[1]["msg"] != "log_suppressed" || recs[1]["suppressed"] != float64(2) {
+		t.Errorf("summary = %v, want 2 suppressed", recs[1])
+	}
+
+	// A new window: the next one passes
+	logger.Warn("storm")
+	recs = buf.records(t)
+	if len(recs) != 3 || recs[2]["msg"] != "storm" {
+		t.Errorf("records after the window = %v, want the storm record again", recs)
+	}
+}
+
+func TestWithDedup_ExpiredWindow(t *testing.T) {
+	var buf syncBuffer
+	logger, d := WithDedup(NewLoggerWithWriter(&buf, "json", "info"), time.Minute)
+	now := time.Unix(1000, 0)
+	d.now = func() time.Time { return now }
+
+	logger.Warn("rare")
+	now = now.Add(2 * time.Minute)
+	logger.Warn("rare") // Nothing suppressed in between: logged, no summary
+	d.Flush()
+
+	recs := buf.records(t)
+	if len(recs) != 2 || recs[0]["msg"] != "rare" || recs[1]["msg"] != "rare" {
+		t.Errorf("records = %v, want rare twice", recs)
+	}
+}
+
+func TestWithDedup_Off(t *testing.T) {
+	var buf syncBuffer
+	logger, d := WithDedup(NewLoggerWithWriter(&buf, "json", "info"), 0)
+	if d != nil {
+		t.Error("WithDedup(0) returned a Dedup, want nil")
+	}
+	logger.Warn("storm")
+	logger.Warn("storm")
+	d.Flush() // Safe on nil
+	if got := len(buf.records(t)); got != 2 {
+		t.Errorf("got %d records, want 2", got)
+	}
+}
+
+func TestWithDedup_RunAttrs(t *testing.T) {
+	// Outside WithRun, as main wraps them, the summary carries the run
+	var buf syncBuffer
+	run := NewRun()
+	logger, d := WithDedup(WithRun(NewLoggerWithWriter(&buf, "json", "info"), run), time.Hour)
+	logger.Warn("storm")
+	logger.Warn("storm")
+	run.SetPhase("draining")
+	d.Flush()
+
+	recs := buf.records(t)
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	if recs[1]["run_id"] != run.ID || recs[1]["phase"] != "draining" {
+		t.Errorf("summary = %v, want run_id %s in phase draining", recs[1], run.ID)
+	}
+}
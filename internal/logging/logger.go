@@ -6,10 +6,11 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 )
 
 // NewLogger creates a new structured logger with the specified format and level.
-// Format should be "json" or "text".
+// Format should be "json", "logfmt" or "text".
 // Level should be "debug", "info", "warn", or "error".
 func NewLogger(format, level string, verbose bool) *slog.Logger {
 	var handler slog.Handler
@@ -30,6 +31,8 @@ func NewLogger(format, level string, verbose bool) *slog.Logger {
 	switch strings.ToLower(format) {
 	case "json":
 		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "logfmt":
+		handler = newLogfmtHandler(os.Stderr, opts)
 	case "text":
 		handler = slog.NewTextHandler(os.Stderr, opts)
 	default:
@@ -51,6 +54,8 @@ func NewLoggerWithWriter(w io.Writer, format, level string) *slog.Logger {
 	switch strings.ToLower(format) {
 	case "json":
 		handler = slog.NewJSONHandler(w, opts)
+	case "logfmt":
+		handler = newLogfmtHandler(w, opts)
 	default:
 		handler = slog.NewTextHandler(w, opts)
 	}
@@ -58,6 +63,30 @@ func NewLoggerWithWriter(w io.Writer, format, level string) *slog.Logger {
 	return slog.New(handler)
 }
 
+// newLogfmtHandler returns a handler writing logfmt as Loki and ELK parse
+// it: slog's text format, with the time in UTC and the level in lower case
+// ("level=warn").
+func newLogfmtHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	o := *opts
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) > 0 {
+			return a
+		}
+		switch a.Key {
+		case slog.TimeKey:
+			if t, ok := a.Value.Any().(time.Time); ok {
+				a.Value = slog.StringValue(t.UTC().Format(time.RFC3339Nano))
+			}
+		case slog.LevelKey:
+			if l, ok := a.Value.Any().(slog.Level); ok {
+				a.Value = slog.StringValue(strings.ToLower(l.String()))
+			}
+		}
+		return a
+	}
+	return slog.NewTextHandler(w, &o)
+}
+
 // parseLevel converts a string level to slog.Level.
 func parseLevel(level string) slog.Level {
 	switch strings.ToLower(level) {
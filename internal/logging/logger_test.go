@@ -3,6 +3,7 @@ package logging
 import (
 	"bytes"
 	"log/slog"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -38,7 +39,7 @@ func TestParseLevel(t *testing.T) {
 }
 
 func TestNewLogger_Formats(t *testing.T) {
-	testCases := []string{"json", "text", "JSON", "TEXT", "", "invalid"}
+	testCases := []string{"json", "logfmt", "text", "JSON", "TEXT", "", "invalid"}
 
 	for _, format := range testCases {
 		t.Run(format, func(t *testing.T) {
@@ -126,6 +127,25 @@ func TestNewLoggerWithWriter_Text(t *testing.T) {
 	}
 }
 
+func TestNewLoggerWithWriter_Logfmt(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := NewLoggerWithWriter(&buf, "logfmt", "info")
+	logger.Warn("test message", "key", "value")
+
+	output := buf.String()
+	if !strings.Contains(output, "level=warn") {
+		t.Errorf("Expected lowercase level in output, got: %s", output)
+	}
+	if !strings.Contains(output, `msg="test message"`) || !strings.Contains(output, "key=value") {
+		t.Errorf("Expected msg and key=value in output, got: %s", output)
+	}
+	// Time in UTC: RFC 3339 with a Z suffix
+	if !regexp.MustCompile(`^time=\S+Z `).MatchString(output) {
+		t.Errorf("Expected UTC time in output, got: %s", output)
+	}
+}
+
 func TestNewLoggerWithWriter_LevelFiltering(t *testing.T) {
 	t.Run("debug_logs_all", func(t *testing.T) {
 		var buf bytes.Buffer
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// Run identifies one swarm run in its logs: every record of a logger from
+// WithRun carries the run's ID and the phase it was in, so the records of
+// many runs can be told apart once shipped to Loki or ELK.
+type Run struct {
+	ID    string
+	phase atomic.Pointer[string]
+}
+
+// NewRun returns a run with a fresh ID (see NewRunID), in the "starting"
+// phase until SetPhase says otherwise.
+func NewRun() *Run {
+	r := &Run{ID: NewRunID()}
+	r.SetPhase("starting")
+	return r
+}
+
+// NewRunID returns a run ID: the UTC start time to the second, so IDs
+// sort by time, and 6 random hex digits apart from runs started the same
+// second ("20260115T093000Z-3fa9c1").
+func NewRunID() string {
+	var b [3]byte
+	rand.Read(b[:]) // Never fails (crypto/rand panics instead)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(b[:])
+}
+
+// SetPhase sets the phase later records carry.
+func (r *Run) SetPhase(phase string) {
+	r.phase.Store(&phase)
+}
+
+// Phase returns the run's current phase.
+func (r *Run) Phase() string {
+	if p := r.phase.Load(); p != nil {
+		return *p
+	}
+	return ""
+}
+
+// WithRun returns a logger that adds run_id and phase to each record of
+// logger, phase as of the record.
+func WithRun(logger *slog.Logger, run *Run) *slog.Logger {
+	return slog.New(&runHandler{inner: logger.Handler(), run: run})
+}
+
+// runHandler adds the run's attributes to records. They're added ahead of
+// any WithAttrs of the logger's own, and with groups open they land in the
+// innermost (slog has no way back to the top level).
+type runHandler struct {
+	inner slog.Handler
+	run   *Run
+}
+
+func (h *runHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *runHandler) Handle(ctx context.Context, r slog.Record) error {
+	r = r.Clone()
+	r.AddAttrs(slog.String("run_id", h.run.ID), slog.String("phase", h.run.Phase()))
+	return h.inner.Handle(ctx, r)
+}
+
+func (h *runHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &runHandler{inner: h.inner.WithAttrs(attrs), run: h.run}
+}
+
+func (h *runHandler) WithGroup(name string) slog.Handler {
+	return &runHandler{inner: h.inner.WithGroup(name), run: h.run}
+}
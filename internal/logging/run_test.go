@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNewRunID(t *testing.T) {
+	re := regexp.MustCompile(`^\d{8}T\d{6}Z-[0-9a-f]{6}$`)
+	a, b := NewRunID(), NewRunID()
+	if !re.MatchString(a) {
+		t.Errorf("NewRunID() = %q, want 20060102T150405Z-xxxxxx", a)
+	}
+	if a == b {
+		t.Errorf("NewRunID() twice = %q, want different IDs", a)
+	}
+}
+
+func TestWithRun(t *testing.T) {
+	var buf bytes.Buffer
+	run := NewRun()
+	logger := WithRun(NewLoggerWithWriter(&buf, "json", "info"), run)
+
+	logger.Info("first")
+	run.SetPhase("ramping")
+	logger.With("client_id", 7).Info("second")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d records, want 2: %s", len(lines), buf.String())
+	}
+	want := []map[string]any{
+		{"msg": "first", "run_id": run.ID, "phase": "starting"},
+		{"msg": "second", "run_id": run.ID, "phase": "ramping", "client_id": float64(7)},
+	}
+	for i, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		for k, v := range want[i] {
+			if rec[k] != v {
+				t.Errorf("record %d: %s = %v, want %v", i, k, rec[k], v)
+			}
+		}
+	}
+}
+
+func TestWithRun_Logfmt(t *testing.T) {
+	var buf bytes.Buffer
+	run := NewRun()
+	run.SetPhase("steady")
+	WithRun(NewLoggerWithWriter(&buf, "logfmt", "info"), run).Info("tick")
+
+	output := buf.String()
+	if !strings.Contains(output, "run_id="+run.ID) || !strings.Contains(output, "phase=steady") {
+		t.Errorf("Expected run_id and phase in output, got: %s", output)
+	}
+}
+
+func TestWithRun_LevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	logger := WithRun(NewLoggerWithWriter(&buf, "json", "warn"), NewRun())
+	logger.Info("dropped")
+	if buf.Len() != 0 {
+		t.Errorf("Info at warn level logged: %s", buf.String())
+	}
+}
@@ -0,0 +1,127 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoadEstimate is the expected steady-state origin load for a swarm.
+//
+// The model is the same one a real player follows: each rendition a client
+// pulls costs one segment request per segment duration and one playlist
+// refresh per target duration (live only; VOD playlists are fetched once).
+type LoadEstimate struct {
+	Clients         int
+	Renditions      int           // Renditions fetched per client
+	SegmentDuration time.Duration // Average #EXTINF
+	TargetDuration  time.Duration
+	Live            bool
+	BitsPerClient   int64 // Sum of selected renditions' bandwidth
+	SegmentRPS      float64
+	PlaylistRPS     float64
+	BandwidthMbps   float64
+}
+
+// TotalRPS returns segment + playlist requests per second.
+func (e LoadEstimate) TotalRPS() float64 {
+	return e.SegmentRPS + e.PlaylistRPS
+}
+
+// Estimate computes the load for clients using the given variant selection
+// ("all", "highest", "lowest", "first"), matching FFmpeg's -map behaviour.
+func Estimate(res *ProbeResult, variant string, clients int) LoadEstimate {
+	e := LoadEstimate{Clients: clients}
+	if res == nil || res.Media == nil {
+		return e
+	}
+
+	e.SegmentDuration = res.Media.AvgSegmentDuration()
+	e.TargetDuration = res.Media.TargetDuration
+	e.Live = !res.Media.Ended
+
+	selected := SelectVariants(res.Variants, variant)
+	e.Renditions = len(selected)
+	if e.Renditions == 0 {
+		e.Renditions = 1 // Media playlist URL: a single rendition of unknown bitrate
+	}
+	for _, v := range selected {
+		e.BitsPerClient += v.Bandwidth
+	}
+
+	streams := float64(clients * e.Renditions)
+	if e.SegmentDuration > 0 {
+		e.SegmentRPS = streams / e.SegmentDuration.Seconds()
+	}
+	if e.Live && e.TargetDuration > 0 {
+		e.PlaylistRPS = streams / e.TargetDuration.Seconds()
+	}
+	e.BandwidthMbps = float64(e.BitsPerClient) * float64(clients) / 1e6
+
+	return e
+}
+
+// SelectVariants returns the variants a client fetches for selection
+// ("all", "highest", "lowest", "first"), mirroring process.FFmpegRunner.mapArgs.
+func SelectVariants(variants []Variant, selection string) []Variant {
+	if len(variants) == 0 {
+		return nil
+	}
+	switch selection {
+	case "highest", "lowest":
+		best := variants[0]
+		for _, v := range variants[1:] {
+			if (selection == "highest" && v.Bandwidth > best.Bandwidth) ||
+				(selection == "lowest" && v.Bandwidth < best.Bandwidth) {
+				best = v
+			}
+		}
+		return []Variant{best}
+	case "first":
+		return variants[:1]
+	default:
+		return variants
+	}
+}
+
+// Budget is a user-supplied ceiling on origin load (0 = no limit).
+type Budget struct {
+	MaxRPS  float64
+	MaxMbps float64
+}
+
+// CheckBudget returns one warning per exceeded limit.
+func (e LoadEstimate) CheckBudget(b Budget) []string {
+	var warnings []string
+	if b.MaxRPS > 0 && e.TotalRPS() > b.MaxRPS {
+		warnings = append(warnings, fmt.Sprintf(
+			"estimated %.1f req/s exceeds budget of %.1f req/s (%.0f%%)",
+			e.TotalRPS(), b.MaxRPS, 100*e.TotalRPS()/b.MaxRPS))
+	}
+	if b.MaxMbps > 0 && e.BandwidthMbps > b.MaxMbps {
+		warnings = append(warnings, fmt.Sprintf(
+			"estimated %.1f Mbps exceeds budget of %.1f Mbps (%.0f%%)",
+			e.BandwidthMbps, b.MaxMbps, 100*e.BandwidthMbps/b.MaxMbps))
+	}
+	return warnings
+}
+
+// String renders the estimate for the startup output.
+func (e LoadEstimate) String() string {
+	var b strings.Builder
+	kind := "VOD"
+	if e.Live {
+		kind = "live"
+	}
+	fmt.Fprintf(&b, "Estimated origin load (%d clients × %d rendition(s), %s):\n", e.Clients, e.Renditions, kind)
+	fmt.Fprintf(&b, "  Segment duration:   %v (target %v)\n", e.SegmentDuration.Round(time.Millisecond), e.TargetDuration)
+	fmt.Fprintf(&b, "  Segment requests:   %.1f/s\n", e.SegmentRPS)
+	fmt.Fprintf(&b, "  Playlist requests:  %.1f/s\n", e.PlaylistRPS)
+	if e.BitsPerClient > 0 {
+		fmt.Fprintf(&b, "  Bandwidth:          %.1f Mbps (%.2f Mbps per client)\n",
+			e.BandwidthMbps, float64(e.BitsPerClient)/1e6)
+	} else {
+		b.WriteString("  Bandwidth:          unknown (no BANDWIDTH in playlist)\n")
+	}
+	return b.String()
+}
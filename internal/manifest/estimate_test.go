@@ -0,0 +1,83 @@
+package manifest
+
+import (
+	"testing"
+	"time"
+)
+
+func testProbeResult() *ProbeResult {
+	return &ProbeResult{
+		Variants: []Variant{
+			{URI: "low.m3u8", Bandwidth: 1_000_000},
+			{URI: "high.m3u8", Bandwidth: 4_000_000},
+		},
+		Media: &Playlist{
+			TargetDuration: 2 * time.Second,
+			Segments:       []Segment{{Duration: 2 * time.Second}, {Duration: 2 * time.Second}, {Duration: 2 * time.Second}},
+			TotalDuration:  6 * time.Second,
+		},
+	}
+}
+
+func TestEstimate_VariantSelection(t *testing.T) {
+	tests := []struct {
+		variant    string
+		renditions int
+		mbps       float64
+	}{
+		{"all", 2, 500},     // 100 × 5 Mbps
+		{"highest", 1, 400}, // 100 × 4 Mbps
+		{"lowest", 1, 100},  // 100 × 1 Mbps
+		{"first", 1, 100},   // first listed = low
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.variant, func(t *testing.T) {
+			e := Estimate(testProbeResult(), tt.variant, 100)
+			if e.Renditions != tt.renditions {
+				t.Errorf("Renditions = %d, want %d", e.Renditions, tt.renditions)
+			}
+			if e.BandwidthMbps != tt.mbps {
+				t.Errorf("BandwidthMbps = %v, want %v", e.BandwidthMbps, tt.mbps)
+			}
+			// 2s segments, live: 0.5 seg/s + 0.5 playlist/s per stream
+			want := float64(100*tt.renditions) * 0.5
+			if e.SegmentRPS != want || e.PlaylistRPS != want {
+				t.Errorf("SegmentRPS/PlaylistRPS = %v/%v, want %v", e.SegmentRPS, e.PlaylistRPS, want)
+			}
+		})
+	}
+}
+
+func TestEstimate_VODHasNoPlaylistRefresh(t *testing.T) {
+	res := testProbeResult()
+	res.Media.Ended = true
+	e := Estimate(res, "first", 10)
+	if e.PlaylistRPS != 0 {
+		t.Errorf("PlaylistRPS = %v, want 0 for VOD", e.PlaylistRPS)
+	}
+	if e.Live {
+		t.Error("Live = true for VOD")
+	}
+}
+
+func TestEstimate_Nil(t *testing.T) {
+	e := Estimate(nil, "all", 10)
+	if e.TotalRPS() != 0 || e.Clients != 10 {
+		t.Errorf("Estimate(nil) = %+v", e)
+	}
+}
+
+func TestLoadEstimate_CheckBudget(t *testing.T) {
+	e := Estimate(testProbeResult(), "all", 100) // 200 req/s, 500 Mbps
+
+	if w := e.CheckBudget(Budget{}); len(w) != 0 {
+		t.Errorf("no budget should give no warnings, got %v", w)
+	}
+	if w := e.CheckBudget(Budget{MaxRPS: 1000, MaxMbps: 1000}); len(w) != 0 {
+		t.Errorf("within budget should give no warnings, got %v", w)
+	}
+	if w := e.CheckBudget(Budget{MaxRPS: 100, MaxMbps: 100}); len(w) != 2 {
+		t.Errorf("over both budgets should give 2 warnings, got %v", w)
+	}
+}
@@ -0,0 +1,201 @@
+package manifest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// MonitorConfig configures a Monitor.
+type MonitorConfig struct {
+	Prober   *Prober
+	URLs     []string      // Media playlist URLs to validate
+	Interval time.Duration // Reload interval (default: 1s)
+	Logger   *slog.Logger
+
+	// OnViolation is called for every violation found (from the URL's goroutine).
+	OnViolation func(url string, v Violation)
+
+	// OnFetch is called for every reload that got a response, with how it
+	// was encoded and whether decoding it failed (from the URL's goroutine).
+	OnFetch func(url string, info FetchInfo, decodeFailed bool)
+}
+
+// EncodingStats counts reloads served with one Content-Encoding.
+type EncodingStats struct {
+	Fetches        int64
+	WireBytes      int64
+	DecodedBytes   int64
+	DecodeFailures int64
+}
+
+// Monitor reloads media playlists alongside the swarm and validates each
+// reload. It is one extra "client" per rendition, so it sees exactly what
+// the origin serves while under load.
+type Monitor struct {
+	cfg MonitorConfig
+
+	mu        sync.Mutex
+	counts    map[ViolationKind]int64
+	errors    int64 // Fetch failures (not violations; the swarm reports those)
+	encodings map[string]*EncodingStats
+	timings   map[string]*SegmentTiming // By URL
+}
+
+// NewMonitor creates a playlist monitor.
+func NewMonitor(cfg MonitorConfig) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &Monitor{
+		cfg:       cfg,
+		counts:    make(map[ViolationKind]int64),
+		encodings: make(map[string]*EncodingStats),
+		timings:   make(map[string]*SegmentTiming),
+	}
+}
+
+// Run reloads every URL until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, u := range m.cfg.URLs {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			m.watch(ctx, u)
+		}(u)
+	}
+	wg.Wait()
+}
+
+func (m *Monitor) watch(ctx context.Context, url string) {
+	validator := NewValidator()
+	timing := NewSegmentTiming()
+	m.mu.Lock()
+	m.timings[url] = timing
+	m.mu.Unlock()
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		pl, info, err := m.cfg.Prober.FetchWithInfo(ctx, url)
+		if info.Encoding != "" {
+			var decodeErr *DecodeError
+			m.recordFetch(url, info, errors.As(err, &decodeErr))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			m.mu.Lock()
+			m.errors++
+			m.mu.Unlock()
+			m.cfg.Logger.Debug("playlist_monitor_fetch_failed", "url", url, "error", err)
+		} else {
+			now := time.Now()
+			for _, v := range validator.Observe(pl, now) {
+				m.record(url, v)
+			}
+			m.observeTiming(url, timing, pl, now)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) record(url string, v Violation) {
+	m.mu.Lock()
+	m.counts[v.Kind]++
+	m.mu.Unlock()
+
+	m.cfg.Logger.Warn("playlist_violation", "url", url, "kind", string(v.Kind), "detail", v.Detail)
+	if m.cfg.OnViolation != nil {
+		m.cfg.OnViolation(url, v)
+	}
+}
+
+// observeTiming records a reload's new segments, logging when their pace
+// starts or stops drifting from what #EXTINF says.
+func (m *Monitor) observeTiming(url string, timing *SegmentTiming, pl *Playlist, now time.Time) {
+	m.mu.Lock()
+	change, drifting := timing.Observe(pl, now)
+	m.mu.Unlock()
+
+	switch {
+	case change == "":
+	case drifting:
+		m.cfg.Logger.Warn("segment_timing_drift", "url", url, "detail", change)
+	default:
+		m.cfg.Logger.Info("segment_timing_recovered", "url", url, "detail", change)
+	}
+}
+
+func (m *Monitor) recordFetch(url string, info FetchInfo, decodeFailed bool) {
+	m.mu.Lock()
+	es := m.encodings[info.Encoding]
+	if es == nil {
+		es = &EncodingStats{}
+		m.encodings[info.Encoding] = es
+	}
+	es.Fetches++
+	es.WireBytes += info.WireBytes
+	es.DecodedBytes += info.DecodedBytes
+	if decodeFailed {
+		es.DecodeFailures++
+	}
+	m.mu.Unlock()
+
+	if m.cfg.OnFetch != nil {
+		m.cfg.OnFetch(url, info, decodeFailed)
+	}
+}
+
+// Violations returns a copy of the violation counts by kind.
+func (m *Monitor) Violations() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]int64, len(m.counts))
+	for kind, n := range m.counts {
+		out[string(kind)] = n
+	}
+	return out
+}
+
+// FetchErrors returns the number of failed reloads.
+func (m *Monitor) FetchErrors() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.errors
+}
+
+// SegmentTimings returns each URL's segment timing so far.
+func (m *Monitor) SegmentTimings() map[string]SegmentTimingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]SegmentTimingStats, len(m.timings))
+	for url, t := range m.timings {
+		out[url] = t.Stats()
+	}
+	return out
+}
+
+// Encodings returns a copy of the reload counts by Content-Encoding
+// ("identity" for uncompressed responses).
+func (m *Monitor) Encodings() map[string]EncodingStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]EncodingStats, len(m.encodings))
+	for enc, es := range m.encodings {
+		out[enc] = *es
+	}
+	return out
+}
@@ -0,0 +1,229 @@
+// Package manifest provides a minimal HLS playlist prober.
+//
+// It is deliberately small: FFmpeg does the real playlist handling during a
+// run. This package only fetches and parses enough of the master and one
+// media playlist to size a test before it starts (variant count, bitrates,
+// segment duration), and to check that reloads under load stay compliant
+// (see Validator).
+package manifest
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Variant is one #EXT-X-STREAM-INF entry from a master playlist.
+type Variant struct {
+	URI          string // Resolved against the master playlist URL
+	Bandwidth    int64  // BANDWIDTH attribute (bits/sec, peak)
+	AvgBandwidth int64  // AVERAGE-BANDWIDTH attribute (0 if absent)
+	Resolution   string // RESOLUTION attribute (e.g. "1920x1080")
+}
+
+// Rendition is one #EXT-X-MEDIA entry from a master playlist: an
+// alternate audio, subtitle or video rendition of a group.
+type Rendition struct {
+	Type     string // TYPE attribute: AUDIO, SUBTITLES, VIDEO or CLOSED-CAPTIONS
+	GroupID  string // GROUP-ID attribute
+	Language string // LANGUAGE attribute ("" if absent)
+	Name     string // NAME attribute
+	URI      string // Resolved against the master playlist URL ("" = muxed into the variant)
+	Default  bool   // DEFAULT=YES
+}
+
+// Segment is one media segment entry.
+type Segment struct {
+	URI           string
+	Duration      time.Duration // #EXTINF
+	Discontinuity bool          // Preceded by #EXT-X-DISCONTINUITY
+}
+
+// Playlist is the parsed subset of an HLS playlist that the prober needs.
+type Playlist struct {
+	// HasHeader is true if the first line is #EXTM3U (required by RFC 8216).
+	HasHeader bool
+
+	// IsMaster is true if the playlist lists variants rather than segments.
+	IsMaster bool
+
+	// Variants (master playlists only), in playlist order.
+	Variants []Variant
+
+	// Renditions (master playlists only), in playlist order.
+	Renditions []Rendition
+
+	// Media playlist fields
+	TargetDuration        time.Duration
+	Segments              []Segment
+	TotalDuration         time.Duration // Sum of #EXTINF durations
+	MediaSequence         int64
+	DiscontinuitySequence int64
+	Ended                 bool // #EXT-X-ENDLIST present (VOD)
+}
+
+// AvgSegmentDuration returns the mean #EXTINF duration, falling back to
+// the target duration when the playlist has no segments.
+func (p *Playlist) AvgSegmentDuration() time.Duration {
+	if len(p.Segments) == 0 {
+		return p.TargetDuration
+	}
+	return p.TotalDuration / time.Duration(len(p.Segments))
+}
+
+// Parse reads an HLS playlist. base resolves relative variant URIs and may be nil.
+// Unknown tags are ignored; this is not a validating parser.
+func Parse(r io.Reader, base *url.URL) (*Playlist, error) {
+	p := &Playlist{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var pending *Variant // #EXT-X-STREAM-INF waiting for its URI line
+	var segment *Segment // #EXTINF waiting for its URI line
+	discontinuity := false
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if first && line != "" {
+			p.HasHeader = line == "#EXTM3U"
+			first = false
+		}
+		switch {
+		case line == "":
+			continue
+
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			p.IsMaster = true
+			pending = parseStreamInf(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"):
+			p.IsMaster = true
+			p.Renditions = append(p.Renditions, parseMedia(strings.TrimPrefix(line, "#EXT-X-MEDIA:"), base))
+
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			if secs, err := strconv.ParseFloat(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"), 64); err == nil {
+				p.TargetDuration = secondsToDuration(secs)
+			}
+
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			if seq, err := strconv.ParseInt(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"), 10, 64); err == nil {
+				p.MediaSequence = seq
+			}
+
+		case strings.HasPrefix(line, "#EXT-X-DISCONTINUITY-SEQUENCE:"):
+			if seq, err := strconv.ParseInt(strings.TrimPrefix(line, "#EXT-X-DISCONTINUITY-SEQUENCE:"), 10, 64); err == nil {
+				p.DiscontinuitySequence = seq
+			}
+
+		case line == "#EXT-X-DISCONTINUITY":
+			discontinuity = true
+
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value := strings.TrimPrefix(line, "#EXTINF:")
+			value, _, _ = strings.Cut(value, ",")
+			if secs, err := strconv.ParseFloat(value, 64); err == nil {
+				segment = &Segment{Duration: secondsToDuration(secs), Discontinuity: discontinuity}
+				discontinuity = false
+			}
+
+		case line == "#EXT-X-ENDLIST":
+			p.Ended = true
+
+		case strings.HasPrefix(line, "#"):
+			// Other tags/comments are not needed for sizing
+
+		default:
+			// URI line
+			if pending != nil {
+				pending.URI = resolve(base, line)
+				p.Variants = append(p.Variants, *pending)
+				pending = nil
+			} else if segment != nil {
+				segment.URI = line
+				p.Segments = append(p.Segments, *segment)
+				p.TotalDuration += segment.Duration
+				segment = nil
+			}
+		}
+	}
+	return p, scanner.Err()
+}
+
+// parseStreamInf extracts the attributes we care about from #EXT-X-STREAM-INF.
+func parseStreamInf(attrs string) *Variant {
+	v := &Variant{}
+	for key, value := range parseAttributes(attrs) {
+		switch key {
+		case "BANDWIDTH":
+			v.Bandwidth, _ = strconv.ParseInt(value, 10, 64)
+		case "AVERAGE-BANDWIDTH":
+			v.AvgBandwidth, _ = strconv.ParseInt(value, 10, 64)
+		case "RESOLUTION":
+			v.Resolution = value
+		}
+	}
+	return v
+}
+
+// parseMedia extracts the attributes we care about from #EXT-X-MEDIA.
+func parseMedia(attrs string, base *url.URL) Rendition {
+	a := parseAttributes(attrs)
+	r := Rendition{
+		Type:     a["TYPE"],
+		GroupID:  a["GROUP-ID"],
+		Language: a["LANGUAGE"],
+		Name:     a["NAME"],
+		Default:  a["DEFAULT"] == "YES",
+	}
+	if uri := a["URI"]; uri != "" {
+		r.URI = resolve(base, uri)
+	}
+	return r
+}
+
+// parseAttributes splits an HLS attribute list, honouring quoted values
+// (CODECS="avc1.4d401f,mp4a.40.2" contains a comma).
+func parseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for len(s) > 0 {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(key)
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, s = rest[1:], ""
+			} else {
+				value, s = rest[1:1+end], rest[2+end:]
+			}
+			s = strings.TrimPrefix(s, ",")
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		attrs[key] = value
+	}
+	return attrs
+}
+
+// resolve makes a playlist URI absolute.
+func resolve(base *url.URL, ref string) string {
+	if base == nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return base.ResolveReference(u).String()
+}
+
+func secondsToDuration(secs float64) time.Duration {
+	return time.Duration(secs * float64(time.Second))
+}
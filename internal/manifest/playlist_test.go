@@ -0,0 +1,165 @@
+package manifest
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMaster = `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=800000,AVERAGE-BANDWIDTH=700000,RESOLUTION=640x360,CODECS="avc1.4d401e,mp4a.40.2"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,RESOLUTION=1920x1080
+https://cdn.example.com/high/index.m3u8
+`
+
+const testMedia = `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-MEDIA-SEQUENCE:42
+#EXTINF:2.000,
+seg42.ts
+#EXTINF:2.000,
+seg43.ts
+#EXTINF:1.500,
+seg44.ts
+`
+
+func TestParse_Master(t *testing.T) {
+	base, _ := url.Parse("http://origin.example.com/live/master.m3u8")
+	pl, err := Parse(strings.NewReader(testMaster), base)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if !pl.IsMaster {
+		t.Fatal("IsMaster = false, want true")
+	}
+	if len(pl.Variants) != 2 {
+		t.Fatalf("len(Variants) = %d, want 2", len(pl.Variants))
+	}
+
+	low := pl.Variants[0]
+	if low.URI != "http://origin.example.com/live/low/index.m3u8" {
+		t.Errorf("relative URI resolved to %q", low.URI)
+	}
+	if low.Bandwidth != 800000 || low.AvgBandwidth != 700000 || low.Resolution != "640x360" {
+		t.Errorf("low variant = %+v", low)
+	}
+	if pl.Variants[1].URI != "https://cdn.example.com/high/index.m3u8" {
+		t.Errorf("absolute URI changed to %q", pl.Variants[1].URI)
+	}
+}
+
+func TestParse_Renditions(t *testing.T) {
+	const master = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="en",NAME="English",DEFAULT=YES,URI="audio/en/index.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="fr",NAME="Français",URI="audio/fr/index.m3u8"
+#EXT-X-MEDIA:TYPE=CLOSED-CAPTIONS,GROUP-ID="cc",NAME="CC1",INSTREAM-ID="CC1"
+#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO="aac",CLOSED-CAPTIONS="cc"
+low/index.m3u8
+`
+	base, _ := url.Parse("http://origin.example.com/live/master.m3u8")
+	pl, err := Parse(strings.NewReader(master), base)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(pl.Renditions) != 3 || len(pl.Variants) != 1 {
+		t.Fatalf("renditions = %+v, variants = %d; want 3 and 1", pl.Renditions, len(pl.Variants))
+	}
+	want := Rendition{Type: "AUDIO", GroupID: "aac", Language: "en", Name: "English", Default: true, URI: "http://origin.example.com/live/audio/en/index.m3u8"}
+	if pl.Renditions[0] != want {
+		t.Errorf("rendition 0 = %+v, want %+v", pl.Renditions[0], want)
+	}
+	if r := pl.Renditions[2]; r.URI != "" || r.Default {
+		t.Errorf("closed captions = %+v, want no URI (muxed)", r)
+	}
+}
+
+func TestParse_Media(t *testing.T) {
+	pl, err := Parse(strings.NewReader(testMedia), nil)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if pl.IsMaster {
+		t.Error("IsMaster = true, want false")
+	}
+	if pl.TargetDuration != 2*time.Second {
+		t.Errorf("TargetDuration = %v, want 2s", pl.TargetDuration)
+	}
+	if pl.MediaSequence != 42 {
+		t.Errorf("MediaSequence = %d, want 42", pl.MediaSequence)
+	}
+	if len(pl.Segments) != 3 {
+		t.Fatalf("len(Segments) = %d, want 3", len(pl.Segments))
+	}
+	if pl.Segments[2].URI != "seg44.ts" || pl.Segments[2].Duration != 1500*time.Millisecond {
+		t.Errorf("Segments[2] = %+v", pl.Segments[2])
+	}
+	if !pl.HasHeader {
+		t.Error("HasHeader = false, want true")
+	}
+	if got := pl.AvgSegmentDuration(); got != 1833333333*time.Nanosecond {
+		t.Errorf("AvgSegmentDuration = %v, want ~1.833s", got)
+	}
+	if pl.Ended {
+		t.Error("Ended = true for live playlist")
+	}
+}
+
+func TestParse_VODAndEmpty(t *testing.T) {
+	pl, err := Parse(strings.NewReader("#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXT-X-ENDLIST\n"), nil)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !pl.Ended {
+		t.Error("Ended = false, want true")
+	}
+	// No segments: fall back to target duration
+	if pl.AvgSegmentDuration() != 6*time.Second {
+		t.Errorf("AvgSegmentDuration = %v, want 6s", pl.AvgSegmentDuration())
+	}
+}
+
+func TestParseAttributes(t *testing.T) {
+	attrs := parseAttributes(`BANDWIDTH=1,CODECS="a,b",NAME="x"`)
+	if attrs["BANDWIDTH"] != "1" || attrs["CODECS"] != "a,b" || attrs["NAME"] != "x" {
+		t.Errorf("parseAttributes = %v", attrs)
+	}
+}
+
+func TestParse_Discontinuity(t *testing.T) {
+	input := `#EXTM3U
+#EXT-X-TARGETDURATION:2
+#EXT-X-DISCONTINUITY-SEQUENCE:7
+#EXTINF:2,
+a.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:2,
+ad1.ts
+#EXTINF:2,
+ad2.ts
+`
+	pl, err := Parse(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if pl.DiscontinuitySequence != 7 {
+		t.Errorf("DiscontinuitySequence = %d, want 7", pl.DiscontinuitySequence)
+	}
+	want := []bool{false, true, false}
+	for i, seg := range pl.Segments {
+		if seg.Discontinuity != want[i] {
+			t.Errorf("Segments[%d].Discontinuity = %v, want %v", i, seg.Discontinuity, want[i])
+		}
+	}
+}
+
+func TestParse_MissingHeader(t *testing.T) {
+	pl, _ := Parse(strings.NewReader("#EXT-X-TARGETDURATION:2\n"), nil)
+	if pl.HasHeader {
+		t.Error("HasHeader = true without #EXTM3U")
+	}
+}
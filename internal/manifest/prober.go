@@ -0,0 +1,342 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxPlaylistBytes bounds how much of a playlist the prober will read.
+const maxPlaylistBytes = 4 * 1024 * 1024
+
+// ProberConfig configures a Prober. Fields mirror the FFmpeg options so the
+// probe reaches the same origin the clients will.
+type ProberConfig struct {
+	Timeout       time.Duration
+	UserAgent     string
+	Headers       []string // "Name: value"
+	ResolveIP     string   // Connect to this IP instead of DNS
+	DangerousMode bool     // Skip TLS verification (required with ResolveIP)
+
+	// AcceptEncoding to request ("" = gzip, as Go's client would)
+	AcceptEncoding string
+
+	// NoCache sends the -no-cache headers, as the clients do
+	NoCache bool
+
+	// NoKeepAlive opens a new connection for every request
+	NoKeepAlive bool
+}
+
+// Validators are a playlist response's cache validators. A player with a
+// cache sends them back on its next reload (If-None-Match,
+// If-Modified-Since) and the origin answers 304 if nothing changed.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// ErrNotModified is FetchConditional's error for a 304 response.
+var ErrNotModified = errors.New("playlist not modified")
+
+// FetchInfo describes how a playlist response was encoded on the wire.
+type FetchInfo struct {
+	Encoding     string // Content-Encoding, "identity" if none
+	WireBytes    int64  // Body bytes received
+	DecodedBytes int64  // Body bytes after decoding
+}
+
+// DecodeError is a response the prober could not decode: a corrupt body
+// or a coding it doesn't support (br).
+type DecodeError struct {
+	Encoding string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s playlist: %v", e.Encoding, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// errUnsupportedEncoding is the DecodeError cause for codings without a
+// decoder in the standard library.
+var errUnsupportedEncoding = errors.New("unsupported content coding")
+
+// ProbeResult describes a stream as seen before the test starts.
+type ProbeResult struct {
+	URL string
+
+	// Variants from the master playlist (empty if URL is a media playlist).
+	Variants []Variant
+
+	// Media is the first media playlist (or URL itself if not a master).
+	Media *Playlist
+}
+
+// Prober fetches and parses playlists.
+type Prober struct {
+	client         *http.Client
+	userAgent      string
+	headers        []string
+	acceptEncoding string
+	noCache        bool
+}
+
+// NewProber creates a prober.
+func NewProber(cfg ProberConfig) *Prober {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.ResolveIP != "" {
+		dialer := &net.Dialer{Timeout: timeout}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(cfg.ResolveIP, port))
+		}
+	}
+	if cfg.DangerousMode {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // explicit --dangerous
+	}
+	// Decode bodies here, so compressed responses can be measured
+	transport.DisableCompression = true
+	transport.DisableKeepAlives = cfg.NoKeepAlive
+
+	return &Prober{
+		client:         &http.Client{Timeout: timeout, Transport: transport},
+		userAgent:      cfg.UserAgent,
+		headers:        cfg.Headers,
+		acceptEncoding: cfg.AcceptEncoding,
+		noCache:        cfg.NoCache,
+	}
+}
+
+// Probe fetches rawURL and, if it is a master playlist, its first variant.
+func (p *Prober) Probe(ctx context.Context, rawURL string) (*ProbeResult, error) {
+	top, err := p.Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProbeResult{URL: rawURL, Media: top}
+	if !top.IsMaster {
+		return result, nil
+	}
+
+	result.Variants = top.Variants
+	if len(top.Variants) == 0 {
+		return nil, fmt.Errorf("master playlist %s lists no variants", rawURL)
+	}
+
+	// Segment durations are normally uniform across renditions, so one
+	// media playlist is enough for sizing.
+	media, err := p.Fetch(ctx, top.Variants[0].URI)
+	if err != nil {
+		return nil, fmt.Errorf("fetch variant playlist: %w", err)
+	}
+	result.Media = media
+	return result, nil
+}
+
+// Fetch downloads and parses a single playlist.
+func (p *Prober) Fetch(ctx context.Context, rawURL string) (*Playlist, error) {
+	pl, _, err := p.FetchWithInfo(ctx, rawURL)
+	return pl, err
+}
+
+// FetchWithInfo is Fetch that also reports the response encoding. The
+// FetchInfo is filled whenever a response was received, including when
+// decoding fails (a *DecodeError).
+func (p *Prober) FetchWithInfo(ctx context.Context, rawURL string) (*Playlist, FetchInfo, error) {
+	pl, info, _, err := p.fetch(ctx, rawURL, Validators{})
+	return pl, info, err
+}
+
+// FetchConditional is Fetch as a player with a cache reloads: with the
+// validators of its last response, if any. It returns the validators to
+// send next time, and ErrNotModified when the origin answered 304.
+func (p *Prober) FetchConditional(ctx context.Context, rawURL string, v Validators) (*Playlist, Validators, error) {
+	pl, _, next, err := p.fetch(ctx, rawURL, v)
+	return pl, next, err
+}
+
+// fetch sends the request, conditional on v if set, and reads the
+// playlist. next is v updated from the response's validators.
+func (p *Prober) fetch(ctx context.Context, rawURL string, v Validators) (_ *Playlist, info FetchInfo, next Validators, _ error) {
+	next = v
+	base, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, info, next, fmt.Errorf("invalid playlist URL: %w", err)
+	}
+
+	req, err := p.newRequest(ctx, rawURL)
+	if err != nil {
+		return nil, info, next, err
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, info, next, fmt.Errorf("fetch playlist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// A 304 may leave the validators out: they are still the same
+	if etag := resp.Header.Get("ETag"); etag != "" || resp.StatusCode == http.StatusOK {
+		next.ETag = etag
+	}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" || resp.StatusCode == http.StatusOK {
+		next.LastModified = lm
+	}
+
+	if resp.StatusCode == http.StatusNotModified && (v.ETag != "" || v.LastModified != "") {
+		return nil, info, next, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, info, next, fmt.Errorf("fetch playlist %s: HTTP %d", rawURL, resp.StatusCode)
+	}
+
+	// Read the whole body first: a corrupt compressed stream should be a
+	// decode failure, not a half-parsed playlist
+	info.Encoding = strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if info.Encoding == "" {
+		info.Encoding = "identity"
+	}
+	wire := &countingReader{r: resp.Body}
+	decoded, err := decodeBody(info.Encoding, wire)
+	if err == nil {
+		var body []byte
+		body, err = io.ReadAll(io.LimitReader(decoded, maxPlaylistBytes+1))
+		info.DecodedBytes = int64(len(body))
+		if err == nil && len(body) > maxPlaylistBytes {
+			err = fmt.Errorf("playlist exceeds %d bytes", maxPlaylistBytes)
+		}
+		if err == nil {
+			decoded = bytes.NewReader(body)
+		}
+	}
+	if err != nil {
+		_, _ = io.Copy(io.Discard, io.LimitReader(wire, maxPlaylistBytes)) // Count the rest of the body
+	}
+	info.WireBytes = wire.n
+	if err != nil {
+		if info.Encoding != "identity" {
+			err = &DecodeError{Encoding: info.Encoding, Err: err}
+		}
+		return nil, info, next, fmt.Errorf("fetch playlist %s: %w", rawURL, err)
+	}
+
+	pl, err := Parse(decoded, base)
+	if err != nil {
+		return nil, info, next, fmt.Errorf("parse playlist %s: %w", rawURL, err)
+	}
+	return pl, info, next, nil
+}
+
+// Request sends a GET for rawURL with the probe's headers and returns the
+// response status, discarding the body: a request that tests the origin's
+// connections rather than its playlists. The error is a transport error
+// (the connection was refused, reset or timed out).
+func (p *Prober) Request(ctx context.Context, rawURL string) (int, error) {
+	req, err := p.newRequest(ctx, rawURL)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, maxPlaylistBytes)); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
+
+// newRequest returns a GET for rawURL with the headers the clients send.
+func (p *Prober) newRequest(ctx context.Context, rawURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.userAgent != "" {
+		req.Header.Set("User-Agent", p.userAgent+"/probe")
+	}
+	for _, h := range p.headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			continue
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Add(name, value)
+	}
+	if p.acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", p.acceptEncoding)
+	} else if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if p.noCache {
+		req.Header.Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		req.Header.Set("Pragma", "no-cache")
+	}
+	return req, nil
+}
+
+// decodeBody wraps r in a decoder for a Content-Encoding.
+func decodeBody(encoding string, r io.Reader) (io.Reader, error) {
+	switch encoding {
+	case "identity":
+		return r, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		// RFC 9110 deflate is zlib-wrapped, but some servers send raw
+		// deflate: tell them apart by the zlib header
+		br := bufio.NewReader(r)
+		if hdr, err := br.Peek(2); err == nil && hdr[0]&0x0f == 8 && (uint16(hdr[0])<<8|uint16(hdr[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	default:
+		return nil, errUnsupportedEncoding
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,253 @@
+package manifest
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProber_Probe_MasterAndMedia(t *testing.T) {
+	var gotUA, gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/live/master.m3u8":
+			gotUA = r.Header.Get("User-Agent")
+			gotHeader = r.Header.Get("X-Test")
+			w.Write([]byte(testMaster))
+		case "/live/low/index.m3u8":
+			w.Write([]byte(testMedia))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewProber(ProberConfig{
+		Timeout:   2 * time.Second,
+		UserAgent: "swarm/1.0",
+		Headers:   []string{"X-Test: yes"},
+	})
+	res, err := p.Probe(context.Background(), srv.URL+"/live/master.m3u8")
+	if err != nil {
+		t.Fatalf("Probe: %v", err)
+	}
+
+	if len(res.Variants) != 2 {
+		t.Errorf("len(Variants) = %d, want 2", len(res.Variants))
+	}
+	if res.Media == nil || res.Media.TargetDuration != 2*time.Second {
+		t.Errorf("media playlist not fetched: %+v", res.Media)
+	}
+	if gotUA != "swarm/1.0/probe" {
+		t.Errorf("User-Agent = %q, want swarm/1.0/probe", gotUA)
+	}
+	if gotHeader != "yes" {
+		t.Errorf("custom header = %q, want yes", gotHeader)
+	}
+}
+
+func TestProber_Probe_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	_, err := NewProber(ProberConfig{}).Probe(context.Background(), srv.URL+"/missing.m3u8")
+	if err == nil {
+		t.Error("expected error for 404")
+	}
+}
+
+// compress encodes body with a Content-Encoding ("deflate-raw" for a
+// headerless deflate stream).
+func compress(t *testing.T, encoding, body string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "deflate-raw":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		t.Fatalf("compress: unknown encoding %q", encoding)
+	}
+	if _, err := io.WriteString(w, body); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProber_FetchWithInfo_Encodings(t *testing.T) {
+	tests := []struct {
+		name       string
+		accept     string
+		wantAccept string
+		encoding   string // Content-Encoding served
+		body       func(t *testing.T) []byte
+		wantDecode bool // Want a *DecodeError
+	}{
+		{"identity", "identity", "identity", "", func(*testing.T) []byte { return []byte(testMedia) }, false},
+		{"gzip by default", "", "gzip", "gzip", func(t *testing.T) []byte { return compress(t, "gzip", testMedia) }, false},
+		{"deflate (zlib)", "deflate", "deflate", "deflate", func(t *testing.T) []byte { return compress(t, "deflate", testMedia) }, false},
+		{"deflate (raw)", "deflate", "deflate", "deflate", func(t *testing.T) []byte { return compress(t, "deflate-raw", testMedia) }, false},
+		{"corrupt gzip", "gzip", "gzip", "gzip", func(*testing.T) []byte { return []byte("\x1f\x8bnot gzip at all") }, true},
+		{"brotli", "br", "br", "br", func(*testing.T) []byte { return []byte("opaque brotli bytes") }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body(t)
+			var gotAccept string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAccept = r.Header.Get("Accept-Encoding")
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.Write(body)
+			}))
+			defer srv.Close()
+
+			p := NewProber(ProberConfig{Timeout: 2 * time.Second, AcceptEncoding: tt.accept})
+			pl, info, err := p.FetchWithInfo(context.Background(), srv.URL+"/live/index.m3u8")
+
+			if gotAccept != tt.wantAccept {
+				t.Errorf("Accept-Encoding = %q, want %q", gotAccept, tt.wantAccept)
+			}
+			if info.WireBytes != int64(len(body)) {
+				t.Errorf("WireBytes = %d, want %d", info.WireBytes, len(body))
+			}
+			var decodeErr *DecodeError
+			if errors.As(err, &decodeErr) != tt.wantDecode {
+				t.Fatalf("FetchWithInfo() error = %v, want decode error %v", err, tt.wantDecode)
+			}
+			if tt.wantDecode {
+				return
+			}
+			if err != nil || pl == nil || len(pl.Segments) == 0 {
+				t.Fatalf("FetchWithInfo() = %+v, %v", pl, err)
+			}
+			wantEnc := tt.encoding
+			if wantEnc == "" {
+				wantEnc = "identity"
+			}
+			if info.Encoding != wantEnc || info.DecodedBytes != int64(len(testMedia)) {
+				t.Errorf("info = %+v, want %s with %d decoded bytes", info, wantEnc, len(testMedia))
+			}
+		})
+	}
+}
+
+func TestProber_FetchConditional(t *testing.T) {
+	const etag = `"v1"`
+	var gotINM, gotIMS, gotCacheControl string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotINM, gotIMS = r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+		gotCacheControl = r.Header.Get("Cache-Control")
+		if gotINM == etag {
+			w.WriteHeader(http.StatusNotModified) // Validators left out
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Thu, 15 Oct 2026 10:00:00 GMT")
+		w.Write([]byte(testMedia))
+	}))
+	defer srv.Close()
+	url := srv.URL + "/live/index.m3u8"
+	p := NewProber(ProberConfig{Timeout: 2 * time.Second})
+
+	// First fetch: unconditional, full playlist
+	pl, v, err := p.FetchConditional(context.Background(), url, Validators{})
+	if err != nil || pl == nil {
+		t.Fatalf("FetchConditional() = %v, %v", pl, err)
+	}
+	if gotINM != "" || gotIMS != "" {
+		t.Errorf("first fetch sent If-None-Match %q, If-Modified-Since %q", gotINM, gotIMS)
+	}
+	if v.ETag != etag || v.LastModified == "" {
+		t.Errorf("validators = %+v, want the response's", v)
+	}
+
+	// Reload with them: 304, validators kept
+	pl, next, err := p.FetchConditional(context.Background(), url, v)
+	if !errors.Is(err, ErrNotModified) || pl != nil {
+		t.Fatalf("FetchConditional() = %v, %v; want ErrNotModified", pl, err)
+	}
+	if gotINM != etag || gotIMS != v.LastModified {
+		t.Errorf("sent If-None-Match %q, If-Modified-Since %q; want %q, %q", gotINM, gotIMS, etag, v.LastModified)
+	}
+	if next != v {
+		t.Errorf("validators after 304 = %+v, want %+v", next, v)
+	}
+
+	// Plain fetches stay unconditional; -no-cache headers when asked
+	p = NewProber(ProberConfig{Timeout: 2 * time.Second, NoCache: true})
+	if _, err := p.Fetch(context.Background(), url); err != nil {
+		t.Fatal(err)
+	}
+	if gotINM != "" || gotCacheControl != "no-cache, no-store, must-revalidate" {
+		t.Errorf("If-None-Match %q, Cache-Control %q; want none, no-cache", gotINM, gotCacheControl)
+	}
+}
+
+func TestProber_Fetch_Unsolicited304(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	// Not asked to revalidate: a 304 is a broken response, not a cache hit
+	_, _, err := NewProber(ProberConfig{}).FetchConditional(context.Background(), srv.URL+"/index.m3u8", Validators{})
+	if err == nil || errors.Is(err, ErrNotModified) {
+		t.Errorf("FetchConditional() error = %v, want an HTTP 304 error", err)
+	}
+}
+
+func TestProber_Request_NoKeepAlive(t *testing.T) {
+	var gotHeader string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Test")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "busy")
+	}))
+	var conns atomic.Int32
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	p := NewProber(ProberConfig{Headers: []string{"X-Test: yes"}, NoKeepAlive: true})
+	for i := 0; i < 3; i++ {
+		status, err := p.Request(context.Background(), srv.URL+"/live.m3u8")
+		if err != nil || status != http.StatusServiceUnavailable {
+			t.Fatalf("Request() = %d, %v; want 503", status, err)
+		}
+	}
+	if gotHeader != "yes" {
+		t.Errorf("X-Test = %q, want the configured header", gotHeader)
+	}
+	if n := conns.Load(); n != 3 {
+		t.Errorf("%d connections for 3 requests, want one each", n)
+	}
+
+	srv.Close()
+	if _, err := p.Request(context.Background(), srv.URL); err == nil {
+		t.Error("Request() to a closed server = nil error")
+	}
+}
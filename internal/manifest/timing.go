@@ -0,0 +1,174 @@
+package manifest
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// timingWindow is how many of the newest segments the pace drift check
+	// looks at: enough to average out the reload interval's granularity.
+	timingWindow = 10
+
+	// timingDriftTolerance is the largest difference between the pace new
+	// segments appear at and their #EXTINF durations that still counts as
+	// real time (0.1 = 10%).
+	timingDriftTolerance = 0.1
+)
+
+// SegmentTimingStats summarizes one media playlist's segmenting: the
+// #EXTINF durations of the segments seen, and the pace new segments
+// actually appeared at, inferred from the media sequence.
+type SegmentTimingStats struct {
+	Target   time.Duration // #EXT-X-TARGETDURATION (latest)
+	Segments int64         // Segments whose #EXTINF was recorded
+	Mean     time.Duration // #EXTINF
+	StdDev   time.Duration
+	Min      time.Duration
+	Max      time.Duration
+
+	// Pace is the wall time per new segment since the first reload, over
+	// PaceSegments segments (0 until the media sequence advanced; VOD
+	// playlists never do)
+	Pace         time.Duration
+	PaceSegments int64
+}
+
+// SegmentTiming follows one media playlist's segmenting across reloads.
+// Each segment's #EXTINF is recorded once, when it first appears, and its
+// arrival time gives the pace the packager is producing at. Not safe for
+// concurrent use.
+type SegmentTiming struct {
+	target time.Duration
+	newest int64 // Media sequence of the newest segment seen (-1 = none)
+
+	// #EXTINF running totals, in seconds
+	n          int64
+	sum, sumSq float64
+	min, max   time.Duration
+
+	// Pace since the first reload
+	firstAt  time.Time
+	firstSeq int64
+	lastAt   time.Time // When the newest segment appeared
+
+	// The newest segments' arrival, for the drift check
+	recent   []timedSegment
+	drifting bool
+}
+
+// timedSegment is a segment's arrival time and #EXTINF.
+type timedSegment struct {
+	at       time.Time
+	duration time.Duration
+}
+
+// NewSegmentTiming returns an empty tracker.
+func NewSegmentTiming() *SegmentTiming {
+	return &SegmentTiming{newest: -1}
+}
+
+// Observe records the segments of pl (fetched at now) that are new since
+// the last reload. When the pace of the newest segments starts (drifting)
+// or stops drifting from their #EXTINF durations by more than 10%, it
+// returns a message saying so; otherwise "". Master playlists are ignored.
+func (t *SegmentTiming) Observe(pl *Playlist, now time.Time) (change string, drifting bool) {
+	if pl == nil || pl.IsMaster || len(pl.Segments) == 0 {
+		return "", t.drifting
+	}
+	t.target = pl.TargetDuration
+	last := pl.MediaSequence + int64(len(pl.Segments)) - 1
+
+	if t.newest < 0 || last < t.newest {
+		// First reload, or the sequence restarted: the segments already
+		// listed were made before we looked, so they say nothing of pace
+		for _, seg := range pl.Segments {
+			t.record(seg.Duration)
+		}
+		t.newest, t.firstSeq, t.firstAt, t.lastAt = last, last, now, now
+		t.recent = t.recent[:0]
+		return "", t.drifting
+	}
+
+	if pl.MediaSequence > t.newest+1 {
+		t.recent = t.recent[:0] // Segments came and went between reloads
+	}
+	for seq := max(t.newest+1, pl.MediaSequence); seq <= last; seq++ {
+		seg := pl.Segments[seq-pl.MediaSequence]
+		t.record(seg.Duration)
+		t.recent = append(t.recent, timedSegment{at: now, duration: seg.Duration})
+	}
+	if last == t.newest {
+		return "", t.drifting
+	}
+	t.newest, t.lastAt = last, now
+	if len(t.recent) > timingWindow+1 {
+		t.recent = t.recent[len(t.recent)-timingWindow-1:]
+	}
+	return t.checkDrift()
+}
+
+// record adds one segment's #EXTINF to the running totals.
+func (t *SegmentTiming) record(d time.Duration) {
+	if t.n == 0 || d < t.min {
+		t.min = d
+	}
+	if d > t.max {
+		t.max = d
+	}
+	t.n++
+	s := d.Seconds()
+	t.sum += s
+	t.sumSq += s * s
+}
+
+// checkDrift compares how long the newest timingWindow segments took to
+// appear with their #EXTINF durations. The first of the window only marks
+// the start.
+func (t *SegmentTiming) checkDrift() (string, bool) {
+	if len(t.recent) <= timingWindow {
+		return "", t.drifting
+	}
+	var advertised time.Duration
+	for _, s := range t.recent[1:] {
+		advertised += s.duration
+	}
+	if advertised <= 0 {
+		return "", t.drifting
+	}
+	elapsed := t.recent[len(t.recent)-1].at.Sub(t.recent[0].at)
+	drift := float64(elapsed-advertised) / float64(advertised)
+
+	drifting := math.Abs(drift) > timingDriftTolerance
+	if drifting == t.drifting {
+		return "", drifting
+	}
+	t.drifting = drifting
+	verb := "back within"
+	if drifting {
+		verb = "drifting beyond"
+	}
+	return fmt.Sprintf("last %d segments took %v for %v of #EXTINF (%+.0f%%), %s ±%.0f%%",
+		timingWindow, elapsed.Round(time.Millisecond), advertised.Round(time.Millisecond),
+		drift*100, verb, timingDriftTolerance*100), drifting
+}
+
+// Stats returns the summary so far.
+func (t *SegmentTiming) Stats() SegmentTimingStats {
+	s := SegmentTimingStats{Target: t.target, Segments: t.n, Min: t.min, Max: t.max}
+	if t.n > 0 {
+		mean := t.sum / float64(t.n)
+		s.Mean = secondsDuration(mean)
+		s.StdDev = secondsDuration(math.Sqrt(max(t.sumSq/float64(t.n)-mean*mean, 0)))
+	}
+	if n := t.newest - t.firstSeq; n > 0 {
+		s.PaceSegments = n
+		s.Pace = t.lastAt.Sub(t.firstAt) / time.Duration(n)
+	}
+	return s
+}
+
+func secondsDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
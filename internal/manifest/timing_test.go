@@ -0,0 +1,118 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSegmentTiming_Steady(t *testing.T) {
+	timing := NewSegmentTiming()
+	now := time.Unix(1000, 0)
+	for seq := int64(0); seq <= 20; seq++ {
+		if change, drifting := timing.Observe(livePlaylist(seq, 0), now); change != "" || drifting {
+			t.Fatalf("seq %d: unexpected drift %q", seq, change)
+		}
+		now = now.Add(2 * time.Second)
+	}
+
+	s := timing.Stats()
+	want := SegmentTimingStats{
+		Target:       2 * time.Second,
+		Segments:     23, // The first window's 3, then one per reload
+		Mean:         2 * time.Second,
+		Min:          2 * time.Second,
+		Max:          2 * time.Second,
+		Pace:         2 * time.Second,
+		PaceSegments: 20,
+	}
+	if s != want {
+		t.Errorf("Stats() = %+v, want %+v", s, want)
+	}
+}
+
+func TestSegmentTiming_Drift(t *testing.T) {
+	timing := NewSegmentTiming()
+	now := time.Unix(1000, 0)
+	seq := int64(0)
+	observe := func(every time.Duration) (string, bool) {
+		change, drifting := timing.Observe(livePlaylist(seq, 0), now)
+		seq++
+		now = now.Add(every)
+		return change, drifting
+	}
+
+	// A packager falling behind: 2s segments every 2.5s
+	var change string
+	for i := 0; i < 12 && change == ""; i++ {
+		change, _ = observe(2500 * time.Millisecond)
+	}
+	if !strings.Contains(change, "drifting beyond") || !strings.Contains(change, "+25%") {
+		t.Fatalf("drift message = %q, want drifting beyond at +25%%", change)
+	}
+	if _, drifting := observe(2500 * time.Millisecond); !drifting {
+		t.Error("drifting = false while still behind")
+	}
+
+	// Back to real time: recovers once the slow segments leave the window
+	change = ""
+	for i := 0; i < 12 && change == ""; i++ {
+		change, _ = observe(2 * time.Second)
+	}
+	if !strings.Contains(change, "back within") {
+		t.Fatalf("recovery message = %q, want back within", change)
+	}
+
+	if s := timing.Stats(); s.Pace <= s.Mean {
+		t.Errorf("overall pace %v, want slower than the %v #EXTINF mean", s.Pace, s.Mean)
+	}
+}
+
+func TestSegmentTiming_Irregular(t *testing.T) {
+	timing := NewSegmentTiming()
+	pl := livePlaylist(0, 0)
+	pl.TargetDuration = 3 * time.Second
+	pl.Segments[0].Duration = 1 * time.Second
+	pl.Segments[2].Duration = 3 * time.Second
+	timing.Observe(pl, time.Unix(1000, 0))
+
+	s := timing.Stats()
+	if s.Segments != 3 || s.Mean != 2*time.Second || s.Min != time.Second || s.Max != 3*time.Second {
+		t.Errorf("Stats() = %+v, want 3 segments of 1-3s, mean 2s", s)
+	}
+	if want := 816 * time.Millisecond; s.StdDev.Round(time.Millisecond) != want {
+		t.Errorf("StdDev = %v, want %v", s.StdDev, want)
+	}
+	if s.PaceSegments != 0 || s.Pace != 0 {
+		t.Errorf("pace %v over %d segments from a single reload, want none", s.Pace, s.PaceSegments)
+	}
+}
+
+func TestSegmentTiming_SequenceGapsAndRestarts(t *testing.T) {
+	timing := NewSegmentTiming()
+	now := time.Unix(1000, 0)
+	timing.Observe(livePlaylist(0, 0), now)
+
+	// Reloaded slowly: seq 3-4 came and went unseen, 5-7 are new, and
+	// all five still count towards the pace since seq 2
+	now = now.Add(16 * time.Second)
+	timing.Observe(livePlaylist(5, 0), now)
+	s := timing.Stats()
+	if s.Segments != 6 || s.PaceSegments != 5 || s.Pace != 16*time.Second/5 {
+		t.Errorf("after a gap: %d segments, pace %v over %d, want 6, 3.2s over 5", s.Segments, s.Pace, s.PaceSegments)
+	}
+
+	// The packager restarted: a new baseline
+	now = now.Add(2 * time.Second)
+	timing.Observe(livePlaylist(0, 0), now)
+	if s := timing.Stats(); s.Segments != 9 || s.PaceSegments != 0 {
+		t.Errorf("after a restart: %d segments, pace over %d, want 9, none", s.Segments, s.PaceSegments)
+	}
+
+	if change, _ := timing.Observe(&Playlist{IsMaster: true}, now); change != "" {
+		t.Errorf("master playlist: %q", change)
+	}
+	if s := timing.Stats(); s.Segments != 9 {
+		t.Errorf("master playlist recorded segments: %d", s.Segments)
+	}
+}
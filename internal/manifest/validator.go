@@ -0,0 +1,166 @@
+package manifest
+
+import (
+	"fmt"
+	"time"
+)
+
+// ViolationKind identifies an RFC 8216 rule a playlist broke.
+// Values are used as Prometheus label values, so the set must stay small.
+type ViolationKind string
+
+const (
+	// ViolationMissingHeader: first line is not #EXTM3U (4.3.1.1).
+	ViolationMissingHeader ViolationKind = "missing_extm3u"
+
+	// ViolationExtinfExceedsTarget: a rounded #EXTINF is longer than
+	// #EXT-X-TARGETDURATION (4.3.3.1).
+	ViolationExtinfExceedsTarget ViolationKind = "extinf_exceeds_target"
+
+	// ViolationTargetDurationChanged: #EXT-X-TARGETDURATION changed
+	// between reloads (6.2.1).
+	ViolationTargetDurationChanged ViolationKind = "target_duration_changed"
+
+	// ViolationMediaSequenceRegressed: #EXT-X-MEDIA-SEQUENCE went backwards.
+	ViolationMediaSequenceRegressed ViolationKind = "media_sequence_regressed"
+
+	// ViolationSegmentMismatch: after the media sequence advanced, a segment
+	// still in the window has a different URI than before (6.2.2).
+	ViolationSegmentMismatch ViolationKind = "segment_mismatch"
+
+	// ViolationDiscontinuitySequence: #EXT-X-DISCONTINUITY-SEQUENCE does not
+	// account for the discontinuities that left the window (6.2.2).
+	ViolationDiscontinuitySequence ViolationKind = "discontinuity_sequence"
+
+	// ViolationEndlistRemoved: a playlist that had #EXT-X-ENDLIST lost it.
+	ViolationEndlistRemoved ViolationKind = "endlist_removed"
+
+	// ViolationStale: a live playlist did not change for more than 1.5×
+	// the target duration (6.2.1).
+	ViolationStale ViolationKind = "playlist_stale"
+)
+
+// Violation is one compliance problem found in a playlist reload.
+type Violation struct {
+	Kind   ViolationKind
+	Detail string
+}
+
+func (v Violation) String() string {
+	return string(v.Kind) + ": " + v.Detail
+}
+
+// Validator checks successive reloads of one media playlist.
+// Most rules compare against the previous reload, so a Validator must not
+// be shared between playlists. Not safe for concurrent use.
+type Validator struct {
+	prev         *Playlist
+	lastChange   time.Time
+	staleFlagged bool // Report each stall once, not on every reload
+}
+
+// NewValidator creates a validator for a single media playlist URL.
+func NewValidator() *Validator {
+	return &Validator{}
+}
+
+// Observe checks pl (fetched at now) and returns any violations.
+// Master playlists are ignored.
+func (v *Validator) Observe(pl *Playlist, now time.Time) []Violation {
+	if pl == nil || pl.IsMaster {
+		return nil
+	}
+
+	var out []Violation
+	add := func(kind ViolationKind, format string, args ...any) {
+		out = append(out, Violation{Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	if !pl.HasHeader {
+		add(ViolationMissingHeader, "first line is not #EXTM3U")
+	}
+
+	target := pl.TargetDuration.Round(time.Second)
+	for i, seg := range pl.Segments {
+		if target > 0 && seg.Duration.Round(time.Second) > target {
+			add(ViolationExtinfExceedsTarget, "segment %d (%s) is %v, target %v",
+				pl.MediaSequence+int64(i), seg.URI, seg.Duration, pl.TargetDuration)
+			break // One per reload is enough to flag the playlist
+		}
+	}
+
+	prev := v.prev
+	v.prev = pl
+	if prev == nil {
+		v.lastChange = now
+		return out
+	}
+
+	if pl.TargetDuration != prev.TargetDuration {
+		add(ViolationTargetDurationChanged, "%v -> %v", prev.TargetDuration, pl.TargetDuration)
+	}
+	if prev.Ended && !pl.Ended {
+		add(ViolationEndlistRemoved, "#EXT-X-ENDLIST disappeared")
+	}
+
+	advance := pl.MediaSequence - prev.MediaSequence
+	switch {
+	case advance < 0:
+		add(ViolationMediaSequenceRegressed, "%d -> %d", prev.MediaSequence, pl.MediaSequence)
+
+	case advance <= int64(len(prev.Segments)):
+		// The window slid by advance segments: the overlap must be unchanged
+		// and the discontinuity sequence must count what slid out.
+		overlap := prev.Segments[advance:]
+		for i := 0; i < len(overlap) && i < len(pl.Segments); i++ {
+			if overlap[i].URI != pl.Segments[i].URI {
+				add(ViolationSegmentMismatch, "sequence %d was %s, now %s",
+					pl.MediaSequence+int64(i), overlap[i].URI, pl.Segments[i].URI)
+				break
+			}
+		}
+
+		want := prev.DiscontinuitySequence
+		for _, seg := range prev.Segments[:advance] {
+			if seg.Discontinuity {
+				want++
+			}
+		}
+		if pl.DiscontinuitySequence != want {
+			add(ViolationDiscontinuitySequence, "got %d, want %d",
+				pl.DiscontinuitySequence, want)
+		}
+
+	default:
+		// Jumped past the whole previous window (e.g. we reloaded slowly):
+		// only monotonicity can be checked.
+		if pl.DiscontinuitySequence < prev.DiscontinuitySequence {
+			add(ViolationDiscontinuitySequence, "regressed %d -> %d",
+				prev.DiscontinuitySequence, pl.DiscontinuitySequence)
+		}
+	}
+
+	if changed(prev, pl) {
+		v.lastChange = now
+		v.staleFlagged = false
+	} else if !pl.Ended && pl.TargetDuration > 0 && !v.staleFlagged {
+		limit := pl.TargetDuration * 3 / 2
+		if age := now.Sub(v.lastChange); age > limit {
+			add(ViolationStale, "unchanged for %v (limit %v)", age.Round(time.Millisecond), limit)
+			v.staleFlagged = true
+		}
+	}
+
+	return out
+}
+
+// changed reports whether a reload is a new version of the playlist.
+func changed(prev, cur *Playlist) bool {
+	if cur.MediaSequence != prev.MediaSequence || len(cur.Segments) != len(prev.Segments) || cur.Ended != prev.Ended {
+		return true
+	}
+	if n := len(cur.Segments); n > 0 && cur.Segments[n-1].URI != prev.Segments[n-1].URI {
+		return true
+	}
+	return false
+}
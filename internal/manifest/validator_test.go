@@ -0,0 +1,256 @@
+package manifest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// livePlaylist builds a 3-segment live window starting at seq.
+// Segments listed in disc are preceded by #EXT-X-DISCONTINUITY.
+func livePlaylist(seq, discSeq int64, disc ...int64) *Playlist {
+	pl := &Playlist{
+		HasHeader:             true,
+		TargetDuration:        2 * time.Second,
+		MediaSequence:         seq,
+		DiscontinuitySequence: discSeq,
+	}
+	for i := seq; i < seq+3; i++ {
+		seg := Segment{URI: fmt.Sprintf("seg%d.ts", i), Duration: 2 * time.Second}
+		for _, d := range disc {
+			if d == i {
+				seg.Discontinuity = true
+			}
+		}
+		pl.Segments = append(pl.Segments, seg)
+	}
+	return pl
+}
+
+func kinds(vs []Violation) []ViolationKind {
+	out := make([]ViolationKind, len(vs))
+	for i, v := range vs {
+		out[i] = v.Kind
+	}
+	return out
+}
+
+func TestValidator_CleanSlidingWindow(t *testing.T) {
+	v := NewValidator()
+	now := time.Unix(1000, 0)
+	for seq := int64(0); seq < 5; seq++ {
+		if got := v.Observe(livePlaylist(seq, 0), now); len(got) != 0 {
+			t.Fatalf("seq %d: unexpected violations %v", seq, got)
+		}
+		now = now.Add(2 * time.Second)
+	}
+}
+
+func TestValidator_SingleReload(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Playlist)
+		want   ViolationKind
+	}{
+		{"missing header", func(p *Playlist) { p.HasHeader = false }, ViolationMissingHeader},
+		{"long segment", func(p *Playlist) { p.Segments[1].Duration = 2600 * time.Millisecond }, ViolationExtinfExceedsTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := livePlaylist(0, 0)
+			tt.mutate(pl)
+			got := kinds(NewValidator().Observe(pl, time.Now()))
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("violations = %v, want [%s]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidator_ExtinfRoundsToNearestSecond(t *testing.T) {
+	pl := livePlaylist(0, 0)
+	pl.Segments[0].Duration = 2400 * time.Millisecond // rounds to 2s: allowed
+	if got := NewValidator().Observe(pl, time.Now()); len(got) != 0 {
+		t.Errorf("unexpected violations %v", got)
+	}
+}
+
+func TestValidator_Reloads(t *testing.T) {
+	tests := []struct {
+		name string
+		prev *Playlist
+		cur  func() *Playlist
+		want ViolationKind
+	}{
+		{
+			name: "media sequence regressed",
+			prev: livePlaylist(10, 0),
+			cur:  func() *Playlist { return livePlaylist(9, 0) },
+			want: ViolationMediaSequenceRegressed,
+		},
+		{
+			name: "target duration changed",
+			prev: livePlaylist(10, 0),
+			cur: func() *Playlist {
+				p := livePlaylist(11, 0)
+				p.TargetDuration = 4 * time.Second
+				return p
+			},
+			want: ViolationTargetDurationChanged,
+		},
+		{
+			name: "segment rewritten",
+			prev: livePlaylist(10, 0),
+			cur: func() *Playlist {
+				p := livePlaylist(11, 0)
+				p.Segments[0].URI = "other.ts"
+				return p
+			},
+			want: ViolationSegmentMismatch,
+		},
+		{
+			name: "discontinuity sequence not bumped",
+			prev: livePlaylist(10, 4, 10),
+			cur:  func() *Playlist { return livePlaylist(11, 4) },
+			want: ViolationDiscontinuitySequence,
+		},
+		{
+			name: "discontinuity sequence bumped without cause",
+			prev: livePlaylist(10, 4),
+			cur:  func() *Playlist { return livePlaylist(11, 5) },
+			want: ViolationDiscontinuitySequence,
+		},
+		{
+			name: "discontinuity sequence regressed after jump",
+			prev: livePlaylist(10, 4),
+			cur:  func() *Playlist { return livePlaylist(50, 3) },
+			want: ViolationDiscontinuitySequence,
+		},
+		{
+			name: "endlist removed",
+			prev: func() *Playlist { p := livePlaylist(10, 0); p.Ended = true; return p }(),
+			cur:  func() *Playlist { return livePlaylist(10, 0) },
+			want: ViolationEndlistRemoved,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator()
+			now := time.Unix(1000, 0)
+			if got := v.Observe(tt.prev, now); len(got) != 0 {
+				t.Fatalf("first reload: unexpected violations %v", got)
+			}
+			got := kinds(v.Observe(tt.cur(), now.Add(time.Second)))
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("violations = %v, want [%s]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidator_DiscontinuityAccounted(t *testing.T) {
+	v := NewValidator()
+	now := time.Unix(1000, 0)
+	v.Observe(livePlaylist(10, 4, 10, 11), now)
+
+	// Both discontinuities slide out: sequence must advance by 2
+	if got := v.Observe(livePlaylist(12, 6), now.Add(time.Second)); len(got) != 0 {
+		t.Errorf("unexpected violations %v", got)
+	}
+}
+
+func TestValidator_Stale(t *testing.T) {
+	v := NewValidator()
+	now := time.Unix(1000, 0)
+	v.Observe(livePlaylist(10, 0), now)
+
+	// Limit is 1.5 × 2s = 3s
+	if got := v.Observe(livePlaylist(10, 0), now.Add(3*time.Second)); len(got) != 0 {
+		t.Fatalf("at limit: unexpected violations %v", got)
+	}
+	got := kinds(v.Observe(livePlaylist(10, 0), now.Add(4*time.Second)))
+	if len(got) != 1 || got[0] != ViolationStale {
+		t.Fatalf("violations = %v, want [%s]", got, ViolationStale)
+	}
+
+	// Same stall is reported once
+	if got := v.Observe(livePlaylist(10, 0), now.Add(5*time.Second)); len(got) != 0 {
+		t.Errorf("repeat stall reported: %v", got)
+	}
+
+	// Recovery re-arms the check
+	v.Observe(livePlaylist(11, 0), now.Add(6*time.Second))
+	got = kinds(v.Observe(livePlaylist(11, 0), now.Add(10*time.Second)))
+	if len(got) != 1 || got[0] != ViolationStale {
+		t.Errorf("second stall: violations = %v, want [%s]", got, ViolationStale)
+	}
+}
+
+func TestValidator_VODNeverStale(t *testing.T) {
+	v := NewValidator()
+	now := time.Unix(1000, 0)
+	pl := livePlaylist(0, 0)
+	pl.Ended = true
+	v.Observe(pl, now)
+	if got := v.Observe(pl, now.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("unexpected violations %v", got)
+	}
+}
+
+func TestValidator_IgnoresMaster(t *testing.T) {
+	if got := NewValidator().Observe(&Playlist{IsMaster: true}, time.Now()); got != nil {
+		t.Errorf("unexpected violations %v", got)
+	}
+}
+
+func TestMonitor_CountsViolations(t *testing.T) {
+	var reloads atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Media sequence goes backwards on the second reload
+		seq := 10 - reloads.Add(1)
+		var b strings.Builder
+		fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:%d\n", seq)
+		for i := seq; i < seq+3; i++ {
+			fmt.Fprintf(&b, "#EXTINF:2.0,\nseg%d.ts\n", i)
+		}
+		w.Write([]byte(b.String()))
+	}))
+	defer srv.Close()
+
+	var callbacks atomic.Int64
+	m := NewMonitor(MonitorConfig{
+		Prober:      NewProber(ProberConfig{Timeout: time.Second}),
+		URLs:        []string{srv.URL + "/index.m3u8"},
+		Interval:    10 * time.Millisecond,
+		OnViolation: func(string, Violation) { callbacks.Add(1) },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for callbacks.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := m.Violations()[string(ViolationMediaSequenceRegressed)]; got == 0 {
+		t.Errorf("media_sequence_regressed count = 0, want > 0 (all: %v)", m.Violations())
+	}
+	if m.FetchErrors() != 0 {
+		t.Errorf("FetchErrors = %d, want 0", m.FetchErrors())
+	}
+	if timing := m.SegmentTimings()[srv.URL+"/index.m3u8"]; timing.Segments < 3 || timing.Mean != 2*time.Second {
+		t.Errorf("segment timing = %+v, want the 2s segments recorded", timing)
+	}
+}
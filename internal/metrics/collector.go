@@ -8,11 +8,18 @@
 package metrics
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
 )
 
 // =============================================================================
@@ -26,7 +33,7 @@ var (
 			Name: "hls_swarm_info",
 			Help: "Information about the load test (value always 1)",
 		},
-		[]string{"version", "stream_url", "variant"},
+		[]string{"version", "stream_url", "variant", "pacing"},
 	)
 
 	hlsTargetClients = prometheus.NewGauge(
@@ -50,6 +57,13 @@ var (
 		},
 	)
 
+	hlsQuarantinedClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_quarantined_clients",
+			Help: "Clients parked in quarantine after failing repeatedly (-quarantine-after)",
+		},
+	)
+
 	hlsRampProgress = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "hls_swarm_ramp_progress",
@@ -57,6 +71,20 @@ var (
 		},
 	)
 
+	hlsRampRateTarget = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_ramp_rate_target",
+			Help: "Clients to start per second during a ramp (-ramp-rate)",
+		},
+	)
+
+	hlsRampRateAchieved = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_ramp_rate_achieved",
+			Help: "Clients actually started per second over the current (or last) ramp",
+		},
+	)
+
 	hlsTestElapsedSeconds = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "hls_swarm_test_elapsed_seconds",
@@ -70,6 +98,63 @@ var (
 			Help: "Seconds remaining until test ends (-1 = unlimited)",
 		},
 	)
+
+	hlsEstimatedRequestsPerSec = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_estimated_requests_per_second",
+			Help: "Pre-run estimate of steady-state origin request rate (from manifest probe)",
+		},
+	)
+
+	hlsEstimatedBandwidthMbps = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_estimated_bandwidth_mbps",
+			Help: "Pre-run estimate of steady-state origin bandwidth in Mbps (from manifest probe)",
+		},
+	)
+
+	hlsClockOffsetSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_clock_offset_seconds",
+			Help: "NTP server clock minus local clock, measured before a -start-at run",
+		},
+	)
+
+	hlsOriginProbeLatencySeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_origin_probe_latency_seconds",
+			Help: "Playlist fetch time from the -cool-down prober (pre-load baseline, then each cool-down probe)",
+		},
+	)
+
+	hlsCoolDownRecoverySeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_cooldown_recovery_seconds",
+			Help: "Seconds after the clients stopped until origin latency returned to baseline (-1 = not recovered)",
+		},
+	)
+
+	hlsPhase = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_phase",
+			Help: "Lifecycle phase of the run: 1 for the current phase (starting, ramping, steady, draining, stopped), 0 for the others",
+		},
+		[]string{"phase"},
+	)
+
+	hlsRollingRestartActive = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_rolling_restart_active",
+			Help: "1 while a rolling restart of the clients is going on, 0 otherwise",
+		},
+	)
+
+	hlsRollingRestartClientsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_rolling_restart_clients_total",
+			Help: "Clients restarted by rolling restarts",
+		},
+	)
 )
 
 // --- Panel 2: Request Rates & Throughput ---
@@ -213,6 +298,23 @@ var (
 			Help: "Maximum inferred segment latency observed",
 		},
 	)
+
+	// Segment download time (transfer only) vs the idle gap before the next
+	// request; segment wall time is roughly the two added together
+	hlsSegmentDownloadSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_segment_download_seconds",
+			Help: "Segment download time percentiles across the swarm (request to end of transfer)",
+		},
+		[]string{"quantile"},
+	)
+
+	hlsSegmentGapAvgSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_segment_gap_avg_seconds",
+			Help: "Average idle time between a segment transfer ending and the next segment request",
+		},
+	)
 )
 
 // --- Panel 4: Client Health & Playback ---
@@ -265,17 +367,81 @@ var (
 			Help: "Maximum wall-clock drift",
 		},
 	)
+
+	hlsLastEventAgeSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_last_event_age_seconds",
+			Help: "Time since each client's last parsed FFmpeg event, across the clients",
+		},
+		[]string{"stat"}, // "p50", "p95", "max"
+	)
+
+	hlsIdleClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_idle_clients",
+			Help: "Clients with no parsed FFmpeg event for 30 seconds: running but silent",
+		},
+	)
+)
+
+// --- Panel 4b: Discontinuities & Ad Insertion ---
+var (
+	hlsDiscontinuitiesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_discontinuities_total",
+			Help: "Timestamp discontinuities crossed by clients (EXT-X-DISCONTINUITY / SSAI transitions)",
+		},
+	)
+
+	hlsAdBreaksTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_ad_breaks_total",
+			Help: "SCTE-35 cue-out markers seen by clients",
+		},
+	)
+
+	hlsClientsRecovering = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_clients_recovering",
+			Help: "Clients waiting for their first segment after a discontinuity",
+		},
+	)
+
+	hlsDiscontinuityRecoveryAvgSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_discontinuity_recovery_avg_seconds",
+			Help: "Average time from discontinuity to next completed segment",
+		},
+	)
+
+	hlsDiscontinuityRecoveryMaxSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_discontinuity_recovery_max_seconds",
+			Help: "Maximum time from discontinuity to next completed segment",
+		},
+	)
 )
 
 // --- Panel 5: Errors & Recovery ---
 var (
-	// HTTP errors by status code (low cardinality: ~5-10 codes)
+	// HTTP and network errors by status code and reason (low cardinality:
+	// ~5-10 codes; reason is 4xx/5xx/other or one of stats.NetworkErrorReasons).
+	// Network failures have status_code="none".
 	hlsHTTPErrorsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "hls_swarm_http_errors_total",
-			Help: "HTTP errors by status code",
+			Help: "HTTP and network errors by status code and reason (timeout, refused, reset, dns, tls, 4xx, 5xx, other)",
+		},
+		[]string{"status_code", "reason"},
+	)
+
+	// Downloads slower than -slow-request-log, by kind (segment/manifest)
+	hlsSlowRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_slow_requests_total",
+			Help: "Segment/manifest downloads slower than the -slow-request-log threshold",
 		},
-		[]string{"status_code"},
+		[]string{"kind"},
 	)
 
 	hlsTimeoutsTotal = prometheus.NewCounter(
@@ -292,6 +458,14 @@ var (
 		},
 	)
 
+	hlsReconnectionCausesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_reconnection_causes_total",
+			Help: "FFmpeg reconnection attempts by the request stage that failed (dns, tcp, tls, http, read) and cause",
+		},
+		[]string{"stage", "cause"},
+	)
+
 	hlsClientStartsTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "hls_swarm_client_starts_total",
@@ -306,6 +480,13 @@ var (
 		},
 	)
 
+	hlsClientQuarantinesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_client_quarantines_total",
+			Help: "Total times a client was quarantined",
+		},
+	)
+
 	hlsClientExitsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "hls_swarm_client_exits_total",
@@ -314,12 +495,63 @@ var (
 		[]string{"category"}, // "success", "error", "signal"
 	)
 
+	hlsClientStopSignalsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_client_stop_signals_total",
+			Help: "Signals sent to stop client processes (SIGKILL = the stop grace period ran out)",
+		},
+		[]string{"signal"},
+	)
+
 	hlsErrorRate = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "hls_swarm_error_rate",
 			Help: "Current error rate (errors/total requests)",
 		},
 	)
+
+	// Playlist compliance (only with -validate-playlists; ~8 kinds)
+	hlsPlaylistViolationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_playlist_violations_total",
+			Help: "RFC 8216 violations seen in reloaded media playlists, by kind",
+		},
+		[]string{"kind"},
+	)
+
+	// Playlist compression (only with -validate-playlists; one series per
+	// Content-Encoding served, "identity" when uncompressed)
+	hlsPlaylistFetchesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_playlist_fetches_total",
+			Help: "Validation playlist reloads by response Content-Encoding",
+		},
+		[]string{"encoding"},
+	)
+
+	hlsPlaylistWireBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_playlist_wire_bytes_total",
+			Help: "Validation playlist body bytes as received, by Content-Encoding",
+		},
+		[]string{"encoding"},
+	)
+
+	hlsPlaylistDecodedBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_playlist_decoded_bytes_total",
+			Help: "Validation playlist body bytes after decoding, by Content-Encoding",
+		},
+		[]string{"encoding"},
+	)
+
+	hlsPlaylistDecodeFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_playlist_decode_failures_total",
+			Help: "Validation playlist reloads whose body could not be decoded, by Content-Encoding",
+		},
+		[]string{"encoding"},
+	)
 )
 
 // --- Panel 6: Pipeline Health (Metrics System) ---
@@ -340,6 +572,35 @@ var (
 		[]string{"stream"},
 	)
 
+	hlsStatsBytesReadTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_stats_bytes_read_total",
+			Help: "FFmpeg output bytes read, per path (-progress-mode for progress)",
+		},
+		[]string{"stream"},
+	)
+
+	hlsStatsLinesTruncatedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_stats_lines_truncated_total",
+			Help: "FFmpeg output lines cut at the -stats-max-line limit",
+		},
+	)
+
+	hlsStatsParserPanicsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_stats_parser_panics_total",
+			Help: "FFmpeg output lines a stats parser panicked on (line skipped, parser reset)",
+		},
+	)
+
+	hlsStatsInvalidUTF8Total = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_stats_invalid_utf8_total",
+			Help: "Runs of invalid UTF-8 in FFmpeg output replaced with U+FFFD",
+		},
+	)
+
 	hlsStatsClientsDegraded = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "hls_swarm_stats_clients_degraded",
@@ -360,6 +621,28 @@ var (
 			Help: "Peak metrics line drop rate observed",
 		},
 	)
+
+	hlsDebugPendingEntries = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_debug_pending_entries",
+			Help: "Requests awaiting a completion event in the debug parsers",
+		},
+	)
+
+	hlsDebugPendingOrphanedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_debug_pending_orphaned_total",
+			Help: "Pending requests expired without a completion event",
+		},
+	)
+
+	hlsDebugClockSkewSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_debug_clock_skew_seconds",
+			Help: "Estimated offset of FFmpeg log timestamps from the swarm's clock, corrected before use",
+		},
+		[]string{"stat"}, // "min", "max"
+	)
 )
 
 // --- Panel 7: Uptime Distribution ---
@@ -394,6 +677,620 @@ var (
 	)
 )
 
+// --- Panel 8: Tenants (only with -tenants; one series per tenant) ---
+var (
+	hlsTenantClients = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tenant_clients",
+			Help: "Clients started for each tenant",
+		},
+		[]string{"tenant"},
+	)
+
+	hlsTenantHeldClients = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tenant_held_clients",
+			Help: "Clients of each tenant held back or stopped by its request-rate quota",
+		},
+		[]string{"tenant"},
+	)
+
+	hlsTenantQuotaRequestsPerSec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tenant_quota_requests_per_second",
+			Help: "Request-rate quota of each tenant (0 = unlimited)",
+		},
+		[]string{"tenant"},
+	)
+
+	hlsTenantRequestsPerSec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tenant_requests_per_second",
+			Help: "Current manifest + segment request rate of each tenant",
+		},
+		[]string{"tenant"},
+	)
+
+	hlsTenantRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_tenant_requests_total",
+			Help: "Manifest + segment requests by tenant",
+		},
+		[]string{"tenant"},
+	)
+
+	hlsTenantBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_tenant_bytes_downloaded_total",
+			Help: "Bytes downloaded by tenant",
+		},
+		[]string{"tenant"},
+	)
+
+	hlsTenantErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_tenant_errors_total",
+			Help: "HTTP and network errors by tenant",
+		},
+		[]string{"tenant"},
+	)
+
+	hlsTenantQuotaThrottlesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_tenant_quota_throttles_total",
+			Help: "Times a tenant's request-rate quota held back or stopped one of its clients",
+		},
+		[]string{"tenant"},
+	)
+)
+
+// --- Panel 9: Session Tokens (only with -token-url) ---
+var (
+	hlsAuthTokenFetchesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_auth_token_fetches_total",
+			Help: "Session token fetches by result (ok, error)",
+		},
+		[]string{"result"},
+	)
+
+	hlsAuthTokenFetchSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hls_swarm_auth_token_fetch_seconds",
+			Help:    "Session token fetch latency",
+			Buckets: []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+	)
+
+	hlsAuthReauthsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_auth_reauths_total",
+			Help: "Clients running again with a new token after an HTTP 401 or a -token-ttl expiry",
+		},
+	)
+
+	hlsAuthTokenExpiriesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_auth_token_expiries_total",
+			Help: "Session tokens expired by -token-ttl",
+		},
+	)
+
+	hlsAuthReauthSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hls_swarm_auth_reauth_seconds",
+			Help:    "Time from an HTTP 401 or token expiry to the client running again with a new token",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+	)
+)
+
+// --- Panel 10: Geos (only with -geo; one series per geo) ---
+var (
+	hlsGeoClients = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_geo_clients",
+			Help: "Started clients of each emulated geo",
+		},
+		[]string{"geo"},
+	)
+
+	hlsGeoRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_geo_requests_total",
+			Help: "Manifest + segment requests by emulated geo",
+		},
+		[]string{"geo"},
+	)
+
+	hlsGeoErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_geo_errors_total",
+			Help: "HTTP and network errors by emulated geo",
+		},
+		[]string{"geo"},
+	)
+
+	hlsGeoSegmentWallSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_geo_segment_wall_time_seconds",
+			Help: "Segment wall time percentiles of each emulated geo",
+		},
+		[]string{"geo", "quantile"},
+	)
+
+	hlsGeoManifestWallSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_geo_manifest_wall_time_seconds",
+			Help: "Manifest wall time percentiles of each emulated geo",
+		},
+		[]string{"geo", "quantile"},
+	)
+)
+
+// --- Panel 11: Kernel TCP Sockets (only with -socket-stats) ---
+var (
+	hlsSocketConnections = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tcp_socket_connections",
+			Help: "Open TCP connections of the FFmpeg processes",
+		},
+	)
+
+	hlsSocketRTTSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tcp_socket_rtt_seconds",
+			Help: "Kernel smoothed RTT percentiles over the open connections",
+		},
+		[]string{"quantile"},
+	)
+
+	hlsSocketMinRTTSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tcp_socket_min_rtt_seconds",
+			Help: "Lowest kernel min RTT of the open connections",
+		},
+	)
+
+	hlsSocketRetransmitsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_tcp_socket_retransmits_total",
+			Help: "TCP segments retransmitted on the FFmpeg processes' connections",
+		},
+	)
+
+	hlsSocketOutOfOrderTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_tcp_socket_out_of_order_packets_total",
+			Help: "Packets the FFmpeg processes' connections received out of order (loss or retransmits upstream)",
+		},
+	)
+
+	hlsSocketBytesReceivedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_tcp_socket_bytes_received_total",
+			Help: "Bytes received on the FFmpeg processes' connections, as counted by the kernel",
+		},
+	)
+
+	hlsSocketThroughputBytesPerSec = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_tcp_socket_throughput_bytes_per_second",
+			Help: "Bytes received per second over the last socket sample",
+		},
+	)
+)
+
+// --- Panel 12: Network Flaps (only with -flap-interval) ---
+var (
+	hlsFlapPausedClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_flap_paused_clients",
+			Help: "Clients currently paused by a network flap",
+		},
+	)
+
+	hlsFlapPausesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_flap_pauses_total",
+			Help: "Client pauses ended by a network flap (recovered or not)",
+		},
+	)
+
+	hlsFlapCatchUpSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hls_swarm_flap_catchup_seconds",
+			Help:    "Time from resuming a paused client to its next downloaded segment",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+		},
+	)
+
+	hlsFlapUnrecoveredTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_flap_unrecovered_total",
+			Help: "Paused clients that downloaded no segment within the catch-up timeout",
+		},
+	)
+
+	hlsFlapSequenceSkipsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_flap_sequence_skips_total",
+			Help: "Media sequence jumps of clients catching up after a flap",
+		},
+	)
+
+	hlsFlapSegmentsExpiredTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_flap_segments_expired_total",
+			Help: "Segments clients skipped after a flap because they had left the playlist",
+		},
+	)
+
+	hlsFlapSegmentsSkippedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_flap_segments_skipped_total",
+			Help: "Segments clients gave up on after retries while catching up after a flap",
+		},
+	)
+)
+
+// --- Panel 13: Client CPU (demux-only guard, -client-cpu-limit) ---
+var (
+	hlsClientCPUPercent = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_client_cpu_percent",
+			Help: "FFmpeg CPU use per client over the last sample, in percent of one core",
+		},
+		[]string{"stat"}, // "mean", "max"
+	)
+
+	hlsClientCPUHighTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_client_cpu_high_total",
+			Help: "Client processes flagged for using more CPU than -client-cpu-limit (decoding)",
+		},
+	)
+)
+
+// --- Panel 14: Variant Mix (only with -variant-mix; one series per variant) ---
+var (
+	hlsVariantClients = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_variant_clients",
+			Help: "Started clients of each -variant-mix variant",
+		},
+		[]string{"variant"},
+	)
+
+	hlsVariantRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_variant_requests_total",
+			Help: "Manifest + segment requests by -variant-mix variant",
+		},
+		[]string{"variant"},
+	)
+
+	hlsVariantBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_variant_bytes_total",
+			Help: "Bytes downloaded by -variant-mix variant",
+		},
+		[]string{"variant"},
+	)
+
+	hlsVariantErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_variant_errors_total",
+			Help: "HTTP and network errors by -variant-mix variant",
+		},
+		[]string{"variant"},
+	)
+)
+
+// --- Panel 15: Playlist Refresh (debug stats) ---
+var (
+	hlsPlaylistRefreshInterval = &refreshIntervalHistogram{
+		desc: prometheus.NewDesc(
+			"hls_swarm_playlist_refresh_interval_ratio",
+			"Playlist refresh intervals as a multiple of the target duration (1 = on time)",
+			nil, nil,
+		),
+	}
+
+	hlsPlaylistRefreshStormsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_playlist_refresh_storms_total",
+			Help: "100ms windows in which enough clients refreshed the playlist to contend for it at the origin",
+		},
+	)
+
+	hlsPlaylistRefreshPeakClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_playlist_refresh_peak_clients",
+			Help: "Most playlist refreshes seen in one 100ms window",
+		},
+	)
+
+	hlsPlaylistRefreshOverrideTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_playlist_refresh_override_fetches_total",
+			Help: "Playlist fetches the swarm added between FFmpeg's reloads for -playlist-refresh",
+		},
+		[]string{"result"}, // "ok", "not_modified" (304 to -conditional-playlists), "error"
+	)
+)
+
+// --- Panel 16: Shard Balance (debug stats; one series per sharded URL template) ---
+var (
+	hlsShardSkew = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_shard_skew",
+			Help: "Segment downloads of the busiest shard over the mean of its URL template (1 = even)",
+		},
+		[]string{"template"},
+	)
+
+	hlsShardHot = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_shard_hot",
+			Help: "Shards of a URL template with at least 1.5x the mean segment downloads",
+		},
+		[]string{"template"},
+	)
+)
+
+// --- Panel 17: CDN vs Origin (only with -cdn-detect) ---
+var (
+	hlsServedResponsesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_served_responses_total",
+			Help: "Responses by what served them, from their headers: edge-hit, edge-miss, edge (cache status unknown) or origin",
+		},
+		[]string{"served_by"},
+	)
+
+	hlsServedBytesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_served_bytes_total",
+			Help: "Response bytes (Content-Length) by what served them",
+		},
+		[]string{"served_by"},
+	)
+
+	hlsServedTTFBSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_served_ttfb_seconds",
+			Help: "Mean time from request to first response header by what served the response",
+		},
+		[]string{"served_by"},
+	)
+
+	hlsOriginHitRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_origin_hit_ratio",
+			Help: "Share of responses with a known source that the origin served (CDN misses and responses around the CDN)",
+		},
+	)
+
+	hlsOriginHitAlert = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_origin_hit_alert",
+			Help: "1 while the origin hit ratio behind a CDN is above -origin-hit-alert",
+		},
+	)
+)
+
+// --- Panel 18: Memory Guard (only with -max-memory) ---
+var (
+	hlsMemoryRSSBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_memory_rss_bytes",
+			Help: "Resident set size of the swarm process itself",
+		},
+	)
+
+	hlsMemoryLimitBytes = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_memory_limit_bytes",
+			Help: "The -max-memory limit above which the swarm sheds features",
+		},
+	)
+
+	hlsMemoryFeaturesShed = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_memory_features_shed",
+			Help: "Features the memory guard has turned off to stay under -max-memory, in order: per-client metrics, parser sample rings, in-memory history",
+		},
+	)
+)
+
+// --- Panel 19: Latency SLO (only with -slo) ---
+var (
+	hlsSLOTargetRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_slo_target_ratio",
+			Help: "Fraction of segments the -slo objective wants under its threshold",
+		},
+	)
+
+	hlsSLOThresholdSeconds = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_slo_threshold_seconds",
+			Help: "Segment wall time a segment must stay under to meet the -slo objective",
+		},
+	)
+
+	hlsSLOAttainmentRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_slo_attainment_ratio",
+			Help: "Fraction of the run's segments under the -slo threshold",
+		},
+	)
+
+	hlsSLOBurnRate = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_slo_burn_rate",
+			Help: "Error budget burn rate over the last 5 minutes (1 = spending it exactly; above 1 the SLO is missed if it keeps up)",
+		},
+	)
+
+	hlsSLOErrorBudgetRemaining = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_slo_error_budget_remaining_ratio",
+			Help: "Fraction of the -slo error budget left over the run (negative once overspent)",
+		},
+	)
+)
+
+// --- Panel 20: Traffic Bursts (only with -burst) ---
+var (
+	hlsBurstClients = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_burst_clients",
+			Help: "Clients added by -burst bursts going on now, on top of the baseline (0 outside bursts)",
+		},
+	)
+
+	hlsBurstsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_bursts_total",
+			Help: "Traffic bursts started",
+		},
+	)
+)
+
+// --- Panel 21: Viewer Sessions (only with -session-duration) ---
+var (
+	hlsSessionArrivalsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_session_arrivals_total",
+			Help: "Viewer sessions started (a client's first start, or a new session replacing an ended one)",
+		},
+	)
+
+	hlsSessionDeparturesTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_session_departures_total",
+			Help: "Viewer sessions ended after their drawn length",
+		},
+	)
+
+	hlsSessionArrivalRate = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_session_arrival_rate",
+			Help: "Viewer sessions started per second, over the last sample interval",
+		},
+	)
+
+	hlsSessionDepartureRate = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_session_departure_rate",
+			Help: "Viewer sessions ended per second, over the last sample interval",
+		},
+	)
+
+	hlsSessionLengthSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hls_swarm_session_length_seconds",
+			Help:    "Length of the viewer sessions ended",
+			Buckets: []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+		},
+	)
+)
+
+// --- Panel 22: VOD Start Positions (only with -vod-start) ---
+var (
+	hlsVODStartPosition = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hls_swarm_vod_start_position_ratio",
+			Help:    "Where in the VOD asset clients started playing, as a fraction of its duration",
+			Buckets: prometheus.LinearBuckets(0.1, 0.1, 10),
+		},
+	)
+)
+
+// --- Panel 23: VOD Completion (only with -vod-end) ---
+var (
+	hlsVODPlaysTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_vod_plays_total",
+			Help: "Plays of the VOD asset started",
+		},
+	)
+
+	hlsVODPlaysFinishedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_vod_plays_finished_total",
+			Help: "Plays of the VOD asset that reached its end",
+		},
+	)
+
+	hlsVODCompletionSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "hls_swarm_vod_completion_seconds",
+			Help:    "How long the plays that reached the end of the VOD asset took",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+		},
+	)
+
+	hlsVODClientsDone = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_vod_clients_done",
+			Help: "Clients that have finished the VOD asset and stopped (-vod-end stop)",
+		},
+	)
+)
+
+// --- Panel 24: Renditions (only with -renditions) ---
+var (
+	hlsRenditionRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "hls_swarm_rendition_requests_total",
+			Help: "Requests per rendition (video = the variant's own) and kind (segment or playlist)",
+		},
+		[]string{"rendition", "kind"},
+	)
+)
+
+// refreshIntervalHistogram exports the playlist refresh interval counts.
+// The debug parsers keep the counts, so this is a const histogram of the
+// latest RecordStats rather than a Histogram observed here.
+type refreshIntervalHistogram struct {
+	desc *prometheus.Desc
+
+	mu sync.Mutex
+	d  stats.RefreshDistribution
+}
+
+func (h *refreshIntervalHistogram) set(d stats.RefreshDistribution) {
+	h.mu.Lock()
+	h.d = d
+	h.mu.Unlock()
+}
+
+// Describe implements prometheus.Collector.
+func (h *refreshIntervalHistogram) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.desc
+}
+
+// Collect implements prometheus.Collector.
+func (h *refreshIntervalHistogram) Collect(ch chan<- prometheus.Metric) {
+	h.mu.Lock()
+	d := h.d
+	h.mu.Unlock()
+	if len(d.Counts) != len(d.Bounds)+1 {
+		return // No debug stats yet
+	}
+
+	buckets := make(map[float64]uint64, len(d.Bounds))
+	var cum uint64
+	for i, le := range d.Bounds {
+		cum += uint64(d.Counts[i])
+		buckets[le] = cum
+	}
+	ch <- prometheus.MustNewConstHistogram(h.desc, uint64(d.Intervals()), d.RatioSum, buckets)
+}
+
 // =============================================================================
 // Tier 2: Per-Client Metrics (Optional, --prom-client-metrics)
 // WARNING: High cardinality - use only with <200 clients
@@ -403,36 +1300,92 @@ var (
 	hlsClientSpeed *prometheus.GaugeVec
 	hlsClientDrift *prometheus.GaugeVec
 	hlsClientBytes *prometheus.GaugeVec
+	hlsClientAge   *prometheus.GaugeVec
+
+	// Bucketed fallback above the per-client cap (client_id % buckets)
+	hlsClientBucketSpeed    *prometheus.GaugeVec
+	hlsClientBucketDrift    *prometheus.GaugeVec
+	hlsClientBucketBytes    *prometheus.GaugeVec
+	hlsClientBucketAge      *prometheus.GaugeVec
+	hlsClientMetricsBuckets prometheus.Gauge
 )
 
-// initPerClientMetrics initializes Tier 2 metrics.
-// Only called when --prom-client-metrics is enabled.
-func initPerClientMetrics(registry prometheus.Registerer) {
-	hlsClientSpeed = prometheus.NewGaugeVec(
+// initPerClientMetrics initializes Tier 2 metrics.
+// Only called when --prom-client-metrics is enabled.
+func initPerClientMetrics(registry prometheus.Registerer) {
+	hlsClientSpeed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_client_speed",
+			Help: "Per-client playback speed (requires --prom-client-metrics)",
+		},
+		[]string{"client_id"},
+	)
+
+	hlsClientDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_client_drift_seconds",
+			Help: "Per-client wall-clock drift (requires --prom-client-metrics)",
+		},
+		[]string{"client_id"},
+	)
+
+	hlsClientBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_client_bytes_total",
+			Help: "Per-client bytes downloaded (requires --prom-client-metrics)",
+		},
+		[]string{"client_id"},
+	)
+
+	hlsClientAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_client_last_event_age_seconds",
+			Help: "Per-client time since its last parsed FFmpeg event, a heartbeat (requires --prom-client-metrics)",
+		},
+		[]string{"client_id"},
+	)
+
+	hlsClientBucketSpeed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_client_bucket_speed",
+			Help: "Mean playback speed of the clients in each bucket (client_id modulo bucket count), used above the per-client cap",
+		},
+		[]string{"bucket"},
+	)
+
+	hlsClientBucketDrift = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "hls_swarm_client_bucket_drift_seconds",
+			Help: "Worst wall-clock drift of the clients in each bucket, used above the per-client cap",
+		},
+		[]string{"bucket"},
+	)
+
+	hlsClientBucketBytes = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "hls_swarm_client_speed",
-			Help: "Per-client playback speed (requires --prom-client-metrics)",
+			Name: "hls_swarm_client_bucket_bytes_total",
+			Help: "Bytes downloaded by the clients in each bucket, used above the per-client cap",
 		},
-		[]string{"client_id"},
+		[]string{"bucket"},
 	)
 
-	hlsClientDrift = prometheus.NewGaugeVec(
+	hlsClientBucketAge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "hls_swarm_client_drift_seconds",
-			Help: "Per-client wall-clock drift (requires --prom-client-metrics)",
+			Name: "hls_swarm_client_bucket_last_event_age_seconds",
+			Help: "Longest time since a parsed FFmpeg event among the clients in each bucket, used above the per-client cap",
 		},
-		[]string{"client_id"},
+		[]string{"bucket"},
 	)
 
-	hlsClientBytes = prometheus.NewGaugeVec(
+	hlsClientMetricsBuckets = prometheus.NewGauge(
 		prometheus.GaugeOpts{
-			Name: "hls_swarm_client_bytes_total",
-			Help: "Per-client bytes downloaded (requires --prom-client-metrics)",
+			Name: "hls_swarm_client_metrics_buckets",
+			Help: "Buckets per-client metrics were aggregated into after exceeding the cap (0 = full per-client granularity)",
 		},
-		[]string{"client_id"},
 	)
 
-	registry.MustRegister(hlsClientSpeed, hlsClientDrift, hlsClientBytes)
+	registry.MustRegister(hlsClientSpeed, hlsClientDrift, hlsClientBytes, hlsClientAge,
+		hlsClientBucketSpeed, hlsClientBucketDrift, hlsClientBucketBytes, hlsClientBucketAge, hlsClientMetricsBuckets)
 }
 
 // =============================================================================
@@ -442,7 +1395,8 @@ func initPerClientMetrics(registry prometheus.Registerer) {
 // Collector manages all Prometheus metrics for the swarm.
 type Collector struct {
 	// Configuration
-	perClientEnabled bool
+	perClientEnabled bool // Guarded by mu: DisablePerClient can turn it off
+	perClientMax     int  // Above this many clients, aggregate into perClientMax buckets (0 = no cap)
 	targetClients    int
 	testDuration     time.Duration
 	streamURL        string
@@ -452,30 +1406,81 @@ type Collector struct {
 	startTime time.Time
 
 	// Internal tracking for delta calculations
-	mu                   sync.Mutex
-	prevManifestReqs     int64
-	prevSegmentReqs      int64
-	prevInitReqs         int64
-	prevUnknownReqs      int64
-	prevBytes            int64
-	prevSegmentBytes     int64 // From segment scraper (accurate sizes)
-	prevTimeouts         int64
-	prevReconnections    int64
-	prevHTTPErrors       map[int]int64
-	prevProgressDropped  int64
-	prevStderrDropped    int64
-	prevProgressParsed   int64
-	prevStderrParsed     int64
+	mu                  sync.Mutex
+	prevManifestReqs    int64
+	prevSegmentReqs     int64
+	prevInitReqs        int64
+	prevUnknownReqs     int64
+	prevBytes           int64
+	prevSegmentBytes    int64 // From segment scraper (accurate sizes)
+	prevTimeouts        int64
+	prevReconnections   int64
+	prevHTTPErrors      map[int]int64
+	prevNetworkErrors   map[string]int64
+	prevReconnectCauses map[string]int64
+	prevProgressDropped int64
+	prevStderrDropped   int64
+	prevProgressParsed  int64
+	prevStderrParsed    int64
+	prevProgressBytes   int64
+	prevStderrBytes     int64
+	prevDiscontinuities int64
+	prevAdBreaks        int64
+	prevSlowSegments    int64
+	prevSlowManifests   int64
+	prevOrphanedPending int64
+	prevLinesTruncated  int64
+	prevParserPanics    int64
+	prevInvalidUTF8     int64
+	prevRefreshStorms   int64
 
 	// For summary generation
 	peakActive    int
 	totalStarts   int64
 	totalRestarts int64
+	quarantines   int64
 	exitCodes     map[int]int64
-	uptimes       []time.Duration
+	exitReasons   map[string]int64
+	stopSignals   map[string]int64
+	uptimes       *stats.DurationHistory // Bounded: downsampled on long runs
 
 	// Track registered client IDs for cleanup
 	registeredClientIDs map[int]struct{}
+
+	// Set once the per-client cap is exceeded; stays set for the run so
+	// series don't flap between the two forms during ramp-down
+	perClientBucketed bool
+	registeredBuckets map[int]struct{}
+
+	// Previous per-tenant counter values, by tenant name
+	prevTenants map[string]TenantUpdate
+
+	// Previous per-geo counter values, by geo name
+	prevGeos map[string]GeoUpdate
+
+	// Previous per-variant counter values, by variant
+	prevVariants map[string]VariantUpdate
+	prevServing  map[string]stats.ServingTotals
+
+	// Previous kernel socket counter values
+	prevSockets SocketUpdate
+
+	// Session tokens (-token-url)
+	tokenFetches       int64
+	tokenFetchFailures int64
+	tokenExpiries      int64
+	reauths            *stats.DurationHistory // 401 or expiry -> running again
+
+	// Network flaps (-flap-interval)
+	flapPauses      int64
+	flapUnrecovered int64
+	flapSeqSkips    int64
+	flapExpired     int64
+	flapSkipped     int64
+	flapCatchUps    *stats.DurationHistory // Resume -> next segment
+
+	// Current lifecycle phase (see SetPhase)
+	phase string
 }
 
 // CollectorConfig holds configuration for the collector.
@@ -484,7 +1489,10 @@ type CollectorConfig struct {
 	TestDuration     time.Duration
 	StreamURL        string
 	Variant          string
+	Pacing           string // -pacing model(s), e.g. "realtime"
 	PerClientMetrics bool
+	PerClientMax     int // Cap for per-client series; above it, bucket by client_id (0 = no cap)
+	RetentionSamples int // Max uptimes kept in memory (0 = stats.DefaultRetentionSamples)
 }
 
 // NewCollector creates a new metrics collector.
@@ -497,15 +1505,26 @@ func NewCollector(cfg CollectorConfig) *Collector {
 func NewCollectorWithRegistry(cfg CollectorConfig, registry prometheus.Registerer) *Collector {
 	c := &Collector{
 		perClientEnabled:    cfg.PerClientMetrics,
+		perClientMax:        cfg.PerClientMax,
 		targetClients:       cfg.TargetClients,
 		testDuration:        cfg.TestDuration,
 		streamURL:           cfg.StreamURL,
 		variant:             cfg.Variant,
 		startTime:           time.Now(),
 		prevHTTPErrors:      make(map[int]int64),
+		prevNetworkErrors:   make(map[string]int64),
+		prevReconnectCauses: make(map[string]int64),
 		exitCodes:           make(map[int]int64),
-		uptimes:             make([]time.Duration, 0, cfg.TargetClients),
+		exitReasons:         make(map[string]int64),
+		stopSignals:         make(map[string]int64),
+		uptimes:             stats.NewDurationHistory(cfg.RetentionSamples),
 		registeredClientIDs: make(map[int]struct{}),
+		prevTenants:         make(map[string]TenantUpdate),
+		prevGeos:            make(map[string]GeoUpdate),
+		prevVariants:        make(map[string]VariantUpdate),
+		prevServing:         make(map[string]stats.ServingTotals),
+		reauths:             stats.NewDurationHistory(cfg.RetentionSamples),
+		flapCatchUps:        stats.NewDurationHistory(cfg.RetentionSamples),
 	}
 
 	// Register Tier 1 metrics (always)
@@ -515,9 +1534,20 @@ func NewCollectorWithRegistry(cfg CollectorConfig, registry prometheus.Registere
 		hlsTargetClients,
 		hlsTestDurationSeconds,
 		hlsActiveClients,
+		hlsQuarantinedClients,
 		hlsRampProgress,
+		hlsRampRateTarget,
+		hlsRampRateAchieved,
 		hlsTestElapsedSeconds,
 		hlsTestRemainingSeconds,
+		hlsEstimatedRequestsPerSec,
+		hlsEstimatedBandwidthMbps,
+		hlsClockOffsetSeconds,
+		hlsOriginProbeLatencySeconds,
+		hlsCoolDownRecoverySeconds,
+		hlsPhase,
+		hlsRollingRestartActive,
+		hlsRollingRestartClientsTotal,
 
 		// Panel 2: Request Rates
 		hlsManifestRequestsTotal,
@@ -542,6 +1572,8 @@ func NewCollectorWithRegistry(cfg CollectorConfig, registry prometheus.Registere
 		hlsLatencyP95Seconds,
 		hlsLatencyP99Seconds,
 		hlsLatencyMaxSeconds,
+		hlsSegmentDownloadSeconds,
+		hlsSegmentGapAvgSeconds,
 
 		// Panel 4: Health
 		hlsClientsAboveRealtime,
@@ -551,28 +1583,157 @@ func NewCollectorWithRegistry(cfg CollectorConfig, registry prometheus.Registere
 		hlsHighDriftClients,
 		hlsAverageDriftSeconds,
 		hlsMaxDriftSeconds,
+		hlsLastEventAgeSeconds,
+		hlsIdleClients,
+
+		// Panel 4b: Discontinuities
+		hlsDiscontinuitiesTotal,
+		hlsAdBreaksTotal,
+		hlsClientsRecovering,
+		hlsDiscontinuityRecoveryAvgSeconds,
+		hlsDiscontinuityRecoveryMaxSeconds,
 
 		// Panel 5: Errors
 		hlsHTTPErrorsTotal,
+		hlsSlowRequestsTotal,
 		hlsTimeoutsTotal,
 		hlsReconnectionsTotal,
+		hlsReconnectionCausesTotal,
 		hlsClientStartsTotal,
 		hlsClientRestartsTotal,
+		hlsClientQuarantinesTotal,
 		hlsClientExitsTotal,
+		hlsClientStopSignalsTotal,
 		hlsErrorRate,
+		hlsPlaylistViolationsTotal,
+		hlsPlaylistFetchesTotal,
+		hlsPlaylistWireBytesTotal,
+		hlsPlaylistDecodedBytesTotal,
+		hlsPlaylistDecodeFailuresTotal,
 
 		// Panel 6: Pipeline Health
 		hlsStatsLinesDroppedTotal,
 		hlsStatsLinesParsedTotal,
+		hlsStatsBytesReadTotal,
+		hlsStatsLinesTruncatedTotal,
+		hlsStatsParserPanicsTotal,
+		hlsStatsInvalidUTF8Total,
 		hlsStatsClientsDegraded,
 		hlsStatsDropRate,
 		hlsStatsPeakDropRate,
+		hlsDebugPendingEntries,
+		hlsDebugClockSkewSeconds,
+		hlsDebugPendingOrphanedTotal,
 
 		// Panel 7: Uptime
 		hlsClientUptimeSeconds,
 		hlsUptimeP50Seconds,
 		hlsUptimeP95Seconds,
 		hlsUptimeP99Seconds,
+
+		// Panel 8: Tenants
+		hlsTenantClients,
+		hlsTenantHeldClients,
+		hlsTenantQuotaRequestsPerSec,
+		hlsTenantRequestsPerSec,
+		hlsTenantRequestsTotal,
+		hlsTenantBytesTotal,
+		hlsTenantErrorsTotal,
+		hlsTenantQuotaThrottlesTotal,
+
+		// Panel 9: Session Tokens
+		hlsAuthTokenFetchesTotal,
+		hlsAuthTokenFetchSeconds,
+		hlsAuthReauthsTotal,
+		hlsAuthTokenExpiriesTotal,
+		hlsAuthReauthSeconds,
+
+		// Panel 10: Geos
+		hlsGeoClients,
+		hlsGeoRequestsTotal,
+		hlsGeoErrorsTotal,
+		hlsGeoSegmentWallSeconds,
+		hlsGeoManifestWallSeconds,
+
+		// Panel 11: Kernel TCP Sockets
+		hlsSocketConnections,
+		hlsSocketRTTSeconds,
+		hlsSocketMinRTTSeconds,
+		hlsSocketRetransmitsTotal,
+		hlsSocketOutOfOrderTotal,
+		hlsSocketBytesReceivedTotal,
+		hlsSocketThroughputBytesPerSec,
+
+		// Panel 12: Network Flaps
+		hlsFlapPausedClients,
+		hlsFlapPausesTotal,
+		hlsFlapCatchUpSeconds,
+		hlsFlapUnrecoveredTotal,
+		hlsFlapSequenceSkipsTotal,
+		hlsFlapSegmentsExpiredTotal,
+		hlsFlapSegmentsSkippedTotal,
+
+		// Panel 13: Client CPU
+		hlsClientCPUPercent,
+		hlsClientCPUHighTotal,
+
+		// Panel 14: Variant Mix
+		hlsVariantClients,
+		hlsVariantRequestsTotal,
+		hlsVariantBytesTotal,
+		hlsVariantErrorsTotal,
+
+		// Panel 15: Playlist Refresh
+		hlsPlaylistRefreshInterval,
+		hlsPlaylistRefreshStormsTotal,
+		hlsPlaylistRefreshPeakClients,
+		hlsPlaylistRefreshOverrideTotal,
+
+		// Panel 16: Shard Balance
+		hlsShardSkew,
+		hlsShardHot,
+
+		// Panel 17: CDN vs Origin
+		hlsServedResponsesTotal,
+		hlsServedBytesTotal,
+		hlsServedTTFBSeconds,
+		hlsOriginHitRatio,
+		hlsOriginHitAlert,
+
+		// Panel 18: Memory Guard
+		hlsMemoryRSSBytes,
+		hlsMemoryLimitBytes,
+		hlsMemoryFeaturesShed,
+
+		// Panel 19: Latency SLO
+		hlsSLOTargetRatio,
+		hlsSLOThresholdSeconds,
+		hlsSLOAttainmentRatio,
+		hlsSLOBurnRate,
+		hlsSLOErrorBudgetRemaining,
+
+		// Panel 20: Traffic Bursts
+		hlsBurstClients,
+		hlsBurstsTotal,
+
+		// Panel 21: Viewer Sessions
+		hlsSessionArrivalsTotal,
+		hlsSessionDeparturesTotal,
+		hlsSessionArrivalRate,
+		hlsSessionDepartureRate,
+		hlsSessionLengthSeconds,
+
+		// Panel 22: VOD Start Positions
+		hlsVODStartPosition,
+
+		// Panel 23: VOD Completion
+		hlsVODPlaysTotal,
+		hlsVODPlaysFinishedTotal,
+		hlsVODCompletionSeconds,
+		hlsVODClientsDone,
+
+		// Panel 24: Renditions
+		hlsRenditionRequestsTotal,
 	)
 
 	// Register Tier 2 metrics (optional)
@@ -581,10 +1742,14 @@ func NewCollectorWithRegistry(cfg CollectorConfig, registry prometheus.Registere
 	}
 
 	// Set initial values
-	hlsSwarmInfo.WithLabelValues("1.0", cfg.StreamURL, cfg.Variant).Set(1)
+	hlsSwarmInfo.WithLabelValues("1.0", cfg.StreamURL, cfg.Variant, cfg.Pacing).Set(1)
 	hlsTargetClients.Set(float64(cfg.TargetClients))
 	hlsTestDurationSeconds.Set(cfg.TestDuration.Seconds())
 	hlsTestRemainingSeconds.Set(-1) // -1 = unlimited
+	hlsPhase.Reset()                // Phases of an earlier run in this process
+	hlsRenditionRequestsTotal.Reset()
+	hlsRollingRestartActive.Set(0)
+	hlsBurstClients.Set(0)
 
 	return c
 }
@@ -614,7 +1779,9 @@ type AggregatedStatsUpdate struct {
 
 	// Errors
 	TotalHTTPErrors    map[int]int64
+	TotalNetworkErrors map[string]int64 // By reason (stats.NetworkErrorReasons)
 	TotalReconnections int64
+	ReconnectCauses    map[string]int64 // By cause (parser.ReconnectCauses)
 	TotalTimeouts      int64
 	ErrorRate          float64
 
@@ -624,6 +1791,12 @@ type AggregatedStatsUpdate struct {
 	InferredLatencyP99 time.Duration
 	InferredLatencyMax time.Duration
 
+	// Segment download time vs idle gap (from debug parser)
+	SegmentDownloadP50 time.Duration
+	SegmentDownloadP95 time.Duration
+	SegmentDownloadP99 time.Duration
+	SegmentGapAvg      time.Duration
+
 	// Health
 	ClientsAboveRealtime int
 	ClientsBelowRealtime int
@@ -632,16 +1805,36 @@ type AggregatedStatsUpdate struct {
 	AverageDrift         time.Duration
 	MaxDrift             time.Duration
 
+	// Heartbeat: time since the clients' last parsed event
+	EventAgeP50 time.Duration
+	EventAgeP95 time.Duration
+	EventAgeMax time.Duration
+	IdleClients int
+
 	// Pipeline health
 	TotalLinesDropped    int64
 	TotalLinesRead       int64
 	ClientsWithDrops     int
 	MetricsDegraded      bool
 	PeakDropRate         float64
+	TotalLinesTruncated  int64
+	TotalParserPanics    int64
+	TotalInvalidUTF8     int64
 	ProgressLinesDropped int64
 	ProgressLinesRead    int64
 	StderrLinesDropped   int64
 	StderrLinesRead      int64
+	ProgressBytesRead    int64
+	StderrBytesRead      int64
+	PendingEntries       int   // Debug parser pending maps, all clients
+	TotalOrphanedPending int64 // Pending entries expired without completing
+
+	// FFmpeg timestamp offsets from the swarm's clock, over clients
+	ClockSkewMin time.Duration
+	ClockSkewMax time.Duration
+
+	// Playlist refresh intervals and storms (from debug parser)
+	PlaylistRefresh stats.RefreshDistribution
 
 	// Uptime
 	UptimeP50 time.Duration
@@ -649,11 +1842,22 @@ type AggregatedStatsUpdate struct {
 	UptimeP99 time.Duration
 
 	// Segment-specific (from accurate segment sizes via segment scraper)
-	TotalSegmentBytes          int64
-	SegmentThroughputAvg1s     float64
-	SegmentThroughputAvg30s    float64
-	SegmentThroughputAvg60s    float64
-	SegmentThroughputAvg300s   float64
+	TotalSegmentBytes        int64
+	SegmentThroughputAvg1s   float64
+	SegmentThroughputAvg30s  float64
+	SegmentThroughputAvg60s  float64
+	SegmentThroughputAvg300s float64
+
+	// Discontinuities / ad insertion (from debug parser)
+	TotalDiscontinuities int64
+	TotalAdBreaks        int64
+
+	// Slow requests (from debug parser, -slow-request-log)
+	TotalSlowSegments  int64
+	TotalSlowManifests int64
+	ClientsRecovering  int
+	RecoveryAvg        time.Duration
+	RecoveryMax        time.Duration
 
 	// Per-client (only if enabled)
 	PerClientStats []PerClientStatsUpdate
@@ -665,6 +1869,7 @@ type PerClientStatsUpdate struct {
 	CurrentSpeed float64
 	CurrentDrift time.Duration
 	TotalBytes   int64
+	EventAge     time.Duration // Since the client's last parsed event
 }
 
 // RecordStats updates all metrics from aggregated stats.
@@ -750,6 +1955,10 @@ func (c *Collector) RecordStats(stats *AggregatedStatsUpdate) {
 	hlsLatencyP95Seconds.Set(stats.InferredLatencyP95.Seconds())
 	hlsLatencyP99Seconds.Set(stats.InferredLatencyP99.Seconds())
 	hlsLatencyMaxSeconds.Set(stats.InferredLatencyMax.Seconds())
+	hlsSegmentDownloadSeconds.WithLabelValues("0.5").Set(stats.SegmentDownloadP50.Seconds())
+	hlsSegmentDownloadSeconds.WithLabelValues("0.95").Set(stats.SegmentDownloadP95.Seconds())
+	hlsSegmentDownloadSeconds.WithLabelValues("0.99").Set(stats.SegmentDownloadP99.Seconds())
+	hlsSegmentGapAvgSeconds.Set(stats.SegmentGapAvg.Seconds())
 
 	// --- Panel 4: Health ---
 	hlsClientsAboveRealtime.Set(float64(stats.ClientsAboveRealtime))
@@ -759,6 +1968,31 @@ func (c *Collector) RecordStats(stats *AggregatedStatsUpdate) {
 	hlsHighDriftClients.Set(float64(stats.ClientsWithHighDrift))
 	hlsAverageDriftSeconds.Set(stats.AverageDrift.Seconds())
 	hlsMaxDriftSeconds.Set(stats.MaxDrift.Seconds())
+	hlsLastEventAgeSeconds.WithLabelValues("p50").Set(stats.EventAgeP50.Seconds())
+	hlsLastEventAgeSeconds.WithLabelValues("p95").Set(stats.EventAgeP95.Seconds())
+	hlsLastEventAgeSeconds.WithLabelValues("max").Set(stats.EventAgeMax.Seconds())
+	hlsIdleClients.Set(float64(stats.IdleClients))
+
+	// --- Panel 4b: Discontinuities ---
+	if delta := stats.TotalDiscontinuities - c.prevDiscontinuities; delta > 0 {
+		hlsDiscontinuitiesTotal.Add(float64(delta))
+	}
+	if delta := stats.TotalAdBreaks - c.prevAdBreaks; delta > 0 {
+		hlsAdBreaksTotal.Add(float64(delta))
+	}
+	c.prevDiscontinuities = stats.TotalDiscontinuities
+	c.prevAdBreaks = stats.TotalAdBreaks
+	if delta := stats.TotalSlowSegments - c.prevSlowSegments; delta > 0 {
+		hlsSlowRequestsTotal.WithLabelValues("segment").Add(float64(delta))
+	}
+	if delta := stats.TotalSlowManifests - c.prevSlowManifests; delta > 0 {
+		hlsSlowRequestsTotal.WithLabelValues("manifest").Add(float64(delta))
+	}
+	c.prevSlowSegments = stats.TotalSlowSegments
+	c.prevSlowManifests = stats.TotalSlowManifests
+	hlsClientsRecovering.Set(float64(stats.ClientsRecovering))
+	hlsDiscontinuityRecoveryAvgSeconds.Set(stats.RecoveryAvg.Seconds())
+	hlsDiscontinuityRecoveryMaxSeconds.Set(stats.RecoveryMax.Seconds())
 
 	// --- Panel 5: Errors ---
 	// HTTP errors by status code (delta)
@@ -768,14 +2002,22 @@ func (c *Collector) RecordStats(stats *AggregatedStatsUpdate) {
 		if delta > 0 {
 			if code == 0 {
 				// Code 0 is the sentinel for "other" (non-standard HTTP error codes)
-				hlsHTTPErrorsTotal.WithLabelValues("other").Add(float64(delta))
+				hlsHTTPErrorsTotal.WithLabelValues("other", "other").Add(float64(delta))
 			} else {
-				hlsHTTPErrorsTotal.WithLabelValues(strconv.Itoa(code)).Add(float64(delta))
+				hlsHTTPErrorsTotal.WithLabelValues(strconv.Itoa(code), httpErrorReason(code)).Add(float64(delta))
 			}
 		}
 		c.prevHTTPErrors[code] = count
 	}
 
+	// Network failures by reason (delta), no status code
+	for reason, count := range stats.TotalNetworkErrors {
+		if delta := count - c.prevNetworkErrors[reason]; delta > 0 {
+			hlsHTTPErrorsTotal.WithLabelValues("none", reason).Add(float64(delta))
+		}
+		c.prevNetworkErrors[reason] = count
+	}
+
 	// Timeouts and reconnections (delta)
 	timeoutDelta := stats.TotalTimeouts - c.prevTimeouts
 	reconnectDelta := stats.TotalReconnections - c.prevReconnections
@@ -787,6 +2029,13 @@ func (c *Collector) RecordStats(stats *AggregatedStatsUpdate) {
 	}
 	c.prevTimeouts = stats.TotalTimeouts
 	c.prevReconnections = stats.TotalReconnections
+	for cause, count := range stats.ReconnectCauses {
+		if delta := count - c.prevReconnectCauses[cause]; delta > 0 {
+			stage, _, _ := strings.Cut(cause, " ")
+			hlsReconnectionCausesTotal.WithLabelValues(stage, cause).Add(float64(delta))
+		}
+		c.prevReconnectCauses[cause] = count
+	}
 
 	hlsErrorRate.Set(stats.ErrorRate)
 
@@ -815,6 +2064,15 @@ func (c *Collector) RecordStats(stats *AggregatedStatsUpdate) {
 	c.prevStderrDropped = stats.StderrLinesDropped
 	c.prevStderrParsed = stats.StderrLinesRead - stats.StderrLinesDropped
 
+	if delta := stats.ProgressBytesRead - c.prevProgressBytes; delta > 0 {
+		hlsStatsBytesReadTotal.WithLabelValues("progress").Add(float64(delta))
+	}
+	c.prevProgressBytes = stats.ProgressBytesRead
+	if delta := stats.StderrBytesRead - c.prevStderrBytes; delta > 0 {
+		hlsStatsBytesReadTotal.WithLabelValues("stderr").Add(float64(delta))
+	}
+	c.prevStderrBytes = stats.StderrBytesRead
+
 	hlsStatsClientsDegraded.Set(float64(stats.ClientsWithDrops))
 
 	// Calculate overall drop rate
@@ -826,24 +2084,131 @@ func (c *Collector) RecordStats(stats *AggregatedStatsUpdate) {
 	}
 	hlsStatsDropRate.Set(dropRate)
 	hlsStatsPeakDropRate.Set(stats.PeakDropRate)
+	if delta := stats.TotalLinesTruncated - c.prevLinesTruncated; delta > 0 {
+		hlsStatsLinesTruncatedTotal.Add(float64(delta))
+	}
+	c.prevLinesTruncated = stats.TotalLinesTruncated
+	if delta := stats.TotalParserPanics - c.prevParserPanics; delta > 0 {
+		hlsStatsParserPanicsTotal.Add(float64(delta))
+	}
+	c.prevParserPanics = stats.TotalParserPanics
+	if delta := stats.TotalInvalidUTF8 - c.prevInvalidUTF8; delta > 0 {
+		hlsStatsInvalidUTF8Total.Add(float64(delta))
+	}
+	c.prevInvalidUTF8 = stats.TotalInvalidUTF8
+	hlsDebugPendingEntries.Set(float64(stats.PendingEntries))
+	if delta := stats.TotalOrphanedPending - c.prevOrphanedPending; delta > 0 {
+		hlsDebugPendingOrphanedTotal.Add(float64(delta))
+	}
+	c.prevOrphanedPending = stats.TotalOrphanedPending
+	hlsDebugClockSkewSeconds.WithLabelValues("min").Set(stats.ClockSkewMin.Seconds())
+	hlsDebugClockSkewSeconds.WithLabelValues("max").Set(stats.ClockSkewMax.Seconds())
 
 	// --- Panel 7: Uptime ---
 	hlsUptimeP50Seconds.Set(stats.UptimeP50.Seconds())
 	hlsUptimeP95Seconds.Set(stats.UptimeP95.Seconds())
 	hlsUptimeP99Seconds.Set(stats.UptimeP99.Seconds())
 
+	// --- Panel 15: Playlist Refresh ---
+	hlsPlaylistRefreshInterval.set(stats.PlaylistRefresh)
+	if delta := stats.PlaylistRefresh.Storms - c.prevRefreshStorms; delta > 0 {
+		hlsPlaylistRefreshStormsTotal.Add(float64(delta))
+	}
+	c.prevRefreshStorms = stats.PlaylistRefresh.Storms
+	hlsPlaylistRefreshPeakClients.Set(float64(stats.PlaylistRefresh.PeakClients))
+
 	// --- Tier 2: Per-client metrics ---
 	if c.perClientEnabled && len(stats.PerClientStats) > 0 {
+		if c.perClientMax > 0 && len(stats.PerClientStats) > c.perClientMax && !c.perClientBucketed {
+			c.switchToBuckets()
+		}
+		if c.perClientBucketed {
+			c.recordClientBuckets(stats.PerClientStats)
+			return
+		}
 		for _, cs := range stats.PerClientStats {
 			clientID := strconv.Itoa(cs.ClientID)
 			hlsClientSpeed.WithLabelValues(clientID).Set(cs.CurrentSpeed)
 			hlsClientDrift.WithLabelValues(clientID).Set(cs.CurrentDrift.Seconds())
 			hlsClientBytes.WithLabelValues(clientID).Set(float64(cs.TotalBytes))
+			hlsClientAge.WithLabelValues(clientID).Set(cs.EventAge.Seconds())
 			c.registeredClientIDs[cs.ClientID] = struct{}{}
 		}
 	}
 }
 
+// switchToBuckets drops the per-client series for the bucketed form.
+// Caller must hold c.mu.
+func (c *Collector) switchToBuckets() {
+	c.perClientBucketed = true
+	c.registeredClientIDs = make(map[int]struct{})
+	c.registeredBuckets = make(map[int]struct{})
+	hlsClientSpeed.Reset()
+	hlsClientDrift.Reset()
+	hlsClientBytes.Reset()
+	hlsClientAge.Reset()
+	hlsClientMetricsBuckets.Set(float64(c.perClientMax))
+}
+
+// recordClientBuckets aggregates per-client stats into c.perClientMax
+// buckets by client_id: mean speed, worst drift, summed bytes and the
+// longest last-event age. Buckets left without clients are deleted.
+// Caller must hold c.mu.
+func (c *Collector) recordClientBuckets(clients []PerClientStatsUpdate) {
+	type bucket struct {
+		n     int
+		speed float64
+		drift time.Duration
+		bytes int64
+		age   time.Duration
+	}
+	buckets := make(map[int]*bucket)
+	for _, cs := range clients {
+		id := cs.ClientID % c.perClientMax
+		b := buckets[id]
+		if b == nil {
+			b = &bucket{}
+			buckets[id] = b
+		}
+		b.n++
+		b.speed += cs.CurrentSpeed
+		b.drift = max(b.drift, cs.CurrentDrift)
+		b.bytes += cs.TotalBytes
+		b.age = max(b.age, cs.EventAge)
+	}
+
+	for id, b := range buckets {
+		label := strconv.Itoa(id)
+		hlsClientBucketSpeed.WithLabelValues(label).Set(b.speed / float64(b.n))
+		hlsClientBucketDrift.WithLabelValues(label).Set(b.drift.Seconds())
+		hlsClientBucketBytes.WithLabelValues(label).Set(float64(b.bytes))
+		hlsClientBucketAge.WithLabelValues(label).Set(b.age.Seconds())
+		c.registeredBuckets[id] = struct{}{}
+	}
+	for id := range c.registeredBuckets {
+		if _, ok := buckets[id]; !ok {
+			label := strconv.Itoa(id)
+			hlsClientBucketSpeed.DeleteLabelValues(label)
+			hlsClientBucketDrift.DeleteLabelValues(label)
+			hlsClientBucketBytes.DeleteLabelValues(label)
+			hlsClientBucketAge.DeleteLabelValues(label)
+			delete(c.registeredBuckets, id)
+		}
+	}
+}
+
+// httpErrorReason classifies a status code for the reason label.
+func httpErrorReason(code int) string {
+	switch {
+	case code >= 400 && code < 500:
+		return "4xx"
+	case code >= 500 && code < 600:
+		return "5xx"
+	default:
+		return "other"
+	}
+}
+
 // RecordLatency records a single latency observation to the histogram.
 func (c *Collector) RecordLatency(d time.Duration) {
 	hlsInferredLatencySeconds.Observe(d.Seconds())
@@ -871,6 +2236,20 @@ func (c *Collector) ClientRestarted() {
 	c.mu.Unlock()
 }
 
+// RecordQuarantine records a client being quarantined.
+func (c *Collector) RecordQuarantine() {
+	hlsClientQuarantinesTotal.Inc()
+
+	c.mu.Lock()
+	c.quarantines++
+	c.mu.Unlock()
+}
+
+// SetQuarantinedCount sets the number of clients in quarantine.
+func (c *Collector) SetQuarantinedCount(count int) {
+	hlsQuarantinedClients.Set(float64(count))
+}
+
 // RecordExit records a process exit event.
 func (c *Collector) RecordExit(exitCode int, uptime time.Duration) {
 	// Categorize exit code
@@ -887,8 +2266,58 @@ func (c *Collector) RecordExit(exitCode int, uptime time.Duration) {
 
 	c.mu.Lock()
 	c.exitCodes[exitCode]++
-	c.uptimes = append(c.uptimes, uptime)
 	c.mu.Unlock()
+
+	c.uptimes.Add(uptime)
+}
+
+// RecordExitReason counts a classified process exit for the exit summary.
+func (c *Collector) RecordExitReason(reason string) {
+	c.mu.Lock()
+	c.exitReasons[reason]++
+	c.mu.Unlock()
+}
+
+// signalNames are the labels of the signals a client can be stopped with.
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGKILL: "SIGKILL",
+}
+
+// RecordStopSignal counts a signal sent to stop a client process.
+func (c *Collector) RecordStopSignal(sig syscall.Signal) {
+	name, ok := signalNames[sig]
+	if !ok {
+		name = fmt.Sprintf("signal %d", int(sig))
+	}
+	hlsClientStopSignalsTotal.WithLabelValues(name).Inc()
+
+	c.mu.Lock()
+	c.stopSignals[name]++
+	c.mu.Unlock()
+}
+
+// SpillHistoryTo writes the full-resolution uptime history to a file in dir,
+// gzip-compressed if compress is set, so exit-summary percentiles stay
+// exact after in-memory downsampling. Must be called before the first
+// client exits. Returns the file path.
+func (c *Collector) SpillHistoryTo(dir string, compress bool) (string, error) {
+	path := filepath.Join(dir, fmt.Sprintf("hls-swarm-uptimes-%d.bin", os.Getpid()))
+	if compress {
+		path += stats.CompressedSuffix
+	}
+	if err := c.uptimes.SpillTo(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Close flushes any history spill file.
+func (c *Collector) Close() error {
+	return c.uptimes.Close()
 }
 
 // SetActiveCount updates the active client count (for backward compatibility).
@@ -902,11 +2331,476 @@ func (c *Collector) SetActiveCount(count int) {
 	c.mu.Unlock()
 }
 
+// SetLoadEstimate records the pre-run origin load estimate.
+func (c *Collector) SetLoadEstimate(requestsPerSec, bandwidthMbps float64) {
+	hlsEstimatedRequestsPerSec.Set(requestsPerSec)
+	hlsEstimatedBandwidthMbps.Set(bandwidthMbps)
+}
+
+// SetClockOffset records the local clock's measured offset from NTP.
+func (c *Collector) SetClockOffset(offset time.Duration) {
+	hlsClockOffsetSeconds.Set(offset.Seconds())
+}
+
+// SetProbeLatency records the latest -cool-down origin probe.
+func (c *Collector) SetProbeLatency(d time.Duration) {
+	hlsOriginProbeLatencySeconds.Set(d.Seconds())
+}
+
+// SetCoolDownRecovery records when origin latency returned to baseline.
+func (c *Collector) SetCoolDownRecovery(d time.Duration) {
+	hlsCoolDownRecoverySeconds.Set(d.Seconds())
+}
+
+// RecordPlaylistViolation counts one playlist compliance violation.
+func (c *Collector) RecordPlaylistViolation(kind string) {
+	hlsPlaylistViolationsTotal.WithLabelValues(kind).Inc()
+}
+
+// RecordPlaylistFetch counts one validation playlist reload and its body
+// size on the wire and decoded.
+func (c *Collector) RecordPlaylistFetch(encoding string, wireBytes, decodedBytes int64, decodeFailed bool) {
+	hlsPlaylistFetchesTotal.WithLabelValues(encoding).Inc()
+	hlsPlaylistWireBytesTotal.WithLabelValues(encoding).Add(float64(wireBytes))
+	hlsPlaylistDecodedBytesTotal.WithLabelValues(encoding).Add(float64(decodedBytes))
+	if decodeFailed {
+		hlsPlaylistDecodeFailuresTotal.WithLabelValues(encoding).Inc()
+	}
+}
+
+// TenantUpdate is one tenant's state for RecordTenants. Counters are
+// cumulative; the collector exports the increase since the last update.
+type TenantUpdate struct {
+	Tenant      string
+	Clients     int     // Running
+	HeldClients int     // Held back or stopped by the quota
+	QuotaRPS    float64 // 0 = unlimited
+	RequestRate float64
+	Requests    int64
+	Bytes       int64
+	Errors      int64
+	Throttles   int64
+}
+
+// RecordTenants updates the per-tenant metrics of a -tenants run.
+func (c *Collector) RecordTenants(tenants []TenantUpdate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, t := range tenants {
+		hlsTenantClients.WithLabelValues(t.Tenant).Set(float64(t.Clients))
+		hlsTenantHeldClients.WithLabelValues(t.Tenant).Set(float64(t.HeldClients))
+		hlsTenantQuotaRequestsPerSec.WithLabelValues(t.Tenant).Set(t.QuotaRPS)
+		hlsTenantRequestsPerSec.WithLabelValues(t.Tenant).Set(t.RequestRate)
+
+		prev := c.prevTenants[t.Tenant]
+		if delta := t.Requests - prev.Requests; delta > 0 {
+			hlsTenantRequestsTotal.WithLabelValues(t.Tenant).Add(float64(delta))
+		}
+		if delta := t.Bytes - prev.Bytes; delta > 0 {
+			hlsTenantBytesTotal.WithLabelValues(t.Tenant).Add(float64(delta))
+		}
+		if delta := t.Errors - prev.Errors; delta > 0 {
+			hlsTenantErrorsTotal.WithLabelValues(t.Tenant).Add(float64(delta))
+		}
+		if delta := t.Throttles - prev.Throttles; delta > 0 {
+			hlsTenantQuotaThrottlesTotal.WithLabelValues(t.Tenant).Add(float64(delta))
+		}
+		c.prevTenants[t.Tenant] = t
+	}
+}
+
+// GeoUpdate is one geo's state for RecordGeos. Counters are cumulative;
+// the collector exports the increase since the last update.
+type GeoUpdate struct {
+	Geo      string
+	Clients  int
+	Requests int64
+	Errors   int64
+
+	SegmentP50, SegmentP95, SegmentP99    time.Duration
+	ManifestP50, ManifestP95, ManifestP99 time.Duration
+}
+
+// RecordGeos updates the per-geo metrics of a -geo run.
+func (c *Collector) RecordGeos(geos []GeoUpdate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, g := range geos {
+		hlsGeoClients.WithLabelValues(g.Geo).Set(float64(g.Clients))
+		hlsGeoSegmentWallSeconds.WithLabelValues(g.Geo, "0.5").Set(g.SegmentP50.Seconds())
+		hlsGeoSegmentWallSeconds.WithLabelValues(g.Geo, "0.95").Set(g.SegmentP95.Seconds())
+		hlsGeoSegmentWallSeconds.WithLabelValues(g.Geo, "0.99").Set(g.SegmentP99.Seconds())
+		hlsGeoManifestWallSeconds.WithLabelValues(g.Geo, "0.5").Set(g.ManifestP50.Seconds())
+		hlsGeoManifestWallSeconds.WithLabelValues(g.Geo, "0.95").Set(g.ManifestP95.Seconds())
+		hlsGeoManifestWallSeconds.WithLabelValues(g.Geo, "0.99").Set(g.ManifestP99.Seconds())
+
+		prev := c.prevGeos[g.Geo]
+		if delta := g.Requests - prev.Requests; delta > 0 {
+			hlsGeoRequestsTotal.WithLabelValues(g.Geo).Add(float64(delta))
+		}
+		if delta := g.Errors - prev.Errors; delta > 0 {
+			hlsGeoErrorsTotal.WithLabelValues(g.Geo).Add(float64(delta))
+		}
+		c.prevGeos[g.Geo] = g
+	}
+}
+
+// VariantUpdate is one -variant-mix variant's load for RecordVariants.
+// Counters are cumulative; the collector exports the increase since the
+// last update.
+type VariantUpdate struct {
+	Variant  string
+	Clients  int
+	Requests int64
+	Bytes    int64
+	Errors   int64
+}
+
+// RecordVariants updates the per-variant metrics of a -variant-mix run.
+func (c *Collector) RecordVariants(variants []VariantUpdate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, v := range variants {
+		hlsVariantClients.WithLabelValues(v.Variant).Set(float64(v.Clients))
+
+		prev := c.prevVariants[v.Variant]
+		if delta := v.Requests - prev.Requests; delta > 0 {
+			hlsVariantRequestsTotal.WithLabelValues(v.Variant).Add(float64(delta))
+		}
+		if delta := v.Bytes - prev.Bytes; delta > 0 {
+			hlsVariantBytesTotal.WithLabelValues(v.Variant).Add(float64(delta))
+		}
+		if delta := v.Errors - prev.Errors; delta > 0 {
+			hlsVariantErrorsTotal.WithLabelValues(v.Variant).Add(float64(delta))
+		}
+		c.prevVariants[v.Variant] = v
+	}
+}
+
+// RecordShards updates the shard balance of sharded origins (see
+// stats.GroupShards).
+func (c *Collector) RecordShards(groups []stats.ShardGroup) {
+	for _, g := range groups {
+		hlsShardSkew.WithLabelValues(g.Template).Set(g.Skew)
+		hlsShardHot.WithLabelValues(g.Template).Set(float64(len(g.HotShards())))
+	}
+}
+
+// RecordServing updates the CDN vs origin metrics of a -cdn-detect run
+// (see stats.BreakdownServing).
+func (c *Collector) RecordServing(s *stats.ServingBreakdown) {
+	if s == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, class := range s.Classes {
+		prev := c.prevServing[class.Class]
+		if delta := class.Responses - prev.Responses; delta > 0 {
+			hlsServedResponsesTotal.WithLabelValues(class.Class).Add(float64(delta))
+		}
+		if delta := class.Bytes - prev.Bytes; delta > 0 {
+			hlsServedBytesTotal.WithLabelValues(class.Class).Add(float64(delta))
+		}
+		hlsServedTTFBSeconds.WithLabelValues(class.Class).Set(class.TTFB().Seconds())
+		c.prevServing[class.Class] = class.ServingTotals
+	}
+	hlsOriginHitRatio.Set(s.OriginHitRatio)
+	if s.Flagged {
+		hlsOriginHitAlert.Set(1)
+	} else {
+		hlsOriginHitAlert.Set(0)
+	}
+}
+
+// SocketUpdate is one kernel socket sample for RecordSockets. Counters
+// are cumulative; the collector exports the increase since the last update.
+type SocketUpdate struct {
+	Connections            int
+	RTTP50, RTTP95, RTTP99 time.Duration
+	MinRTT                 time.Duration
+	Retransmits            int64
+	OutOfOrder             int64
+	BytesReceived          int64
+	ThroughputBps          float64
+}
+
+// RecordSockets updates the kernel TCP socket metrics of a -socket-stats run.
+func (c *Collector) RecordSockets(s SocketUpdate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hlsSocketConnections.Set(float64(s.Connections))
+	hlsSocketRTTSeconds.WithLabelValues("0.5").Set(s.RTTP50.Seconds())
+	hlsSocketRTTSeconds.WithLabelValues("0.95").Set(s.RTTP95.Seconds())
+	hlsSocketRTTSeconds.WithLabelValues("0.99").Set(s.RTTP99.Seconds())
+	hlsSocketMinRTTSeconds.Set(s.MinRTT.Seconds())
+	hlsSocketThroughputBytesPerSec.Set(s.ThroughputBps)
+
+	if delta := s.Retransmits - c.prevSockets.Retransmits; delta > 0 {
+		hlsSocketRetransmitsTotal.Add(float64(delta))
+	}
+	if delta := s.OutOfOrder - c.prevSockets.OutOfOrder; delta > 0 {
+		hlsSocketOutOfOrderTotal.Add(float64(delta))
+	}
+	if delta := s.BytesReceived - c.prevSockets.BytesReceived; delta > 0 {
+		hlsSocketBytesReceivedTotal.Add(float64(delta))
+	}
+	c.prevSockets = s
+}
+
+// RecordTokenFetch records one session token fetch.
+func (c *Collector) RecordTokenFetch(latency time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	hlsAuthTokenFetchesTotal.WithLabelValues(result).Inc()
+	hlsAuthTokenFetchSeconds.Observe(latency.Seconds())
+
+	c.mu.Lock()
+	c.tokenFetches++
+	if err != nil {
+		c.tokenFetchFailures++
+	}
+	c.mu.Unlock()
+}
+
+// RecordTokenExpiry records a session token expired by -token-ttl.
+func (c *Collector) RecordTokenExpiry() {
+	hlsAuthTokenExpiriesTotal.Inc()
+
+	c.mu.Lock()
+	c.tokenExpiries++
+	c.mu.Unlock()
+}
+
+// RecordReauth records a client running again after a 401 or a token
+// expiry, latency after it.
+func (c *Collector) RecordReauth(latency time.Duration) {
+	hlsAuthReauthsTotal.Inc()
+	hlsAuthReauthSeconds.Observe(latency.Seconds())
+	c.reauths.Add(latency)
+}
+
+// FlapResult is how one client caught up after a network flap paused it.
+type FlapResult struct {
+	CatchUp         time.Duration // Resume to next downloaded segment
+	Recovered       bool          // A segment was downloaded within the timeout
+	SequenceSkips   int64
+	SegmentsExpired int64
+	SegmentsSkipped int64
+}
+
+// SetFlapPaused sets the number of clients paused by network flaps.
+func (c *Collector) SetFlapPaused(n int) {
+	hlsFlapPausedClients.Set(float64(n))
+}
+
+// RecordFlapResult records one client's catch-up after a network flap.
+func (c *Collector) RecordFlapResult(r FlapResult) {
+	hlsFlapPausesTotal.Inc()
+	if r.Recovered {
+		hlsFlapCatchUpSeconds.Observe(r.CatchUp.Seconds())
+		c.flapCatchUps.Add(r.CatchUp)
+	} else {
+		hlsFlapUnrecoveredTotal.Inc()
+	}
+	hlsFlapSequenceSkipsTotal.Add(float64(r.SequenceSkips))
+	hlsFlapSegmentsExpiredTotal.Add(float64(r.SegmentsExpired))
+	hlsFlapSegmentsSkippedTotal.Add(float64(r.SegmentsSkipped))
+
+	c.mu.Lock()
+	c.flapPauses++
+	if !r.Recovered {
+		c.flapUnrecovered++
+	}
+	c.flapSeqSkips += r.SequenceSkips
+	c.flapExpired += r.SegmentsExpired
+	c.flapSkipped += r.SegmentsSkipped
+	c.mu.Unlock()
+}
+
+// RecordPlaylistRefreshOverride counts one -playlist-refresh fetch;
+// notModified is a 304 to a conditional one.
+func (c *Collector) RecordPlaylistRefreshOverride(notModified bool, err error) {
+	result := "ok"
+	switch {
+	case err != nil:
+		result = "error"
+	case notModified:
+		result = "not_modified"
+	}
+	hlsPlaylistRefreshOverrideTotal.WithLabelValues(result).Inc()
+}
+
+// RecordClientCPU records one sample of the clients' CPU use, in percent
+// of one core, and the processes newly flagged as too busy.
+func (c *Collector) RecordClientCPU(mean, max float64, flagged int) {
+	hlsClientCPUPercent.WithLabelValues("mean").Set(mean)
+	hlsClientCPUPercent.WithLabelValues("max").Set(max)
+	hlsClientCPUHighTotal.Add(float64(flagged))
+}
+
+// DisablePerClient stops exporting per-client metrics and drops their
+// series, per client or bucketed, for the rest of the run (the -max-memory
+// guard). Returns false if they were off already.
+func (c *Collector) DisablePerClient() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.perClientEnabled {
+		return false
+	}
+	c.perClientEnabled = false
+	c.registeredClientIDs = make(map[int]struct{})
+	c.registeredBuckets = make(map[int]struct{})
+	hlsClientSpeed.Reset()
+	hlsClientDrift.Reset()
+	hlsClientBytes.Reset()
+	hlsClientAge.Reset()
+	hlsClientBucketSpeed.Reset()
+	hlsClientBucketDrift.Reset()
+	hlsClientBucketBytes.Reset()
+	hlsClientBucketAge.Reset()
+	return true
+}
+
+// ShrinkHistories lowers the in-memory sample cap of the run-long
+// duration histories (uptimes, re-auths, flap catch-ups) to maxSamples,
+// for the -max-memory guard. Percentiles from them get coarser.
+func (c *Collector) ShrinkHistories(maxSamples int) {
+	for _, h := range []*stats.DurationHistory{c.uptimes, c.reauths, c.flapCatchUps} {
+		h.Shrink(maxSamples)
+	}
+}
+
+// RecordMemory records the swarm's own RSS against the -max-memory limit
+// and how many features the guard has shed so far.
+func (c *Collector) RecordMemory(rss, limit int64, shed int) {
+	hlsMemoryRSSBytes.Set(float64(rss))
+	hlsMemoryLimitBytes.Set(float64(limit))
+	hlsMemoryFeaturesShed.Set(float64(shed))
+}
+
+// RecordSLO records where the -slo latency objective stands.
+func (c *Collector) RecordSLO(s stats.SLOStatus) {
+	hlsSLOTargetRatio.Set(s.Target)
+	hlsSLOThresholdSeconds.Set(s.Threshold.Seconds())
+	hlsSLOAttainmentRatio.Set(s.Attainment())
+	hlsSLOBurnRate.Set(s.BurnRate)
+	hlsSLOErrorBudgetRemaining.Set(s.BudgetRemaining())
+}
+
 // SetRampProgress updates the ramp-up progress (for backward compatibility).
 func (c *Collector) SetRampProgress(progress float64) {
 	hlsRampProgress.Set(progress)
 }
 
+// SetRampRate records the target and achieved client start rates of a
+// ramp, in clients per second.
+func (c *Collector) SetRampRate(target, achieved float64) {
+	hlsRampRateTarget.Set(target)
+	hlsRampRateAchieved.Set(achieved)
+}
+
+// SetPhase marks phase as the run's current lifecycle phase, and every
+// phase set before as past.
+func (c *Collector) SetPhase(phase string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.phase != "" {
+		hlsPhase.WithLabelValues(c.phase).Set(0)
+	}
+	c.phase = phase
+	hlsPhase.WithLabelValues(phase).Set(1)
+}
+
+// SetRollingRestart marks a rolling restart of the clients as going on or
+// over.
+func (c *Collector) SetRollingRestart(active bool) {
+	if active {
+		hlsRollingRestartActive.Set(1)
+	} else {
+		hlsRollingRestartActive.Set(0)
+	}
+}
+
+// RecordRollingRestart counts a client restarted by a rolling restart.
+func (c *Collector) RecordRollingRestart() {
+	hlsRollingRestartClientsTotal.Inc()
+}
+
+// SetBurstClients sets the number of clients added by bursts going on
+// now.
+func (c *Collector) SetBurstClients(n int) {
+	hlsBurstClients.Set(float64(n))
+}
+
+// RecordBurst counts a traffic burst started.
+func (c *Collector) RecordBurst() {
+	hlsBurstsTotal.Inc()
+}
+
+// RecordSessionStart counts a viewer session started.
+func (c *Collector) RecordSessionStart() {
+	hlsSessionArrivalsTotal.Inc()
+}
+
+// RecordSessionEnd counts a viewer session ended after length.
+func (c *Collector) RecordSessionEnd(length time.Duration) {
+	hlsSessionDeparturesTotal.Inc()
+	hlsSessionLengthSeconds.Observe(length.Seconds())
+}
+
+// SetSessionRates sets the viewer session arrivals and departures per
+// second.
+func (c *Collector) SetSessionRates(arrivals, departures float64) {
+	hlsSessionArrivalRate.Set(arrivals)
+	hlsSessionDepartureRate.Set(departures)
+}
+
+// RecordVODStart records a client starting at ratio of the VOD asset's
+// duration.
+func (c *Collector) RecordVODStart(ratio float64) {
+	hlsVODStartPosition.Observe(ratio)
+}
+
+// RecordVODPlay counts a play of the VOD asset started.
+func (c *Collector) RecordVODPlay() {
+	hlsVODPlaysTotal.Inc()
+}
+
+// RecordVODFinish counts a play that reached the end of the VOD asset
+// after played.
+func (c *Collector) RecordVODFinish(played time.Duration) {
+	hlsVODPlaysFinishedTotal.Inc()
+	hlsVODCompletionSeconds.Observe(played.Seconds())
+}
+
+// SetVODClientsDone sets the number of clients done with the VOD asset.
+func (c *Collector) SetVODClientsDone(n int) {
+	hlsVODClientsDone.Set(float64(n))
+}
+
+// RecordRenditionRequest counts a request for a rendition's segment or
+// playlist (kind).
+func (c *Collector) RecordRenditionRequest(rendition, kind string) {
+	hlsRenditionRequestsTotal.WithLabelValues(rendition, kind).Inc()
+}
+
+// SetTargetClients changes the target client count, when the swarm is
+// scaled while it runs.
+func (c *Collector) SetTargetClients(n int) {
+	c.mu.Lock()
+	c.targetClients = n
+	c.mu.Unlock()
+	hlsTargetClients.Set(float64(n))
+}
+
 // =============================================================================
 // Cleanup Methods
 // =============================================================================
@@ -914,18 +2808,19 @@ func (c *Collector) SetRampProgress(progress float64) {
 // RemoveClient removes per-client metrics for a client.
 // Only relevant when per-client metrics are enabled.
 func (c *Collector) RemoveClient(clientID int) {
-	if !c.perClientEnabled {
-		return
-	}
-
 	c.mu.Lock()
+	enabled := c.perClientEnabled
 	delete(c.registeredClientIDs, clientID)
 	c.mu.Unlock()
+	if !enabled {
+		return
+	}
 
 	clientIDStr := strconv.Itoa(clientID)
 	hlsClientSpeed.DeleteLabelValues(clientIDStr)
 	hlsClientDrift.DeleteLabelValues(clientIDStr)
 	hlsClientBytes.DeleteLabelValues(clientIDStr)
+	hlsClientAge.DeleteLabelValues(clientIDStr)
 }
 
 // =============================================================================
@@ -939,10 +2834,34 @@ type Summary struct {
 	PeakActiveClients int
 	TotalStarts       int64
 	TotalRestarts     int64
+	Quarantines       int64
 	ExitCodes         map[int]int64
+	ExitReasons       map[string]int64 // See stats.ClassifyExit
+	StopSignals       map[string]int64 // Signals sent to stop clients, by name
 	UptimeP50         time.Duration
 	UptimeP95         time.Duration
 	UptimeP99         time.Duration
+	UptimeExits       int64 // Exits recorded
+	UptimeComplete    bool  // Percentiles cover every exit (not downsampled, or spilled)
+
+	// Session tokens (-token-url)
+	TokenFetches       int64
+	TokenFetchFailures int64
+	TokenExpiries      int64
+	Reauths            int64
+	ReauthP50          time.Duration
+	ReauthP95          time.Duration
+	ReauthP99          time.Duration
+
+	// Network flaps (-flap-interval)
+	FlapPauses          int64 // Client pauses ended
+	FlapUnrecovered     int64
+	FlapCatchUpP50      time.Duration
+	FlapCatchUpP95      time.Duration
+	FlapCatchUpP99      time.Duration
+	FlapSequenceSkips   int64
+	FlapSegmentsExpired int64
+	FlapSegmentsSkipped int64
 }
 
 // GenerateSummary creates a summary of the run.
@@ -956,23 +2875,42 @@ func (c *Collector) GenerateSummary() *Summary {
 		PeakActiveClients: c.peakActive,
 		TotalStarts:       c.totalStarts,
 		TotalRestarts:     c.totalRestarts,
+		Quarantines:       c.quarantines,
 		ExitCodes:         make(map[int]int64),
+		ExitReasons:       make(map[string]int64),
+		StopSignals:       make(map[string]int64),
 	}
 
 	// Copy exit codes
 	for code, count := range c.exitCodes {
 		s.ExitCodes[code] = count
 	}
+	for reason, count := range c.exitReasons {
+		s.ExitReasons[reason] = count
+	}
+	for name, count := range c.stopSignals {
+		s.StopSignals[name] = count
+	}
 
 	// Calculate percentiles
-	if len(c.uptimes) > 0 {
-		sorted := make([]time.Duration, len(c.uptimes))
-		copy(sorted, c.uptimes)
-		sortDurations(sorted)
+	if s.UptimeExits = c.uptimes.Count(); s.UptimeExits > 0 {
+		p := c.uptimes.Percentiles(0.50, 0.95, 0.99)
+		s.UptimeP50, s.UptimeP95, s.UptimeP99 = p[0], p[1], p[2]
+		s.UptimeComplete = c.uptimes.Complete()
+	}
 
-		s.UptimeP50 = percentile(sorted, 0.50)
-		s.UptimeP95 = percentile(sorted, 0.95)
-		s.UptimeP99 = percentile(sorted, 0.99)
+	s.TokenFetches, s.TokenFetchFailures = c.tokenFetches, c.tokenFetchFailures
+	s.TokenExpiries = c.tokenExpiries
+	if s.Reauths = c.reauths.Count(); s.Reauths > 0 {
+		p := c.reauths.Percentiles(0.50, 0.95, 0.99)
+		s.ReauthP50, s.ReauthP95, s.ReauthP99 = p[0], p[1], p[2]
+	}
+
+	s.FlapPauses, s.FlapUnrecovered = c.flapPauses, c.flapUnrecovered
+	s.FlapSequenceSkips, s.FlapSegmentsExpired, s.FlapSegmentsSkipped = c.flapSeqSkips, c.flapExpired, c.flapSkipped
+	if c.flapCatchUps.Count() > 0 {
+		p := c.flapCatchUps.Percentiles(0.50, 0.95, 0.99)
+		s.FlapCatchUpP50, s.FlapCatchUpP95, s.FlapCatchUpP99 = p[0], p[1], p[2]
 	}
 
 	return s
@@ -1001,6 +2939,8 @@ func (c *Collector) TotalRestarts() int64 {
 
 // PerClientEnabled returns whether per-client metrics are enabled.
 func (c *Collector) PerClientEnabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.perClientEnabled
 }
 
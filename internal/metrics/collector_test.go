@@ -1,10 +1,18 @@
 package metrics
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
 )
 
 // =============================================================================
@@ -206,6 +214,124 @@ func TestCollector_RecordStats_HTTPErrors(t *testing.T) {
 	}
 }
 
+func TestCollector_RecordStats_NetworkErrors(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsHTTPErrorsTotal.Reset() // Package-level: earlier tests added to it
+
+	c.RecordStats(&AggregatedStatsUpdate{
+		TotalHTTPErrors:    map[int]int64{404: 1, 503: 2},
+		TotalNetworkErrors: map[string]int64{"refused": 3},
+	})
+	c.RecordStats(&AggregatedStatsUpdate{
+		TotalHTTPErrors:    map[int]int64{404: 1, 503: 2},
+		TotalNetworkErrors: map[string]int64{"refused": 4, "dns": 1},
+	})
+
+	if c.prevNetworkErrors["refused"] != 4 || c.prevNetworkErrors["dns"] != 1 {
+		t.Errorf("prevNetworkErrors = %v, want refused=4 dns=1", c.prevNetworkErrors)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	got := make(map[string]float64)
+	for _, mf := range families {
+		if mf.GetName() != "hls_swarm_http_errors_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			var code, reason string
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "status_code":
+					code = l.GetValue()
+				case "reason":
+					reason = l.GetValue()
+				}
+			}
+			got[code+"/"+reason] = m.GetCounter().GetValue()
+		}
+	}
+	want := map[string]float64{"404/4xx": 1, "503/5xx": 2, "none/refused": 4, "none/dns": 1}
+	for series, v := range want {
+		if got[series] != v {
+			t.Errorf("http_errors_total{%s} = %v, want %v (all: %v)", series, got[series], v, got)
+		}
+	}
+}
+
+func TestCollector_RecordStats_SlowRequests(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+
+	c.RecordStats(&AggregatedStatsUpdate{TotalSlowSegments: 4, TotalSlowManifests: 1})
+	c.RecordStats(&AggregatedStatsUpdate{TotalSlowSegments: 6, TotalSlowManifests: 1})
+
+	if c.prevSlowSegments != 6 || c.prevSlowManifests != 1 {
+		t.Errorf("prev slow segments/manifests = %d/%d, want 6/1", c.prevSlowSegments, c.prevSlowManifests)
+	}
+}
+
+func TestCollector_RecordStats_OrphanedPending(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+
+	c.RecordStats(&AggregatedStatsUpdate{PendingEntries: 12, TotalOrphanedPending: 2})
+	c.RecordStats(&AggregatedStatsUpdate{PendingEntries: 9, TotalOrphanedPending: 5})
+
+	if c.prevOrphanedPending != 5 {
+		t.Errorf("prevOrphanedPending = %d, want 5", c.prevOrphanedPending)
+	}
+}
+
+func TestCollector_RecordStats_LinesTruncated(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+
+	c.RecordStats(&AggregatedStatsUpdate{TotalLinesTruncated: 3})
+	c.RecordStats(&AggregatedStatsUpdate{TotalLinesTruncated: 8})
+
+	if c.prevLinesTruncated != 8 {
+		t.Errorf("prevLinesTruncated = %d, want 8", c.prevLinesTruncated)
+	}
+}
+
+func TestCollector_RecordStats_ParserPanics(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+	var before dto.Metric
+	if err := hlsStatsParserPanicsTotal.Write(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	c.RecordStats(&AggregatedStatsUpdate{TotalParserPanics: 2})
+	c.RecordStats(&AggregatedStatsUpdate{TotalParserPanics: 5})
+
+	var after dto.Metric
+	if err := hlsStatsParserPanicsTotal.Write(&after); err != nil {
+		t.Fatal(err)
+	}
+	if got := after.GetCounter().GetValue() - before.GetCounter().GetValue(); got != 5 {
+		t.Errorf("parser_panics_total increased by %v, want 5", got)
+	}
+}
+
+func TestCollector_RecordStats_InvalidUTF8(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+	var before dto.Metric
+	if err := hlsStatsInvalidUTF8Total.Write(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	c.RecordStats(&AggregatedStatsUpdate{TotalInvalidUTF8: 4})
+	c.RecordStats(&AggregatedStatsUpdate{TotalInvalidUTF8: 9})
+
+	var after dto.Metric
+	if err := hlsStatsInvalidUTF8Total.Write(&after); err != nil {
+		t.Fatal(err)
+	}
+	if got := after.GetCounter().GetValue() - before.GetCounter().GetValue(); got != 9 {
+		t.Errorf("invalid_utf8_total increased by %v, want 9", got)
+	}
+}
+
 func TestCollector_RecordStats_PerClient(t *testing.T) {
 	c, _ := newTestCollector(CollectorConfig{
 		TargetClients:    10,
@@ -232,6 +358,245 @@ func TestCollector_RecordStats_PerClient(t *testing.T) {
 	}
 }
 
+func TestCollector_DisablePerClient(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10, PerClientMetrics: true})
+	c.RecordStats(&AggregatedStatsUpdate{
+		ActiveClients:  2,
+		PerClientStats: []PerClientStatsUpdate{{ClientID: 1, CurrentSpeed: 1}, {ClientID: 2, CurrentSpeed: 1}},
+	})
+	if got := len(gaugeSeries(t, reg, "hls_swarm_client_speed")); got != 2 {
+		t.Fatalf("client_speed has %d series, want 2", got)
+	}
+
+	if !c.DisablePerClient() {
+		t.Fatal("DisablePerClient() = false with per-client metrics on")
+	}
+	if c.PerClientEnabled() {
+		t.Error("PerClientEnabled() = true after DisablePerClient")
+	}
+	if got := len(gaugeSeries(t, reg, "hls_swarm_client_speed")); got != 0 {
+		t.Errorf("client_speed has %d series after DisablePerClient, want 0", got)
+	}
+
+	// Later per-client updates are ignored
+	c.RecordStats(&AggregatedStatsUpdate{PerClientStats: []PerClientStatsUpdate{{ClientID: 3}}})
+	if got := len(gaugeSeries(t, reg, "hls_swarm_client_speed")); got != 0 {
+		t.Errorf("client_speed has %d series, want 0", got)
+	}
+	if c.DisablePerClient() {
+		t.Error("DisablePerClient() = true the second time")
+	}
+}
+
+func TestCollector_ShrinkHistories(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+	for i := 0; i < 1000; i++ {
+		c.uptimes.Add(time.Duration(i) * time.Second)
+	}
+
+	c.ShrinkHistories(100)
+	if r := c.uptimes.Retained(); r >= 100 {
+		t.Errorf("uptimes retain %d samples, want < 100", r)
+	}
+	if c.uptimes.Count() != 1000 {
+		t.Errorf("uptimes count = %d, want 1000", c.uptimes.Count())
+	}
+}
+
+func TestCollector_RecordMemory(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	c.RecordMemory(1_500_000_000, 2_000_000_000, 1)
+
+	for name, want := range map[string]float64{
+		"hls_swarm_memory_rss_bytes":     1_500_000_000,
+		"hls_swarm_memory_limit_bytes":   2_000_000_000,
+		"hls_swarm_memory_features_shed": 1,
+	} {
+		if got := gaugeSeries(t, reg, name)[""]; got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCollector_RecordSLO(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	c.RecordSLO(stats.SLOStatus{
+		Target:    0.99,
+		Threshold: 1500 * time.Millisecond,
+		Segments:  1000,
+		Misses:    5,
+		BurnRate:  2,
+		Window:    5 * time.Minute,
+	})
+
+	for name, want := range map[string]float64{
+		"hls_swarm_slo_target_ratio":                 0.99,
+		"hls_swarm_slo_threshold_seconds":            1.5,
+		"hls_swarm_slo_attainment_ratio":             0.995,
+		"hls_swarm_slo_burn_rate":                    2,
+		"hls_swarm_slo_error_budget_remaining_ratio": 0.5,
+	} {
+		if got := gaugeSeries(t, reg, name)[""]; math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// gaugeSeries returns label value -> gauge value for one metric family.
+func gaugeSeries(t *testing.T, reg *prometheus.Registry, name string) map[string]float64 {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	series := make(map[string]float64)
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			label := ""
+			if len(m.GetLabel()) > 0 {
+				label = m.GetLabel()[0].GetValue()
+			}
+			series[label] = m.GetGauge().GetValue()
+		}
+	}
+	return series
+}
+
+func TestCollector_RecordStats_PerClientCap(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{
+		TargetClients:    10,
+		StreamURL:        "http://example.com/stream.m3u8",
+		Variant:          "all",
+		PerClientMetrics: true,
+		PerClientMax:     2,
+	})
+
+	// At the cap: full per-client granularity
+	c.RecordStats(&AggregatedStatsUpdate{
+		PerClientStats: []PerClientStatsUpdate{
+			{ClientID: 0, CurrentSpeed: 1.0, TotalBytes: 100},
+			{ClientID: 1, CurrentSpeed: 1.0, TotalBytes: 100},
+		},
+	})
+	if got := gaugeSeries(t, reg, "hls_swarm_client_speed"); len(got) != 2 {
+		t.Fatalf("client_speed series = %v, want 2 clients", got)
+	}
+	if got := gaugeSeries(t, reg, "hls_swarm_client_metrics_buckets"); got[""] != 0 {
+		t.Errorf("client_metrics_buckets = %v, want 0", got[""])
+	}
+
+	// Above the cap: per-client series replaced by 2 buckets
+	c.RecordStats(&AggregatedStatsUpdate{
+		PerClientStats: []PerClientStatsUpdate{
+			{ClientID: 0, CurrentSpeed: 1.0, CurrentDrift: time.Second, TotalBytes: 100},
+			{ClientID: 1, CurrentSpeed: 0.5, TotalBytes: 200},
+			{ClientID: 2, CurrentSpeed: 0.8, CurrentDrift: 3 * time.Second, TotalBytes: 300},
+			{ClientID: 3, CurrentSpeed: 1.5, TotalBytes: 400},
+		},
+	})
+	if got := gaugeSeries(t, reg, "hls_swarm_client_speed"); len(got) != 0 {
+		t.Errorf("client_speed series = %v, want none once bucketed", got)
+	}
+	if len(c.registeredClientIDs) != 0 {
+		t.Errorf("registeredClientIDs = %d, want 0", len(c.registeredClientIDs))
+	}
+	if got := gaugeSeries(t, reg, "hls_swarm_client_metrics_buckets"); got[""] != 2 {
+		t.Errorf("client_metrics_buckets = %v, want 2", got[""])
+	}
+	speed := gaugeSeries(t, reg, "hls_swarm_client_bucket_speed")
+	if len(speed) != 2 || speed["0"] != 0.9 || speed["1"] != 1.0 {
+		t.Errorf("bucket speed = %v, want mean per bucket {0:0.9 1:1.0}", speed)
+	}
+	if drift := gaugeSeries(t, reg, "hls_swarm_client_bucket_drift_seconds"); drift["0"] != 3 {
+		t.Errorf("bucket 0 drift = %v, want worst (3s)", drift["0"])
+	}
+	if bytes := gaugeSeries(t, reg, "hls_swarm_client_bucket_bytes_total"); bytes["0"] != 400 || bytes["1"] != 600 {
+		t.Errorf("bucket bytes = %v, want sums {0:400 1:600}", bytes)
+	}
+
+	// Back under the cap: stays bucketed, empty buckets dropped
+	c.RecordStats(&AggregatedStatsUpdate{
+		PerClientStats: []PerClientStatsUpdate{
+			{ClientID: 3, CurrentSpeed: 1.0, TotalBytes: 500},
+		},
+	})
+	if got := gaugeSeries(t, reg, "hls_swarm_client_speed"); len(got) != 0 {
+		t.Errorf("client_speed series = %v, want none after ramp-down", got)
+	}
+	if speed := gaugeSeries(t, reg, "hls_swarm_client_bucket_speed"); len(speed) != 1 || speed["1"] != 1.0 {
+		t.Errorf("bucket speed = %v, want only bucket 1", speed)
+	}
+}
+
+func TestCollector_RecordStats_StreamBytes(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	c.RecordStats(&AggregatedStatsUpdate{ProgressBytesRead: 1000, StderrBytesRead: 5000})
+	c.RecordStats(&AggregatedStatsUpdate{ProgressBytesRead: 1500, StderrBytesRead: 5000})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	got := make(map[string]float64)
+	for _, mf := range families {
+		if mf.GetName() == "hls_swarm_stats_bytes_read_total" {
+			for _, m := range mf.GetMetric() {
+				got[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+			}
+		}
+	}
+	if got["progress"] != 1500 || got["stderr"] != 5000 {
+		t.Errorf("stats_bytes_read_total = %v, want {progress:1500 stderr:5000}", got)
+	}
+}
+
+func TestCollector_RecordStats_EventAge(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10, PerClientMetrics: true, PerClientMax: 2})
+	c.RecordStats(&AggregatedStatsUpdate{
+		EventAgeP50: time.Second,
+		EventAgeP95: 20 * time.Second,
+		EventAgeMax: 45 * time.Second,
+		IdleClients: 1,
+		PerClientStats: []PerClientStatsUpdate{
+			{ClientID: 0, EventAge: time.Second},
+			{ClientID: 1, EventAge: 45 * time.Second},
+		},
+	})
+
+	if age := gaugeSeries(t, reg, "hls_swarm_last_event_age_seconds"); age["p50"] != 1 || age["p95"] != 20 || age["max"] != 45 {
+		t.Errorf("last_event_age = %v, want {p50:1 p95:20 max:45}", age)
+	}
+	if idle := gaugeSeries(t, reg, "hls_swarm_idle_clients")[""]; idle != 1 {
+		t.Errorf("idle_clients = %v, want 1", idle)
+	}
+	if age := gaugeSeries(t, reg, "hls_swarm_client_last_event_age_seconds"); age["0"] != 1 || age["1"] != 45 {
+		t.Errorf("client_last_event_age = %v, want {0:1 1:45}", age)
+	}
+
+	c.RemoveClient(1)
+	if age := gaugeSeries(t, reg, "hls_swarm_client_last_event_age_seconds"); len(age) != 1 {
+		t.Errorf("client_last_event_age = %v after RemoveClient(1), want only client 0", age)
+	}
+
+	// Bucketed: the worst age per bucket
+	c.RecordStats(&AggregatedStatsUpdate{
+		PerClientStats: []PerClientStatsUpdate{
+			{ClientID: 0, EventAge: time.Second},
+			{ClientID: 1, EventAge: 2 * time.Second},
+			{ClientID: 2, EventAge: 50 * time.Second},
+		},
+	})
+	if age := gaugeSeries(t, reg, "hls_swarm_client_last_event_age_seconds"); len(age) != 0 {
+		t.Errorf("client_last_event_age = %v once bucketed, want none", age)
+	}
+	if age := gaugeSeries(t, reg, "hls_swarm_client_bucket_last_event_age_seconds"); age["0"] != 50 || age["1"] != 2 {
+		t.Errorf("bucket last_event_age = %v, want worst per bucket {0:50 1:2}", age)
+	}
+}
+
 func TestCollector_RecordStats_PerClientDisabled(t *testing.T) {
 	c, _ := newTestCollector(CollectorConfig{
 		TargetClients:    10,
@@ -316,14 +681,38 @@ func TestCollector_RecordExit(t *testing.T) {
 			if c.exitCodes[tt.exitCode] != 1 {
 				t.Errorf("exitCodes[%d] = %d, want 1", tt.exitCode, c.exitCodes[tt.exitCode])
 			}
-			if len(c.uptimes) != 1 {
-				t.Errorf("uptimes length = %d, want 1", len(c.uptimes))
+			if c.uptimes.Count() != 1 {
+				t.Errorf("uptimes count = %d, want 1", c.uptimes.Count())
 			}
 			c.mu.Unlock()
 		})
 	}
 }
 
+func TestCollector_RecordExit_BoundedHistory(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{
+		TargetClients:    10,
+		StreamURL:        "http://example.com/stream.m3u8",
+		Variant:          "all",
+		RetentionSamples: 100,
+	})
+
+	for i := 0; i < 5000; i++ {
+		c.RecordExit(0, time.Duration(i)*time.Second)
+	}
+
+	if r := c.uptimes.Retained(); r >= 100 {
+		t.Errorf("retained %d uptimes, want < 100", r)
+	}
+	summary := c.GenerateSummary()
+	if summary.UptimeExits != 5000 {
+		t.Errorf("UptimeExits = %d, want 5000", summary.UptimeExits)
+	}
+	if summary.UptimeComplete {
+		t.Error("UptimeComplete = true for a downsampled history without spill")
+	}
+}
+
 func TestCollector_SetActiveCount(t *testing.T) {
 	c, _ := newTestCollector(CollectorConfig{
 		TargetClients: 100,
@@ -469,6 +858,93 @@ func TestCollector_GenerateSummary(t *testing.T) {
 	}
 }
 
+func TestCollector_RecordExitReason(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{
+		TargetClients: 10,
+		StreamURL:     "http://example.com/stream.m3u8",
+		Variant:       "all",
+	})
+
+	c.RecordExitReason("ffmpeg error")
+	c.RecordExitReason("ffmpeg error")
+	c.RecordExitReason("shutdown")
+
+	summary := c.GenerateSummary()
+	if summary.ExitReasons["ffmpeg error"] != 2 || summary.ExitReasons["shutdown"] != 1 {
+		t.Errorf("ExitReasons = %v", summary.ExitReasons)
+	}
+
+	// The summary is a copy
+	summary.ExitReasons["shutdown"] = 100
+	if c.GenerateSummary().ExitReasons["shutdown"] != 1 {
+		t.Error("GenerateSummary shares the collector's map")
+	}
+}
+
+func TestCollector_RecordClientCPU(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{
+		TargetClients: 10,
+		StreamURL:     "http://example.com/stream.m3u8",
+		Variant:       "all",
+	})
+
+	value := func(w interface{ Write(*dto.Metric) error }) float64 {
+		var m dto.Metric
+		if err := w.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Gauge != nil {
+			return m.GetGauge().GetValue()
+		}
+		return m.GetCounter().GetValue()
+	}
+	before := value(hlsClientCPUHighTotal)
+
+	c.RecordClientCPU(2.5, 60, 1)
+	c.RecordClientCPU(3, 55, 0)
+
+	if got := value(hlsClientCPUPercent.WithLabelValues("mean")); got != 3 {
+		t.Errorf("mean = %v, want the latest sample, 3", got)
+	}
+	if got := value(hlsClientCPUPercent.WithLabelValues("max")); got != 55 {
+		t.Errorf("max = %v, want 55", got)
+	}
+	if got := value(hlsClientCPUHighTotal) - before; got != 1 {
+		t.Errorf("hls_swarm_client_cpu_high_total grew by %v, want 1", got)
+	}
+}
+
+func TestCollector_RecordStopSignal(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{
+		TargetClients: 10,
+		StreamURL:     "http://example.com/stream.m3u8",
+		Variant:       "all",
+	})
+
+	kills := func() float64 {
+		var m dto.Metric
+		if err := hlsClientStopSignalsTotal.WithLabelValues("SIGKILL").Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	before := kills()
+
+	c.RecordStopSignal(syscall.SIGTERM)
+	c.RecordStopSignal(syscall.SIGTERM)
+	c.RecordStopSignal(syscall.SIGKILL)
+	c.RecordStopSignal(syscall.SIGUSR1)
+
+	summary := c.GenerateSummary()
+	want := map[string]int64{"SIGTERM": 2, "SIGKILL": 1, fmt.Sprintf("signal %d", int(syscall.SIGUSR1)): 1}
+	if !maps.Equal(summary.StopSignals, want) {
+		t.Errorf("StopSignals = %v, want %v", summary.StopSignals, want)
+	}
+	if got := kills() - before; got != 1 {
+		t.Errorf("hls_swarm_client_stop_signals_total{signal=SIGKILL} grew by %v, want 1", got)
+	}
+}
+
 func TestCollector_GenerateSummary_Empty(t *testing.T) {
 	c, _ := newTestCollector(CollectorConfig{
 		TargetClients: 10,
@@ -766,3 +1242,512 @@ func BenchmarkCollector_GenerateSummary(b *testing.B) {
 		_ = c.GenerateSummary()
 	}
 }
+
+func TestCollector_RecordTenants(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsTenantRequestsTotal.Reset() // Package-level: reset for absolute values
+	hlsTenantQuotaThrottlesTotal.Reset()
+
+	c.RecordTenants([]TenantUpdate{
+		{Tenant: "alpha", Clients: 6, QuotaRPS: 50, RequestRate: 40, Requests: 100},
+		{Tenant: "beta", Clients: 4, Requests: 30},
+	})
+	c.RecordTenants([]TenantUpdate{
+		{Tenant: "alpha", Clients: 5, HeldClients: 1, QuotaRPS: 50, RequestRate: 55, Requests: 160, Throttles: 1},
+		{Tenant: "beta", Clients: 4, Requests: 70},
+	})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	got := make(map[string]float64) // "metric/tenant"
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() != "tenant" {
+					continue
+				}
+				key := mf.GetName() + "/" + l.GetValue()
+				if m.GetCounter() != nil {
+					got[key] = m.GetCounter().GetValue()
+				} else {
+					got[key] = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"hls_swarm_tenant_requests_total/alpha":           160,
+		"hls_swarm_tenant_requests_total/beta":            70,
+		"hls_swarm_tenant_clients/alpha":                  5,
+		"hls_swarm_tenant_held_clients/alpha":             1,
+		"hls_swarm_tenant_quota_requests_per_second/beta": 0,
+		"hls_swarm_tenant_requests_per_second/alpha":      55,
+		"hls_swarm_tenant_quota_throttles_total/alpha":    1,
+	}
+	for key, v := range want {
+		if got[key] != v {
+			t.Errorf("%s = %v, want %v", key, got[key], v)
+		}
+	}
+}
+
+func TestCollector_RecordPlaylistFetch(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsPlaylistFetchesTotal.Reset() // Package-level: reset for absolute values
+	hlsPlaylistWireBytesTotal.Reset()
+	hlsPlaylistDecodeFailuresTotal.Reset()
+
+	c.RecordPlaylistFetch("gzip", 200, 1000, false)
+	c.RecordPlaylistFetch("gzip", 300, 1500, false)
+	c.RecordPlaylistFetch("br", 400, 0, true)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	got := make(map[string]float64) // "metric/encoding"
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "encoding" {
+					got[mf.GetName()+"/"+l.GetValue()] = m.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"hls_swarm_playlist_fetches_total/gzip":         2,
+		"hls_swarm_playlist_wire_bytes_total/gzip":      500,
+		"hls_swarm_playlist_decode_failures_total/br":   1,
+		"hls_swarm_playlist_decode_failures_total/gzip": 0,
+	}
+	for key, v := range want {
+		if got[key] != v {
+			t.Errorf("%s = %v, want %v", key, got[key], v)
+		}
+	}
+}
+
+func TestCollector_RecordTokenFetchAndReauth(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsAuthTokenFetchesTotal.Reset() // Package-level: reset for absolute values
+
+	c.RecordTokenFetch(20*time.Millisecond, nil)
+	c.RecordTokenFetch(30*time.Millisecond, nil)
+	c.RecordTokenFetch(time.Second, errors.New("HTTP 503"))
+	for _, latency := range []time.Duration{100, 200, 300, 400} {
+		c.RecordReauth(latency * time.Millisecond)
+	}
+	c.RecordTokenExpiry()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	got := make(map[string]float64) // result label
+	for _, mf := range families {
+		if mf.GetName() != "hls_swarm_auth_token_fetches_total" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			got[m.GetLabel()[0].GetValue()] = m.GetCounter().GetValue()
+		}
+	}
+	if got["ok"] != 2 || got["error"] != 1 {
+		t.Errorf("token fetches = %v, want ok=2 error=1", got)
+	}
+
+	s := c.GenerateSummary()
+	if s.TokenFetches != 3 || s.TokenFetchFailures != 1 {
+		t.Errorf("summary fetches = %d (%d failed), want 3 (1 failed)", s.TokenFetches, s.TokenFetchFailures)
+	}
+	if s.Reauths != 4 || s.ReauthP50 < 200*time.Millisecond || s.ReauthP99 < 300*time.Millisecond {
+		t.Errorf("summary re-auths = %d, P50 %v, P99 %v", s.Reauths, s.ReauthP50, s.ReauthP99)
+	}
+	if s.TokenExpiries != 1 {
+		t.Errorf("summary token expiries = %d, want 1", s.TokenExpiries)
+	}
+}
+
+func TestCollector_RecordGeos(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsGeoRequestsTotal.Reset() // Package-level: reset for absolute values
+	hlsGeoErrorsTotal.Reset()
+
+	c.RecordGeos([]GeoUpdate{{Geo: "eu", Clients: 3, Requests: 100, SegmentP95: 80 * time.Millisecond}})
+	c.RecordGeos([]GeoUpdate{{Geo: "eu", Clients: 5, Requests: 250, Errors: 4, SegmentP95: 120 * time.Millisecond}})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	got := make(map[string]float64) // "metric/quantile"
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			key := mf.GetName()
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "quantile" {
+					key += "/" + l.GetValue()
+				}
+			}
+			if m.GetCounter() != nil {
+				got[key] = m.GetCounter().GetValue()
+			} else {
+				got[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"hls_swarm_geo_clients":                        5,
+		"hls_swarm_geo_requests_total":                 250,
+		"hls_swarm_geo_errors_total":                   4,
+		"hls_swarm_geo_segment_wall_time_seconds/0.95": 0.12,
+	}
+	for key, v := range want {
+		if got[key] != v {
+			t.Errorf("%s = %v, want %v", key, got[key], v)
+		}
+	}
+}
+
+func TestCollector_RecordVariants(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsVariantRequestsTotal.Reset() // Package-level: reset for absolute values
+	hlsVariantBytesTotal.Reset()
+	hlsVariantErrorsTotal.Reset()
+
+	c.RecordVariants([]VariantUpdate{{Variant: "1080p", Clients: 3, Requests: 100, Bytes: 1000}, {Variant: "480p", Clients: 1, Requests: 40}})
+	c.RecordVariants([]VariantUpdate{{Variant: "1080p", Clients: 6, Requests: 250, Bytes: 4000, Errors: 2}, {Variant: "480p", Clients: 2, Requests: 90}})
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	got := make(map[string]float64) // "metric/variant"
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			key := mf.GetName()
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "variant" {
+					key += "/" + l.GetValue()
+				}
+			}
+			if m.GetCounter() != nil {
+				got[key] = m.GetCounter().GetValue()
+			} else {
+				got[key] = m.GetGauge().GetValue()
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"hls_swarm_variant_clients/1080p":        6,
+		"hls_swarm_variant_requests_total/1080p": 250,
+		"hls_swarm_variant_bytes_total/1080p":    4000,
+		"hls_swarm_variant_errors_total/1080p":   2,
+		"hls_swarm_variant_clients/480p":         2,
+		"hls_swarm_variant_requests_total/480p":  90,
+	}
+	for key, v := range want {
+		if got[key] != v {
+			t.Errorf("%s = %v, want %v", key, got[key], v)
+		}
+	}
+}
+
+func TestCollector_RecordSockets(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	// Package-level counters can't be reset: check their increase
+	counter := func(cnt prometheus.Counter) float64 {
+		var m dto.Metric
+		if err := cnt.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetCounter().GetValue()
+	}
+	retransBefore := counter(hlsSocketRetransmitsTotal)
+	bytesBefore := counter(hlsSocketBytesReceivedTotal)
+
+	c.RecordSockets(SocketUpdate{Connections: 4, Retransmits: 3, BytesReceived: 1000})
+	c.RecordSockets(SocketUpdate{
+		Connections:   6,
+		RTTP50:        2 * time.Millisecond,
+		RTTP99:        40 * time.Millisecond,
+		Retransmits:   10,
+		BytesReceived: 5000,
+		ThroughputBps: 4000,
+	})
+
+	if got := counter(hlsSocketRetransmitsTotal) - retransBefore; got != 10 {
+		t.Errorf("retransmits_total increased by %v, want 10", got)
+	}
+	if got := counter(hlsSocketBytesReceivedTotal) - bytesBefore; got != 5000 {
+		t.Errorf("bytes_received_total increased by %v, want 5000", got)
+	}
+	if got := gaugeSeries(t, reg, "hls_swarm_tcp_socket_connections")[""]; got != 6 {
+		t.Errorf("connections = %v, want 6", got)
+	}
+	rtt := gaugeSeries(t, reg, "hls_swarm_tcp_socket_rtt_seconds")
+	if rtt["0.5"] != 0.002 || rtt["0.99"] != 0.04 {
+		t.Errorf("rtt = %v, want 0.5=0.002 0.99=0.04", rtt)
+	}
+	if got := gaugeSeries(t, reg, "hls_swarm_tcp_socket_throughput_bytes_per_second")[""]; got != 4000 {
+		t.Errorf("throughput = %v, want 4000", got)
+	}
+}
+
+func TestCollector_RecordFlapResult(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+
+	c.SetFlapPaused(3)
+	c.RecordFlapResult(FlapResult{CatchUp: time.Second, Recovered: true, SequenceSkips: 1, SegmentsExpired: 4})
+	c.RecordFlapResult(FlapResult{CatchUp: 3 * time.Second, Recovered: true, SegmentsSkipped: 1})
+	c.RecordFlapResult(FlapResult{})
+
+	if got := gaugeSeries(t, reg, "hls_swarm_flap_paused_clients")[""]; got != 3 {
+		t.Errorf("paused clients = %v, want 3", got)
+	}
+	s := c.GenerateSummary()
+	if s.FlapPauses != 3 || s.FlapUnrecovered != 1 {
+		t.Errorf("pauses = %d, unrecovered = %d; want 3, 1", s.FlapPauses, s.FlapUnrecovered)
+	}
+	if s.FlapSequenceSkips != 1 || s.FlapSegmentsExpired != 4 || s.FlapSegmentsSkipped != 1 {
+		t.Errorf("skips = %d, expired = %d, skipped = %d; want 1, 4, 1",
+			s.FlapSequenceSkips, s.FlapSegmentsExpired, s.FlapSegmentsSkipped)
+	}
+	if s.FlapCatchUpP50 < time.Second || s.FlapCatchUpP99 > 3*time.Second {
+		t.Errorf("catch-up P50 %v, P99 %v; want within [1s, 3s] (unrecovered excluded)", s.FlapCatchUpP50, s.FlapCatchUpP99)
+	}
+}
+
+func TestCollector_PlaylistRefresh(t *testing.T) {
+	c, reg := newTestCollector(CollectorConfig{TargetClients: 10})
+	var before dto.Metric
+	if err := hlsPlaylistRefreshStormsTotal.Write(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	d := stats.RefreshDistribution{
+		Bounds:      []float64{0.9, 1.1, 1.5},
+		Counts:      []int64{1, 6, 2, 1},
+		RatioSum:    10.5,
+		Storms:      1,
+		PeakClients: 12,
+	}
+	c.RecordStats(&AggregatedStatsUpdate{PlaylistRefresh: d})
+	d.Storms = 3
+	c.RecordStats(&AggregatedStatsUpdate{PlaylistRefresh: d})
+
+	var after dto.Metric
+	if err := hlsPlaylistRefreshStormsTotal.Write(&after); err != nil {
+		t.Fatal(err)
+	}
+	if got := after.GetCounter().GetValue() - before.GetCounter().GetValue(); got != 3 {
+		t.Errorf("storms_total increased by %v, want 3", got)
+	}
+	if got := gaugeSeries(t, reg, "hls_swarm_playlist_refresh_peak_clients")[""]; got != 12 {
+		t.Errorf("peak_clients = %v, want 12", got)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v", err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != "hls_swarm_playlist_refresh_interval_ratio" {
+			continue
+		}
+		h := mf.GetMetric()[0].GetHistogram()
+		if h.GetSampleCount() != 10 || h.GetSampleSum() != 10.5 {
+			t.Errorf("count, sum = %d, %v; want 10, 10.5", h.GetSampleCount(), h.GetSampleSum())
+		}
+		cum := []uint64{1, 7, 9}
+		for i, b := range h.GetBucket() {
+			if b.GetCumulativeCount() != cum[i] {
+				t.Errorf("bucket le=%v = %d, want %d", b.GetUpperBound(), b.GetCumulativeCount(), cum[i])
+			}
+		}
+		return
+	}
+	t.Error("hls_swarm_playlist_refresh_interval_ratio not gathered")
+}
+
+func TestCollector_RecordPlaylistRefreshOverride(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsPlaylistRefreshOverrideTotal.Reset() // Package-level: reset for absolute values
+
+	c.RecordPlaylistRefreshOverride(false, nil)
+	c.RecordPlaylistRefreshOverride(false, nil)
+	c.RecordPlaylistRefreshOverride(true, nil)
+	c.RecordPlaylistRefreshOverride(false, errors.New("503"))
+
+	for result, want := range map[string]float64{"ok": 2, "not_modified": 1, "error": 1} {
+		var m dto.Metric
+		if err := hlsPlaylistRefreshOverrideTotal.WithLabelValues(result).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetCounter().GetValue(); got != want {
+			t.Errorf("%s = %v, want %v", result, got, want)
+		}
+	}
+}
+
+func TestCollector_RecordShards(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+
+	c.RecordShards(stats.GroupShards(map[string]int64{
+		"edge1.example.com/live": 60,
+		"edge2.example.com/live": 20,
+		"edge3.example.com/live": 20,
+	}))
+
+	var m dto.Metric
+	if err := hlsShardSkew.WithLabelValues("edge{n}.example.com/live").Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetGauge().GetValue(); math.Abs(got-1.8) > 1e-9 {
+		t.Errorf("hls_swarm_shard_skew = %v, want 1.8", got)
+	}
+	if err := hlsShardHot.WithLabelValues("edge{n}.example.com/live").Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetGauge().GetValue(); got != 1 {
+		t.Errorf("hls_swarm_shard_hot = %v, want 1", got)
+	}
+}
+
+func TestCollector_RecordServing(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+	hlsServedResponsesTotal.Reset() // Package-level: reset for absolute values
+	hlsServedBytesTotal.Reset()
+
+	c.RecordServing(nil) // No-op
+	c.RecordServing(stats.BreakdownServing(map[string]stats.ServingTotals{
+		stats.ServedEdgeHit:  {Responses: 60, Bytes: 600},
+		stats.ServedEdgeMiss: {Responses: 40, Bytes: 400, TTFBSum: 2 * time.Second, TTFBCount: 40},
+	}, 20))
+	c.RecordServing(stats.BreakdownServing(map[string]stats.ServingTotals{
+		stats.ServedEdgeHit:  {Responses: 150, Bytes: 1500},
+		stats.ServedEdgeMiss: {Responses: 50, Bytes: 500, TTFBSum: 2500 * time.Millisecond, TTFBCount: 50},
+	}, 20))
+
+	var m dto.Metric
+	for _, tt := range []struct {
+		metric interface{ Write(*dto.Metric) error }
+		name   string
+		want   float64
+	}{
+		{hlsServedResponsesTotal.WithLabelValues(stats.ServedEdgeHit), "responses edge-hit", 150},
+		{hlsServedResponsesTotal.WithLabelValues(stats.ServedEdgeMiss), "responses edge-miss", 50},
+		{hlsServedBytesTotal.WithLabelValues(stats.ServedEdgeMiss), "bytes edge-miss", 500},
+	} {
+		if err := tt.metric.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetCounter().GetValue(); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	for _, tt := range []struct {
+		metric interface{ Write(*dto.Metric) error }
+		name   string
+		want   float64
+	}{
+		{hlsServedTTFBSeconds.WithLabelValues(stats.ServedEdgeMiss), "ttfb edge-miss", 0.05},
+		{hlsOriginHitRatio, "origin hit ratio", 0.25},
+		{hlsOriginHitAlert, "origin hit alert", 1},
+	} {
+		if err := tt.metric.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetGauge().GetValue(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_Quarantine(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+
+	c.RecordQuarantine()
+	c.RecordQuarantine()
+	c.SetQuarantinedCount(1)
+
+	var m dto.Metric
+	if err := hlsQuarantinedClients.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.GetGauge().GetValue(); got != 1 {
+		t.Errorf("hls_swarm_quarantined_clients = %v, want 1", got)
+	}
+	if got := c.GenerateSummary().Quarantines; got != 2 {
+		t.Errorf("Summary.Quarantines = %d, want 2", got)
+	}
+}
+
+func TestCollector_RollingRestart(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+	gauge := func() float64 {
+		var m dto.Metric
+		if err := hlsRollingRestartActive.Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetGauge().GetValue()
+	}
+	var before dto.Metric
+	if err := hlsRollingRestartClientsTotal.Write(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetRollingRestart(true)
+	if got := gauge(); got != 1 {
+		t.Errorf("rolling_restart_active = %v during a rolling restart, want 1", got)
+	}
+	c.RecordRollingRestart()
+	c.RecordRollingRestart()
+	c.SetRollingRestart(false)
+	if got := gauge(); got != 0 {
+		t.Errorf("rolling_restart_active = %v after it, want 0", got)
+	}
+
+	var after dto.Metric
+	if err := hlsRollingRestartClientsTotal.Write(&after); err != nil {
+		t.Fatal(err)
+	}
+	if got := after.GetCounter().GetValue() - before.GetCounter().GetValue(); got != 2 {
+		t.Errorf("rolling_restart_clients_total rose by %v, want 2", got)
+	}
+}
+
+func TestCollector_SetPhase(t *testing.T) {
+	c, _ := newTestCollector(CollectorConfig{TargetClients: 10})
+
+	phases := func() map[string]float64 {
+		got := make(map[string]float64)
+		for _, phase := range []string{"starting", "ramping", "steady"} {
+			var m dto.Metric
+			if err := hlsPhase.WithLabelValues(phase).Write(&m); err != nil {
+				t.Fatal(err)
+			}
+			got[phase] = m.GetGauge().GetValue()
+		}
+		return got
+	}
+
+	c.SetPhase("starting")
+	c.SetPhase("ramping")
+	got := phases()
+	if got["starting"] != 0 || got["ramping"] != 1 || got["steady"] != 0 {
+		t.Errorf("after starting -> ramping: %v, want only ramping set", got)
+	}
+
+	c.SetPhase("steady")
+	if got := phases(); got["ramping"] != 0 || got["steady"] != 1 {
+		t.Errorf("after ramping -> steady: %v, want only steady set", got)
+	}
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// PushConfig describes where final metrics are pushed at exit.
+type PushConfig struct {
+	URL    string            // Pushgateway base URL
+	Job    string            // job label
+	Labels map[string]string // Extra grouping labels (e.g. instance, run)
+}
+
+// ParsePushLabels converts key=value strings (from -pushgateway-labels)
+// into grouping labels. Entries without "=" are ignored; config validation
+// rejects them before we get here.
+func ParsePushLabels(labels []string) map[string]string {
+	m := make(map[string]string, len(labels))
+	for _, label := range labels {
+		if key, value, ok := strings.Cut(label, "="); ok {
+			m[key] = value
+		}
+	}
+	return m
+}
+
+// Push sends everything in gatherer to the Pushgateway, replacing the
+// group identified by cfg.Job and cfg.Labels. Used once at exit so short
+// CI runs finishing between scrapes still leave their final counters.
+func Push(ctx context.Context, cfg PushConfig, gatherer prometheus.Gatherer) error {
+	p := push.New(cfg.URL, cfg.Job).Gatherer(gatherer)
+	for key, value := range cfg.Labels {
+		p = p.Grouping(key, value)
+	}
+	if err := p.PushContext(ctx); err != nil {
+		return fmt.Errorf("pushgateway %s: %w", cfg.URL, err)
+	}
+	return nil
+}
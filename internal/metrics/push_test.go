@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParsePushLabels(t *testing.T) {
+	got := ParsePushLabels([]string{"run=1234", "branch=main", "bogus"})
+	if len(got) != 2 || got["run"] != "1234" || got["branch"] != "main" {
+		t.Errorf("ParsePushLabels() = %v, want run and branch only", got)
+	}
+}
+
+func TestPush(t *testing.T) {
+	var method, path, body string
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gw.Close()
+
+	reg := prometheus.NewRegistry()
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "hls_swarm_test_total", Help: "test"})
+	reg.MustRegister(c)
+	c.Add(42)
+
+	cfg := PushConfig{URL: gw.URL, Job: "hls_swarm", Labels: map[string]string{"instance": "loadgen-1"}}
+	if err := Push(context.Background(), cfg, reg); err != nil {
+		t.Fatalf("Push() = %v", err)
+	}
+
+	// PUT replaces the whole group, so a rerun doesn't leave stale series
+	if method != http.MethodPut {
+		t.Errorf("method = %s, want PUT", method)
+	}
+	if path != "/metrics/job/hls_swarm/instance/loadgen-1" {
+		t.Errorf("path = %q", path)
+	}
+	if !strings.Contains(body, "hls_swarm_test_total") {
+		t.Errorf("pushed body missing counter (%d bytes)", len(body))
+	}
+}
+
+func TestPush_GatewayError(t *testing.T) {
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer gw.Close()
+
+	cfg := PushConfig{URL: gw.URL, Job: "hls_swarm"}
+	err := Push(context.Background(), cfg, prometheus.NewRegistry())
+	if err == nil || !strings.Contains(err.Error(), gw.URL) {
+		t.Errorf("Push() = %v, want error naming the gateway", err)
+	}
+}
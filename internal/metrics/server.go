@@ -2,27 +2,81 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// Server provides HTTP endpoints for Prometheus metrics and health checks.
+// UnixAddrPrefix marks a -metrics address as a Unix domain socket path.
+const UnixAddrPrefix = "unix:"
+
+// ValidateAddr checks one -metrics address: unix:/path or host:port.
+func ValidateAddr(addr string) error {
+	if path, ok := strings.CutPrefix(addr, UnixAddrPrefix); ok {
+		if path == "" {
+			return errors.New("missing socket path after unix:")
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("want host:port or unix:/path (%v)", err)
+	}
+	return nil
+}
+
+// Endpoint renders a metrics listen address for people: an http:// URL, or
+// the socket path for a Unix socket.
+func Endpoint(addr string) string {
+	if path, ok := strings.CutPrefix(addr, UnixAddrPrefix); ok {
+		return "unix socket " + path + " (/metrics)"
+	}
+	return "http://" + addr + "/metrics"
+}
+
+// Server provides HTTP endpoints for Prometheus metrics and health checks
+// on one or more listeners: TCP host:port addresses and Unix sockets
+// ("unix:/path", for node-local scrapers).
+//
+// Start binds every listener before returning, so a port already in use is
+// reported as an error instead of a log line from a background goroutine,
+// and a ":0" address resolves to the port actually chosen (see Addrs).
 type Server struct {
-	addr   string
+	addrs  []string
 	server *http.Server
 	logger *slog.Logger
+
+	mu        sync.Mutex
+	listeners []net.Listener // Empty until Start
+	started   bool
+	wg        sync.WaitGroup // Serve goroutines
 }
 
-// NewServer creates a new metrics server.
-func NewServer(addr string, logger *slog.Logger) *Server {
+// NewServer creates a new metrics server for the given listen addresses.
+func NewServer(addrs []string, logger *slog.Logger) *Server {
+	return newServer(addrs, logger, promhttp.Handler())
+}
+
+// NewServerFor is NewServer serving the metrics gathered by gatherer
+// instead of the default registry's.
+func NewServerFor(addrs []string, logger *slog.Logger, gatherer prometheus.Gatherer) *Server {
+	return newServer(addrs, logger, promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+}
+
+func newServer(addrs []string, logger *slog.Logger, metricsHandler http.Handler) *Server {
 	mux := http.NewServeMux()
 
 	// Prometheus metrics endpoint
-	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/metrics", metricsHandler)
 
 	// Health check endpoint
 	mux.HandleFunc("/health", healthHandler)
@@ -33,10 +87,9 @@ func NewServer(addr string, logger *slog.Logger) *Server {
 	mux.HandleFunc("/readyz", healthHandler)
 
 	return &Server{
-		addr:   addr,
+		addrs:  addrs,
 		logger: logger,
 		server: &http.Server{
-			Addr:         addr,
 			Handler:      mux,
 			ReadTimeout:  5 * time.Second,
 			WriteTimeout: 10 * time.Second,
@@ -52,27 +105,128 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "ok")
 }
 
-// Start starts the metrics server in a goroutine.
-// Returns immediately. Use Shutdown to stop.
+// Start binds all listen addresses and serves each in a goroutine.
+// Returns once listening; if any address fails, none are left open.
+// Calling Start again is a no-op. Use Shutdown to stop.
 func (s *Server) Start() error {
-	s.logger.Info("metrics_server_starting", "addr", s.addr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return nil
+	}
 
-	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.logger.Error("metrics_server_error", "error", err)
+	listeners := make([]net.Listener, 0, len(s.addrs))
+	for _, addr := range s.addrs {
+		ln, err := listen(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
 		}
-	}()
+		listeners = append(listeners, ln)
+	}
+	s.listeners = listeners
+	s.started = true
+
+	for i, ln := range listeners {
+		s.logger.Info("metrics_server_started", "addr", listenerAddr(ln), "requested", s.addrs[i])
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("metrics_server_error", "addr", listenerAddr(ln), "error", err)
+			}
+		}()
+	}
 
 	return nil
 }
 
-// Shutdown gracefully shuts down the server.
+// listen opens one metrics address (host:port or unix:/path).
+func listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, UnixAddrPrefix); ok {
+		return listenUnix(path)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return nil, fmt.Errorf("metrics address %s is already in use (another swarm or exporter?); "+
+				"pick a free port with -metrics, or -metrics :0 for a random one: %w", addr, err)
+		}
+		return nil, fmt.Errorf("metrics listen on %s: %w", addr, err)
+	}
+	return ln, nil
+}
+
+// listenUnix opens a Unix socket, replacing a stale socket file left by a
+// crashed run. A socket someone still accepts on is reported as in use.
+func listenUnix(path string) (net.Listener, error) {
+	ln, err := net.Listen("unix", path)
+	if err != nil && errors.Is(err, syscall.EADDRINUSE) {
+		if conn, dialErr := net.Dial("unix", path); dialErr == nil {
+			conn.Close()
+			return nil, fmt.Errorf("metrics socket %s is already in use: %w", path, err)
+		}
+		if rmErr := os.Remove(path); rmErr == nil {
+			ln, err = net.Listen("unix", path)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("metrics listen on unix socket %s: %w", path, err)
+	}
+	return ln, nil
+}
+
+// listenerAddr formats a bound listener the way -metrics accepts it.
+func listenerAddr(ln net.Listener) string {
+	if ln.Addr().Network() == "unix" {
+		return UnixAddrPrefix + ln.Addr().String()
+	}
+	return ln.Addr().String()
+}
+
+// Shutdown gracefully shuts down all listeners, waiting for in-flight
+// scrapes until ctx expires. Unix socket files are removed.
+// Safe to call before Start or more than once.
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if !started {
+		return nil
+	}
+
 	s.logger.Debug("metrics_server_shutting_down")
-	return s.server.Shutdown(ctx)
+	if err := s.server.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// Addr returns the server address.
-func (s *Server) Addr() string {
-	return s.addr
+// Addrs returns the addresses being served: the bound addresses once
+// started (with the real port for ":0"), otherwise the configured ones.
+func (s *Server) Addrs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		return s.addrs
+	}
+	addrs := make([]string, len(s.listeners))
+	for i, ln := range s.listeners {
+		addrs[i] = listenerAddr(ln)
+	}
+	return addrs
 }
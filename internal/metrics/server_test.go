@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func newTestServer(addrs ...string) *Server {
+	return NewServer(addrs, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// unixClient returns an HTTP client that sends every request to a Unix socket.
+func unixClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+func TestServer_RandomPort(t *testing.T) {
+	s := newTestServer("127.0.0.1:0")
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	defer s.Shutdown(context.Background())
+
+	addr := s.Addrs()[0]
+	if strings.HasSuffix(addr, ":0") {
+		t.Fatalf("Addrs()[0] = %q, want the bound port", addr)
+	}
+
+	for _, path := range []string{"/metrics", "/health", "/readyz"} {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestServer_PortInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	s := newTestServer(ln.Addr().String())
+	err = s.Start()
+	if err == nil {
+		s.Shutdown(context.Background())
+		t.Fatal("Start() on a busy port succeeded, want error")
+	}
+	if !strings.Contains(err.Error(), "already in use") || !strings.Contains(err.Error(), "-metrics :0") {
+		t.Errorf("error %q should explain the conflict and suggest -metrics :0", err)
+	}
+}
+
+func TestServer_StartTwice(t *testing.T) {
+	s := newTestServer("127.0.0.1:0")
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	defer s.Shutdown(context.Background())
+
+	addr := s.Addrs()[0]
+	if err := s.Start(); err != nil {
+		t.Errorf("second Start() = %v, want nil", err)
+	}
+	if s.Addrs()[0] != addr {
+		t.Errorf("Addrs()[0] changed from %q to %q", addr, s.Addrs()[0])
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	// Before Start: nothing to stop
+	s := newTestServer("127.0.0.1:0")
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() before Start = %v", err)
+	}
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	addr := s.Addrs()[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if err := s.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown() = %v", err)
+	}
+
+	// The port is released
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("port %s still held after Shutdown: %v", addr, err)
+	}
+	ln.Close()
+}
+
+func TestServer_MultipleListeners(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "metrics.sock")
+	s := newTestServer("127.0.0.1:0", "127.0.0.1:0", "unix:"+sock)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+
+	addrs := s.Addrs()
+	if len(addrs) != 3 || addrs[0] == addrs[1] || addrs[2] != "unix:"+sock {
+		t.Fatalf("Addrs() = %q, want two distinct TCP ports and the socket", addrs)
+	}
+	for _, addr := range addrs[:2] {
+		resp, err := http.Get("http://" + addr + "/metrics")
+		if err != nil {
+			t.Fatalf("GET %s: %v", addr, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s = %d, want 200", addr, resp.StatusCode)
+		}
+	}
+
+	resp, err := unixClient(sock).Get("http://unix/metrics")
+	if err != nil {
+		t.Fatalf("GET over unix socket: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET over unix socket = %d, want 200", resp.StatusCode)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("socket file left behind after Shutdown: %v", err)
+	}
+}
+
+func TestServer_StaleUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "metrics.sock")
+
+	// A socket file nobody accepts on, as left by a killed run
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	s := newTestServer("unix:" + sock)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() over a stale socket = %v", err)
+	}
+	s.Shutdown(context.Background())
+}
+
+func TestServer_UnixSocketInUse(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "metrics.sock")
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	s := newTestServer("unix:" + sock)
+	if err := s.Start(); err == nil || !strings.Contains(err.Error(), "already in use") {
+		s.Shutdown(context.Background())
+		t.Errorf("Start() on a live socket = %v, want in-use error", err)
+	}
+}
+
+func TestServer_PartialFailureClosesListeners(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer busy.Close()
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	freeAddr := free.Addr().String()
+	free.Close()
+
+	s := newTestServer(freeAddr, busy.Addr().String())
+	if err := s.Start(); err == nil {
+		s.Shutdown(context.Background())
+		t.Fatal("Start() with a busy address succeeded, want error")
+	}
+
+	// The first address must have been released again
+	ln, err := net.Listen("tcp", freeAddr)
+	if err != nil {
+		t.Fatalf("%s still held after failed Start: %v", freeAddr, err)
+	}
+	ln.Close()
+}
+
+func TestServer_Gatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "swarm_private_gauge", Help: "test"})
+	registry.MustRegister(gauge)
+	gauge.Set(42)
+
+	s := NewServerFor([]string{"127.0.0.1:0"}, slog.New(slog.NewTextHandler(io.Discard, nil)), registry)
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	defer s.Shutdown(context.Background())
+
+	resp, err := http.Get("http://" + s.Addrs()[0] + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "swarm_private_gauge 42") {
+		t.Errorf("/metrics should serve the registry's metrics, got:\n%s", body)
+	}
+	if strings.Contains(string(body), "go_goroutines") {
+		t.Error("/metrics served the default registry too")
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	if got := Endpoint("127.0.0.1:17091"); got != "http://127.0.0.1:17091/metrics" {
+		t.Errorf("Endpoint(tcp) = %q", got)
+	}
+	if got := Endpoint("unix:/run/swarm.sock"); got != "unix socket /run/swarm.sock (/metrics)" {
+		t.Errorf("Endpoint(unix) = %q", got)
+	}
+}
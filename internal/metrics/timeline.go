@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// TimelinePrefix selects the swarm's own metrics for Sample, leaving out
+// the Go runtime and process collectors.
+const TimelinePrefix = "hls_swarm_"
+
+// Sample gathers the swarm's metrics from gatherer as flat series for a
+// stats.MetricsTimeline, keyed as Prometheus shows them:
+//
+//	hls_swarm_active_clients
+//	hls_swarm_last_event_age_seconds{stat="p95"}
+//
+// Histograms and summaries give their _count and _sum (and a summary its
+// quantiles). Per-client series (a client_id label) are left out: a run's
+// timeline is of the swarm, and per-client series would multiply its size
+// by the client count.
+func Sample(gatherer prometheus.Gatherer) (map[string]float64, error) {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64)
+	for _, mf := range families {
+		name := mf.GetName()
+		if !strings.HasPrefix(name, TimelinePrefix) {
+			continue
+		}
+	series:
+		for _, m := range mf.GetMetric() {
+			for _, lp := range m.GetLabel() {
+				if lp.GetName() == "client_id" {
+					continue series
+				}
+			}
+			labels := m.GetLabel()
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				values[seriesName(name, labels)] = m.GetCounter().GetValue()
+			case dto.MetricType_GAUGE:
+				values[seriesName(name, labels)] = m.GetGauge().GetValue()
+			case dto.MetricType_UNTYPED:
+				values[seriesName(name, labels)] = m.GetUntyped().GetValue()
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				values[seriesName(name+"_count", labels)] = float64(h.GetSampleCount())
+				values[seriesName(name+"_sum", labels)] = h.GetSampleSum()
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				values[seriesName(name+"_count", labels)] = float64(s.GetSampleCount())
+				values[seriesName(name+"_sum", labels)] = s.GetSampleSum()
+				for _, q := range s.GetQuantile() {
+					key, value := "quantile", strconv.FormatFloat(q.GetQuantile(), 'g', -1, 64)
+					quantile := &dto.LabelPair{Name: &key, Value: &value}
+					values[seriesName(name, append(labels[:len(labels):len(labels)], quantile))] = q.GetValue()
+				}
+			}
+		}
+	}
+	return values, nil
+}
+
+// seriesName formats a series as name{label="value",...}, labels in the
+// order given (Gather sorts them).
+func seriesName(name string, labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return name
+	}
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, lp := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(lp.GetName())
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(lp.GetValue()))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSample(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	clients := prometheus.NewGauge(prometheus.GaugeOpts{Name: "hls_swarm_active_clients", Help: "h"})
+	reqs := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "hls_swarm_requests_total", Help: "h"}, []string{"type", "code"})
+	perClient := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "hls_swarm_client_speed", Help: "h"}, []string{"client_id"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "hls_swarm_latency_seconds", Help: "h"})
+	age := prometheus.NewSummary(prometheus.SummaryOpts{Name: "hls_swarm_age_seconds", Help: "h", Objectives: map[float64]float64{0.5: 0.05}})
+	other := prometheus.NewGauge(prometheus.GaugeOpts{Name: "go_goroutines_test", Help: "h"})
+	reg.MustRegister(clients, reqs, perClient, latency, age, other)
+
+	clients.Set(42)
+	reqs.WithLabelValues("segment", "200").Add(7)
+	perClient.WithLabelValues("3").Set(1.5)
+	latency.Observe(0.25)
+	latency.Observe(0.75)
+	age.Observe(2)
+	other.Set(1)
+
+	got, err := Sample(reg)
+	if err != nil {
+		t.Fatalf("Sample() = %v", err)
+	}
+	want := map[string]float64{
+		"hls_swarm_active_clients":                            42,
+		`hls_swarm_requests_total{code="200",type="segment"}`: 7,
+		"hls_swarm_latency_seconds_count":                     2,
+		"hls_swarm_latency_seconds_sum":                       1,
+		"hls_swarm_age_seconds_count":                         1,
+		"hls_swarm_age_seconds_sum":                           2,
+		`hls_swarm_age_seconds{quantile="0.5"}`:               2,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Sample() = %v, want %v", got, want)
+	}
+	for name, v := range want {
+		if g, ok := got[name]; !ok || g != v {
+			t.Errorf("Sample()[%s] = %v (present %v), want %v", name, g, ok, v)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package orchestrator
+
+import (
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// backoffJitterPct is the restart jitter of the -backoff-initial/-max/
+// -multiply policy (the presets carry their own).
+const backoffJitterPct = 0.4
+
+// backoffPolicy returns the clients' restart backoff and its overrides by
+// exit cause, from -backoff-preset and -backoff-on. Preset names are
+// validated, so lookups can't fail.
+func backoffPolicy(cfg *config.Config) (supervisor.BackoffConfig, map[string]supervisor.BackoffConfig) {
+	backoff := supervisor.BackoffConfig{
+		Initial:    cfg.BackoffInitial,
+		Max:        cfg.BackoffMax,
+		Multiplier: cfg.BackoffMultiply,
+		JitterPct:  backoffJitterPct,
+	}
+	if cfg.BackoffPreset != "" {
+		backoff, _ = supervisor.BackoffPreset(cfg.BackoffPreset)
+	}
+
+	var overrides map[string]supervisor.BackoffConfig
+	if len(cfg.BackoffOn) > 0 {
+		overrides = make(map[string]supervisor.BackoffConfig, len(cfg.BackoffOn))
+		for cause, preset := range cfg.BackoffOn {
+			overrides[cause], _ = supervisor.BackoffPreset(preset)
+		}
+	}
+	return backoff, overrides
+}
+
+// recordFailure notes why a client's process is failing (an HTTP status
+// or network error from its output), most specific cause first, for the
+// backoff overrides when it exits. The latest failure wins.
+func (m *ClientManager) recordFailure(clientID int, causes ...string) {
+	if len(m.backoffOverrides) == 0 {
+		return
+	}
+	m.exitCausesMu.Lock()
+	m.exitCauses[clientID] = causes
+	m.exitCausesMu.Unlock()
+}
+
+// lastFailure returns the causes recordFailure noted for the client's
+// current process (nil if none). Used as the supervisor's ExitCauses.
+func (m *ClientManager) lastFailure(clientID int) []string {
+	m.exitCausesMu.Lock()
+	defer m.exitCausesMu.Unlock()
+	return m.exitCauses[clientID]
+}
+
+// clearFailure forgets the causes of a client's previous process.
+func (m *ClientManager) clearFailure(clientID int) {
+	m.exitCausesMu.Lock()
+	delete(m.exitCauses, clientID)
+	m.exitCausesMu.Unlock()
+}
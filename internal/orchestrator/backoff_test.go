@@ -0,0 +1,67 @@
+package orchestrator
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+func TestBackoffPolicy(t *testing.T) {
+	cfg := config.DefaultConfig()
+	backoff, overrides := backoffPolicy(cfg)
+	want := supervisor.BackoffConfig{Initial: cfg.BackoffInitial, Max: cfg.BackoffMax, Multiplier: cfg.BackoffMultiply, JitterPct: 0.4}
+	if backoff != want || overrides != nil {
+		t.Errorf("defaults: backoffPolicy() = %+v, %v; want %+v, no overrides", backoff, overrides, want)
+	}
+
+	cfg.BackoffPreset = "aggressive"
+	cfg.BackoffOn = map[string]string{"http-404": "gentle", "exit-1": "none"}
+	backoff, overrides = backoffPolicy(cfg)
+	if backoff != supervisor.BackoffPresets["aggressive"] {
+		t.Errorf("backoff = %+v, want the aggressive preset", backoff)
+	}
+	if len(overrides) != 2 || overrides["http-404"] != supervisor.BackoffPresets["gentle"] || overrides["exit-1"].Max != 0 {
+		t.Errorf("overrides = %+v", overrides)
+	}
+}
+
+func TestClientManager_ExitCauses(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{
+		Builder:          &mockProcessBuilder{},
+		Logger:           nil,
+		StatsEnabled:     true,
+		BackoffOverrides: map[string]supervisor.BackoffConfig{"http-404": {Initial: time.Minute}},
+	})
+
+	events := cm.createDebugEventCallback(1, nil)
+	events(&parser.DebugEvent{Type: parser.DebugEventHTTPError, HTTPCode: 404})
+	if got, want := cm.lastFailure(1), []string{"http-404", "http-4xx"}; !slices.Equal(got, want) {
+		t.Errorf("after a 404: lastFailure() = %q, want %q", got, want)
+	}
+
+	// The latest failure wins
+	events(&parser.DebugEvent{Type: parser.DebugEventNetworkError, FailReason: "reset"})
+	if got := cm.lastFailure(1); !slices.Equal(got, []string{"reset"}) {
+		t.Errorf("after a reset: lastFailure() = %q, want reset", got)
+	}
+	if got := cm.lastFailure(2); got != nil {
+		t.Errorf("other client: lastFailure() = %q, want none", got)
+	}
+
+	// A new process starts clean
+	cm.handleStart(1, 1234)
+	if got := cm.lastFailure(1); got != nil {
+		t.Errorf("after restart: lastFailure() = %q, want none", got)
+	}
+
+	// Without overrides nothing is tracked
+	plain := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}, StatsEnabled: true})
+	plain.createDebugEventCallback(1, nil)(&parser.DebugEvent{Type: parser.DebugEventHTTPError, HTTPCode: 404})
+	if got := plain.lastFailure(1); got != nil {
+		t.Errorf("without overrides: lastFailure() = %q, want none", got)
+	}
+}
@@ -0,0 +1,161 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// burster runs -burst: every burst interval it scales the swarm up by the
+// burst's clients and, once the burst duration is over, back down again.
+// Scale starts the extra clients at the ramp rate and stops the newest
+// first, so a burst's own clients are the ones that go. Several -burst
+// schedules run independently; when their bursts overlap they add up.
+//
+// Each burst window is recorded as a run event (burst_started,
+// burst_ended) for -save-run and the event sink, in the
+// hls_swarm_burst_clients gauge and in the exit summary.
+type burster struct {
+	bursts  []config.Burst
+	scaleBy func(delta int) error // Changes the client target by delta
+	event   func(name, detail string)
+	metrics *metrics.Collector
+	logger  *slog.Logger
+
+	mu      sync.Mutex // Also serializes scaleBy
+	start   time.Time  // Of the run
+	active  int        // Burst clients wanted right now
+	windows []stats.BurstWindow
+}
+
+// newBurster returns the -burst burster. Returns nil without -burst.
+func newBurster(cfg *config.Config, scaleBy func(int) error, event func(name, detail string), m *metrics.Collector, logger *slog.Logger) *burster {
+	if len(cfg.Bursts) == 0 {
+		return nil
+	}
+	return &burster{
+		bursts:  cfg.Bursts,
+		scaleBy: scaleBy,
+		event:   event,
+		metrics: m,
+		logger:  logger,
+	}
+}
+
+// scaleBy changes the number of clients wanted by delta.
+func (o *Orchestrator) scaleBy(delta int) error {
+	return o.Scale(max(0, o.scaleTarget()+delta))
+}
+
+// run starts each schedule's bursts until ctx is cancelled, timing the
+// windows from start.
+func (b *burster) run(ctx context.Context, start time.Time) {
+	b.mu.Lock()
+	b.start = start
+	b.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, burst := range b.bursts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(burst.Every)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					b.burst(ctx, burst)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// burst adds the burst's clients for its duration. A run stopping ends
+// the burst early; its clients stop with the rest.
+func (b *burster) burst(ctx context.Context, burst config.Burst) {
+	spec := burst.String()
+	i, err := b.begin(burst)
+	if err != nil {
+		b.logger.Warn("burst_failed", "burst", spec, "error", err)
+		return
+	}
+	b.event("burst_started", spec)
+	b.logger.Info("burst_started", "burst", spec, "clients", burst.Clients)
+
+	select {
+	case <-ctx.Done():
+		b.end(i, burst.Clients, false)
+		return
+	case <-time.After(burst.For):
+	}
+
+	if err := b.end(i, burst.Clients, true); err != nil {
+		b.logger.Warn("burst_end_failed", "burst", spec, "error", err)
+		return
+	}
+	b.event("burst_ended", spec)
+	b.logger.Info("burst_ended", "burst", spec, "clients", burst.Clients)
+}
+
+// begin scales up for burst and opens its window, returning the window's
+// index.
+func (b *burster) begin(burst config.Burst) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.scaleBy(burst.Clients); err != nil {
+		return 0, err
+	}
+	b.active += burst.Clients
+	b.metrics.SetBurstClients(b.active)
+	b.metrics.RecordBurst()
+	b.windows = append(b.windows, stats.BurstWindow{
+		Schedule: burst.String(),
+		Clients:  burst.Clients,
+		Start:    time.Since(b.start),
+	})
+	return len(b.windows) - 1, nil
+}
+
+// end closes window i, scaling clients back down if scaleDown. A window
+// that ends without scaling down successfully is cut short.
+func (b *burster) end(i, clients int, scaleDown bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var err error
+	if scaleDown {
+		err = b.scaleBy(-clients)
+	}
+	b.active -= clients
+	b.metrics.SetBurstClients(b.active)
+	b.windows[i].End = time.Since(b.start)
+	b.windows[i].CutShort = !scaleDown || err != nil
+	return err
+}
+
+// summary returns the -burst exit summary section. Windows still open are
+// shown ending now, cut short.
+func (b *burster) summary() *stats.BurstSummary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := &stats.BurstSummary{Windows: make([]stats.BurstWindow, len(b.windows))}
+	for _, burst := range b.bursts {
+		s.Schedules = append(s.Schedules, burst.String())
+	}
+	for i, w := range b.windows {
+		if w.End == 0 {
+			w.End = time.Since(b.start)
+			w.CutShort = true
+		}
+		s.Windows[i] = w
+	}
+	return s
+}
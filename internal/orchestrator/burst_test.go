@@ -0,0 +1,151 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+// fakeBurstTarget records the scale changes and run events of a burster.
+type fakeBurstTarget struct {
+	mu     sync.Mutex
+	deltas []int
+	events []string
+	fail   bool
+}
+
+func (f *fakeBurstTarget) scaleBy(delta int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("scale: not running")
+	}
+	f.deltas = append(f.deltas, delta)
+	return nil
+}
+
+func (f *fakeBurstTarget) event(name, detail string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, name)
+}
+
+func newTestBurster(target *fakeBurstTarget, bursts ...config.Burst) *burster {
+	m := metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 10}, prometheus.NewRegistry())
+	return newBurster(&config.Config{Bursts: bursts}, target.scaleBy, target.event, m, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestNewBurster_Off(t *testing.T) {
+	if b := newBurster(&config.Config{}, nil, nil, nil, nil); b != nil {
+		t.Errorf("newBurster() without -burst = %+v, want nil", b)
+	}
+}
+
+func TestBurster_Burst(t *testing.T) {
+	target := &fakeBurstTarget{}
+	burst := config.Burst{Every: time.Minute, Clients: 5, For: 20 * time.Millisecond}
+	b := newTestBurster(target, burst)
+	b.start = time.Now()
+
+	b.burst(context.Background(), burst)
+
+	if want := []int{5, -5}; !slices.Equal(target.deltas, want) {
+		t.Errorf("scale deltas = %v, want %v", target.deltas, want)
+	}
+	if want := []string{"burst_started", "burst_ended"}; !slices.Equal(target.events, want) {
+		t.Errorf("events = %v, want %v", target.events, want)
+	}
+	s := b.summary()
+	if len(s.Windows) != 1 || len(s.Schedules) != 1 || s.Schedules[0] != burst.String() {
+		t.Fatalf("summary = %+v", s)
+	}
+	if w := s.Windows[0]; w.Clients != 5 || w.CutShort || w.End-w.Start < burst.For {
+		t.Errorf("window = %+v, want +5 clients for at least %s", w, burst.For)
+	}
+	if b.active != 0 {
+		t.Errorf("active = %d after the burst, want 0", b.active)
+	}
+}
+
+func TestBurster_CutShort(t *testing.T) {
+	target := &fakeBurstTarget{}
+	burst := config.Burst{Every: time.Minute, Clients: 5, For: time.Minute}
+	b := newTestBurster(target, burst)
+	b.start = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	b.burst(ctx, burst)
+
+	// The run stopping stops the burst's clients with the rest
+	if want := []int{5}; !slices.Equal(target.deltas, want) {
+		t.Errorf("scale deltas = %v, want %v", target.deltas, want)
+	}
+	if s := b.summary(); len(s.Windows) != 1 || !s.Windows[0].CutShort {
+		t.Errorf("summary = %+v, want one window cut short", s)
+	}
+}
+
+func TestBurster_ScaleFails(t *testing.T) {
+	target := &fakeBurstTarget{fail: true}
+	burst := config.Burst{Every: time.Minute, Clients: 5, For: 10 * time.Millisecond}
+	b := newTestBurster(target, burst)
+
+	b.burst(context.Background(), burst)
+
+	if len(target.events) != 0 {
+		t.Errorf("events = %v for a burst that never started", target.events)
+	}
+	if s := b.summary(); len(s.Windows) != 0 {
+		t.Errorf("windows = %+v, want none", s.Windows)
+	}
+}
+
+func TestBurster_Run(t *testing.T) {
+	target := &fakeBurstTarget{}
+	b := newTestBurster(target,
+		config.Burst{Every: 30 * time.Millisecond, Clients: 5, For: 10 * time.Millisecond},
+		config.Burst{Every: 50 * time.Millisecond, Clients: 100, For: 20 * time.Millisecond},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	b.run(ctx, time.Now())
+
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	var ups, sum int
+	for _, d := range target.deltas {
+		if d > 0 {
+			ups++
+		}
+		sum += d
+	}
+	if ups < 3 {
+		t.Errorf("bursts started = %d, want both schedules bursting (deltas %v)", ups, target.deltas)
+	}
+	s := b.summary()
+	if len(s.Windows) != ups {
+		t.Errorf("windows = %d, want %d", len(s.Windows), ups)
+	}
+	// Only bursts cut short by the end of the run keep their clients
+	var open int
+	for _, w := range s.Windows {
+		if w.CutShort {
+			open += w.Clients
+		}
+	}
+	if sum != open {
+		t.Errorf("clients left = %d, want %d (bursts cut short)", sum, open)
+	}
+}
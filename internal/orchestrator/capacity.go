@@ -0,0 +1,111 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+const (
+	// capacitySampleInterval is how often the ramp's segment wall time P95
+	// is sampled against the running client count.
+	capacitySampleInterval = 5 * time.Second
+
+	// capacityMinSegments is the fewest segments a window needs for its
+	// P95 to be worth a sample.
+	capacityMinSegments = 20
+)
+
+// capacityProjector warns of origin saturation during the ramp: it
+// samples the segment wall time P95 as clients are added and projects the
+// client count at which it reaches the -capacity-p95 threshold.
+type capacityProjector struct {
+	threshold time.Duration
+	window    *parser.LatencyWindow
+	clients   func() int // Running clients
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	samples []stats.CapacitySample
+	latest  stats.CapacityProjection
+}
+
+// newCapacityProjector returns the projector, or nil without -stats (no
+// segment wall times to sample).
+func newCapacityProjector(cfg *config.Config, window *parser.LatencyWindow, clients func() int, logger *slog.Logger) *capacityProjector {
+	if !cfg.StatsEnabled {
+		return nil
+	}
+	threshold := cfg.CapacityP95
+	if threshold <= 0 {
+		threshold = cfg.TargetDuration
+	}
+	return &capacityProjector{
+		threshold: threshold,
+		window:    window,
+		clients:   clients,
+		logger:    logger,
+		latest:    stats.CapacityProjection{Threshold: threshold},
+	}
+}
+
+// run samples every capacitySampleInterval until the ramp is done or ctx
+// is cancelled. The last projection stays available afterwards.
+func (c *capacityProjector) run(ctx context.Context, rampDone <-chan struct{}) {
+	ticker := time.NewTicker(capacitySampleInterval)
+	defer ticker.Stop()
+	c.window.Rotate() // Start from the ramp, not setup
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-rampDone:
+			c.sample()
+			p := c.projection()
+			c.logger.Info("capacity_projection_final",
+				"projected_clients", p.Clients,
+				"exceeded", p.Exceeded,
+				"threshold", p.Threshold,
+				"samples", p.Samples,
+			)
+			return
+		case <-ticker.C:
+			c.sample()
+		}
+	}
+}
+
+// sample takes the P95 since the previous sample and refits.
+func (c *capacityProjector) sample() {
+	p95, n := c.window.Rotate()
+	if n < capacityMinSegments {
+		return
+	}
+	clients := c.clients()
+
+	c.mu.Lock()
+	c.samples = append(c.samples, stats.CapacitySample{Clients: clients, P95: p95})
+	c.latest = stats.ProjectCapacity(c.samples, c.threshold)
+	p := c.latest
+	c.mu.Unlock()
+
+	c.logger.Info("capacity_projection",
+		"clients", clients,
+		"p95", p95,
+		"projected_clients", p.Clients,
+		"exceeded", p.Exceeded,
+		"threshold", p.Threshold,
+	)
+}
+
+// projection returns the latest projection.
+func (c *capacityProjector) projection() stats.CapacityProjection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.latest
+}
@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+)
+
+func TestNewCapacityProjector(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.DefaultConfig()
+	cfg.StatsEnabled = false
+	if c := newCapacityProjector(cfg, parser.NewLatencyWindow(), nil, logger); c != nil {
+		t.Error("projector without -stats, want nil (no wall times to sample)")
+	}
+
+	cfg.StatsEnabled = true
+	cfg.TargetDuration = 2 * time.Second
+	if c := newCapacityProjector(cfg, parser.NewLatencyWindow(), nil, logger); c.threshold != 2*time.Second {
+		t.Errorf("threshold = %v, want -target-duration (2s)", c.threshold)
+	}
+	cfg.CapacityP95 = 500 * time.Millisecond
+	if c := newCapacityProjector(cfg, parser.NewLatencyWindow(), nil, logger); c.projection().Threshold != 500*time.Millisecond {
+		t.Errorf("threshold = %v, want -capacity-p95 (500ms)", c.projection().Threshold)
+	}
+}
+
+func TestCapacityProjector_Sample(t *testing.T) {
+	window := parser.NewLatencyWindow()
+	clients := 0
+	cfg := config.DefaultConfig()
+	cfg.StatsEnabled = true
+	cfg.CapacityP95 = 2 * time.Second
+	c := newCapacityProjector(cfg, window, func() int { return clients }, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// n segments of wallTime each, back to back: each open completes the
+	// previous one
+	segments := func(wallTime time.Duration, n int) {
+		p := parser.NewDebugEventParser(0, 2*time.Second, nil)
+		p.SetLatencyWindow(window)
+		at := time.Date(2026, 1, 23, 8, 0, 0, 0, time.UTC)
+		for i := 0; i <= n; i++ {
+			p.ParseLine(fmt.Sprintf("%s [http @ 0x55c32c0d7ac0] Opening 'http://origin/seg%d.ts' for reading",
+				at.Format("2006-01-02 15:04:05.000"), i))
+			at = at.Add(wallTime)
+		}
+	}
+
+	// Too few segments: no sample
+	clients = 100
+	segments(200*time.Millisecond, capacityMinSegments-1)
+	c.sample()
+	if p := c.projection(); p.Samples != 0 {
+		t.Errorf("projection = %+v, want no samples", p)
+	}
+
+	for _, n := range []int{100, 200, 300} {
+		clients = n
+		segments(100*time.Millisecond+time.Duration(n)*time.Millisecond, capacityMinSegments)
+		c.sample()
+	}
+	if p := c.projection(); p.Samples != 3 || p.Clients < 1850 || p.Clients > 1950 {
+		t.Errorf("projection = %+v, want ~1900 clients from 3 samples", p)
+	}
+}
@@ -2,26 +2,24 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/influxdata/tdigest"
+
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/timeseries"
 )
 
-// debugRateSnapshot holds values for calculating instantaneous rates for debug stats.
-type debugRateSnapshot struct {
-	timestamp    time.Time
-	segments     int64
-	playlists    int64
-	httpRequests int64
-	tcpConnects  int64
-}
-
 // cachedDebugStatsEntry holds cached debug stats to avoid redundant computation.
 // Both TUI (500ms tick) and statsUpdateLoop (1s tick) call GetDebugStats().
 type cachedDebugStatsEntry struct {
@@ -36,20 +34,72 @@ type ClientManager struct {
 	logger     *slog.Logger
 	configSeed int64
 
-	// Backoff configuration
-	backoffConfig supervisor.BackoffConfig
+	// Backoff configuration, and the policies for some exit causes
+	backoffConfig    supervisor.BackoffConfig
+	backoffOverrides map[string]supervisor.BackoffConfig
+
+	// Why each client's current process is failing, for the overrides:
+	// clientID -> causes of its latest HTTP or network error
+	exitCauses   map[int][]string
+	exitCausesMu sync.Mutex
 
 	// Maximum restarts per client (0 = unlimited)
 	maxRestarts int
 
+	// Parking of clients that keep failing (zero = off)
+	quarantine supervisor.QuarantineConfig
+
+	// Stop policy: signal, then SIGKILL after the grace period
+	stopSignal syscall.Signal
+	stopGrace  time.Duration
+
+	sessionLength func() time.Duration // nil = unlimited sessions
+	atEnd         supervisor.EndPolicy
+
 	// Stats collection
 	statsEnabled       bool
 	statsBufferSize    int
 	statsDropThreshold float64
+	statsMaxLineLength int
+	progressMode       parser.ProgressMode
 
 	// Segment size lookup (for accurate byte tracking)
 	segmentSizeLookup parser.SegmentSizeLookup
 
+	// CPU pinning (nil = let the kernel schedule)
+	cpuAllocator *supervisor.CPUAllocator
+
+	// Per-client scheduling priority (nil = the swarm's)
+	clientPriority func(clientID int) supervisor.Priority
+
+	// Slow request logging threshold (0 = off)
+	slowRequestThreshold time.Duration
+
+	// Segment wall time counted as an SLO miss (0 = no -slo)
+	sloThreshold time.Duration
+
+	// FFmpeg log dialect of new parsers (see SetLogDialect)
+	logDialect parser.LogDialect
+
+	// Clients whose every debug event is logged (nil = none; -debug-sample)
+	traceClient func(clientID int) bool
+
+	// Session token re-auth: a 401 restarts the client's process, which
+	// fetches a new token. reauthPending maps clientID -> time of the 401
+	// until the new process starts.
+	reauthOn401   bool
+	reauthPending map[int]time.Time
+	reauthMu      sync.Mutex
+
+	// Forced token expiry (-token-ttl): each process start arms a timer
+	// that re-auths the client; guarded by reauthMu
+	tokenTTL      time.Duration
+	tokenExpiries map[int]*tokenExpiry
+
+	// Clients RestartClient has stopped, until their new process starts
+	// (guarded by reauthMu)
+	restartPending map[int]struct{}
+
 	// Per-client progress tracking (Phase 2)
 	// Maps clientID -> latest ProgressUpdate
 	latestProgress map[int]*parser.ProgressUpdate
@@ -60,8 +110,24 @@ type ClientManager struct {
 	debugParsers map[int]*parser.DebugEventParser
 	debugMu      sync.RWMutex
 
-	// Rate tracking for debug stats (Phase 7.4) - Lock-free using atomic.Value
-	prevDebugSnapshot atomic.Value // *debugRateSnapshot
+	// Set by DropSampleRings: parsers added later start without rings
+	samplesDropped bool // Guarded by debugMu
+
+	// Running counter totals pushed by every debug parser, so the per-tick
+	// counts and the throughput sampler don't poll each client
+	debugTotals parser.DebugTotals
+
+	// Counters of the previous computeDebugStats, for the Instant rates
+	prevDebugStats atomic.Pointer[stats.DebugStatsAggregate]
+
+	// Swarm-wide playlist refresh intervals and storms
+	refreshes      *parser.RefreshTracker
+	reconnects     *stats.ReconnectCauseTracker // Reconnects by failed stage
+	targetDuration time.Duration
+
+	// Swarm-wide segment wall times since the capacity projection last sampled
+	latency *parser.LatencyWindow
+	heatmap *parser.LatencyHeatmap // Since the -save-run timeline last sampled
 
 	// Throughput tracking (rolling time-window averages)
 	// Replaces histogram-based tracking to fix TUI flashing issue
@@ -73,6 +139,15 @@ type ClientManager struct {
 	cachedDebugStats   atomic.Value  // *cachedDebugStatsEntry
 	debugStatsCacheTTL time.Duration
 
+	// Latest aggregated stats, refreshed by aggregationLoop. Readers (TUI,
+	// Prometheus, status line) only load this pointer, so a slow reader
+	// can never hold up aggregation.
+	latestStats       atomic.Pointer[stats.AggregatedStats]
+	aggregateInterval time.Duration
+	aggregateDone     chan struct{}
+	aggregateExited   chan struct{} // Closed when aggregationLoop returns
+	stopAggregate     sync.Once
+
 	// Per-client stats (Phase 4/5)
 	// Maps clientID -> ClientStats
 	clientStats   map[int]*stats.ClientStats
@@ -96,9 +171,16 @@ type ClientManager struct {
 	callbacks ManagerCallbacks
 
 	// Counters
-	activeCount  atomic.Int64
-	startedCount atomic.Int64
-	restartCount atomic.Int64
+	activeCount      atomic.Int64
+	quarantinedCount atomic.Int64
+	startedCount     atomic.Int64
+	restartCount     atomic.Int64
+
+	// Clients whose supervisor gave up (MaxRestarts), and the first
+	// one's error
+	gaveUpMu  sync.Mutex
+	gaveUp    int
+	gaveUpErr error
 }
 
 // ManagerCallbacks contains optional callbacks for manager events.
@@ -114,6 +196,37 @@ type ManagerCallbacks struct {
 
 	// OnClientRestart is called when a client is about to restart.
 	OnClientRestart func(clientID int, attempt int, delay time.Duration)
+
+	// OnClientReauth is called when a client restarted after a 401 (or
+	// its token expired) is running again, with the time since then.
+	OnClientReauth func(clientID int, latency time.Duration)
+
+	// OnClientTokenExpired is called when a client's token reaches
+	// TokenTTL, before the client is restarted with a new one.
+	OnClientTokenExpired func(clientID int)
+
+	// OnClientStopSignal is called when a client process is sent its stop
+	// signal or, after the grace period, SIGKILL.
+	OnClientStopSignal func(clientID int, sig syscall.Signal)
+
+	// OnClientQuarantine is called when a client that keeps failing is
+	// parked for the quarantine cooldown.
+	OnClientQuarantine func(clientID int, failures int, cooldown time.Duration)
+
+	// OnClientSessionStart and OnClientSessionEnd are called when a
+	// client's viewer session starts and when it ends after its drawn
+	// length (see ManagerConfig.SessionLength).
+	OnClientSessionStart func(clientID int)
+	OnClientSessionEnd   func(clientID int, length time.Duration)
+
+	// OnClientStreamEnd is called when a client played the stream to the
+	// end (see ManagerConfig.AtEnd), with how long the play took.
+	OnClientStreamEnd func(clientID int, played time.Duration)
+
+	// OnDebugEvent is called with every parsed debug event of a client
+	// (needs stats). It runs on the client's parser goroutine, so it must
+	// not block.
+	OnDebugEvent func(clientID int, event *parser.DebugEvent)
 }
 
 // ManagerConfig holds configuration for the ClientManager.
@@ -124,14 +237,75 @@ type ManagerConfig struct {
 	MaxRestarts   int
 	Callbacks     ManagerCallbacks
 
+	// Quarantine parks clients that keep failing (zero = off)
+	Quarantine supervisor.QuarantineConfig
+
+	// BackoffOverrides replace BackoffConfig for clients that exit for
+	// these causes: "http-404", "http-4xx", a network failure ("reset")
+	// or "exit-N". HTTP and network causes need stats for the events.
+	BackoffOverrides map[string]supervisor.BackoffConfig
+
+	// Stop policy (zero values = SIGTERM, supervisor.DefaultStopGrace)
+	StopSignal syscall.Signal
+	StopGrace  time.Duration
+
+	// SessionLength draws each viewer session's length; at its end the
+	// client's process is stopped and replaced (nil = unlimited)
+	SessionLength func() time.Duration
+
+	// AtEnd is what a client does when it plays the stream to the end, as
+	// at the end of a VOD asset (zero = restart like any other exit)
+	AtEnd supervisor.EndPolicy
+
 	// Stats collection
 	StatsEnabled       bool
 	StatsBufferSize    int
 	StatsDropThreshold float64
+	StatsMaxLineLength int                 // Output lines longer than this are truncated (0 = parser default)
+	ProgressMode       parser.ProgressMode // Where -progress output is read from ("" = pipe)
 
 	// Segment size lookup (for accurate byte tracking)
 	SegmentSizeLookup parser.SegmentSizeLookup
 
+	// CPU pinning (optional)
+	CPUAllocator *supervisor.CPUAllocator
+
+	// ClientPriority returns a client's niceness and IO priority (nil = the
+	// swarm's for all)
+	ClientPriority func(clientID int) supervisor.Priority
+
+	// SlowRequestThreshold logs segment/manifest downloads at least this slow
+	SlowRequestThreshold time.Duration
+
+	// SLOThreshold counts segments at least this slow as -slo misses
+	SLOThreshold time.Duration
+
+	// LogDialect is how the parsers read FFmpeg's log lines ("" = auto)
+	LogDialect parser.LogDialect
+
+	// AggregateInterval is how often per-client stats are aggregated (default 1s)
+	AggregateInterval time.Duration
+
+	// TargetDuration is the expected playlist refresh interval, for refresh
+	// jitter and the refresh interval histogram (default 2s)
+	TargetDuration time.Duration
+
+	// RefreshStormThreshold is how many playlist refreshes in 100ms count
+	// as a refresh storm (see parser.RefreshStormThreshold)
+	RefreshStormThreshold int
+
+	// ReauthOn401 restarts a client's process when it gets HTTP 401, so it
+	// comes back with a fresh session token (needs stats for the events)
+	ReauthOn401 bool
+
+	// TokenTTL restarts each client's process this long after it started,
+	// as if its session token expired (0 = never)
+	TokenTTL time.Duration
+
+	// TraceClient reports whether every debug event of a client is logged
+	// as client_trace (nil = none). Used for -debug-sample.
+	TraceClient func(clientID int) bool
+
 	// FD mode is always enabled when stats are enabled (no flag needed)
 }
 
@@ -149,15 +323,41 @@ func NewClientManager(cfg ManagerConfig) *ClientManager {
 		threshold = 0.01
 	}
 
+	// Default aggregation interval
+	aggregateInterval := cfg.AggregateInterval
+	if aggregateInterval <= 0 {
+		aggregateInterval = time.Second
+	}
+
 	cm := &ClientManager{
 		builder:            cfg.Builder,
 		logger:             cfg.Logger,
 		backoffConfig:      cfg.BackoffConfig,
+		backoffOverrides:   cfg.BackoffOverrides,
+		exitCauses:         make(map[int][]string),
 		maxRestarts:        cfg.MaxRestarts,
+		quarantine:         cfg.Quarantine,
+		stopSignal:         cfg.StopSignal,
+		stopGrace:          cfg.StopGrace,
+		sessionLength:      cfg.SessionLength,
+		atEnd:              cfg.AtEnd,
 		statsEnabled:       cfg.StatsEnabled,
 		statsBufferSize:    bufferSize,
 		statsDropThreshold: threshold,
+		statsMaxLineLength: cfg.StatsMaxLineLength,
+		progressMode:       cfg.ProgressMode,
 		segmentSizeLookup:  cfg.SegmentSizeLookup,
+		cpuAllocator:       cfg.CPUAllocator,
+		clientPriority:     cfg.ClientPriority,
+		slowRequestThreshold: cfg.SlowRequestThreshold,
+		sloThreshold:         cfg.SLOThreshold,
+		logDialect:           cfg.LogDialect,
+		reauthOn401:          cfg.ReauthOn401,
+		traceClient:          cfg.TraceClient,
+		reauthPending:        make(map[int]time.Time),
+		tokenTTL:             cfg.TokenTTL,
+		tokenExpiries:        make(map[int]*tokenExpiry),
+		restartPending:       make(map[int]struct{}),
 		callbacks:          cfg.Callbacks,
 		supervisors:        make(map[int]*supervisor.Supervisor),
 		latestProgress:     make(map[int]*parser.ProgressUpdate),
@@ -165,16 +365,30 @@ func NewClientManager(cfg ManagerConfig) *ClientManager {
 		clientStats:        make(map[int]*stats.ClientStats),
 		aggregator:         stats.NewStatsAggregator(threshold),
 		configSeed:            time.Now().UnixNano(),
+		refreshes:             parser.NewRefreshTracker(cfg.RefreshStormThreshold),
+		reconnects:            stats.NewReconnectCauseTracker(),
+		latency:               parser.NewLatencyWindow(),
+		heatmap:               parser.NewLatencyHeatmap(),
+		targetDuration:        cfg.TargetDuration,
 		throughputTracker:     timeseries.NewThroughputTracker(),
 		throughputSamplerDone: make(chan struct{}),
-		debugStatsCacheTTL:    time.Second, // Cache TTL for debug stats
+		debugStatsCacheTTL:    aggregateInterval, // Cache TTL for debug stats
+		aggregateInterval:     aggregateInterval,
+		aggregateDone:         make(chan struct{}),
+		aggregateExited:       make(chan struct{}),
 	}
-	// Initialize atomic.Value with first snapshot (lock-free)
-	cm.prevDebugSnapshot.Store(&debugRateSnapshot{timestamp: time.Now()})
+	cm.prevDebugStats.Store(&stats.DebugStatsAggregate{Timestamp: time.Now()})
 
 	// Start throughput sampler goroutine
 	go cm.throughputSamplerLoop()
 
+	// Aggregate on our own clock rather than whenever a reader asks
+	if cm.statsEnabled {
+		go cm.aggregationLoop()
+	} else {
+		close(cm.aggregateExited)
+	}
+
 	return cm
 }
 
@@ -183,10 +397,15 @@ func NewClientManager(cfg ManagerConfig) *ClientManager {
 func (m *ClientManager) StartClient(ctx context.Context, clientID int) {
 	// Create backoff calculator for this client
 	backoff := supervisor.NewBackoff(clientID, m.configSeed, m.backoffConfig)
+	backoff.SetOverrides(m.backoffOverrides)
 
-	// Create ClientStats for this client (Phase 4/5)
+	// Create ClientStats for this client (Phase 4/5). A client started
+	// again after being stopped (tenant quota) keeps its stats and parser,
+	// so its counters stay cumulative.
 	var clientStats *stats.ClientStats
-	if m.statsEnabled {
+	if existing := m.GetClientStats(clientID); existing != nil {
+		clientStats = existing
+	} else if m.statsEnabled {
 		clientStats = stats.NewClientStats(clientID)
 
 		// Register with aggregator
@@ -208,21 +427,25 @@ func (m *ClientManager) StartClient(ctx context.Context, clientID int) {
 	// Replaces HLSEventParser with comprehensive HLS/HTTP/TCP tracking
 	var stderrParser parser.LineParser
 	var debugParser *parser.DebugEventParser
-	if m.statsEnabled {
-		// Target duration for jitter calculation (2s is HLS default)
-		targetDuration := 2 * time.Second
+	if existing := m.debugParser(clientID); existing != nil {
+		stderrParser = existing
+	} else if m.statsEnabled {
 		debugParser = parser.NewDebugEventParserWithSizeLookup(
 			clientID,
-			targetDuration,
+			m.targetDuration, // 0 = the parser's 2s default
 			m.createDebugEventCallback(clientID, clientStats),
 			m.segmentSizeLookup, // Pass segment size lookup for accurate byte tracking
 		)
+		debugParser.SetSlowRequestThreshold(m.slowRequestThreshold)
+		debugParser.SetSLOThreshold(m.sloThreshold)
+		debugParser.SetDialect(m.logDialect)
 		stderrParser = debugParser
+		m.addDebugParser(clientID, debugParser)
+	}
 
-		// Store reference for stats aggregation
-		m.debugMu.Lock()
-		m.debugParsers[clientID] = debugParser
-		m.debugMu.Unlock()
+	var priority supervisor.Priority
+	if m.clientPriority != nil {
+		priority = m.clientPriority(clientID)
 	}
 
 	// Create supervisor with callbacks
@@ -232,19 +455,50 @@ func (m *ClientManager) StartClient(ctx context.Context, clientID int) {
 		Backoff:     backoff,
 		Logger:      m.logger,
 		MaxRestarts: m.maxRestarts,
+		Quarantine:  m.quarantine,
+		ExitCauses:  m.lastFailure,
+		StopSignal:  m.stopSignal,
+		StopGrace:   m.stopGrace,
+
+		SessionLength: m.sessionLength,
+		AtEnd:         m.atEnd,
+
 		// Stats collection
 		StatsEnabled:       m.statsEnabled,
 		StatsBufferSize:    m.statsBufferSize,
 		StatsDropThreshold: m.statsDropThreshold,
-		// FD mode is always enabled when stats are enabled
+		StatsMaxLineLength: m.statsMaxLineLength,
+		ProgressMode:       m.progressMode,
 		// Parsers (Phase 2 - ProgressParser, Phase 7 - DebugEventParser)
 		ProgressParser: progressParser,
 		StderrParser:   stderrParser,
+		CPUs:           m.cpuAllocator.CPUsFor(clientID),
+		Priority:       priority,
 		Callbacks: supervisor.Callbacks{
-			OnStateChange: m.handleStateChange,
-			OnStart:       m.handleStart,
-			OnExit:        m.handleExit,
-			OnRestart:     m.handleRestart,
+			OnStateChange:   m.handleStateChange,
+			OnStart:         m.handleStart,
+			OnExit:          m.handleExit,
+			OnRestart:       m.handleRestart,
+			OnStopSignal:    m.callbacks.OnClientStopSignal,
+			OnQuarantine:    m.callbacks.OnClientQuarantine,
+			OnSessionStart:  m.callbacks.OnClientSessionStart,
+			OnSessionEnd:    m.handleSessionEnd,
+			OnStreamEnd:     m.callbacks.OnClientStreamEnd,
+			OnLineTruncated: func(int) {
+				if clientStats != nil {
+					clientStats.RecordTruncatedLine()
+				}
+			},
+			OnInvalidUTF8: func(_ int, n int) {
+				if clientStats != nil {
+					clientStats.RecordInvalidUTF8(n)
+				}
+			},
+			OnParserPanic: func(int, string) {
+				if clientStats != nil {
+					clientStats.RecordParserPanic()
+				}
+			},
 		},
 	})
 
@@ -266,6 +520,14 @@ func (m *ClientManager) StartClient(ctx context.Context, clientID int) {
 				"client_id", clientID,
 				"error", err,
 			)
+			if errors.Is(err, supervisor.ErrMaxRestarts) {
+				m.gaveUpMu.Lock()
+				m.gaveUp++
+				if m.gaveUpErr == nil {
+					m.gaveUpErr = fmt.Errorf("client %d: %w", clientID, err)
+				}
+				m.gaveUpMu.Unlock()
+			}
 		}
 	}()
 }
@@ -281,6 +543,11 @@ func (m *ClientManager) handleStateChange(clientID int, oldState, newState super
 	} else if wasActive && !isActive {
 		m.activeCount.Add(-1)
 	}
+	if newState == supervisor.StateQuarantined {
+		m.quarantinedCount.Add(1)
+	} else if oldState == supervisor.StateQuarantined {
+		m.quarantinedCount.Add(-1)
+	}
 
 	// Forward to external callback
 	if m.callbacks.OnClientStateChange != nil {
@@ -290,13 +557,153 @@ func (m *ClientManager) handleStateChange(clientID int, oldState, newState super
 
 // handleStart processes client start events.
 func (m *ClientManager) handleStart(clientID int, pid int) {
+	m.clearFailure(clientID)
+
+	m.reauthMu.Lock()
+	failedAt, reauthed := m.reauthPending[clientID]
+	delete(m.reauthPending, clientID)
+	delete(m.restartPending, clientID)
+	m.reauthMu.Unlock()
+	if reauthed && m.callbacks.OnClientReauth != nil {
+		m.callbacks.OnClientReauth(clientID, time.Since(failedAt))
+	}
+	if m.tokenTTL > 0 {
+		m.armTokenExpiry(clientID)
+	}
+
 	if m.callbacks.OnClientStart != nil {
 		m.callbacks.OnClientStart(clientID, pid)
 	}
 }
 
+// reauth restarts a client's process after a 401, once per 401 burst:
+// later 401s from the same process are ignored until the new one starts.
+func (m *ClientManager) reauth(clientID int) {
+	m.reauthMu.Lock()
+	if _, pending := m.reauthPending[clientID]; pending {
+		m.reauthMu.Unlock()
+		return
+	}
+	m.reauthPending[clientID] = time.Now()
+	m.reauthMu.Unlock()
+
+	m.mu.RLock()
+	sup := m.supervisors[clientID]
+	m.mu.RUnlock()
+	if sup == nil || !sup.RestartProcess() {
+		// Gone or already exiting: no restart of ours to wait for
+		m.reauthMu.Lock()
+		delete(m.reauthPending, clientID)
+		m.reauthMu.Unlock()
+		return
+	}
+	m.logger.Debug("client_reauth", "client_id", clientID)
+}
+
+// tokenExpiry is the pending -token-ttl expiry of one client process.
+type tokenExpiry struct {
+	timer *time.Timer
+}
+
+// armTokenExpiry schedules the token expiry of a client's new process,
+// replacing the previous process's.
+func (m *ClientManager) armTokenExpiry(clientID int) {
+	m.reauthMu.Lock()
+	defer m.reauthMu.Unlock()
+	if e := m.tokenExpiries[clientID]; e != nil {
+		e.timer.Stop()
+	}
+	e := &tokenExpiry{}
+	e.timer = time.AfterFunc(m.tokenTTL, func() { m.expireToken(clientID, e) })
+	m.tokenExpiries[clientID] = e
+}
+
+// disarmTokenExpiry cancels a client's pending token expiry, once its
+// process has exited.
+func (m *ClientManager) disarmTokenExpiry(clientID int) {
+	m.reauthMu.Lock()
+	defer m.reauthMu.Unlock()
+	if e := m.tokenExpiries[clientID]; e != nil {
+		e.timer.Stop()
+		delete(m.tokenExpiries, clientID)
+	}
+}
+
+// expireToken re-auths a client whose token reached TokenTTL, unless e was
+// replaced or cancelled meanwhile.
+func (m *ClientManager) expireToken(clientID int, e *tokenExpiry) {
+	m.reauthMu.Lock()
+	current := m.tokenExpiries[clientID] == e
+	if current {
+		delete(m.tokenExpiries, clientID)
+	}
+	m.reauthMu.Unlock()
+	if !current {
+		return
+	}
+
+	m.logger.Debug("client_token_expired", "client_id", clientID, "ttl", m.tokenTTL.String())
+	if m.callbacks.OnClientTokenExpired != nil {
+		m.callbacks.OnClientTokenExpired(clientID)
+	}
+	m.reauth(clientID)
+}
+
+// reauthing reports whether a client is being restarted after a 401, so
+// its exit is expected.
+func (m *ClientManager) reauthing(clientID int) bool {
+	m.reauthMu.Lock()
+	defer m.reauthMu.Unlock()
+	_, pending := m.reauthPending[clientID]
+	return pending
+}
+
+// RestartClient stops a client's process for its supervisor to start a
+// new one, with the client's options as they are then. Returns false if
+// no process is running.
+func (m *ClientManager) RestartClient(clientID int) bool {
+	sup := m.GetSupervisor(clientID)
+	if sup == nil {
+		return false
+	}
+	m.reauthMu.Lock()
+	m.restartPending[clientID] = struct{}{}
+	m.reauthMu.Unlock()
+	if !sup.RestartProcess() {
+		m.reauthMu.Lock()
+		delete(m.restartPending, clientID)
+		m.reauthMu.Unlock()
+		return false
+	}
+	return true
+}
+
+// restarting reports whether a client is being restarted by RestartClient
+// or after a 401, so its exit is expected.
+func (m *ClientManager) restarting(clientID int) bool {
+	m.reauthMu.Lock()
+	defer m.reauthMu.Unlock()
+	_, restart := m.restartPending[clientID]
+	_, reauth := m.reauthPending[clientID]
+	return restart || reauth
+}
+
+// handleSessionEnd marks a client's exit at the end of its session as
+// expected, like a RestartClient's.
+func (m *ClientManager) handleSessionEnd(clientID int, length time.Duration) {
+	m.reauthMu.Lock()
+	m.restartPending[clientID] = struct{}{}
+	m.reauthMu.Unlock()
+	if m.callbacks.OnClientSessionEnd != nil {
+		m.callbacks.OnClientSessionEnd(clientID, length)
+	}
+}
+
 // handleExit processes client exit events.
 func (m *ClientManager) handleExit(clientID int, exitCode int, uptime time.Duration) {
+	if m.tokenTTL > 0 {
+		m.disarmTokenExpiry(clientID)
+	}
 	if m.callbacks.OnClientExit != nil {
 		m.callbacks.OnClientExit(clientID, exitCode, uptime)
 	}
@@ -312,7 +719,9 @@ func (m *ClientManager) handleRestart(clientID int, attempt int, delay time.Dura
 }
 
 // Shutdown gracefully stops all clients.
-// It waits for all supervisors to stop, with a timeout.
+// It waits for all supervisors to stop, with a timeout. Either way it then
+// stops the aggregation loop and aggregates one last time for the exit
+// summary.
 func (m *ClientManager) Shutdown(ctx context.Context) error {
 	m.logger.Info("shutdown_initiated", "active_clients", m.ActiveCount())
 
@@ -324,14 +733,26 @@ func (m *ClientManager) Shutdown(ctx context.Context) error {
 		close(done)
 	}()
 
+	var err error
 	select {
 	case <-done:
 		m.logger.Info("all_clients_stopped")
-		return nil
 	case <-ctx.Done():
 		m.logger.Warn("shutdown_timeout")
-		return ctx.Err()
+		err = ctx.Err()
 	}
+
+	// Final aggregation so the exit summary sees every client's last output.
+	// The loop must have exited first, or a tick could publish over it.
+	m.stopAggregation()
+	m.RefreshAggregatedStats()
+	return err
+}
+
+// stopAggregation stops aggregationLoop and waits for it to return.
+func (m *ClientManager) stopAggregation() {
+	m.stopAggregate.Do(func() { close(m.aggregateDone) })
+	<-m.aggregateExited
 }
 
 // ActiveCount returns the number of currently running clients.
@@ -339,6 +760,19 @@ func (m *ClientManager) ActiveCount() int {
 	return int(m.activeCount.Load())
 }
 
+// QuarantinedCount returns the number of clients parked in quarantine.
+func (m *ClientManager) QuarantinedCount() int {
+	return int(m.quarantinedCount.Load())
+}
+
+// GaveUp returns how many clients stopped for good after MaxRestarts,
+// and the first one's error (wrapping supervisor.ErrMaxRestarts).
+func (m *ClientManager) GaveUp() (int, error) {
+	m.gaveUpMu.Lock()
+	defer m.gaveUpMu.Unlock()
+	return m.gaveUp, m.gaveUpErr
+}
+
 // StartedCount returns the total number of clients that have been started.
 func (m *ClientManager) StartedCount() int {
 	return int(m.startedCount.Load())
@@ -356,6 +790,80 @@ func (m *ClientManager) ClientCount() int {
 	return len(m.supervisors)
 }
 
+// ClientPIDs returns the process ID of each client with a running FFmpeg.
+func (m *ClientManager) ClientPIDs() map[int]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pids := make(map[int]int, len(m.supervisors))
+	for id, sup := range m.supervisors {
+		if pid := sup.PID(); pid != 0 {
+			pids[id] = pid
+		}
+	}
+	return pids
+}
+
+// PIDs returns the process IDs of the running FFmpeg processes.
+func (m *ClientManager) PIDs() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pids := make([]int, 0, len(m.supervisors))
+	for _, sup := range m.supervisors {
+		if pid := sup.PID(); pid != 0 {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// RunningClients returns the IDs of the clients whose process is running
+// and not paused, in ID order.
+func (m *ClientManager) RunningClients() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []int
+	for id, sup := range m.supervisors {
+		if sup.State() == supervisor.StateRunning && !sup.Paused() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// LatencyWindow returns the swarm-wide window of segment wall times the
+// capacity projection samples.
+func (m *ClientManager) LatencyWindow() *parser.LatencyWindow {
+	return m.latency
+}
+
+// LatencyHeatmap returns the swarm-wide segment wall time buckets the
+// -save-run heatmap samples.
+func (m *ClientManager) LatencyHeatmap() *parser.LatencyHeatmap {
+	return m.heatmap
+}
+
+// PauseClient stops a client's process with SIGSTOP. Returns false if it
+// isn't running or is already paused.
+func (m *ClientManager) PauseClient(clientID int) bool {
+	if sup := m.GetSupervisor(clientID); sup != nil {
+		return sup.Pause()
+	}
+	return false
+}
+
+// ResumeClient continues a client paused by PauseClient. Returns false if
+// it wasn't paused.
+func (m *ClientManager) ResumeClient(clientID int) bool {
+	if sup := m.GetSupervisor(clientID); sup != nil {
+		return sup.Resume()
+	}
+	return false
+}
+
 // GetSupervisor returns the supervisor for a specific client ID.
 func (m *ClientManager) GetSupervisor(clientID int) *supervisor.Supervisor {
 	m.mu.RLock()
@@ -400,6 +908,8 @@ func (m *ClientManager) createProgressCallback(clientID int, clientStats *stats.
 
 		// Update ClientStats (Phase 4/5)
 		if clientStats != nil {
+			clientStats.RecordEvent()
+
 			// Update bytes - ClientStats handles FFmpeg restart resets internally
 			clientStats.UpdateCurrentBytes(update.TotalSize)
 
@@ -480,7 +990,18 @@ func (m *ClientManager) GetClientProgress(clientID int) *parser.ProgressUpdate {
 // createDebugEventCallback creates a callback for the DebugEventParser.
 // This callback handles all debug events from the HLS/HTTP/TCP layers.
 func (m *ClientManager) createDebugEventCallback(clientID int, clientStats *stats.ClientStats) parser.DebugEventCallback {
+	traced := m.traceClient != nil && m.traceClient(clientID)
 	return func(event *parser.DebugEvent) {
+		if traced {
+			logTraceEvent(m.logger, clientID, event)
+		}
+		if m.callbacks.OnDebugEvent != nil {
+			m.callbacks.OnDebugEvent(clientID, event)
+		}
+		if clientStats != nil {
+			clientStats.RecordEvent()
+		}
+
 		// Track bytes from Content-Length headers (for live streams where total_size=N/A)
 		// Note: Content-Length headers are logged at TRACE level, so may not be available
 		// For now, we'll track bytes when available, and estimate from segments as fallback
@@ -527,19 +1048,32 @@ func (m *ClientManager) createDebugEventCallback(clientID int, clientStats *stat
 			if clientStats != nil {
 				clientStats.RecordHTTPError(event.HTTPCode)
 			}
+			m.recordFailure(clientID, fmt.Sprintf("http-%d", event.HTTPCode), fmt.Sprintf("http-%dxx", event.HTTPCode/100))
+			if event.HTTPCode == http.StatusUnauthorized && m.reauthOn401 {
+				m.reauth(clientID)
+			}
 
 		case parser.DebugEventReconnect:
 			if clientStats != nil {
 				clientStats.RecordReconnection()
 			}
+			m.reconnects.Add(event.Cause, event.Timestamp)
 
 		// TCP Layer events
 		case parser.DebugEventTCPFailed:
-			if event.FailReason == "timeout" {
-				if clientStats != nil {
+			if clientStats != nil {
+				if event.FailReason == "timeout" {
 					clientStats.RecordTimeout()
 				}
+				clientStats.RecordNetworkError(event.FailReason) // "error" counts as other
+			}
+			m.recordFailure(clientID, event.FailReason)
+
+		case parser.DebugEventNetworkError:
+			if clientStats != nil {
+				clientStats.RecordNetworkError(event.FailReason)
 			}
+			m.recordFailure(clientID, event.FailReason)
 
 		// Error events (critical for load testing)
 		case parser.DebugEventSegmentFailed:
@@ -558,6 +1092,18 @@ func (m *ClientManager) createDebugEventCallback(clientID int, clientStats *stat
 				"playlist_id", event.PlaylistID,
 			)
 
+		case parser.DebugEventSlowRequest:
+			m.logger.Warn("slow_request",
+				"client_id", clientID,
+				"kind", event.Kind,
+				"url", event.URL,
+				"started", event.Started,
+				"wall_time", event.WallTime.String(),
+				"connect", event.Connect.String(),
+				"transfer", event.Transfer.String(),
+				"idle", event.Idle.String(),
+			)
+
 		case parser.DebugEventPlaylistFailed:
 			// Live edge lost!
 			m.logger.Warn("playlist_failed",
@@ -575,6 +1121,68 @@ func (m *ClientManager) createDebugEventCallback(clientID int, clientStats *stat
 	}
 }
 
+// SetLogDialect sets how the parsers of clients started from now on read
+// FFmpeg's log lines. Call before the clients start.
+func (m *ClientManager) SetLogDialect(d parser.LogDialect) {
+	m.logDialect = d
+}
+
+// debugParser returns a client's debug parser (nil if none).
+func (m *ClientManager) debugParser(clientID int) *parser.DebugEventParser {
+	m.debugMu.RLock()
+	defer m.debugMu.RUnlock()
+	return m.debugParsers[clientID]
+}
+
+// addDebugParser attaches a client's debug parser to the running totals and
+// registers it for the per-client pass of computeDebugStats.
+func (m *ClientManager) addDebugParser(clientID int, dp *parser.DebugEventParser) {
+	dp.SetTotals(&m.debugTotals)
+	dp.SetRefreshTracker(m.refreshes)
+	dp.SetLatencyWindow(m.latency)
+	dp.SetLatencyHeatmap(m.heatmap)
+
+	m.debugMu.Lock()
+	m.debugParsers[clientID] = dp
+	dropSamples := m.samplesDropped
+	m.debugMu.Unlock()
+	if dropSamples {
+		dp.DropSamples()
+	}
+}
+
+// DropSampleRings frees the sample ring buffers of every client's debug
+// parser, now and for clients started later (the -max-memory guard).
+// Returns false if there was nothing to drop: stats off, or already done.
+func (m *ClientManager) DropSampleRings() bool {
+	if !m.statsEnabled {
+		return false
+	}
+	m.debugMu.Lock()
+	if m.samplesDropped {
+		m.debugMu.Unlock()
+		return false
+	}
+	m.samplesDropped = true
+	parsers := make([]*parser.DebugEventParser, 0, len(m.debugParsers))
+	for _, dp := range m.debugParsers {
+		parsers = append(parsers, dp)
+	}
+	m.debugMu.Unlock()
+
+	// Outside debugMu: DropSamples takes each parser's own lock
+	for _, dp := range parsers {
+		dp.DropSamples()
+	}
+	return true
+}
+
+// ReconnectCauses returns the clients' reconnects so far by the stage
+// that failed.
+func (m *ClientManager) ReconnectCauses() stats.ReconnectCauseSummary {
+	return m.reconnects.Summary()
+}
+
 // GetDebugStats returns aggregated debug statistics across all clients.
 // This is the primary method for the layered TUI dashboard (Phase 7).
 // Uses caching to avoid redundant computation when both TUI and Prometheus
@@ -597,29 +1205,89 @@ func (m *ClientManager) computeDebugStats() stats.DebugStatsAggregate {
 	m.debugMu.RLock()
 	defer m.debugMu.RUnlock()
 
+	// Counters come from the running totals; only distributions, averages
+	// and hot spots need the per-client pass below
+	totals := m.debugTotals.Stats()
+	now := time.Now()
 	agg := stats.DebugStatsAggregate{
+		Timestamp:             now,
 		ClientsWithDebugStats: len(m.debugParsers),
-	}
 
-	// Aggregate stats from all debug parsers
-	var totalSegWallTime, totalTCPConnect float64
-	var segWallTimeCount, tcpConnectCount int64
+		// HLS Layer
+		SegmentsDownloaded: totals.SegmentCount,
+		SegmentsFailed:     totals.SegmentFailedCount,
+		SegmentsSkipped:    totals.SegmentSkippedCount,
+		SegmentsExpired:    totals.SegmentsExpiredSum,
+		PlaylistsRefreshed: totals.PlaylistRefreshes,
+		PlaylistsFailed:    totals.PlaylistFailedCount,
+		PlaylistLateCount:  totals.PlaylistLateCount,
+		PlaylistRefresh:    refreshDistribution(m.refreshes.Stats()),
+		SequenceSkips:      totals.SequenceSkips,
+		ManifestCount:      totals.ManifestCount,
+		Discontinuities:    totals.DiscontinuityCount,
+		AdMarkers:          totals.AdMarkerCount,
+		AdBreaks:           totals.AdBreakCount,
+		SlowSegments:       totals.SlowSegmentCount,
+		SlowManifests:      totals.SlowManifestCount,
+		SLOMisses:          totals.SLOMissCount,
 
-	for _, dp := range m.debugParsers {
-		stats := dp.Stats()
+		// HTTP Layer
+		HTTPOpenCount:  totals.HTTPOpenCount,
+		HTTP4xxCount:   totals.HTTP4xxCount,
+		HTTP5xxCount:   totals.HTTP5xxCount,
+		ReconnectCount: totals.ReconnectCount,
 
-		// HLS Layer
-		agg.SegmentsDownloaded += stats.SegmentCount
-		agg.SegmentsFailed += stats.SegmentFailedCount
-		agg.SegmentsSkipped += stats.SegmentSkippedCount
-		agg.SegmentsExpired += stats.SegmentsExpiredSum
-		agg.PlaylistsRefreshed += stats.PlaylistRefreshes
-		agg.PlaylistsFailed += stats.PlaylistFailedCount
-		agg.PlaylistLateCount += stats.PlaylistLateCount
-		agg.SequenceSkips += stats.SequenceSkips
-
-		// Debug: Log parser stats for diagnostics (only when TUI is not enabled to avoid log spam)
-		// This helps identify if events are being parsed but not counted
+		// TCP Layer
+		TCPConnectCount: totals.TCPConnectCount,
+		TCPSuccessCount: totals.TCPSuccessCount,
+		TCPRefusedCount: totals.TCPRefusedCount,
+		TCPTimeoutCount: totals.TCPTimeoutCount,
+
+		// Timing accuracy
+		TimestampsUsed:  totals.TimestampsUsed,
+		LinesProcessed:  totals.LinesProcessed,
+		OrphanedPending: totals.OrphanedPending,
+
+		// Segment bytes and size lookup diagnostics
+		TotalSegmentBytes:          totals.SegmentBytesDownloaded,
+		SegmentSizeLookupAttempts:  totals.SegmentSizeLookupAttempts,
+		SegmentSizeLookupSuccesses: totals.SegmentSizeLookupSuccesses,
+	}
+
+	// Aggregate distributions from all debug parsers
+	var totalSegWallTime, totalManifestWallTime, totalTCPConnect, totalRecovery float64
+	var segWallTimeCount, manifestWallTimeCount, tcpConnectCount int64
+	slowestByClient := make(map[int][]parser.SegmentTiming)
+
+	// Percentiles don't aggregate: the max or mean of per-client P95s is not
+	// the swarm's P95. Merge the per-client t-digests and query the result.
+	segmentDigest := tdigest.NewWithCompression(100)
+	manifestDigest := tdigest.NewWithCompression(100)
+	downloadDigest := tdigest.NewWithCompression(100)
+	var totalGapMs float64
+	var gapCount int64
+	segmentURLs := make(map[string]int64)
+	serving := make(map[string]stats.ServingTotals)
+	skewSeen := false
+
+	for clientID, dp := range m.debugParsers {
+		stats := dp.Stats()
+		dp.MergeWallTimeDigests(segmentDigest, manifestDigest)
+		dp.MergeDownloadDigest(downloadDigest)
+		totalGapMs += stats.SegmentGapAvgMs * float64(stats.SegmentGapCount)
+		gapCount += stats.SegmentGapCount
+
+		// Discontinuity recovery
+		if stats.DiscontinuityPending {
+			agg.ClientsRecovering++
+		}
+		if stats.RecoveryCount > 0 {
+			agg.DiscontinuityRecovered += stats.RecoveryCount
+			totalRecovery += stats.RecoveryAvgMs * float64(stats.RecoveryCount)
+			if stats.RecoveryMaxMs > agg.RecoveryMaxMs {
+				agg.RecoveryMaxMs = stats.RecoveryMaxMs
+			}
+		}
 
 		// Aggregate wall time (weighted average)
 		if stats.SegmentCount > 0 {
@@ -631,34 +1299,12 @@ func (m *ClientManager) computeDebugStats() stats.DebugStatsAggregate {
 			if agg.SegmentWallTimeMin == 0 || stats.SegmentMinMs < agg.SegmentWallTimeMin {
 				agg.SegmentWallTimeMin = stats.SegmentMinMs
 			}
-
-			// Aggregate percentiles (take max across clients - worst-case is useful for load testing)
-			if stats.SegmentWallTimeP25 > agg.SegmentWallTimeP25 {
-				agg.SegmentWallTimeP25 = stats.SegmentWallTimeP25
-			}
-			if stats.SegmentWallTimeP50 > agg.SegmentWallTimeP50 {
-				agg.SegmentWallTimeP50 = stats.SegmentWallTimeP50
-			}
-			if stats.SegmentWallTimeP75 > agg.SegmentWallTimeP75 {
-				agg.SegmentWallTimeP75 = stats.SegmentWallTimeP75
-			}
-			if stats.SegmentWallTimeP95 > agg.SegmentWallTimeP95 {
-				agg.SegmentWallTimeP95 = stats.SegmentWallTimeP95
-			}
-			if stats.SegmentWallTimeP99 > agg.SegmentWallTimeP99 {
-				agg.SegmentWallTimeP99 = stats.SegmentWallTimeP99
-			}
 		}
 
-		// Aggregate manifest wall time
-		agg.ManifestCount += stats.ManifestCount
+		// Aggregate manifest wall time (weighted average)
 		if stats.ManifestCount > 0 {
-			// Weighted average
-			totalCount := agg.ManifestCount
-			if totalCount > 0 {
-				agg.ManifestWallTimeAvg = (agg.ManifestWallTimeAvg*float64(agg.ManifestCount-stats.ManifestCount) +
-					stats.ManifestAvgMs*float64(stats.ManifestCount)) / float64(totalCount)
-			}
+			totalManifestWallTime += stats.ManifestAvgMs * float64(stats.ManifestCount)
+			manifestWallTimeCount += stats.ManifestCount
 
 			// Min/Max
 			if stats.ManifestMaxMs > agg.ManifestWallTimeMax {
@@ -667,23 +1313,6 @@ func (m *ClientManager) computeDebugStats() stats.DebugStatsAggregate {
 			if agg.ManifestWallTimeMin == 0 || stats.ManifestMinMs < agg.ManifestWallTimeMin {
 				agg.ManifestWallTimeMin = stats.ManifestMinMs
 			}
-
-			// Aggregate percentiles (take max across clients - worst-case is useful for load testing)
-			if stats.ManifestWallTimeP25 > agg.ManifestWallTimeP25 {
-				agg.ManifestWallTimeP25 = stats.ManifestWallTimeP25
-			}
-			if stats.ManifestWallTimeP50 > agg.ManifestWallTimeP50 {
-				agg.ManifestWallTimeP50 = stats.ManifestWallTimeP50
-			}
-			if stats.ManifestWallTimeP75 > agg.ManifestWallTimeP75 {
-				agg.ManifestWallTimeP75 = stats.ManifestWallTimeP75
-			}
-			if stats.ManifestWallTimeP95 > agg.ManifestWallTimeP95 {
-				agg.ManifestWallTimeP95 = stats.ManifestWallTimeP95
-			}
-			if stats.ManifestWallTimeP99 > agg.ManifestWallTimeP99 {
-				agg.ManifestWallTimeP99 = stats.ManifestWallTimeP99
-			}
 		}
 
 		// Aggregate jitter
@@ -692,16 +1321,35 @@ func (m *ClientManager) computeDebugStats() stats.DebugStatsAggregate {
 		}
 
 		// HTTP Layer
-		agg.HTTPOpenCount += stats.HTTPOpenCount
-		agg.HTTP4xxCount += stats.HTTP4xxCount
-		agg.HTTP5xxCount += stats.HTTP5xxCount
-		agg.ReconnectCount += stats.ReconnectCount
+		for host, n := range stats.HostOpens {
+			if agg.HostRequests == nil {
+				agg.HostRequests = make(map[string]int64)
+			}
+			agg.HostRequests[host] += n
+		}
 
-		// TCP Layer
-		agg.TCPConnectCount += stats.TCPConnectCount
-		agg.TCPSuccessCount += stats.TCPSuccessCount
-		agg.TCPRefusedCount += stats.TCPRefusedCount
-		agg.TCPTimeoutCount += stats.TCPTimeoutCount
+		// Segment hot spots
+		for url, n := range stats.SegmentURLCounts {
+			segmentURLs[url] += n
+		}
+		for shard, n := range stats.ShardSegments {
+			if agg.ShardRequests == nil {
+				agg.ShardRequests = make(map[string]int64)
+			}
+			agg.ShardRequests[shard] += n
+		}
+		for class, s := range stats.Serving {
+			t := serving[class]
+			t.Responses += s.Responses
+			t.Segments += s.Segments
+			t.Bytes += s.Bytes
+			t.TTFBSum += s.TTFBSum
+			t.TTFBCount += s.TTFBCount
+			serving[class] = t
+		}
+		if len(stats.SlowestSegments) > 0 {
+			slowestByClient[clientID] = stats.SlowestSegments
+		}
 
 		// Aggregate TCP connect time (weighted average)
 		if stats.TCPConnectCount > 0 {
@@ -715,25 +1363,76 @@ func (m *ClientManager) computeDebugStats() stats.DebugStatsAggregate {
 			}
 		}
 
-		// Timing accuracy
-		agg.TimestampsUsed += stats.TimestampsUsed
-		agg.LinesProcessed += stats.LinesProcessed
+		// Requests started but not yet completed
+		agg.PendingEntries += stats.PendingEntries
 
-		// Segment bytes (from segment size tracking)
-		agg.TotalSegmentBytes += stats.SegmentBytesDownloaded
+		// Timestamp clock offsets
+		if stats.TimestampsUsed > 0 {
+			if !skewSeen || stats.ClockSkew < agg.ClockSkewMin {
+				agg.ClockSkewMin = stats.ClockSkew
+			}
+			if !skewSeen || stats.ClockSkew > agg.ClockSkewMax {
+				agg.ClockSkewMax = stats.ClockSkew
+			}
+			skewSeen = true
+		}
+	}
+
+	// Swarm-wide percentiles
+	if segmentDigest.Count() > 0 {
+		agg.SegmentWallTimeP25 = quantileDuration(segmentDigest, 0.25)
+		agg.SegmentWallTimeP50 = quantileDuration(segmentDigest, 0.50)
+		agg.SegmentWallTimeP75 = quantileDuration(segmentDigest, 0.75)
+		agg.SegmentWallTimeP95 = quantileDuration(segmentDigest, 0.95)
+		agg.SegmentWallTimeP99 = quantileDuration(segmentDigest, 0.99)
+	}
+	if downloadDigest.Count() > 0 {
+		agg.SegmentDownloadP50 = quantileDuration(downloadDigest, 0.50)
+		agg.SegmentDownloadP95 = quantileDuration(downloadDigest, 0.95)
+		agg.SegmentDownloadP99 = quantileDuration(downloadDigest, 0.99)
+	}
+	if gapCount > 0 {
+		agg.SegmentGapAvgMs = totalGapMs / float64(gapCount)
+	}
+	if manifestDigest.Count() > 0 {
+		agg.ManifestWallTimeP25 = quantileDuration(manifestDigest, 0.25)
+		agg.ManifestWallTimeP50 = quantileDuration(manifestDigest, 0.50)
+		agg.ManifestWallTimeP75 = quantileDuration(manifestDigest, 0.75)
+		agg.ManifestWallTimeP95 = quantileDuration(manifestDigest, 0.95)
+		agg.ManifestWallTimeP99 = quantileDuration(manifestDigest, 0.99)
+	}
 
-		// Segment size lookup diagnostics
-		agg.SegmentSizeLookupAttempts += stats.SegmentSizeLookupAttempts
-		agg.SegmentSizeLookupSuccesses += stats.SegmentSizeLookupSuccesses
+	// Segment hot spots
+	if len(segmentURLs) > 0 {
+		agg.HottestSegments = stats.TopURLCounts(segmentURLs, stats.HotSpotsKept)
+	}
+	agg.ShardGroups = stats.GroupShards(agg.ShardRequests)
+	if len(serving) > 0 {
+		agg.Serving = serving
+	}
+	var slowest []stats.SlowSegment
+	for clientID, timings := range slowestByClient {
+		for _, seg := range timings {
+			slowest = append(slowest, stats.SlowSegment{URL: seg.URL, ClientID: clientID, WallTime: seg.WallTime})
+		}
+	}
+	if len(slowest) > 0 {
+		agg.SlowestSegments = stats.TopSlowSegments(slowest, stats.HotSpotsKept)
 	}
 
 	// Calculate averages
 	if segWallTimeCount > 0 {
 		agg.SegmentWallTimeAvg = totalSegWallTime / float64(segWallTimeCount)
 	}
+	if manifestWallTimeCount > 0 {
+		agg.ManifestWallTimeAvg = totalManifestWallTime / float64(manifestWallTimeCount)
+	}
 	if tcpConnectCount > 0 {
 		agg.TCPConnectAvgMs = totalTCPConnect / float64(tcpConnectCount)
 	}
+	if agg.DiscontinuityRecovered > 0 {
+		agg.RecoveryAvgMs = totalRecovery / float64(agg.DiscontinuityRecovered)
+	}
 
 	// Calculate TCP health ratio
 	totalTCP := agg.TCPSuccessCount + agg.TCPRefusedCount + agg.TCPTimeoutCount
@@ -757,29 +1456,12 @@ func (m *ClientManager) computeDebugStats() stats.DebugStatsAggregate {
 	agg.SegmentThroughputAvg300s = throughputStats.Avg300s
 	agg.SegmentThroughputAvgOverall = throughputStats.AvgOverall
 
-	// Calculate instantaneous rates (Phase 7.4) - Lock-free using atomic.Value
-	now := time.Now()
-	// Lock-free read
-	prevSnapshotPtr := m.prevDebugSnapshot.Load()
-	if prevSnapshotPtr != nil {
-		prevSnapshot := prevSnapshotPtr.(*debugRateSnapshot)
-		elapsed := now.Sub(prevSnapshot.timestamp).Seconds()
-		if elapsed > 0 {
-			agg.InstantSegmentsRate = float64(agg.SegmentsDownloaded-prevSnapshot.segments) / elapsed
-			agg.InstantPlaylistsRate = float64(agg.PlaylistsRefreshed-prevSnapshot.playlists) / elapsed
-			agg.InstantHTTPRequestsRate = float64(agg.HTTPOpenCount-prevSnapshot.httpRequests) / elapsed
-			agg.InstantTCPConnectsRate = float64(agg.TCPConnectCount-prevSnapshot.tcpConnects) / elapsed
-		}
-	}
-	// Lock-free write - atomically swap snapshot pointer
-	newSnapshot := &debugRateSnapshot{
-		timestamp:    now,
-		segments:     agg.SegmentsDownloaded,
-		playlists:    agg.PlaylistsRefreshed,
-		httpRequests: agg.HTTPOpenCount,
-		tcpConnects:  agg.TCPConnectCount,
-	}
-	m.prevDebugSnapshot.Store(newSnapshot)
+	// Instantaneous rates since the previous computation (lock-free)
+	d := agg.Delta(m.prevDebugStats.Swap(agg.Counters()))
+	agg.InstantSegmentsRate = d.SegmentsRate()
+	agg.InstantPlaylistsRate = d.PlaylistsRate()
+	agg.InstantHTTPRequestsRate = d.HTTPRequestsRate()
+	agg.InstantTCPConnectsRate = d.TCPConnectsRate()
 
 	// Cache the result to avoid double-drain race condition
 	m.cachedDebugStats.Store(&cachedDebugStatsEntry{
@@ -790,6 +1472,24 @@ func (m *ClientManager) computeDebugStats() stats.DebugStatsAggregate {
 	return agg
 }
 
+// refreshDistribution converts the refresh tracker's stats.
+func refreshDistribution(s parser.RefreshStats) stats.RefreshDistribution {
+	return stats.RefreshDistribution{
+		Bounds:         parser.RefreshIntervalBuckets,
+		Counts:         s.Counts,
+		RatioSum:       s.RatioSum,
+		Storms:         s.Storms,
+		StormThreshold: s.Threshold,
+		PeakClients:    s.Peak,
+		PeakAt:         s.PeakAt,
+	}
+}
+
+// quantileDuration reads a nanosecond t-digest quantile as a Duration.
+func quantileDuration(d *tdigest.TDigest, q float64) time.Duration {
+	return time.Duration(d.Quantile(q))
+}
+
 // throughputSamplerLoop runs every second to sample bytes from parsers
 // and feed them into the ThroughputTracker for rolling average calculation.
 func (m *ClientManager) throughputSamplerLoop() {
@@ -806,15 +1506,10 @@ func (m *ClientManager) throughputSamplerLoop() {
 	}
 }
 
-// sampleThroughput reads total bytes from all parsers and feeds delta to tracker.
+// sampleThroughput reads total bytes from the running totals and feeds the
+// delta to the tracker.
 func (m *ClientManager) sampleThroughput() {
-	m.debugMu.RLock()
-	var currentTotal int64
-	for _, dp := range m.debugParsers {
-		stats := dp.Stats()
-		currentTotal += stats.SegmentBytesDownloaded
-	}
-	m.debugMu.RUnlock()
+	currentTotal := m.debugTotals.SegmentBytesDownloaded()
 
 	// Calculate delta since last sample
 	prevTotal := m.prevTotalBytes.Load()
@@ -843,15 +1538,133 @@ func (m *ClientManager) GetClientDebugStats(clientID int) *parser.DebugStats {
 	return nil
 }
 
+// GroupStats are the combined stats of a subset of the clients.
+type GroupStats struct {
+	Clients       int   // Clients that have started
+	Requests      int64 // Manifest + segment requests
+	Bytes         int64
+	HTTPErrors    map[int]int64 // By status code
+	NetworkErrors int64
+
+	// Wall time percentiles from the clients' merged digests
+	SegmentP50, SegmentP95, SegmentP99    time.Duration
+	ManifestP50, ManifestP95, ManifestP99 time.Duration
+}
+
+// GetGroupStats combines the stats of the given clients. Clients that have
+// not started yet are skipped.
+func (m *ClientManager) GetGroupStats(clientIDs []int) GroupStats {
+	g := GroupStats{HTTPErrors: make(map[int]int64)}
+	for _, clientID := range clientIDs {
+		cs := m.GetClientStats(clientID)
+		if cs == nil {
+			continue
+		}
+		g.Clients++
+		g.Requests += cs.ManifestRequests.Load() + cs.SegmentRequests.Load()
+		g.Bytes += cs.TotalBytes()
+		for code, n := range cs.GetHTTPErrors() {
+			g.HTTPErrors[code] += n
+		}
+		for _, n := range cs.GetNetworkErrors() {
+			g.NetworkErrors += n
+		}
+	}
+
+	segmentDigest := tdigest.NewWithCompression(100)
+	manifestDigest := tdigest.NewWithCompression(100)
+	m.debugMu.RLock()
+	for _, clientID := range clientIDs {
+		if dp, ok := m.debugParsers[clientID]; ok {
+			dp.MergeWallTimeDigests(segmentDigest, manifestDigest)
+		}
+	}
+	m.debugMu.RUnlock()
+
+	if segmentDigest.Count() > 0 {
+		g.SegmentP50 = quantileDuration(segmentDigest, 0.50)
+		g.SegmentP95 = quantileDuration(segmentDigest, 0.95)
+		g.SegmentP99 = quantileDuration(segmentDigest, 0.99)
+	}
+	if manifestDigest.Count() > 0 {
+		g.ManifestP50 = quantileDuration(manifestDigest, 0.50)
+		g.ManifestP95 = quantileDuration(manifestDigest, 0.95)
+		g.ManifestP99 = quantileDuration(manifestDigest, 0.99)
+	}
+	return g
+}
+
+// Errors returns the group's HTTP and network errors.
+func (g GroupStats) Errors() int64 {
+	n := g.NetworkErrors
+	for _, c := range g.HTTPErrors {
+		n += c
+	}
+	return n
+}
+
 // Legacy methods removed - use GetDebugStats() for accurate metrics from DebugEventParser
 
 // GetAggregatedStats returns aggregated statistics across all clients.
 // This is the primary method for getting comprehensive stats (Phase 5).
+// It returns the latest snapshot from aggregationLoop (at most one
+// aggregate interval old) and only aggregates itself before the first tick.
+// The snapshot is shared: callers must not modify it.
 func (m *ClientManager) GetAggregatedStats() *stats.AggregatedStats {
 	if m.aggregator == nil {
 		return nil
 	}
-	return m.aggregator.Aggregate()
+	if agg := m.latestStats.Load(); agg != nil {
+		return agg
+	}
+	return m.RefreshAggregatedStats()
+}
+
+// RefreshAggregatedStats aggregates now and publishes the result to
+// GetAggregatedStats readers.
+func (m *ClientManager) RefreshAggregatedStats() *stats.AggregatedStats {
+	if m.aggregator == nil {
+		return nil
+	}
+	m.recordStreamStats()
+	agg := m.aggregator.Aggregate()
+	m.latestStats.Store(agg)
+	return agg
+}
+
+// recordStreamStats copies each supervisor's per-path counters (bytes,
+// lines, drops) into its client's stats for the next Aggregate.
+func (m *ClientManager) recordStreamStats() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for id, sup := range m.supervisors {
+		cs := m.GetClientStats(id)
+		if cs == nil {
+			continue
+		}
+		progress, stderr := sup.StreamStats()
+		cs.RecordDroppedLines(progress.Lines, progress.Dropped, stderr.Lines, stderr.Dropped)
+		cs.RecordBytesRead(progress.Bytes, stderr.Bytes)
+	}
+}
+
+// aggregationLoop refreshes the aggregated and debug stats every
+// aggregateInterval until Shutdown. Being the only regular caller of
+// Aggregate also keeps its rate calculations on an even interval.
+func (m *ClientManager) aggregationLoop() {
+	defer close(m.aggregateExited)
+	ticker := time.NewTicker(m.aggregateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.aggregateDone:
+			return
+		case <-ticker.C:
+			m.RefreshAggregatedStats()
+			m.computeDebugStats() // Also refreshes the GetDebugStats cache
+		}
+	}
 }
 
 // GetStatsAggregator returns the stats aggregator for direct access.
@@ -2,12 +2,18 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"os/exec"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
 )
 
 // mockProcessBuilder is a simple mock for testing
@@ -90,9 +96,7 @@ func TestGetDebugStats_RateCalculation(t *testing.T) {
 	// Simulate some activity by creating a debug parser and updating it
 	// We need to add a debug parser to the manager
 	debugParser := parser.NewDebugEventParser(1, 2*time.Second, nil)
-	cm.debugMu.Lock()
-	cm.debugParsers[1] = debugParser
-	cm.debugMu.Unlock()
+	cm.addDebugParser(1, debugParser)
 
 	// Parse some events to increment counters
 	debugParser.ParseLine("[hls @ 0x123] HLS request for url 'http://example.com/seg1.ts', offset 0, playlist 0")
@@ -116,6 +120,99 @@ func TestGetDebugStats_RateCalculation(t *testing.T) {
 	}
 }
 
+// outputProcessBuilder runs a process that writes one line to stderr and,
+// with a progress FD, one to it.
+type outputProcessBuilder struct{ mockProcessBuilder }
+
+func (*outputProcessBuilder) BuildCommand(ctx context.Context, clientID int) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "sh", "-c", "echo stderr >&2; { echo progress=end >&3; } 2>/dev/null; sleep 0.05"), nil
+}
+
+func TestClientManager_StreamStats(t *testing.T) {
+	for _, mode := range []parser.ProgressMode{parser.ProgressPipe, parser.ProgressOff} {
+		t.Run(string(mode), func(t *testing.T) {
+			cm := NewClientManager(ManagerConfig{
+				Builder:      &outputProcessBuilder{},
+				Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+				StatsEnabled: true,
+				ProgressMode: mode,
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			cm.StartClient(ctx, 1)
+
+			var agg *stats.AggregatedStats
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+				if agg = cm.RefreshAggregatedStats(); agg.StderrLinesRead >= 2 {
+					break
+				}
+			}
+			cancel()
+			cm.Shutdown(context.Background())
+
+			if agg.StderrLinesRead < 2 || agg.StderrBytesRead != 7*agg.StderrLinesRead {
+				t.Errorf("stderr: %d lines, %d bytes; want 2+ lines of 7 bytes (across restarts)", agg.StderrLinesRead, agg.StderrBytesRead)
+			}
+			if mode == parser.ProgressOff {
+				if agg.ProgressLinesRead != 0 || agg.ProgressBytesRead != 0 {
+					t.Errorf("progress: %d lines, %d bytes with progress off, want none", agg.ProgressLinesRead, agg.ProgressBytesRead)
+				}
+			} else if agg.ProgressLinesRead == 0 || agg.ProgressBytesRead != 13*agg.ProgressLinesRead {
+				t.Errorf("progress: %d lines, %d bytes; want lines of 13 bytes", agg.ProgressLinesRead, agg.ProgressBytesRead)
+			}
+		})
+	}
+}
+
+func TestClientManager_DropSampleRings(t *testing.T) {
+	off := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}})
+	if off.DropSampleRings() {
+		t.Error("DropSampleRings() = true with stats off")
+	}
+
+	cm := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}, StatsEnabled: true})
+	cm.addDebugParser(1, parser.NewDebugEventParser(1, 2*time.Second, nil))
+	if !cm.DropSampleRings() {
+		t.Fatal("DropSampleRings() = false")
+	}
+	if cm.DropSampleRings() {
+		t.Error("DropSampleRings() = true the second time")
+	}
+
+	// Parsers added later still work (they start without rings)
+	p := parser.NewDebugEventParser(2, 2*time.Second, nil)
+	cm.addDebugParser(2, p)
+	p.ParseLine("[http @ 0x456] Opening 'http://example.com/seg1.ts' for reading")
+	if got := cm.GetDebugStats().ClientsWithDebugStats; got != 2 {
+		t.Errorf("ClientsWithDebugStats = %d, want 2", got)
+	}
+}
+
+// Clients on different clocks are each corrected; the spread is reported.
+func TestGetDebugStats_ClockSkew(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{
+		Builder:      &mockProcessBuilder{},
+		StatsEnabled: true,
+	})
+
+	now := time.Now().UTC()
+	for id, behind := range []time.Duration{0, time.Hour} {
+		p := parser.NewDebugEventParser(id, 2*time.Second, nil)
+		cm.addDebugParser(id, p)
+		ts := now.Add(-behind).Format("2006-01-02 15:04:05.000")
+		p.ParseLine(ts + " [tcp @ 0x5647feb5e100] [verbose] Starting connection attempt to 10.177.0.10 port 17080")
+	}
+	// No timestamped lines: no estimate to count
+	cm.addDebugParser(2, parser.NewDebugEventParser(2, 2*time.Second, nil))
+
+	s := cm.GetDebugStats()
+	if s.ClockSkewMin < 0 || s.ClockSkewMin > time.Second {
+		t.Errorf("ClockSkewMin = %v, want about 0", s.ClockSkewMin)
+	}
+	if s.ClockSkewMax < time.Hour || s.ClockSkewMax > time.Hour+time.Second {
+		t.Errorf("ClockSkewMax = %v, want about 1h", s.ClockSkewMax)
+	}
+}
+
 func TestGetDebugStats_AtomicValueTypeSafety(t *testing.T) {
 	cm := NewClientManager(ManagerConfig{
 		Builder:         &mockProcessBuilder{},
@@ -125,24 +222,18 @@ func TestGetDebugStats_AtomicValueTypeSafety(t *testing.T) {
 	})
 
 	// Call GetDebugStats multiple times to verify type safety
-	// This ensures the atomic.Value always contains *debugRateSnapshot
+	// This ensures the previous sample is always set and timestamped
 	for i := 0; i < 10; i++ {
 		stats := cm.GetDebugStats()
 		_ = stats // Use the result
 
-		// Verify atomic.Value contains correct type
-		snapshotPtr := cm.prevDebugSnapshot.Load()
-		if snapshotPtr == nil {
-			t.Fatal("prevDebugSnapshot should never be nil after initialization")
-		}
-
-		snapshot, ok := snapshotPtr.(*debugRateSnapshot)
-		if !ok {
-			t.Fatalf("prevDebugSnapshot should contain *debugRateSnapshot, got %T", snapshotPtr)
+		snapshot := cm.prevDebugStats.Load()
+		if snapshot == nil {
+			t.Fatal("prevDebugStats should never be nil after initialization")
 		}
 
 		// Verify snapshot has valid timestamp
-		if snapshot.timestamp.IsZero() {
+		if snapshot.Timestamp.IsZero() {
 			t.Error("Snapshot timestamp should not be zero")
 		}
 
@@ -160,9 +251,7 @@ func TestGetDebugStats_NoRaceCondition(t *testing.T) {
 
 	// Add a debug parser to generate some stats
 	debugParser := parser.NewDebugEventParser(1, 2*time.Second, nil)
-	cm.debugMu.Lock()
-	cm.debugParsers[1] = debugParser
-	cm.debugMu.Unlock()
+	cm.addDebugParser(1, debugParser)
 
 	// Run concurrent readers and writers
 	var wg sync.WaitGroup
@@ -201,3 +290,398 @@ func TestGetDebugStats_NoRaceCondition(t *testing.T) {
 	// Note: Segment count may be 0 if parser didn't match the test line format exactly
 	// The key test is that concurrent access works without races
 }
+
+// TestGetDebugStats_MergedPercentiles checks swarm percentiles come from the
+// merged distributions, not the per-client percentiles.
+func TestGetDebugStats_MergedPercentiles(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{
+		Builder:         &mockProcessBuilder{},
+		StatsEnabled:    true,
+		StatsBufferSize: 1000,
+	})
+
+	// Nine fast clients (100ms segments) and one slow one (2s segments).
+	// Max-of-client P50 would be 2s; the swarm median is 100ms.
+	for id := 1; id <= 10; id++ {
+		gap := 100 * time.Millisecond
+		if id == 10 {
+			gap = 2 * time.Second
+		}
+		dp := parser.NewDebugEventParser(id, 2*time.Second, nil)
+		cm.addDebugParser(id, dp)
+		start := time.Date(2026, 1, 23, 8, 0, 0, 0, time.UTC)
+		for seg := 0; seg <= 20; seg++ {
+			ts := start.Add(time.Duration(seg) * gap).Format("2006-01-02 15:04:05.000")
+			dp.ParseLine(fmt.Sprintf("%s [hls @ 0x123] [debug] HLS request for url 'http://example.com/seg%05d.ts', offset 0, playlist 0", ts, seg))
+		}
+	}
+
+	ds := cm.GetDebugStats()
+	if ds.SegmentsDownloaded != 200 {
+		t.Fatalf("SegmentsDownloaded = %d, want 200", ds.SegmentsDownloaded)
+	}
+	if ds.SegmentWallTimeP50 < 90*time.Millisecond || ds.SegmentWallTimeP50 > 110*time.Millisecond {
+		t.Errorf("SegmentWallTimeP50 = %v, want ~100ms", ds.SegmentWallTimeP50)
+	}
+	if ds.SegmentWallTimeP99 < time.Second {
+		t.Errorf("SegmentWallTimeP99 = %v, want the slow client's 2s tail", ds.SegmentWallTimeP99)
+	}
+}
+
+func TestGetAggregatedStats_Snapshot(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{
+		Builder:           &mockProcessBuilder{},
+		Logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		StatsEnabled:      true,
+		AggregateInterval: 20 * time.Millisecond,
+	})
+
+	cs := stats.NewClientStats(1)
+	cs.SegmentRequests.Add(5)
+	cm.aggregator.AddClient(cs)
+
+	// Readers share one snapshot instead of each aggregating
+	first := cm.GetAggregatedStats()
+	if first.TotalSegmentReqs != 5 {
+		t.Fatalf("TotalSegmentReqs = %d, want 5", first.TotalSegmentReqs)
+	}
+	if again := cm.GetAggregatedStats(); again != first {
+		t.Error("second read re-aggregated, want the cached snapshot")
+	}
+
+	// The aggregation loop picks up new activity on its own clock
+	cs.SegmentRequests.Add(5)
+	deadline := time.Now().Add(2 * time.Second)
+	for cm.GetAggregatedStats().TotalSegmentReqs != 10 {
+		if time.Now().After(deadline) {
+			t.Fatal("aggregation loop never refreshed the snapshot")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Shutdown stops the loop with a final aggregation
+	cs.SegmentRequests.Add(5)
+	if err := cm.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	if got := cm.GetAggregatedStats().TotalSegmentReqs; got != 15 {
+		t.Errorf("TotalSegmentReqs after Shutdown = %d, want 15", got)
+	}
+}
+
+func TestShutdown_TimeoutStopsAggregation(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{
+		Builder:           &mockProcessBuilder{},
+		Logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		StatsEnabled:      true,
+		AggregateInterval: time.Millisecond,
+	})
+
+	cs := stats.NewClientStats(1)
+	cs.SegmentRequests.Add(5)
+	cm.aggregator.AddClient(cs)
+
+	// A supervisor that never stops
+	cm.wg.Add(1)
+	defer cm.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cm.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Shutdown() = %v, want context.Canceled", err)
+	}
+	select {
+	case <-cm.aggregateExited:
+	default:
+		t.Fatal("aggregation loop still running after a timed out Shutdown")
+	}
+	if got := cm.GetAggregatedStats().TotalSegmentReqs; got != 5 {
+		t.Errorf("TotalSegmentReqs after Shutdown = %d, want 5", got)
+	}
+}
+
+// benchManager returns a manager with n clients that have each parsed a
+// few minutes of segment traffic.
+func benchManager(b *testing.B, n int) *ClientManager {
+	b.Helper()
+	cm := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}})
+	start := time.Date(2026, 1, 23, 8, 0, 0, 0, time.UTC)
+	for id := 1; id <= n; id++ {
+		dp := parser.NewDebugEventParser(id, 2*time.Second, nil)
+		cm.addDebugParser(id, dp)
+		for seg := 0; seg < 60; seg++ {
+			ts := start.Add(time.Duration(seg) * 2 * time.Second).Format("2006-01-02 15:04:05.000")
+			dp.ParseLine(fmt.Sprintf("%s [hls @ 0x123] [debug] HLS request for url 'http://example.com/seg%05d.ts', offset 0, playlist 0", ts, seg))
+		}
+	}
+	return cm
+}
+
+// BenchmarkThroughputTick compares the once-a-second throughput sample at
+// 2000 clients: polling every parser's Stats() against reading the pushed
+// running total.
+func BenchmarkThroughputTick(b *testing.B) {
+	cm := benchManager(b, 2000)
+
+	b.Run("poll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cm.debugMu.RLock()
+			var total int64
+			for _, dp := range cm.debugParsers {
+				total += dp.Stats().SegmentBytesDownloaded
+			}
+			cm.debugMu.RUnlock()
+		}
+	})
+	b.Run("totals", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			cm.sampleThroughput()
+		}
+	})
+}
+
+func BenchmarkComputeDebugStats(b *testing.B) {
+	cm := benchManager(b, 2000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cm.computeDebugStats()
+	}
+}
+
+func TestClientManager_Reauth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var reauths []time.Duration
+	cm := NewClientManager(ManagerConfig{
+		Builder:      &stubbornProcessBuilder{},
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		StatsEnabled: true,
+		ReauthOn401:  true,
+		StopGrace:    50 * time.Millisecond,
+		Callbacks: ManagerCallbacks{
+			OnClientReauth: func(clientID int, latency time.Duration) {
+				mu.Lock()
+				reauths = append(reauths, latency)
+				mu.Unlock()
+			},
+		},
+	})
+	defer func() {
+		cancel()
+		cm.Shutdown(context.Background())
+	}()
+
+	cm.StartClient(ctx, 5)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(cm.RunningClients()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A burst of 401s from one process is one re-auth: it outlives the
+	// stop signal until killed after the grace
+	cm.reauth(5)
+	time.Sleep(10 * time.Millisecond)
+	cm.reauth(5)
+	if !cm.reauthing(5) || cm.reauthing(6) {
+		t.Fatal("reauthing() should be true only for client 5")
+	}
+
+	deadline = time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]time.Duration(nil), reauths...)
+		mu.Unlock()
+		if len(got) > 0 {
+			if len(got) != 1 || got[0] < 10*time.Millisecond {
+				t.Fatalf("re-auths = %v, want one measured from the first 401", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client not restarted after a 401")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if cm.reauthing(5) {
+		t.Error("client still reauthing after its restart")
+	}
+
+	cm.handleStart(5, 102)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reauths) != 1 {
+		t.Error("ordinary restart reported as a re-auth")
+	}
+}
+
+func TestClientManager_Reauth_NotRunning(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{
+		Builder:     &mockProcessBuilder{},
+		ReauthOn401: true,
+	})
+
+	// Nothing was restarted, so nothing is left pending
+	cm.reauth(5)
+	if cm.reauthing(5) {
+		t.Error("client without a process left reauthing")
+	}
+}
+
+func TestClientManager_SessionEnd(t *testing.T) {
+	var ended []time.Duration
+	cm := NewClientManager(ManagerConfig{
+		Builder: &mockProcessBuilder{},
+		Callbacks: ManagerCallbacks{
+			OnClientSessionEnd: func(clientID int, length time.Duration) {
+				ended = append(ended, length)
+			},
+		},
+	})
+
+	// The exit at the end of a session is expected, until the new one
+	// starts
+	cm.handleSessionEnd(3, time.Minute)
+	if !cm.restarting(3) || cm.restarting(4) {
+		t.Fatal("restarting() should be true only for client 3")
+	}
+	if len(ended) != 1 || ended[0] != time.Minute {
+		t.Errorf("sessions ended = %v, want one of 1m", ended)
+	}
+	cm.handleStart(3, 100)
+	if cm.restarting(3) {
+		t.Error("client still restarting after its new session started")
+	}
+}
+
+// sleepProcessBuilder starts processes that run until killed.
+type sleepProcessBuilder struct{ mockProcessBuilder }
+
+func (m *sleepProcessBuilder) BuildCommand(ctx context.Context, clientID int) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "sleep", "30"), nil
+}
+
+// stubbornProcessBuilder starts processes that ignore SIGTERM, so they
+// are only gone once killed.
+type stubbornProcessBuilder struct{ mockProcessBuilder }
+
+func (m *stubbornProcessBuilder) BuildCommand(ctx context.Context, clientID int) (*exec.Cmd, error) {
+	return exec.CommandContext(ctx, "sh", "-c", `trap "" TERM; exec sleep 30`), nil
+}
+
+func TestClientManager_PauseResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cm := NewClientManager(ManagerConfig{
+		Builder: &sleepProcessBuilder{},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	defer func() {
+		cancel()
+		cm.Shutdown(context.Background())
+	}()
+
+	cm.StartClient(ctx, 0)
+	cm.StartClient(ctx, 1)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(cm.RunningClients()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := cm.RunningClients(); len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Fatalf("RunningClients() = %v, want [0 1]", got)
+	}
+
+	if !cm.PauseClient(1) {
+		t.Fatal("PauseClient(1) = false")
+	}
+	if got := cm.RunningClients(); len(got) != 1 || got[0] != 0 {
+		t.Errorf("RunningClients() with 1 paused = %v, want [0]", got)
+	}
+	if cm.PauseClient(7) || cm.ResumeClient(0) {
+		t.Error("pause of an unknown client or resume of a running one succeeded")
+	}
+	if !cm.ResumeClient(1) || len(cm.RunningClients()) != 2 {
+		t.Errorf("after ResumeClient(1): RunningClients() = %v", cm.RunningClients())
+	}
+}
+
+func TestClientManager_TokenTTL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var expiries, reauths int
+	cm := NewClientManager(ManagerConfig{
+		Builder:  &sleepProcessBuilder{},
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		TokenTTL: 50 * time.Millisecond,
+		Callbacks: ManagerCallbacks{
+			OnClientTokenExpired: func(int) {
+				mu.Lock()
+				expiries++
+				mu.Unlock()
+			},
+			OnClientReauth: func(int, time.Duration) {
+				mu.Lock()
+				reauths++
+				mu.Unlock()
+			},
+		},
+	})
+	defer func() {
+		cancel()
+		cm.Shutdown(context.Background())
+	}()
+
+	// Every process start arms the next expiry, so the client keeps
+	// coming back with a new token
+	cm.StartClient(ctx, 0)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		e, r := expiries, reauths
+		mu.Unlock()
+		if r >= 2 {
+			if e < r {
+				t.Errorf("%d re-auths from %d expiries", r, e)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d expiries, %d re-auths; want the client re-authed twice", e, r)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientManager_TokenTTL_Exited(t *testing.T) {
+	expired := make(chan int, 1)
+	cm := NewClientManager(ManagerConfig{
+		Builder:  &mockProcessBuilder{},
+		TokenTTL: 20 * time.Millisecond,
+		Callbacks: ManagerCallbacks{
+			OnClientTokenExpired: func(clientID int) { expired <- clientID },
+		},
+	})
+
+	// A process that exits takes its pending expiry with it
+	cm.handleStart(3, 100)
+	cm.handleExit(3, 1, time.Millisecond)
+	select {
+	case id := <-expired:
+		t.Errorf("token of exited client %d expired", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClientManager_QuarantinedCount(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}})
+
+	cm.handleStateChange(1, supervisor.StateRunning, supervisor.StateQuarantined)
+	cm.handleStateChange(2, supervisor.StateRunning, supervisor.StateQuarantined)
+	if got := cm.QuarantinedCount(); got != 2 {
+		t.Errorf("QuarantinedCount() = %d, want 2", got)
+	}
+
+	cm.handleStateChange(1, supervisor.StateQuarantined, supervisor.StateStarting)
+	cm.handleStateChange(2, supervisor.StateQuarantined, supervisor.StateStopped)
+	if got := cm.QuarantinedCount(); got != 0 {
+		t.Errorf("after leaving quarantine: QuarantinedCount() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// originCompare runs -compare-url and -compare-opt: it points
+// -compare-split percent of the clients at the second origin (B), or has
+// them use B's request options, and reports both cohorts side by side,
+// answering whether B holds up better under the same load.
+type originCompare struct {
+	cm        *ClientManager
+	urls      [2]string  // A (the stream URL), B
+	shares    [2]float64 // Percent of the clients asked for
+	byClient  []int      // Origin index, indexed by client ID
+	clientIDs [2][]int
+
+	opts     *process.RequestOptions // B's request options (nil = shared)
+	optsDesc string                  // As given, e.g. "keepalive=off"
+
+	latest atomic.Pointer[stats.OriginComparison] // As of the last refresh
+}
+
+// newOriginCompare splits clients clients between the cohorts,
+// interleaved so both ramp up together. Returns nil without -compare-url
+// and -compare-opt.
+func newOriginCompare(cfg *config.Config, cm *ClientManager) *originCompare {
+	if cfg.CompareURL == "" && len(cfg.CompareOpts) == 0 {
+		return nil
+	}
+
+	c := &originCompare{
+		cm:     cm,
+		urls:   [2]string{cfg.StreamURL, cfg.CompareURL},
+		shares: [2]float64{float64(100 - cfg.CompareSplit), float64(cfg.CompareSplit)},
+	}
+	if c.urls[1] == "" {
+		c.urls[1] = cfg.StreamURL
+	}
+	if len(cfg.CompareOpts) > 0 {
+		c.opts = compareOptions(cfg.CompareOpts)
+		c.optsDesc = strings.Join(cfg.CompareOpts, ", ")
+	}
+	for clientID, idx := range assignByWeight([]int{100 - cfg.CompareSplit, cfg.CompareSplit}, cfg.Clients) {
+		c.byClient = append(c.byClient, idx)
+		c.clientIDs[idx] = append(c.clientIDs[idx], clientID)
+	}
+	return c
+}
+
+// url returns a client's playlist URL (process.FFmpegConfig.ClientURL).
+func (c *originCompare) url(clientID int) string {
+	if clientID < 0 || clientID >= len(c.byClient) {
+		return ""
+	}
+	return c.urls[c.byClient[clientID]]
+}
+
+// compareOptions converts -compare-opt entries (validated by the config)
+// to request options; later entries win.
+func compareOptions(opts []string) *process.RequestOptions {
+	ro := &process.RequestOptions{}
+	for _, opt := range opts {
+		key, value, err := config.ParseCompareOpt(opt)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "keepalive":
+			noKeepAlive := value == "off"
+			ro.NoKeepAlive = &noKeepAlive
+		case "user-agent":
+			ro.UserAgent = value
+		case "variant":
+			ro.Variant = process.VariantSelection(value)
+		}
+	}
+	return ro
+}
+
+// options returns a client's request options
+// (process.FFmpegConfig.ClientOptions): B's, or nil for the shared ones.
+func (c *originCompare) options(clientID int) *process.RequestOptions {
+	if clientID < 0 || clientID >= len(c.byClient) || c.byClient[clientID] != 1 {
+		return nil
+	}
+	return c.opts
+}
+
+// comparison returns both origins' results over elapsed.
+func (c *originCompare) comparison(elapsed time.Duration) *stats.OriginComparison {
+	result := func(i int, name string) stats.OriginResult {
+		gs := c.cm.GetGroupStats(c.clientIDs[i])
+		return stats.OriginResult{
+			Name:        name,
+			URL:         c.urls[i],
+			Share:       c.shares[i],
+			Clients:     gs.Clients,
+			Requests:    gs.Requests,
+			Errors:      gs.Errors(),
+			Bytes:       gs.Bytes,
+			SegmentP50:  gs.SegmentP50,
+			SegmentP95:  gs.SegmentP95,
+			SegmentP99:  gs.SegmentP99,
+			ManifestP50: gs.ManifestP50,
+			ManifestP99: gs.ManifestP99,
+		}
+	}
+	b := result(1, "B")
+	b.Options = c.optsDesc
+	return &stats.OriginComparison{A: result(0, "A"), B: b, Elapsed: elapsed}
+}
+
+// refresh recomputes the comparison the dashboard shows. Merging every
+// client's digests is too slow for each redraw, so it runs with the
+// metrics update instead.
+func (c *originCompare) refresh(elapsed time.Duration) {
+	c.latest.Store(c.comparison(elapsed))
+}
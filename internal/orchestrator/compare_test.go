@@ -0,0 +1,115 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestOriginCompare(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}, StatsEnabled: true})
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = "http://old/stream.m3u8"
+	cfg.CompareURL = "http://new/stream.m3u8"
+	cfg.CompareSplit = 25
+	cfg.Clients = 8
+	c := newOriginCompare(cfg, cm)
+
+	// 3:1, interleaved
+	if len(c.clientIDs[0]) != 6 || len(c.clientIDs[1]) != 2 {
+		t.Fatalf("clients = %v, want 6 on A and 2 on B", c.clientIDs)
+	}
+	b := c.clientIDs[1][0]
+	if got := c.url(b); got != "http://new/stream.m3u8" {
+		t.Errorf("url(%d) = %q, want B", b, got)
+	}
+	if got := c.url(c.clientIDs[0][0]); got != "http://old/stream.m3u8" {
+		t.Errorf("url(%d) = %q, want A", c.clientIDs[0][0], got)
+	}
+	if got := c.url(8); got != "" {
+		t.Errorf("url(8) = %q, want \"\" for an unknown client", got)
+	}
+
+	// One client started on each origin
+	a := c.clientIDs[0][0]
+	for _, id := range []int{a, b} {
+		cm.clientStats[id] = stats.NewClientStats(id)
+		cm.clientStats[id].SegmentRequests.Add(10)
+	}
+	cm.clientStats[b].RecordHTTPError(503)
+
+	if c.latest.Load() != nil {
+		t.Error("comparison before the first refresh, want nil")
+	}
+	c.refresh(time.Minute)
+	got := c.latest.Load()
+	if got == nil {
+		t.Fatal("no comparison after refresh")
+	}
+	if got.A.Clients != 1 || got.A.Share != 75 || got.A.URL != cfg.StreamURL || got.A.Errors != 0 {
+		t.Errorf("A = %+v", got.A)
+	}
+	if got.B.Clients != 1 || got.B.Share != 25 || got.B.Requests != 10 || got.B.Errors != 1 {
+		t.Errorf("B = %+v, want one client with a 503", got.B)
+	}
+	if got.Elapsed != time.Minute {
+		t.Errorf("Elapsed = %v, want 1m", got.Elapsed)
+	}
+
+	cfg.CompareURL = ""
+	if newOriginCompare(cfg, cm) != nil {
+		t.Error("newOriginCompare() without -compare-url should be nil")
+	}
+	if c.options(b) != nil {
+		t.Error("options() without -compare-opt should be nil")
+	}
+}
+
+func TestOriginCompare_Options(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}, StatsEnabled: true})
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = "http://origin/stream.m3u8"
+	cfg.CompareOpts = []string{"keepalive=off", "user-agent=Safari/17", "variant=first"}
+	cfg.Clients = 4
+	c := newOriginCompare(cfg, cm)
+	if c == nil {
+		t.Fatal("newOriginCompare() with -compare-opt = nil")
+	}
+
+	a, b := c.clientIDs[0][0], c.clientIDs[1][0]
+	if c.url(a) != cfg.StreamURL || c.url(b) != cfg.StreamURL {
+		t.Errorf("url() = %q, %q, want the stream URL for both cohorts", c.url(a), c.url(b))
+	}
+	if c.options(a) != nil {
+		t.Errorf("options(%d) = %+v, want nil for A", a, c.options(a))
+	}
+	opts := c.options(b)
+	if opts == nil || opts.NoKeepAlive == nil || !*opts.NoKeepAlive || opts.UserAgent != "Safari/17" || opts.Variant != process.VariantFirst {
+		t.Errorf("options(%d) = %+v, want B's", b, opts)
+	}
+	if c.options(4) != nil {
+		t.Error("options(4) for an unknown client, want nil")
+	}
+
+	c.refresh(time.Minute)
+	got := c.latest.Load()
+	if got.B.Options != "keepalive=off, user-agent=Safari/17, variant=first" || got.A.Options != "" {
+		t.Errorf("Options = %q, %q", got.A.Options, got.B.Options)
+	}
+	if got.Title() != "Red/Black Comparison" {
+		t.Errorf("Title() = %q", got.Title())
+	}
+}
+
+func TestCompareOptions(t *testing.T) {
+	opts := compareOptions([]string{"keepalive=off", "keepalive=on"})
+	if opts.NoKeepAlive == nil || *opts.NoKeepAlive {
+		t.Errorf("NoKeepAlive = %v, want the later keepalive=on", opts.NoKeepAlive)
+	}
+	if opts.UserAgent != "" || opts.Variant != "" {
+		t.Errorf("unset options changed: %+v", opts)
+	}
+}
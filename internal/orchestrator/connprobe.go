@@ -0,0 +1,153 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// connStep counts the requests of one --conn-probe step.
+type connStep struct {
+	requests  atomic.Int64
+	failures  atomic.Int64
+	latencyNs atomic.Int64 // Sum over the requests that didn't fail
+}
+
+// ProbeConnections finds the origin's connection limit (--conn-probe):
+// workers request the stream URL back to back, each request on a new
+// connection, and every -conn-probe-step-duration -conn-probe-step more
+// workers join. No segments are downloaded, so the origin runs out of
+// connections (or accept queue, or file descriptors) long before
+// bandwidth. The ramp stops at the first step that fails more than
+// stats.ConnLimitMaxFailures of its requests, at -conn-probe-max, or
+// when ctx is cancelled. The summary comes with an ErrEnvironment error
+// if the origin didn't serve even the first step.
+func ProbeConnections(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*stats.ConnLimitSummary, error) {
+	prober := manifest.NewProber(manifest.ProberConfig{
+		Timeout:        cfg.Timeout,
+		UserAgent:      cfg.UserAgent,
+		Headers:        process.NewTemplateValues(0, 0).ExpandHeaders(cfg.Headers),
+		ResolveIP:      cfg.ResolveIP,
+		DangerousMode:  cfg.DangerousMode,
+		AcceptEncoding: cfg.AcceptEncoding,
+		NoCache:        cfg.NoCache,
+		NoKeepAlive:    true,
+	})
+	summary := &stats.ConnLimitSummary{URL: cfg.StreamURL, Failures: make(map[string]int64)}
+	var failuresMu sync.Mutex
+
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		stopWorkers()
+		wg.Wait()
+	}()
+
+	var current atomic.Pointer[connStep]
+	worker := func() {
+		defer wg.Done()
+		for workersCtx.Err() == nil {
+			start := time.Now()
+			status, err := prober.Request(workersCtx, cfg.StreamURL)
+			if workersCtx.Err() != nil {
+				return // Cut short by the probe, not the origin
+			}
+			step := current.Load()
+			step.requests.Add(1)
+			if cause := connFailure(status, err); cause != "" {
+				step.failures.Add(1)
+				failuresMu.Lock()
+				summary.Failures[cause]++
+				failuresMu.Unlock()
+				continue
+			}
+			step.latencyNs.Add(int64(time.Since(start)))
+		}
+	}
+
+	logger.Info("conn_probe_started",
+		"url", cfg.StreamURL,
+		"step", cfg.ConnProbeStep,
+		"max", cfg.ConnProbeMax,
+		"step_duration", cfg.ConnProbeStepDuration.String(),
+	)
+	for conns := cfg.ConnProbeStep; conns <= cfg.ConnProbeMax; conns += cfg.ConnProbeStep {
+		step := &connStep{}
+		current.Store(step)
+		for range cfg.ConnProbeStep {
+			wg.Add(1)
+			go worker()
+		}
+
+		select {
+		case <-time.After(cfg.ConnProbeStepDuration):
+		case <-ctx.Done():
+			summary.Interrupted = true
+			logger.Info("conn_probe_interrupted", "connections", conns)
+			return summary, nil
+		}
+
+		result := stats.ConnLimitStep{
+			Connections: conns,
+			Requests:    step.requests.Load(),
+			Failures:    step.failures.Load(),
+		}
+		if ok := result.Requests - result.Failures; ok > 0 {
+			result.MeanLatency = time.Duration(step.latencyNs.Load() / ok)
+		}
+		logger.Info("conn_probe_step",
+			"connections", conns,
+			"requests", result.Requests,
+			"failures", result.Failures,
+			"mean_latency", result.MeanLatency.String(),
+		)
+		if !summary.Add(result) {
+			break
+		}
+	}
+	logger.Info("conn_probe_finished", "ceiling", summary.Ceiling, "reached", summary.Reached)
+	if summary.Ceiling == 0 {
+		return summary, fmt.Errorf("%w: conn probe: %s served none of %d connections",
+			ErrEnvironment, cfg.StreamURL, cfg.ConnProbeStep)
+	}
+	return summary, nil
+}
+
+// connFailure names why a --conn-probe request counts as refused, or ""
+// if the origin served it. Statuses other than 429 and 503 count as
+// served: the connection was accepted, whatever the answer. Running out
+// of local ports or file descriptors is this host's limit, not the
+// origin's, and is named so.
+func connFailure(status int, err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil && (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable):
+		return fmt.Sprintf("HTTP %d", status)
+	case err == nil:
+		return ""
+	case errors.Is(err, syscall.EADDRNOTAVAIL):
+		return "local ports exhausted"
+	case errors.Is(err, syscall.EMFILE), errors.Is(err, syscall.ENFILE):
+		return "local fd limit"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return "reset"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "other"
+	}
+}
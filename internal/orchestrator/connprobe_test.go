@@ -0,0 +1,116 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+)
+
+func TestProbeConnections(t *testing.T) {
+	// An origin that turns away requests past 20 at once, as a
+	// connection limit would
+	var inFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer inFlight.Add(-1)
+		if inFlight.Add(1) > 20 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+		io.WriteString(w, "#EXTM3U\n")
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = srv.URL + "/live.m3u8"
+	cfg.ConnProbeStep = 10
+	cfg.ConnProbeMax = 60
+	cfg.ConnProbeStepDuration = 200 * time.Millisecond
+	s, err := ProbeConnections(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.Reached || s.Ceiling != 20 {
+		t.Errorf("ceiling = %d (reached %v), want 20 reached; steps %+v", s.Ceiling, s.Reached, s.Steps)
+	}
+	if len(s.Steps) != 3 || s.Steps[0].Requests == 0 || s.Steps[0].MeanLatency < 5*time.Millisecond {
+		t.Errorf("steps = %+v, want 3, the first served", s.Steps)
+	}
+	if s.Failures["HTTP 503"] == 0 {
+		t.Errorf("failures = %v, want HTTP 503s", s.Failures)
+	}
+}
+
+func TestProbeConnections_Interrupted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = srv.URL
+	cfg.ConnProbeStep = 2
+	cfg.ConnProbeStepDuration = 100 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	s, err := ProbeConnections(ctx, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Interrupted || s.Reached || s.Ceiling != 4 {
+		t.Errorf("summary = %+v, want interrupted at least 4", s)
+	}
+}
+
+func TestProbeConnections_NoneServed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = srv.URL
+	cfg.ConnProbeStep = 2
+	cfg.ConnProbeStepDuration = 50 * time.Millisecond
+	s, err := ProbeConnections(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if ExitCode(err) != ExitEnvironment {
+		t.Errorf("error = %v, want an environment failure", err)
+	}
+	if s == nil || !s.Reached || s.Failures["HTTP 503"] == 0 {
+		t.Errorf("summary = %+v, want the refused first step", s)
+	}
+}
+
+func TestConnFailure(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+		want   string
+	}{
+		{200, nil, ""},
+		{404, nil, ""},
+		{503, nil, "HTTP 503"},
+		{429, nil, "HTTP 429"},
+		{0, &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}, "refused"},
+		{0, fmt.Errorf("read: %w", syscall.ECONNRESET), "reset"},
+		{0, fmt.Errorf("dial: %w", syscall.EADDRNOTAVAIL), "local ports exhausted"},
+		{0, fmt.Errorf("socket: %w", syscall.EMFILE), "local fd limit"},
+		{0, context.DeadlineExceeded, "timeout"},
+		{0, errors.New("tls: handshake failure"), "other"},
+	}
+	for _, tt := range tests {
+		if got := connFailure(tt.status, tt.err); got != tt.want {
+			t.Errorf("connFailure(%d, %v) = %q, want %q", tt.status, tt.err, got, tt.want)
+		}
+	}
+}
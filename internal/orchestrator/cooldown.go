@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+const (
+	// coolDownProbeInterval is how often the origin is probed during cool-down.
+	coolDownProbeInterval = time.Second
+
+	// baselineProbes are taken before the ramp; their median is the baseline.
+	baselineProbes = 3
+
+	// The origin counts as recovered once recoveryStreak consecutive probes
+	// are within recoveryFactor of the baseline (or recoveryMargin of it,
+	// whichever is looser: a 5ms origin shouldn't need to hit 7.5ms).
+	recoveryFactor = 1.5
+	recoveryMargin = 20 * time.Millisecond
+	recoveryStreak = 3
+)
+
+// recoveryTracker decides when cool-down probe latency is back to baseline.
+type recoveryTracker struct {
+	threshold time.Duration // 0 = no baseline, recovery can't be judged
+	result    stats.CoolDownSummary
+
+	streak      int
+	streakStart time.Duration // Cool-down elapsed at the first probe of the streak
+}
+
+func newRecoveryTracker(coolDown, baseline time.Duration) *recoveryTracker {
+	t := &recoveryTracker{result: stats.CoolDownSummary{Duration: coolDown, Baseline: baseline}}
+	if baseline > 0 {
+		t.threshold = max(time.Duration(float64(baseline)*recoveryFactor), baseline+recoveryMargin)
+	}
+	return t
+}
+
+// observe records one probe taken elapsed into the cool-down. It returns
+// true the first time the origin is judged recovered.
+func (t *recoveryTracker) observe(elapsed, latency time.Duration, err error) bool {
+	t.result.Probes++
+	if err != nil {
+		t.result.Failures++
+		t.streak = 0
+		return false
+	}
+
+	t.result.Final = latency
+	t.result.Peak = max(t.result.Peak, latency)
+	if t.threshold == 0 || t.result.Recovered {
+		return false
+	}
+
+	if latency > t.threshold {
+		t.streak = 0
+		return false
+	}
+	if t.streak == 0 {
+		t.streakStart = elapsed
+	}
+	t.streak++
+	if t.streak < recoveryStreak {
+		return false
+	}
+	t.result.Recovered = true
+	t.result.RecoveryTime = t.streakStart
+	return true
+}
+
+// probeOrigin times one fetch of the stream's playlist.
+func (o *Orchestrator) probeOrigin(ctx context.Context, prober *manifest.Prober) (time.Duration, error) {
+	start := time.Now()
+	if _, err := prober.Fetch(ctx, o.config.StreamURL); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// measureBaseline probes the origin before any load, for the cool-down
+// recovery comparison. Returns 0 if every probe failed.
+func (o *Orchestrator) measureBaseline(ctx context.Context) time.Duration {
+	prober := o.newProber(ctx)
+	var samples []time.Duration
+	for i := 0; i < baselineProbes; i++ {
+		latency, err := o.probeOrigin(ctx, prober)
+		if err != nil {
+			o.logger.Warn("baseline_probe_failed", "error", err)
+			continue
+		}
+		samples = append(samples, latency)
+	}
+	if len(samples) == 0 {
+		return 0
+	}
+
+	slices.Sort(samples)
+	baseline := samples[len(samples)/2]
+	o.metrics.SetProbeLatency(baseline)
+	o.logger.Info("origin_baseline", "latency", baseline.String(), "probes", len(samples))
+	return baseline
+}
+
+// runCoolDown keeps probing the origin for -cool-down after the clients have
+// stopped, recording how long its latency takes to return to the pre-load
+// baseline. A signal ends it early.
+func (o *Orchestrator) runCoolDown(sigCh <-chan os.Signal, baseline time.Duration) *stats.CoolDownSummary {
+	coolDown := o.config.CoolDown
+	tracker := newRecoveryTracker(coolDown, baseline)
+
+	o.metrics.SetCoolDownRecovery(-time.Second) // Not recovered (yet)
+	o.logger.Info("cool_down_started", "duration", coolDown.String(), "baseline", baseline.String())
+	fmt.Fprintf(o.out, "\nCool-down: clients stopped, probing origin for %s (Ctrl+C to skip)...\n", coolDown)
+
+	ctx, cancel := context.WithTimeout(context.Background(), coolDown)
+	defer cancel()
+
+	prober := o.newProber(ctx)
+	start := time.Now()
+	ticker := time.NewTicker(coolDownProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		elapsed := time.Since(start)
+		probeCtx, probeCancel := context.WithTimeout(ctx, o.config.Timeout)
+		latency, err := o.probeOrigin(probeCtx, prober)
+		probeCancel()
+		if ctx.Err() != nil {
+			break // Cut short by the end of cool-down, not the origin
+		}
+
+		if err != nil {
+			o.logger.Warn("cool_down_probe_failed", "error", err)
+		} else {
+			o.metrics.SetProbeLatency(latency)
+		}
+		if tracker.observe(elapsed, latency, err) {
+			o.metrics.SetCoolDownRecovery(tracker.result.RecoveryTime)
+			o.logger.Info("origin_recovered", "after", tracker.result.RecoveryTime.String(), "latency", latency.String())
+		}
+
+		select {
+		case <-ticker.C:
+			continue
+		case sig := <-sigCh:
+			o.logger.Info("received_signal", "signal", sig.String(), "phase", "cool_down")
+		case <-ctx.Done():
+		}
+		break
+	}
+
+	tracker.result.Elapsed = time.Since(start)
+	o.logger.Info("cool_down_finished",
+		"elapsed", tracker.result.Elapsed.String(),
+		"probes", tracker.result.Probes,
+		"recovered", tracker.result.Recovered,
+	)
+	return &tracker.result
+}
@@ -0,0 +1,143 @@
+package orchestrator
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+func TestRecoveryTracker(t *testing.T) {
+	ms := time.Millisecond
+	tr := newRecoveryTracker(time.Minute, 100*ms) // Threshold 150ms
+
+	probes := []struct {
+		at      time.Duration
+		latency time.Duration
+		err     error
+	}{
+		{0, 900 * ms, nil},
+		{1 * time.Second, 140 * ms, nil},            // Streak starts...
+		{2 * time.Second, 0, errors.New("timeout")}, // ...and breaks
+		{3 * time.Second, 120 * ms, nil},            // New streak
+		{4 * time.Second, 300 * ms, nil},            // Broken again
+		{5 * time.Second, 110 * ms, nil},
+		{6 * time.Second, 105 * ms, nil},
+	}
+	for _, p := range probes {
+		if tr.observe(p.at, p.latency, p.err) {
+			t.Fatalf("recovered early at %v", p.at)
+		}
+	}
+	if !tr.observe(7*time.Second, 100*ms, nil) {
+		t.Fatal("third consecutive fast probe should report recovery")
+	}
+	if tr.observe(8*time.Second, 100*ms, nil) {
+		t.Error("recovery reported twice")
+	}
+
+	r := tr.result
+	if !r.Recovered || r.RecoveryTime != 5*time.Second {
+		t.Errorf("Recovered = %v at %v, want true at 5s (start of the streak)", r.Recovered, r.RecoveryTime)
+	}
+	if r.Probes != 9 || r.Failures != 1 {
+		t.Errorf("Probes = %d, Failures = %d, want 9, 1", r.Probes, r.Failures)
+	}
+	if r.Peak != 900*ms || r.Final != 100*ms {
+		t.Errorf("Peak = %v, Final = %v", r.Peak, r.Final)
+	}
+}
+
+func TestRecoveryTracker_Threshold(t *testing.T) {
+	// Fast origins get an absolute margin rather than a tight ratio
+	if got := newRecoveryTracker(time.Minute, 4*time.Millisecond).threshold; got != 24*time.Millisecond {
+		t.Errorf("threshold(4ms) = %v, want 24ms", got)
+	}
+	if got := newRecoveryTracker(time.Minute, 200*time.Millisecond).threshold; got != 300*time.Millisecond {
+		t.Errorf("threshold(200ms) = %v, want 300ms", got)
+	}
+}
+
+func TestRecoveryTracker_NoBaseline(t *testing.T) {
+	tr := newRecoveryTracker(time.Minute, 0)
+	for i := 0; i < 5; i++ {
+		if tr.observe(time.Duration(i)*time.Second, time.Millisecond, nil) {
+			t.Fatal("recovery cannot be judged without a baseline")
+		}
+	}
+	if tr.result.Recovered || tr.result.Probes != 5 {
+		t.Errorf("result = %+v", tr.result)
+	}
+}
+
+func newCoolDownOrchestrator(t *testing.T, coolDown time.Duration) (*Orchestrator, *atomic.Int64) {
+	var fetches atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		io.WriteString(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXTINF:2.0,\nseg1.ts\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = srv.URL + "/stream.m3u8"
+	cfg.CoolDown = coolDown
+	return &Orchestrator{
+		config:  cfg,
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		out:     io.Discard,
+		metrics: metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 1}, prometheus.NewRegistry()),
+	}, &fetches
+}
+
+func TestMeasureBaseline(t *testing.T) {
+	o, fetches := newCoolDownOrchestrator(t, time.Second)
+
+	if baseline := o.measureBaseline(t.Context()); baseline <= 0 {
+		t.Errorf("baseline = %v, want > 0", baseline)
+	}
+	if n := fetches.Load(); n != baselineProbes {
+		t.Errorf("fetched %d times, want %d", n, baselineProbes)
+	}
+}
+
+func TestRunCoolDown(t *testing.T) {
+	o, fetches := newCoolDownOrchestrator(t, 2500*time.Millisecond)
+
+	// Baseline far above any local fetch: every probe counts as recovered
+	res := o.runCoolDown(make(chan os.Signal), time.Second)
+
+	if res.Elapsed < 2500*time.Millisecond {
+		t.Errorf("Elapsed = %v, want the full cool-down", res.Elapsed)
+	}
+	if res.Probes != 3 || fetches.Load() < 3 {
+		t.Errorf("Probes = %d (fetches %d), want 3 (one per second)", res.Probes, fetches.Load())
+	}
+	if !res.Recovered || res.RecoveryTime > 100*time.Millisecond {
+		t.Errorf("Recovered = %v after %v, want true at the first probe", res.Recovered, res.RecoveryTime)
+	}
+}
+
+func TestRunCoolDown_Signal(t *testing.T) {
+	o, _ := newCoolDownOrchestrator(t, time.Minute)
+
+	sigCh := make(chan os.Signal, 1)
+	sigCh <- os.Interrupt
+	res := o.runCoolDown(sigCh, 0)
+
+	if res.Elapsed >= time.Second || res.Probes != 1 {
+		t.Errorf("Elapsed = %v, Probes = %d: a signal should end cool-down after the current probe", res.Elapsed, res.Probes)
+	}
+	if res.Recovered {
+		t.Error("Recovered without a baseline")
+	}
+}
@@ -0,0 +1,207 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// The guard samples every cpuGuardInterval and flags a client after
+// cpuGuardStrikes samples in a row over the limit, so FFmpeg's start-up
+// probing doesn't count.
+const (
+	cpuGuardInterval = 5 * time.Second
+	cpuGuardStrikes  = 2
+)
+
+// cpuSample is a client process's CPU time at the previous sample.
+type cpuSample struct {
+	pid     int
+	cpu     time.Duration
+	at      time.Time
+	strikes int
+	flagged bool
+}
+
+// cpuGuard runs -client-cpu-limit: it samples each client's CPU use and
+// flags processes that use more than a demuxing FFmpeg could, which
+// happens when something makes it decode: a wrapper script behind -ffmpeg,
+// an FFmpeg build with different defaults. Flagged clients are logged and
+// counted; with -client-cpu-policy fail the run is stopped.
+type cpuGuard struct {
+	limit   float64 // Percent of one core
+	fail    bool
+	pids    func() map[int]int // Client ID -> PID
+	cpuTime func(pid int) (time.Duration, error)
+	metrics *metrics.Collector
+	logger  *slog.Logger
+	onFail  func() // Called once when a client is flagged and fail is set
+
+	prev map[int]*cpuSample // By client ID; used by sample only
+
+	mu        sync.Mutex
+	cpu, wall time.Duration // Totals over all clients
+	peak      float64
+	flagged   int64
+	failed    bool
+}
+
+// newCPUGuard returns the -client-cpu-limit guard. Returns nil if the
+// limit is 0 or process CPU times can't be read here.
+func newCPUGuard(cfg *config.Config, pids func() map[int]int, m *metrics.Collector, logger *slog.Logger) *cpuGuard {
+	if cfg.ClientCPULimit <= 0 {
+		return nil
+	}
+	if _, err := supervisor.ProcessCPUTime(os.Getpid()); err != nil {
+		logger.Warn("client_cpu_guard_disabled", "error", err)
+		return nil
+	}
+	return &cpuGuard{
+		limit:   cfg.ClientCPULimit,
+		fail:    cfg.ClientCPUPolicy == "fail",
+		pids:    pids,
+		cpuTime: supervisor.ProcessCPUTime,
+		metrics: m,
+		logger:  logger,
+		prev:    make(map[int]*cpuSample),
+	}
+}
+
+// run samples every cpuGuardInterval until ctx is cancelled.
+func (g *cpuGuard) run(ctx context.Context) {
+	ticker := time.NewTicker(cpuGuardInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			g.sample(now)
+		}
+	}
+}
+
+// sample measures each client's CPU use since the previous sample.
+func (g *cpuGuard) sample(now time.Time) {
+	pids := g.pids()
+	for id := range g.prev {
+		if _, ok := pids[id]; !ok {
+			delete(g.prev, id)
+		}
+	}
+
+	var sum, max float64
+	var measured int
+	var cpu, wall time.Duration
+	var flagged []int
+	for id, pid := range pids {
+		used, err := g.cpuTime(pid)
+		if err != nil {
+			continue // Exited since
+		}
+		p := g.prev[id]
+		if p == nil || p.pid != pid {
+			// New or restarted process: the next sample measures it
+			g.prev[id] = &cpuSample{pid: pid, cpu: used, at: now}
+			continue
+		}
+
+		elapsed := now.Sub(p.at)
+		if elapsed <= 0 {
+			continue
+		}
+		percent := float64(used-p.cpu) * 100 / float64(elapsed)
+		cpu += used - p.cpu
+		wall += elapsed
+		sum += percent
+		max = maxFloat(max, percent)
+		measured++
+
+		if percent > g.limit {
+			p.strikes++
+		} else {
+			p.strikes = 0
+		}
+		if p.strikes >= cpuGuardStrikes && !p.flagged {
+			p.flagged = true
+			flagged = append(flagged, id)
+			g.logger.Warn("client_cpu_high",
+				"client_id", id,
+				"pid", pid,
+				"cpu_percent", fmt.Sprintf("%.1f", percent),
+				"limit", g.limit,
+				"hint", "FFmpeg seems to be decoding; check the -ffmpeg binary and its defaults",
+			)
+		}
+		p.cpu, p.at = used, now
+	}
+	if measured == 0 {
+		return
+	}
+	g.metrics.RecordClientCPU(sum/float64(measured), max, len(flagged))
+
+	g.mu.Lock()
+	g.cpu += cpu
+	g.wall += wall
+	g.peak = maxFloat(g.peak, max)
+	g.flagged += int64(len(flagged))
+	stop := g.fail && !g.failed && len(flagged) > 0
+	g.failed = g.failed || stop
+	g.mu.Unlock()
+
+	if stop {
+		g.logger.Error("client_cpu_limit_exceeded", "clients", flagged, "policy", "fail")
+		if g.onFail != nil {
+			g.onFail()
+		}
+	}
+}
+
+// maxFloat returns the larger of a and b.
+func maxFloat(a, b float64) float64 {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+// summary describes the run's client CPU use (nil without the guard or
+// before the first measurement).
+func (g *cpuGuard) summary() *stats.ClientCPUSummary {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.wall == 0 {
+		return nil
+	}
+	return &stats.ClientCPUSummary{
+		Limit:   g.limit,
+		Mean:    float64(g.cpu) * 100 / float64(g.wall),
+		Peak:    g.peak,
+		Flagged: g.flagged,
+		Failed:  g.failed,
+	}
+}
+
+// err is the run's error if the guard stopped it.
+func (g *cpuGuard) err() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.failed {
+		return nil
+	}
+	return fmt.Errorf("%w: clients used more than -client-cpu-limit (%.0f%% of a core), FFmpeg is decoding", ErrSafetyAbort, g.limit)
+}
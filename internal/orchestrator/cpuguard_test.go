@@ -0,0 +1,153 @@
+package orchestrator
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+// fakeCPU is a set of client processes whose CPU times tests advance.
+type fakeCPU struct {
+	pids map[int]int           // Client ID -> PID
+	cpu  map[int]time.Duration // By PID
+}
+
+func (f *fakeCPU) clientPIDs() map[int]int { return f.pids }
+
+func (f *fakeCPU) cpuTime(pid int) (time.Duration, error) {
+	cpu, ok := f.cpu[pid]
+	if !ok {
+		return 0, errors.New("no such process")
+	}
+	return cpu, nil
+}
+
+func newTestCPUGuard(limit float64, fail bool, f *fakeCPU) *cpuGuard {
+	return &cpuGuard{
+		limit:   limit,
+		fail:    fail,
+		pids:    f.clientPIDs,
+		cpuTime: f.cpuTime,
+		metrics: metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 2}, prometheus.NewRegistry()),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		prev:    make(map[int]*cpuSample),
+	}
+}
+
+func TestCPUGuard_Sample(t *testing.T) {
+	f := &fakeCPU{
+		pids: map[int]int{0: 100, 1: 101},
+		cpu:  map[int]time.Duration{100: 0, 101: 0},
+	}
+	g := newTestCPUGuard(25, false, f)
+
+	start := time.Now()
+	g.sample(start) // Baseline
+	if s := g.summary(); s != nil {
+		t.Fatalf("summary() after the baseline = %+v, want nil", s)
+	}
+
+	// Client 0 demuxes (5%), client 1 decodes (80%)
+	for i := 1; i <= 3; i++ {
+		f.cpu[100] += 250 * time.Millisecond
+		f.cpu[101] += 4 * time.Second
+		g.sample(start.Add(time.Duration(i) * cpuGuardInterval))
+		if i == 1 && g.flagged != 0 {
+			t.Fatal("flagged after one sample over the limit")
+		}
+	}
+
+	s := g.summary()
+	if s == nil {
+		t.Fatal("summary() = nil")
+	}
+	if s.Flagged != 1 {
+		t.Errorf("Flagged = %d, want 1 (once per client)", s.Flagged)
+	}
+	if s.Mean < 42.4 || s.Mean > 42.6 {
+		t.Errorf("Mean = %.2f, want 42.5", s.Mean)
+	}
+	if s.Peak < 79.9 || s.Peak > 80.1 {
+		t.Errorf("Peak = %.2f, want 80", s.Peak)
+	}
+	if s.Failed || g.err() != nil {
+		t.Errorf("warn policy failed the run: %v", g.err())
+	}
+}
+
+func TestCPUGuard_Restart(t *testing.T) {
+	f := &fakeCPU{
+		pids: map[int]int{0: 100},
+		cpu:  map[int]time.Duration{100: 0},
+	}
+	g := newTestCPUGuard(25, false, f)
+
+	start := time.Now()
+	g.sample(start)
+	f.cpu[100] = 4 * time.Second
+	g.sample(start.Add(cpuGuardInterval)) // Strike one
+
+	// Restarted: the new process's CPU time isn't a delta of the old one's
+	f.pids[0] = 200
+	f.cpu[200] = 10 * time.Second
+	g.sample(start.Add(2 * cpuGuardInterval))
+	if g.prev[0].pid != 200 || g.prev[0].strikes != 0 {
+		t.Errorf("after restart prev = %+v, want a fresh baseline for pid 200", g.prev[0])
+	}
+
+	// Gone: forgotten
+	delete(f.pids, 0)
+	g.sample(start.Add(3 * cpuGuardInterval))
+	if len(g.prev) != 0 {
+		t.Errorf("prev = %v, want empty", g.prev)
+	}
+}
+
+func TestCPUGuard_FailPolicy(t *testing.T) {
+	f := &fakeCPU{
+		pids: map[int]int{0: 100, 1: 101},
+		cpu:  map[int]time.Duration{100: 0, 101: 0},
+	}
+	g := newTestCPUGuard(25, true, f)
+	stops := 0
+	g.onFail = func() { stops++ }
+
+	start := time.Now()
+	g.sample(start)
+	for i := 1; i <= 4; i++ {
+		f.cpu[100] += 4 * time.Second
+		f.cpu[101] += 4 * time.Second
+		g.sample(start.Add(time.Duration(i) * cpuGuardInterval))
+	}
+
+	if stops != 1 {
+		t.Errorf("onFail called %d times, want 1", stops)
+	}
+	if s := g.summary(); !s.Failed || s.Flagged != 2 {
+		t.Errorf("summary() = %+v, want Failed with 2 flagged", s)
+	}
+	if err := g.err(); !errors.Is(err, ErrSafetyAbort) {
+		t.Errorf("err() = %v, want the run to fail with ErrSafetyAbort", err)
+	}
+}
+
+func TestNewCPUGuard_Disabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ClientCPULimit = 0
+	if g := newCPUGuard(cfg, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil))); g != nil {
+		t.Error("newCPUGuard() with no limit should return nil")
+	}
+
+	// A nil guard reports nothing
+	var g *cpuGuard
+	if g.summary() != nil || g.err() != nil {
+		t.Error("nil guard should have no summary and no error")
+	}
+}
@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"log/slog"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+)
+
+// debugSample runs -debug-sample: a random few percent of the clients run
+// FFmpeg at debug loglevel with every parsed event logged, the rest at
+// verbose. The sample gives full traces (segment timings, playlist
+// refreshes, connects) without parsing debug output from every client.
+type debugSample struct {
+	clients map[int]bool
+	ids     []int // Sorted, for the log
+}
+
+// newDebugSample picks the sample from the cfg.Clients initial clients:
+// DebugSample percent of them, at least one. Clients added later by
+// scaling up run lean. Returns nil without -debug-sample.
+func newDebugSample(cfg *config.Config) *debugSample {
+	if cfg.DebugSample <= 0 || cfg.Clients <= 0 {
+		return nil
+	}
+	n := int(math.Round(float64(cfg.Clients) * cfg.DebugSample / 100))
+	n = min(max(n, 1), cfg.Clients)
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	d := &debugSample{clients: make(map[int]bool, n)}
+	for _, id := range rng.Perm(cfg.Clients)[:n] {
+		d.clients[id] = true
+		d.ids = append(d.ids, id)
+	}
+	slices.Sort(d.ids)
+	return d
+}
+
+// traced reports whether a client is in the sample
+// (process.FFmpegConfig.ClientDebug and ManagerConfig.TraceClient).
+func (d *debugSample) traced(clientID int) bool {
+	return d.clients[clientID]
+}
+
+// logTraceEvent logs one parsed debug event of a sampled client, with the
+// fields its type sets.
+func logTraceEvent(logger *slog.Logger, clientID int, e *parser.DebugEvent) {
+	attrs := append([]any{"client_id", clientID, "event", e.Type.String()}, debugEventAttrs(e)...)
+	logger.Info("client_trace", attrs...)
+}
+
+// debugEventAttrs returns the fields a debug event's type sets, as slog
+// key-value pairs.
+func debugEventAttrs(e *parser.DebugEvent) []any {
+	var attrs []any
+	if !e.Timestamp.IsZero() {
+		attrs = append(attrs, "ts", e.Timestamp)
+	}
+	if e.URL != "" {
+		attrs = append(attrs, "url", e.URL)
+	}
+	if e.IP != "" {
+		attrs = append(attrs, "ip", e.IP, "port", e.Port)
+	}
+	if e.HTTPCode != 0 {
+		attrs = append(attrs, "http_code", e.HTTPCode)
+	}
+	if e.FailReason != "" {
+		attrs = append(attrs, "reason", e.FailReason)
+	}
+	if e.Cause != "" {
+		attrs = append(attrs, "cause", e.Cause)
+	}
+	if e.ErrorMsg != "" {
+		attrs = append(attrs, "error", e.ErrorMsg)
+	}
+	if e.Type == parser.DebugEventSequenceChange {
+		attrs = append(attrs, "old_seq", e.OldSeq, "new_seq", e.NewSeq)
+	}
+	if e.SegmentID != 0 {
+		attrs = append(attrs, "segment_id", e.SegmentID)
+	}
+	if e.SkipCount != 0 {
+		attrs = append(attrs, "skipped", e.SkipCount)
+	}
+	if e.Bandwidth != 0 {
+		attrs = append(attrs, "bandwidth", e.Bandwidth)
+	}
+	if e.Bytes != 0 {
+		attrs = append(attrs, "bytes", e.Bytes)
+	}
+	if e.Marker != "" {
+		attrs = append(attrs, "marker", e.Marker)
+	}
+	if e.WallTime != 0 {
+		attrs = append(attrs, "kind", e.Kind, "wall_time", e.WallTime.String())
+	}
+	return attrs
+}
@@ -0,0 +1,89 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+)
+
+func TestNewDebugSample(t *testing.T) {
+	tests := []struct {
+		clients int
+		percent float64
+		want    int // Clients sampled (-1 = no sample)
+	}{
+		{100, 0, -1},
+		{100, 1, 1},
+		{1000, 1, 10},
+		{10, 1, 1}, // At least one
+		{10, 50, 5},
+		{10, 100, 10},
+	}
+	for _, tt := range tests {
+		cfg := config.DefaultConfig()
+		cfg.Clients = tt.clients
+		cfg.DebugSample = tt.percent
+		d := newDebugSample(cfg)
+		if tt.want < 0 {
+			if d != nil {
+				t.Errorf("%d clients, %v%%: sample %v, want none", tt.clients, tt.percent, d.ids)
+			}
+			continue
+		}
+		if d == nil || len(d.ids) != tt.want {
+			t.Errorf("%d clients, %v%%: got %v, want %d clients", tt.clients, tt.percent, d, tt.want)
+			continue
+		}
+		traced := 0
+		for id := range tt.clients {
+			if d.traced(id) {
+				traced++
+			}
+		}
+		if traced != tt.want || d.traced(tt.clients) {
+			t.Errorf("%d clients, %v%%: %d traced, want %d of the initial clients", tt.clients, tt.percent, traced, tt.want)
+		}
+	}
+}
+
+func TestClientManager_TraceClient(t *testing.T) {
+	var buf bytes.Buffer
+	cm := NewClientManager(ManagerConfig{
+		Builder:      &mockProcessBuilder{},
+		Logger:       slog.New(slog.NewJSONHandler(&buf, nil)),
+		StatsEnabled: true,
+		TraceClient:  func(clientID int) bool { return clientID == 3 },
+	})
+
+	ts := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := &parser.DebugEvent{Type: parser.DebugEventHTTPError, Timestamp: ts, URL: "http://origin/seg1.ts", HTTPCode: 503}
+	cm.createDebugEventCallback(2, nil)(event)
+	if strings.Contains(buf.String(), "client_trace") {
+		t.Errorf("untraced client logged:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	cm.createDebugEventCallback(3, nil)(event)
+	var got map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if json.Unmarshal([]byte(line), &rec) == nil && rec["msg"] == "client_trace" {
+			got = rec
+		}
+	}
+	if got == nil {
+		t.Fatalf("no client_trace for a traced client:\n%s", buf.String())
+	}
+	if got["client_id"] != 3.0 || got["event"] != "http_error" || got["url"] != "http://origin/seg1.ts" || got["http_code"] != 503.0 {
+		t.Errorf("client_trace = %v", got)
+	}
+	if _, ok := got["segment_id"]; ok {
+		t.Errorf("client_trace logs unset fields: %v", got)
+	}
+}
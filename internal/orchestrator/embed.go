@@ -0,0 +1,44 @@
+package orchestrator
+
+import "io"
+
+// SetOutput sets where Run writes its human-readable output: preflight
+// results, the load estimate, start-time waits and the exit summary
+// (default os.Stdout). pkg/swarm uses it to keep an embedding program's
+// stdout clean.
+func (o *Orchestrator) SetOutput(w io.Writer) {
+	o.out = w
+}
+
+// DisableSignals leaves SIGINT and SIGTERM to the embedding program: Run
+// then stops only when its context is cancelled or -duration elapses.
+func (o *Orchestrator) DisableSignals() {
+	o.noSignals = true
+}
+
+// Started is closed once Run has finished its setup (preflight, probes,
+// metrics server) and begins the ramp.
+func (o *Orchestrator) Started() <-chan struct{} {
+	return o.started
+}
+
+// TargetClients returns the number of clients wanted now: -clients, or
+// the last Scale.
+func (o *Orchestrator) TargetClients() int {
+	return o.scaleTarget()
+}
+
+// RunningClients returns the number of clients the ramp has started and
+// not scaled away. Restarting clients count: this is the swarm's size,
+// not ClientManager.ActiveCount.
+func (o *Orchestrator) RunningClients() int {
+	o.scale.mu.Lock()
+	defer o.scale.mu.Unlock()
+	return o.scale.started
+}
+
+// MetricsAddrs returns the addresses /metrics is served on, bound ones
+// once Run (or StartMetricsServer) has started the server.
+func (o *Orchestrator) MetricsAddrs() []string {
+	return o.metricsServer.Addrs()
+}
@@ -0,0 +1,93 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// Run's errors, wrapped with the details: branch on them with errors.Is.
+// A run can end with several (errors.Join), e.g. a missed -slo and a slow
+// drain.
+var (
+	// ErrMaxRestarts: clients stopped for good after MaxRestarts.
+	ErrMaxRestarts = supervisor.ErrMaxRestarts
+
+	// ErrBuildFailed: a client's FFmpeg command couldn't be built (with
+	// ErrMaxRestarts, when that is why the client gave up).
+	ErrBuildFailed = supervisor.ErrBuildFailed
+
+	// ErrDrainTimeout: clients were still stopping when the shutdown
+	// timeout ran out, so the summary may miss their last output.
+	ErrDrainTimeout = errors.New("drain timeout")
+
+	// ErrThresholdViolated: the run finished, but missed its -slo
+	// objective.
+	ErrThresholdViolated = errors.New("threshold violated")
+
+	// ErrSafetyAbort: a guard stopped the run early (-client-cpu-limit).
+	ErrSafetyAbort = errors.New("safety abort")
+
+	// ErrConfig: the configuration can't work, such as FFmpeg options
+	// that decode, or a -variant-mix for a stream without variants.
+	ErrConfig = errors.New("configuration error")
+
+	// ErrEnvironment: the run couldn't start here: preflight, the FFmpeg
+	// binary, the metrics listener, or an origin that can't be probed.
+	ErrEnvironment = errors.New("environment failure")
+)
+
+// The command's exit codes, by failure class, for CI pipelines (see
+// ExitCode).
+const (
+	ExitOK          = 0
+	ExitFailure     = 1 // Any other error
+	ExitThreshold   = 2
+	ExitSafetyAbort = 3
+	ExitConfig      = 4
+	ExitEnvironment = 5
+)
+
+// ExitCode returns the exit code for Run's error. A run failing several
+// ways gets the code of the first class in order config, environment,
+// safety abort, threshold: the cause rather than its consequences (a
+// stopped run misses its SLO too).
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrConfig):
+		return ExitConfig
+	case errors.Is(err, ErrEnvironment):
+		return ExitEnvironment
+	case errors.Is(err, ErrSafetyAbort):
+		return ExitSafetyAbort
+	case errors.Is(err, ErrThresholdViolated):
+		return ExitThreshold
+	default:
+		return ExitFailure
+	}
+}
+
+// gaveUpErr returns an ErrMaxRestarts error if clients stopped for good
+// after MaxRestarts, or nil.
+func (o *Orchestrator) gaveUpErr() error {
+	n, err := o.clientManager.GaveUp()
+	if n == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d clients stopped restarting, first %w", n, err)
+}
+
+// sloErr returns an ErrThresholdViolated error if the run missed its -slo
+// objective (as of the exit summary's final update), or nil.
+func (o *Orchestrator) sloErr() error {
+	if o.slo == nil {
+		return nil
+	}
+	if s := o.slo.status(); !s.Met() {
+		return fmt.Errorf("%w: -slo missed, %s", ErrThresholdViolated, s)
+	}
+	return nil
+}
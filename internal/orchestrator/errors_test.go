@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// failingProcessBuilder can't build a command.
+type failingProcessBuilder struct{ mockProcessBuilder }
+
+func (m *failingProcessBuilder) BuildCommand(ctx context.Context, clientID int) (*exec.Cmd, error) {
+	return nil, errors.New("no token")
+}
+
+func TestGaveUpErr(t *testing.T) {
+	o := newScaleOrchestrator(2)
+	o.clientManager = NewClientManager(ManagerConfig{
+		Builder:       &failingProcessBuilder{},
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		BackoffConfig: supervisor.BackoffConfig{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1},
+		MaxRestarts:   1,
+	})
+	if err := o.gaveUpErr(); err != nil {
+		t.Fatalf("gaveUpErr() = %v before any client ran", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.clientManager.StartClient(ctx, 0)
+	o.clientManager.StartClient(ctx, 1)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	if err := o.clientManager.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("clients didn't give up: %v", err)
+	}
+
+	err := o.gaveUpErr()
+	if !errors.Is(err, ErrMaxRestarts) || !errors.Is(err, ErrBuildFailed) {
+		t.Errorf("gaveUpErr() = %v, want ErrMaxRestarts and ErrBuildFailed", err)
+	}
+	if n, _ := o.clientManager.GaveUp(); n != 2 {
+		t.Errorf("GaveUp() = %d clients, want 2", n)
+	}
+}
+
+func TestSLOErr(t *testing.T) {
+	o := newScaleOrchestrator(1)
+	if err := o.sloErr(); err != nil {
+		t.Errorf("sloErr() = %v without -slo", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.SLO = "99:1s"
+	o.slo = newSLOTracker(cfg, o.logger)
+	now := time.Now()
+	o.slo.update(now, 1000, 5)
+	if err := o.sloErr(); err != nil {
+		t.Errorf("sloErr() = %v with 99.5%% under 1s", err)
+	}
+
+	o.slo.update(now.Add(time.Minute), 2000, 50)
+	if err := o.sloErr(); !errors.Is(err, ErrThresholdViolated) {
+		t.Errorf("sloErr() = %v with 97.5%% under 1s, want ErrThresholdViolated", err)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"clean run", nil, ExitOK},
+		{"unclassified", errors.New("boom"), ExitFailure},
+		{"max restarts", fmt.Errorf("2 clients stopped restarting, first %w", ErrMaxRestarts), ExitFailure},
+		{"slo missed", fmt.Errorf("%w: -slo missed", ErrThresholdViolated), ExitThreshold},
+		{"cpu guard", fmt.Errorf("%w: clients used more than -client-cpu-limit", ErrSafetyAbort), ExitSafetyAbort},
+		{"decoding ffmpeg", fmt.Errorf("refusing to start: %w: stream copy", ErrConfig), ExitConfig},
+		{"preflight", fmt.Errorf("%w: preflight checks failed", ErrEnvironment), ExitEnvironment},
+		{"abort beats its slo miss", errors.Join(
+			fmt.Errorf("%w: cpu", ErrSafetyAbort),
+			fmt.Errorf("%w: slo", ErrThresholdViolated),
+		), ExitSafetyAbort},
+		{"slo miss beats a slow drain", errors.Join(
+			fmt.Errorf("%w: slo", ErrThresholdViolated),
+			fmt.Errorf("%w: clients still stopping", ErrDrainTimeout),
+		), ExitThreshold},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package orchestrator
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/eventsink"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/logging"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+)
+
+// eventSinkCloseTimeout bounds sending the events still buffered at exit.
+const eventSinkCloseTimeout = 10 * time.Second
+
+// setupEventSink starts streaming to -event-sink. The instance label
+// defaults to the hostname, as for -pushgateway-url, so the streams of
+// several load generators can be told apart.
+func (o *Orchestrator) setupEventSink() {
+	labels := metrics.ParsePushLabels(o.config.EventSinkLabels)
+	if _, ok := labels["instance"]; !ok {
+		if host, err := os.Hostname(); err == nil {
+			labels["instance"] = host
+		}
+	}
+	sink, err := eventsink.New(o.config.EventSink, o.config.EventSinkURL, o.config.EventSinkTopic, labels, &http.Client{})
+	if err != nil {
+		o.logger.Warn("event_sink_disabled", "error", err) // Validate rejects unknown sinks
+		return
+	}
+	o.sink = eventsink.NewStreamer(sink, eventsink.StreamerConfig{}, o.logger)
+	o.phases.sink = o.sink
+	o.logger.Info("event_sink", "type", o.config.EventSink, "url", o.config.EventSinkURL, "debug_events", o.config.EventSinkDebug)
+}
+
+// closeEventSink sends the events still buffered and logs how many were
+// streamed.
+func (o *Orchestrator) closeEventSink() {
+	if o.sink == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), eventSinkCloseTimeout)
+	defer cancel()
+	if err := o.sink.Close(ctx); err != nil {
+		o.logger.Warn("event_sink_close_incomplete", "error", err)
+	}
+	sent, dropped, failed := o.sink.Stats()
+	o.logger.Info("event_sink_closed", "sent", sent, "dropped", dropped, "failed", failed)
+}
+
+// emitLifecycle streams a lifecycle event of a client (clientID -1: of
+// the swarm) to -event-sink, if set.
+func (o *Orchestrator) emitLifecycle(name string, clientID int, fields map[string]any) {
+	if o.sink != nil {
+		o.sink.Emit(sinkEvent(o.phases.run, eventsink.KindLifecycle, name, clientID, time.Now(), fields))
+	}
+}
+
+// onDebugEvent streams a client's parsed debug event to -event-sink
+// (ManagerCallbacks.OnDebugEvent, set with -event-sink-debug).
+func (o *Orchestrator) onDebugEvent(clientID int, e *parser.DebugEvent) {
+	attrs := debugEventAttrs(e)
+	fields := make(map[string]any, len(attrs)/2)
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key := attrs[i].(string)
+		switch key {
+		case "ts":
+			continue // The event's time
+		case "kind":
+			key = "request_kind" // Segment or manifest; kind is the event's
+		}
+		fields[key] = attrs[i+1]
+	}
+	ts := e.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	o.sink.Emit(sinkEvent(o.phases.run, eventsink.KindDebug, e.Type.String(), clientID, ts, fields))
+}
+
+// sinkEvent returns an event for -event-sink, carrying the run ID (if
+// run is set) as the log records do.
+func sinkEvent(run *logging.Run, kind, name string, clientID int, ts time.Time, fields map[string]any) eventsink.Event {
+	if run != nil {
+		if fields == nil {
+			fields = make(map[string]any, 1)
+		}
+		fields["run_id"] = run.ID
+	}
+	return eventsink.Event{Time: ts, Kind: kind, Name: name, ClientID: clientID, Fields: fields}
+}
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/eventsink"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/logging"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+)
+
+// sinkRecorder is an event sink keeping what it's sent.
+type sinkRecorder struct {
+	mu     sync.Mutex
+	events []eventsink.Event
+}
+
+func (r *sinkRecorder) Send(ctx context.Context, events []eventsink.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, events...)
+	return nil
+}
+
+// named returns the recorded events called name.
+func (r *sinkRecorder) named(name string) []eventsink.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []eventsink.Event
+	for _, ev := range r.events {
+		if ev.Name == name {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func TestOnDebugEvent(t *testing.T) {
+	o := newScaleOrchestrator(1)
+	rec := &sinkRecorder{}
+	o.sink = eventsink.NewStreamer(rec, eventsink.StreamerConfig{}, o.logger)
+	o.phases.run = &logging.Run{ID: "run-1"}
+
+	ts := time.Date(2026, 1, 15, 9, 30, 0, 0, time.UTC)
+	o.onDebugEvent(4, &parser.DebugEvent{
+		Type:      parser.DebugEventSlowRequest,
+		Timestamp: ts,
+		URL:       "http://origin/seg_7.ts",
+		Kind:      parser.SlowRequestSegment,
+		WallTime:  1500 * time.Millisecond,
+	})
+	o.closeEventSink()
+
+	got := rec.named("slow_request")
+	if len(got) != 1 {
+		t.Fatalf("got %d slow_request events, want 1", len(got))
+	}
+	ev := got[0]
+	if ev.Kind != eventsink.KindDebug || ev.ClientID != 4 || !ev.Time.Equal(ts) {
+		t.Errorf("event = %+v, want a debug event of client 4 at %s", ev, ts)
+	}
+	want := map[string]any{
+		"url":          "http://origin/seg_7.ts",
+		"request_kind": "segment",
+		"wall_time":    "1.5s",
+		"run_id":       "run-1",
+	}
+	for k, v := range want {
+		if ev.Fields[k] != v {
+			t.Errorf("field %s = %v, want %v", k, ev.Fields[k], v)
+		}
+	}
+	if _, ok := ev.Fields["ts"]; ok {
+		t.Error("ts duplicated in the fields")
+	}
+}
+
+func TestEventSink_Lifecycle(t *testing.T) {
+	o := newScaleOrchestrator(2)
+	rec := &sinkRecorder{}
+	o.sink = eventsink.NewStreamer(rec, eventsink.StreamerConfig{}, o.logger)
+	o.phases.sink = o.sink
+	o.clientManager = NewClientManager(ManagerConfig{
+		Builder: &sleepProcessBuilder{},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Callbacks: ManagerCallbacks{
+			OnClientStart: o.onStart,
+			OnClientExit:  o.onExit,
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.scale.ctx, o.scale.ramping = ctx, true
+	o.phases.set(PhaseRamping, 2)
+	o.rampUp(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(o.clientManager.RunningClients()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	o.stopping.Store(true)
+	cancel()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer shutdownCancel()
+	o.clientManager.Shutdown(shutdownCtx)
+	o.closeEventSink()
+
+	if n := len(rec.named("client_start")); n != 2 {
+		t.Errorf("%d client_start events, want 2", n)
+	}
+	exits := rec.named("client_exit")
+	if len(exits) != 2 {
+		t.Fatalf("%d client_exit events, want 2", len(exits))
+	}
+	if exits[0].Kind != eventsink.KindLifecycle || exits[0].Fields["reason"] == "" {
+		t.Errorf("client_exit = %+v, want a lifecycle event with the exit reason", exits[0])
+	}
+	phases := rec.named("phase")
+	if len(phases) == 0 || phases[0].ClientID != -1 || phases[0].Fields["phase"] != "ramping" {
+		t.Errorf("phase events = %+v, want ramping, swarm-wide", phases)
+	}
+}
@@ -0,0 +1,210 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// Catch-up after a flap is watched for at most flapCatchUpTimeout, polling
+// every flapCatchUpPoll.
+const (
+	flapCatchUpTimeout = 60 * time.Second
+	flapCatchUpPoll    = 100 * time.Millisecond
+)
+
+// flapTarget is what the flapper needs of the ClientManager.
+type flapTarget interface {
+	RunningClients() []int
+	PauseClient(clientID int) bool
+	ResumeClient(clientID int) bool
+	GetClientDebugStats(clientID int) *parser.DebugStats
+}
+
+// flapper runs -flap-interval: every interval it pauses a few random
+// running clients for the flap duration, as if their network had dropped
+// out, then watches how they catch up: how long until their next segment,
+// and how many segments they skipped because the live window had moved on.
+//
+// FFmpeg has no way to drop its connections on request, so a flap stops
+// the whole process (SIGSTOP). Its sockets stay open but unread, which the
+// origin sees as a stalled client; on SIGCONT FFmpeg finds its playlist
+// out of date, exactly as after a real outage.
+type flapper struct {
+	target   flapTarget
+	metrics  *metrics.Collector
+	logger   *slog.Logger
+	interval time.Duration
+	duration time.Duration
+	clients  int
+	timeout  time.Duration // Catch-up watched for
+	rng      *rand.Rand    // Used by run only
+
+	mu     sync.Mutex
+	flaps  int64 // Flaps that paused at least one client
+	paused int   // Clients paused right now
+}
+
+// newFlapper returns the -flap-interval flapper. Returns nil without it.
+func newFlapper(cfg *config.Config, target flapTarget, m *metrics.Collector, logger *slog.Logger) *flapper {
+	if cfg.FlapInterval <= 0 {
+		return nil
+	}
+	return &flapper{
+		target:   target,
+		metrics:  m,
+		logger:   logger,
+		interval: cfg.FlapInterval,
+		duration: cfg.FlapDuration,
+		clients:  cfg.FlapClients,
+		timeout:  flapCatchUpTimeout,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// run starts a flap every interval until ctx is cancelled. Paused clients
+// are resumed before it returns.
+func (f *flapper) run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ids := f.pick()
+			if len(ids) == 0 {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f.flap(ctx, ids)
+			}()
+		}
+	}
+}
+
+// pick returns up to f.clients random running clients.
+func (f *flapper) pick() []int {
+	running := f.target.RunningClients()
+	f.rng.Shuffle(len(running), func(i, j int) { running[i], running[j] = running[j], running[i] })
+	return running[:min(f.clients, len(running))]
+}
+
+// flapBaseline is a paused client's counters when it was paused.
+type flapBaseline struct {
+	segments, sequenceSkips, expired, skipped int64
+}
+
+// flap pauses the clients for the flap duration, resumes them and records
+// how each caught up.
+func (f *flapper) flap(ctx context.Context, ids []int) {
+	before := make(map[int]flapBaseline, len(ids))
+	for _, id := range ids {
+		ds := f.target.GetClientDebugStats(id)
+		if ds == nil || !f.target.PauseClient(id) {
+			continue
+		}
+		before[id] = flapBaseline{ds.SegmentCount, ds.SequenceSkips, ds.SegmentsExpiredSum, ds.SegmentSkippedCount}
+	}
+	if len(before) == 0 {
+		return
+	}
+	f.addPaused(len(before), true)
+	f.logger.Info("flap_started", "clients", len(before), "duration", f.duration.String())
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(f.duration):
+	}
+	resumed := time.Now()
+	for id := range before {
+		f.target.ResumeClient(id)
+	}
+	f.addPaused(-len(before), false)
+	if ctx.Err() != nil {
+		return // Shutting down: nothing to catch up with
+	}
+
+	// Wait for each client's next segment
+	pending := make(map[int]flapBaseline, len(before))
+	for id, b := range before {
+		pending[id] = b
+	}
+	catchUp := make(map[int]time.Duration, len(before))
+	ticker := time.NewTicker(flapCatchUpPoll)
+	defer ticker.Stop()
+	deadline := time.After(f.timeout)
+wait:
+	for len(pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			break wait
+		case <-ticker.C:
+		}
+		for id, b := range pending {
+			if ds := f.target.GetClientDebugStats(id); ds != nil && ds.SegmentCount > b.segments {
+				catchUp[id] = time.Since(resumed)
+				delete(pending, id)
+			}
+		}
+	}
+
+	for id, b := range before {
+		r := metrics.FlapResult{CatchUp: catchUp[id]}
+		_, r.Recovered = catchUp[id]
+		if ds := f.target.GetClientDebugStats(id); ds != nil {
+			r.SequenceSkips = max(0, ds.SequenceSkips-b.sequenceSkips)
+			r.SegmentsExpired = max(0, ds.SegmentsExpiredSum-b.expired)
+			r.SegmentsSkipped = max(0, ds.SegmentSkippedCount-b.skipped)
+		}
+		f.metrics.RecordFlapResult(r)
+		if !r.Recovered {
+			f.logger.Warn("flap_not_recovered", "client_id", id, "timeout", f.timeout.String())
+		}
+	}
+}
+
+// addPaused adjusts the paused client count, counting a new flap if
+// started.
+func (f *flapper) addPaused(n int, started bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if started {
+		f.flaps++
+	}
+	f.paused += n
+	f.metrics.SetFlapPaused(f.paused)
+}
+
+// summary returns the -flap-interval exit summary section.
+func (f *flapper) summary(s *metrics.Summary) *stats.FlapSummary {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &stats.FlapSummary{
+		Interval:        f.interval,
+		Duration:        f.duration,
+		Flaps:           f.flaps,
+		Pauses:          s.FlapPauses,
+		Unrecovered:     s.FlapUnrecovered,
+		CatchUpP50:      s.FlapCatchUpP50,
+		CatchUpP95:      s.FlapCatchUpP95,
+		CatchUpP99:      s.FlapCatchUpP99,
+		SequenceSkips:   s.FlapSequenceSkips,
+		SegmentsExpired: s.FlapSegmentsExpired,
+		SegmentsSkipped: s.FlapSegmentsSkipped,
+	}
+}
@@ -0,0 +1,169 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+)
+
+// fakeFlapTarget is a flapTarget whose paused clients download nothing;
+// resumed ones catch up unless stuck.
+type fakeFlapTarget struct {
+	mu      sync.Mutex
+	running []int
+	paused  map[int]bool
+	stuck   map[int]bool // Never download again
+	stats   map[int]*parser.DebugStats
+	pauses  int
+}
+
+func newFakeFlapTarget(ids ...int) *fakeFlapTarget {
+	f := &fakeFlapTarget{
+		running: ids,
+		paused:  make(map[int]bool),
+		stuck:   make(map[int]bool),
+		stats:   make(map[int]*parser.DebugStats),
+	}
+	for _, id := range ids {
+		f.stats[id] = &parser.DebugStats{SegmentCount: 10}
+	}
+	return f
+}
+
+func (f *fakeFlapTarget) RunningClients() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var ids []int
+	for _, id := range f.running {
+		if !f.paused[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func (f *fakeFlapTarget) PauseClient(id int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.paused[id] {
+		return false
+	}
+	f.paused[id] = true
+	f.pauses++
+	return true
+}
+
+func (f *fakeFlapTarget) ResumeClient(id int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.paused[id] {
+		return false
+	}
+	delete(f.paused, id)
+	if !f.stuck[id] {
+		// Catches up by skipping ahead of the expired segments
+		ds := f.stats[id]
+		ds.SegmentCount++
+		ds.SequenceSkips++
+		ds.SegmentsExpiredSum += 3
+	}
+	return true
+}
+
+func (f *fakeFlapTarget) GetClientDebugStats(id int) *parser.DebugStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ds, ok := f.stats[id]; ok {
+		snapshot := *ds
+		return &snapshot
+	}
+	return nil
+}
+
+func newTestFlapper(t *testing.T, target flapTarget, clients int) (*flapper, *metrics.Collector) {
+	t.Helper()
+	m := metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 10}, prometheus.NewRegistry())
+	f := newFlapper(&config.Config{
+		FlapInterval: 20 * time.Millisecond,
+		FlapDuration: 50 * time.Millisecond,
+		FlapClients:  clients,
+	}, target, m, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	f.timeout = 300 * time.Millisecond
+	return f, m
+}
+
+func TestFlapper_Flap(t *testing.T) {
+	target := newFakeFlapTarget(0, 1, 2)
+	target.stuck[2] = true
+	f, m := newTestFlapper(t, target, 3)
+
+	f.flap(context.Background(), []int{0, 1, 2})
+
+	if len(target.paused) != 0 {
+		t.Errorf("paused after flap = %v, want all resumed", target.paused)
+	}
+	s := m.GenerateSummary()
+	if s.FlapPauses != 3 || s.FlapUnrecovered != 1 {
+		t.Errorf("pauses = %d, unrecovered = %d; want 3, 1", s.FlapPauses, s.FlapUnrecovered)
+	}
+	if s.FlapSequenceSkips != 2 || s.FlapSegmentsExpired != 6 {
+		t.Errorf("sequence skips = %d, expired = %d; want 2, 6", s.FlapSequenceSkips, s.FlapSegmentsExpired)
+	}
+	if s.FlapCatchUpP50 <= 0 || s.FlapCatchUpP99 > 250*time.Millisecond {
+		t.Errorf("catch-up P50 %v, P99 %v; want one poll or so", s.FlapCatchUpP50, s.FlapCatchUpP99)
+	}
+
+	sum := f.summary(s)
+	if sum.Flaps != 1 || sum.Pauses != 3 || sum.Interval != 20*time.Millisecond {
+		t.Errorf("summary = %+v", sum)
+	}
+}
+
+func TestFlapper_Run(t *testing.T) {
+	target := newFakeFlapTarget(0, 1, 2, 3, 4)
+	f, _ := newTestFlapper(t, target, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	f.run(ctx)
+
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	if target.pauses < 2 {
+		t.Errorf("pauses = %d, want at least one flap of 2 clients", target.pauses)
+	}
+	if len(target.paused) != 0 {
+		t.Errorf("paused after run = %v, want all resumed on return", target.paused)
+	}
+	if f.paused != 0 {
+		t.Errorf("paused count = %d after run, want 0", f.paused)
+	}
+}
+
+func TestFlapper_Pick(t *testing.T) {
+	target := newFakeFlapTarget(0, 1, 2)
+	f, _ := newTestFlapper(t, target, 2)
+	if ids := f.pick(); len(ids) != 2 {
+		t.Errorf("pick() = %v, want 2 clients", ids)
+	}
+
+	f.clients = 5 // More than are running
+	if ids := f.pick(); len(ids) != 3 {
+		t.Errorf("pick() = %v, want all 3 running clients", ids)
+	}
+}
+
+func TestNewFlapper_Disabled(t *testing.T) {
+	if f := newFlapper(&config.Config{}, nil, nil, nil); f != nil {
+		t.Error("newFlapper() != nil, want disabled without -flap-interval")
+	}
+}
@@ -0,0 +1,111 @@
+package orchestrator
+
+import (
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// geoMap runs -geo: it spreads the clients over the geo cohorts, gives
+// each client its geo's headers, and breaks latency and errors down by geo
+// so CDN mapping rules can be checked against what each geo got.
+type geoMap struct {
+	cm        *ClientManager
+	geos      []config.Geo
+	byClient  []int   // Geo index, indexed by client ID
+	clientIDs [][]int // Per geo
+}
+
+// newGeoMap assigns clients clients to geos in proportion to their weights,
+// interleaved so every geo ramps up together. Returns nil without -geo.
+func newGeoMap(geos []config.Geo, clients int, cm *ClientManager) *geoMap {
+	if len(geos) == 0 {
+		return nil
+	}
+
+	g := &geoMap{cm: cm, geos: geos, clientIDs: make([][]int, len(geos))}
+	for clientID, idx := range assignGeos(geos, clients) {
+		g.byClient = append(g.byClient, idx)
+		g.clientIDs[idx] = append(g.clientIDs[idx], clientID)
+	}
+	return g
+}
+
+// assignGeos returns each client's geo index, see assignByWeight.
+func assignGeos(geos []config.Geo, clients int) []int {
+	weights := make([]int, len(geos))
+	for i, g := range geos {
+		weights[i] = g.Weight
+	}
+	return assignByWeight(weights, clients)
+}
+
+// assignByWeight returns each client's cohort index: the weights
+// interleaved and repeated.
+func assignByWeight(weights []int, clients int) []int {
+	cycle := interleave(weights)
+
+	assigned := make([]int, clients)
+	for clientID := range assigned {
+		assigned[clientID] = cycle[clientID%len(cycle)]
+	}
+	return assigned
+}
+
+// headers returns a client's geo headers (process.FFmpegConfig.ClientHeaders).
+func (g *geoMap) headers(clientID int) []string {
+	if clientID < 0 || clientID >= len(g.byClient) {
+		return nil
+	}
+	return g.geos[g.byClient[clientID]].Headers
+}
+
+// env returns a client's geo environment (process.FFmpegConfig.ClientEnv).
+func (g *geoMap) env(clientID int) []string {
+	if clientID < 0 || clientID >= len(g.byClient) {
+		return nil
+	}
+	return g.geos[g.byClient[clientID]].Env
+}
+
+// metricsUpdates returns each geo's state for the Prometheus collector.
+func (g *geoMap) metricsUpdates() []metrics.GeoUpdate {
+	updates := make([]metrics.GeoUpdate, len(g.geos))
+	for i, geo := range g.geos {
+		gs := g.cm.GetGroupStats(g.clientIDs[i])
+		updates[i] = metrics.GeoUpdate{
+			Geo:         geo.Name,
+			Clients:     gs.Clients,
+			Requests:    gs.Requests,
+			Errors:      gs.Errors(),
+			SegmentP50:  gs.SegmentP50,
+			SegmentP95:  gs.SegmentP95,
+			SegmentP99:  gs.SegmentP99,
+			ManifestP50: gs.ManifestP50,
+			ManifestP95: gs.ManifestP95,
+			ManifestP99: gs.ManifestP99,
+		}
+	}
+	return updates
+}
+
+// summaries returns the per-geo exit summary rows.
+func (g *geoMap) summaries() []stats.GeoSummary {
+	rows := make([]stats.GeoSummary, len(g.geos))
+	for i, geo := range g.geos {
+		gs := g.cm.GetGroupStats(g.clientIDs[i])
+		rows[i] = stats.GeoSummary{
+			Name:          geo.Name,
+			Clients:       gs.Clients,
+			Requests:      gs.Requests,
+			HTTPErrors:    gs.HTTPErrors,
+			NetworkErrors: gs.NetworkErrors,
+			SegmentP50:    gs.SegmentP50,
+			SegmentP95:    gs.SegmentP95,
+			SegmentP99:    gs.SegmentP99,
+			ManifestP50:   gs.ManifestP50,
+			ManifestP99:   gs.ManifestP99,
+		}
+	}
+	return rows
+}
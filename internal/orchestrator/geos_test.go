@@ -0,0 +1,65 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestAssignGeos(t *testing.T) {
+	geos := []config.Geo{{Name: "eu", Weight: 2}, {Name: "us", Weight: 1}}
+
+	got := assignGeos(geos, 7)
+	want := []int{0, 0, 1, 0, 0, 1, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("assignGeos() = %v, want %v (2:1, repeated)", got, want)
+		}
+	}
+}
+
+func TestGeoMap(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}, StatsEnabled: true})
+	g := newGeoMap([]config.Geo{
+		{Name: "eu", Weight: 1, Headers: []string{"X-Forwarded-For: 81.2.69.160"}},
+		{Name: "us", Weight: 1, Headers: []string{"X-Forwarded-For: 8.8.8.8", "X-Geo: US"}, Env: []string{"TZ=America/New_York"}},
+	}, 4, cm)
+
+	if h := g.headers(1); len(h) != 2 || h[1] != "X-Geo: US" {
+		t.Errorf("headers(1) = %v, want the us headers", h)
+	}
+	if h := g.headers(4); h != nil {
+		t.Errorf("headers(4) = %v, want nil for an unknown client", h)
+	}
+	if e := g.env(3); len(e) != 1 || e[0] != "TZ=America/New_York" {
+		t.Errorf("env(3) = %v, want the us environment", e)
+	}
+	if e := g.env(2); e != nil {
+		t.Errorf("env(2) = %v, want nil for eu", e)
+	}
+
+	// eu: clients 0 and 2; us: 1 and 3, of which only 1 has started
+	for _, id := range []int{0, 1, 2} {
+		cm.clientStats[id] = stats.NewClientStats(id)
+		cm.clientStats[id].SegmentRequests.Add(10)
+	}
+	cm.clientStats[1].RecordHTTPError(403)
+	cm.clientStats[1].RecordHTTPError(403)
+	cm.clientStats[1].RecordNetworkError("timeout")
+
+	rows := g.summaries()
+	if eu := rows[0]; eu.Name != "eu" || eu.Clients != 2 || eu.Requests != 20 || len(eu.HTTPErrors) != 0 {
+		t.Errorf("eu = %+v", eu)
+	}
+	if us := rows[1]; us.Clients != 1 || us.Requests != 10 || us.HTTPErrors[403] != 2 || us.NetworkErrors != 1 {
+		t.Errorf("us = %+v, want one client with 2×403 and a network error", us)
+	}
+	if u := g.metricsUpdates()[1]; u.Geo != "us" || u.Errors != 3 {
+		t.Errorf("us update = %+v, want 3 errors", u)
+	}
+
+	if newGeoMap(nil, 4, cm) != nil {
+		t.Error("newGeoMap() without -geo should be nil")
+	}
+}
@@ -0,0 +1,51 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+// versionProbeTimeout bounds "ffmpeg -version": a binary that hangs on it
+// leaves the dialect to the log rather than holding up the start.
+const versionProbeTimeout = 5 * time.Second
+
+// logDialect returns the -ffmpeg-log-dialect setting (auto if unset).
+func logDialect(cfg *config.Config) parser.LogDialect {
+	d, err := parser.ParseLogDialect(cfg.FFmpegLogDialect)
+	if err != nil {
+		return parser.DialectAuto // Validated
+	}
+	return d
+}
+
+// resolveLogDialect settles an auto -ffmpeg-log-dialect before the clients
+// start, from the version "ffmpeg -version" reports, so an FFmpeg without
+// the datetime log flag isn't handed a -loglevel it refuses. The probe is a
+// preflight check and is skipped with it; a dialect still undecided then
+// (or after a failed probe) assumes a current FFmpeg, and the parsers
+// settle it from the log.
+func (o *Orchestrator) resolveLogDialect(ctx context.Context) {
+	dialect := logDialect(o.config)
+	if dialect != parser.DialectAuto || !o.config.StatsEnabled || o.config.SkipPreflight {
+		return
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, versionProbeTimeout)
+	defer cancel()
+	version, err := process.FFmpegVersion(probeCtx, o.config.FFmpegPath)
+	if err != nil {
+		o.logger.Warn("ffmpeg_version_unknown", "error", err, "log_dialect", "detected from the log")
+		return
+	}
+	if dialect = parser.DialectForVersion(version); dialect == parser.DialectAuto {
+		o.logger.Warn("ffmpeg_version_unknown", "version", version, "log_dialect", "detected from the log")
+		return
+	}
+	o.runner.Config().NoLogDatetime = !dialect.DatetimeFlag()
+	o.clientManager.SetLogDialect(dialect)
+	o.logger.Info("ffmpeg_log_dialect", "version", version, "dialect", dialect)
+}
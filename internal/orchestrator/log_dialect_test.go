@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+func TestNewFFmpegConfig_LogDialect(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if NewFFmpegConfig(cfg).NoLogDatetime {
+		t.Error("auto: datetime flag left out")
+	}
+	cfg.FFmpegLogDialect = "ffmpeg6"
+	if !NewFFmpegConfig(cfg).NoLogDatetime {
+		t.Error("ffmpeg6: datetime flag asked for")
+	}
+}
+
+// newDialectOrchestrator returns an orchestrator with -ffmpeg-log-dialect
+// dialect, whose FFmpeg prints version to -version.
+func newDialectOrchestrator(t *testing.T, version, dialect string, skipPreflight bool) *Orchestrator {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\necho 'ffmpeg version " + version + " Copyright (c) 2000-2025 the FFmpeg developers'\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write fake ffmpeg: %v", err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.FFmpegPath = path
+	cfg.FFmpegLogDialect = dialect
+	cfg.SkipPreflight = skipPreflight
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	runner := process.NewFFmpegRunner(NewFFmpegConfig(cfg))
+	return &Orchestrator{
+		config:        cfg,
+		logger:        logger,
+		runner:        runner,
+		clientManager: NewClientManager(ManagerConfig{Builder: runner, Logger: logger, LogDialect: logDialect(cfg)}),
+	}
+}
+
+func TestResolveLogDialect(t *testing.T) {
+	tests := []struct {
+		version       string
+		flag          string
+		skipPreflight bool
+		want          parser.LogDialect
+	}{
+		{"6.1.1-3ubuntu5", "auto", false, parser.DialectFFmpeg6},
+		{"7.1.1", "auto", false, parser.DialectFFmpeg7},
+		{"not-a-version", "auto", false, parser.DialectAuto},
+		{"6.1.1", "auto", true, parser.DialectAuto},        // No probe without preflight
+		{"6.1.1", "ffmpeg7", false, parser.DialectFFmpeg7}, // The flag wins
+	}
+	for _, tt := range tests {
+		o := newDialectOrchestrator(t, tt.version, tt.flag, tt.skipPreflight)
+		o.resolveLogDialect(t.Context())
+
+		if got := o.clientManager.logDialect; got != tt.want {
+			t.Errorf("%s with -ffmpeg-log-dialect %s: dialect %q, want %q", tt.version, tt.flag, got, tt.want)
+		}
+		if noDatetime := o.runner.Config().NoLogDatetime; noDatetime != !tt.want.DatetimeFlag() {
+			t.Errorf("%s with -ffmpeg-log-dialect %s: NoLogDatetime = %v, want %v", tt.version, tt.flag, noDatetime, !tt.want.DatetimeFlag())
+		}
+	}
+}
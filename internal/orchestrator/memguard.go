@@ -0,0 +1,181 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+const (
+	// memGuardInterval is how often the guard samples the swarm's RSS; at
+	// most one feature is shed per sample, so each has time to take effect.
+	memGuardInterval = 5 * time.Second
+
+	// memGuardHistorySamples is what the in-memory histories are cut to.
+	memGuardHistorySamples = 1000
+)
+
+// memStep is a feature the memory guard can shed.
+type memStep struct {
+	feature     string
+	description string
+	shed        func() bool // False if the feature was off: nothing freed
+}
+
+// memGuard runs -max-memory: it samples the swarm's own RSS and, while it
+// is over the limit, sheds memory-hungry features in a fixed order, the
+// least missed first. Each is logged and listed in the summary, since
+// the results lack it from then on.
+type memGuard struct {
+	limit   int64 // Bytes
+	rss     func() (int64, error)
+	steps   []memStep
+	metrics *metrics.Collector
+	logger  *slog.Logger
+	start   time.Time
+
+	mu        sync.Mutex
+	next      int // Index of the next step to try
+	peak      int64
+	shed      []stats.MemoryDegradation
+	exhausted bool
+}
+
+// newMemGuard returns the -max-memory guard. Returns nil if the limit is
+// 0 or the swarm's RSS can't be read here.
+func newMemGuard(cfg *config.Config, steps []memStep, m *metrics.Collector, logger *slog.Logger) *memGuard {
+	if cfg.MaxMemoryMB <= 0 {
+		return nil
+	}
+	pid := os.Getpid()
+	rss := func() (int64, error) { return supervisor.ProcessRSS(pid) }
+	if _, err := rss(); err != nil {
+		logger.Warn("memory_guard_disabled", "error", err)
+		return nil
+	}
+	return &memGuard{
+		limit:   int64(cfg.MaxMemoryMB) * 1_000_000,
+		rss:     rss,
+		steps:   steps,
+		metrics: m,
+		logger:  logger,
+	}
+}
+
+// memSteps lists what the guard sheds, in order: per-client Prometheus
+// series (grow with clients and restarts), the parsers' sample rings (per
+// client), then the run-long in-memory histories.
+func (o *Orchestrator) memSteps() []memStep {
+	return []memStep{
+		{
+			feature:     "per-client-metrics",
+			description: "per-client Prometheus series dropped",
+			shed:        o.metrics.DisablePerClient,
+		},
+		{
+			feature:     "sample-rings",
+			description: "per-client parser sample rings freed",
+			shed:        o.clientManager.DropSampleRings,
+		},
+		{
+			feature:     "history",
+			description: fmt.Sprintf("in-memory histories cut to %d samples (coarser uptime percentiles)", memGuardHistorySamples),
+			shed: func() bool {
+				o.metrics.ShrinkHistories(memGuardHistorySamples)
+				return true
+			},
+		},
+	}
+}
+
+// run samples every memGuardInterval until ctx is cancelled.
+func (g *memGuard) run(ctx context.Context) {
+	g.start = time.Now()
+	ticker := time.NewTicker(memGuardInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			g.sample(now)
+		}
+	}
+}
+
+// sample reads the RSS and, if it is over the limit, sheds the next
+// feature that is on.
+func (g *memGuard) sample(now time.Time) {
+	rss, err := g.rss()
+	if err != nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.peak = max(g.peak, rss)
+	if rss > g.limit && !g.exhausted {
+		g.shedNext(now, rss)
+	}
+	g.metrics.RecordMemory(rss, g.limit, len(g.shed))
+}
+
+// shedNext sheds the next step that frees something, or marks the guard
+// exhausted. Must be called with g.mu held.
+func (g *memGuard) shedNext(now time.Time, rss int64) {
+	for g.next < len(g.steps) {
+		step := g.steps[g.next]
+		g.next++
+		if !step.shed() {
+			continue
+		}
+		g.shed = append(g.shed, stats.MemoryDegradation{
+			Feature:     step.feature,
+			Description: step.description,
+			At:          now.Sub(g.start),
+			RSS:         rss,
+		})
+		g.logger.Warn("memory_limit_shed",
+			"feature", step.feature,
+			"rss_mb", rss/1_000_000,
+			"limit_mb", g.limit/1_000_000,
+		)
+		// Hand the freed memory back so the next sample sees it
+		debug.FreeOSMemory()
+		return
+	}
+	g.exhausted = true
+	g.logger.Error("memory_limit_exhausted",
+		"rss_mb", rss/1_000_000,
+		"limit_mb", g.limit/1_000_000,
+		"hint", "nothing left to shed; run fewer clients or raise -max-memory",
+	)
+}
+
+// summary describes the swarm's memory use (nil without the guard or
+// before the first sample).
+func (g *memGuard) summary() *stats.MemoryGuardSummary {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.peak == 0 {
+		return nil
+	}
+	return &stats.MemoryGuardSummary{
+		Limit:     g.limit,
+		Peak:      g.peak,
+		Shed:      append([]stats.MemoryDegradation(nil), g.shed...),
+		Exhausted: g.exhausted,
+	}
+}
@@ -0,0 +1,125 @@
+package orchestrator
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+// newTestMemGuard returns a 1000 MB guard reading *rss, with steps a, b
+// (off: nothing to shed) and c, each counting its calls.
+func newTestMemGuard(rss *int64, calls map[string]int) *memGuard {
+	step := func(name string, on bool) memStep {
+		return memStep{feature: name, description: name + " shed", shed: func() bool {
+			calls[name]++
+			return on
+		}}
+	}
+	return &memGuard{
+		limit:   1000 * 1_000_000,
+		rss:     func() (int64, error) { return *rss, nil },
+		steps:   []memStep{step("a", true), step("b", false), step("c", true)},
+		metrics: metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 2}, prometheus.NewRegistry()),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestMemGuard_Sample(t *testing.T) {
+	rss := int64(800 * 1_000_000)
+	calls := make(map[string]int)
+	g := newTestMemGuard(&rss, calls)
+	start := time.Now()
+	g.start = start
+
+	g.sample(start.Add(memGuardInterval))
+	if len(calls) != 0 {
+		t.Fatalf("shed %v under the limit", calls)
+	}
+
+	// Over the limit: one feature per sample, skipping ones that are off
+	rss = 1200 * 1_000_000
+	g.sample(start.Add(2 * memGuardInterval))
+	if calls["a"] != 1 || calls["c"] != 0 {
+		t.Fatalf("calls after the first sample over = %v, want only a", calls)
+	}
+	g.sample(start.Add(3 * memGuardInterval))
+	if calls["b"] != 1 || calls["c"] != 1 {
+		t.Fatalf("calls after the second sample over = %v, want b skipped, c shed", calls)
+	}
+
+	// Nothing left: exhausted once, no more calls
+	g.sample(start.Add(4 * memGuardInterval))
+	g.sample(start.Add(5 * memGuardInterval))
+	if calls["a"] != 1 || calls["c"] != 1 {
+		t.Errorf("steps shed again: %v", calls)
+	}
+
+	s := g.summary()
+	if s == nil {
+		t.Fatal("summary() = nil")
+	}
+	if s.Peak != 1200*1_000_000 || s.Limit != 1000*1_000_000 {
+		t.Errorf("Peak = %d, Limit = %d", s.Peak, s.Limit)
+	}
+	if len(s.Shed) != 2 || s.Shed[0].Feature != "a" || s.Shed[1].Feature != "c" {
+		t.Fatalf("Shed = %+v, want a then c", s.Shed)
+	}
+	if s.Shed[0].At != 2*memGuardInterval || s.Shed[0].RSS != rss {
+		t.Errorf("Shed[0] = %+v, want at %v with RSS %d", s.Shed[0], 2*memGuardInterval, rss)
+	}
+	if !s.Exhausted {
+		t.Error("Exhausted = false with every step shed and RSS still over")
+	}
+}
+
+func TestMemGuard_RecoversUnderLimit(t *testing.T) {
+	rss := int64(1200 * 1_000_000)
+	calls := make(map[string]int)
+	g := newTestMemGuard(&rss, calls)
+
+	g.sample(time.Now())
+	rss = 900 * 1_000_000 // The first step did it
+	g.sample(time.Now())
+
+	if s := g.summary(); len(s.Shed) != 1 || s.Exhausted {
+		t.Errorf("summary = %+v, want one feature shed", s)
+	}
+}
+
+func TestMemGuard_ReadError(t *testing.T) {
+	calls := make(map[string]int)
+	g := newTestMemGuard(new(int64), calls)
+	g.rss = func() (int64, error) { return 0, errors.New("no /proc") }
+
+	g.sample(time.Now())
+	if s := g.summary(); s != nil {
+		t.Errorf("summary() without a sample = %+v, want nil", s)
+	}
+}
+
+func TestNewMemGuard(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := config.DefaultConfig()
+	if g := newMemGuard(cfg, nil, nil, logger); g != nil {
+		t.Error("newMemGuard() with -max-memory 0 != nil")
+	}
+	if (*memGuard)(nil).summary() != nil {
+		t.Error("nil guard has a summary")
+	}
+
+	cfg.MaxMemoryMB = 2048
+	g := newMemGuard(cfg, nil, nil, logger)
+	if g == nil {
+		t.Skip("RSS unreadable here")
+	}
+	if g.limit != 2048*1_000_000 {
+		t.Errorf("limit = %d, want 2048 MB", g.limit)
+	}
+}
@@ -2,18 +2,31 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/capture"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/eventsink"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/originlog"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/preflight"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/sockstats"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/tui"
@@ -31,47 +44,114 @@ type Orchestrator struct {
 	metricsServer  *metrics.Server
 	originScraper  *metrics.OriginScraper
 	segmentScraper *metrics.SegmentScraper
-
-	startTime time.Time
+	playlistMon    *manifest.Monitor        // nil unless -validate-playlists
+	tenancy        *tenancy                 // nil unless -tenants
+	geos           *geoMap                  // nil unless -geo
+	variants       *variantMix              // nil unless -variant-mix
+	compare        *originCompare           // nil unless -compare-url or -compare-opt
+	pcap           *capture.Capturer        // nil unless -pcap-dir (and capturing is possible)
+	pcapErr        string                   // Why -pcap-dir captured nothing
+	sockets        *sockstats.Collector     // nil unless -socket-stats (and the kernel can be asked)
+	socketsErr     string                   // Why -socket-stats sampled nothing
+	flaps          *flapper                 // nil unless -flap-interval
+	bursts         *burster                 // nil unless -burst
+	sessions       *sessionTracker          // nil unless -session-duration
+	vodStarts      *vodStarts               // nil unless -vod-start spreads the starts
+	vodPlays       *vodPlays                // nil unless -vod-end
+	vodAsset       *manifest.Playlist       // Once probed for -vod-start or -vod-end
+	renditions     *renditionStats          // nil unless -renditions
+	cpuGuard       *cpuGuard                // nil unless -client-cpu-limit
+	memGuard       *memGuard                // nil unless -max-memory
+	reloader       *refreshOverride         // nil unless -playlist-refresh
+	capacity       *capacityProjector       // nil unless -stats
+	slo            *sloTracker              // nil unless -slo
+	tokenSource    *process.HTTPTokenSource // nil unless -token-url
+	sink           *eventsink.Streamer      // nil unless -event-sink
+	statsOut       io.Writer                // -stats-stdout destination (see SetStatsOutput)
+	runTag         string                   // User-Agent run tag ("" unless -tag-requests or -origin-log)
+
+	originHitFlagged bool // -origin-hit-alert crossed at the last update (see recordServing)
+
+	metricsRates rateSampler // Rates of the Prometheus gauges, per update
+
+	startTime   time.Time
+	clockOffset time.Duration // NTP offset measured for -start-at, added to exported timestamps
+	stopping    atomic.Bool   // Set once shutdown starts: later exits are expected
+
+	// Embedding in another program (pkg/swarm): see SetOutput,
+	// DisableSignals and Scale
+	out       io.Writer     // Preflight results, estimates and the exit summary
+	noSignals bool          // SIGINT/SIGTERM are left to the embedding program
+	started   chan struct{} // Closed once the ramp begins
+	scale     *scaler
+	gatherer  prometheus.Gatherer // Pushed to -pushgateway-url
+	phases    *phases             // Lifecycle phase (see OnPhase)
+
+	overrides *clientOverrides // Options set by Reconfigure
+	rollout   rollout          // Rolling restart going on
+	events    runEvents        // Control actions taken, for -save-run
+
+	timeline *stats.MetricsTimeline // Metrics over the run, for -save-run; nil otherwise
+	heatmap  *stats.LatencyHeatmap  // Segment wall times over the run, sampled with timeline; nil without -stats
 }
 
 // New creates a new Orchestrator with the given configuration.
 func New(cfg *config.Config, logger *slog.Logger) *Orchestrator {
-	// Create FFmpeg runner
-	ffmpegConfig := &process.FFmpegConfig{
-		BinaryPath:        cfg.FFmpegPath,
-		StreamURL:         cfg.StreamURL,
-		Variant:           process.VariantSelection(cfg.Variant),
-		UserAgent:         cfg.UserAgent,
-		Timeout:           cfg.Timeout,
-		Reconnect:         cfg.Reconnect,
-		ReconnectDelayMax: cfg.ReconnectDelayMax,
-		SegMaxRetry:       cfg.SegMaxRetry,
-		LogLevel:          cfg.LogLevel,
-		ResolveIP:         cfg.ResolveIP,
-		DangerousMode:     cfg.DangerousMode,
-		NoCache:           cfg.NoCache,
-		Headers:           cfg.Headers,
-		ProgramID:         -1,
-		// Stats collection
-		StatsEnabled:  cfg.StatsEnabled,
-		StatsLogLevel: cfg.StatsLogLevel,
-		DebugLogging:  cfg.DebugLogging,
+	return NewWithRegistry(cfg, logger, nil)
+}
+
+// NewWithRegistry is New with the metrics registered on and served from
+// registry instead of the default one (nil = default). The metrics are
+// process-wide, so only one Orchestrator may run at a time either way, but
+// a private registry lets pkg/swarm run one after another.
+func NewWithRegistry(cfg *config.Config, logger *slog.Logger, registry *prometheus.Registry) *Orchestrator {
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if registry != nil {
+		registerer, gatherer = registry, registry
 	}
-	runner := process.NewFFmpegRunner(ffmpegConfig)
+
+	// Create FFmpeg runner
+	runner := process.NewFFmpegRunner(NewFFmpegConfig(cfg))
 
 	// Create ramp scheduler
 	rampScheduler := NewRampScheduler(cfg.RampRate, cfg.RampJitter)
 
 	// Create metrics
-	collector := metrics.NewCollector(metrics.CollectorConfig{
+	collector := metrics.NewCollectorWithRegistry(metrics.CollectorConfig{
 		TargetClients:    cfg.Clients,
 		TestDuration:     cfg.Duration,
 		StreamURL:        cfg.StreamURL,
 		Variant:          cfg.Variant,
+		Pacing:           describePacing(cfg),
 		PerClientMetrics: cfg.PromClientMetrics,
-	})
-	metricsServer := metrics.NewServer(cfg.MetricsAddr, logger)
+		PerClientMax:     cfg.PromClientMetricsMax,
+		RetentionSamples: cfg.StatsRetention,
+	}, registerer)
+	if cfg.StatsSpillDir != "" {
+		if path, err := collector.SpillHistoryTo(cfg.StatsSpillDir, cfg.StatsSpillCompress); err != nil {
+			logger.Warn("stats_spill_disabled", "error", err)
+		} else {
+			logger.Info("stats_spill_enabled", "path", path)
+		}
+	}
+	metricsServer := metrics.NewServer(cfg.MetricsAddrs, logger)
+	if registry != nil {
+		metricsServer = metrics.NewServerFor(cfg.MetricsAddrs, logger, registry)
+	}
+
+	// Session tokens: fetched by the runner on every client start
+	var tokenSource *process.HTTPTokenSource
+	if cfg.TokenURL != "" {
+		tokenSource = process.NewHTTPTokenSource(cfg.TokenURL, cfg.UserAgent, cfg.Timeout)
+		tokenSource.OnFetch = func(clientID int, latency time.Duration, err error) {
+			collector.RecordTokenFetch(latency, err)
+			if err != nil {
+				logger.Warn("token_fetch_failed", "client_id", clientID, "error", err)
+			}
+		}
+		runner.Config().TokenSource = tokenSource
+	}
 
 	// Initialize origin scraper if URLs are configured
 	var originScraper *metrics.OriginScraper
@@ -116,63 +196,259 @@ func New(cfg *config.Config, logger *slog.Logger) *Orchestrator {
 		metricsServer:  metricsServer,
 		originScraper:  originScraper,
 		segmentScraper: segmentScraper,
+		tokenSource:    tokenSource,
+		out:            os.Stdout,
+		started:        make(chan struct{}),
+		scale:          newScaler(cfg.Clients),
+		gatherer:       gatherer,
+		phases:         newPhases(collector, logger),
 	}
 
 	// Create client manager with callbacks
+	stopSignal, _ := config.ParseStopSignal(cfg.StopSignal) // Validated; 0 = SIGTERM
+	backoff, backoffOverrides := backoffPolicy(cfg)
 	managerCfg := ManagerConfig{
 		Builder: runner,
 		Logger:  logger,
-		BackoffConfig: supervisor.BackoffConfig{
-			Initial:    cfg.BackoffInitial,
-			Max:        cfg.BackoffMax,
-			Multiplier: cfg.BackoffMultiply,
-			JitterPct:  0.4,
-		},
+
+		BackoffConfig:    backoff,
+		BackoffOverrides: backoffOverrides,
+
 		MaxRestarts: cfg.MaxRestarts,
+		StopSignal:  stopSignal,
+		StopGrace:   cfg.StopGrace,
 		// Stats collection
 		StatsEnabled:       cfg.StatsEnabled,
 		StatsBufferSize:    cfg.StatsBufferSize,
 		StatsDropThreshold: cfg.StatsDropThreshold,
+		StatsMaxLineLength: cfg.StatsMaxLineLength,
+		ProgressMode:       progressMode(cfg),
+		SlowRequestThreshold: cfg.SlowRequestLog,
+		TargetDuration:        cfg.TargetDuration,
+		RefreshStormThreshold: parser.RefreshStormThreshold(cfg.Clients, cfg.TargetDuration),
+		AggregateInterval:    cfg.StatsAggregateInterval,
+		ReauthOn401:          cfg.TokenURL != "",
+		TokenTTL:             cfg.TokenTTL,
 		// Segment size lookup (for accurate byte tracking)
 		// NOTE: Only set if non-nil to avoid Go's nil interface gotcha
 		// (a nil pointer in an interface makes interface != nil but method calls panic)
 		SegmentSizeLookup: nil, // Set below if configured
 		// FD mode is always enabled when stats are enabled
 		Callbacks: ManagerCallbacks{
-			OnClientStateChange: orch.onStateChange,
-			OnClientStart:       orch.onStart,
-			OnClientExit:        orch.onExit,
-			OnClientRestart:     orch.onRestart,
+			OnClientStateChange:  orch.onStateChange,
+			OnClientStart:        orch.onStart,
+			OnClientExit:         orch.onExit,
+			OnClientRestart:      orch.onRestart,
+			OnClientReauth:       orch.onReauth,
+			OnClientTokenExpired: orch.onTokenExpired,
+			OnClientStopSignal:   orch.onStopSignal,
+			OnClientQuarantine:   orch.onQuarantine,
+		},
+		Quarantine: supervisor.QuarantineConfig{
+			Failures: cfg.QuarantineFailures,
+			Window:   cfg.QuarantineWindow,
+			Cooldown: cfg.QuarantineCooldown,
 		},
 	}
 	// Only set SegmentSizeLookup if scraper is configured (avoid nil interface gotcha)
 	if segmentScraper != nil {
 		managerCfg.SegmentSizeLookup = segmentScraper
 	}
+
+	// CPU pinning: topology problems degrade to unpinned rather than aborting
+	if cpuAllocator, err := supervisor.NewCPUAllocator(supervisor.AffinityPolicy(cfg.CPUAffinity)); err != nil {
+		logger.Warn("cpu_affinity_disabled", "policy", cfg.CPUAffinity, "error", err)
+	} else if cpuAllocator != nil {
+		managerCfg.CPUAllocator = cpuAllocator
+		logger.Info("cpu_affinity_enabled", "policy", cfg.CPUAffinity, "slots", cpuAllocator.Slots())
+	}
+	managerCfg.ClientPriority = clientPriorities(cfg)
+	if orch.sessions = newSessionTracker(cfg, collector, logger); orch.sessions != nil {
+		managerCfg.SessionLength = orch.sessions.length
+		managerCfg.Callbacks.OnClientSessionStart = orch.sessions.started
+		managerCfg.Callbacks.OnClientSessionEnd = orch.sessions.ended
+	}
+	if orch.vodPlays = newVODPlays(cfg, orch.scaleTarget, collector, logger); orch.vodPlays != nil {
+		managerCfg.AtEnd = orch.vodPlays.atEnd()
+		managerCfg.Callbacks.OnClientStreamEnd = orch.vodPlays.finished
+	}
+	managerCfg.LogDialect = logDialect(cfg)
+	if orch.slo = newSLOTracker(cfg, logger); orch.slo != nil {
+		managerCfg.SLOThreshold = orch.slo.threshold
+		logger.Info("slo", "target", orch.slo.target, "threshold", orch.slo.threshold, "burn_window", sloBurnWindow)
+	}
+	if pacing := describePacing(cfg); pacing != config.PacingFast {
+		logger.Info("pacing", "model", pacing)
+	}
+	if sample := newDebugSample(cfg); sample != nil {
+		runner.Config().ClientDebug = sample.traced
+		managerCfg.TraceClient = sample.traced
+		logger.Info("debug_sample", "percent", cfg.DebugSample, "clients", sample.ids)
+	}
+	if cfg.EventSink != "" {
+		orch.setupEventSink()
+		if orch.sink != nil && cfg.EventSinkDebug && cfg.StatsEnabled {
+			managerCfg.Callbacks.OnDebugEvent = orch.onDebugEvent
+		}
+	}
+	if orch.renditions = newRenditionStats(cfg, collector); orch.renditions != nil && cfg.StatsEnabled {
+		sinkEvent := managerCfg.Callbacks.OnDebugEvent
+		managerCfg.Callbacks.OnDebugEvent = func(clientID int, e *parser.DebugEvent) {
+			orch.renditions.observe(clientID, e)
+			if sinkEvent != nil {
+				sinkEvent(clientID, e)
+			}
+		}
+	}
 	orch.clientManager = NewClientManager(managerCfg)
+	if orch.tenancy = newTenancy(cfg.Tenants, orch.clientManager, logger); orch.tenancy != nil {
+		orch.tenancy.onStart = collector.ClientStarted
+	}
+	orch.geos = newGeoMap(cfg.Geos, cfg.Clients, orch.clientManager)
+	orch.compare = newOriginCompare(cfg, orch.clientManager)
+	applyCohorts(runner.Config(), orch.geos, orch.compare)
+	if orch.compare != nil {
+		logger.Info("origin_compare",
+			"a", cfg.StreamURL,
+			"b", orch.compare.urls[1],
+			"b_options", orch.compare.optsDesc,
+			"a_clients", len(orch.compare.clientIDs[0]),
+			"b_clients", len(orch.compare.clientIDs[1]),
+		)
+	}
+	// Reconfigure's changes, on top of the cohort's options
+	orch.overrides = newClientOverrides(runner.Config().ClientOptions)
+	runner.Config().ClientOptions = orch.overrides.options
+	if cfg.RunTagged() {
+		orch.runTag = originlog.NewRunTag()
+		runner.Config().RunTag = orch.runTag
+		logger.Info("run_tag", "tag", orch.runTag)
+	}
+	if cfg.PcapDir != "" {
+		orch.setupPcap()
+	}
+	if cfg.SocketStats {
+		orch.setupSocketStats()
+	}
+	orch.flaps = newFlapper(cfg, orch.clientManager, orch.metrics, logger)
+	orch.bursts = newBurster(cfg, orch.scaleBy, orch.runEvent, orch.metrics, logger)
+	orch.cpuGuard = newCPUGuard(cfg, orch.clientManager.ClientPIDs, orch.metrics, logger)
+	orch.memGuard = newMemGuard(cfg, orch.memSteps(), orch.metrics, logger)
+	orch.capacity = newCapacityProjector(cfg, orch.clientManager.LatencyWindow(), orch.clientManager.ActiveCount, logger)
 
 	return orch
 }
 
+// applyCohorts points the runner's per-client hooks at the -geo and
+// -compare-url cohorts (either may be nil).
+func applyCohorts(fc *process.FFmpegConfig, geos *geoMap, compare *originCompare) {
+	if geos != nil {
+		fc.ClientHeaders = geos.headers
+		fc.ClientEnv = geos.env
+	}
+	if compare != nil {
+		fc.ClientURL = compare.url
+		if compare.opts != nil {
+			fc.ClientOptions = compare.options
+		}
+	}
+}
+
+// NewFFmpegConfig maps the CLI configuration onto the FFmpeg runner config.
+func NewFFmpegConfig(cfg *config.Config) *process.FFmpegConfig {
+	audio, subs, _ := config.ParseRenditions(cfg.Renditions) // Validated
+	return &process.FFmpegConfig{
+		BinaryPath:        cfg.FFmpegPath,
+		StreamURL:         cfg.StreamURL,
+		Variant:           process.VariantSelection(cfg.Variant),
+		AudioRendition:    audio,
+		SubtitleRendition: subs,
+		UserAgent:         cfg.UserAgent,
+		Timeout:           cfg.Timeout,
+		Reconnect:         cfg.Reconnect,
+		ReconnectDelayMax: cfg.ReconnectDelayMax,
+		SegMaxRetry:       cfg.SegMaxRetry,
+		LogLevel:          cfg.LogLevel,
+		ResolveIP:         cfg.ResolveIP,
+		DangerousMode:     cfg.DangerousMode,
+		NoCache:           cfg.NoCache,
+		NoKeepAlive:       cfg.NoKeepAlive,
+		Prefetch:          cfg.Prefetch,
+		Headers:           cfg.Headers,
+		AcceptEncoding:    cfg.AcceptEncoding,
+		Env:               cfg.ClientEnv,
+		ExtraArgs:         cfg.FFmpegExtraArgs,
+		Pacing:            processPacing(cfg.Pacing),
+		ClientPacing:      clientPacing(cfg),
+		ProgramID:         -1,
+		// Stats collection
+		StatsEnabled:    cfg.StatsEnabled,
+		StatsLogLevel:   cfg.StatsLogLevel,
+		NoLogDatetime:   !logDialect(cfg).DatetimeFlag(),
+		ProgressMode:    progressMode(cfg),
+		DebugLogging:    cfg.DebugLogging,
+		ResponseHeaders: cfg.CDNDetect,
+	}
+}
+
+// progressMode returns the -progress-mode setting (pipe if unset).
+func progressMode(cfg *config.Config) parser.ProgressMode {
+	m, err := parser.ParseProgressMode(cfg.ProgressMode)
+	if err != nil {
+		return parser.ProgressPipe // Validated
+	}
+	return m
+}
+
+// describePrefetch returns the -prefetch depth for the exit summary ("" if
+// left to FFmpeg).
+func describePrefetch(cfg *config.Config) string {
+	if cfg.Prefetch < 0 {
+		return ""
+	}
+	return strconv.Itoa(cfg.Prefetch)
+}
+
 // Run executes the load test. It blocks until completion or signal.
+// Once the clients have run, the error (nil for a clean run) wraps
+// ErrThresholdViolated, ErrMaxRestarts or ErrDrainTimeout as they apply;
+// the summary is printed either way.
 func (o *Orchestrator) Run(ctx context.Context) error {
 	o.startTime = time.Now()
+	defer o.closeEventSink() // After the stopped phase
+	o.phases.start()
+	o.phases.set(PhaseStarting, o.config.Clients)
+	defer func() { o.phases.set(PhaseStopped, o.scaleTarget()) }()
+
+	// Leftovers of crashed runs would skew the preflight limits and the load
+	sweepOrphans(o.logger, o.config.KillOrphans, supervisor.FindOrphans, supervisor.KillOrphans)
+
+	// A decoding FFmpeg would load this host, not the origin
+	if err := o.runner.VerifyStreamCopy(); err != nil {
+		return fmt.Errorf("refusing to start: %w: %w", ErrConfig, err)
+	}
 
 	// Run preflight checks
 	if !o.config.SkipPreflight {
 		result := preflight.RunAll(o.config.Clients, o.config.FFmpegPath)
-		preflight.PrintResults(result)
+		preflight.WriteResults(o.out, result)
 		if !result.Passed {
-			return fmt.Errorf("preflight checks failed (use --skip-preflight to override)")
+			return fmt.Errorf("%w: preflight checks failed (use --skip-preflight to override)", ErrEnvironment)
 		}
 	}
 
+	// An FFmpeg too old for timestamped logs must not be asked for them
+	o.resolveLogDialect(ctx)
+	if err := o.runner.VerifyLogLevel(); err != nil {
+		return fmt.Errorf("refusing to start: %w: %w", ErrEnvironment, err)
+	}
+
 	// Probe variants if needed
 	if o.config.Variant == "highest" || o.config.Variant == "lowest" {
 		o.logger.Info("probing_variants", "url", o.config.StreamURL)
 		if err := o.runner.ProbeVariants(ctx); err != nil {
 			if o.config.ProbeFailurePolicy == "fail" {
-				return fmt.Errorf("variant probe failed: %w", err)
+				return fmt.Errorf("%w: variant probe failed: %w", ErrEnvironment, err)
 			}
 			o.logger.Warn("variant_probe_failed", "error", err, "fallback", "first")
 		} else {
@@ -180,17 +456,94 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 		}
 	}
 
-	// Start metrics server
-	if err := o.metricsServer.Start(); err != nil {
-		return fmt.Errorf("failed to start metrics server: %w", err)
+	// Device mix over the master playlist's variants
+	if len(o.config.VariantMix) > 0 {
+		if err := o.setupVariantMix(ctx); err != nil {
+			return err
+		}
 	}
 
-	// Setup signal handling
+	// Alternate renditions fetched with the variant
+	if o.renditions != nil {
+		if err := o.setupRenditions(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Faster playlist refreshes than FFmpeg's (after the variant mix, whose
+	// playlists it fetches)
+	if o.config.PlaylistRefresh > 0 {
+		if err := o.setupRefreshOverride(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Start positions spread over a VOD asset
+	if o.config.VODStart != "" {
+		if err := o.setupVODStart(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Plays of a VOD asset to its end
+	if o.vodPlays != nil {
+		if err := o.setupVODEnd(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Estimate origin load from the manifest (warning only)
+	if o.config.EstimateLoad {
+		o.estimateLoad(ctx)
+	}
+
+	// Start metrics server (no-op if main already did, see StartMetricsServer)
+	if err := o.StartMetricsServer(); err != nil {
+		return err
+	}
+
+	// Setup signal handling. The origin scraper gets its own context so it
+	// keeps recording through the cool-down after the clients stop.
+	originCtx, originCancel := context.WithCancel(ctx)
+	defer originCancel()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	if !o.noSignals {
+		signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+		defer signal.Stop(sigCh)
+	}
+
+	// Hold until the scheduled start (multi-host runs)
+	if !o.config.StartAt.IsZero() {
+		if !o.waitForStartAt(ctx, sigCh) {
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer shutdownCancel()
+			if err := o.metricsServer.Shutdown(shutdownCtx); err != nil {
+				o.logger.Warn("metrics_server_shutdown_error", "error", err)
+			}
+			return nil
+		}
+		o.startTime = time.Now()
+	}
+
+	// Origin latency before any load, for cool-down recovery
+	var baseline time.Duration
+	if o.config.CoolDown > 0 {
+		baseline = o.measureBaseline(ctx)
+	}
+
+	// Packet capture of sampled clients, from their first connection
+	if o.pcap != nil {
+		o.startPcap(ctx)
+	}
+
+	// Every client done with the VOD asset (-vod-end stop). Set before the
+	// ramp: the supervisors of started clients call it.
+	if o.vodPlays != nil {
+		o.vodPlays.onDone = cancel
+	}
 
 	// Start ramp-up
 	o.logger.Info("ramp_starting",
@@ -199,6 +552,11 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 		"estimated_duration", o.rampScheduler.EstimatedRampDuration(o.config.Clients).String(),
 	)
 
+	o.scale.mu.Lock()
+	o.scale.ctx, o.scale.ramping = ctx, true
+	o.scale.mu.Unlock()
+	close(o.started)
+
 	rampDone := make(chan struct{})
 	go func() {
 		defer close(rampDone)
@@ -207,13 +565,60 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 
 	// Start stats update loop for Prometheus
 	if o.config.StatsEnabled {
+		o.metricsRates = rateSampler{start: o.startTime}
 		go o.statsUpdateLoop(ctx)
 	}
 
+	// Kernel tcp_info of the clients' connections (-socket-stats)
+	if o.sockets != nil {
+		go o.sockets.Run(ctx)
+	}
+
+	// Network flaps (-flap-interval)
+	if o.flaps != nil {
+		go o.flaps.run(ctx)
+	}
+
+	// Traffic bursts over the baseline (-burst)
+	if o.bursts != nil {
+		go o.bursts.run(ctx, o.startTime)
+	}
+
+	// Viewer session arrival and departure rates (-session-duration)
+	if o.sessions != nil {
+		go o.sessions.run(ctx)
+	}
+
+	// Playlist fetches between FFmpeg's reloads (-playlist-refresh)
+	if o.reloader != nil {
+		go o.reloader.run(ctx)
+	}
+
+	// Projected origin capacity while clients are added
+	if o.capacity != nil {
+		go o.capacity.run(ctx, rampDone)
+	}
+
+	// Clients that decode (-client-cpu-limit)
+	if o.cpuGuard != nil {
+		o.cpuGuard.onFail = cancel
+		go o.cpuGuard.run(ctx)
+	}
+
+	// The swarm's own memory (-max-memory)
+	if o.memGuard != nil {
+		go o.memGuard.run(ctx)
+	}
+
+	// Per-tenant quotas (-tenants)
+	if o.tenancy != nil {
+		go o.tenancy.run(ctx, o.config.StatsAggregateInterval)
+	}
+
 	// Start origin metrics scraper if configured
 	if o.originScraper != nil {
 		go func() {
-			o.originScraper.Run(ctx)
+			o.originScraper.Run(originCtx)
 		}()
 		o.logger.Info("origin_metrics_scraper_started",
 			"node_exporter", o.config.OriginMetricsURL != "",
@@ -242,6 +647,48 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 		}
 	}
 
+	// Start playlist validation if configured
+	if o.config.ValidatePlaylists {
+		o.startPlaylistMonitor(ctx)
+	}
+
+	// Headless status line (alternative to the TUI)
+	var statusDone chan struct{}
+	if o.config.StatusLine {
+		statusDone = make(chan struct{})
+		go func() {
+			defer close(statusDone)
+			o.runStatusLine(ctx)
+		}()
+	}
+
+	// Machine-readable snapshots for wrapper scripts
+	var statsStdoutDone chan struct{}
+	if o.config.StatsStdout != "" {
+		if o.statsOut == nil {
+			o.statsOut = os.Stdout
+		}
+		statsStdoutDone = make(chan struct{})
+		go func() {
+			defer close(statsStdoutDone)
+			o.runStatsStdout(ctx)
+		}()
+	}
+
+	// Metrics time series for the saved run record
+	var timelineDone chan struct{}
+	if o.config.SaveRun {
+		o.timeline = stats.NewMetricsTimeline(o.config.StatsInterval)
+		if o.config.StatsEnabled {
+			o.heatmap = stats.NewLatencyHeatmap(parser.HeatmapBucketsMs, o.config.StatsInterval)
+		}
+		timelineDone = make(chan struct{})
+		go func() {
+			defer close(timelineDone)
+			o.runTimeline(ctx)
+		}()
+	}
+
 	// Setup duration timer if configured
 	var durationTimer <-chan time.Time
 	if o.config.Duration > 0 {
@@ -264,64 +711,221 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 	}
 
 	// Cancel context to stop all clients
+	o.stopping.Store(true)
 	cancel()
+	o.phases.set(PhaseDraining, o.scaleTarget())
+	if statusDone != nil {
+		<-statusDone // Finish the status line before the summary prints
+	}
+	if statsStdoutDone != nil {
+		<-statsStdoutDone
+	}
+	if timelineDone != nil {
+		<-timelineDone
+	}
 
-	// Graceful shutdown with timeout
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Graceful shutdown with timeout, long enough for stalled clients to
+	// be killed once their stop grace period runs out
+	shutdownTimeout := max(10*time.Second, o.config.StopGrace+5*time.Second)
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer shutdownCancel()
 
+	var drainErr error
 	if err := o.clientManager.Shutdown(shutdownCtx); err != nil {
 		o.logger.Warn("shutdown_incomplete", "error", err)
+		drainErr = fmt.Errorf("%w: clients still stopping after %s", ErrDrainTimeout, shutdownTimeout)
+	}
+
+	// Watch the origin recover with the load gone (metrics stay served)
+	var coolDown *stats.CoolDownSummary
+	if o.config.CoolDown > 0 {
+		coolDown = o.runCoolDown(sigCh, baseline)
 	}
+	originCancel()
 
-	if err := o.metricsServer.Shutdown(shutdownCtx); err != nil {
+	o.pushMetrics()
+
+	metricsCtx, metricsCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer metricsCancel()
+	if err := o.metricsServer.Shutdown(metricsCtx); err != nil {
 		o.logger.Warn("metrics_server_shutdown_error", "error", err)
 	}
 
 	// Print exit summary
-	o.printExitSummary()
+	o.printExitSummary(coolDown)
+	o.saveRun(coolDown)
 
-	return nil
+	if err := o.metrics.Close(); err != nil {
+		o.logger.Warn("stats_spill_error", "error", err)
+	}
+
+	return errors.Join(o.cpuGuard.err(), o.sloErr(), o.gaveUpErr(), drainErr)
+}
+
+// estimateLoad probes the manifest and prints the expected origin load,
+// warning if it exceeds the configured budget. Probe failures are logged
+// and ignored: FFmpeg may still cope with a playlist we can't parse.
+func (o *Orchestrator) estimateLoad(ctx context.Context) {
+	probeCtx, cancel := context.WithTimeout(ctx, o.config.Timeout)
+	defer cancel()
+
+	res, err := o.newProber(probeCtx).Probe(probeCtx, o.config.StreamURL)
+	if err != nil {
+		o.logger.Warn("load_estimate_failed", "error", err)
+		return
+	}
+
+	est := manifest.Estimate(res, o.config.Variant, o.config.Clients)
+	o.metrics.SetLoadEstimate(est.TotalRPS(), est.BandwidthMbps)
+	o.logger.Info("load_estimate",
+		"clients", est.Clients,
+		"renditions", est.Renditions,
+		"segment_rps", est.SegmentRPS,
+		"playlist_rps", est.PlaylistRPS,
+		"bandwidth_mbps", est.BandwidthMbps,
+	)
+	fmt.Fprint(o.out, est.String())
+
+	warnings := est.CheckBudget(manifest.Budget{
+		MaxRPS:  o.config.LoadBudgetRPS,
+		MaxMbps: o.config.LoadBudgetMbps,
+	})
+	for _, w := range warnings {
+		o.logger.Warn("load_budget_exceeded", "detail", w)
+		fmt.Fprintf(o.out, "  ⚠ %s\n", w)
+	}
+	fmt.Fprintln(o.out)
+}
+
+// newProber returns a manifest prober that reaches the origin the same way
+// the FFmpeg clients do. Header templates are expanded once, as client 0;
+// with -token-url that includes fetching a token, which the prober keeps.
+func (o *Orchestrator) newProber(ctx context.Context) *manifest.Prober {
+	vars := process.NewTemplateValues(0, 0)
+	if o.tokenSource != nil {
+		token, err := o.tokenSource.Token(ctx, vars)
+		if err != nil {
+			o.logger.Warn("probe_token_failed", "error", err)
+		}
+		vars.Token = token
+	}
+
+	return manifest.NewProber(manifest.ProberConfig{
+		Timeout:        o.config.Timeout,
+		UserAgent:      o.config.UserAgent,
+		Headers:        vars.ExpandHeaders(o.config.Headers),
+		ResolveIP:      o.config.ResolveIP,
+		DangerousMode:  o.config.DangerousMode,
+		AcceptEncoding: o.config.AcceptEncoding,
+		NoCache:        o.config.NoCache,
+	})
+}
+
+// startPlaylistMonitor reloads the media playlists the clients use and
+// counts compliance violations for the rest of the run.
+func (o *Orchestrator) startPlaylistMonitor(ctx context.Context) {
+	prober := o.newProber(ctx)
+
+	probeCtx, cancel := context.WithTimeout(ctx, o.config.Timeout)
+	res, err := prober.Probe(probeCtx, o.config.StreamURL)
+	cancel()
+	if err != nil {
+		o.logger.Warn("playlist_validation_disabled", "error", err)
+		return
+	}
+
+	// Validate the renditions the clients actually fetch
+	urls := []string{o.config.StreamURL}
+	if len(res.Variants) > 0 {
+		urls = urls[:0]
+		for _, v := range manifest.SelectVariants(res.Variants, o.config.Variant) {
+			urls = append(urls, v.URI)
+		}
+	}
+
+	o.playlistMon = manifest.NewMonitor(manifest.MonitorConfig{
+		Prober:   prober,
+		URLs:     urls,
+		Interval: o.config.ValidatePlaylistInterval,
+		Logger:   o.logger,
+		OnViolation: func(_ string, v manifest.Violation) {
+			o.metrics.RecordPlaylistViolation(string(v.Kind))
+		},
+		OnFetch: func(_ string, info manifest.FetchInfo, decodeFailed bool) {
+			o.metrics.RecordPlaylistFetch(info.Encoding, info.WireBytes, info.DecodedBytes, decodeFailed)
+		},
+	})
+	go o.playlistMon.Run(ctx)
+
+	o.logger.Info("playlist_validation_started",
+		"playlists", len(urls),
+		"interval", o.config.ValidatePlaylistInterval,
+	)
 }
 
-// rampUp starts clients at the configured rate.
+// rampUp starts clients at the configured rate, up to the scaler's target
+// (-clients, or a later Scale).
 func (o *Orchestrator) rampUp(ctx context.Context) {
-	for i := 0; i < o.config.Clients; i++ {
+	o.phases.set(PhaseRamping, o.scaleTarget())
+	rate := newRampRate()
+	for {
+		i, ok := o.scale.next()
+		if !ok {
+			break
+		}
+
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
-			o.logger.Info("ramp_cancelled", "started", i, "target", o.config.Clients)
+			o.logger.Info("ramp_cancelled", "started", i, "target", o.scaleTarget())
 			return
 		default:
 		}
 
-		// Wait according to ramp schedule
-		if i > 0 { // Don't wait for first client
-			if err := o.rampScheduler.Schedule(ctx, i); err != nil {
-				return
-			}
+		// Wait for the client's slot in the ramp (the first starts at once)
+		if err := o.rampScheduler.ScheduleAt(ctx, rate.start, rate.launched, i); err != nil {
+			return
 		}
 
-		// Start client
-		o.clientManager.StartClient(ctx, i)
-		o.metrics.ClientStarted()
+		// Start client (or hold it back, if its tenant is at its quota:
+		// the tenancy counts it once it does start)
+		launched := true
+		started := o.scale.start(i, func(clientCtx context.Context, clientID int) {
+			if o.tenancy != nil {
+				launched = o.tenancy.startClient(clientCtx, clientID)
+			} else {
+				o.clientManager.StartClient(clientCtx, clientID)
+			}
+		})
+		if !started {
+			continue // Scaled down while waiting
+		}
+		if launched {
+			o.metrics.ClientStarted()
+			rate.launch()
+			o.metrics.SetRampRate(float64(o.rampScheduler.Rate()), rate.achieved())
+		}
 
 		// Update ramp progress
-		o.metrics.SetRampProgress(float64(i+1) / float64(o.config.Clients))
+		target := o.scaleTarget()
+		o.metrics.SetRampProgress(float64(i+1) / float64(target))
 
 		// Log progress periodically
-		if (i+1)%10 == 0 || i == o.config.Clients-1 {
+		if (i+1)%10 == 0 || i == target-1 {
 			o.logger.Info("ramp_progress",
 				"started", i+1,
-				"target", o.config.Clients,
+				"target", target,
 				"active", o.clientManager.ActiveCount(),
 			)
 		}
 	}
 
+	o.rampFinished()
 	o.logger.Info("ramp_complete",
-		"clients", o.config.Clients,
+		"clients", o.scaleTarget(),
 		"active", o.clientManager.ActiveCount(),
+		"rate", o.rampScheduler.Rate(),
+		"achieved_rate", fmt.Sprintf("%.1f", rate.achieved()),
 	)
 }
 
@@ -330,20 +934,65 @@ func (o *Orchestrator) rampUp(ctx context.Context) {
 func (o *Orchestrator) onStateChange(clientID int, oldState, newState supervisor.State) {
 	// Update active count metric
 	o.metrics.SetActiveCount(o.clientManager.ActiveCount())
+	if newState == supervisor.StateQuarantined || oldState == supervisor.StateQuarantined {
+		o.metrics.SetQuarantinedCount(o.clientManager.QuarantinedCount())
+	}
+}
+
+func (o *Orchestrator) onQuarantine(clientID int, failures int, cooldown time.Duration) {
+	o.metrics.RecordQuarantine()
+	o.emitLifecycle("client_quarantined", clientID, map[string]any{"failures": failures, "cooldown": cooldown.String()})
 }
 
 func (o *Orchestrator) onStart(clientID int, pid int) {
 	if o.config.Verbose {
 		o.logger.Debug("client_process_started", "client_id", clientID, "pid", pid)
 	}
+	if o.pcap != nil {
+		o.pcap.ClientStarted(clientID, pid)
+	}
+	o.emitLifecycle("client_start", clientID, map[string]any{"pid": pid})
+	if o.vodPlays != nil {
+		o.vodPlays.started(clientID)
+	}
 }
 
 func (o *Orchestrator) onExit(clientID int, exitCode int, uptime time.Duration) {
 	o.metrics.RecordExit(exitCode, uptime)
+	expected := o.stopping.Load() || o.clientManager.restarting(clientID) ||
+		(o.tenancy != nil && o.tenancy.expectedExit(clientID))
+	reason := stats.ClassifyExit(exitCode, expected)
+	o.metrics.RecordExitReason(reason)
+	o.emitLifecycle("client_exit", clientID, map[string]any{"exit_code": exitCode, "uptime": uptime.String(), "reason": reason})
+	if o.vodPlays != nil && o.stopping.Load() {
+		o.vodPlays.cutShort()
+	}
+}
+
+func (o *Orchestrator) onReauth(clientID int, latency time.Duration) {
+	o.metrics.RecordReauth(latency)
+	o.emitLifecycle("client_reauth", clientID, map[string]any{"latency": latency.String()})
+	if o.config.Verbose {
+		o.logger.Debug("client_reauthed", "client_id", clientID, "latency", latency.String())
+	}
+}
+
+func (o *Orchestrator) onTokenExpired(clientID int) {
+	o.metrics.RecordTokenExpiry()
+	o.emitLifecycle("client_token_expired", clientID, map[string]any{"ttl": o.config.TokenTTL.String()})
+}
+
+func (o *Orchestrator) onStopSignal(clientID int, sig syscall.Signal) {
+	o.metrics.RecordStopSignal(sig)
+	o.emitLifecycle("client_stop_signal", clientID, map[string]any{"signal": sig.String()})
+	if sig == syscall.SIGKILL {
+		o.logger.Warn("client_killed", "client_id", clientID, "stop_grace", o.config.StopGrace.String())
+	}
 }
 
 func (o *Orchestrator) onRestart(clientID int, attempt int, delay time.Duration) {
 	o.metrics.ClientRestarted()
+	o.emitLifecycle("client_restart", clientID, map[string]any{"attempt": attempt, "delay": delay.String()})
 
 	if o.config.Verbose {
 		o.logger.Debug("client_restart_scheduled",
@@ -354,20 +1003,40 @@ func (o *Orchestrator) onRestart(clientID int, attempt int, delay time.Duration)
 	}
 }
 
+// metricsEndpoints renders the metrics listen addresses for the summary.
+func metricsEndpoints(addrs []string) []string {
+	endpoints := make([]string, len(addrs))
+	for i, addr := range addrs {
+		endpoints[i] = metrics.Endpoint(addr)
+	}
+	return endpoints
+}
+
 // printExitSummary prints a summary of the load test run.
-func (o *Orchestrator) printExitSummary() {
+func (o *Orchestrator) printExitSummary(coolDown *stats.CoolDownSummary) {
 	metricsSummary := o.metrics.GenerateSummary()
 
 	// Build SummaryConfig from metrics collector data
 	cfg := stats.SummaryConfig{
-		TargetClients: metricsSummary.TargetClients,
-		Duration:      metricsSummary.Duration,
-		MetricsAddr:   o.config.MetricsAddr,
-		TotalStarts:   int(metricsSummary.TotalStarts),
-		TotalRestarts: int(metricsSummary.TotalRestarts),
-		UptimeP50:     metricsSummary.UptimeP50,
-		UptimeP95:     metricsSummary.UptimeP95,
-		UptimeP99:     metricsSummary.UptimeP99,
+		TargetClients:    metricsSummary.TargetClients,
+		Duration:         metricsSummary.Duration,
+		MetricsEndpoints: metricsEndpoints(o.config.MetricsAddrs),
+		TotalStarts:      int(metricsSummary.TotalStarts),
+		TotalRestarts:    int(metricsSummary.TotalRestarts),
+		Quarantines:      int(metricsSummary.Quarantines),
+		UptimeP50:        metricsSummary.UptimeP50,
+		UptimeP95:        metricsSummary.UptimeP95,
+		UptimeP99:        metricsSummary.UptimeP99,
+		UptimeExits:      metricsSummary.UptimeExits,
+		UptimeSampled:    metricsSummary.UptimeExits > 0 && !metricsSummary.UptimeComplete,
+		CoolDown:         coolDown,
+		NoKeepAlive:      o.config.NoKeepAlive,
+		Prefetch:         describePrefetch(o.config),
+		SlowRequest:      o.config.SlowRequestLog,
+		Pacing:           describePacing(o.config),
+	}
+	if coolDown != nil {
+		cfg.Duration -= coolDown.Elapsed // Report the load phase only
 	}
 
 	// Convert exit codes from int64 to int
@@ -377,18 +1046,235 @@ func (o *Orchestrator) printExitSummary() {
 			cfg.ExitCodes[code] = int(count)
 		}
 	}
+	if len(metricsSummary.ExitReasons) > 0 {
+		cfg.ExitReasons = make(map[string]int, len(metricsSummary.ExitReasons))
+		for reason, count := range metricsSummary.ExitReasons {
+			cfg.ExitReasons[reason] = int(count)
+		}
+	}
+	if len(metricsSummary.StopSignals) > 0 {
+		cfg.StopSignals = make(map[string]int, len(metricsSummary.StopSignals))
+		for name, count := range metricsSummary.StopSignals {
+			cfg.StopSignals[name] = int(count)
+		}
+	}
+
+	if o.playlistMon != nil {
+		cfg.PlaylistValidation = true
+		cfg.PlaylistViolations = o.playlistMon.Violations()
+		cfg.PlaylistFetchErrors = o.playlistMon.FetchErrors()
+		if encodings := o.playlistMon.Encodings(); len(encodings) > 0 {
+			cfg.PlaylistEncodings = make(map[string]stats.PlaylistEncoding, len(encodings))
+			for enc, es := range encodings {
+				cfg.PlaylistEncodings[enc] = stats.PlaylistEncoding(es)
+			}
+		}
+		if timings := o.playlistMon.SegmentTimings(); len(timings) > 0 {
+			cfg.PlaylistTimings = make(map[string]stats.PlaylistTiming, len(timings))
+			for url, t := range timings {
+				cfg.PlaylistTimings[url] = stats.PlaylistTiming(t)
+			}
+		}
+	}
+	if o.tenancy != nil {
+		cfg.Tenants = o.tenancy.summaries()
+	}
+	if o.geos != nil {
+		cfg.Geos = o.geos.summaries()
+	}
+	if o.variants != nil {
+		cfg.Variants = o.variants.summaries()
+	}
+	if o.renditions != nil {
+		cfg.Renditions = o.renditions.summary()
+	}
+	if o.compare != nil {
+		cfg.Compare = o.compare.comparison(time.Since(o.startTime))
+	}
+	if o.config.PcapDir != "" {
+		cfg.Capture = o.pcapSummary()
+	}
+	if o.config.SocketStats {
+		cfg.Sockets = o.socketSummary()
+	}
+	if o.flaps != nil {
+		cfg.Flaps = o.flaps.summary(metricsSummary)
+	}
+	if o.bursts != nil {
+		cfg.Bursts = o.bursts.summary()
+	}
+	if o.sessions != nil {
+		cfg.Sessions = o.sessions.summary()
+	}
+	if o.vodStarts != nil {
+		cfg.VODStarts = o.vodStarts.summary()
+	}
+	if o.vodPlays != nil {
+		cfg.VODCompletion = o.vodPlays.summary()
+	}
+	cfg.ClientCPU = o.cpuGuard.summary()
+	cfg.Memory = o.memGuard.summary()
+	if o.reloader != nil {
+		cfg.RefreshOverride = o.reloader.summary()
+	}
 
 	// Get aggregated stats if stats collection is enabled
 	var aggregatedStats *stats.AggregatedStats
 	if o.config.StatsEnabled {
 		aggregatedStats = o.GetAggregatedStats()
+		ds := o.GetDebugStats()
+		if ds.ClientsWithDebugStats > 0 {
+			cfg.Debug = &ds
+		}
+		if o.slo != nil {
+			cfg.SLO = o.slo.final(ds)
+		}
+		if o.config.CDNDetect && cfg.Debug != nil {
+			cfg.Serving = stats.BreakdownServing(cfg.Debug.Serving, o.config.OriginHitAlert)
+		}
+		if rc := o.clientManager.ReconnectCauses(); rc.Total > 0 {
+			cfg.ReconnectCauses = &rc
+		}
+	}
+
+	if o.tokenSource != nil {
+		cfg.Tokens = &stats.TokenSummary{
+			Fetches:       metricsSummary.TokenFetches,
+			FetchFailures: metricsSummary.TokenFetchFailures,
+			Expiries:      metricsSummary.TokenExpiries,
+			Reauths:       metricsSummary.Reauths,
+			ReauthP50:     metricsSummary.ReauthP50,
+			ReauthP95:     metricsSummary.ReauthP95,
+			ReauthP99:     metricsSummary.ReauthP99,
+		}
+		if aggregatedStats != nil {
+			cfg.Tokens.HTTP401 = aggregatedStats.TotalHTTPErrors[http.StatusUnauthorized]
+		}
 	}
 
+	cfg.OriginLog = o.originLogSummary(aggregatedStats, cfg.Debug)
+
 	// Print the enhanced exit summary
-	fmt.Print(stats.FormatExitSummary(aggregatedStats, cfg))
+	fmt.Fprint(o.out, stats.FormatExitSummary(aggregatedStats, cfg))
 }
 
 
+// saveRun appends the run summary to the -save-run history file. Failures
+// are logged: the run itself succeeded.
+func (o *Orchestrator) saveRun(coolDown *stats.CoolDownSummary) {
+	if !o.config.SaveRun {
+		return
+	}
+
+	metricsSummary := o.metrics.GenerateSummary()
+	rec := &stats.RunRecord{
+		StartedAt:       o.startTime.Add(o.clockOffset).UTC(),
+		DurationSeconds: metricsSummary.Duration.Seconds(),
+		StreamURL:       o.config.StreamURL,
+		Variant:         o.config.Variant,
+		TargetClients:   metricsSummary.TargetClients,
+		PeakClients:     metricsSummary.PeakActiveClients,
+		Starts:          metricsSummary.TotalStarts,
+		Restarts:        metricsSummary.TotalRestarts,
+		FFmpegCommand:   process.NewFFmpegRunner(o.runner.Config()).CommandString(), // As -print-cmd, not the last client's
+		PlaylistRefresh: o.config.PlaylistRefresh,
+		RunTag:          o.runTag,
+		Timeline:        o.timeline,
+		SegmentHeatmap:  o.heatmap,
+		Events:          o.events.list(),
+	}
+	for _, w := range config.Warnings(o.config) {
+		rec.ConfigWarnings = append(rec.ConfigWarnings, stats.ConfigWarning{Field: w.Field, Message: w.Message, Suggestion: w.Suggestion})
+	}
+	if coolDown != nil {
+		rec.DurationSeconds -= coolDown.Elapsed.Seconds() // Load phase only, as in the summary
+	}
+	if o.config.StatsEnabled {
+		ds := o.GetDebugStats()
+		if ds.ClientsWithDebugStats == 0 {
+			rec.AddStats(o.GetAggregatedStats(), nil)
+		} else {
+			rec.AddStats(o.GetAggregatedStats(), &ds)
+		}
+		if o.slo != nil {
+			rec.SLO = o.slo.final(ds).Record()
+		}
+	}
+
+	if err := stats.AppendRun(o.config.RunsFile, rec); err != nil {
+		o.logger.Warn("run_save_failed", "path", o.config.RunsFile, "error", err)
+		return
+	}
+	fmt.Fprintf(o.out, "Run #%d saved to %s (compare with: go-ffmpeg-hls-swarm runs compare)\n", rec.ID, o.config.RunsFile)
+}
+
+// runStatusLine prints the compact status line until ctx is cancelled.
+// On a terminal the line is rewritten in place; otherwise (CI logs) one
+// line is appended per interval.
+func (o *Orchestrator) runStatusLine(ctx context.Context) {
+	overwrite := false
+	if f, ok := o.out.(*os.File); ok {
+		if info, err := f.Stat(); err == nil {
+			overwrite = info.Mode()&os.ModeCharDevice != 0
+		}
+	}
+
+	interval := o.config.StatusInterval
+	if interval == 0 {
+		interval = 10 * time.Second
+		if overwrite {
+			interval = time.Second
+		}
+	}
+
+	var debugSource tui.DebugStatsSource
+	if o.config.StatsEnabled {
+		debugSource = o
+	}
+	tui.RunStatusLine(ctx, tui.StatusLineConfig{
+		Out:              o.out,
+		Interval:         interval,
+		Overwrite:        overwrite,
+		TargetClients:    o.config.Clients,
+		StatsSource:      o,
+		DebugStatsSource: debugSource,
+	})
+}
+
+// loadTUIPrefs reads the saved TUI layout. Errors are logged and the
+// default layout is used: a bad prefs file must not block a test.
+func (o *Orchestrator) loadTUIPrefs() tui.Prefs {
+	if o.config.TUIPrefsPath == "" {
+		return tui.Prefs{}
+	}
+	prefs, err := tui.LoadPrefs(o.config.TUIPrefsPath)
+	if err != nil {
+		o.logger.Warn("tui_prefs_load_failed", "path", o.config.TUIPrefsPath, "error", err)
+	}
+	return prefs
+}
+
+// saveTUIPrefs persists the TUI layout on exit.
+func (o *Orchestrator) saveTUIPrefs(prefs tui.Prefs) {
+	if o.config.TUIPrefsPath == "" {
+		return
+	}
+	if err := tui.SavePrefs(o.config.TUIPrefsPath, prefs); err != nil {
+		o.logger.Warn("tui_prefs_save_failed", "path", o.config.TUIPrefsPath, "error", err)
+	}
+}
+
+// StartMetricsServer binds the metrics addresses and starts serving. Run
+// calls it too; main calls it first so the banner can show the bound
+// addresses, which differ from -metrics where that asks for a random port.
+func (o *Orchestrator) StartMetricsServer() error {
+	if err := o.metricsServer.Start(); err != nil {
+		return fmt.Errorf("%w: failed to start metrics server: %w", ErrEnvironment, err)
+	}
+	o.config.MetricsAddrs = o.metricsServer.Addrs()
+	return nil
+}
+
 // ClientManager returns the client manager for external access.
 func (o *Orchestrator) ClientManager() *ClientManager {
 	return o.clientManager
@@ -421,20 +1307,64 @@ func (o *Orchestrator) GetDebugStats() stats.DebugStatsAggregate {
 	return o.clientManager.GetDebugStats()
 }
 
+// SLOStatus returns where the -slo latency objective stands as of the last
+// metrics update (not Enabled without -slo).
+func (o *Orchestrator) SLOStatus() stats.SLOStatus {
+	if o.slo == nil {
+		return stats.SLOStatus{}
+	}
+	return o.slo.status()
+}
+
+// ProjectedCapacity returns the client count at which the segment wall
+// time P95 is projected to reach -capacity-p95, from the ramp so far.
+func (o *Orchestrator) ProjectedCapacity() stats.CapacityProjection {
+	if o.capacity == nil {
+		return stats.CapacityProjection{}
+	}
+	return o.capacity.projection()
+}
+
+// OriginComparison returns the -compare-url or -compare-opt comparison as
+// of the last metrics update (nil without either or before the first
+// update).
+func (o *Orchestrator) OriginComparison() *stats.OriginComparison {
+	if o.compare == nil {
+		return nil
+	}
+	return o.compare.latest.Load()
+}
+
 // runWithTUI runs the orchestrator with the TUI dashboard.
 func (o *Orchestrator) runWithTUI(ctx context.Context, cancel context.CancelFunc, sigCh <-chan os.Signal, durationTimer <-chan time.Time) {
+	// Restore saved layout; -tui-panels overrides it for this run only
+	prefs := o.loadTUIPrefs()
+	savedPanels := prefs.Panels
+	if o.config.TUIPanels != nil {
+		prefs.Panels = o.config.TUIPanels
+	}
+
+	if err := tui.SetTheme(o.config.TUITheme); err != nil {
+		o.logger.Warn("tui_theme_invalid", "error", err)
+	}
+
 	// Create TUI model
 	tuiModel := tui.New(tui.Config{
 		TargetClients:    o.config.Clients,
 		StreamURL:        o.config.StreamURL,
-		MetricsAddr:      o.config.MetricsAddr,
+		MetricsAddr:      strings.Join(o.config.MetricsAddrs, ", "),
 		StatsSource:      o,
 		DebugStatsSource: o,
+		CapacitySource:   o,
+		CompareSource:    o,
+		SLOSource:        o,
 		OriginScraper:    o.originScraper,
+		Prefs:            prefs,
+		RefreshInterval:  o.config.TUIRefreshInterval,
 	})
 
 	// Create Bubble Tea program
-	p := tea.NewProgram(tuiModel, tea.WithAltScreen())
+	p := tea.NewProgram(tuiModel, tea.WithAltScreen(), tea.WithOutput(o.out))
 
 	// Monitor for external quit signals in background
 	go func() {
@@ -452,17 +1382,26 @@ func (o *Orchestrator) runWithTUI(ctx context.Context, cancel context.CancelFunc
 	}()
 
 	// Run TUI (blocks until user quits or external signal)
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+	if err != nil {
 		o.logger.Error("tui_error", "error", err)
 	}
+	if m, ok := finalModel.(tui.Model); ok {
+		prefs := m.Prefs()
+		if o.config.TUIPanels != nil {
+			prefs.Panels = savedPanels
+		}
+		o.saveTUIPrefs(prefs)
+	}
 
 	// TUI has exited, trigger shutdown
+	o.stopping.Store(true)
 	cancel()
 }
 
 // statsUpdateLoop periodically updates Prometheus metrics from aggregated stats.
 func (o *Orchestrator) statsUpdateLoop(ctx context.Context) {
-	ticker := time.NewTicker(time.Second) // Update every second
+	ticker := time.NewTicker(o.config.MetricsUpdateInterval)
 	defer ticker.Stop()
 
 	for {
@@ -470,17 +1409,7 @@ func (o *Orchestrator) statsUpdateLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			aggStats := o.GetAggregatedStats()
-			if aggStats == nil {
-				continue
-			}
-
-			// Get debug stats for segment throughput (from segment scraper)
-			debugStats := o.GetDebugStats()
-
-			// Convert stats.AggregatedStats to metrics.AggregatedStatsUpdate
-			update := o.convertToMetricsUpdate(aggStats, &debugStats)
-			o.metrics.RecordStats(update)
+			o.updateMetrics()
 
 			// Also record latency samples to histogram
 			// Note: T-Digest percentiles are approximate, so we use the P50 as a proxy
@@ -490,9 +1419,77 @@ func (o *Orchestrator) statsUpdateLoop(ctx context.Context) {
 	}
 }
 
+// updateMetrics copies the current aggregated stats into Prometheus.
+func (o *Orchestrator) updateMetrics() {
+	aggStats := o.GetAggregatedStats()
+	if aggStats == nil {
+		return
+	}
+
+	// Get debug stats for segment throughput (from segment scraper)
+	debugStats := o.GetDebugStats()
+
+	// Convert stats.AggregatedStats to metrics.AggregatedStatsUpdate, with
+	// rates over this update's own interval
+	rates, _ := o.metricsRates.sample(aggStats, nil)
+	update := o.convertToMetricsUpdate(aggStats, rates, &debugStats)
+	o.metrics.RecordStats(update)
+	o.metrics.RecordShards(debugStats.ShardGroups)
+	if o.slo != nil {
+		o.metrics.RecordSLO(o.slo.update(time.Now(), debugStats.SegmentsDownloaded, debugStats.SLOMisses))
+	}
+	if o.config.CDNDetect {
+		o.recordServing(&debugStats)
+	}
+	if o.tenancy != nil {
+		o.metrics.RecordTenants(o.tenancy.metricsUpdates())
+	}
+	if o.geos != nil {
+		o.metrics.RecordGeos(o.geos.metricsUpdates())
+	}
+	if o.variants != nil {
+		o.metrics.RecordVariants(o.variants.metricsUpdates())
+	}
+	if o.compare != nil {
+		o.compare.refresh(time.Since(o.startTime))
+	}
+}
+
+// pushMetrics sends the final metrics to the Pushgateway, if configured.
+// The group is keyed by job, instance (the hostname unless given) and any
+// -pushgateway-labels, so each host of a multi-host run keeps its own.
+// Failures are logged: the run itself succeeded.
+func (o *Orchestrator) pushMetrics() {
+	if o.config.PushgatewayURL == "" {
+		return
+	}
+
+	// The update loop has stopped; record what happened since its last tick
+	if o.config.StatsEnabled {
+		o.updateMetrics()
+	}
+
+	labels := metrics.ParsePushLabels(o.config.PushgatewayLabels)
+	if _, ok := labels["instance"]; !ok {
+		if host, err := os.Hostname(); err == nil {
+			labels["instance"] = host
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cfg := metrics.PushConfig{URL: o.config.PushgatewayURL, Job: o.config.PushgatewayJob, Labels: labels}
+	if err := metrics.Push(ctx, cfg, o.gatherer); err != nil {
+		o.logger.Warn("pushgateway_push_failed", "error", err)
+		return
+	}
+	o.logger.Info("pushgateway_pushed", "url", cfg.URL, "job", cfg.Job, "labels", labels)
+}
+
 // convertToMetricsUpdate converts stats.AggregatedStats to metrics.AggregatedStatsUpdate.
-// debugStats is optional and provides segment throughput data from the segment scraper.
-func (o *Orchestrator) convertToMetricsUpdate(aggStats *stats.AggregatedStats, debugStats *stats.DebugStatsAggregate) *metrics.AggregatedStatsUpdate {
+// rates is the change since the previous update. debugStats is optional and
+// provides segment throughput data from the segment scraper.
+func (o *Orchestrator) convertToMetricsUpdate(aggStats *stats.AggregatedStats, rates stats.AggregatedDelta, debugStats *stats.DebugStatsAggregate) *metrics.AggregatedStatsUpdate {
 	update := &metrics.AggregatedStatsUpdate{
 		// Client counts
 		ActiveClients:  aggStats.ActiveClients,
@@ -506,13 +1503,15 @@ func (o *Orchestrator) convertToMetricsUpdate(aggStats *stats.AggregatedStats, d
 		TotalBytes:        aggStats.TotalBytes,
 
 		// Rates
-		ManifestReqRate:       aggStats.InstantManifestRate,
-		SegmentReqRate:        aggStats.InstantSegmentRate,
-		ThroughputBytesPerSec: aggStats.InstantThroughputRate,
+		ManifestReqRate:       rates.ManifestRate(),
+		SegmentReqRate:        rates.SegmentRate(),
+		ThroughputBytesPerSec: rates.ThroughputRate(),
 
 		// Errors
 		TotalHTTPErrors:    aggStats.TotalHTTPErrors,
+		TotalNetworkErrors: aggStats.TotalNetworkErrors,
 		TotalReconnections: aggStats.TotalReconnections,
+		ReconnectCauses:    o.clientManager.ReconnectCauses().Causes,
 		TotalTimeouts:      aggStats.TotalTimeouts,
 		ErrorRate:          aggStats.ErrorRate,
 
@@ -531,19 +1530,29 @@ func (o *Orchestrator) convertToMetricsUpdate(aggStats *stats.AggregatedStats, d
 		AverageDrift:         aggStats.AverageDrift,
 		MaxDrift:             aggStats.MaxDrift,
 
+		// Heartbeat
+		EventAgeP50: aggStats.EventAgeP50,
+		EventAgeP95: aggStats.EventAgeP95,
+		EventAgeMax: aggStats.EventAgeMax,
+		IdleClients: aggStats.IdleClients,
+
 		// Pipeline health
-		TotalLinesDropped: aggStats.TotalLinesDropped,
-		TotalLinesRead:    aggStats.TotalLinesRead,
-		ClientsWithDrops:  aggStats.ClientsWithDrops,
-		MetricsDegraded:   aggStats.MetricsDegraded,
-		PeakDropRate:      aggStats.PeakDropRate,
-
-		// Per-stream breakdown (approximation: assume 50/50 split)
-		// The aggregator doesn't track per-stream, but Prometheus needs it
-		ProgressLinesDropped: aggStats.TotalLinesDropped / 2,
-		ProgressLinesRead:    aggStats.TotalLinesRead / 2,
-		StderrLinesDropped:   aggStats.TotalLinesDropped - aggStats.TotalLinesDropped/2,
-		StderrLinesRead:      aggStats.TotalLinesRead - aggStats.TotalLinesRead/2,
+		TotalLinesDropped:   aggStats.TotalLinesDropped,
+		TotalLinesRead:      aggStats.TotalLinesRead,
+		ClientsWithDrops:    aggStats.ClientsWithDrops,
+		MetricsDegraded:     aggStats.MetricsDegraded,
+		PeakDropRate:        aggStats.PeakDropRate,
+		TotalLinesTruncated: aggStats.TotalLinesTruncated,
+		TotalParserPanics:   aggStats.TotalParserPanics,
+		TotalInvalidUTF8:    aggStats.TotalInvalidUTF8,
+
+		// Per-stream breakdown
+		ProgressLinesDropped: aggStats.ProgressLinesDropped,
+		ProgressLinesRead:    aggStats.ProgressLinesRead,
+		ProgressBytesRead:    aggStats.ProgressBytesRead,
+		StderrLinesDropped:   aggStats.StderrLinesDropped,
+		StderrLinesRead:      aggStats.StderrLinesRead,
+		StderrBytesRead:      aggStats.StderrBytesRead,
 
 		// Note: Uptime percentiles are tracked separately by metrics.Collector
 		// via RecordExit() calls, not from aggregated stats
@@ -556,17 +1565,42 @@ func (o *Orchestrator) convertToMetricsUpdate(aggStats *stats.AggregatedStats, d
 		update.SegmentThroughputAvg30s = debugStats.SegmentThroughputAvg30s
 		update.SegmentThroughputAvg60s = debugStats.SegmentThroughputAvg60s
 		update.SegmentThroughputAvg300s = debugStats.SegmentThroughputAvg300s
+
+		update.TotalDiscontinuities = debugStats.Discontinuities
+		update.TotalAdBreaks = debugStats.AdBreaks
+		update.TotalSlowSegments = debugStats.SlowSegments
+		update.SegmentDownloadP50 = debugStats.SegmentDownloadP50
+		update.SegmentDownloadP95 = debugStats.SegmentDownloadP95
+		update.SegmentDownloadP99 = debugStats.SegmentDownloadP99
+		update.SegmentGapAvg = time.Duration(debugStats.SegmentGapAvgMs * float64(time.Millisecond))
+		update.TotalSlowManifests = debugStats.SlowManifests
+		update.PendingEntries = debugStats.PendingEntries
+		update.TotalOrphanedPending = debugStats.OrphanedPending
+		update.ClockSkewMin = debugStats.ClockSkewMin
+		update.ClockSkewMax = debugStats.ClockSkewMax
+		update.PlaylistRefresh = debugStats.PlaylistRefresh
+		update.ClientsRecovering = debugStats.ClientsRecovering
+		update.RecoveryAvg = time.Duration(debugStats.RecoveryAvgMs * float64(time.Millisecond))
+		update.RecoveryMax = time.Duration(debugStats.RecoveryMaxMs * float64(time.Millisecond))
 	}
 
-	// Add per-client stats if enabled
-	if o.metrics.PerClientEnabled() && len(aggStats.PerClientSummaries) > 0 {
-		update.PerClientStats = make([]metrics.PerClientStatsUpdate, len(aggStats.PerClientSummaries))
-		for i, summary := range aggStats.PerClientSummaries {
+	// Add per-client stats if enabled. Aggregate doesn't fill in the
+	// summaries, so they come from the aggregator itself.
+	if o.metrics.PerClientEnabled() {
+		summaries := aggStats.PerClientSummaries
+		if len(summaries) == 0 && o.clientManager != nil {
+			if agg := o.GetStatsAggregator(); agg != nil {
+				summaries = agg.GetAllClientSummaries()
+			}
+		}
+		update.PerClientStats = make([]metrics.PerClientStatsUpdate, len(summaries))
+		for i, summary := range summaries {
 			update.PerClientStats[i] = metrics.PerClientStatsUpdate{
 				ClientID:     summary.ClientID,
 				CurrentSpeed: summary.CurrentSpeed,
 				CurrentDrift: summary.CurrentDrift,
 				TotalBytes:   summary.TotalBytes,
+				EventAge:     summary.EventAge,
 			}
 		}
 	}
@@ -116,9 +116,7 @@ func TestClientManager_NilSegmentSizeLookupSafe(t *testing.T) {
 		nil,
 		nil, // nil SegmentSizeLookup - should be safe
 	)
-	cm.debugMu.Lock()
-	cm.debugParsers[1] = debugParser
-	cm.debugMu.Unlock()
+	cm.addDebugParser(1, debugParser)
 
 	// Parse events that would trigger segment size lookup
 	debugParser.ParseLine("[hls @ 0x123] HLS request for url 'http://example.com/segment0.ts', offset 0, playlist 0")
@@ -173,9 +171,7 @@ func TestClientManager_WithSegmentSizeLookup(t *testing.T) {
 		nil,
 		lookup, // Working lookup
 	)
-	cm.debugMu.Lock()
-	cm.debugParsers[1] = debugParser
-	cm.debugMu.Unlock()
+	cm.addDebugParser(1, debugParser)
 
 	// Parse events that trigger segment size lookup
 	debugParser.ParseLine("[hls @ 0x123] HLS request for url 'http://example.com/segment0.ts', offset 0, playlist 0")
@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/originlog"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+const (
+	// originLogTimeout bounds reading the -origin-log after the run.
+	originLogTimeout = 2 * time.Minute
+
+	// originLogSkew widens the Loki query range on both sides, for clock
+	// differences between this host and the origin.
+	originLogSkew = time.Minute
+)
+
+// originLogSummary reads the run's tagged requests from the -origin-log
+// and sets them against the clients' own measurements (agg and debug,
+// nil without -stats). Returns nil without -origin-log.
+func (o *Orchestrator) originLogSummary(agg *stats.AggregatedStats, debug *stats.DebugStatsAggregate) *stats.OriginLogSummary {
+	if o.config.OriginLog == "" {
+		return nil
+	}
+
+	j := originlog.NewJoin(o.runTag)
+	var err error
+	if o.config.OriginLogLoki() {
+		ctx, cancel := context.WithTimeout(context.Background(), originLogTimeout)
+		defer cancel()
+		err = originlog.ReadLoki(ctx, &http.Client{Timeout: originLogTimeout}, o.config.OriginLog, o.config.OriginLogQuery,
+			o.startTime.Add(o.clockOffset-originLogSkew), time.Now().Add(o.clockOffset+originLogSkew), j)
+	} else {
+		err = originlog.ReadFile(o.config.OriginLog, j)
+	}
+	s := &stats.OriginLogSummary{Source: o.config.OriginLog, Tag: o.runTag}
+	if err != nil {
+		o.logger.Warn("origin_log_failed", "source", o.config.OriginLog, "error", err)
+		s.Error = err.Error()
+		return s
+	}
+	o.logger.Info("origin_log_read", "source", o.config.OriginLog, "tag", o.runTag, "requests", j.Requests)
+	return joinSummary(s, j, agg, debug)
+}
+
+// joinSummary fills s from the origin log join and the clients' stats.
+func joinSummary(s *stats.OriginLogSummary, j *originlog.Join, agg *stats.AggregatedStats, debug *stats.DebugStatsAggregate) *stats.OriginLogSummary {
+	s.Requests = j.Requests
+	s.Segments = j.Segments
+	s.Manifests = j.Manifests
+	s.Untimed = j.Untimed
+	s.Status = j.Status
+	s.Clients = j.Clients()
+	s.OriginSegmentP50 = j.SegmentLatency(0.50)
+	s.OriginSegmentP95 = j.SegmentLatency(0.95)
+	s.OriginSegmentP99 = j.SegmentLatency(0.99)
+	s.OriginManifestP50 = j.ManifestLatency(0.50)
+	s.OriginManifestP99 = j.ManifestLatency(0.99)
+
+	if agg != nil {
+		s.ClientRequests = agg.TotalSegmentReqs + agg.TotalManifestReqs
+	}
+	if debug != nil {
+		s.ClientSegmentP50 = debug.SegmentWallTimeP50
+		s.ClientSegmentP95 = debug.SegmentWallTimeP95
+		s.ClientSegmentP99 = debug.SegmentWallTimeP99
+		s.ClientManifestP50 = debug.ManifestWallTimeP50
+		s.ClientManifestP99 = debug.ManifestWallTimeP99
+	}
+	return s
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func originLogOrchestrator(cfg *config.Config) *Orchestrator {
+	return &Orchestrator{
+		config:    cfg,
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		out:       io.Discard,
+		runTag:    "run-1a2b3c4d",
+		startTime: time.Now().Add(-time.Minute),
+	}
+}
+
+const testAccessLog = `10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /live/seg_1.ts HTTP/1.1" 200 1048576 "-" "go-ffmpeg-hls-swarm/1.0/run-1a2b3c4d/client-1" 0.020
+10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /live/seg_1.ts HTTP/1.1" 200 1048576 "-" "go-ffmpeg-hls-swarm/1.0/run-ffffffff/client-1" 0.900
+10.0.0.9 - - [15/Oct/2026:10:00:01 +0000] "GET /live/stream.m3u8 HTTP/1.1" 200 512 "-" "go-ffmpeg-hls-swarm/1.0/run-1a2b3c4d/client-2" 0.002
+10.0.0.9 - - [15/Oct/2026:10:00:02 +0000] "GET /live/seg_2.ts HTTP/1.1" 503 0 "-" "go-ffmpeg-hls-swarm/1.0/run-1a2b3c4d/client-2" 0.001
+`
+
+func TestOriginLogSummary_File(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(name, []byte(testAccessLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.OriginLog = name
+	o := originLogOrchestrator(cfg)
+
+	agg := &stats.AggregatedStats{TotalSegmentReqs: 3, TotalManifestReqs: 1}
+	debug := &stats.DebugStatsAggregate{SegmentWallTimeP50: 120 * time.Millisecond, ManifestWallTimeP50: 30 * time.Millisecond}
+	s := o.originLogSummary(agg, debug)
+	if s == nil || s.Error != "" {
+		t.Fatalf("originLogSummary() = %+v", s)
+	}
+	if s.Tag != "run-1a2b3c4d" || s.Requests != 3 || s.Segments != 2 || s.Manifests != 1 || s.Clients != 2 {
+		t.Errorf("summary = %+v, want this run's 3 requests from 2 clients", s)
+	}
+	if s.Status[2] != 2 || s.Status[5] != 1 {
+		t.Errorf("Status = %v, want 2 2xx and 1 5xx", s.Status)
+	}
+	if s.ClientRequests != 4 || s.ClientSegmentP50 != 120*time.Millisecond || s.ClientManifestP50 != 30*time.Millisecond {
+		t.Errorf("client side = %d requests, %v, %v", s.ClientRequests, s.ClientSegmentP50, s.ClientManifestP50)
+	}
+	if s.OriginManifestP50 != 2*time.Millisecond {
+		t.Errorf("OriginManifestP50 = %v, want 2ms", s.OriginManifestP50)
+	}
+
+	// Without -stats only the origin's side
+	s = o.originLogSummary(nil, nil)
+	if s.Requests != 3 || s.ClientRequests != 0 || s.ClientSegmentP50 != 0 {
+		t.Errorf("summary without stats = %+v", s)
+	}
+}
+
+func TestOriginLogSummary_Loki(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("query"); q != `{job="nginx"} |= "run-1a2b3c4d"` {
+			t.Errorf("query = %q", q)
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"streams","result":[{"stream":{},"values":[`)
+		for i, line := range strings.Split(strings.TrimSpace(testAccessLog), "\n") {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `["%d",%q]`, 1000+i, line)
+		}
+		fmt.Fprint(w, `]}]}}`)
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.OriginLog = srv.URL
+	cfg.OriginLogQuery = `{job="nginx"}`
+	s := originLogOrchestrator(cfg).originLogSummary(nil, nil)
+	if s.Error != "" || s.Requests != 3 {
+		t.Errorf("summary = %+v, want 3 requests", s)
+	}
+}
+
+func TestOriginLogSummary_Errors(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if s := originLogOrchestrator(cfg).originLogSummary(nil, nil); s != nil {
+		t.Errorf("originLogSummary() without -origin-log = %+v, want nil", s)
+	}
+
+	cfg.OriginLog = filepath.Join(t.TempDir(), "rotated-away.log")
+	s := originLogOrchestrator(cfg).originLogSummary(nil, nil)
+	if s == nil || s.Error == "" || s.Source != cfg.OriginLog {
+		t.Errorf("originLogSummary() of a missing log = %+v, want an error", s)
+	}
+}
@@ -0,0 +1,49 @@
+package orchestrator
+
+import (
+	"log/slog"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// maxOrphansLogged bounds the orphans logged one by one.
+const maxOrphansLogged = 10
+
+// sweepOrphans looks for FFmpegs left behind by earlier runs that crashed
+// before stopping their clients. They still load the origin and use up
+// process and FD limits, so they're reported and, with -kill-orphans,
+// killed. Returns how many were killed.
+func sweepOrphans(
+	logger *slog.Logger,
+	kill bool,
+	find func() ([]supervisor.Orphan, error),
+	killAll func([]supervisor.Orphan) (int, error),
+) int {
+	orphans, err := find()
+	if err != nil {
+		logger.Debug("orphan_sweep_skipped", "error", err)
+		return 0
+	}
+	if len(orphans) == 0 {
+		return 0
+	}
+
+	for i, o := range orphans {
+		if i == maxOrphansLogged {
+			logger.Warn("orphaned_process", "more", len(orphans)-i)
+			break
+		}
+		logger.Warn("orphaned_process", "pid", o.PID, "pgid", o.PGID, "owner_pid", o.Owner, "command", o.Command)
+	}
+	if !kill {
+		logger.Warn("orphaned_processes_found", "count", len(orphans), "hint", "use -kill-orphans to kill them")
+		return 0
+	}
+
+	killed, err := killAll(orphans)
+	if err != nil {
+		logger.Warn("orphan_kill_failed", "error", err)
+	}
+	logger.Info("orphaned_processes_killed", "count", killed)
+	return killed
+}
@@ -0,0 +1,74 @@
+package orchestrator
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+func TestSweepOrphans(t *testing.T) {
+	orphans := []supervisor.Orphan{
+		{PID: 100, PGID: 100, Owner: 42, Command: "ffmpeg -i a"},
+		{PID: 101, PGID: 101, Owner: 42, Command: "ffmpeg -i b"},
+	}
+
+	tests := []struct {
+		name       string
+		found      []supervisor.Orphan
+		findErr    error
+		kill       bool
+		wantKilled int
+		wantCalled bool
+		wantLog    string
+	}{
+		{"none", nil, nil, true, 0, false, ""},
+		{"no /proc", nil, errors.New("list processes: no such file"), true, 0, false, "orphan_sweep_skipped"},
+		{"report only", orphans, nil, false, 0, false, "use -kill-orphans"},
+		{"kill", orphans, nil, true, 2, true, "orphaned_processes_killed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			called := false
+
+			killed := sweepOrphans(logger, tt.kill,
+				func() ([]supervisor.Orphan, error) { return tt.found, tt.findErr },
+				func(o []supervisor.Orphan) (int, error) {
+					called = true
+					return len(o), nil
+				},
+			)
+
+			if killed != tt.wantKilled || called != tt.wantCalled {
+				t.Errorf("sweepOrphans() = %d (kill called %v), want %d (%v)", killed, called, tt.wantKilled, tt.wantCalled)
+			}
+			if tt.wantLog != "" && !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("logs missing %q:\n%s", tt.wantLog, logs.String())
+			}
+			if len(tt.found) > 0 && !strings.Contains(logs.String(), `command="ffmpeg -i b"`) {
+				t.Errorf("orphans not logged:\n%s", logs.String())
+			}
+		})
+	}
+}
+
+func TestSweepOrphans_BoundsLogging(t *testing.T) {
+	orphans := make([]supervisor.Orphan, maxOrphansLogged+5)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	sweepOrphans(logger, false, func() ([]supervisor.Orphan, error) { return orphans, nil }, nil)
+
+	if got := strings.Count(logs.String(), "msg=orphaned_process "); got != maxOrphansLogged+1 {
+		t.Errorf("logged %d orphan lines, want %d", got, maxOrphansLogged+1)
+	}
+	if !strings.Contains(logs.String(), "more=5") {
+		t.Errorf("missing overflow count:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,72 @@
+package orchestrator
+
+import (
+	"strings"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+// processPacing returns the FFmpeg pacing for a -pacing spec (validated).
+func processPacing(spec string) process.Pacing {
+	model, buffer, _ := config.ParsePacing(spec)
+	switch model {
+	case config.PacingRealtime:
+		return process.Pacing{Realtime: true}
+	case config.PacingBuffer:
+		return process.Pacing{Realtime: true, Burst: buffer}
+	}
+	return process.Pacing{}
+}
+
+// clientPacing returns each client's pacing
+// (process.FFmpegConfig.ClientPacing): its -geo cohort's where set, nil for
+// the global one. Returns nil if no cohort sets one.
+func clientPacing(cfg *config.Config) func(clientID int) *process.Pacing {
+	perGeo := make([]*process.Pacing, len(cfg.Geos))
+	set := false
+	for i, g := range cfg.Geos {
+		if g.Pacing != "" {
+			p := processPacing(g.Pacing)
+			perGeo[i] = &p
+			set = true
+		}
+	}
+	if !set {
+		return nil
+	}
+
+	assigned := assignGeos(cfg.Geos, cfg.Clients)
+	return func(clientID int) *process.Pacing {
+		if clientID < 0 || clientID >= len(assigned) {
+			return nil
+		}
+		return perGeo[assigned[clientID]]
+	}
+}
+
+// describePacing names the pacing models in use for the run record:
+// "fast", "realtime", "buffer:10s", with any cohort overrides in brackets,
+// e.g. "fast (eu=realtime)".
+func describePacing(cfg *config.Config) string {
+	desc := pacingName(cfg.Pacing)
+	var geos []string
+	for _, g := range cfg.Geos {
+		if g.Pacing != "" {
+			geos = append(geos, g.Name+"="+pacingName(g.Pacing))
+		}
+	}
+	if len(geos) > 0 {
+		desc += " (" + strings.Join(geos, ", ") + ")"
+	}
+	return desc
+}
+
+// pacingName returns a -pacing spec (validated) in canonical form.
+func pacingName(spec string) string {
+	model, buffer, _ := config.ParsePacing(spec)
+	if model == config.PacingBuffer {
+		return model + ":" + buffer.String()
+	}
+	return model
+}
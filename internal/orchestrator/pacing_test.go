@@ -0,0 +1,84 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+func TestProcessPacing(t *testing.T) {
+	for spec, want := range map[string]process.Pacing{
+		"":            {},
+		"fast":        {},
+		"realtime":    {Realtime: true},
+		"buffer:10s":  {Realtime: true, Burst: 10 * time.Second},
+		"buffer:1m0s": {Realtime: true, Burst: time.Minute},
+	} {
+		if got := processPacing(spec); got != want {
+			t.Errorf("processPacing(%q) = %+v, want %+v", spec, got, want)
+		}
+	}
+}
+
+func TestClientPacing(t *testing.T) {
+	t.Run("no cohorts", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Pacing = "realtime"
+		cfg.Geos = []config.Geo{{Name: "eu", Weight: 1}}
+		if clientPacing(cfg) != nil {
+			t.Error("clientPacing() != nil without a cohort -pacing")
+		}
+	})
+
+	t.Run("per geo", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Clients = 4
+		cfg.Geos = []config.Geo{
+			{Name: "eu", Weight: 1},
+			{Name: "mobile", Weight: 1, Pacing: "buffer:10s"},
+		}
+		pacing := clientPacing(cfg)
+
+		mobile := process.Pacing{Realtime: true, Burst: 10 * time.Second}
+		for clientID, want := range []*process.Pacing{nil, &mobile, nil, &mobile} {
+			got := pacing(clientID)
+			if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+				t.Errorf("client %d pacing = %v, want %v", clientID, got, want)
+			}
+		}
+		if got := pacing(99); got != nil {
+			t.Errorf("out of range client pacing = %+v, want nil (the global one)", *got)
+		}
+	})
+}
+
+func TestDescribePacing(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if got := describePacing(cfg); got != "fast" {
+		t.Errorf("describePacing() = %q, want fast", got)
+	}
+
+	cfg.Pacing = "realtime"
+	cfg.Geos = []config.Geo{
+		{Name: "eu", Weight: 1},
+		{Name: "mobile", Weight: 1, Pacing: "buffer:90s"},
+		{Name: "bots", Weight: 1, Pacing: "fast"},
+	}
+	if got, want := describePacing(cfg), "realtime (mobile=buffer:1m30s, bots=fast)"; got != want {
+		t.Errorf("describePacing() = %q, want %q", got, want)
+	}
+}
+
+func TestNewFFmpegConfig_Pacing(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Pacing = "buffer:5s"
+	ffmpegCfg := NewFFmpegConfig(cfg)
+	if want := (process.Pacing{Realtime: true, Burst: 5 * time.Second}); ffmpegCfg.Pacing != want {
+		t.Errorf("Pacing = %+v, want %+v", ffmpegCfg.Pacing, want)
+	}
+	if ffmpegCfg.ClientPacing != nil {
+		t.Error("ClientPacing set without a cohort -pacing")
+	}
+}
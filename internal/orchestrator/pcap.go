@@ -0,0 +1,62 @@
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/capture"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// setupPcap prepares the -pcap-dir capture. Capturing is a debugging aid:
+// when it isn't possible (no tcpdump, not Linux, no privileges) the run
+// goes ahead and the summary says why nothing was captured.
+func (o *Orchestrator) setupPcap() {
+	filter, err := capture.OriginFilter(o.config.StreamURL, o.config.ResolveIP)
+	if err == nil {
+		o.pcap, err = capture.New(capture.Config{
+			Dir:       o.config.PcapDir,
+			Filter:    filter,
+			SnapLen:   o.config.PcapSnapLen,
+			FileMB:    o.config.PcapFileMB,
+			Files:     o.config.PcapFiles,
+			ClientIDs: capture.SampleClients(o.config.Clients, o.config.PcapClients),
+			Logger:    o.logger,
+		})
+	}
+	if err != nil {
+		o.pcapErr = err.Error()
+		o.logger.Warn("pcap_disabled", "error", err)
+	}
+}
+
+// startPcap starts capturing before the first client connects.
+func (o *Orchestrator) startPcap(ctx context.Context) {
+	if err := o.pcap.Start(ctx); err != nil {
+		o.pcapErr = err.Error()
+		o.pcap = nil
+		o.logger.Warn("pcap_disabled", "error", err)
+		return
+	}
+	o.logger.Info("pcap_started", "dir", o.config.PcapDir, "clients", o.config.PcapClients)
+}
+
+// pcapSummary stops the capture, writing the per-client pcaps, and
+// describes it for the exit summary.
+func (o *Orchestrator) pcapSummary() *stats.CaptureSummary {
+	s := &stats.CaptureSummary{Dir: o.config.PcapDir, Error: o.pcapErr}
+	if o.pcap == nil {
+		return s
+	}
+
+	result := o.pcap.Stop()
+	s.RingFiles, s.RingBytes = len(result.RingFiles), result.RingBytes
+	for _, c := range result.Clients {
+		s.Clients = append(s.Clients, stats.CapturedClient{
+			ClientID:    c.ClientID,
+			Connections: len(c.Conns),
+			File:        c.File,
+			Filter:      c.Filter(),
+		})
+	}
+	return s
+}
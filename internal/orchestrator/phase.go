@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/eventsink"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/logging"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+// Phase is a stage of a run's lifecycle. A run goes starting -> ramping
+// -> steady -> draining -> stopped; Scale takes a steady run back to
+// ramping while it adds clients, and a failed setup goes from starting
+// straight to stopped.
+type Phase string
+
+const (
+	PhaseStarting Phase = "starting" // Preflight, probes, -start-at wait, baseline
+	PhaseRamping  Phase = "ramping"  // Clients being started at the ramp rate
+	PhaseSteady   Phase = "steady"   // Every wanted client started
+	PhaseDraining Phase = "draining" // Clients stopping, then -cool-down
+	PhaseStopped  Phase = "stopped"  // Run returned
+)
+
+// PhaseEvent is a phase change, passed to OnPhase handlers.
+type PhaseEvent struct {
+	Phase    Phase
+	Previous Phase // "" for the first event
+	Time     time.Time
+	Clients  int // Wanted clients at the change
+}
+
+// phases tracks the run's phase, exports it as hls_swarm_phase and passes
+// each change to the OnPhase handlers. Handlers run one event at a time on
+// their own goroutine, so they may call back into the Orchestrator (Scale,
+// say) without deadlocking the change that triggered them.
+type phases struct {
+	metrics *metrics.Collector
+	logger  *slog.Logger
+	run     *logging.Run        // Phase carried by log records (see SetRun); may be nil
+	sink    *eventsink.Streamer // Phase changes streamed to -event-sink; may be nil
+
+	mu       sync.Mutex
+	current  Phase
+	handlers []func(PhaseEvent)
+	pending  []PhaseEvent  // Not yet passed to the handlers
+	wake     chan struct{} // Signals pending events; nil unless dispatching
+}
+
+func newPhases(collector *metrics.Collector, logger *slog.Logger) *phases {
+	return &phases{metrics: collector, logger: logger}
+}
+
+// start begins passing events to the handlers, until PhaseStopped.
+func (p *phases) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.handlers) == 0 {
+		return
+	}
+	p.wake = make(chan struct{}, 1)
+	go p.dispatch(p.wake, p.handlers)
+}
+
+func (p *phases) dispatch(wake <-chan struct{}, handlers []func(PhaseEvent)) {
+	for range wake {
+		p.mu.Lock()
+		events := p.pending
+		p.pending = nil
+		p.mu.Unlock()
+
+		for _, ev := range events {
+			for _, h := range handlers {
+				h(ev)
+			}
+			if ev.Phase == PhaseStopped {
+				return
+			}
+		}
+	}
+}
+
+// set moves the run to phase if it isn't there already.
+func (p *phases) set(phase Phase, clients int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.setLocked(phase, clients)
+}
+
+func (p *phases) setLocked(phase Phase, clients int) {
+	if phase == p.current || p.current == PhaseStopped {
+		return
+	}
+	if p.current == PhaseDraining && phase != PhaseStopped {
+		return // A ramp that lost the race with the stop
+	}
+	ev := PhaseEvent{Phase: phase, Previous: p.current, Time: time.Now(), Clients: clients}
+	p.current = phase
+	if p.run != nil {
+		p.run.SetPhase(string(phase))
+	}
+	p.metrics.SetPhase(string(phase))
+	p.logger.Info("phase", "phase", phase, "previous", ev.Previous, "clients", clients)
+	if p.sink != nil {
+		p.sink.Emit(sinkEvent(p.run, eventsink.KindLifecycle, "phase", -1, ev.Time,
+			map[string]any{"phase": string(phase), "previous": string(ev.Previous), "clients": clients}))
+	}
+
+	if p.wake != nil {
+		p.pending = append(p.pending, ev)
+		select {
+		case p.wake <- struct{}{}:
+		default: // Already signalled
+		}
+		if phase == PhaseStopped {
+			p.wake = nil
+		}
+	}
+}
+
+// get returns the current phase ("" before Run).
+func (p *phases) get() Phase {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current
+}
+
+// OnPhase registers fn to be called with every phase change of Run,
+// starting with PhaseStarting and ending with PhaseStopped. Register
+// handlers before Run. fn is called on a goroutine of its own, one event
+// at a time and in order, so it may lag the run: Run can return before
+// the PhaseStopped call.
+func (o *Orchestrator) OnPhase(fn func(PhaseEvent)) {
+	o.phases.mu.Lock()
+	defer o.phases.mu.Unlock()
+	o.phases.handlers = append(o.phases.handlers, fn)
+}
+
+// SetRun has the run's phase changes set run's phase, so the records of a
+// logger from logging.WithRun(logger, run) carry the phase they were
+// logged in. Call it before Run.
+func (o *Orchestrator) SetRun(run *logging.Run) {
+	o.phases.mu.Lock()
+	defer o.phases.mu.Unlock()
+	o.phases.run = run
+}
+
+// Phase returns the run's current lifecycle phase ("" before Run).
+func (o *Orchestrator) Phase() Phase {
+	return o.phases.get()
+}
+
+// rampFinished moves a ramping run to steady, unless a Scale has started
+// another ramp since.
+func (o *Orchestrator) rampFinished() {
+	o.phases.mu.Lock()
+	defer o.phases.mu.Unlock()
+	o.scale.mu.Lock()
+	ramping, target := o.scale.ramping, o.scale.target
+	o.scale.mu.Unlock()
+	if !ramping && o.phases.current == PhaseRamping {
+		o.phases.setLocked(PhaseSteady, target)
+	}
+}
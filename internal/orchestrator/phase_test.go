@@ -0,0 +1,112 @@
+package orchestrator
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/logging"
+)
+
+func TestPhases(t *testing.T) {
+	o := newScaleOrchestrator(2)
+	events := make(chan PhaseEvent, 16)
+	o.OnPhase(func(ev PhaseEvent) { events <- ev })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		o.clientManager.Shutdown(shutdownCtx)
+	}()
+
+	// As Run drives them
+	o.phases.start()
+	o.phases.set(PhaseStarting, 2)
+	o.scale.ctx, o.scale.ramping = ctx, true
+	o.rampUp(ctx)
+	if got := o.Phase(); got != PhaseSteady {
+		t.Fatalf("Phase() after the ramp = %q, want steady", got)
+	}
+
+	// A Scale ramps again
+	if err := o.Scale(3); err != nil {
+		t.Fatalf("Scale(3) = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for (o.RunningClients() < 3 || o.Phase() != PhaseSteady) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	o.phases.set(PhaseDraining, 3)
+	o.phases.set(PhaseRamping, 3) // Late ramp: ignored
+	o.phases.set(PhaseStopped, 3)
+	o.phases.set(PhaseStarting, 3) // After the run: ignored
+
+	want := []Phase{PhaseStarting, PhaseRamping, PhaseSteady, PhaseRamping, PhaseSteady, PhaseDraining, PhaseStopped}
+	var got []Phase
+	var last PhaseEvent
+	for len(got) < len(want) {
+		select {
+		case ev := <-events:
+			if ev.Previous != last.Phase {
+				t.Errorf("event %q: Previous = %q, want %q", ev.Phase, ev.Previous, last.Phase)
+			}
+			got = append(got, ev.Phase)
+			last = ev
+		case <-time.After(5 * time.Second):
+			t.Fatalf("phases = %v, want %v", got, want)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("phases = %v, want %v", got, want)
+	}
+	if last.Clients != 3 {
+		t.Errorf("stopped event Clients = %d, want 3", last.Clients)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("event %q after stopped", ev.Phase)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestPhases_Callback(t *testing.T) {
+	// A handler may call back into the orchestrator
+	o := newScaleOrchestrator(1)
+	done := make(chan Phase, 1)
+	o.OnPhase(func(ev PhaseEvent) {
+		if ev.Phase == PhaseStopped {
+			done <- o.Phase()
+		}
+	})
+	o.phases.start()
+	o.phases.set(PhaseStarting, 1)
+	o.phases.set(PhaseStopped, 1)
+	select {
+	case got := <-done:
+		if got != PhaseStopped {
+			t.Errorf("Phase() in the handler = %q, want stopped", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
+func TestPhases_SetRun(t *testing.T) {
+	o := newScaleOrchestrator(1)
+	run := logging.NewRun()
+	o.SetRun(run)
+
+	o.phases.set(PhaseStarting, 1)
+	o.phases.set(PhaseDraining, 1)
+	if got := run.Phase(); got != string(PhaseDraining) {
+		t.Errorf("run.Phase() = %q, want draining", got)
+	}
+	o.phases.set(PhaseRamping, 1) // Ignored, so the run keeps its phase
+	if got := run.Phase(); got != string(PhaseDraining) {
+		t.Errorf("run.Phase() after an ignored change = %q, want draining", got)
+	}
+}
@@ -0,0 +1,624 @@
+package orchestrator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// Placeholders for what a run only learns once it starts: the run tag is
+// random, tokens come from -token-url and -variant-mix URLs from the
+// master playlist.
+const (
+	planRunTag = "<run-tag>"
+	planToken  = "<token>"
+)
+
+// planArgAbsent stands for an argument a client's command lacks.
+const planArgAbsent = "(absent)"
+
+// planScheduleRows is the number of ramp rows printed before the schedule
+// is summarised as milestones (a 1000-client plan should still fit a screen).
+const planScheduleRows = 10
+
+// Plan describes what a run would do, computed without starting any process
+// or touching the network. Used by --plan for review before a real run.
+type Plan struct {
+	Clients     int
+	RampRate    int
+	RampJitter  time.Duration
+	Duration    time.Duration
+	CoolDown    time.Duration
+	StreamURL   string
+	Variant     string
+	CPUAffinity string
+	StartAt     time.Time // Zero = start immediately
+
+	// StartOffsets[i] is when client i starts, relative to the first client.
+	StartOffsets []time.Duration
+
+	// CPUs[i] is client i's CPU set (nil when affinity is disabled or unavailable).
+	CPUs [][]int
+
+	// Cohorts[i] is client i's cohorts (nil without -geo, -tenants,
+	// -variant-mix or per-geo -pacing).
+	Cohorts []ClientCohort
+
+	// BaseCommand is the FFmpeg command line for client 0.
+	BaseCommand string
+
+	// ArgDiffs lists the arguments that vary between clients.
+	ArgDiffs []ArgDiff
+
+	// Steady-state estimates once every client is running.
+	TargetDuration  time.Duration
+	Playlists       int           // media playlists each client follows: its variant and -renditions
+	SegmentRate     float64       // segment requests/sec
+	PlaylistRate    float64       // playlist requests/sec, with -playlist-refresh and session starts
+	PlaylistRefresh time.Duration // how often a variant playlist is fetched (0 = VOD: once a session)
+	SessionRate     float64       // session starts/sec with -session-duration (0 = none)
+	SessionLength   time.Duration // mean -session-duration
+	Prefetch        int           // -prefetch (-1 = FFmpeg default)
+	PeakClients     int           // clients at the height of the -burst schedules
+	VOD             bool          // -vod-start or -vod-end: the stream is an asset
+	UnpacedClients  int           // fast-paced clients of a VOD asset, not in SegmentRate
+	BitrateKbps     int           // assumed per-client bitrate (0 = unknown)
+	BandwidthBps    float64       // aggregate bits/sec (0 if bitrate unknown)
+	RenditionsKnown bool          // false for -variant all or a -renditions "all" (count comes from the master playlist)
+}
+
+// ClientCohort is the cohorts of one client ("" where not in use).
+type ClientCohort struct {
+	Geo     string
+	Tenant  string
+	Variant string // -variant-mix share
+	Pacing  string
+}
+
+// ArgDiff is an FFmpeg argument whose value differs per client.
+type ArgDiff struct {
+	Flag    string
+	Samples []string // value for clients 0, 1, ... (up to 3)
+}
+
+// BuildPlan computes the launch plan for cfg. Fails only if a client's
+// command can't be built.
+func BuildPlan(cfg *config.Config) (*Plan, error) {
+	p := &Plan{
+		Clients:        cfg.Clients,
+		RampRate:       cfg.RampRate,
+		RampJitter:     cfg.RampJitter,
+		Duration:       cfg.Duration,
+		CoolDown:       cfg.CoolDown,
+		StreamURL:      cfg.StreamURL,
+		Variant:        cfg.Variant,
+		CPUAffinity:    cfg.CPUAffinity,
+		StartAt:        cfg.StartAt,
+		TargetDuration: cfg.TargetDuration,
+		BitrateKbps:    cfg.ExpectedBitrate,
+	}
+
+	// Ramp schedule (same arithmetic as rampUp: client 0 starts immediately)
+	scheduler := NewRampScheduler(cfg.RampRate, cfg.RampJitter)
+	p.StartOffsets = make([]time.Duration, cfg.Clients)
+	for i := 1; i < cfg.Clients; i++ {
+		p.StartOffsets[i] = scheduler.Offset(i, i)
+	}
+
+	// CPU assignment (best effort: a plan must never fail on topology)
+	if allocator, err := supervisor.NewCPUAllocator(supervisor.AffinityPolicy(cfg.CPUAffinity)); err == nil && allocator != nil {
+		p.CPUs = make([][]int, cfg.Clients)
+		for i := range p.CPUs {
+			p.CPUs[i] = allocator.CPUsFor(i)
+		}
+	}
+
+	// Per-client command lines
+	var err error
+	p.BaseCommand, p.ArgDiffs, err = planCommands(planFFmpegConfig(cfg), cfg.Clients)
+	if err != nil {
+		return nil, fmt.Errorf("plan: %w", err)
+	}
+
+	p.Cohorts = planCohorts(cfg)
+	p.estimateLoad(cfg)
+	return p, nil
+}
+
+// planCohorts returns each client's cohorts, assigned as the run assigns
+// them, or nil if all clients are alike.
+func planCohorts(cfg *config.Config) []ClientCohort {
+	pacing := clientPacing(cfg)
+	if cfg.Clients == 0 || len(cfg.Geos) == 0 && len(cfg.Tenants) == 0 && len(cfg.VariantMix) == 0 {
+		return nil
+	}
+
+	cohorts := make([]ClientCohort, cfg.Clients)
+	if len(cfg.Geos) > 0 {
+		for i, g := range assignGeos(cfg.Geos, cfg.Clients) {
+			geo := cfg.Geos[g]
+			cohorts[i].Geo = geo.Name
+			if pacing != nil {
+				cohorts[i].Pacing = pacingName(cmp.Or(geo.Pacing, cfg.Pacing))
+			}
+		}
+	}
+	for i, t := range assignTenants(cfg.Tenants) {
+		if i < len(cohorts) {
+			cohorts[i].Tenant = cfg.Tenants[t].Name
+		}
+	}
+	if len(cfg.VariantMix) > 0 {
+		for i, v := range assignVariants(cfg.VariantMix, cfg.Clients) {
+			cohorts[i].Variant = cfg.VariantMix[v].Variant
+		}
+	}
+	return cohorts
+}
+
+// estimateLoad fills in the steady-state estimates, with each feature
+// configured by the helpers the run uses: a client follows its variant's
+// playlist and its -renditions, reads one segment of each per target
+// duration (fast-paced clients of a VOD asset read as fast as the origin
+// serves instead), and fetches each live playlist once per target
+// duration, or per -playlist-refresh for the variant's. Every
+// -session-duration session fetches the playlists anew.
+func (p *Plan) estimateLoad(cfg *config.Config) {
+	p.Prefetch = cfg.Prefetch
+	p.VOD = cfg.VODStart != "" || cfg.VODEnd != ""
+	p.PeakClients = cfg.Clients
+	for _, b := range cfg.Bursts {
+		p.PeakClients += b.Clients // Overlapping bursts add up
+	}
+	if cfg.ExpectedBitrate > 0 {
+		p.BandwidthBps = float64(cfg.ExpectedBitrate) * 1000 * float64(cfg.Clients)
+	}
+
+	audio, subs, _ := config.ParseRenditions(cfg.Renditions) // Validated
+	p.RenditionsKnown = cfg.Variant != "all" || len(cfg.VariantMix) > 0
+	renditions := 0
+	for _, sel := range []string{audio, subs} {
+		if sel != "" {
+			renditions++
+			p.RenditionsKnown = p.RenditionsKnown && sel != config.RenditionsAll
+		}
+	}
+	p.Playlists = 1 + renditions
+
+	target := cfg.TargetDuration
+	if target <= 0 {
+		return
+	}
+	perTarget := 1 / target.Seconds()
+
+	// Segments: every playlist at the stream's rate, unless fast-paced
+	// through an asset
+	paced := cfg.Clients
+	if p.VOD {
+		pacing := clientPacing(cfg)
+		global := processPacing(cfg.Pacing)
+		for i := range cfg.Clients {
+			pc := &global
+			if pacing != nil && pacing(i) != nil {
+				pc = pacing(i)
+			}
+			if !pc.Realtime {
+				p.UnpacedClients++
+			}
+		}
+		paced -= p.UnpacedClients
+	}
+	p.SegmentRate = float64(paced*p.Playlists) * perTarget
+
+	// Playlists: live ones reloaded by FFmpeg every target duration, the
+	// variant's topped up by -playlist-refresh; an asset's only once
+	if !p.VOD {
+		p.PlaylistRefresh = target
+		variant := perTarget
+		if cfg.PlaylistRefresh > 0 {
+			variant += 1 / extraInterval(cfg.PlaylistRefresh, target).Seconds()
+			p.PlaylistRefresh = time.Duration(float64(time.Second) / variant)
+		}
+		p.PlaylistRate = float64(cfg.Clients) * (variant + float64(renditions)*perTarget)
+	}
+
+	// Session starts: the master playlist (the variant's playlist for a
+	// -variant-mix client) and every media playlist again
+	if sessions := newSessionTracker(cfg, nil, nil); sessions != nil {
+		p.SessionLength = sessions.meanLength()
+		p.SessionRate = float64(cfg.Clients) / p.SessionLength.Seconds()
+		perSession := p.Playlists
+		if len(cfg.VariantMix) == 0 {
+			perSession++
+		}
+		p.PlaylistRate += p.SessionRate * float64(perSession)
+	}
+}
+
+// planFFmpegConfig returns the runner config New would give the clients,
+// with the per-client hooks of the cohorts, and placeholders where the
+// run fills in something at start.
+func planFFmpegConfig(cfg *config.Config) *process.FFmpegConfig {
+	fc := NewFFmpegConfig(cfg)
+	applyCohorts(fc, newGeoMap(cfg.Geos, cfg.Clients, nil), newOriginCompare(cfg, nil))
+	if len(cfg.VariantMix) > 0 {
+		assigned := assignVariants(cfg.VariantMix, cfg.Clients)
+		fc.ClientURL = func(clientID int) string {
+			if clientID < 0 || clientID >= len(assigned) {
+				return ""
+			}
+			return "<" + cfg.VariantMix[assigned[clientID]].Variant + " media playlist>"
+		}
+	}
+	if cfg.TokenURL != "" {
+		fc.TokenSource = planTokenSource{}
+	}
+	if cfg.RunTagged() {
+		fc.RunTag = planRunTag
+	}
+	return fc
+}
+
+// planTokenSource stands in for -token-url: a plan doesn't fetch tokens.
+type planTokenSource struct{}
+
+func (planTokenSource) Token(context.Context, process.TemplateValues) (string, error) {
+	return planToken, nil
+}
+
+// planCommands returns client 0's command line and the arguments that differ
+// across the first few clients, built as the supervisors build them.
+func planCommands(ffmpegCfg *process.FFmpegConfig, clients int) (string, []ArgDiff, error) {
+	runner := process.NewFFmpegRunner(ffmpegCfg)
+	if ffmpegCfg.StatsEnabled {
+		runner.SetProgressFD(3) // What the supervisor does at runtime
+	}
+
+	samples := clients
+	if samples > 3 {
+		samples = 3
+	}
+
+	argv := make([][]string, samples)
+	for i := range argv {
+		cmd, err := runner.BuildCommand(context.Background(), i)
+		if err != nil {
+			return "", nil, fmt.Errorf("client %d command: %w", i, err)
+		}
+		argv[i] = cmd.Args[1:]
+	}
+	if len(argv) == 0 {
+		return runner.CommandString(), nil, nil
+	}
+
+	return ffmpegCfg.BinaryPath + " " + strings.Join(argv[0], " "), diffArgs(argv), nil
+}
+
+// planArg is one option of a command line: a flag and its value ("" for
+// a flag without one). A repeated flag is numbered by its occurrence, so
+// the nth -map of each client is compared with the others' nth.
+type planArg struct {
+	flag  string
+	nth   int
+	value string
+}
+
+// splitArgs splits a command line's arguments into options. A flag takes
+// the next argument as its value unless that is a flag itself; "-" (the
+// null output) and negative numbers are values.
+func splitArgs(args []string) []planArg {
+	var opts []planArg
+	seen := make(map[string]int)
+	for i := 0; i < len(args); i++ {
+		arg := planArg{flag: args[i]}
+		if i+1 < len(args) && !isFlag(args[i+1]) {
+			arg.value = args[i+1]
+			i++
+		}
+		arg.nth = seen[arg.flag]
+		seen[arg.flag]++
+		opts = append(opts, arg)
+	}
+	return opts
+}
+
+// isFlag reports whether a command line argument is an option name.
+func isFlag(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
+
+// diffArgs compares argument lists option by option, matched by flag
+// rather than position, so an option only some clients have doesn't shift
+// every later one. Options are listed in the order they first appear.
+func diffArgs(argv [][]string) []ArgDiff {
+	type key struct {
+		flag string
+		nth  int
+	}
+	var order []key
+	listed := make(map[key]bool)
+	values := make([]map[key]string, len(argv))
+	for i, args := range argv {
+		values[i] = make(map[key]string)
+		for _, a := range splitArgs(args) {
+			k := key{a.flag, a.nth}
+			if !listed[k] {
+				listed[k] = true
+				order = append(order, k)
+			}
+			values[i][k] = a.value
+		}
+	}
+
+	var diffs []ArgDiff
+	for _, k := range order {
+		d := ArgDiff{Flag: k.flag}
+		differs := false
+		for i := range argv {
+			v, ok := values[i][k]
+			if !ok {
+				v = planArgAbsent
+			}
+			d.Samples = append(d.Samples, v)
+			differs = differs || v != d.Samples[0]
+		}
+		if differs {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// RampDuration returns when the last client starts.
+func (p *Plan) RampDuration() time.Duration {
+	if len(p.StartOffsets) == 0 {
+		return 0
+	}
+	return p.StartOffsets[len(p.StartOffsets)-1]
+}
+
+// Print writes the plan in the same sectioned layout as the exit summary.
+func (p *Plan) Print(w io.Writer) {
+	rule := strings.Repeat("─", 79)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.Repeat("═", 79))
+	fmt.Fprintln(w, "                        go-ffmpeg-hls-swarm Launch Plan")
+	fmt.Fprintln(w, strings.Repeat("═", 79))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  Nothing will be started. Review, then re-run without --plan.")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Stream:                 %s\n", p.StreamURL)
+	fmt.Fprintf(w, "Variant:                %s\n", p.Variant)
+	fmt.Fprintf(w, "Target Clients:         %d\n", p.Clients)
+	if p.Duration > 0 {
+		fmt.Fprintf(w, "Run Duration:           %s\n", stats.FormatDuration(p.Duration))
+	} else {
+		fmt.Fprintf(w, "Run Duration:           until interrupted\n")
+	}
+	if p.CoolDown > 0 {
+		fmt.Fprintf(w, "Cool-down:              %s (clients stopped, origin probed)\n", stats.FormatDuration(p.CoolDown))
+	}
+	if !p.StartAt.IsZero() {
+		fmt.Fprintf(w, "Start At:               %s\n", p.StartAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintln(w)
+
+	// Ramp schedule
+	fmt.Fprintln(w, rule)
+	fmt.Fprintln(w, "                              Ramp Schedule")
+	fmt.Fprintln(w, rule)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Rate %d/s, jitter up to %v (jitter is re-seeded per run; offsets are indicative)\n",
+		p.RampRate, p.RampJitter)
+	fmt.Fprintf(w, "  All clients started after ~%s\n\n", p.RampDuration().Round(time.Millisecond))
+	fmt.Fprintf(w, "  %-8s %12s  %s\n", "Client", "Start", "CPUs")
+	fmt.Fprintln(w, "  "+strings.Repeat("─", 40))
+	for _, i := range p.scheduleRows() {
+		if i < 0 {
+			fmt.Fprintln(w, "  ...")
+			continue
+		}
+		fmt.Fprintf(w, "  %-8d %12s  %s\n", i, p.StartOffsets[i].Round(time.Millisecond), p.cpuLabel(i))
+	}
+	fmt.Fprintln(w)
+
+	// Cohorts
+	if p.Cohorts != nil {
+		fmt.Fprintln(w, rule)
+		fmt.Fprintln(w, "                              Client Cohorts")
+		fmt.Fprintln(w, rule)
+		fmt.Fprintln(w)
+		p.printCohorts(w)
+		fmt.Fprintln(w)
+	}
+
+	// Commands
+	fmt.Fprintln(w, rule)
+	fmt.Fprintln(w, "                              FFmpeg Commands")
+	fmt.Fprintln(w, rule)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Client 0:\n    %s\n\n", p.BaseCommand)
+	if len(p.ArgDiffs) == 0 {
+		fmt.Fprintln(w, "  All clients run the same command.")
+	} else {
+		fmt.Fprintln(w, "  Differences between clients:")
+		for _, d := range p.ArgDiffs {
+			fmt.Fprintf(w, "    %-14s %s\n", d.Flag, strings.Join(quoteAll(d.Samples), "  →  "))
+		}
+	}
+	fmt.Fprintln(w)
+
+	// Expected load
+	fmt.Fprintln(w, rule)
+	fmt.Fprintln(w, "                           Expected Steady State")
+	fmt.Fprintln(w, rule)
+	fmt.Fprintln(w)
+	suffix := ""
+	if !p.RenditionsKnown {
+		suffix = "  × renditions in master playlist"
+	}
+	fmt.Fprintf(w, "  Segment requests:     %s%s\n", stats.FormatRate(p.SegmentRate), suffix)
+	fmt.Fprintf(w, "  Playlist requests:    %s%s\n", stats.FormatRate(p.PlaylistRate), suffix)
+	fmt.Fprintf(w, "  (%d playlist(s) per client, one segment of each per %v target duration", p.Playlists, p.TargetDuration)
+	if p.PlaylistRefresh > 0 {
+		fmt.Fprintf(w, ";\n   variant playlist fetched every %v)\n", p.PlaylistRefresh.Round(time.Millisecond))
+	} else {
+		fmt.Fprintln(w, ";\n   VOD playlists fetched once per session)")
+	}
+	if p.UnpacedClients > 0 {
+		fmt.Fprintf(w, "  Fast-paced clients:   %d read the asset as fast as the origin serves (not in the segment rate)\n", p.UnpacedClients)
+	}
+	if p.SessionRate > 0 {
+		fmt.Fprintf(w, "  Session starts:       %s  (%s mean session, playlists fetched anew)\n",
+			stats.FormatRate(p.SessionRate), stats.FormatDuration(p.SessionLength))
+	}
+	switch p.Prefetch {
+	case 0:
+		fmt.Fprintln(w, "  Prefetch:             off (one segment request in flight per playlist)")
+	case 1:
+		fmt.Fprintln(w, "  Prefetch:             next segment (up to 2 segment requests in flight per playlist)")
+	default:
+		fmt.Fprintln(w, "  Prefetch:             FFmpeg default (next segment on HTTP/1.1 origins)")
+	}
+	if p.PeakClients > p.Clients && p.Clients > 0 {
+		fmt.Fprintf(w, "  During bursts:        up to %d clients (rates × %.2f)\n",
+			p.PeakClients, float64(p.PeakClients)/float64(p.Clients))
+	}
+	if p.BandwidthBps > 0 {
+		fmt.Fprintf(w, "  Bandwidth:            %s/s  (%d kbps × %d clients)%s\n",
+			formatBits(p.BandwidthBps), p.BitrateKbps, p.Clients, suffix)
+	} else {
+		fmt.Fprintln(w, "  Bandwidth:            unknown (set -expected-bitrate to estimate)")
+	}
+	fmt.Fprintln(w)
+}
+
+// printCohorts writes the clients per cohort, then each client's cohorts
+// for the rows of the schedule table.
+func (p *Plan) printCohorts(w io.Writer) {
+	type column struct {
+		name  string
+		value func(ClientCohort) string
+	}
+	var cols []column
+	for _, c := range []column{
+		{"Geo", func(c ClientCohort) string { return c.Geo }},
+		{"Tenant", func(c ClientCohort) string { return c.Tenant }},
+		{"Variant", func(c ClientCohort) string { return c.Variant }},
+		{"Pacing", func(c ClientCohort) string { return c.Pacing }},
+	} {
+		if c.value(p.Cohorts[0]) == "" {
+			continue // Not in use
+		}
+		cols = append(cols, c)
+
+		var names []string
+		counts := make(map[string]int)
+		for _, cohort := range p.Cohorts {
+			v := c.value(cohort)
+			if counts[v] == 0 {
+				names = append(names, v)
+			}
+			counts[v]++
+		}
+		parts := make([]string, len(names))
+		for i, n := range names {
+			parts[i] = fmt.Sprintf("%s %d", n, counts[n])
+		}
+		fmt.Fprintf(w, "  %-8s %s\n", c.name+":", strings.Join(parts, ", "))
+	}
+	fmt.Fprintln(w)
+
+	row := func(first string, values func(column) string) {
+		line := fmt.Sprintf("  %-8s", first)
+		for _, c := range cols {
+			line += fmt.Sprintf(" %-14s", values(c))
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+	row("Client", func(c column) string { return c.name })
+	fmt.Fprintln(w, "  "+strings.Repeat("─", 8+15*len(cols)))
+	for _, i := range p.scheduleRows() {
+		if i < 0 {
+			fmt.Fprintln(w, "  ...")
+			continue
+		}
+		row(strconv.Itoa(i), func(c column) string { return c.value(p.Cohorts[i]) })
+	}
+}
+
+// scheduleRows picks which client rows to print: the first planScheduleRows,
+// then 25/50/75/100% milestones. -1 marks an elision.
+func (p *Plan) scheduleRows() []int {
+	n := len(p.StartOffsets)
+	if n <= planScheduleRows+4 {
+		rows := make([]int, n)
+		for i := range rows {
+			rows[i] = i
+		}
+		return rows
+	}
+
+	rows := make([]int, 0, planScheduleRows+5)
+	for i := 0; i < planScheduleRows; i++ {
+		rows = append(rows, i)
+	}
+	last := planScheduleRows - 1
+	for _, frac := range []int{25, 50, 75, 100} {
+		i := n*frac/100 - 1
+		if i <= last {
+			continue
+		}
+		rows = append(rows, -1, i)
+		last = i
+	}
+	return rows
+}
+
+// cpuLabel renders a client's CPU set for the schedule table.
+func (p *Plan) cpuLabel(clientID int) string {
+	if p.CPUs == nil || p.CPUs[clientID] == nil {
+		return "-"
+	}
+	return supervisor.FormatCPUList(p.CPUs[clientID])
+}
+
+// formatBits formats a bit rate with Kb/Mb/Gb suffixes.
+func formatBits(bps float64) string {
+	switch {
+	case bps >= 1e9:
+		return fmt.Sprintf("%.2f Gb", bps/1e9)
+	case bps >= 1e6:
+		return fmt.Sprintf("%.2f Mb", bps/1e6)
+	case bps >= 1e3:
+		return fmt.Sprintf("%.2f Kb", bps/1e3)
+	}
+	return fmt.Sprintf("%.0f b", bps)
+}
+
+// quoteAll quotes argument values for the differences table.
+func quoteAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		if v == planArgAbsent {
+			out[i] = v
+			continue
+		}
+		out[i] = fmt.Sprintf("%q", v)
+	}
+	return out
+}
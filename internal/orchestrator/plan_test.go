@@ -0,0 +1,326 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+func newPlanConfig(clients int) *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = "http://example.com/live/master.m3u8"
+	cfg.Clients = clients
+	cfg.RampRate = 10
+	cfg.RampJitter = 0
+	cfg.TargetDuration = 2 * time.Second
+	return cfg
+}
+
+func mustBuildPlan(t *testing.T, cfg *config.Config) *Plan {
+	t.Helper()
+	p, err := BuildPlan(cfg)
+	if err != nil {
+		t.Fatalf("BuildPlan: %v", err)
+	}
+	return p
+}
+
+func TestBuildPlan_RampSchedule(t *testing.T) {
+	p := mustBuildPlan(t, newPlanConfig(5))
+
+	if len(p.StartOffsets) != 5 {
+		t.Fatalf("len(StartOffsets) = %d, want 5", len(p.StartOffsets))
+	}
+	// No jitter: exactly 100ms apart, client 0 immediate
+	for i, got := range p.StartOffsets {
+		want := time.Duration(i) * 100 * time.Millisecond
+		if got != want {
+			t.Errorf("StartOffsets[%d] = %v, want %v", i, got, want)
+		}
+	}
+	if p.RampDuration() != 400*time.Millisecond {
+		t.Errorf("RampDuration() = %v, want 400ms", p.RampDuration())
+	}
+}
+
+func TestBuildPlan_SteadyState(t *testing.T) {
+	cfg := newPlanConfig(100)
+	cfg.Variant = "highest"
+	cfg.ExpectedBitrate = 2000
+	p := mustBuildPlan(t, cfg)
+
+	// 100 clients / 2s target duration
+	if p.SegmentRate != 50 {
+		t.Errorf("SegmentRate = %v, want 50", p.SegmentRate)
+	}
+	if !p.RenditionsKnown {
+		t.Error("RenditionsKnown should be true for -variant highest")
+	}
+	if p.BandwidthBps != 200e6 {
+		t.Errorf("BandwidthBps = %v, want 200e6", p.BandwidthBps)
+	}
+}
+
+func TestBuildPlan_LoadModel(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(*config.Config)
+		playlists int
+		segments  float64
+		refreshes float64
+	}{
+		// 10 clients, 2s target duration
+		{"defaults", func(*config.Config) {}, 1, 5, 5},
+		{"playlist refresh", func(c *config.Config) { c.PlaylistRefresh = 0.5 }, 1, 5, 10},
+		{"renditions", func(c *config.Config) { c.Renditions = "audio=en,subs=en" }, 3, 15, 15},
+		// Every 10m session fetches the master and media playlist again
+		{"sessions", func(c *config.Config) { c.SessionDuration = "10m" }, 1, 5, 5 + 2*10.0/600},
+		// A VOD asset's playlists are fetched once; fast-paced clients
+		// have no stream rate
+		{"vod fast", func(c *config.Config) { c.VODEnd = config.VODEndLoop }, 1, 0, 0},
+		{"vod realtime", func(c *config.Config) { c.VODEnd = config.VODEndLoop; c.Pacing = "realtime" }, 1, 5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newPlanConfig(10)
+			cfg.Variant = "highest"
+			tt.setup(cfg)
+			p := mustBuildPlan(t, cfg)
+			if p.Playlists != tt.playlists {
+				t.Errorf("Playlists = %d, want %d", p.Playlists, tt.playlists)
+			}
+			if d := p.SegmentRate - tt.segments; d < -1e-9 || d > 1e-9 {
+				t.Errorf("SegmentRate = %v, want %v", p.SegmentRate, tt.segments)
+			}
+			if d := p.PlaylistRate - tt.refreshes; d < -1e-9 || d > 1e-9 {
+				t.Errorf("PlaylistRate = %v, want %v", p.PlaylistRate, tt.refreshes)
+			}
+		})
+	}
+}
+
+func TestBuildPlan_LoadModelCohorts(t *testing.T) {
+	cfg := newPlanConfig(9)
+	cfg.VODStart = "uniform"
+	cfg.Geos = []config.Geo{
+		{Name: "eu", Weight: 2},
+		{Name: "us", Weight: 1, Pacing: "realtime"},
+	}
+	cfg.Bursts = []config.Burst{{Every: 5 * time.Minute, Clients: 3, For: 30 * time.Second}}
+	p := mustBuildPlan(t, cfg)
+
+	// Only the us third reads at the stream's rate
+	if p.UnpacedClients != 6 || p.SegmentRate != 1.5 {
+		t.Errorf("UnpacedClients = %d, SegmentRate = %v; want 6, 1.5", p.UnpacedClients, p.SegmentRate)
+	}
+	if p.PeakClients != 12 {
+		t.Errorf("PeakClients = %d, want 12", p.PeakClients)
+	}
+}
+
+func TestBuildPlan_ClientCohorts(t *testing.T) {
+	if p := mustBuildPlan(t, newPlanConfig(3)); p.Cohorts != nil {
+		t.Errorf("Cohorts = %+v without cohorts, want nil", p.Cohorts)
+	}
+
+	cfg := newPlanConfig(6)
+	cfg.Geos = []config.Geo{{Name: "eu", Weight: 2}, {Name: "us", Weight: 1, Pacing: "realtime"}}
+	cfg.Tenants = []config.Tenant{{Name: "acme", Clients: 3}, {Name: "beta", Clients: 3}}
+	cfg.VariantMix = []config.VariantShare{{Variant: "720p", Weight: 1}, {Variant: "1080p", Weight: 1}}
+	p := mustBuildPlan(t, cfg)
+
+	if len(p.Cohorts) != 6 {
+		t.Fatalf("len(Cohorts) = %d, want 6", len(p.Cohorts))
+	}
+	// The run's own assignments, past the clients the diffs sample
+	geos := assignGeos(cfg.Geos, 6)
+	tenants := assignTenants(cfg.Tenants)
+	variants := assignVariants(cfg.VariantMix, 6)
+	for i, c := range p.Cohorts {
+		want := ClientCohort{
+			Geo:     cfg.Geos[geos[i]].Name,
+			Tenant:  cfg.Tenants[tenants[i]].Name,
+			Variant: cfg.VariantMix[variants[i]].Variant,
+			Pacing:  config.PacingFast,
+		}
+		if want.Geo == "us" {
+			want.Pacing = config.PacingRealtime
+		}
+		if c != want {
+			t.Errorf("client %d cohorts = %+v, want %+v", i, c, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	p.Print(&buf)
+	for _, want := range []string{"Client Cohorts", "Geo:     eu 4, us 2", "Tenant:  acme 3, beta 3", "Pacing:  fast 4, realtime 2"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("plan output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestBuildPlan_ArgDiffs(t *testing.T) {
+	p := mustBuildPlan(t, newPlanConfig(3))
+
+	if len(p.ArgDiffs) != 1 || p.ArgDiffs[0].Flag != "-user_agent" {
+		t.Fatalf("ArgDiffs = %+v, want only -user_agent", p.ArgDiffs)
+	}
+	if got := p.ArgDiffs[0].Samples[2]; !strings.HasSuffix(got, "/client-2") {
+		t.Errorf("client 2 user agent = %q, want /client-2 suffix", got)
+	}
+	if !strings.Contains(p.BaseCommand, "-progress pipe:3") {
+		t.Errorf("BaseCommand should use FD progress like the supervisor: %s", p.BaseCommand)
+	}
+}
+
+func TestDiffArgs_Identical(t *testing.T) {
+	argv := [][]string{{"-a", "1"}, {"-a", "1"}}
+	if diffs := diffArgs(argv); len(diffs) != 0 {
+		t.Errorf("diffArgs = %+v, want none", diffs)
+	}
+}
+
+func TestBuildPlan_Cohorts(t *testing.T) {
+	cfg := newPlanConfig(4)
+	cfg.Geos = []config.Geo{
+		{Name: "eu", Weight: 1, Headers: []string{"X-Forwarded-For: 81.2.69.1"}},
+		{Name: "us", Weight: 1, Headers: []string{"X-Forwarded-For: 8.8.8.8"}},
+	}
+	cfg.CompareURL = "http://b.example.com/live/master.m3u8"
+	cfg.TagRequests = true
+	p := mustBuildPlan(t, cfg)
+
+	samples := make(map[string][]string)
+	for _, d := range p.ArgDiffs {
+		samples[d.Flag] = d.Samples
+	}
+	if got := samples["-headers"]; len(got) != 3 || !strings.Contains(got[0], "81.2.69.1") || !strings.Contains(got[1], "8.8.8.8") {
+		t.Errorf("-headers samples = %q, want the geos' headers", got)
+	}
+	if got := samples["-i"]; len(got) != 3 || got[0] != cfg.StreamURL || got[1] != cfg.CompareURL {
+		t.Errorf("-i samples = %q, want A then B", got)
+	}
+	if !strings.Contains(p.BaseCommand, planRunTag) {
+		t.Errorf("BaseCommand lacks the run tag: %s", p.BaseCommand)
+	}
+}
+
+func TestBuildPlan_VariantMix(t *testing.T) {
+	cfg := newPlanConfig(2)
+	cfg.VariantMix = []config.VariantShare{{Variant: "720p", Weight: 1}, {Variant: "1080p", Weight: 1}}
+	p := mustBuildPlan(t, cfg)
+	if len(p.ArgDiffs) < 1 || p.ArgDiffs[len(p.ArgDiffs)-1].Flag != "-i" {
+		t.Fatalf("ArgDiffs = %+v, want -i to differ", p.ArgDiffs)
+	}
+	if got := p.ArgDiffs[len(p.ArgDiffs)-1].Samples; got[0] != "<720p media playlist>" || got[1] != "<1080p media playlist>" {
+		t.Errorf("-i samples = %q", got)
+	}
+}
+
+func TestBuildPlan_TokenPlaceholder(t *testing.T) {
+	cfg := newPlanConfig(1)
+	cfg.TokenURL = "http://127.0.0.1:1/token"
+	cfg.Headers = []string{"Authorization: Bearer {token}"}
+	p := mustBuildPlan(t, cfg)
+	if !strings.Contains(p.BaseCommand, "Bearer "+planToken) {
+		t.Errorf("BaseCommand = %s, want the token placeholder", p.BaseCommand)
+	}
+}
+
+func TestPlanCommands_Error(t *testing.T) {
+	cfg := NewFFmpegConfig(newPlanConfig(2))
+	cfg.TokenSource = failingTokenSource{}
+	if _, _, err := planCommands(cfg, 2); err == nil {
+		t.Error("planCommands swallowed the BuildCommand error")
+	}
+}
+
+type failingTokenSource struct{}
+
+func (failingTokenSource) Token(context.Context, process.TemplateValues) (string, error) {
+	return "", errors.New("token server down")
+}
+
+func TestDiffArgs_ByFlag(t *testing.T) {
+	argv := [][]string{
+		{"-nostdin", "-user_agent", "a", "-map", "0:v", "-map", "0:a", "-i", "x", "-"},
+		{"-nostdin", "-user_agent", "b", "-ss", "-1.5", "-map", "0:v", "-map", "0:a", "-i", "x", "-"},
+	}
+	diffs := diffArgs(argv)
+	if len(diffs) != 2 {
+		t.Fatalf("diffArgs = %+v, want -user_agent and -ss only", diffs)
+	}
+	if diffs[0].Flag != "-user_agent" || diffs[1].Flag != "-ss" {
+		t.Errorf("diffs = %+v", diffs)
+	}
+	if got := diffs[1].Samples; got[0] != planArgAbsent || got[1] != "-1.5" {
+		t.Errorf("-ss samples = %q, want absent then -1.5", got)
+	}
+}
+
+func TestPlan_ScheduleRows(t *testing.T) {
+	small := &Plan{StartOffsets: make([]time.Duration, 5)}
+	if rows := small.scheduleRows(); len(rows) != 5 {
+		t.Errorf("small plan rows = %v, want all 5", rows)
+	}
+
+	large := &Plan{StartOffsets: make([]time.Duration, 1000)}
+	rows := large.scheduleRows()
+	if rows[len(rows)-1] != 999 {
+		t.Errorf("last row = %d, want 999", rows[len(rows)-1])
+	}
+	if len(rows) > planScheduleRows+8 {
+		t.Errorf("large plan printed %d rows, want it summarised", len(rows))
+	}
+}
+
+func TestPlan_Print(t *testing.T) {
+	var buf bytes.Buffer
+	mustBuildPlan(t, newPlanConfig(20)).Print(&buf)
+	out := buf.String()
+
+	for _, want := range []string{"Launch Plan", "Ramp Schedule", "FFmpeg Commands", "Expected Steady State", "-expected-bitrate"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("plan output missing %q", want)
+		}
+	}
+}
+
+func TestPlan_PrintStartAt(t *testing.T) {
+	cfg := newPlanConfig(2)
+	var buf bytes.Buffer
+	mustBuildPlan(t, cfg).Print(&buf)
+	if strings.Contains(buf.String(), "Start At:") {
+		t.Error("Start At printed without -start-at")
+	}
+
+	cfg.StartAt = time.Date(2026, 5, 1, 14, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	buf.Reset()
+	mustBuildPlan(t, cfg).Print(&buf)
+	if !strings.Contains(buf.String(), "Start At:               2026-05-01T12:00:00Z") {
+		t.Errorf("plan output missing UTC start time:\n%s", buf.String())
+	}
+}
+
+func TestPlan_PrintCoolDown(t *testing.T) {
+	cfg := newPlanConfig(2)
+	var buf bytes.Buffer
+	mustBuildPlan(t, cfg).Print(&buf)
+	if strings.Contains(buf.String(), "Cool-down:") {
+		t.Error("Cool-down printed without -cool-down")
+	}
+
+	cfg.CoolDown = 2 * time.Minute
+	buf.Reset()
+	mustBuildPlan(t, cfg).Print(&buf)
+	if !strings.Contains(buf.String(), "Cool-down:              00:02:00") {
+		t.Errorf("plan output missing cool-down:\n%s", buf.String())
+	}
+}
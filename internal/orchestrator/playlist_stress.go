@@ -0,0 +1,205 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// playlistStressWorkerHeader identifies a --playlist-stress worker, unless
+// -header does with {client_id}.
+const playlistStressWorkerHeader = "X-Swarm-Worker"
+
+// playlistStressLogEvery is how often --playlist-stress logs its rate.
+const playlistStressLogEvery = 5 * time.Second
+
+// StressPlaylists sizes the origin's playlist serving (--playlist-stress):
+// -playlist-stress-workers native workers reload the stream's media
+// playlists, spread over the variants, at -playlist-stress-rate in all
+// (or back to back) for -duration or until ctx is cancelled. A worker is
+// far lighter than an FFmpeg client and fetches no segments, so one host
+// can reach playlist rates a swarm of players would need many hosts for.
+// Each worker keeps its own connection and identifies itself with an
+// X-Swarm-Worker header, or -header's {client_id}.
+func StressPlaylists(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*stats.PlaylistStressSummary, error) {
+	newProber := func(worker int) *manifest.Prober {
+		vars := process.NewTemplateValues(worker, 1)
+		headers := vars.ExpandHeaders(cfg.Headers)
+		if !process.UsesTemplate(cfg.Headers, process.HeaderVarClientID) {
+			headers = append(headers[:len(headers):len(headers)], playlistStressWorkerHeader+": "+strconv.Itoa(worker))
+		}
+		return manifest.NewProber(manifest.ProberConfig{
+			Timeout:        cfg.Timeout,
+			UserAgent:      cfg.UserAgent,
+			Headers:        headers,
+			ResolveIP:      cfg.ResolveIP,
+			DangerousMode:  cfg.DangerousMode,
+			AcceptEncoding: cfg.AcceptEncoding,
+			NoCache:        cfg.NoCache,
+		})
+	}
+
+	// The media playlists are what players reload: a master playlist is
+	// fetched once per session
+	probeCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	top, err := newProber(0).Fetch(probeCtx, cfg.StreamURL)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("%w: playlist stress: %w", ErrEnvironment, err)
+	}
+	playlists := []string{cfg.StreamURL}
+	if top.IsMaster {
+		playlists = playlists[:0]
+		for _, v := range top.Variants {
+			playlists = append(playlists, v.URI)
+		}
+		if len(playlists) == 0 {
+			return nil, fmt.Errorf("playlist stress: master playlist %s lists no variants", cfg.StreamURL)
+		}
+	}
+
+	summary := &stats.PlaylistStressSummary{
+		URL:        cfg.StreamURL,
+		Playlists:  len(playlists),
+		Workers:    cfg.PlaylistStressWorkers,
+		TargetRate: cfg.PlaylistStressRate,
+		Failed:     make(map[string]int64),
+		Latency:    stats.NewDurationHistory(cfg.StatsRetention),
+	}
+	var requests, failures atomic.Int64
+	var failedMu sync.Mutex
+
+	runCtx := ctx
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+
+	// Each worker's share of the rate, started at a random point of its
+	// interval so the requests don't come in waves
+	var interval time.Duration
+	if cfg.PlaylistStressRate > 0 {
+		interval = time.Duration(float64(cfg.PlaylistStressWorkers) / cfg.PlaylistStressRate * float64(time.Second))
+	}
+	worker := func(id int) {
+		prober := newProber(id)
+		playlist := playlists[id%len(playlists)]
+		var ticker *time.Ticker
+		if interval > 0 {
+			select {
+			case <-time.After(rand.N(interval)):
+			case <-runCtx.Done():
+				return
+			}
+			ticker = time.NewTicker(interval)
+			defer ticker.Stop()
+		}
+		for {
+			start := time.Now()
+			status, err := prober.Request(runCtx, playlist)
+			if runCtx.Err() != nil {
+				return // Cut short by the end of the run, not the origin
+			}
+			requests.Add(1)
+			if cause := playlistFailure(status, err); cause != "" {
+				failures.Add(1)
+				failedMu.Lock()
+				summary.Failed[cause]++
+				failedMu.Unlock()
+			} else {
+				summary.Latency.Add(time.Since(start))
+			}
+
+			if ticker != nil {
+				select {
+				case <-ticker.C:
+				case <-runCtx.Done():
+					return
+				}
+			}
+		}
+	}
+
+	logger.Info("playlist_stress_started",
+		"url", cfg.StreamURL,
+		"playlists", len(playlists),
+		"workers", cfg.PlaylistStressWorkers,
+		"rate", cfg.PlaylistStressRate,
+		"duration", cfg.Duration.String(),
+	)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for id := range cfg.PlaylistStressWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(id)
+		}()
+	}
+
+	// Served requests per second, for the peak rate and the log
+	ticker := time.NewTicker(time.Second)
+	var lastServed int64
+	lastLog := start
+	for done := false; !done; {
+		select {
+		case <-ticker.C:
+		case <-runCtx.Done():
+			done = true
+		}
+		served := requests.Load() - failures.Load()
+		if !done {
+			summary.PeakRate = max(summary.PeakRate, float64(served-lastServed))
+		}
+		lastServed = served
+		if time.Since(lastLog) >= playlistStressLogEvery {
+			lastLog = time.Now()
+			logger.Info("playlist_stress_progress",
+				"requests", requests.Load(),
+				"failures", failures.Load(),
+				"rate", float64(served)/time.Since(start).Seconds(),
+			)
+		}
+	}
+	ticker.Stop()
+	wg.Wait()
+
+	summary.Elapsed = time.Since(start)
+	summary.Interrupted = ctx.Err() != nil
+	summary.Requests, summary.Failures = requests.Load(), failures.Load()
+	if summary.PeakRate == 0 {
+		summary.PeakRate = summary.Rate() // Over before a whole second
+	}
+	logger.Info("playlist_stress_finished",
+		"requests", summary.Requests,
+		"failures", summary.Failures,
+		"rate", summary.Rate(),
+		"peak_rate", summary.PeakRate,
+	)
+	return summary, nil
+}
+
+// playlistFailure names why a --playlist-stress request failed, or "" if
+// the origin served the playlist (200, or 304 to a conditional reload).
+func playlistFailure(status int, err error) string {
+	switch {
+	case err != nil:
+		return connFailure(0, err)
+	case status == http.StatusOK || status == http.StatusNotModified:
+		return ""
+	default:
+		return fmt.Sprintf("HTTP %d", status)
+	}
+}
@@ -0,0 +1,137 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+)
+
+func TestStressPlaylists(t *testing.T) {
+	var mu sync.Mutex
+	byPath := make(map[string]int)
+	workers := make(map[string]bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		byPath[r.URL.Path]++
+		workers[r.Header.Get("X-Swarm-Worker")] = true
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/master.m3u8":
+			io.WriteString(w, "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=800000\nlow.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=2000000\nhigh.m3u8\n")
+		case "/high.m3u8":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			io.WriteString(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXTINF:2,\nseg1.ts\n")
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = srv.URL + "/master.m3u8"
+	cfg.PlaylistStressWorkers = 4
+	cfg.PlaylistStressRate = 200
+	cfg.Duration = 500 * time.Millisecond
+	s, err := StressPlaylists(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("StressPlaylists() = %v", err)
+	}
+
+	if s.Playlists != 2 || s.Interrupted {
+		t.Errorf("summary = %+v, want 2 playlists, not interrupted", s)
+	}
+	// 200/s for 0.5s: ~100 requests, half of them to the failing variant
+	if s.Requests < 60 || s.Requests > 110 {
+		t.Errorf("Requests = %d, want ~100 at the rate asked", s.Requests)
+	}
+	if s.Failed["HTTP 503"] != s.Failures || s.Failures < s.Requests/3 {
+		t.Errorf("failures = %d of %d (%v), want the high variant's 503s", s.Failures, s.Requests, s.Failed)
+	}
+	if s.Latency.Count() != s.Requests-s.Failures {
+		t.Errorf("latencies = %d, want one per served request", s.Latency.Count())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if byPath["/master.m3u8"] != 1 || byPath["/low.m3u8"] == 0 || byPath["/high.m3u8"] == 0 {
+		t.Errorf("requests by path = %v, want the master once and both variants reloaded", byPath)
+	}
+	for _, id := range []string{"0", "1", "2", "3"} {
+		if !workers[id] {
+			t.Errorf("no request from worker %s: %v", id, workers)
+		}
+	}
+}
+
+func TestStressPlaylists_ClientIDHeader(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = append(got, r.Header.Get("X-Viewer")+"|"+r.Header.Get("X-Swarm-Worker"))
+		mu.Unlock()
+		io.WriteString(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXTINF:2,\nseg1.ts\n")
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = srv.URL + "/live.m3u8"
+	cfg.Headers = []string{"X-Viewer: v-{client_id}"}
+	cfg.PlaylistStressWorkers = 2
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	s, err := StressPlaylists(ctx, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("StressPlaylists() = %v", err)
+	}
+	if !s.Interrupted || s.Requests == 0 || s.Failures != 0 {
+		t.Errorf("summary = %+v, want interrupted with requests served", s)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	seen := make(map[string]bool)
+	for _, h := range got {
+		seen[h] = true
+	}
+	if !seen["v-1|"] || seen["v-{client_id}|"] || len(seen) != 2 {
+		t.Errorf("headers = %v, want v-0 and v-1 and no X-Swarm-Worker", seen)
+	}
+}
+
+func TestStressPlaylists_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = srv.URL + "/live.m3u8"
+	if _, err := StressPlaylists(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil))); err == nil {
+		t.Error("StressPlaylists() = nil error for a missing playlist")
+	}
+}
+
+func TestPlaylistFailure(t *testing.T) {
+	tests := []struct {
+		status int
+		err    error
+		want   string
+	}{
+		{200, nil, ""},
+		{304, nil, ""},
+		{404, nil, "HTTP 404"},
+		{503, nil, "HTTP 503"},
+		{0, context.DeadlineExceeded, "timeout"},
+		{0, errors.New("boom"), "other"},
+	}
+	for _, tt := range tests {
+		if got := playlistFailure(tt.status, tt.err); got != tt.want {
+			t.Errorf("playlistFailure(%d, %v) = %q, want %q", tt.status, tt.err, got, tt.want)
+		}
+	}
+}
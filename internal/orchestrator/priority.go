@@ -0,0 +1,45 @@
+package orchestrator
+
+import (
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// processPriority returns the priority for -nice and -ionice values (both
+// validated), overriding base where set.
+func processPriority(base supervisor.Priority, nice int, ionice string) supervisor.Priority {
+	if nice != 0 {
+		base.Nice = nice
+	}
+	if ionice != "" {
+		base.IOClass, base.IOLevel, _ = config.ParseIONice(ionice)
+	}
+	return base
+}
+
+// clientPriorities returns each client's -nice/-ionice priority: its -geo
+// cohort's where set, the global one otherwise. Returns nil if no client
+// has one.
+func clientPriorities(cfg *config.Config) func(clientID int) supervisor.Priority {
+	global := processPriority(supervisor.Priority{}, cfg.Nice, cfg.IONice)
+	perGeo := make([]supervisor.Priority, len(cfg.Geos))
+	set := !global.IsZero()
+	for i, g := range cfg.Geos {
+		perGeo[i] = processPriority(global, g.Nice, g.IONice)
+		set = set || !perGeo[i].IsZero()
+	}
+	if !set {
+		return nil
+	}
+	if len(cfg.Geos) == 0 {
+		return func(int) supervisor.Priority { return global }
+	}
+
+	assigned := assignGeos(cfg.Geos, cfg.Clients)
+	return func(clientID int) supervisor.Priority {
+		if clientID < 0 || clientID >= len(assigned) {
+			return global
+		}
+		return perGeo[assigned[clientID]]
+	}
+}
@@ -0,0 +1,50 @@
+package orchestrator
+
+import (
+	"testing"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+func TestClientPriorities(t *testing.T) {
+	t.Run("unset", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Geos = []config.Geo{{Name: "eu", Weight: 1}}
+		if clientPriorities(cfg) != nil {
+			t.Error("clientPriorities() != nil without -nice or -ionice")
+		}
+	})
+
+	t.Run("global", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Nice = 10
+		cfg.IONice = "best-effort:6"
+		want := supervisor.Priority{Nice: 10, IOClass: supervisor.IOClassBestEffort, IOLevel: 6}
+		if got := clientPriorities(cfg)(3); got != want {
+			t.Errorf("priority = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("per geo", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Clients = 4
+		cfg.Nice = 5
+		cfg.Geos = []config.Geo{
+			{Name: "eu", Weight: 1},
+			{Name: "bulk", Weight: 1, Nice: 19, IONice: "idle"},
+		}
+		priority := clientPriorities(cfg)
+
+		eu := supervisor.Priority{Nice: 5}
+		bulk := supervisor.Priority{Nice: 19, IOClass: supervisor.IOClassIdle}
+		for clientID, want := range []supervisor.Priority{eu, bulk, eu, bulk} {
+			if got := priority(clientID); got != want {
+				t.Errorf("client %d priority = %+v, want %+v", clientID, got, want)
+			}
+		}
+		if got := priority(99); got != eu {
+			t.Errorf("out of range client priority = %+v, want the global %+v", got, eu)
+		}
+	})
+}
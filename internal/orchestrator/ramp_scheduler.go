@@ -38,25 +38,7 @@ func NewRampSchedulerWithSeed(rate int, maxJitter time.Duration, seed int64) *Ra
 // Schedule waits the appropriate amount of time before starting client N.
 // Returns nil on success, or context error if cancelled.
 func (r *RampScheduler) Schedule(ctx context.Context, clientID int) error {
-	// Calculate base delay from rate
-	// rate=5 means 1 client per 200ms
-	var baseDelay time.Duration
-	if r.rate > 0 {
-		baseDelay = time.Second / time.Duration(r.rate)
-	}
-
-	// Add per-client jitter, but scale it to not dominate at high rates.
-	// For high ramp rates, cap jitter to 50% of baseDelay to maintain the target rate.
-	// For low ramp rates, use the full jitter to prevent synchronization.
-	effectiveJitter := r.maxJitter
-	if baseDelay > 0 && r.maxJitter > baseDelay/2 {
-		// Cap jitter to 50% of baseDelay for high rates
-		effectiveJitter = baseDelay / 2
-	}
-	jitter := r.jitter.ClientJitter(clientID, effectiveJitter)
-
-	// Total delay
-	totalDelay := baseDelay + jitter
+	totalDelay := r.Delay(clientID)
 
 	// Wait
 	if totalDelay > 0 {
@@ -71,6 +53,67 @@ func (r *RampScheduler) Schedule(ctx context.Context, clientID int) error {
 	return nil
 }
 
+// Delay returns how long Schedule waits before starting client N.
+// Deterministic for a given seed, so it can also be used to preview the ramp.
+func (r *RampScheduler) Delay(clientID int) time.Duration {
+	// Calculate base delay from rate
+	// rate=5 means 1 client per 200ms
+	var baseDelay time.Duration
+	if r.rate > 0 {
+		baseDelay = time.Second / time.Duration(r.rate)
+	}
+
+	jitter := r.jitter.ClientJitter(clientID, r.effectiveJitter(baseDelay))
+
+	return baseDelay + jitter
+}
+
+// effectiveJitter scales the per-client jitter so it doesn't dominate at
+// high rates: it is capped at 50% of the interval between clients to
+// maintain the target rate. At low ramp rates the full jitter applies,
+// to prevent synchronization.
+func (r *RampScheduler) effectiveJitter(interval time.Duration) time.Duration {
+	if interval > 0 && r.maxJitter > interval/2 {
+		return interval / 2
+	}
+	return r.maxJitter
+}
+
+// Offset returns when the n-th client of a ramp starts, relative to the
+// start of the ramp: n intervals of 1/rate, plus clientID's jitter. The
+// first client starts immediately.
+//
+// Unlike a sum of Delays, offsets don't accumulate the jitter or the time
+// spent launching each client, so a ramp keeps its rate: clients that
+// fall behind their slot start at once, as a batch, until the ramp has
+// caught up.
+func (r *RampScheduler) Offset(n, clientID int) time.Duration {
+	if n <= 0 || r.rate <= 0 {
+		return 0
+	}
+	interval := time.Second / time.Duration(r.rate)
+	slot := time.Duration(n) * time.Second / time.Duration(r.rate)
+	return slot + r.jitter.ClientJitter(clientID, r.effectiveJitter(interval))
+}
+
+// ScheduleAt waits until the n-th client of a ramp begun at start is due
+// (see Offset). Returns nil at once if it is overdue, or the context
+// error if cancelled.
+func (r *RampScheduler) ScheduleAt(ctx context.Context, start time.Time, n, clientID int) error {
+	wait := time.Until(start.Add(r.Offset(n, clientID)))
+	if wait <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // ScheduleImmediate returns immediately without waiting.
 // Useful for the first client.
 func (r *RampScheduler) ScheduleImmediate() {
@@ -97,3 +140,35 @@ func (r *RampScheduler) Rate() int {
 func (r *RampScheduler) MaxJitter() time.Duration {
 	return r.maxJitter
 }
+
+// rampRate measures the start rate a ramp achieves.
+type rampRate struct {
+	start    time.Time // Of the ramp; its schedule is relative to this
+	launched int       // Clients started so far
+	first    time.Time // When the first of them started
+	last     time.Time
+}
+
+func newRampRate() *rampRate {
+	return &rampRate{start: time.Now()}
+}
+
+// launch records a client start.
+func (r *rampRate) launch() {
+	now := time.Now()
+	if r.launched == 0 {
+		r.first = now
+	}
+	r.launched++
+	r.last = now
+}
+
+// achieved returns the clients started per second: the starts after the
+// first over the time they took (0 until there are two).
+func (r *rampRate) achieved() float64 {
+	elapsed := r.last.Sub(r.first).Seconds()
+	if r.launched < 2 || elapsed <= 0 {
+		return 0
+	}
+	return float64(r.launched-1) / elapsed
+}
@@ -354,3 +354,101 @@ func TestRampScheduler_JitterCapping(t *testing.T) {
 		t.Errorf("Jitter should be capped, max elapsed = %v", maxElapsed)
 	}
 }
+
+func TestRampScheduler_Delay_MatchesRate(t *testing.T) {
+	rs := NewRampSchedulerWithSeed(4, 0, 1)
+	if d := rs.Delay(7); d != 250*time.Millisecond {
+		t.Errorf("Delay() = %v, want 250ms", d)
+	}
+
+	// Same seed, same client => same delay
+	a := NewRampSchedulerWithSeed(4, 100*time.Millisecond, 99)
+	b := NewRampSchedulerWithSeed(4, 100*time.Millisecond, 99)
+	if a.Delay(3) != b.Delay(3) {
+		t.Error("Delay should be deterministic for a given seed")
+	}
+}
+
+func TestRampScheduler_Offset(t *testing.T) {
+	rs := NewRampSchedulerWithSeed(3, 0, 1)
+	if d := rs.Offset(0, 7); d != 0 {
+		t.Errorf("Offset(0) = %v, want 0 (first client at once)", d)
+	}
+	// Slots are exact multiples, not a sum of rounded intervals
+	if d := rs.Offset(3, 7); d != time.Second {
+		t.Errorf("Offset(3) at 3/s = %v, want 1s", d)
+	}
+
+	// Jitter stays within half an interval, so clients keep their order
+	rs = NewRampSchedulerWithSeed(50, time.Second, 42)
+	prev := time.Duration(-1)
+	for n := range 100 {
+		d := rs.Offset(n, n+10)
+		slot := time.Duration(n) * 20 * time.Millisecond
+		if n > 0 && (d < slot || d >= slot+10*time.Millisecond) {
+			t.Errorf("Offset(%d) = %v, want within [%v, %v)", n, d, slot, slot+10*time.Millisecond)
+		}
+		if d <= prev {
+			t.Errorf("Offset(%d) = %v, not after Offset(%d) = %v", n, d, n-1, prev)
+		}
+		prev = d
+	}
+
+	if d := NewRampScheduler(0, time.Second).Offset(5, 5); d != 0 {
+		t.Errorf("Offset() with rate 0 = %v, want 0", d)
+	}
+}
+
+func TestRampScheduler_ScheduleAt_NoDrift(t *testing.T) {
+	// Clients that fall behind their slot start at once, so time spent
+	// launching doesn't push the rest of the ramp back
+	rs := NewRampSchedulerWithSeed(100, 0, 1)
+	start := time.Now().Add(-time.Second) // 100 slots overdue
+	ctx := context.Background()
+	for n := range 100 {
+		if err := rs.ScheduleAt(ctx, start, n, n); err != nil {
+			t.Fatalf("ScheduleAt(%d) = %v", n, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 1100*time.Millisecond {
+		t.Errorf("overdue clients waited: ramp took %v, want ~1s", elapsed)
+	}
+
+	// On schedule: 20 clients at 200/s take 95ms plus the last launch,
+	// where waiting a full interval after each would take 171ms
+	rs = NewRampSchedulerWithSeed(200, 0, 1)
+	start = time.Now()
+	for n := range 20 {
+		if err := rs.ScheduleAt(ctx, start, n, n); err != nil {
+			t.Fatalf("ScheduleAt(%d) = %v", n, err)
+		}
+		time.Sleep(4 * time.Millisecond) // Launch cost
+	}
+	if elapsed := time.Since(start); elapsed < 95*time.Millisecond || elapsed > 150*time.Millisecond {
+		t.Errorf("ramp took %v, want ~99ms", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := rs.ScheduleAt(cancelled, time.Now(), 10, 10); err != context.Canceled {
+		t.Errorf("ScheduleAt() cancelled = %v, want context.Canceled", err)
+	}
+}
+
+func TestRampRate(t *testing.T) {
+	r := newRampRate()
+	if r.achieved() != 0 {
+		t.Errorf("achieved() before any start = %v, want 0", r.achieved())
+	}
+	r.launch()
+	if r.achieved() != 0 {
+		t.Errorf("achieved() after one start = %v, want 0", r.achieved())
+	}
+
+	// 11 starts over 200ms: 50/s
+	r.launched = 11
+	r.last = r.first.Add(200 * time.Millisecond)
+	if got := r.achieved(); got != 50 {
+		t.Errorf("achieved() = %v, want 50", got)
+	}
+}
@@ -0,0 +1,43 @@
+package orchestrator
+
+import (
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// rateSampler keeps one consumer's previous sample of the counters, so the
+// rates it exports cover exactly its own interval. The Instant* fields of
+// the stats are since whichever caller aggregated last (the TUI refreshes
+// twice as often as the metrics loop), which is right for the dashboard
+// but not for a series with its own period. Not safe for concurrent use:
+// each consumer samples from one goroutine.
+type rateSampler struct {
+	start time.Time // Rates of the first sample are since then
+	agg   *stats.AggregatedStats
+	debug *stats.DebugStatsAggregate
+}
+
+// sample returns the change since the previous sample and keeps this one.
+// Either stats may be nil (not collected): its delta is then empty.
+func (r *rateSampler) sample(agg *stats.AggregatedStats, ds *stats.DebugStatsAggregate) (stats.AggregatedDelta, stats.DebugDelta) {
+	var a stats.AggregatedDelta
+	if agg != nil {
+		prev := r.agg
+		if prev == nil {
+			prev = &stats.AggregatedStats{Timestamp: r.start}
+		}
+		a = agg.Delta(prev)
+		r.agg = agg.Counters()
+	}
+	var d stats.DebugDelta
+	if ds != nil {
+		prev := r.debug
+		if prev == nil {
+			prev = &stats.DebugStatsAggregate{Timestamp: r.start}
+		}
+		d = ds.Delta(prev)
+		r.debug = ds.Counters()
+	}
+	return a, d
+}
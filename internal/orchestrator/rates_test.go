@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestRateSampler(t *testing.T) {
+	t0 := time.Now()
+	r := rateSampler{start: t0}
+
+	// The first sample's rates are since the start
+	a, d := r.sample(
+		&stats.AggregatedStats{Timestamp: t0.Add(2 * time.Second), TotalSegmentReqs: 40, TotalBytes: 8000},
+		&stats.DebugStatsAggregate{Timestamp: t0.Add(2 * time.Second), TCPConnectCount: 10})
+	if a.SegmentRate() != 20 || a.ThroughputRate() != 4000 || d.TCPConnectsRate() != 5 {
+		t.Errorf("first sample: %v req/s, %v B/s, %v conn/s; want 20, 4000, 5",
+			a.SegmentRate(), a.ThroughputRate(), d.TCPConnectsRate())
+	}
+
+	// Then since the previous sample, whatever the stats' Instant fields say
+	a, _ = r.sample(&stats.AggregatedStats{
+		Timestamp:          t0.Add(3 * time.Second),
+		TotalSegmentReqs:   50,
+		InstantSegmentRate: 99,
+	}, nil)
+	if a.SegmentRate() != 10 {
+		t.Errorf("second sample: %v req/s, want 10", a.SegmentRate())
+	}
+
+	// Stats not collected: no rates, and the debug sample is kept
+	a, d = r.sample(nil, nil)
+	if a != (stats.AggregatedDelta{}) || d != (stats.DebugDelta{}) {
+		t.Errorf("nil stats: %+v, %+v; want empty", a, d)
+	}
+	_, d = r.sample(nil, &stats.DebugStatsAggregate{Timestamp: t0.Add(4 * time.Second), TCPConnectCount: 14})
+	if d.TCPConnectsRate() != 2 {
+		t.Errorf("debug after gap: %v conn/s, want 2", d.TCPConnectsRate())
+	}
+}
@@ -0,0 +1,186 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+// ClientChange changes the options of running clients (see Reconfigure).
+// Nil and zero fields keep what the clients have.
+type ClientChange struct {
+	// Clients the change applies to; nil for every client, including ones
+	// started later (by Scale, say)
+	Clients []int
+
+	// Headers replace the clients' custom headers (-header); empty for
+	// none. Cohort headers (-geo) are still added.
+	Headers []string
+
+	// NoCache turns the cache bypass headers (-no-cache) on or off
+	NoCache *bool
+
+	// Variant replaces the variant selection: process.VariantAll or
+	// process.VariantFirst (only the shared selection's programs are
+	// probed)
+	Variant process.VariantSelection
+
+	// RestartRate, if > 0, restarts the changed clients at this many per
+	// second so the change applies now. Otherwise each client picks it up
+	// at its next restart.
+	RestartRate float64
+}
+
+// clientOverrides holds the request options Reconfigure has set, for
+// process.FFmpegConfig.ClientOptions. A client's options are the cohort's
+// (-compare-opt), then every change covering it, in order.
+type clientOverrides struct {
+	base func(clientID int) *process.RequestOptions // nil = none
+
+	mu       sync.Mutex
+	changed  bool                           // Any change applied yet
+	all      process.RequestOptions         // Changes to every client
+	byClient map[int]process.RequestOptions // Changes to some, on top of all
+}
+
+func newClientOverrides(base func(clientID int) *process.RequestOptions) *clientOverrides {
+	return &clientOverrides{base: base, byClient: make(map[int]process.RequestOptions)}
+}
+
+// options returns a client's request options
+// (process.FFmpegConfig.ClientOptions), nil for the shared ones.
+func (c *clientOverrides) options(clientID int) *process.RequestOptions {
+	var opts process.RequestOptions
+	changed := false
+	if c.base != nil {
+		if o := c.base(clientID); o != nil {
+			opts, changed = *o, true
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		if changed {
+			return &opts
+		}
+		return nil
+	}
+	mergeOptions(&opts, c.all)
+	if o, ok := c.byClient[clientID]; ok {
+		mergeOptions(&opts, o)
+	}
+	return &opts
+}
+
+// apply records ch; clients pick it up as they next start.
+func (c *clientOverrides) apply(ch ClientChange) {
+	o := process.RequestOptions{NoCache: ch.NoCache, Headers: ch.Headers, Variant: ch.Variant}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changed = true
+	if ch.Clients == nil {
+		mergeOptions(&c.all, o)
+		// Every client: earlier per-client changes of the same options
+		// are superseded
+		for id, prev := range c.byClient {
+			c.byClient[id] = supersede(prev, o)
+		}
+		return
+	}
+	for _, id := range ch.Clients {
+		prev := c.byClient[id]
+		mergeOptions(&prev, o)
+		c.byClient[id] = prev
+	}
+}
+
+// mergeOptions sets the options o sets in dst.
+func mergeOptions(dst *process.RequestOptions, o process.RequestOptions) {
+	if o.NoKeepAlive != nil {
+		dst.NoKeepAlive = o.NoKeepAlive
+	}
+	if o.UserAgent != "" {
+		dst.UserAgent = o.UserAgent
+	}
+	if o.Variant != "" {
+		dst.Variant = o.Variant
+	}
+	if o.NoCache != nil {
+		dst.NoCache = o.NoCache
+	}
+	if o.Headers != nil {
+		dst.Headers = o.Headers
+	}
+}
+
+// supersede clears the options o sets from prev.
+func supersede(prev, o process.RequestOptions) process.RequestOptions {
+	if o.Variant != "" {
+		prev.Variant = ""
+	}
+	if o.NoCache != nil {
+		prev.NoCache = nil
+	}
+	if o.Headers != nil {
+		prev.Headers = nil
+	}
+	return prev
+}
+
+// Reconfigure changes the options of running clients without stopping
+// the run. Each client picks the change up when its FFmpeg next starts:
+// at its next restart, or now with a rolling restart at ch.RestartRate.
+// A rolling restart replaces one still going on.
+func (o *Orchestrator) Reconfigure(ch ClientChange) error {
+	if ch.Variant != "" {
+		if ch.Variant != process.VariantAll && ch.Variant != process.VariantFirst {
+			return fmt.Errorf("reconfigure: variant %q: want all or first", ch.Variant)
+		}
+		if o.variants != nil {
+			return errors.New("reconfigure: -variant-mix picks each client's variant")
+		}
+	}
+	if ch.RestartRate < 0 {
+		return fmt.Errorf("reconfigure: restart rate %g", ch.RestartRate)
+	}
+	for _, id := range ch.Clients {
+		if id < 0 {
+			return fmt.Errorf("reconfigure: client %d", id)
+		}
+	}
+
+	ctx, err := o.runContext("reconfigure")
+	if err != nil {
+		return err
+	}
+
+	o.overrides.apply(ch)
+	o.logger.Info("reconfigure",
+		"clients", describeClients(ch.Clients),
+		"headers", ch.Headers,
+		"no_cache", ch.NoCache,
+		"variant", ch.Variant,
+		"restart_rate", ch.RestartRate,
+	)
+
+	if ch.RestartRate > 0 {
+		ids := ch.Clients
+		if ids == nil {
+			ids = o.clientManager.RunningClients() // The rest start with the change anyway
+		}
+		o.rollingRestart(ctx, ids, ch.RestartRate, "reconfigure")
+	}
+	return nil
+}
+
+// describeClients is ch.Clients for the log: "all" or the IDs.
+func describeClients(ids []int) any {
+	if ids == nil {
+		return "all"
+	}
+	return ids
+}
@@ -0,0 +1,119 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+func TestClientOverrides(t *testing.T) {
+	// Client 1 is in a -compare-opt cohort
+	c := newClientOverrides(func(clientID int) *process.RequestOptions {
+		if clientID == 1 {
+			return &process.RequestOptions{Variant: process.VariantFirst, UserAgent: "B"}
+		}
+		return nil
+	})
+	if c.options(0) != nil || c.options(1).UserAgent != "B" {
+		t.Fatal("before any change: want the cohort's options only")
+	}
+
+	on := true
+	c.apply(ClientChange{Clients: []int{2}, Headers: []string{"X-Cohort: 2"}})
+	c.apply(ClientChange{NoCache: &on})
+	if o := c.options(0); o == nil || o.NoCache == nil || !*o.NoCache || o.Headers != nil {
+		t.Errorf("client 0 = %+v, want NoCache only", o)
+	}
+	if o := c.options(1); o.UserAgent != "B" || o.Variant != process.VariantFirst || !*o.NoCache {
+		t.Errorf("client 1 = %+v, want the cohort's options and NoCache", o)
+	}
+	if o := c.options(2); !slices.Equal(o.Headers, []string{"X-Cohort: 2"}) || !*o.NoCache {
+		t.Errorf("client 2 = %+v, want its headers and NoCache", o)
+	}
+
+	// A later change to every client supersedes client 2's headers
+	c.apply(ClientChange{Headers: []string{}})
+	if o := c.options(2); o.Headers == nil || len(o.Headers) != 0 {
+		t.Errorf("client 2 headers = %#v, want none", o.Headers)
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	o := newScaleOrchestrator(3)
+	var mu sync.Mutex
+	starts := make(map[int]int)
+	startsOf := func(id int) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return starts[id]
+	}
+	o.clientManager = NewClientManager(ManagerConfig{
+		Builder: &sleepProcessBuilder{},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Callbacks: ManagerCallbacks{
+			OnClientStart: func(clientID, pid int) {
+				mu.Lock()
+				starts[clientID]++
+				mu.Unlock()
+			},
+		},
+	})
+	o.overrides = newClientOverrides(nil)
+
+	if err := o.Reconfigure(ClientChange{NoCache: new(bool)}); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("Reconfigure() before Run = %v, want not running", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		o.clientManager.Shutdown(shutdownCtx)
+	}()
+	o.scale.ctx, o.scale.ramping = ctx, true
+	o.rampUp(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(o.clientManager.RunningClients()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, bad := range []ClientChange{
+		{Variant: process.VariantHighest},
+		{RestartRate: -1},
+		{Clients: []int{-1}},
+	} {
+		if err := o.Reconfigure(bad); err == nil {
+			t.Errorf("Reconfigure(%+v) = nil, want error", bad)
+		}
+	}
+
+	// Clients 0 and 2 restart with the change; client 1 keeps running
+	if err := o.Reconfigure(ClientChange{Clients: []int{0, 2}, Variant: process.VariantFirst, RestartRate: 100}); err != nil {
+		t.Fatalf("Reconfigure() = %v", err)
+	}
+	if got := o.overrides.options(2); got == nil || got.Variant != process.VariantFirst {
+		t.Errorf("client 2 options = %+v, want variant first", got)
+	}
+	for _, id := range []int{0, 2} {
+		for startsOf(id) < 2 && time.Now().Before(deadline.Add(5*time.Second)) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := startsOf(id); n != 2 {
+			t.Errorf("client %d started %d times, want 2", id, n)
+		}
+		if o.clientManager.restarting(id) {
+			t.Errorf("client %d still restarting once started again", id)
+		}
+	}
+	if n := startsOf(1); n != 1 {
+		t.Errorf("client 1 started %d times, want 1", n)
+	}
+}
@@ -0,0 +1,228 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// refreshOverride runs -playlist-refresh. FFmpeg reloads a live media
+// playlist once per target duration and has no option to change that, so
+// for a faster cadence the swarm fetches each running client's playlists
+// itself in between. At factor f the client's playlists are then fetched
+// 1/(f*target) times a second: FFmpeg's 1/target plus the swarm's rest.
+//
+// With conditional set, each client revalidates like a player with a
+// cache: every fetch carries the validators of the client's last response
+// for that playlist, and a 304 (manifest.ErrNotModified) counts as such
+// rather than as an error.
+type refreshOverride struct {
+	factor      float64
+	target      time.Duration               // The stream's target duration
+	conditional bool                        // -conditional-playlists
+	clients     func() []int                // Running clients
+	urls        func(clientID int) []string // A client's media playlists
+	fetch       func(context.Context, string, manifest.Validators) (manifest.Validators, error)
+	metrics     *metrics.Collector
+	logger      *slog.Logger
+
+	mu         sync.Mutex
+	validators map[refreshTarget]manifest.Validators // Running clients' last responses (conditional only)
+
+	fetches     atomic.Int64
+	errors      atomic.Int64
+	notModified atomic.Int64
+}
+
+// refreshTarget is one client's media playlist.
+type refreshTarget struct {
+	clientID int
+	url      string
+}
+
+// extraInterval returns how often a client's playlists are fetched by the
+// swarm, on top of FFmpeg's reloads, for a refresh every factor x target.
+func extraInterval(factor float64, target time.Duration) time.Duration {
+	return time.Duration(float64(target) * factor / (1 - factor))
+}
+
+// run fetches every running client's playlists once per extra interval,
+// spread evenly over it so the extra load is steady rather than a burst,
+// until ctx is cancelled.
+func (r *refreshOverride) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	round := extraInterval(r.factor, r.target)
+	wait := func(d time.Duration) bool {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+			return true
+		}
+	}
+
+	for {
+		var targets []refreshTarget
+		for _, id := range r.clients() {
+			for _, u := range r.urls(id) {
+				targets = append(targets, refreshTarget{clientID: id, url: u})
+			}
+		}
+		r.forgetStopped(targets)
+		if len(targets) == 0 {
+			if !wait(round) {
+				return
+			}
+			continue
+		}
+
+		gap := round / time.Duration(len(targets))
+		for _, target := range targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.fetchOne(ctx, target)
+			}()
+			if !wait(gap) {
+				return
+			}
+		}
+	}
+}
+
+func (r *refreshOverride) fetchOne(ctx context.Context, target refreshTarget) {
+	var v manifest.Validators
+	if r.conditional {
+		r.mu.Lock()
+		v = r.validators[target]
+		r.mu.Unlock()
+	}
+
+	next, err := r.fetch(ctx, target.url, v)
+	if ctx.Err() != nil {
+		return // Cut short by shutdown, not the origin
+	}
+	r.fetches.Add(1)
+	notModified := errors.Is(err, manifest.ErrNotModified)
+	switch {
+	case notModified:
+		r.notModified.Add(1)
+		err = nil
+	case err != nil:
+		r.errors.Add(1)
+		r.logger.Debug("playlist_refresh_failed", "url", target.url, "error", err)
+	}
+	if r.conditional && err == nil {
+		r.mu.Lock()
+		if r.validators == nil {
+			r.validators = make(map[refreshTarget]manifest.Validators)
+		}
+		r.validators[target] = next
+		r.mu.Unlock()
+	}
+	r.metrics.RecordPlaylistRefreshOverride(notModified, err)
+}
+
+// forgetStopped drops the validators of clients no longer running: a
+// restarted client is a new player with an empty cache.
+func (r *refreshOverride) forgetStopped(running []refreshTarget) {
+	if !r.conditional {
+		return
+	}
+	keep := make(map[refreshTarget]bool, len(running))
+	for _, t := range running {
+		keep[t] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for t := range r.validators {
+		if !keep[t] {
+			delete(r.validators, t)
+		}
+	}
+}
+
+// summary returns the override for the exit summary.
+func (r *refreshOverride) summary() *stats.RefreshOverrideSummary {
+	return &stats.RefreshOverrideSummary{
+		Factor:      r.factor,
+		Target:      r.target,
+		Fetches:     r.fetches.Load(),
+		Errors:      r.errors.Load(),
+		Conditional: r.conditional,
+		NotModified: r.notModified.Load(),
+	}
+}
+
+// setupRefreshOverride finds the media playlists the clients fetch and
+// their target duration for -playlist-refresh.
+func (o *Orchestrator) setupRefreshOverride(ctx context.Context) error {
+	prober := o.newProber(ctx)
+
+	probeCtx, cancel := context.WithTimeout(ctx, o.config.Timeout)
+	defer cancel()
+	res, err := prober.Probe(probeCtx, o.config.StreamURL)
+	if err != nil {
+		return fmt.Errorf("%w: playlist refresh: %w", ErrEnvironment, err)
+	}
+
+	// The renditions the clients fetch: their -variant-mix variant, or
+	// every -variant selection of a master playlist
+	var urls []string
+	if len(res.Variants) == 0 {
+		urls = []string{o.config.StreamURL}
+	} else {
+		for _, v := range manifest.SelectVariants(res.Variants, o.config.Variant) {
+			urls = append(urls, v.URI)
+		}
+	}
+	clientURLs := func(int) []string { return urls }
+	if o.variants != nil {
+		clientURLs = func(clientID int) []string {
+			if u := o.variants.url(clientID); u != "" {
+				return []string{u}
+			}
+			return nil
+		}
+	}
+
+	target := o.config.TargetDuration
+	if res.Media != nil && res.Media.TargetDuration > 0 {
+		target = res.Media.TargetDuration
+	}
+
+	o.reloader = &refreshOverride{
+		factor:      o.config.PlaylistRefresh,
+		target:      target,
+		conditional: o.config.ConditionalPlaylists,
+		clients:     o.clientManager.RunningClients,
+		urls:        clientURLs,
+		fetch: func(ctx context.Context, url string, v manifest.Validators) (manifest.Validators, error) {
+			_, next, err := prober.FetchConditional(ctx, url, v)
+			return next, err
+		},
+		metrics: o.metrics,
+		logger:  o.logger,
+	}
+	o.logger.Info("playlist_refresh_override",
+		"factor", o.config.PlaylistRefresh,
+		"target_duration", target,
+		"interval", time.Duration(float64(target)*o.config.PlaylistRefresh),
+		"extra_interval", extraInterval(o.config.PlaylistRefresh, target),
+		"playlists_per_client", len(clientURLs(0)),
+		"conditional", o.config.ConditionalPlaylists,
+	)
+	return nil
+}
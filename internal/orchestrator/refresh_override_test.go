@@ -0,0 +1,149 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+func TestExtraInterval(t *testing.T) {
+	tests := []struct {
+		factor float64
+		want   time.Duration
+	}{
+		{0.5, 6 * time.Second},  // FFmpeg at 6s, the swarm at 6s between: every 3s
+		{0.25, 2 * time.Second}, // The swarm 3 per 6s: 4 per 6s with FFmpeg's
+		{0.75, 18 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := extraInterval(tt.factor, 6*time.Second); got != tt.want {
+			t.Errorf("extraInterval(%v, 6s) = %v, want %v", tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshOverride_Run(t *testing.T) {
+	var mu sync.Mutex
+	fetched := make(map[string]int)
+	r := &refreshOverride{
+		factor:  0.5,
+		target:  40 * time.Millisecond, // One extra round per 40ms
+		clients: func() []int { return []int{0, 1} },
+		urls: func(clientID int) []string {
+			if clientID == 0 {
+				return []string{"http://origin/a.m3u8"}
+			}
+			return []string{"http://origin/b.m3u8"}
+		},
+		fetch: func(_ context.Context, url string, v manifest.Validators) (manifest.Validators, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			fetched[url]++
+			if v != (manifest.Validators{}) {
+				t.Errorf("conditional fetch of %s without -conditional-playlists", url)
+			}
+			if url == "http://origin/b.m3u8" {
+				return v, errors.New("503")
+			}
+			return manifest.Validators{ETag: `"1"`}, nil
+		},
+		metrics: metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 2}, prometheus.NewRegistry()),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	r.run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// ~4 rounds; both clients fetched evenly
+	if a, b := fetched["http://origin/a.m3u8"], fetched["http://origin/b.m3u8"]; a < 2 || a > 5 || b < a-1 || b > a+1 {
+		t.Errorf("fetches = %v, want 2-5 per client, evenly", fetched)
+	}
+
+	s := r.summary()
+	if s.Factor != 0.5 || s.Target != 40*time.Millisecond {
+		t.Errorf("summary() = %+v", s)
+	}
+	if s.Fetches != int64(fetched["http://origin/a.m3u8"]+fetched["http://origin/b.m3u8"]) ||
+		s.Errors != int64(fetched["http://origin/b.m3u8"]) {
+		t.Errorf("summary() = %+v, want every fetch counted and b's as errors (%v)", s, fetched)
+	}
+}
+
+func TestRefreshOverride_NoClients(t *testing.T) {
+	r := &refreshOverride{
+		factor:  0.5,
+		target:  10 * time.Millisecond,
+		clients: func() []int { return nil },
+		urls:    func(int) []string { t.Error("urls() called without clients"); return nil },
+		fetch: func(context.Context, string, manifest.Validators) (manifest.Validators, error) {
+			t.Error("fetch() called without clients")
+			return manifest.Validators{}, nil
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r.run(ctx) // Waits for clients until cancelled
+}
+
+func TestRefreshOverride_Conditional(t *testing.T) {
+	running := []int{0, 1}
+	var sent []manifest.Validators
+	r := &refreshOverride{
+		conditional: true,
+		clients:     func() []int { return running },
+		urls:        func(int) []string { return []string{"http://origin/a.m3u8"} },
+		fetch: func(_ context.Context, _ string, v manifest.Validators) (manifest.Validators, error) {
+			sent = append(sent, v)
+			if v.ETag != "" {
+				return v, manifest.ErrNotModified
+			}
+			return manifest.Validators{ETag: `"1"`}, nil
+		},
+		metrics: metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 2}, prometheus.NewRegistry()),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	a0 := refreshTarget{clientID: 0, url: "http://origin/a.m3u8"}
+	a1 := refreshTarget{clientID: 1, url: "http://origin/a.m3u8"}
+
+	// Each client keeps its own cache: client 1's first fetch is
+	// unconditional even though client 0 has the playlist
+	r.fetchOne(context.Background(), a0)
+	r.fetchOne(context.Background(), a0)
+	r.fetchOne(context.Background(), a1)
+	want := []manifest.Validators{{}, {ETag: `"1"`}, {}}
+	if len(sent) != len(want) {
+		t.Fatalf("sent = %v, want %v", sent, want)
+	}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("fetch %d sent %+v, want %+v", i, sent[i], want[i])
+		}
+	}
+
+	s := r.summary()
+	if !s.Conditional || s.Fetches != 3 || s.NotModified != 1 || s.Errors != 0 {
+		t.Errorf("summary() = %+v, want 3 fetches, 1 not modified, no errors", s)
+	}
+
+	// A stopped client's cache goes with it
+	running = []int{0}
+	r.forgetStopped([]refreshTarget{a0})
+	if _, ok := r.validators[a1]; ok {
+		t.Error("validators of stopped client 1 kept")
+	}
+	if _, ok := r.validators[a0]; !ok {
+		t.Error("validators of running client 0 dropped")
+	}
+}
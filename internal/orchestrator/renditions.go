@@ -0,0 +1,227 @@
+package orchestrator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// renditionVideo names the requests of no picked rendition: the
+// variant's own playlist and segments.
+const renditionVideo = "video"
+
+// renditionStats runs the per-rendition report of -renditions. FFmpeg
+// fetches the picked renditions itself (see process.FFmpegConfig
+// AudioRendition); this tells the clients' segment and playlist requests
+// apart by the directory of each rendition's playlist, where its segments
+// normally live too.
+type renditionStats struct {
+	metrics *metrics.Collector
+
+	mu     sync.Mutex
+	tracks []renditionTrack // Longest directory first
+	counts map[string]*renditionCount
+}
+
+// renditionTrack is a picked rendition whose requests are counted.
+type renditionTrack struct {
+	name  string // "audio en"
+	label string // NAME attribute
+	dir   string // URL path of its playlist's directory, with a trailing /
+}
+
+// renditionCount is one rendition's requests.
+type renditionCount struct {
+	segments, playlists int64
+}
+
+// newRenditionStats returns the -renditions report. Returns nil without
+// -renditions.
+func newRenditionStats(cfg *config.Config, m *metrics.Collector) *renditionStats {
+	if cfg.Renditions == "" {
+		return nil
+	}
+	return &renditionStats{
+		metrics: m,
+		counts:  map[string]*renditionCount{renditionVideo: {}},
+	}
+}
+
+// track starts counting the requests of renditions.
+func (r *renditionStats) track(renditions []manifest.Rendition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rend := range renditions {
+		name := strings.ToLower(rend.Type)
+		if rend.Language != "" {
+			name += " " + rend.Language
+		}
+		if _, dup := r.counts[name]; dup {
+			name += "/" + rend.GroupID
+		}
+		r.counts[name] = &renditionCount{}
+		r.tracks = append(r.tracks, renditionTrack{
+			name:  name,
+			label: rend.Name,
+			dir:   path.Dir(urlPath(rend.URI)) + "/",
+		})
+	}
+	slices.SortStableFunc(r.tracks, func(a, b renditionTrack) int { return cmp.Compare(len(b.dir), len(a.dir)) })
+}
+
+// observe counts a client's segment or playlist request (a
+// ManagerCallbacks.OnDebugEvent).
+func (r *renditionStats) observe(clientID int, e *parser.DebugEvent) {
+	var kind string
+	switch e.Type {
+	case parser.DebugEventHLSRequest:
+		kind = "segment"
+	case parser.DebugEventPlaylistOpen:
+		kind = "playlist"
+	default:
+		return
+	}
+
+	p := urlPath(e.URL)
+	r.mu.Lock()
+	name := renditionVideo
+	for _, t := range r.tracks {
+		if strings.HasPrefix(p, t.dir) {
+			name = t.name
+			break
+		}
+	}
+	if kind == "segment" {
+		r.counts[name].segments++
+	} else {
+		r.counts[name].playlists++
+	}
+	r.mu.Unlock()
+	r.metrics.RecordRenditionRequest(name, kind)
+}
+
+// summary returns the per-rendition exit summary rows, video first.
+func (r *renditionStats) summary() []stats.RenditionSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	video := r.counts[renditionVideo]
+	rows := []stats.RenditionSummary{{Name: renditionVideo, Segments: video.segments, Playlists: video.playlists}}
+	for _, t := range r.tracks {
+		c := r.counts[t.name]
+		rows = append(rows, stats.RenditionSummary{Name: t.name, Label: t.label, Segments: c.segments, Playlists: c.playlists})
+	}
+	slices.SortStableFunc(rows[1:], func(a, b stats.RenditionSummary) int { return strings.Compare(a.Name, b.Name) })
+	return rows
+}
+
+// urlPath returns the path of a request URL, so a -resolve host rewrite
+// doesn't matter.
+func urlPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Path
+}
+
+// pickRenditions returns the renditions of type typ in language sel (or
+// all of them), and whether any matched. Muxed renditions (no URI) match
+// but have no requests of their own, so aren't returned.
+func pickRenditions(renditions []manifest.Rendition, typ, sel string) ([]manifest.Rendition, bool) {
+	var picked []manifest.Rendition
+	found := false
+	seen := make(map[string]bool)
+	for _, r := range renditions {
+		if r.Type != typ || (sel != config.RenditionsAll && r.Language != sel) {
+			continue
+		}
+		found = true
+		if r.URI != "" && !seen[r.URI] {
+			seen[r.URI] = true
+			picked = append(picked, r)
+		}
+	}
+	return picked, found
+}
+
+// renditionLanguages lists the languages of the renditions of type typ,
+// for error messages.
+func renditionLanguages(renditions []manifest.Rendition, typ string) string {
+	var langs []string
+	for _, r := range renditions {
+		if r.Type == typ && r.Language != "" && !slices.Contains(langs, r.Language) {
+			langs = append(langs, r.Language)
+		}
+	}
+	if len(langs) == 0 {
+		return "none"
+	}
+	return strings.Join(langs, ", ")
+}
+
+// setupRenditions fetches the master playlist, checks that it offers the
+// -renditions asked for and starts counting their requests. Fails if the
+// stream isn't a master playlist or lacks a language asked for.
+func (o *Orchestrator) setupRenditions(ctx context.Context) error {
+	audio, subs, _ := config.ParseRenditions(o.config.Renditions) // Validated
+
+	probeCtx, cancel := context.WithTimeout(ctx, o.config.Timeout)
+	defer cancel()
+	master, err := o.newProber(probeCtx).Fetch(probeCtx, o.config.StreamURL)
+	if err != nil {
+		return fmt.Errorf("%w: renditions: %w", ErrEnvironment, err)
+	}
+	if !master.IsMaster {
+		return fmt.Errorf("%w: renditions: %s is not a master playlist", ErrConfig, o.config.StreamURL)
+	}
+
+	var picked []manifest.Rendition
+	for _, want := range []struct{ typ, sel string }{{"AUDIO", audio}, {"SUBTITLES", subs}} {
+		if want.sel == "" {
+			continue
+		}
+		matched, found := pickRenditions(master.Renditions, want.typ, want.sel)
+		if !found {
+			return fmt.Errorf("%w: renditions: no %s rendition in %q (have %s)",
+				ErrConfig, strings.ToLower(want.typ), want.sel, renditionLanguages(master.Renditions, want.typ))
+		}
+		picked = append(picked, matched...)
+	}
+	o.renditions.track(picked)
+
+	// Requests are told apart by directory: a rendition sharing one with
+	// the variants or another rendition takes their requests too
+	for i, t := range o.renditions.tracks {
+		for _, u := range o.renditions.tracks[:i] {
+			if u.dir == t.dir {
+				o.logger.Warn("rendition_requests_ambiguous", "rendition", t.name, "dir", t.dir, "shared_with", u.name)
+			}
+		}
+		for _, v := range master.Variants {
+			if strings.HasPrefix(urlPath(v.URI), t.dir) {
+				o.logger.Warn("rendition_requests_ambiguous", "rendition", t.name, "dir", t.dir, "shared_with", v.URI)
+				break
+			}
+		}
+	}
+	for _, r := range picked {
+		o.logger.Info("rendition",
+			"type", r.Type,
+			"language", r.Language,
+			"name", r.Name,
+			"group", r.GroupID,
+		)
+	}
+	return nil
+}
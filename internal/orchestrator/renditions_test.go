@@ -0,0 +1,107 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
+)
+
+const testRenditionMaster = `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="en",NAME="English",DEFAULT=YES,URI="audio/en/index.m3u8"
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="aac",LANGUAGE="fr",NAME="French",URI="audio/fr/index.m3u8"
+#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID="subs",LANGUAGE="en",NAME="English",URI="subs/en/index.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=800000,AUDIO="aac",SUBTITLES="subs"
+video/low/index.m3u8
+`
+
+func newRenditionOrchestrator(t *testing.T, spec string) *Orchestrator {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/master.m3u8" {
+			io.WriteString(w, testRenditionMaster)
+			return
+		}
+		io.WriteString(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXTINF:2,\nseg1.ts\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	o := newScaleOrchestrator(1)
+	o.config.StreamURL = srv.URL + "/master.m3u8"
+	o.config.Renditions = spec
+	m := metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 1}, prometheus.NewRegistry())
+	o.renditions = newRenditionStats(o.config, m)
+	return o
+}
+
+func TestSetupRenditions(t *testing.T) {
+	o := newRenditionOrchestrator(t, "audio=en,subs=all")
+	if err := o.setupRenditions(context.Background()); err != nil {
+		t.Fatalf("setupRenditions() = %v", err)
+	}
+
+	base := "http://10.0.0.1:8080" // A -resolve rewrite keeps the paths
+	for _, u := range []string{
+		"/video/low/index.m3u8", "/video/low/seg1.ts", "/video/low/seg2.ts",
+		"/audio/en/index.m3u8", "/audio/en/seg1.aac",
+		"/subs/en/index.m3u8", "/subs/en/seg1.vtt",
+		"/audio/fr/seg1.aac", // Not picked
+	} {
+		typ := parser.DebugEventHLSRequest
+		if strings.HasSuffix(u, ".m3u8") {
+			typ = parser.DebugEventPlaylistOpen
+		}
+		o.renditions.observe(1, &parser.DebugEvent{Type: typ, URL: base + u})
+	}
+	o.renditions.observe(1, &parser.DebugEvent{Type: parser.DebugEventTCPStart, URL: base + "/audio/en/seg2.aac"})
+
+	got := o.renditions.summary()
+	want := []struct {
+		name                string
+		segments, playlists int64
+	}{
+		{"video", 3, 1},
+		{"audio en", 1, 1},
+		{"subtitles en", 1, 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("summary = %+v, want %d rows", got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Name != w.name || got[i].Segments != w.segments || got[i].Playlists != w.playlists {
+			t.Errorf("row %d = %+v, want %s with %d segments, %d playlists", i, got[i], w.name, w.segments, w.playlists)
+		}
+	}
+	if got[1].Label != "English" {
+		t.Errorf("audio en label = %q, want English", got[1].Label)
+	}
+}
+
+func TestSetupRenditions_Missing(t *testing.T) {
+	o := newRenditionOrchestrator(t, "audio=de")
+	err := o.setupRenditions(context.Background())
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "have en, fr") {
+		t.Errorf("setupRenditions() = %v, want a config error listing en, fr", err)
+	}
+
+	o = newRenditionOrchestrator(t, "audio=en")
+	o.config.StreamURL = strings.Replace(o.config.StreamURL, "master", "media", 1)
+	if err := o.setupRenditions(context.Background()); !errors.Is(err, ErrConfig) {
+		t.Errorf("setupRenditions() on a media playlist = %v, want a config error", err)
+	}
+}
+
+func TestNewRenditionStats_Off(t *testing.T) {
+	if r := newRenditionStats(&config.Config{}, nil); r != nil {
+		t.Errorf("newRenditionStats() without -renditions = %+v, want nil", r)
+	}
+}
@@ -0,0 +1,139 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// RollingRestart restarts every running client, at rate per second, in
+// the background: each one's FFmpeg is stopped and a new one opens new
+// connections and fetches the manifest afresh, as when a player app
+// update is pushed to every viewer. The rolling restart is annotated in
+// the log, the metrics (hls_swarm_rolling_restart_active) and the
+// -save-run record, so its impact on the origin can be told apart from
+// the load's. It replaces a rolling restart still going on.
+func (o *Orchestrator) RollingRestart(rate float64) error {
+	if rate <= 0 {
+		return fmt.Errorf("rolling restart: rate %g, want > 0", rate)
+	}
+	ctx, err := o.runContext("rolling restart")
+	if err != nil {
+		return err
+	}
+	o.rollingRestart(ctx, o.clientManager.RunningClients(), rate, "")
+	return nil
+}
+
+// runContext returns the run's context, or an error for op when the
+// swarm is not running (yet) or stopping.
+func (o *Orchestrator) runContext(op string) (context.Context, error) {
+	o.scale.mu.Lock()
+	ctx := o.scale.ctx
+	o.scale.mu.Unlock()
+	if ctx == nil {
+		return nil, errors.New(op + ": not running")
+	}
+	if ctx.Err() != nil {
+		return nil, errors.New(op + ": stopping")
+	}
+	return ctx, nil
+}
+
+// rollout is the rolling restart going on, if any.
+type rollout struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc // nil when none is going on
+	done   chan struct{}
+}
+
+// rollingRestart restarts clients ids, at rate per second, in the
+// background: each one's FFmpeg is stopped and its supervisor starts a
+// new one, with whatever options the client has by then. Clients not
+// running when their turn comes are skipped. A rolling restart going on
+// is stopped first. why, if set, is what the restart is for, for the
+// annotations.
+func (o *Orchestrator) rollingRestart(ctx context.Context, ids []int, rate float64, why string) {
+	ids = slices.Clone(ids)
+	o.rollout.mu.Lock()
+	defer o.rollout.mu.Unlock()
+	if o.rollout.cancel != nil {
+		o.rollout.cancel()
+		<-o.rollout.done
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	o.rollout.cancel, o.rollout.done = cancel, done
+
+	detail := fmt.Sprintf("%d clients at %g/s", len(ids), rate)
+	if why != "" {
+		detail += " (" + why + ")"
+	}
+	o.logger.Info("rolling_restart", "clients", len(ids), "rate", rate, "reason", why)
+	o.runEvent("rolling_restart", detail)
+	o.metrics.SetRollingRestart(true)
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		defer o.metrics.SetRollingRestart(false)
+		start := time.Now()
+		interval := time.Duration(float64(time.Second) / rate)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		restarted := 0
+		for i, id := range ids {
+			if i > 0 {
+				select {
+				case <-ctx.Done():
+					o.logger.Info("rolling_restart_stopped", "restarted", restarted, "clients", len(ids))
+					o.runEvent("rolling_restart_stopped",
+						fmt.Sprintf("%d of %d clients restarted", restarted, len(ids)))
+					return
+				case <-ticker.C:
+				}
+			}
+			if o.clientManager.RestartClient(id) {
+				restarted++
+				o.metrics.RecordRollingRestart()
+			}
+		}
+		o.logger.Info("rolling_restart_done", "restarted", restarted, "clients", len(ids))
+		o.runEvent("rolling_restart_done",
+			fmt.Sprintf("%d of %d clients restarted in %s", restarted, len(ids), time.Since(start).Round(time.Millisecond)))
+	}()
+}
+
+// runEvent records a control action for -save-run and streams it to
+// -event-sink.
+func (o *Orchestrator) runEvent(name, detail string) {
+	o.events.add(o.startTime, name, detail)
+	o.emitLifecycle(name, -1, map[string]any{"detail": detail})
+}
+
+// runEvents are the control actions taken during the run, for the
+// -save-run record.
+type runEvents struct {
+	mu     sync.Mutex
+	events []stats.RunEvent
+}
+
+// add records event name now, on the clock of a run started at start.
+func (e *runEvents) add(start time.Time, name, detail string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, stats.RunEvent{T: time.Since(start).Seconds(), Name: name, Detail: detail})
+}
+
+// list returns the events so far.
+func (e *runEvents) list() []stats.RunEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return slices.Clone(e.events)
+}
@@ -0,0 +1,107 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRollingRestart(t *testing.T) {
+	o := newScaleOrchestrator(3)
+	var mu sync.Mutex
+	starts := 0
+	o.clientManager = NewClientManager(ManagerConfig{
+		Builder: &sleepProcessBuilder{},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Callbacks: ManagerCallbacks{
+			OnClientStart: func(clientID, pid int) {
+				mu.Lock()
+				starts++
+				mu.Unlock()
+			},
+		},
+	})
+	startCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return starts
+	}
+
+	if err := o.RollingRestart(10); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("RollingRestart() before Run = %v, want not running", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		o.clientManager.Shutdown(shutdownCtx)
+	}()
+	o.startTime = time.Now()
+	o.scale.ctx, o.scale.ramping = ctx, true
+	o.rampUp(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(o.clientManager.RunningClients()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := o.RollingRestart(-1); err == nil {
+		t.Error("RollingRestart(-1) = nil, want error")
+	}
+	if err := o.RollingRestart(100); err != nil {
+		t.Fatalf("RollingRestart() = %v", err)
+	}
+	for startCount() < 6 && time.Now().Before(deadline.Add(5*time.Second)) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := startCount(); n != 6 {
+		t.Errorf("%d starts, want every client started twice", n)
+	}
+
+	// The rolling restart is annotated in the run record
+	o.rollout.mu.Lock()
+	<-o.rollout.done
+	o.rollout.mu.Unlock()
+	events := o.events.list()
+	if len(events) != 2 || events[0].Name != "rolling_restart" || events[0].Detail != "3 clients at 100/s" ||
+		events[1].Name != "rolling_restart_done" || !strings.HasPrefix(events[1].Detail, "3 of 3 clients restarted") {
+		t.Errorf("events = %+v, want the rolling restart and its end", events)
+	}
+	if events[1].T < events[0].T {
+		t.Errorf("events out of order: %+v", events)
+	}
+}
+
+func TestRollingRestart_Replaced(t *testing.T) {
+	o := newScaleOrchestrator(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	o.startTime = time.Now()
+
+	// Clients that aren't running are skipped, at 1/s: the first rolling
+	// restart is still going on when the second replaces it
+	o.rollingRestart(ctx, []int{7, 8, 9}, 1, "")
+	o.rollingRestart(ctx, []int{7}, 100, "reconfigure")
+	o.rollout.mu.Lock()
+	<-o.rollout.done
+	o.rollout.mu.Unlock()
+
+	var names []string
+	for _, e := range o.events.list() {
+		names = append(names, e.Name+": "+e.Detail)
+	}
+	want := []string{
+		"rolling_restart: 3 clients at 1/s",
+		"rolling_restart_stopped: 0 of 3 clients restarted",
+		"rolling_restart: 1 clients at 100/s (reconfigure)",
+	}
+	if len(names) != 4 || strings.Join(names[:3], "\n") != strings.Join(want, "\n") ||
+		!strings.HasPrefix(names[3], "rolling_restart_done: 0 of 1 clients restarted") {
+		t.Errorf("events =\n%s\nwant\n%s\n...done", strings.Join(names, "\n"), strings.Join(want, "\n"))
+	}
+}
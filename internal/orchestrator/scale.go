@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// scaler tracks the clients the ramp has started so Scale can move the
+// target while the run goes on. Clients are numbered from 0; with n
+// clients wanted, clients 0..n-1 run. Each runs under its own context so
+// scaling down can stop it.
+type scaler struct {
+	mu      sync.Mutex
+	ctx     context.Context // Run's; nil until the ramp begins
+	target  int
+	started int // Clients 0..started-1 have been started
+	cancels map[int]context.CancelFunc
+	ramping bool // A rampUp is starting clients up to target
+}
+
+func newScaler(target int) *scaler {
+	return &scaler{target: target, cancels: make(map[int]context.CancelFunc)}
+}
+
+// next returns the next client to start, or false once target clients
+// are started (the ramp is then over).
+func (s *scaler) next() (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started >= s.target {
+		s.ramping = false
+		return 0, false
+	}
+	return s.started, true
+}
+
+// start starts clientID through startFn under its own context, unless a
+// Scale made it surplus while the ramp waited.
+func (s *scaler) start(clientID int, startFn func(context.Context, int)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if clientID != s.started || clientID >= s.target {
+		return false
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.cancels[clientID] = cancel
+	s.started++
+	startFn(ctx, clientID)
+	return true
+}
+
+// scaleTarget returns the number of clients wanted now.
+func (o *Orchestrator) scaleTarget() int {
+	o.scale.mu.Lock()
+	defer o.scale.mu.Unlock()
+	return o.scale.target
+}
+
+// Scale changes the number of clients while Run runs. Added clients are
+// started at the ramp rate; surplus ones are stopped, newest first. A
+// client started again keeps its stats, so totals stay cumulative.
+//
+// Cohort assignments (-geo, -variant-mix, -compare-url) cover the
+// configured clients only, so with those the swarm can't grow beyond
+// them; -tenants quotas start and stop clients themselves and can't be
+// combined with scaling.
+func (o *Orchestrator) Scale(n int) error {
+	if n < 0 {
+		return fmt.Errorf("scale: %d clients", n)
+	}
+	if o.tenancy != nil {
+		return errors.New("scale: tenant quotas manage the clients (-tenants)")
+	}
+	if n > o.config.Clients && (o.geos != nil || o.variants != nil || o.compare != nil) {
+		return fmt.Errorf("scale: cohorts are assigned to %d clients; can't grow to %d", o.config.Clients, n)
+	}
+
+	s := o.scale
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return errors.New("scale: not running")
+	}
+	if s.ctx.Err() != nil {
+		return errors.New("scale: stopping")
+	}
+
+	for clientID := s.started - 1; clientID >= n; clientID-- {
+		s.cancels[clientID]()
+		delete(s.cancels, clientID)
+	}
+	s.started = min(s.started, n)
+	s.target = n
+	o.metrics.SetTargetClients(n)
+	o.logger.Info("scale", "clients", n, "running", s.started)
+
+	if s.started < n && !s.ramping {
+		s.ramping = true
+		go o.rampUp(s.ctx)
+	}
+	return nil
+}
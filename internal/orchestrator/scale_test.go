@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+func newScaleOrchestrator(clients int) *Orchestrator {
+	cfg := config.DefaultConfig()
+	cfg.Clients = clients
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	collector := metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: clients}, prometheus.NewRegistry())
+	return &Orchestrator{
+		config:        cfg,
+		logger:        logger,
+		metrics:       collector,
+		phases:        newPhases(collector, logger),
+		clientManager: NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}, Logger: logger}),
+		rampScheduler: NewRampScheduler(1000, 0),
+		scale:         newScaler(clients),
+	}
+}
+
+func TestScale(t *testing.T) {
+	o := newScaleOrchestrator(2)
+	if err := o.Scale(3); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("Scale() before Run = %v, want not running", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+		o.clientManager.Shutdown(shutdownCtx)
+	}()
+	o.scale.ctx, o.scale.ramping = ctx, true
+	o.rampUp(ctx)
+	if got := o.clientManager.StartedCount(); got != 2 || o.scale.ramping {
+		t.Fatalf("after the ramp: started %d (ramping %v), want 2 and done", got, o.scale.ramping)
+	}
+
+	// Up: a new ramp starts the added clients
+	if err := o.Scale(4); err != nil {
+		t.Fatalf("Scale(4) = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for o.clientManager.StartedCount() < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := o.clientManager.StartedCount(); got != 4 {
+		t.Fatalf("after Scale(4): started %d, want 4", got)
+	}
+
+	// Down: the newest clients are stopped
+	if err := o.Scale(1); err != nil {
+		t.Fatalf("Scale(1) = %v", err)
+	}
+	o.scale.mu.Lock()
+	started, cancels := o.scale.started, len(o.scale.cancels)
+	o.scale.mu.Unlock()
+	if started != 1 || cancels != 1 || o.scaleTarget() != 1 {
+		t.Errorf("after Scale(1): started %d with %d cancels, target %d; want 1, 1, 1", started, cancels, o.scaleTarget())
+	}
+
+	if err := o.Scale(-1); err == nil {
+		t.Error("Scale(-1) = nil, want an error")
+	}
+	cancel()
+	if err := o.Scale(2); err == nil || !strings.Contains(err.Error(), "stopping") {
+		t.Errorf("Scale() after stop = %v, want stopping", err)
+	}
+}
+
+func TestScale_Cohorts(t *testing.T) {
+	o := newScaleOrchestrator(4)
+	o.scale.ctx = t.Context()
+	o.geos = newGeoMap([]config.Geo{{Name: "eu", Weight: 1}}, 4, o.clientManager)
+	if err := o.Scale(5); err == nil || !strings.Contains(err.Error(), "cohorts") {
+		t.Errorf("Scale(5) with -geo = %v, want refused", err)
+	}
+	if err := o.Scale(2); err != nil {
+		t.Errorf("Scale(2) with -geo = %v, want nil (the cohorts still cover them)", err)
+	}
+
+	o.tenancy = &tenancy{}
+	if err := o.Scale(2); err == nil || !strings.Contains(err.Error(), "tenant") {
+		t.Errorf("Scale() with -tenants = %v, want refused", err)
+	}
+}
+
+func TestScaler_SurplusWhileWaiting(t *testing.T) {
+	s := newScaler(3)
+	s.ctx = t.Context()
+	s.ramping = true
+	start := func(context.Context, int) {}
+
+	id, ok := s.next()
+	if !ok || id != 0 || !s.start(id, start) {
+		t.Fatalf("first client: next() = %d, %v", id, ok)
+	}
+	id, _ = s.next()
+
+	// Scaled down to 1 while the ramp waited to start client 1
+	s.mu.Lock()
+	s.target = 1
+	s.mu.Unlock()
+	if s.start(id, start) {
+		t.Error("start() of a surplus client = true, want false")
+	}
+	if _, ok := s.next(); ok || s.ramping {
+		t.Error("next() after scaling down = true, want the ramp over")
+	}
+}
@@ -0,0 +1,35 @@
+package orchestrator
+
+import (
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// recordServing exports the -cdn-detect breakdown of the responses so far
+// and logs when the origin hit ratio behind the CDN crosses
+// -origin-hit-alert, either way, so a cache falling over mid-run shows up
+// in the log as it happens rather than only in the exit summary.
+func (o *Orchestrator) recordServing(ds *stats.DebugStatsAggregate) {
+	s := stats.BreakdownServing(ds.Serving, o.config.OriginHitAlert)
+	if s == nil {
+		return
+	}
+	o.metrics.RecordServing(s)
+
+	if s.Flagged == o.originHitFlagged {
+		return
+	}
+	o.originHitFlagged = s.Flagged
+	if s.Flagged {
+		o.logger.Warn("origin_hit_ratio_high",
+			"ratio_percent", s.OriginHitRatio*100,
+			"alert_percent", s.Alert,
+			"origin_hits", s.OriginHits,
+			"responses", s.Responses,
+		)
+		return
+	}
+	o.logger.Info("origin_hit_ratio_recovered",
+		"ratio_percent", s.OriginHitRatio*100,
+		"alert_percent", s.Alert,
+	)
+}
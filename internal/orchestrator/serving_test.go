@@ -0,0 +1,48 @@
+package orchestrator
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestRecordServing(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := config.DefaultConfig()
+	cfg.CDNDetect = true
+	o := &Orchestrator{
+		config:  cfg,
+		logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+		metrics: metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 1}, prometheus.NewRegistry()),
+	}
+
+	o.recordServing(&stats.DebugStatsAggregate{}) // Nothing classified yet
+	if o.originHitFlagged || logs.Len() > 0 {
+		t.Fatalf("flagged without responses: %s", logs.String())
+	}
+
+	high := &stats.DebugStatsAggregate{Serving: map[string]stats.ServingTotals{
+		stats.ServedEdgeHit:  {Responses: 50},
+		stats.ServedEdgeMiss: {Responses: 50},
+	}}
+	o.recordServing(high)
+	o.recordServing(high) // Logged once
+	if !o.originHitFlagged || strings.Count(logs.String(), "origin_hit_ratio_high") != 1 {
+		t.Fatalf("flagged = %v, logs:\n%s", o.originHitFlagged, logs.String())
+	}
+
+	o.recordServing(&stats.DebugStatsAggregate{Serving: map[string]stats.ServingTotals{
+		stats.ServedEdgeHit:  {Responses: 950},
+		stats.ServedEdgeMiss: {Responses: 50},
+	}})
+	if o.originHitFlagged || !strings.Contains(logs.String(), "origin_hit_ratio_recovered") {
+		t.Errorf("flagged = %v, logs:\n%s", o.originHitFlagged, logs.String())
+	}
+}
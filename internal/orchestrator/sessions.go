@@ -0,0 +1,156 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// Session lengths are drawn no shorter than minSessionLength, so a
+// lognormal tail can't have a client restart in a tight loop. Arrival and
+// departure rates are sampled every sessionRateInterval.
+const (
+	minSessionLength    = time.Second
+	sessionRateInterval = 10 * time.Second
+)
+
+// sessionTracker runs -session-duration: it draws each viewer session's
+// length for the supervisors, which end the session cleanly and start a
+// new one in its place, and counts the arrivals and departures.
+type sessionTracker struct {
+	spec    string
+	dist    config.SessionDist
+	metrics *metrics.Collector
+	logger  *slog.Logger
+
+	mu         sync.Mutex
+	rng        *rand.Rand
+	arrivals   int64
+	departures int64
+	lengthSum  time.Duration // Of the ended sessions
+	minLength  time.Duration
+	maxLength  time.Duration
+}
+
+// newSessionTracker returns the -session-duration tracker. Returns nil
+// without it.
+func newSessionTracker(cfg *config.Config, m *metrics.Collector, logger *slog.Logger) *sessionTracker {
+	if cfg.SessionDuration == "" {
+		return nil
+	}
+	dist, err := config.ParseSessionDuration(cfg.SessionDuration) // Validated
+	if err != nil {
+		return nil
+	}
+	return &sessionTracker{
+		spec:    cfg.SessionDuration,
+		dist:    dist,
+		metrics: m,
+		logger:  logger,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// length draws a session length.
+func (t *sessionTracker) length() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var d time.Duration
+	switch t.dist.Kind {
+	case config.SessionUniform:
+		d = t.dist.Min + time.Duration(t.rng.Int63n(int64(t.dist.Max-t.dist.Min)+1))
+	case config.SessionLognormal:
+		d = time.Duration(float64(t.dist.Median) * math.Exp(t.dist.Sigma*t.rng.NormFloat64()))
+	default:
+		d = t.dist.Min
+	}
+	return max(d, minSessionLength)
+}
+
+// meanLength returns the mean length of the sessions length draws, for
+// the --plan estimate of how often sessions start.
+func (t *sessionTracker) meanLength() time.Duration {
+	var d time.Duration
+	switch t.dist.Kind {
+	case config.SessionUniform:
+		d = (t.dist.Min + t.dist.Max) / 2
+	case config.SessionLognormal:
+		d = time.Duration(float64(t.dist.Median) * math.Exp(t.dist.Sigma*t.dist.Sigma/2))
+	default:
+		d = t.dist.Min
+	}
+	return max(d, minSessionLength)
+}
+
+// started counts a session started.
+func (t *sessionTracker) started(clientID int) {
+	t.mu.Lock()
+	t.arrivals++
+	t.mu.Unlock()
+	t.metrics.RecordSessionStart()
+}
+
+// ended counts a session ended after length.
+func (t *sessionTracker) ended(clientID int, length time.Duration) {
+	t.mu.Lock()
+	if t.departures == 0 || length < t.minLength {
+		t.minLength = length
+	}
+	t.maxLength = max(t.maxLength, length)
+	t.departures++
+	t.lengthSum += length
+	t.mu.Unlock()
+	t.metrics.RecordSessionEnd(length)
+}
+
+// counts returns the sessions started and ended so far.
+func (t *sessionTracker) counts() (arrivals, departures int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.arrivals, t.departures
+}
+
+// run sets the arrival and departure rates every sessionRateInterval
+// until ctx is cancelled.
+func (t *sessionTracker) run(ctx context.Context) {
+	ticker := time.NewTicker(sessionRateInterval)
+	defer ticker.Stop()
+
+	prevArrivals, prevDepartures := t.counts()
+	prevAt := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			arrivals, departures := t.counts()
+			secs := now.Sub(prevAt).Seconds()
+			t.metrics.SetSessionRates(float64(arrivals-prevArrivals)/secs, float64(departures-prevDepartures)/secs)
+			prevArrivals, prevDepartures, prevAt = arrivals, departures, now
+		}
+	}
+}
+
+// summary returns the -session-duration exit summary section.
+func (t *sessionTracker) summary() *stats.SessionSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &stats.SessionSummary{
+		Distribution: t.spec,
+		Started:      t.arrivals,
+		Ended:        t.departures,
+		MinLength:    t.minLength,
+		MaxLength:    t.maxLength,
+	}
+	if t.departures > 0 {
+		s.MeanLength = t.lengthSum / time.Duration(t.departures)
+	}
+	return s
+}
@@ -0,0 +1,95 @@
+package orchestrator
+
+import (
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+func newTestSessionTracker(t *testing.T, spec string) *sessionTracker {
+	t.Helper()
+	m := metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: 10}, prometheus.NewRegistry())
+	tr := newSessionTracker(&config.Config{SessionDuration: spec}, m, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if tr == nil {
+		t.Fatalf("newSessionTracker(%q) = nil", spec)
+	}
+	return tr
+}
+
+func TestNewSessionTracker_Off(t *testing.T) {
+	if tr := newSessionTracker(&config.Config{}, nil, nil); tr != nil {
+		t.Errorf("newSessionTracker() without -session-duration = %+v, want nil", tr)
+	}
+}
+
+func TestSessionTracker_Length(t *testing.T) {
+	if got := newTestSessionTracker(t, "90s").length(); got != 90*time.Second {
+		t.Errorf("fixed length = %s, want 90s", got)
+	}
+
+	uniform := newTestSessionTracker(t, "uniform:5m-30m")
+	for i := 0; i < 1000; i++ {
+		if d := uniform.length(); d < 5*time.Minute || d > 30*time.Minute {
+			t.Fatalf("uniform length %s outside 5m-30m", d)
+		}
+	}
+
+	lognormal := newTestSessionTracker(t, "lognormal:10m,1")
+	lengths := make([]time.Duration, 2001)
+	for i := range lengths {
+		lengths[i] = lognormal.length()
+		if lengths[i] < minSessionLength {
+			t.Fatalf("lognormal length %s below %s", lengths[i], minSessionLength)
+		}
+	}
+	slices.Sort(lengths)
+	if median := lengths[len(lengths)/2]; median < 8*time.Minute || median > 12*time.Minute {
+		t.Errorf("lognormal median = %s, want about 10m", median)
+	}
+	if lengths[len(lengths)*95/100] < 30*time.Minute {
+		t.Errorf("lognormal P95 = %s, want a long tail (about 52m)", lengths[len(lengths)*95/100])
+	}
+}
+
+func TestSessionTracker_MeanLength(t *testing.T) {
+	tests := []struct {
+		spec string
+		want time.Duration
+	}{
+		{"90s", 90 * time.Second},
+		{"uniform:5m-30m", 17*time.Minute + 30*time.Second},
+		{"lognormal:10m,1", time.Duration(float64(10*time.Minute) * 1.6487212707)}, // e^(1/2)
+		{"100ms", minSessionLength},
+	}
+	for _, tt := range tests {
+		got := newTestSessionTracker(t, tt.spec).meanLength()
+		if d := got - tt.want; d < -time.Millisecond || d > time.Millisecond {
+			t.Errorf("meanLength(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestSessionTracker_Summary(t *testing.T) {
+	tr := newTestSessionTracker(t, "uniform:1m-3m")
+	for id := 0; id < 3; id++ {
+		tr.started(id)
+	}
+	tr.ended(0, 2*time.Minute)
+	tr.started(0)
+	tr.ended(1, time.Minute)
+
+	s := tr.summary()
+	if s.Distribution != "uniform:1m-3m" || s.Started != 4 || s.Ended != 2 {
+		t.Errorf("summary = %+v, want 4 started, 2 ended", s)
+	}
+	if s.MeanLength != 90*time.Second || s.MinLength != time.Minute || s.MaxLength != 2*time.Minute {
+		t.Errorf("lengths mean %s, min %s, max %s; want 1m30s, 1m, 2m", s.MeanLength, s.MinLength, s.MaxLength)
+	}
+}
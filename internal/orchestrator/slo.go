@@ -0,0 +1,107 @@
+package orchestrator
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// sloBurnWindow is how far back the -slo burn rate looks: long enough to
+// ride out one slow refresh, short enough to show the current load's.
+const sloBurnWindow = 5 * time.Minute
+
+// sloTracker follows the -slo segment latency objective: compliance over
+// the run from the parsers' miss counts, and the error budget burn rate
+// over the last sloBurnWindow from samples of them.
+type sloTracker struct {
+	target    float64
+	threshold time.Duration
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	samples []sloSample // Oldest first; the first is at or before the window start
+	latest  stats.SLOStatus
+	burning bool // BurnRate > 1 at the last update
+}
+
+// sloSample is the cumulative segment and miss counts at one update.
+type sloSample struct {
+	at       time.Time
+	segments int64
+	misses   int64
+}
+
+// newSLOTracker returns the tracker, or nil without -slo.
+func newSLOTracker(cfg *config.Config, logger *slog.Logger) *sloTracker {
+	if cfg.SLO == "" {
+		return nil
+	}
+	target, threshold, err := config.ParseSLO(cfg.SLO)
+	if err != nil {
+		return nil // Validated
+	}
+	return &sloTracker{
+		target:    target,
+		threshold: threshold,
+		logger:    logger,
+		latest:    stats.SLOStatus{Target: target, Threshold: threshold},
+	}
+}
+
+// update takes the cumulative segment and miss counts as of now and
+// returns the new status. It logs when the burn rate goes over 1 (and
+// back), so a log-only run still shows when the budget started to go.
+func (t *sloTracker) update(now time.Time, segments, misses int64) stats.SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.samples = append(t.samples, sloSample{at: now, segments: segments, misses: misses})
+	start := now.Add(-sloBurnWindow)
+	for len(t.samples) > 1 && !t.samples[1].at.After(start) {
+		t.samples = t.samples[1:]
+	}
+	base := t.samples[0]
+
+	t.latest = stats.SLOStatus{
+		Target:    t.target,
+		Threshold: t.threshold,
+		Segments:  segments,
+		Misses:    misses,
+		BurnRate:  stats.BurnRate(misses-base.misses, segments-base.segments, t.target),
+		Window:    now.Sub(base.at),
+	}
+
+	if burning := t.latest.BurnRate > 1; burning != t.burning {
+		t.burning = burning
+		args := []any{
+			"burn_rate", t.latest.BurnRate,
+			"window", t.latest.Window,
+			"attainment", t.latest.Attainment(),
+			"target", t.target,
+			"threshold", t.threshold,
+		}
+		if burning {
+			t.logger.Warn("slo_burning", args...)
+		} else {
+			t.logger.Info("slo_burn_recovered", args...)
+		}
+	}
+	return t.latest
+}
+
+// final updates from the debug stats at the end of the run and returns
+// the run's result.
+func (t *sloTracker) final(ds stats.DebugStatsAggregate) *stats.SLOStatus {
+	s := t.update(time.Now(), ds.SegmentsDownloaded, ds.SLOMisses)
+	return &s
+}
+
+// status returns the status as of the last update.
+func (t *sloTracker) status() stats.SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.latest
+}
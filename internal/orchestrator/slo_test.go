@@ -0,0 +1,78 @@
+package orchestrator
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+)
+
+func TestNewSLOTracker(t *testing.T) {
+	cfg := config.DefaultConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if newSLOTracker(cfg, logger) != nil {
+		t.Error("newSLOTracker() != nil without -slo")
+	}
+
+	cfg.SLO = "99.5:750ms"
+	tr := newSLOTracker(cfg, logger)
+	if tr == nil {
+		t.Fatal("newSLOTracker() = nil with -slo")
+	}
+	if s := tr.status(); !s.Enabled() || math.Abs(s.Target-0.995) > 1e-9 || s.Threshold != 750*time.Millisecond {
+		t.Errorf("status() = %+v, want target 0.995 under 750ms", s)
+	}
+}
+
+func TestSLOTracker_Update(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := config.DefaultConfig()
+	cfg.SLO = "99:1s"
+	tr := newSLOTracker(cfg, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	// Clean first minute: 1000 segments, no misses
+	tr.update(at(0), 0, 0)
+	s := tr.update(at(time.Minute), 1000, 0)
+	if s.BurnRate != 0 || s.Window != time.Minute {
+		t.Errorf("clean minute: burn %v over %v, want 0 over 1m", s.BurnRate, s.Window)
+	}
+
+	// Next minute 1000 segments, 30 misses: 3% over the window's 2000
+	// segments against a 1% budget
+	s = tr.update(at(2*time.Minute), 2000, 30)
+	if math.Abs(s.BurnRate-1.5) > 1e-9 || s.Window != 2*time.Minute {
+		t.Errorf("burn %v over %v, want 1.5 over 2m", s.BurnRate, s.Window)
+	}
+	if math.Abs(s.Attainment()-0.985) > 1e-9 || s.Met() {
+		t.Errorf("attainment %v (met %v), want 0.985, missed", s.Attainment(), s.Met())
+	}
+	if !strings.Contains(logs.String(), "slo_burning") {
+		t.Errorf("no slo_burning log:\n%s", logs.String())
+	}
+
+	// Clean minutes until the bad one leaves the window: the burn rate only
+	// looks back sloBurnWindow, while attainment still counts the misses
+	for m := 3; m <= 8; m++ {
+		s = tr.update(at(time.Duration(m)*time.Minute), int64(m)*1000, 30)
+	}
+	if s.BurnRate != 0 || s.Window != sloBurnWindow {
+		t.Errorf("after the window: burn %v over %v, want 0 over %v", s.BurnRate, s.Window, sloBurnWindow)
+	}
+	if s.Misses != 30 || s.Segments != 8000 {
+		t.Errorf("run totals = %d/%d, want 30/8000", s.Misses, s.Segments)
+	}
+	if !strings.Contains(logs.String(), "slo_burn_recovered") {
+		t.Errorf("no slo_burn_recovered log:\n%s", logs.String())
+	}
+	if got := tr.status(); got != s {
+		t.Errorf("status() = %+v, want the last update %+v", got, s)
+	}
+}
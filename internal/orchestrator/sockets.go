@@ -0,0 +1,56 @@
+package orchestrator
+
+import (
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/sockstats"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// setupSocketStats prepares -socket-stats sampling of the clients'
+// connections. Where the kernel can't be asked (not Linux, netlink denied)
+// the run goes ahead with log-derived TCP stats and the summary says why.
+func (o *Orchestrator) setupSocketStats() {
+	var err error
+	o.sockets, err = sockstats.New(sockstats.Config{
+		Interval: o.config.StatsAggregateInterval,
+		PIDs:     o.clientManager.PIDs,
+		Logger:   o.logger,
+		OnSample: func(s sockstats.Snapshot) {
+			o.metrics.RecordSockets(metrics.SocketUpdate{
+				Connections:   s.Connections,
+				RTTP50:        s.RTTP50,
+				RTTP95:        s.RTTP95,
+				RTTP99:        s.RTTP99,
+				MinRTT:        s.MinRTT,
+				Retransmits:   s.Retransmits,
+				OutOfOrder:    s.OutOfOrder,
+				BytesReceived: s.BytesReceived,
+				ThroughputBps: s.ThroughputBps,
+			})
+		},
+	})
+	if err != nil {
+		o.sockets = nil
+		o.socketsErr = err.Error()
+		o.logger.Warn("socket_stats_disabled", "error", err)
+	}
+}
+
+// socketSummary describes the run's socket samples for the exit summary.
+func (o *Orchestrator) socketSummary() *stats.SocketSummary {
+	if o.sockets == nil {
+		return &stats.SocketSummary{Error: o.socketsErr}
+	}
+	s := o.sockets.Summary()
+	return &stats.SocketSummary{
+		Samples:         s.Samples,
+		PeakConnections: s.PeakConnections,
+		RTTP50:          s.RTTP50,
+		RTTP95:          s.RTTP95,
+		RTTP99:          s.RTTP99,
+		MinRTT:          s.MinRTT,
+		Retransmits:     s.Retransmits,
+		OutOfOrder:      s.OutOfOrder,
+		BytesReceived:   s.BytesReceived,
+	}
+}
@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/timesync"
+)
+
+const (
+	// ntpQueryTimeout bounds the clock check so an unreachable NTP server
+	// delays a scheduled start by at most this much.
+	ntpQueryTimeout = 2 * time.Second
+
+	// clockUncertaintyWarn is the NTP round trip above which the measured
+	// offset is too imprecise for hosts to start within a few tens of ms.
+	clockUncertaintyWarn = 200 * time.Millisecond
+)
+
+// startDelay returns how long to wait until startAt, given the local clock
+// reading and its offset from the reference clock (positive: local is behind).
+func startDelay(startAt, localNow time.Time, offset time.Duration) time.Duration {
+	return startAt.Sub(localNow.Add(offset))
+}
+
+// waitForStartAt blocks until -start-at on the NTP-corrected clock, so
+// independent swarm instances begin ramping together. It returns false if
+// a signal or cancellation arrives first.
+func (o *Orchestrator) waitForStartAt(ctx context.Context, sigCh <-chan os.Signal) bool {
+	startAt := o.config.StartAt
+	offset := o.measureClockOffset(ctx)
+	o.clockOffset = offset // Hosts of one run then agree on timestamps too
+
+	delay := startDelay(startAt, time.Now(), offset)
+	if delay <= 0 {
+		o.logger.Warn("start_at_passed", "start_at", startAt.Format(time.RFC3339), "late_by", (-delay).String())
+		fmt.Fprintf(o.out, "⚠ Start time %s passed %s ago, starting now\n\n", startAt.Format(time.RFC3339), (-delay).Round(time.Millisecond))
+		return true
+	}
+
+	o.logger.Info("waiting_for_start_at", "start_at", startAt.Format(time.RFC3339), "wait", delay.String())
+	fmt.Fprintf(o.out, "Waiting until %s (in %s)...\n\n", startAt.Format(time.RFC3339), delay.Round(time.Second))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		o.logger.Info("start_at_reached", "start_at", startAt.Format(time.RFC3339))
+		return true
+	case sig := <-sigCh:
+		o.logger.Info("received_signal", "signal", sig.String(), "phase", "waiting_for_start_at")
+	case <-ctx.Done():
+		o.logger.Info("context_cancelled", "phase", "waiting_for_start_at")
+	}
+	return false
+}
+
+// measureClockOffset queries the NTP server and reports the local clock's
+// skew. It returns 0 (trust the local clock) if no server is configured or
+// the query fails.
+func (o *Orchestrator) measureClockOffset(ctx context.Context) time.Duration {
+	server := o.config.NTPServer
+	if server == "" {
+		return 0
+	}
+
+	res, err := timesync.Query(ctx, server, ntpQueryTimeout)
+	if err != nil {
+		o.logger.Warn("ntp_query_failed", "server", server, "error", err)
+		fmt.Fprintf(o.out, "⚠ Could not reach NTP server %s (%v); using local clock\n", server, err)
+		return 0
+	}
+
+	if o.metrics != nil {
+		o.metrics.SetClockOffset(res.Offset)
+	}
+	o.logger.Info("clock_offset",
+		"server", res.Server,
+		"offset", res.Offset.String(),
+		"rtt", res.RTT.String(),
+		"stratum", res.Stratum,
+	)
+	fmt.Fprintf(o.out, "Clock offset:           %+dms vs %s (rtt %dms, stratum %d)\n",
+		res.Offset.Milliseconds(), res.Server, res.RTT.Milliseconds(), res.Stratum)
+	if res.RTT > clockUncertaintyWarn {
+		o.logger.Warn("clock_offset_imprecise", "rtt", res.RTT.String())
+		fmt.Fprintf(o.out, "  ⚠ High NTP round trip: hosts may start up to ±%dms apart\n", (res.RTT / 2).Milliseconds())
+	}
+	return res.Offset
+}
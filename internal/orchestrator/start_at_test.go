@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+)
+
+func newStartAtOrchestrator(startAt time.Time) *Orchestrator {
+	cfg := config.DefaultConfig()
+	cfg.StartAt = startAt
+	cfg.NTPServer = "" // No network in tests
+	return &Orchestrator{config: cfg, logger: slog.New(slog.NewTextHandler(io.Discard, nil)), out: io.Discard}
+}
+
+func TestStartDelay(t *testing.T) {
+	startAt := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := startAt.Add(-10 * time.Second)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   time.Duration
+	}{
+		{"clock in sync", 0, 10 * time.Second},
+		{"local clock behind", 2 * time.Second, 8 * time.Second},
+		{"local clock ahead", -2 * time.Second, 12 * time.Second},
+		{"already passed", 15 * time.Second, -5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := startDelay(startAt, now, tt.offset); got != tt.want {
+				t.Errorf("startDelay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitForStartAt_WaitsUntilStart(t *testing.T) {
+	startAt := time.Now().Add(50 * time.Millisecond)
+	o := newStartAtOrchestrator(startAt)
+
+	if !o.waitForStartAt(context.Background(), make(chan os.Signal)) {
+		t.Fatal("waitForStartAt = false, want true")
+	}
+	if now := time.Now(); now.Before(startAt) {
+		t.Errorf("returned %v early", startAt.Sub(now))
+	}
+}
+
+func TestWaitForStartAt_PastStartsImmediately(t *testing.T) {
+	o := newStartAtOrchestrator(time.Now().Add(-time.Minute))
+
+	begin := time.Now()
+	if !o.waitForStartAt(context.Background(), make(chan os.Signal)) {
+		t.Fatal("waitForStartAt = false, want true")
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("waited %v for a start time in the past", elapsed)
+	}
+}
+
+func TestWaitForStartAt_Interrupted(t *testing.T) {
+	o := newStartAtOrchestrator(time.Now().Add(time.Hour))
+
+	sigCh := make(chan os.Signal, 1)
+	sigCh <- syscall.SIGINT
+	if o.waitForStartAt(context.Background(), sigCh) {
+		t.Error("signal: waitForStartAt = true, want false")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if o.waitForStartAt(ctx, make(chan os.Signal)) {
+		t.Error("cancelled: waitForStartAt = true, want false")
+	}
+}
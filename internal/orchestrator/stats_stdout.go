@@ -0,0 +1,59 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// SetStatsOutput sets where -stats-stdout writes snapshots (default
+// os.Stdout). main points SetOutput at stderr alongside, so the stream stays
+// machine-readable while the banner and summary remain visible.
+func (o *Orchestrator) SetStatsOutput(w io.Writer) {
+	o.statsOut = w
+}
+
+// runStatsStdout writes an aggregate snapshot every -stats-interval until
+// ctx is cancelled, then a final one marked "final": true.
+func (o *Orchestrator) runStatsStdout(ctx context.Context) {
+	ticker := time.NewTicker(o.config.StatsInterval)
+	defer ticker.Stop()
+
+	rates := &rateSampler{start: o.startTime}
+	for {
+		select {
+		case <-ctx.Done():
+			o.writeStatsSnapshot(rates, true)
+			return
+		case <-ticker.C:
+			o.writeStatsSnapshot(rates, false)
+		}
+	}
+}
+
+// writeStatsSnapshot writes one snapshot, its rates since the previous
+// one taken through rates.
+func (o *Orchestrator) writeStatsSnapshot(rates *rateSampler, final bool) {
+	var agg *stats.AggregatedStats
+	var ds *stats.DebugStatsAggregate
+	if o.config.StatsEnabled {
+		agg = o.GetAggregatedStats()
+		d := o.GetDebugStats()
+		ds = &d
+	}
+
+	// Timestamps on the NTP-corrected clock, so the streams of a multi-host
+	// run merge in order
+	now := time.Now()
+	snap := stats.NewSnapshot(now.Add(o.clockOffset), now.Sub(o.startTime), o.config.Clients, agg, ds)
+	snap.SetRates(rates.sample(agg, ds))
+	snap.Final = final
+	if o.slo != nil {
+		snap.SLO = o.slo.status().Snapshot()
+	}
+	if err := stats.WriteNDJSON(o.statsOut, snap); err != nil {
+		o.logger.Warn("stats_stdout_write_failed", "error", err)
+	}
+}
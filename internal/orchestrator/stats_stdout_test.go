@@ -0,0 +1,52 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestRunStatsStdout(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Clients = 7
+	cfg.StatsEnabled = false // No client manager in this test
+	cfg.StatsStdout = "ndjson"
+	cfg.StatsInterval = 10 * time.Millisecond
+
+	var buf bytes.Buffer
+	o := &Orchestrator{
+		config:    cfg,
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		startTime: time.Now(),
+	}
+	o.SetStatsOutput(&buf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	o.runStatsStdout(ctx)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) < 3 {
+		t.Fatalf("got %d snapshots, want >= 3:\n%s", len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var s stats.Snapshot
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		if s.TargetClients != 7 {
+			t.Errorf("line %d: target_clients = %d, want 7", i, s.TargetClients)
+		}
+		if last := i == len(lines)-1; s.Final != last {
+			t.Errorf("line %d: final = %v, want %v", i, s.Final, last)
+		}
+	}
+}
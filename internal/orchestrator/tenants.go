@@ -0,0 +1,266 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// tenancy runs -tenants: it assigns clients to tenants, keeps each tenant
+// under its request-rate quota, and totals each tenant's traffic.
+//
+// FFmpeg can't be throttled per request, so the quota is enforced by
+// client count: a tenant over quota has its newest client stopped (one per
+// update) and further ramp-up held back; held clients are started again
+// once the measured rate leaves room for one more. A tenant always keeps
+// at least one client running.
+type tenancy struct {
+	cm      *ClientManager
+	logger  *slog.Logger
+	onStart func() // Called when a held client starts (nil = none)
+
+	mu       sync.Mutex
+	tenants  []*tenant
+	byClient []*tenant // Indexed by client ID
+	lastTick time.Time
+}
+
+// tenant is one tenant's assignment and quota state, guarded by tenancy.mu.
+type tenant struct {
+	config.Tenant
+	clientIDs []int                      // Every assigned client, in ramp order
+	running   []int                      // Started clients, oldest first
+	cancels   map[int]context.CancelFunc // Per running client
+	held      []int                      // Held back by the quota, next to start first
+	stopped   map[int]bool               // Stopped by the quota: their exit is expected
+
+	requests, bytes, errors int64
+	rate, peakRate          float64
+	throttles               int64
+}
+
+// newTenancy assigns cfg.Clients clients to tenants. Returns nil without
+// -tenants.
+func newTenancy(tenants []config.Tenant, cm *ClientManager, logger *slog.Logger) *tenancy {
+	if len(tenants) == 0 {
+		return nil
+	}
+
+	tn := &tenancy{cm: cm, logger: logger, lastTick: time.Now()}
+	for _, cfg := range tenants {
+		tn.tenants = append(tn.tenants, &tenant{
+			Tenant:  cfg,
+			cancels: make(map[int]context.CancelFunc),
+			stopped: make(map[int]bool),
+		})
+	}
+	for clientID, idx := range assignTenants(tenants) {
+		t := tn.tenants[idx]
+		t.clientIDs = append(t.clientIDs, clientID)
+		tn.byClient = append(tn.byClient, t)
+	}
+	return tn
+}
+
+// assignTenants returns each client's tenant index. Tenants are interleaved
+// in proportion to their size, so they all ramp up together.
+func assignTenants(tenants []config.Tenant) []int {
+	sizes := make([]int, len(tenants))
+	for i, t := range tenants {
+		sizes[i] = t.Clients
+	}
+	return interleave(sizes)
+}
+
+// interleave returns sum(sizes) group indexes with each group i appearing
+// sizes[i] times, spread evenly: every prefix holds each group in close to
+// its overall proportion.
+func interleave(sizes []int) []int {
+	total := 0
+	for _, n := range sizes {
+		total += n
+	}
+
+	assigned := make([]int, len(sizes))
+	order := make([]int, 0, total)
+	for range total {
+		// Smallest share of its own size after one more
+		best := -1
+		for i, n := range sizes {
+			if assigned[i] >= n {
+				continue
+			}
+			if best < 0 || float64(assigned[i]+1)/float64(n) < float64(assigned[best]+1)/float64(sizes[best]) {
+				best = i
+			}
+		}
+		assigned[best]++
+		order = append(order, best)
+	}
+	return order
+}
+
+// startClient starts a client during ramp-up, unless its tenant is at its
+// quota, in which case the client is held until there is room. Reports
+// whether the client started.
+func (tn *tenancy) startClient(ctx context.Context, clientID int) bool {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+
+	t := tn.byClient[clientID]
+	if t.MaxRPS > 0 && len(t.running) > 0 && t.rate >= t.MaxRPS {
+		t.held = append(t.held, clientID)
+		t.throttles++
+		tn.logger.Debug("tenant_quota_hold", "tenant", t.Name, "client_id", clientID, "rate", t.rate, "quota", t.MaxRPS)
+		return false
+	}
+	tn.start(ctx, t, clientID)
+	return true
+}
+
+// start runs a client under its own context so the quota can stop it.
+// Called with tn.mu held.
+func (tn *tenancy) start(ctx context.Context, t *tenant, clientID int) {
+	clientCtx, cancel := context.WithCancel(ctx)
+	t.cancels[clientID] = cancel
+	t.running = append(t.running, clientID)
+	delete(t.stopped, clientID)
+	tn.cm.StartClient(clientCtx, clientID)
+}
+
+// run measures tenant request rates and applies the quotas every interval
+// until ctx is cancelled.
+func (tn *tenancy) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tn.update(ctx)
+		}
+	}
+}
+
+// update refreshes each tenant's totals and rate, then moves each tenant
+// over (or comfortably under) its quota by one client.
+func (tn *tenancy) update(ctx context.Context) {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(tn.lastTick).Seconds()
+	tn.lastTick = now
+
+	for _, t := range tn.tenants {
+		prev := t.requests
+		tn.refreshTotals(t)
+		if elapsed > 0 {
+			t.rate = float64(t.requests-prev) / elapsed
+			t.peakRate = max(t.peakRate, t.rate)
+		}
+
+		if t.MaxRPS <= 0 || ctx.Err() != nil {
+			continue
+		}
+		switch {
+		case t.rate > t.MaxRPS && len(t.running) > 1:
+			// Stop the newest client; it is first in line to come back
+			clientID := t.running[len(t.running)-1]
+			t.running = t.running[:len(t.running)-1]
+			t.stopped[clientID] = true
+			t.cancels[clientID]()
+			delete(t.cancels, clientID)
+			t.held = append([]int{clientID}, t.held...)
+			t.throttles++
+			tn.logger.Info("tenant_quota_exceeded", "tenant", t.Name, "rate", t.rate, "quota", t.MaxRPS,
+				"stopped_client", clientID, "running", len(t.running))
+
+		case len(t.held) > 0 && (len(t.running) == 0 || t.rate+t.rate/float64(len(t.running)) <= t.MaxRPS):
+			clientID := t.held[0]
+			t.held = t.held[1:]
+			tn.start(ctx, t, clientID)
+			if tn.onStart != nil {
+				tn.onStart()
+			}
+		}
+	}
+}
+
+// refreshTotals sums the tenant's client stats. Called with tn.mu held.
+func (tn *tenancy) refreshTotals(t *tenant) {
+	t.requests, t.bytes, t.errors = 0, 0, 0
+	for _, clientID := range t.clientIDs {
+		cs := tn.cm.GetClientStats(clientID)
+		if cs == nil {
+			continue // Not started yet
+		}
+		t.requests += cs.ManifestRequests.Load() + cs.SegmentRequests.Load()
+		t.bytes += cs.TotalBytes()
+		for _, n := range cs.GetHTTPErrors() {
+			t.errors += n
+		}
+		for _, n := range cs.GetNetworkErrors() {
+			t.errors += n
+		}
+	}
+}
+
+// expectedExit reports whether a client exited because the quota stopped it.
+func (tn *tenancy) expectedExit(clientID int) bool {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+	return clientID < len(tn.byClient) && tn.byClient[clientID].stopped[clientID]
+}
+
+// metricsUpdates returns each tenant's state for the Prometheus collector.
+func (tn *tenancy) metricsUpdates() []metrics.TenantUpdate {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+
+	updates := make([]metrics.TenantUpdate, len(tn.tenants))
+	for i, t := range tn.tenants {
+		updates[i] = metrics.TenantUpdate{
+			Tenant:      t.Name,
+			Clients:     len(t.running),
+			HeldClients: len(t.held),
+			QuotaRPS:    t.MaxRPS,
+			RequestRate: t.rate,
+			Requests:    t.requests,
+			Bytes:       t.bytes,
+			Errors:      t.errors,
+			Throttles:   t.throttles,
+		}
+	}
+	return updates
+}
+
+// summaries returns the per-tenant exit summary rows, with final totals.
+func (tn *tenancy) summaries() []stats.TenantSummary {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+
+	rows := make([]stats.TenantSummary, len(tn.tenants))
+	for i, t := range tn.tenants {
+		tn.refreshTotals(t)
+		rows[i] = stats.TenantSummary{
+			Name:      t.Name,
+			Clients:   t.Clients,
+			Held:      len(t.held),
+			MaxRPS:    t.MaxRPS,
+			PeakRPS:   t.peakRate,
+			Requests:  t.requests,
+			Bytes:     t.bytes,
+			Errors:    t.errors,
+			Throttles: t.throttles,
+		}
+	}
+	return rows
+}
@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestAssignTenants(t *testing.T) {
+	order := assignTenants([]config.Tenant{{Name: "a", Clients: 4}, {Name: "b", Clients: 2}})
+
+	want := []int{0, 0, 1, 0, 0, 1}
+	if len(order) != len(want) {
+		t.Fatalf("assignTenants() = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("assignTenants() = %v, want %v (interleaved by size)", order, want)
+		}
+	}
+
+	if got := assignTenants([]config.Tenant{{Name: "solo", Clients: 3}}); len(got) != 3 || got[2] != 0 {
+		t.Errorf("single tenant = %v, want [0 0 0]", got)
+	}
+}
+
+func TestTenancy_Quota(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cm := NewClientManager(ManagerConfig{
+		Builder: &mockProcessBuilder{},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	defer func() {
+		cancel()
+		if err := cm.Shutdown(context.Background()); err != nil {
+			t.Errorf("Shutdown() = %v", err)
+		}
+	}()
+
+	tn := newTenancy([]config.Tenant{
+		{Name: "capped", Clients: 4, MaxRPS: 10},
+		{Name: "open", Clients: 1},
+	}, cm, cm.logger)
+	capped := tn.tenants[0]
+	starts := 0
+	tn.onStart = func() { starts++ }
+
+	// Clients 0-2 of "capped" already running (ramp-up without processes)
+	cancelled := make(map[int]bool)
+	for _, id := range capped.clientIDs[:3] {
+		id := id
+		cs := stats.NewClientStats(id)
+		cm.clientStats[id] = cs
+		capped.running = append(capped.running, id)
+		capped.cancels[id] = func() { cancelled[id] = true }
+	}
+	addRequests := func(n int64) {
+		cm.clientStats[capped.clientIDs[0]].SegmentRequests.Add(n)
+	}
+	tick := func() {
+		tn.lastTick = time.Now().Add(-time.Second)
+		tn.update(ctx)
+	}
+
+	// Over quota: the newest client is stopped, and its exit is expected
+	addRequests(30)
+	tick()
+	newest := capped.clientIDs[2]
+	if !cancelled[newest] || len(capped.running) != 2 || !tn.expectedExit(newest) {
+		t.Fatalf("over quota: running %v, cancelled %v; want client %d stopped", capped.running, cancelled, newest)
+	}
+
+	// Ramp-up holds the last client back while the tenant is at its quota
+	last := capped.clientIDs[3]
+	if tn.startClient(ctx, last) {
+		t.Error("startClient() = true for a held client")
+	}
+	if len(capped.held) != 2 || capped.held[1] != last {
+		t.Fatalf("held = %v, want [%d %d]", capped.held, newest, last)
+	}
+	if starts != 0 {
+		t.Errorf("starts = %d while held, want 0", starts)
+	}
+
+	// Room for one more: the stopped client comes back first
+	addRequests(3)
+	tick()
+	if len(capped.running) != 3 || capped.running[2] != newest || tn.expectedExit(newest) {
+		t.Errorf("under quota: running %v, want client %d restarted", capped.running, newest)
+	}
+	if starts != 1 {
+		t.Errorf("starts = %d after the release, want 1", starts)
+	}
+
+	updates := tn.metricsUpdates()
+	if u := updates[0]; u.Clients != 3 || u.HeldClients != 1 || u.Throttles != 2 || u.Requests != 33 {
+		t.Errorf("capped update = %+v, want 3 running, 1 held, 2 throttles, 33 requests", u)
+	}
+	if u := updates[1]; u.Tenant != "open" || u.Throttles != 0 || u.QuotaRPS != 0 {
+		t.Errorf("open update = %+v, want no quota activity", u)
+	}
+
+	rows := tn.summaries()
+	if rows[0].PeakRPS < 25 || rows[0].Held != 1 {
+		t.Errorf("capped summary = %+v, want peak ~30 req/s and 1 held", rows[0])
+	}
+}
+
+func TestNewTenancy_Disabled(t *testing.T) {
+	if tn := newTenancy(nil, nil, nil); tn != nil {
+		t.Error("newTenancy(nil) != nil, want disabled without -tenants")
+	}
+}
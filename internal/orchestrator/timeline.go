@@ -0,0 +1,43 @@
+package orchestrator
+
+import (
+	"context"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+)
+
+// runTimeline samples the swarm metrics into o.timeline every
+// -stats-interval until ctx is cancelled, then once more, for the
+// -save-run record.
+func (o *Orchestrator) runTimeline(ctx context.Context) {
+	ticker := time.NewTicker(o.config.StatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			o.sampleTimeline()
+			return
+		case <-ticker.C:
+			if o.timeline.Due() {
+				o.sampleTimeline()
+			}
+		}
+	}
+}
+
+// sampleTimeline adds the metrics as they are now to o.timeline, and the
+// segment wall times since the last sample to o.heatmap.
+func (o *Orchestrator) sampleTimeline() {
+	elapsed := time.Since(o.startTime)
+	values, err := metrics.Sample(o.gatherer)
+	if err != nil {
+		o.logger.Warn("timeline_sample_failed", "error", err)
+		values = nil // An empty sample, keeping the heatmap's T the timeline's
+	}
+	o.timeline.Add(elapsed, values)
+	if o.heatmap != nil {
+		o.heatmap.Add(elapsed, o.clientManager.LatencyHeatmap().Rotate())
+	}
+}
@@ -0,0 +1,177 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// variantMix runs -variant-mix: it spreads the clients over the master
+// playlist's variants like a device mix, points each client at its
+// variant's media playlist, and breaks the request load down by variant.
+type variantMix struct {
+	cm        *ClientManager
+	shares    []config.VariantShare
+	variants  []manifest.Variant // Matched variant, per share
+	byClient  []int              // Share index, indexed by client ID
+	clientIDs [][]int            // Per share
+}
+
+// newVariantMix matches each share to one of the master playlist's
+// variants and assigns clients clients to them in proportion to their
+// weights, interleaved so every variant ramps up together.
+func newVariantMix(shares []config.VariantShare, variants []manifest.Variant, clients int, cm *ClientManager) (*variantMix, error) {
+	m := &variantMix{cm: cm, shares: shares, clientIDs: make([][]int, len(shares))}
+	for _, s := range shares {
+		v, ok := matchVariant(s.Variant, variants)
+		if !ok {
+			return nil, fmt.Errorf("variant mix: no %s variant in the master playlist (have %s)", s.Variant, variantResolutions(variants))
+		}
+		m.variants = append(m.variants, v)
+	}
+	for clientID, idx := range assignVariants(shares, clients) {
+		m.byClient = append(m.byClient, idx)
+		m.clientIDs[idx] = append(m.clientIDs[idx], clientID)
+	}
+	return m, nil
+}
+
+// assignVariants returns each client's share index, see assignByWeight.
+func assignVariants(shares []config.VariantShare, clients int) []int {
+	weights := make([]int, len(shares))
+	for i, s := range shares {
+		weights[i] = s.Weight
+	}
+	return assignByWeight(weights, clients)
+}
+
+// matchVariant returns the variant with the share's resolution ("720p"
+// matches any width). Of several (e.g. one per codec), the highest
+// bandwidth wins.
+func matchVariant(share string, variants []manifest.Variant) (manifest.Variant, bool) {
+	width, height, err := config.ParseVariantResolution(share)
+	if err != nil {
+		return manifest.Variant{}, false
+	}
+	var best manifest.Variant
+	found := false
+	for _, v := range variants {
+		w, h, ok := parseResolution(v.Resolution)
+		if !ok || h != height || (width > 0 && w != width) {
+			continue
+		}
+		if !found || v.Bandwidth > best.Bandwidth {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
+
+// parseResolution parses a RESOLUTION attribute ("1280x720").
+func parseResolution(res string) (width, height int, ok bool) {
+	w, h, found := strings.Cut(res, "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	return width, height, errW == nil && errH == nil
+}
+
+// variantResolutions lists the variants' resolutions for error messages.
+func variantResolutions(variants []manifest.Variant) string {
+	var res []string
+	for _, v := range variants {
+		if v.Resolution != "" {
+			res = append(res, v.Resolution)
+		}
+	}
+	if len(res) == 0 {
+		return "no RESOLUTION attributes"
+	}
+	return strings.Join(res, ", ")
+}
+
+// url returns a client's media playlist URL (process.FFmpegConfig.ClientURL).
+func (m *variantMix) url(clientID int) string {
+	if clientID < 0 || clientID >= len(m.byClient) {
+		return ""
+	}
+	return m.variants[m.byClient[clientID]].URI
+}
+
+// metricsUpdates returns each variant's load for the Prometheus collector.
+func (m *variantMix) metricsUpdates() []metrics.VariantUpdate {
+	updates := make([]metrics.VariantUpdate, len(m.shares))
+	for i, s := range m.shares {
+		gs := m.cm.GetGroupStats(m.clientIDs[i])
+		updates[i] = metrics.VariantUpdate{
+			Variant:  s.Variant,
+			Clients:  gs.Clients,
+			Requests: gs.Requests,
+			Bytes:    gs.Bytes,
+			Errors:   gs.Errors(),
+		}
+	}
+	return updates
+}
+
+// summaries returns the per-variant exit summary rows.
+func (m *variantMix) summaries() []stats.VariantSummary {
+	total := 0
+	for _, s := range m.shares {
+		total += s.Weight
+	}
+	rows := make([]stats.VariantSummary, len(m.shares))
+	for i, s := range m.shares {
+		gs := m.cm.GetGroupStats(m.clientIDs[i])
+		rows[i] = stats.VariantSummary{
+			Name:       s.Variant,
+			Resolution: m.variants[i].Resolution,
+			Bandwidth:  m.variants[i].Bandwidth,
+			Target:     float64(s.Weight) * 100 / float64(total),
+			Clients:    gs.Clients,
+			Requests:   gs.Requests,
+			Bytes:      gs.Bytes,
+			Errors:     gs.Errors(),
+		}
+	}
+	return rows
+}
+
+// setupVariantMix fetches the master playlist and points every client at
+// its -variant-mix variant. Fails if the playlist isn't a master playlist
+// or lacks a variant of the mix.
+func (o *Orchestrator) setupVariantMix(ctx context.Context) error {
+	probeCtx, cancel := context.WithTimeout(ctx, o.config.Timeout)
+	defer cancel()
+
+	res, err := o.newProber(probeCtx).Probe(probeCtx, o.config.StreamURL)
+	if err != nil {
+		return fmt.Errorf("%w: variant mix: %w", ErrEnvironment, err)
+	}
+	if len(res.Variants) == 0 {
+		return fmt.Errorf("%w: variant mix: %s is not a master playlist", ErrConfig, o.config.StreamURL)
+	}
+
+	o.variants, err = newVariantMix(o.config.VariantMix, res.Variants, o.config.Clients, o.clientManager)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfig, err)
+	}
+	o.runner.Config().ClientURL = o.variants.url
+	for i, s := range o.variants.shares {
+		o.logger.Info("variant_mix",
+			"variant", s.Variant,
+			"resolution", o.variants.variants[i].Resolution,
+			"bandwidth", o.variants.variants[i].Bandwidth,
+			"clients", len(o.variants.clientIDs[i]),
+		)
+	}
+	return nil
+}
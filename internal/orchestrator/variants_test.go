@@ -0,0 +1,83 @@
+package orchestrator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+var testVariants = []manifest.Variant{
+	{URI: "http://origin/1080.m3u8", Bandwidth: 6_000_000, Resolution: "1920x1080"},
+	{URI: "http://origin/720-hevc.m3u8", Bandwidth: 2_000_000, Resolution: "1280x720"},
+	{URI: "http://origin/720.m3u8", Bandwidth: 3_000_000, Resolution: "1280x720"},
+	{URI: "http://origin/480.m3u8", Bandwidth: 1_000_000, Resolution: "854x480"},
+	{URI: "http://origin/audio.m3u8", Bandwidth: 128_000},
+}
+
+func TestMatchVariant(t *testing.T) {
+	tests := []struct {
+		share   string
+		wantURI string // "" = no match
+	}{
+		{"1080p", "http://origin/1080.m3u8"},
+		{"720p", "http://origin/720.m3u8"}, // Highest bandwidth of two
+		{"854x480", "http://origin/480.m3u8"},
+		{"640x480", ""},
+		{"360p", ""},
+		{"hd", ""},
+	}
+	for _, tt := range tests {
+		v, ok := matchVariant(tt.share, testVariants)
+		if ok != (tt.wantURI != "") || v.URI != tt.wantURI {
+			t.Errorf("matchVariant(%q) = %q, %v; want %q", tt.share, v.URI, ok, tt.wantURI)
+		}
+	}
+}
+
+func TestVariantMix(t *testing.T) {
+	cm := NewClientManager(ManagerConfig{Builder: &mockProcessBuilder{}, StatsEnabled: true})
+	m, err := newVariantMix([]config.VariantShare{
+		{Variant: "1080p", Weight: 3},
+		{Variant: "480p", Weight: 1},
+	}, testVariants, 8, cm)
+	if err != nil {
+		t.Fatalf("newVariantMix() = %v", err)
+	}
+
+	if len(m.clientIDs[0]) != 6 || len(m.clientIDs[1]) != 2 {
+		t.Errorf("clients per variant = %d, %d; want 6, 2", len(m.clientIDs[0]), len(m.clientIDs[1]))
+	}
+	if u := m.url(m.clientIDs[1][0]); u != "http://origin/480.m3u8" {
+		t.Errorf("url() of a 480p client = %q", u)
+	}
+	if u := m.url(8); u != "" {
+		t.Errorf("url(8) = %q, want \"\" for an unknown client", u)
+	}
+
+	for _, id := range m.clientIDs[0][:2] {
+		cm.clientStats[id] = stats.NewClientStats(id)
+		cm.clientStats[id].SegmentRequests.Add(10)
+	}
+	cm.clientStats[m.clientIDs[0][0]].RecordHTTPError(404)
+
+	rows := m.summaries()
+	if r := rows[0]; r.Name != "1080p" || r.Resolution != "1920x1080" || r.Target != 75 || r.Clients != 2 || r.Requests != 20 || r.Errors != 1 {
+		t.Errorf("1080p = %+v", r)
+	}
+	if r := rows[1]; r.Target != 25 || r.Clients != 0 {
+		t.Errorf("480p = %+v, want a 25%% target and no started clients", r)
+	}
+	if u := m.metricsUpdates()[0]; u.Variant != "1080p" || u.Requests != 20 || u.Errors != 1 {
+		t.Errorf("1080p update = %+v", u)
+	}
+}
+
+func TestVariantMix_Missing(t *testing.T) {
+	_, err := newVariantMix([]config.VariantShare{{Variant: "2160p", Weight: 1}}, testVariants, 4, nil)
+	if err == nil || !strings.Contains(err.Error(), "no 2160p variant") || !strings.Contains(err.Error(), "1920x1080, 1280x720") {
+		t.Errorf("newVariantMix() = %v, want an error listing the resolutions", err)
+	}
+}
@@ -0,0 +1,137 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+// vodPlays runs -vod-end: it counts the clients' plays of a VOD asset and
+// how long those that reached its end took. A play reaching the end is
+// FFmpeg exiting cleanly without being stopped; the supervisors then play
+// the asset again (loop) or stop the client (stop). In stop mode the run
+// ends once every client wanted is done.
+type vodPlays struct {
+	mode    string
+	target  func() int // Clients wanted now
+	metrics *metrics.Collector
+	logger  *slog.Logger
+	onDone  func() // Called once when every client is done (stop mode)
+
+	mu          sync.Mutex
+	asset       time.Duration // Set once the asset is probed
+	plays       int64
+	finishes    int64
+	cutShorts   int64
+	timeSum     time.Duration // Of the finished plays
+	minTime     time.Duration
+	maxTime     time.Duration
+	clients     map[int]struct{} // Finished at least once
+	allDoneSeen bool
+}
+
+// newVODPlays returns the -vod-end tracker. Returns nil without -vod-end.
+func newVODPlays(cfg *config.Config, target func() int, m *metrics.Collector, logger *slog.Logger) *vodPlays {
+	if cfg.VODEnd == "" {
+		return nil
+	}
+	return &vodPlays{
+		mode:    cfg.VODEnd,
+		target:  target,
+		metrics: m,
+		logger:  logger,
+		clients: make(map[int]struct{}),
+	}
+}
+
+// atEnd returns what the supervisors do at the end of the asset.
+func (v *vodPlays) atEnd() supervisor.EndPolicy {
+	if v.mode == config.VODEndStop {
+		return supervisor.EndStop
+	}
+	return supervisor.EndReplay
+}
+
+// started counts a play started with a client process.
+func (v *vodPlays) started(clientID int) {
+	v.mu.Lock()
+	v.plays++
+	v.mu.Unlock()
+	v.metrics.RecordVODPlay()
+}
+
+// finished counts a play that reached the end of the asset after played.
+func (v *vodPlays) finished(clientID int, played time.Duration) {
+	v.mu.Lock()
+	if v.finishes == 0 || played < v.minTime {
+		v.minTime = played
+	}
+	v.maxTime = max(v.maxTime, played)
+	v.finishes++
+	v.timeSum += played
+	v.clients[clientID] = struct{}{}
+	done := len(v.clients)
+	allDone := v.mode == config.VODEndStop && !v.allDoneSeen && done >= v.target()
+	if allDone {
+		v.allDoneSeen = true
+	}
+	v.mu.Unlock()
+
+	v.metrics.RecordVODFinish(played)
+	if v.mode != config.VODEndStop {
+		return
+	}
+	v.metrics.SetVODClientsDone(done)
+	if allDone {
+		v.logger.Info("vod_all_done", "clients", done)
+		if v.onDone != nil {
+			v.onDone()
+		}
+	}
+}
+
+// cutShort counts a play stopped by the end of the run.
+func (v *vodPlays) cutShort() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cutShorts++
+}
+
+// summary returns the -vod-end exit summary section.
+func (v *vodPlays) summary() *stats.VODCompletionSummary {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s := &stats.VODCompletionSummary{
+		Mode:     v.mode,
+		Asset:    v.asset,
+		Plays:    v.plays,
+		Finished: v.finishes,
+		CutShort: v.cutShorts,
+		Clients:  len(v.clients),
+		MinTime:  v.minTime,
+		MaxTime:  v.maxTime,
+	}
+	if v.finishes > 0 {
+		s.MeanTime = v.timeSum / time.Duration(v.finishes)
+	}
+	return s
+}
+
+// setupVODEnd checks that the stream is a VOD asset for -vod-end.
+func (o *Orchestrator) setupVODEnd(ctx context.Context) error {
+	asset, err := o.probeVODAsset(ctx, "-vod-end")
+	if err != nil {
+		return err
+	}
+	o.vodPlays.mu.Lock()
+	o.vodPlays.asset = asset.TotalDuration
+	o.vodPlays.mu.Unlock()
+	o.logger.Info("vod_end", "mode", o.config.VODEnd, "asset", asset.TotalDuration.String())
+	return nil
+}
@@ -0,0 +1,112 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/supervisor"
+)
+
+func newTestVODPlays(mode string, target int) *vodPlays {
+	m := metrics.NewCollectorWithRegistry(metrics.CollectorConfig{TargetClients: target}, prometheus.NewRegistry())
+	return newVODPlays(&config.Config{VODEnd: mode}, func() int { return target }, m, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestNewVODPlays(t *testing.T) {
+	if v := newVODPlays(&config.Config{}, nil, nil, nil); v != nil {
+		t.Errorf("newVODPlays() without -vod-end = %+v, want nil", v)
+	}
+	if p := newTestVODPlays(config.VODEndStop, 1).atEnd(); p != supervisor.EndStop {
+		t.Errorf("atEnd() for stop = %v, want EndStop", p)
+	}
+	if p := newTestVODPlays(config.VODEndLoop, 1).atEnd(); p != supervisor.EndReplay {
+		t.Errorf("atEnd() for loop = %v, want EndReplay", p)
+	}
+}
+
+func TestVODPlays_Stop(t *testing.T) {
+	v := newTestVODPlays(config.VODEndStop, 2)
+	var done int
+	v.onDone = func() { done++ }
+
+	for _, id := range []int{0, 1, 1} {
+		v.started(id)
+	}
+	v.finished(0, time.Minute)
+	if done != 0 {
+		t.Fatal("run ended with a client still playing")
+	}
+	v.finished(1, 3*time.Minute)
+	v.finished(1, 2*time.Minute)
+	if done != 1 {
+		t.Errorf("onDone called %d times, want once every client is done", done)
+	}
+
+	s := v.summary()
+	if s.Plays != 3 || s.Finished != 3 || s.Clients != 2 || s.CompletionRatio() != 1 {
+		t.Errorf("summary = %+v, want 3 plays all finished by 2 clients", s)
+	}
+	if s.MinTime != time.Minute || s.MaxTime != 3*time.Minute || s.MeanTime != 2*time.Minute {
+		t.Errorf("completion times = %s/%s/%s, want 1m/2m/3m", s.MinTime, s.MeanTime, s.MaxTime)
+	}
+}
+
+func TestVODPlays_Loop(t *testing.T) {
+	v := newTestVODPlays(config.VODEndLoop, 1)
+	v.onDone = func() { t.Error("loop mode ended the run") }
+
+	for range 4 {
+		v.started(0)
+	}
+	v.finished(0, time.Minute)
+	v.finished(0, time.Minute)
+	v.cutShort()
+
+	// One play ended early: two of three ended plays finished
+	s := v.summary()
+	if s.Plays != 4 || s.Finished != 2 || s.CutShort != 1 {
+		t.Fatalf("summary = %+v", s)
+	}
+	if r := s.CompletionRatio(); r < 0.66 || r > 0.67 {
+		t.Errorf("CompletionRatio() = %.3f, want 2/3", r)
+	}
+}
+
+func TestSetupVODEnd(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nseg1.ts\n#EXTINF:10,\nseg2.ts\n"
+		if r.URL.Path == "/vod.m3u8" {
+			body += "#EXT-X-ENDLIST\n"
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	o := newScaleOrchestrator(1)
+	o.config.StreamURL = srv.URL + "/vod.m3u8"
+	o.config.VODEnd = config.VODEndLoop
+	o.vodPlays = newTestVODPlays(config.VODEndLoop, 1)
+	if err := o.setupVODEnd(context.Background()); err != nil {
+		t.Fatalf("setupVODEnd() = %v", err)
+	}
+	if s := o.vodPlays.summary(); s.Asset != 20*time.Second {
+		t.Errorf("asset = %s, want 20s", s.Asset)
+	}
+
+	o = newScaleOrchestrator(1)
+	o.config.StreamURL = srv.URL + "/live.m3u8"
+	o.vodPlays = newTestVODPlays(config.VODEndStop, 1)
+	if err := o.setupVODEnd(context.Background()); !errors.Is(err, ErrConfig) {
+		t.Errorf("setupVODEnd() on a live stream = %v, want a config error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/metrics"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// vodStarts draws where in a VOD asset each client starts playing
+// (-vod-start), at every process start, and counts the starts per tenth
+// of the asset.
+type vodStarts struct {
+	spec     string
+	dist     config.VODStartDist
+	duration time.Duration // Of the asset
+	latest   time.Duration // Start of its last segment: later starts are cut to it
+	metrics  *metrics.Collector
+
+	mu     sync.Mutex
+	rng    *rand.Rand
+	starts int64
+	tenths [10]int64
+}
+
+// newVODStarts returns the start position draws for an asset playlist.
+func newVODStarts(spec string, dist config.VODStartDist, asset *manifest.Playlist, m *metrics.Collector) *vodStarts {
+	latest := asset.TotalDuration
+	if n := len(asset.Segments); n > 0 {
+		latest -= asset.Segments[n-1].Duration
+	}
+	if dist.Kind == config.VODStartUniform && dist.Max == 0 {
+		dist.Max = asset.TotalDuration
+	}
+	return &vodStarts{
+		spec:     spec,
+		dist:     dist,
+		duration: asset.TotalDuration,
+		latest:   max(latest, 0),
+		metrics:  m,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// offset draws a client's start position.
+func (v *vodStarts) offset(clientID int) time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var d time.Duration
+	switch v.dist.Kind {
+	case config.VODStartUniform:
+		d = v.dist.Min + time.Duration(v.rng.Int63n(int64(v.dist.Max-v.dist.Min)+1))
+	case config.VODStartExponential:
+		d = time.Duration(v.rng.ExpFloat64() * float64(v.dist.Mean))
+	default:
+		d = v.dist.Min
+	}
+	if d > v.latest {
+		d = v.latest
+	}
+
+	v.starts++
+	if v.duration > 0 {
+		ratio := float64(d) / float64(v.duration)
+		v.tenths[min(int(ratio*10), 9)]++
+		v.metrics.RecordVODStart(ratio)
+	}
+	return d
+}
+
+// summary returns the -vod-start exit summary section.
+func (v *vodStarts) summary() *stats.VODStartSummary {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return &stats.VODStartSummary{
+		Distribution: v.spec,
+		Asset:        v.duration,
+		Starts:       v.starts,
+		Tenths:       v.tenths,
+	}
+}
+
+// setupVODStart checks that the stream is a VOD asset and spreads the
+// clients' start positions over it for -vod-start.
+func (o *Orchestrator) setupVODStart(ctx context.Context) error {
+	dist, _ := config.ParseVODStart(o.config.VODStart) // Validated
+	if dist.Kind == config.VODStartFixed && dist.Min == 0 {
+		return nil // Everyone from the start, FFmpeg's default
+	}
+
+	asset, err := o.probeVODAsset(ctx, "-vod-start")
+	if err != nil {
+		return err
+	}
+
+	o.vodStarts = newVODStarts(o.config.VODStart, dist, asset, o.metrics)
+	if dist.Min > o.vodStarts.latest {
+		o.logger.Warn("vod_start_beyond_asset",
+			"vod_start", o.config.VODStart,
+			"asset", o.vodStarts.duration.String(),
+			"clamped_to", o.vodStarts.latest.String(),
+		)
+	}
+	o.runner.Config().ClientStartOffset = o.vodStarts.offset
+	o.logger.Info("vod_start", "distribution", o.config.VODStart, "asset", o.vodStarts.duration.String())
+	return nil
+}
+
+// probeVODAsset probes the stream's media playlist for flag, checking
+// that it is a VOD asset. The first probe's playlist is kept for the
+// other VOD flags.
+func (o *Orchestrator) probeVODAsset(ctx context.Context, flag string) (*manifest.Playlist, error) {
+	if o.vodAsset != nil {
+		return o.vodAsset, nil
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, o.config.Timeout)
+	defer cancel()
+	res, err := o.newProber(probeCtx).Probe(probeCtx, o.config.StreamURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrEnvironment, flag, err)
+	}
+	if res.Media == nil || !res.Media.Ended {
+		return nil, fmt.Errorf("%w: %s: %s is a live playlist (no #EXT-X-ENDLIST); %s needs a VOD asset", ErrConfig, flag, o.config.StreamURL, flag)
+	}
+	o.vodAsset = res.Media
+	return o.vodAsset, nil
+}
@@ -0,0 +1,106 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/manifest"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+// testVODAsset is a 100s asset of ten 10s segments.
+func testVODAsset() *manifest.Playlist {
+	p := &manifest.Playlist{Ended: true, TotalDuration: 100 * time.Second}
+	for i := 0; i < 10; i++ {
+		p.Segments = append(p.Segments, manifest.Segment{Duration: 10 * time.Second})
+	}
+	return p
+}
+
+func TestVODStarts_Offset(t *testing.T) {
+	o := newScaleOrchestrator(1)
+	draw := func(spec string, n int) *vodStarts {
+		t.Helper()
+		dist, err := config.ParseVODStart(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := newVODStarts(spec, dist, testVODAsset(), o.metrics)
+		for i := 0; i < n; i++ {
+			if d := v.offset(i); d < 0 || d > 90*time.Second {
+				t.Fatalf("%s: offset %s outside the asset's 0s-90s", spec, d)
+			}
+		}
+		return v
+	}
+
+	// Uniform covers the whole asset
+	s := draw("uniform", 2000).summary()
+	if s.Starts != 2000 || s.Asset != 100*time.Second {
+		t.Errorf("summary = %+v, want 2000 starts over a 100s asset", s)
+	}
+	for i, n := range s.Tenths[:9] {
+		if n < 120 || n > 320 {
+			t.Errorf("uniform: %d starts in tenth %d, want about 200", n, i)
+		}
+	}
+
+	// Exponential favours the start
+	s = draw("exponential:15s", 2000).summary()
+	if s.Tenths[0] < s.Tenths[3] || s.Tenths[0] < 600 {
+		t.Errorf("exponential tenths = %v, want most near the start", s.Tenths)
+	}
+
+	// Offsets past the end are cut to the last segment
+	v := draw("fixed:5m", 0)
+	if d := v.offset(0); d != 90*time.Second {
+		t.Errorf("offset beyond the asset = %s, want 1m30s", d)
+	}
+	if v.summary().Tenths[9] != 1 {
+		t.Errorf("tenths = %v, want the start in the last tenth", v.summary().Tenths)
+	}
+}
+
+func TestSetupVODStart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10,\nseg1.ts\n#EXTINF:10,\nseg2.ts\n"
+		if r.URL.Path == "/vod.m3u8" {
+			body += "#EXT-X-ENDLIST\n"
+		}
+		io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	setup := func(url, spec string) (*Orchestrator, error) {
+		o := newScaleOrchestrator(1)
+		o.config.StreamURL = url
+		o.config.VODStart = spec
+		o.runner = process.NewFFmpegRunner(process.DefaultFFmpegConfig(url))
+		return o, o.setupVODStart(context.Background())
+	}
+
+	o, err := setup(srv.URL+"/vod.m3u8", "uniform")
+	if err != nil {
+		t.Fatalf("setupVODStart() = %v", err)
+	}
+	if o.vodStarts == nil || o.runner.Config().ClientStartOffset == nil || o.vodStarts.duration != 20*time.Second {
+		t.Fatalf("vod starts not set up for the 20s asset: %+v", o.vodStarts)
+	}
+
+	_, err = setup(srv.URL+"/live.m3u8", "uniform")
+	if !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "live playlist") {
+		t.Errorf("setupVODStart() on a live stream = %v, want a config error", err)
+	}
+
+	// Everyone from the start needs no playlist at all
+	if o, err := setup("http://127.0.0.1:1/live.m3u8", "start"); err != nil || o.vodStarts != nil {
+		t.Errorf("setupVODStart(start) = %v, %+v; want nothing to set up", err, o.vodStarts)
+	}
+}
@@ -0,0 +1,102 @@
+package originlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lokiPageSize is how many lines one Loki query_range call asks for.
+const lokiPageSize = 5000
+
+// lokiMaxPages bounds the pages read for one run (lokiPageSize lines each).
+const lokiMaxPages = 1000
+
+// lokiResponse is the part of a Loki query_range response that is read.
+type lokiResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Values [][2]string `json:"values"` // [unix nanoseconds, line]
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// LokiQuery returns the LogQL query for the run's lines: selector (a
+// stream selector such as {job="nginx"}) filtered to lines with tag.
+func LokiQuery(selector, tag string) string {
+	return fmt.Sprintf("%s |= %s", selector, strconv.Quote(tag))
+}
+
+// ReadLoki adds the run's requests between start and end from a Loki
+// server at baseURL (e.g. http://loki:3100), paging forward through the
+// lines of selector that carry the tag.
+func ReadLoki(ctx context.Context, client *http.Client, baseURL, selector string, start, end time.Time, j *Join) error {
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/loki/api/v1/query_range"
+	from := start.UnixNano()
+	for page := 0; page < lokiMaxPages; page++ {
+		q := url.Values{
+			"query":     {LokiQuery(selector, j.Tag)},
+			"start":     {strconv.FormatInt(from, 10)},
+			"end":       {strconv.FormatInt(end.UnixNano(), 10)},
+			"limit":     {strconv.Itoa(lokiPageSize)},
+			"direction": {"forward"},
+		}
+		resp, err := lokiGet(ctx, client, endpoint+"?"+q.Encode())
+		if err != nil {
+			return err
+		}
+
+		lines, last := 0, from
+		for _, stream := range resp.Data.Result {
+			for _, v := range stream.Values {
+				lines++
+				if ts, err := strconv.ParseInt(v[0], 10, 64); err == nil && ts > last {
+					last = ts
+				}
+				j.AddLine(v[1])
+			}
+		}
+		if lines < lokiPageSize {
+			return nil
+		}
+		// Lines at the page's last timestamp may be split over two pages;
+		// skipping past it loses at most a few of them
+		from = last + 1
+	}
+	return fmt.Errorf("loki: more than %d lines, stopped reading", lokiPageSize*lokiMaxPages)
+}
+
+// lokiGet runs one query_range call.
+func lokiGet(ctx context.Context, client *http.Client, u string) (*lokiResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("loki request: %w", err)
+	}
+	httpResp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("loki query: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("loki query: HTTP %d", httpResp.StatusCode)
+	}
+	var resp lokiResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("loki response: %w", err)
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("loki query: status %q", resp.Status)
+	}
+	if resp.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("loki query: %q result, want streams (is the selector a log query?)", resp.Data.ResultType)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,88 @@
+package originlog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLokiQuery(t *testing.T) {
+	if got := LokiQuery(`{job="nginx"}`, testTag); got != `{job="nginx"} |= "run-1a2b3c4d"` {
+		t.Errorf("LokiQuery() = %s", got)
+	}
+}
+
+func TestReadLoki(t *testing.T) {
+	const lines = lokiPageSize + 10 // Two pages
+	line := func(i int) string {
+		return fmt.Sprintf(`{"status":"200","request_uri":"/seg_%d.ts","request_time":"0.010","http_user_agent":"go-ffmpeg-hls-swarm/1.0/%s/client-%d"}`, i, testTag, i%3)
+	}
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/loki/api/v1/query_range" {
+			http.NotFound(w, r)
+			return
+		}
+		if q := r.URL.Query().Get("query"); q != LokiQuery(`{job="nginx"}`, testTag) {
+			t.Errorf("query = %q", q)
+		}
+		from, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		// Line i at timestamp 1000+i
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"streams","result":[{"stream":{},"values":[`)
+		n := 0
+		for i := max(from-1000, 0); i < lines && n < limit; i++ {
+			if n > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `[%q,%q]`, strconv.Itoa(1000+i), line(i))
+			n++
+		}
+		fmt.Fprint(w, `]}]}}`)
+	}))
+	defer srv.Close()
+
+	j := NewJoin(testTag)
+	err := ReadLoki(context.Background(), srv.Client(), srv.URL+"/", `{job="nginx"}`, time.Unix(0, 1000), time.Unix(0, 1_000_000), j)
+	if err != nil {
+		t.Fatalf("ReadLoki() = %v", err)
+	}
+	if j.Requests != lines || j.Clients() != 3 {
+		t.Errorf("Requests = %d from %d clients, want %d from 3", j.Requests, j.Clients(), lines)
+	}
+	if calls != 2 {
+		t.Errorf("%d queries, want 2 pages", calls)
+	}
+}
+
+func TestReadLoki_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{"http error", http.StatusBadRequest, `parse error`},
+		{"not json", http.StatusOK, `<html>`},
+		{"failed", http.StatusOK, `{"status":"error"}`},
+		{"metric query", http.StatusOK, `{"status":"success","data":{"resultType":"matrix","result":[]}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			if err := ReadLoki(context.Background(), srv.Client(), srv.URL, `{job="nginx"}`, time.Now(), time.Now(), NewJoin(testTag)); err == nil {
+				t.Error("ReadLoki() = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+// Package originlog joins the origin's view of a run with the swarm's.
+//
+// Every request of a tagged run carries the run's unique tag in its
+// User-Agent ("go-ffmpeg-hls-swarm/1.0/run-1a2b3c4d/client-42"). After the
+// run, the origin's access log (a file, or a Loki endpoint) is searched
+// for the tag, and the matching requests' status and request time are
+// summed up, to be set against the latencies the clients measured: the
+// difference is what the network and any CDN in between added.
+package originlog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/tdigest"
+)
+
+// NewRunTag returns a random run tag, "run-" and 8 hex digits.
+func NewRunTag() string {
+	var b [4]byte
+	_, _ = rand.Read(b[:]) // Never fails (crypto/rand panics instead)
+	return "run-" + hex.EncodeToString(b[:])
+}
+
+// Entry is one tagged request from the origin's access log.
+type Entry struct {
+	Path     string
+	Status   int
+	ClientID int           // From the User-Agent (-1 = not found)
+	Latency  time.Duration // The origin's request time
+	Timed    bool          // The line had a request time
+}
+
+// Segment reports whether the request was for a media segment rather than
+// a playlist.
+func (e Entry) Segment() bool {
+	return path.Ext(e.Path) != ".m3u8"
+}
+
+var (
+	// clientTag finds the client ID after the run tag in a User-Agent.
+	clientTag = regexp.MustCompile(`/client-([0-9]+)`)
+
+	// requestTimeKey finds a logged request time in seconds, as
+	// "request_time=0.012" or "rt=0.012".
+	requestTimeKey = regexp.MustCompile(`\b(?:request_time|rt)=([0-9]+(?:\.[0-9]+)?)\b`)
+)
+
+// ParseLine parses an access log line carrying tag. Two formats are
+// understood:
+//
+//   - NCSA combined ("... \"GET /x.ts HTTP/1.1\" 200 1234 ..."), with the
+//     request time in seconds as request_time= or rt=, or as the last
+//     field (nginx's $request_time appended to the format)
+//   - JSON objects with status, request_time (seconds) and request_uri,
+//     uri, path or request fields, as nginx's escape=json and most log
+//     shippers write them
+//
+// ok is false for lines without the tag or in neither format.
+func ParseLine(line, tag string) (e Entry, ok bool) {
+	i := strings.Index(line, tag)
+	if i < 0 {
+		return Entry{}, false
+	}
+	e.ClientID = -1
+	if m := clientTag.FindStringSubmatch(line[i+len(tag):]); m != nil {
+		e.ClientID, _ = strconv.Atoi(m[1])
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return parseJSON(line, e)
+	}
+	return parseCombined(line, e)
+}
+
+// parseCombined parses the NCSA combined format.
+func parseCombined(line string, e Entry) (Entry, bool) {
+	start := strings.IndexByte(line, '"')
+	if start < 0 {
+		return Entry{}, false
+	}
+	end := strings.IndexByte(line[start+1:], '"')
+	if end < 0 {
+		return Entry{}, false
+	}
+	request := strings.Fields(line[start+1 : start+1+end])
+	if len(request) < 2 {
+		return Entry{}, false
+	}
+	e.Path = stripQuery(request[1])
+
+	rest := strings.Fields(line[start+end+2:])
+	if len(rest) == 0 {
+		return Entry{}, false
+	}
+	status, err := strconv.Atoi(rest[0])
+	if err != nil || status < 100 || status > 599 {
+		return Entry{}, false
+	}
+	e.Status = status
+
+	if m := requestTimeKey.FindStringSubmatch(line); m != nil {
+		e.Latency, e.Timed = seconds(m[1])
+	} else if fields := strings.Fields(line); len(fields) > 0 {
+		e.Latency, e.Timed = seconds(fields[len(fields)-1])
+	}
+	return e, true
+}
+
+// parseJSON parses a JSON log line.
+func parseJSON(line string, e Entry) (Entry, bool) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return Entry{}, false
+	}
+	status, ok := number(fields["status"])
+	if !ok || status < 100 || status > 599 {
+		return Entry{}, false
+	}
+	e.Status = int(status)
+
+	for _, key := range []string{"request_uri", "uri", "path"} {
+		if s, ok := fields[key].(string); ok && s != "" {
+			e.Path = stripQuery(s)
+			break
+		}
+	}
+	if e.Path == "" {
+		if s, ok := fields["request"].(string); ok {
+			if request := strings.Fields(s); len(request) >= 2 {
+				e.Path = stripQuery(request[1])
+			}
+		}
+	}
+	if rt, ok := number(fields["request_time"]); ok && rt >= 0 {
+		e.Latency, e.Timed = time.Duration(rt*float64(time.Second)), true
+	}
+	return e, true
+}
+
+// number returns a JSON number, or a number written as a string (nginx
+// logs every variable as a string).
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// seconds parses a request time in seconds; ok is false if s isn't one.
+func seconds(s string) (time.Duration, bool) {
+	if !strings.Contains(s, ".") {
+		return 0, false // A byte count or status, not a time
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, false
+	}
+	return time.Duration(f * float64(time.Second)), true
+}
+
+// stripQuery drops the query string of a request target.
+func stripQuery(target string) string {
+	if i := strings.IndexByte(target, '?'); i >= 0 {
+		return target[:i]
+	}
+	return target
+}
+
+// Join sums up the tagged requests found in an origin log.
+type Join struct {
+	Tag       string
+	Requests  int64
+	Segments  int64
+	Manifests int64
+	Untimed   int64    // Requests logged without a request time
+	Status    [6]int64 // By class: Status[2] is 2xx
+
+	clients  map[int]struct{}
+	segment  *tdigest.TDigest
+	manifest *tdigest.TDigest
+}
+
+// NewJoin returns an empty join for tag.
+func NewJoin(tag string) *Join {
+	return &Join{
+		Tag:      tag,
+		clients:  make(map[int]struct{}),
+		segment:  tdigest.NewWithCompression(100),
+		manifest: tdigest.NewWithCompression(100),
+	}
+}
+
+// Add counts one entry.
+func (j *Join) Add(e Entry) {
+	j.Requests++
+	if class := e.Status / 100; class >= 1 && class <= 5 {
+		j.Status[class]++
+	}
+	if e.ClientID >= 0 {
+		j.clients[e.ClientID] = struct{}{}
+	}
+
+	digest := j.manifest
+	if e.Segment() {
+		j.Segments++
+		digest = j.segment
+	} else {
+		j.Manifests++
+	}
+	if !e.Timed {
+		j.Untimed++
+		return
+	}
+	digest.Add(float64(e.Latency), 1)
+}
+
+// AddLine parses line and counts it if it is one of the run's requests.
+func (j *Join) AddLine(line string) bool {
+	e, ok := ParseLine(line, j.Tag)
+	if ok {
+		j.Add(e)
+	}
+	return ok
+}
+
+// Clients returns how many clients the requests came from.
+func (j *Join) Clients() int {
+	return len(j.clients)
+}
+
+// SegmentLatency returns the q quantile (0..1) of the origin's segment
+// request times (0 if none were logged).
+func (j *Join) SegmentLatency(q float64) time.Duration {
+	return quantile(j.segment, q)
+}
+
+// ManifestLatency returns the q quantile of the origin's playlist request
+// times (0 if none were logged).
+func (j *Join) ManifestLatency(q float64) time.Duration {
+	return quantile(j.manifest, q)
+}
+
+func quantile(d *tdigest.TDigest, q float64) time.Duration {
+	if d.Count() == 0 {
+		return 0
+	}
+	return time.Duration(d.Quantile(q))
+}
+
+// maxLineBytes bounds one access log line; longer lines are skipped.
+const maxLineBytes = 64 * 1024
+
+// ReadFile adds the run's requests from an access log file.
+func ReadFile(name string, j *Join) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, maxLineBytes)
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			for err == bufio.ErrBufferFull {
+				_, err = r.ReadSlice('\n')
+			}
+		} else if len(line) > 0 {
+			j.AddLine(strings.TrimRight(string(line), "\r\n"))
+		}
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return fmt.Errorf("read %s: %w", name, err)
+		}
+	}
+}
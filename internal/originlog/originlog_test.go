@@ -0,0 +1,141 @@
+package originlog
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTag = "run-1a2b3c4d"
+
+func TestNewRunTag(t *testing.T) {
+	tag := NewRunTag()
+	if !regexp.MustCompile(`^run-[0-9a-f]{8}$`).MatchString(tag) {
+		t.Errorf("NewRunTag() = %q, want run- and 8 hex digits", tag)
+	}
+	if NewRunTag() == tag {
+		t.Error("NewRunTag() returned the same tag twice")
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	ua := "go-ffmpeg-hls-swarm/1.0/" + testTag + "/client-42"
+	tests := []struct {
+		name string
+		line string
+		want Entry
+		ok   bool
+	}{
+		{
+			name: "combined with request time last",
+			line: `10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /live/seg_001.ts?token=x HTTP/1.1" 200 1048576 "-" "` + ua + `" 0.012`,
+			want: Entry{Path: "/live/seg_001.ts", Status: 200, ClientID: 42, Latency: 12 * time.Millisecond, Timed: true},
+			ok:   true,
+		},
+		{
+			name: "combined with rt=",
+			line: `10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /live/stream.m3u8 HTTP/1.1" 304 0 "-" "` + ua + `" rt=0.250 uct="0.001"`,
+			want: Entry{Path: "/live/stream.m3u8", Status: 304, ClientID: 42, Latency: 250 * time.Millisecond, Timed: true},
+			ok:   true,
+		},
+		{
+			name: "combined without request time",
+			line: `10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /live/seg_002.ts HTTP/1.1" 503 0 "-" "` + ua + `"`,
+			want: Entry{Path: "/live/seg_002.ts", Status: 503, ClientID: 42},
+			ok:   true,
+		},
+		{
+			name: "json",
+			line: `{"status":"200","request_uri":"/live/seg_003.ts","request_time":"0.100","http_user_agent":"` + ua + `"}`,
+			want: Entry{Path: "/live/seg_003.ts", Status: 200, ClientID: 42, Latency: 100 * time.Millisecond, Timed: true},
+			ok:   true,
+		},
+		{
+			name: "json with request line and numbers",
+			line: `{"status":404,"request":"GET /live/x.m3u8 HTTP/1.1","request_time":0.002,"ua":"` + ua + `"}`,
+			want: Entry{Path: "/live/x.m3u8", Status: 404, ClientID: 42, Latency: 2 * time.Millisecond, Timed: true},
+			ok:   true,
+		},
+		{
+			name: "tag without client",
+			line: `10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /live/seg_001.ts HTTP/1.1" 200 10 "-" "go-ffmpeg-hls-swarm/1.0/` + testTag + `"`,
+			want: Entry{Path: "/live/seg_001.ts", Status: 200, ClientID: -1},
+			ok:   true,
+		},
+		{
+			name: "another run",
+			line: `10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /live/seg_001.ts HTTP/1.1" 200 10 "-" "go-ffmpeg-hls-swarm/1.0/run-ffffffff/client-1" 0.010`,
+		},
+		{name: "unknown format", line: "client " + testTag + " fetched something"},
+		{name: "bad json", line: `{"status": ` + testTag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseLine(tt.line, testTag)
+			if ok != tt.ok {
+				t.Fatalf("ParseLine() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != tt.want {
+				t.Errorf("ParseLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	j := NewJoin(testTag)
+	for i := 1; i <= 100; i++ {
+		j.Add(Entry{Path: "/seg.ts", Status: 200, ClientID: i % 4, Latency: time.Duration(i) * time.Millisecond, Timed: true})
+	}
+	j.Add(Entry{Path: "/live.m3u8", Status: 200, ClientID: 1, Latency: 5 * time.Millisecond, Timed: true})
+	j.Add(Entry{Path: "/seg.ts", Status: 503, ClientID: -1})
+
+	if j.Requests != 102 || j.Segments != 101 || j.Manifests != 1 || j.Untimed != 1 {
+		t.Errorf("counts = %d requests, %d segments, %d manifests, %d untimed", j.Requests, j.Segments, j.Manifests, j.Untimed)
+	}
+	if j.Status[2] != 101 || j.Status[5] != 1 {
+		t.Errorf("Status = %v", j.Status)
+	}
+	if j.Clients() != 4 {
+		t.Errorf("Clients() = %d, want 4", j.Clients())
+	}
+	if p50 := j.SegmentLatency(0.5); p50 < 45*time.Millisecond || p50 > 55*time.Millisecond {
+		t.Errorf("SegmentLatency(0.5) = %v, want ~50ms", p50)
+	}
+	if got := j.ManifestLatency(0.99); got != 5*time.Millisecond {
+		t.Errorf("ManifestLatency(0.99) = %v, want 5ms", got)
+	}
+	if got := NewJoin(testTag).SegmentLatency(0.5); got != 0 {
+		t.Errorf("SegmentLatency() without requests = %v, want 0", got)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	ua := "go-ffmpeg-hls-swarm/1.0/" + testTag + "/client-"
+	lines := []string{
+		`10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /seg_1.ts HTTP/1.1" 200 10 "-" "` + ua + `1" 0.010`,
+		`10.0.0.9 - - [15/Oct/2026:10:00:00 +0000] "GET /` + strings.Repeat("x", maxLineBytes) + `.ts HTTP/1.1" 200 10 "-" "` + ua + `1" 0.010`,
+		`10.0.0.8 - - [15/Oct/2026:10:00:01 +0000] "GET /seg_1.ts HTTP/1.1" 200 10 "-" "curl/8.0" 0.010`,
+		`10.0.0.9 - - [15/Oct/2026:10:00:02 +0000] "GET /live.m3u8 HTTP/1.1" 200 10 "-" "` + ua + `2" 0.001`,
+	}
+	name := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(name, []byte(strings.Join(lines, "\r\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	j := NewJoin(testTag)
+	if err := ReadFile(name, j); err != nil {
+		t.Fatalf("ReadFile() = %v", err)
+	}
+	// The overlong line is skipped, the last one (no newline) is read
+	if j.Requests != 2 || j.Segments != 1 || j.Manifests != 1 {
+		t.Errorf("Requests = %d (%d segments, %d manifests), want 2 (1, 1)", j.Requests, j.Segments, j.Manifests)
+	}
+
+	if err := ReadFile(filepath.Join(t.TempDir(), "missing.log"), NewJoin(testTag)); err == nil {
+		t.Error("ReadFile() of a missing file: want error")
+	}
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// skewWindow is how long the clock skew estimator collects samples
+	// before it checks the offset it applies against their minimum.
+	skewWindow = 30 * time.Second
+
+	// skewStep is how far a window's minimum must be from the applied
+	// offset to replace it: far above the delay of a line getting to us,
+	// so only a clock step moves timestamps.
+	skewStep = time.Second
+)
+
+// clockSkew estimates the offset between FFmpeg's log timestamps and the
+// swarm's clock, so timestamped events can be moved onto the swarm's time
+// base before they meet anything measured with time.Now.
+//
+// Each timestamped line gives one sample: receipt time minus FFmpeg
+// timestamp. That is the clock offset (a host clock difference, or FFmpeg
+// running in another time zone) plus the delay getting the line to us,
+// which is never negative, so the smallest sample of a window is the best
+// estimate, as in NTP's minimum-delay filter.
+//
+// Until the first window is over, the offset applied is the first line's
+// sample, delay and all; the first window's minimum then replaces it.
+// From there it only changes when a whole window disagrees with it by
+// more than skewStep: shifting it by a few milliseconds would stretch or
+// shrink the downloads in flight, while FFmpeg's own timestamps are exact
+// relative to each other.
+//
+// observe is called from ParseLine only; Offset may be called from any
+// goroutine.
+type clockSkew struct {
+	windowStart time.Time
+	windowMin   time.Duration
+	settled     bool // The first window's minimum was adopted
+
+	offset atomic.Int64 // Applied offset, nanoseconds
+}
+
+// observe adds a sample for an FFmpeg timestamp ts received at receipt
+// and returns ts moved onto our clock.
+func (s *clockSkew) observe(ts, receipt time.Time) time.Time {
+	sample := receipt.Sub(ts)
+	switch {
+	case s.windowStart.IsZero():
+		s.offset.Store(int64(sample))
+		s.windowStart, s.windowMin = receipt, sample
+	case receipt.Sub(s.windowStart) >= skewWindow:
+		if d := s.windowMin - s.Offset(); !s.settled || d > skewStep || d < -skewStep {
+			s.offset.Store(int64(s.windowMin))
+		}
+		s.settled = true
+		s.windowStart, s.windowMin = receipt, sample
+	case sample < s.windowMin:
+		s.windowMin = sample
+	}
+	return ts.Add(s.Offset())
+}
+
+// Offset returns the offset added to FFmpeg timestamps (0 before the
+// first timestamped line).
+func (s *clockSkew) Offset() time.Duration {
+	return time.Duration(s.offset.Load())
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockSkew(t *testing.T) {
+	var s clockSkew
+	if s.Offset() != 0 {
+		t.Fatalf("Offset() before any line = %v, want 0", s.Offset())
+	}
+
+	// FFmpeg's clock runs 2h behind ours (another time zone), and lines
+	// reach us 5-40ms after FFmpeg wrote them
+	const behind = 2 * time.Hour
+	start := time.Date(2026, 1, 23, 8, 0, 0, 0, time.UTC)
+	delays := []time.Duration{20, 5, 40, 12}
+	for i, d := range delays {
+		ts := start.Add(time.Duration(i) * time.Second)
+		got := s.observe(ts, ts.Add(behind+d*time.Millisecond))
+		// Applied from the first line, unchanged within the window
+		if want := ts.Add(behind + 20*time.Millisecond); !got.Equal(want) {
+			t.Errorf("line %d: observe() = %v, want %v", i, got, want)
+		}
+	}
+
+	// The first full window's minimum replaces the first line's sample
+	ts := start.Add(skewWindow)
+	s.observe(ts, ts.Add(behind+30*time.Millisecond))
+	if want := behind + 5*time.Millisecond; s.Offset() != want {
+		t.Errorf("Offset() after the first window = %v, want its minimum %v", s.Offset(), want)
+	}
+
+	// Later, small differences never move it: durations in flight keep
+	ts = start.Add(2 * skewWindow)
+	s.observe(ts, ts.Add(behind+25*time.Millisecond))
+	if want := behind + 5*time.Millisecond; s.Offset() != want {
+		t.Errorf("Offset() = %v, want the first window's %v", s.Offset(), want)
+	}
+
+	// A clock step is followed once a whole window disagrees
+	const stepped = behind - 3*time.Second
+	for i := 1; i <= 3; i++ {
+		ts := start.Add(2*skewWindow + time.Duration(i)*skewWindow/2)
+		s.observe(ts, ts.Add(stepped+10*time.Millisecond))
+	}
+	if want := stepped + 10*time.Millisecond; s.Offset() != want {
+		t.Errorf("Offset() after a clock step = %v, want %v", s.Offset(), want)
+	}
+}
+
+// Timestamps from another clock keep their durations and land on ours.
+func TestDebugEventParser_ClockSkewCorrection(t *testing.T) {
+	var events []*DebugEvent
+	p := NewDebugEventParser(1, 2*time.Second, func(e *DebugEvent) { events = append(events, e) })
+
+	p.ParseLine("2020-01-23 08:12:52.614 [tcp @ 0x5647feb5e100] [verbose] Starting connection attempt to 10.177.0.10 port 17080")
+	p.ParseLine("2020-01-23 08:12:52.615 [tcp @ 0x5647feb5e100] [verbose] Successfully connected to 10.177.0.10 port 17080")
+
+	stats := p.Stats()
+	if stats.TCPConnectAvgMs < 0.5 || stats.TCPConnectAvgMs > 1.5 {
+		t.Errorf("TCPConnectAvgMs = %f, want ~1.0", stats.TCPConnectAvgMs)
+	}
+	if stats.ClockSkew < 5*365*24*time.Hour {
+		t.Errorf("ClockSkew = %v, want the years since the timestamps", stats.ClockSkew)
+	}
+	for _, e := range events {
+		if age := time.Since(e.Timestamp); age < -time.Second || age > time.Second {
+			t.Errorf("%v event at %v, want about now", e.Type, e.Timestamp)
+		}
+	}
+}
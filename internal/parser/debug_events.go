@@ -2,7 +2,11 @@
 package parser
 
 import (
+	"cmp"
+	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -43,8 +47,37 @@ const (
 
 	// Bandwidth events
 	DebugEventBandwidth // BANDWIDTH=... from manifest parsing
+
+	// Discontinuity / ad insertion events
+	DebugEventDiscontinuity // timestamp discontinuity (client crossed #EXT-X-DISCONTINUITY)
+	DebugEventAdMarker      // SCTE-35 cue tag seen in a playlist (first sighting only)
+
+	// Slow request events (-slow-request-log)
+	DebugEventSlowRequest // Segment or manifest download slower than the threshold
+
+	// Network failures outside TCP connect (FailReason: dns, reset, tls)
+	DebugEventNetworkError
 )
 
+// debugEventNames are the DebugEventType names, in iota order.
+var debugEventNames = [...]string{
+	"hls_request", "http_open", "tcp_start", "tcp_connected", "tcp_failed",
+	"playlist_open", "sequence_change",
+	"http_error", "reconnect", "segment_failed", "segment_skipped", "playlist_failed", "segments_expired",
+	"bandwidth",
+	"discontinuity", "ad_marker",
+	"slow_request",
+	"network_error",
+}
+
+// String returns the event type's name, e.g. "hls_request" (for logs).
+func (t DebugEventType) String() string {
+	if t < 0 || int(t) >= len(debugEventNames) {
+		return fmt.Sprintf("DebugEventType(%d)", int(t))
+	}
+	return debugEventNames[t]
+}
+
 // DebugEvent represents a parsed debug log event.
 type DebugEvent struct {
 	Type       DebugEventType
@@ -54,7 +87,7 @@ type DebugEvent struct {
 	Port       int
 	OldSeq     int
 	NewSeq     int
-	FailReason string // "refused", "timeout", "error"
+	FailReason string // TCPFailed: "refused", "timeout", "error"; NetworkError: "dns", "reset", "tls"
 	Bandwidth  int64  // bits per second
 	HTTPCode   int    // HTTP status code (4xx, 5xx)
 	ErrorMsg   string // Error message text
@@ -62,8 +95,24 @@ type DebugEvent struct {
 	PlaylistID int    // Playlist index
 	SegmentID  int64  // Segment sequence number
 	Bytes      int64  // Bytes downloaded (from Content-Length header)
+	Marker     string // Ad marker tag (e.g. "#EXT-X-CUE-OUT:DURATION=30")
+	Cause      string // Reconnect: the failed stage and why, see ReconnectCauses
+
+	// Slow request (DebugEventSlowRequest)
+	Kind     string        // SlowRequestSegment or SlowRequestManifest
+	Started  time.Time     // When the download started
+	WallTime time.Duration // Start to completion
+	Connect  time.Duration // TCP connect during the request (0 = reused connection)
+	Transfer time.Duration // After Connect, to the end of the transfer
+	Idle     time.Duration // Segments: end of the transfer to the next request
 }
 
+// Request kinds reported in DebugEventSlowRequest.
+const (
+	SlowRequestSegment  = "segment"
+	SlowRequestManifest = "manifest"
+)
+
 // Pre-compiled regex patterns for performance.
 // These match FFmpeg -loglevel debug output lines.
 var (
@@ -87,7 +136,20 @@ var (
 
 	// [tcp @ 0x55...] Connection refused / timed out / Failed to connect
 	// Also matches: Connection attempt to ... failed: ...
-	reTCPFailed = regexp.MustCompile(`(?i)\[tcp @ 0x[0-9a-f]+\] (?:\[(?:verbose|debug|info)\] )?(connection refused|connection timed out|failed to connect|connection attempt to .+ failed)`)
+	// and tcp.c's: Connection to tcp://10.177.0.10:17080 failed: Connection refused
+	reTCPFailed = regexp.MustCompile(`(?i)\[tcp @ 0x[0-9a-f]+\] (?:\[(?:verbose|debug|info|warning|error)\] )?(connection refused|connection timed out|failed to connect|connection attempt to .+ failed|connection to \S+ failed)`)
+
+	// [tcp @ 0x55...] Failed to resolve hostname origin.example: Name or service not known
+	reDNSFailed = regexp.MustCompile(`\[tcp @ 0x[0-9a-f]+\] (?:\[(?:warning|error)\] )?Failed to resolve hostname`)
+
+	// ECONNRESET from any layer, including the final "url: Connection reset by peer"
+	reConnReset = regexp.MustCompile(`(?i)connection reset by peer`)
+
+	// [tls @ 0x55...] error:0A000086:SSL routines::certificate verify failed
+	// [tls @ 0x55...] The TLS connection was non-properly terminated / handshake failed
+	// Limited to handshake/certificate failures: "Error in the pull function"
+	// follows a reset or timeout underneath and is not a TLS problem.
+	reTLSError = regexp.MustCompile(`(?i)\[tls @ 0x[0-9a-f]+\] (?:\[(?:warning|error)\] )?.*(?:handshake|certificate|SSL routines|verif)`)
 
 	// [hls @ 0x55...] Opening 'http://.../stream.m3u8' for reading
 	// [AVFormatContext @ 0x55...] Opening 'http://.../stream.m3u8' for reading (initial open)
@@ -115,7 +177,9 @@ var (
 	reHTTPError = regexp.MustCompile(`(?i)\[http @ 0x[0-9a-f]+\] (?:\[(?:warning|error)\] )?HTTP error (\d+) (.*)`)
 
 	// Will reconnect at 12345 in 2 second(s)
-	reReconnect = regexp.MustCompile(`(?i)Will reconnect at (\d+) in (\d+) second`)
+	// Will reconnect at 12345 in 2 second(s), error=Connection timed out.
+	// (the error only when reading a body failed)
+	reReconnect = regexp.MustCompile(`(?i)Will reconnect at (\d+) in (\d+) second(?:\(s\))?(?:, error=(.*?)\.?\s*$)?`)
 
 	// [hls @ 0x55...] Failed to open segment 1234 of playlist 0
 	reSegmentFailed = regexp.MustCompile(`\[hls @ 0x[0-9a-f]+\] (?:\[(?:warning|error)\] )?Failed to open segment (\d+) of playlist (\d+)`)
@@ -138,9 +202,30 @@ var (
 	// This is critical for tracking segment requests after initial parsing.
 	// Captures the URL path (e.g., /seg00001.ts)
 	reHTTPRequestGET = regexp.MustCompile(`\[http @ 0x[0-9a-f]+\] (?:\[(?:debug|verbose|info)\] )?request: GET ([^\s]+) HTTP/`)
+
+	// [AVIOContext @ 0x55...] Statistics: 1234567 bytes read, 0 seeks
+	// Logged (verbose) when an input is closed, which for a segment fetched
+	// without keep-alive is the end of its transfer.
+	reAVIOStatistics = regexp.MustCompile(`\[AVIOContext @ 0x[0-9a-f]+\] (?:\[(?:debug|verbose|info)\] )?Statistics: (\d+) bytes read`)
+
+	// Discontinuity / ad insertion patterns
+
+	// timestamp discontinuity for stream #0:1 (id=257, type=audio): -95443717689, new offset= 95443717689
+	// Older FFmpeg: "timestamp discontinuity -95443717689, new offset= 95443717689"
+	// Logged by the ffmpeg CLI (not libavformat) when demuxed timestamps jump,
+	// which is how a client crossing #EXT-X-DISCONTINUITY shows up. hls.c
+	// itself ignores the tag, so this is the only per-client signal.
+	reTimestampDiscontinuity = regexp.MustCompile(`timestamp discontinuity.*?(-?\d+), new offset`)
+
+	// [hls @ 0x55...] Skip ('#EXT-X-CUE-OUT:DURATION=30')
+	// [hls @ 0x55...] Skip ('#EXT-X-DATERANGE:ID="ad1",START-DATE="...",SCTE35-OUT=0xFC...')
+	// hls.c logs unknown tags on every playlist parse, so these repeat while
+	// the tag stays in the live window.
+	reAdMarker = regexp.MustCompile(`\[hls @ 0x[0-9a-f]+\] (?:\[(?:verbose|debug|info)\] )?Skip \('(#EXT-X-CUE-OUT(?:-CONT)?|#EXT-X-CUE-IN|#EXT-X-DATERANGE)([^']*)'\)`)
 )
 
-// timestampLayout is the format FFmpeg uses with -loglevel datetime
+// timestampLayout is the format FFmpeg uses with -loglevel datetime, in
+// its local time
 const timestampLayout = "2006-01-02 15:04:05.000"
 
 // parseTimestamp extracts a timestamp from an FFmpeg log line.
@@ -148,7 +233,7 @@ const timestampLayout = "2006-01-02 15:04:05.000"
 // If no timestamp is found, returns time.Time{} (zero) and the original line.
 func parseTimestamp(line string) (time.Time, string) {
 	if m := reTimestamp.FindStringSubmatch(line); m != nil {
-		if ts, err := time.Parse(timestampLayout, m[1]); err == nil {
+		if ts, err := time.ParseInLocation(timestampLayout, m[1], time.Local); err == nil {
 			// Strip timestamp from line for further processing
 			return ts, line[len(m[0]):]
 		}
@@ -188,7 +273,7 @@ type DebugEventParser struct {
 	pendingSegments   map[string]time.Time
 	segmentWallTimes  []time.Duration // Ring buffer (last N samples)
 	segmentWallTimeP0 int             // Ring buffer position
-	segmentCount      atomic.Int64
+	segmentCount      sharedCounter
 
 	// Segment wall time aggregates
 	segmentWallTimeSum   int64 // nanoseconds
@@ -204,7 +289,7 @@ type DebugEventParser struct {
 	pendingManifests   map[string]time.Time
 	manifestWallTimes  []time.Duration // Ring buffer (last N samples)
 	manifestWallTimeP0 int             // Ring buffer position
-	manifestCount      atomic.Int64
+	manifestCount      sharedCounter
 
 	// Manifest wall time aggregates
 	manifestWallTimeSum   int64 // nanoseconds
@@ -220,47 +305,115 @@ type DebugEventParser struct {
 	pendingTCPConnect  map[string]time.Time
 	tcpConnectSamples  []time.Duration // Ring buffer
 	tcpConnectP0       int             // Ring buffer position
-	tcpConnectCount    atomic.Int64
+	tcpConnectCount    sharedCounter
 	tcpConnectSum      int64 // nanoseconds
 	tcpConnectMax      int64 // nanoseconds
 	tcpConnectMin      int64 // nanoseconds (-1 = unset)
 
+	// Log dialect: the pattern set lines are read with (see dialect.go)
+	patterns atomic.Pointer[linePatterns]
+
 	// Timestamp parsing stats
-	timestampsUsed atomic.Int64 // Lines where FFmpeg timestamp was used
+	timestampsUsed sharedCounter // Lines where FFmpeg timestamp was used
+	skew           clockSkew     // FFmpeg timestamp offset from our clock
 
 	// TCP Health (success/failure ratio)
-	tcpSuccessCount atomic.Int64
+	tcpSuccessCount sharedCounter
 	tcpFailureCount atomic.Int64
-	tcpTimeoutCount atomic.Int64
-	tcpRefusedCount atomic.Int64
+	tcpTimeoutCount sharedCounter
+	tcpRefusedCount sharedCounter
 
 	// Playlist jitter tracking
 	lastPlaylistRefresh time.Time
-	playlistRefreshes   atomic.Int64
-	playlistLateCount   atomic.Int64
+	playlistRefreshes   sharedCounter
+	playlistLateCount   sharedCounter
 	playlistJitterSum   int64 // nanoseconds (signed: early is negative)
 	playlistJitterMax   int64 // nanoseconds (absolute max deviation)
 
+	// Swarm-wide playlist refresh intervals and storms (nil = not attached)
+	refreshes *RefreshTracker
+
+	// Swarm-wide segment wall times for the current window (nil = not attached)
+	latency *LatencyWindow
+
+	// Swarm-wide segment wall time buckets for the heatmap (nil = not attached)
+	heatmap *LatencyHeatmap
+
+	// Set by DropSamples: the wall time and TCP connect rings stay empty
+	samplesDropped bool
+
 	// Sequence tracking
 	lastSequence  int
-	sequenceSkips atomic.Int64
+	sequenceSkips sharedCounter
+
+	// Discontinuity tracking
+	// A discontinuity is "recovered" when the next segment download completes.
+	discontinuityCount sharedCounter
+	discontinuityAt    time.Time // Zero when no recovery is pending
+	recoveryCount      int64
+	recoverySum        int64 // nanoseconds
+	recoveryMax        int64 // nanoseconds
+
+	// Segment download time: request to end of transfer, without the idle
+	// gap before the next request that wall time includes. See startDownload.
+	downloadURL    string    // Segment being timed ("" = none yet)
+	downloadStart  time.Time // Its request
+	downloadEnd    time.Time // End of its transfer (zero = still downloading)
+	downloadDigest *tdigest.TDigest
+	downloadCount  int64
+	gapSum         int64 // nanoseconds, transfer end -> next segment request
+	gapCount       int64
+
+	// Last TCP connect, for the stage timings of slow requests
+	lastConnectAt time.Time
+	lastConnect   time.Duration
+
+	// Slow request tracking (0 threshold = disabled)
+	slowThreshold time.Duration
+	slowSegments  sharedCounter
+	slowManifests sharedCounter
+
+	// Latency SLO tracking (0 threshold = disabled)
+	sloThreshold time.Duration
+	sloMisses    sharedCounter
+
+	// Ad markers (SCTE-35 cue tags)
+	adMarkersSeen map[string]time.Time // Marker text -> last sighting (dedupes refreshes)
+	adMarkerCount sharedCounter
+	adBreakCount  sharedCounter // CUE-OUT / SCTE35-OUT markers
+
+	// Latest DNS/TCP/TLS/HTTP failure, for reconnect causes (parsing
+	// goroutine only)
+	lastFailure failureMark
 
 	// Error event counters (critical for load testing)
-	httpErrorCount      atomic.Int64 // HTTP 4xx/5xx errors
-	http4xxCount        atomic.Int64 // Client errors
-	http5xxCount        atomic.Int64 // Server errors
-	reconnectCount      atomic.Int64 // Reconnection attempts
-	segmentFailedCount  atomic.Int64 // Segment open failures
-	segmentSkippedCount atomic.Int64 // Segments skipped after retries
-	playlistFailedCount atomic.Int64 // Playlist reload failures
-	segmentsExpiredSum  atomic.Int64 // Total segments skipped due to expiry
+	httpErrorCount      atomic.Int64  // HTTP 4xx/5xx errors
+	http4xxCount        sharedCounter // Client errors
+	http5xxCount        sharedCounter // Server errors
+	reconnectCount      sharedCounter // Reconnection attempts
+	segmentFailedCount  sharedCounter // Segment open failures
+	segmentSkippedCount sharedCounter // Segments skipped after retries
+	playlistFailedCount sharedCounter // Playlist reload failures
+	segmentsExpiredSum  sharedCounter // Total segments skipped due to expiry
 
 	// HTTP open timing (for request vs download separation)
 	pendingHTTPOpen   map[string]time.Time
-	httpOpenCount     atomic.Int64
+	httpOpenCount     sharedCounter
+	hostOpens         map[string]int64 // Host -> HTTP opens (bounded, see maxTrackedHosts)
 	httpOpenSum       int64 // nanoseconds
 	httpOpenMax       int64 // nanoseconds
 
+	// Per-URL segment tracking (for swarm-wide hot spot lists)
+	segmentURLCounts map[string]int64 // URL -> downloads (bounded, see recordSegmentURL)
+	segmentURLLast   map[string]int64 // URL -> segmentURLSeq at its latest download
+	segmentURLSeq    int64
+	slowestSegments  []SegmentTiming  // Slowest first, at most slowestSegmentsKept
+	shardSegments    map[string]int64 // segmentShard -> downloads (bounded, see maxTrackedShards)
+
+	// CDN vs origin serving (see serving.go)
+	resp    responseState           // The response being read
+	serving map[string]ServingStats // Serving class -> responses
+
 	// Bytes tracking (from HTTP Content-Length headers)
 	// Critical for live streams where progress total_size=N/A
 	bytesDownloaded atomic.Int64
@@ -270,21 +423,62 @@ type DebugEventParser struct {
 
 	// Segment bytes tracking (from segment scraper, accurate sizes)
 	// This tracks bytes from COMPLETED segment downloads only
-	segmentBytesDownloaded atomic.Int64
+	segmentBytesDownloaded sharedCounter
 
 	// Segment size lookup diagnostics
-	segmentSizeLookupAttempts  atomic.Int64 // Total lookup attempts
-	segmentSizeLookupSuccesses atomic.Int64 // Successful lookups (size found)
+	segmentSizeLookupAttempts  sharedCounter // Total lookup attempts
+	segmentSizeLookupSuccesses sharedCounter // Successful lookups (size found)
+
+	// Pending map expiry (see expirePending)
+	lastPendingSweep time.Time
+	orphanedPending  sharedCounter // Segment/manifest/TCP starts that never completed
+
+	// mu is taken by a handler of the parsing goroutine (see lock); only
+	// read and written by that goroutine
+	parseHeld bool
 
 	// Parser stats
-	linesProcessed atomic.Int64
+	linesProcessed sharedCounter
 }
 
 const (
 	// defaultRingSize is the number of samples to keep for percentile calculations.
 	defaultRingSize = 100
+
+	// maxTrackedHosts bounds hostOpens; further hosts are counted as OtherHosts.
+	maxTrackedHosts = 32
+
+	// maxTrackedSegmentURLs bounds segmentURLCounts per client.
+	maxTrackedSegmentURLs = 64
+
+	// maxTrackedShards bounds shardSegments; further shards are counted as
+	// OtherShards.
+	maxTrackedShards = 64
+
+	// slowestSegmentsKept is the length of each client's slowest list.
+	slowestSegmentsKept = 10
+
+	// pendingTTL is how long a started request may wait for its completion
+	// event before it is dropped as orphaned. Far above any real download.
+	pendingTTL = 2 * time.Minute
+
+	// pendingSweepInterval is how often (in log time) pending maps are swept.
+	pendingSweepInterval = 10 * time.Second
 )
 
+// SegmentTiming is one completed segment download.
+type SegmentTiming struct {
+	URL      string
+	WallTime time.Duration
+}
+
+// OtherHosts is the DebugStats.HostOpens key for hosts beyond maxTrackedHosts.
+const OtherHosts = "(other)"
+
+// OtherShards is the DebugStats.ShardSegments key for shards beyond
+// maxTrackedShards.
+const OtherShards = "(other)"
+
 // extractSegmentName extracts the filename from a segment URL.
 // Example: "http://10.177.0.10:17080/seg00017.ts" -> "seg00017.ts"
 func extractSegmentName(url string) string {
@@ -315,7 +509,7 @@ func NewDebugEventParserWithSizeLookup(clientID int, targetDuration time.Duratio
 	if targetDuration <= 0 {
 		targetDuration = 2 * time.Second // HLS default
 	}
-	return &DebugEventParser{
+	p := &DebugEventParser{
 		clientID:               clientID,
 		callback:               callback,
 		targetDuration:         targetDuration,
@@ -324,14 +518,23 @@ func NewDebugEventParserWithSizeLookup(clientID int, targetDuration time.Duratio
 		pendingTCPConnect:      make(map[string]time.Time),
 		tcpConnectSamples:      make([]time.Duration, 0, defaultRingSize),
 		pendingHTTPOpen:        make(map[string]time.Time),
+		hostOpens:              make(map[string]int64),
+		segmentURLCounts:       make(map[string]int64),
+		segmentURLLast:         make(map[string]int64),
+		shardSegments:          make(map[string]int64),
+		serving:                make(map[string]ServingStats),
 		segmentWallTimeMin:     -1, // -1 = unset
 		tcpConnectMin:          -1, // -1 = unset
 		segmentWallTimeDigest:  tdigest.NewWithCompression(100), // ~100 centroids, ~10KB
 		pendingManifests:       make(map[string]time.Time),
 		manifestWallTimeMin:    -1, // -1 = unset
 		manifestWallTimeDigest: tdigest.NewWithCompression(100), // ~100 centroids, ~10KB
+		downloadDigest:         tdigest.NewWithCompression(100),
 		segmentSizeLookup:      sizeLookup,
+		adMarkersSeen:          make(map[string]time.Time),
 	}
+	p.patterns.Store(dialectPatterns[DialectAuto])
+	return p
 }
 
 // ParseLine implements LineParser interface.
@@ -341,6 +544,14 @@ func NewDebugEventParserWithSizeLookup(clientID int, targetDuration time.Duratio
 func (p *DebugEventParser) ParseLine(line string) {
 	p.linesProcessed.Add(1)
 
+	patterns := p.patterns.Load()
+	if patterns.dialect == DialectAuto {
+		if d := detectDialect(line); d != DialectAuto {
+			patterns = dialectPatterns[d]
+			p.patterns.Store(patterns)
+		}
+	}
+
 	// Fast path: most lines don't match any pattern
 	// Check for common keywords to skip irrelevant lines quickly
 	if !strings.Contains(line, " @ 0x") &&
@@ -350,22 +561,31 @@ func (p *DebugEventParser) ParseLine(line string) {
 		!strings.Contains(line, "HTTP error") &&
 		!strings.Contains(line, "reconnect") &&
 		!strings.Contains(line, "Failed to") &&
-		!strings.Contains(line, "skipping") {
+		!strings.Contains(line, "skipping") &&
+		!strings.Contains(line, "discontinuity") &&
+		!strings.Contains(line, "reset by peer") {
 		return
 	}
 
 	// Parse FFmpeg timestamp if present (from -loglevel datetime)
 	// This gives us accurate timing even if logs back up in channels
-	parsedTs, line := parseTimestamp(line)
+	parsedTs, line := patterns.parseTimestamp(line)
 
+	// Moved onto our clock: FFmpeg's may be skewed or in another zone
 	var now time.Time
 	if !parsedTs.IsZero() {
-		now = parsedTs
+		now = p.skew.observe(parsedTs, time.Now())
 		p.timestampsUsed.Add(1)
 	} else {
 		now = time.Now()
 	}
 
+	p.lock()
+	if now.Sub(p.lastPendingSweep) >= pendingSweepInterval {
+		p.expirePending(now)
+	}
+	p.unlock()
+
 	// Check patterns in order of expected frequency
 
 	// 1. TCP Connected (completes TCP timing)
@@ -402,7 +622,7 @@ func (p *DebugEventParser) ParseLine(line string) {
 
 	// 5. TCP Failed
 	if m := reTCPFailed.FindStringSubmatch(line); m != nil {
-		p.handleTCPFailed(now, m[1])
+		p.handleTCPFailed(now, line) // Reason may follow the match ("failed: Connection refused")
 		return
 	}
 
@@ -426,6 +646,12 @@ func (p *DebugEventParser) ParseLine(line string) {
 		return
 	}
 
+	// 8b. Ad markers (other Skip lines; reManifestSkip only matches #EXT-X-VERSION)
+	if m := reAdMarker.FindStringSubmatch(line); m != nil {
+		p.handleAdMarker(now, m[1], m[1]+m[2])
+		return
+	}
+
 	// 9. Manifest Skip (manifest parsing started - download complete, appears on refreshes)
 	if reManifestSkip.MatchString(line) {
 		p.handleFormatProbed(now) // Reuse same handler - completes pending manifest
@@ -457,6 +683,9 @@ func (p *DebugEventParser) ParseLine(line string) {
 
 	// 12. Content-Length header (tracks bytes downloaded - critical for live streams)
 	if m := reContentLength.FindStringSubmatch(line); m != nil {
+		p.lock()
+		p.responseHeader(now, "Content-Length", m[1])
+		p.unlock()
 		if size, err := strconv.ParseInt(m[1], 10, 64); err == nil {
 			p.bytesDownloaded.Add(size)
 			// Emit event for callback to update ClientStats
@@ -471,9 +700,17 @@ func (p *DebugEventParser) ParseLine(line string) {
 		return
 	}
 
+	// 12b. Other response headers (CDN vs origin, see serving.go)
+	if m := reHTTPHeader.FindStringSubmatch(line); m != nil {
+		p.lock()
+		p.responseHeader(now, m[1], m[2])
+		p.unlock()
+		return
+	}
+
 	// 13. Reconnect attempt
 	if m := reReconnect.FindStringSubmatch(line); m != nil {
-		p.handleReconnect(now)
+		p.handleReconnect(now, m[3])
 		return
 	}
 
@@ -506,11 +743,41 @@ func (p *DebugEventParser) ParseLine(line string) {
 		p.handleSegmentsExpired(now, skipCount)
 		return
 	}
+
+	// 17b. Network failures outside TCP connect
+	if reDNSFailed.MatchString(line) {
+		p.handleNetworkError(now, "dns")
+		return
+	}
+	if reConnReset.MatchString(line) {
+		p.handleNetworkError(now, "reset")
+		return
+	}
+	if reTLSError.MatchString(line) {
+		p.handleNetworkError(now, "tls")
+		return
+	}
+
+	// 18. Input closed (segment transfer end, see reAVIOStatistics)
+	if reAVIOStatistics.MatchString(line) {
+		p.lock()
+		p.endDownload(now)
+		p.unlock()
+		return
+	}
+
+	// 19. Timestamp discontinuity
+	if reTimestampDiscontinuity.MatchString(line) {
+		p.handleDiscontinuity(now)
+		return
+	}
 }
 
 // handleFormatProbed is called when manifest format is probed.
 // This indicates the manifest download and parsing is complete.
 func (p *DebugEventParser) handleFormatProbed(now time.Time) {
+	var slow *DebugEvent
+	defer func() { p.emit(slow) }() // Runs after the unlock below
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -541,18 +808,14 @@ func (p *DebugEventParser) handleFormatProbed(now time.Time) {
 				p.manifestWallTimeMax = ns
 			}
 
-			// Ring buffer
-			if len(p.manifestWallTimes) < defaultRingSize {
-				p.manifestWallTimes = append(p.manifestWallTimes, wallTime)
-			} else {
-				p.manifestWallTimes[p.manifestWallTimeP0] = wallTime
-				p.manifestWallTimeP0 = (p.manifestWallTimeP0 + 1) % defaultRingSize
-			}
+			p.addSample(&p.manifestWallTimes, &p.manifestWallTimeP0, wallTime)
 
 			// Add to T-Digest for percentile calculation
 			p.manifestWallTimeDigestMu.Lock()
 			p.manifestWallTimeDigest.Add(float64(wallTime.Nanoseconds()), 1)
 			p.manifestWallTimeDigestMu.Unlock()
+
+			slow = p.checkSlow(SlowRequestManifest, oldestURL, oldestTime, wallTime)
 		}
 	}
 }
@@ -561,7 +824,8 @@ func (p *DebugEventParser) handleFormatProbed(now time.Time) {
 // Automatically completes the oldest pending segment (if any) using the timestamp
 // from this log line for accurate timing.
 func (p *DebugEventParser) handleHLSRequest(now time.Time, url string) {
-	p.mu.Lock()
+	var slow *DebugEvent
+	p.lock()
 
 	// Complete oldest pending segment (if any) before starting new one
 	// This uses the timestamp from the log line for accurate timing
@@ -582,6 +846,7 @@ func (p *DebugEventParser) handleHLSRequest(now time.Time, url string) {
 			ns := int64(wallTime)
 			p.segmentCount.Add(1)
 			p.segmentWallTimeSum += ns
+			p.recoverDiscontinuity(now)
 
 			if p.segmentWallTimeMin < 0 || ns < p.segmentWallTimeMin {
 				p.segmentWallTimeMin = ns
@@ -590,18 +855,17 @@ func (p *DebugEventParser) handleHLSRequest(now time.Time, url string) {
 				p.segmentWallTimeMax = ns
 			}
 
-			// Ring buffer
-			if len(p.segmentWallTimes) < defaultRingSize {
-				p.segmentWallTimes = append(p.segmentWallTimes, wallTime)
-			} else {
-				p.segmentWallTimes[p.segmentWallTimeP0] = wallTime
-				p.segmentWallTimeP0 = (p.segmentWallTimeP0 + 1) % defaultRingSize
-			}
+			p.addSample(&p.segmentWallTimes, &p.segmentWallTimeP0, wallTime)
 
 			// Add to T-Digest for percentile calculation (using accurate timestamps)
 			p.segmentWallTimeDigestMu.Lock()
 			p.segmentWallTimeDigest.Add(float64(wallTime.Nanoseconds()), 1)
 			p.segmentWallTimeDigestMu.Unlock()
+			p.recordLatency(wallTime)
+			p.checkSLO(wallTime)
+
+			slow = p.checkSlow(SlowRequestSegment, oldestURL, oldestTime, wallTime)
+			p.recordSegmentURL(oldestURL, wallTime)
 
 			// Track segment bytes from scraper (accurate sizes for completed downloads)
 			// Design decision: Count bytes only on "segment complete" to ensure
@@ -619,8 +883,10 @@ func (p *DebugEventParser) handleHLSRequest(now time.Time, url string) {
 
 	// Start tracking new segment
 	p.pendingSegments[url] = now
-	p.mu.Unlock()
+	p.startDownload(now, url)
+	p.unlock()
 
+	p.emit(slow)
 	if p.callback != nil {
 		p.callback(&DebugEvent{
 			Type:      DebugEventHLSRequest,
@@ -630,14 +896,289 @@ func (p *DebugEventParser) handleHLSRequest(now time.Time, url string) {
 	}
 }
 
+// MergeWallTimeDigests adds this client's segment and manifest wall time
+// distributions (nanoseconds) to the given digests. Merging digests is the
+// only way to get correct swarm-wide percentiles.
+func (p *DebugEventParser) MergeWallTimeDigests(segments, manifests *tdigest.TDigest) {
+	p.segmentWallTimeDigestMu.Lock()
+	segments.AddCentroidList(p.segmentWallTimeDigest.Centroids())
+	p.segmentWallTimeDigestMu.Unlock()
+
+	p.manifestWallTimeDigestMu.Lock()
+	manifests.AddCentroidList(p.manifestWallTimeDigest.Centroids())
+	p.manifestWallTimeDigestMu.Unlock()
+}
+
+// SetSlowRequestThreshold enables slow request reporting: every segment or
+// manifest download taking at least d is counted and emitted as a
+// DebugEventSlowRequest. Zero disables it. Call before parsing starts.
+func (p *DebugEventParser) SetSlowRequestThreshold(d time.Duration) {
+	p.slowThreshold = d
+}
+
+// SetDialect sets the FFmpeg log dialect lines are read as. DialectAuto
+// (the default) reads both and settles on one from the log. Call before
+// parsing starts.
+func (p *DebugEventParser) SetDialect(d LogDialect) {
+	if patterns, ok := dialectPatterns[d]; ok {
+		p.patterns.Store(patterns)
+	}
+}
+
+// Dialect returns the log dialect lines are read as: the one set, or the
+// one detected (DialectAuto until then). Safe to call concurrently.
+func (p *DebugEventParser) Dialect() LogDialect {
+	return p.patterns.Load().dialect
+}
+
+// SetSLOThreshold enables the -slo latency objective: every segment whose
+// wall time reaches d counts as a miss. Zero disables it. Call before
+// parsing starts.
+func (p *DebugEventParser) SetSLOThreshold(d time.Duration) {
+	p.sloThreshold = d
+}
+
+// checkSLO counts a completed segment that missed the SLO threshold.
+func (p *DebugEventParser) checkSLO(wallTime time.Duration) {
+	if p.sloThreshold > 0 && wallTime >= p.sloThreshold {
+		p.sloMisses.Add(1)
+	}
+}
+
+// checkSlow counts a completed download if it exceeded the slow request
+// threshold, returning the event to emit (nil if not slow). Called with p.mu
+// held, before a segment's successor starts being timed; the caller emits
+// after unlocking.
+//
+// The stages split the wall time: a TCP connect that completed during the
+// request, then the transfer and, for a segment whose transfer end was
+// seen (see startDownload), the idle wait before the next request.
+func (p *DebugEventParser) checkSlow(kind, url string, started time.Time, wallTime time.Duration) *DebugEvent {
+	if p.slowThreshold <= 0 || wallTime < p.slowThreshold {
+		return nil
+	}
+	if kind == SlowRequestManifest {
+		p.slowManifests.Add(1)
+	} else {
+		p.slowSegments.Add(1)
+	}
+	e := &DebugEvent{
+		Type:      DebugEventSlowRequest,
+		Timestamp: started.Add(wallTime),
+		URL:       url,
+		Kind:      kind,
+		Started:   started,
+		WallTime:  wallTime,
+	}
+	if !p.lastConnectAt.Before(started) && !p.lastConnectAt.After(e.Timestamp) {
+		e.Connect = p.lastConnect
+	}
+	transferEnd := e.Timestamp
+	if kind == SlowRequestSegment && !p.downloadEnd.IsZero() && p.downloadEnd.After(started) &&
+		extractSegmentName(p.downloadURL) == extractSegmentName(url) {
+		transferEnd = p.downloadEnd
+	}
+	e.Transfer = max(transferEnd.Sub(started)-e.Connect, 0)
+	e.Idle = e.Timestamp.Sub(transferEnd)
+	return e
+}
+
+// startDownload times a new segment request, first closing out the previous
+// segment. Called with p.mu held.
+//
+// Wall time runs from one segment request to the next, so it includes the
+// idle pacing (waiting for the live edge) between them. Download time stops
+// at the first sign the transfer is over: the input being closed (only
+// logged without keep-alive), a playlist reload (hls.c reloads only after
+// finishing a segment) or, failing both, the next segment request. It is
+// an upper bound: with keep-alive, the wait before an on-schedule reload
+// still counts as downloading.
+func (p *DebugEventParser) startDownload(now time.Time, url string) {
+	if p.downloadURL != "" && extractSegmentName(p.downloadURL) == extractSegmentName(url) {
+		return // Same request seen at the HLS and HTTP layers
+	}
+	if p.downloadURL != "" {
+		if p.downloadEnd.IsZero() {
+			p.endDownload(now)
+		} else {
+			p.gapSum += int64(now.Sub(p.downloadEnd))
+			p.gapCount++
+		}
+	}
+	p.downloadURL = url
+	p.downloadStart = now
+	p.downloadEnd = time.Time{}
+}
+
+// endDownload records the download time of the segment being timed, if its
+// transfer hasn't already ended. Called with p.mu held.
+func (p *DebugEventParser) endDownload(now time.Time) {
+	if p.downloadURL == "" || !p.downloadEnd.IsZero() {
+		return
+	}
+	p.downloadEnd = now
+	p.downloadCount++
+	p.downloadDigest.Add(float64(now.Sub(p.downloadStart)), 1)
+}
+
+// MergeDownloadDigest adds this client's segment download time distribution
+// (nanoseconds) to d, for swarm-wide percentiles.
+func (p *DebugEventParser) MergeDownloadDigest(d *tdigest.TDigest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d.AddCentroidList(p.downloadDigest.Centroids())
+}
+
+// recordSegmentURL counts a completed segment download by URL and keeps the
+// slowest ones. Called with p.mu held.
+//
+// Counts are exact. Once maxTrackedSegmentURLs are tracked, a new URL
+// replaces the least recently downloaded one: a live segment that has left
+// the playlist window isn't fetched again, while the ones still in it are.
+// A URL dropped and later fetched again (a looping VOD) starts over, so a
+// count can run low but never high.
+func (p *DebugEventParser) recordSegmentURL(url string, wallTime time.Duration) {
+	if _, ok := p.segmentURLCounts[url]; !ok && len(p.segmentURLCounts) >= maxTrackedSegmentURLs {
+		oldestURL, oldest := "", int64(-1)
+		for u, seq := range p.segmentURLLast {
+			if oldest < 0 || seq < oldest {
+				oldestURL, oldest = u, seq
+			}
+		}
+		delete(p.segmentURLCounts, oldestURL)
+		delete(p.segmentURLLast, oldestURL)
+	}
+	p.segmentURLCounts[url]++
+	p.segmentURLSeq++
+	p.segmentURLLast[url] = p.segmentURLSeq
+
+	if shard := segmentShard(url); shard != "" {
+		if _, ok := p.shardSegments[shard]; !ok && len(p.shardSegments) >= maxTrackedShards {
+			shard = OtherShards
+		}
+		p.shardSegments[shard]++
+	}
+
+	n := len(p.slowestSegments)
+	if n == slowestSegmentsKept && wallTime <= p.slowestSegments[n-1].WallTime {
+		return
+	}
+	i, _ := slices.BinarySearchFunc(p.slowestSegments, wallTime, func(s SegmentTiming, d time.Duration) int {
+		return cmp.Compare(d, s.WallTime) // Descending
+	})
+	p.slowestSegments = slices.Insert(p.slowestSegments, i, SegmentTiming{URL: url, WallTime: wallTime})
+	if len(p.slowestSegments) > slowestSegmentsKept {
+		p.slowestSegments = p.slowestSegments[:slowestSegmentsKept]
+	}
+}
+
+// lock takes mu for a ParseLine handler. Handlers unlock explicitly rather
+// than deferred, so a handler that panics leaves mu locked: lock notes it,
+// for Reset to release.
+func (p *DebugEventParser) lock() {
+	p.mu.Lock()
+	p.parseHeld = true
+}
+
+func (p *DebugEventParser) unlock() {
+	p.parseHeld = false
+	p.mu.Unlock()
+}
+
+// Reset recovers from a line that panicked half-way through ParseLine
+// (see Pipeline.RunParser): it releases mu if the handler left it locked
+// and drops the in-flight request state the line may have left
+// inconsistent. Pending starts count as orphaned; the counters and
+// distributions of completed requests are kept. Must be called on the
+// parsing goroutine.
+func (p *DebugEventParser) Reset() {
+	if !p.parseHeld {
+		p.mu.Lock()
+	}
+	p.parseHeld = false
+	defer p.mu.Unlock()
+
+	p.orphanedPending.Add(int64(len(p.pendingSegments) + len(p.pendingManifests) + len(p.pendingTCPConnect)))
+	clear(p.pendingSegments)
+	clear(p.pendingManifests)
+	clear(p.pendingTCPConnect)
+	clear(p.pendingHTTPOpen)
+	p.downloadURL = ""
+	p.downloadStart = time.Time{}
+	p.downloadEnd = time.Time{}
+	p.lastConnectAt, p.lastConnect = time.Time{}, 0
+	p.discontinuityAt = time.Time{}
+	p.resp = responseState{}
+}
+
+// DropSamples frees the sample ring buffers and stops filling them, to
+// save memory (see the -max-memory guard). Counts, sums and the digests,
+// which the percentiles come from, are unaffected.
+func (p *DebugEventParser) DropSamples() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.samplesDropped = true
+	p.segmentWallTimes, p.segmentWallTimeP0 = nil, 0
+	p.manifestWallTimes, p.manifestWallTimeP0 = nil, 0
+	p.tcpConnectSamples, p.tcpConnectP0 = nil, 0
+}
+
+// addSample stores d in the defaultRingSize ring at *pos, overwriting the
+// oldest sample once it is full. Must be called with p.mu held.
+func (p *DebugEventParser) addSample(ring *[]time.Duration, pos *int, d time.Duration) {
+	if p.samplesDropped {
+		return
+	}
+	if len(*ring) < defaultRingSize {
+		*ring = append(*ring, d)
+		return
+	}
+	(*ring)[*pos] = d
+	*pos = (*pos + 1) % defaultRingSize
+}
+
+// expirePending drops pending entries older than pendingTTL. Completion
+// events can go missing (lost lines, aborted requests, a URL that is never
+// requested again), and without expiry these maps would grow for the whole
+// run. Must be called with p.mu held.
+func (p *DebugEventParser) expirePending(now time.Time) {
+	p.lastPendingSweep = now
+	cutoff := now.Add(-pendingTTL)
+
+	var orphaned int64
+	for _, m := range []map[string]time.Time{p.pendingSegments, p.pendingManifests, p.pendingTCPConnect} {
+		for k, t := range m {
+			if t.Before(cutoff) {
+				delete(m, k)
+				orphaned++
+			}
+		}
+	}
+	p.orphanedPending.Add(orphaned)
+
+	// HTTP opens have no completion event, so expiry is their normal end.
+	for k, t := range p.pendingHTTPOpen {
+		if t.Before(cutoff) {
+			delete(p.pendingHTTPOpen, k)
+		}
+	}
+}
+
+// emit passes ev to the callback (if both are set).
+func (p *DebugEventParser) emit(ev *DebugEvent) {
+	if ev != nil && p.callback != nil {
+		p.callback(ev)
+	}
+}
+
 // handleTCPStart is called when TCP connection starts.
 func (p *DebugEventParser) handleTCPStart(now time.Time, ip, portStr string) {
 	port, _ := strconv.Atoi(portStr)
 	key := ip + ":" + portStr
 
-	p.mu.Lock()
+	p.lock()
 	p.pendingTCPConnect[key] = now
-	p.mu.Unlock()
+	p.unlock()
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
@@ -656,15 +1197,16 @@ func (p *DebugEventParser) handleTCPConnected(now time.Time, ip, portStr string)
 
 	p.tcpSuccessCount.Add(1)
 
-	p.mu.Lock()
+	p.lock()
 	if startTime, ok := p.pendingTCPConnect[key]; ok {
 		connectTime := now.Sub(startTime)
 		delete(p.pendingTCPConnect, key)
+		p.lastConnectAt, p.lastConnect = now, connectTime
 
 		// Record TCP connect sample
 		p.recordTCPConnect(connectTime)
 	}
-	p.mu.Unlock()
+	p.unlock()
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
@@ -688,6 +1230,7 @@ func (p *DebugEventParser) handleTCPFailed(now time.Time, reason string) {
 		failReason = "timeout"
 		p.tcpTimeoutCount.Add(1)
 	}
+	p.markFailure(now, "tcp "+failReason)
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
@@ -703,13 +1246,16 @@ func (p *DebugEventParser) handlePlaylistOpen(now time.Time, url string) {
 	p.playlistRefreshes.Add(1)
 
 	// Track manifest download start time
-	p.mu.Lock()
+	p.lock()
 	p.pendingManifests[url] = now
-	p.mu.Unlock()
+	p.endDownload(now) // hls.c reloads only once the current segment is read
+	p.unlock()
 
-	p.mu.Lock()
+	p.lock()
+	ratio := -1.0 // No interval before the first refresh
 	if !p.lastPlaylistRefresh.IsZero() {
 		interval := now.Sub(p.lastPlaylistRefresh)
+		ratio = float64(interval) / float64(p.targetDuration)
 		jitter := interval - p.targetDuration
 
 		// Track jitter sum (signed)
@@ -730,7 +1276,11 @@ func (p *DebugEventParser) handlePlaylistOpen(now time.Time, url string) {
 		}
 	}
 	p.lastPlaylistRefresh = now
-	p.mu.Unlock()
+	p.unlock()
+
+	if p.refreshes != nil {
+		p.refreshes.record(now, ratio)
+	}
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
@@ -743,7 +1293,7 @@ func (p *DebugEventParser) handlePlaylistOpen(now time.Time, url string) {
 
 // handleSequenceChange is called when media sequence changes.
 func (p *DebugEventParser) handleSequenceChange(now time.Time, oldSeq, newSeq int) {
-	p.mu.Lock()
+	p.lock()
 	if p.lastSequence > 0 {
 		expected := p.lastSequence + 1
 		if newSeq != expected {
@@ -752,7 +1302,7 @@ func (p *DebugEventParser) handleSequenceChange(now time.Time, oldSeq, newSeq in
 		}
 	}
 	p.lastSequence = newSeq
-	p.mu.Unlock()
+	p.unlock()
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
@@ -782,9 +1332,15 @@ func (p *DebugEventParser) handleHTTPOpen(now time.Time, url string) {
 	}
 
 	// Track HTTP open for potential timing (from HLS request to HTTP open)
-	p.mu.Lock()
+	p.lock()
 	p.pendingHTTPOpen[url] = now
-	p.mu.Unlock()
+	if host := urlHost(url); host != "" {
+		if _, ok := p.hostOpens[host]; !ok && len(p.hostOpens) >= maxTrackedHosts {
+			host = OtherHosts
+		}
+		p.hostOpens[host]++
+	}
+	p.unlock()
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
@@ -795,10 +1351,45 @@ func (p *DebugEventParser) handleHTTPOpen(now time.Time, url string) {
 	}
 }
 
+// urlHost returns the host[:port] of an absolute URL ("" if not absolute).
+func urlHost(rawURL string) string {
+	_, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return ""
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	host, _, _ = strings.Cut(host, "?")
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:] // Drop userinfo
+	}
+	return host
+}
+
+// segmentShard returns the host and directory of a segment URL, the unit
+// sharded origins spread segments over: "edge3.example.com/live/720p" for
+// "http://edge3.example.com/live/720p/seg17.ts?t=1". "" if the URL has no
+// directory.
+func segmentShard(rawURL string) string {
+	path, _, _ := strings.Cut(rawURL, "?")
+	if _, rest, ok := strings.Cut(path, "://"); ok {
+		_, p, _ := strings.Cut(rest, "/")
+		path = urlHost(rawURL) + "/" + p
+	}
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return ""
+	}
+	return path[:i]
+}
+
 // handleHTTPRequestGET is called for HTTP GET requests.
 // This fires for EVERY HTTP request including keep-alive connections.
 // Critical for tracking segment requests in steady state after initial parsing.
 func (p *DebugEventParser) handleHTTPRequestGET(now time.Time, path string) {
+	p.lock()
+	p.startResponse(now, path)
+	p.unlock()
+
 	// Track segment downloads from HTTP layer
 	// The path is like /seg00001.ts or /stream.m3u8
 	if strings.HasSuffix(path, ".ts") || strings.Contains(path, ".ts?") {
@@ -813,8 +1404,15 @@ func (p *DebugEventParser) handleHTTPRequestGET(now time.Time, path string) {
 // This mirrors handleHLSRequest logic but triggers from HTTP layer.
 // Needed because FFmpeg only logs HLS-specific events during initial playlist parsing.
 func (p *DebugEventParser) trackSegmentFromHTTP(now time.Time, url string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.lock()
+	slow := p.completeSegmentFromHTTP(now, url)
+	p.unlock()
+	p.emit(slow)
+}
+
+// completeSegmentFromHTTP does the work of trackSegmentFromHTTP with p.mu
+// held. It returns a DebugEventSlowRequest to emit once unlocked, or nil.
+func (p *DebugEventParser) completeSegmentFromHTTP(now time.Time, url string) (slow *DebugEvent) {
 
 	// Complete oldest pending segment (if any) before starting new one
 	if len(p.pendingSegments) > 0 {
@@ -842,7 +1440,7 @@ func (p *DebugEventParser) trackSegmentFromHTTP(now time.Time, url string) {
 				// Just update the timestamp and URL to the latest event
 				delete(p.pendingSegments, oldestURL)
 				p.pendingSegments[url] = now
-				return
+				return nil
 			}
 
 			// Different segment - complete the old one
@@ -852,6 +1450,7 @@ func (p *DebugEventParser) trackSegmentFromHTTP(now time.Time, url string) {
 			ns := int64(wallTime)
 			p.segmentCount.Add(1)
 			p.segmentWallTimeSum += ns
+			p.recoverDiscontinuity(now)
 
 			if p.segmentWallTimeMin < 0 || ns < p.segmentWallTimeMin {
 				p.segmentWallTimeMin = ns
@@ -860,18 +1459,17 @@ func (p *DebugEventParser) trackSegmentFromHTTP(now time.Time, url string) {
 				p.segmentWallTimeMax = ns
 			}
 
-			// Ring buffer for wall times
-			if len(p.segmentWallTimes) < defaultRingSize {
-				p.segmentWallTimes = append(p.segmentWallTimes, wallTime)
-			} else {
-				p.segmentWallTimes[p.segmentWallTimeP0] = wallTime
-				p.segmentWallTimeP0 = (p.segmentWallTimeP0 + 1) % defaultRingSize
-			}
+			p.addSample(&p.segmentWallTimes, &p.segmentWallTimeP0, wallTime)
 
 			// Add to T-Digest for percentile calculation
 			p.segmentWallTimeDigestMu.Lock()
 			p.segmentWallTimeDigest.Add(float64(wallTime.Nanoseconds()), 1)
 			p.segmentWallTimeDigestMu.Unlock()
+			p.recordLatency(wallTime)
+			p.checkSLO(wallTime)
+
+			slow = p.checkSlow(SlowRequestSegment, oldestURL, oldestTime, wallTime)
+			p.recordSegmentURL(oldestURL, wallTime)
 
 			// Track segment bytes from scraper (accurate sizes for completed downloads)
 			if p.segmentSizeLookup != nil {
@@ -887,6 +1485,8 @@ func (p *DebugEventParser) trackSegmentFromHTTP(now time.Time, url string) {
 
 	// Start tracking new segment
 	p.pendingSegments[url] = now
+	p.startDownload(now, url)
+	return slow
 }
 
 // handleHTTPError is called when HTTP 4xx/5xx error occurs.
@@ -897,6 +1497,11 @@ func (p *DebugEventParser) handleHTTPError(now time.Time, code int, message stri
 	} else if code >= 500 {
 		p.http5xxCount.Add(1)
 	}
+	if code >= 500 {
+		p.markFailure(now, ReconnectHTTP5xx)
+	} else {
+		p.markFailure(now, ReconnectHTTP4xx)
+	}
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
@@ -908,14 +1513,37 @@ func (p *DebugEventParser) handleHTTPError(now time.Time, code int, message stri
 	}
 }
 
-// handleReconnect is called when FFmpeg attempts reconnection.
-func (p *DebugEventParser) handleReconnect(now time.Time) {
+// handleNetworkError is called on a DNS, reset or TLS failure.
+func (p *DebugEventParser) handleNetworkError(now time.Time, reason string) {
+	switch reason {
+	case "dns":
+		p.markFailure(now, ReconnectDNS)
+	case "reset":
+		p.markFailure(now, ReconnectReadReset)
+	case "tls":
+		p.markFailure(now, ReconnectTLS)
+	}
+	if p.callback != nil {
+		p.callback(&DebugEvent{
+			Type:       DebugEventNetworkError,
+			Timestamp:  now,
+			FailReason: reason,
+		})
+	}
+}
+
+// handleReconnect is called when FFmpeg attempts reconnection; readErr
+// is the error of a failed body read ("" otherwise).
+func (p *DebugEventParser) handleReconnect(now time.Time, readErr string) {
 	p.reconnectCount.Add(1)
+	cause := p.reconnectCause(now, readErr)
 
 	if p.callback != nil {
 		p.callback(&DebugEvent{
 			Type:      DebugEventReconnect,
 			Timestamp: now,
+			Cause:     cause,
+			ErrorMsg:  readErr,
 		})
 	}
 }
@@ -974,6 +1602,98 @@ func (p *DebugEventParser) handleSegmentsExpired(now time.Time, skipCount int) {
 	}
 }
 
+// handleDiscontinuity is called when FFmpeg reports a timestamp discontinuity.
+// FFmpeg logs one line per stream (audio and video), so lines arriving while
+// a recovery is already pending belong to the same discontinuity.
+func (p *DebugEventParser) handleDiscontinuity(now time.Time) {
+	p.lock()
+	if !p.discontinuityAt.IsZero() {
+		p.unlock()
+		return
+	}
+	p.discontinuityAt = now
+	p.unlock()
+
+	p.discontinuityCount.Add(1)
+
+	if p.callback != nil {
+		p.callback(&DebugEvent{
+			Type:      DebugEventDiscontinuity,
+			Timestamp: now,
+		})
+	}
+}
+
+// recoverDiscontinuity records the recovery time if a discontinuity is pending.
+// Called on every segment completion. MUST be called with mu held.
+func (p *DebugEventParser) recoverDiscontinuity(now time.Time) {
+	if p.discontinuityAt.IsZero() {
+		return
+	}
+	ns := int64(now.Sub(p.discontinuityAt))
+	p.discontinuityAt = time.Time{}
+	if ns < 0 {
+		return // Mixed timestamp sources; not a meaningful sample
+	}
+	p.recoveryCount++
+	p.recoverySum += ns
+	if ns > p.recoveryMax {
+		p.recoveryMax = ns
+	}
+}
+
+// handleAdMarker is called for each SCTE-35 cue tag in a parsed playlist.
+// A tag seen again within 3× target duration is a playlist refresh repeating
+// it, not a new marker.
+func (p *DebugEventParser) handleAdMarker(now time.Time, tag, marker string) {
+	isOut := false
+	switch tag {
+	case "#EXT-X-CUE-OUT-CONT":
+		return // Progress within a break, no new information
+	case "#EXT-X-CUE-OUT":
+		isOut = true
+	case "#EXT-X-DATERANGE":
+		switch {
+		case strings.Contains(marker, "SCTE35-OUT"):
+			isOut = true
+		case !strings.Contains(marker, "SCTE35-IN"):
+			return // Not an ad marker (program boundary, interstitial, ...)
+		}
+	}
+
+	repeatWindow := 3 * p.targetDuration
+
+	p.lock()
+	last, seen := p.adMarkersSeen[marker]
+	p.adMarkersSeen[marker] = now
+	if len(p.adMarkersSeen) > 64 {
+		// Bound memory: forget markers that have left the window
+		for m, t := range p.adMarkersSeen {
+			if now.Sub(t) > repeatWindow {
+				delete(p.adMarkersSeen, m)
+			}
+		}
+	}
+	p.unlock()
+
+	if seen && now.Sub(last) <= repeatWindow {
+		return
+	}
+
+	p.adMarkerCount.Add(1)
+	if isOut {
+		p.adBreakCount.Add(1)
+	}
+
+	if p.callback != nil {
+		p.callback(&DebugEvent{
+			Type:      DebugEventAdMarker,
+			Timestamp: now,
+			Marker:    marker,
+		})
+	}
+}
+
 // recordTCPConnect records a TCP connect time sample.
 // MUST be called with mu held.
 func (p *DebugEventParser) recordTCPConnect(d time.Duration) {
@@ -988,13 +1708,7 @@ func (p *DebugEventParser) recordTCPConnect(d time.Duration) {
 		p.tcpConnectMax = ns
 	}
 
-	// Ring buffer
-	if len(p.tcpConnectSamples) < defaultRingSize {
-		p.tcpConnectSamples = append(p.tcpConnectSamples, d)
-	} else {
-		p.tcpConnectSamples[p.tcpConnectP0] = d
-		p.tcpConnectP0 = (p.tcpConnectP0 + 1) % defaultRingSize
-	}
+	p.addSample(&p.tcpConnectSamples, &p.tcpConnectP0, d)
 }
 
 // CompleteSegment records segment wall time when segment download completes.
@@ -1022,6 +1736,7 @@ func (p *DebugEventParser) CompleteSegmentWithTimestamp(url string, endTime time
 		ns := int64(wallTime)
 		p.segmentCount.Add(1)
 		p.segmentWallTimeSum += ns
+		p.recoverDiscontinuity(endTime)
 
 		if p.segmentWallTimeMin < 0 || ns < p.segmentWallTimeMin {
 			p.segmentWallTimeMin = ns
@@ -1030,18 +1745,14 @@ func (p *DebugEventParser) CompleteSegmentWithTimestamp(url string, endTime time
 			p.segmentWallTimeMax = ns
 		}
 
-		// Ring buffer
-		if len(p.segmentWallTimes) < defaultRingSize {
-			p.segmentWallTimes = append(p.segmentWallTimes, wallTime)
-		} else {
-			p.segmentWallTimes[p.segmentWallTimeP0] = wallTime
-			p.segmentWallTimeP0 = (p.segmentWallTimeP0 + 1) % defaultRingSize
-		}
+		p.addSample(&p.segmentWallTimes, &p.segmentWallTimeP0, wallTime)
 
 		// Add to T-Digest for percentile calculation (using accurate timestamps)
 		p.segmentWallTimeDigestMu.Lock()
 		p.segmentWallTimeDigest.Add(float64(wallTime.Nanoseconds()), 1)
 		p.segmentWallTimeDigestMu.Unlock()
+		p.recordLatency(wallTime)
+		p.checkSLO(wallTime)
 	}
 }
 
@@ -1055,6 +1766,10 @@ type DebugStats struct {
 	// When 0, timing is based on wall clock (may have channel delay)
 	TimestampsUsed int64
 
+	// ClockSkew is the estimated offset of FFmpeg's timestamps from the
+	// swarm's clock, added to them before use (0 when TimestampsUsed is 0)
+	ClockSkew time.Duration
+
 	// Manifest bandwidth (bits per second)
 	ManifestBandwidth int64
 
@@ -1104,6 +1819,26 @@ type DebugStats struct {
 	// Sequence tracking
 	SequenceSkips int64
 
+	// Discontinuities and ad insertion
+	DiscontinuityCount   int64   // Timestamp discontinuities (one per crossing)
+	DiscontinuityPending bool    // Waiting for the first segment after a discontinuity
+	RecoveryCount        int64   // Discontinuities followed by a completed segment
+	RecoveryAvgMs        float64 // Discontinuity -> next segment complete
+	RecoveryMaxMs        float64
+	AdMarkerCount        int64 // Distinct SCTE-35 cue tags seen
+	AdBreakCount         int64 // Of which CUE-OUT / SCTE35-OUT
+
+	// Downloads slower than the -slow-request-log threshold
+	SlowSegmentCount  int64
+	SlowManifestCount int64
+
+	// Segments at or over the -slo threshold
+	SLOMissCount int64
+
+	// Pending request maps (leak detection)
+	PendingEntries  int   // Started requests awaiting completion, all maps
+	OrphanedPending int64 // Entries expired after pendingTTL without completing
+
 	// Error events (critical for load testing)
 	HTTPErrorCount      int64   // Total HTTP 4xx/5xx errors
 	HTTP4xxCount        int64   // Client errors (4xx)
@@ -1117,6 +1852,25 @@ type DebugStats struct {
 
 	// HTTP open count (for request tracking)
 	HTTPOpenCount int64
+	HostOpens     map[string]int64 // By URL host; OtherHosts past the tracking limit
+
+	// Segment download time (transfer only) and the idle gap after it.
+	// Wall time above is roughly download + gap.
+	SegmentDownloadCount int64
+	SegmentDownloadP50   time.Duration
+	SegmentDownloadP95   time.Duration
+	SegmentDownloadP99   time.Duration
+	SegmentGapCount      int64
+	SegmentGapAvgMs      float64
+
+	// Segment hot spots
+	SegmentURLCounts map[string]int64 // Downloads by URL still tracked (see recordSegmentURL)
+	SlowestSegments  []SegmentTiming  // Slowest downloads, slowest first
+	ShardSegments    map[string]int64 // Downloads by host and directory; OtherShards past the tracking limit
+
+	// Responses by serving class (ServedEdgeHit etc.), from their headers
+	// (only logged at FFmpeg's trace level; nil without)
+	Serving map[string]ServingStats
 
 	// Bytes downloaded (from HTTP Content-Length headers)
 	// Critical for live streams where progress total_size=N/A
@@ -1139,6 +1893,7 @@ func (p *DebugEventParser) Stats() DebugStats {
 	stats := DebugStats{
 		LinesProcessed:    p.linesProcessed.Load(),
 		TimestampsUsed:    p.timestampsUsed.Load(),
+		ClockSkew:         p.skew.Offset(),
 		ManifestBandwidth: p.manifestBandwidth.Load(),
 		SegmentCount:      p.segmentCount.Load(),
 		TCPConnectCount:   p.tcpConnectCount.Load(),
@@ -1151,6 +1906,22 @@ func (p *DebugEventParser) Stats() DebugStats {
 		SequenceSkips:     p.sequenceSkips.Load(),
 		ManifestCount:     p.manifestCount.Load(),
 
+		// Discontinuities
+		DiscontinuityCount:   p.discontinuityCount.Load(),
+		DiscontinuityPending: !p.discontinuityAt.IsZero(),
+		RecoveryCount:        p.recoveryCount,
+		AdMarkerCount:        p.adMarkerCount.Load(),
+		AdBreakCount:         p.adBreakCount.Load(),
+
+		// Slow requests
+		SlowSegmentCount:  p.slowSegments.Load(),
+		SlowManifestCount: p.slowManifests.Load(),
+		SLOMissCount:      p.sloMisses.Load(),
+
+		// Pending maps
+		PendingEntries:  len(p.pendingSegments) + len(p.pendingManifests) + len(p.pendingTCPConnect) + len(p.pendingHTTPOpen),
+		OrphanedPending: p.orphanedPending.Load(),
+
 		// Error metrics
 		HTTPErrorCount:      p.httpErrorCount.Load(),
 		HTTP4xxCount:        p.http4xxCount.Load(),
@@ -1167,6 +1938,26 @@ func (p *DebugEventParser) Stats() DebugStats {
 		SegmentSizeLookupSuccesses: p.segmentSizeLookupSuccesses.Load(),
 	}
 
+	if len(p.hostOpens) > 0 {
+		stats.HostOpens = make(map[string]int64, len(p.hostOpens))
+		for host, n := range p.hostOpens {
+			stats.HostOpens[host] = n
+		}
+	}
+	if len(p.shardSegments) > 0 {
+		stats.ShardSegments = maps.Clone(p.shardSegments)
+	}
+	if len(p.serving) > 0 {
+		stats.Serving = maps.Clone(p.serving)
+	}
+	if len(p.segmentURLCounts) > 0 {
+		stats.SegmentURLCounts = make(map[string]int64, len(p.segmentURLCounts))
+		for url, n := range p.segmentURLCounts {
+			stats.SegmentURLCounts[url] = n
+		}
+		stats.SlowestSegments = slices.Clone(p.slowestSegments)
+	}
+
 	// Segment wall time averages
 	if stats.SegmentCount > 0 {
 		stats.SegmentAvgMs = float64(p.segmentWallTimeSum) / float64(stats.SegmentCount) / 1e6
@@ -1187,6 +1978,24 @@ func (p *DebugEventParser) Stats() DebugStats {
 		p.segmentWallTimeDigestMu.Unlock()
 	}
 
+	// Segment download time / gap
+	if p.downloadCount > 0 {
+		stats.SegmentDownloadCount = p.downloadCount
+		stats.SegmentDownloadP50 = time.Duration(p.downloadDigest.Quantile(0.50))
+		stats.SegmentDownloadP95 = time.Duration(p.downloadDigest.Quantile(0.95))
+		stats.SegmentDownloadP99 = time.Duration(p.downloadDigest.Quantile(0.99))
+	}
+	if p.gapCount > 0 {
+		stats.SegmentGapCount = p.gapCount
+		stats.SegmentGapAvgMs = float64(p.gapSum) / float64(p.gapCount) / 1e6
+	}
+
+	// Discontinuity recovery
+	if p.recoveryCount > 0 {
+		stats.RecoveryAvgMs = float64(p.recoverySum) / float64(p.recoveryCount) / 1e6
+		stats.RecoveryMaxMs = float64(p.recoveryMax) / 1e6
+	}
+
 	// TCP connect averages
 	if stats.TCPConnectCount > 0 {
 		stats.TCPConnectAvgMs = float64(p.tcpConnectSum) / float64(stats.TCPConnectCount) / 1e6
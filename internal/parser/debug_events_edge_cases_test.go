@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -310,3 +311,173 @@ func TestDebugEventParser_FastPathOptimization(t *testing.T) {
 		t.Errorf("Irrelevant lines should not generate events, got %d TCP connects", finalStats.TCPConnectCount)
 	}
 }
+
+// ════════════════════════════════════════════════════════════════════════════════
+// Fuzz Tests
+// ════════════════════════════════════════════════════════════════════════════════
+//
+// Run with: go test -fuzz=FuzzDebugEventParser_ParseLine ./internal/parser/...
+//
+// FFmpeg output is untrusted input: servers control URLs and headers that
+// end up in log lines, and lines can be cut, interleaved or hold invalid
+// UTF-8 and NULs. ParseLine must never panic on any of it.
+
+// fuzzSeedLines are real debug lines, one per pattern ParseLine handles.
+var fuzzSeedLines = []string{
+	"2026-01-23 08:44:23.117 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+	"[tcp @ 0x558f5f5ddbc0] Starting connection attempt to 10.177.0.10 port 17080",
+	"[tcp @ 0x558f5f5ddbc0] Successfully connected to 10.177.0.10 port 17080",
+	"[tcp @ 0x558f5f5ddbc0] Connection to tcp://10.177.0.10:17080 failed: Connection refused",
+	"[http @ 0x55c32c0c5800] [debug] Opening 'http://10.177.0.10:17080/seg00002.ts' for reading",
+	"[http @ 0x55c32c0c5800] [debug] request: GET /seg00003.ts HTTP/1.1",
+	"[hls @ 0x55c32c0c5700] [debug] Opening 'http://10.177.0.10:17080/stream.m3u8' for reading",
+	"[hls @ 0x55c32c0c5700] Media sequence change (3 -> 5) reflected in first_timestamp",
+	"[hls @ 0x55c32c0c5700] Format hls probed with size=2048 and score=100",
+	"[http @ 0x55c32c0c5800] HTTP error 503 Service Unavailable",
+	"[hls @ 0x55c32c0c5700] Failed to open segment 17 of playlist 0",
+	"[hls @ 0x55c32c0c5700] Segment 17 of playlist 0 failed too many times, skipping",
+	"[hls @ 0x55c32c0c5700] skipping 3 segments ahead, expired from playlists",
+	"[AVIOContext @ 0x55c32c0d1200] [verbose] Statistics: 1316 bytes read, 0 seeks",
+	"[hls @ 0x55c32c0c5700] [debug] #EXT-X-CUE-OUT:30",
+	"#EXT-X-STREAM-INF:BANDWIDTH=1280000,RESOLUTION=640x360",
+	"[mpegts @ 0x558f5f5e0980] discontinuity detected",
+}
+
+// FuzzDebugEventParser_ParseLine feeds arbitrary lines to a parser that is
+// mid-download, so completion paths run as well as start paths.
+func FuzzDebugEventParser_ParseLine(f *testing.F) {
+	for _, line := range fuzzSeedLines {
+		f.Add(line)
+	}
+	f.Add("")
+	f.Add("2026-01-23 08:44:23.117 ")
+	f.Add("9999-99-99 99:99:99.999 [hls @ 0x0] HLS request for url '', offset 0, playlist 0")
+	f.Add("[hls @ 0x55c32c0c5700] HLS request for url '\x00\xff\xfe', offset 0, playlist 0")
+	f.Add("[http @ 0x55c32c0c5800] HTTP error " + strings.Repeat("9", 40) + " x")
+	f.Add("[http @ 0x55c32c0c5800] [debug] Opening 'http://" + strings.Repeat("a", 10000) + ".ts' for reading")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		p := NewDebugEventParser(1, 2*time.Second, func(*DebugEvent) {})
+		p.SetSlowRequestThreshold(time.Nanosecond)
+		p.ParseLine(fuzzSeedLines[0])
+
+		p.ParseLine(line)
+		p.ParseLine(line) // Second sighting: completion/dedupe paths
+
+		stats := p.Stats()
+		if stats.LinesProcessed != 3 {
+			t.Errorf("LinesProcessed = %d, want 3", stats.LinesProcessed)
+		}
+		if stats.SegmentCount < 0 || stats.HTTPErrorCount < 0 || stats.TCPConnectCount < 0 {
+			t.Errorf("negative counters: %+v", stats)
+		}
+		if stats.ErrorRate < 0 {
+			t.Errorf("ErrorRate = %v, want >= 0", stats.ErrorRate)
+		}
+	})
+}
+
+// TestDebugEventParser_ResetAfterPanic checks that Reset recovers a parser
+// whose handler panicked with the lock held: the lock is released and the
+// half-tracked requests are dropped as orphans.
+func TestDebugEventParser_ResetAfterPanic(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+
+	func() {
+		defer func() { _ = recover() }()
+		p.lock()
+		p.pendingSegments["http://origin/seg1.ts"] = time.Now()
+		p.pendingManifests["http://origin/live.m3u8"] = time.Now()
+		p.downloadURL = "http://origin/seg1.ts"
+		panic("handler bug")
+	}()
+	p.Reset()
+
+	done := make(chan DebugStats)
+	go func() { done <- p.Stats() }()
+	select {
+	case stats := <-done:
+		if stats.OrphanedPending != 2 {
+			t.Errorf("OrphanedPending = %d, want 2", stats.OrphanedPending)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Stats() blocked: Reset left the lock held")
+	}
+	if len(p.pendingSegments) != 0 || len(p.pendingManifests) != 0 || p.downloadURL != "" {
+		t.Error("Reset kept in-flight request state")
+	}
+
+	// A Reset without a panic takes the lock itself, and parsing carries on.
+	p.Reset()
+	p.ParseLine("[hls @ 0x55c32c0c5700] Opening 'http://origin/seg2.ts' for reading")
+	_ = p.Stats()
+}
+
+// TestDebugEventParser_DropSamples checks that dropping the sample rings
+// keeps them empty without losing counts or percentiles.
+func TestDebugEventParser_DropSamples(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+	start := time.Now()
+	complete := func(url string, wallTime time.Duration) {
+		p.mu.Lock()
+		p.pendingSegments[url] = start
+		p.mu.Unlock()
+		p.CompleteSegmentWithTimestamp(url, start.Add(wallTime))
+	}
+
+	complete("http://origin/seg1.ts", 100*time.Millisecond)
+	if len(p.segmentWallTimes) != 1 {
+		t.Fatalf("ring holds %d samples, want 1", len(p.segmentWallTimes))
+	}
+
+	p.DropSamples()
+	complete("http://origin/seg2.ts", 100*time.Millisecond)
+	p.mu.Lock()
+	p.recordTCPConnect(5 * time.Millisecond)
+	p.mu.Unlock()
+
+	if p.segmentWallTimes != nil || p.tcpConnectSamples != nil {
+		t.Error("rings refilled after DropSamples")
+	}
+	stats := p.Stats()
+	if stats.SegmentCount != 2 {
+		t.Errorf("SegmentCount = %d, want 2", stats.SegmentCount)
+	}
+	if stats.SegmentWallTimeP50 != 100*time.Millisecond {
+		t.Errorf("SegmentWallTimeP50 = %v, want 100ms from the digest", stats.SegmentWallTimeP50)
+	}
+}
+
+// FFmpeg prints its local time, so timestamps are parsed in ours: only a
+// host clock or time zone difference is left for the clock skew.
+func TestParseTimestamp_Local(t *testing.T) {
+	ts, _ := parseTimestamp("2026-01-23 08:44:23.117 [hls @ 0x55c32c0c5700] rest")
+	want := time.Date(2026, 1, 23, 8, 44, 23, 117e6, time.Local)
+	if !ts.Equal(want) || ts.Location() != time.Local {
+		t.Errorf("parseTimestamp() = %v, want %v", ts, want)
+	}
+}
+
+// FuzzParseTimestamp checks that parseTimestamp only strips a prefix and
+// leaves lines without a valid timestamp untouched.
+func FuzzParseTimestamp(f *testing.F) {
+	f.Add("2026-01-23 08:44:23.117 [hls @ 0x55c32c0c5700] rest")
+	f.Add("2026-01-23 08:44:23.117")
+	f.Add("2026-13-45 25:61:61.999 [hls @ 0x0] bad date")
+	f.Add("2026-01-23 08:44:23 no millis")
+	f.Add("")
+	f.Add("\x00\xff2026-01-23 08:44:23.117 ")
+
+	f.Fuzz(func(t *testing.T, line string) {
+		ts, rest := parseTimestamp(line)
+		if ts.IsZero() {
+			if rest != line {
+				t.Errorf("no timestamp but line changed: %q -> %q", line, rest)
+			}
+			return
+		}
+		if !strings.HasSuffix(line, rest) || len(rest) >= len(line) {
+			t.Errorf("rest %q is not a stripped suffix of %q", rest, line)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"fmt"
 	"os"
 	"strings"
 	"sync"
@@ -107,6 +108,22 @@ func TestDebugEventParser_RegexPatterns(t *testing.T) {
 // Basic Parser Tests
 // =============================================================================
 
+func TestDebugEventType_String(t *testing.T) {
+	tests := map[DebugEventType]string{
+		DebugEventHLSRequest:   "hls_request",
+		DebugEventPlaylistOpen: "playlist_open",
+		DebugEventHTTPError:    "http_error",
+		DebugEventNetworkError: "network_error",
+		DebugEventType(-1):     "DebugEventType(-1)",
+		DebugEventType(99):     "DebugEventType(99)",
+	}
+	for typ, want := range tests {
+		if got := typ.String(); got != want {
+			t.Errorf("DebugEventType(%d).String() = %q, want %q", int(typ), got, want)
+		}
+	}
+}
+
 func TestNewDebugEventParser(t *testing.T) {
 	p := NewDebugEventParser(42, 2*time.Second, nil)
 
@@ -355,6 +372,34 @@ func TestDebugEventParser_Stats_SequenceSkips(t *testing.T) {
 	}
 }
 
+func TestDebugEventParser_Stats_HostOpens(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+
+	p.ParseLine("[http @ 0x55c32c0c5700] Opening 'http://cdn-a.example.com/live/seg1.ts' for reading")
+	p.ParseLine("[http @ 0x55c32c0c5700] Opening 'http://cdn-a.example.com/live/seg2.ts' for reading")
+	p.ParseLine("[http @ 0x55c32c0c5700] Opening 'https://user:pw@cdn-b.example.com:8443/seg3.ts?t=1' for reading")
+
+	stats := p.Stats()
+	if stats.HostOpens["cdn-a.example.com"] != 2 {
+		t.Errorf("HostOpens[cdn-a] = %d, want 2", stats.HostOpens["cdn-a.example.com"])
+	}
+	if stats.HostOpens["cdn-b.example.com:8443"] != 1 {
+		t.Errorf("HostOpens = %v, want cdn-b.example.com:8443 without userinfo", stats.HostOpens)
+	}
+
+	// Hosts past the limit share one bucket
+	for i := 0; i < maxTrackedHosts+5; i++ {
+		p.ParseLine(fmt.Sprintf("[http @ 0x55c32c0c5700] Opening 'http://edge%d.example.com/seg.ts' for reading", i))
+	}
+	stats = p.Stats()
+	if len(stats.HostOpens) != maxTrackedHosts+1 {
+		t.Errorf("len(HostOpens) = %d, want %d", len(stats.HostOpens), maxTrackedHosts+1)
+	}
+	if stats.HostOpens[OtherHosts] != 7 {
+		t.Errorf("HostOpens[%s] = %d, want 7", OtherHosts, stats.HostOpens[OtherHosts])
+	}
+}
+
 func TestDebugEventParser_Stats_LinesProcessed(t *testing.T) {
 	p := NewDebugEventParser(1, 2*time.Second, nil)
 
@@ -1422,3 +1467,479 @@ func TestDebugEventParser_TrackSegmentFromHTTP_ConcurrentAccess(t *testing.T) {
 	t.Logf("Concurrent test: SegmentCount=%d, HTTPOpenCount=%d, SegmentBytes=%d",
 		stats.SegmentCount, stats.HTTPOpenCount, stats.SegmentBytesDownloaded)
 }
+
+// =============================================================================
+// Discontinuity / Ad Marker Tests
+// =============================================================================
+
+func TestDebugEventParser_Discontinuity_Recovery(t *testing.T) {
+	var events []DebugEventType
+	p := NewDebugEventParser(1, 2*time.Second, func(e *DebugEvent) {
+		events = append(events, e.Type)
+	})
+
+	lines := []string{
+		"2026-01-23 08:12:50.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+		// One line per stream for the same discontinuity
+		"2026-01-23 08:12:51.000 [debug] timestamp discontinuity for stream #0:0 (id=256, type=video): -10800000, new offset= 10800000",
+		"2026-01-23 08:12:51.010 [debug] timestamp discontinuity for stream #0:1 (id=257, type=audio): -10800000, new offset= 10800000",
+		"2026-01-23 08:12:51.500 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00002.ts', offset 0, playlist 0",
+	}
+	for _, line := range lines {
+		p.ParseLine(line)
+	}
+
+	stats := p.Stats()
+	if stats.DiscontinuityCount != 1 {
+		t.Errorf("DiscontinuityCount = %d, want 1", stats.DiscontinuityCount)
+	}
+	if stats.RecoveryCount != 1 {
+		t.Fatalf("RecoveryCount = %d, want 1", stats.RecoveryCount)
+	}
+	if stats.RecoveryAvgMs != 500 || stats.RecoveryMaxMs != 500 {
+		t.Errorf("Recovery avg/max = %.1f/%.1f ms, want 500/500", stats.RecoveryAvgMs, stats.RecoveryMaxMs)
+	}
+	if stats.DiscontinuityPending {
+		t.Error("DiscontinuityPending = true after recovery")
+	}
+
+	discontinuityEvents := 0
+	for _, e := range events {
+		if e == DebugEventDiscontinuity {
+			discontinuityEvents++
+		}
+	}
+	if discontinuityEvents != 1 {
+		t.Errorf("discontinuity events = %d, want 1", discontinuityEvents)
+	}
+}
+
+func TestDebugEventParser_SlowRequests(t *testing.T) {
+	var slow []*DebugEvent
+	p := NewDebugEventParser(1, 2*time.Second, func(e *DebugEvent) {
+		if e.Type == DebugEventSlowRequest {
+			slow = append(slow, e)
+		}
+	})
+	p.SetSlowRequestThreshold(time.Second)
+
+	lines := []string{
+		// Manifest: 1.2s (slow)
+		"2026-01-23 08:12:50.000 [hls @ 0x55c32c0c5700] [debug] Opening 'http://10.177.0.10:17080/stream.m3u8' for reading",
+		"2026-01-23 08:12:51.200 [hls @ 0x55c32c0c5700] [debug] Format hls probed with size=2048 and score=100",
+		// Segments: 300ms, then 1.5s (slow), completed by the next request
+		"2026-01-23 08:12:51.300 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+		"2026-01-23 08:12:51.600 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00002.ts', offset 0, playlist 0",
+		"2026-01-23 08:12:53.100 [http @ 0x55c32c0c5700] [debug] Opening 'http://10.177.0.10:17080/seg00003.ts' for reading",
+	}
+	for _, line := range lines {
+		p.ParseLine(line)
+	}
+
+	stats := p.Stats()
+	if stats.SlowSegmentCount != 1 || stats.SlowManifestCount != 1 {
+		t.Errorf("slow segments/manifests = %d/%d, want 1/1", stats.SlowSegmentCount, stats.SlowManifestCount)
+	}
+	if len(slow) != 2 {
+		t.Fatalf("slow request events = %d, want 2", len(slow))
+	}
+	if slow[0].Kind != SlowRequestManifest || slow[0].WallTime != 1200*time.Millisecond {
+		t.Errorf("first slow event = %s %v, want manifest 1.2s", slow[0].Kind, slow[0].WallTime)
+	}
+	if slow[1].Kind != SlowRequestSegment || slow[1].WallTime != 1500*time.Millisecond ||
+		slow[1].URL != "http://10.177.0.10:17080/seg00002.ts" {
+		t.Errorf("second slow event = %s %s %v, want segment seg00002.ts 1.5s", slow[1].Kind, slow[1].URL, slow[1].WallTime)
+	}
+	if slow[0].Transfer != 1200*time.Millisecond || slow[0].Connect != 0 || slow[0].Idle != 0 {
+		t.Errorf("manifest stages = %v/%v/%v, want 0s/1.2s/0s", slow[0].Connect, slow[0].Transfer, slow[0].Idle)
+	}
+}
+
+func TestDebugEventParser_SlowRequestStages(t *testing.T) {
+	var slow []*DebugEvent
+	p := NewDebugEventParser(1, 2*time.Second, func(e *DebugEvent) {
+		if e.Type == DebugEventSlowRequest {
+			slow = append(slow, e)
+		}
+	})
+	p.SetSlowRequestThreshold(time.Second)
+
+	lines := []string{
+		// seg00001.ts: 200ms connect, transfer ends at 1.0s, next request at 1.5s
+		"2026-01-23 08:12:50.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+		"2026-01-23 08:12:50.000 [tcp @ 0x55c32c0d7800] [verbose] Starting connection attempt to 10.177.0.10 port 17080",
+		"2026-01-23 08:12:50.200 [tcp @ 0x55c32c0d7800] [verbose] Successfully connected to 10.177.0.10 port 17080",
+		"2026-01-23 08:12:51.000 [AVIOContext @ 0x55c32c0d1200] [verbose] Statistics: 1316 bytes read, 0 seeks",
+		"2026-01-23 08:12:51.500 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00002.ts', offset 0, playlist 0",
+		// seg00002.ts: reused connection, transfer end not seen
+		"2026-01-23 08:12:52.700 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00003.ts', offset 0, playlist 0",
+	}
+	for _, line := range lines {
+		p.ParseLine(line)
+	}
+
+	if len(slow) != 2 {
+		t.Fatalf("slow request events = %d, want 2", len(slow))
+	}
+	tests := []struct {
+		connect, transfer, idle time.Duration
+	}{
+		{200 * time.Millisecond, 800 * time.Millisecond, 500 * time.Millisecond},
+		{0, 1200 * time.Millisecond, 0},
+	}
+	for i, tt := range tests {
+		e := slow[i]
+		if e.Connect != tt.connect || e.Transfer != tt.transfer || e.Idle != tt.idle {
+			t.Errorf("%s stages = %v/%v/%v, want %v/%v/%v", e.URL, e.Connect, e.Transfer, e.Idle, tt.connect, tt.transfer, tt.idle)
+		}
+	}
+}
+
+func TestDebugEventParser_SLOMisses(t *testing.T) {
+	totals := &DebugTotals{}
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+	p.SetTotals(totals)
+	p.SetSLOThreshold(time.Second)
+
+	lines := []string{
+		// Segments: 300ms, 1s (a miss: the threshold is exclusive), then
+		// 1.5s (a miss), each completed by the next request
+		"2026-01-23 08:12:51.300 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+		"2026-01-23 08:12:51.600 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00002.ts', offset 0, playlist 0",
+		"2026-01-23 08:12:52.600 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00003.ts', offset 0, playlist 0",
+		"2026-01-23 08:12:54.100 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00004.ts', offset 0, playlist 0",
+	}
+	for _, line := range lines {
+		p.ParseLine(line)
+	}
+
+	if stats := p.Stats(); stats.SegmentCount != 3 || stats.SLOMissCount != 2 {
+		t.Errorf("segments/SLO misses = %d/%d, want 3/2", stats.SegmentCount, stats.SLOMissCount)
+	}
+	if got := totals.Stats().SLOMissCount; got != 2 {
+		t.Errorf("total SLO misses = %d, want 2", got)
+	}
+}
+
+func TestDebugEventParser_SegmentHotSpots(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+
+	// 15 distinct segments with increasing wall times, plus seg00001.ts
+	// downloaded again at the end
+	p.mu.Lock()
+	for i := 1; i <= 15; i++ {
+		p.recordSegmentURL(fmt.Sprintf("http://cdn/seg%05d.ts", i), time.Duration(i)*100*time.Millisecond)
+	}
+	p.recordSegmentURL("http://cdn/seg00001.ts", 50*time.Millisecond)
+	p.mu.Unlock()
+
+	stats := p.Stats()
+	if stats.SegmentURLCounts["http://cdn/seg00001.ts"] != 2 {
+		t.Errorf("seg00001.ts count = %d, want 2", stats.SegmentURLCounts["http://cdn/seg00001.ts"])
+	}
+	if len(stats.SlowestSegments) != slowestSegmentsKept {
+		t.Fatalf("SlowestSegments has %d entries, want %d", len(stats.SlowestSegments), slowestSegmentsKept)
+	}
+	if got := stats.SlowestSegments[0]; got.URL != "http://cdn/seg00015.ts" || got.WallTime != 1500*time.Millisecond {
+		t.Errorf("slowest = %+v, want seg00015.ts 1.5s", got)
+	}
+	if got := stats.SlowestSegments[slowestSegmentsKept-1]; got.URL != "http://cdn/seg00006.ts" {
+		t.Errorf("last kept = %+v, want seg00006.ts", got)
+	}
+}
+
+func TestSegmentShard(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"http://edge3.example.com/live/720p/seg17.ts?t=1", "edge3.example.com/live/720p"},
+		{"https://user:pw@cdn.example.com:8443/seg1.ts", "cdn.example.com:8443"},
+		{"http://cdn/seg1.ts", "cdn"},
+		{"/shard-2/seg1.ts", "/shard-2"},
+		{"seg1.ts", ""},
+		{"/seg1.ts", ""},
+	}
+	for _, tt := range tests {
+		if got := segmentShard(tt.url); got != tt.want {
+			t.Errorf("segmentShard(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestDebugEventParser_ShardSegments(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+
+	p.mu.Lock()
+	p.recordSegmentURL("http://edge1.example.com/live/seg1.ts", time.Millisecond)
+	p.recordSegmentURL("http://edge1.example.com/live/seg2.ts", time.Millisecond)
+	p.recordSegmentURL("http://edge2.example.com/live/seg3.ts", time.Millisecond)
+	for i := 0; i < maxTrackedShards+3; i++ {
+		p.recordSegmentURL(fmt.Sprintf("http://cdn/shard%d/seg.ts", i), time.Millisecond)
+	}
+	p.mu.Unlock()
+
+	stats := p.Stats()
+	if stats.ShardSegments["edge1.example.com/live"] != 2 || stats.ShardSegments["edge2.example.com/live"] != 1 {
+		t.Errorf("ShardSegments = %v, want edge1 2, edge2 1", stats.ShardSegments)
+	}
+	// Shards past the limit share one bucket
+	if len(stats.ShardSegments) != maxTrackedShards+1 {
+		t.Errorf("len(ShardSegments) = %d, want %d", len(stats.ShardSegments), maxTrackedShards+1)
+	}
+	if stats.ShardSegments[OtherShards] != 5 {
+		t.Errorf("ShardSegments[%s] = %d, want 5", OtherShards, stats.ShardSegments[OtherShards])
+	}
+}
+
+func TestDebugEventParser_SegmentURLCounts_Bounded(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+
+	// hot.ts is fetched again every 10 live segments; old.ts only first
+	p.mu.Lock()
+	p.recordSegmentURL("http://cdn/old.ts", time.Millisecond)
+	n := 3 * maxTrackedSegmentURLs
+	for i := 0; i < n; i++ {
+		if i%10 == 0 {
+			p.recordSegmentURL("http://cdn/hot.ts", time.Millisecond)
+		}
+		p.recordSegmentURL(fmt.Sprintf("http://cdn/live%05d.ts", i), time.Millisecond)
+	}
+	p.mu.Unlock()
+
+	stats := p.Stats()
+	if len(stats.SegmentURLCounts) != maxTrackedSegmentURLs {
+		t.Errorf("tracking %d URLs, want %d", len(stats.SegmentURLCounts), maxTrackedSegmentURLs)
+	}
+	if got, want := stats.SegmentURLCounts["http://cdn/hot.ts"], int64((n+9)/10); got != want {
+		t.Errorf("hot.ts count = %d, want %d", got, want)
+	}
+	if _, ok := stats.SegmentURLCounts["http://cdn/old.ts"]; ok {
+		t.Error("old.ts still tracked, want it dropped")
+	}
+	for url, count := range stats.SegmentURLCounts {
+		if url != "http://cdn/hot.ts" && count != 1 {
+			t.Errorf("%s count = %d, want 1 (exact, not inherited)", url, count)
+		}
+	}
+	if _, ok := stats.SegmentURLCounts[fmt.Sprintf("http://cdn/live%05d.ts", n-1)]; !ok {
+		t.Error("latest live segment not tracked")
+	}
+}
+
+func TestDebugEventParser_SegmentDownloadTime(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		wantDownload time.Duration
+		wantGapMs    float64
+	}{
+		{
+			name: "input closed (no keep-alive)",
+			lines: []string{
+				"2026-01-23 08:12:50.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+				"2026-01-23 08:12:50.200 [AVIOContext @ 0x55c32c0d1200] [verbose] Statistics: 1316 bytes read, 0 seeks",
+				"2026-01-23 08:12:52.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00002.ts', offset 0, playlist 0",
+			},
+			wantDownload: 200 * time.Millisecond,
+			wantGapMs:    1800,
+		},
+		{
+			name: "playlist reload",
+			lines: []string{
+				"2026-01-23 08:12:50.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+				"2026-01-23 08:12:50.300 [hls @ 0x55c32c0c5700] [debug] Opening 'http://10.177.0.10:17080/stream.m3u8' for reading",
+				"2026-01-23 08:12:50.350 [AVIOContext @ 0x55c32c0d1200] [verbose] Statistics: 512 bytes read, 0 seeks",
+				"2026-01-23 08:12:52.000 [http @ 0x55c32c0c5800] [debug] request: GET /seg00002.ts HTTP/1.1",
+			},
+			wantDownload: 300 * time.Millisecond,
+			wantGapMs:    1700,
+		},
+		{
+			name: "next request only",
+			lines: []string{
+				"2026-01-23 08:12:50.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0",
+				"2026-01-23 08:12:50.010 [http @ 0x55c32c0c5800] [debug] Opening 'http://10.177.0.10:17080/seg00001.ts' for reading",
+				"2026-01-23 08:12:50.400 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00002.ts', offset 0, playlist 0",
+			},
+			wantDownload: 400 * time.Millisecond,
+			wantGapMs:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewDebugEventParser(1, 2*time.Second, nil)
+			for _, line := range tt.lines {
+				p.ParseLine(line)
+			}
+
+			stats := p.Stats()
+			if stats.SegmentDownloadCount != 1 {
+				t.Fatalf("SegmentDownloadCount = %d, want 1", stats.SegmentDownloadCount)
+			}
+			if stats.SegmentDownloadP50 != tt.wantDownload {
+				t.Errorf("SegmentDownloadP50 = %v, want %v", stats.SegmentDownloadP50, tt.wantDownload)
+			}
+			if stats.SegmentGapAvgMs != tt.wantGapMs {
+				t.Errorf("SegmentGapAvgMs = %v, want %v", stats.SegmentGapAvgMs, tt.wantGapMs)
+			}
+		})
+	}
+}
+
+func TestDebugEventParser_PendingExpiry(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+
+	// Requests whose completion events never arrive
+	p.ParseLine("2026-01-23 08:00:00.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0")
+	p.ParseLine("2026-01-23 08:00:00.000 [hls @ 0x55c32c0c5700] [debug] Opening 'http://10.177.0.10:17080/stream.m3u8' for reading")
+	p.ParseLine("2026-01-23 08:00:00.000 [tcp @ 0x55c32c0c5800] [verbose] Starting connection attempt to 10.177.0.10 port 17080")
+
+	stats := p.Stats()
+	if stats.PendingEntries != 3 {
+		t.Fatalf("PendingEntries = %d, want 3", stats.PendingEntries)
+	}
+
+	// Still within pendingTTL: nothing expires
+	p.ParseLine("2026-01-23 08:01:00.000 [hls @ 0x55c32c0c5700] [debug] Opening 'http://10.177.0.10:17080/live.m3u8' for reading")
+	if stats := p.Stats(); stats.OrphanedPending != 0 || stats.PendingEntries != 4 {
+		t.Fatalf("at +1m: orphaned %d, pending %d; want 0, 4", stats.OrphanedPending, stats.PendingEntries)
+	}
+
+	// Past pendingTTL for the first three
+	p.ParseLine("2026-01-23 08:02:30.000 [hls @ 0x55c32c0c5700] [debug] Opening 'http://10.177.0.10:17080/live.m3u8' for reading")
+	stats = p.Stats()
+	if stats.OrphanedPending != 3 {
+		t.Errorf("OrphanedPending = %d, want 3", stats.OrphanedPending)
+	}
+	if stats.PendingEntries != 1 {
+		t.Errorf("PendingEntries = %d, want 1 (latest manifest)", stats.PendingEntries)
+	}
+}
+
+func TestDebugEventParser_SlowRequests_Disabled(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, func(e *DebugEvent) {
+		if e.Type == DebugEventSlowRequest {
+			t.Error("slow request event without a threshold")
+		}
+	})
+	p.ParseLine("2026-01-23 08:12:50.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00001.ts', offset 0, playlist 0")
+	p.ParseLine("2026-01-23 08:13:50.000 [hls @ 0x55c32c0c5700] [debug] HLS request for url 'http://10.177.0.10:17080/seg00002.ts', offset 0, playlist 0")
+
+	if stats := p.Stats(); stats.SlowSegmentCount != 0 {
+		t.Errorf("SlowSegmentCount = %d, want 0", stats.SlowSegmentCount)
+	}
+}
+
+func TestDebugEventParser_Discontinuity_OldFormat(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+	p.ParseLine("timestamp discontinuity -95443717689, new offset= 95443717689")
+
+	stats := p.Stats()
+	if stats.DiscontinuityCount != 1 {
+		t.Errorf("DiscontinuityCount = %d, want 1", stats.DiscontinuityCount)
+	}
+	if !stats.DiscontinuityPending {
+		t.Error("DiscontinuityPending = false, want true before any segment completes")
+	}
+}
+
+func TestDebugEventParser_AdMarkers(t *testing.T) {
+	var markers []string
+	p := NewDebugEventParser(1, 2*time.Second, func(e *DebugEvent) {
+		if e.Type == DebugEventAdMarker {
+			markers = append(markers, e.Marker)
+		}
+	})
+
+	refresh := func(ts string, tags ...string) {
+		for _, tag := range tags {
+			p.ParseLine(ts + " [hls @ 0x55c32c0c5700] [verbose] Skip ('" + tag + "')")
+		}
+	}
+
+	// Break starts, stays in the window for two refreshes, then ends
+	refresh("2026-01-23 08:12:50.000", "#EXT-X-CUE-OUT:DURATION=30")
+	refresh("2026-01-23 08:12:52.000", "#EXT-X-CUE-OUT:DURATION=30", "#EXT-X-CUE-OUT-CONT:ELAPSED=2,DURATION=30")
+	refresh("2026-01-23 08:12:54.000", "#EXT-X-CUE-OUT:DURATION=30", "#EXT-X-CUE-IN")
+
+	// DATERANGE: only SCTE-35 ones count
+	refresh("2026-01-23 08:12:56.000",
+		`#EXT-X-DATERANGE:ID="ad2",START-DATE="2026-01-23T08:13:22Z",SCTE35-OUT=0xFC30`,
+		`#EXT-X-DATERANGE:ID="prog",START-DATE="2026-01-23T08:13:22Z",CLASS="program"`)
+
+	// Same CUE-OUT text well after it left the window is a new break
+	refresh("2026-01-23 08:20:00.000", "#EXT-X-CUE-OUT:DURATION=30")
+
+	stats := p.Stats()
+	if stats.AdMarkerCount != 4 {
+		t.Errorf("AdMarkerCount = %d, want 4 (markers: %v)", stats.AdMarkerCount, markers)
+	}
+	if stats.AdBreakCount != 3 {
+		t.Errorf("AdBreakCount = %d, want 3", stats.AdBreakCount)
+	}
+}
+
+func TestDebugEventParser_AdMarkers_Bounded(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+	start := time.Date(2026, 1, 23, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 500; i++ {
+		ts := start.Add(time.Duration(i) * 10 * time.Second).Format(timestampLayout)
+		p.ParseLine(ts + ` [hls @ 0x55c32c0c5700] Skip ('#EXT-X-DATERANGE:ID="ad` + strings.Repeat("x", i%7) + time.Duration(i).String() + `",SCTE35-OUT=0xFC')`)
+	}
+
+	p.mu.Lock()
+	n := len(p.adMarkersSeen)
+	p.mu.Unlock()
+	if n > 65 {
+		t.Errorf("adMarkersSeen has %d entries, want <= 65", n)
+	}
+	if got := p.Stats().AdMarkerCount; got != 500 {
+		t.Errorf("AdMarkerCount = %d, want 500", got)
+	}
+}
+
+func TestDebugEventParser_FailureReasons(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantType   DebugEventType
+		wantReason string // Empty = no failure event
+	}{
+		{"tcp refused", "[tcp @ 0x55f8] Connection refused", DebugEventTCPFailed, "refused"},
+		{"tcp timeout", "[tcp @ 0x55f8] Connection timed out", DebugEventTCPFailed, "timeout"},
+		{"refused after match", "[tcp @ 0x55f8] Connection attempt to 10.177.0.10 port 17080 failed: Connection refused", DebugEventTCPFailed, "refused"},
+		{"tcp other", "[tcp @ 0x55f8] Failed to connect", DebugEventTCPFailed, "error"},
+		{"dns", "[tcp @ 0x55f8] [error] Failed to resolve hostname origin.example: Name or service not known", DebugEventNetworkError, "dns"},
+		{"reset tagged", "[http @ 0x55f8] [error] Connection reset by peer", DebugEventNetworkError, "reset"},
+		{"reset final line", "http://origin/seg00001.ts: Connection reset by peer", DebugEventNetworkError, "reset"},
+		{"tls cert", "[tls @ 0x55f8] [error] error:0A000086:SSL routines::certificate verify failed", DebugEventNetworkError, "tls"},
+		{"tls handshake", "[tls @ 0x55f8] Error during TLS handshake", DebugEventNetworkError, "tls"},
+		{"tls pull is not tls", "[tls @ 0x55f8] Error in the pull function.", DebugEventNetworkError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *DebugEvent
+			p := NewDebugEventParser(1, 2*time.Second, func(ev *DebugEvent) {
+				if ev.Type == DebugEventTCPFailed || ev.Type == DebugEventNetworkError {
+					got = ev
+				}
+			})
+			p.ParseLine(tt.line)
+
+			if tt.wantReason == "" {
+				if got != nil {
+					t.Fatalf("got %v/%q, want no failure event", got.Type, got.FailReason)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("no failure event")
+			}
+			if got.Type != tt.wantType || got.FailReason != tt.wantReason {
+				t.Errorf("got %v/%q, want %v/%q", got.Type, got.FailReason, tt.wantType, tt.wantReason)
+			}
+		})
+	}
+}
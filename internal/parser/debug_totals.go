@@ -0,0 +1,156 @@
+package parser
+
+import "sync/atomic"
+
+// sharedCounter is a per-parser counter that also adds every increment to
+// a swarm-wide running total (see DebugTotals). Load returns the parser's
+// own count.
+type sharedCounter struct {
+	n     atomic.Int64
+	total *atomic.Int64 // nil until SetTotals
+}
+
+// Add adds delta to the counter and its running total, returning the new
+// per-parser value.
+func (c *sharedCounter) Add(delta int64) int64 {
+	if c.total != nil {
+		c.total.Add(delta)
+	}
+	return c.n.Add(delta)
+}
+
+// Load returns the per-parser value.
+func (c *sharedCounter) Load() int64 {
+	return c.n.Load()
+}
+
+// DebugTotals holds running totals of the DebugEventParser counters across
+// every parser attached with SetTotals. Parsers push each increment as it
+// happens, so reading swarm-wide counts costs the same at 2000 clients as
+// at one, instead of a locked Stats() call per client.
+//
+// Only counters are pushed. Latency distributions, averages and hot spot
+// lists are still merged from each parser.
+type DebugTotals struct {
+	linesProcessed  atomic.Int64
+	timestampsUsed  atomic.Int64
+	orphanedPending atomic.Int64
+
+	// HLS layer
+	segmentCount        atomic.Int64
+	segmentFailedCount  atomic.Int64
+	segmentSkippedCount atomic.Int64
+	segmentsExpiredSum  atomic.Int64
+	manifestCount       atomic.Int64
+	playlistRefreshes   atomic.Int64
+	playlistFailedCount atomic.Int64
+	playlistLateCount   atomic.Int64
+	sequenceSkips       atomic.Int64
+	discontinuityCount  atomic.Int64
+	adMarkerCount       atomic.Int64
+	adBreakCount        atomic.Int64
+	slowSegments        atomic.Int64
+	slowManifests       atomic.Int64
+	sloMisses           atomic.Int64
+
+	// HTTP layer
+	httpOpenCount  atomic.Int64
+	http4xxCount   atomic.Int64
+	http5xxCount   atomic.Int64
+	reconnectCount atomic.Int64
+
+	// TCP layer
+	tcpConnectCount atomic.Int64
+	tcpSuccessCount atomic.Int64
+	tcpTimeoutCount atomic.Int64
+	tcpRefusedCount atomic.Int64
+
+	// Segment bytes (segment size lookup)
+	segmentBytesDownloaded     atomic.Int64
+	segmentSizeLookupAttempts  atomic.Int64
+	segmentSizeLookupSuccesses atomic.Int64
+}
+
+// SegmentBytesDownloaded returns the bytes of completed segment downloads
+// across all attached parsers.
+func (t *DebugTotals) SegmentBytesDownloaded() int64 {
+	return t.segmentBytesDownloaded.Load()
+}
+
+// Stats returns the running totals in the DebugStats counter fields.
+// Fields that are not counters (averages, percentiles, maps) are zero.
+func (t *DebugTotals) Stats() DebugStats {
+	return DebugStats{
+		LinesProcessed:  t.linesProcessed.Load(),
+		TimestampsUsed:  t.timestampsUsed.Load(),
+		OrphanedPending: t.orphanedPending.Load(),
+
+		SegmentCount:        t.segmentCount.Load(),
+		SegmentFailedCount:  t.segmentFailedCount.Load(),
+		SegmentSkippedCount: t.segmentSkippedCount.Load(),
+		SegmentsExpiredSum:  t.segmentsExpiredSum.Load(),
+		ManifestCount:       t.manifestCount.Load(),
+		PlaylistRefreshes:   t.playlistRefreshes.Load(),
+		PlaylistFailedCount: t.playlistFailedCount.Load(),
+		PlaylistLateCount:   t.playlistLateCount.Load(),
+		SequenceSkips:       t.sequenceSkips.Load(),
+		DiscontinuityCount:  t.discontinuityCount.Load(),
+		AdMarkerCount:       t.adMarkerCount.Load(),
+		AdBreakCount:        t.adBreakCount.Load(),
+		SlowSegmentCount:    t.slowSegments.Load(),
+		SlowManifestCount:   t.slowManifests.Load(),
+		SLOMissCount:        t.sloMisses.Load(),
+
+		HTTPOpenCount:  t.httpOpenCount.Load(),
+		HTTP4xxCount:   t.http4xxCount.Load(),
+		HTTP5xxCount:   t.http5xxCount.Load(),
+		ReconnectCount: t.reconnectCount.Load(),
+
+		TCPConnectCount: t.tcpConnectCount.Load(),
+		TCPSuccessCount: t.tcpSuccessCount.Load(),
+		TCPTimeoutCount: t.tcpTimeoutCount.Load(),
+		TCPRefusedCount: t.tcpRefusedCount.Load(),
+
+		SegmentBytesDownloaded:     t.segmentBytesDownloaded.Load(),
+		SegmentSizeLookupAttempts:  t.segmentSizeLookupAttempts.Load(),
+		SegmentSizeLookupSuccesses: t.segmentSizeLookupSuccesses.Load(),
+	}
+}
+
+// SetTotals attaches the parser to swarm-wide running totals: from now on
+// every counter increment is also added to t. Call before parsing starts.
+func (p *DebugEventParser) SetTotals(t *DebugTotals) {
+	p.linesProcessed.total = &t.linesProcessed
+	p.timestampsUsed.total = &t.timestampsUsed
+	p.orphanedPending.total = &t.orphanedPending
+
+	p.segmentCount.total = &t.segmentCount
+	p.segmentFailedCount.total = &t.segmentFailedCount
+	p.segmentSkippedCount.total = &t.segmentSkippedCount
+	p.segmentsExpiredSum.total = &t.segmentsExpiredSum
+	p.manifestCount.total = &t.manifestCount
+	p.playlistRefreshes.total = &t.playlistRefreshes
+	p.playlistFailedCount.total = &t.playlistFailedCount
+	p.playlistLateCount.total = &t.playlistLateCount
+	p.sequenceSkips.total = &t.sequenceSkips
+	p.discontinuityCount.total = &t.discontinuityCount
+	p.adMarkerCount.total = &t.adMarkerCount
+	p.adBreakCount.total = &t.adBreakCount
+	p.slowSegments.total = &t.slowSegments
+	p.slowManifests.total = &t.slowManifests
+	p.sloMisses.total = &t.sloMisses
+
+	p.httpOpenCount.total = &t.httpOpenCount
+	p.http4xxCount.total = &t.http4xxCount
+	p.http5xxCount.total = &t.http5xxCount
+	p.reconnectCount.total = &t.reconnectCount
+
+	p.tcpConnectCount.total = &t.tcpConnectCount
+	p.tcpSuccessCount.total = &t.tcpSuccessCount
+	p.tcpTimeoutCount.total = &t.tcpTimeoutCount
+	p.tcpRefusedCount.total = &t.tcpRefusedCount
+
+	p.segmentBytesDownloaded.total = &t.segmentBytesDownloaded
+	p.segmentSizeLookupAttempts.total = &t.segmentSizeLookupAttempts
+	p.segmentSizeLookupSuccesses.total = &t.segmentSizeLookupSuccesses
+}
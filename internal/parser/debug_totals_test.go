@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebugTotals(t *testing.T) {
+	var totals DebugTotals
+
+	lines := []string{
+		"[hls @ 0x1] HLS request for url 'http://origin/seg00001.ts', offset 0, playlist 0",
+		"[http @ 0x2] Opening 'http://origin/seg00001.ts' for reading",
+		"[tcp @ 0x3] Starting connection attempt to 10.0.0.1 port 80",
+		"[tcp @ 0x3] Successfully connected to 10.0.0.1 port 80",
+		"[http @ 0x2] HTTP error 503 Service Unavailable",
+	}
+
+	parsers := []*DebugEventParser{
+		NewDebugEventParser(1, 2*time.Second, nil),
+		NewDebugEventParser(2, 2*time.Second, nil),
+	}
+	for _, p := range parsers {
+		p.SetTotals(&totals)
+	}
+	for _, line := range lines {
+		parsers[0].ParseLine(line)
+	}
+	parsers[1].ParseLine(lines[0])
+
+	// A parser that isn't attached doesn't count
+	NewDebugEventParser(3, 2*time.Second, nil).ParseLine(lines[0])
+
+	got := totals.Stats()
+	a, b := parsers[0].Stats(), parsers[1].Stats()
+	for _, c := range []struct {
+		name      string
+		got, a, b int64
+	}{
+		{"LinesProcessed", got.LinesProcessed, a.LinesProcessed, b.LinesProcessed},
+		{"SegmentCount", got.SegmentCount, a.SegmentCount, b.SegmentCount},
+		{"HTTPOpenCount", got.HTTPOpenCount, a.HTTPOpenCount, b.HTTPOpenCount},
+		{"TCPConnectCount", got.TCPConnectCount, a.TCPConnectCount, b.TCPConnectCount},
+		{"TCPSuccessCount", got.TCPSuccessCount, a.TCPSuccessCount, b.TCPSuccessCount},
+		{"HTTP5xxCount", got.HTTP5xxCount, a.HTTP5xxCount, b.HTTP5xxCount},
+	} {
+		if c.got != c.a+c.b {
+			t.Errorf("%s total = %d, want %d + %d", c.name, c.got, c.a, c.b)
+		}
+	}
+	if got.LinesProcessed != int64(len(lines)+1) {
+		t.Errorf("LinesProcessed = %d, want %d", got.LinesProcessed, len(lines)+1)
+	}
+	if got.HTTP5xxCount != 1 || got.TCPSuccessCount != 1 {
+		t.Errorf("totals = %+v, want one 5xx and one TCP success", got)
+	}
+}
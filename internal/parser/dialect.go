@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogDialect is a family of FFmpeg versions whose log lines are read the
+// same way (-ffmpeg-log-dialect).
+type LogDialect string
+
+const (
+	// DialectAuto reads both dialects and settles on one from the log
+	// itself (see detectDialect).
+	DialectAuto LogDialect = "auto"
+
+	// DialectFFmpeg6 is FFmpeg 4.x to 6.x. Their -loglevel has no datetime
+	// flag, so lines carry no timestamp and timing falls back to our clock.
+	DialectFFmpeg6 LogDialect = "ffmpeg6"
+
+	// DialectFFmpeg7 is FFmpeg 7.x and later (8.x logs the same way):
+	// lines start with the -loglevel datetime timestamp.
+	DialectFFmpeg7 LogDialect = "ffmpeg7"
+)
+
+// LogDialects lists the dialects -ffmpeg-log-dialect accepts.
+var LogDialects = []LogDialect{DialectAuto, DialectFFmpeg6, DialectFFmpeg7}
+
+// ParseLogDialect parses a -ffmpeg-log-dialect name ("" = auto).
+func ParseLogDialect(name string) (LogDialect, error) {
+	d := LogDialect(strings.ToLower(strings.TrimSpace(name)))
+	if d == "" {
+		return DialectAuto, nil
+	}
+	for _, known := range LogDialects {
+		if d == known {
+			return d, nil
+		}
+	}
+	return "", fmt.Errorf("ffmpeg log dialect %q: want auto, ffmpeg6 or ffmpeg7", name)
+}
+
+// DatetimeFlag reports whether FFmpeg accepts the datetime -loglevel flag.
+// Versions without it refuse the whole -loglevel, so it must only be asked
+// for when this is true. Auto assumes a current FFmpeg.
+func (d LogDialect) DatetimeFlag() bool {
+	return d != DialectFFmpeg6
+}
+
+// DialectForVersion maps an FFmpeg version, as "ffmpeg -version" prints it
+// ("7.1.1", "n6.1.2", "6.0-static", "N-118080-g...", "git-2024-..."), to
+// its dialect. Development builds are taken to be current; versions that
+// don't parse give DialectAuto.
+func DialectForVersion(version string) LogDialect {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "n")
+	if strings.HasPrefix(v, "N-") || strings.HasPrefix(v, "git-") {
+		return DialectFFmpeg7
+	}
+	end := strings.IndexFunc(v, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(v)
+	}
+	major, err := strconv.Atoi(v[:end])
+	switch {
+	case err != nil:
+		return DialectAuto
+	case major >= 7:
+		return DialectFFmpeg7
+	default:
+		return DialectFFmpeg6
+	}
+}
+
+// VersionFromBanner returns the version in the first line of FFmpeg's
+// banner or "ffmpeg -version" ("ffmpeg version 7.1 Copyright ..." gives
+// "7.1"), or "" if line isn't one.
+func VersionFromBanner(line string) string {
+	if !strings.Contains(line, "ffmpeg version ") {
+		return ""
+	}
+	if m := reBanner.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// reBanner matches the banner's first line, which -hide_banner leaves out
+// but "ffmpeg -version" and captures without it still print.
+var reBanner = regexp.MustCompile(`(?:^|\] )ffmpeg version (\S+)`)
+
+// linePatterns is one dialect's pattern set: how its lines are laid out
+// around the messages. The message patterns themselves (reHLSRequest and
+// the rest) match every dialect, since FFmpeg 7 changed what is printed
+// around a message, not the messages the parser reads.
+type linePatterns struct {
+	dialect LogDialect
+
+	// timestamp matches the -loglevel datetime prefix (nil = never there)
+	timestamp *regexp.Regexp
+}
+
+// dialectPatterns holds each dialect's pattern set. Auto's reads both
+// (the timestamp is optional) until detectDialect settles on one.
+var dialectPatterns = map[LogDialect]*linePatterns{
+	DialectAuto:    {dialect: DialectAuto, timestamp: reTimestamp},
+	DialectFFmpeg6: {dialect: DialectFFmpeg6},
+	DialectFFmpeg7: {dialect: DialectFFmpeg7, timestamp: reTimestamp},
+}
+
+// parseTimestamp extracts the timestamp from a line of this dialect.
+// Returns the timestamp and the rest of the line, or time.Time{} (zero)
+// and the whole line if there is none.
+func (lp *linePatterns) parseTimestamp(line string) (time.Time, string) {
+	if lp.timestamp == nil {
+		return time.Time{}, line
+	}
+	return parseTimestamp(line)
+}
+
+// detectDialect tells the dialect from one line: the banner gives the
+// version, and a timestamp prefix means FFmpeg 7 or later. Anything else
+// gives DialectAuto: undecided. A line without a timestamp proves nothing,
+// as the datetime flag may not have been asked for.
+func detectDialect(line string) LogDialect {
+	if v := VersionFromBanner(line); v != "" {
+		return DialectForVersion(v)
+	}
+	if reTimestamp.MatchString(line) {
+		return DialectFFmpeg7
+	}
+	return DialectAuto
+}
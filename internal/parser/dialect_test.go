@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    LogDialect
+		wantErr bool
+	}{
+		{"", DialectAuto, false},
+		{"auto", DialectAuto, false},
+		{"ffmpeg6", DialectFFmpeg6, false},
+		{" FFmpeg7 ", DialectFFmpeg7, false},
+		{"ffmpeg5", "", true},
+		{"7", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLogDialect(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLogDialect(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDialectForVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    LogDialect
+	}{
+		{"8.0", DialectFFmpeg7},
+		{"7.1.1", DialectFFmpeg7},
+		{"n7.0.2", DialectFFmpeg7},
+		{"6.1.1-3ubuntu5", DialectFFmpeg6},
+		{"6.0-static", DialectFFmpeg6},
+		{"4.4.2-0ubuntu0.22.04.1", DialectFFmpeg6},
+		{"N-118080-g1a2b3c4d5e", DialectFFmpeg7},
+		{"git-2024-06-01-abcdef", DialectFFmpeg7},
+		{"", DialectAuto},
+		{"unknown", DialectAuto},
+	}
+	for _, tt := range tests {
+		if got := DialectForVersion(tt.version); got != tt.want {
+			t.Errorf("DialectForVersion(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestVersionFromBanner(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"ffmpeg version 7.1 Copyright (c) 2000-2024 the FFmpeg developers", "7.1"},
+		{"2026-01-23 08:12:29.600 [info] ffmpeg version 8.0 Copyright (c) 2000-2025 the FFmpeg developers", "8.0"},
+		{"ffmpeg version n6.1.2 Copyright (c) 2000-2023 the FFmpeg developers", "n6.1.2"},
+		{"  built with gcc 15.2.0 (GCC)", ""},
+		{"[hls @ 0x55f8a1b2c3d0] Opening 'http://origin/stream.m3u8' for reading", ""},
+	}
+	for _, tt := range tests {
+		if got := VersionFromBanner(tt.line); got != tt.want {
+			t.Errorf("VersionFromBanner(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestDialect_DatetimeFlag(t *testing.T) {
+	if DialectFFmpeg6.DatetimeFlag() {
+		t.Error("ffmpeg6 asks for the datetime flag")
+	}
+	if !DialectFFmpeg7.DatetimeFlag() || !DialectAuto.DatetimeFlag() {
+		t.Error("ffmpeg7 and auto don't ask for the datetime flag")
+	}
+}
+
+func TestDebugEventParser_DetectsDialect(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+	p.ParseLine("[hls @ 0x55f8a1b2c3d0] [debug] HLS request for url 'http://origin/seg1.ts', offset 0, playlist 0")
+	if d := p.Dialect(); d != DialectAuto {
+		t.Fatalf("Dialect() = %q after an untimestamped line, want still auto", d)
+	}
+	p.ParseLine("2026-01-23 08:12:29.601 [tcp @ 0x558133030100] [verbose] Starting connection attempt to 10.177.0.10 port 17080")
+	if d := p.Dialect(); d != DialectFFmpeg7 {
+		t.Errorf("Dialect() = %q after a timestamped line, want ffmpeg7", d)
+	}
+
+	p = NewDebugEventParser(1, 2*time.Second, nil)
+	p.ParseLine("ffmpeg version 6.1.1 Copyright (c) 2000-2023 the FFmpeg developers")
+	if d := p.Dialect(); d != DialectFFmpeg6 {
+		t.Errorf("Dialect() = %q after a 6.1.1 banner, want ffmpeg6", d)
+	}
+
+	p = NewDebugEventParser(1, 2*time.Second, nil)
+	p.SetDialect(DialectFFmpeg6)
+	p.ParseLine("2026-01-23 08:12:29.601 [tcp @ 0x558133030100] [verbose] Starting connection attempt to 10.177.0.10 port 17080")
+	if d := p.Dialect(); d != DialectFFmpeg6 {
+		t.Errorf("Dialect() = %q with ffmpeg6 set, want it kept", d)
+	}
+	if used := p.Stats().TimestampsUsed; used != 0 {
+		t.Errorf("TimestampsUsed = %d reading ffmpeg6, want 0", used)
+	}
+}
+
+// TestDebugEventParser_DialectCorpus parses the same session as FFmpeg 6
+// and FFmpeg 7+ log it, with the dialect set and detected: every dialect
+// must find the same events.
+func TestDebugEventParser_DialectCorpus(t *testing.T) {
+	corpora := []struct {
+		file     string
+		dialect  LogDialect // Its FFmpeg versions
+		detected LogDialect // What auto settles on
+		stamped  bool
+	}{
+		{"ffmpeg6_debug.txt", DialectFFmpeg6, DialectAuto, false},
+		{"ffmpeg_timestamped_1.txt", DialectFFmpeg7, DialectFFmpeg7, true},
+	}
+
+	var want *DebugStats
+	for _, c := range corpora {
+		data, err := os.ReadFile("../../testdata/" + c.file)
+		if err != nil {
+			t.Fatalf("%s corpus: %v", c.dialect, err)
+		}
+		lines := strings.Split(string(data), "\n")
+
+		for _, set := range []LogDialect{DialectAuto, c.dialect} {
+			p := NewDebugEventParser(1, 2*time.Second, nil)
+			p.SetDialect(set)
+			for _, line := range lines {
+				p.ParseLine(line)
+			}
+			stats := p.Stats()
+
+			if set == DialectAuto && p.Dialect() != c.detected {
+				t.Errorf("%s: detected %q, want %q", c.file, p.Dialect(), c.detected)
+			}
+			if (stats.TimestampsUsed > 0) != c.stamped {
+				t.Errorf("%s as %s: TimestampsUsed = %d, want timestamps %v", c.file, set, stats.TimestampsUsed, c.stamped)
+			}
+			if stats.SegmentCount == 0 || stats.TCPConnectCount == 0 || stats.PlaylistRefreshes == 0 {
+				t.Errorf("%s as %s: segments %d, TCP connects %d, refreshes %d; want all found",
+					c.file, set, stats.SegmentCount, stats.TCPConnectCount, stats.PlaylistRefreshes)
+			}
+
+			if want == nil {
+				want = &stats
+				continue
+			}
+			for _, f := range []struct {
+				name      string
+				got, want int64
+			}{
+				{"SegmentCount", stats.SegmentCount, want.SegmentCount},
+				{"ManifestCount", stats.ManifestCount, want.ManifestCount},
+				{"TCPConnectCount", stats.TCPConnectCount, want.TCPConnectCount},
+				{"TCPSuccessCount", stats.TCPSuccessCount, want.TCPSuccessCount},
+				{"PlaylistRefreshes", stats.PlaylistRefreshes, want.PlaylistRefreshes},
+				{"SequenceSkips", stats.SequenceSkips, want.SequenceSkips},
+				{"HTTPOpenCount", stats.HTTPOpenCount, want.HTTPOpenCount},
+				{"HTTPErrorCount", stats.HTTPErrorCount, want.HTTPErrorCount},
+				{"ManifestBandwidth", stats.ManifestBandwidth, want.ManifestBandwidth},
+			} {
+				if f.got != f.want {
+					t.Errorf("%s as %s: %s = %d, want %d like the other dialects", c.file, set, f.name, f.got, f.want)
+				}
+			}
+		}
+	}
+}
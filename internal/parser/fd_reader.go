@@ -2,7 +2,6 @@
 package parser
 
 import (
-	"bufio"
 	"os"
 	"sync/atomic"
 )
@@ -54,12 +53,7 @@ func (f *FDReader) Run() {
 	// I1: Pipeline channel MUST be closed on exit
 	defer f.pipeline.CloseChannel()
 
-	scanner := bufio.NewScanner(f.file)
-
-	// Use a larger buffer for long FFmpeg output lines
-	const maxLineSize = 64 * 1024
-	scanner.Buffer(make([]byte, maxLineSize), 1024*1024)
-
+	scanner := f.pipeline.newLineScanner(f.file)
 	for scanner.Scan() {
 		line := scanner.Text()
 		f.bytesRead.Add(int64(len(line) + 1)) // +1 for newline
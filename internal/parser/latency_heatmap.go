@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// HeatmapBucketsMs are the upper bounds, in milliseconds, of the segment
+// wall time buckets of a LatencyHeatmap: 1-2-5 steps from 1ms to 50s, with
+// one more bucket for anything slower. Steps this coarse still keep the
+// modes of a bimodal latency (cache hits and misses, say) apart.
+var HeatmapBucketsMs = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000, 20000, 50000}
+
+// LatencyHeatmap counts segment wall times from every client by bucket
+// between calls to Rotate: one column of a time x latency heatmap.
+type LatencyHeatmap struct {
+	mu     sync.Mutex
+	counts []int64
+}
+
+// NewLatencyHeatmap returns an empty heatmap column.
+func NewLatencyHeatmap() *LatencyHeatmap {
+	return &LatencyHeatmap{counts: make([]int64, len(HeatmapBucketsMs)+1)}
+}
+
+func (h *LatencyHeatmap) record(wallTime time.Duration) {
+	ms := float64(wallTime) / float64(time.Millisecond)
+	i, _ := slices.BinarySearch(HeatmapBucketsMs, ms) // First bound >= ms
+	h.mu.Lock()
+	h.counts[i]++
+	h.mu.Unlock()
+}
+
+// Rotate returns the segments per bucket since the last Rotate, one more
+// than HeatmapBucketsMs, and starts a new column.
+func (h *LatencyHeatmap) Rotate() []int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts := h.counts
+	h.counts = make([]int64, len(HeatmapBucketsMs)+1)
+	return counts
+}
+
+// SetLatencyHeatmap attaches a heatmap shared by all clients' parsers;
+// every completed segment's wall time is counted in it.
+func (p *DebugEventParser) SetLatencyHeatmap(h *LatencyHeatmap) {
+	p.heatmap = h
+}
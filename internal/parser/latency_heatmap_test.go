@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLatencyHeatmap_Rotate(t *testing.T) {
+	h := NewLatencyHeatmap()
+	for _, d := range []time.Duration{
+		500 * time.Microsecond, // ≤1ms
+		time.Millisecond,       // ≤1ms: bounds are inclusive
+		1500 * time.Microsecond,
+		150 * time.Millisecond,
+		time.Minute, // Past the last bound
+	} {
+		h.record(d)
+	}
+
+	want := make([]int64, len(HeatmapBucketsMs)+1)
+	want[0], want[1], want[7], want[len(HeatmapBucketsMs)] = 2, 1, 1, 1 // 150ms is ≤200ms
+	if got := h.Rotate(); !slices.Equal(got, want) {
+		t.Errorf("Rotate() = %v, want %v", got, want)
+	}
+	if got := h.Rotate(); !slices.Equal(got, make([]int64, len(want))) {
+		t.Errorf("second Rotate() = %v, want all zero", got)
+	}
+}
+
+func TestDebugEventParser_LatencyHeatmap(t *testing.T) {
+	h := NewLatencyHeatmap()
+	lines := []string{
+		"2026-01-23 08:00:00.000 [http @ 0x55c32c0d7ac0] Opening 'http://origin/seg1.ts' for reading",
+		"2026-01-23 08:00:00.300 [http @ 0x55c32c0d7ac0] Opening 'http://origin/seg2.ts' for reading",
+		"2026-01-23 08:00:00.500 [http @ 0x55c32c0d7ac0] Opening 'http://origin/seg3.ts' for reading",
+	}
+	for id := 0; id < 2; id++ {
+		p := NewDebugEventParser(id, 2*time.Second, nil)
+		p.SetLatencyHeatmap(h)
+		for _, line := range lines {
+			p.ParseLine(line)
+		}
+	}
+
+	// Per client: seg1 300ms (≤500ms), seg2 200ms (≤200ms)
+	got := h.Rotate()
+	if got[7] != 2 || got[8] != 2 {
+		t.Errorf("Rotate() = %v, want 2 at ≤200ms and 2 at ≤500ms", got)
+	}
+}
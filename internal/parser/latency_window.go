@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/tdigest"
+)
+
+// LatencyWindow collects segment wall times from every client between
+// calls to Rotate, for the latency at the current load rather than the
+// run-long percentiles the per-client digests give.
+type LatencyWindow struct {
+	mu     sync.Mutex
+	digest *tdigest.TDigest
+	n      int
+}
+
+// NewLatencyWindow returns an empty window.
+func NewLatencyWindow() *LatencyWindow {
+	return &LatencyWindow{digest: tdigest.NewWithCompression(100)}
+}
+
+func (w *LatencyWindow) record(wallTime time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.digest.Add(float64(wallTime.Nanoseconds()), 1)
+	w.n++
+}
+
+// Rotate returns the P95 segment wall time and the number of segments
+// since the last Rotate, and starts a new window.
+func (w *LatencyWindow) Rotate() (time.Duration, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d, n := w.digest, w.n
+	w.digest = tdigest.NewWithCompression(100)
+	w.n = 0
+	if n == 0 {
+		return 0, 0
+	}
+	return time.Duration(d.Quantile(0.95)), n
+}
+
+// SetLatencyWindow attaches a window shared by all clients' parsers; every
+// completed segment's wall time is recorded in it as well.
+func (p *DebugEventParser) SetLatencyWindow(w *LatencyWindow) {
+	p.latency = w
+}
+
+func (p *DebugEventParser) recordLatency(wallTime time.Duration) {
+	if p.latency != nil {
+		p.latency.record(wallTime)
+	}
+	if p.heatmap != nil {
+		p.heatmap.record(wallTime)
+	}
+}
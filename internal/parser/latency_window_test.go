@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyWindow_Rotate(t *testing.T) {
+	w := NewLatencyWindow()
+	if p95, n := w.Rotate(); p95 != 0 || n != 0 {
+		t.Errorf("empty Rotate() = %v, %d; want 0, 0", p95, n)
+	}
+
+	for i := 1; i <= 100; i++ {
+		w.record(time.Duration(i) * time.Millisecond)
+	}
+	p95, n := w.Rotate()
+	if n != 100 {
+		t.Errorf("n = %d, want 100", n)
+	}
+	if p95 < 93*time.Millisecond || p95 > 97*time.Millisecond {
+		t.Errorf("p95 = %v, want ~95ms", p95)
+	}
+
+	// A new window: the earlier wall times are gone
+	w.record(time.Second)
+	if p95, n := w.Rotate(); p95 != time.Second || n != 1 {
+		t.Errorf("Rotate() = %v, %d; want 1s, 1", p95, n)
+	}
+}
+
+func TestDebugEventParser_LatencyWindow(t *testing.T) {
+	w := NewLatencyWindow()
+	lines := []string{
+		"2026-01-23 08:00:00.000 [http @ 0x55c32c0d7ac0] Opening 'http://origin/seg1.ts' for reading",
+		"2026-01-23 08:00:00.300 [http @ 0x55c32c0d7ac0] Opening 'http://origin/seg2.ts' for reading",
+		"2026-01-23 08:00:00.500 [http @ 0x55c32c0d7ac0] Opening 'http://origin/seg3.ts' for reading",
+	}
+	for id := 0; id < 2; id++ {
+		p := NewDebugEventParser(id, 2*time.Second, nil)
+		p.SetLatencyWindow(w)
+		for _, line := range lines {
+			p.ParseLine(line)
+		}
+	}
+
+	p95, n := w.Rotate()
+	if n != 4 {
+		t.Errorf("n = %d, want 4 (two completed segments per client)", n)
+	}
+	if p95 < 200*time.Millisecond || p95 > 300*time.Millisecond {
+		t.Errorf("p95 = %v, want 200-300ms", p95)
+	}
+}
@@ -2,7 +2,6 @@
 package parser
 
 import (
-	"bufio"
 	"io"
 	"sync/atomic"
 )
@@ -43,12 +42,7 @@ func (p *PipeReader) Run() {
 	// I1: Pipeline channel MUST be closed on exit
 	defer p.pipeline.CloseChannel()
 
-	scanner := bufio.NewScanner(p.reader)
-
-	// Use a larger buffer for long FFmpeg output lines
-	const maxLineSize = 64 * 1024
-	scanner.Buffer(make([]byte, maxLineSize), 1024*1024)
-
+	scanner := p.pipeline.newLineScanner(p.reader)
 	for scanner.Scan() {
 		line := scanner.Text()
 		p.bytesRead.Add(int64(len(line) + 1)) // +1 for newline
@@ -13,9 +13,11 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"io"
 	"sync"
 	"sync/atomic"
+	"unicode/utf8"
 )
 
 // LineParser is implemented by ProgressParser and HLSEventParser.
@@ -23,6 +25,14 @@ type LineParser interface {
 	ParseLine(line string)
 }
 
+// Resetter is implemented by LineParsers that keep state across lines. A
+// Pipeline resets its parser after a line panicked (see RunParser), so
+// whatever the line left half-done can't trip up the lines after it.
+// Reset is called on the parsing goroutine.
+type Resetter interface {
+	Reset()
+}
+
 // LineSource abstracts the source of lines for a Pipeline.
 // Both PipeReader (stdout) and SocketReader (Unix socket) implement this.
 //
@@ -55,6 +65,98 @@ type LineSource interface {
 	Stats() (bytesRead int64, linesRead int64, healthy bool)
 }
 
+// DefaultMaxLineLength bounds a single line of FFmpeg output unless
+// SetMaxLineLength says otherwise. Longer lines are truncated to the limit
+// and the rest of the line is discarded. A plain bufio.Scanner stops at the
+// first over-long line (bufio.ErrTooLong), which would leave FFmpeg blocked
+// writing to a pipe nobody reads.
+const DefaultMaxLineLength = 64 * 1024
+
+// truncatingSplit is bufio.ScanLines, except that a line longer than maxLen
+// bytes is returned cut at maxLen and its remainder skipped up to the next
+// newline. The scanner buffer must hold maxLen+1 bytes. onTruncate (optional)
+// is called once per cut line.
+func truncatingSplit(maxLen int, onTruncate func()) bufio.SplitFunc {
+	discarding := false
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if discarding {
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				discarding = false
+				return i + 1, nil, nil
+			}
+			return len(data), nil, nil
+		}
+
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if advance == 0 && err == nil && len(data) > maxLen {
+			discarding = true
+			if onTruncate != nil {
+				onTruncate()
+			}
+			cut := runeCut(data, maxLen)
+			return cut, data[:cut], nil
+		}
+		return advance, token, err
+	}
+}
+
+// runeCut returns where to cut data (longer than n) at most n bytes in:
+// n, or the start of a valid multi-byte character that n would split, so
+// a cut line doesn't end in a broken character.
+func runeCut(data []byte, n int) int {
+	if utf8.RuneStart(data[n]) {
+		return n
+	}
+	for i := n - 1; i > 0 && n-i < utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if _, size := utf8.DecodeRune(data[i:]); size > 1 && i+size > n {
+				return i
+			}
+			break
+		}
+	}
+	return n
+}
+
+// sanitizingSplit wraps split so every line it returns is valid UTF-8:
+// each run of invalid bytes becomes one U+FFFD, as strings.ToValidUTF8
+// does, and is counted with onInvalid. FFmpeg echoes URLs, metadata and
+// server responses byte for byte, and a stray byte would otherwise reach
+// the JSON logs, the event sink and the TUI. Lines stay within maxLen
+// bytes: whatever no longer fits after the (longer) replacements is cut.
+func sanitizingSplit(split bufio.SplitFunc, maxLen int, onInvalid func(n int)) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := split(data, atEOF)
+		if token == nil || utf8.Valid(token) {
+			return advance, token, err
+		}
+		clean := make([]byte, 0, len(token)+8)
+		replaced := 0
+		invalid := false
+		for i := 0; i < len(token); {
+			r, size := utf8.DecodeRune(token[i:])
+			i += size
+			if r == utf8.RuneError && size == 1 {
+				if !invalid {
+					invalid = true
+					replaced++
+					clean = utf8.AppendRune(clean, utf8.RuneError)
+				}
+				continue
+			}
+			invalid = false
+			clean = append(clean, token[i-size:i]...)
+		}
+		if len(clean) > maxLen {
+			clean = clean[:runeCut(clean, maxLen)]
+		}
+		if onInvalid != nil {
+			onInvalid(replaced)
+		}
+		return advance, clean, err
+	}
+}
+
 // Pipeline implements three-layer lossy-by-design parsing.
 //
 // It reads lines from an io.Reader into a bounded channel. If the parser
@@ -68,9 +170,19 @@ type Pipeline struct {
 	closeOnce sync.Once // Ensures CloseChannel() is idempotent
 
 	// Pipeline health metrics (atomic for concurrent access)
-	linesRead    int64
-	linesDropped int64
-	linesParsed  int64
+	linesRead      int64
+	linesDropped   int64
+	linesParsed    int64
+	linesTruncated int64
+	linesPanicked  int64
+	invalidUTF8    int64 // Runs of invalid UTF-8 replaced
+
+	// Longest line passed on; the rest is cut (see DefaultMaxLineLength)
+	maxLineLength int
+	onTruncate    func()      // Optional, called per cut line
+	onInvalidUTF8 func(n int) // Optional, called per line with invalid UTF-8
+
+	onPanic func(line string, v any) // Optional, called per line the parser panicked on
 
 	// Configurable threshold for degradation detection
 	dropThreshold float64
@@ -97,9 +209,60 @@ func NewPipeline(clientID int, streamType string, bufferSize int, dropThreshold
 		bufferSize:    bufferSize,
 		lineChan:      make(chan string, bufferSize),
 		dropThreshold: dropThreshold,
+		maxLineLength: DefaultMaxLineLength,
+	}
+}
+
+// SetMaxLineLength sets the longest line (in bytes) the readers pass on;
+// n <= 0 keeps DefaultMaxLineLength. Must be called before a reader runs.
+func (p *Pipeline) SetMaxLineLength(n int) {
+	if n > 0 {
+		p.maxLineLength = n
 	}
 }
 
+// SetTruncateCallback sets a function called (on the reader goroutine) for
+// every line cut at the maximum length. Must be called before a reader runs.
+func (p *Pipeline) SetTruncateCallback(fn func()) {
+	p.onTruncate = fn
+}
+
+// SetInvalidUTF8Callback sets a function called (on the reader goroutine)
+// for every line that had invalid UTF-8, with the number of invalid runs
+// replaced. Must be called before a reader runs.
+func (p *Pipeline) SetInvalidUTF8Callback(fn func(n int)) {
+	p.onInvalidUTF8 = fn
+}
+
+// SetPanicCallback sets a function called (on the parser goroutine, with
+// the panicking stack still live for debug.Stack) for every line the
+// parser panicked on, with the panic value. Must be called before the
+// parser runs.
+func (p *Pipeline) SetPanicCallback(fn func(line string, v any)) {
+	p.onPanic = fn
+}
+
+// newLineScanner returns a line scanner that cuts lines at the pipeline's
+// maximum length and replaces invalid UTF-8, counting both. All
+// LineSources read through it, so every line downstream is well-formed.
+func (p *Pipeline) newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, min(4096, p.maxLineLength+1)), p.maxLineLength+1)
+	truncating := truncatingSplit(p.maxLineLength, func() {
+		atomic.AddInt64(&p.linesTruncated, 1)
+		if p.onTruncate != nil {
+			p.onTruncate()
+		}
+	})
+	scanner.Split(sanitizingSplit(truncating, p.maxLineLength, func(n int) {
+		atomic.AddInt64(&p.invalidUTF8, int64(n))
+		if p.onInvalidUTF8 != nil {
+			p.onInvalidUTF8(n)
+		}
+	}))
+	return scanner
+}
+
 // RunReader is Layer 1: reads lines fast, drops if channel full.
 //
 // MUST run in dedicated goroutine. Never blocks on channel send.
@@ -111,12 +274,7 @@ func (p *Pipeline) RunReader(r io.Reader) {
 	// I4: Use CloseChannel() for symmetry with socket mode
 	defer p.CloseChannel()
 
-	scanner := bufio.NewScanner(r)
-
-	// Use a larger buffer for long FFmpeg output lines
-	buf := make([]byte, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
+	scanner := p.newLineScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
 		atomic.AddInt64(&p.linesRead, 1)
@@ -169,13 +327,38 @@ func (p *Pipeline) CloseChannel() {
 // RunParser is Layer 2: consumes lines at own pace.
 //
 // MUST run in dedicated goroutine. Blocks until lineChan is closed.
+//
+// A panic in the parser costs the line, not the run: it is recovered,
+// counted (Panicked), reported to the panic callback, and the parser reset
+// if it is a Resetter. The pipeline then carries on with the next line.
 func (p *Pipeline) RunParser(parser LineParser) {
 	for line := range p.lineChan {
-		parser.ParseLine(line)
-		atomic.AddInt64(&p.linesParsed, 1)
+		if p.parseLine(parser, line) {
+			atomic.AddInt64(&p.linesParsed, 1)
+		}
 	}
 }
 
+// parseLine passes line to parser, recovering from a panic. Returns false
+// if the parser panicked.
+func (p *Pipeline) parseLine(parser LineParser, line string) (ok bool) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		atomic.AddInt64(&p.linesPanicked, 1)
+		if p.onPanic != nil {
+			p.onPanic(line, v)
+		}
+		if r, isResetter := parser.(Resetter); isResetter {
+			r.Reset()
+		}
+	}()
+	parser.ParseLine(line)
+	return true
+}
+
 // Stats returns pipeline health metrics.
 //
 // Returns:
@@ -188,6 +371,22 @@ func (p *Pipeline) Stats() (read, dropped, parsed int64) {
 		atomic.LoadInt64(&p.linesParsed)
 }
 
+// Truncated returns the number of lines cut at the maximum line length.
+func (p *Pipeline) Truncated() int64 {
+	return atomic.LoadInt64(&p.linesTruncated)
+}
+
+// InvalidUTF8 returns the number of invalid UTF-8 runs replaced with
+// U+FFFD.
+func (p *Pipeline) InvalidUTF8() int64 {
+	return atomic.LoadInt64(&p.invalidUTF8)
+}
+
+// Panicked returns the number of lines the parser panicked on.
+func (p *Pipeline) Panicked() int64 {
+	return atomic.LoadInt64(&p.linesPanicked)
+}
+
 // DropRate returns the current drop rate as a fraction (0.0 to 1.0).
 func (p *Pipeline) DropRate() float64 {
 	read := atomic.LoadInt64(&p.linesRead)
@@ -1,10 +1,13 @@
 package parser
 
 import (
+	"bytes"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 // slowParser simulates a parser that can't keep up with input.
@@ -265,6 +268,23 @@ func TestPipeline_DefaultValues(t *testing.T) {
 	}
 }
 
+func TestPipeline_SetMaxLineLength(t *testing.T) {
+	pipeline := NewPipeline(0, "stderr", 10, 0.01)
+	if pipeline.maxLineLength != DefaultMaxLineLength {
+		t.Errorf("default maxLineLength = %d, want %d", pipeline.maxLineLength, DefaultMaxLineLength)
+	}
+
+	pipeline.SetMaxLineLength(0)
+	if pipeline.maxLineLength != DefaultMaxLineLength {
+		t.Errorf("SetMaxLineLength(0) changed limit to %d", pipeline.maxLineLength)
+	}
+
+	pipeline.SetMaxLineLength(1 << 20)
+	if pipeline.maxLineLength != 1<<20 {
+		t.Errorf("maxLineLength = %d, want %d", pipeline.maxLineLength, 1<<20)
+	}
+}
+
 func TestNoopParser(t *testing.T) {
 	// Just ensure NoopParser compiles and doesn't panic
 	var parser NoopParser
@@ -272,6 +292,190 @@ func TestNoopParser(t *testing.T) {
 	parser.ParseLine("")
 }
 
+func TestPipeline_LongLineTruncated(t *testing.T) {
+	pipeline := NewPipeline(0, "stderr", 100, 0.01)
+	parser := &slowParser{}
+
+	long := strings.Repeat("x", 3*DefaultMaxLineLength+17)
+	input := "first\n" + long + "\nafter\r\n" + strings.Repeat("y", DefaultMaxLineLength) + "\nlast"
+
+	go pipeline.RunReader(strings.NewReader(input))
+	pipeline.RunParser(parser)
+
+	lines := parser.Lines()
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5 (reader must not stop at a long line)", len(lines))
+	}
+	if lines[0] != "first" || lines[2] != "after" || lines[4] != "last" {
+		t.Errorf("lines around long ones = %q, %q, %q", lines[0], lines[2], lines[4])
+	}
+	if len(lines[1]) != DefaultMaxLineLength {
+		t.Errorf("long line length = %d, want %d", len(lines[1]), DefaultMaxLineLength)
+	}
+	if len(lines[3]) != DefaultMaxLineLength {
+		t.Errorf("max-length line length = %d, want %d", len(lines[3]), DefaultMaxLineLength)
+	}
+	if got := pipeline.Truncated(); got != 1 {
+		t.Errorf("Truncated() = %d, want 1 (a line of exactly the limit is not cut)", got)
+	}
+}
+
+// panickyParser panics on "boom" lines and records its resets.
+type panickyParser struct {
+	lines  []string
+	resets int
+}
+
+func (p *panickyParser) ParseLine(line string) {
+	if line == "boom" {
+		panic("pathological line")
+	}
+	p.lines = append(p.lines, line)
+}
+
+func (p *panickyParser) Reset() { p.resets++ }
+
+func TestPipeline_ParserPanic(t *testing.T) {
+	pipeline := NewPipeline(0, "stderr", 100, 0.01)
+	parser := &panickyParser{}
+	var panicked []string
+	pipeline.SetPanicCallback(func(line string, v any) {
+		panicked = append(panicked, line+": "+v.(string))
+	})
+
+	go pipeline.RunReader(strings.NewReader("first\nboom\nafter\nboom\nlast\n"))
+	pipeline.RunParser(parser) // Must not panic
+
+	if !slices.Equal(parser.lines, []string{"first", "after", "last"}) {
+		t.Errorf("parsed %q, want the lines around the panics", parser.lines)
+	}
+	if got := pipeline.Panicked(); got != 2 {
+		t.Errorf("Panicked() = %d, want 2", got)
+	}
+	if parser.resets != 2 {
+		t.Errorf("resets = %d, want one per panic", parser.resets)
+	}
+	if len(panicked) != 2 || panicked[0] != "boom: pathological line" {
+		t.Errorf("panic callback got %q", panicked)
+	}
+	if read, _, parsed := pipeline.Stats(); read != 5 || parsed != 3 {
+		t.Errorf("read %d, parsed %d; want 5, 3 (panicked lines aren't parsed)", read, parsed)
+	}
+}
+
+func TestTruncatingSplit(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		want          []string
+		wantTruncated int64
+	}{
+		{"short lines", "ab\ncd\n", []string{"ab", "cd"}, 0},
+		{"crlf", "ab\r\ncd", []string{"ab", "cd"}, 0},
+		{"exactly max", "abcd\nef", []string{"abcd", "ef"}, 0},
+		{"exactly max at eof", "abcd", []string{"abcd"}, 0},
+		{"over max", "abcdefghij\nk", []string{"abcd", "k"}, 1},
+		{"over max at eof", "abcdefghij", []string{"abcd"}, 1},
+		{"two over max", "abcde\nfghij\n", []string{"abcd", "fghi"}, 2},
+		{"nul and invalid utf-8", "\x00\xff\n", []string{"\x00\ufffd"}, 0},
+		{"over max mid character", "abéé\nc", []string{"abé", "c"}, 1},
+		{"empty lines", "\n\nx", []string{"", "", "x"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := NewPipeline(0, "stderr", 10, 0.01)
+			pipeline.SetMaxLineLength(4)
+			scanner := pipeline.newLineScanner(strings.NewReader(tt.input))
+
+			var got []string
+			for scanner.Scan() {
+				got = append(got, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("scanner error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+			if got := pipeline.Truncated(); got != tt.wantTruncated {
+				t.Errorf("Truncated() = %d, want %d", got, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+// FuzzTruncatingSplit checks that the line scanner never errors and never
+// returns a line longer than the limit or containing a newline.
+// Run with: go test -fuzz=FuzzTruncatingSplit ./internal/parser/...
+func FuzzTruncatingSplit(f *testing.F) {
+	f.Add("line one\nline two\r\n")
+	f.Add(strings.Repeat("z", 100) + "\nshort")
+	f.Add("\x00\xff\xfe\n\n\r\n")
+	f.Add("")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		const maxLen = 16
+		pipeline := NewPipeline(0, "stderr", 10, 0.01)
+		pipeline.SetMaxLineLength(maxLen)
+		scanner := pipeline.newLineScanner(strings.NewReader(input))
+
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) > maxLen {
+				t.Errorf("line length %d > %d", len(line), maxLen)
+			}
+			if bytes.IndexByte(line, '\n') >= 0 {
+				t.Errorf("line %q contains a newline", line)
+			}
+			if !utf8.Valid(line) {
+				t.Errorf("line %q is not valid UTF-8", line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			t.Errorf("scanner error: %v", err)
+		}
+	})
+}
+
+func TestSanitizingSplit(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		want        []string
+		wantInvalid int64
+	}{
+		{"valid", "café ✓\n", []string{"café ✓"}, 0},
+		{"one bad byte", "a\xffb\n", []string{"a\ufffdb"}, 1},
+		{"run of bad bytes", "a\xff\xfe\xfdb\n", []string{"a\ufffdb"}, 1},
+		{"two runs", "\xffa\xc3\n", []string{"\ufffda\ufffd"}, 2},
+		{"truncated sequence", "\xe2\x9cb\n", []string{"\ufffdb"}, 1},
+		{"latin-1", "Bj\xf6rk\nok\n", []string{"Bj\ufffdrk", "ok"}, 1},
+		{"replacements grow past max", "\xffab\xffcd\xffef\xff\n", []string{"\ufffdab\ufffdcd"}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := NewPipeline(0, "stderr", 10, 0.01)
+			pipeline.SetMaxLineLength(12)
+			var calls int64
+			pipeline.SetInvalidUTF8Callback(func(n int) { calls += int64(n) })
+			scanner := pipeline.newLineScanner(strings.NewReader(tt.input))
+
+			var got []string
+			for scanner.Scan() {
+				got = append(got, scanner.Text())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+			if got := pipeline.InvalidUTF8(); got != tt.wantInvalid || calls != tt.wantInvalid {
+				t.Errorf("InvalidUTF8() = %d, callback %d, want %d", got, calls, tt.wantInvalid)
+			}
+		})
+	}
+}
+
 func BenchmarkPipeline_FastParser(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		pipeline := NewPipeline(0, "bench", 1000, 0.01)
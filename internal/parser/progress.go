@@ -105,6 +105,14 @@ func (p *ProgressParser) ParseLine(line string) {
 	p.parseLine(line)
 }
 
+// Reset drops the progress block being accumulated, after a line panicked
+// (see Pipeline.RunParser): the next block starts clean.
+func (p *ProgressParser) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = &ProgressUpdate{}
+}
+
 // parseLine handles a single line of progress output.
 func (p *ProgressParser) parseLine(line string) {
 	key, value, ok := parseKeyValue(line)
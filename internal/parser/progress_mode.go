@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProgressMode is the path a client's -progress output takes to the
+// ProgressParser (-progress-mode). The stderr path, which the debug event
+// parser reads, is independent of it.
+type ProgressMode string
+
+const (
+	// ProgressPipe passes an anonymous pipe to FFmpeg as FD 3 (FDReader).
+	ProgressPipe ProgressMode = "pipe"
+
+	// ProgressSocket has FFmpeg connect to a Unix socket per client
+	// (SocketReader), named by ProgressSocketPath.
+	ProgressSocket ProgressMode = "socket"
+
+	// ProgressOff asks for no -progress output at all: no speed, drift or
+	// progress bytes, for the least parsing per client.
+	ProgressOff ProgressMode = "off"
+)
+
+// ProgressModes lists the modes -progress-mode accepts.
+var ProgressModes = []ProgressMode{ProgressPipe, ProgressSocket, ProgressOff}
+
+// ParseProgressMode parses a -progress-mode name ("" = pipe).
+func ParseProgressMode(name string) (ProgressMode, error) {
+	m := ProgressMode(strings.ToLower(strings.TrimSpace(name)))
+	if m == "" {
+		return ProgressPipe, nil
+	}
+	for _, known := range ProgressModes {
+		if m == known {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("progress mode %q: want pipe, socket or off", name)
+}
+
+// ProgressSocketPath is the Unix socket a client's progress goes to in
+// ProgressSocket mode, under dir ("" = os.TempDir()). The process ID keeps
+// concurrent swarms on one host apart; a client reuses its socket across
+// restarts.
+func ProgressSocketPath(dir string, clientID int) string {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("hls-swarm-%d-%d.sock", os.Getpid(), clientID))
+}
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProgressMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ProgressMode
+		wantErr bool
+	}{
+		{"", ProgressPipe, false},
+		{"pipe", ProgressPipe, false},
+		{" Socket ", ProgressSocket, false},
+		{"off", ProgressOff, false},
+		{"stdout", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseProgressMode(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseProgressMode(%q) = %q, %v; want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestProgressSocketPath(t *testing.T) {
+	path := ProgressSocketPath("/run/swarm", 42)
+	if filepath.Dir(path) != "/run/swarm" || !strings.HasSuffix(path, "-42.sock") {
+		t.Errorf("ProgressSocketPath = %q, want /run/swarm/...-42.sock", path)
+	}
+	if other := ProgressSocketPath("/run/swarm", 43); other == path {
+		t.Errorf("clients 42 and 43 share socket %q", path)
+	}
+	if def := ProgressSocketPath("", 1); filepath.Dir(def) != filepath.Clean(os.TempDir()) {
+		t.Errorf("ProgressSocketPath(\"\", 1) = %q, want it in %s", def, os.TempDir())
+	}
+}
@@ -215,6 +215,24 @@ func TestProgressParser_Current(t *testing.T) {
 	}
 }
 
+func TestProgressParser_Reset(t *testing.T) {
+	p := NewProgressParser(nil)
+	p.ParseLine("frame=100")
+	p.ParseLine("total_size=12345")
+
+	p.Reset()
+	if current := p.Current(); current.Frame != 0 || current.TotalSize != 0 {
+		t.Errorf("Current() after Reset = %+v, want an empty block", current)
+	}
+
+	// The next block parses normally.
+	p.ParseLine("frame=5")
+	p.ParseLine("progress=continue")
+	if blocks, _ := p.Stats(); blocks != 1 {
+		t.Errorf("blocks = %d, want 1", blocks)
+	}
+}
+
 func TestProgressUpdate_OutTimeDuration(t *testing.T) {
 	tests := []struct {
 		outTimeUS int64
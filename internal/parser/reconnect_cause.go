@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"strings"
+	"time"
+)
+
+// ReconnectCauseWindow is how far before a reconnect a client's failure
+// event may be to be taken as its cause. FFmpeg logs the failure and the
+// reconnect back to back, so this only needs to cover log buffering.
+const ReconnectCauseWindow = 2 * time.Second
+
+// Reconnect causes (DebugEvent.Cause): the stage of the request that
+// failed, then what went wrong in it.
+const (
+	ReconnectDNS         = "dns"
+	ReconnectTCPRefused  = "tcp refused"
+	ReconnectTCPTimeout  = "tcp timeout"
+	ReconnectTCPError    = "tcp error"
+	ReconnectTLS         = "tls"
+	ReconnectHTTP4xx     = "http 4xx"
+	ReconnectHTTP5xx     = "http 5xx"
+	ReconnectReadTimeout = "read timeout"
+	ReconnectReadReset   = "read reset"
+	ReconnectReadEOF     = "read eof"
+	ReconnectReadError   = "read error"
+	ReconnectUnknown     = "unknown"
+)
+
+// ReconnectCauses lists the reconnect causes in request stage order.
+var ReconnectCauses = [...]string{
+	ReconnectDNS,
+	ReconnectTCPRefused, ReconnectTCPTimeout, ReconnectTCPError,
+	ReconnectTLS,
+	ReconnectHTTP4xx, ReconnectHTTP5xx,
+	ReconnectReadTimeout, ReconnectReadReset, ReconnectReadEOF, ReconnectReadError,
+	ReconnectUnknown,
+}
+
+// ReconnectStage returns the request stage of a reconnect cause: "dns",
+// "tcp", "tls", "http", "read" or "unknown".
+func ReconnectStage(cause string) string {
+	stage, _, _ := strings.Cut(cause, " ")
+	return stage
+}
+
+// failureMark is a client's latest failure event, for attributing the
+// reconnect that usually follows it.
+type failureMark struct {
+	cause string
+	at    time.Time
+}
+
+// markFailure records a failure event's cause (parsing goroutine only).
+func (p *DebugEventParser) markFailure(now time.Time, cause string) {
+	p.lastFailure = failureMark{cause: cause, at: now}
+}
+
+// reconnectCause attributes a reconnect at now. FFmpeg's reconnects while
+// reading a body carry the read error ("error=Connection timed out");
+// reconnects while opening a connection don't, but follow the DNS, TCP,
+// TLS or HTTP failure that caused them within ReconnectCauseWindow.
+func (p *DebugEventParser) reconnectCause(now time.Time, readErr string) string {
+	if readErr != "" {
+		return readErrorCause(readErr)
+	}
+	if f := p.lastFailure; f.cause != "" && !now.Before(f.at) && now.Sub(f.at) <= ReconnectCauseWindow {
+		return f.cause
+	}
+	return ReconnectUnknown
+}
+
+// readErrorCause classifies the error of a reconnect while reading.
+func readErrorCause(msg string) string {
+	msg = strings.ToLower(msg)
+	switch {
+	case strings.Contains(msg, "timed out"), strings.Contains(msg, "timeout"):
+		return ReconnectReadTimeout
+	case strings.Contains(msg, "reset"), strings.Contains(msg, "broken pipe"):
+		return ReconnectReadReset
+	case strings.Contains(msg, "end of file"):
+		return ReconnectReadEOF
+	case strings.Contains(msg, "server returned 4"):
+		return ReconnectHTTP4xx
+	case strings.Contains(msg, "server returned 5"):
+		return ReconnectHTTP5xx
+	case strings.Contains(msg, "refused"):
+		return ReconnectTCPRefused
+	default:
+		return ReconnectReadError
+	}
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectCause(t *testing.T) {
+	const reconnect = "2026-01-23 08:12:55.000 [http @ 0x558f5f5da980] Will reconnect at 0 in 1 second(s)"
+	tests := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"no failure before", []string{reconnect}, ReconnectUnknown},
+		{"dns", []string{
+			"2026-01-23 08:12:54.900 [tcp @ 0x558f5f5ddbc0] Failed to resolve hostname origin.example: Name or service not known",
+			reconnect,
+		}, ReconnectDNS},
+		{"tcp refused", []string{
+			"2026-01-23 08:12:54.900 [tcp @ 0x558f5f5ddbc0] Connection to tcp://10.177.0.10:17080 failed: Connection refused",
+			reconnect,
+		}, ReconnectTCPRefused},
+		{"tcp timeout", []string{
+			"2026-01-23 08:12:54.900 [tcp @ 0x558f5f5ddbc0] Connection to tcp://10.177.0.10:17080 failed: Connection timed out",
+			reconnect,
+		}, ReconnectTCPTimeout},
+		{"tls", []string{
+			"2026-01-23 08:12:54.900 [tls @ 0x558f5f5ddbc0] error:0A000086:SSL routines::certificate verify failed",
+			reconnect,
+		}, ReconnectTLS},
+		{"http 503", []string{
+			"2026-01-23 08:12:54.900 [http @ 0x558f5f5da980] HTTP error 503 Service Unavailable",
+			reconnect,
+		}, ReconnectHTTP5xx},
+		{"http 404", []string{
+			"2026-01-23 08:12:54.900 [http @ 0x558f5f5da980] HTTP error 404 Not Found",
+			reconnect,
+		}, ReconnectHTTP4xx},
+		{"latest failure wins", []string{
+			"2026-01-23 08:12:54.500 [http @ 0x558f5f5da980] HTTP error 503 Service Unavailable",
+			"2026-01-23 08:12:54.900 [tcp @ 0x558f5f5ddbc0] Connection to tcp://10.177.0.10:17080 failed: Connection refused",
+			reconnect,
+		}, ReconnectTCPRefused},
+		{"failure outside the window", []string{
+			"2026-01-23 08:12:50.000 [http @ 0x558f5f5da980] HTTP error 503 Service Unavailable",
+			reconnect,
+		}, ReconnectUnknown},
+		{"read timeout", []string{
+			"2026-01-23 08:12:55.000 [http @ 0x558f5f5da980] Will reconnect at 1048576 in 1 second(s), error=Connection timed out.",
+		}, ReconnectReadTimeout},
+		{"read error beats an earlier failure", []string{
+			"2026-01-23 08:12:54.900 [http @ 0x558f5f5da980] HTTP error 503 Service Unavailable",
+			"2026-01-23 08:12:55.000 [http @ 0x558f5f5da980] [warning] Will reconnect at 1048576 in 1 second(s), error=End of file.",
+		}, ReconnectReadEOF},
+		{"read reset", []string{
+			"2026-01-23 08:12:55.000 [http @ 0x558f5f5da980] Will reconnect at 1048576 in 1 second(s), error=Connection reset by peer.",
+		}, ReconnectReadReset},
+		{"read, other error", []string{
+			"2026-01-23 08:12:55.000 [http @ 0x558f5f5da980] Will reconnect at 1048576 in 1 second(s), error=I/O error.",
+		}, ReconnectReadError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []*DebugEvent
+			p := NewDebugEventParser(1, 2*time.Second, func(e *DebugEvent) {
+				if e.Type == DebugEventReconnect {
+					got = append(got, e)
+				}
+			})
+			for _, line := range tt.lines {
+				p.ParseLine(line)
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d reconnect events, want 1", len(got))
+			}
+			if got[0].Cause != tt.want {
+				t.Errorf("Cause = %q, want %q", got[0].Cause, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconnectStage(t *testing.T) {
+	for cause, want := range map[string]string{
+		ReconnectDNS:         "dns",
+		ReconnectTCPRefused:  "tcp",
+		ReconnectHTTP5xx:     "http",
+		ReconnectReadTimeout: "read",
+		ReconnectUnknown:     "unknown",
+	} {
+		if got := ReconnectStage(cause); got != want {
+			t.Errorf("ReconnectStage(%q) = %q, want %q", cause, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RefreshIntervalBuckets are the upper bounds of the playlist refresh
+// interval histogram, as multiples of the target duration (1 = on time).
+var RefreshIntervalBuckets = []float64{0.5, 0.75, 0.9, 1, 1.1, 1.25, 1.5, 2, 3}
+
+const (
+	// RefreshStormBucket is the window a refresh storm is counted in:
+	// this many clients fetching the playlist within it hit the origin
+	// as one burst.
+	RefreshStormBucket = 100 * time.Millisecond
+
+	// refreshStormSlots is how many recent buckets stay open for lines
+	// that reach us late (3.2s).
+	refreshStormSlots = 32
+
+	// A storm is a bucket with refreshStormFactor times the refreshes an
+	// even spread over the target duration would put there, and at least
+	// refreshStormMin.
+	refreshStormFactor = 4
+	refreshStormMin    = 5
+)
+
+// RefreshStormThreshold returns the refreshes in one RefreshStormBucket
+// that make a storm for clients refreshing every target.
+func RefreshStormThreshold(clients int, target time.Duration) int {
+	if target <= 0 {
+		return refreshStormMin
+	}
+	even := float64(clients) * float64(RefreshStormBucket) / float64(target)
+	return max(refreshStormMin, int(math.Ceil(refreshStormFactor*even)))
+}
+
+// RefreshStats is a RefreshTracker's view of the swarm's playlist
+// refreshes.
+type RefreshStats struct {
+	// Counts holds the refresh intervals per RefreshIntervalBuckets bound
+	// (not cumulative), plus one for those above the last
+	Counts   []int64
+	RatioSum float64 // Sum of interval / target over all intervals
+
+	Storms    int64     // 100ms buckets that reached the storm threshold
+	Threshold int       // Refreshes per bucket that make a storm
+	Peak      int       // Most refreshes seen in one bucket
+	PeakAt    time.Time // Start of that bucket
+}
+
+// refreshSlot counts the refreshes of one RefreshStormBucket.
+type refreshSlot struct {
+	bucket int64 // Bucket index: UnixNano / RefreshStormBucket
+	n      int
+}
+
+// RefreshTracker collects the playlist refreshes of every parser attached
+// with SetRefreshTracker: how far each client's refresh interval is from
+// its target duration, and refresh storms, where many clients fetch the
+// playlist in the same 100ms and contend for it at the origin.
+type RefreshTracker struct {
+	threshold int
+
+	mu       sync.Mutex
+	counts   []int64
+	ratioSum float64
+	slots    [refreshStormSlots]refreshSlot
+	storms   int64
+	peak     int
+	peakAt   time.Time
+}
+
+// NewRefreshTracker returns a tracker that counts a storm at threshold
+// refreshes in one bucket (see RefreshStormThreshold; <= 0 = the minimum).
+func NewRefreshTracker(threshold int) *RefreshTracker {
+	if threshold <= 0 {
+		threshold = refreshStormMin
+	}
+	return &RefreshTracker{
+		threshold: threshold,
+		counts:    make([]int64, len(RefreshIntervalBuckets)+1),
+	}
+}
+
+// record adds a refresh at time at, and its interval from the client's
+// previous refresh as a multiple of the target duration (ratio < 0 for a
+// client's first refresh, which has none).
+func (t *RefreshTracker) record(at time.Time, ratio float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if ratio >= 0 {
+		i := 0
+		for i < len(RefreshIntervalBuckets) && ratio > RefreshIntervalBuckets[i] {
+			i++
+		}
+		t.counts[i]++
+		t.ratioSum += ratio
+	}
+
+	bucket := at.UnixNano() / int64(RefreshStormBucket)
+	slot := &t.slots[bucket%refreshStormSlots]
+	if slot.bucket != bucket {
+		if slot.bucket > bucket {
+			return // Older than the slots held open
+		}
+		*slot = refreshSlot{bucket: bucket}
+	}
+	slot.n++
+	if slot.n == t.threshold {
+		t.storms++
+	}
+	if slot.n > t.peak {
+		t.peak = slot.n
+		t.peakAt = time.Unix(0, bucket*int64(RefreshStormBucket))
+	}
+}
+
+// Stats returns the refreshes recorded so far.
+func (t *RefreshTracker) Stats() RefreshStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return RefreshStats{
+		Counts:    append([]int64(nil), t.counts...),
+		RatioSum:  t.ratioSum,
+		Storms:    t.storms,
+		Threshold: t.threshold,
+		Peak:      t.peak,
+		PeakAt:    t.peakAt,
+	}
+}
+
+// SetRefreshTracker attaches the parser to a swarm-wide refresh tracker:
+// every playlist refresh from now on is also recorded in t. Call before
+// parsing starts.
+func (p *DebugEventParser) SetRefreshTracker(t *RefreshTracker) {
+	p.refreshes = t
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshStormThreshold(t *testing.T) {
+	tests := []struct {
+		clients int
+		target  time.Duration
+		want    int
+	}{
+		{10, 6 * time.Second, refreshStormMin},
+		{1000, 6 * time.Second, 67}, // 16.7 per 100ms spread evenly, x4
+		{1000, 2 * time.Second, 200},
+		{1000, 0, refreshStormMin},
+	}
+	for _, tt := range tests {
+		if got := RefreshStormThreshold(tt.clients, tt.target); got != tt.want {
+			t.Errorf("RefreshStormThreshold(%d, %v) = %d, want %d", tt.clients, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshTracker(t *testing.T) {
+	tr := NewRefreshTracker(3)
+	start := time.Date(2026, 1, 23, 8, 0, 0, 0, time.UTC)
+
+	// Histogram: bounds are inclusive upper limits
+	for _, ratio := range []float64{-1, 0.4, 1, 1.05, 5} {
+		tr.record(start, ratio)
+	}
+	s := tr.Stats()
+	want := []int64{1, 0, 0, 1, 1, 0, 0, 0, 0, 1}
+	for i := range want {
+		if s.Counts[i] != want[i] {
+			t.Fatalf("Counts = %v, want %v", s.Counts, want)
+		}
+	}
+	if s.RatioSum < 7.44 || s.RatioSum > 7.46 {
+		t.Errorf("RatioSum = %v, want 7.45 (first refreshes have no interval)", s.RatioSum)
+	}
+
+	// All five fell in one 100ms bucket: one storm, counted once
+	if s.Storms != 1 || s.Peak != 5 || !s.PeakAt.Equal(start) {
+		t.Errorf("storms = %d, peak %d at %v; want 1, 5 at %v", s.Storms, s.Peak, s.PeakAt, start)
+	}
+
+	// Spread out: no storm
+	for i := 1; i <= 10; i++ {
+		tr.record(start.Add(time.Duration(i)*RefreshStormBucket), 1)
+	}
+	// Too late to count: its slot has moved on
+	tr.record(start.Add(RefreshStormBucket*(1-refreshStormSlots)), 1)
+	if s := tr.Stats(); s.Storms != 1 || s.Peak != 5 {
+		t.Errorf("storms = %d, peak %d; want 1, 5", s.Storms, s.Peak)
+	}
+}
+
+func TestDebugEventParser_RefreshTracker(t *testing.T) {
+	tr := NewRefreshTracker(2)
+	lines := []string{
+		"2026-01-23 08:00:00.000 [hls @ 0x55c32c0c5700] [debug] Opening 'http://origin/stream.m3u8' for reading",
+		"2026-01-23 08:00:02.000 [hls @ 0x55c32c0c5700] [debug] Opening 'http://origin/stream.m3u8' for reading",
+		"2026-01-23 08:00:05.000 [hls @ 0x55c32c0c5700] [debug] Opening 'http://origin/stream.m3u8' for reading",
+	}
+	for id := 0; id < 2; id++ {
+		p := NewDebugEventParser(id, 2*time.Second, nil)
+		p.SetRefreshTracker(tr)
+		for _, line := range lines {
+			p.ParseLine(line)
+		}
+	}
+
+	s := tr.Stats()
+	if got := s.Counts[3]; got != 2 {
+		t.Errorf("on-time intervals = %d, want 2 (one per client)", got)
+	}
+	if got := s.Counts[6]; got != 2 {
+		t.Errorf("1.5x intervals = %d, want 2", got)
+	}
+	if s.Peak == 0 {
+		t.Error("Peak = 0, want refreshes counted for storm detection")
+	}
+}
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Serving classes of a response, from its headers (see DebugStats.Serving).
+const (
+	ServedEdgeHit  = "edge-hit"  // A CDN or cache answered from its cache
+	ServedEdgeMiss = "edge-miss" // A CDN or cache fetched it from the origin
+	ServedEdge     = "edge"      // Through a CDN, cache status not reported
+	ServedOrigin   = "origin"    // No CDN or cache in the path
+)
+
+// ServingStats are the responses of one serving class.
+type ServingStats struct {
+	Responses int64
+	Segments  int64         // Of them, media segments
+	Bytes     int64         // Content-Length total
+	TTFBSum   time.Duration // Request to first response header
+	TTFBCount int64
+}
+
+// reHTTPHeader matches a response header, as FFmpeg logs them at trace
+// level: "header='Via: 1.1 varnish'" (or "header: Via: ...").
+var reHTTPHeader = regexp.MustCompile(`\[http @ 0x[0-9a-f]+\] (?:\[(?:trace|debug|verbose|info)\] )?header(?:='|:\s*)([A-Za-z0-9-]+):\s*(.*?)'?$`)
+
+// cacheStatusHeaders report whether a cache answered: X-Cache
+// (CloudFront, Fastly, Varnish), CF-Cache-Status (Cloudflare),
+// X-Cache-Status (nginx proxy_cache) and the like.
+var cacheStatusHeaders = map[string]bool{
+	"x-cache":             true,
+	"x-cache-status":      true,
+	"x-proxy-cache":       true,
+	"cf-cache-status":     true,
+	"x-cache-lookup":      true,
+	"cdn-cache":           true,
+	"x-edge-cache":        true,
+	"x-fastcgi-cache":     true,
+	"x-varnish-cache":     true,
+	"akamai-cache-status": true,
+}
+
+// cdnHeaders are only set by CDNs: their presence alone puts an edge in
+// the path.
+var cdnHeaders = map[string]bool{
+	"via":                 true,
+	"x-served-by":         true, // Fastly
+	"x-amz-cf-pop":        true, // CloudFront
+	"x-amz-cf-id":         true,
+	"cf-ray":              true, // Cloudflare
+	"x-akamai-request-id": true,
+	"x-edge-location":     true,
+	"x-cdn":               true,
+}
+
+// cdnServers are Server header values of CDN edges (lower case
+// substrings).
+var cdnServers = []string{"cloudflare", "cloudfront", "akamai", "fastly", "varnish", "ecacc", "bunnycdn", "keycdn", "cdn"}
+
+// responseState is what the headers of the response being read said.
+type responseState struct {
+	open      bool // A request was sent and not yet accounted
+	segment   bool
+	start     time.Time
+	firstByte time.Time // First header (zero = none yet)
+	bytes     int64
+
+	cache   int  // 1 = hit, -1 = miss, 0 = not reported
+	cdn     bool // A CDN header or server
+	aged    bool // Age > 0: a cached copy
+	headers bool
+}
+
+// class returns the response's serving class ("" = no headers seen,
+// i.e. FFmpeg doesn't log them at this loglevel).
+func (r *responseState) class() string {
+	switch {
+	case !r.headers:
+		return ""
+	case r.cache > 0:
+		return ServedEdgeHit
+	case r.cache < 0:
+		return ServedEdgeMiss
+	case r.cdn && r.aged:
+		return ServedEdgeHit
+	case r.cdn:
+		return ServedEdge
+	default:
+		return ServedOrigin
+	}
+}
+
+// header applies one response header.
+func (r *responseState) header(name, value string) {
+	r.headers = true
+	name = strings.ToLower(name)
+	switch {
+	case cacheStatusHeaders[name]:
+		r.cdn = true
+		if status := cacheStatus(value); status != 0 {
+			r.cache = status
+		}
+	case cdnHeaders[name]:
+		r.cdn = true
+	case name == "server":
+		server := strings.ToLower(value)
+		for _, cdn := range cdnServers {
+			if strings.Contains(server, cdn) {
+				r.cdn = true
+				break
+			}
+		}
+	case name == "age":
+		if age, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && age > 0 {
+			r.aged = true
+		}
+	case name == "content-length":
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			r.bytes = n
+		}
+	}
+}
+
+// cacheStatus reads a cache status header: 1 for a hit, -1 for a miss, 0
+// if it says neither. With several caches listed ("MISS, HIT" from a
+// shield and an edge) the last, closest to the client, counts.
+func cacheStatus(value string) int {
+	parts := strings.Split(value, ",")
+	last := strings.ToUpper(strings.TrimSpace(parts[len(parts)-1]))
+	switch {
+	case strings.Contains(last, "HIT"), strings.Contains(last, "STALE"),
+		strings.Contains(last, "UPDATING"), strings.Contains(last, "REVALIDATED"):
+		return 1
+	case strings.Contains(last, "MISS"), strings.Contains(last, "EXPIRED"),
+		strings.Contains(last, "BYPASS"), strings.Contains(last, "DYNAMIC"),
+		strings.Contains(last, "PASS"), strings.Contains(last, "REFRESH"):
+		return -1
+	}
+	return 0
+}
+
+// startResponse accounts the previous response and starts tracking the
+// one for a new request. Called with p.mu held.
+func (p *DebugEventParser) startResponse(now time.Time, path string) {
+	p.finishResponse()
+	p.resp = responseState{open: true, segment: !strings.Contains(path, ".m3u8"), start: now}
+}
+
+// responseHeader applies a header of the current response. Called with
+// p.mu held.
+func (p *DebugEventParser) responseHeader(now time.Time, name, value string) {
+	if !p.resp.open {
+		return
+	}
+	if p.resp.firstByte.IsZero() {
+		p.resp.firstByte = now
+	}
+	p.resp.header(name, value)
+}
+
+// finishResponse counts the current response under its serving class.
+// Called with p.mu held.
+func (p *DebugEventParser) finishResponse() {
+	r := &p.resp
+	class := r.class()
+	if !r.open || class == "" {
+		p.resp = responseState{}
+		return
+	}
+	s := p.serving[class]
+	s.Responses++
+	if r.segment {
+		s.Segments++
+	}
+	s.Bytes += r.bytes
+	if ttfb := r.firstByte.Sub(r.start); ttfb >= 0 {
+		s.TTFBSum += ttfb
+		s.TTFBCount++
+	}
+	p.serving[class] = s
+	p.resp = responseState{}
+}
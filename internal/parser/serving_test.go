@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheStatus(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"HIT", 1},
+		{"Hit from cloudfront", 1},
+		{"TCP_MEM_HIT", 1},
+		{"STALE", 1},
+		{"Miss from cloudfront", -1},
+		{"MISS, HIT", 1}, // Shield missed, edge hit
+		{"HIT, MISS", -1},
+		{"EXPIRED", -1},
+		{"BYPASS", -1},
+		{"DYNAMIC", -1},
+		{"", 0},
+		{"ok", 0},
+	}
+	for _, tt := range tests {
+		if got := cacheStatus(tt.value); got != tt.want {
+			t.Errorf("cacheStatus(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestResponseState_Class(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers [][2]string
+		want    string
+	}{
+		{"no headers", nil, ""},
+		{"plain origin", [][2]string{{"Server", "nginx/1.25"}, {"Content-Length", "100"}}, ServedOrigin},
+		{"x-cache hit", [][2]string{{"X-Cache", "Hit from cloudfront"}}, ServedEdgeHit},
+		{"cloudflare miss", [][2]string{{"Server", "cloudflare"}, {"CF-Cache-Status", "MISS"}}, ServedEdgeMiss},
+		{"nginx proxy cache", [][2]string{{"Server", "nginx"}, {"X-Cache-Status", "HIT"}}, ServedEdgeHit},
+		{"via only", [][2]string{{"Via", "1.1 varnish"}}, ServedEdge},
+		{"cdn server", [][2]string{{"Server", "AkamaiGHost"}}, ServedEdge},
+		{"cdn with age", [][2]string{{"Via", "1.1 abc.cloudfront.net"}, {"Age", "12"}}, ServedEdgeHit},
+		{"age without cdn", [][2]string{{"Age", "12"}}, ServedOrigin},
+		{"age zero", [][2]string{{"X-Served-By", "cache-fra1"}, {"Age", "0"}}, ServedEdge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r responseState
+			for _, h := range tt.headers {
+				r.header(h[0], h[1])
+			}
+			if got := r.class(); got != tt.want {
+				t.Errorf("class() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugEventParser_Serving(t *testing.T) {
+	p := NewDebugEventParser(1, 2*time.Second, nil)
+	lines := []string{
+		// Segment through a CDN, cache hit
+		"2026-01-30 16:25:36.900 [http @ 0x55c32c0c5800] [debug] request: GET /seg00001.ts HTTP/1.1",
+		"2026-01-30 16:25:36.920 [http @ 0x55c32c0c5800] [trace] header='HTTP/1.1 200 OK'",
+		"2026-01-30 16:25:36.920 [http @ 0x55c32c0c5800] [trace] header='Via: 1.1 varnish'",
+		"2026-01-30 16:25:36.921 [http @ 0x55c32c0c5800] [trace] header='X-Cache: HIT'",
+		"2026-01-30 16:25:36.921 [http @ 0x55c32c0c5800] [trace] header='Content-Length: 1000'",
+		// Playlist from the origin
+		"2026-01-30 16:25:37.000 [http @ 0x55c32c0c5800] [debug] request: GET /stream.m3u8 HTTP/1.1",
+		"2026-01-30 16:25:37.050 [http @ 0x55c32c0c5800] [trace] header='Server: nginx'",
+		// Headers not logged: not classified
+		"2026-01-30 16:25:38.000 [http @ 0x55c32c0c5800] [debug] request: GET /seg00002.ts HTTP/1.1",
+		// Accounts the one before
+		"2026-01-30 16:25:39.000 [http @ 0x55c32c0c5800] [debug] request: GET /seg00003.ts HTTP/1.1",
+	}
+	for _, line := range lines {
+		p.ParseLine(line)
+	}
+
+	s := p.Stats().Serving
+	if len(s) != 2 {
+		t.Fatalf("Serving = %+v, want edge-hit and origin", s)
+	}
+	hit := s[ServedEdgeHit]
+	if hit.Responses != 1 || hit.Segments != 1 || hit.Bytes != 1000 || hit.TTFBCount != 1 || hit.TTFBSum != 20*time.Millisecond {
+		t.Errorf("edge-hit = %+v, want 1 segment, 1000 bytes, 20ms TTFB", hit)
+	}
+	origin := s[ServedOrigin]
+	if origin.Responses != 1 || origin.Segments != 0 || origin.TTFBSum != 50*time.Millisecond {
+		t.Errorf("origin = %+v, want 1 playlist, 50ms TTFB", origin)
+	}
+}
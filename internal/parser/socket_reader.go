@@ -4,7 +4,6 @@
 package parser
 
 import (
-	"bufio"
 	"fmt"
 	"log/slog"
 	"net"
@@ -184,12 +183,7 @@ func (r *SocketReader) Run() {
 		r.logger.Debug("socket connection accepted", "path", r.socketPath)
 	}
 
-	scanner := bufio.NewScanner(conn)
-
-	// Increase buffer for long lines (default is 64KB)
-	const maxLineSize = 64 * 1024
-	scanner.Buffer(make([]byte, maxLineSize), maxLineSize)
-
+	scanner := r.pipeline.newLineScanner(conn)
 	for scanner.Scan() {
 		line := scanner.Text()
 		r.bytesRead.Add(int64(len(line) + 1)) // +1 for newline
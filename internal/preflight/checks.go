@@ -3,6 +3,7 @@ package preflight
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -214,14 +215,19 @@ func checkEphemeralPorts(clients int) Check {
 
 // PrintResults prints the preflight check results to stdout.
 func PrintResults(result *Result) {
-	fmt.Println("Preflight checks:")
+	WriteResults(os.Stdout, result)
+}
+
+// WriteResults writes the preflight check results to w.
+func WriteResults(w io.Writer, result *Result) {
+	fmt.Fprintln(w, "Preflight checks:")
 	for _, check := range result.Checks {
-		fmt.Println(check.String())
+		fmt.Fprintln(w, check.String())
 		if !check.Passed {
-			fmt.Printf("    Fix: %s\n", suggestFix(check.Name))
+			fmt.Fprintf(w, "    Fix: %s\n", suggestFix(check.Name))
 		}
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 }
 
 // suggestFix returns a suggestion for fixing a failed check.
@@ -336,3 +336,21 @@ func TestPrintResults(t *testing.T) {
 	// Should not panic
 	PrintResults(result)
 }
+
+func TestWriteResults(t *testing.T) {
+	result := &Result{
+		Checks: []Check{
+			{Name: "test1", Passed: true, Message: "ok"},
+			{Name: "file_descriptors", Passed: false, Required: 100, Actual: 50},
+		},
+	}
+
+	var b strings.Builder
+	WriteResults(&b, result)
+	out := b.String()
+	for _, want := range []string{"Preflight checks:\n", "  ✓ test1: ok\n", "  ✗ file_descriptors: 50 available (need 100)\n", "    Fix: "} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteResults() missing %q:\n%s", want, out)
+		}
+	}
+}
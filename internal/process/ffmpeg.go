@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
 )
 
 // VariantSelection specifies which HLS variants to download.
@@ -42,10 +46,21 @@ type FFmpegConfig struct {
 	// Variant specifies which quality level(s) to download.
 	Variant VariantSelection
 
+	// AudioRendition and SubtitleRendition add the master playlist's
+	// alternate renditions (#EXT-X-MEDIA) to a single variant's streams,
+	// as a player does: a LANGUAGE ("en") or "all". Empty keeps the
+	// variant's first audio stream and no subtitles.
+	AudioRendition    string
+	SubtitleRendition string
+
 	// UserAgent is the HTTP User-Agent header base.
 	// Client ID will be appended for per-client identification.
 	UserAgent string
 
+	// RunTag is added to the User-Agent, after the base, so the origin's
+	// access log can tell this run's requests apart ("" = none).
+	RunTag string
+
 	// Timeout is the network read/write timeout.
 	Timeout time.Duration
 
@@ -71,9 +86,69 @@ type FFmpegConfig struct {
 	// NoCache adds cache-busting headers to bypass CDN caches.
 	NoCache bool
 
-	// Headers are additional HTTP headers to send.
+	// NoKeepAlive disables persistent HTTP connections, so every playlist
+	// and segment request opens (and closes) its own TCP connection.
+	NoKeepAlive bool
+
+	// Prefetch is how many segments the HLS demuxer downloads ahead of the
+	// one being read, on a second connection: 0 or 1 (-1 = FFmpeg default).
+	Prefetch int
+
+	// Headers are additional HTTP headers to send. Values may use the
+	// HeaderVars templates, expanded each time a client process starts.
 	Headers []string
 
+	// AcceptEncoding replaces FFmpeg's Accept-Encoding ("" = FFmpeg default).
+	// FFmpeg only decodes gzip and deflate: other codings reach the demuxer
+	// still compressed and fail as unparseable playlists.
+	AcceptEncoding string
+
+	// ClientHeaders returns extra headers for one client, sent after Headers
+	// and templated the same way (nil = none). Used for -geo cohorts.
+	ClientHeaders func(clientID int) []string
+
+	// Env is extra environment for FFmpeg, as KEY=VALUE, on top of the
+	// swarm's own. Values may use the HeaderVars templates.
+	Env []string
+
+	// ClientEnv returns extra environment for one client, after Env and
+	// templated the same way (nil = none). Used for -geo cohorts.
+	ClientEnv func(clientID int) []string
+
+	// ClientURL returns the playlist URL for one client instead of
+	// StreamURL ("" or nil = StreamURL). Used for -variant-mix.
+	ClientURL func(clientID int) string
+
+	// ClientOptions returns request options for one client that replace
+	// the shared ones (nil = none). Used for -compare-opt cohorts.
+	ClientOptions func(clientID int) *RequestOptions
+
+	// Pacing is how fast the clients read the stream (zero = as fast as
+	// FFmpeg can).
+	Pacing Pacing
+
+	// ClientPacing returns the pacing of one client instead of Pacing
+	// (nil = Pacing). Used for -pacing geo cohorts.
+	ClientPacing func(clientID int) *Pacing
+
+	// ClientStartOffset returns where in a VOD asset one client starts
+	// playing, drawn at each process start (nil or 0 = the start). Used
+	// for -vod-start.
+	ClientStartOffset func(clientID int) time.Duration
+
+	// ClientDebug reports whether a client runs at debug loglevel; the
+	// others then run at verbose, whatever StatsLogLevel says (nil = all
+	// at StatsLogLevel). Used for -debug-sample.
+	ClientDebug func(clientID int) bool
+
+	// ExtraArgs are passed through as input options, just before -i
+	// (-ffmpeg-extra-args, checked by config validation).
+	ExtraArgs []string
+
+	// TokenSource supplies {token} for Headers, fetched on every process
+	// start (nil = no session tokens).
+	TokenSource TokenSource
+
 	// ProgramID is the HLS program ID for highest/lowest variant selection.
 	// Set by ProbeVariants().
 	ProgramID int
@@ -82,6 +157,21 @@ type FFmpegConfig struct {
 	StatsEnabled  bool   // Enable -progress output
 	StatsLogLevel string // Override LogLevel when stats enabled ("verbose" or "debug")
 
+	// ProgressMode is where -progress output goes with stats ("" = pipe,
+	// see parser.ProgressMode); ProgressSocketDir holds the sockets of
+	// socket mode ("" = os.TempDir()).
+	ProgressMode      parser.ProgressMode
+	ProgressSocketDir string
+
+	// NoLogDatetime leaves the datetime flag out of the stats loglevel,
+	// for FFmpeg 6.x and older: they refuse a -loglevel with it. Lines then
+	// carry no timestamp and are timed as they arrive.
+	NoLogDatetime bool
+
+	// ResponseHeaders raises the stats loglevel to trace, the only level
+	// FFmpeg logs response headers at (for CDN vs origin detection).
+	ResponseHeaders bool
+
 	// DebugLogging enables -loglevel debug for detailed segment timing.
 	// Only safe when socket mode is enabled (otherwise debug output
 	// would corrupt progress parsing on stdout).
@@ -101,6 +191,7 @@ func DefaultFFmpegConfig(streamURL string) *FFmpegConfig {
 		SegMaxRetry:       3,
 		LogLevel:          "info",
 		ProgramID:         -1, // Not set
+		Prefetch:          -1,
 	}
 }
 
@@ -117,6 +208,15 @@ type FFmpegRunner struct {
 	// clientID is set during BuildCommand for per-client User-Agent.
 	// This enables correlation with origin logs and packet captures.
 	clientID int
+
+	// vars are the header template values, set during BuildCommand
+	// (nil outside BuildCommand: templates are left unexpanded).
+	vars *TemplateValues
+
+	// mu serializes BuildCommand, which supervisors call concurrently on
+	// the shared runner; starts counts BuildCommand calls per client.
+	mu     sync.Mutex
+	starts map[int]int
 }
 
 // NewFFmpegRunner creates a new FFmpeg runner with the given configuration.
@@ -143,45 +243,62 @@ func (r *FFmpegRunner) SetProgressFD(fd int) {
 
 // BuildCommand creates an exec.Cmd for FFmpeg with all configured options.
 func (r *FFmpegRunner) BuildCommand(ctx context.Context, clientID int) (*exec.Cmd, error) {
+	r.mu.Lock()
+	if r.starts == nil {
+		r.starts = make(map[int]int)
+	}
+	r.starts[clientID]++
+	vars := NewTemplateValues(clientID, r.starts[clientID])
+	r.mu.Unlock()
+
+	// Outside the lock: one slow token fetch mustn't hold up other clients
+	if r.config.TokenSource != nil {
+		token, err := r.config.TokenSource.Token(ctx, vars)
+		if err != nil {
+			return nil, err
+		}
+		vars.Token = token
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.clientID = clientID // Capture for per-client User-Agent
+	r.vars = &vars
 	args := r.buildArgs()
+	env := r.buildEnv()
+	r.vars = nil
 	cmd := exec.CommandContext(ctx, r.config.BinaryPath, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	return cmd, nil
 }
 
+// buildEnv returns the extra environment for the client being built, or
+// nil if there is none.
+func (r *FFmpegRunner) buildEnv() []string {
+	var env []string
+	if r.config.ClientEnv != nil && r.vars != nil {
+		env = r.config.ClientEnv(r.vars.ClientID)
+	}
+	if len(r.config.Env) == 0 && len(env) == 0 {
+		return nil
+	}
+	env = append(append([]string(nil), r.config.Env...), env...)
+	if r.vars != nil {
+		env = r.vars.ExpandHeaders(env)
+	}
+	return env
+}
+
 // buildArgs constructs the FFmpeg command-line arguments.
 func (r *FFmpegRunner) buildArgs() []string {
-	// Determine log level
-	logLevel := r.config.LogLevel
-
-	// Use StatsLogLevel when stats enabled
-	if r.config.StatsEnabled && r.config.StatsLogLevel != "" {
-		logLevel = r.config.StatsLogLevel
-	}
-
-	// When stats are enabled, ALWAYS use timestamped logging for accurate metrics.
-	// Format: "repeat+level+datetime+<level>" provides:
-	//   - repeat: Don't collapse repeated messages
-	//   - level: Add [debug]/[verbose]/[info] tags for filtering
-	//   - datetime: Millisecond-precision timestamps (YYYY-MM-DD HH:MM:SS.mmm)
-	//
-	// This is critical because timestamps come directly from FFmpeg, not from
-	// when Go processes the lines. Even if logs back up in channels, we get
-	// accurate timing for TCP connects, segment downloads, and playlist refreshes.
-	if r.config.StatsEnabled {
-		// Determine base level for timestamped logging
-		// Default to "debug" to capture manifest refreshes ([hls @ ...] Opening '...m3u8')
-		// which are logged at DEBUG level. Verbose only captures segment requests.
-		baseLevel := "debug" // Default to debug for stats (required for manifest tracking)
-		if r.config.DebugLogging {
-			// Full debug when enabled (safe - progress is on separate FD)
-			baseLevel = "debug"
-		} else if r.config.StatsLogLevel != "" {
-			// Use configured stats level (allows override to verbose if needed)
-			baseLevel = r.config.StatsLogLevel
-		}
-		logLevel = "repeat+level+datetime+" + baseLevel
-	}
+	// When stats are enabled, ALWAYS use timestamped logging for accurate
+	// metrics (see StatsLogLevel): timestamps come directly from FFmpeg, not
+	// from when Go processes the lines. Even if logs back up in channels, we
+	// get accurate timing for TCP connects, segment downloads, and playlist
+	// refreshes.
+	logLevel := r.logLevel()
 
 	args := []string{
 		"-hide_banner",
@@ -189,9 +306,15 @@ func (r *FFmpegRunner) buildArgs() []string {
 		"-loglevel", logLevel,
 	}
 
-	// Progress output for stats parsing
-	// Always uses FD mode (pipe:3) when stats are enabled for clean separation from stderr
-	if r.config.StatsEnabled {
+	// Progress output for stats parsing, kept apart from stderr: FD mode
+	// (pipe:3) unless ProgressMode says otherwise
+	switch {
+	case !r.config.StatsEnabled || r.config.ProgressMode == parser.ProgressOff:
+		// No -progress: nothing reads it
+	case r.config.ProgressMode == parser.ProgressSocket:
+		args = append(args, "-progress", "unix://"+parser.ProgressSocketPath(r.config.ProgressSocketDir, r.clientID))
+		args = append(args, "-stats_period", "1")
+	default:
 		if r.progressFD > 0 {
 			// FD mode: use file descriptor for cleaner separation from stderr
 			// No filesystem files needed, completely ephemeral
@@ -228,14 +351,19 @@ func (r *FFmpegRunner) buildArgs() []string {
 	// - tcpdump: tcpdump -A | grep "client-42"
 	// - Wireshark: http.user_agent contains "client-42"
 	// - Nginx: grep "client-42" access.log
-	userAgent := r.config.UserAgent
+	// With -tag-requests: "go-ffmpeg-hls-swarm/1.0/run-1a2b3c4d/client-42"
+	opts := r.requestOptions()
+	userAgent := opts.UserAgent
+	if r.config.RunTag != "" {
+		userAgent += "/" + r.config.RunTag
+	}
 	if r.clientID > 0 {
-		userAgent = fmt.Sprintf("%s/client-%d", r.config.UserAgent, r.clientID)
+		userAgent = fmt.Sprintf("%s/client-%d", userAgent, r.clientID)
 	}
 	args = append(args, "-user_agent", userAgent)
 
 	// HTTP headers
-	headers := r.buildHeaders()
+	headers := r.buildHeaders(opts)
 	if len(headers) > 0 {
 		args = append(args, "-headers", strings.Join(headers, "\r\n")+"\r\n")
 	}
@@ -243,12 +371,39 @@ func (r *FFmpegRunner) buildArgs() []string {
 	// Segment retry
 	args = append(args, "-seg_max_retry", strconv.Itoa(r.config.SegMaxRetry))
 
+	// Connection reuse: the HLS demuxer keeps connections open by default.
+	// Without it FFmpeg also sends "Connection: close", so the origin sees a
+	// fresh connection per request even behind a keep-alive proxy.
+	if opts.NoKeepAlive {
+		args = append(args, "-http_persistent", "0")
+	}
+
+	// Segment prefetch (-prefetch): with -http_multiple the demuxer requests
+	// the next segment as soon as it opens the current one
+	if r.config.Prefetch >= 0 {
+		args = append(args, "-http_multiple", strconv.Itoa(r.config.Prefetch))
+	}
+
+	// Input pacing (-pacing)
+	args = append(args, r.pacing().args()...)
+
+	// Start position in a VOD asset (-vod-start): an input seek, so the
+	// HLS demuxer starts at the segment holding it
+	if r.config.ClientStartOffset != nil && r.vars != nil {
+		if d := r.config.ClientStartOffset(r.vars.ClientID); d > 0 {
+			args = append(args, "-ss", strconv.FormatFloat(d.Seconds(), 'f', 3, 64))
+		}
+	}
+
+	// Pass-through input options (-ffmpeg-extra-args)
+	args = append(args, r.config.ExtraArgs...)
+
 	// Input URL (potentially rewritten for IP override)
 	inputURL := r.effectiveURL()
 	args = append(args, "-i", inputURL)
 
 	// Output mapping based on variant selection
-	args = append(args, r.mapArgs()...)
+	args = append(args, r.mapArgs(opts.Variant)...)
 
 	// Output: copy streams to null (no decode)
 	args = append(args, "-c", "copy", "-f", "null", "-")
@@ -256,8 +411,9 @@ func (r *FFmpegRunner) buildArgs() []string {
 	return args
 }
 
-// buildHeaders constructs HTTP headers based on configuration.
-func (r *FFmpegRunner) buildHeaders() []string {
+// buildHeaders constructs HTTP headers based on configuration and the
+// client's request options.
+func (r *FFmpegRunner) buildHeaders(opts resolvedOptions) []string {
 	var headers []string
 
 	// Host header for IP override (preserve original hostname)
@@ -269,29 +425,150 @@ func (r *FFmpegRunner) buildHeaders() []string {
 	}
 
 	// Cache bypass headers
-	if r.config.NoCache {
+	if opts.NoCache {
 		headers = append(headers,
 			"Cache-Control: no-cache, no-store, must-revalidate",
 			"Pragma: no-cache",
 		)
 	}
 
-	// Custom headers
-	headers = append(headers, r.config.Headers...)
+	if r.config.AcceptEncoding != "" {
+		headers = append(headers, "Accept-Encoding: "+r.config.AcceptEncoding)
+	}
+
+	// Custom headers, templated per process start
+	if r.vars != nil {
+		headers = append(headers, r.vars.ExpandHeaders(opts.Headers)...)
+		if r.config.ClientHeaders != nil {
+			headers = append(headers, r.vars.ExpandHeaders(r.config.ClientHeaders(r.vars.ClientID))...)
+		}
+	} else {
+		headers = append(headers, opts.Headers...)
+	}
 
 	return headers
 }
 
+// RequestOptions are the request options a client can override (see
+// FFmpegConfig.ClientOptions). Zero values keep the shared setting.
+type RequestOptions struct {
+	// NoKeepAlive overrides FFmpegConfig.NoKeepAlive (nil = shared)
+	NoKeepAlive *bool
+
+	// UserAgent replaces the User-Agent base; the client suffix is kept
+	UserAgent string
+
+	// Variant replaces the variant selection. Only VariantAll and
+	// VariantFirst: the highest and lowest programs are probed for the
+	// shared selection.
+	Variant VariantSelection
+
+	// NoCache overrides FFmpegConfig.NoCache (nil = shared)
+	NoCache *bool
+
+	// Headers replace the shared custom headers, FFmpegConfig.Headers
+	// (nil = shared; empty = none). ClientHeaders are still added.
+	Headers []string
+}
+
+// resolvedOptions are a client's effective request options.
+type resolvedOptions struct {
+	NoKeepAlive bool
+	UserAgent   string
+	Variant     VariantSelection
+	NoCache     bool
+	Headers     []string
+}
+
+// Pacing is how fast a client reads the stream, which shapes the load it
+// puts on the origin. The zero value reads as fast as FFmpeg can.
+type Pacing struct {
+	// Realtime reads at the stream's own rate (-readrate 1)
+	Realtime bool
+
+	// Burst, with Realtime, first reads this far ahead at full speed, like
+	// a player filling its buffer (-readrate_initial_burst)
+	Burst time.Duration
+}
+
+// args returns the input options for p.
+func (p Pacing) args() []string {
+	if !p.Realtime {
+		return nil
+	}
+	args := []string{"-readrate", "1"}
+	if p.Burst > 0 {
+		args = append(args, "-readrate_initial_burst", strconv.FormatFloat(p.Burst.Seconds(), 'f', -1, 64))
+	}
+	return args
+}
+
+// pacing returns the pacing of the client being built.
+func (r *FFmpegRunner) pacing() Pacing {
+	if r.config.ClientPacing != nil && r.vars != nil {
+		if p := r.config.ClientPacing(r.vars.ClientID); p != nil {
+			return *p
+		}
+	}
+	return r.config.Pacing
+}
+
+// requestOptions returns the request options of the client being built:
+// the shared ones, with its ClientOptions applied.
+func (r *FFmpegRunner) requestOptions() resolvedOptions {
+	opts := resolvedOptions{
+		NoKeepAlive: r.config.NoKeepAlive,
+		UserAgent:   r.config.UserAgent,
+		Variant:     r.config.Variant,
+		NoCache:     r.config.NoCache,
+		Headers:     r.config.Headers,
+	}
+	if r.config.ClientOptions == nil || r.vars == nil {
+		return opts
+	}
+	o := r.config.ClientOptions(r.vars.ClientID)
+	if o == nil {
+		return opts
+	}
+	if o.NoKeepAlive != nil {
+		opts.NoKeepAlive = *o.NoKeepAlive
+	}
+	if o.UserAgent != "" {
+		opts.UserAgent = o.UserAgent
+	}
+	if o.Variant != "" {
+		opts.Variant = o.Variant
+	}
+	if o.NoCache != nil {
+		opts.NoCache = *o.NoCache
+	}
+	if o.Headers != nil {
+		opts.Headers = o.Headers
+	}
+	return opts
+}
+
+// streamURL returns the playlist URL of the client being built.
+func (r *FFmpegRunner) streamURL() string {
+	if r.config.ClientURL != nil && r.vars != nil {
+		if u := r.config.ClientURL(r.vars.ClientID); u != "" {
+			return u
+		}
+	}
+	return r.config.StreamURL
+}
+
 // effectiveURL returns the URL to use, potentially with IP override.
 func (r *FFmpegRunner) effectiveURL() string {
+	streamURL := r.streamURL()
 	if r.config.ResolveIP == "" {
-		return r.config.StreamURL
+		return streamURL
 	}
 
 	// Replace hostname with IP address
-	u, err := url.Parse(r.config.StreamURL)
+	u, err := url.Parse(streamURL)
 	if err != nil {
-		return r.config.StreamURL
+		return streamURL
 	}
 
 	// Preserve port if specified
@@ -306,22 +583,32 @@ func (r *FFmpegRunner) effectiveURL() string {
 }
 
 // mapArgs returns the -map arguments based on variant selection.
-func (r *FFmpegRunner) mapArgs() []string {
-	switch r.config.Variant {
+func (r *FFmpegRunner) mapArgs(variant VariantSelection) []string {
+	switch variant {
 	case VariantAll:
 		// Map all streams
 		return []string{"-map", "0"}
 
 	case VariantFirst:
 		// Map first video and first audio (if present)
+		if r.hasRenditions() {
+			return r.renditionArgs("0", "0:v:0?")
+		}
 		return []string{"-map", "0:v:0?", "-map", "0:a:0?"}
 
 	case VariantHighest, VariantLowest:
 		// Map specific program (determined by ffprobe)
 		if r.config.ProgramID >= 0 {
-			return []string{"-map", fmt.Sprintf("0:p:%d", r.config.ProgramID)}
+			program := fmt.Sprintf("0:p:%d", r.config.ProgramID)
+			if r.hasRenditions() {
+				return r.renditionArgs(program, program+":v?")
+			}
+			return []string{"-map", program}
 		}
 		// Fallback to first variant if not probed
+		if r.hasRenditions() {
+			return r.renditionArgs("0", "0:v:0?")
+		}
 		return []string{"-map", "0:v:0?", "-map", "0:a:0?"}
 
 	default:
@@ -329,6 +616,36 @@ func (r *FFmpegRunner) mapArgs() []string {
 	}
 }
 
+// hasRenditions reports whether alternate renditions are picked.
+func (r *FFmpegRunner) hasRenditions() bool {
+	return r.config.AudioRendition != "" || r.config.SubtitleRendition != ""
+}
+
+// renditionArgs returns the -map arguments for the video stream(s) and the
+// audio and subtitle renditions picked, from the streams under scope (the
+// input, or the variant's program). The HLS demuxer sets each rendition
+// stream's language from its LANGUAGE attribute and fetches only mapped
+// streams' playlists.
+func (r *FFmpegRunner) renditionArgs(scope, video string) []string {
+	args := []string{"-map", video}
+	switch a := r.config.AudioRendition; a {
+	case "":
+		args = append(args, "-map", scope+":a:0?")
+	case "all":
+		args = append(args, "-map", scope+":a?")
+	default:
+		args = append(args, "-map", scope+":a:m:language:"+a+"?")
+	}
+	switch s := r.config.SubtitleRendition; s {
+	case "":
+	case "all":
+		args = append(args, "-map", scope+":s?")
+	default:
+		args = append(args, "-map", scope+":s:m:language:"+s+"?")
+	}
+	return args
+}
+
 // Config returns the FFmpeg configuration.
 func (r *FFmpegRunner) Config() *FFmpegConfig {
 	return r.config
@@ -337,5 +654,9 @@ func (r *FFmpegRunner) Config() *FFmpegConfig {
 // CommandString returns the command that would be executed (for debugging).
 func (r *FFmpegRunner) CommandString() string {
 	args := r.buildArgs()
-	return r.config.BinaryPath + " " + strings.Join(args, " ")
+	cmd := r.config.BinaryPath + " " + strings.Join(args, " ")
+	if env := r.buildEnv(); len(env) > 0 {
+		cmd = strings.Join(env, " ") + " " + cmd
+	}
+	return cmd
 }
@@ -2,9 +2,12 @@ package process
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
 )
 
 // =============================================================================
@@ -421,7 +424,7 @@ func TestFFmpegRunner_buildHeaders(t *testing.T) {
 				Headers:   tt.headers,
 			}
 			runner := &FFmpegRunner{config: cfg}
-			headers := runner.buildHeaders()
+			headers := runner.buildHeaders(runner.requestOptions())
 
 			if len(headers) != tt.wantLen {
 				t.Errorf("len(headers) = %d, want %d", len(headers), tt.wantLen)
@@ -468,7 +471,7 @@ func TestFFmpegRunner_mapArgs(t *testing.T) {
 				ProgramID: tt.programID,
 			}
 			runner := &FFmpegRunner{config: cfg}
-			got := runner.mapArgs()
+			got := runner.mapArgs(cfg.Variant)
 
 			if len(got) != len(tt.want) {
 				t.Errorf("mapArgs() len = %d, want %d", len(got), len(tt.want))
@@ -483,6 +486,41 @@ func TestFFmpegRunner_mapArgs(t *testing.T) {
 	}
 }
 
+func TestFFmpegRunner_mapArgsRenditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		variant     VariantSelection
+		programID   int
+		audio, subs string
+		want        []string
+	}{
+		{"first audio en", VariantFirst, -1, "en", "",
+			[]string{"-map", "0:v:0?", "-map", "0:a:m:language:en?"}},
+		{"first subs only", VariantFirst, -1, "", "all",
+			[]string{"-map", "0:v:0?", "-map", "0:a:0?", "-map", "0:s?"}},
+		{"highest probed", VariantHighest, 2, "all", "fr",
+			[]string{"-map", "0:p:2:v?", "-map", "0:p:2:a?", "-map", "0:p:2:s:m:language:fr?"}},
+		{"lowest no probe", VariantLowest, -1, "en", "en",
+			[]string{"-map", "0:v:0?", "-map", "0:a:m:language:en?", "-map", "0:s:m:language:en?"}},
+		{"all ignores renditions", VariantAll, -1, "en", "en", []string{"-map", "0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &FFmpegConfig{
+				Variant:           tt.variant,
+				ProgramID:         tt.programID,
+				AudioRendition:    tt.audio,
+				SubtitleRendition: tt.subs,
+			}
+			runner := &FFmpegRunner{config: cfg}
+			if got := runner.mapArgs(cfg.Variant); !slices.Equal(got, tt.want) {
+				t.Errorf("mapArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 // =============================================================================
 // Tests: BuildCommand
 // =============================================================================
@@ -635,6 +673,90 @@ func TestFFmpegRunner_DebugLogging(t *testing.T) {
 	})
 }
 
+func TestFFmpegRunner_ClientDebug(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.StatsEnabled = true
+	cfg.StatsLogLevel = "debug"
+	cfg.ClientDebug = func(clientID int) bool { return clientID == 7 }
+	runner := NewFFmpegRunner(cfg)
+
+	for clientID, want := range map[int]string{7: "repeat+level+datetime+debug", 8: "repeat+level+datetime+verbose"} {
+		cmd, err := runner.BuildCommand(context.Background(), clientID)
+		if err != nil {
+			t.Fatalf("BuildCommand(%d) failed: %v", clientID, err)
+		}
+		if cmdStr := strings.Join(cmd.Args, " "); !strings.Contains(cmdStr, "-loglevel "+want) {
+			t.Errorf("client %d: want -loglevel %s, got: %s", clientID, want, cmdStr)
+		}
+	}
+}
+
+func TestFFmpegRunner_NoLogDatetime(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.StatsEnabled = true
+	cfg.NoLogDatetime = true
+	runner := NewFFmpegRunner(cfg)
+	runner.SetProgressFD(3)
+
+	cmdStr := strings.Join(runner.buildArgs(), " ")
+	if !strings.Contains(cmdStr, "-loglevel repeat+level+debug ") {
+		t.Errorf("Without the datetime flag, should use -loglevel repeat+level+debug, got: %s", cmdStr)
+	}
+}
+
+func TestFFmpegRunner_ProgressMode(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		mode parser.ProgressMode
+		want string // -progress argument ("" = none)
+	}{
+		{"", "pipe:3"},
+		{parser.ProgressPipe, "pipe:3"},
+		{parser.ProgressSocket, "unix://" + parser.ProgressSocketPath(dir, 5)},
+		{parser.ProgressOff, ""},
+	}
+	for _, tt := range tests {
+		cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+		cfg.StatsEnabled = true
+		cfg.ProgressMode = tt.mode
+		cfg.ProgressSocketDir = dir
+		runner := NewFFmpegRunner(cfg)
+		runner.SetProgressFD(3)
+
+		cmd, err := runner.BuildCommand(context.Background(), 5)
+		if err != nil {
+			t.Fatalf("%q: BuildCommand failed: %v", tt.mode, err)
+		}
+		got := ""
+		if i := slices.Index(cmd.Args, "-progress"); i >= 0 {
+			got = cmd.Args[i+1]
+		}
+		if got != tt.want {
+			t.Errorf("%q: -progress %q, want %q", tt.mode, got, tt.want)
+		}
+		if hasPeriod := slices.Contains(cmd.Args, "-stats_period"); hasPeriod != (tt.want != "") {
+			t.Errorf("%q: -stats_period present = %v, want it only with -progress", tt.mode, hasPeriod)
+		}
+		if !strings.Contains(strings.Join(cmd.Args, " "), "-loglevel repeat+level+datetime+debug") {
+			t.Errorf("%q: stderr loglevel changed: %v", tt.mode, cmd.Args)
+		}
+	}
+}
+
+func TestFFmpegRunner_ResponseHeaders(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.StatsEnabled = true
+	cfg.StatsLogLevel = "verbose"
+	cfg.ResponseHeaders = true
+	runner := NewFFmpegRunner(cfg)
+	runner.SetProgressFD(3)
+
+	cmdStr := strings.Join(runner.buildArgs(), " ")
+	if !strings.Contains(cmdStr, "repeat+level+datetime+trace") {
+		t.Errorf("With response headers, should use -loglevel repeat+level+datetime+trace, got: %s", cmdStr)
+	}
+}
+
 func TestFFmpegRunner_PerClientUserAgent(t *testing.T) {
 	t.Run("user_agent_includes_client_id", func(t *testing.T) {
 		cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
@@ -689,6 +811,20 @@ func TestFFmpegRunner_PerClientUserAgent(t *testing.T) {
 			t.Errorf("Custom user agent should include client ID, got: %s", cmdStr)
 		}
 	})
+
+	t.Run("run_tag", func(t *testing.T) {
+		cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+		cfg.RunTag = "run-1a2b3c4d"
+		runner := NewFFmpegRunner(cfg)
+
+		cmd, err := runner.BuildCommand(context.Background(), 7)
+		if err != nil {
+			t.Fatalf("BuildCommand failed: %v", err)
+		}
+		if cmdStr := strings.Join(cmd.Args, " "); !strings.Contains(cmdStr, "-user_agent go-ffmpeg-hls-swarm/1.0/run-1a2b3c4d/client-7 ") {
+			t.Errorf("User agent should carry the run tag before the client ID, got: %s", cmdStr)
+		}
+	})
 }
 
 // =============================================================================
@@ -756,3 +892,236 @@ func BenchmarkFFmpegRunner_BuildCommand(b *testing.B) {
 		_, _ = runner.BuildCommand(ctx, i)
 	}
 }
+
+func TestFFmpegRunner_buildArgs_NoKeepAlive(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	argsStr := strings.Join(NewFFmpegRunner(cfg).buildArgs(), " ")
+	if strings.Contains(argsStr, "-http_persistent") {
+		t.Errorf("keep-alive is FFmpeg's default, got %q", argsStr)
+	}
+
+	cfg.NoKeepAlive = true
+	args := NewFFmpegRunner(cfg).buildArgs()
+	argsStr = strings.Join(args, " ")
+	if !strings.Contains(argsStr, "-http_persistent 0") {
+		t.Errorf("missing -http_persistent 0: %q", argsStr)
+	}
+
+	// Input option: must precede -i
+	persistIdx, inputIdx := -1, -1
+	for i, arg := range args {
+		switch arg {
+		case "-http_persistent":
+			persistIdx = i
+		case "-i":
+			inputIdx = i
+		}
+	}
+	if persistIdx > inputIdx {
+		t.Error("-http_persistent must come before -i")
+	}
+}
+
+func TestFFmpegRunner_buildArgs_Prefetch(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	if argsStr := strings.Join(NewFFmpegRunner(cfg).buildArgs(), " "); strings.Contains(argsStr, "-http_multiple") {
+		t.Errorf("prefetch is left to FFmpeg by default, got %q", argsStr)
+	}
+
+	for depth, want := range map[int]string{0: "-http_multiple 0 ", 1: "-http_multiple 1 "} {
+		cfg.Prefetch = depth
+		if argsStr := strings.Join(NewFFmpegRunner(cfg).buildArgs(), " "); !strings.Contains(argsStr, want) {
+			t.Errorf("-prefetch %d: missing %q in %q", depth, want, argsStr)
+		}
+	}
+}
+
+func TestFFmpegRunner_buildArgs_ExtraArgs(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.ExtraArgs = []string{"-http_seekable", "0", "-max_reload", "100"}
+	args := NewFFmpegRunner(cfg).buildArgs()
+	argsStr := strings.Join(args, " ")
+	if !strings.Contains(argsStr, "-http_seekable 0 -max_reload 100 -i http://example.com/stream.m3u8") {
+		t.Errorf("extra args should come just before -i: %q", argsStr)
+	}
+	if err := VerifyStreamCopy(args); err != nil {
+		t.Errorf("VerifyStreamCopy() = %v", err)
+	}
+}
+
+func TestFFmpegRunner_ClientURL(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/master.m3u8")
+	cfg.ClientURL = func(clientID int) string {
+		if clientID == 1 {
+			return "http://example.com/720p.m3u8"
+		}
+		return ""
+	}
+	r := NewFFmpegRunner(cfg)
+
+	for clientID, want := range []string{"http://example.com/master.m3u8", "http://example.com/720p.m3u8"} {
+		cmd, err := r.BuildCommand(context.Background(), clientID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if args := strings.Join(cmd.Args, " "); !strings.Contains(args, "-i "+want+" ") {
+			t.Errorf("client %d: want input %s, got %q", clientID, want, args)
+		}
+	}
+	if s := r.CommandString(); !strings.Contains(s, "-i http://example.com/master.m3u8 ") {
+		t.Errorf("CommandString() = %q, want the master playlist", s)
+	}
+}
+
+func TestFFmpegRunner_ClientOptions(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/master.m3u8")
+	noKeepAlive := true
+	cfg.ClientOptions = func(clientID int) *RequestOptions {
+		if clientID == 1 {
+			return &RequestOptions{NoKeepAlive: &noKeepAlive, UserAgent: "Safari/17", Variant: VariantFirst}
+		}
+		return nil
+	}
+	r := NewFFmpegRunner(cfg)
+
+	cmd, err := r.BuildCommand(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Join(cmd.Args, " ")
+	if strings.Contains(args, "-http_persistent") || !strings.Contains(args, "-user_agent go-ffmpeg-hls-swarm/1.0 ") || !strings.Contains(args, "-map 0 ") {
+		t.Errorf("client 0 should keep the shared options: %q", args)
+	}
+
+	cmd, err = r.BuildCommand(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args = strings.Join(cmd.Args, " ")
+	for _, want := range []string{"-http_persistent 0", "-user_agent Safari/17/client-1", "-map 0:v:0? -map 0:a:0?"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("client 1: missing %q in %q", want, args)
+		}
+	}
+
+	// An explicit keep-alive overrides the shared -no-keepalive
+	keepAlive := false
+	cfg.NoKeepAlive = true
+	cfg.ClientOptions = func(int) *RequestOptions { return &RequestOptions{NoKeepAlive: &keepAlive} }
+	cmd, err = r.BuildCommand(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args := strings.Join(cmd.Args, " "); strings.Contains(args, "-http_persistent") {
+		t.Errorf("keep-alive override ignored: %q", args)
+	}
+
+	// Headers replace the shared ones; NoCache turns the bypass on or off
+	noCache, cache := true, false
+	cfg.Headers = []string{"X-Shared: 1"}
+	cfg.ClientOptions = func(clientID int) *RequestOptions {
+		switch clientID {
+		case 3:
+			return &RequestOptions{Headers: []string{"X-Cohort: b"}, NoCache: &noCache}
+		case 4:
+			return &RequestOptions{Headers: []string{}, NoCache: &cache}
+		}
+		return nil
+	}
+	headersOf := func(clientID int) string {
+		t.Helper()
+		cmd, err := r.BuildCommand(context.Background(), clientID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i := slices.Index(cmd.Args, "-headers"); i >= 0 {
+			return cmd.Args[i+1]
+		}
+		return ""
+	}
+	if got := headersOf(3); strings.Contains(got, "X-Shared") || !strings.Contains(got, "X-Cohort: b") || !strings.Contains(got, "Cache-Control: no-cache") {
+		t.Errorf("client 3 headers = %q, want X-Cohort and the cache bypass only", got)
+	}
+	cfg.NoCache = true
+	if got := headersOf(4); got != "" {
+		t.Errorf("client 4 headers = %q, want none", got)
+	}
+	if got := headersOf(5); !strings.Contains(got, "X-Shared: 1") || !strings.Contains(got, "Pragma: no-cache") {
+		t.Errorf("client 5 headers = %q, want the shared ones", got)
+	}
+}
+
+func TestFFmpegRunner_Pacing(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.ClientPacing = func(clientID int) *Pacing {
+		if clientID == 1 {
+			return &Pacing{Realtime: true, Burst: 7500 * time.Millisecond}
+		}
+		return nil
+	}
+	r := NewFFmpegRunner(cfg)
+
+	argsOf := func(clientID int) string {
+		t.Helper()
+		cmd, err := r.BuildCommand(context.Background(), clientID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(cmd.Args, " ")
+	}
+
+	// Fast (the zero value): FFmpeg's own pace
+	if args := argsOf(0); strings.Contains(args, "-readrate") {
+		t.Errorf("fast pacing sets a read rate: %q", args)
+	}
+	if args := argsOf(1); !strings.Contains(args, "-readrate 1 -readrate_initial_burst 7.5 ") {
+		t.Errorf("buffer pacing: missing read rate and burst in %q", args)
+	}
+
+	// The shared pacing, before -i
+	cfg.Pacing = Pacing{Realtime: true}
+	args := argsOf(0)
+	if i, j := strings.Index(args, "-readrate 1"), strings.Index(args, " -i "); i < 0 || i > j || strings.Contains(args, "initial_burst") {
+		t.Errorf("realtime pacing: want -readrate 1 before -i, got %q", args)
+	}
+}
+
+func TestFFmpegRunner_StartOffset(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/vod.m3u8")
+	r := NewFFmpegRunner(cfg)
+	argsOf := func(clientID int) string {
+		t.Helper()
+		cmd, err := r.BuildCommand(context.Background(), clientID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(cmd.Args, " ")
+	}
+
+	if args := argsOf(1); strings.Contains(args, "-ss ") {
+		t.Errorf("seek without -vod-start: %q", args)
+	}
+
+	cfg.ClientStartOffset = func(clientID int) time.Duration {
+		return time.Duration(clientID) * 90500 * time.Millisecond
+	}
+	if args := argsOf(0); strings.Contains(args, "-ss ") {
+		t.Errorf("seek to the start: %q", args)
+	}
+	args := argsOf(2)
+	if i, j := strings.Index(args, "-ss 181.000"), strings.Index(args, " -i "); i < 0 || i > j {
+		t.Errorf("want -ss 181.000 before -i, got %q", args)
+	}
+}
+
+func TestFFmpegRunner_buildArgs_AcceptEncoding(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	if argsStr := strings.Join(NewFFmpegRunner(cfg).buildArgs(), " "); strings.Contains(argsStr, "Accept-Encoding") {
+		t.Errorf("Accept-Encoding sent by default: %q", argsStr)
+	}
+
+	cfg.AcceptEncoding = "gzip, deflate"
+	if argsStr := strings.Join(NewFFmpegRunner(cfg).buildArgs(), " "); !strings.Contains(argsStr, "Accept-Encoding: gzip, deflate\r\n") {
+		t.Errorf("missing Accept-Encoding header: %q", argsStr)
+	}
+}
@@ -0,0 +1,83 @@
+package process
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template variables, expanded in -header values and the -token-url.
+// FFmpeg sends the same headers on every request of a process, so each
+// value is fixed for one process start: a restarted client gets a new seq,
+// uuid, timestamp and token.
+const (
+	HeaderVarClientID  = "{client_id}"
+	HeaderVarSeq       = "{seq}"       // Starts of this client so far, from 1
+	HeaderVarUUID      = "{uuid}"      // Random version 4 UUID
+	HeaderVarTimestamp = "{timestamp}" // Unix seconds at process start
+	HeaderVarToken     = "{token}"     // Session token from the TokenSource
+)
+
+// TemplateValues are the template variable values for one process start.
+type TemplateValues struct {
+	ClientID int
+	Seq      int
+	UUID     string
+	Time     time.Time
+	Token    string
+}
+
+// NewTemplateValues returns the values for a client's seq'th start, with a
+// fresh UUID and the current time (and no token yet).
+func NewTemplateValues(clientID, seq int) TemplateValues {
+	return TemplateValues{ClientID: clientID, Seq: seq, UUID: newUUID(), Time: time.Now()}
+}
+
+// Expand replaces the template variables in s. Unknown {names} are left as
+// they are.
+func (v TemplateValues) Expand(s string) string {
+	if !strings.Contains(s, "{") {
+		return s
+	}
+	return strings.NewReplacer(
+		HeaderVarClientID, strconv.Itoa(v.ClientID),
+		HeaderVarSeq, strconv.Itoa(v.Seq),
+		HeaderVarUUID, v.UUID,
+		HeaderVarTimestamp, strconv.FormatInt(v.Time.Unix(), 10),
+		HeaderVarToken, v.Token,
+	).Replace(s)
+}
+
+// ExpandHeaders returns headers with the template variables replaced.
+// Headers without variables are returned unchanged.
+func (v TemplateValues) ExpandHeaders(headers []string) []string {
+	if !UsesTemplate(headers, "{") {
+		return headers
+	}
+	out := make([]string, len(headers))
+	for i, h := range headers {
+		out[i] = v.Expand(h)
+	}
+	return out
+}
+
+// UsesTemplate reports whether any header contains variable.
+func UsesTemplate(headers []string, variable string) bool {
+	for _, h := range headers {
+		if strings.Contains(h, variable) {
+			return true
+		}
+	}
+	return false
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])  // Never fails (crypto/rand panics instead)
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
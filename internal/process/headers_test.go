@@ -0,0 +1,161 @@
+package process
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestTemplateValues_ExpandHeaders(t *testing.T) {
+	headers := []string{
+		"X-Client: c{client_id}-s{seq}",
+		"X-Session: {uuid}",
+		"X-Trace: {uuid}@{timestamp}",
+		"Authorization: Bearer {token}",
+		"X-Literal: {unknown}",
+	}
+
+	v := NewTemplateValues(42, 3)
+	v.Time = time.Unix(1769155200, 0)
+	v.Token = "abc123"
+	got := v.ExpandHeaders(headers)
+	if got[0] != "X-Client: c42-s3" {
+		t.Errorf("client/seq header = %q", got[0])
+	}
+	uuid := strings.TrimPrefix(got[1], "X-Session: ")
+	if !uuidPattern.MatchString(uuid) {
+		t.Errorf("uuid = %q, want a version 4 UUID", uuid)
+	}
+	if got[2] != "X-Trace: "+uuid+"@1769155200" {
+		t.Errorf("trace header = %q, want the same UUID and the start time", got[2])
+	}
+	if got[3] != "Authorization: Bearer abc123" {
+		t.Errorf("token header = %q", got[3])
+	}
+	if got[4] != headers[4] {
+		t.Errorf("unknown variable expanded: %q", got[4])
+	}
+	if headers[0] != "X-Client: c{client_id}-s{seq}" {
+		t.Error("ExpandHeaders modified its input")
+	}
+
+	if again := NewTemplateValues(42, 4).ExpandHeaders(headers); again[1] == got[1] {
+		t.Error("restart reused the UUID")
+	}
+}
+
+func TestFFmpegRunner_BuildCommand_HeaderTemplates(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.Headers = []string{"X-Client: {client_id}/{seq}"}
+	runner := NewFFmpegRunner(cfg)
+
+	headersArg := func(clientID int) string {
+		cmd, err := runner.BuildCommand(context.Background(), clientID)
+		if err != nil {
+			t.Fatalf("BuildCommand() = %v", err)
+		}
+		for i, arg := range cmd.Args {
+			if arg == "-headers" {
+				return cmd.Args[i+1]
+			}
+		}
+		t.Fatal("no -headers argument")
+		return ""
+	}
+
+	// seq counts starts per client
+	for _, tt := range []struct {
+		clientID int
+		want     string
+	}{
+		{7, "X-Client: 7/1"},
+		{7, "X-Client: 7/2"},
+		{8, "X-Client: 8/1"},
+	} {
+		if got := headersArg(tt.clientID); !strings.Contains(got, tt.want) {
+			t.Errorf("client %d headers = %q, want %q", tt.clientID, got, tt.want)
+		}
+	}
+
+	// Printed commands show the template
+	if got := runner.CommandString(); !strings.Contains(got, "{client_id}/{seq}") {
+		t.Errorf("CommandString() = %q, want the unexpanded template", got)
+	}
+}
+
+func TestFFmpegRunner_BuildCommand_ClientHeaders(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.Headers = []string{"X-Run: load"}
+	cfg.ClientHeaders = func(clientID int) []string {
+		if clientID%2 == 0 {
+			return []string{"X-Forwarded-For: 81.2.69.{client_id}"}
+		}
+		return nil
+	}
+	runner := NewFFmpegRunner(cfg)
+
+	for _, tt := range []struct {
+		clientID int
+		want     string
+		notWant  string
+	}{
+		{4, "X-Run: load\r\nX-Forwarded-For: 81.2.69.4", ""},
+		{5, "X-Run: load", "X-Forwarded-For"},
+	} {
+		cmd, err := runner.BuildCommand(context.Background(), tt.clientID)
+		if err != nil {
+			t.Fatalf("BuildCommand() = %v", err)
+		}
+		args := strings.Join(cmd.Args, " ")
+		if !strings.Contains(args, tt.want) || (tt.notWant != "" && strings.Contains(args, tt.notWant)) {
+			t.Errorf("client %d args = %q, want %q without %q", tt.clientID, args, tt.want, tt.notWant)
+		}
+	}
+}
+
+func TestFFmpegRunner_BuildCommand_Env(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	runner := NewFFmpegRunner(cfg)
+
+	// No extra environment: FFmpeg inherits the swarm's as is
+	cmd, err := runner.BuildCommand(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("BuildCommand() = %v", err)
+	}
+	if cmd.Env != nil {
+		t.Errorf("Env = %q, want nil", cmd.Env)
+	}
+
+	cfg.Env = []string{"TZ=UTC", "CLIENT={client_id}"}
+	cfg.ClientEnv = func(clientID int) []string {
+		if clientID%2 == 0 {
+			return []string{"COHORT=even", "TZ=Europe/Berlin"}
+		}
+		return nil
+	}
+	for _, tt := range []struct {
+		clientID int
+		want     []string
+	}{
+		{4, []string{"TZ=UTC", "CLIENT=4", "COHORT=even", "TZ=Europe/Berlin"}},
+		{5, []string{"TZ=UTC", "CLIENT=5"}},
+	} {
+		cmd, err := runner.BuildCommand(context.Background(), tt.clientID)
+		if err != nil {
+			t.Fatalf("BuildCommand() = %v", err)
+		}
+		// Appended to the swarm's environment; the last TZ wins in exec
+		if len(cmd.Env) < len(tt.want) || !slices.Equal(cmd.Env[len(cmd.Env)-len(tt.want):], tt.want) {
+			t.Errorf("client %d Env ends with %q, want %q", tt.clientID, cmd.Env[max(0, len(cmd.Env)-len(tt.want)):], tt.want)
+		}
+	}
+
+	if got := runner.CommandString(); !strings.HasPrefix(got, "TZ=UTC CLIENT={client_id} ffmpeg ") {
+		t.Errorf("CommandString() = %q, want the environment first", got)
+	}
+}
@@ -0,0 +1,146 @@
+package process
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// logLevelNames are the levels FFmpeg's -loglevel accepts, quietest first.
+var logLevelNames = []string{"quiet", "panic", "fatal", "error", "warning", "info", "verbose", "debug", "trace"}
+
+// LogLevel is an FFmpeg -loglevel: a level and the flags that change how
+// lines are printed. String renders it, flags first:
+// "repeat+level+datetime+debug".
+type LogLevel struct {
+	Level    string // One of logLevelNames
+	Repeat   bool   // Print repeated lines instead of "Last message repeated N times"
+	Tags     bool   // Prefix lines with their level ("[debug]"): the "level" flag
+	Datetime bool   // Prefix lines with the date and time to the millisecond (FFmpeg 7 and later)
+}
+
+// ParseLogLevel parses a -loglevel: a level, optionally after "+"-joined
+// flags ("debug", "repeat+level+datetime+debug").
+func ParseLogLevel(s string) (LogLevel, error) {
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	l := LogLevel{Level: parts[len(parts)-1]}
+	if !slices.Contains(logLevelNames, l.Level) {
+		return LogLevel{}, fmt.Errorf("loglevel %q: unknown level %q, want one of %s", s, l.Level, strings.Join(logLevelNames, ", "))
+	}
+	for _, flag := range parts[:len(parts)-1] {
+		switch flag {
+		case "repeat":
+			l.Repeat = true
+		case "level":
+			l.Tags = true
+		case "datetime":
+			l.Datetime = true
+		default:
+			return LogLevel{}, fmt.Errorf("loglevel %q: unknown flag %q, want repeat, level or datetime", s, flag)
+		}
+	}
+	return l, nil
+}
+
+// String renders the -loglevel argument.
+func (l LogLevel) String() string {
+	var b strings.Builder
+	if l.Repeat {
+		b.WriteString("repeat+")
+	}
+	if l.Tags {
+		b.WriteString("level+")
+	}
+	if l.Datetime {
+		b.WriteString("datetime+")
+	}
+	b.WriteString(l.Level)
+	return b.String()
+}
+
+// Validate checks l against the FFmpeg it is for: a known level, and the
+// datetime flag only where FFmpeg has it (datetime false: FFmpeg 6.x and
+// older, which refuse the whole -loglevel over it).
+func (l LogLevel) Validate(datetime bool) error {
+	if !slices.Contains(logLevelNames, l.Level) {
+		return fmt.Errorf("loglevel %q: unknown level %q", l, l.Level)
+	}
+	if l.Datetime && !datetime {
+		return fmt.Errorf("loglevel %q: this FFmpeg has no datetime flag (FFmpeg 7 added it)", l)
+	}
+	return nil
+}
+
+// StatsLogLevel builds the -loglevel of a client whose output the stats
+// parser reads, at level (a plain level, or one with flags, which are
+// replaced). The parser needs every line (repeat), level tags, and the
+// timestamps it times events with wherever FFmpeg can print them
+// (datetime): the lines may reach it late, FFmpeg's timestamps don't.
+// Below verbose FFmpeg logs no segment requests, so those are refused.
+func StatsLogLevel(level string, datetime bool) (LogLevel, error) {
+	l, err := ParseLogLevel(level)
+	if err != nil {
+		return LogLevel{}, err
+	}
+	if slices.Index(logLevelNames, l.Level) < slices.Index(logLevelNames, "verbose") {
+		return LogLevel{}, fmt.Errorf("stats loglevel %q: the parser needs verbose or above", level)
+	}
+	l.Repeat, l.Tags, l.Datetime = true, true, datetime
+	return l, nil
+}
+
+// statsBaseLevel returns the level the current client's stats run at.
+// Default to debug to capture manifest refreshes ([hls @ ...] Opening
+// '...m3u8'), which are logged at debug; verbose only has segment requests.
+func (r *FFmpegRunner) statsBaseLevel() string {
+	baseLevel := "debug"
+	if r.config.DebugLogging {
+		// Full debug when enabled (safe - progress is on separate FD)
+		baseLevel = "debug"
+	} else if r.config.ClientDebug != nil {
+		// Sampled clients at debug, the rest lean
+		baseLevel = "verbose"
+		if r.config.ClientDebug(r.clientID) {
+			baseLevel = "debug"
+		}
+	} else if r.config.StatsLogLevel != "" {
+		// Use configured stats level (allows override to verbose if needed)
+		baseLevel = r.config.StatsLogLevel
+	}
+	if r.config.ResponseHeaders {
+		baseLevel = "trace"
+	}
+	return baseLevel
+}
+
+// logLevel returns the -loglevel of the current client. With stats it is
+// StatsLogLevel's; a stats level that doesn't build (VerifyLogLevel
+// reports it) falls back to the parser's default, debug.
+func (r *FFmpegRunner) logLevel() string {
+	if !r.config.StatsEnabled {
+		return r.config.LogLevel
+	}
+	datetime := !r.config.NoLogDatetime
+	l, err := StatsLogLevel(r.statsBaseLevel(), datetime)
+	if err != nil {
+		l, _ = StatsLogLevel("debug", datetime)
+	}
+	return l.String()
+}
+
+// VerifyLogLevel checks the -loglevel the clients run at builds and suits
+// this FFmpeg (see LogLevel.Validate).
+func (r *FFmpegRunner) VerifyLogLevel() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	datetime := !r.config.NoLogDatetime
+	if r.config.StatsEnabled {
+		_, err := StatsLogLevel(r.statsBaseLevel(), datetime)
+		return err
+	}
+	l, err := ParseLogLevel(r.config.LogLevel)
+	if err != nil {
+		return err
+	}
+	return l.Validate(datetime)
+}
@@ -0,0 +1,116 @@
+package process
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LogLevel
+		wantErr bool
+	}{
+		{"info", LogLevel{Level: "info"}, false},
+		{"repeat+level+datetime+debug", LogLevel{Level: "debug", Repeat: true, Tags: true, Datetime: true}, false},
+		{"level+verbose", LogLevel{Level: "verbose", Tags: true}, false},
+		{" trace ", LogLevel{Level: "trace"}, false},
+		{"loud", LogLevel{}, true},
+		{"debug+repeat", LogLevel{}, true},
+		{"time+debug", LogLevel{}, true},
+		{"", LogLevel{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLogLevel(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLogLevel(%q) = %+v, %v; want %+v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLogLevel_String(t *testing.T) {
+	for _, s := range []string{"info", "repeat+debug", "level+verbose", "repeat+level+datetime+trace"} {
+		l, err := ParseLogLevel(s)
+		if err != nil {
+			t.Fatalf("ParseLogLevel(%q): %v", s, err)
+		}
+		if got := l.String(); got != s {
+			t.Errorf("String() = %q, want %q", got, s)
+		}
+	}
+}
+
+func TestLogLevel_Validate(t *testing.T) {
+	stamped := LogLevel{Level: "debug", Repeat: true, Tags: true, Datetime: true}
+	if err := stamped.Validate(true); err != nil {
+		t.Errorf("Validate(datetime) = %v, want nil", err)
+	}
+	if err := stamped.Validate(false); err == nil || !strings.Contains(err.Error(), "datetime") {
+		t.Errorf("Validate(no datetime) = %v, want a datetime error", err)
+	}
+	if err := (LogLevel{Level: "loud"}).Validate(true); err == nil {
+		t.Error("Validate() of an unknown level: want an error")
+	}
+}
+
+func TestStatsLogLevel(t *testing.T) {
+	tests := []struct {
+		level    string
+		datetime bool
+		want     string
+		wantErr  bool
+	}{
+		{"debug", true, "repeat+level+datetime+debug", false},
+		{"verbose", false, "repeat+level+verbose", false},
+		{"datetime+trace", false, "repeat+level+trace", false}, // Flags are the builder's
+		{"repeat+level+datetime+debug", true, "repeat+level+datetime+debug", false},
+		{"info", true, "", true}, // No segment requests below verbose
+		{"loud", true, "", true},
+	}
+	for _, tt := range tests {
+		l, err := StatsLogLevel(tt.level, tt.datetime)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("StatsLogLevel(%q, %v) error = %v, want error %v", tt.level, tt.datetime, err, tt.wantErr)
+			continue
+		}
+		if err == nil && l.String() != tt.want {
+			t.Errorf("StatsLogLevel(%q, %v) = %q, want %q", tt.level, tt.datetime, l, tt.want)
+		}
+		if err == nil {
+			if verr := l.Validate(tt.datetime); verr != nil {
+				t.Errorf("StatsLogLevel(%q, %v) = %q, which doesn't validate: %v", tt.level, tt.datetime, l, verr)
+			}
+		}
+	}
+}
+
+func TestFFmpegRunner_VerifyLogLevel(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.StatsEnabled = true
+	cfg.StatsLogLevel = "verbose"
+	runner := NewFFmpegRunner(cfg)
+	if err := runner.VerifyLogLevel(); err != nil {
+		t.Errorf("VerifyLogLevel() = %v, want nil", err)
+	}
+
+	// A stats level the parser can't work with is refused, and the
+	// command falls back to debug
+	cfg.StatsLogLevel = "info"
+	if err := runner.VerifyLogLevel(); err == nil {
+		t.Error("VerifyLogLevel() with stats at info: want an error")
+	}
+	if args := strings.Join(runner.buildArgs(), " "); !strings.Contains(args, "-loglevel repeat+level+datetime+debug ") {
+		t.Errorf("fallback loglevel: got %s", args)
+	}
+
+	// Without stats, LogLevel is passed through, checked against the FFmpeg
+	cfg.StatsEnabled = false
+	cfg.LogLevel = "datetime+info"
+	if err := runner.VerifyLogLevel(); err != nil {
+		t.Errorf("VerifyLogLevel() = %v, want nil", err)
+	}
+	cfg.NoLogDatetime = true
+	if err := runner.VerifyLogLevel(); err == nil {
+		t.Error("VerifyLogLevel() with datetime on an FFmpeg without it: want an error")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 // ProbeResult represents the output of ffprobe -show_programs.
@@ -33,6 +34,21 @@ type ProgramInfo struct {
 	Bitrate   int64
 }
 
+// FFmpegVersion runs "ffmpeg -version" and returns the version it reports:
+// the third word of its first line ("7.1.1", "n6.1.2", "N-118080-g...").
+func FFmpegVersion(ctx context.Context, path string) (string, error) {
+	output, err := exec.CommandContext(ctx, path, "-version").Output()
+	if err != nil {
+		return "", fmt.Errorf("%s -version: %w", path, err)
+	}
+	line, _, _ := strings.Cut(string(output), "\n")
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", fmt.Errorf("%s -version: unrecognized output %q", path, line)
+	}
+	return fields[2], nil
+}
+
 // ProbeVariants uses ffprobe to discover available variants and select
 // the appropriate one based on the Variant setting.
 // Sets ProgramID in the config if successful.
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -76,6 +78,30 @@ func TestFindFFprobe_NonFFmpegPath(t *testing.T) {
 	}
 }
 
+func TestFFmpegVersion(t *testing.T) {
+	dir := t.TempDir()
+	script := func(name, output string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("#!/bin/sh\nprintf '"+output+"'\n"), 0o755); err != nil {
+			t.Fatalf("write fake ffmpeg: %v", err)
+		}
+		return path
+	}
+
+	version, err := FFmpegVersion(context.Background(),
+		script("ffmpeg", `ffmpeg version 6.1.1-3ubuntu5 Copyright (c) 2000-2023 the FFmpeg developers\nbuilt with gcc 13\n`))
+	if err != nil || version != "6.1.1-3ubuntu5" {
+		t.Errorf("FFmpegVersion() = %q, %v; want 6.1.1-3ubuntu5", version, err)
+	}
+
+	if _, err := FFmpegVersion(context.Background(), script("not-ffmpeg", `usage: not-ffmpeg\n`)); err == nil {
+		t.Error("FFmpegVersion() of unrecognized output: want an error")
+	}
+	if _, err := FFmpegVersion(context.Background(), filepath.Join(dir, "missing")); err == nil {
+		t.Error("FFmpegVersion() of a missing binary: want an error")
+	}
+}
+
 func TestProbeVariants_VariantAll(t *testing.T) {
 	// When variant is "all", no probing should happen
 	r := &FFmpegRunner{
@@ -0,0 +1,80 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+)
+
+// decodeOptions are output options that only work on decoded frames, so
+// their presence means FFmpeg decodes (and usually re-encodes) the stream.
+var decodeOptions = map[string]bool{
+	"-vf": true, "-af": true, "-filter": true, "-filter_complex": true, "-lavfi": true,
+	"-s": true, "-r": true, "-pix_fmt": true, "-ar": true, "-ac": true,
+	"-b:v": true, "-b:a": true, "-crf": true, "-preset": true,
+}
+
+// hwaccelOptions set up hardware decoding. They are refused anywhere in
+// the command: the swarm must run on hosts without GPUs, and a GPU decode
+// is still a decode.
+var hwaccelOptions = map[string]bool{
+	"-hwaccel": true, "-hwaccel_device": true, "-hwaccel_output_format": true,
+	"-init_hw_device": true, "-filter_hw_device": true, "-vaapi_device": true, "-qsv_device": true,
+}
+
+// VerifyStreamCopy checks that args only demux: every stream is copied
+// (-c copy) into the null muxer (-f null), with nothing that needs decoded
+// frames. Decoding costs one or two orders of magnitude more CPU per
+// client than demuxing, so a swarm that decodes by accident measures its
+// own CPU rather than the origin.
+func VerifyStreamCopy(args []string) error {
+	input := -1
+	for i, arg := range args {
+		if arg == "-i" {
+			input = i
+		}
+		if hwaccelOptions[arg] {
+			return fmt.Errorf("stream copy: %s uses hardware decoding", arg)
+		}
+	}
+	if input < 0 || input+1 >= len(args) {
+		return fmt.Errorf("stream copy: no input (-i)")
+	}
+
+	copied, null := false, false
+	out := args[input+2:]
+	for i := 0; i < len(out); i++ {
+		opt := out[i]
+		value := ""
+		if i+1 < len(out) {
+			value = out[i+1]
+		}
+		name, _, _ := strings.Cut(opt, ":")
+		switch {
+		case name == "-c" || name == "-codec" || name == "-vcodec" || name == "-acodec":
+			if value != "copy" {
+				return fmt.Errorf("stream copy: %s %s decodes and re-encodes (want %s copy)", opt, value, opt)
+			}
+			copied = copied || opt == "-c" || opt == "-codec"
+			i++
+		case opt == "-f":
+			null = value == "null"
+			i++
+		case decodeOptions[opt] || decodeOptions[name]:
+			return fmt.Errorf("stream copy: %s needs decoded frames", opt)
+		}
+	}
+	if !copied {
+		return fmt.Errorf("stream copy: output has no -c copy")
+	}
+	if !null {
+		return fmt.Errorf("stream copy: output is not the null muxer (-f null)")
+	}
+	return nil
+}
+
+// VerifyStreamCopy checks the generated command with VerifyStreamCopy.
+func (r *FFmpegRunner) VerifyStreamCopy() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return VerifyStreamCopy(r.buildArgs())
+}
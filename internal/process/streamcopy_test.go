@@ -0,0 +1,55 @@
+package process
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyStreamCopy(t *testing.T) {
+	const in = "-hide_banner -loglevel info -i http://origin/live.m3u8 -map 0 "
+	tests := []struct {
+		name    string
+		args    string
+		wantErr string
+	}{
+		{"copy to null", in + "-c copy -f null -", ""},
+		{"per-stream copy", in + "-c copy -c:v copy -c:a:0 copy -f null -", ""},
+		{"decode", in + "-c:v libx264 -f null -", "libx264"},
+		{"no codec", in + "-f null -", "no -c copy"},
+		{"filter", in + "-c copy -vf scale=640:360 -f null -", "-vf needs decoded frames"},
+		{"stream filter", in + "-c copy -filter:v fps=1 -f null -", "-filter:v"},
+		{"file output", in + "-c copy -f mpegts out.ts", "null muxer"},
+		{"no input", "-hide_banner -c copy -f null -", "no input"},
+		{"hwaccel", "-hide_banner -hwaccel cuda -i http://origin/live.m3u8 -c copy -f null -", "-hwaccel uses hardware decoding"},
+		{"hw device", "-init_hw_device vaapi=gpu:/dev/dri/renderD128 " + in + "-c copy -f null -", "-init_hw_device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyStreamCopy(strings.Fields(tt.args))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("VerifyStreamCopy() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("VerifyStreamCopy() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// The generated command must stay demux-only whatever the options.
+func TestFFmpegRunner_VerifyStreamCopy(t *testing.T) {
+	for _, variant := range []VariantSelection{VariantAll, VariantFirst, VariantHighest, VariantLowest} {
+		cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+		cfg.Variant = variant
+		cfg.ProgramID = 1
+		cfg.StatsEnabled = true
+		cfg.Headers = []string{"X-Test: 1"}
+		if err := NewFFmpegRunner(cfg).VerifyStreamCopy(); err != nil {
+			t.Errorf("variant %s: %v", variant, err)
+		}
+	}
+}
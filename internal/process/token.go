@@ -0,0 +1,108 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxTokenBytes bounds how much of a token response is read.
+const maxTokenBytes = 64 * 1024
+
+// TokenSource supplies session tokens for {token} in header templates.
+// The runner asks for a new token on every process start, so a client
+// that was restarted after a 401 comes back with a fresh one.
+type TokenSource interface {
+	Token(ctx context.Context, v TemplateValues) (string, error)
+}
+
+// HTTPTokenSource fetches tokens with a GET of a templated URL. The
+// response body is the token, either as plain text or as JSON with a
+// "token" or "access_token" field.
+type HTTPTokenSource struct {
+	url       string
+	userAgent string
+	client    *http.Client
+
+	// OnFetch is called after every fetch with its latency and error.
+	OnFetch func(clientID int, latency time.Duration, err error)
+}
+
+// NewHTTPTokenSource creates a token source for urlTemplate, which may use
+// the template variables other than {token}.
+func NewHTTPTokenSource(urlTemplate, userAgent string, timeout time.Duration) *HTTPTokenSource {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &HTTPTokenSource{
+		url:       urlTemplate,
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Token fetches a token for one process start.
+func (s *HTTPTokenSource) Token(ctx context.Context, v TemplateValues) (string, error) {
+	start := time.Now()
+	token, err := s.fetch(ctx, v)
+	if s.OnFetch != nil {
+		s.OnFetch(v.ClientID, time.Since(start), err)
+	}
+	return token, err
+}
+
+func (s *HTTPTokenSource) fetch(ctx context.Context, v TemplateValues) (string, error) {
+	v.Token = ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.Expand(s.url), nil)
+	if err != nil {
+		return "", fmt.Errorf("token request: %w", err)
+	}
+	if s.userAgent != "" {
+		req.Header.Set("User-Agent", fmt.Sprintf("%s/client-%d", s.userAgent, v.ClientID))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fetch token: HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenBytes))
+	if err != nil {
+		return "", fmt.Errorf("read token: %w", err)
+	}
+	return parseToken(body)
+}
+
+// parseToken extracts the token from a token response body.
+func parseToken(body []byte) (string, error) {
+	text := strings.TrimSpace(string(body))
+	if strings.HasPrefix(text, "{") {
+		var doc struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", fmt.Errorf("parse token response: %w", err)
+		}
+		text = doc.AccessToken
+		if text == "" {
+			text = doc.Token
+		}
+	}
+	if text == "" {
+		return "", errors.New("empty token")
+	}
+	if strings.ContainsAny(text, "\r\n") {
+		return "", errors.New("token contains a line break")
+	}
+	return text, nil
+}
@@ -0,0 +1,109 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	tests := []struct {
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"abc123\n", "abc123", false},
+		{`{"access_token": "jwt.value", "expires_in": 30}`, "jwt.value", false},
+		{`{"token": "t-1"}`, "t-1", false},
+		{"", "", true},
+		{`{"expires_in": 30}`, "", true},
+		{`{not json`, "", true},
+		{"two\nlines", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseToken([]byte(tt.body))
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("parseToken(%q) = %q, %v; want %q, error %v", tt.body, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestHTTPTokenSource(t *testing.T) {
+	var issued atomic.Int64
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.RequestURI()
+		if r.URL.Query().Get("client") == "13" {
+			http.Error(w, "denied", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("token-" + string(rune('0'+issued.Add(1)))))
+	}))
+	defer srv.Close()
+
+	src := NewHTTPTokenSource(srv.URL+"/token?client={client_id}&seq={seq}", "swarm", time.Second)
+	var fetches, failures int
+	src.OnFetch = func(clientID int, latency time.Duration, err error) {
+		fetches++
+		if err != nil {
+			failures++
+		}
+	}
+
+	token, err := src.Token(context.Background(), NewTemplateValues(7, 2))
+	if err != nil || token != "token-1" {
+		t.Fatalf("Token() = %q, %v", token, err)
+	}
+	if gotPath != "/token?client=7&seq=2" {
+		t.Errorf("token URL = %q, want templated client and seq", gotPath)
+	}
+
+	if _, err := src.Token(context.Background(), NewTemplateValues(13, 1)); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Token(denied) = %v, want HTTP 403 error", err)
+	}
+	if fetches != 2 || failures != 1 {
+		t.Errorf("OnFetch saw %d fetches, %d failures; want 2, 1", fetches, failures)
+	}
+}
+
+// staticTokens hands out numbered tokens, or fails.
+type staticTokens struct {
+	n   atomic.Int64
+	err error
+}
+
+func (s *staticTokens) Token(ctx context.Context, v TemplateValues) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	return "t" + string(rune('0'+s.n.Add(1))), nil
+}
+
+func TestFFmpegRunner_BuildCommand_Token(t *testing.T) {
+	cfg := DefaultFFmpegConfig("http://example.com/stream.m3u8")
+	cfg.Headers = []string{"Authorization: Bearer {token}"}
+	tokens := &staticTokens{}
+	cfg.TokenSource = tokens
+	runner := NewFFmpegRunner(cfg)
+
+	// A new token on every start
+	for _, want := range []string{"Bearer t1", "Bearer t2"} {
+		cmd, err := runner.BuildCommand(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("BuildCommand() = %v", err)
+		}
+		if args := strings.Join(cmd.Args, " "); !strings.Contains(args, want) {
+			t.Errorf("args = %q, want %q", args, want)
+		}
+	}
+
+	tokens.err = errors.New("auth down")
+	if _, err := runner.BuildCommand(context.Background(), 1); err == nil {
+		t.Error("BuildCommand() with a failing token source = nil, want error")
+	}
+}
@@ -0,0 +1,145 @@
+//go:build linux
+
+package sockstats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inet_diag constants (linux/inet_diag.h), not in x/sys.
+const (
+	inetDiagInfo    = 2  // INET_DIAG_INFO attribute: struct tcp_info
+	inetDiagReqLen  = 56 // sizeof(struct inet_diag_req_v2)
+	inetDiagMsgLen  = 72 // sizeof(struct inet_diag_msg)
+	inetDiagInodeAt = 68 // offsetof(struct inet_diag_msg, idiag_inode)
+	tcpStatesAll    = 0xfff
+	tcpListen       = 10
+)
+
+// dumpTCP returns every IPv4 and IPv6 TCP connection on the host (except
+// listeners) with its tcp_info.
+func dumpTCP() ([]Conn, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_SOCK_DIAG)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %w", err)
+	}
+	defer unix.Close(fd)
+
+	var conns []Conn
+	for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
+		got, err := dumpFamily(fd, family)
+		if err != nil {
+			return nil, err
+		}
+		conns = append(conns, got...)
+	}
+	return conns, nil
+}
+
+func dumpFamily(fd int, family uint8) ([]Conn, error) {
+	req := make([]byte, unix.NLMSG_HDRLEN+inetDiagReqLen)
+	binary.NativeEndian.PutUint32(req[0:], uint32(len(req)))
+	binary.NativeEndian.PutUint16(req[4:], unix.SOCK_DIAG_BY_FAMILY)
+	binary.NativeEndian.PutUint16(req[6:], unix.NLM_F_REQUEST|unix.NLM_F_DUMP)
+	body := req[unix.NLMSG_HDRLEN:]
+	body[0] = family
+	body[1] = unix.IPPROTO_TCP
+	body[2] = 1 << (inetDiagInfo - 1)
+	binary.NativeEndian.PutUint32(body[4:], tcpStatesAll&^(1<<tcpListen))
+
+	if err := unix.Sendto(fd, req, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("sock_diag request: %w", err)
+	}
+
+	var conns []Conn
+	buf := make([]byte, 64*1024)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, fmt.Errorf("sock_diag reply: %w", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case unix.NLMSG_DONE:
+				return conns, nil
+			case unix.NLMSG_ERROR:
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(m.Data)); errno != 0 {
+						return nil, fmt.Errorf("sock_diag: %w", unix.Errno(-errno))
+					}
+				}
+				return nil, errors.New("sock_diag: error reply")
+			}
+			if conn, ok := parseDiagMsg(m.Data); ok {
+				conns = append(conns, conn)
+			}
+		}
+	}
+}
+
+// parseDiagMsg decodes one inet_diag_msg and its tcp_info attribute.
+func parseDiagMsg(data []byte) (Conn, bool) {
+	if len(data) < inetDiagMsgLen {
+		return Conn{}, false
+	}
+	conn := Conn{Inode: uint64(binary.NativeEndian.Uint32(data[inetDiagInodeAt:]))}
+
+	for attrs := data[inetDiagMsgLen:]; len(attrs) >= unix.SizeofRtAttr; {
+		l := int(binary.NativeEndian.Uint16(attrs[0:]))
+		typ := binary.NativeEndian.Uint16(attrs[2:])
+		if l < unix.SizeofRtAttr || l > len(attrs) {
+			break
+		}
+		if typ == inetDiagInfo {
+			var info unix.TCPInfo
+			// Older kernels send a shorter tcp_info: the rest stays zero
+			copy(unsafe.Slice((*byte)(unsafe.Pointer(&info)), unix.SizeofTCPInfo), attrs[unix.SizeofRtAttr:l])
+			conn.RTT = time.Duration(info.Rtt) * time.Microsecond
+			conn.MinRTT = time.Duration(info.Min_rtt) * time.Microsecond
+			conn.Retransmits = int64(info.Total_retrans)
+			conn.OutOfOrder = int64(info.Rcv_ooopack)
+			conn.BytesReceived = int64(info.Bytes_received)
+		}
+		attrs = attrs[min((l+unix.RTA_ALIGNTO-1)&^(unix.RTA_ALIGNTO-1), len(attrs)):]
+	}
+	return conn, conn.Inode != 0
+}
+
+// processInodes returns the socket inodes the processes have open.
+// Processes that have exited are skipped.
+func processInodes(pids []int) map[uint64]bool {
+	inodes := make(map[uint64]bool)
+	for _, pid := range pids {
+		dir := "/proc/" + strconv.Itoa(pid) + "/fd/"
+		fds, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(dir + fd.Name())
+			if err != nil {
+				continue
+			}
+			if s, ok := strings.CutPrefix(link, "socket:["); ok {
+				if inode, err := strconv.ParseUint(strings.TrimSuffix(s, "]"), 10, 64); err == nil {
+					inodes[inode] = true
+				}
+			}
+		}
+	}
+	return inodes
+}
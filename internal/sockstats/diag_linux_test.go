@@ -0,0 +1,119 @@
+//go:build linux
+
+package sockstats
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// diagMsg builds an inet_diag_msg for inode with a tcp_info attribute
+// holding info (nil = no attribute).
+func diagMsg(inode uint32, info *unix.TCPInfo) []byte {
+	msg := make([]byte, inetDiagMsgLen)
+	binary.NativeEndian.PutUint32(msg[inetDiagInodeAt:], inode)
+	if info == nil {
+		return msg
+	}
+	raw := unsafe.Slice((*byte)(unsafe.Pointer(info)), unix.SizeofTCPInfo)
+	attr := make([]byte, unix.SizeofRtAttr, unix.SizeofRtAttr+len(raw))
+	binary.NativeEndian.PutUint16(attr[0:], uint16(unix.SizeofRtAttr+len(raw)))
+	binary.NativeEndian.PutUint16(attr[2:], inetDiagInfo)
+	return append(msg, append(attr, raw...)...)
+}
+
+func TestParseDiagMsg(t *testing.T) {
+	info := &unix.TCPInfo{
+		Rtt:            12500,
+		Min_rtt:        800,
+		Total_retrans:  3,
+		Rcv_ooopack:    7,
+		Bytes_received: 1 << 20,
+	}
+	conn, ok := parseDiagMsg(diagMsg(4242, info))
+	if !ok {
+		t.Fatal("parseDiagMsg() not ok")
+	}
+	want := Conn{
+		Inode:         4242,
+		RTT:           12500 * time.Microsecond,
+		MinRTT:        800 * time.Microsecond,
+		Retransmits:   3,
+		OutOfOrder:    7,
+		BytesReceived: 1 << 20,
+	}
+	if conn != want {
+		t.Errorf("parseDiagMsg() = %+v, want %+v", conn, want)
+	}
+}
+
+func TestParseDiagMsg_Malformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		ok   bool
+	}{
+		{"short", make([]byte, inetDiagMsgLen-1), false},
+		{"no inode", diagMsg(0, nil), false},
+		{"no tcp_info", diagMsg(1, nil), true},
+		{"truncated attribute", diagMsg(1, &unix.TCPInfo{})[:inetDiagMsgLen+10], true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, ok := parseDiagMsg(tt.data)
+			if ok != tt.ok {
+				t.Errorf("parseDiagMsg() ok = %v, want %v", ok, tt.ok)
+			}
+			if conn.RTT != 0 || conn.BytesReceived != 0 {
+				t.Errorf("parseDiagMsg() = %+v, want no tcp_info values", conn)
+			}
+		})
+	}
+}
+
+func TestDumpTCP_OwnConnection(t *testing.T) {
+	if _, err := dumpTCP(); err != nil {
+		t.Skipf("sock_diag unavailable: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			c.Write(make([]byte, 4096))
+			c.Close()
+		}
+	}()
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := io.ReadFull(c, make([]byte, 4096)); err != nil {
+		t.Fatal(err)
+	}
+
+	owned := processInodes([]int{os.Getpid()})
+	conns, err := dumpTCP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range conns {
+		// The peer's FIN counts too once it has closed
+		if owned[conn.Inode] && conn.BytesReceived >= 4096 {
+			return
+		}
+	}
+	t.Errorf("dialed connection with 4096 bytes received not among %d owned sockets", len(owned))
+}
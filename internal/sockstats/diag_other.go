@@ -0,0 +1,13 @@
+//go:build !linux
+
+package sockstats
+
+import "errors"
+
+func dumpTCP() ([]Conn, error) {
+	return nil, errors.New("kernel socket stats are only available on Linux")
+}
+
+func processInodes(pids []int) map[uint64]bool {
+	return nil
+}
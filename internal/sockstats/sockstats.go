@@ -0,0 +1,224 @@
+// Package sockstats samples the swarm's TCP connections from the kernel:
+// round-trip time, retransmits, out-of-order arrivals and bytes received
+// per connection, without parsing FFmpeg's logs.
+//
+// On Linux the connections' tcp_info comes from a netlink sock_diag dump
+// (what "ss -ti" shows), narrowed to the sockets the FFmpeg processes own.
+// Elsewhere, or where netlink is not permitted, New fails and the run
+// carries on with log-derived TCP stats only.
+package sockstats
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/influxdata/tdigest"
+)
+
+// Conn is one sampled TCP connection.
+type Conn struct {
+	Inode         uint64
+	RTT           time.Duration // Smoothed RTT
+	MinRTT        time.Duration
+	Retransmits   int64 // Segments this host retransmitted over the connection's life
+	OutOfOrder    int64 // Packets received out of order: loss or retransmits upstream
+	BytesReceived int64
+}
+
+// Snapshot is the swarm-wide view after one sample.
+type Snapshot struct {
+	Connections            int // Open connections of the FFmpeg processes
+	RTTP50, RTTP95, RTTP99 time.Duration
+	MinRTT                 time.Duration // Lowest of any open connection
+	Retransmits            int64         // Cumulative, all connections sampled
+	OutOfOrder             int64         // Cumulative, all connections sampled
+	BytesReceived          int64         // Cumulative, all connections sampled
+	ThroughputBps          float64       // Bytes received per second in the last interval
+}
+
+// Summary is the view over the whole run.
+type Summary struct {
+	Samples                int // Samples taken
+	PeakConnections        int
+	RTTP50, RTTP95, RTTP99 time.Duration // Over every connection of every sample
+	MinRTT                 time.Duration
+	Retransmits            int64
+	OutOfOrder             int64
+	BytesReceived          int64
+}
+
+// Config configures a Collector.
+type Config struct {
+	Interval time.Duration // Sampling interval (0 = 1s)
+	PIDs     func() []int  // The FFmpeg processes to sample
+	Logger   *slog.Logger
+
+	// OnSample, if set, is called with each new snapshot.
+	OnSample func(Snapshot)
+}
+
+// Collector samples the processes' connections every interval. Totals
+// grow by each connection's increase between samples, so traffic on a
+// connection that opens and closes between two samples is not counted.
+type Collector struct {
+	cfg    Config
+	logger *slog.Logger
+	dump   func() ([]Conn, error)           // All TCP connections on the host
+	owned  func(pids []int) map[uint64]bool // Socket inodes of the processes
+
+	mu       sync.Mutex
+	prev     map[uint64]Conn // Last sample, by inode
+	lastTime time.Time
+	snapshot Snapshot
+	summary  Summary
+	rtts     *tdigest.TDigest // Run-wide RTTs, in seconds
+}
+
+// New returns a Collector, or an error if the kernel can't be asked (not
+// Linux, or netlink denied).
+func New(cfg Config) (*Collector, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	if _, err := dumpTCP(); err != nil {
+		return nil, fmt.Errorf("socket stats unavailable: %w", err)
+	}
+	return &Collector{
+		cfg:    cfg,
+		logger: logger,
+		dump:   dumpTCP,
+		owned:  processInodes,
+		prev:   make(map[uint64]Conn),
+		rtts:   tdigest.NewWithCompression(100),
+	}, nil
+}
+
+// Run samples every interval until ctx is cancelled.
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.sample(time.Now()); err != nil {
+				c.logger.Debug("socket_stats_sample_failed", "error", err)
+				continue
+			}
+			if c.cfg.OnSample != nil {
+				c.cfg.OnSample(c.Snapshot())
+			}
+		}
+	}
+}
+
+// Snapshot returns the latest sample.
+func (c *Collector) Snapshot() Snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.snapshot
+}
+
+// Summary returns the run-wide view.
+func (c *Collector) Summary() Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.summary
+	s.Retransmits = c.snapshot.Retransmits
+	s.OutOfOrder = c.snapshot.OutOfOrder
+	s.BytesReceived = c.snapshot.BytesReceived
+	if c.rtts.Count() > 0 {
+		s.RTTP50 = seconds(c.rtts.Quantile(0.50))
+		s.RTTP95 = seconds(c.rtts.Quantile(0.95))
+		s.RTTP99 = seconds(c.rtts.Quantile(0.99))
+	}
+	return s
+}
+
+// sample takes one sample of the processes' connections.
+func (c *Collector) sample(now time.Time) error {
+	all, err := c.dump()
+	if err != nil {
+		return err
+	}
+	owned := c.owned(c.cfg.PIDs())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Snapshot{
+		Retransmits:   c.snapshot.Retransmits,
+		OutOfOrder:    c.snapshot.OutOfOrder,
+		BytesReceived: c.snapshot.BytesReceived,
+	}
+	cur := make(map[uint64]Conn, len(owned))
+	var rtts []time.Duration
+	var received int64
+	for _, conn := range all {
+		if !owned[conn.Inode] {
+			continue
+		}
+		cur[conn.Inode] = conn
+		prev := c.prev[conn.Inode] // Zero for a new connection
+		if conn.Retransmits >= prev.Retransmits {
+			s.Retransmits += conn.Retransmits - prev.Retransmits
+		}
+		if conn.OutOfOrder >= prev.OutOfOrder {
+			s.OutOfOrder += conn.OutOfOrder - prev.OutOfOrder
+		}
+		if conn.BytesReceived >= prev.BytesReceived {
+			received += conn.BytesReceived - prev.BytesReceived
+		}
+		if conn.RTT > 0 {
+			rtts = append(rtts, conn.RTT)
+			c.rtts.Add(conn.RTT.Seconds(), 1)
+		}
+		if conn.MinRTT > 0 && (s.MinRTT == 0 || conn.MinRTT < s.MinRTT) {
+			s.MinRTT = conn.MinRTT
+		}
+	}
+	s.Connections = len(cur)
+	s.BytesReceived += received
+	if elapsed := now.Sub(c.lastTime).Seconds(); !c.lastTime.IsZero() && elapsed > 0 {
+		s.ThroughputBps = float64(received) / elapsed
+	}
+	if len(rtts) > 0 {
+		sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+		s.RTTP50 = percentile(rtts, 0.50)
+		s.RTTP95 = percentile(rtts, 0.95)
+		s.RTTP99 = percentile(rtts, 0.99)
+	}
+
+	c.prev = cur
+	c.lastTime = now
+	c.snapshot = s
+	c.summary.Samples++
+	c.summary.PeakConnections = max(c.summary.PeakConnections, s.Connections)
+	if s.MinRTT > 0 && (c.summary.MinRTT == 0 || s.MinRTT < c.summary.MinRTT) {
+		c.summary.MinRTT = s.MinRTT
+	}
+	return nil
+}
+
+// seconds converts a tdigest value back to a duration.
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
+
+// percentile returns the nearest-rank q quantile of sorted values.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	idx := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[max(0, min(idx, len(sorted)-1))]
+}
@@ -0,0 +1,106 @@
+package sockstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/tdigest"
+)
+
+// newTestCollector returns a Collector sampling the connections dump
+// returns, of which the inodes in owned belong to the processes.
+func newTestCollector(dump func() []Conn, owned ...uint64) *Collector {
+	inodes := make(map[uint64]bool)
+	for _, inode := range owned {
+		inodes[inode] = true
+	}
+	return &Collector{
+		cfg:   Config{Interval: time.Second, PIDs: func() []int { return []int{1} }},
+		dump:  func() ([]Conn, error) { return dump(), nil },
+		owned: func([]int) map[uint64]bool { return inodes },
+		prev:  make(map[uint64]Conn),
+		rtts:  tdigest.NewWithCompression(100),
+	}
+}
+
+func TestCollector_Sample(t *testing.T) {
+	conns := []Conn{
+		{Inode: 1, RTT: 2 * time.Millisecond, MinRTT: time.Millisecond, BytesReceived: 1000},
+		{Inode: 2, RTT: 10 * time.Millisecond, MinRTT: 5 * time.Millisecond, Retransmits: 1, BytesReceived: 500},
+		{Inode: 99, RTT: time.Second, BytesReceived: 1 << 30}, // Another process
+	}
+	c := newTestCollector(func() []Conn { return conns }, 1, 2, 3)
+
+	start := time.Now()
+	if err := c.sample(start); err != nil {
+		t.Fatal(err)
+	}
+	s := c.Snapshot()
+	if s.Connections != 2 || s.BytesReceived != 1500 || s.Retransmits != 1 {
+		t.Errorf("first sample = %+v, want 2 connections, 1500 bytes, 1 retransmit", s)
+	}
+	if s.MinRTT != time.Millisecond || s.RTTP50 != 2*time.Millisecond || s.RTTP99 != 10*time.Millisecond {
+		t.Errorf("first sample RTT = min %v, P50 %v, P99 %v", s.MinRTT, s.RTTP50, s.RTTP99)
+	}
+	if s.ThroughputBps != 0 {
+		t.Errorf("first sample throughput = %v, want 0 (no interval yet)", s.ThroughputBps)
+	}
+
+	// Connection 1 grows, 2 closes, 3 opens: totals only count the growth
+	conns = []Conn{
+		{Inode: 1, RTT: 4 * time.Millisecond, BytesReceived: 3000, OutOfOrder: 2},
+		{Inode: 3, RTT: 6 * time.Millisecond, BytesReceived: 400},
+	}
+	if err := c.sample(start.Add(2 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	s = c.Snapshot()
+	if s.Connections != 2 || s.BytesReceived != 3900 || s.OutOfOrder != 2 || s.Retransmits != 1 {
+		t.Errorf("second sample = %+v, want 2 connections, 3900 bytes, 2 out of order, 1 retransmit", s)
+	}
+	if s.ThroughputBps != 1200 { // 2400 bytes in 2s
+		t.Errorf("second sample throughput = %v, want 1200", s.ThroughputBps)
+	}
+
+	sum := c.Summary()
+	if sum.Samples != 2 || sum.PeakConnections != 2 || sum.MinRTT != time.Millisecond || sum.BytesReceived != 3900 {
+		t.Errorf("Summary() = %+v", sum)
+	}
+	if sum.RTTP50 < 2*time.Millisecond || sum.RTTP99 > 10*time.Millisecond {
+		t.Errorf("Summary() RTT P50 %v, P99 %v, want within [2ms, 10ms]", sum.RTTP50, sum.RTTP99)
+	}
+}
+
+func TestCollector_Sample_CounterReset(t *testing.T) {
+	// An inode reused by a new connection starts its counters again
+	conns := []Conn{{Inode: 1, BytesReceived: 5000}}
+	c := newTestCollector(func() []Conn { return conns }, 1)
+	_ = c.sample(time.Now())
+	conns = []Conn{{Inode: 1, BytesReceived: 100}}
+	_ = c.sample(time.Now())
+
+	if got := c.Snapshot().BytesReceived; got != 5000 {
+		t.Errorf("BytesReceived = %d, want 5000 (decrease ignored)", got)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.5, 5},
+		{0.95, 10},
+		{0.99, 10},
+		{0, 1},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.q); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+	if got := percentile([]time.Duration{7}, 0.99); got != 7 {
+		t.Errorf("percentile of one = %v, want 7", got)
+	}
+}
@@ -11,6 +11,7 @@ package stats
 
 import (
 	"math"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -40,13 +41,18 @@ type AggregatedStats struct {
 	SegmentReqRate        float64
 	ThroughputBytesPerSec float64
 
-	// Instantaneous rates (per second) - calculated from last snapshot
+	// Instantaneous rates (per second) since the previous Aggregate, from
+	// Delta. Consumers with their own interval take their own Delta.
 	InstantManifestRate   float64
 	InstantSegmentRate    float64
 	InstantThroughputRate float64
 
+	// Highest throughput over any peakWindow-long interval since start
+	PeakThroughputRate float64
+
 	// Errors
 	TotalHTTPErrors    map[int]int64
+	TotalNetworkErrors map[string]int64 // By reason, see NetworkErrorReasons
 	TotalReconnections int64
 	TotalTimeouts      int64
 	ErrorRate          float64 // errors / total requests
@@ -64,12 +70,31 @@ type AggregatedStats struct {
 	MaxDrift             time.Duration
 	ClientsWithHighDrift int // Drift > 5 seconds
 
+	// Heartbeat: time since each client's last parsed event, across the
+	// clients. IdleClients have gone IdleEventAge without one: alive, as
+	// far as the supervisor can tell, but silent.
+	EventAgeP50 time.Duration
+	EventAgeP95 time.Duration
+	EventAgeMax time.Duration
+	IdleClients int
+
 	// Pipeline health (lossy-by-design)
-	TotalLinesDropped int64
-	TotalLinesRead    int64
-	ClientsWithDrops  int
-	MetricsDegraded   bool    // Drop rate > threshold (default 1%)
-	PeakDropRate      float64 // Highest observed drop rate (correlate with load)
+	TotalLinesDropped   int64
+	TotalLinesRead      int64
+	ClientsWithDrops    int
+	MetricsDegraded     bool    // Drop rate > threshold (default 1%)
+	PeakDropRate        float64 // Highest observed drop rate (correlate with load)
+	TotalLinesTruncated int64   // Lines cut at the maximum line length
+	TotalParserPanics   int64   // Lines a parser panicked on
+	TotalInvalidUTF8    int64   // Runs of invalid UTF-8 replaced with U+FFFD
+
+	// Per output path: progress (-progress-mode) and stderr
+	ProgressLinesRead    int64
+	ProgressLinesDropped int64
+	ProgressBytesRead    int64
+	StderrLinesRead      int64
+	StderrLinesDropped   int64
+	StderrBytesRead      int64
 
 	// Uptime distribution
 	MinUptime time.Duration
@@ -84,6 +109,9 @@ type AggregatedStats struct {
 // Organized by protocol layer (HLS/HTTP/TCP) for the layered TUI dashboard.
 // All metrics come from DebugEventParser with accurate FFmpeg timestamps.
 type DebugStatsAggregate struct {
+	// Timestamp when this snapshot was taken
+	Timestamp time.Time
+
 	// HLS Layer (from DebugEventParser)
 	SegmentsDownloaded int64
 	SegmentsFailed     int64
@@ -94,18 +122,24 @@ type DebugStatsAggregate struct {
 	SegmentWallTimeAvg float64
 	SegmentWallTimeMin float64
 	SegmentWallTimeMax float64
-	// Percentiles (from T-Digest, using accurate FFmpeg timestamps)
+	// Swarm-wide percentiles (per-client T-Digests merged, accurate FFmpeg timestamps)
 	SegmentWallTimeP25 time.Duration // 25th percentile
 	SegmentWallTimeP50 time.Duration // 50th percentile (median)
 	SegmentWallTimeP75 time.Duration // 75th percentile
 	SegmentWallTimeP95 time.Duration // 95th percentile
 	SegmentWallTimeP99 time.Duration // 99th percentile
+	// Segment download time (transfer only, see parser.DebugStats) and the
+	// idle gap between a transfer ending and the next segment request
+	SegmentDownloadP50 time.Duration
+	SegmentDownloadP95 time.Duration
+	SegmentDownloadP99 time.Duration
+	SegmentGapAvgMs    float64
 	// Manifest wall time (using accurate FFmpeg timestamps)
 	ManifestCount int64
 	ManifestWallTimeAvg float64
 	ManifestWallTimeMin float64
 	ManifestWallTimeMax float64
-	// Percentiles (from T-Digest, using accurate FFmpeg timestamps)
+	// Swarm-wide percentiles (per-client T-Digests merged, accurate FFmpeg timestamps)
 	ManifestWallTimeP25 time.Duration // 25th percentile
 	ManifestWallTimeP50 time.Duration // 50th percentile (median)
 	ManifestWallTimeP75 time.Duration // 75th percentile
@@ -116,12 +150,45 @@ type DebugStatsAggregate struct {
 	PlaylistLateCount  int64  // Number of playlist refreshes that were late
 	SequenceSkips      int64
 
+	// Playlist refresh intervals vs target duration, and refresh storms
+	PlaylistRefresh RefreshDistribution
+
+	// Discontinuities / ad insertion
+	Discontinuities        int64   // Timestamp discontinuities across all clients
+	ClientsRecovering      int     // Clients waiting for a segment after a discontinuity
+	DiscontinuityRecovered int64   // Discontinuities followed by a completed segment
+	RecoveryAvgMs          float64 // Weighted across clients
+	RecoveryMaxMs          float64
+	AdMarkers              int64 // SCTE-35 cue tags (per client, summed)
+	AdBreaks               int64 // Of which CUE-OUT / SCTE35-OUT
+
+	// Downloads slower than -slow-request-log
+	SlowSegments  int64
+	SlowManifests int64
+
+	// Segments at or over the -slo threshold
+	SLOMisses int64
+
 	// HTTP Layer
 	HTTPOpenCount  int64
 	HTTP4xxCount   int64
 	HTTP5xxCount   int64
 	ReconnectCount int64
 	ErrorRate      float64
+	HostRequests   map[string]int64 // HTTP opens by URL host (multi-CDN / redirect spread)
+
+	// Segment hot spots (HotSpotsKept each)
+	SlowestSegments []SlowSegment // Slowest downloads across the swarm
+	HottestSegments []URLCount    // Most downloaded segment URLs
+
+	// Segment downloads by shard (host and directory), and the shards
+	// grouped by URL template with their load skew (see GroupShards)
+	ShardRequests map[string]int64
+	ShardGroups   []ShardGroup
+
+	// Responses by serving class (ServedEdgeHit etc.; nil unless
+	// response headers were logged, see -cdn-detect)
+	Serving map[string]ServingTotals
 
 	// TCP Layer
 	TCPConnectCount int64
@@ -137,10 +204,20 @@ type DebugStatsAggregate struct {
 	TimestampsUsed int64
 	LinesProcessed int64
 
+	// FFmpeg timestamp offsets from the swarm's clock, corrected before use,
+	// over the clients with timestamped logs (min = max for one host clock)
+	ClockSkewMin time.Duration
+	ClockSkewMax time.Duration
+
+	// Parser pending maps (a steadily growing size points at a leak)
+	PendingEntries  int   // Requests awaiting a completion event, all clients
+	OrphanedPending int64 // Expired without completing
+
 	// Client count
 	ClientsWithDebugStats int
 
-	// Instantaneous rates (per second) - calculated from last snapshot (Phase 7.4)
+	// Instantaneous rates (per second) since the previous computation, from
+	// Delta. Consumers with their own interval take their own Delta.
 	InstantSegmentsRate     float64 // Segments downloaded per second
 	InstantPlaylistsRate    float64 // Playlists refreshed per second
 	InstantHTTPRequestsRate float64 // HTTP requests per second
@@ -167,22 +244,23 @@ type StatsAggregator struct {
 	clients sync.Map // map[int]*ClientStats (lock-free)
 	startTime time.Time
 
-	// For rate calculations (using atomic.Value for lock-free access)
-	prevSnapshot atomic.Value // *rateSnapshot
+	// Counters of the previous Aggregate, for the Instant rates
+	prev atomic.Pointer[AggregatedStats]
 
 	dropThreshold float64
 	// peakDropRate uses atomic.Uint64 with bit manipulation for lock-free max operation
 	peakDropRate atomic.Uint64 // math.Float64bits(peakDropRate)
-}
 
-// rateSnapshot holds values for calculating instantaneous rates
-type rateSnapshot struct {
-	timestamp    time.Time
-	manifestReqs int64
-	segmentReqs  int64
-	bytes        int64
+	// Peak throughput is measured over windows of at least peakWindow, not
+	// between consecutive Aggregate calls: callers poll at different rates
+	// and a few ms between two calls would turn one burst into a huge peak.
+	peakStart      atomic.Pointer[AggregatedStats] // Counters at the start of the current window
+	peakThroughput atomic.Uint64                   // math.Float64bits(peak bytes/sec)
 }
 
+// peakWindow is the shortest interval peak throughput is measured over.
+const peakWindow = time.Second
+
 // NewStatsAggregator creates a new aggregator.
 func NewStatsAggregator(dropThreshold float64) *StatsAggregator {
 	if dropThreshold <= 0 {
@@ -194,10 +272,8 @@ func NewStatsAggregator(dropThreshold float64) *StatsAggregator {
 		dropThreshold: dropThreshold,
 		// clients sync.Map is zero-initialized (ready to use)
 	}
-	// Initialize atomic.Value with initial snapshot
-	agg.prevSnapshot.Store(&rateSnapshot{
-		timestamp: time.Now(),
-	})
+	agg.prev.Store(&AggregatedStats{Timestamp: agg.startTime})
+	agg.peakStart.Store(&AggregatedStats{Timestamp: agg.startTime})
 	return agg
 }
 
@@ -243,13 +319,6 @@ func (a *StatsAggregator) Aggregate() *AggregatedStats {
 	now := time.Now()
 	elapsed := now.Sub(a.startTime).Seconds()
 
-	// Get previous snapshot for rate calculations (lock-free)
-	prevSnapshotPtr := a.prevSnapshot.Load()
-	var prevSnapshot *rateSnapshot
-	if prevSnapshotPtr != nil {
-		prevSnapshot = prevSnapshotPtr.(*rateSnapshot)
-	}
-
 	// Snapshot clients into regular map for fast iteration
 	clients := make(map[int]*ClientStats)
 	clientCount := 0
@@ -260,9 +329,10 @@ func (a *StatsAggregator) Aggregate() *AggregatedStats {
 	})
 
 	result := &AggregatedStats{
-		Timestamp:       now,
-		TotalClients:    clientCount,
-		TotalHTTPErrors: make(map[int]int64),
+		Timestamp:          now,
+		TotalClients:       clientCount,
+		TotalHTTPErrors:    make(map[int]int64),
+		TotalNetworkErrors: make(map[string]int64),
 	}
 
 	// Accumulators
@@ -271,10 +341,18 @@ func (a *StatsAggregator) Aggregate() *AggregatedStats {
 	var totalDrift time.Duration
 	var driftCount int
 	var totalUptime time.Duration
+	eventAges := make([]time.Duration, 0, len(clients))
 
 	for _, c := range clients {
 		result.ActiveClients++
 
+		// Heartbeat
+		age := c.EventAge(now)
+		eventAges = append(eventAges, age)
+		if age >= IdleEventAge {
+			result.IdleClients++
+		}
+
 		// Sum request counts (lock-free atomic reads)
 		result.TotalManifestReqs += c.ManifestRequests.Load()
 		result.TotalSegmentReqs += c.SegmentRequests.Load()
@@ -286,6 +364,9 @@ func (a *StatsAggregator) Aggregate() *AggregatedStats {
 		for code, count := range c.GetHTTPErrors() {
 			result.TotalHTTPErrors[code] += count
 		}
+		for reason, count := range c.GetNetworkErrors() {
+			result.TotalNetworkErrors[reason] += count
+		}
 		result.TotalReconnections += c.Reconnections.Load()
 		result.TotalTimeouts += c.Timeouts.Load()
 
@@ -329,6 +410,15 @@ func (a *StatsAggregator) Aggregate() *AggregatedStats {
 
 		result.TotalLinesRead += progressRead + stderrRead
 		result.TotalLinesDropped += progressDropped + stderrDropped
+		result.ProgressLinesRead += progressRead
+		result.ProgressLinesDropped += progressDropped
+		result.ProgressBytesRead += c.ProgressBytesRead.Load()
+		result.StderrLinesRead += stderrRead
+		result.StderrLinesDropped += stderrDropped
+		result.StderrBytesRead += c.StderrBytesRead.Load()
+		result.TotalLinesTruncated += c.LinesTruncated.Load()
+		result.TotalParserPanics += c.ParserPanics.Load()
+		result.TotalInvalidUTF8 += c.InvalidUTF8.Load()
 
 		if progressDropped > 0 || stderrDropped > 0 {
 			result.ClientsWithDrops++
@@ -358,19 +448,23 @@ func (a *StatsAggregator) Aggregate() *AggregatedStats {
 		result.ThroughputBytesPerSec = float64(result.TotalBytes) / elapsed
 	}
 
-	// Calculate instantaneous rates from previous snapshot
-	if prevSnapshot != nil {
-		snapElapsed := now.Sub(prevSnapshot.timestamp).Seconds()
-		if snapElapsed > 0 {
-			result.InstantManifestRate = float64(result.TotalManifestReqs-prevSnapshot.manifestReqs) / snapElapsed
-			result.InstantSegmentRate = float64(result.TotalSegmentReqs-prevSnapshot.segmentReqs) / snapElapsed
-			result.InstantThroughputRate = float64(result.TotalBytes-prevSnapshot.bytes) / snapElapsed
-		}
-	}
+	// Instantaneous rates since the previous Aggregate
+	d := result.Delta(a.prev.Load())
+	result.InstantManifestRate = d.ManifestRate()
+	result.InstantSegmentRate = d.SegmentRate()
+	result.InstantThroughputRate = d.ThroughputRate()
 
 	// Note: Inferred latency percentiles removed - use DebugStats.SegmentWallTime*
 	// for accurate latency from FFmpeg timestamps
 
+	// Event age distribution
+	if len(eventAges) > 0 {
+		slices.Sort(eventAges)
+		result.EventAgeP50 = eventAges[(len(eventAges)-1)/2]
+		result.EventAgeP95 = eventAges[int(float64(len(eventAges)-1)*0.95)]
+		result.EventAgeMax = eventAges[len(eventAges)-1]
+	}
+
 	// Average speed
 	if speedCount > 0 {
 		result.AverageSpeed = totalSpeed / float64(speedCount)
@@ -419,17 +513,46 @@ func (a *StatsAggregator) Aggregate() *AggregatedStats {
 		// Retry on CAS failure (another goroutine updated it)
 	}
 
-	// Update previous snapshot for next rate calculation (lock-free)
-	a.prevSnapshot.Store(&rateSnapshot{
-		timestamp:    now,
-		manifestReqs: result.TotalManifestReqs,
-		segmentReqs:  result.TotalSegmentReqs,
-		bytes:        result.TotalBytes,
-	})
+	// Keep the counters for the next Aggregate's rates (lock-free)
+	counters := result.Counters()
+	a.prev.Store(counters)
+
+	a.updatePeakThroughput(counters)
+	result.PeakThroughputRate = a.GetPeakThroughputRate()
 
 	return result
 }
 
+// updatePeakThroughput closes the current peak window if it is at least
+// peakWindow old, raising the peak if the window's rate is higher.
+func (a *StatsAggregator) updatePeakThroughput(counters *AggregatedStats) {
+	start := a.peakStart.Load()
+	d := counters.Delta(start)
+	if d.Interval < peakWindow {
+		return
+	}
+	if !a.peakStart.CompareAndSwap(start, counters) {
+		return // Another caller closed this window
+	}
+
+	rate := d.ThroughputRate()
+	for {
+		oldBits := a.peakThroughput.Load()
+		if rate <= math.Float64frombits(oldBits) {
+			return
+		}
+		if a.peakThroughput.CompareAndSwap(oldBits, math.Float64bits(rate)) {
+			return
+		}
+	}
+}
+
+// GetPeakThroughputRate returns the highest throughput (bytes/sec) observed
+// over any window of at least one second.
+func (a *StatsAggregator) GetPeakThroughputRate() float64 {
+	return math.Float64frombits(a.peakThroughput.Load())
+}
+
 // GetPeakDropRate returns the highest drop rate observed across all aggregations.
 // Uses atomic operations for lock-free access.
 func (a *StatsAggregator) GetPeakDropRate() float64 {
@@ -455,11 +578,11 @@ func (a *StatsAggregator) Reset() {
 		return true
 	})
 	a.startTime = time.Now()
-	a.prevSnapshot.Store(&rateSnapshot{
-		timestamp: time.Now(),
-	})
+	a.prev.Store(&AggregatedStats{Timestamp: a.startTime})
+	a.peakStart.Store(&AggregatedStats{Timestamp: a.startTime})
 
 	a.peakDropRate.Store(math.Float64bits(0))
+	a.peakThroughput.Store(math.Float64bits(0))
 }
 
 // ForEachClient calls the provided function for each client.
@@ -120,6 +120,8 @@ func TestStatsAggregator_AggregateErrors(t *testing.T) {
 	stats2 := NewClientStats(2)
 	stats2.RecordHTTPError(404)
 	stats1.RecordReconnection()
+	stats1.RecordNetworkError("reset")
+	stats2.RecordNetworkError("reset")
 
 	agg.AddClient(stats1)
 	agg.AddClient(stats2)
@@ -135,6 +137,9 @@ func TestStatsAggregator_AggregateErrors(t *testing.T) {
 	if result.TotalTimeouts != 1 {
 		t.Errorf("TotalTimeouts = %d, want 1", result.TotalTimeouts)
 	}
+	if result.TotalNetworkErrors["reset"] != 2 {
+		t.Errorf("TotalNetworkErrors[reset] = %d, want 2", result.TotalNetworkErrors["reset"])
+	}
 	if result.TotalReconnections != 1 {
 		t.Errorf("TotalReconnections = %d, want 1", result.TotalReconnections)
 	}
@@ -171,6 +176,33 @@ func TestStatsAggregator_AggregateSpeed(t *testing.T) {
 	}
 }
 
+func TestStatsAggregator_AggregateEventAge(t *testing.T) {
+	agg := NewStatsAggregator(0.01)
+	now := time.Now()
+	for i, age := range []time.Duration{0, time.Second, 2 * time.Second, 40 * time.Second, 2 * time.Minute} {
+		s := NewClientStats(i)
+		s.lastEventAt.Store(now.Add(-age).UnixNano())
+		agg.AddClient(s)
+	}
+
+	result := agg.Aggregate()
+	within := func(got, want time.Duration) bool {
+		return got >= want && got < want+time.Second
+	}
+	if !within(result.EventAgeP50, 2*time.Second) {
+		t.Errorf("EventAgeP50 = %v, want ~2s", result.EventAgeP50)
+	}
+	if !within(result.EventAgeP95, 40*time.Second) {
+		t.Errorf("EventAgeP95 = %v, want ~40s", result.EventAgeP95)
+	}
+	if !within(result.EventAgeMax, 2*time.Minute) {
+		t.Errorf("EventAgeMax = %v, want ~2m", result.EventAgeMax)
+	}
+	if result.IdleClients != 2 {
+		t.Errorf("IdleClients = %d, want 2 (no event for %v)", result.IdleClients, IdleEventAge)
+	}
+}
+
 func TestStatsAggregator_AggregateDrift(t *testing.T) {
 	agg := NewStatsAggregator(0.01)
 
@@ -216,6 +248,13 @@ func TestStatsAggregator_AggregatePipelineHealth(t *testing.T) {
 
 	stats2 := NewClientStats(2)
 	stats2.RecordDroppedLines(100, 0, 100, 0) // No drops
+	stats1.RecordBytesRead(3000, 8000)
+	stats2.RecordBytesRead(2000, 9000)
+	stats2.RecordTruncatedLine()
+	stats2.RecordTruncatedLine()
+	stats1.RecordParserPanic()
+	stats1.RecordInvalidUTF8(2)
+	stats2.RecordInvalidUTF8(1)
 
 	agg.AddClient(stats1)
 	agg.AddClient(stats2)
@@ -231,6 +270,30 @@ func TestStatsAggregator_AggregatePipelineHealth(t *testing.T) {
 	if result.ClientsWithDrops != 1 {
 		t.Errorf("ClientsWithDrops = %d, want 1", result.ClientsWithDrops)
 	}
+	for _, f := range []struct {
+		name      string
+		got, want int64
+	}{
+		{"ProgressLinesRead", result.ProgressLinesRead, 200},
+		{"ProgressLinesDropped", result.ProgressLinesDropped, 5},
+		{"ProgressBytesRead", result.ProgressBytesRead, 5000},
+		{"StderrLinesRead", result.StderrLinesRead, 200},
+		{"StderrLinesDropped", result.StderrLinesDropped, 5},
+		{"StderrBytesRead", result.StderrBytesRead, 17000},
+	} {
+		if f.got != f.want {
+			t.Errorf("%s = %d, want %d", f.name, f.got, f.want)
+		}
+	}
+	if result.TotalLinesTruncated != 2 {
+		t.Errorf("TotalLinesTruncated = %d, want 2", result.TotalLinesTruncated)
+	}
+	if result.TotalParserPanics != 1 {
+		t.Errorf("TotalParserPanics = %d, want 1", result.TotalParserPanics)
+	}
+	if result.TotalInvalidUTF8 != 3 {
+		t.Errorf("TotalInvalidUTF8 = %d, want 3", result.TotalInvalidUTF8)
+	}
 	if !result.MetricsDegraded {
 		t.Error("MetricsDegraded should be true (2.5% > 1%)")
 	}
@@ -326,6 +389,42 @@ func TestStatsAggregator_InstantaneousRates(t *testing.T) {
 	}
 }
 
+func TestStatsAggregator_PeakThroughput(t *testing.T) {
+	agg := NewStatsAggregator(0.01)
+	stats1 := NewClientStats(1)
+	agg.AddClient(stats1)
+
+	// Backdate the peak window instead of sleeping through it
+	backdate := func(d time.Duration, bytes int64) {
+		agg.peakStart.Store(&AggregatedStats{Timestamp: time.Now().Add(-d), TotalBytes: bytes})
+	}
+
+	backdate(2*time.Second, 0)
+	stats1.UpdateCurrentBytes(4000)
+	result := agg.Aggregate()
+	if result.PeakThroughputRate < 1900 || result.PeakThroughputRate > 2000 {
+		t.Errorf("PeakThroughputRate = %.0f, want ~2000", result.PeakThroughputRate)
+	}
+
+	// A slower window keeps the earlier peak
+	backdate(2*time.Second, 4000)
+	stats1.UpdateCurrentBytes(5000)
+	if got := agg.Aggregate().PeakThroughputRate; got != result.PeakThroughputRate {
+		t.Errorf("PeakThroughputRate = %.0f after a slower window, want %.0f", got, result.PeakThroughputRate)
+	}
+
+	// Calls closer together than peakWindow don't produce a spike
+	stats1.UpdateCurrentBytes(1_000_000)
+	if got := agg.Aggregate().PeakThroughputRate; got != result.PeakThroughputRate {
+		t.Errorf("PeakThroughputRate = %.0f within one window, want %.0f", got, result.PeakThroughputRate)
+	}
+
+	agg.Reset()
+	if got := agg.GetPeakThroughputRate(); got != 0 {
+		t.Errorf("GetPeakThroughputRate after reset = %.0f, want 0", got)
+	}
+}
+
 // TestStatsAggregator_AggregateLatency removed - inferred latency is no longer tracked.
 // Latency metrics are now provided by DebugEventParser using accurate FFmpeg timestamps.
 // See docs/REMOVE_INFERRED_LATENCY_ANALYSIS.md for details.
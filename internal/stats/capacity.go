@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// CapacitySample is the segment wall time P95 seen over one window at a
+// given client count.
+type CapacitySample struct {
+	Clients int
+	P95     time.Duration
+}
+
+// CapacityProjection is the client count at which the segment wall time
+// P95 is projected to reach Threshold, from the samples taken so far.
+type CapacityProjection struct {
+	Clients   int           // Projected capacity (0 = not enough growth to project)
+	Threshold time.Duration // P95 the origin counts as saturated at
+	Exceeded  bool          // P95 already reached Threshold at Clients
+	Samples   int           // Samples the fit used
+}
+
+const (
+	// capacityFitSamples is how many recent samples the fit uses: the
+	// latency trend at the current load, not the whole ramp's.
+	capacityFitSamples = 12
+
+	// capacityMinSamples is the fewest samples worth fitting a line to.
+	capacityMinSamples = 3
+
+	// capacityHorizon caps a projection at this multiple of the current
+	// client count: a nearly flat trend says nothing that far out.
+	capacityHorizon = 10
+)
+
+// ProjectCapacity fits a line to the P95 of the most recent samples
+// against their client count (least squares) and returns where it
+// crosses threshold.
+func ProjectCapacity(samples []CapacitySample, threshold time.Duration) CapacityProjection {
+	proj := CapacityProjection{Threshold: threshold}
+	if len(samples) > capacityFitSamples {
+		samples = samples[len(samples)-capacityFitSamples:]
+	}
+	proj.Samples = len(samples)
+	if len(samples) == 0 || threshold <= 0 {
+		return proj
+	}
+
+	last := samples[len(samples)-1]
+	if last.P95 >= threshold {
+		proj.Clients = last.Clients
+		proj.Exceeded = true
+		return proj
+	}
+	if len(samples) < capacityMinSamples {
+		return proj
+	}
+
+	var n, sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x, y := float64(s.Clients), float64(s.P95)
+		n++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return proj // All at one client count: no trend
+	}
+	slope := (n*sumXY - sumX*sumY) / denom
+	if slope <= 0 {
+		return proj // Latency isn't growing with load
+	}
+	intercept := (sumY - slope*sumX) / n
+
+	at := (float64(threshold) - intercept) / slope
+	if at > float64(last.Clients*capacityHorizon) {
+		return proj
+	}
+	// The line may cross before the current load while the latest window
+	// is still under threshold: capacity is at least what's running
+	proj.Clients = max(last.Clients, int(at))
+	return proj
+}
+
+// String renders the projection for the dashboard and logs.
+func (p CapacityProjection) String() string {
+	switch {
+	case p.Exceeded:
+		return fmt.Sprintf("P95 over %s at %d clients", p.Threshold, p.Clients)
+	case p.Clients > 0:
+		return fmt.Sprintf("~%d clients (P95 %s)", p.Clients, p.Threshold)
+	default:
+		return "not enough latency growth yet"
+	}
+}
@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectCapacity(t *testing.T) {
+	// P95 of 100ms + 1ms per client: 2s at 1900
+	linear := func(clients ...int) []CapacitySample {
+		var s []CapacitySample
+		for _, c := range clients {
+			s = append(s, CapacitySample{Clients: c, P95: 100*time.Millisecond + time.Duration(c)*time.Millisecond})
+		}
+		return s
+	}
+
+	tests := []struct {
+		name         string
+		samples      []CapacitySample
+		wantClients  int
+		wantExceeded bool
+	}{
+		{"none", nil, 0, false},
+		{"too few", linear(100, 200), 0, false},
+		{"linear", linear(100, 200, 300, 400), 1900, false},
+		{"fit uses the recent samples", append([]CapacitySample{{Clients: 50, P95: 5 * time.Second / 4}}, linear(100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200)...), 1900, false},
+		{"flat", []CapacitySample{{100, time.Second}, {200, time.Second}, {300, time.Second}}, 0, false},
+		{"falling", []CapacitySample{{100, time.Second}, {200, 900 * time.Millisecond}, {300, 800 * time.Millisecond}}, 0, false},
+		{"one client count", []CapacitySample{{100, time.Second}, {100, 1100 * time.Millisecond}, {100, 1200 * time.Millisecond}}, 0, false},
+		{"beyond the horizon", linear(10, 20, 30), 0, false},
+		{"exceeded", []CapacitySample{{100, time.Second}, {200, 1500 * time.Millisecond}, {300, 2100 * time.Millisecond}}, 300, true},
+		{"line crosses before the current load", []CapacitySample{{100, 200 * time.Millisecond}, {200, 1900 * time.Millisecond}, {300, 1950 * time.Millisecond}}, 300, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ProjectCapacity(tt.samples, 2*time.Second)
+			if p.Clients != tt.wantClients || p.Exceeded != tt.wantExceeded {
+				t.Errorf("ProjectCapacity() = %+v, want %d clients (exceeded %v)", p, tt.wantClients, tt.wantExceeded)
+			}
+			if p.Threshold != 2*time.Second {
+				t.Errorf("Threshold = %v, want 2s", p.Threshold)
+			}
+		})
+	}
+}
+
+func TestCapacityProjection_String(t *testing.T) {
+	tests := []struct {
+		p    CapacityProjection
+		want string
+	}{
+		{CapacityProjection{Clients: 1450, Threshold: 2 * time.Second}, "~1450 clients (P95 2s)"},
+		{CapacityProjection{Clients: 300, Threshold: 2 * time.Second, Exceeded: true}, "P95 over 2s at 300 clients"},
+		{CapacityProjection{Threshold: 2 * time.Second}, "not enough latency growth yet"},
+	}
+	for _, tt := range tests {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -29,6 +29,11 @@ const (
 	// HighDriftThreshold is the drift above which we flag a client
 	HighDriftThreshold = 5 * time.Second
 
+	// IdleEventAge is how long a client may go without a parsed event
+	// before it counts as idle: FFmpeg reports progress every second and
+	// requests a segment every target duration, so a live one never does
+	IdleEventAge = 30 * time.Second
+
 	// SegmentSizeRingSize is the number of segment sizes to track
 	SegmentSizeRingSize = 100
 )
@@ -63,6 +68,9 @@ type ClientStats struct {
 	Reconnections   atomic.Int64
 	Timeouts        atomic.Int64
 
+	// Network failures below HTTP, indexed like NetworkErrorReasons
+	networkErrorCounts [len(NetworkErrorReasons)]atomic.Int64
+
 	// Note: Inferred latency removed - use DebugEventParser for accurate latency
 	// from FFmpeg timestamps. See docs/REMOVE_INFERRED_LATENCY_ANALYSIS.md
 
@@ -83,11 +91,20 @@ type ClientStats struct {
 	currentDrift     atomic.Int64 // time.Duration as nanoseconds
 	maxDrift         atomic.Int64 // time.Duration as nanoseconds
 
+	// Heartbeat: UnixNano of the last parsed progress or debug event
+	// (0 = none yet), across restarts
+	lastEventAt atomic.Int64
+
 	// Pipeline health (lossy-by-design metrics, atomic, lock-free)
 	ProgressLinesDropped atomic.Int64
 	StderrLinesDropped   atomic.Int64
 	ProgressLinesRead    atomic.Int64
 	StderrLinesRead      atomic.Int64
+	ProgressBytesRead    atomic.Int64 // Per path, cumulative across restarts
+	StderrBytesRead      atomic.Int64
+	LinesTruncated       atomic.Int64 // Cut at -stats-max-line, cumulative across restarts
+	ParserPanics         atomic.Int64 // Lines a parser panicked on (skipped, parser reset)
+	InvalidUTF8          atomic.Int64 // Runs of invalid UTF-8 replaced with U+FFFD, cumulative across restarts
 	// PeakDropRate uses atomic.Uint64 with bit manipulation for lock-free max operation
 	peakDropRate atomic.Uint64 // math.Float64bits(PeakDropRate)
 }
@@ -144,6 +161,35 @@ func (s *ClientStats) RecordHTTPError(code int) {
 	}
 }
 
+// NetworkErrorReasons are the failure classes below HTTP. Reasons from the
+// parser not listed here are counted as "other".
+var NetworkErrorReasons = [...]string{"timeout", "refused", "reset", "dns", "tls", "other"}
+
+// RecordNetworkError records a connection-level failure by reason.
+// Uses atomic operations for lock-free access.
+func (s *ClientStats) RecordNetworkError(reason string) {
+	i := len(NetworkErrorReasons) - 1 // "other"
+	for j, r := range NetworkErrorReasons {
+		if r == reason {
+			i = j
+			break
+		}
+	}
+	s.networkErrorCounts[i].Add(1)
+}
+
+// GetNetworkErrors returns network failure counts by reason.
+// Only includes reasons with non-zero counts.
+func (s *ClientStats) GetNetworkErrors() map[string]int64 {
+	result := make(map[string]int64)
+	for i, reason := range NetworkErrorReasons {
+		if count := s.networkErrorCounts[i].Load(); count > 0 {
+			result[reason] = count
+		}
+	}
+	return result
+}
+
 // RecordReconnection records a reconnection attempt.
 // Uses atomic operations for lock-free access.
 func (s *ClientStats) RecordReconnection() {
@@ -297,6 +343,25 @@ func (s *ClientStats) IsStalled() bool {
 	return time.Since(thresholdTime) > StallDuration
 }
 
+// --- Heartbeat ---
+
+// RecordEvent marks that a progress update or debug event was just parsed
+// for this client.
+func (s *ClientStats) RecordEvent() {
+	s.lastEventAt.Store(time.Now().UnixNano())
+}
+
+// EventAge returns how long before now the client's last event was parsed,
+// or how long it has been up without one. A process that is alive but
+// stuck shows as a growing age, which its speed (last reported) does not.
+func (s *ClientStats) EventAge(now time.Time) time.Duration {
+	last := s.StartTime
+	if ns := s.lastEventAt.Load(); ns != 0 {
+		last = time.Unix(0, ns)
+	}
+	return max(now.Sub(last), 0)
+}
+
 // --- Segment Size Tracking ---
 
 // RecordSegmentSize records an estimated segment size.
@@ -359,6 +424,29 @@ func (s *ClientStats) RecordDroppedLines(progressRead, progressDropped, stderrRe
 	}
 }
 
+// RecordBytesRead records the bytes read from each output path, both
+// cumulative across restarts.
+func (s *ClientStats) RecordBytesRead(progress, stderr int64) {
+	s.ProgressBytesRead.Store(progress)
+	s.StderrBytesRead.Store(stderr)
+}
+
+// RecordParserPanic counts an output line a parser panicked on.
+func (s *ClientStats) RecordParserPanic() {
+	s.ParserPanics.Add(1)
+}
+
+// RecordTruncatedLine counts an output line cut at the maximum line length.
+func (s *ClientStats) RecordTruncatedLine() {
+	s.LinesTruncated.Add(1)
+}
+
+// RecordInvalidUTF8 counts n runs of invalid UTF-8 replaced in an output
+// line.
+func (s *ClientStats) RecordInvalidUTF8(n int) {
+	s.InvalidUTF8.Add(int64(n))
+}
+
 // CurrentDropRate returns current drop rate (0.0 to 1.0).
 // Uses atomic operations for lock-free access.
 func (s *ClientStats) CurrentDropRate() float64 {
@@ -404,6 +492,7 @@ type Summary struct {
 	Timeouts         int64
 	HTTPErrors       map[int]int64
 	// Note: Latency metrics removed - use DebugStats.SegmentWallTime* for accurate latency
+	EventAge     time.Duration // Since the last parsed event (see EventAge)
 	CurrentSpeed float64
 	IsStalled    bool
 	CurrentDrift time.Duration
@@ -430,6 +519,7 @@ func (s *ClientStats) GetSummary() Summary {
 		Timeouts:         s.Timeouts.Load(),
 		HTTPErrors:       s.GetHTTPErrors(),
 		// Latency metrics removed - use DebugStats for accurate latency from FFmpeg timestamps
+		EventAge:     s.EventAge(time.Now()),
 		CurrentSpeed: s.GetSpeed(),
 		IsStalled:    s.IsStalled(),
 		CurrentDrift: currentDrift,
@@ -148,6 +148,28 @@ func TestClientStats_RecordHTTPError(t *testing.T) {
 	}
 }
 
+func TestClientStats_RecordNetworkError(t *testing.T) {
+	s := NewClientStats(0)
+	for _, reason := range []string{"timeout", "timeout", "refused", "reset", "dns", "tls", "error", "bogus"} {
+		s.RecordNetworkError(reason)
+	}
+
+	got := s.GetNetworkErrors()
+	want := map[string]int64{"timeout": 2, "refused": 1, "reset": 1, "dns": 1, "tls": 1, "other": 2}
+	if len(got) != len(want) {
+		t.Fatalf("GetNetworkErrors() = %v, want %v", got, want)
+	}
+	for reason, count := range want {
+		if got[reason] != count {
+			t.Errorf("GetNetworkErrors()[%q] = %d, want %d", reason, got[reason], count)
+		}
+	}
+
+	if len(NewClientStats(1).GetNetworkErrors()) != 0 {
+		t.Error("fresh stats should report no network errors")
+	}
+}
+
 func TestClientStats_RecordHTTPError_Concurrent(t *testing.T) {
 	s := NewClientStats(0)
 	var wg sync.WaitGroup
@@ -340,6 +362,29 @@ func TestClientStats_SpeedAndStall(t *testing.T) {
 	}
 }
 
+func TestClientStats_EventAge(t *testing.T) {
+	stats := NewClientStats(0)
+	now := stats.StartTime.Add(10 * time.Second)
+
+	// No event yet: age since start
+	if got := stats.EventAge(now); got != 10*time.Second {
+		t.Errorf("EventAge() = %v before any event, want 10s since start", got)
+	}
+
+	stats.RecordEvent()
+	if got := stats.EventAge(time.Now()); got > time.Second {
+		t.Errorf("EventAge() = %v right after an event, want ~0", got)
+	}
+	if got := stats.EventAge(stats.StartTime.Add(-time.Second)); got != 0 {
+		t.Errorf("EventAge() = %v before the event, want clamped to 0", got)
+	}
+
+	stats.lastEventAt.Store(time.Now().Add(-IdleEventAge).UnixNano())
+	if got := stats.GetSummary().EventAge; got < IdleEventAge {
+		t.Errorf("Summary.EventAge = %v, want >= %v", got, IdleEventAge)
+	}
+}
+
 func TestClientStats_SegmentSizeTracking(t *testing.T) {
 	stats := NewClientStats(0)
 
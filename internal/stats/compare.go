@@ -0,0 +1,203 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// OriginResult is one origin's results in a -compare-url run, or one
+// cohort's in a -compare-opt run.
+type OriginResult struct {
+	Name     string // "A" (the stream URL) or "B" (-compare-url)
+	URL      string
+	Options  string  // Request options replacing the shared ones, e.g. "keepalive=off" ("" = none)
+	Share    float64 // Share of the clients asked for, percent
+	Clients  int     // Started clients
+	Requests int64
+	Errors   int64 // HTTP and network errors
+	Bytes    int64
+
+	SegmentP50, SegmentP95, SegmentP99 time.Duration
+	ManifestP50, ManifestP99           time.Duration
+}
+
+// Label returns the URL, with the request options if there are any.
+func (r OriginResult) Label() string {
+	if r.Options == "" {
+		return r.URL
+	}
+	return r.URL + " [" + r.Options + "]"
+}
+
+// ErrorRate returns the errors per request, as a percentage.
+func (r OriginResult) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) * 100 / float64(r.Requests)
+}
+
+// ClientBitrate returns the mean throughput of one client over elapsed, in
+// bits/sec: the fair comparison when the split isn't 50/50.
+func (r OriginResult) ClientBitrate(elapsed time.Duration) float64 {
+	if r.Clients == 0 || elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) * 8 / elapsed.Seconds() / float64(r.Clients)
+}
+
+// OriginComparison is a -compare-url run: the same stream from two origins
+// under one load, with each origin's clients reported side by side. With
+// -compare-opt alone both are the same origin and the cohorts differ in
+// their request options (a red/black comparison).
+type OriginComparison struct {
+	A, B    OriginResult
+	Elapsed time.Duration
+}
+
+// Title names the comparison: of two origins, or of request options on
+// one.
+func (c *OriginComparison) Title() string {
+	if c.A.URL == c.B.URL {
+		return "Red/Black Comparison"
+	}
+	return "Origin A/B Comparison"
+}
+
+// ComparisonRow is one metric of an OriginComparison.
+type ComparisonRow struct {
+	Metric string
+	A, B   string
+	Delta  string // B relative to A ("" = not compared)
+	Better int    // 1 = B better, -1 = B worse, 0 = within noise or not compared
+}
+
+const (
+	// comparisonNoise is the relative difference, in percent, below which
+	// two origins count as even: run-to-run noise under load.
+	comparisonNoise = 5
+
+	// comparisonErrorNoise is the same for error rates, in percentage
+	// points.
+	comparisonErrorNoise = 0.1
+)
+
+// Rows returns the comparison as rows for the exit summary and dashboard.
+func (c *OriginComparison) Rows() []ComparisonRow {
+	a, b := c.A, c.B
+	latency := func(metric string, av, bv time.Duration) ComparisonRow {
+		row := ComparisonRow{Metric: metric, A: FormatMs(av), B: FormatMs(bv)}
+		if av > 0 && bv > 0 {
+			pct := (float64(bv) - float64(av)) * 100 / float64(av)
+			row.Delta = fmt.Sprintf("%+.1f%%", pct)
+			row.Better = verdict(-pct, comparisonNoise)
+		}
+		return row
+	}
+
+	rows := []ComparisonRow{
+		{Metric: "Clients", A: fmt.Sprintf("%d (%.0f%%)", a.Clients, a.Share), B: fmt.Sprintf("%d (%.0f%%)", b.Clients, b.Share)},
+		{Metric: "Requests", A: FormatNumber(a.Requests), B: FormatNumber(b.Requests)},
+	}
+
+	errRow := ComparisonRow{
+		Metric: "Errors",
+		A:      fmt.Sprintf("%d (%.2f%%)", a.Errors, a.ErrorRate()),
+		B:      fmt.Sprintf("%d (%.2f%%)", b.Errors, b.ErrorRate()),
+	}
+	if a.Requests > 0 && b.Requests > 0 {
+		pp := b.ErrorRate() - a.ErrorRate()
+		errRow.Delta = fmt.Sprintf("%+.2fpp", pp)
+		errRow.Better = verdict(-pp, comparisonErrorNoise)
+	}
+	rows = append(rows, errRow)
+
+	ab, bb := a.ClientBitrate(c.Elapsed), b.ClientBitrate(c.Elapsed)
+	tput := ComparisonRow{Metric: "Mbps/client", A: fmt.Sprintf("%.2f", ab/1e6), B: fmt.Sprintf("%.2f", bb/1e6)}
+	if ab > 0 && bb > 0 {
+		pct := (bb - ab) * 100 / ab
+		tput.Delta = fmt.Sprintf("%+.1f%%", pct)
+		tput.Better = verdict(pct, comparisonNoise)
+	}
+	rows = append(rows, tput,
+		latency("Segment P50", a.SegmentP50, b.SegmentP50),
+		latency("Segment P95", a.SegmentP95, b.SegmentP95),
+		latency("Segment P99", a.SegmentP99, b.SegmentP99),
+		latency("Manifest P50", a.ManifestP50, b.ManifestP50),
+		latency("Manifest P99", a.ManifestP99, b.ManifestP99),
+	)
+	return rows
+}
+
+// verdict returns 1 if improvement (positive = better) clears noise, -1 if
+// it is as far the other way, 0 otherwise.
+func verdict(improvement, noise float64) int {
+	switch {
+	case math.Abs(improvement) < noise:
+		return 0
+	case improvement > 0:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// Tally returns how many compared metrics B is better and worse on.
+func (c *OriginComparison) Tally() (better, worse int) {
+	for _, row := range c.Rows() {
+		switch row.Better {
+		case 1:
+			better++
+		case -1:
+			worse++
+		}
+	}
+	return better, worse
+}
+
+// renderOriginComparison renders the two origins of a -compare-url run,
+// or the cohorts of a -compare-opt run, side by side. Returns "" without
+// either.
+func renderOriginComparison(c *OriginComparison) string {
+	if c == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                           " + c.Title() + "\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  A: %s\n", c.A.Label())
+	fmt.Fprintf(&b, "  B: %s\n\n", c.B.Label())
+
+	fmt.Fprintf(&b, "  %-14s %16s %16s %10s\n", "", "A", "B", "B vs A")
+	for _, row := range c.Rows() {
+		mark := ""
+		switch row.Better {
+		case 1:
+			mark = "  better"
+		case -1:
+			mark = "  worse"
+		}
+		line := fmt.Sprintf("  %-14s %16s %16s %10s%s", row.Metric, row.A, row.B, row.Delta, mark)
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	better, worse := c.Tally()
+	switch {
+	case better > 0 && worse == 0:
+		fmt.Fprintf(&b, "\n  B is better on %d metric(s) and worse on none.\n", better)
+	case worse > 0 && better == 0:
+		fmt.Fprintf(&b, "\n  B is worse on %d metric(s) and better on none.\n", worse)
+	case better > 0:
+		fmt.Fprintf(&b, "\n  B is better on %d metric(s), worse on %d.\n", better, worse)
+	default:
+		fmt.Fprintf(&b, "\n  No difference beyond noise (%d%% relative, %.1fpp errors).\n", comparisonNoise, comparisonErrorNoise)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testComparison() *OriginComparison {
+	return &OriginComparison{
+		A: OriginResult{
+			Name: "A", URL: "http://old/stream.m3u8", Share: 50, Clients: 10,
+			Requests: 1000, Errors: 10, Bytes: 75_000_000,
+			SegmentP50: 100 * time.Millisecond, SegmentP95: 400 * time.Millisecond, SegmentP99: time.Second,
+			ManifestP50: 20 * time.Millisecond, ManifestP99: 100 * time.Millisecond,
+		},
+		B: OriginResult{
+			Name: "B", URL: "http://new/stream.m3u8", Share: 50, Clients: 10,
+			Requests: 1000, Errors: 1, Bytes: 75_000_000,
+			SegmentP50: 102 * time.Millisecond, SegmentP95: 300 * time.Millisecond, SegmentP99: 1500 * time.Millisecond,
+			ManifestP50: 20 * time.Millisecond, ManifestP99: 80 * time.Millisecond,
+		},
+		Elapsed: time.Minute,
+	}
+}
+
+func TestOriginComparison_Rows(t *testing.T) {
+	rows := testComparison().Rows()
+	byMetric := make(map[string]ComparisonRow)
+	for _, r := range rows {
+		byMetric[r.Metric] = r
+	}
+
+	tests := []struct {
+		metric     string
+		wantA      string
+		wantB      string
+		wantDelta  string
+		wantBetter int
+	}{
+		{"Clients", "10 (50%)", "10 (50%)", "", 0},
+		{"Errors", "10 (1.00%)", "1 (0.10%)", "-0.90pp", 1},
+		{"Mbps/client", "1.00", "1.00", "+0.0%", 0},
+		{"Segment P50", "100 ms", "102 ms", "+2.0%", 0}, // Within noise
+		{"Segment P95", "400 ms", "300 ms", "-25.0%", 1},
+		{"Segment P99", "1000 ms", "1500 ms", "+50.0%", -1},
+		{"Manifest P99", "100 ms", "80 ms", "-20.0%", 1},
+	}
+	for _, tt := range tests {
+		r, ok := byMetric[tt.metric]
+		if !ok {
+			t.Errorf("no %s row", tt.metric)
+			continue
+		}
+		if r.A != tt.wantA || r.B != tt.wantB || r.Delta != tt.wantDelta || r.Better != tt.wantBetter {
+			t.Errorf("%s = %+v, want A %q B %q delta %q better %d", tt.metric, r, tt.wantA, tt.wantB, tt.wantDelta, tt.wantBetter)
+		}
+	}
+
+	if better, worse := testComparison().Tally(); better != 3 || worse != 1 {
+		t.Errorf("Tally() = %d, %d; want 3, 1", better, worse)
+	}
+}
+
+func TestOriginComparison_RowsWithoutData(t *testing.T) {
+	// Nothing started on B yet: nothing to compare
+	c := &OriginComparison{A: OriginResult{Clients: 1, Requests: 10, Bytes: 1000, SegmentP95: time.Second}, Elapsed: time.Second}
+	for _, r := range c.Rows() {
+		if r.Delta != "" || r.Better != 0 {
+			t.Errorf("%s = %+v, want no comparison without B data", r.Metric, r)
+		}
+	}
+}
+
+func TestOriginResult_ClientBitrate(t *testing.T) {
+	r := OriginResult{Clients: 4, Bytes: 4_000_000}
+	if got := r.ClientBitrate(8 * time.Second); got != 1e6 {
+		t.Errorf("ClientBitrate() = %v, want 1e6", got)
+	}
+	if got := (OriginResult{}).ClientBitrate(time.Second); got != 0 {
+		t.Errorf("ClientBitrate() without clients = %v, want 0", got)
+	}
+}
+
+func TestFormatExitSummary_Compare(t *testing.T) {
+	result := FormatExitSummary(&AggregatedStats{}, SummaryConfig{Compare: testComparison()})
+	for _, want := range []string{
+		"Origin A/B Comparison",
+		"  A: http://old/stream.m3u8",
+		"  B: http://new/stream.m3u8",
+		"  Segment P95              400 ms           300 ms     -25.0%  better",
+		"  Segment P99             1000 ms          1500 ms     +50.0%  worse",
+		"B is better on 3 metric(s), worse on 1.",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "A/B Comparison") {
+		t.Error("comparison shown without -compare-url")
+	}
+}
+
+func TestFormatExitSummary_CompareOptions(t *testing.T) {
+	c := testComparison()
+	c.B.URL = c.A.URL
+	c.B.Options = "keepalive=off, user-agent=Safari/17"
+	result := FormatExitSummary(&AggregatedStats{}, SummaryConfig{Compare: c})
+	for _, want := range []string{
+		"Red/Black Comparison",
+		"  A: http://old/stream.m3u8\n",
+		"  B: http://old/stream.m3u8 [keepalive=off, user-agent=Safari/17]",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "Origin A/B Comparison") {
+		t.Error("one origin titled as an origin comparison")
+	}
+}
@@ -0,0 +1,94 @@
+package stats
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ConnLimitMaxFailures is the share of failed requests a --conn-probe
+// step may have and still count as served: above it the origin is
+// refusing connections.
+const ConnLimitMaxFailures = 0.01
+
+// ConnLimitStep is one --conn-probe step: Connections concurrent
+// requests, each on a new connection, for the step's duration.
+type ConnLimitStep struct {
+	Connections int
+	Requests    int64
+	Failures    int64         // Refused, reset, timed out, or 429/503
+	MeanLatency time.Duration // Of the requests that didn't fail
+}
+
+// FailureRatio returns the share of the step's requests that failed.
+func (s ConnLimitStep) FailureRatio() float64 {
+	return failureRatio(s.Failures, s.Requests)
+}
+
+// ConnLimitSummary is the result of --conn-probe: the origin's connection
+// ceiling, found apart from its throughput ceiling (which the -capacity-p95
+// projection of a swarm run estimates).
+type ConnLimitSummary struct {
+	URL         string
+	Steps       []ConnLimitStep
+	Ceiling     int              // Most concurrent connections a step served (0 = none)
+	Reached     bool             // A step past Ceiling failed: it's the limit, not a lower bound
+	Failures    map[string]int64 // By cause ("refused", "reset", "timeout", "HTTP 503", ...)
+	Interrupted bool             // Stopped before reaching the limit or -conn-probe-max
+}
+
+// Add records a finished step and reports whether to go on to the next:
+// not once a step failed more than ConnLimitMaxFailures.
+func (s *ConnLimitSummary) Add(step ConnLimitStep) bool {
+	s.Steps = append(s.Steps, step)
+	if step.Requests == 0 || step.FailureRatio() > ConnLimitMaxFailures {
+		s.Reached = true
+		return false
+	}
+	s.Ceiling = step.Connections
+	return true
+}
+
+// FormatConnLimit renders the --conn-probe result.
+func FormatConnLimit(s *ConnLimitSummary) string {
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                            Connection Limit\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Target:               %s (new connection per request)\n\n", s.URL)
+	fmt.Fprintf(&b, "  %11s %10s %10s %9s %12s\n", "Connections", "Requests", "Failures", "Failed", "Mean Latency")
+	for _, st := range s.Steps {
+		fmt.Fprintf(&b, "  %11d %10s %10s %8.2f%% %12s\n", st.Connections,
+			FormatNumber(st.Requests), FormatNumber(st.Failures), st.FailureRatio()*100, FormatMs(st.MeanLatency))
+	}
+	b.WriteString("\n")
+
+	switch {
+	case s.Reached && s.Ceiling == 0:
+		fmt.Fprintf(&b, "  Connection Ceiling:   below %d (the first step failed)\n", s.Steps[0].Connections)
+	case s.Reached:
+		last := s.Steps[len(s.Steps)-1]
+		fmt.Fprintf(&b, "  Connection Ceiling:   %d concurrent connections (%.1f%% failed at %d)\n",
+			s.Ceiling, last.FailureRatio()*100, last.Connections)
+	case s.Interrupted:
+		fmt.Fprintf(&b, "  Connection Ceiling:   at least %d (interrupted)\n", s.Ceiling)
+	default:
+		fmt.Fprintf(&b, "  Connection Ceiling:   at least %d (no refusals up to -conn-probe-max)\n", s.Ceiling)
+	}
+	if len(s.Failures) > 0 {
+		causes := slices.SortedFunc(maps.Keys(s.Failures), func(a, b string) int {
+			return cmp.Or(cmp.Compare(s.Failures[b], s.Failures[a]), strings.Compare(a, b))
+		})
+		parts := make([]string, len(causes))
+		for i, c := range causes {
+			parts[i] = fmt.Sprintf("%s %s", c, FormatNumber(s.Failures[c]))
+		}
+		fmt.Fprintf(&b, "  Failures:             %s\n", strings.Join(parts, ", "))
+	}
+	b.WriteString("  (Connections only: a swarm run's -capacity-p95 projection is the throughput ceiling)\n\n")
+	return b.String()
+}
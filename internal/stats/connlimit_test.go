@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnLimitSummary_Add(t *testing.T) {
+	s := &ConnLimitSummary{}
+	if !s.Add(ConnLimitStep{Connections: 50, Requests: 1000, Failures: 5}) {
+		t.Error("Add(0.5% failed) = stop, want go on")
+	}
+	if !s.Add(ConnLimitStep{Connections: 100, Requests: 2000, Failures: 20}) {
+		t.Error("Add(1% failed) = stop, want go on")
+	}
+	if s.Add(ConnLimitStep{Connections: 150, Requests: 2000, Failures: 400}) {
+		t.Error("Add(20% failed) = go on, want stop")
+	}
+	if s.Ceiling != 100 || !s.Reached || len(s.Steps) != 3 {
+		t.Errorf("summary = %+v, want ceiling 100 reached", s)
+	}
+
+	// A step with no request completed is refused outright
+	s = &ConnLimitSummary{}
+	if s.Add(ConnLimitStep{Connections: 50}) || s.Ceiling != 0 || !s.Reached {
+		t.Errorf("summary = %+v, want the first step to fail", s)
+	}
+}
+
+func TestFormatConnLimit(t *testing.T) {
+	s := &ConnLimitSummary{
+		URL:      "http://origin/live.m3u8",
+		Failures: map[string]int64{"refused": 380, "HTTP 503": 20},
+	}
+	s.Add(ConnLimitStep{Connections: 50, Requests: 1000, MeanLatency: 3 * time.Millisecond})
+	s.Add(ConnLimitStep{Connections: 100, Requests: 2000, Failures: 400, MeanLatency: 9 * time.Millisecond})
+	out := FormatConnLimit(s)
+	for _, want := range []string{
+		"Connection Limit",
+		"http://origin/live.m3u8",
+		"Connection Ceiling:   50 concurrent connections (20.0% failed at 100)",
+		"Failures:             refused 380, HTTP 503 20",
+		"throughput ceiling",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatConnLimit() missing %q:\n%s", want, out)
+		}
+	}
+
+	s.Reached, s.Steps = false, s.Steps[:1]
+	if want := "at least 50 (no refusals up to -conn-probe-max)"; !strings.Contains(FormatConnLimit(s), want) {
+		t.Errorf("FormatConnLimit() missing %q:\n%s", want, FormatConnLimit(s))
+	}
+	s.Interrupted = true
+	if want := "at least 50 (interrupted)"; !strings.Contains(FormatConnLimit(s), want) {
+		t.Errorf("FormatConnLimit() missing %q:\n%s", want, FormatConnLimit(s))
+	}
+}
@@ -0,0 +1,118 @@
+package stats
+
+import "time"
+
+// Rates over an interval come from two samples of the cumulative counters:
+// keep the previous sample (Counters is enough) and take cur.Delta(prev).
+// Each consumer keeps its own previous sample, so rates cover exactly the
+// consumer's own interval however often anyone else samples.
+
+// AggregatedDelta is the change in an AggregatedStats' request counters
+// between two samples.
+type AggregatedDelta struct {
+	Interval     time.Duration
+	ManifestReqs int64
+	SegmentReqs  int64
+	Bytes        int64
+}
+
+// Delta returns the change in s's counters since prev. A nil prev, or one
+// not older than s, gives an empty delta (no rates yet).
+func (s *AggregatedStats) Delta(prev *AggregatedStats) AggregatedDelta {
+	if s == nil || prev == nil || !s.Timestamp.After(prev.Timestamp) {
+		return AggregatedDelta{}
+	}
+	return AggregatedDelta{
+		Interval:     s.Timestamp.Sub(prev.Timestamp),
+		ManifestReqs: counterDelta(s.TotalManifestReqs, prev.TotalManifestReqs),
+		SegmentReqs:  counterDelta(s.TotalSegmentReqs, prev.TotalSegmentReqs),
+		Bytes:        counterDelta(s.TotalBytes, prev.TotalBytes),
+	}
+}
+
+// Counters returns the part of s that Delta reads: cheap to keep as the
+// previous sample.
+func (s *AggregatedStats) Counters() *AggregatedStats {
+	return &AggregatedStats{
+		Timestamp:         s.Timestamp,
+		TotalManifestReqs: s.TotalManifestReqs,
+		TotalSegmentReqs:  s.TotalSegmentReqs,
+		TotalBytes:        s.TotalBytes,
+	}
+}
+
+// ManifestRate returns manifest requests per second.
+func (d AggregatedDelta) ManifestRate() float64 { return perSecond(d.ManifestReqs, d.Interval) }
+
+// SegmentRate returns segment requests per second.
+func (d AggregatedDelta) SegmentRate() float64 { return perSecond(d.SegmentReqs, d.Interval) }
+
+// ThroughputRate returns bytes per second.
+func (d AggregatedDelta) ThroughputRate() float64 { return perSecond(d.Bytes, d.Interval) }
+
+// DebugDelta is the change in a DebugStatsAggregate's event counters
+// between two samples.
+type DebugDelta struct {
+	Interval     time.Duration
+	Segments     int64
+	Playlists    int64
+	HTTPRequests int64
+	TCPConnects  int64
+}
+
+// Delta returns the change in s's counters since prev. A nil prev, or one
+// not older than s, gives an empty delta (no rates yet).
+func (s *DebugStatsAggregate) Delta(prev *DebugStatsAggregate) DebugDelta {
+	if s == nil || prev == nil || !s.Timestamp.After(prev.Timestamp) {
+		return DebugDelta{}
+	}
+	return DebugDelta{
+		Interval:     s.Timestamp.Sub(prev.Timestamp),
+		Segments:     counterDelta(s.SegmentsDownloaded, prev.SegmentsDownloaded),
+		Playlists:    counterDelta(s.PlaylistsRefreshed, prev.PlaylistsRefreshed),
+		HTTPRequests: counterDelta(s.HTTPOpenCount, prev.HTTPOpenCount),
+		TCPConnects:  counterDelta(s.TCPConnectCount, prev.TCPConnectCount),
+	}
+}
+
+// Counters returns the part of s that Delta reads: cheap to keep as the
+// previous sample.
+func (s *DebugStatsAggregate) Counters() *DebugStatsAggregate {
+	return &DebugStatsAggregate{
+		Timestamp:          s.Timestamp,
+		SegmentsDownloaded: s.SegmentsDownloaded,
+		PlaylistsRefreshed: s.PlaylistsRefreshed,
+		HTTPOpenCount:      s.HTTPOpenCount,
+		TCPConnectCount:    s.TCPConnectCount,
+	}
+}
+
+// SegmentsRate returns segments downloaded per second.
+func (d DebugDelta) SegmentsRate() float64 { return perSecond(d.Segments, d.Interval) }
+
+// PlaylistsRate returns playlists refreshed per second.
+func (d DebugDelta) PlaylistsRate() float64 { return perSecond(d.Playlists, d.Interval) }
+
+// HTTPRequestsRate returns HTTP requests per second.
+func (d DebugDelta) HTTPRequestsRate() float64 { return perSecond(d.HTTPRequests, d.Interval) }
+
+// TCPConnectsRate returns new TCP connections per second.
+func (d DebugDelta) TCPConnectsRate() float64 { return perSecond(d.TCPConnects, d.Interval) }
+
+// counterDelta is cur - prev for a cumulative counter. Totals summed over
+// clients drop when a client is removed or the stats are reset; that
+// interval counts as nothing rather than a negative rate.
+func counterDelta(cur, prev int64) int64 {
+	if cur < prev {
+		return 0
+	}
+	return cur - prev
+}
+
+// perSecond returns n per second over d (0 for an empty interval).
+func perSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregatedStats_Delta(t *testing.T) {
+	t0 := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	prev := &AggregatedStats{Timestamp: t0, TotalManifestReqs: 100, TotalSegmentReqs: 400, TotalBytes: 1_000_000}
+	cur := &AggregatedStats{Timestamp: t0.Add(2 * time.Second), TotalManifestReqs: 120, TotalSegmentReqs: 500, TotalBytes: 9_000_000}
+
+	d := cur.Delta(prev)
+	if d.Interval != 2*time.Second || d.ManifestReqs != 20 || d.SegmentReqs != 100 || d.Bytes != 8_000_000 {
+		t.Errorf("Delta = %+v", d)
+	}
+	if d.ManifestRate() != 10 || d.SegmentRate() != 50 || d.ThroughputRate() != 4_000_000 {
+		t.Errorf("rates = %v/%v/%v, want 10/50/4000000", d.ManifestRate(), d.SegmentRate(), d.ThroughputRate())
+	}
+
+	// Counters keeps what Delta reads
+	if got := cur.Delta(prev.Counters()); got != d {
+		t.Errorf("Delta(Counters) = %+v, want %+v", got, d)
+	}
+}
+
+func TestAggregatedStats_Delta_NoRates(t *testing.T) {
+	t0 := time.Now()
+	cur := &AggregatedStats{Timestamp: t0, TotalBytes: 100}
+
+	tests := []struct {
+		name string
+		cur  *AggregatedStats
+		prev *AggregatedStats
+	}{
+		{"nil prev", cur, nil},
+		{"nil cur", nil, cur},
+		{"same time", cur, &AggregatedStats{Timestamp: t0}},
+		{"prev newer", cur, &AggregatedStats{Timestamp: t0.Add(time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := tt.cur.Delta(tt.prev)
+			if d != (AggregatedDelta{}) || d.ThroughputRate() != 0 {
+				t.Errorf("Delta = %+v, want empty", d)
+			}
+		})
+	}
+}
+
+func TestAggregatedStats_Delta_CounterDrop(t *testing.T) {
+	// A removed client takes its requests out of the totals
+	t0 := time.Now()
+	prev := &AggregatedStats{Timestamp: t0, TotalSegmentReqs: 500, TotalBytes: 5000}
+	cur := &AggregatedStats{Timestamp: t0.Add(time.Second), TotalSegmentReqs: 300, TotalBytes: 6000}
+
+	d := cur.Delta(prev)
+	if d.SegmentReqs != 0 || d.SegmentRate() != 0 {
+		t.Errorf("SegmentReqs = %d, rate %v; want 0 (no negative rates)", d.SegmentReqs, d.SegmentRate())
+	}
+	if d.Bytes != 1000 {
+		t.Errorf("Bytes = %d, want 1000", d.Bytes)
+	}
+}
+
+func TestDebugStatsAggregate_Delta(t *testing.T) {
+	t0 := time.Now()
+	prev := &DebugStatsAggregate{Timestamp: t0, SegmentsDownloaded: 10, PlaylistsRefreshed: 4, HTTPOpenCount: 14, TCPConnectCount: 3}
+	cur := &DebugStatsAggregate{
+		Timestamp:          t0.Add(500 * time.Millisecond),
+		SegmentsDownloaded: 20,
+		PlaylistsRefreshed: 5,
+		HTTPOpenCount:      25,
+		TCPConnectCount:    1, // Dropped: counts as none
+	}
+
+	d := cur.Delta(prev.Counters())
+	if d.SegmentsRate() != 20 || d.PlaylistsRate() != 2 || d.HTTPRequestsRate() != 22 || d.TCPConnectsRate() != 0 {
+		t.Errorf("rates = %v/%v/%v/%v, want 20/2/22/0",
+			d.SegmentsRate(), d.PlaylistsRate(), d.HTTPRequestsRate(), d.TCPConnectsRate())
+	}
+	if d := cur.Delta(nil); d != (DebugDelta{}) {
+		t.Errorf("Delta(nil) = %+v, want empty", d)
+	}
+}
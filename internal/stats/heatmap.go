@@ -0,0 +1,147 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LatencyHeatmap is a run's segment wall times as a time x latency matrix:
+// Counts[i][b] segments took up to BucketsMs[b] during the interval ending
+// T[i] seconds into the run (the last bucket, one past BucketsMs, is
+// anything slower). Percentiles average a bimodal latency into a number
+// no segment had; the heatmap shows both modes.
+type LatencyHeatmap struct {
+	BucketsMs       []float64 `json:"buckets_ms"`
+	IntervalSeconds float64   `json:"interval_s"` // Between samples, after any halving
+	T               []float64 `json:"t"`
+	Counts          [][]int64 `json:"counts"`
+}
+
+// NewLatencyHeatmap returns an empty heatmap over the given buckets, to be
+// sampled every interval.
+func NewLatencyHeatmap(bucketsMs []float64, interval time.Duration) *LatencyHeatmap {
+	return &LatencyHeatmap{BucketsMs: bucketsMs, IntervalSeconds: interval.Seconds()}
+}
+
+// Add appends the counts of the interval ending elapsed into the run.
+// Like MetricsTimeline it halves its resolution past TimelineMaxSamples,
+// here by merging intervals rather than dropping them, and as both are
+// sampled together their T stay the same.
+func (h *LatencyHeatmap) Add(elapsed time.Duration, counts []int64) {
+	h.T = append(h.T, elapsed.Seconds())
+	h.Counts = append(h.Counts, counts)
+	if len(h.T) > TimelineMaxSamples {
+		h.halve()
+	}
+}
+
+// halve merges each odd interval into the even one after it, keeping the
+// first on its own: the intervals then end at the times MetricsTimeline
+// keeps.
+func (h *LatencyHeatmap) halve() {
+	h.T = everyOther(h.T)
+	merged := h.Counts[:1]
+	for i := 1; i < len(h.Counts); i += 2 {
+		row := h.Counts[i]
+		if i+1 < len(h.Counts) {
+			for b, n := range h.Counts[i+1] {
+				row[b] += n
+			}
+		}
+		merged = append(merged, row)
+	}
+	h.Counts = merged
+	h.IntervalSeconds *= 2
+}
+
+// Segments returns the number of segments in the heatmap.
+func (h *LatencyHeatmap) Segments() int64 {
+	var total int64
+	for _, row := range h.Counts {
+		for _, n := range row {
+			total += n
+		}
+	}
+	return total
+}
+
+// heatmapShades shade a cell by its share of its column's segments.
+var heatmapShades = []rune(" ░▒▓█")
+
+// heatmapMaxColumns caps the width of FormatHeatmap; more intervals are
+// merged into one column.
+const heatmapMaxColumns = 60
+
+// FormatHeatmap renders h as text, slowest bucket on top and time running
+// left to right. Each cell is shaded by its share of the column's
+// segments, so the shape of the latency shows however the load varied.
+// Buckets no segment fell in above or below the rest are left out.
+func FormatHeatmap(h *LatencyHeatmap) string {
+	if h == nil || h.Segments() == 0 {
+		return ""
+	}
+
+	// Merge intervals into at most heatmapMaxColumns columns
+	per := (len(h.Counts) + heatmapMaxColumns - 1) / heatmapMaxColumns
+	buckets := len(h.BucketsMs) + 1
+	var columns [][]int64
+	for i := 0; i < len(h.Counts); i += per {
+		col := make([]int64, buckets)
+		for _, row := range h.Counts[i:min(i+per, len(h.Counts))] {
+			for b, n := range row {
+				col[b] += n
+			}
+		}
+		columns = append(columns, col)
+	}
+
+	lo, hi := buckets, -1
+	for _, col := range columns {
+		for b, n := range col {
+			if n > 0 {
+				lo, hi = min(lo, b), max(hi, b)
+			}
+		}
+	}
+
+	var s strings.Builder
+	fmt.Fprintf(&s, "Segment latency heatmap (%s per column, shaded by share of the column's segments)\n",
+		time.Duration(float64(per)*h.IntervalSeconds*float64(time.Second)))
+	for b := hi; b >= lo; b-- {
+		fmt.Fprintf(&s, "  %7s │", heatmapBucketLabel(h.BucketsMs, b))
+		for _, col := range columns {
+			var total int64
+			for _, n := range col {
+				total += n
+			}
+			shade := 0
+			if total > 0 && col[b] > 0 {
+				// Any segment at all shows, however small its share
+				shade = max(1, int(float64(col[b])/float64(total)*float64(len(heatmapShades)-1)+0.5))
+			}
+			s.WriteRune(heatmapShades[shade])
+		}
+		s.WriteByte('\n')
+	}
+	fmt.Fprintf(&s, "  %7s └%s\n", "", strings.Repeat("─", len(columns)))
+	fmt.Fprintf(&s, "  %7s  0s%*s\n", "", max(len(columns)-2, 0),
+		time.Duration(h.T[len(h.T)-1]*float64(time.Second)).Round(time.Second))
+	return s.String()
+}
+
+// heatmapBucketLabel names bucket b by its upper bound ("≤200ms", "≤5s"),
+// or the last bound for the overflow bucket (">50s").
+func heatmapBucketLabel(bucketsMs []float64, b int) string {
+	if b == len(bucketsMs) {
+		return ">" + formatBucketMs(bucketsMs[b-1])
+	}
+	return "≤" + formatBucketMs(bucketsMs[b])
+}
+
+func formatBucketMs(ms float64) string {
+	if ms >= 1000 {
+		return fmt.Sprintf("%gs", ms/1000)
+	}
+	return fmt.Sprintf("%gms", ms)
+}
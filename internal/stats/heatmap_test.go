@@ -0,0 +1,75 @@
+package stats
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testHeatmapBuckets = []float64{10, 100, 1000}
+
+func TestLatencyHeatmap_Halve(t *testing.T) {
+	h := NewLatencyHeatmap(testHeatmapBuckets, time.Second)
+	tl := NewMetricsTimeline(time.Second)
+	for i := range TimelineMaxSamples + 1 {
+		elapsed := time.Duration(i) * time.Second
+		tl.Add(elapsed, map[string]float64{"x": 1})
+		h.Add(elapsed, []int64{1, 0, 0, int64(i)})
+	}
+
+	if !slices.Equal(h.T, tl.T) {
+		t.Errorf("heatmap T differs from the timeline's after halving")
+	}
+	if h.IntervalSeconds != 2 || len(h.Counts) != len(h.T) {
+		t.Errorf("IntervalSeconds = %v, %d rows for %d times; want 2, equal", h.IntervalSeconds, len(h.Counts), len(h.T))
+	}
+	// Merged, not dropped: every segment is still counted
+	if got, want := h.Segments(), int64(TimelineMaxSamples+1+TimelineMaxSamples*(TimelineMaxSamples+1)/2); got != want {
+		t.Errorf("Segments() = %d, want %d", got, want)
+	}
+	if want := []int64{2, 0, 0, 3}; !slices.Equal(h.Counts[1], want) {
+		t.Errorf("Counts[1] = %v, want intervals 1 and 2 merged: %v", h.Counts[1], want)
+	}
+}
+
+func TestFormatHeatmap(t *testing.T) {
+	if got := FormatHeatmap(nil); got != "" {
+		t.Errorf("FormatHeatmap(nil) = %q, want empty", got)
+	}
+	h := NewLatencyHeatmap(testHeatmapBuckets, 5*time.Second)
+	if got := FormatHeatmap(h); got != "" {
+		t.Errorf("FormatHeatmap(empty) = %q, want empty", got)
+	}
+
+	// Bimodal: fast and slow segments, none in between
+	for i := 1; i <= 4; i++ {
+		h.Add(time.Duration(i)*5*time.Second, []int64{0, 9, 0, 1})
+	}
+	got := FormatHeatmap(h)
+	for _, want := range []string{
+		"5s per column",
+		"  >1s │░░░░\n",
+		"   ≤1s │    \n",
+		" ≤100ms │████\n",
+		"20s",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatHeatmap() missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "≤10ms") {
+		t.Errorf("FormatHeatmap() shows the empty bottom bucket:\n%s", got)
+	}
+}
+
+func TestFormatHeatmap_MergesColumns(t *testing.T) {
+	h := NewLatencyHeatmap(testHeatmapBuckets, time.Second)
+	for i := range 3 * heatmapMaxColumns {
+		h.Add(time.Duration(i+1)*time.Second, []int64{1, 0, 0, 0})
+	}
+	got := FormatHeatmap(h)
+	if !strings.Contains(got, "3s per column") || !strings.Contains(got, "│"+strings.Repeat("█", heatmapMaxColumns)+"\n") {
+		t.Errorf("FormatHeatmap() = \n%s\nwant %d columns of 3s", got, heatmapMaxColumns)
+	}
+}
@@ -0,0 +1,563 @@
+package stats
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RunRecord is one finished run in the -save-run history file, stored one
+// JSON object per line so the file can be appended to without rewriting.
+//
+// Like Snapshot, field names are a stable interface: add fields, don't
+// rename them. Rates are per second and latencies are milliseconds.
+type RunRecord struct {
+	ID              int       `json:"id"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds float64   `json:"duration_s"` // Load phase, without cool-down
+	StreamURL       string    `json:"stream_url"`
+	Variant         string    `json:"variant"`
+	FFmpegCommand   string    `json:"ffmpeg_command,omitempty"`   // As -print-cmd shows it, to reproduce the run
+	PlaylistRefresh float64   `json:"playlist_refresh,omitempty"` // -playlist-refresh (x target duration; 0 = FFmpeg's own)
+	RunTag          string    `json:"run_tag,omitempty"`          // User-Agent tag of a -tag-requests run, to find it in origin logs
+
+	// Clients
+	TargetClients int   `json:"target_clients"`
+	PeakClients   int   `json:"peak_clients"`
+	Starts        int64 `json:"starts"`
+	Restarts      int64 `json:"restarts"`
+
+	// Totals (zero without -stats)
+	ManifestRequests int64   `json:"manifest_requests"`
+	SegmentRequests  int64   `json:"segment_requests"`
+	Bytes            int64   `json:"bytes"`
+	ThroughputBps    float64 `json:"throughput_bytes_per_sec"` // Average over the run
+	PeakThroughput   float64 `json:"peak_throughput_bytes_per_sec"`
+
+	// Errors
+	HTTPErrors    int64   `json:"http_errors"`
+	NetworkErrors int64   `json:"network_errors"`
+	Timeouts      int64   `json:"timeouts"`
+	ErrorRate     float64 `json:"error_rate"`
+
+	// Download wall time (nil without debug stats)
+	SegmentLatency  *LatencySnapshot `json:"segment_latency_ms,omitempty"`
+	ManifestLatency *LatencySnapshot `json:"manifest_latency_ms,omitempty"`
+
+	// Segment latency SLO (nil without -slo)
+	SLO *SLORecord `json:"slo,omitempty"`
+
+	// Configuration warnings the run started with (see config.Warnings)
+	ConfigWarnings []ConfigWarning `json:"config_warnings,omitempty"`
+
+	// Swarm metrics every -stats-interval (nil in records from older versions)
+	Timeline *MetricsTimeline `json:"timeline,omitempty"`
+
+	// Segment wall times by interval and bucket, on the timeline's T (nil
+	// without -stats)
+	SegmentHeatmap *LatencyHeatmap `json:"segment_latency_heatmap,omitempty"`
+
+	// Control actions taken during the run (a rolling restart, say), to
+	// annotate the timeline with
+	Events []RunEvent `json:"events,omitempty"`
+}
+
+// RunEvent is something done to a run while it ran, T seconds into it
+// (on the timeline's clock).
+type RunEvent struct {
+	T      float64 `json:"t"`
+	Name   string  `json:"name"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// ConfigWarning is a configuration warning recorded with a run.
+type ConfigWarning struct {
+	Field      string `json:"field"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion"`
+}
+
+// AddStats fills the traffic, error and latency fields. agg and ds may be
+// nil when stats collection is disabled.
+func (r *RunRecord) AddStats(agg *AggregatedStats, ds *DebugStatsAggregate) {
+	if agg != nil {
+		r.ManifestRequests = agg.TotalManifestReqs
+		r.SegmentRequests = agg.TotalSegmentReqs
+		r.Bytes = agg.TotalBytes
+		r.ThroughputBps = agg.ThroughputBytesPerSec
+		r.PeakThroughput = agg.PeakThroughputRate
+		for _, count := range agg.TotalHTTPErrors {
+			r.HTTPErrors += count
+		}
+		for _, count := range agg.TotalNetworkErrors {
+			r.NetworkErrors += count
+		}
+		r.Timeouts = agg.TotalTimeouts
+		r.ErrorRate = agg.ErrorRate
+	}
+	if ds != nil && ds.SegmentWallTimeP50 > 0 {
+		r.SegmentLatency = &LatencySnapshot{
+			P50: durationMs(ds.SegmentWallTimeP50),
+			P95: durationMs(ds.SegmentWallTimeP95),
+			P99: durationMs(ds.SegmentWallTimeP99),
+		}
+	}
+	if ds != nil && ds.ManifestWallTimeP50 > 0 {
+		r.ManifestLatency = &LatencySnapshot{
+			P50: durationMs(ds.ManifestWallTimeP50),
+			P95: durationMs(ds.ManifestWallTimeP95),
+			P99: durationMs(ds.ManifestWallTimeP99),
+		}
+	}
+}
+
+// Duration returns the load phase duration.
+func (r *RunRecord) Duration() time.Duration {
+	return time.Duration(r.DurationSeconds * float64(time.Second))
+}
+
+// CompressedSuffix marks a gzip-compressed history file (and spill file):
+// each run is appended as a gzip member of its own, so the file is still
+// appended to without rewriting, and reads as one stream with zcat.
+const CompressedSuffix = ".gz"
+
+// LoadRuns reads the history file, oldest run first. A missing file is an
+// empty history.
+func LoadRuns(path string) ([]RunRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, CompressedSuffix) {
+		zr, err := gzip.NewReader(f)
+		if errors.Is(err, io.EOF) {
+			return nil, nil // Created, nothing appended yet
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	var runs []RunRecord
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 32<<20) // A record with its timeline is large
+	for line := 1; sc.Scan(); line++ {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var r RunRecord
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, sc.Err()
+}
+
+// AppendRun assigns r the next run ID and appends it to the history file,
+// creating the file and its directory if needed.
+func AppendRun(path string, r *RunRecord) error {
+	runs, err := LoadRuns(path)
+	if err != nil {
+		return err
+	}
+	r.ID = 1
+	if len(runs) > 0 {
+		r.ID = runs[len(runs)-1].ID + 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	var w io.WriteCloser = f
+	if strings.HasSuffix(path, CompressedSuffix) {
+		w = gzip.NewWriter(f)
+	}
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		f.Close()
+		return err
+	}
+	if w != f {
+		if err := w.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// FindRun returns the run with the given ID.
+func FindRun(runs []RunRecord, id int) (*RunRecord, bool) {
+	for i := range runs {
+		if runs[i].ID == id {
+			return &runs[i], true
+		}
+	}
+	return nil, false
+}
+
+// FormatRunList renders one line per run, oldest first.
+func FormatRunList(runs []RunRecord) string {
+	if len(runs) == 0 {
+		return "No runs recorded yet (run with -save-run).\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%4s  %-16s  %8s  %9s  %12s  %10s  %7s  %s\n",
+		"ID", "Started", "Duration", "Clients", "Throughput", "Seg P95", "Errors", "Stream")
+	for _, r := range runs {
+		segP95 := "-"
+		if r.SegmentLatency != nil {
+			segP95 = fmt.Sprintf("%.0f ms", r.SegmentLatency.P95)
+		}
+		fmt.Fprintf(&b, "%4d  %-16s  %8s  %9s  %12s  %10s  %7s  %s\n",
+			r.ID,
+			r.StartedAt.Local().Format("2006-01-02 15:04"),
+			FormatDuration(r.Duration()),
+			fmt.Sprintf("%d/%d", r.PeakClients, r.TargetClients),
+			FormatBytes(int64(r.ThroughputBps))+"/s",
+			segP95,
+			FormatNumber(r.HTTPErrors+r.NetworkErrors),
+			r.StreamURL,
+		)
+	}
+	return b.String()
+}
+
+// runField is one comparable row of a run, for show and compare.
+type runField struct {
+	name   string
+	value  func(r *RunRecord) (float64, bool) // false = not recorded
+	format func(v float64) string
+	better int // +1 higher is better, -1 lower is better, 0 setting (no verdict)
+}
+
+func latencyField(name string, pick func(r *RunRecord) *LatencySnapshot, p func(l *LatencySnapshot) float64) runField {
+	return runField{
+		name: name,
+		value: func(r *RunRecord) (float64, bool) {
+			if l := pick(r); l != nil {
+				return p(l), true
+			}
+			return 0, false
+		},
+		format: func(v float64) string { return fmt.Sprintf("%.0f ms", v) },
+		better: -1,
+	}
+}
+
+var (
+	segmentLatency  = func(r *RunRecord) *LatencySnapshot { return r.SegmentLatency }
+	manifestLatency = func(r *RunRecord) *LatencySnapshot { return r.ManifestLatency }
+	p50             = func(l *LatencySnapshot) float64 { return l.P50 }
+	p95             = func(l *LatencySnapshot) float64 { return l.P95 }
+	p99             = func(l *LatencySnapshot) float64 { return l.P99 }
+	formatCount     = func(v float64) string { return FormatNumber(int64(v)) }
+)
+
+var runFields = []runField{
+	{"Duration", func(r *RunRecord) (float64, bool) { return r.DurationSeconds, true },
+		func(v float64) string { return FormatDuration(time.Duration(v * float64(time.Second))) }, 0},
+	{"Target clients", func(r *RunRecord) (float64, bool) { return float64(r.TargetClients), true }, formatCount, 0},
+	{"Playlist refresh", func(r *RunRecord) (float64, bool) { return r.PlaylistRefresh, r.PlaylistRefresh > 0 },
+		func(v float64) string { return fmt.Sprintf("%gx target", v) }, 0},
+	{"Peak clients", func(r *RunRecord) (float64, bool) { return float64(r.PeakClients), true }, formatCount, 1},
+	{"Restarts", func(r *RunRecord) (float64, bool) { return float64(r.Restarts), true }, formatCount, -1},
+	{"Segments", func(r *RunRecord) (float64, bool) { return float64(r.SegmentRequests), true }, formatCount, 1},
+	{"Manifests", func(r *RunRecord) (float64, bool) { return float64(r.ManifestRequests), true }, formatCount, 1},
+	{"Throughput", func(r *RunRecord) (float64, bool) { return r.ThroughputBps, true },
+		func(v float64) string { return FormatBytes(int64(v)) + "/s" }, 1},
+	{"Peak throughput", func(r *RunRecord) (float64, bool) { return r.PeakThroughput, r.PeakThroughput > 0 },
+		func(v float64) string { return FormatBytes(int64(v)) + "/s" }, 1},
+	latencyField("Segment P50", segmentLatency, p50),
+	latencyField("Segment P95", segmentLatency, p95),
+	latencyField("Segment P99", segmentLatency, p99),
+	latencyField("Manifest P95", manifestLatency, p95),
+	{"HTTP errors", func(r *RunRecord) (float64, bool) { return float64(r.HTTPErrors), true }, formatCount, -1},
+	{"Network errors", func(r *RunRecord) (float64, bool) { return float64(r.NetworkErrors), true }, formatCount, -1},
+	{"Timeouts", func(r *RunRecord) (float64, bool) { return float64(r.Timeouts), true }, formatCount, -1},
+	{"Error rate", func(r *RunRecord) (float64, bool) { return r.ErrorRate, true },
+		func(v float64) string { return fmt.Sprintf("%.3f%%", v*100) }, -1},
+	{"SLO attainment", func(r *RunRecord) (float64, bool) {
+		if r.SLO == nil {
+			return 0, false
+		}
+		return r.SLO.Attainment, true
+	}, func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) }, 1},
+}
+
+// FormatRun renders every recorded field of one run.
+func FormatRun(r *RunRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Run #%d\n", r.ID)
+	fmt.Fprintf(&b, "  %-16s %s\n", "Started", r.StartedAt.Local().Format(time.RFC3339))
+	fmt.Fprintf(&b, "  %-16s %s\n", "Stream", r.StreamURL)
+	if r.Variant != "" {
+		fmt.Fprintf(&b, "  %-16s %s\n", "Variant", r.Variant)
+	}
+	if r.FFmpegCommand != "" {
+		fmt.Fprintf(&b, "  %-16s %s\n", "FFmpeg command", r.FFmpegCommand)
+	}
+	if r.RunTag != "" {
+		fmt.Fprintf(&b, "  %-16s %s\n", "Run tag", r.RunTag)
+	}
+	for _, f := range runFields {
+		if v, ok := f.value(r); ok {
+			fmt.Fprintf(&b, "  %-16s %s\n", f.name, f.format(v))
+		}
+	}
+	for i, w := range r.ConfigWarnings {
+		label := ""
+		if i == 0 {
+			label = "Config warnings"
+		}
+		fmt.Fprintf(&b, "  %-16s %s: %s (%s)\n", label, w.Field, w.Message, w.Suggestion)
+	}
+	for i, e := range r.Events {
+		label := ""
+		if i == 0 {
+			label = "Events"
+		}
+		fmt.Fprintf(&b, "  %-16s %8s %s", label,
+			"+"+time.Duration(e.T*float64(time.Second)).Round(time.Second).String(), e.Name)
+		if e.Detail != "" {
+			fmt.Fprintf(&b, ": %s", e.Detail)
+		}
+		b.WriteString("\n")
+	}
+	if heatmap := FormatHeatmap(r.SegmentHeatmap); heatmap != "" {
+		b.WriteString("\n")
+		b.WriteString(heatmap)
+	}
+	return b.String()
+}
+
+// FormatRunCompare renders two runs side by side with the relative change
+// from a to b, marking changes for the better (+) and worse (-).
+func FormatRunCompare(a, b *RunRecord) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "  %-16s %14s %14s  %s\n", "", fmt.Sprintf("Run #%d", a.ID), fmt.Sprintf("Run #%d", b.ID), "Change")
+	for _, f := range runFields {
+		va, okA := f.value(a)
+		vb, okB := f.value(b)
+		if !okA && !okB {
+			continue
+		}
+		colA, colB, change := "-", "-", ""
+		if okA {
+			colA = f.format(va)
+		}
+		if okB {
+			colB = f.format(vb)
+		}
+		if okA && okB && va != vb {
+			change = "n/a"
+			if va != 0 {
+				change = fmt.Sprintf("%+.1f%%", (vb-va)/va*100)
+			}
+			switch {
+			case f.better == 0:
+			case (vb > va) == (f.better > 0):
+				change += " (+)"
+			default:
+				change += " (-)"
+			}
+		}
+		line := fmt.Sprintf("  %-16s %14s %14s  %s", f.name, colA, colB, change)
+		s.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return s.String()
+}
+
+// key is the name used on the command line: "Segment P95" -> "segment-p95".
+func (f runField) key() string {
+	return strings.ReplaceAll(strings.ToLower(f.name), " ", "-")
+}
+
+// RunMetricNames lists the metrics accepted by NewRunTrend.
+func RunMetricNames() []string {
+	names := make([]string, len(runFields))
+	for i, f := range runFields {
+		names[i] = f.key()
+	}
+	return names
+}
+
+// Trend thresholds
+const (
+	trendMinBaseline = 3 // Previous runs needed before judging the latest
+	trendZThreshold  = 2 // Standard deviations from the baseline mean (~95%)
+)
+
+// RunTrend is one metric across comparable runs (same target clients),
+// oldest first, with the latest run judged against the ones before it.
+type RunTrend struct {
+	Metric  string
+	Clients int
+	Runs    []*RunRecord
+	Values  []float64
+
+	SlopePct float64 // Least-squares change per run, % of the mean
+
+	// Latest run against the previous runs (valid when Judged)
+	Judged         bool
+	BaselineMean   float64
+	BaselineStdDev float64
+	Z              float64 // (latest - mean) / stddev; ±Inf off a flat baseline
+	Regression     bool    // Significantly worse
+	Improvement    bool    // Significantly better
+
+	field runField
+}
+
+// NewRunTrend selects the last n runs at the given target client count
+// that recorded metric (see RunMetricNames) and fits the trend.
+func NewRunTrend(runs []RunRecord, metric string, clients, n int) (*RunTrend, error) {
+	var field *runField
+	for i := range runFields {
+		if runFields[i].key() == metric {
+			field = &runFields[i]
+		}
+	}
+	if field == nil {
+		return nil, fmt.Errorf("unknown metric %q (one of: %s)", metric, strings.Join(RunMetricNames(), ", "))
+	}
+
+	t := &RunTrend{Metric: field.name, Clients: clients, field: *field}
+	for i := range runs {
+		if runs[i].TargetClients != clients {
+			continue
+		}
+		if v, ok := field.value(&runs[i]); ok {
+			t.Runs = append(t.Runs, &runs[i])
+			t.Values = append(t.Values, v)
+		}
+	}
+	if len(t.Runs) > n {
+		t.Runs = t.Runs[len(t.Runs)-n:]
+		t.Values = t.Values[len(t.Values)-n:]
+	}
+	if len(t.Runs) == 0 {
+		return nil, fmt.Errorf("no runs at %d clients recorded %s", clients, field.name)
+	}
+
+	t.SlopePct = slopePct(t.Values)
+
+	if base := t.Values[:len(t.Values)-1]; len(base) >= trendMinBaseline {
+		t.Judged = true
+		t.BaselineMean, t.BaselineStdDev = meanStdDev(base)
+		latest := t.Values[len(t.Values)-1]
+		switch {
+		case t.BaselineStdDev > 0:
+			t.Z = (latest - t.BaselineMean) / t.BaselineStdDev
+		case latest != t.BaselineMean:
+			// Flat baseline (e.g. 0 errors every run): any change stands out
+			t.Z = math.Inf(1)
+			if latest < t.BaselineMean {
+				t.Z = math.Inf(-1)
+			}
+		}
+		worse := (t.Z > 0) == (field.better < 0)
+		if field.better != 0 && math.Abs(t.Z) >= trendZThreshold {
+			t.Regression = worse
+			t.Improvement = !worse
+		}
+	}
+	return t, nil
+}
+
+// meanStdDev returns the mean and sample standard deviation.
+func meanStdDev(v []float64) (mean, stddev float64) {
+	for _, x := range v {
+		mean += x
+	}
+	mean /= float64(len(v))
+	if len(v) < 2 {
+		return mean, 0
+	}
+	var ss float64
+	for _, x := range v {
+		ss += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(v)-1))
+}
+
+// slopePct fits v against the run index by least squares and returns the
+// slope as a percentage of the mean (0 for fewer than two runs).
+func slopePct(v []float64) float64 {
+	n := float64(len(v))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range v {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	mean := sumY / n
+	if mean == 0 {
+		return 0
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	return slope / mean * 100
+}
+
+// Format renders the trend: one line per run, the fitted slope, and the
+// verdict on the latest run.
+func (t *RunTrend) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s at %d clients, last %d run(s):\n", t.Metric, t.Clients, len(t.Runs))
+	for i, r := range t.Runs {
+		fmt.Fprintf(&b, "  #%-4d %s  %12s\n", r.ID, r.StartedAt.Local().Format("2006-01-02 15:04"), t.field.format(t.Values[i]))
+	}
+	if len(t.Runs) > 1 {
+		fmt.Fprintf(&b, "\n  Trend: %+.1f%% per run\n", t.SlopePct)
+	}
+
+	if !t.Judged {
+		fmt.Fprintf(&b, "  Need %d earlier runs to judge the latest\n", trendMinBaseline)
+		return b.String()
+	}
+	latest := t.Runs[len(t.Runs)-1]
+	fmt.Fprintf(&b, "  Latest #%d: %s vs %s ± %s over the previous %d runs (z = %+.1f)\n",
+		latest.ID,
+		t.field.format(t.Values[len(t.Values)-1]),
+		t.field.format(t.BaselineMean),
+		t.field.format(t.BaselineStdDev),
+		len(t.Runs)-1,
+		t.Z,
+	)
+	switch {
+	case t.Regression:
+		b.WriteString("  ⚠️  REGRESSION: significantly worse than the previous runs\n")
+	case t.Improvement:
+		b.WriteString("  ✅ Improvement: significantly better than the previous runs\n")
+	default:
+		b.WriteString("  No significant change\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,363 @@
+package stats
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendRun_LoadRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "runs.jsonl")
+
+	runs, err := LoadRuns(path)
+	if err != nil || len(runs) != 0 {
+		t.Fatalf("LoadRuns(missing) = %v, %v; want empty history", runs, err)
+	}
+
+	start := time.Date(2026, 1, 23, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		r := &RunRecord{StartedAt: start.Add(time.Duration(i) * time.Hour), TargetClients: 100 * (i + 1)}
+		if err := AppendRun(path, r); err != nil {
+			t.Fatalf("AppendRun() = %v", err)
+		}
+		if r.ID != i+1 {
+			t.Errorf("run %d got ID %d", i, r.ID)
+		}
+	}
+
+	runs, err = LoadRuns(path)
+	if err != nil {
+		t.Fatalf("LoadRuns() = %v", err)
+	}
+	if len(runs) != 3 || runs[2].ID != 3 || runs[2].TargetClients != 300 || !runs[0].StartedAt.Equal(start) {
+		t.Errorf("LoadRuns() = %+v", runs)
+	}
+
+	r, ok := FindRun(runs, 2)
+	if !ok || r.TargetClients != 200 {
+		t.Errorf("FindRun(2) = %+v, %v", r, ok)
+	}
+	if _, ok := FindRun(runs, 9); ok {
+		t.Error("FindRun(9) found a run")
+	}
+}
+
+func TestAppendRun_Compressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.jsonl.gz")
+	for i := 0; i < 3; i++ {
+		if err := AppendRun(path, &RunRecord{TargetClients: 100 * (i + 1)}); err != nil {
+			t.Fatalf("AppendRun() = %v", err)
+		}
+	}
+	runs, err := LoadRuns(path)
+	if err != nil || len(runs) != 3 || runs[2].ID != 3 || runs[2].TargetClients != 300 {
+		t.Fatalf("LoadRuns() = %+v, %v; want 3 runs", runs, err)
+	}
+
+	// One gzip member per run: the file is one stream to zcat
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 3 {
+		t.Errorf("decompressed history has %d lines, want 3", n)
+	}
+
+	// An empty file (created, nothing appended) is an empty history
+	empty := filepath.Join(t.TempDir(), "empty.jsonl.gz")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if runs, err := LoadRuns(empty); err != nil || len(runs) != 0 {
+		t.Errorf("LoadRuns(empty) = %v, %v; want empty history", runs, err)
+	}
+}
+
+func TestFormatRun(t *testing.T) {
+	r := &RunRecord{
+		ID:            4,
+		StreamURL:     "http://origin/live.m3u8",
+		FFmpegCommand: "ffmpeg -hide_banner -max_reload 100 -i http://origin/live.m3u8 -c copy -f null -",
+		TargetClients: 50,
+	}
+	out := FormatRun(r)
+	for _, want := range []string{"Run #4", "FFmpeg command   ffmpeg -hide_banner -max_reload 100", "Target clients"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatRun() missing %q:\n%s", want, out)
+		}
+	}
+
+	r.FFmpegCommand = "" // Runs saved before the command was recorded
+	if strings.Contains(FormatRun(r), "FFmpeg command") {
+		t.Error("FormatRun() shows an empty FFmpeg command")
+	}
+	if strings.Contains(FormatRun(r), "Run tag") {
+		t.Error("FormatRun() shows a run tag the run didn't have")
+	}
+	r.RunTag = "run-1a2b3c4d"
+	if want := "Run tag          run-1a2b3c4d"; !strings.Contains(FormatRun(r), want) {
+		t.Errorf("FormatRun() missing %q:\n%s", want, FormatRun(r))
+	}
+	r.RunTag = ""
+	if strings.Contains(FormatRun(r), "Playlist refresh") {
+		t.Error("FormatRun() shows a playlist refresh override the run didn't have")
+	}
+
+	if strings.Contains(FormatRun(r), "Config warnings") {
+		t.Error("FormatRun() shows config warnings the run didn't have")
+	}
+	r.ConfigWarnings = []ConfigWarning{{Field: "reconnect_delay_max", Message: "no delay", Suggestion: "set -reconnect-delay"}}
+	if want := "Config warnings  reconnect_delay_max: no delay (set -reconnect-delay)"; !strings.Contains(FormatRun(r), want) {
+		t.Errorf("FormatRun() missing %q:\n%s", want, FormatRun(r))
+	}
+
+	r.PlaylistRefresh = 0.5
+	if want := "Playlist refresh 0.5x target"; !strings.Contains(FormatRun(r), want) {
+		t.Errorf("FormatRun() missing %q:\n%s", want, FormatRun(r))
+	}
+
+	if strings.Contains(FormatRun(r), "SLO attainment") {
+		t.Error("FormatRun() shows an SLO the run didn't have")
+	}
+	r.SLO = &SLORecord{Target: 0.99, ThresholdMs: 1000, Attainment: 0.9942, Met: true}
+	if want := "SLO attainment   99.42%"; !strings.Contains(FormatRun(r), want) {
+		t.Errorf("FormatRun() missing %q:\n%s", want, FormatRun(r))
+	}
+
+	if strings.Contains(FormatRun(r), "Events") {
+		t.Error("FormatRun() shows events the run didn't have")
+	}
+	r.Events = []RunEvent{
+		{T: 90.2, Name: "rolling_restart", Detail: "50 clients at 10/s"},
+		{T: 95.4, Name: "rolling_restart_done"},
+	}
+	for _, want := range []string{
+		"Events             +1m30s rolling_restart: 50 clients at 10/s\n",
+		"                   +1m35s rolling_restart_done\n",
+	} {
+		if !strings.Contains(FormatRun(r), want) {
+			t.Errorf("FormatRun() missing %q:\n%s", want, FormatRun(r))
+		}
+	}
+}
+
+func TestLoadRuns_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.jsonl")
+	if err := os.WriteFile(path, []byte("{\"id\":1}\n{not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRuns(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("LoadRuns() = %v, want error naming line 2", err)
+	}
+}
+
+func TestRunRecord_AddStats(t *testing.T) {
+	var r RunRecord
+	r.AddStats(nil, nil) // Stats disabled
+	if r.SegmentRequests != 0 || r.SegmentLatency != nil {
+		t.Errorf("AddStats(nil, nil) filled %+v", r)
+	}
+
+	agg := &AggregatedStats{
+		TotalSegmentReqs:      500,
+		TotalBytes:            1_000_000,
+		ThroughputBytesPerSec: 2000,
+		TotalHTTPErrors:       map[int]int64{503: 3, 404: 1},
+		TotalNetworkErrors:    map[string]int64{"reset": 2},
+		TotalTimeouts:         1,
+	}
+	ds := &DebugStatsAggregate{
+		SegmentWallTimeP50: 20 * time.Millisecond,
+		SegmentWallTimeP95: 60 * time.Millisecond,
+		SegmentWallTimeP99: 90 * time.Millisecond,
+	}
+	r.AddStats(agg, ds)
+
+	if r.SegmentRequests != 500 || r.HTTPErrors != 4 || r.NetworkErrors != 2 || r.Timeouts != 1 {
+		t.Errorf("AddStats() totals = %+v", r)
+	}
+	if r.SegmentLatency == nil || r.SegmentLatency.P95 != 60 {
+		t.Errorf("SegmentLatency = %+v, want P95 60 ms", r.SegmentLatency)
+	}
+	if r.ManifestLatency != nil {
+		t.Errorf("ManifestLatency = %+v, want nil without manifest timings", r.ManifestLatency)
+	}
+}
+
+func TestFormatRunList(t *testing.T) {
+	if got := FormatRunList(nil); !strings.Contains(got, "-save-run") {
+		t.Errorf("empty list = %q, want a hint about -save-run", got)
+	}
+
+	got := FormatRunList([]RunRecord{{
+		ID:              7,
+		StartedAt:       time.Date(2026, 1, 23, 8, 0, 0, 0, time.Local),
+		DurationSeconds: 300,
+		StreamURL:       "http://origin/stream.m3u8",
+		TargetClients:   100,
+		PeakClients:     98,
+		SegmentLatency:  &LatencySnapshot{P95: 61.4},
+	}})
+	for _, want := range []string{"2026-01-23 08:00", "00:05:00", "98/100", "61 ms", "http://origin/stream.m3u8"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("list missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatRunCompare(t *testing.T) {
+	a := &RunRecord{ID: 1, TargetClients: 100, PeakClients: 100, ThroughputBps: 1_000_000,
+		SegmentLatency: &LatencySnapshot{P50: 20, P95: 50, P99: 80}}
+	b := &RunRecord{ID: 2, TargetClients: 200, PeakClients: 200, ThroughputBps: 1_500_000,
+		SegmentLatency: &LatencySnapshot{P50: 20, P95: 100, P99: 80}}
+
+	got := FormatRunCompare(a, b)
+	row := func(name string) (string, bool) {
+		for _, line := range strings.Split(got, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(line), name+"  ") {
+				return line, true
+			}
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		row  string
+		want string
+	}{
+		{"Target clients", "+100.0%"}, // A setting: no verdict
+		{"Throughput", "+50.0% (+)"},
+		{"Segment P95", "+100.0% (-)"},
+	}
+	for _, tt := range tests {
+		line, ok := row(tt.row)
+		if !ok {
+			t.Errorf("no %q row in:\n%s", tt.row, got)
+			continue
+		}
+		if !strings.HasSuffix(line, tt.want) {
+			t.Errorf("%q row = %q, want suffix %q", tt.row, line, tt.want)
+		}
+	}
+	if line, _ := row("Segment P50"); !strings.HasSuffix(line, "20 ms") {
+		t.Errorf("unchanged P50 row = %q, want no change column", line)
+	}
+	if strings.Contains(got, "Manifest P95") {
+		t.Error("compare shows Manifest P95, recorded by neither run")
+	}
+}
+
+// trendRuns returns runs at the given client count with these segment P95s.
+func trendRuns(clients int, p95s ...float64) []RunRecord {
+	runs := make([]RunRecord, len(p95s))
+	for i, v := range p95s {
+		runs[i] = RunRecord{ID: i + 1, TargetClients: clients, SegmentLatency: &LatencySnapshot{P95: v}}
+	}
+	return runs
+}
+
+func TestNewRunTrend(t *testing.T) {
+	tests := []struct {
+		name        string
+		p95s        []float64
+		wantJudged  bool
+		wantRegress bool
+		wantImprove bool
+	}{
+		{"too few runs", []float64{50, 52, 90}, false, false, false},
+		{"stable", []float64{50, 52, 48, 51, 49, 50}, true, false, false},
+		{"regression", []float64{50, 52, 48, 51, 49, 90}, true, true, false},
+		{"improvement", []float64{50, 52, 48, 51, 49, 20}, true, false, true},
+		{"flat baseline", []float64{50, 50, 50, 51}, true, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend, err := NewRunTrend(trendRuns(500, tt.p95s...), "segment-p95", 500, 10)
+			if err != nil {
+				t.Fatalf("NewRunTrend() = %v", err)
+			}
+			if trend.Judged != tt.wantJudged || trend.Regression != tt.wantRegress || trend.Improvement != tt.wantImprove {
+				t.Errorf("judged=%v regression=%v improvement=%v (z=%.1f), want %v/%v/%v",
+					trend.Judged, trend.Regression, trend.Improvement, trend.Z,
+					tt.wantJudged, tt.wantRegress, tt.wantImprove)
+			}
+		})
+	}
+}
+
+func TestNewRunTrend_SLOAttainment(t *testing.T) {
+	var runs []RunRecord
+	for i, attainment := range []float64{0.995, 0.996, 0.994, 0.995, 0.95} {
+		runs = append(runs, RunRecord{ID: i + 1, TargetClients: 500, SLO: &SLORecord{Target: 0.99, Attainment: attainment}})
+	}
+	runs = append(runs, RunRecord{ID: 6, TargetClients: 500}) // Without -slo: skipped
+
+	trend, err := NewRunTrend(runs, "slo-attainment", 500, 10)
+	if err != nil {
+		t.Fatalf("NewRunTrend() = %v", err)
+	}
+	if len(trend.Runs) != 5 || !trend.Regression {
+		t.Errorf("runs=%d regression=%v, want 5 runs and a regression (lower attainment is worse)", len(trend.Runs), trend.Regression)
+	}
+}
+
+func TestNewRunTrend_Selection(t *testing.T) {
+	runs := append(trendRuns(500, 10, 20, 30, 40, 50), trendRuns(100, 999)...)
+	runs[5].ID = 6
+	runs = append(runs, RunRecord{ID: 7, TargetClients: 500}) // No debug stats
+
+	trend, err := NewRunTrend(runs, "segment-p95", 500, 3)
+	if err != nil {
+		t.Fatalf("NewRunTrend() = %v", err)
+	}
+	if len(trend.Runs) != 3 || trend.Runs[0].ID != 3 || trend.Runs[2].ID != 5 {
+		t.Errorf("selected runs %v, want #3-#5 (other client counts and unrecorded metrics skipped)", trend.Values)
+	}
+	if trend.SlopePct <= 0 {
+		t.Errorf("SlopePct = %.1f, want rising", trend.SlopePct)
+	}
+
+	if _, err := NewRunTrend(runs, "bogus", 500, 3); err == nil || !strings.Contains(err.Error(), "segment-p95") {
+		t.Errorf("unknown metric error = %v, want list of metrics", err)
+	}
+	if _, err := NewRunTrend(runs, "segment-p95", 42, 3); err == nil {
+		t.Error("no matching runs: want error")
+	}
+}
+
+func TestRunTrend_Format(t *testing.T) {
+	trend, err := NewRunTrend(trendRuns(500, 50, 52, 48, 51, 49, 90), "segment-p95", 500, 10)
+	if err != nil {
+		t.Fatalf("NewRunTrend() = %v", err)
+	}
+	got := trend.Format()
+	for _, want := range []string{"Segment P95 at 500 clients, last 6 run(s)", "Trend: +", "Latest #6: 90 ms vs 50 ms", "REGRESSION"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Format() missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestSlopePct(t *testing.T) {
+	// +10 per run on a mean of 110
+	if got := slopePct([]float64{100, 110, 120}); got < 9.08 || got > 9.10 {
+		t.Errorf("slopePct(100,110,120) = %.2f, want 9.09", got)
+	}
+	if got := slopePct([]float64{42}); got != 0 {
+		t.Errorf("slopePct(one run) = %.2f, want 0", got)
+	}
+}
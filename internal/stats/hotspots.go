@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+// HotSpotsKept is the length of the swarm-wide segment hot spot lists.
+const HotSpotsKept = 10
+
+// URLCount is a URL and how many times it was downloaded.
+type URLCount struct {
+	URL      string
+	Requests int64
+}
+
+// SlowSegment is one slow segment download and the client that made it.
+type SlowSegment struct {
+	URL      string
+	ClientID int
+	WallTime time.Duration
+}
+
+// TopURLCounts returns the n most downloaded URLs, most first (ties by URL).
+func TopURLCounts(counts map[string]int64, n int) []URLCount {
+	out := make([]URLCount, 0, len(counts))
+	for url, count := range counts {
+		out = append(out, URLCount{URL: url, Requests: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Requests != out[j].Requests {
+			return out[i].Requests > out[j].Requests
+		}
+		return out[i].URL < out[j].URL
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// TopSlowSegments sorts segs slowest first and truncates to n.
+func TopSlowSegments(segs []SlowSegment, n int) []SlowSegment {
+	sort.Slice(segs, func(i, j int) bool {
+		if segs[i].WallTime != segs[j].WallTime {
+			return segs[i].WallTime > segs[j].WallTime
+		}
+		return segs[i].ClientID < segs[j].ClientID
+	})
+	if len(segs) > n {
+		segs = segs[:n]
+	}
+	return segs
+}
@@ -0,0 +1,44 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTopURLCounts(t *testing.T) {
+	counts := map[string]int64{"a.ts": 3, "b.ts": 10, "c.ts": 3, "d.ts": 1}
+
+	got := TopURLCounts(counts, 3)
+	want := []URLCount{{"b.ts", 10}, {"a.ts", 3}, {"c.ts", 3}}
+	if len(got) != len(want) {
+		t.Fatalf("TopURLCounts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("TopURLCounts()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if got := TopURLCounts(nil, 3); len(got) != 0 {
+		t.Errorf("TopURLCounts(nil) = %v, want empty", got)
+	}
+}
+
+func TestTopSlowSegments(t *testing.T) {
+	segs := []SlowSegment{
+		{URL: "a.ts", ClientID: 2, WallTime: time.Second},
+		{URL: "b.ts", ClientID: 1, WallTime: 3 * time.Second},
+		{URL: "c.ts", ClientID: 1, WallTime: time.Second},
+		{URL: "d.ts", ClientID: 3, WallTime: 100 * time.Millisecond},
+	}
+
+	got := TopSlowSegments(segs, 3)
+	if len(got) != 3 {
+		t.Fatalf("TopSlowSegments() returned %d, want 3", len(got))
+	}
+	for i, url := range []string{"b.ts", "c.ts", "a.ts"} {
+		if got[i].URL != url {
+			t.Errorf("TopSlowSegments()[%d] = %s, want %s", i, got[i].URL, url)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OriginLogSummary is a tagged run's requests as the origin's access log
+// recorded them (-origin-log), set against what the clients measured.
+type OriginLogSummary struct {
+	Source    string // Log file or Loki URL
+	Tag       string // Run tag in the User-Agent
+	Requests  int64  // Tagged requests in the log
+	Segments  int64
+	Manifests int64
+	Untimed   int64    // Logged without a request time
+	Status    [6]int64 // By class: Status[2] is 2xx
+	Clients   int      // Clients the requests came from
+
+	// ClientRequests is what the clients sent: segments and playlist
+	// refreshes (0 = unknown, without -stats)
+	ClientRequests int64
+
+	// The origin's request times and the clients' wall times
+	OriginSegmentP50, OriginSegmentP95, OriginSegmentP99 time.Duration
+	ClientSegmentP50, ClientSegmentP95, ClientSegmentP99 time.Duration
+	OriginManifestP50, OriginManifestP99                 time.Duration
+	ClientManifestP50, ClientManifestP99                 time.Duration
+
+	Error string // Why the log couldn't be read ("" = it was)
+}
+
+// LatencyGap is one percentile of the client-vs-origin latency comparison.
+type LatencyGap struct {
+	Metric         string
+	Client, Origin time.Duration
+}
+
+// Gap returns the latency the network and any CDN added: the client's
+// wall time minus the origin's request time (0 if either is unknown).
+func (g LatencyGap) Gap() time.Duration {
+	if g.Client <= 0 || g.Origin <= 0 {
+		return 0
+	}
+	return g.Client - g.Origin
+}
+
+// Gaps returns the compared percentiles.
+func (s *OriginLogSummary) Gaps() []LatencyGap {
+	return []LatencyGap{
+		{"Segment P50", s.ClientSegmentP50, s.OriginSegmentP50},
+		{"Segment P95", s.ClientSegmentP95, s.OriginSegmentP95},
+		{"Segment P99", s.ClientSegmentP99, s.OriginSegmentP99},
+		{"Manifest P50", s.ClientManifestP50, s.OriginManifestP50},
+		{"Manifest P99", s.ClientManifestP99, s.OriginManifestP99},
+	}
+}
+
+// renderOriginLog renders the -origin-log join. Returns "" without
+// -origin-log.
+func renderOriginLog(s *OriginLogSummary) string {
+	if s == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                              Origin Access Log\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Run Tag:              %s\n", s.Tag)
+	fmt.Fprintf(&b, "  Source:               %s\n", s.Source)
+	if s.Error != "" {
+		fmt.Fprintf(&b, "  Not read:             %s\n\n", s.Error)
+		return b.String()
+	}
+	if s.Requests == 0 {
+		b.WriteString("  No tagged requests in the log (is it this origin's, and does it log the User-Agent?)\n\n")
+		return b.String()
+	}
+
+	matched := FormatNumber(s.Requests)
+	if s.ClientRequests > 0 {
+		matched += fmt.Sprintf(" of %s sent (%.1f%%)", FormatNumber(s.ClientRequests), float64(s.Requests)*100/float64(s.ClientRequests))
+	}
+	fmt.Fprintf(&b, "  Requests Logged:      %s from %d clients (%s segments, %s playlists)\n",
+		matched, s.Clients, FormatNumber(s.Segments), FormatNumber(s.Manifests))
+
+	var classes []string
+	for class := 1; class <= 5; class++ {
+		if s.Status[class] > 0 {
+			classes = append(classes, fmt.Sprintf("%dxx %s", class, FormatNumber(s.Status[class])))
+		}
+	}
+	fmt.Fprintf(&b, "  Origin Status:        %s\n", strings.Join(classes, ", "))
+	if s.Untimed > 0 {
+		fmt.Fprintf(&b, "  Untimed:              %s (no request time logged; add $request_time)\n", FormatNumber(s.Untimed))
+	}
+
+	fmt.Fprintf(&b, "\n  %-14s %12s %12s %12s\n", "", "Client", "Origin", "Gap")
+	for _, g := range s.Gaps() {
+		if g.Client <= 0 && g.Origin <= 0 {
+			continue
+		}
+		gap := "-"
+		if g.Client > 0 && g.Origin > 0 {
+			gap = FormatMs(g.Gap())
+		}
+		fmt.Fprintf(&b, "  %-14s %12s %12s %12s\n", g.Metric, FormatMs(g.Client), FormatMs(g.Origin), gap)
+	}
+	b.WriteString("\n  Gap: client wall time less the origin's request time, i.e. network and CDN.\n\n")
+
+	return b.String()
+}
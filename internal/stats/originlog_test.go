@@ -0,0 +1,73 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testOriginLog() *OriginLogSummary {
+	return &OriginLogSummary{
+		Source:    "/var/log/nginx/access.log",
+		Tag:       "run-1a2b3c4d",
+		Requests:  990,
+		Segments:  900,
+		Manifests: 90,
+		Untimed:   10,
+		Status:    [6]int64{2: 980, 5: 10},
+		Clients:   10,
+
+		ClientRequests: 1000,
+
+		OriginSegmentP50: 20 * time.Millisecond, OriginSegmentP95: 80 * time.Millisecond, OriginSegmentP99: 150 * time.Millisecond,
+		ClientSegmentP50: 120 * time.Millisecond, ClientSegmentP95: 300 * time.Millisecond, ClientSegmentP99: 500 * time.Millisecond,
+		OriginManifestP50: 2 * time.Millisecond, OriginManifestP99: 10 * time.Millisecond,
+		ClientManifestP50: 30 * time.Millisecond,
+	}
+}
+
+func TestLatencyGap(t *testing.T) {
+	if got := (LatencyGap{Client: 120 * time.Millisecond, Origin: 20 * time.Millisecond}).Gap(); got != 100*time.Millisecond {
+		t.Errorf("Gap() = %v, want 100ms", got)
+	}
+	if got := (LatencyGap{Client: 120 * time.Millisecond}).Gap(); got != 0 {
+		t.Errorf("Gap() without the origin's time = %v, want 0", got)
+	}
+}
+
+func TestFormatExitSummary_OriginLog(t *testing.T) {
+	result := FormatExitSummary(&AggregatedStats{}, SummaryConfig{OriginLog: testOriginLog()})
+	for _, want := range []string{
+		"Origin Access Log",
+		"Run Tag:              run-1a2b3c4d",
+		"Requests Logged:      990 of 1.0K sent (99.0%) from 10 clients (900 segments, 90 playlists)",
+		"Origin Status:        2xx 980, 5xx 10",
+		"Untimed:              10",
+		"  Segment P50          120 ms        20 ms       100 ms",
+		"  Manifest P50          30 ms         2 ms        28 ms",
+		"  Manifest P99           0 ms        10 ms            -",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Origin Access Log") {
+		t.Error("origin log shown without -origin-log")
+	}
+	if !strings.Contains(FormatExitSummary(nil, SummaryConfig{OriginLog: testOriginLog()}), "Origin Access Log") {
+		t.Error("origin log missing from the summary without stats")
+	}
+}
+
+func TestRenderOriginLog_NotRead(t *testing.T) {
+	got := renderOriginLog(&OriginLogSummary{Source: "http://loki:3100", Tag: "run-1", Error: "loki query: HTTP 400"})
+	if !strings.Contains(got, "Not read:             loki query: HTTP 400") || strings.Contains(got, "Gap") {
+		t.Errorf("renderOriginLog() =\n%s", got)
+	}
+
+	got = renderOriginLog(&OriginLogSummary{Source: "/var/log/nginx/access.log", Tag: "run-1"})
+	if !strings.Contains(got, "No tagged requests") {
+		t.Errorf("renderOriginLog() without requests =\n%s", got)
+	}
+}
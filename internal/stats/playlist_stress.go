@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PlaylistStressSummary is the result of --playlist-stress: how many
+// playlist requests per second the origin served, and how fast.
+type PlaylistStressSummary struct {
+	URL         string
+	Playlists   int // Media playlists the workers reloaded
+	Workers     int
+	TargetRate  float64 // Requests/sec asked for (0 = as fast as possible)
+	Elapsed     time.Duration
+	Interrupted bool // Stopped by Ctrl+C before -duration
+
+	Requests int64
+	Failures int64            // Transport errors and statuses other than 200 and 304
+	Failed   map[string]int64 // Failures by cause ("HTTP 503", "timeout", ...)
+	PeakRate float64          // Most requests served in one second
+
+	Latency *DurationHistory // Of the requests served
+}
+
+// Rate returns the requests served per second over the run.
+func (s *PlaylistStressSummary) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Requests-s.Failures) / s.Elapsed.Seconds()
+}
+
+// FormatPlaylistStress renders the --playlist-stress result.
+func FormatPlaylistStress(s *PlaylistStressSummary) string {
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                            Playlist Stress\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Target:               %s (%d media playlist(s))\n", s.URL, s.Playlists)
+	fmt.Fprintf(&b, "  Workers:              %d\n", s.Workers)
+	fmt.Fprintf(&b, "  Duration:             %s", FormatDuration(s.Elapsed))
+	if s.Interrupted {
+		b.WriteString(" (interrupted)")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  Requests:             %s (%s failed, %.2f%%)\n",
+		FormatNumber(s.Requests), FormatNumber(s.Failures), failureRatio(s.Failures, s.Requests)*100)
+
+	rate := FormatRate(s.Rate()) + " served"
+	if s.TargetRate > 0 {
+		rate += " of " + FormatRate(s.TargetRate) + " asked"
+	}
+	fmt.Fprintf(&b, "  Playlist Rate:        %s, peak %s\n", rate, FormatRate(s.PeakRate))
+	if s.Latency != nil && s.Latency.Count() > 0 {
+		p := s.Latency.Percentiles(0.5, 0.95, 0.99)
+		fmt.Fprintf(&b, "  Latency P50/P95/P99:  %s / %s / %s (max %s)\n",
+			FormatMs(p[0]), FormatMs(p[1]), FormatMs(p[2]), FormatMs(s.Latency.Max()))
+	}
+	if len(s.Failed) > 0 {
+		causes := slices.SortedFunc(maps.Keys(s.Failed), func(a, b string) int {
+			return cmp.Or(cmp.Compare(s.Failed[b], s.Failed[a]), strings.Compare(a, b))
+		})
+		parts := make([]string, len(causes))
+		for i, c := range causes {
+			parts[i] = fmt.Sprintf("%s %s", c, FormatNumber(s.Failed[c]))
+		}
+		fmt.Fprintf(&b, "  Failures:             %s\n", strings.Join(parts, ", "))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func failureRatio(failures, requests int64) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(failures) / float64(requests)
+}
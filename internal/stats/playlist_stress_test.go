@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatPlaylistStress(t *testing.T) {
+	s := &PlaylistStressSummary{
+		URL:        "http://origin/master.m3u8",
+		Playlists:  3,
+		Workers:    500,
+		TargetRate: 20000,
+		Elapsed:    10 * time.Second,
+		Requests:   150000,
+		Failures:   1500,
+		Failed:     map[string]int64{"HTTP 503": 1400, "timeout": 100},
+		PeakRate:   16000,
+		Latency:    NewDurationHistory(0),
+	}
+	for i := 1; i <= 100; i++ {
+		s.Latency.Add(time.Duration(i) * time.Millisecond)
+	}
+	if got := s.Rate(); got != 14850 {
+		t.Errorf("Rate() = %v, want 14850", got)
+	}
+
+	out := FormatPlaylistStress(s)
+	for _, want := range []string{
+		"Playlist Stress",
+		"http://origin/master.m3u8 (3 media playlist(s))",
+		"Workers:              500",
+		"150.0K (1.5K failed, 1.00%)",
+		"14.8K/s served of 20.0K/s asked, peak 16.0K/s",
+		"Latency P50/P95/P99:",
+		"Failures:             HTTP 503 1.4K, timeout 100",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("FormatPlaylistStress() missing %q:\n%s", want, out)
+		}
+	}
+
+	s.TargetRate, s.Interrupted = 0, true
+	out = FormatPlaylistStress(s)
+	if strings.Contains(out, "asked") || !strings.Contains(out, "(interrupted)") {
+		t.Errorf("FormatPlaylistStress() unlimited and interrupted:\n%s", out)
+	}
+}
@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reconnectSecondsOpen is how many recent seconds take late reconnects
+// (clients' log lines arrive slightly out of order) before the busiest
+// second is looked for among them.
+const reconnectSecondsOpen = 5
+
+// ReconnectCauseTracker counts the swarm's reconnects by cause (see
+// parser.ReconnectCauses) and keeps the busiest second's breakdown, so a
+// reconnect storm is attributed to the stage that failed, not just
+// counted.
+type ReconnectCauseTracker struct {
+	mu      sync.Mutex
+	totals  map[string]int64
+	seconds map[int64]map[string]int64 // Open seconds (Unix) -> reconnects by cause
+	latest  int64
+	peak    reconnectSecond
+}
+
+// reconnectSecond is one second's reconnects.
+type reconnectSecond struct {
+	at     int64
+	total  int64
+	causes map[string]int64
+}
+
+// NewReconnectCauseTracker returns an empty tracker.
+func NewReconnectCauseTracker() *ReconnectCauseTracker {
+	return &ReconnectCauseTracker{
+		totals:  make(map[string]int64),
+		seconds: make(map[int64]map[string]int64),
+	}
+}
+
+// Add counts one reconnect of cause at time at.
+func (t *ReconnectCauseTracker) Add(cause string, at time.Time) {
+	sec := at.Unix()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totals[cause]++
+	if sec <= t.latest-reconnectSecondsOpen {
+		return // Too late for its second's breakdown
+	}
+	counts := t.seconds[sec]
+	if counts == nil {
+		counts = make(map[string]int64)
+		t.seconds[sec] = counts
+	}
+	counts[cause]++
+	if sec > t.latest {
+		t.latest = sec
+		for s, c := range t.seconds {
+			if s <= t.latest-reconnectSecondsOpen {
+				t.closeSecond(s, c)
+			}
+		}
+	}
+}
+
+// closeSecond makes second s's counts the peak if it beats it.
+func (t *ReconnectCauseTracker) closeSecond(s int64, counts map[string]int64) {
+	delete(t.seconds, s)
+	if total := sumCounts(counts); total > t.peak.total {
+		t.peak = reconnectSecond{at: s, total: total, causes: counts}
+	}
+}
+
+// Summary returns the reconnects so far.
+func (t *ReconnectCauseTracker) Summary() ReconnectCauseSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	peak := t.peak
+	for s, counts := range t.seconds {
+		if total := sumCounts(counts); total > peak.total || (total == peak.total && s < peak.at) {
+			peak = reconnectSecond{at: s, total: total, causes: counts}
+		}
+	}
+	summary := ReconnectCauseSummary{
+		Total:  sumCounts(t.totals),
+		Causes: maps.Clone(t.totals),
+	}
+	if peak.total > 0 {
+		summary.PeakAt = time.Unix(peak.at, 0)
+		summary.PeakCount = peak.total
+		summary.PeakCauses = maps.Clone(peak.causes)
+	}
+	return summary
+}
+
+func sumCounts(counts map[string]int64) int64 {
+	var n int64
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
+
+// ReconnectCauseSummary is the run's reconnects by cause, and the busiest
+// second's.
+type ReconnectCauseSummary struct {
+	Total      int64
+	Causes     map[string]int64
+	PeakAt     time.Time // Start of the busiest second
+	PeakCount  int64
+	PeakCauses map[string]int64
+}
+
+// renderReconnectCauses renders the reconnect cause breakdown, most
+// frequent cause first. Returns "" without reconnects.
+func renderReconnectCauses(rc *ReconnectCauseSummary) string {
+	if rc == nil || rc.Total == 0 {
+		return ""
+	}
+	causes := slices.SortedFunc(maps.Keys(rc.Causes), func(a, b string) int {
+		return cmp.Or(cmp.Compare(rc.Causes[b], rc.Causes[a]), cmp.Compare(a, b))
+	})
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                             Reconnect Causes\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  %-8s %-10s %10s %8s %15s\n", "Stage", "Cause", "Reconnects", "Share", "Busiest Second")
+	for _, cause := range causes {
+		stage, detail, _ := strings.Cut(cause, " ")
+		n := rc.Causes[cause]
+		fmt.Fprintf(&b, "  %-8s %-10s %10d %7.1f%% %15d\n", stage, detail, n, float64(n)*100/float64(rc.Total), rc.PeakCauses[cause])
+	}
+	if rc.PeakCount > 0 {
+		top := slices.MaxFunc(slices.Collect(maps.Keys(rc.PeakCauses)), func(a, b string) int {
+			return cmp.Or(cmp.Compare(rc.PeakCauses[a], rc.PeakCauses[b]), cmp.Compare(b, a))
+		})
+		fmt.Fprintf(&b, "\n  Busiest second:       %d reconnects at %s (%.0f%% %s)\n",
+			rc.PeakCount, rc.PeakAt.Format("15:04:05"), float64(rc.PeakCauses[top])*100/float64(rc.PeakCount), top)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
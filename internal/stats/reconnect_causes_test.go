@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReconnectCauseTracker(t *testing.T) {
+	base := time.Date(2026, 1, 23, 8, 12, 0, 0, time.Local)
+	tr := NewReconnectCauseTracker()
+	tr.Add("http 5xx", base)
+	for i := 0; i < 3; i++ {
+		tr.Add("tcp refused", base.Add(10*time.Second))
+	}
+	tr.Add("http 5xx", base.Add(10*time.Second+500*time.Millisecond))
+	tr.Add("read timeout", base.Add(20*time.Second))
+	tr.Add("tcp refused", base.Add(2*time.Second)) // Too late for its second
+
+	s := tr.Summary()
+	if s.Total != 7 {
+		t.Errorf("Total = %d, want 7", s.Total)
+	}
+	if s.Causes["tcp refused"] != 4 || s.Causes["http 5xx"] != 2 || s.Causes["read timeout"] != 1 {
+		t.Errorf("Causes = %v", s.Causes)
+	}
+	if !s.PeakAt.Equal(base.Add(10*time.Second)) || s.PeakCount != 4 {
+		t.Errorf("peak = %d at %s, want 4 at %s", s.PeakCount, s.PeakAt, base.Add(10*time.Second))
+	}
+	if s.PeakCauses["tcp refused"] != 3 || s.PeakCauses["http 5xx"] != 1 {
+		t.Errorf("PeakCauses = %v", s.PeakCauses)
+	}
+}
+
+func TestRenderReconnectCauses(t *testing.T) {
+	if got := renderReconnectCauses(nil); got != "" {
+		t.Errorf("nil summary rendered %q", got)
+	}
+	out := renderReconnectCauses(&ReconnectCauseSummary{
+		Total:      10,
+		Causes:     map[string]int64{"tcp refused": 8, "http 5xx": 2},
+		PeakAt:     time.Date(2026, 1, 23, 8, 12, 10, 0, time.Local),
+		PeakCount:  5,
+		PeakCauses: map[string]int64{"tcp refused": 4, "http 5xx": 1},
+	})
+	for _, want := range []string{"Reconnect Causes", "tcp      refused", "80.0%", "Busiest second:       5 reconnects at 08:12:10 (80% tcp refused)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "refused") > strings.Index(out, "5xx") {
+		t.Errorf("causes not most frequent first:\n%s", out)
+	}
+}
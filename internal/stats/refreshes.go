@@ -0,0 +1,60 @@
+package stats
+
+import "time"
+
+// RefreshDistribution is the swarm's playlist refresh intervals against
+// the target duration, and its refresh storms (see parser.RefreshTracker).
+type RefreshDistribution struct {
+	Bounds   []float64 // Histogram upper bounds, multiples of the target duration
+	Counts   []int64   // Per bound (not cumulative), plus one above the last
+	RatioSum float64   // Sum of interval / target
+
+	Storms         int64     // 100ms windows with at least StormThreshold refreshes
+	StormThreshold int       // Refreshes in one window that make a storm
+	PeakClients    int       // Most refreshes in one 100ms window
+	PeakAt         time.Time // Start of that window
+}
+
+// Intervals returns the number of refresh intervals measured.
+func (d RefreshDistribution) Intervals() int64 {
+	var n int64
+	for _, c := range d.Counts {
+		n += c
+	}
+	return n
+}
+
+// MeanRatio returns the mean interval as a multiple of the target
+// duration (0 before any interval).
+func (d RefreshDistribution) MeanRatio() float64 {
+	n := d.Intervals()
+	if n == 0 {
+		return 0
+	}
+	return d.RatioSum / float64(n)
+}
+
+// Share returns the fraction of intervals with lo < interval/target <= hi.
+// Only whole buckets count, so lo and hi should be bounds (lo 0 for no
+// lower limit, hi 0 for no upper one).
+func (d RefreshDistribution) Share(lo, hi float64) float64 {
+	total := d.Intervals()
+	if total == 0 {
+		return 0
+	}
+	var n int64
+	for i, c := range d.Counts {
+		upper := 0.0 // +Inf bucket
+		if i < len(d.Bounds) {
+			upper = d.Bounds[i]
+		}
+		lower := 0.0
+		if i > 0 {
+			lower = d.Bounds[i-1]
+		}
+		if lower >= lo && (hi <= 0 || (upper > 0 && upper <= hi)) {
+			n += c
+		}
+	}
+	return float64(n) / float64(total)
+}
@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+// testRefreshes has 10 intervals: 1 early, 7 on time, 1 late, 1 very late.
+var testRefreshes = RefreshDistribution{
+	Bounds:         []float64{0.5, 0.75, 0.9, 1, 1.1, 1.25, 1.5, 2, 3},
+	Counts:         []int64{0, 0, 1, 4, 3, 1, 0, 0, 0, 1},
+	RatioSum:       11,
+	Storms:         2,
+	StormThreshold: 17,
+	PeakClients:    40,
+	PeakAt:         time.Date(2026, 1, 23, 8, 0, 4, 100_000_000, time.UTC),
+}
+
+func TestRefreshDistribution(t *testing.T) {
+	d := testRefreshes
+	if d.Intervals() != 10 {
+		t.Errorf("Intervals() = %d, want 10", d.Intervals())
+	}
+	if d.MeanRatio() != 1.1 {
+		t.Errorf("MeanRatio() = %v, want 1.1", d.MeanRatio())
+	}
+
+	tests := []struct {
+		lo, hi float64
+		want   float64
+	}{
+		{0, 0.9, 0.1},
+		{0.9, 1.1, 0.7},
+		{1.1, 1.5, 0.1},
+		{1.5, 0, 0.1},
+		{0, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := d.Share(tt.lo, tt.hi); got != tt.want {
+			t.Errorf("Share(%v, %v) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+
+	var empty RefreshDistribution
+	if empty.MeanRatio() != 0 || empty.Share(0, 0) != 0 {
+		t.Error("empty distribution should have no mean or shares")
+	}
+}
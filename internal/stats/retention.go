@@ -0,0 +1,320 @@
+package stats
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/tdigest"
+)
+
+// DefaultRetentionSamples caps a DurationHistory's in-memory samples
+// (80KB of durations, enough for stable P99s).
+const DefaultRetentionSamples = 10000
+
+// spillRecordSize is one spilled sample: int64 nanoseconds, little-endian.
+const spillRecordSize = 8
+
+// DurationHistory records durations for the final report in bounded memory.
+//
+// Count, min, max and mean are exact. For percentiles at most maxSamples
+// values are kept: when the buffer fills, every other sample is dropped and
+// from then on only every 2nd (then 4th, 8th, ...) new sample is stored, so
+// the retained set stays a uniform sample of the whole run however long it
+// lasts.
+//
+// With a spill file (SpillTo) every raw sample is also appended to disk, and
+// once the in-memory set has been downsampled, percentiles are computed by
+// streaming the file through a T-Digest instead.
+type DurationHistory struct {
+	mu         sync.Mutex
+	maxSamples int
+	samples    []time.Duration
+	stride     int64 // Store one sample in every stride
+	count      int64
+	sum        time.Duration
+	min        time.Duration
+	max        time.Duration
+
+	spill    *os.File
+	spillGz  *gzip.Writer // Between spillBuf and spill when compressing; nil otherwise
+	spillBuf *bufio.Writer
+	spillErr error // First write error; spilling stops after it
+}
+
+// NewDurationHistory creates a history keeping at most maxSamples in memory
+// (DefaultRetentionSamples if <= 0).
+func NewDurationHistory(maxSamples int) *DurationHistory {
+	if maxSamples <= 0 {
+		maxSamples = DefaultRetentionSamples
+	}
+	if maxSamples < 2 {
+		maxSamples = 2 // Halving needs something to keep
+	}
+	return &DurationHistory{
+		maxSamples: maxSamples,
+		samples:    make([]time.Duration, 0, min(maxSamples, 1024)),
+		stride:     1,
+	}
+}
+
+// SpillTo writes every subsequent sample to path (created or truncated),
+// gzip-compressed if path ends in CompressedSuffix.
+func (h *DurationHistory) SpillTo(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("create spill file: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.count > 0 {
+		// Percentiles from the file would miss what came before
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("spill must be enabled before the first sample")
+	}
+	h.spill = f
+	if strings.HasSuffix(path, CompressedSuffix) {
+		h.spillGz = gzip.NewWriter(f)
+		h.spillBuf = bufio.NewWriterSize(h.spillGz, 64*1024)
+	} else {
+		h.spillBuf = bufio.NewWriterSize(f, 64*1024)
+	}
+	return nil
+}
+
+// Add records one duration.
+func (h *DurationHistory) Add(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 || d < h.min {
+		h.min = d
+	}
+	if h.count == 0 || d > h.max {
+		h.max = d
+	}
+	h.count++
+	h.sum += d
+
+	if h.spillBuf != nil && h.spillErr == nil {
+		var rec [spillRecordSize]byte
+		binary.LittleEndian.PutUint64(rec[:], uint64(d))
+		if _, err := h.spillBuf.Write(rec[:]); err != nil {
+			h.spillErr = err
+		}
+	}
+
+	if (h.count-1)%h.stride != 0 {
+		return
+	}
+	h.samples = append(h.samples, d)
+	if len(h.samples) >= h.maxSamples {
+		h.halve()
+	}
+}
+
+// halve drops every other retained sample and doubles the stride. Must be
+// called with h.mu held.
+func (h *DurationHistory) halve() {
+	kept := h.samples[:0]
+	for i := 0; i < len(h.samples); i += 2 {
+		kept = append(kept, h.samples[i])
+	}
+	h.samples = kept
+	h.stride *= 2
+}
+
+// Shrink lowers the in-memory cap to maxSamples (if below the current one),
+// downsampling what is held now as a full buffer would be, and releases the
+// memory. The spill file, if any, is unaffected.
+func (h *DurationHistory) Shrink(maxSamples int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	maxSamples = max(maxSamples, 2)
+	if maxSamples >= h.maxSamples {
+		return
+	}
+	h.maxSamples = maxSamples
+	for len(h.samples) >= h.maxSamples {
+		h.halve()
+	}
+	h.samples = append(make([]time.Duration, 0, len(h.samples)), h.samples...)
+}
+
+// Count returns the number of durations recorded.
+func (h *DurationHistory) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Retained returns the number of samples held in memory.
+func (h *DurationHistory) Retained() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.samples)
+}
+
+// Downsampled reports whether samples have been discarded from memory.
+func (h *DurationHistory) Downsampled() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stride > 1
+}
+
+// Complete reports whether Percentiles reflects every recorded sample,
+// either because nothing was downsampled or because the spill file is intact.
+func (h *DurationHistory) Complete() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stride == 1 || (h.spill != nil && h.spillErr == nil)
+}
+
+// Min returns the smallest duration recorded (0 if none).
+func (h *DurationHistory) Min() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.min
+}
+
+// Max returns the largest duration recorded (0 if none).
+func (h *DurationHistory) Max() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.max
+}
+
+// Mean returns the average duration (0 if none).
+func (h *DurationHistory) Mean() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.count == 0 {
+		return 0
+	}
+	return h.sum / time.Duration(h.count)
+}
+
+// Percentiles returns the value at each quantile q (0.0-1.0).
+func (h *DurationHistory) Percentiles(qs ...float64) []time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]time.Duration, len(qs))
+	if h.count == 0 {
+		return out
+	}
+
+	if h.stride > 1 && h.spill != nil && h.spillErr == nil {
+		if digest, err := h.digestSpill(); err == nil {
+			for i, q := range qs {
+				out[i] = time.Duration(digest.Quantile(q))
+			}
+			return out
+		}
+	}
+
+	sorted := make([]time.Duration, len(h.samples))
+	copy(sorted, h.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for i, q := range qs {
+		idx := int(float64(len(sorted)-1) * q)
+		if idx >= len(sorted) {
+			idx = len(sorted) - 1
+		}
+		out[i] = sorted[idx]
+	}
+	return out
+}
+
+// digestSpill streams the spill file into a T-Digest. Caller holds h.mu.
+func (h *DurationHistory) digestSpill() (*tdigest.TDigest, error) {
+	if err := h.flushSpill(); err != nil {
+		h.spillErr = err
+		return nil, err
+	}
+	info, err := h.spill.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	digest := tdigest.NewWithCompression(100)
+	var r io.Reader = io.NewSectionReader(h.spill, 0, info.Size())
+	if h.spillGz != nil {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	r = bufio.NewReaderSize(r, 64*1024)
+	var rec [spillRecordSize]byte
+	for {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			// A compressed spill is flushed but not closed: it ends
+			// unexpectedly, after the last whole record
+			if err == io.EOF || (h.spillGz != nil && err == io.ErrUnexpectedEOF) {
+				return digest, nil
+			}
+			return nil, err
+		}
+		digest.Add(float64(int64(binary.LittleEndian.Uint64(rec[:]))), 1)
+	}
+}
+
+// SpillPath returns the spill file path ("" if not spilling).
+func (h *DurationHistory) SpillPath() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spill == nil {
+		return ""
+	}
+	return h.spill.Name()
+}
+
+// Close flushes and closes the spill file. The file is kept for offline
+// analysis. Returns the first write error, if any.
+func (h *DurationHistory) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.spill == nil {
+		return nil
+	}
+
+	err := h.spillErr
+	if ferr := h.spillBuf.Flush(); err == nil {
+		err = ferr
+	}
+	if h.spillGz != nil {
+		if zerr := h.spillGz.Close(); err == nil {
+			err = zerr
+		}
+	}
+	if cerr := h.spill.Close(); err == nil {
+		err = cerr
+	}
+	h.spill, h.spillGz, h.spillBuf = nil, nil, nil
+	return err
+}
+
+// flushSpill writes out the buffered samples, through the compressor if
+// any, so the spill file can be read. Caller holds h.mu.
+func (h *DurationHistory) flushSpill() error {
+	if err := h.spillBuf.Flush(); err != nil {
+		return err
+	}
+	if h.spillGz != nil {
+		return h.spillGz.Flush()
+	}
+	return nil
+}
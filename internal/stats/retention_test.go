@@ -0,0 +1,200 @@
+package stats
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDurationHistory_Empty(t *testing.T) {
+	h := NewDurationHistory(100)
+	if h.Count() != 0 || h.Mean() != 0 || h.Min() != 0 || h.Max() != 0 {
+		t.Error("empty history should report zeros")
+	}
+	if got := h.Percentiles(0.5); got[0] != 0 {
+		t.Errorf("P50 = %v, want 0", got[0])
+	}
+	if !h.Complete() {
+		t.Error("empty history should be complete")
+	}
+}
+
+func TestDurationHistory_ExactBelowCap(t *testing.T) {
+	h := NewDurationHistory(1000)
+	for i := 100; i >= 1; i-- {
+		h.Add(time.Duration(i) * time.Second)
+	}
+
+	if h.Count() != 100 || h.Retained() != 100 || h.Downsampled() {
+		t.Fatalf("Count=%d Retained=%d Downsampled=%v", h.Count(), h.Retained(), h.Downsampled())
+	}
+	p := h.Percentiles(0.50, 0.99)
+	if p[0] != 50*time.Second || p[1] != 99*time.Second {
+		t.Errorf("P50=%v P99=%v, want 50s 99s", p[0], p[1])
+	}
+	if h.Min() != time.Second || h.Max() != 100*time.Second {
+		t.Errorf("Min=%v Max=%v", h.Min(), h.Max())
+	}
+	if h.Mean() != 50500*time.Millisecond {
+		t.Errorf("Mean = %v, want 50.5s", h.Mean())
+	}
+}
+
+func TestDurationHistory_Downsamples(t *testing.T) {
+	const max = 100
+	h := NewDurationHistory(max)
+	const n = 100000
+	for i := 1; i <= n; i++ {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+
+	if h.Count() != n {
+		t.Errorf("Count = %d, want %d", h.Count(), n)
+	}
+	if r := h.Retained(); r >= max || r < max/4 {
+		t.Errorf("Retained = %d, want within [%d, %d)", r, max/4, max)
+	}
+	if !h.Downsampled() || h.Complete() {
+		t.Error("history should be downsampled and incomplete")
+	}
+
+	// Exact aggregates survive downsampling
+	if h.Min() != time.Millisecond || h.Max() != n*time.Millisecond {
+		t.Errorf("Min=%v Max=%v", h.Min(), h.Max())
+	}
+
+	// Retained set is uniform across the run: P50 near the middle
+	p50 := h.Percentiles(0.5)[0]
+	if want := n / 2 * time.Millisecond; p50 < want*9/10 || p50 > want*11/10 {
+		t.Errorf("P50 = %v, want ~%v", p50, want)
+	}
+}
+
+func TestDurationHistory_Shrink(t *testing.T) {
+	h := NewDurationHistory(10000)
+	const n = 5000
+	for i := 1; i <= n; i++ {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+	if h.Downsampled() {
+		t.Fatal("downsampled below the cap")
+	}
+
+	h.Shrink(100)
+	if r := h.Retained(); r >= 100 || r < 25 {
+		t.Errorf("Retained = %d after Shrink(100), want within [25, 100)", r)
+	}
+	if !h.Downsampled() {
+		t.Error("Shrink should downsample")
+	}
+	p50 := h.Percentiles(0.5)[0]
+	if want := n / 2 * time.Millisecond; p50 < want*9/10 || p50 > want*11/10 {
+		t.Errorf("P50 = %v, want ~%v", p50, want)
+	}
+
+	// New samples keep to the lower cap; a higher one is ignored
+	h.Shrink(1000)
+	for i := 1; i <= n; i++ {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+	if r := h.Retained(); r >= 100 {
+		t.Errorf("Retained = %d, want < 100", r)
+	}
+	if h.Count() != 2*n {
+		t.Errorf("Count = %d, want %d", h.Count(), 2*n)
+	}
+}
+
+func TestDurationHistory_Spill(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uptimes.bin")
+	h := NewDurationHistory(100)
+	if err := h.SpillTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 10000
+	for i := 1; i <= n; i++ {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+	if !h.Downsampled() || !h.Complete() {
+		t.Fatalf("Downsampled=%v Complete=%v, want true true", h.Downsampled(), h.Complete())
+	}
+
+	// Percentiles come from the full file (T-Digest is accurate at the tails)
+	p99 := h.Percentiles(0.99)[0]
+	if want := 9900 * time.Millisecond; p99 < want-50*time.Millisecond || p99 > want+50*time.Millisecond {
+		t.Errorf("P99 = %v, want ~%v", p99, want)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != n*spillRecordSize {
+		t.Errorf("spill size = %d, want %d", info.Size(), n*spillRecordSize)
+	}
+}
+
+func TestDurationHistory_SpillCompressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "uptimes.bin.gz")
+	h := NewDurationHistory(100)
+	if err := h.SpillTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 10000
+	for i := 1; i <= n; i++ {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+	// Read back mid-run, while the compressed stream is still open
+	for range 2 {
+		p99 := h.Percentiles(0.99)[0]
+		if want := 9900 * time.Millisecond; p99 < want-50*time.Millisecond || p99 > want+50*time.Millisecond {
+			t.Errorf("P99 = %v, want ~%v", p99, want)
+		}
+		h.Add(time.Millisecond)
+	}
+
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, _ := f.Stat()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("closed spill isn't a whole gzip stream: %v", err)
+	}
+	if len(data) != (n+2)*spillRecordSize {
+		t.Errorf("decompressed spill = %d bytes, want %d", len(data), (n+2)*spillRecordSize)
+	}
+	if info.Size() >= int64(len(data)) {
+		t.Errorf("compressed spill = %d bytes, not smaller than %d", info.Size(), len(data))
+	}
+}
+
+func TestDurationHistory_SpillAfterSamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "late.bin")
+	h := NewDurationHistory(100)
+	h.Add(time.Second)
+
+	if err := h.SpillTo(path); err == nil {
+		t.Error("SpillTo after first sample should fail")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("rejected spill file should be removed")
+	}
+}
@@ -0,0 +1,122 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Serving classes of a response (as parser.ServedEdgeHit etc.).
+const (
+	ServedEdgeHit  = "edge-hit"
+	ServedEdgeMiss = "edge-miss"
+	ServedEdge     = "edge"
+	ServedOrigin   = "origin"
+)
+
+// ServingClasses lists the serving classes in report order.
+var ServingClasses = []string{ServedEdgeHit, ServedEdgeMiss, ServedEdge, ServedOrigin}
+
+// ServingTotals are the responses of one serving class (as
+// parser.ServingStats).
+type ServingTotals struct {
+	Responses int64
+	Segments  int64
+	Bytes     int64
+	TTFBSum   time.Duration
+	TTFBCount int64
+}
+
+// TTFB returns the mean time to the first response header (0 if none).
+func (t ServingTotals) TTFB() time.Duration {
+	if t.TTFBCount == 0 {
+		return 0
+	}
+	return t.TTFBSum / time.Duration(t.TTFBCount)
+}
+
+// ServingClass is one class of a ServingBreakdown.
+type ServingClass struct {
+	Class string
+	ServingTotals
+	Share float64 // Of all classified responses, 0..1
+}
+
+// ServingBreakdown is where a -cdn-detect run's responses came from.
+type ServingBreakdown struct {
+	Classes   []ServingClass // In ServingClasses order, empty ones left out
+	Responses int64
+	CDN       bool // Some responses came through a CDN
+
+	// OriginHits are the responses the origin served, through the CDN
+	// (edge-miss) or around it; OriginHitRatio is their share of the
+	// responses whose source is known (edge without a cache status isn't)
+	OriginHits     int64
+	OriginHitRatio float64
+
+	Alert   float64 // -origin-hit-alert, percent (0 = off)
+	Flagged bool    // Behind a CDN, and OriginHitRatio above Alert
+}
+
+// BreakdownServing sums up responses by serving class (see
+// parser.DebugStats.Serving), flagging an origin hit ratio above alert
+// percent when a CDN is in the path. Returns nil without responses.
+func BreakdownServing(serving map[string]ServingTotals, alert float64) *ServingBreakdown {
+	b := &ServingBreakdown{Alert: alert}
+	for _, class := range ServingClasses {
+		t := serving[class]
+		if t.Responses == 0 {
+			continue
+		}
+		b.Classes = append(b.Classes, ServingClass{Class: class, ServingTotals: t})
+		b.Responses += t.Responses
+		if class != ServedOrigin {
+			b.CDN = true
+		}
+	}
+	if b.Responses == 0 {
+		return nil
+	}
+	for i := range b.Classes {
+		b.Classes[i].Share = float64(b.Classes[i].Responses) / float64(b.Responses)
+	}
+
+	b.OriginHits = serving[ServedEdgeMiss].Responses + serving[ServedOrigin].Responses
+	if known := b.OriginHits + serving[ServedEdgeHit].Responses; known > 0 {
+		b.OriginHitRatio = float64(b.OriginHits) / float64(known)
+	}
+	b.Flagged = b.CDN && alert > 0 && b.OriginHitRatio*100 > alert
+	return b
+}
+
+// renderServing shows how responses split between CDN edges and the
+// origin. Returns "" without a breakdown.
+func renderServing(s *ServingBreakdown) string {
+	if s == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                               CDN vs Origin\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  %-12s %10s %7s %10s %10s %10s\n", "Served by", "Responses", "Share", "Segments", "Bytes", "Avg TTFB")
+	for _, c := range s.Classes {
+		fmt.Fprintf(&b, "  %-12s %10s %6.1f%% %10s %10s %10s\n",
+			c.Class, FormatNumber(c.Responses), c.Share*100, FormatNumber(c.Segments), FormatBytes(c.Bytes), FormatMs(c.TTFB()))
+	}
+	b.WriteString("\n")
+
+	if !s.CDN {
+		b.WriteString("  No CDN detected: every response came from the origin\n\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  Origin hit ratio:     %.1f%% (%s responses)\n", s.OriginHitRatio*100, FormatNumber(s.OriginHits))
+	if s.Flagged {
+		fmt.Fprintf(&b, "  ⚠️  Origin hit ratio above %.0f%%: the CDN is passing load through to the origin\n", s.Alert)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreakdownServing(t *testing.T) {
+	if got := BreakdownServing(nil, 20); got != nil {
+		t.Errorf("BreakdownServing(nil) = %+v, want nil", got)
+	}
+
+	serving := map[string]ServingTotals{
+		ServedEdgeHit:  {Responses: 60, Segments: 50, Bytes: 6000, TTFBSum: 600 * time.Millisecond, TTFBCount: 60},
+		ServedEdgeMiss: {Responses: 30, Segments: 30, Bytes: 3000, TTFBSum: 3 * time.Second, TTFBCount: 30},
+		ServedEdge:     {Responses: 5},
+		ServedOrigin:   {Responses: 5},
+	}
+	s := BreakdownServing(serving, 20)
+	if s.Responses != 100 || !s.CDN || len(s.Classes) != 4 {
+		t.Fatalf("BreakdownServing() = %+v", s)
+	}
+	if s.Classes[0].Class != ServedEdgeHit || s.Classes[0].Share != 0.6 || s.Classes[0].TTFB() != 10*time.Millisecond {
+		t.Errorf("Classes[0] = %+v, want edge-hit, 60%%, 10ms", s.Classes[0])
+	}
+	// (30 misses + 5 around the CDN) of 95 with a known source
+	if s.OriginHits != 35 || s.OriginHitRatio < 0.368 || s.OriginHitRatio > 0.369 || !s.Flagged {
+		t.Errorf("origin hits = %d, ratio %v, flagged %v; want 35, 0.368, true", s.OriginHits, s.OriginHitRatio, s.Flagged)
+	}
+
+	if s := BreakdownServing(serving, 50); s.Flagged {
+		t.Error("Flagged under the alert")
+	}
+	if s := BreakdownServing(serving, 0); s.Flagged {
+		t.Error("Flagged with the alert off")
+	}
+
+	// No CDN: all from the origin, as expected
+	s = BreakdownServing(map[string]ServingTotals{ServedOrigin: {Responses: 10}}, 20)
+	if s.CDN || s.OriginHitRatio != 1 || s.Flagged {
+		t.Errorf("origin only = %+v, want no CDN, ratio 1, not flagged", s)
+	}
+}
+
+func TestRenderServing(t *testing.T) {
+	if got := renderServing(nil); got != "" {
+		t.Errorf("renderServing(nil) = %q, want empty", got)
+	}
+
+	out := renderServing(BreakdownServing(map[string]ServingTotals{
+		ServedEdgeHit:  {Responses: 70, TTFBSum: 700 * time.Millisecond, TTFBCount: 70},
+		ServedEdgeMiss: {Responses: 30},
+	}, 20))
+	for _, want := range []string{"CDN vs Origin", "edge-hit", "edge-miss", "70.0%", "Origin hit ratio:     30.0% (30 responses)", "above 20%"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	out = renderServing(BreakdownServing(map[string]ServingTotals{ServedOrigin: {Responses: 10}}, 20))
+	if !strings.Contains(out, "No CDN detected") || strings.Contains(out, "Origin hit ratio") {
+		t.Errorf("origin only:\n%s", out)
+	}
+}
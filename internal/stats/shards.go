@@ -0,0 +1,119 @@
+package stats
+
+import (
+	"regexp"
+	"sort"
+)
+
+// HotShardRatio is how far above its group's mean a shard's requests must
+// be for it to count as hot.
+const HotShardRatio = 1.5
+
+// ShardGroupsKept bounds the shard groups reported.
+const ShardGroupsKept = 5
+
+// ShardLoad is one shard of a ShardGroup and its share of the requests.
+type ShardLoad struct {
+	Shard    string // Host and directory, e.g. "edge3.example.com/live"
+	Requests int64
+	Share    float64 // Of the group's requests, 0..1
+	Hot      bool    // At least HotShardRatio times the group mean
+}
+
+// ShardGroup is a sharded origin as seen by the swarm: segment shards
+// (host and directory) that share a URL template, i.e. differ only in
+// their numbers, like edge1.example.com/live and edge2.example.com/live.
+type ShardGroup struct {
+	Template string      // Shard with each number as {n}: "edge{n}.example.com/live"
+	Shards   []ShardLoad // Busiest first
+	Requests int64
+
+	// Skew is the busiest shard's requests over the group mean: 1 is an
+	// even spread, the shard count means one shard takes everything
+	Skew float64
+}
+
+// HotShards returns the group's hot shards, busiest first.
+func (g ShardGroup) HotShards() []ShardLoad {
+	var hot []ShardLoad
+	for _, s := range g.Shards {
+		if s.Hot {
+			hot = append(hot, s)
+		}
+	}
+	return hot
+}
+
+// otherShards is the overflow bucket of the shard counts (as
+// parser.OtherShards).
+const otherShards = "(other)"
+
+// shardNumbers matches the numbers a shard template abstracts.
+var shardNumbers = regexp.MustCompile(`[0-9]+`)
+
+// ShardTemplate returns the URL template of a shard: the shard with every
+// run of digits replaced by {n}.
+func ShardTemplate(shard string) string {
+	return shardNumbers.ReplaceAllLiteralString(shard, "{n}")
+}
+
+// GroupShards groups segment downloads by shard (see
+// parser.DebugStats.ShardSegments) into the URL templates they share, and
+// measures how evenly each template's shards are loaded. Templates with a
+// single shard aren't sharded and are left out, as is the overflow bucket
+// (other). Groups are returned most skewed first (ties by requests), at
+// most ShardGroupsKept.
+//
+// Variant directories that differ in a number (720p, 1080p) group too;
+// their skew reflects the clients' variant choice rather than the origin.
+func GroupShards(requests map[string]int64) []ShardGroup {
+	byTemplate := make(map[string]*ShardGroup)
+	for shard, n := range requests {
+		if shard == otherShards || n <= 0 {
+			continue
+		}
+		tmpl := ShardTemplate(shard)
+		g := byTemplate[tmpl]
+		if g == nil {
+			g = &ShardGroup{Template: tmpl}
+			byTemplate[tmpl] = g
+		}
+		g.Shards = append(g.Shards, ShardLoad{Shard: shard, Requests: n})
+		g.Requests += n
+	}
+
+	var groups []ShardGroup
+	for _, g := range byTemplate {
+		if len(g.Shards) < 2 {
+			continue
+		}
+		sort.Slice(g.Shards, func(i, j int) bool {
+			if g.Shards[i].Requests != g.Shards[j].Requests {
+				return g.Shards[i].Requests > g.Shards[j].Requests
+			}
+			return g.Shards[i].Shard < g.Shards[j].Shard
+		})
+		mean := float64(g.Requests) / float64(len(g.Shards))
+		for i := range g.Shards {
+			s := &g.Shards[i]
+			s.Share = float64(s.Requests) / float64(g.Requests)
+			s.Hot = float64(s.Requests) >= HotShardRatio*mean
+		}
+		g.Skew = float64(g.Shards[0].Requests) / mean
+		groups = append(groups, *g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Skew != groups[j].Skew {
+			return groups[i].Skew > groups[j].Skew
+		}
+		if groups[i].Requests != groups[j].Requests {
+			return groups[i].Requests > groups[j].Requests
+		}
+		return groups[i].Template < groups[j].Template
+	})
+	if len(groups) > ShardGroupsKept {
+		groups = groups[:ShardGroupsKept]
+	}
+	return groups
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestShardTemplate(t *testing.T) {
+	tests := []struct {
+		shard, want string
+	}{
+		{"edge12.example.com/live", "edge{n}.example.com/live"},
+		{"cdn.example.com/shard-3/v2", "cdn.example.com/shard-{n}/v{n}"},
+		{"cdn.example.com/live", "cdn.example.com/live"},
+	}
+	for _, tt := range tests {
+		if got := ShardTemplate(tt.shard); got != tt.want {
+			t.Errorf("ShardTemplate(%q) = %q, want %q", tt.shard, got, tt.want)
+		}
+	}
+}
+
+func TestGroupShards(t *testing.T) {
+	groups := GroupShards(map[string]int64{
+		// Even: skew 1
+		"cdn.example.com/shard-1/live": 100,
+		"cdn.example.com/shard-2/live": 100,
+		// Skewed: edge1 takes 60 of 100 over 4 shards
+		"edge1.example.com/live": 60,
+		"edge2.example.com/live": 20,
+		"edge3.example.com/live": 10,
+		"edge4.example.com/live": 10,
+		// Not sharded, and the overflow bucket
+		"origin.example.com/vod": 500,
+		"(other)":                50,
+	})
+
+	if len(groups) != 2 {
+		t.Fatalf("GroupShards() = %+v, want 2 groups", groups)
+	}
+	edge := groups[0]
+	if edge.Template != "edge{n}.example.com/live" || edge.Requests != 100 || len(edge.Shards) != 4 {
+		t.Fatalf("most skewed group = %+v, want the 4 edges", edge)
+	}
+	if math.Abs(edge.Skew-2.4) > 1e-9 {
+		t.Errorf("Skew = %v, want 2.4 (60 over a mean of 25)", edge.Skew)
+	}
+	hot := edge.HotShards()
+	if len(hot) != 1 || hot[0].Shard != "edge1.example.com/live" || hot[0].Share != 0.6 {
+		t.Errorf("HotShards() = %+v, want edge1 with 60%%", hot)
+	}
+	if edge.Shards[len(edge.Shards)-1].Shard != "edge4.example.com/live" {
+		t.Errorf("Shards = %+v, want busiest first, ties by name", edge.Shards)
+	}
+
+	even := groups[1]
+	if even.Template != "cdn.example.com/shard-{n}/live" || even.Skew != 1 || len(even.HotShards()) != 0 {
+		t.Errorf("even group = %+v, want skew 1 and no hot shards", even)
+	}
+
+	if got := GroupShards(nil); len(got) != 0 {
+		t.Errorf("GroupShards(nil) = %+v, want none", got)
+	}
+}
+
+func TestGroupShards_Kept(t *testing.T) {
+	requests := make(map[string]int64)
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		requests[name+"1.example.com"] = 10
+		requests[name+"2.example.com"] = 10
+	}
+	if got := GroupShards(requests); len(got) != ShardGroupsKept {
+		t.Errorf("GroupShards() returned %d groups, want %d", len(got), ShardGroupsKept)
+	}
+}
+
+func TestRenderShardBalance(t *testing.T) {
+	if got := renderShardBalance(&DebugStatsAggregate{}); got != "" {
+		t.Errorf("renderShardBalance() without groups = %q, want empty", got)
+	}
+
+	ds := &DebugStatsAggregate{ShardGroups: GroupShards(map[string]int64{
+		"edge1.example.com/live": 75,
+		"edge2.example.com/live": 25,
+	})}
+	out := renderShardBalance(ds)
+	for _, want := range []string{"Shard Balance", "edge{n}.example.com/live", "2 shards", "skew 1.50x", "(75.0%)  HOT"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderShardBalance() missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "HOT") != 1 {
+		t.Errorf("want one hot shard:\n%s", out)
+	}
+}
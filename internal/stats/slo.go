@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+)
+
+// SLOStatus is where a -slo segment latency objective stands: compliance
+// over the run so far, and how fast the error budget is burning lately.
+type SLOStatus struct {
+	Target    float64       // Fraction of segments that must beat Threshold (0.99)
+	Threshold time.Duration // Segment wall time a segment must stay under
+	Segments  int64         // Completed segments over the run
+	Misses    int64         // Of which at or over Threshold
+
+	// BurnRate is the miss ratio over the last Window divided by the error
+	// budget (1 - Target): at 1 the budget lasts exactly, above 1 the SLO
+	// is missed if it keeps up. 0 while the window saw no segments.
+	BurnRate float64
+	Window   time.Duration
+}
+
+// Enabled reports whether a -slo was set.
+func (s SLOStatus) Enabled() bool {
+	return s.Threshold > 0
+}
+
+// Attainment returns the fraction of segments under Threshold (1 before
+// any completed).
+func (s SLOStatus) Attainment() float64 {
+	if s.Segments == 0 {
+		return 1
+	}
+	return 1 - float64(s.Misses)/float64(s.Segments)
+}
+
+// Met reports whether the attainment reaches the target.
+func (s SLOStatus) Met() bool {
+	return s.Attainment() >= s.Target
+}
+
+// BudgetRemaining returns the fraction of the error budget left: 1 with
+// no misses, 0 when spent, negative once overspent.
+func (s SLOStatus) BudgetRemaining() float64 {
+	return 1 - BurnRate(s.Misses, s.Segments, s.Target)
+}
+
+// BurnRate returns the miss ratio of misses out of segments over the error
+// budget of target (0 without segments).
+func BurnRate(misses, segments int64, target float64) float64 {
+	if segments <= 0 || target >= 1 {
+		return 0
+	}
+	return float64(misses) / float64(segments) / (1 - target)
+}
+
+// String renders the status for the dashboard and logs:
+// "99.42% under 1s (target 99%), burn 0.6x over 5m, 42% budget left".
+func (s SLOStatus) String() string {
+	if s.Segments == 0 {
+		return fmt.Sprintf("no segments yet (target %s under %s)", formatTarget(s.Target), s.Threshold)
+	}
+	return fmt.Sprintf("%.2f%% under %s (target %s), burn %.1fx over %s, %.0f%% budget left",
+		s.Attainment()*100, s.Threshold, formatTarget(s.Target),
+		s.BurnRate, s.Window.Round(time.Second), max(s.BudgetRemaining(), 0)*100)
+}
+
+// SLORecord is the -slo result saved with a run.
+type SLORecord struct {
+	Target      float64 `json:"target"` // Fraction, 0.99
+	ThresholdMs float64 `json:"threshold_ms"`
+	Segments    int64   `json:"segments"`
+	Misses      int64   `json:"misses"`
+	Attainment  float64 `json:"attainment"` // Fraction of segments under the threshold
+	Met         bool    `json:"met"`
+}
+
+// Record returns the status as saved with a run.
+func (s SLOStatus) Record() *SLORecord {
+	return &SLORecord{
+		Target:      s.Target,
+		ThresholdMs: durationMs(s.Threshold),
+		Segments:    s.Segments,
+		Misses:      s.Misses,
+		Attainment:  s.Attainment(),
+		Met:         s.Met(),
+	}
+}
+
+// SLOSnapshot is the live -slo standing in a -stats-stdout snapshot.
+type SLOSnapshot struct {
+	Attainment      float64 `json:"attainment"`
+	BurnRate        float64 `json:"burn_rate"`
+	WindowSeconds   float64 `json:"burn_window_s"`
+	BudgetRemaining float64 `json:"budget_remaining"`
+}
+
+// Snapshot returns the status for a -stats-stdout snapshot.
+func (s SLOStatus) Snapshot() *SLOSnapshot {
+	return &SLOSnapshot{
+		Attainment:      s.Attainment(),
+		BurnRate:        s.BurnRate,
+		WindowSeconds:   s.Window.Seconds(),
+		BudgetRemaining: s.BudgetRemaining(),
+	}
+}
+
+// formatTarget renders a target fraction as a percentage: 0.999 -> "99.9%".
+func formatTarget(target float64) string {
+	return fmt.Sprintf("%.6g%%", target*100)
+}
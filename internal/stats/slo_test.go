@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSLOStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		segments       int64
+		misses         int64
+		wantAttainment float64
+		wantMet        bool
+		wantBudget     float64
+	}{
+		{"no segments", 0, 0, 1, true, 1},
+		{"no misses", 1000, 0, 1, true, 1},
+		{"half the budget", 1000, 5, 0.995, true, 0.5},
+		{"budget spent exactly", 1000, 10, 0.99, true, 0},
+		{"overspent", 1000, 30, 0.97, false, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := SLOStatus{Target: 0.99, Threshold: time.Second, Segments: tt.segments, Misses: tt.misses}
+			if got := s.Attainment(); math.Abs(got-tt.wantAttainment) > 1e-9 {
+				t.Errorf("Attainment() = %v, want %v", got, tt.wantAttainment)
+			}
+			if got := s.Met(); got != tt.wantMet {
+				t.Errorf("Met() = %v, want %v", got, tt.wantMet)
+			}
+			if got := s.BudgetRemaining(); math.Abs(got-tt.wantBudget) > 1e-9 {
+				t.Errorf("BudgetRemaining() = %v, want %v", got, tt.wantBudget)
+			}
+		})
+	}
+
+	if (SLOStatus{}).Enabled() {
+		t.Error("zero SLOStatus is Enabled")
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	for _, tt := range []struct {
+		misses, segments int64
+		target           float64
+		want             float64
+	}{
+		{0, 0, 0.99, 0},
+		{1, 100, 0.99, 1},
+		{3, 100, 0.99, 3},
+		{1, 1000, 0.999, 1},
+		{5, 100, 1, 0},
+	} {
+		if got := BurnRate(tt.misses, tt.segments, tt.target); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("BurnRate(%d, %d, %v) = %v, want %v", tt.misses, tt.segments, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestSLOStatus_String(t *testing.T) {
+	s := SLOStatus{Target: 0.999, Threshold: 500 * time.Millisecond}
+	if got, want := s.String(), "no segments yet (target 99.9% under 500ms)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	s = SLOStatus{Target: 0.99, Threshold: time.Second, Segments: 1000, Misses: 20, BurnRate: 3.25, Window: 5 * time.Minute}
+	if got, want := s.String(), "98.00% under 1s (target 99%), burn 3.2x over 5m0s, 0% budget left"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestSLOStatus_RecordSnapshot(t *testing.T) {
+	s := SLOStatus{Target: 0.99, Threshold: 1500 * time.Millisecond, Segments: 1000, Misses: 5, BurnRate: 2, Window: time.Minute}
+
+	rec := s.Record()
+	if rec.Target != 0.99 || rec.ThresholdMs != 1500 || rec.Segments != 1000 || rec.Misses != 5 ||
+		math.Abs(rec.Attainment-0.995) > 1e-9 || !rec.Met {
+		t.Errorf("Record() = %+v", rec)
+	}
+
+	snap := s.Snapshot()
+	if math.Abs(snap.Attainment-0.995) > 1e-9 || snap.BurnRate != 2 || snap.WindowSeconds != 60 ||
+		math.Abs(snap.BudgetRemaining-0.5) > 1e-9 {
+		t.Errorf("Snapshot() = %+v", snap)
+	}
+}
@@ -0,0 +1,180 @@
+package stats
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Snapshot is the machine-readable form of an aggregate sample, written one
+// JSON object per line by -stats-stdout ndjson.
+//
+// Field names are a stable interface for wrapper scripts: add fields, don't
+// rename them. Rates are per second and latencies are milliseconds.
+type Snapshot struct {
+	Timestamp      time.Time `json:"ts"`
+	ElapsedSeconds float64   `json:"elapsed_s"`
+	Final          bool      `json:"final,omitempty"` // Last snapshot, written at shutdown
+
+	// Clients
+	TargetClients  int `json:"target_clients"`
+	ActiveClients  int `json:"active_clients"`
+	StalledClients int `json:"stalled_clients"`
+
+	// Cumulative totals
+	ManifestRequests int64 `json:"manifest_requests"`
+	SegmentRequests  int64 `json:"segment_requests"`
+	Bytes            int64 `json:"bytes"`
+
+	// Instantaneous rates (since the previous snapshot)
+	ManifestRate  float64 `json:"manifest_rate"`
+	SegmentRate   float64 `json:"segment_rate"`
+	ThroughputBps float64 `json:"throughput_bytes_per_sec"`
+
+	// Errors
+	HTTPErrors    map[int]int64 `json:"http_errors,omitempty"` // Status code -> count
+	Timeouts      int64         `json:"timeouts"`
+	Reconnections int64         `json:"reconnections"`
+	ErrorRate     float64       `json:"error_rate"` // errors / total requests
+
+	// Playback health
+	AverageSpeed         float64 `json:"average_speed"`
+	ClientsBelowRealtime int     `json:"clients_below_realtime"`
+	MaxDriftMs           float64 `json:"max_drift_ms"`
+	MetricsDegraded      bool    `json:"metrics_degraded"`
+
+	// Segment download wall time (nil without debug stats)
+	SegmentLatency *LatencySnapshot `json:"segment_latency_ms,omitempty"`
+
+	// Segment transfer time alone, and the idle gap before the next request
+	// (wall time is roughly the two together; nil/0 without debug stats)
+	SegmentDownload *LatencySnapshot `json:"segment_download_ms,omitempty"`
+	SegmentGapAvgMs float64          `json:"segment_gap_avg_ms"`
+
+	// New TCP connections per second (debug stats only)
+	TCPConnectRate float64 `json:"tcp_connects_per_sec"`
+
+	// Playlist refresh intervals and storms (nil before any refresh)
+	PlaylistRefresh *RefreshSnapshot `json:"playlist_refresh,omitempty"`
+
+	// Latency SLO standing (nil without -slo)
+	SLO *SLOSnapshot `json:"slo,omitempty"`
+}
+
+// RefreshSnapshot is the playlist refresh interval histogram, cumulative
+// like a Prometheus one, and the refresh storms so far.
+type RefreshSnapshot struct {
+	Buckets     []RefreshBucket `json:"buckets"`
+	Intervals   int64           `json:"intervals"`
+	MeanRatio   float64         `json:"mean_ratio"` // Mean interval / target duration
+	Storms      int64           `json:"storms"`
+	PeakClients int             `json:"peak_clients"` // Most refreshes in one 100ms window
+}
+
+// RefreshBucket counts the refresh intervals of at most LE times the
+// target duration (LE 0 = +Inf).
+type RefreshBucket struct {
+	LE    float64 `json:"le"`
+	Count int64   `json:"count"`
+}
+
+// LatencySnapshot holds latency percentiles in milliseconds.
+type LatencySnapshot struct {
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+}
+
+// NewSnapshot builds a Snapshot from the aggregate (and optional debug)
+// stats. agg may be nil when stats collection is disabled; only the
+// timestamp and target are then filled in.
+func NewSnapshot(now time.Time, elapsed time.Duration, targetClients int, agg *AggregatedStats, ds *DebugStatsAggregate) Snapshot {
+	s := Snapshot{
+		Timestamp:      now.UTC(),
+		ElapsedSeconds: elapsed.Seconds(),
+		TargetClients:  targetClients,
+	}
+	if agg != nil {
+		s.ActiveClients = agg.ActiveClients
+		s.StalledClients = agg.StalledClients
+		s.ManifestRequests = agg.TotalManifestReqs
+		s.SegmentRequests = agg.TotalSegmentReqs
+		s.Bytes = agg.TotalBytes
+		s.ManifestRate = agg.InstantManifestRate
+		s.SegmentRate = agg.InstantSegmentRate
+		s.ThroughputBps = agg.InstantThroughputRate
+		s.Timeouts = agg.TotalTimeouts
+		s.Reconnections = agg.TotalReconnections
+		s.ErrorRate = agg.ErrorRate
+		s.AverageSpeed = agg.AverageSpeed
+		s.ClientsBelowRealtime = agg.ClientsBelowRealtime
+		s.MaxDriftMs = durationMs(agg.MaxDrift)
+		s.MetricsDegraded = agg.MetricsDegraded
+		if len(agg.TotalHTTPErrors) > 0 {
+			s.HTTPErrors = make(map[int]int64, len(agg.TotalHTTPErrors))
+			for code, count := range agg.TotalHTTPErrors {
+				s.HTTPErrors[code] = count
+			}
+		}
+	}
+	if ds != nil {
+		s.TCPConnectRate = ds.InstantTCPConnectsRate
+		s.SegmentGapAvgMs = ds.SegmentGapAvgMs
+		if ds.SegmentDownloadP50 > 0 {
+			s.SegmentDownload = &LatencySnapshot{
+				P50: durationMs(ds.SegmentDownloadP50),
+				P95: durationMs(ds.SegmentDownloadP95),
+				P99: durationMs(ds.SegmentDownloadP99),
+			}
+		}
+		if pr := ds.PlaylistRefresh; pr.PeakClients > 0 {
+			s.PlaylistRefresh = newRefreshSnapshot(pr)
+		}
+	}
+	if ds != nil && ds.SegmentWallTimeP50 > 0 {
+		s.SegmentLatency = &LatencySnapshot{
+			P50: durationMs(ds.SegmentWallTimeP50),
+			P95: durationMs(ds.SegmentWallTimeP95),
+			P99: durationMs(ds.SegmentWallTimeP99),
+		}
+	}
+	return s
+}
+
+// SetRates replaces the rates NewSnapshot took from the stats' Instant
+// fields (since whoever aggregated last) with ones over the snapshot's own
+// interval.
+func (s *Snapshot) SetRates(a AggregatedDelta, d DebugDelta) {
+	s.ManifestRate = a.ManifestRate()
+	s.SegmentRate = a.SegmentRate()
+	s.ThroughputBps = a.ThroughputRate()
+	s.TCPConnectRate = d.TCPConnectsRate()
+}
+
+func newRefreshSnapshot(d RefreshDistribution) *RefreshSnapshot {
+	s := &RefreshSnapshot{
+		Intervals:   d.Intervals(),
+		MeanRatio:   d.MeanRatio(),
+		Storms:      d.Storms,
+		PeakClients: d.PeakClients,
+	}
+	var cum int64
+	for i, c := range d.Counts {
+		cum += c
+		b := RefreshBucket{Count: cum}
+		if i < len(d.Bounds) {
+			b.LE = d.Bounds[i]
+		}
+		s.Buckets = append(s.Buckets, b)
+	}
+	return s
+}
+
+// WriteNDJSON writes s as a single line of JSON.
+func WriteNDJSON(w io.Writer, s Snapshot) error {
+	return json.NewEncoder(w).Encode(s) // Encode appends the newline
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
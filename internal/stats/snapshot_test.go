@@ -0,0 +1,134 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSnapshot(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	agg := &AggregatedStats{
+		ActiveClients:         98,
+		StalledClients:        2,
+		TotalSegmentReqs:      1200,
+		InstantSegmentRate:    50,
+		InstantThroughputRate: 6_000_000,
+		TotalHTTPErrors:       map[int]int64{503: 4},
+		TotalTimeouts:         1,
+		ErrorRate:             0.004,
+		MaxDrift:              1500 * time.Millisecond,
+	}
+	ds := &DebugStatsAggregate{
+		SegmentWallTimeP50:     80 * time.Millisecond,
+		SegmentWallTimeP95:     180 * time.Millisecond,
+		SegmentWallTimeP99:     250 * time.Millisecond,
+		InstantTCPConnectsRate: 12.5,
+		SegmentDownloadP50:     20 * time.Millisecond,
+		SegmentDownloadP95:     60 * time.Millisecond,
+		SegmentDownloadP99:     90 * time.Millisecond,
+		SegmentGapAvgMs:        1900,
+	}
+
+	s := NewSnapshot(now, 90*time.Second, 100, agg, ds)
+
+	if s.ElapsedSeconds != 90 || s.TargetClients != 100 || s.ActiveClients != 98 || s.StalledClients != 2 {
+		t.Errorf("clients/elapsed = %+v", s)
+	}
+	if s.SegmentRequests != 1200 || s.SegmentRate != 50 || s.ThroughputBps != 6_000_000 {
+		t.Errorf("requests = %+v", s)
+	}
+	if s.HTTPErrors[503] != 4 || s.Timeouts != 1 || s.MaxDriftMs != 1500 {
+		t.Errorf("errors/drift = %+v", s)
+	}
+	if s.SegmentLatency == nil || *s.SegmentLatency != (LatencySnapshot{P50: 80, P95: 180, P99: 250}) {
+		t.Errorf("SegmentLatency = %+v", s.SegmentLatency)
+	}
+	if s.SegmentDownload == nil || *s.SegmentDownload != (LatencySnapshot{P50: 20, P95: 60, P99: 90}) || s.SegmentGapAvgMs != 1900 {
+		t.Errorf("SegmentDownload = %+v, gap %v", s.SegmentDownload, s.SegmentGapAvgMs)
+	}
+	if s.TCPConnectRate != 12.5 {
+		t.Errorf("TCPConnectRate = %v, want 12.5", s.TCPConnectRate)
+	}
+
+	// The snapshot must not alias the aggregator's map
+	agg.TotalHTTPErrors[503] = 10
+	if s.HTTPErrors[503] != 4 {
+		t.Error("HTTPErrors shares the source map")
+	}
+}
+
+func TestSnapshot_SetRates(t *testing.T) {
+	s := NewSnapshot(time.Now(), time.Minute, 10,
+		&AggregatedStats{InstantSegmentRate: 99},
+		&DebugStatsAggregate{InstantTCPConnectsRate: 99})
+
+	s.SetRates(
+		AggregatedDelta{Interval: 2 * time.Second, ManifestReqs: 4, SegmentReqs: 20, Bytes: 1000},
+		DebugDelta{Interval: 2 * time.Second, TCPConnects: 6})
+	if s.ManifestRate != 2 || s.SegmentRate != 10 || s.ThroughputBps != 500 || s.TCPConnectRate != 3 {
+		t.Errorf("rates = %v/%v/%v/%v, want 2/10/500/3", s.ManifestRate, s.SegmentRate, s.ThroughputBps, s.TCPConnectRate)
+	}
+}
+
+func TestNewSnapshot_PlaylistRefresh(t *testing.T) {
+	s := NewSnapshot(time.Now(), time.Minute, 10, &AggregatedStats{}, &DebugStatsAggregate{})
+	if s.PlaylistRefresh != nil {
+		t.Errorf("PlaylistRefresh = %+v before any refresh, want nil", s.PlaylistRefresh)
+	}
+
+	s = NewSnapshot(time.Now(), time.Minute, 10, &AggregatedStats{}, &DebugStatsAggregate{PlaylistRefresh: testRefreshes})
+	pr := s.PlaylistRefresh
+	if pr == nil {
+		t.Fatal("PlaylistRefresh = nil")
+	}
+	if pr.Intervals != 10 || pr.Storms != 2 || pr.PeakClients != 40 {
+		t.Errorf("PlaylistRefresh = %+v", pr)
+	}
+	// Cumulative, ending in +Inf (le 0) with every interval
+	if len(pr.Buckets) != 10 || pr.Buckets[3] != (RefreshBucket{LE: 1, Count: 5}) || pr.Buckets[9] != (RefreshBucket{Count: 10}) {
+		t.Errorf("Buckets = %+v", pr.Buckets)
+	}
+}
+
+func TestNewSnapshot_StatsDisabled(t *testing.T) {
+	s := NewSnapshot(time.Now(), time.Second, 10, nil, nil)
+	if s.TargetClients != 10 || s.ActiveClients != 0 || s.SegmentLatency != nil || s.HTTPErrors != nil {
+		t.Errorf("NewSnapshot(nil) = %+v", s)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	agg := &AggregatedStats{ActiveClients: 5, TotalHTTPErrors: map[int]int64{404: 2}}
+
+	for i := 0; i < 2; i++ {
+		if err := WriteNDJSON(&buf, NewSnapshot(now, time.Duration(i)*time.Second, 5, agg, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+	if got["ts"] != "2026-05-01T12:00:00Z" || got["elapsed_s"] != 1.0 || got["active_clients"] != 5.0 {
+		t.Errorf("snapshot = %v", got)
+	}
+	if errs, _ := got["http_errors"].(map[string]any); errs["404"] != 2.0 {
+		t.Errorf("http_errors = %v", got["http_errors"])
+	}
+	for _, omitted := range []string{"final", "segment_latency_ms"} {
+		if _, ok := got[omitted]; ok {
+			t.Errorf("%q should be omitted when unset", omitted)
+		}
+	}
+}
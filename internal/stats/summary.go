@@ -6,7 +6,9 @@ package stats
 
 import (
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,8 +21,8 @@ type SummaryConfig struct {
 	// Duration is the total run duration
 	Duration time.Duration
 
-	// MetricsAddr is the Prometheus metrics endpoint address
-	MetricsAddr string
+	// MetricsEndpoints are the Prometheus endpoints served, ready to print
+	MetricsEndpoints []string
 
 	// ShowPerClientStats enables detailed per-client statistics
 	ShowPerClientStats bool
@@ -28,16 +30,384 @@ type SummaryConfig struct {
 	// ExitCodes is a map of exit codes to counts (from metrics.Collector)
 	ExitCodes map[int]int
 
+	// ExitReasons counts exits by ClassifyExit reason
+	ExitReasons map[string]int
+
+	// StopSignals counts signals sent to stop clients, by name ("SIGTERM");
+	// SIGKILLs are stops whose grace period ran out
+	StopSignals map[string]int
+
+	// Debug holds the HLS/HTTP/TCP layer aggregates (nil without debug stats)
+	Debug *DebugStatsAggregate
+
 	// TotalStarts is the total number of client starts
 	TotalStarts int
 
 	// TotalRestarts is the total number of client restarts
 	TotalRestarts int
 
+	// Quarantines counts clients parked after failing repeatedly
+	Quarantines int
+
 	// UptimeP50, UptimeP95, UptimeP99 are uptime percentiles
 	UptimeP50 time.Duration
 	UptimeP95 time.Duration
 	UptimeP99 time.Duration
+
+	// UptimeExits is the number of exits behind the percentiles; UptimeSampled
+	// is set when they come from a downsampled history (very long runs).
+	UptimeExits   int64
+	UptimeSampled bool
+
+	// PlaylistValidation is true if -validate-playlists ran
+	PlaylistValidation bool
+
+	// PlaylistViolations counts compliance violations by kind
+	PlaylistViolations map[string]int64
+
+	// PlaylistFetchErrors is the number of failed validation reloads
+	PlaylistFetchErrors int64
+
+	// PlaylistEncodings counts validation reloads by Content-Encoding
+	PlaylistEncodings map[string]PlaylistEncoding
+
+	// PlaylistTimings is each validated playlist's segmenting, by URL
+	PlaylistTimings map[string]PlaylistTiming
+
+	// CoolDown is the -cool-down result (nil if not run)
+	CoolDown *CoolDownSummary
+
+	// ReconnectCauses is the clients' reconnects by failed stage (nil
+	// without reconnects or stats)
+	ReconnectCauses *ReconnectCauseSummary
+
+	// NoKeepAlive is true if clients opened a connection per request
+	NoKeepAlive bool
+
+	// Prefetch is the -prefetch depth clients ran with ("" = FFmpeg default)
+	Prefetch string
+
+	// SlowRequest is the -slow-request-log threshold (0 = off)
+	SlowRequest time.Duration
+
+	// SLO is the final -slo segment latency objective standing (nil without -slo)
+	SLO *SLOStatus
+
+	// Pacing names the -pacing model(s) clients ran with ("" = not recorded)
+	Pacing string
+
+	// Tenants are the per-tenant results of a -tenants run (nil otherwise)
+	Tenants []TenantSummary
+
+	// Tokens is the session token activity of a -token-url run (nil otherwise)
+	Tokens *TokenSummary
+
+	// Geos are the per-geo results of a -geo run (nil otherwise)
+	Geos []GeoSummary
+
+	// Variants are the per-variant loads of a -variant-mix run (nil
+	// otherwise)
+	Variants []VariantSummary
+
+	// Renditions are the requests per rendition of a -renditions run (nil
+	// otherwise)
+	Renditions []RenditionSummary
+
+	// Capture is the -pcap-dir packet capture (nil otherwise)
+	Capture *CaptureSummary
+
+	// Sockets is the kernel's view of the clients' connections in a
+	// -socket-stats run (nil otherwise)
+	Sockets *SocketSummary
+
+	// Flaps is how clients caught up after -flap-interval network flaps
+	// (nil otherwise)
+	Flaps *FlapSummary
+
+	// Bursts are the -burst traffic bursts (nil otherwise)
+	Bursts *BurstSummary
+
+	// Sessions are the -session-duration viewer sessions (nil otherwise)
+	Sessions *SessionSummary
+
+	// VODStarts are where clients started in the asset with -vod-start
+	// (nil otherwise)
+	VODStarts *VODStartSummary
+
+	// VODCompletion is how the plays of the VOD asset went with -vod-end
+	// (nil otherwise)
+	VODCompletion *VODCompletionSummary
+
+	// ClientCPU is the clients' CPU use with -client-cpu-limit (nil if it
+	// was off or nothing was sampled)
+	ClientCPU *ClientCPUSummary
+
+	// Memory is the swarm's own memory use with -max-memory (nil if it was
+	// off or nothing was sampled)
+	Memory *MemoryGuardSummary
+
+	// RefreshOverride is the -playlist-refresh override (nil without it)
+	RefreshOverride *RefreshOverrideSummary
+
+	// Compare is the A/B origin comparison of a -compare-url run (nil
+	// otherwise)
+	Compare *OriginComparison
+
+	// OriginLog is the origin's access log of a -origin-log run (nil
+	// otherwise)
+	OriginLog *OriginLogSummary
+
+	// Serving is the CDN vs origin breakdown of a -cdn-detect run (nil
+	// otherwise)
+	Serving *ServingBreakdown
+}
+
+// TokenSummary describes session token fetches and 401 re-auths.
+type TokenSummary struct {
+	Fetches       int64 // Token fetches (one per client process start)
+	FetchFailures int64
+	HTTP401       int64 // 401 responses seen by the clients
+	Expiries      int64 // Tokens expired by -token-ttl
+	Reauths       int64 // Clients running again with a new token after a 401 or expiry
+	ReauthP50     time.Duration
+	ReauthP95     time.Duration
+	ReauthP99     time.Duration
+}
+
+// GeoSummary is one emulated geo's results in a -geo run.
+type GeoSummary struct {
+	Name          string
+	Clients       int // Started clients
+	Requests      int64
+	HTTPErrors    map[int]int64 // By status code
+	NetworkErrors int64
+
+	SegmentP50, SegmentP95, SegmentP99 time.Duration
+	ManifestP50, ManifestP99           time.Duration
+}
+
+// VariantSummary is one variant's load in a -variant-mix run.
+type VariantSummary struct {
+	Name       string  // As given to -variant-mix ("720p")
+	Resolution string  // Of the matched variant
+	Bandwidth  int64   // BANDWIDTH of the matched variant, bits/sec
+	Target     float64 // Share of the clients asked for, percent
+	Clients    int     // Started clients
+	Requests   int64
+	Bytes      int64
+	Errors     int64
+}
+
+// RenditionSummary is one rendition's requests in a -renditions run. The
+// variant's own playlists and segments are the "video" row.
+type RenditionSummary struct {
+	Name      string // "video", or the type and language ("audio en")
+	Label     string // NAME attribute of the rendition ("" for video)
+	Segments  int64
+	Playlists int64
+}
+
+// CaptureSummary describes the -pcap-dir packet capture.
+type CaptureSummary struct {
+	Dir       string
+	RingFiles int // Files of the whole-origin capture ring
+	RingBytes int64
+	Clients   []CapturedClient
+	Error     string // Why nothing was captured ("" = it was)
+}
+
+// SocketSummary is the kernel tcp_info of the clients' connections over
+// a -socket-stats run.
+type SocketSummary struct {
+	Samples                int
+	PeakConnections        int
+	RTTP50, RTTP95, RTTP99 time.Duration
+	MinRTT                 time.Duration
+	Retransmits            int64 // Sent by the clients
+	OutOfOrder             int64 // Received out of order
+	BytesReceived          int64
+	Error                  string // Why nothing was sampled ("" = it was)
+}
+
+// ClientCPUSummary describes the clients' CPU use, checked against
+// -client-cpu-limit to catch FFmpegs that decode instead of just demuxing.
+type ClientCPUSummary struct {
+	Limit   float64 // Percent of one core
+	Mean    float64 // Over all clients and the whole run
+	Peak    float64 // Highest one client used over a sample interval
+	Flagged int64   // Client processes over the limit
+	Failed  bool    // The run was stopped for it (-client-cpu-policy fail)
+}
+
+// MemoryGuardSummary describes the swarm's own memory use against
+// -max-memory, and the features it shed to stay under it.
+type MemoryGuardSummary struct {
+	Limit     int64               // Bytes
+	Peak      int64               // Highest RSS sampled, bytes
+	Shed      []MemoryDegradation // In the order they were shed
+	Exhausted bool                // Still over the limit with nothing left to shed
+}
+
+// MemoryDegradation is one feature the memory guard shed.
+type MemoryDegradation struct {
+	Feature     string        // "per-client-metrics", "sample-rings", "history"
+	Description string        // What was given up
+	At          time.Duration // Into the run
+	RSS         int64         // Bytes, when it was shed
+}
+
+// RefreshOverrideSummary describes the -playlist-refresh override: the
+// playlist fetches the swarm added between FFmpeg's own reloads.
+type RefreshOverrideSummary struct {
+	Factor  float64       // Refresh every Factor x Target
+	Target  time.Duration // The stream's target duration
+	Fetches int64
+	Errors  int64
+
+	// Conditional is set with -conditional-playlists; NotModified are the
+	// fetches answered 304 (counted in Fetches, not Errors)
+	Conditional bool
+	NotModified int64
+}
+
+// FlapSummary describes the -flap-interval network flaps and the paused
+// clients' catch-up.
+type FlapSummary struct {
+	Interval, Duration time.Duration
+	Flaps              int64 // Flaps that paused at least one client
+	Pauses             int64 // Client pauses (several per flap with -flap-clients)
+	Unrecovered        int64 // Pauses not followed by a segment within the timeout
+
+	CatchUpP50, CatchUpP95, CatchUpP99 time.Duration // Resume to next segment
+
+	SequenceSkips   int64
+	SegmentsExpired int64
+	SegmentsSkipped int64
+}
+
+// BurstSummary describes the -burst traffic bursts layered on the
+// baseline.
+type BurstSummary struct {
+	Schedules []string      // In -burst syntax
+	Windows   []BurstWindow // In start order
+}
+
+// BurstWindow is one burst, Start and End into the run.
+type BurstWindow struct {
+	Schedule   string
+	Clients    int
+	Start, End time.Duration
+	CutShort   bool // Ended by the run stopping (or a failed scale down)
+}
+
+// SessionSummary describes the -session-duration viewer sessions: their
+// arrivals, departures and lengths.
+type SessionSummary struct {
+	Distribution string // As given to -session-duration
+	Started      int64  // Arrivals: first starts and replacements
+	Ended        int64  // Departures after the drawn length
+
+	MeanLength, MinLength, MaxLength time.Duration // Of the ended sessions
+}
+
+// VODStartSummary describes where in a VOD asset the clients started
+// playing with -vod-start.
+type VODStartSummary struct {
+	Distribution string // As given to -vod-start
+	Asset        time.Duration
+	Starts       int64     // Client process starts
+	Tenths       [10]int64 // Starts per tenth of the asset
+}
+
+// VODCompletionSummary describes the plays of a VOD asset with -vod-end.
+// A play ends finished (it reached the end of the asset), early (a
+// failure, restart or session end), or cut short by the end of the run.
+type VODCompletionSummary struct {
+	Mode     string // -vod-end: stop or loop
+	Asset    time.Duration
+	Plays    int64 // Started
+	Finished int64
+	CutShort int64
+	Clients  int // Clients that finished at least once
+
+	MeanTime, MinTime, MaxTime time.Duration // Of the finished plays
+}
+
+// CompletionRatio returns the fraction of the plays that ended, rather
+// than being cut short, which reached the end of the asset.
+func (v *VODCompletionSummary) CompletionRatio() float64 {
+	ended := v.Plays - v.CutShort
+	if ended <= 0 {
+		return 0
+	}
+	return float64(v.Finished) / float64(ended)
+}
+
+// CapturedClient is one sampled client's capture.
+type CapturedClient struct {
+	ClientID    int
+	Connections int
+	File        string // Its own pcap ("" = none written)
+	Filter      string // Wireshark display filter for the ring files
+}
+
+// TenantSummary is one tenant's share of a -tenants run.
+type TenantSummary struct {
+	Name      string
+	Clients   int     // Assigned clients
+	Held      int     // Clients held back by the quota at the end
+	MaxRPS    float64 // Request-rate quota (0 = unlimited)
+	PeakRPS   float64 // Highest measured request rate
+	Requests  int64   // Manifest + segment requests
+	Bytes     int64
+	Errors    int64 // HTTP and network errors
+	Throttles int64 // Times the quota held back or stopped a client
+}
+
+// CoolDownSummary describes origin latency after the clients stopped.
+type CoolDownSummary struct {
+	Duration     time.Duration // Configured cool-down
+	Elapsed      time.Duration // Actual (shorter if interrupted)
+	Baseline     time.Duration // Pre-load probe latency (0 = unknown)
+	Peak         time.Duration // Slowest successful probe
+	Final        time.Duration // Last successful probe
+	Probes       int
+	Failures     int
+	Recovered    bool
+	RecoveryTime time.Duration // Cool-down start -> latency back near baseline
+}
+
+// Exit reasons reported by ClassifyExit.
+const (
+	ExitReasonShutdown    = "shutdown"             // Stopped by the swarm at end of run
+	ExitReasonStreamEnded = "stream ended"         // Exit 0 mid-run (VOD end, ENDLIST)
+	ExitReasonError       = "ffmpeg error"         // Non-zero exit (network, HTTP, demux)
+	ExitReasonKilled      = "killed (SIGKILL/OOM)" // Usually the OOM killer
+	ExitReasonTerminated  = "terminated (SIGTERM)" // Sent from outside the swarm
+	ExitReasonSignal      = "other signal"
+)
+
+// topN limits the ranked lists (error codes, hosts) in the exit summary.
+const topN = 5
+
+// ClassifyExit explains an FFmpeg exit code (128+N for signal N). stopping
+// is true once the swarm has begun shutting clients down, when any exit is
+// expected.
+func ClassifyExit(code int, stopping bool) string {
+	switch {
+	case stopping:
+		return ExitReasonShutdown
+	case code == 0:
+		return ExitReasonStreamEnded
+	case code == 137:
+		return ExitReasonKilled
+	case code == 143:
+		return ExitReasonTerminated
+	case code > 128:
+		return ExitReasonSignal
+	default:
+		return ExitReasonError
+	}
 }
 
 // FormatExitSummary formats aggregated stats for display at program exit.
@@ -45,10 +415,11 @@ type SummaryConfig struct {
 // The summary includes:
 // - Metrics degradation warning (if applicable)
 // - Run information
-// - Request statistics with rates
-// - Inferred latency percentiles
+// - Request statistics with rates and peak throughput
 // - Playback health metrics
-// - Error statistics
+// - HLS/HTTP/TCP layer breakdown (mirrors the TUI layered view)
+// - Error statistics, top error codes and top hosts
+// - Exit reasons and codes
 // - Footnotes with diagnostic information
 func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 	if stats == nil {
@@ -69,12 +440,31 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 			FormatNumber(stats.TotalLinesDropped),
 			stats.ClientsWithDrops,
 		)
-		b.WriteString("    Consider: --stats-buffer 2000 or fewer clients for accurate metrics\n\n")
+		fmt.Fprintf(&b, "    By path: progress %s of %s lines, stderr %s of %s\n",
+			FormatNumber(stats.ProgressLinesDropped), FormatNumber(stats.ProgressLinesRead),
+			FormatNumber(stats.StderrLinesDropped), FormatNumber(stats.StderrLinesRead),
+		)
+		b.WriteString("    Consider: --stats-buffer 2000, -progress-mode off or fewer clients for accurate metrics\n\n")
+	}
+	if stats.TotalLinesTruncated > 0 {
+		fmt.Fprintf(&b, "ℹ️  Lines truncated: %s over the -stats-max-line limit (tails discarded)\n\n",
+			FormatNumber(stats.TotalLinesTruncated))
+	}
+	if stats.TotalInvalidUTF8 > 0 {
+		fmt.Fprintf(&b, "ℹ️  Invalid UTF-8: %s byte runs in FFmpeg output replaced with U+FFFD\n\n",
+			FormatNumber(stats.TotalInvalidUTF8))
+	}
+	if stats.TotalParserPanics > 0 {
+		fmt.Fprintf(&b, "⚠️  Parser panics: %s lines skipped and their parser reset (see parser_panic logs)\n\n",
+			FormatNumber(stats.TotalParserPanics))
 	}
 
 	// Run info
 	fmt.Fprintf(&b, "Run Duration:           %s\n", FormatDuration(cfg.Duration))
 	fmt.Fprintf(&b, "Target Clients:         %d\n", cfg.TargetClients)
+	if cfg.Pacing != "" {
+		fmt.Fprintf(&b, "Pacing:                 %s\n", cfg.Pacing)
+	}
 	fmt.Fprintf(&b, "Peak Active Clients:    %d\n\n", stats.TotalClients)
 
 	// Request statistics
@@ -109,10 +499,17 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 			stats.TotalInitReqs/perClient,
 		)
 	}
-	fmt.Fprintf(&b, "\n  Total Bytes:          %s  (%s/s)\n\n",
+	fmt.Fprintf(&b, "\n  Total Bytes:          %s  (%s/s)\n",
 		FormatBytes(stats.TotalBytes),
 		FormatBytes(int64(stats.ThroughputBytesPerSec)),
 	)
+	if stats.PeakThroughputRate > 0 {
+		fmt.Fprintf(&b, "  Peak Throughput:      %s/s  (%.1f Mbps)\n",
+			FormatBytes(int64(stats.PeakThroughputRate)),
+			stats.PeakThroughputRate*8/1e6,
+		)
+	}
+	b.WriteString("\n")
 
 	// Note: Latency metrics removed - use DebugStats.SegmentWallTime* for accurate latency
 	// from FFmpeg timestamps. See docs/REMOVE_INFERRED_LATENCY_ANALYSIS.md
@@ -146,6 +543,8 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 	}
 	b.WriteString("\n")
 
+	b.WriteString(renderLayers(cfg))
+
 	// Uptime distribution (from metrics.Collector)
 	if cfg.UptimeP50 > 0 || cfg.UptimeP95 > 0 {
 		b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
@@ -155,6 +554,9 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 		fmt.Fprintf(&b, "  P50 (median):         %s\n", FormatDuration(cfg.UptimeP50))
 		fmt.Fprintf(&b, "  P95:                  %s\n", FormatDuration(cfg.UptimeP95))
 		fmt.Fprintf(&b, "  P99:                  %s\n", FormatDuration(cfg.UptimeP99))
+		if cfg.UptimeSampled {
+			fmt.Fprintf(&b, "  (sampled from %d exits; use -stats-spill-dir for full history)\n", cfg.UptimeExits)
+		}
 		b.WriteString("\n")
 	}
 
@@ -166,6 +568,9 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 
 		fmt.Fprintf(&b, "  Total Starts:         %d\n", cfg.TotalStarts)
 		fmt.Fprintf(&b, "  Total Restarts:       %d\n", cfg.TotalRestarts)
+		if cfg.Quarantines > 0 {
+			fmt.Fprintf(&b, "  Quarantines:          %d\n", cfg.Quarantines)
+		}
 		b.WriteString("\n")
 	}
 
@@ -176,15 +581,29 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 		b.WriteString("                                  Errors\n")
 		b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
 
-		// Sort HTTP error codes for consistent output
+		// Most frequent codes first
 		codes := make([]int, 0, len(stats.TotalHTTPErrors))
 		for code := range stats.TotalHTTPErrors {
 			codes = append(codes, code)
 		}
-		sort.Ints(codes)
+		sort.Slice(codes, func(i, j int) bool {
+			ci, cj := stats.TotalHTTPErrors[codes[i]], stats.TotalHTTPErrors[codes[j]]
+			if ci != cj {
+				return ci > cj
+			}
+			return codes[i] < codes[j]
+		})
 
-		for _, code := range codes {
+		for i, code := range codes {
 			count := stats.TotalHTTPErrors[code]
+			if i == topN {
+				var rest int64
+				for _, c := range codes[topN:] {
+					rest += stats.TotalHTTPErrors[c]
+				}
+				fmt.Fprintf(&b, "  %-22s%d\n", fmt.Sprintf("(%d more codes):", len(codes)-topN), rest)
+				break
+			}
 			if code == 0 {
 				// Code 0 is the sentinel for "other" (non-standard HTTP error codes)
 				fmt.Fprintf(&b, "  HTTP Other:            %d\n", count)
@@ -200,6 +619,13 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 		}
 		fmt.Fprintf(&b, "  Error Rate:           %.4f%%\n\n", stats.ErrorRate*100)
 	}
+	b.WriteString(renderReconnectCauses(cfg.ReconnectCauses))
+
+	b.WriteString(renderTopHosts(cfg.Debug))
+	b.WriteString(renderShardBalance(cfg.Debug))
+	b.WriteString(renderServing(cfg.Serving))
+	b.WriteString(renderHotSpots(cfg.Debug))
+	b.WriteString(renderExitReasons(cfg.ExitReasons, cfg.StopSignals))
 
 	// Exit codes (from metrics.Collector)
 	if len(cfg.ExitCodes) > 0 {
@@ -222,22 +648,48 @@ func FormatExitSummary(stats *AggregatedStats, cfg SummaryConfig) string {
 		b.WriteString("\n")
 	}
 
+	b.WriteString(renderTenants(cfg.Tenants, cfg.Duration))
+	b.WriteString(renderTokens(cfg.Tokens))
+	b.WriteString(renderGeos(cfg.Geos))
+	b.WriteString(renderVariants(cfg.Variants))
+	b.WriteString(renderRenditions(cfg.Renditions))
+	b.WriteString(renderOriginComparison(cfg.Compare))
+	b.WriteString(renderOriginLog(cfg.OriginLog))
+	b.WriteString(renderCapture(cfg.Capture))
+	b.WriteString(renderSockets(cfg.Sockets))
+	b.WriteString(renderFlaps(cfg.Flaps))
+	b.WriteString(renderBursts(cfg.Bursts))
+	b.WriteString(renderSessions(cfg.Sessions, cfg.Duration))
+	b.WriteString(renderVODStarts(cfg.VODStarts))
+	b.WriteString(renderVODCompletion(cfg.VODCompletion))
+	b.WriteString(renderClientCPU(cfg.ClientCPU))
+	b.WriteString(renderMemoryGuard(cfg.Memory))
+	b.WriteString(renderRefreshOverride(cfg.RefreshOverride))
+	b.WriteString(renderPlaylistCompliance(cfg))
+	b.WriteString(renderCoolDown(cfg.CoolDown))
+
 	// Footnotes (diagnostic information)
 	footnotes := renderFootnotes(stats)
 	if footnotes != "" {
 		b.WriteString(footnotes)
 	}
 
-	// Metrics endpoint
-	if cfg.MetricsAddr != "" {
-		fmt.Fprintf(&b, "Metrics endpoint was: http://%s/metrics\n", cfg.MetricsAddr)
-	}
+	b.WriteString(renderMetricsEndpoints(cfg.MetricsEndpoints))
 
 	b.WriteString("═══════════════════════════════════════════════════════════════════════════════\n")
 
 	return b.String()
 }
 
+// renderMetricsEndpoints lists where /metrics was served.
+func renderMetricsEndpoints(endpoints []string) string {
+	var b strings.Builder
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(&b, "Metrics endpoint was: %s\n", endpoint)
+	}
+	return b.String()
+}
+
 // formatBasicSummary formats a basic summary when stats are not available.
 func formatBasicSummary(cfg SummaryConfig) string {
 	var b strings.Builder
@@ -252,15 +704,965 @@ func formatBasicSummary(cfg SummaryConfig) string {
 
 	b.WriteString("(Stats collection was disabled - use --stats to enable detailed metrics)\n\n")
 
-	if cfg.MetricsAddr != "" {
-		fmt.Fprintf(&b, "Metrics endpoint was: http://%s/metrics\n", cfg.MetricsAddr)
-	}
+	b.WriteString(renderOriginLog(cfg.OriginLog))
+	b.WriteString(renderRefreshOverride(cfg.RefreshOverride))
+	b.WriteString(renderPlaylistCompliance(cfg))
+	b.WriteString(renderCoolDown(cfg.CoolDown))
+
+	b.WriteString(renderMetricsEndpoints(cfg.MetricsEndpoints))
 
 	b.WriteString("═══════════════════════════════════════════════════════════════════════════════\n")
 
 	return b.String()
 }
 
+// renderLayers renders the HLS/HTTP/TCP breakdown from FFmpeg debug
+// events, in the same layers as the TUI. Returns "" without debug stats.
+func renderLayers(cfg SummaryConfig) string {
+	ds := cfg.Debug
+	if ds == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                 HLS Layer\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Segments:             %s downloaded, %s failed, %s skipped, %s expired\n",
+		FormatNumber(ds.SegmentsDownloaded), FormatNumber(ds.SegmentsFailed),
+		FormatNumber(ds.SegmentsSkipped), FormatNumber(ds.SegmentsExpired))
+	fmt.Fprintf(&b, "  Playlists:            %s refreshed, %s failed, %s late\n",
+		FormatNumber(ds.PlaylistsRefreshed), FormatNumber(ds.PlaylistsFailed), FormatNumber(ds.PlaylistLateCount))
+	if pr := ds.PlaylistRefresh; pr.Intervals() > 0 {
+		fmt.Fprintf(&b, "  Refresh Interval:     %.0f%% early  %.0f%% on time  %.0f%% late  %.0f%% very late  (mean %.2fx target)\n",
+			pr.Share(0, 0.9)*100, pr.Share(0.9, 1.1)*100, pr.Share(1.1, 1.5)*100, pr.Share(1.5, 0)*100, pr.MeanRatio())
+	}
+	if pr := ds.PlaylistRefresh; pr.PeakClients > 0 {
+		fmt.Fprintf(&b, "  Refresh Storms:       %d  (>= %d refreshes in 100ms; peak %d at %s)\n",
+			pr.Storms, pr.StormThreshold, pr.PeakClients, pr.PeakAt.Format("15:04:05.0"))
+	}
+	if ds.SegmentWallTimeP50 > 0 {
+		fmt.Fprintf(&b, "  Segment Wall Time:    P50 %s  P95 %s  P99 %s  (max %.0f ms)\n",
+			FormatMs(ds.SegmentWallTimeP50), FormatMs(ds.SegmentWallTimeP95),
+			FormatMs(ds.SegmentWallTimeP99), ds.SegmentWallTimeMax)
+	}
+	if ds.SegmentDownloadP50 > 0 {
+		fmt.Fprintf(&b, "  Segment Download:     P50 %s  P95 %s  P99 %s  (gap avg %.0f ms)\n",
+			FormatMs(ds.SegmentDownloadP50), FormatMs(ds.SegmentDownloadP95),
+			FormatMs(ds.SegmentDownloadP99), ds.SegmentGapAvgMs)
+	}
+	if ds.ManifestWallTimeP50 > 0 {
+		fmt.Fprintf(&b, "  Manifest Wall Time:   P50 %s  P95 %s  P99 %s  (max %.0f ms)\n",
+			FormatMs(ds.ManifestWallTimeP50), FormatMs(ds.ManifestWallTimeP95),
+			FormatMs(ds.ManifestWallTimeP99), ds.ManifestWallTimeMax)
+	}
+	if ds.SequenceSkips > 0 {
+		fmt.Fprintf(&b, "  Sequence Skips:       %d\n", ds.SequenceSkips)
+	}
+	if cfg.SlowRequest > 0 {
+		fmt.Fprintf(&b, "  Slow Requests:        %s segments, %s manifests  (>= %s)\n",
+			FormatNumber(ds.SlowSegments), FormatNumber(ds.SlowManifests), cfg.SlowRequest)
+	}
+	if cfg.SLO != nil {
+		b.WriteString(renderSLO(cfg.SLO))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                 HTTP Layer\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Opens:                %s\n", FormatNumber(ds.HTTPOpenCount))
+	fmt.Fprintf(&b, "  4xx / 5xx:            %d / %d\n", ds.HTTP4xxCount, ds.HTTP5xxCount)
+	fmt.Fprintf(&b, "  Reconnects:           %d\n", ds.ReconnectCount)
+	fmt.Fprintf(&b, "  Error Rate:           %.4f%%\n\n", ds.ErrorRate*100)
+
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                 TCP Layer\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Connects:             %s ok, %d refused, %d timed out\n",
+		FormatNumber(ds.TCPSuccessCount), ds.TCPRefusedCount, ds.TCPTimeoutCount)
+	fmt.Fprintf(&b, "  Health:               %.1f%%\n", ds.TCPHealthRatio*100)
+	mode := "keep-alive"
+	if cfg.NoKeepAlive {
+		mode = "new connection per request (-no-keepalive)"
+	}
+	fmt.Fprintf(&b, "  Connection Mode:      %s\n", mode)
+	switch cfg.Prefetch {
+	case "0":
+		b.WriteString("  Segment Prefetch:     off, one segment at a time (-prefetch 0)\n")
+	case "1":
+		b.WriteString("  Segment Prefetch:     next segment on a second connection (-prefetch 1)\n")
+	}
+	if cfg.Duration > 0 {
+		line := fmt.Sprintf("  Connections/sec:      %.2f", float64(ds.TCPSuccessCount)/cfg.Duration.Seconds())
+		if ds.HTTPOpenCount > 0 {
+			line += fmt.Sprintf("  (%.2f per request)", float64(ds.TCPSuccessCount)/float64(ds.HTTPOpenCount))
+		}
+		b.WriteString(line + "\n")
+	}
+	if ds.TCPConnectCount > 0 {
+		fmt.Fprintf(&b, "  Connect Latency:      avg %.1f ms  (min %.1f, max %.1f)\n",
+			ds.TCPConnectAvgMs, ds.TCPConnectMinMs, ds.TCPConnectMaxMs)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderTopHosts ranks URL hosts by HTTP opens. Useful when a CDN spreads
+// clients across edges or redirects. Returns "" if no hosts were seen.
+func renderTopHosts(ds *DebugStatsAggregate) string {
+	if ds == nil || len(ds.HostRequests) == 0 {
+		return ""
+	}
+
+	hosts := make([]string, 0, len(ds.HostRequests))
+	var total int64
+	for host, n := range ds.HostRequests {
+		hosts = append(hosts, host)
+		total += n
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		ni, nj := ds.HostRequests[hosts[i]], ds.HostRequests[hosts[j]]
+		if ni != nj {
+			return ni > nj
+		}
+		return hosts[i] < hosts[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                 Top Hosts\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	if len(hosts) > topN {
+		hosts = hosts[:topN]
+	}
+	for _, host := range hosts {
+		n := ds.HostRequests[host]
+		fmt.Fprintf(&b, "  %-40s %10s  (%.1f%%)\n", host, FormatNumber(n), float64(n)*100/float64(total))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderShardBalance shows how evenly sharded origins spread the
+// segment downloads, flagging hot shards. Returns "" if no URL template
+// has more than one shard.
+func renderShardBalance(ds *DebugStatsAggregate) string {
+	if ds == nil || len(ds.ShardGroups) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                               Shard Balance\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	for _, g := range ds.ShardGroups {
+		fmt.Fprintf(&b, "  %s\n", g.Template)
+		fmt.Fprintf(&b, "    %d shards, %s segments, skew %.2fx\n", len(g.Shards), FormatNumber(g.Requests), g.Skew)
+		shards := g.Shards
+		if len(shards) > topN {
+			shards = shards[:topN]
+		}
+		for _, s := range shards {
+			hot := ""
+			if s.Hot {
+				hot = "  HOT"
+			}
+			fmt.Fprintf(&b, "    %-40s %10s  (%.1f%%)%s\n", s.Shard, FormatNumber(s.Requests), s.Share*100, hot)
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderHotSpots lists the slowest segment downloads and the most
+// downloaded segment URLs across the swarm. Slowness concentrated on a few
+// segments or one edge shows up here. Returns "" without data.
+func renderHotSpots(ds *DebugStatsAggregate) string {
+	if ds == nil || (len(ds.SlowestSegments) == 0 && len(ds.HottestSegments) == 0) {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                              Segment Hot Spots\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	if len(ds.SlowestSegments) > 0 {
+		b.WriteString("  Slowest Downloads:\n")
+		for _, seg := range ds.SlowestSegments {
+			fmt.Fprintf(&b, "    %10s  client %-6d %s\n", FormatMs(seg.WallTime), seg.ClientID, seg.URL)
+		}
+	}
+	if len(ds.HottestSegments) > 0 {
+		b.WriteString("  Most Downloaded:\n")
+		for _, u := range ds.HottestSegments {
+			fmt.Fprintf(&b, "    %10s  %s\n", FormatNumber(u.Requests), u.URL)
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderExitReasons renders exits grouped by ClassifyExit reason, most
+// frequent first, and the signals sent to stop clients. Returns "" if no
+// client exited or was signalled.
+func renderExitReasons(reasons, stopSignals map[string]int) string {
+	if len(reasons) == 0 && len(stopSignals) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(reasons))
+	total := 0
+	for name, n := range reasons {
+		names = append(names, name)
+		total += n
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if reasons[names[i]] != reasons[names[j]] {
+			return reasons[names[i]] > reasons[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                               Exit Reasons\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-22s %6d  (%.1f%%)\n", name, reasons[name], float64(reasons[name])*100/float64(total))
+	}
+	if len(stopSignals) > 0 {
+		if len(names) > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  %-22s %s\n", "Stop signals sent:", formatStopSignals(stopSignals))
+		if kills := stopSignals["SIGKILL"]; kills > 0 {
+			fmt.Fprintf(&b, "  ⚠️  %d client(s) ignored the stop signal for the whole grace period and were killed\n", kills)
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// formatStopSignals formats stop signal counts as "SIGTERM 120, SIGKILL 3",
+// the stop signal first and SIGKILL last.
+func formatStopSignals(signals map[string]int) string {
+	names := make([]string, 0, len(signals))
+	for name := range signals {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "SIGKILL") != (names[j] == "SIGKILL") {
+			return names[j] == "SIGKILL"
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, signals[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// renderPlaylistCompliance renders the -validate-playlists results.
+// Returns "" if validation was not enabled.
+func renderPlaylistCompliance(cfg SummaryConfig) string {
+	if !cfg.PlaylistValidation {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                            Playlist Compliance\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	if len(cfg.PlaylistViolations) == 0 {
+		b.WriteString("  No violations observed\n")
+	} else {
+		kinds := make([]string, 0, len(cfg.PlaylistViolations))
+		for kind := range cfg.PlaylistViolations {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+
+		for _, kind := range kinds {
+			fmt.Fprintf(&b, "  %-26s %d\n", kind, cfg.PlaylistViolations[kind])
+		}
+	}
+	if cfg.PlaylistFetchErrors > 0 {
+		fmt.Fprintf(&b, "  %-26s %d\n", "(reload failures)", cfg.PlaylistFetchErrors)
+	}
+	b.WriteString(renderPlaylistEncodings(cfg.PlaylistEncodings))
+	b.WriteString(renderPlaylistTimings(cfg.PlaylistTimings))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// PlaylistEncoding is the validation reloads served with one
+// Content-Encoding.
+type PlaylistEncoding struct {
+	Fetches        int64
+	WireBytes      int64
+	DecodedBytes   int64
+	DecodeFailures int64
+}
+
+// renderPlaylistEncodings renders how validation reloads were compressed.
+// Returns "" if no reload got a response.
+func renderPlaylistEncodings(encodings map[string]PlaylistEncoding) string {
+	if len(encodings) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(encodings))
+	for name := range encodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n  %-12s %9s %11s %11s %7s %9s\n", "Encoding", "Reloads", "Wire", "Decoded", "Saved", "Failures")
+	for _, name := range names {
+		e := encodings[name]
+		saved := "-"
+		if name != "identity" && e.DecodedBytes > 0 {
+			saved = fmt.Sprintf("%.0f%%", 100*(1-float64(e.WireBytes)/float64(e.DecodedBytes)))
+		}
+		fmt.Fprintf(&b, "  %-12s %9s %11s %11s %7s %9d\n",
+			name, FormatNumber(e.Fetches), FormatBytes(e.WireBytes), FormatBytes(e.DecodedBytes), saved, e.DecodeFailures)
+	}
+	return b.String()
+}
+
+// PlaylistTiming is one validated playlist's segmenting: its #EXTINF
+// durations, and the pace new segments appeared at (from the media
+// sequence; 0 if it never advanced).
+type PlaylistTiming struct {
+	Target       time.Duration
+	Segments     int64
+	Mean         time.Duration
+	StdDev       time.Duration
+	Min          time.Duration
+	Max          time.Duration
+	Pace         time.Duration
+	PaceSegments int64
+}
+
+// playlistTimingTolerance is how far segmenting may stray (pace from
+// #EXTINF, #EXTINF from its mean) before the summary flags it.
+const playlistTimingTolerance = 0.1
+
+// renderPlaylistTimings renders the segment durations and production pace
+// of the validated playlists, flagging pace drift and irregular segmenting.
+// Returns "" if no playlist had segments.
+func renderPlaylistTimings(timings map[string]PlaylistTiming) string {
+	urls := make([]string, 0, len(timings))
+	for url, t := range timings {
+		if t.Segments > 0 {
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) == 0 {
+		return ""
+	}
+	sort.Strings(urls)
+
+	var b, flags strings.Builder
+	fmt.Fprintf(&b, "\n  %-20s %8s %7s %15s %9s %13s\n", "Segment Timing", "Segments", "Target", "#EXTINF", "vs Target", "Pace")
+	for _, url := range urls {
+		t := timings[url]
+		name := playlistName(url)
+		pace := "-"
+		if t.PaceSegments > 0 && t.Mean > 0 {
+			drift := float64(t.Pace-t.Mean) / float64(t.Mean)
+			pace = fmt.Sprintf("%s (%+.0f%%)", formatSeconds(t.Pace), drift*100)
+			if math.Abs(drift) > playlistTimingTolerance {
+				behind := "faster"
+				if drift > 0 {
+					behind = "slower"
+				}
+				fmt.Fprintf(&flags, "  ⚠️  %s: segments appeared %.0f%% %s than their #EXTINF over %d segments\n",
+					name, math.Abs(drift)*100, behind, t.PaceSegments)
+			}
+		}
+		vsTarget := "-"
+		if t.Target > 0 {
+			vsTarget = fmt.Sprintf("%+.0f%%", float64(t.Mean-t.Target)/float64(t.Target)*100)
+		}
+		fmt.Fprintf(&b, "  %-20s %8s %7s %15s %9s %13s\n",
+			name, FormatNumber(t.Segments), formatSeconds(t.Target),
+			formatSeconds(t.Mean)+" ±"+formatSeconds(t.StdDev), vsTarget, pace)
+		if t.Mean > 0 {
+			if cv := float64(t.StdDev) / float64(t.Mean); cv > playlistTimingTolerance {
+				fmt.Fprintf(&flags, "  ⚠️  %s: irregular segmenting, #EXTINF %s to %s (±%.0f%%)\n",
+					name, formatSeconds(t.Min), formatSeconds(t.Max), cv*100)
+			}
+		}
+	}
+	if flags.Len() > 0 {
+		b.WriteString("\n")
+		b.WriteString(flags.String())
+	}
+	return b.String()
+}
+
+// playlistName shortens a playlist URL to its last path element for a
+// table column.
+func playlistName(url string) string {
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
+	name := url[strings.LastIndex(url, "/")+1:]
+	if len(name) > 20 {
+		name = "…" + name[len(name)-19:]
+	}
+	return name
+}
+
+// formatSeconds renders a segment duration: "6s", "5.97s".
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(math.Round(d.Seconds()*100)/100, 'f', -1, 64) + "s"
+}
+
+// renderTenants renders the per-tenant table of a -tenants run.
+// Returns "" without tenants.
+func renderTenants(tenants []TenantSummary, duration time.Duration) string {
+	if len(tenants) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                  Tenants\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  %-14s %7s %9s %9s %9s %9s %11s %7s\n",
+		"Tenant", "Clients", "Requests", "Avg", "Peak", "Quota", "Bytes", "Errors")
+	for _, t := range tenants {
+		avg := 0.0
+		if duration > 0 {
+			avg = float64(t.Requests) / duration.Seconds()
+		}
+		quota := "-"
+		if t.MaxRPS > 0 {
+			quota = FormatRate(t.MaxRPS)
+		}
+		fmt.Fprintf(&b, "  %-14s %7d %9s %9s %9s %9s %11s %7d\n",
+			t.Name, t.Clients, FormatNumber(t.Requests), FormatRate(avg), FormatRate(t.PeakRPS),
+			quota, FormatBytes(t.Bytes), t.Errors)
+	}
+	for _, t := range tenants {
+		if t.Throttles > 0 {
+			fmt.Fprintf(&b, "  %s: quota throttled clients %d time(s)", t.Name, t.Throttles)
+			if t.Held > 0 {
+				fmt.Fprintf(&b, ", %d held at exit", t.Held)
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderSLO renders the final standing of the -slo latency objective.
+func renderSLO(s *SLOStatus) string {
+	verdict, budget := "met", fmt.Sprintf("%.0f%% of error budget left", s.BudgetRemaining()*100)
+	if !s.Met() {
+		verdict, budget = "MISSED", fmt.Sprintf("error budget spent %.1fx", 1-s.BudgetRemaining())
+	}
+	return fmt.Sprintf("  Latency SLO:          %s, %.2f%% of %s segments under %s  (target %s; %s)\n",
+		verdict, s.Attainment()*100, FormatNumber(s.Segments), s.Threshold, formatTarget(s.Target), budget)
+}
+
+// renderGeos renders the per-geo breakdown of a -geo run, with the HTTP
+// status codes each geo got (a 403 for one geo only is usually a geo
+// block). Returns "" without -geo.
+func renderGeos(geos []GeoSummary) string {
+	if len(geos) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                               CDN Geo Mapping\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  %-12s %7s %9s %7s %9s %9s %9s %9s %9s\n",
+		"Geo", "Clients", "Requests", "Errors", "Seg P50", "Seg P95", "Seg P99", "Man P50", "Man P99")
+	for _, g := range geos {
+		errs := g.NetworkErrors
+		for _, n := range g.HTTPErrors {
+			errs += n
+		}
+		fmt.Fprintf(&b, "  %-12s %7d %9s %7d %9s %9s %9s %9s %9s\n",
+			g.Name, g.Clients, FormatNumber(g.Requests), errs,
+			FormatMs(g.SegmentP50), FormatMs(g.SegmentP95), FormatMs(g.SegmentP99),
+			FormatMs(g.ManifestP50), FormatMs(g.ManifestP99))
+	}
+	for _, g := range geos {
+		if len(g.HTTPErrors) == 0 {
+			continue
+		}
+		codes := make([]int, 0, len(g.HTTPErrors))
+		for code := range g.HTTPErrors {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		parts := make([]string, len(codes))
+		for i, code := range codes {
+			parts[i] = fmt.Sprintf("%d×%d", code, g.HTTPErrors[code])
+		}
+		fmt.Fprintf(&b, "  %s: HTTP %s\n", g.Name, strings.Join(parts, ", "))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderVariants renders the per-variant load of a -variant-mix run: the
+// share of the clients asked for next to the share of the requests and
+// bytes each variant caused. Returns "" without -variant-mix.
+func renderVariants(variants []VariantSummary) string {
+	if len(variants) == 0 {
+		return ""
+	}
+
+	var requests, bytes int64
+	for _, v := range variants {
+		requests += v.Requests
+		bytes += v.Bytes
+	}
+	share := func(n, total int64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(n) * 100 / float64(total)
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                 Variant Mix\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  %-8s %-10s %8s %7s %7s %9s %6s %11s %6s %6s\n",
+		"Variant", "Resolution", "Mbps", "Target", "Clients", "Requests", "Req %", "Data", "Data %", "Errors")
+	for _, v := range variants {
+		fmt.Fprintf(&b, "  %-8s %-10s %8.2f %6.1f%% %7d %9s %5.1f%% %11s %5.1f%% %6d\n",
+			v.Name, v.Resolution, float64(v.Bandwidth)/1e6, v.Target, v.Clients,
+			FormatNumber(v.Requests), share(v.Requests, requests),
+			FormatBytes(v.Bytes), share(v.Bytes, bytes), v.Errors)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderRenditions renders the requests per rendition. Returns "" without
+// -renditions.
+func renderRenditions(renditions []RenditionSummary) string {
+	if len(renditions) == 0 {
+		return ""
+	}
+
+	var total int64
+	for _, r := range renditions {
+		total += r.Segments + r.Playlists
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                  Renditions\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  %-16s %-16s %10s %10s %6s\n", "Rendition", "Name", "Segments", "Playlists", "Req %")
+	for _, r := range renditions {
+		share := 0.0
+		if total > 0 {
+			share = float64(r.Segments+r.Playlists) * 100 / float64(total)
+		}
+		fmt.Fprintf(&b, "  %-16s %-16s %10s %10s %5.1f%%\n",
+			r.Name, r.Label, FormatNumber(r.Segments), FormatNumber(r.Playlists), share)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderCapture renders where the -pcap-dir captures were written.
+// Returns "" without -pcap-dir.
+func renderCapture(c *CaptureSummary) string {
+	if c == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                              Packet Capture\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Directory:            %s\n", c.Dir)
+	if c.Error != "" {
+		fmt.Fprintf(&b, "  Not captured:         %s\n\n", c.Error)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  Origin Ring:          %d file(s), %s\n", c.RingFiles, FormatBytes(c.RingBytes))
+	for _, cl := range c.Clients {
+		label := fmt.Sprintf("client %d:", cl.ClientID)
+		switch {
+		case cl.Connections == 0:
+			fmt.Fprintf(&b, "  %-21s no connections seen\n", label)
+		case cl.File == "":
+			fmt.Fprintf(&b, "  %-21s %d connection(s), in the ring as %s\n", label, cl.Connections, cl.Filter)
+		default:
+			fmt.Fprintf(&b, "  %-21s %d connection(s), %s\n", label, cl.Connections, cl.File)
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderSockets renders the kernel's view of the clients' connections.
+// Returns "" without -socket-stats.
+func renderSockets(s *SocketSummary) string {
+	if s == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                            Kernel TCP Sockets\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	if s.Error != "" {
+		fmt.Fprintf(&b, "  Not sampled:          %s\n\n", s.Error)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  Samples:              %s (peak %d connections)\n", FormatNumber(int64(s.Samples)), s.PeakConnections)
+	fmt.Fprintf(&b, "  RTT:                  P50 %s, P95 %s, P99 %s (min %s)\n",
+		FormatMs(s.RTTP50), FormatMs(s.RTTP95), FormatMs(s.RTTP99), FormatMs(s.MinRTT))
+	fmt.Fprintf(&b, "  Bytes Received:       %s\n", FormatBytes(s.BytesReceived))
+	fmt.Fprintf(&b, "  Out-of-Order Packets: %s\n", FormatNumber(s.OutOfOrder))
+	fmt.Fprintf(&b, "  Retransmits Sent:     %s\n", FormatNumber(s.Retransmits))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderFlaps renders how clients caught up after -flap-interval network
+// flaps. Returns "" without -flap-interval.
+func renderFlaps(f *FlapSummary) string {
+	if f == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                              Network Flaps\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Flaps:                %s (every %s, %s pause)\n", FormatNumber(f.Flaps), f.Interval, f.Duration)
+	fmt.Fprintf(&b, "  Client Pauses:        %s\n", FormatNumber(f.Pauses))
+	if f.Pauses > f.Unrecovered {
+		fmt.Fprintf(&b, "  Catch-up:             P50 %s, P95 %s, P99 %s (resume to next segment)\n",
+			FormatMs(f.CatchUpP50), FormatMs(f.CatchUpP95), FormatMs(f.CatchUpP99))
+	}
+	if f.Unrecovered > 0 {
+		fmt.Fprintf(&b, "  Not Recovered:        %s (no segment within a minute of resuming)\n", FormatNumber(f.Unrecovered))
+	}
+	fmt.Fprintf(&b, "  Sequence Skips:       %s\n", FormatNumber(f.SequenceSkips))
+	fmt.Fprintf(&b, "  Expired Segments:     %s (left the playlist while paused)\n", FormatNumber(f.SegmentsExpired))
+	fmt.Fprintf(&b, "  Skipped Segments:     %s (failed after retries)\n", FormatNumber(f.SegmentsSkipped))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// maxBurstWindows is how many burst windows the exit summary lists.
+const maxBurstWindows = 10
+
+// renderBursts renders the -burst windows, so the latency and errors of
+// the run can be matched against them. Returns "" without -burst.
+func renderBursts(s *BurstSummary) string {
+	if s == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                              Traffic Bursts\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	for _, sched := range s.Schedules {
+		fmt.Fprintf(&b, "  Schedule:             %s\n", sched)
+	}
+	fmt.Fprintf(&b, "  Bursts:               %s\n", FormatNumber(int64(len(s.Windows))))
+	for i, w := range s.Windows {
+		if i == maxBurstWindows {
+			fmt.Fprintf(&b, "    ... and %d more (see -save-run events)\n", len(s.Windows)-maxBurstWindows)
+			break
+		}
+		note := ""
+		if w.CutShort {
+			note = " (cut short)"
+		}
+		fmt.Fprintf(&b, "    %s - %s  +%d clients%s\n",
+			FormatDuration(w.Start), FormatDuration(w.End), w.Clients, note)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderSessions renders the -session-duration viewer churn over a run of
+// duration. Returns "" without -session-duration.
+func renderSessions(s *SessionSummary, duration time.Duration) string {
+	if s == nil {
+		return ""
+	}
+	perMinute := func(n int64) string {
+		if duration < time.Second {
+			return ""
+		}
+		return fmt.Sprintf(" (%.1f/min)", float64(n)/duration.Minutes())
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                              Viewer Sessions\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Distribution:         %s\n", s.Distribution)
+	fmt.Fprintf(&b, "  Arrivals:             %s%s\n", FormatNumber(s.Started), perMinute(s.Started))
+	fmt.Fprintf(&b, "  Departures:           %s%s\n", FormatNumber(s.Ended), perMinute(s.Ended))
+	if s.Ended > 0 {
+		fmt.Fprintf(&b, "  Session Length:       mean %s, min %s, max %s (ended sessions)\n",
+			s.MeanLength.Round(time.Second), s.MinLength.Round(time.Second), s.MaxLength.Round(time.Second))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderVODStarts renders where in the asset clients started, per tenth
+// of it. Returns "" without -vod-start.
+func renderVODStarts(v *VODStartSummary) string {
+	if v == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                            VOD Start Positions\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Distribution:         %s (asset %s)\n", v.Distribution, v.Asset.Round(time.Second))
+	fmt.Fprintf(&b, "  Client Starts:        %s\n", FormatNumber(v.Starts))
+	if v.Starts > 0 {
+		for i, n := range v.Tenths {
+			pct := float64(n) / float64(v.Starts) * 100
+			fmt.Fprintf(&b, "    %3d%% - %3d%%:        %6s  %5.1f%%  %s\n",
+				i*10, (i+1)*10, FormatNumber(n), pct, strings.Repeat("█", int(pct/2+0.5)))
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderVODCompletion renders how the plays of the VOD asset went.
+// Returns "" without -vod-end.
+func renderVODCompletion(v *VODCompletionSummary) string {
+	if v == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                               VOD Completion\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Mode:                 %s (asset %s)\n", v.Mode, v.Asset.Round(time.Second))
+	fmt.Fprintf(&b, "  Plays:                %s", FormatNumber(v.Plays))
+	if v.CutShort > 0 {
+		fmt.Fprintf(&b, " (%s still playing at the end)", FormatNumber(v.CutShort))
+	}
+	b.WriteString("\n")
+	if ended := v.Plays - v.CutShort; ended > 0 {
+		fmt.Fprintf(&b, "  Finished:             %s of %s ended (%.1f%%)\n",
+			FormatNumber(v.Finished), FormatNumber(ended), v.CompletionRatio()*100)
+		if early := ended - v.Finished; early > 0 {
+			fmt.Fprintf(&b, "  Ended Early:          %s (failures, restarts or session ends)\n", FormatNumber(early))
+		}
+	}
+	fmt.Fprintf(&b, "  Clients Finished:     %s\n", FormatNumber(int64(v.Clients)))
+	if v.Finished > 0 {
+		fmt.Fprintf(&b, "  Completion Time:      mean %s, min %s, max %s\n",
+			v.MeanTime.Round(time.Second), v.MinTime.Round(time.Second), v.MaxTime.Round(time.Second))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderClientCPU renders the clients' CPU use against -client-cpu-limit.
+// Returns "" if it wasn't checked.
+func renderClientCPU(c *ClientCPUSummary) string {
+	if c == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                                Client CPU\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Mean per Client:      %.1f%% of a core\n", c.Mean)
+	fmt.Fprintf(&b, "  Peak:                 %.1f%% (limit %.0f%%)\n", c.Peak, c.Limit)
+	if c.Flagged > 0 {
+		fmt.Fprintf(&b, "  ⚠️  %s client process(es) over the limit: FFmpeg seems to be decoding, not just demuxing\n", FormatNumber(c.Flagged))
+		if c.Failed {
+			b.WriteString("      The run was stopped (-client-cpu-policy fail)\n")
+		}
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderMemoryGuard renders the swarm's memory use against -max-memory.
+func renderMemoryGuard(m *MemoryGuardSummary) string {
+	if m == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                               Memory Guard\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Peak RSS:             %s (limit %s)\n", FormatBytes(m.Peak), FormatBytes(m.Limit))
+	if len(m.Shed) == 0 {
+		b.WriteString("  Nothing shed: the swarm stayed under -max-memory\n\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  ⚠️  %d feature(s) shed over the limit; results below lack them from then on\n", len(m.Shed))
+	for _, d := range m.Shed {
+		fmt.Fprintf(&b, "      %-10s %-20s at RSS %s: %s\n", FormatDuration(d.At), d.Feature, FormatBytes(d.RSS), d.Description)
+	}
+	if m.Exhausted {
+		b.WriteString("  ⚠️  Still over the limit with nothing left to shed: run fewer clients\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderRefreshOverride renders the -playlist-refresh section. Returns ""
+// without it.
+func renderRefreshOverride(r *RefreshOverrideSummary) string {
+	if r == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                          Playlist Refresh Override\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Refresh Interval:     %s (%gx the %s target duration)\n",
+		time.Duration(float64(r.Target)*r.Factor), r.Factor, r.Target)
+	fmt.Fprintf(&b, "  Added Fetches:        %s (%s failed), between FFmpeg's own reloads\n",
+		FormatNumber(r.Fetches), FormatNumber(r.Errors))
+	if r.Conditional {
+		rate := 0.0
+		if r.Fetches > 0 {
+			rate = float64(r.NotModified) * 100 / float64(r.Fetches)
+		}
+		fmt.Fprintf(&b, "  Not Modified (304):   %s (%.1f%% of the fetches, conditional)\n", FormatNumber(r.NotModified), rate)
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderTokens renders the session token section of a -token-url run.
+// Returns "" without -token-url.
+func renderTokens(t *TokenSummary) string {
+	if t == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                              Session Tokens\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Token Fetches:        %s (%s failed)\n", FormatNumber(t.Fetches), FormatNumber(t.FetchFailures))
+	fmt.Fprintf(&b, "  HTTP 401:             %s\n", FormatNumber(t.HTTP401))
+	if t.Expiries > 0 {
+		fmt.Fprintf(&b, "  Token Expiries:       %s\n", FormatNumber(t.Expiries))
+	}
+	fmt.Fprintf(&b, "  Re-auths:             %s\n", FormatNumber(t.Reauths))
+	if t.Reauths > 0 {
+		fmt.Fprintf(&b, "  Re-auth Latency:      P50 %s, P95 %s, P99 %s (401 or expiry to running again)\n",
+			FormatMs(t.ReauthP50), FormatMs(t.ReauthP95), FormatMs(t.ReauthP99))
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderCoolDown renders the -cool-down origin recovery result.
+// Returns "" if no cool-down ran.
+func renderCoolDown(cd *CoolDownSummary) string {
+	if cd == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n")
+	b.WriteString("                            Cool-down Recovery\n")
+	b.WriteString("───────────────────────────────────────────────────────────────────────────────\n\n")
+
+	fmt.Fprintf(&b, "  Cool-down:            %s", FormatDuration(cd.Elapsed))
+	if cd.Elapsed < cd.Duration {
+		fmt.Fprintf(&b, " of %s (interrupted)", FormatDuration(cd.Duration))
+	}
+	b.WriteString("\n")
+	if cd.Baseline > 0 {
+		fmt.Fprintf(&b, "  Baseline Latency:     %s (before load)\n", FormatMs(cd.Baseline))
+	} else {
+		b.WriteString("  Baseline Latency:     unknown (pre-load probes failed)\n")
+	}
+	if cd.Peak > 0 {
+		fmt.Fprintf(&b, "  Peak / Final:         %s / %s\n", FormatMs(cd.Peak), FormatMs(cd.Final))
+	}
+	fmt.Fprintf(&b, "  Probes:               %d (%d failed)\n", cd.Probes, cd.Failures)
+	switch {
+	case cd.Recovered:
+		fmt.Fprintf(&b, "  Recovered After:      %s\n", cd.RecoveryTime.Round(time.Second))
+	case cd.Baseline > 0:
+		b.WriteString("  Recovered After:      not within cool-down\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
 // renderFootnotes adds diagnostic info that doesn't belong in main metrics.
 func renderFootnotes(stats *AggregatedStats) string {
 	var footnotes []string
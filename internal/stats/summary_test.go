@@ -168,9 +168,9 @@ func TestExitCodeLabel(t *testing.T) {
 
 func TestFormatExitSummary_NilStats(t *testing.T) {
 	cfg := SummaryConfig{
-		TargetClients: 100,
-		Duration:      5 * time.Minute,
-		MetricsAddr:   "localhost:9090",
+		TargetClients:    100,
+		Duration:         5 * time.Minute,
+		MetricsEndpoints: []string{"http://localhost:9090/metrics"},
 	}
 
 	result := FormatExitSummary(nil, cfg)
@@ -205,9 +205,9 @@ func TestFormatExitSummary_BasicStats(t *testing.T) {
 	}
 
 	cfg := SummaryConfig{
-		TargetClients: 50,
-		Duration:      10 * time.Minute,
-		MetricsAddr:   "localhost:9090",
+		TargetClients:    50,
+		Duration:         10 * time.Minute,
+		MetricsEndpoints: []string{"http://localhost:9090/metrics"},
 	}
 
 	result := FormatExitSummary(stats, cfg)
@@ -302,6 +302,11 @@ func TestFormatExitSummary_WithDegradation(t *testing.T) {
 		TotalLinesDropped: 5000,
 		ClientsWithDrops:  20,
 		PeakDropRate:      0.05, // 5%
+
+		ProgressLinesRead:    40000,
+		ProgressLinesDropped: 4000,
+		StderrLinesRead:      60000,
+		StderrLinesDropped:   1000,
 	}
 
 	cfg := SummaryConfig{
@@ -323,6 +328,73 @@ func TestFormatExitSummary_WithDegradation(t *testing.T) {
 	if !strings.Contains(result, "--stats-buffer") {
 		t.Error("missing buffer suggestion")
 	}
+	if !strings.Contains(result, "progress 4.0K of 40.0K lines, stderr 1.0K of 60.0K") {
+		t.Errorf("missing per-path drops in:\n%s", result)
+	}
+}
+
+func TestFormatExitSummary_MetricsEndpoints(t *testing.T) {
+	cfg := SummaryConfig{
+		TargetClients:    10,
+		Duration:         time.Minute,
+		MetricsEndpoints: []string{"http://127.0.0.1:17091/metrics", "unix socket /run/swarm.sock (/metrics)"},
+	}
+
+	for _, result := range []string{
+		FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg),
+		FormatExitSummary(nil, cfg),
+	} {
+		for _, want := range []string{
+			"Metrics endpoint was: http://127.0.0.1:17091/metrics\n",
+			"Metrics endpoint was: unix socket /run/swarm.sock (/metrics)\n",
+		} {
+			if !strings.Contains(result, want) {
+				t.Errorf("summary missing %q", want)
+			}
+		}
+	}
+}
+
+func TestFormatExitSummary_LinesTruncated(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if strings.Contains(result, "Lines truncated") {
+		t.Error("truncation note shown with no truncated lines")
+	}
+
+	result = FormatExitSummary(&AggregatedStats{TotalClients: 10, TotalLinesTruncated: 42}, cfg)
+	if !strings.Contains(result, "Lines truncated: 42 over the -stats-max-line limit") {
+		t.Errorf("missing truncation note:\n%s", result)
+	}
+}
+
+func TestFormatExitSummary_ParserPanics(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if strings.Contains(result, "Parser panics") {
+		t.Error("parser panic warning shown with no panics")
+	}
+
+	result = FormatExitSummary(&AggregatedStats{TotalClients: 10, TotalParserPanics: 3}, cfg)
+	if !strings.Contains(result, "Parser panics: 3 lines skipped") {
+		t.Errorf("missing parser panic warning:\n%s", result)
+	}
+}
+
+func TestFormatExitSummary_InvalidUTF8(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if strings.Contains(result, "Invalid UTF-8") {
+		t.Error("invalid UTF-8 note shown with none replaced")
+	}
+
+	result = FormatExitSummary(&AggregatedStats{TotalClients: 10, TotalInvalidUTF8: 7}, cfg)
+	if !strings.Contains(result, "Invalid UTF-8: 7 byte runs") {
+		t.Errorf("missing invalid UTF-8 note:\n%s", result)
+	}
 }
 
 func TestFormatExitSummary_WithDrift(t *testing.T) {
@@ -374,6 +446,24 @@ func TestFormatExitSummary_WithUptime(t *testing.T) {
 	}
 }
 
+func TestFormatExitSummary_SampledUptime(t *testing.T) {
+	cfg := SummaryConfig{
+		TargetClients: 10,
+		Duration:      time.Hour,
+		UptimeP50:     30 * time.Second,
+		UptimeExits:   250000,
+	}
+
+	if result := FormatExitSummary(&AggregatedStats{}, cfg); strings.Contains(result, "sampled from") {
+		t.Error("sampled note shown for complete history")
+	}
+
+	cfg.UptimeSampled = true
+	if result := FormatExitSummary(&AggregatedStats{}, cfg); !strings.Contains(result, "sampled from 250000 exits") {
+		t.Error("missing sampled note")
+	}
+}
+
 func TestFormatExitSummary_WithExitCodes(t *testing.T) {
 	stats := &AggregatedStats{
 		TotalClients: 10,
@@ -402,6 +492,193 @@ func TestFormatExitSummary_WithExitCodes(t *testing.T) {
 	}
 }
 
+func TestClassifyExit(t *testing.T) {
+	tests := []struct {
+		code     int
+		stopping bool
+		want     string
+	}{
+		{0, false, ExitReasonStreamEnded},
+		{1, false, ExitReasonError},
+		{137, false, ExitReasonKilled},
+		{143, false, ExitReasonTerminated},
+		{134, false, ExitReasonSignal}, // SIGABRT
+		{1, true, ExitReasonShutdown},
+		{143, true, ExitReasonShutdown},
+	}
+	for _, tt := range tests {
+		if got := ClassifyExit(tt.code, tt.stopping); got != tt.want {
+			t.Errorf("ClassifyExit(%d, %v) = %q, want %q", tt.code, tt.stopping, got, tt.want)
+		}
+	}
+}
+
+func TestFormatExitSummary_WithExitReasons(t *testing.T) {
+	cfg := SummaryConfig{
+		TargetClients: 10,
+		Duration:      time.Minute,
+		ExitReasons: map[string]int{
+			ExitReasonShutdown: 10,
+			ExitReasonError:    30,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+
+	if !strings.Contains(result, "Exit Reasons") {
+		t.Fatal("missing exit reasons section")
+	}
+	errIdx := strings.Index(result, "ffmpeg error")
+	shutdownIdx := strings.Index(result, "shutdown ")
+	if errIdx < 0 || shutdownIdx < 0 || errIdx > shutdownIdx {
+		t.Errorf("reasons should be ordered by count:\n%s", result)
+	}
+	if !strings.Contains(result, "(75.0%)") {
+		t.Error("missing reason share")
+	}
+}
+
+func TestRenderExitReasons_StopSignals(t *testing.T) {
+	tests := []struct {
+		name        string
+		reasons     map[string]int
+		signals     map[string]int
+		want        []string
+		wantMissing []string
+	}{
+		{
+			name:        "clean stops",
+			reasons:     map[string]int{ExitReasonShutdown: 10},
+			signals:     map[string]int{"SIGTERM": 10},
+			want:        []string{"Exit Reasons", "Stop signals sent:", "SIGTERM 10"},
+			wantMissing: []string{"were killed"},
+		},
+		{
+			name:    "escalated",
+			reasons: map[string]int{ExitReasonShutdown: 10},
+			signals: map[string]int{"SIGKILL": 2, "SIGINT": 10},
+			want:    []string{"SIGINT 10, SIGKILL 2", "2 client(s) ignored the stop signal"},
+		},
+		{
+			name:    "signals only",
+			signals: map[string]int{"SIGTERM": 1},
+			want:    []string{"Exit Reasons", "SIGTERM 1"},
+		},
+		{
+			name:        "nothing",
+			wantMissing: []string{"Exit Reasons"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderExitReasons(tt.reasons, tt.signals)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("missing %q in:\n%s", want, got)
+				}
+			}
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(got, missing) {
+					t.Errorf("unexpected %q in:\n%s", missing, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatExitSummary_TopErrorCodes(t *testing.T) {
+	stats := &AggregatedStats{
+		TotalClients: 10,
+		TotalHTTPErrors: map[int]int64{
+			400: 1, 403: 2, 404: 50, 410: 1, 500: 3, 502: 4, 503: 100,
+		},
+	}
+
+	result := FormatExitSummary(stats, SummaryConfig{TargetClients: 10, Duration: time.Minute})
+
+	if i503, i404 := strings.Index(result, "HTTP 503"), strings.Index(result, "HTTP 404"); i503 > i404 {
+		t.Error("HTTP 503 (most frequent) should be listed first")
+	}
+	if strings.Contains(result, "HTTP 400") || strings.Contains(result, "HTTP 410") {
+		t.Error("codes beyond the top 5 should be folded")
+	}
+	if !strings.Contains(result, "(2 more codes):       2") {
+		t.Errorf("missing folded code count:\n%s", result)
+	}
+}
+
+func TestFormatExitSummary_PeakThroughput(t *testing.T) {
+	stats := &AggregatedStats{TotalClients: 1, PeakThroughputRate: 12_500_000}
+
+	result := FormatExitSummary(stats, SummaryConfig{TargetClients: 1, Duration: time.Minute})
+
+	if !strings.Contains(result, "Peak Throughput:      12.50 MB/s  (100.0 Mbps)") {
+		t.Errorf("missing peak throughput:\n%s", result)
+	}
+}
+
+func TestFormatExitSummary_WithLayers(t *testing.T) {
+	ds := &DebugStatsAggregate{
+		SegmentsDownloaded: 1500,
+		SegmentsFailed:     3,
+		PlaylistsRefreshed: 400,
+		SegmentWallTimeP50: 80 * time.Millisecond,
+		SegmentWallTimeP95: 180 * time.Millisecond,
+		SegmentWallTimeP99: 250 * time.Millisecond,
+		SegmentDownloadP50: 20 * time.Millisecond,
+		SegmentDownloadP95: 60 * time.Millisecond,
+		SegmentDownloadP99: 90 * time.Millisecond,
+		SegmentGapAvgMs:    1900,
+		HTTPOpenCount:      1900,
+		HTTP5xxCount:       3,
+		TCPSuccessCount:    40,
+		TCPRefusedCount:    2,
+		TCPHealthRatio:     0.95,
+		HostRequests: map[string]int64{
+			"edge1.cdn.example.com": 1500,
+			"edge2.cdn.example.com": 400,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, SummaryConfig{
+		TargetClients: 10,
+		Duration:      time.Minute,
+		Debug:         ds,
+	})
+
+	for _, want := range []string{
+		"HLS Layer", "HTTP Layer", "TCP Layer", "Top Hosts",
+		"1.5K downloaded, 3 failed",
+		"P50 80 ms  P95 180 ms  P99 250 ms",
+		"Segment Download:     P50 20 ms  P95 60 ms  P99 90 ms  (gap avg 1900 ms)",
+		"4xx / 5xx:            0 / 3",
+		"40 ok, 2 refused",
+		"Health:               95.0%",
+		"Connection Mode:      keep-alive",
+		"Connections/sec:      0.67  (0.02 per request)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if i1, i2 := strings.Index(result, "edge1"), strings.Index(result, "edge2"); i1 > i2 {
+		t.Error("hosts should be ordered by requests")
+	}
+	if !strings.Contains(result, "(78.9%)") {
+		t.Error("missing host share")
+	}
+}
+
+func TestFormatExitSummary_NoLayersWithoutDebugStats(t *testing.T) {
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 1}, SummaryConfig{TargetClients: 1})
+	for _, section := range []string{"HLS Layer", "Top Hosts", "Exit Reasons"} {
+		if strings.Contains(result, section) {
+			t.Errorf("unexpected %q section", section)
+		}
+	}
+}
+
 func TestFormatExitSummary_WithLifecycle(t *testing.T) {
 	stats := &AggregatedStats{
 		TotalClients: 10,
@@ -425,6 +702,59 @@ func TestFormatExitSummary_WithLifecycle(t *testing.T) {
 	if !strings.Contains(result, "Total Restarts:       5") {
 		t.Error("missing total restarts")
 	}
+	if strings.Contains(result, "Quarantines") {
+		t.Error("quarantines shown without any")
+	}
+
+	cfg.Quarantines = 2
+	if result := FormatExitSummary(stats, cfg); !strings.Contains(result, "Quarantines:          2") {
+		t.Error("missing quarantines")
+	}
+}
+
+func TestFormatExitSummary_WithPlaylistViolations(t *testing.T) {
+	cfg := SummaryConfig{
+		TargetClients:      10,
+		Duration:           time.Minute,
+		PlaylistValidation: true,
+		PlaylistViolations: map[string]int64{
+			"playlist_stale":           3,
+			"media_sequence_regressed": 1,
+		},
+		PlaylistFetchErrors: 2,
+	}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+
+	if !strings.Contains(result, "Playlist Compliance") {
+		t.Error("missing playlist compliance section")
+	}
+	if !strings.Contains(result, "playlist_stale             3") {
+		t.Error("missing stale count")
+	}
+	if strings.Index(result, "media_sequence_regressed") > strings.Index(result, "playlist_stale") {
+		t.Error("violation kinds not sorted")
+	}
+	if !strings.Contains(result, "(reload failures)          2") {
+		t.Error("missing reload failures")
+	}
+
+	// Shown even without stats collection
+	if !strings.Contains(FormatExitSummary(nil, cfg), "Playlist Compliance") {
+		t.Error("missing playlist compliance section in basic summary")
+	}
+}
+
+func TestFormatExitSummary_PlaylistValidationClean(t *testing.T) {
+	result := FormatExitSummary(&AggregatedStats{}, SummaryConfig{PlaylistValidation: true})
+	if !strings.Contains(result, "No violations observed") {
+		t.Error("missing clean validation line")
+	}
+
+	result = FormatExitSummary(&AggregatedStats{}, SummaryConfig{})
+	if strings.Contains(result, "Playlist Compliance") {
+		t.Error("playlist compliance section shown without validation")
+	}
 }
 
 func TestFormatExitSummary_WithUnknownURLs(t *testing.T) {
@@ -552,14 +882,14 @@ func BenchmarkFormatExitSummary(b *testing.B) {
 	}
 
 	cfg := SummaryConfig{
-		TargetClients: 100,
-		Duration:      10 * time.Minute,
-		MetricsAddr:   "localhost:9090",
-		TotalStarts:   120,
-		TotalRestarts: 20,
-		UptimeP50:     5 * time.Minute,
-		UptimeP95:     9 * time.Minute,
-		UptimeP99:     10 * time.Minute,
+		TargetClients:    100,
+		Duration:         10 * time.Minute,
+		MetricsEndpoints: []string{"http://localhost:9090/metrics"},
+		TotalStarts:      120,
+		TotalRestarts:    20,
+		UptimeP50:        5 * time.Minute,
+		UptimeP95:        9 * time.Minute,
+		UptimeP99:        10 * time.Minute,
 	}
 
 	b.ResetTimer()
@@ -579,3 +909,718 @@ func BenchmarkFormatBytes(b *testing.B) {
 		_ = FormatBytes(1234567890)
 	}
 }
+
+func TestFormatExitSummary_RefreshOverride(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg), "Playlist Refresh Override") {
+		t.Error("override section shown without -playlist-refresh")
+	}
+
+	cfg.RefreshOverride = &RefreshOverrideSummary{Factor: 0.5, Target: 6 * time.Second, Fetches: 1200, Errors: 3}
+	for _, result := range []string{
+		FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg),
+		FormatExitSummary(nil, cfg), // Works without stats
+	} {
+		for _, want := range []string{
+			"Playlist Refresh Override",
+			"Refresh Interval:     3s (0.5x the 6s target duration)",
+			"Added Fetches:        1.2K (3 failed), between FFmpeg's own reloads",
+		} {
+			if !strings.Contains(result, want) {
+				t.Errorf("missing %q", want)
+			}
+		}
+	}
+	if strings.Contains(FormatExitSummary(nil, cfg), "Not Modified") {
+		t.Error("304s shown without -conditional-playlists")
+	}
+
+	cfg.RefreshOverride.Conditional = true
+	cfg.RefreshOverride.NotModified = 900
+	if want := "Not Modified (304):   900 (75.0% of the fetches, conditional)"; !strings.Contains(FormatExitSummary(nil, cfg), want) {
+		t.Errorf("missing %q", want)
+	}
+}
+
+func TestFormatExitSummary_CoolDown(t *testing.T) {
+	cfg := SummaryConfig{
+		TargetClients: 10,
+		Duration:      time.Minute,
+		CoolDown: &CoolDownSummary{
+			Duration:     2 * time.Minute,
+			Elapsed:      2 * time.Minute,
+			Baseline:     40 * time.Millisecond,
+			Peak:         900 * time.Millisecond,
+			Final:        45 * time.Millisecond,
+			Probes:       120,
+			Failures:     2,
+			Recovered:    true,
+			RecoveryTime: 17 * time.Second,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	for _, want := range []string{
+		"Cool-down Recovery",
+		"Baseline Latency:     40 ms",
+		"Peak / Final:         900 ms / 45 ms",
+		"Probes:               120 (2 failed)",
+		"Recovered After:      17s",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	// Interrupted, never recovered; also shown without detailed stats
+	cfg.CoolDown.Elapsed = 30 * time.Second
+	cfg.CoolDown.Recovered = false
+	result = FormatExitSummary(nil, cfg)
+	for _, want := range []string{"00:00:30 of 00:02:00 (interrupted)", "not within cool-down"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestFormatExitSummary_NoKeepAlive(t *testing.T) {
+	ds := &DebugStatsAggregate{HTTPOpenCount: 600, TCPSuccessCount: 600, TCPHealthRatio: 1}
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, SummaryConfig{
+		TargetClients: 10,
+		Duration:      time.Minute,
+		Debug:         ds,
+		NoKeepAlive:   true,
+	})
+	for _, want := range []string{
+		"Connection Mode:      new connection per request (-no-keepalive)",
+		"Connections/sec:      10.00  (1.00 per request)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestFormatExitSummary_Prefetch(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute, Debug: &DebugStatsAggregate{}}
+	if result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg); strings.Contains(result, "Segment Prefetch:") {
+		t.Error("Segment Prefetch shown without -prefetch")
+	}
+
+	cfg.Prefetch = "1"
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if want := "Segment Prefetch:     next segment on a second connection (-prefetch 1)"; !strings.Contains(result, want) {
+		t.Errorf("missing %q", want)
+	}
+}
+
+func TestFormatExitSummary_SlowRequests(t *testing.T) {
+	ds := &DebugStatsAggregate{SlowSegments: 12, SlowManifests: 3}
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute, Debug: ds}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if strings.Contains(result, "Slow Requests:") {
+		t.Error("Slow Requests shown without -slow-request-log")
+	}
+
+	cfg.SlowRequest = time.Second
+	result = FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if want := "Slow Requests:        12 segments, 3 manifests  (>= 1s)"; !strings.Contains(result, want) {
+		t.Errorf("missing %q", want)
+	}
+}
+
+func TestFormatExitSummary_SLO(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute, Debug: &DebugStatsAggregate{}}
+	if result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg); strings.Contains(result, "Latency SLO:") {
+		t.Error("Latency SLO shown without -slo")
+	}
+
+	cfg.SLO = &SLOStatus{Target: 0.99, Threshold: time.Second, Segments: 2000, Misses: 5}
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if want := "Latency SLO:          met, 99.75% of 2.0K segments under 1s  (target 99%; 75% of error budget left)"; !strings.Contains(result, want) {
+		t.Errorf("missing %q", want)
+	}
+
+	cfg.SLO.Misses = 60
+	result = FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if want := "Latency SLO:          MISSED, 97.00% of 2.0K segments under 1s  (target 99%; error budget spent 3.0x)"; !strings.Contains(result, want) {
+		t.Errorf("missing %q", want)
+	}
+}
+
+func TestFormatExitSummary_Pacing(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute}
+	if result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg); strings.Contains(result, "Pacing:") {
+		t.Error("Pacing shown without a model")
+	}
+
+	cfg.Pacing = "fast (eu=buffer:10s)"
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if want := "Pacing:                 fast (eu=buffer:10s)\n"; !strings.Contains(result, want) {
+		t.Errorf("missing %q", want)
+	}
+}
+
+func TestFormatExitSummary_PlaylistRefresh(t *testing.T) {
+	cfg := SummaryConfig{TargetClients: 10, Duration: time.Minute, Debug: &DebugStatsAggregate{}}
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	if strings.Contains(result, "Refresh Interval:") || strings.Contains(result, "Refresh Storms:") {
+		t.Error("refresh lines shown without refreshes")
+	}
+
+	cfg.Debug.PlaylistRefresh = testRefreshes
+	result = FormatExitSummary(&AggregatedStats{TotalClients: 10}, cfg)
+	for _, want := range []string{
+		"Refresh Interval:     10% early  70% on time  10% late  10% very late  (mean 1.10x target)",
+		"Refresh Storms:       2  (>= 17 refreshes in 100ms; peak 40 at ",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestFormatExitSummary_HotSpots(t *testing.T) {
+	ds := &DebugStatsAggregate{
+		SlowestSegments: []SlowSegment{
+			{URL: "http://edge2.example.com/seg00042.ts", ClientID: 7, WallTime: 1800 * time.Millisecond},
+		},
+		HottestSegments: []URLCount{
+			{URL: "http://edge1.example.com/seg00041.ts", Requests: 1200},
+		},
+	}
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 10}, SummaryConfig{TargetClients: 10, Debug: ds})
+	for _, want := range []string{
+		"Segment Hot Spots",
+		"1800 ms  client 7      http://edge2.example.com/seg00042.ts",
+		"1.2K  http://edge1.example.com/seg00041.ts",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	result = FormatExitSummary(&AggregatedStats{TotalClients: 10}, SummaryConfig{TargetClients: 10, Debug: &DebugStatsAggregate{}})
+	if strings.Contains(result, "Segment Hot Spots") {
+		t.Error("hot spots shown without data")
+	}
+}
+
+func TestFormatExitSummary_Tenants(t *testing.T) {
+	cfg := SummaryConfig{
+		TargetClients: 30,
+		Duration:      100 * time.Second,
+		Tenants: []TenantSummary{
+			{Name: "alpha", Clients: 20, Held: 2, MaxRPS: 50, PeakRPS: 58, Requests: 4800, Throttles: 3},
+			{Name: "beta", Clients: 10, PeakRPS: 21, Requests: 2000, Errors: 4},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{TotalClients: 30}, cfg)
+	for _, want := range []string{"Tenants", "alpha", "beta", "alpha: quota throttled clients 3 time(s), 2 held at exit"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "beta: quota") {
+		t.Error("throttle line shown for a tenant that was never throttled")
+	}
+
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Tenants") {
+		t.Error("tenants section shown without -tenants")
+	}
+}
+
+func TestFormatExitSummary_PlaylistEncodings(t *testing.T) {
+	cfg := SummaryConfig{
+		PlaylistValidation: true,
+		PlaylistEncodings: map[string]PlaylistEncoding{
+			"gzip":     {Fetches: 100, WireBytes: 20_000, DecodedBytes: 100_000},
+			"br":       {Fetches: 3, WireBytes: 600, DecodeFailures: 3},
+			"identity": {Fetches: 10, WireBytes: 10_000, DecodedBytes: 10_000},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	lines := make(map[string]string)
+	for _, line := range strings.Split(result, "\n") {
+		if f := strings.Fields(line); len(f) > 0 {
+			lines[f[0]] = line
+		}
+	}
+	if !strings.Contains(lines["gzip"], "80%") {
+		t.Errorf("gzip row = %q, want 80%% saved", lines["gzip"])
+	}
+	if !strings.HasSuffix(lines["br"], " 3") {
+		t.Errorf("br row = %q, want 3 decode failures", lines["br"])
+	}
+	if !strings.Contains(lines["identity"], " - ") {
+		t.Errorf("identity row = %q, want no saving", lines["identity"])
+	}
+	if strings.Index(result, "br ") > strings.Index(result, "gzip ") {
+		t.Error("encodings not sorted")
+	}
+}
+
+func TestFormatExitSummary_PlaylistTimings(t *testing.T) {
+	cfg := SummaryConfig{
+		PlaylistValidation: true,
+		PlaylistTimings: map[string]PlaylistTiming{
+			"http://origin/live/720p.m3u8": {
+				Target: 2 * time.Second, Segments: 150, Mean: 2 * time.Second,
+				Min: 2 * time.Second, Max: 2 * time.Second, Pace: 2010 * time.Millisecond, PaceSegments: 147,
+			},
+			"http://origin/live/1080p.m3u8?token=x": {
+				Target: 6 * time.Second, Segments: 40, Mean: 4 * time.Second, StdDev: 1500 * time.Millisecond,
+				Min: 1 * time.Second, Max: 6 * time.Second, Pace: 5 * time.Second, PaceSegments: 37,
+			},
+			"http://origin/vod/empty.m3u8": {},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	lines := make(map[string]string)
+	for _, line := range strings.Split(result, "\n") {
+		if f := strings.Fields(line); len(f) > 0 {
+			lines[f[0]] = line
+		}
+	}
+	for name, want := range map[string][]string{
+		"720p.m3u8":  {"150", "2s ±0s", "+0%", "2.01s (+0%)"},
+		"1080p.m3u8": {"40", "6s", "4s ±1.5s", "-33%", "5s (+25%)"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(lines[name], w) {
+				t.Errorf("%s row = %q, want %q", name, lines[name], w)
+			}
+		}
+	}
+	if strings.Contains(result, "empty.m3u8") {
+		t.Error("playlist without segments listed")
+	}
+	for _, want := range []string{
+		"1080p.m3u8: segments appeared 25% slower than their #EXTINF over 37 segments",
+		"1080p.m3u8: irregular segmenting, #EXTINF 1s to 6s (±38%)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "720p.m3u8: ") {
+		t.Errorf("steady playlist flagged:\n%s", result)
+	}
+}
+
+func TestFormatExitSummary_Tokens(t *testing.T) {
+	cfg := SummaryConfig{
+		Tokens: &TokenSummary{
+			Fetches: 120, FetchFailures: 2, HTTP401: 18, Expiries: 40, Reauths: 57,
+			ReauthP50: 450 * time.Millisecond, ReauthP95: 900 * time.Millisecond, ReauthP99: 1200 * time.Millisecond,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{"Session Tokens", "120 (2 failed)", "HTTP 401:             18", "Token Expiries:       40", "Re-auths:             57", "P50 450"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	cfg.Tokens = &TokenSummary{Fetches: 10}
+	if result := FormatExitSummary(&AggregatedStats{}, cfg); strings.Contains(result, "Re-auth Latency") || strings.Contains(result, "Token Expiries") {
+		t.Error("re-auth latency or expiries shown without re-auths")
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Session Tokens") {
+		t.Error("token section shown without -token-url")
+	}
+}
+
+func TestFormatExitSummary_Geos(t *testing.T) {
+	cfg := SummaryConfig{
+		Geos: []GeoSummary{
+			{Name: "eu", Clients: 20, Requests: 4800, SegmentP50: 40 * time.Millisecond, SegmentP99: 210 * time.Millisecond},
+			{Name: "apac", Clients: 10, Requests: 900, HTTPErrors: map[int]int64{503: 2, 403: 120}, NetworkErrors: 1},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{"CDN Geo Mapping", "eu", "210 ms", "apac: HTTP 403×120, 503×2"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "eu: HTTP") {
+		t.Error("status code line shown for a geo without HTTP errors")
+	}
+	if !strings.Contains(result, "  apac              10       900     123") {
+		t.Errorf("apac row should total HTTP and network errors:\n%s", result)
+	}
+
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "CDN Geo Mapping") {
+		t.Error("geo section shown without -geo")
+	}
+}
+
+func TestFormatExitSummary_Variants(t *testing.T) {
+	cfg := SummaryConfig{
+		Variants: []VariantSummary{
+			{Name: "1080p", Resolution: "1920x1080", Bandwidth: 6_000_000, Target: 60, Clients: 6, Requests: 600, Bytes: 9_000_000},
+			{Name: "480p", Resolution: "854x480", Bandwidth: 1_000_000, Target: 40, Clients: 4, Requests: 400, Bytes: 1_000_000, Errors: 3},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Variant Mix",
+		"  1080p    1920x1080      6.00   60.0%       6       600  60.0%     9.00 MB  90.0%      0",
+		"  480p     854x480        1.00   40.0%       4       400  40.0%     1.00 MB  10.0%      3",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Variant Mix") {
+		t.Error("variant section shown without -variant-mix")
+	}
+}
+
+func TestFormatExitSummary_Capture(t *testing.T) {
+	cfg := SummaryConfig{
+		Capture: &CaptureSummary{
+			Dir:       "/tmp/pcap",
+			RingFiles: 3,
+			RingBytes: 250 << 20,
+			Clients: []CapturedClient{
+				{ClientID: 0, Connections: 2, File: "/tmp/pcap/client-0.pcap", Filter: "tcp.port in {50000 50001}"},
+				{ClientID: 33, Connections: 1, Filter: "tcp.port in {50100}"},
+				{ClientID: 66},
+			},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Packet Capture",
+		"3 file(s)",
+		"client 0:", "2 connection(s), /tmp/pcap/client-0.pcap",
+		"1 connection(s), in the ring as tcp.port in {50100}",
+		"client 66:            no connections seen",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	cfg.Capture = &CaptureSummary{Dir: "/tmp/pcap", Error: "tcpdump not found"}
+	if result := FormatExitSummary(&AggregatedStats{}, cfg); !strings.Contains(result, "Not captured:         tcpdump not found") {
+		t.Errorf("summary missing the capture error:\n%s", result)
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Packet Capture") {
+		t.Error("capture section shown without -pcap-dir")
+	}
+}
+
+func TestFormatExitSummary_Sockets(t *testing.T) {
+	cfg := SummaryConfig{
+		Sockets: &SocketSummary{
+			Samples:         120,
+			PeakConnections: 50,
+			RTTP50:          2 * time.Millisecond,
+			RTTP95:          15 * time.Millisecond,
+			RTTP99:          40 * time.Millisecond,
+			MinRTT:          time.Millisecond,
+			OutOfOrder:      1234,
+			BytesReceived:   3 << 30,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Kernel TCP Sockets",
+		"120 (peak 50 connections)",
+		"P50 2 ms, P95 15 ms, P99 40 ms (min 1 ms)",
+		"Out-of-Order Packets: 1.2K",
+		"Retransmits Sent:     0",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	cfg.Sockets = &SocketSummary{Error: "netlink denied"}
+	if result := FormatExitSummary(&AggregatedStats{}, cfg); !strings.Contains(result, "Not sampled:          netlink denied") {
+		t.Errorf("summary missing the socket stats error:\n%s", result)
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Kernel TCP Sockets") {
+		t.Error("socket section shown without -socket-stats")
+	}
+}
+
+func TestFormatExitSummary_Flaps(t *testing.T) {
+	cfg := SummaryConfig{
+		Flaps: &FlapSummary{
+			Interval:        time.Minute,
+			Duration:        10 * time.Second,
+			Flaps:           5,
+			Pauses:          10,
+			Unrecovered:     1,
+			CatchUpP50:      1500 * time.Millisecond,
+			CatchUpP95:      4 * time.Second,
+			CatchUpP99:      6 * time.Second,
+			SequenceSkips:   9,
+			SegmentsExpired: 27,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Network Flaps",
+		"Flaps:                5 (every 1m0s, 10s pause)",
+		"Client Pauses:        10",
+		"Catch-up:             P50 1500 ms",
+		"Not Recovered:        1",
+		"Expired Segments:     27",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	cfg.Flaps = &FlapSummary{Interval: time.Minute, Duration: 10 * time.Second}
+	result = FormatExitSummary(&AggregatedStats{}, cfg)
+	if strings.Contains(result, "Catch-up:") || strings.Contains(result, "Not Recovered:") {
+		t.Errorf("catch-up shown without any pauses:\n%s", result)
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Network Flaps") {
+		t.Error("flap section shown without -flap-interval")
+	}
+}
+
+func TestFormatExitSummary_Bursts(t *testing.T) {
+	sched := "every 5m0s: +200 clients for 30s"
+	cfg := SummaryConfig{
+		Bursts: &BurstSummary{
+			Schedules: []string{sched},
+			Windows: []BurstWindow{
+				{Schedule: sched, Clients: 200, Start: 5 * time.Minute, End: 5*time.Minute + 30*time.Second},
+				{Schedule: sched, Clients: 200, Start: 10 * time.Minute, End: 10*time.Minute + 12*time.Second, CutShort: true},
+			},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Traffic Bursts",
+		"Schedule:             " + sched,
+		"Bursts:               2",
+		"00:05:00 - 00:05:30  +200 clients\n",
+		"00:10:00 - 00:10:12  +200 clients (cut short)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	for i := 0; i < maxBurstWindows+3; i++ {
+		cfg.Bursts.Windows = append(cfg.Bursts.Windows, BurstWindow{Schedule: sched, Clients: 200})
+	}
+	if result = FormatExitSummary(&AggregatedStats{}, cfg); !strings.Contains(result, "... and 5 more") {
+		t.Errorf("long burst list not cut:\n%s", result)
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Traffic Bursts") {
+		t.Error("burst section shown without -burst")
+	}
+}
+
+func TestFormatExitSummary_Sessions(t *testing.T) {
+	cfg := SummaryConfig{
+		Duration: 10 * time.Minute,
+		Sessions: &SessionSummary{
+			Distribution: "lognormal:2m,0.8",
+			Started:      150,
+			Ended:        100,
+			MeanLength:   150 * time.Second,
+			MinLength:    4 * time.Second,
+			MaxLength:    9 * time.Minute,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Viewer Sessions",
+		"Distribution:         lognormal:2m,0.8",
+		"Arrivals:             150 (15.0/min)",
+		"Departures:           100 (10.0/min)",
+		"Session Length:       mean 2m30s, min 4s, max 9m0s",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	cfg.Sessions = &SessionSummary{Distribution: "10m", Started: 10}
+	if result = FormatExitSummary(&AggregatedStats{}, cfg); strings.Contains(result, "Session Length:") {
+		t.Errorf("session length shown without ended sessions:\n%s", result)
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Viewer Sessions") {
+		t.Error("session section shown without -session-duration")
+	}
+}
+
+func TestFormatExitSummary_VODStarts(t *testing.T) {
+	cfg := SummaryConfig{
+		VODStarts: &VODStartSummary{
+			Distribution: "uniform",
+			Asset:        92 * time.Minute,
+			Starts:       200,
+			Tenths:       [10]int64{40, 20, 20, 20, 20, 20, 20, 20, 10, 10},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"VOD Start Positions",
+		"Distribution:         uniform (asset 1h32m0s)",
+		"Client Starts:        200",
+		"  0% -  10%:            40   20.0%  ██████████\n",
+		" 90% - 100%:            10    5.0%  ███\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "VOD Start Positions") {
+		t.Error("vod start section shown without -vod-start")
+	}
+}
+
+func TestFormatExitSummary_VODCompletion(t *testing.T) {
+	cfg := SummaryConfig{
+		VODCompletion: &VODCompletionSummary{
+			Mode:     "loop",
+			Asset:    10 * time.Minute,
+			Plays:    120,
+			Finished: 90,
+			CutShort: 20,
+			Clients:  20,
+			MeanTime: 10*time.Minute + 3*time.Second,
+			MinTime:  10 * time.Minute,
+			MaxTime:  11 * time.Minute,
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"VOD Completion",
+		"Mode:                 loop (asset 10m0s)",
+		"Plays:                120 (20 still playing at the end)",
+		"Finished:             90 of 100 ended (90.0%)",
+		"Ended Early:          10",
+		"Clients Finished:     20",
+		"Completion Time:      mean 10m3s, min 10m0s, max 11m0s",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "VOD Completion") {
+		t.Error("vod completion section shown without -vod-end")
+	}
+}
+
+func TestFormatExitSummary_Renditions(t *testing.T) {
+	cfg := SummaryConfig{
+		Renditions: []RenditionSummary{
+			{Name: "video", Segments: 600, Playlists: 200},
+			{Name: "audio en", Label: "English", Segments: 150, Playlists: 50},
+		},
+	}
+
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Renditions",
+		"  video                                    600        200  80.0%\n",
+		"  audio en         English                 150         50  20.0%\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Rendition ") {
+		t.Error("renditions section shown without -renditions")
+	}
+}
+
+func TestFormatExitSummary_ClientCPU(t *testing.T) {
+	cfg := SummaryConfig{
+		ClientCPU: &ClientCPUSummary{Limit: 25, Mean: 1.84, Peak: 3.2},
+	}
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Client CPU",
+		"Mean per Client:      1.8% of a core",
+		"Peak:                 3.2% (limit 25%)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "decoding") {
+		t.Errorf("decode warning without flagged clients:\n%s", result)
+	}
+
+	cfg.ClientCPU = &ClientCPUSummary{Limit: 25, Mean: 80, Peak: 140, Flagged: 12, Failed: true}
+	result = FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{"12 client process(es) over the limit", "The run was stopped"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Client CPU") {
+		t.Error("client CPU section shown without samples")
+	}
+}
+
+func TestFormatExitSummary_MemoryGuard(t *testing.T) {
+	cfg := SummaryConfig{
+		Memory: &MemoryGuardSummary{Limit: 2_000_000_000, Peak: 1_500_000_000},
+	}
+	result := FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"Memory Guard",
+		"Peak RSS:             1.50 GB (limit 2.00 GB)",
+		"Nothing shed",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	cfg.Memory = &MemoryGuardSummary{
+		Limit: 2_000_000_000,
+		Peak:  2_300_000_000,
+		Shed: []MemoryDegradation{
+			{Feature: "per-client-metrics", Description: "per-client Prometheus series dropped", At: 90 * time.Second, RSS: 2_100_000_000},
+			{Feature: "sample-rings", Description: "parser sample rings freed", At: 2 * time.Minute, RSS: 2_050_000_000},
+		},
+		Exhausted: true,
+	}
+	result = FormatExitSummary(&AggregatedStats{}, cfg)
+	for _, want := range []string{
+		"2 feature(s) shed over the limit",
+		"per-client-metrics   at RSS 2.10 GB: per-client Prometheus series dropped",
+		"sample-rings",
+		"nothing left to shed",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("summary missing %q:\n%s", want, result)
+		}
+	}
+
+	if strings.Contains(FormatExitSummary(&AggregatedStats{}, SummaryConfig{}), "Memory Guard") {
+		t.Error("memory guard section shown without samples")
+	}
+}
@@ -0,0 +1,90 @@
+package stats
+
+import "time"
+
+// TimelineMaxSamples caps a MetricsTimeline's length. Past it the timeline
+// halves its resolution, so a day-long run still saves as one line of the
+// run history, at a coarser interval.
+const TimelineMaxSamples = 360
+
+// MetricsTimeline is a run's swarm metrics sampled over time, kept in the
+// run record so the run can be graphed offline even when no Prometheus
+// server scraped it. It is columnar: sample i was taken T[i] seconds into
+// the run, and Series[name][i] is the value of series name then (null if
+// the series didn't exist at the time).
+type MetricsTimeline struct {
+	IntervalSeconds float64               `json:"interval_s"` // Between samples, after any halving
+	T               []float64             `json:"t"`
+	Series          map[string][]*float64 `json:"series"`
+
+	stride int // Ticks per sample
+	ticks  int
+}
+
+// NewMetricsTimeline returns an empty timeline to be sampled every
+// interval.
+func NewMetricsTimeline(interval time.Duration) *MetricsTimeline {
+	return &MetricsTimeline{
+		IntervalSeconds: interval.Seconds(),
+		Series:          make(map[string][]*float64),
+		stride:          1,
+	}
+}
+
+// Due counts a tick of the sampling interval and reports whether it should
+// be sampled: every tick at first, every other one after a halving, and
+// so on.
+func (t *MetricsTimeline) Due() bool {
+	due := t.ticks%t.stride == 0
+	t.ticks++
+	return due
+}
+
+// Add appends a sample taken at elapsed into the run, values keyed by
+// series name. Series missing from values get a null; series new in
+// values are null for the earlier samples.
+func (t *MetricsTimeline) Add(elapsed time.Duration, values map[string]float64) {
+	n := len(t.T)
+	t.T = append(t.T, elapsed.Seconds())
+	for name, v := range values {
+		col, ok := t.Series[name]
+		if !ok {
+			col = make([]*float64, n, n+1)
+		}
+		t.Series[name] = append(col, &v)
+	}
+	for name, col := range t.Series {
+		if len(col) == n {
+			t.Series[name] = append(col, nil)
+		}
+	}
+
+	if len(t.T) > TimelineMaxSamples {
+		t.halve()
+	}
+}
+
+// halve drops every other sample, keeping the first and doubling the
+// interval.
+func (t *MetricsTimeline) halve() {
+	t.T = everyOther(t.T)
+	for name, col := range t.Series {
+		t.Series[name] = everyOther(col)
+	}
+	t.IntervalSeconds *= 2
+	t.stride *= 2
+	t.ticks = 1 // The last sample is kept: the next is a stride after it
+}
+
+func everyOther[T any](s []T) []T {
+	out := s[:0]
+	for i := 0; i < len(s); i += 2 {
+		out = append(out, s[i])
+	}
+	return out
+}
+
+// Len returns the number of samples.
+func (t *MetricsTimeline) Len() int {
+	return len(t.T)
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMetricsTimeline_Add(t *testing.T) {
+	tl := NewMetricsTimeline(5 * time.Second)
+	tl.Add(5*time.Second, map[string]float64{"a": 1})
+	tl.Add(10*time.Second, map[string]float64{"a": 2, "b": 10}) // b appears
+	tl.Add(15*time.Second, map[string]float64{"b": 20})         // a goes
+
+	data, err := json.Marshal(tl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"interval_s":5,"t":[5,10,15],"series":{"a":[1,2,null],"b":[null,10,20]}}`
+	if string(data) != want {
+		t.Errorf("timeline = %s, want %s", data, want)
+	}
+}
+
+func TestMetricsTimeline_Halve(t *testing.T) {
+	tl := NewMetricsTimeline(time.Second)
+	tick := 0
+	sample := func() {
+		if tl.Due() {
+			tl.Add(time.Duration(tick)*time.Second, map[string]float64{"tick": float64(tick)})
+		}
+		tick++
+	}
+
+	for range TimelineMaxSamples + 1 {
+		sample()
+	}
+	if got, want := tl.Len(), TimelineMaxSamples/2+1; got != want {
+		t.Fatalf("Len() after the cap = %d, want %d", got, want)
+	}
+	if tl.IntervalSeconds != 2 {
+		t.Errorf("IntervalSeconds = %v, want 2", tl.IntervalSeconds)
+	}
+
+	// From here on every other tick is sampled, keeping the spacing even
+	for range 4 {
+		sample()
+	}
+	n := tl.Len()
+	for i := 1; i < n; i++ {
+		if d := tl.T[i] - tl.T[i-1]; d != 2 {
+			t.Fatalf("T[%d]-T[%d] = %v, want 2 (T ends %v)", i, i-1, d, tl.T[n-3:])
+		}
+		if v := *tl.Series["tick"][i]; v != tl.T[i] {
+			t.Fatalf("tick[%d] = %v, want %v", i, v, tl.T[i])
+		}
+	}
+	if n != TimelineMaxSamples/2+3 {
+		t.Errorf("Len() = %d, want %d", n, TimelineMaxSamples/2+3)
+	}
+}
+
+func TestAppendRun_Timeline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.jsonl")
+	tl := NewMetricsTimeline(time.Second)
+	for i := range TimelineMaxSamples {
+		values := make(map[string]float64)
+		for s := range 200 {
+			values[string(rune('a'+s%26))+string(rune('a'+s/26))] = float64(i*s) / 7
+		}
+		tl.Add(time.Duration(i)*time.Second, values)
+	}
+	if err := AppendRun(path, &RunRecord{Timeline: tl}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A full timeline is past the old 1 MiB line limit
+	runs, err := LoadRuns(path)
+	if err != nil {
+		t.Fatalf("LoadRuns() = %v", err)
+	}
+	got := runs[0].Timeline
+	if got == nil || got.Len() != TimelineMaxSamples || len(got.Series) != 200 {
+		t.Fatalf("loaded timeline = %d samples, want %d of 200 series", got.Len(), TimelineMaxSamples)
+	}
+	if v := got.Series["ba"][7]; v == nil || *v != 1 {
+		t.Errorf("Series[ba][7] = %v, want 1", v)
+	}
+}
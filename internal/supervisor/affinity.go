@@ -0,0 +1,184 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AffinityPolicy selects how client processes are pinned to CPUs.
+//
+// On large machines the scheduler migrates hundreds of FFmpeg processes
+// between cores and NUMA nodes, which shows up as noise in latency and
+// throughput measurements. Pinning trades a little flexibility for
+// repeatable runs.
+type AffinityPolicy string
+
+const (
+	// AffinityNone leaves scheduling to the kernel (default).
+	AffinityNone AffinityPolicy = "none"
+
+	// AffinityCore pins each client to a single CPU, round-robin.
+	AffinityCore AffinityPolicy = "core"
+
+	// AffinityNUMA pins each client to all CPUs of one NUMA node, round-robin
+	// across nodes. Clients stay node-local but can still balance within it.
+	AffinityNUMA AffinityPolicy = "numa"
+)
+
+// errAffinityUnsupported is returned on platforms without sched_setaffinity.
+var errAffinityUnsupported = errors.New("cpu affinity is not supported on this platform")
+
+// CPUAllocator assigns CPU sets to clients according to an AffinityPolicy.
+// Assignment is a pure function of the client ID, so a restarted client
+// lands back on the same CPUs.
+type CPUAllocator struct {
+	policy AffinityPolicy
+	sets   [][]int
+}
+
+// NewCPUAllocator creates an allocator for the given policy using the
+// CPUs this process is allowed to run on.
+// Returns (nil, nil) for AffinityNone.
+func NewCPUAllocator(policy AffinityPolicy) (*CPUAllocator, error) {
+	switch policy {
+	case AffinityNone, "":
+		return nil, nil
+	case AffinityCore:
+		cpus, err := allowedCPUs()
+		if err != nil {
+			return nil, err
+		}
+		sets := make([][]int, 0, len(cpus))
+		for _, cpu := range cpus {
+			sets = append(sets, []int{cpu})
+		}
+		return newCPUAllocator(policy, sets)
+	case AffinityNUMA:
+		allowed, err := allowedCPUs()
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := numaNodes()
+		if err != nil {
+			return nil, err
+		}
+		return newCPUAllocator(policy, intersectNodes(nodes, allowed))
+	default:
+		return nil, fmt.Errorf("unknown cpu affinity policy %q", policy)
+	}
+}
+
+// newCPUAllocator builds an allocator from pre-computed CPU sets.
+func newCPUAllocator(policy AffinityPolicy, sets [][]int) (*CPUAllocator, error) {
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("cpu affinity policy %q: no usable CPUs found", policy)
+	}
+	return &CPUAllocator{policy: policy, sets: sets}, nil
+}
+
+// CPUsFor returns the CPU set for a client, or nil if pinning is disabled.
+// Safe to call on a nil allocator.
+func (a *CPUAllocator) CPUsFor(clientID int) []int {
+	if a == nil || len(a.sets) == 0 {
+		return nil
+	}
+	idx := clientID % len(a.sets)
+	if idx < 0 {
+		idx += len(a.sets)
+	}
+	return a.sets[idx]
+}
+
+// Policy returns the allocator's policy.
+func (a *CPUAllocator) Policy() AffinityPolicy {
+	if a == nil {
+		return AffinityNone
+	}
+	return a.policy
+}
+
+// Slots returns the number of distinct CPU sets clients are spread over.
+func (a *CPUAllocator) Slots() int {
+	if a == nil {
+		return 0
+	}
+	return len(a.sets)
+}
+
+// intersectNodes restricts each NUMA node's CPU list to the allowed CPUs,
+// dropping nodes that end up empty (e.g. when running inside a cpuset).
+func intersectNodes(nodes [][]int, allowed []int) [][]int {
+	ok := make(map[int]bool, len(allowed))
+	for _, cpu := range allowed {
+		ok[cpu] = true
+	}
+
+	var out [][]int
+	for _, node := range nodes {
+		var cpus []int
+		for _, cpu := range node {
+			if ok[cpu] {
+				cpus = append(cpus, cpu)
+			}
+		}
+		if len(cpus) > 0 {
+			out = append(out, cpus)
+		}
+	}
+	return out
+}
+
+// parseCPUList parses the kernel's cpulist format (e.g. "0-3,8,10-11").
+func parseCPUList(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	var cpus []int
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu list %q: %w", s, err)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil {
+				return nil, fmt.Errorf("invalid cpu list %q: %w", s, err)
+			}
+		}
+		if start < 0 || end < start {
+			return nil, fmt.Errorf("invalid cpu range %q", part)
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+// FormatCPUList renders CPUs in the kernel's cpulist format (inverse of parseCPUList).
+func FormatCPUList(cpus []int) string {
+	sorted := append([]int(nil), cpus...)
+	sort.Ints(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if j == i {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
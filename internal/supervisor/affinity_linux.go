@@ -0,0 +1,75 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysNodePath is where the kernel exposes NUMA topology.
+const sysNodePath = "/sys/devices/system/node"
+
+// allowedCPUs returns the CPUs the current process may run on, honouring
+// any cpuset/taskset the swarm itself was started under.
+func allowedCPUs() ([]int, error) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return nil, fmt.Errorf("sched_getaffinity: %w", err)
+	}
+
+	var cpus []int
+	n := set.Count()
+	for cpu := 0; len(cpus) < n; cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// numaNodes returns the CPU list of each NUMA node, ordered by node ID.
+// Machines without NUMA sysfs entries are treated as a single node.
+func numaNodes() ([][]int, error) {
+	matches, _ := filepath.Glob(filepath.Join(sysNodePath, "node[0-9]*"))
+	if len(matches) == 0 {
+		cpus, err := allowedCPUs()
+		if err != nil {
+			return nil, err
+		}
+		return [][]int{cpus}, nil
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return nodeID(matches[i]) < nodeID(matches[j])
+	})
+
+	nodes := make([][]int, 0, len(matches))
+	for _, dir := range matches {
+		data, err := os.ReadFile(filepath.Join(dir, "cpulist"))
+		if err != nil {
+			return nil, fmt.Errorf("read numa topology: %w", err)
+		}
+		cpus, err := parseCPUList(string(data))
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, cpus)
+	}
+	return nodes, nil
+}
+
+// nodeID extracts N from a ".../nodeN" path (-1 if malformed).
+func nodeID(path string) int {
+	id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(path), "node"))
+	if err != nil {
+		return -1
+	}
+	return id
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package supervisor
+
+func allowedCPUs() ([]int, error) {
+	return nil, errAffinityUnsupported
+}
+
+func numaNodes() ([][]int, error) {
+	return nil, errAffinityUnsupported
+}
@@ -0,0 +1,115 @@
+package supervisor
+
+import (
+	"reflect"
+	"testing"
+)
+
+// =============================================================================
+// Table-Driven Tests: parseCPUList
+// =============================================================================
+
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"single", "3", []int{3}, false},
+		{"range", "0-3", []int{0, 1, 2, 3}, false},
+		{"mixed", "0-1,4,6-7\n", []int{0, 1, 4, 6, 7}, false},
+		{"unsorted", "8,2-3", []int{2, 3, 8}, false},
+		{"reversed range", "3-1", nil, true},
+		{"garbage", "a-b", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCPUList(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCPUList(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCPUList(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// =============================================================================
+// CPUAllocator
+// =============================================================================
+
+func TestCPUAllocator_RoundRobin(t *testing.T) {
+	a, err := newCPUAllocator(AffinityCore, [][]int{{0}, {1}, {2}})
+	if err != nil {
+		t.Fatalf("newCPUAllocator: %v", err)
+	}
+
+	for clientID, want := range []int{0, 1, 2, 0, 1} {
+		got := a.CPUsFor(clientID)
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("CPUsFor(%d) = %v, want [%d]", clientID, got, want)
+		}
+	}
+	if a.Slots() != 3 {
+		t.Errorf("Slots() = %d, want 3", a.Slots())
+	}
+}
+
+func TestCPUAllocator_NilIsUnpinned(t *testing.T) {
+	var a *CPUAllocator
+	if cpus := a.CPUsFor(5); cpus != nil {
+		t.Errorf("nil allocator CPUsFor = %v, want nil", cpus)
+	}
+	if a.Policy() != AffinityNone {
+		t.Errorf("nil allocator Policy = %q, want %q", a.Policy(), AffinityNone)
+	}
+}
+
+func TestNewCPUAllocator_NoneAndUnknown(t *testing.T) {
+	a, err := NewCPUAllocator(AffinityNone)
+	if a != nil || err != nil {
+		t.Errorf("NewCPUAllocator(none) = %v, %v; want nil, nil", a, err)
+	}
+	if _, err := NewCPUAllocator("bogus"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+	if _, err := newCPUAllocator(AffinityNUMA, nil); err == nil {
+		t.Error("expected error for empty topology")
+	}
+}
+
+func TestIntersectNodes(t *testing.T) {
+	nodes := [][]int{{0, 1, 2, 3}, {4, 5, 6, 7}}
+	got := intersectNodes(nodes, []int{2, 3, 4})
+	want := [][]int{{2, 3}, {4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("intersectNodes = %v, want %v", got, want)
+	}
+
+	// Nodes outside the allowed set disappear entirely
+	got = intersectNodes(nodes, []int{5})
+	if !reflect.DeepEqual(got, [][]int{{5}}) {
+		t.Errorf("intersectNodes = %v, want [[5]]", got)
+	}
+}
+
+func TestFormatCPUList(t *testing.T) {
+	tests := []struct {
+		cpus []int
+		want string
+	}{
+		{nil, ""},
+		{[]int{3}, "3"},
+		{[]int{0, 1, 2, 3}, "0-3"},
+		{[]int{7, 0, 1, 4, 6}, "0-1,4,6-7"},
+	}
+	for _, tt := range tests {
+		if got := FormatCPUList(tt.cpus); got != tt.want {
+			t.Errorf("FormatCPUList(%v) = %q, want %q", tt.cpus, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package supervisor
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 	"time"
@@ -24,12 +25,45 @@ func DefaultBackoffConfig() BackoffConfig {
 	}
 }
 
+// BackoffPresets are the named backoff policies (-backoff-preset,
+// -backoff-on).
+var BackoffPresets = map[string]BackoffConfig{
+	"default": DefaultBackoffConfig(),
+	// Restart almost at once: for short-lived faults such as a reset
+	// connection, where the client should be back before the next segment
+	"aggressive": {
+		Initial:    50 * time.Millisecond,
+		Max:        time.Second,
+		Multiplier: 1.5,
+		JitterPct:  0.4,
+	},
+	// Back off to a minute: for faults that won't clear soon, such as a
+	// missing playlist, so the swarm doesn't hammer the origin with them
+	"gentle": {
+		Initial:    2 * time.Second,
+		Max:        time.Minute,
+		Multiplier: 2,
+		JitterPct:  0.4,
+	},
+	"none": {Multiplier: 1}, // Restart immediately
+}
+
+// BackoffPreset returns the named backoff policy.
+func BackoffPreset(name string) (BackoffConfig, error) {
+	cfg, ok := BackoffPresets[name]
+	if !ok {
+		return BackoffConfig{}, fmt.Errorf("unknown backoff preset %q", name)
+	}
+	return cfg, nil
+}
+
 // Backoff calculates exponential backoff delays with jitter.
 // Each instance is tied to a specific client for deterministic jitter.
 type Backoff struct {
-	config   BackoffConfig
-	attempts int
-	rng      *rand.Rand
+	config    BackoffConfig
+	overrides map[string]BackoffConfig // By exit cause; see NextFor
+	attempts  int
+	rng       *rand.Rand
 }
 
 // NewBackoff creates a new Backoff calculator for a specific client.
@@ -50,20 +84,47 @@ func (b *Backoff) Next() time.Duration {
 	return delay
 }
 
+// SetOverrides sets the policies used instead of the default for some
+// exit causes, such as "http-404" or "exit-1" (see NextFor).
+func (b *Backoff) SetOverrides(overrides map[string]BackoffConfig) {
+	b.overrides = overrides
+}
+
+// NextFor is Next for a process that exited for the given causes, most
+// specific first: the first cause with an override picks the policy. The
+// attempt count is shared by all policies, so a client failing the same
+// way keeps backing off further whichever policy applies.
+func (b *Backoff) NextFor(causes ...string) time.Duration {
+	cfg := b.config
+	for _, cause := range causes {
+		if override, ok := b.overrides[cause]; ok {
+			cfg = override
+			break
+		}
+	}
+	delay := b.calculate(cfg)
+	b.attempts++
+	return delay
+}
+
 // Calculate returns the current backoff delay without incrementing attempts.
 func (b *Backoff) Calculate() time.Duration {
+	return b.calculate(b.config)
+}
+
+func (b *Backoff) calculate(cfg BackoffConfig) time.Duration {
 	// Calculate base delay: initial * multiplier^attempts
-	delay := float64(b.config.Initial) * math.Pow(b.config.Multiplier, float64(b.attempts))
+	delay := float64(cfg.Initial) * math.Pow(cfg.Multiplier, float64(b.attempts))
 
 	// Cap at maximum
-	if delay > float64(b.config.Max) {
-		delay = float64(b.config.Max)
+	if delay > float64(cfg.Max) {
+		delay = float64(cfg.Max)
 	}
 
 	// Add jitter: ±(JitterPct/2) of the delay
 	// e.g., JitterPct=0.4 means ±20% jitter
-	if b.config.JitterPct > 0 {
-		jitterRange := delay * b.config.JitterPct
+	if cfg.JitterPct > 0 {
+		jitterRange := delay * cfg.JitterPct
 		jitter := jitterRange*b.rng.Float64() - jitterRange/2
 		delay += jitter
 	}
@@ -355,6 +355,79 @@ func TestBackoff_DeterministicJitter(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// Tests: Presets and Overrides
+// =============================================================================
+
+func TestBackoffPreset(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantFirst time.Duration // Attempt 0, no jitter
+		wantLast  time.Duration // Attempt 20, capped
+	}{
+		{"default", 250 * time.Millisecond, 5 * time.Second},
+		{"aggressive", 50 * time.Millisecond, time.Second},
+		{"gentle", 2 * time.Second, time.Minute},
+		{"none", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := BackoffPreset(tt.name)
+			if err != nil {
+				t.Fatalf("BackoffPreset() = %v", err)
+			}
+			cfg.JitterPct = 0
+			b := NewBackoff(0, 0, cfg)
+			if d := b.Calculate(); d != tt.wantFirst {
+				t.Errorf("first delay = %v, want %v", d, tt.wantFirst)
+			}
+			b.SetAttempts(20)
+			if d := b.Calculate(); d != tt.wantLast {
+				t.Errorf("capped delay = %v, want %v", d, tt.wantLast)
+			}
+		})
+	}
+
+	if _, err := BackoffPreset("slow"); err == nil {
+		t.Error("BackoffPreset(slow) = nil, want error")
+	}
+}
+
+func TestBackoff_NextFor(t *testing.T) {
+	cfg := BackoffConfig{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	b := NewBackoff(0, 0, cfg)
+	b.SetOverrides(map[string]BackoffConfig{
+		"http-404": {Initial: 10 * time.Second, Max: time.Minute, Multiplier: 2},
+		"http-4xx": {Initial: 5 * time.Second, Max: time.Minute, Multiplier: 2},
+		"exit-1":   {Multiplier: 1},
+	})
+
+	steps := []struct {
+		causes []string
+		want   time.Duration
+	}{
+		{[]string{"reset", "exit-8"}, 100 * time.Millisecond},          // No override: default
+		{[]string{"http-404", "http-4xx", "exit-8"}, 20 * time.Second}, // Most specific wins; attempts shared
+		{[]string{"http-403", "http-4xx", "exit-8"}, 20 * time.Second}, // Class
+		{[]string{"reset", "exit-1"}, 0},                               // Exit code
+		{nil, time.Second},                                             // Default, capped
+	}
+	for i, step := range steps {
+		if d := b.NextFor(step.causes...); d != step.want {
+			t.Errorf("step %d: NextFor(%v) = %v, want %v", i, step.causes, d, step.want)
+		}
+	}
+	if b.Attempts() != len(steps) {
+		t.Errorf("Attempts() = %d, want %d", b.Attempts(), len(steps))
+	}
+}
+
+func TestExitCodeCause(t *testing.T) {
+	if got := ExitCodeCause(137); got != "exit-137" {
+		t.Errorf("ExitCodeCause(137) = %q, want exit-137", got)
+	}
+}
+
 // =============================================================================
 // Edge Cases
 // =============================================================================
@@ -0,0 +1,81 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// ioprioWhoProcess and ioprioClassShift are from linux/ioprio.h.
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// launch starts cmd with cpus and p applied. The CPU mask, niceness and IO
+// priority are per thread on Linux and inherited by a child, so they are
+// set on a locked thread that then starts cmd: FFmpeg, and every thread it
+// creates, has them from its first instruction. The thread is never
+// unlocked, so it exits with its goroutine instead of running others with
+// them.
+//
+// Each attribute that can't be applied is reported (affinityErr,
+// priorityErr) while the rest still are; startErr is cmd.Start's.
+func launch(cmd *exec.Cmd, cpus []int, p Priority) (startErr, affinityErr, priorityErr error) {
+	if len(cpus) == 0 && p.IsZero() {
+		return cmd.Start(), nil, nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runtime.LockOSThread()
+		if len(cpus) > 0 {
+			affinityErr = setThreadAffinity(cpus)
+		}
+		priorityErr = setThreadPriority(p)
+		startErr = cmd.Start()
+	}()
+	<-done
+	return startErr, affinityErr, priorityErr
+}
+
+// setThreadAffinity pins the calling thread to cpus.
+func setThreadAffinity(cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		set.Set(cpu)
+	}
+	if err := unix.SchedSetaffinity(0, &set); err != nil {
+		return fmt.Errorf("sched_setaffinity: %w", err)
+	}
+	return nil
+}
+
+// setThreadPriority gives the calling thread p's niceness and IO priority,
+// each independently of the other.
+func setThreadPriority(p Priority) error {
+	var errs []error
+	if p.Nice != 0 {
+		// For PRIO_PROCESS, who 0 is the calling thread
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, p.Nice); err != nil {
+			errs = append(errs, fmt.Errorf("setpriority: %w", err))
+		}
+	}
+	if p.IOClass != IOClassNone {
+		level := p.IOLevel
+		if p.IOClass == IOClassIdle {
+			level = 0 // Has no levels
+		}
+		prio := p.IOClass<<ioprioClassShift | level
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(prio)); errno != 0 {
+			errs = append(errs, fmt.Errorf("ioprio_set: %w", errno))
+		}
+	}
+	return errors.Join(errs...)
+}
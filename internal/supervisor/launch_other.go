@@ -0,0 +1,15 @@
+//go:build !linux
+
+package supervisor
+
+import "os/exec"
+
+func launch(cmd *exec.Cmd, cpus []int, p Priority) (startErr, affinityErr, priorityErr error) {
+	if len(cpus) > 0 {
+		affinityErr = errAffinityUnsupported
+	}
+	if !p.IsZero() {
+		priorityErr = errPriorityUnsupported
+	}
+	return cmd.Start(), affinityErr, priorityErr
+}
@@ -0,0 +1,125 @@
+package supervisor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// OwnerEnv tags every process the supervisor starts with the PID of the
+// swarm that started it, so FindOrphans can tell leftovers of a crashed
+// run from processes that are still supervised.
+const OwnerEnv = "HLS_SWARM_OWNER"
+
+// procDir is where FindOrphans looks for processes (a variable for tests).
+var procDir = "/proc"
+
+// Orphan is a swarm-tagged process whose swarm is gone.
+type Orphan struct {
+	PID     int
+	PGID    int
+	Owner   int    // PID of the swarm that started it
+	Command string // Command line, space separated
+}
+
+// FindOrphans returns the swarm-tagged processes whose owning swarm is no
+// longer running, e.g. FFmpegs left behind by a run that was SIGKILLed or
+// crashed before its clients were stopped. Only processes whose
+// environment is readable (those of the same user, or all as root) are
+// seen. Needs /proc (Linux).
+func FindOrphans() ([]Orphan, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, fmt.Errorf("list processes: %w", err)
+	}
+
+	self := os.Getpid()
+	var orphans []Orphan
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		owner := processOwner(pid)
+		if owner == 0 || owner == self || processAlive(owner) {
+			continue
+		}
+		orphans = append(orphans, Orphan{
+			PID:     pid,
+			PGID:    processGroup(pid),
+			Owner:   owner,
+			Command: processCommand(pid),
+		})
+	}
+	return orphans, nil
+}
+
+// KillOrphans sends SIGKILL to the orphans' process groups (or just the
+// process, if its group is unknown). Returns how many were signalled.
+func KillOrphans(orphans []Orphan) (int, error) {
+	killed := 0
+	var errs []error
+	for _, o := range orphans {
+		target := o.PID
+		if o.PGID > 1 {
+			target = -o.PGID
+		}
+		err := syscall.Kill(target, syscall.SIGKILL)
+		switch {
+		case err == nil:
+			killed++
+		case errors.Is(err, syscall.ESRCH):
+			// Already gone
+		default:
+			errs = append(errs, fmt.Errorf("kill orphan %d: %w", o.PID, err))
+		}
+	}
+	return killed, errors.Join(errs...)
+}
+
+// processOwner returns the OwnerEnv tag of pid, or 0 if it has none (or
+// its environment can't be read).
+func processOwner(pid int) int {
+	environ, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "environ"))
+	if err != nil {
+		return 0
+	}
+	prefix := []byte(OwnerEnv + "=")
+	for _, kv := range bytes.Split(environ, []byte{0}) {
+		if value, ok := bytes.CutPrefix(kv, prefix); ok {
+			owner, _ := strconv.Atoi(string(value))
+			return owner
+		}
+	}
+	return 0
+}
+
+// processGroup returns the process group of pid from its stat file, or 0.
+func processGroup(pid int) int {
+	fields, err := statFields(pid)
+	if err != nil || len(fields) <= statPGRP {
+		return 0
+	}
+	pgid, _ := strconv.Atoi(fields[statPGRP])
+	return pgid
+}
+
+// processCommand returns the command line of pid, or "" if unreadable.
+func processCommand(pid int) string {
+	cmdline, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bytes.ReplaceAll(cmdline, []byte{0}, []byte{' '})))
+}
+
+// processAlive reports whether pid exists.
+func processAlive(pid int) bool {
+	_, err := os.Stat(filepath.Join(procDir, strconv.Itoa(pid)))
+	return err == nil
+}
@@ -0,0 +1,96 @@
+package supervisor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+// writeProc adds a fake /proc/<pid> with the given files.
+func writeProc(t *testing.T, dir string, pid int, files map[string]string) {
+	t.Helper()
+	pidDir := filepath.Join(dir, strconv.Itoa(pid))
+	if err := os.MkdirAll(pidDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(pidDir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	dir := t.TempDir()
+	old := procDir
+	procDir = dir
+	t.Cleanup(func() { procDir = old })
+
+	// Orphan: its owner, 999, is gone
+	writeProc(t, dir, 100, map[string]string{
+		"environ": "PATH=/bin\x00" + OwnerEnv + "=999\x00HOME=/root\x00",
+		"stat":    "100 (ff) mpeg) S 1 100 100 0 -1",
+		"cmdline": "ffmpeg\x00-i\x00http://origin/live.m3u8\x00",
+	})
+	// Supervised: its owner, 200, is running
+	writeProc(t, dir, 101, map[string]string{
+		"environ": OwnerEnv + "=200\x00",
+		"stat":    "101 (ffmpeg) S 200 101 101 0 -1",
+	})
+	// Not ours
+	writeProc(t, dir, 102, map[string]string{"environ": "PATH=/bin\x00"})
+	// The owner, with an unreadable environment
+	writeProc(t, dir, 200, nil)
+	writeProc(t, dir, os.Getpid(), map[string]string{"environ": OwnerEnv + "=999\x00"})
+	if err := os.MkdirAll(filepath.Join(dir, "self"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindOrphans()
+	if err != nil {
+		t.Fatalf("FindOrphans: %v", err)
+	}
+	want := []Orphan{{PID: 100, PGID: 100, Owner: 999, Command: "ffmpeg -i http://origin/live.m3u8"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindOrphans() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFindOrphans_NoProc(t *testing.T) {
+	old := procDir
+	procDir = filepath.Join(t.TempDir(), "missing")
+	t.Cleanup(func() { procDir = old })
+
+	if _, err := FindOrphans(); err == nil {
+		t.Error("FindOrphans() without /proc should fail")
+	}
+}
+
+func TestKillOrphans(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pid := cmd.Process.Pid
+
+	killed, err := KillOrphans([]Orphan{{PID: pid, PGID: pid}})
+	if err != nil || killed != 1 {
+		t.Fatalf("KillOrphans() = %d, %v; want 1, nil", killed, err)
+	}
+	var exitErr *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &exitErr) || exitErr.Sys().(syscall.WaitStatus).Signal() != syscall.SIGKILL {
+		t.Errorf("Wait() = %v, want killed", err)
+	}
+
+	// Gone by now: not an error, not counted
+	killed, err = KillOrphans([]Orphan{{PID: pid, PGID: pid}})
+	if err != nil || killed != 0 {
+		t.Errorf("KillOrphans() of a dead process = %d, %v; want 0, nil", killed, err)
+	}
+}
@@ -0,0 +1,31 @@
+package supervisor
+
+import "errors"
+
+// IO scheduling classes (ioprio_set(2)); IOClassNone keeps the swarm's.
+const (
+	IOClassNone       = 0
+	IOClassRealtime   = 1
+	IOClassBestEffort = 2
+	IOClassIdle       = 3
+)
+
+// errPriorityUnsupported is returned on platforms without setpriority and
+// ioprio_set.
+var errPriorityUnsupported = errors.New("process priority is not supported on this platform")
+
+// Priority is the CPU and IO scheduling priority of a client process.
+//
+// Big swarms spend most of their CPU on FFmpeg demuxing; lowering the
+// clients' priority keeps the orchestrator (and its measurements) and
+// other tenants of the host responsive when the machine is saturated.
+type Priority struct {
+	Nice    int // Niceness, -20..19 (0 = keep the swarm's)
+	IOClass int // IOClass* (IOClassNone = keep the swarm's)
+	IOLevel int // 0 (highest) .. 7, for the realtime and best-effort classes
+}
+
+// IsZero reports whether p leaves the priority alone.
+func (p Priority) IsZero() bool {
+	return p.Nice == 0 && p.IOClass == IOClassNone
+}
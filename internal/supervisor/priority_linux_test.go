@@ -0,0 +1,177 @@
+//go:build linux
+
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// getPriority returns pid's niceness and IO priority.
+func getPriority(pid int) (Priority, error) {
+	// The raw syscall returns 20 - nice, so it's never negative
+	prio, err := unix.Getpriority(unix.PRIO_PROCESS, pid)
+	if err != nil {
+		return Priority{}, fmt.Errorf("getpriority: %w", err)
+	}
+	io, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(pid), 0)
+	if errno != 0 {
+		return Priority{}, fmt.Errorf("ioprio_get: %w", errno)
+	}
+	p := Priority{
+		Nice:    20 - prio,
+		IOClass: int(io >> ioprioClassShift),
+		IOLevel: int(io & (1<<ioprioClassShift - 1)),
+	}
+	if p.IOClass == IOClassIdle {
+		p.IOLevel = 0 // Has no levels
+	}
+	return p, nil
+}
+
+// getAffinity returns the CPUs pid may run on.
+func getAffinity(pid int) (unix.CPUSet, error) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(pid, &set); err != nil {
+		return set, fmt.Errorf("sched_getaffinity: %w", err)
+	}
+	return set, nil
+}
+
+func TestLaunch(t *testing.T) {
+	tests := []struct {
+		name string
+		cpus []int
+		p    Priority
+	}{
+		{"nice", nil, Priority{Nice: 10}},
+		{"idle IO", nil, Priority{IOClass: IOClassIdle}},
+		{"both", nil, Priority{Nice: 15, IOClass: IOClassBestEffort, IOLevel: 7}},
+		{"pinned", []int{0}, Priority{}},
+		{"pinned and nice", []int{0}, Priority{Nice: 5}},
+	}
+
+	before, err := getPriority(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command("sleep", "10")
+			startErr, affinityErr, priorityErr := launch(cmd, tt.cpus, tt.p)
+			if startErr != nil {
+				t.Fatal(startErr)
+			}
+			defer func() {
+				cmd.Process.Kill()
+				cmd.Wait()
+			}()
+			if affinityErr != nil || priorityErr != nil {
+				t.Skipf("not permitted here: %v, %v", affinityErr, priorityErr)
+			}
+
+			want := tt.p
+			if want.Nice == 0 {
+				want.Nice = before.Nice
+			}
+			if want.IOClass == IOClassNone {
+				want.IOClass, want.IOLevel = before.IOClass, before.IOLevel
+			}
+			got, err := getPriority(cmd.Process.Pid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("priority = %+v, want %+v", got, want)
+			}
+			if tt.cpus != nil {
+				set, err := getAffinity(cmd.Process.Pid)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if set.Count() != 1 || !set.IsSet(0) {
+					t.Errorf("affinity has %d CPUs, want CPU 0 only", set.Count())
+				}
+			}
+		})
+	}
+}
+
+// TestLaunch_PartialFailure checks that an affinity the kernel rejects
+// still leaves the priority applied and the process started.
+func TestLaunch_PartialFailure(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	startErr, affinityErr, priorityErr := launch(cmd, []int{1023}, Priority{Nice: 11})
+	if startErr != nil {
+		t.Fatal(startErr)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	if affinityErr == nil {
+		t.Error("affinity to a missing CPU succeeded")
+	}
+	if priorityErr != nil {
+		t.Skipf("not permitted here: %v", priorityErr)
+	}
+	got, err := getPriority(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Nice != 11 {
+		t.Errorf("nice = %d, want 11", got.Nice)
+	}
+}
+
+func TestLaunch_None(t *testing.T) {
+	cmd := exec.Command("true")
+	startErr, affinityErr, priorityErr := launch(cmd, nil, Priority{})
+	if startErr != nil || affinityErr != nil || priorityErr != nil {
+		t.Fatalf("launch() = %v, %v, %v; want no errors", startErr, affinityErr, priorityErr)
+	}
+	cmd.Wait()
+}
+
+func TestSupervisor_Priority(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	want := Priority{Nice: 12, IOClass: IOClassIdle}
+	got := make(chan Priority, 1)
+	sup := New(Config{
+		ClientID: 1,
+		Builder:  newShellBuilder("sleep 5"),
+		Backoff:  newTestBackoff(),
+		Logger:   newTestLogger(),
+		Priority: want,
+		Callbacks: Callbacks{
+			OnStart: func(_ int, pid int) {
+				p, err := getPriority(pid)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				select {
+				case got <- p:
+				default:
+				}
+			},
+		},
+	})
+	go sup.Run(ctx)
+
+	select {
+	case p := <-got:
+		if p.Nice != 12 || p.IOClass != IOClassIdle {
+			t.Errorf("client priority = %+v, want nice 12, idle IO", p)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("process did not start")
+	}
+}
@@ -0,0 +1,70 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Indexes into statFields, which start after "pid (comm)" (proc(5)).
+const (
+	statPGRP  = 2
+	statUTime = 11
+	statSTime = 12
+	statRSS   = 21
+)
+
+// userHZ is the unit of the /proc CPU times (USER_HZ, 100 on Linux).
+const userHZ = 100
+
+// statFields returns the fields of /proc/<pid>/stat after the command
+// name, which may itself contain spaces and parentheses.
+func statFields(pid int) ([]string, error) {
+	stat, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+	i := strings.LastIndex(string(stat), ") ")
+	if i < 0 {
+		return nil, fmt.Errorf("parse %s/%d/stat: no command name", procDir, pid)
+	}
+	return strings.Fields(string(stat[i+2:])), nil
+}
+
+// ProcessCPUTime returns the user plus system CPU time pid has used so far
+// (its own threads, not its children). Needs /proc (Linux).
+func ProcessCPUTime(pid int) (time.Duration, error) {
+	fields, err := statFields(pid)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) <= statSTime {
+		return 0, fmt.Errorf("parse %s/%d/stat: %d fields", procDir, pid, len(fields))
+	}
+	utime, err1 := strconv.ParseInt(fields[statUTime], 10, 64)
+	stime, err2 := strconv.ParseInt(fields[statSTime], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("parse %s/%d/stat: bad CPU times", procDir, pid)
+	}
+	return time.Duration(utime+stime) * time.Second / userHZ, nil
+}
+
+// ProcessRSS returns the resident set size of pid in bytes. Needs /proc
+// (Linux).
+func ProcessRSS(pid int) (int64, error) {
+	fields, err := statFields(pid)
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) <= statRSS {
+		return 0, fmt.Errorf("parse %s/%d/stat: %d fields", procDir, pid, len(fields))
+	}
+	pages, err := strconv.ParseInt(fields[statRSS], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse %s/%d/stat: bad RSS", procDir, pid)
+	}
+	return pages * int64(os.Getpagesize()), nil
+}
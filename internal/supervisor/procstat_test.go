@@ -0,0 +1,73 @@
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessCPUTime(t *testing.T) {
+	dir := t.TempDir()
+	old := procDir
+	procDir = dir
+	t.Cleanup(func() { procDir = old })
+
+	// utime 250 and stime 50 ticks: 3 seconds
+	writeProc(t, dir, 100, map[string]string{
+		"stat": "100 (ffmpeg (hls)) S 1 100 100 0 -1 4194560 1000 0 0 0 250 50 0 0 20 0 4 0 12345",
+	})
+	writeProc(t, dir, 101, map[string]string{"stat": "101 (ffmpeg) S 1 101"})
+
+	if got, err := ProcessCPUTime(100); err != nil || got != 3*time.Second {
+		t.Errorf("ProcessCPUTime(100) = %v, %v; want 3s", got, err)
+	}
+	if _, err := ProcessCPUTime(101); err == nil {
+		t.Error("ProcessCPUTime() of a short stat = nil error")
+	}
+	if _, err := ProcessCPUTime(102); err == nil {
+		t.Error("ProcessCPUTime() of a missing process = nil error")
+	}
+}
+
+func TestProcessCPUTime_Self(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(procDir, "self")); err != nil {
+		t.Skip("no /proc")
+	}
+	// Burn a little CPU so there's something to count
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+	}
+	if got, err := ProcessCPUTime(os.Getpid()); err != nil || got <= 0 {
+		t.Errorf("ProcessCPUTime(self) = %v, %v; want > 0", got, err)
+	}
+}
+
+func TestProcessRSS(t *testing.T) {
+	dir := t.TempDir()
+	old := procDir
+	procDir = dir
+	t.Cleanup(func() { procDir = old })
+
+	// vsize, then 300 resident pages
+	writeProc(t, dir, 100, map[string]string{
+		"stat": "100 (hls-swarm) S 1 100 100 0 -1 4194560 1000 0 0 0 250 50 0 0 20 0 4 0 12345 104857600 300 18446744073709551615",
+	})
+	writeProc(t, dir, 101, map[string]string{"stat": "101 (hls-swarm) S 1 101"})
+
+	if got, err := ProcessRSS(100); err != nil || got != 300*int64(os.Getpagesize()) {
+		t.Errorf("ProcessRSS(100) = %d, %v; want 300 pages", got, err)
+	}
+	if _, err := ProcessRSS(101); err == nil {
+		t.Error("ProcessRSS() of a short stat = nil error")
+	}
+}
+
+func TestProcessRSS_Self(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(procDir, "self")); err != nil {
+		t.Skip("no /proc")
+	}
+	if got, err := ProcessRSS(os.Getpid()); err != nil || got <= 0 {
+		t.Errorf("ProcessRSS(self) = %d, %v; want > 0", got, err)
+	}
+}
@@ -0,0 +1,44 @@
+package supervisor
+
+import "time"
+
+// QuarantineConfig parks a client that keeps failing: after Failures
+// failed runs within Window, it waits Cooldown before its next restart
+// instead of the usual backoff. Failures 0 turns quarantine off.
+//
+// A failed run is one ShouldReset doesn't forgive: a non-zero exit after
+// less than BackoffResetThreshold of uptime.
+type QuarantineConfig struct {
+	Failures int
+	Window   time.Duration
+	Cooldown time.Duration
+}
+
+// quarantine counts a client's recent failures against its
+// QuarantineConfig.
+type quarantine struct {
+	config   QuarantineConfig
+	failures []time.Time // Within config.Window, oldest first
+}
+
+// fail records a failed run at now and reports whether it puts the
+// client in quarantine. Tripping clears the count, so a client let out
+// gets Failures more tries before it is parked again.
+func (q *quarantine) fail(now time.Time) bool {
+	if q.config.Failures <= 0 {
+		return false
+	}
+	cutoff := now.Add(-q.config.Window)
+	kept := q.failures[:0]
+	for _, t := range q.failures {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	q.failures = append(kept, now)
+	if len(q.failures) < q.config.Failures {
+		return false
+	}
+	q.failures = q.failures[:0]
+	return true
+}
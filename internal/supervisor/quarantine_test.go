@@ -0,0 +1,41 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuarantine_Fail(t *testing.T) {
+	start := time.Now()
+	q := quarantine{config: QuarantineConfig{Failures: 3, Window: time.Minute, Cooldown: time.Minute}}
+
+	steps := []struct {
+		at   time.Duration // Since start
+		want bool
+	}{
+		{0, false},
+		{10 * time.Second, false},
+		{65 * time.Second, false}, // The first has left the window
+		{68 * time.Second, true},  // Three within a minute
+		{80 * time.Second, false}, // Count starts over
+		{85 * time.Second, false},
+		{90 * time.Second, true},
+	}
+	for i, step := range steps {
+		if got := q.fail(start.Add(step.at)); got != step.want {
+			t.Errorf("step %d (%v): fail() = %v, want %v", i, step.at, got, step.want)
+		}
+	}
+}
+
+func TestQuarantine_Off(t *testing.T) {
+	q := quarantine{}
+	for i := 0; i < 100; i++ {
+		if q.fail(time.Now()) {
+			t.Fatal("fail() = true with quarantine off")
+		}
+	}
+	if len(q.failures) != 0 {
+		t.Errorf("failures kept with quarantine off: %d", len(q.failures))
+	}
+}
@@ -19,6 +19,10 @@ const (
 
 	// StateStopped indicates the client has been permanently stopped.
 	StateStopped
+
+	// StateQuarantined indicates the client failed too often and is parked
+	// for the quarantine cooldown before its next restart.
+	StateQuarantined
 )
 
 // String returns a human-readable name for the state.
@@ -34,13 +38,16 @@ func (s State) String() string {
 		return "backoff"
 	case StateStopped:
 		return "stopped"
+	case StateQuarantined:
+		return "quarantined"
 	default:
 		return "unknown"
 	}
 }
 
 // IsActive returns true if the state represents an active client
-// (either running or in the process of starting/restarting).
+// (either running or in the process of starting/restarting). A
+// quarantined client is parked, so it isn't active.
 func (s State) IsActive() bool {
 	return s == StateStarting || s == StateRunning || s == StateBackoff
 }
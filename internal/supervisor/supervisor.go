@@ -4,10 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
 	"os/exec"
+	"runtime/debug"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -15,6 +16,17 @@ import (
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/parser"
 )
 
+var (
+	// ErrMaxRestarts is returned by Run once the client has been
+	// restarted MaxRestarts times, wrapping the last attempt's error.
+	ErrMaxRestarts = errors.New("max restarts reached")
+
+	// ErrBuildFailed wraps a ProcessBuilder's error: the client's command
+	// couldn't be built (a -token-url fetch failing, say). The attempt is
+	// retried like a failed run.
+	ErrBuildFailed = errors.New("build command failed")
+)
+
 // ProcessBuilder creates executable commands for clients.
 // This interface allows the supervisor to be decoupled from FFmpeg specifics.
 type ProcessBuilder interface {
@@ -39,13 +51,65 @@ type Callbacks struct {
 	// OnStart is called when a client process starts.
 	OnStart func(clientID int, pid int)
 
+	// OnQuarantine is called when a client is quarantined, before it waits
+	// out the cooldown.
+	OnQuarantine func(clientID int, failures int, cooldown time.Duration)
+
 	// OnExit is called when a client process exits.
 	OnExit func(clientID int, exitCode int, uptime time.Duration)
 
 	// OnRestart is called before a restart attempt.
 	OnRestart func(clientID int, attempt int, delay time.Duration)
+
+	// OnLineTruncated is called when an output line is cut at the maximum
+	// line length. Runs on the reader goroutine and must not block.
+	OnLineTruncated func(clientID int)
+
+	// OnInvalidUTF8 is called for an output line that had invalid UTF-8,
+	// with the number of invalid runs replaced by U+FFFD. Runs on the
+	// reader goroutine and must not block.
+	OnInvalidUTF8 func(clientID int, n int)
+
+	// OnParserPanic is called when a stats parser panics on a line; the
+	// line is skipped and the parser reset. Runs on the parser goroutine
+	// and must not block.
+	OnParserPanic func(clientID int, stream string)
+
+	// OnStopSignal is called for each signal sent to stop a process: the
+	// stop signal, then SIGKILL if it outlived the grace period.
+	OnStopSignal func(clientID int, sig syscall.Signal)
+	// OnSessionStart is called when a process starts a new session (see
+	// Config.SessionLength), after OnStart. Restarts after a failure
+	// continue the session.
+	OnSessionStart func(clientID int)
+	// OnSessionEnd is called when a process was stopped at the end of its
+	// session, before OnExit, with how long the session lasted.
+	OnSessionEnd func(clientID int, length time.Duration)
+	// OnStreamEnd is called when a process played its stream to the end
+	// (see Config.AtEnd), before OnExit, with how long the play took.
+	OnStreamEnd func(clientID int, played time.Duration)
 }
 
+// EndPolicy says what a supervisor does when its process plays the stream
+// to the end: a clean exit (code 0) that no stop asked for, as FFmpeg's
+// at the end of a VOD asset.
+type EndPolicy int
+
+const (
+	EndRestart EndPolicy = iota // Restart it like any other exit (the default)
+	EndReplay                   // Start the next play at once
+	EndStop                     // Stop: the client is done
+)
+
+// minReplayUptime is how long a play has to last for the next one to
+// start at once (EndReplay); shorter ones wait out the backoff, so an
+// asset that ends as soon as it starts can't spin a client.
+const minReplayUptime = time.Second
+
+// DefaultStopGrace is how long a process has to exit after the stop
+// signal before it is killed, unless Config.StopGrace says otherwise.
+const DefaultStopGrace = 5 * time.Second
+
 // Supervisor manages the lifecycle of a single client process.
 // It handles starting, monitoring, and restarting the process with backoff.
 type Supervisor struct {
@@ -55,34 +119,74 @@ type Supervisor struct {
 	logger    *slog.Logger
 	callbacks Callbacks
 
+	// Why the last process failed, for the backoff overrides (optional)
+	exitCauses func(clientID int) []string
+
 	// State management
 	state     State
 	stateMu   sync.RWMutex
 	startTime time.Time
 
 	// Current process
-	cmd   *exec.Cmd
-	cmdMu sync.Mutex
+	cmd      *exec.Cmd
+	pid      int           // Of cmd once started, 0 otherwise
+	paused   bool          // cmd is stopped with SIGSTOP (see Pause)
+	stopping bool          // cmd has been sent the stop signal
+	exited   chan struct{} // Closed once cmd has exited
+	cmdMu    sync.Mutex
+
+	// Stopping: stopSignal, then SIGKILL after stopGrace
+	stopSignal syscall.Signal
+	stopGrace  time.Duration
+
+	// Viewer sessions (see Config.SessionLength)
+	sessionLength func() time.Duration
+	newSession    bool      // The next process starts a session
+	sessionStart  time.Time // Of the current session
+	sessionEnd    time.Time // Zero = unlimited
+	sessionOver   bool      // cmd was stopped at sessionEnd; guarded by cmdMu
+
+	// What to do once the stream has been played to the end
+	atEnd     EndPolicy
+	streamEnd bool // The last process played the stream to the end
 
 	// Configuration
 	maxRestarts int // 0 = unlimited
 	restarts    int
+	lastErr     error // The last run's, for ErrMaxRestarts
+	quarantine  quarantine
 
 	// Stats collection (metrics enhancement)
 	statsEnabled       bool
 	statsBufferSize    int
 	statsDropThreshold float64
+	statsMaxLineLength int
 
-	// FD-based progress is always used when stats are enabled
-	// Provides clean separation from stderr without creating filesystem files
+	// Where -progress output is read from (see parser.ProgressMode)
+	progressMode      parser.ProgressMode
+	progressSocketDir string
 
-	// Parsing pipelines (created per runOnce)
+	// Parsing pipelines and their sources (created per runOnce), and the
+	// finished runs' per-path counters (see StreamStats). streamMu guards
+	// them against the stats readers.
+	streamMu         sync.Mutex
 	progressPipeline *parser.Pipeline
 	stderrPipeline   *parser.Pipeline
+	progressSource   parser.LineSource
+	stderrSource     parser.LineSource
+	progressTotals   StreamStats
+	stderrTotals     StreamStats
 
 	// Parsers (set externally or use defaults)
 	progressParser parser.LineParser
 	stderrParser   parser.LineParser
+
+	// CPU affinity and priority each process starts with (see launch),
+	// and whether a failure to apply them was logged (once per client)
+	cpus           []int
+	priority       Priority
+	affinityWarned bool
+	priorityWarned bool
 }
 
 // Config holds configuration for creating a new Supervisor.
@@ -94,14 +198,52 @@ type Config struct {
 	Callbacks   Callbacks
 	MaxRestarts int // 0 = unlimited
 
+	// Quarantine parks a client that keeps failing (zero = off)
+	Quarantine QuarantineConfig
+
+	// ExitCauses returns what the client's last process failed on, most
+	// specific first ("http-404", "reset"), to pick a Backoff override.
+	// The exit code ("exit-1") is always tried after them. Optional.
+	ExitCauses func(clientID int) []string
+
 	// Stats collection
 	StatsEnabled       bool
 	StatsBufferSize    int
 	StatsDropThreshold float64
+	StatsMaxLineLength int // Longer output lines are truncated (0 = parser default)
+
+	// ProgressMode picks the progress path ("" = pipe); in socket mode
+	// the sockets go in ProgressSocketDir ("" = os.TempDir()). It must
+	// match the builder's, which writes the -progress argument.
+	ProgressMode      parser.ProgressMode
+	ProgressSocketDir string
 
 	// Parsers (optional - defaults to NoopParser)
 	ProgressParser parser.LineParser
 	StderrParser   parser.LineParser
+
+	// CPUs to pin the process to (optional, see CPUAllocator)
+	CPUs []int
+
+	// Priority to give the process (zero = the swarm's)
+	Priority Priority
+
+	// StopSignal is sent to the process group to stop it, so FFmpeg can
+	// finish cleanly (0 = SIGTERM); SIGKILL follows if it is still running
+	// after StopGrace (0 = DefaultStopGrace).
+	StopSignal syscall.Signal
+	StopGrace  time.Duration
+
+	// SessionLength draws the length of each viewer session (nil =
+	// unlimited). At its end the process is stopped cleanly and a new one
+	// starts a new session at once: not a failure, and not counted as a
+	// restart.
+	SessionLength func() time.Duration
+
+	// AtEnd is what to do when the process plays the stream to the end.
+	// Unless it is EndRestart, the play is not a failure and not counted
+	// as a restart.
+	AtEnd EndPolicy
 }
 
 // New creates a new Supervisor with the given configuration.
@@ -128,19 +270,40 @@ func New(cfg Config) *Supervisor {
 		threshold = 0.01
 	}
 
+	stopSignal := cfg.StopSignal
+	if stopSignal == 0 {
+		stopSignal = syscall.SIGTERM
+	}
+	stopGrace := cfg.StopGrace
+	if stopGrace <= 0 {
+		stopGrace = DefaultStopGrace
+	}
+
 	return &Supervisor{
 		clientID:           cfg.ClientID,
 		builder:            cfg.Builder,
 		backoff:            cfg.Backoff,
 		logger:             cfg.Logger,
 		callbacks:          cfg.Callbacks,
+		exitCauses:         cfg.ExitCauses,
 		state:              StateCreated,
 		maxRestarts:        cfg.MaxRestarts,
+		quarantine:         quarantine{config: cfg.Quarantine},
 		statsEnabled:       cfg.StatsEnabled,
 		statsBufferSize:    bufferSize,
 		statsDropThreshold: threshold,
+		statsMaxLineLength: cfg.StatsMaxLineLength,
+		progressMode:       cfg.ProgressMode,
+		progressSocketDir:  cfg.ProgressSocketDir,
 		progressParser:     progressParser,
 		stderrParser:       stderrParser,
+		cpus:               cfg.CPUs,
+		priority:           cfg.Priority,
+		stopSignal:         stopSignal,
+		stopGrace:          stopGrace,
+		sessionLength:      cfg.SessionLength,
+		newSession:         true,
+		atEnd:              cfg.AtEnd,
 	}
 }
 
@@ -169,7 +332,10 @@ func (s *Supervisor) Run(ctx context.Context) error {
 				"restarts", s.restarts,
 				"max", s.maxRestarts,
 			)
-			return errors.New("max restarts reached")
+			if s.lastErr != nil {
+				return fmt.Errorf("%w after %d restarts: %w", ErrMaxRestarts, s.restarts, s.lastErr)
+			}
+			return fmt.Errorf("%w after %d restarts", ErrMaxRestarts, s.restarts)
 		}
 
 		// Start the process
@@ -179,14 +345,63 @@ func (s *Supervisor) Run(ctx context.Context) error {
 			s.setState(StateStopped)
 			return ctx.Err()
 		}
+		s.lastErr = err
+
+		if s.sessionOver {
+			// The viewer left: a new session takes its place at once
+			s.backoff.Reset()
+			s.newSession = true
+			continue
+		}
+		if s.streamEnd {
+			if s.atEnd == EndStop {
+				s.setState(StateStopped)
+				s.logger.Info("client_done", "client_id", s.clientID, "reason", "stream_ended")
+				return nil
+			}
+			// Played to the end: the next play starts at once, unless
+			// the plays end as soon as they start
+			if uptime >= minReplayUptime {
+				s.backoff.Reset()
+				continue
+			}
+			s.setState(StateBackoff)
+			select {
+			case <-ctx.Done():
+				s.setState(StateStopped)
+				return ctx.Err()
+			case <-time.After(s.backoff.Next()):
+			}
+			continue
+		}
 
 		// Process exited, determine if we should reset backoff
-		if ShouldReset(uptime, exitCode) {
+		failed := !ShouldReset(uptime, exitCode)
+		if !failed {
 			s.backoff.Reset()
 		}
 
-		// Calculate backoff delay
-		delay := s.backoff.Next()
+		// Calculate backoff delay, by why the process exited
+		causes := s.causes(exitCode)
+		delay := s.backoff.NextFor(causes...)
+		wait := StateBackoff
+		if failed && s.quarantine.fail(time.Now()) {
+			// Parked: the cooldown replaces the backoff, which starts
+			// over once the client is let out
+			delay = s.quarantine.config.Cooldown
+			wait = StateQuarantined
+			s.backoff.Reset()
+			s.logger.Warn("client_quarantined",
+				"client_id", s.clientID,
+				"failures", s.quarantine.config.Failures,
+				"window", s.quarantine.config.Window.String(),
+				"cooldown", delay.String(),
+				"causes", causes,
+			)
+			if s.callbacks.OnQuarantine != nil {
+				s.callbacks.OnQuarantine(s.clientID, s.quarantine.config.Failures, delay)
+			}
+		}
 		s.restarts++
 
 		// Notify callback
@@ -198,10 +413,11 @@ func (s *Supervisor) Run(ctx context.Context) error {
 			"client_id", s.clientID,
 			"attempt", s.restarts,
 			"delay", delay.String(),
+			"causes", causes,
 		)
 
-		// Wait with backoff
-		s.setState(StateBackoff)
+		// Wait with backoff (or out the quarantine)
+		s.setState(wait)
 		select {
 		case <-ctx.Done():
 			s.setState(StateStopped)
@@ -212,30 +428,126 @@ func (s *Supervisor) Run(ctx context.Context) error {
 	}
 }
 
+// maxPanicLineLog is how much of the offending line a parser_panic log
+// keeps.
+const maxPanicLineLog = 256
+
+// parserPanicked logs a parser panic and reports it through OnParserPanic.
+func (s *Supervisor) parserPanicked(stream, line string, v any) {
+	if len(line) > maxPanicLineLog {
+		line = line[:maxPanicLineLog] + "..."
+	}
+	s.logger.Error("parser_panic",
+		"client_id", s.clientID,
+		"stream", stream,
+		"panic", fmt.Sprint(v),
+		"line", line,
+		"stack", string(debug.Stack()),
+	)
+	if s.callbacks.OnParserPanic != nil {
+		s.callbacks.OnParserPanic(s.clientID, stream)
+	}
+}
+
+// causes returns the exit causes of the last process, for NextFor.
+func (s *Supervisor) causes(exitCode int) []string {
+	var causes []string
+	if s.exitCauses != nil {
+		causes = s.exitCauses(s.clientID)
+	}
+	return append(causes, ExitCodeCause(exitCode))
+}
+
+// ExitCodeCause is the backoff override cause for an exit code, e.g.
+// "exit-1".
+func ExitCodeCause(exitCode int) string {
+	return "exit-" + strconv.Itoa(exitCode)
+}
+
+// warnLaunch logs the first failure to pin or deprioritize the client's
+// process. Either only costs measurement stability, so the client runs
+// anyway, with whatever could be applied.
+func (s *Supervisor) warnLaunch(affinityErr, priorityErr error) {
+	if affinityErr != nil && !s.affinityWarned {
+		s.affinityWarned = true
+		s.logger.Warn("cpu_affinity_failed",
+			"client_id", s.clientID,
+			"cpus", s.cpus,
+			"error", affinityErr,
+		)
+	}
+	if priorityErr != nil && !s.priorityWarned {
+		s.priorityWarned = true
+		s.logger.Warn("priority_failed",
+			"client_id", s.clientID,
+			"nice", s.priority.Nice,
+			"io_class", s.priority.IOClass,
+			"error", priorityErr,
+		)
+	}
+}
+
 // runOnce runs the process once and waits for it to exit.
 // Returns the exit code, uptime, and any error.
 func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Duration, err error) {
 	s.setState(StateStarting)
 
-	// Create pipelines for this run
+	// Create pipelines for this run (no progress one with progress off)
+	var progressPipeline, stderrPipeline *parser.Pipeline
 	if s.statsEnabled {
-		s.progressPipeline = parser.NewPipeline(
-			s.clientID, "progress",
-			s.statsBufferSize, s.statsDropThreshold,
-		)
-		s.stderrPipeline = parser.NewPipeline(
+		stderrPipeline = parser.NewPipeline(
 			s.clientID, "stderr",
 			s.statsBufferSize, s.statsDropThreshold,
 		)
+		pipelines := []*parser.Pipeline{stderrPipeline}
+		if s.progressMode != parser.ProgressOff {
+			progressPipeline = parser.NewPipeline(
+				s.clientID, "progress",
+				s.statsBufferSize, s.statsDropThreshold,
+			)
+			pipelines = append(pipelines, progressPipeline)
+		}
+		for _, p := range pipelines {
+			p.SetMaxLineLength(s.statsMaxLineLength)
+			if s.callbacks.OnLineTruncated != nil {
+				p.SetTruncateCallback(func() { s.callbacks.OnLineTruncated(s.clientID) })
+			}
+			if s.callbacks.OnInvalidUTF8 != nil {
+				p.SetInvalidUTF8Callback(func(n int) { s.callbacks.OnInvalidUTF8(s.clientID, n) })
+			}
+			p.SetPanicCallback(func(line string, v any) { s.parserPanicked(p.StreamType(), line, v) })
+		}
 	}
+	s.streamMu.Lock()
+	s.progressPipeline, s.stderrPipeline = progressPipeline, stderrPipeline
+	s.streamMu.Unlock()
 
-	// Create progress source using FD mode (always when stats enabled)
-	// No filesystem files needed - completely ephemeral
+	// Create the progress source: an anonymous pipe passed as FD 3 (no
+	// filesystem files, completely ephemeral) unless ProgressMode says a
+	// Unix socket, or nothing
 	var progressSource parser.LineSource
+	var progressSocket *parser.SocketReader
 	var progressFDRead *os.File
 	var progressFDWrite *os.File
-
-	if s.statsEnabled {
+	var stderrRead, stderrWrite *os.File
+
+	switch {
+	case progressPipeline == nil:
+		// Stats or progress off
+	case s.progressMode == parser.ProgressSocket:
+		path := parser.ProgressSocketPath(s.progressSocketDir, s.clientID)
+		var sockErr error
+		progressSocket, sockErr = parser.NewSocketReader(path, progressPipeline, s.logger.With("client_id", s.clientID))
+		if sockErr != nil {
+			s.logger.Error("progress_socket_failed",
+				"client_id", s.clientID,
+				"path", path,
+				"error", sockErr,
+			)
+			return 1, 0, fmt.Errorf("progress socket: %w", sockErr)
+		}
+		progressSource = progressSocket
+	default:
 		// Create an anonymous pipe for progress output
 		var fdErr error
 		progressFDRead, progressFDWrite, fdErr = os.Pipe()
@@ -248,7 +560,7 @@ func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Dur
 		}
 
 		// Create FD reader for progress
-		fdReader := parser.NewFDReader(progressFDRead, s.progressPipeline)
+		fdReader := parser.NewFDReader(progressFDRead, progressPipeline)
 		progressSource = fdReader
 
 		// Tell the builder to use FD 3 instead of pipe:1
@@ -256,6 +568,19 @@ func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Dur
 		s.builder.SetProgressFD(3)
 	}
 
+	// closePipes releases the output pipes and progress source when the
+	// process never started
+	closePipes := func() {
+		if progressSocket != nil {
+			progressSocket.Close()
+		}
+		for _, f := range []*os.File{progressFDRead, progressFDWrite, stderrRead, stderrWrite} {
+			if f != nil {
+				f.Close()
+			}
+		}
+	}
+
 	// Build the command (after setting FD if applicable)
 	cmd, err := s.builder.BuildCommand(ctx, s.clientID)
 	if err != nil {
@@ -263,75 +588,101 @@ func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Dur
 			"client_id", s.clientID,
 			"error", err,
 		)
-		// Clean up FD if created
-		if progressFDRead != nil {
-			progressFDRead.Close()
-		}
-		if progressFDWrite != nil {
-			progressFDWrite.Close()
-		}
-		return 1, 0, err
+		closePipes()
+		return 1, 0, fmt.Errorf("%w: %w", ErrBuildFailed, err)
+	}
+	if cmd.Cancel != nil {
+		// Not the default SIGKILL of CommandContext: the stop watcher below
+		// sends the stop signal and escalates
+		cmd.Cancel = func() error { return nil }
 	}
 
-	// Set up ExtraFiles for FD mode (always when stats enabled)
-	if s.statsEnabled && progressFDWrite != nil {
+	// Set up ExtraFiles for FD mode
+	if progressFDWrite != nil {
 		// Pass the write-end to the child process as FD 3
 		cmd.ExtraFiles = []*os.File{progressFDWrite}
 	}
 
-	// stderr is always a pipe
-	var stderr io.ReadCloser
+	// stderr is always a pipe. Ours rather than cmd.StderrPipe, which
+	// Wait closes as the process exits: output still in the pipe would be
+	// lost, and with it the end of a run's events and counts.
 	if s.statsEnabled {
-		stderr, err = cmd.StderrPipe()
+		stderrRead, stderrWrite, err = os.Pipe()
 		if err != nil {
 			s.logger.Error("failed_to_create_stderr_pipe",
 				"client_id", s.clientID,
 				"error", err,
 			)
-			if progressFDRead != nil {
-				progressFDRead.Close()
-			}
-			if progressFDWrite != nil {
-				progressFDWrite.Close()
-			}
+			closePipes()
 			return 1, 0, fmt.Errorf("stderr pipe: %w", err)
 		}
+		cmd.Stderr = stderrWrite
 	}
 
-	// Set process group for clean shutdown
+	// Own process group for clean shutdown: stops signal the whole group,
+	// and whatever is left of it once FFmpeg exits is killed. The owner tag
+	// lets FindOrphans spot the group after a crash.
 	cmd.SysProcAttr = &syscall.SysProcAttr{
 		Setpgid: true,
 	}
+	cmd.Env = append(cmd.Environ(), OwnerEnv+"="+strconv.Itoa(os.Getpid()))
 
 	// Store command reference
+	exited := make(chan struct{})
 	s.cmdMu.Lock()
 	s.cmd = cmd
+	s.exited = exited
+	s.stopping = false
+	s.sessionOver = false
 	s.cmdMu.Unlock()
 
-	// Start the process
+	// Start reading progress before the process, which connects to the
+	// socket as it starts
+	if progressSource != nil {
+		go progressSource.Run()
+		<-progressSource.Ready()
+	}
+
+	// Start the process, pinned and deprioritized from the start
 	s.startTime = time.Now()
-	if err := cmd.Start(); err != nil {
+	err, affinityErr, priorityErr := launch(cmd, s.cpus, s.priority)
+	s.warnLaunch(affinityErr, priorityErr)
+	if err != nil {
 		s.logger.Error("failed_to_start_process",
 			"client_id", s.clientID,
 			"error", err,
 		)
-		if progressFDRead != nil {
-			progressFDRead.Close()
-		}
-		if progressFDWrite != nil {
-			progressFDWrite.Close()
-		}
+		closePipes()
 		return 1, 0, err
 	}
 
-	// IMPORTANT: Close parent's write-end after Start()
+	// IMPORTANT: Close parent's write-ends after Start()
 	// This ensures EOF behavior is correct when FFmpeg exits
 	if progressFDWrite != nil {
 		progressFDWrite.Close()
 		progressFDWrite = nil // Clear reference
 	}
+	if stderrWrite != nil {
+		stderrWrite.Close()
+		stderrWrite = nil
+	}
 
 	pid := cmd.Process.Pid
+	s.cmdMu.Lock()
+	s.pid = pid
+	s.cmdMu.Unlock()
+
+	// Stop the process when the context ends
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.cmdMu.Lock()
+			s.stopLocked()
+			s.cmdMu.Unlock()
+		case <-exited:
+		}
+	}()
+
 	s.setState(StateRunning)
 
 	s.logger.Info("client_started",
@@ -343,24 +694,25 @@ func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Dur
 	// Start parsing pipelines if stats enabled
 	var parseWg sync.WaitGroup
 	if s.statsEnabled {
-		// Start progress reader (FD mode or pipe mode)
-		if progressSource != nil {
-			go progressSource.Run()
-		}
-
-		// Start stderr reader (always pipe)
-		stderrSource := parser.NewPipeReader(stderr, s.stderrPipeline)
+		// Start stderr reader (always pipe); the progress one runs already
+		stderrSource := parser.NewPipeReader(stderrRead, stderrPipeline)
+		s.streamMu.Lock()
+		s.progressSource, s.stderrSource = progressSource, stderrSource
+		s.streamMu.Unlock()
 		go stderrSource.Run()
 
 		// Start Layer 2 (parsers)
-		parseWg.Add(2)
-		go func() {
-			defer parseWg.Done()
-			s.progressPipeline.RunParser(s.progressParser)
-		}()
+		if progressPipeline != nil {
+			parseWg.Add(1)
+			go func() {
+				defer parseWg.Done()
+				progressPipeline.RunParser(s.progressParser)
+			}()
+		}
+		parseWg.Add(1)
 		go func() {
 			defer parseWg.Done()
-			s.stderrPipeline.RunParser(s.stderrParser)
+			stderrPipeline.RunParser(s.stderrParser)
 		}()
 	}
 
@@ -368,20 +720,58 @@ func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Dur
 	if s.callbacks.OnStart != nil {
 		s.callbacks.OnStart(s.clientID, pid)
 	}
+	if s.newSession {
+		s.startSession()
+	}
+	if !s.sessionEnd.IsZero() {
+		timer := time.AfterFunc(time.Until(s.sessionEnd), func() {
+			s.cmdMu.Lock()
+			defer s.cmdMu.Unlock()
+			if s.cmd == cmd && !s.stopping && s.stopLocked() {
+				s.sessionOver = true
+			}
+		})
+		defer timer.Stop()
+	}
 
 	// Wait for process to exit
 	waitErr := cmd.Wait()
+	close(exited)
+	if syscall.Kill(-pid, syscall.SIGKILL) == nil {
+		// Children FFmpeg left running in its group
+		s.logger.Warn("orphaned_children_killed", "client_id", s.clientID, "pgid", pid)
+	}
 	uptime = time.Since(s.startTime)
 	exitCode = extractExitCode(waitErr)
-
-	// Close FD reader (if used) - this will close the pipeline channel
-	if progressFDRead != nil {
-		progressFDRead.Close()
+	var exitErr *exec.ExitError
+	if ps := cmd.ProcessState; ps != nil && !errors.As(waitErr, &exitErr) {
+		// Exited cleanly after the stop signal: Wait reports the context
+		// error instead
+		exitCode = ps.ExitCode()
 	}
 
-	// Wait for parsers to drain remaining data (with timeout)
+	// Wait for parsers to drain remaining data (with timeout). The
+	// readers hit EOF once the process group is gone, which closes the
+	// pipeline channels; closing the read ends after releases any reader
+	// a stray writer still holds up.
 	if s.statsEnabled {
 		s.drainParsers(&parseWg)
+		s.finishStreams()
+	}
+	for _, f := range []*os.File{progressFDRead, stderrRead} {
+		if f != nil {
+			f.Close()
+		}
+	}
+	if progressSocket != nil {
+		if progressSocket.FailedToConnect() {
+			s.logger.Warn("progress_socket_unconnected",
+				"client_id", s.clientID,
+				"path", progressSocket.SocketPath(),
+				"reason", "FFmpeg never connected; it may not support unix:// progress output",
+			)
+		}
+		progressSocket.Close()
 	}
 
 	s.logger.Info("client_exited",
@@ -394,9 +784,25 @@ func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Dur
 	// Clear command reference
 	s.cmdMu.Lock()
 	s.cmd = nil
+	s.pid = 0
+	s.paused = false
+	sessionOver := s.sessionOver
+	s.streamEnd = s.atEnd != EndRestart && exitCode == 0 && !s.stopping && ctx.Err() == nil
 	s.cmdMu.Unlock()
 
 	// Notify callback
+	if sessionOver {
+		s.logger.Info("client_session_ended",
+			"client_id", s.clientID,
+			"length", time.Since(s.sessionStart).String(),
+		)
+		if s.callbacks.OnSessionEnd != nil {
+			s.callbacks.OnSessionEnd(s.clientID, time.Since(s.sessionStart))
+		}
+	}
+	if s.streamEnd && s.callbacks.OnStreamEnd != nil {
+		s.callbacks.OnStreamEnd(s.clientID, uptime)
+	}
 	if s.callbacks.OnExit != nil {
 		s.callbacks.OnExit(s.clientID, exitCode, uptime)
 	}
@@ -404,6 +810,22 @@ func (s *Supervisor) runOnce(ctx context.Context) (exitCode int, uptime time.Dur
 	return exitCode, uptime, waitErr
 }
 
+// startSession starts a new viewer session with the process just
+// started, drawing its length.
+func (s *Supervisor) startSession() {
+	s.newSession = false
+	s.sessionStart = time.Now()
+	s.sessionEnd = time.Time{}
+	if s.sessionLength != nil {
+		if d := s.sessionLength(); d > 0 {
+			s.sessionEnd = s.sessionStart.Add(d)
+		}
+	}
+	if s.callbacks.OnSessionStart != nil {
+		s.callbacks.OnSessionStart(s.clientID)
+	}
+}
+
 // drainParsers waits for parsing pipelines to finish with a timeout.
 func (s *Supervisor) drainParsers(parseWg *sync.WaitGroup) {
 	const drainTimeout = 5 * time.Second
@@ -432,13 +854,17 @@ func (s *Supervisor) drainParsers(parseWg *sync.WaitGroup) {
 func (s *Supervisor) logPipelineStats() {
 	if s.progressPipeline != nil {
 		read, dropped, parsed := s.progressPipeline.Stats()
-		if dropped > 0 || s.logger.Enabled(nil, slog.LevelDebug) {
+		truncated := s.progressPipeline.Truncated()
+		invalid := s.progressPipeline.InvalidUTF8()
+		if dropped > 0 || truncated > 0 || invalid > 0 || s.logger.Enabled(nil, slog.LevelDebug) {
 			s.logger.Info("pipeline_stats",
 				"client_id", s.clientID,
 				"stream", "progress",
 				"lines_read", read,
 				"lines_dropped", dropped,
 				"lines_parsed", parsed,
+				"lines_truncated", truncated,
+				"invalid_utf8", invalid,
 				"degraded", s.progressPipeline.IsDegraded(),
 			)
 		}
@@ -446,63 +872,163 @@ func (s *Supervisor) logPipelineStats() {
 
 	if s.stderrPipeline != nil {
 		read, dropped, parsed := s.stderrPipeline.Stats()
-		if dropped > 0 || s.logger.Enabled(nil, slog.LevelDebug) {
+		truncated := s.stderrPipeline.Truncated()
+		invalid := s.stderrPipeline.InvalidUTF8()
+		if dropped > 0 || truncated > 0 || invalid > 0 || s.logger.Enabled(nil, slog.LevelDebug) {
 			s.logger.Info("pipeline_stats",
 				"client_id", s.clientID,
 				"stream", "stderr",
 				"lines_read", read,
 				"lines_dropped", dropped,
 				"lines_parsed", parsed,
+				"lines_truncated", truncated,
+				"invalid_utf8", invalid,
 				"degraded", s.stderrPipeline.IsDegraded(),
 			)
 		}
 	}
 }
 
-// Stop gracefully stops the supervised process.
-// It first sends SIGTERM, then SIGKILL if the process doesn't exit.
+// Stop gracefully stops the supervised process: the stop signal, then
+// SIGKILL after the grace period. It waits up to timeout for the process
+// to exit and kills it if it hasn't by then.
 func (s *Supervisor) Stop(timeout time.Duration) error {
 	s.cmdMu.Lock()
-	cmd := s.cmd
+	stopped := s.stopLocked()
+	exited := s.exited
 	s.cmdMu.Unlock()
 
-	if cmd == nil || cmd.Process == nil {
+	if !stopped {
 		return nil
 	}
 
-	// Send SIGTERM to the process group
-	pgid, err := syscall.Getpgid(cmd.Process.Pid)
-	if err == nil {
-		syscall.Kill(-pgid, syscall.SIGTERM)
-	} else {
-		cmd.Process.Signal(syscall.SIGTERM)
-	}
-
-	// Wait for graceful shutdown
-	done := make(chan struct{})
-	go func() {
-		cmd.Wait()
-		close(done)
-	}()
-
 	select {
-	case <-done:
+	case <-exited:
 		return nil
 	case <-time.After(timeout):
-		// Force kill
+		s.cmdMu.Lock()
+		defer s.cmdMu.Unlock()
+		if s.exited != exited || s.pid == 0 {
+			return nil // Exited just now
+		}
 		s.logger.Warn("force_killing_process",
 			"client_id", s.clientID,
-			"pid", cmd.Process.Pid,
+			"pid", s.pid,
 		)
-		if pgid, err := syscall.Getpgid(cmd.Process.Pid); err == nil {
-			syscall.Kill(-pgid, syscall.SIGKILL)
-		} else {
-			cmd.Process.Kill()
-		}
+		s.killLocked()
 		return errors.New("process did not exit gracefully")
 	}
 }
 
+// RestartProcess ends the running process (the stop signal to its process
+// group, then SIGKILL after the grace period) without stopping
+// supervision, so the loop starts a new one after the usual backoff.
+// Returns false if no process is running.
+func (s *Supervisor) RestartProcess() bool {
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+	return s.stopLocked()
+}
+
+// stopLocked sends the stop signal to the running process, continues it if
+// paused (a stopped process only acts on signals once continued) and kills
+// it if it is still running after the grace period. A process already
+// being stopped is left to its escalation. Returns false if no process is
+// running. cmdMu must be held.
+func (s *Supervisor) stopLocked() bool {
+	if s.pid == 0 {
+		return false
+	}
+	if s.stopping {
+		return true
+	}
+	if !s.signalLocked(s.stopSignal) {
+		return false
+	}
+	s.stopping = true
+	s.notifyStopSignal(s.stopSignal)
+	if s.paused {
+		s.paused = !s.signalLocked(syscall.SIGCONT)
+	}
+
+	cmd, exited := s.cmd, s.exited
+	go func() {
+		select {
+		case <-exited:
+			return
+		case <-time.After(s.stopGrace):
+		}
+		s.cmdMu.Lock()
+		defer s.cmdMu.Unlock()
+		if s.cmd == cmd && s.pid != 0 {
+			s.logger.Warn("stop_grace_expired",
+				"client_id", s.clientID,
+				"pid", s.pid,
+				"signal", s.stopSignal.String(),
+				"grace", s.stopGrace.String(),
+			)
+			s.killLocked()
+		}
+	}()
+	return true
+}
+
+// killLocked sends SIGKILL to the running process. cmdMu must be held.
+func (s *Supervisor) killLocked() {
+	if s.signalLocked(syscall.SIGKILL) {
+		s.notifyStopSignal(syscall.SIGKILL)
+	}
+}
+
+func (s *Supervisor) notifyStopSignal(sig syscall.Signal) {
+	if s.callbacks.OnStopSignal != nil {
+		s.callbacks.OnStopSignal(s.clientID, sig)
+	}
+}
+
+// Pause stops the running process with SIGSTOP, as if its network had
+// gone away: it sends no requests and reads nothing, so the origin sees
+// its connections stall. Returns false if no process is running or it is
+// already paused.
+func (s *Supervisor) Pause() bool {
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+
+	if s.pid == 0 || s.paused {
+		return false
+	}
+	s.paused = s.signalLocked(syscall.SIGSTOP)
+	return s.paused
+}
+
+// Resume continues a process stopped by Pause. Returns false if it wasn't
+// paused.
+func (s *Supervisor) Resume() bool {
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+
+	if !s.paused {
+		return false
+	}
+	s.paused = !s.signalLocked(syscall.SIGCONT)
+	return !s.paused
+}
+
+// Paused reports whether the process is stopped by Pause.
+func (s *Supervisor) Paused() bool {
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+	return s.paused
+}
+
+// signalLocked sends sig to the process group of cmd. cmdMu must be held.
+func (s *Supervisor) signalLocked(sig syscall.Signal) bool {
+	if pgid, err := syscall.Getpgid(s.cmd.Process.Pid); err == nil {
+		return syscall.Kill(-pgid, sig) == nil
+	}
+	return s.cmd.Process.Signal(sig) == nil
+}
+
 // State returns the current state of the supervisor.
 func (s *Supervisor) State() State {
 	s.stateMu.RLock()
@@ -527,6 +1053,14 @@ func (s *Supervisor) ClientID() int {
 	return s.clientID
 }
 
+// PID returns the process ID of the running FFmpeg, or 0 if none is
+// running.
+func (s *Supervisor) PID() int {
+	s.cmdMu.Lock()
+	defer s.cmdMu.Unlock()
+	return s.pid
+}
+
 // Restarts returns the number of restarts that have occurred.
 func (s *Supervisor) Restarts() int {
 	return s.restarts
@@ -551,9 +1085,55 @@ func (s *Supervisor) SetParsers(progress, stderr parser.LineParser) {
 	}
 }
 
+// StreamStats counts what one output path (progress or stderr) carried
+// to its parser. Dropped lines were read but thrown away because the
+// parser fell behind: the path's backpressure, which FFmpeg never sees.
+type StreamStats struct {
+	Bytes   int64
+	Lines   int64
+	Dropped int64
+}
+
+// add returns the sum of s and o.
+func (s StreamStats) add(o StreamStats) StreamStats {
+	return StreamStats{Bytes: s.Bytes + o.Bytes, Lines: s.Lines + o.Lines, Dropped: s.Dropped + o.Dropped}
+}
+
+// streamStats reads one path's counters: bytes from its source, lines
+// from its pipeline. Zero without a source (the path isn't running).
+func streamStats(src parser.LineSource, p *parser.Pipeline) StreamStats {
+	if src == nil || p == nil {
+		return StreamStats{}
+	}
+	bytes, _, _ := src.Stats()
+	read, dropped, _ := p.Stats()
+	return StreamStats{Bytes: bytes, Lines: read, Dropped: dropped}
+}
+
+// StreamStats returns the progress and stderr paths' counters over all of
+// the client's runs, the running one included. Progress stays zero with
+// progress off.
+func (s *Supervisor) StreamStats() (progress, stderr StreamStats) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	return s.progressTotals.add(streamStats(s.progressSource, s.progressPipeline)),
+		s.stderrTotals.add(streamStats(s.stderrSource, s.stderrPipeline))
+}
+
+// finishStreams adds the finished run's counters to the totals.
+func (s *Supervisor) finishStreams() {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	s.progressTotals = s.progressTotals.add(streamStats(s.progressSource, s.progressPipeline))
+	s.stderrTotals = s.stderrTotals.add(streamStats(s.stderrSource, s.stderrPipeline))
+	s.progressSource, s.stderrSource = nil, nil
+}
+
 // PipelineStats returns the pipeline statistics for both streams.
 // Returns zeros if stats collection is disabled or pipelines haven't run.
 func (s *Supervisor) PipelineStats() (progressRead, progressDropped, stderrRead, stderrDropped int64) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
 	if s.progressPipeline != nil {
 		progressRead, progressDropped, _ = s.progressPipeline.Stats()
 	}
@@ -565,6 +1145,8 @@ func (s *Supervisor) PipelineStats() (progressRead, progressDropped, stderrRead,
 
 // IsMetricsDegraded returns true if either pipeline has dropped >threshold% of lines.
 func (s *Supervisor) IsMetricsDegraded() bool {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
 	if s.progressPipeline != nil && s.progressPipeline.IsDegraded() {
 		return true
 	}
@@ -6,10 +6,16 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -367,6 +373,7 @@ func TestState_String(t *testing.T) {
 		{StateRunning, "running"},
 		{StateBackoff, "backoff"},
 		{StateStopped, "stopped"},
+		{StateQuarantined, "quarantined"},
 		{State(99), "unknown"},
 		{State(-1), "unknown"},
 	}
@@ -390,6 +397,7 @@ func TestState_IsActive(t *testing.T) {
 		{StateRunning, true},
 		{StateBackoff, true},
 		{StateStopped, false},
+		{StateQuarantined, false},
 		{State(99), false},
 	}
 
@@ -412,6 +420,7 @@ func TestState_IsTerminal(t *testing.T) {
 		{StateRunning, false},
 		{StateBackoff, false},
 		{StateStopped, true},
+		{StateQuarantined, false},
 		{State(99), false},
 	}
 
@@ -634,8 +643,8 @@ func TestSupervisor_MaxRestarts(t *testing.T) {
 
 	err := sup.Run(ctx)
 
-	if err == nil || !strings.Contains(err.Error(), "max restarts") {
-		t.Errorf("expected 'max restarts' error, got %v", err)
+	if !errors.Is(err, ErrMaxRestarts) {
+		t.Errorf("expected ErrMaxRestarts, got %v", err)
 	}
 	if sup.Restarts() != 3 {
 		t.Errorf("Restarts() = %d, want 3", sup.Restarts())
@@ -645,6 +654,115 @@ func TestSupervisor_MaxRestarts(t *testing.T) {
 	}
 }
 
+func TestSupervisor_BuildFailed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tokenErr := errors.New("token: 503 Service Unavailable")
+	sup := New(Config{
+		ClientID: 1,
+		Builder: &mockBuilder{
+			buildFn: func(ctx context.Context, clientID int) (*exec.Cmd, error) {
+				return nil, tokenErr
+			},
+		},
+		Backoff:     newTestBackoff(),
+		Logger:      newTestLogger(),
+		MaxRestarts: 2,
+	})
+
+	err := sup.Run(ctx)
+	for _, want := range []error{ErrMaxRestarts, ErrBuildFailed, tokenErr} {
+		if !errors.Is(err, want) {
+			t.Errorf("Run() = %v, want it to wrap %q", err, want)
+		}
+	}
+}
+
+func TestSupervisor_BackoffOverrides(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	backoff := NewBackoff(1, 0, BackoffConfig{Initial: time.Hour, Max: time.Hour, Multiplier: 1})
+	backoff.SetOverrides(map[string]BackoffConfig{
+		"http-404": {Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1},
+		"exit-3":   {Initial: 2 * time.Millisecond, Max: 2 * time.Millisecond, Multiplier: 1},
+	})
+	var causes []string // What ExitCauses reports, per run
+	var delays []time.Duration
+	sup := New(Config{
+		ClientID:    1,
+		Builder:     newExitCodeBuilder(3),
+		Backoff:     backoff,
+		Logger:      newTestLogger(),
+		MaxRestarts: 2,
+		ExitCauses: func(clientID int) []string {
+			c := causes
+			causes = nil
+			return c
+		},
+		Callbacks: Callbacks{
+			OnStart: func(int, int) {
+				if len(delays) == 0 {
+					causes = []string{"http-404", "http-4xx"}
+				}
+			},
+			OnRestart: func(_ int, _ int, delay time.Duration) { delays = append(delays, delay) },
+		},
+	})
+
+	if err := sup.Run(ctx); err == nil || !strings.Contains(err.Error(), "max restarts") {
+		t.Fatalf("Run() = %v, want max restarts", err)
+	}
+	// A 404, then just the exit code; never the hour-long default
+	want := []time.Duration{time.Millisecond, 2 * time.Millisecond}
+	if !slices.Equal(delays, want) {
+		t.Errorf("restart delays = %v, want %v", delays, want)
+	}
+}
+
+func TestSupervisor_Quarantine(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var states []State
+	var quarantined int
+	var delays []time.Duration
+	sup := New(Config{
+		ClientID:    1,
+		Builder:     newExitCodeBuilder(1), // Always fail
+		Backoff:     NewBackoff(1, 0, BackoffConfig{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 1}),
+		Logger:      newTestLogger(),
+		MaxRestarts: 5,
+		Quarantine:  QuarantineConfig{Failures: 2, Window: time.Minute, Cooldown: 20 * time.Millisecond},
+		Callbacks: Callbacks{
+			OnStateChange: func(_ int, _, newState State) { states = append(states, newState) },
+			OnQuarantine: func(_ int, failures int, cooldown time.Duration) {
+				quarantined++
+				if failures != 2 || cooldown != 20*time.Millisecond {
+					t.Errorf("OnQuarantine(%d, %v), want 2 failures, 20ms", failures, cooldown)
+				}
+			},
+			OnRestart: func(_ int, _ int, delay time.Duration) { delays = append(delays, delay) },
+		},
+	})
+
+	if err := sup.Run(ctx); err == nil || !strings.Contains(err.Error(), "max restarts") {
+		t.Fatalf("Run() = %v, want max restarts", err)
+	}
+	// Every second failure parks the client; the count starts over after
+	want := []time.Duration{time.Millisecond, 20 * time.Millisecond, time.Millisecond, 20 * time.Millisecond, time.Millisecond}
+	if !slices.Equal(delays, want) {
+		t.Errorf("restart delays = %v, want %v", delays, want)
+	}
+	if quarantined != 2 {
+		t.Errorf("OnQuarantine called %d times, want 2", quarantined)
+	}
+	if n := strings.Count(fmt.Sprint(states), "quarantined"); n != 2 {
+		t.Errorf("states = %v, want quarantined twice", states)
+	}
+}
+
 func TestSupervisor_BuildError(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -771,6 +889,213 @@ func TestSupervisor_PipelineStats(t *testing.T) {
 	}
 }
 
+func TestSupervisor_ProgressModes(t *testing.T) {
+	const progress = "speed=1.00x\nprogress=continue\n" // 2 lines, 30 bytes
+	const stderr = "[hls] Opening 'seg1.ts'\n"          // 1 line, 24 bytes
+
+	tests := []struct {
+		mode         parser.ProgressMode
+		wantProgress bool
+	}{
+		{parser.ProgressPipe, true},
+		{parser.ProgressSocket, true},
+		{parser.ProgressOff, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			dir := t.TempDir()
+
+			builder := &mockBuilder{}
+			builder.buildFn = func(ctx context.Context, clientID int) (*exec.Cmd, error) {
+				script := fmt.Sprintf("printf %q >&2", stderr)
+				switch tt.mode {
+				case parser.ProgressPipe:
+					script += fmt.Sprintf("; printf %q >&3", progress)
+				case parser.ProgressSocket:
+					// Stands in for FFmpeg connecting to -progress unix://
+					go func() {
+						conn, err := net.Dial("unix", parser.ProgressSocketPath(dir, clientID))
+						if err != nil {
+							return
+						}
+						defer conn.Close()
+						io.WriteString(conn, progress)
+					}()
+				}
+				return exec.CommandContext(ctx, "sh", "-c", script), nil
+			}
+
+			var starts atomic.Int64
+			progressParser := &mockParser{}
+			sup := New(Config{
+				ClientID:          1,
+				Builder:           builder,
+				Backoff:           newTestBackoff(),
+				Logger:            newTestLogger(),
+				MaxRestarts:       2,
+				StatsEnabled:      true,
+				ProgressMode:      tt.mode,
+				ProgressSocketDir: dir,
+				ProgressParser:    progressParser,
+				Callbacks:         Callbacks{OnStart: func(int, int) { starts.Add(1) }},
+			})
+			_ = sup.Run(ctx)
+
+			runs := starts.Load()
+			if runs < 2 {
+				t.Fatalf("%d runs, want 2", runs)
+			}
+			gotProgress, gotStderr := sup.StreamStats()
+			wantStderr := StreamStats{Bytes: 24 * runs, Lines: runs}
+			if gotStderr != wantStderr {
+				t.Errorf("stderr = %+v, want %+v over %d runs", gotStderr, wantStderr, runs)
+			}
+			wantProgress := StreamStats{}
+			if tt.wantProgress {
+				wantProgress = StreamStats{Bytes: 30 * runs, Lines: 2 * runs}
+			}
+			if gotProgress != wantProgress {
+				t.Errorf("progress = %+v, want %+v over %d runs", gotProgress, wantProgress, runs)
+			}
+			if got := progressParser.LineCount(); got != int(wantProgress.Lines) {
+				t.Errorf("progress parser got %d lines, want %d", got, wantProgress.Lines)
+			}
+			if tt.mode == parser.ProgressPipe && builder.progressFD != 3 {
+				t.Errorf("progress FD = %d, want 3", builder.progressFD)
+			}
+			if _, err := os.Stat(parser.ProgressSocketPath(dir, 1)); !os.IsNotExist(err) {
+				t.Errorf("progress socket left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestSupervisor_LineTruncation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var truncated atomic.Int64
+	stderrParser := &mockParser{}
+	sup := New(Config{
+		ClientID: 7,
+		Builder: &mockBuilder{
+			buildFn: func(ctx context.Context, clientID int) (*exec.Cmd, error) {
+				script := "head -c 5000 /dev/zero | tr '\\0' x >&2; echo >&2; echo short >&2"
+				return exec.CommandContext(ctx, "bash", "-c", script), nil
+			},
+		},
+		Backoff:            newTestBackoff(),
+		Logger:             newTestLogger(),
+		MaxRestarts:        1,
+		StatsEnabled:       true,
+		StatsMaxLineLength: 1024,
+		StderrParser:       stderrParser,
+		Callbacks: Callbacks{
+			OnLineTruncated: func(clientID int) {
+				if clientID != 7 {
+					t.Errorf("OnLineTruncated clientID = %d, want 7", clientID)
+				}
+				truncated.Add(1)
+			},
+		},
+	})
+
+	_ = sup.Run(ctx)
+
+	if truncated.Load() == 0 {
+		t.Fatal("OnLineTruncated was not called for a 5000-byte line")
+	}
+	lines := stderrParser.Lines()
+	if !slices.Contains(lines, "short") {
+		t.Errorf("line after the long one was lost: %d lines", len(lines))
+	}
+	for _, line := range lines {
+		if len(line) > 1024 {
+			t.Errorf("line of %d bytes passed a 1024-byte limit", len(line))
+		}
+	}
+}
+
+func TestSupervisor_InvalidUTF8(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var invalid atomic.Int64
+	stderrParser := &mockParser{}
+	sup := New(Config{
+		ClientID: 7,
+		Builder: &mockBuilder{
+			buildFn: func(ctx context.Context, clientID int) (*exec.Cmd, error) {
+				script := `printf 'title=Bj\366rk\n' >&2`
+				return exec.CommandContext(ctx, "bash", "-c", script), nil
+			},
+		},
+		Backoff:      newTestBackoff(),
+		Logger:       newTestLogger(),
+		MaxRestarts:  1,
+		StatsEnabled: true,
+		StderrParser: stderrParser,
+		Callbacks: Callbacks{
+			OnInvalidUTF8: func(clientID int, n int) {
+				invalid.Add(int64(n))
+			},
+		},
+	})
+
+	_ = sup.Run(ctx)
+
+	if got := invalid.Load(); got != 1 {
+		t.Errorf("OnInvalidUTF8 counted %d, want 1", got)
+	}
+	if lines := stderrParser.Lines(); !slices.Contains(lines, "title=Bj\ufffdrk") {
+		t.Errorf("lines = %q, want the invalid byte replaced", lines)
+	}
+}
+
+func TestSupervisor_ParserPanic(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var panics atomic.Int64
+	stderrParser := &mockParser{parseFn: func(line string) {
+		if line == "boom" {
+			panic("pathological line")
+		}
+	}}
+	sup := New(Config{
+		ClientID: 7,
+		Builder: &mockBuilder{
+			buildFn: func(ctx context.Context, clientID int) (*exec.Cmd, error) {
+				return exec.CommandContext(ctx, "bash", "-c", "echo boom >&2; echo after >&2; sleep 0.1"), nil
+			},
+		},
+		Backoff:      newTestBackoff(),
+		Logger:       newTestLogger(),
+		MaxRestarts:  1,
+		StatsEnabled: true,
+		StderrParser: stderrParser,
+		Callbacks: Callbacks{
+			OnParserPanic: func(clientID int, stream string) {
+				if clientID != 7 || stream != "stderr" {
+					t.Errorf("OnParserPanic(%d, %q), want (7, \"stderr\")", clientID, stream)
+				}
+				panics.Add(1)
+			},
+		},
+	})
+
+	_ = sup.Run(ctx) // Must not crash the process
+
+	if panics.Load() == 0 {
+		t.Fatal("OnParserPanic was not called")
+	}
+	if !slices.Contains(stderrParser.Lines(), "after") {
+		t.Error("parser stopped after the panic")
+	}
+}
+
 func TestSupervisor_IsMetricsDegraded_NotDegraded(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -1054,6 +1379,432 @@ func TestSupervisor_UptimeWhileRunning(t *testing.T) {
 	}
 }
 
+func TestSupervisor_PIDWhileRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sup := New(Config{
+		ClientID: 1,
+		Builder:  newSleepBuilder(10 * time.Second),
+		Backoff:  newTestBackoff(),
+		Logger:   newTestLogger(),
+	})
+	if sup.PID() != 0 {
+		t.Errorf("PID() = %d before Run, expected 0", sup.PID())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sup.Run(ctx)
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	if sup.PID() <= 0 {
+		t.Errorf("PID() = %d while running, expected the process ID", sup.PID())
+	}
+
+	cancel()
+	<-done
+	if sup.PID() != 0 {
+		t.Errorf("PID() = %d after stop, expected 0", sup.PID())
+	}
+}
+
+func TestSupervisor_PauseResume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exited := make(chan struct{}, 1)
+	sup := New(Config{
+		ClientID: 1,
+		Builder:  newSleepBuilder(300 * time.Millisecond),
+		Backoff:  newTestBackoff(),
+		Logger:   newTestLogger(),
+		Callbacks: Callbacks{
+			OnExit: func(int, int, time.Duration) {
+				select {
+				case exited <- struct{}{}:
+				default:
+				}
+			},
+		},
+	})
+	if sup.Pause() {
+		t.Error("Pause() = true before Run, expected false")
+	}
+
+	go sup.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	if !sup.Pause() {
+		t.Fatal("Pause() = false while running")
+	}
+	if sup.Pause() {
+		t.Error("Pause() = true while already paused")
+	}
+
+	// Stopped, the 300ms sleep can't finish
+	select {
+	case <-exited:
+		t.Fatal("process exited while paused")
+	case <-time.After(600 * time.Millisecond):
+	}
+	if !sup.Paused() || sup.State() != StateRunning {
+		t.Errorf("Paused() = %v, State() = %v while paused", sup.Paused(), sup.State())
+	}
+
+	if !sup.Resume() {
+		t.Fatal("Resume() = false while paused")
+	}
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("process did not finish after Resume")
+	}
+	if sup.Resume() {
+		t.Error("Resume() = true when not paused")
+	}
+}
+
+func TestSupervisor_RestartProcessWhilePaused(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	exited := make(chan struct{}, 1)
+	sup := New(Config{
+		ClientID: 1,
+		Builder:  newSleepBuilder(10 * time.Second),
+		Backoff:  newTestBackoff(),
+		Logger:   newTestLogger(),
+		Callbacks: Callbacks{
+			OnExit: func(int, int, time.Duration) {
+				select {
+				case exited <- struct{}{}:
+				default:
+				}
+			},
+		},
+	})
+	go sup.Run(ctx)
+	time.Sleep(100 * time.Millisecond)
+
+	if !sup.Pause() {
+		t.Fatal("Pause() = false while running")
+	}
+	// SIGTERM alone would wait for a SIGCONT
+	if !sup.RestartProcess() {
+		t.Fatal("RestartProcess() = false while paused")
+	}
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("paused process did not exit after RestartProcess")
+	}
+}
+
+func TestSupervisor_SessionLength(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var starts, restarts int
+	var lengths []time.Duration
+	sup := New(Config{
+		ClientID:      1,
+		Builder:       newSleepBuilder(10 * time.Second),
+		Backoff:       newTestBackoff(),
+		Logger:        newTestLogger(),
+		SessionLength: func() time.Duration { return 100 * time.Millisecond },
+		Callbacks: Callbacks{
+			OnSessionStart: func(int) {
+				mu.Lock()
+				defer mu.Unlock()
+				starts++
+			},
+			OnSessionEnd: func(_ int, length time.Duration) {
+				mu.Lock()
+				defer mu.Unlock()
+				lengths = append(lengths, length)
+			},
+			OnRestart: func(int, int, time.Duration) {
+				mu.Lock()
+				defer mu.Unlock()
+				restarts++
+			},
+		},
+	})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sup.Run(ctx)
+	}()
+	time.Sleep(550 * time.Millisecond)
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lengths) < 2 || starts != len(lengths)+1 {
+		t.Errorf("sessions started %d, ended %d; want each ended one replaced", starts, len(lengths))
+	}
+	for _, l := range lengths {
+		if l < 100*time.Millisecond || l > 400*time.Millisecond {
+			t.Errorf("session lasted %s, want about 100ms", l)
+		}
+	}
+	if restarts != 0 || sup.Restarts() != 0 {
+		t.Errorf("restarts = %d (callbacks %d), want session ends not counted", sup.Restarts(), restarts)
+	}
+}
+
+func TestSupervisor_AtEnd(t *testing.T) {
+	run := func(atEnd EndPolicy, builder *mockBuilder) (ends, restarts int, err error) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		var mu sync.Mutex
+		sup := New(Config{
+			ClientID: 1,
+			Builder:  builder,
+			Backoff:  newTestBackoff(),
+			Logger:   newTestLogger(),
+			AtEnd:    atEnd,
+			Callbacks: Callbacks{
+				OnStreamEnd: func(int, time.Duration) {
+					mu.Lock()
+					defer mu.Unlock()
+					ends++
+				},
+			},
+		})
+		err = sup.Run(ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		return ends, sup.Restarts(), err
+	}
+
+	// Stop: the first play to the end is the client's last
+	if ends, restarts, err := run(EndStop, newEchoBuilder("done")); err != nil || ends != 1 || restarts != 0 {
+		t.Errorf("EndStop: Run() = %v, %d ends, %d restarts; want nil, 1, 0", err, ends, restarts)
+	}
+
+	// Replay: plays again and again, none a restart (but the one the
+	// timeout catches exiting)
+	if ends, restarts, _ := run(EndReplay, newEchoBuilder("done")); ends < 3 || restarts > 1 {
+		t.Errorf("EndReplay: %d ends, %d restarts; want several ends, no restarts", ends, restarts)
+	}
+
+	// Restart: not a stream end at all
+	if ends, restarts, _ := run(EndRestart, newEchoBuilder("done")); ends != 0 || restarts == 0 {
+		t.Errorf("EndRestart: %d ends, %d restarts; want restarts only", ends, restarts)
+	}
+
+	// A failure is no stream end
+	if ends, restarts, _ := run(EndStop, newExitCodeBuilder(1)); ends != 0 || restarts == 0 {
+		t.Errorf("EndStop on exit 1: %d ends, %d restarts; want restarts only", ends, restarts)
+	}
+
+	// Nor is a process stopped by the run ending
+	if ends, _, _ := run(EndStop, newSleepBuilder(10*time.Second)); ends != 0 {
+		t.Errorf("EndStop on a stopped process: %d ends, want 0", ends)
+	}
+}
+
+// stopSignalRecorder collects OnStopSignal and OnExit callbacks.
+type stopSignalRecorder struct {
+	mu       sync.Mutex
+	signals  []syscall.Signal
+	exitCode int
+	exited   chan struct{}
+}
+
+func newStopSignalRecorder() *stopSignalRecorder {
+	return &stopSignalRecorder{exitCode: -1, exited: make(chan struct{}, 1)}
+}
+
+func (r *stopSignalRecorder) callbacks() Callbacks {
+	return Callbacks{
+		OnStopSignal: func(_ int, sig syscall.Signal) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.signals = append(r.signals, sig)
+		},
+		OnExit: func(_ int, exitCode int, _ time.Duration) {
+			r.mu.Lock()
+			r.exitCode = exitCode
+			r.mu.Unlock()
+			select {
+			case r.exited <- struct{}{}:
+			default:
+			}
+		},
+	}
+}
+
+// newShellBuilder runs script with sh -c.
+func newShellBuilder(script string) *mockBuilder {
+	return &mockBuilder{
+		buildFn: func(ctx context.Context, clientID int) (*exec.Cmd, error) {
+			return exec.CommandContext(ctx, "sh", "-c", script), nil
+		},
+	}
+}
+
+func TestSupervisor_StopSignal(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      string
+		signal      syscall.Signal
+		wantSignals []syscall.Signal
+		wantExit    int
+	}{
+		{
+			name:        "clean exit on the stop signal",
+			script:      `trap "exit 0" INT; while :; do sleep 0.05; done`,
+			signal:      syscall.SIGINT,
+			wantSignals: []syscall.Signal{syscall.SIGINT},
+			wantExit:    0,
+		},
+		{
+			name:        "default SIGTERM",
+			script:      "sleep 10",
+			wantSignals: []syscall.Signal{syscall.SIGTERM},
+			wantExit:    128 + int(syscall.SIGTERM),
+		},
+		{
+			name:        "killed after the grace period",
+			script:      `trap "" TERM; while :; do sleep 0.05; done`,
+			wantSignals: []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL},
+			wantExit:    128 + int(syscall.SIGKILL),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			rec := newStopSignalRecorder()
+			sup := New(Config{
+				ClientID:   1,
+				Builder:    newShellBuilder(tt.script),
+				Backoff:    newTestBackoff(),
+				Logger:     newTestLogger(),
+				Callbacks:  rec.callbacks(),
+				StopSignal: tt.signal,
+				StopGrace:  300 * time.Millisecond,
+			})
+			go sup.Run(ctx)
+			time.Sleep(200 * time.Millisecond)
+
+			cancel()
+			select {
+			case <-rec.exited:
+			case <-time.After(3 * time.Second):
+				t.Fatal("process did not exit after cancel")
+			}
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			if !slices.Equal(rec.signals, tt.wantSignals) {
+				t.Errorf("stop signals = %v, want %v", rec.signals, tt.wantSignals)
+			}
+			if rec.exitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", rec.exitCode, tt.wantExit)
+			}
+		})
+	}
+}
+
+func TestSupervisor_RestartProcess_SignalsOnce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rec := newStopSignalRecorder()
+	sup := New(Config{
+		ClientID:  1,
+		Builder:   newShellBuilder(`trap "" TERM; while :; do sleep 0.05; done`),
+		Backoff:   newTestBackoff(),
+		Logger:    newTestLogger(),
+		Callbacks: rec.callbacks(),
+		StopGrace: 300 * time.Millisecond,
+	})
+	go sup.Run(ctx)
+	time.Sleep(200 * time.Millisecond)
+
+	// A second request while the first is in its grace period is a no-op
+	if !sup.RestartProcess() || !sup.RestartProcess() {
+		t.Fatal("RestartProcess() = false while running")
+	}
+	select {
+	case <-rec.exited:
+	case <-time.After(3 * time.Second):
+		t.Fatal("process did not exit after RestartProcess")
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if want := []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL}; !slices.Equal(rec.signals, want) {
+		t.Errorf("stop signals = %v, want %v", rec.signals, want)
+	}
+}
+
+func TestSupervisor_KillsLeftoverProcessGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// FFmpeg exits but leaves a child running in its process group
+	dir := t.TempDir()
+	rec := newStopSignalRecorder()
+	sup := New(Config{
+		ClientID: 1,
+		Builder: newShellBuilder(`echo "$` + OwnerEnv + `" >> ` + dir + `/owner; ` +
+			`sleep 30 & echo $! >> ` + dir + `/child; exit 0`),
+		Backoff:   newTestBackoff(),
+		Logger:    newTestLogger(),
+		Callbacks: rec.callbacks(),
+	})
+	go sup.Run(ctx)
+	select {
+	case <-rec.exited:
+	case <-time.After(3 * time.Second):
+		t.Fatal("process did not exit")
+	}
+	cancel()
+
+	firstLine := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		return line
+	}
+	if got, want := firstLine("owner"), strconv.Itoa(os.Getpid()); got != want {
+		t.Errorf("%s = %q, want %q", OwnerEnv, got, want)
+	}
+
+	// Gone, or a zombie waiting for whoever it was reparented to
+	child := firstLine("child")
+	dead := func() bool {
+		stat, err := os.ReadFile("/proc/" + child + "/stat")
+		if err != nil {
+			return true
+		}
+		i := strings.LastIndex(string(stat), ") ")
+		return i >= 0 && stat[i+2] == 'Z'
+	}
+	deadline := time.Now().Add(time.Second)
+	for !dead() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !dead() {
+		t.Errorf("child %s still running after FFmpeg exited", child)
+	}
+}
+
 func TestSupervisor_PipelineStats_BeforeRun(t *testing.T) {
 	sup := New(Config{
 		ClientID:     1,
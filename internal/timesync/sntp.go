@@ -0,0 +1,111 @@
+// Package timesync measures the local clock's offset from an NTP server.
+//
+// It implements just enough of SNTPv4 (RFC 4330) to report skew before a
+// scheduled start; it never adjusts the system clock.
+package timesync
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ntpEpochOffset is the number of seconds between 1900-01-01 and 1970-01-01.
+const ntpEpochOffset = 2208988800
+
+// Result is one clock comparison against a server.
+type Result struct {
+	Server  string
+	Offset  time.Duration // server clock - local clock (positive: local is behind)
+	RTT     time.Duration // Round-trip delay, excluding server processing
+	Stratum int
+}
+
+// Now returns the local time corrected by the measured offset.
+func (r Result) Now() time.Time {
+	return time.Now().Add(r.Offset)
+}
+
+// Query sends one SNTP request to server ("host" or "host:port").
+func Query(ctx context.Context, server string, timeout time.Duration) (Result, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return Result{}, fmt.Errorf("dial ntp server: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI=0, VN=4, Mode=3 (client)
+	t1 := time.Now()
+	putTimestamp(req[40:], t1) // Transmit timestamp, echoed back as originate
+	if _, err := conn.Write(req); err != nil {
+		return Result{}, fmt.Errorf("send ntp request: %w", err)
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	t4 := time.Now()
+	if err != nil {
+		return Result{}, fmt.Errorf("read ntp response: %w", err)
+	}
+	return parseResponse(server, req, resp[:n], t1, t4)
+}
+
+// parseResponse computes offset and delay per RFC 4330 section 5:
+//
+//	offset = ((T2 - T1) + (T3 - T4)) / 2
+//	delay  = (T4 - T1) - (T3 - T2)
+func parseResponse(server string, req, resp []byte, t1, t4 time.Time) (Result, error) {
+	if len(resp) < 48 {
+		return Result{}, fmt.Errorf("short ntp response (%d bytes)", len(resp))
+	}
+	if mode := resp[0] & 0x07; mode != 4 {
+		return Result{}, fmt.Errorf("unexpected ntp mode %d", mode)
+	}
+	stratum := int(resp[1])
+	if stratum == 0 {
+		return Result{}, errors.New("ntp server sent kiss-of-death (stratum 0)")
+	}
+	if string(resp[24:32]) != string(req[40:48]) {
+		return Result{}, errors.New("ntp response does not match request")
+	}
+
+	t2 := getTimestamp(resp[32:])
+	t3 := getTimestamp(resp[40:])
+
+	return Result{
+		Server:  server,
+		Offset:  (t2.Sub(t1) + t3.Sub(t4)) / 2,
+		RTT:     t4.Sub(t1) - t3.Sub(t2),
+		Stratum: stratum,
+	}, nil
+}
+
+// putTimestamp writes t as a 64-bit NTP timestamp.
+func putTimestamp(b []byte, t time.Time) {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	binary.BigEndian.PutUint32(b[0:], uint32(secs))
+	binary.BigEndian.PutUint32(b[4:], uint32(frac))
+}
+
+// getTimestamp reads a 64-bit NTP timestamp.
+func getTimestamp(b []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(b[0:])) - ntpEpochOffset
+	frac := int64(binary.BigEndian.Uint32(b[4:]))
+	return time.Unix(secs, frac*1e9>>32)
+}
@@ -0,0 +1,108 @@
+package timesync
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeServer answers SNTP requests with a clock that is skew ahead of ours.
+func fakeServer(t *testing.T, skew time.Duration, mutate func([]byte)) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 48 {
+				continue
+			}
+			resp := make([]byte, 48)
+			resp[0] = 0x24 // VN=4, Mode=4 (server)
+			resp[1] = 2    // Stratum
+			copy(resp[24:32], buf[40:48])
+			now := time.Now().Add(skew)
+			putTimestamp(resp[32:], now)
+			putTimestamp(resp[40:], now)
+			if mutate != nil {
+				mutate(resp)
+			}
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestQuery_Offset(t *testing.T) {
+	for _, skew := range []time.Duration{0, 5 * time.Second, -3 * time.Second} {
+		addr := fakeServer(t, skew, nil)
+		res, err := Query(context.Background(), addr, time.Second)
+		if err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		if diff := res.Offset - skew; diff < -50*time.Millisecond || diff > 50*time.Millisecond {
+			t.Errorf("skew %v: Offset = %v", skew, res.Offset)
+		}
+		if res.RTT < 0 || res.RTT > time.Second {
+			t.Errorf("RTT = %v, want small positive", res.RTT)
+		}
+		if res.Stratum != 2 {
+			t.Errorf("Stratum = %d, want 2", res.Stratum)
+		}
+	}
+}
+
+func TestQuery_BadResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func([]byte)
+	}{
+		{"kiss of death", func(b []byte) { b[1] = 0 }},
+		{"wrong mode", func(b []byte) { b[0] = 0x23 }},
+		{"originate mismatch", func(b []byte) { b[24] ^= 0xff }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := fakeServer(t, 0, tt.mutate)
+			if _, err := Query(context.Background(), addr, time.Second); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestQuery_Timeout(t *testing.T) {
+	// Bound but never answered
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if _, err := Query(context.Background(), conn.LocalAddr().String(), 100*time.Millisecond); err == nil {
+		t.Error("expected timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Query took %v, want ~100ms", elapsed)
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	want := time.Date(2026, 10, 15, 12, 0, 0, 123456789, time.UTC)
+	b := make([]byte, 8)
+	putTimestamp(b, want)
+	got := getTimestamp(b)
+	if diff := got.Sub(want); diff < -time.Microsecond || diff > time.Microsecond {
+		t.Errorf("round trip = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+const (
+	// historyWindow is how far back a paused dashboard can be scrubbed.
+	historyWindow = 15 * time.Minute
+
+	// historyStep is the spacing of the kept frames, and so one scrub step;
+	// faster redraws are not all kept (15 minutes is 900 frames).
+	historyStep = time.Second
+)
+
+// historyFrame is what the dashboard showed at one moment. The origin
+// server panel is not kept: it always shows the scraper's latest sample.
+type historyFrame struct {
+	at         time.Time
+	stats      *stats.AggregatedStats
+	debugStats *stats.DebugStatsAggregate
+	capacity   stats.CapacityProjection
+	compare    *stats.OriginComparison
+	slo        stats.SLOStatus
+}
+
+// history keeps the frames of the last historyWindow, oldest first. Frames
+// are addressed by sequence number, which stays put as old frames are
+// dropped, so a paused cursor keeps pointing at the same moment.
+type history struct {
+	frames []historyFrame
+	first  int // Sequence number of frames[0]
+}
+
+// due reports whether a frame taken at now would be historyStep past the
+// newest kept one.
+func (h *history) due(now time.Time) bool {
+	return len(h.frames) == 0 || now.Sub(h.frames[len(h.frames)-1].at) >= historyStep
+}
+
+// add keeps f and drops the frames older than historyWindow before it.
+func (h *history) add(f historyFrame) {
+	h.frames = append(h.frames, f)
+	n := 0
+	for n < len(h.frames)-1 && f.at.Sub(h.frames[n].at) > historyWindow {
+		h.frames[n] = historyFrame{} // Let the stats go
+		n++
+	}
+	h.frames = h.frames[n:]
+	h.first += n
+}
+
+// newest returns the sequence number of the newest frame (-1 if none).
+func (h *history) newest() int {
+	return h.first + len(h.frames) - 1
+}
+
+// frame returns the frame at seq, clamped to the frames kept, and its
+// sequence number. ok is false if there are none.
+func (h *history) frame(seq int) (f historyFrame, at int, ok bool) {
+	if len(h.frames) == 0 {
+		return historyFrame{}, -1, false
+	}
+	at = min(max(seq, h.first), h.newest())
+	return h.frames[at-h.first], at, true
+}
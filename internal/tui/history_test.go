@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestHistory_Window(t *testing.T) {
+	h := &history{}
+	t0 := time.Now()
+	if _, _, ok := h.frame(0); ok || h.newest() != -1 {
+		t.Fatal("empty history returned a frame")
+	}
+
+	for i := range 20 {
+		h.add(historyFrame{at: t0.Add(time.Duration(i) * time.Minute)})
+	}
+
+	// 15 minutes kept: minutes 4..19 (a frame exactly at the edge stays)
+	if len(h.frames) != 16 || h.first != 4 || h.newest() != 19 {
+		t.Fatalf("kept %d frames from %d to %d, want 16 from 4 to 19", len(h.frames), h.first, h.newest())
+	}
+	f, at, ok := h.frame(10)
+	if !ok || at != 10 || !f.at.Equal(t0.Add(10*time.Minute)) {
+		t.Errorf("frame(10) = %v at %d", f.at.Sub(t0), at)
+	}
+
+	// Out of range clamps to the ends
+	if _, at, _ := h.frame(0); at != 4 {
+		t.Errorf("frame(0) clamped to %d, want 4", at)
+	}
+	if _, at, _ := h.frame(99); at != 19 {
+		t.Errorf("frame(99) clamped to %d, want 19", at)
+	}
+}
+
+func TestHistory_Due(t *testing.T) {
+	h := &history{}
+	t0 := time.Now()
+	if !h.due(t0) {
+		t.Error("empty history should be due a frame")
+	}
+	h.add(historyFrame{at: t0})
+	if h.due(t0.Add(historyStep / 2)) {
+		t.Error("due within historyStep")
+	}
+	if !h.due(t0.Add(historyStep)) {
+		t.Error("not due after historyStep")
+	}
+}
+
+// tickAt feeds the model a tick at t0+i seconds with i active clients.
+func tickAt(t *testing.T, m Model, source *mockStatsSource, t0 time.Time, i int) Model {
+	t.Helper()
+	source.stats = &stats.AggregatedStats{ActiveClients: i}
+	next, _ := m.Update(TickMsg(t0.Add(time.Duration(i) * time.Second)))
+	return next.(Model)
+}
+
+func pressKey(m Model, key tea.KeyMsg) Model {
+	next, _ := m.Update(key)
+	return next.(Model)
+}
+
+func TestModel_PauseAndScrub(t *testing.T) {
+	source := &mockStatsSource{}
+	m := New(Config{TargetClients: 100, StatsSource: source})
+	t0 := time.Now()
+	for i := 1; i <= 10; i++ {
+		m = tickAt(t, m, source, t0, i)
+	}
+
+	// Pausing freezes the display while the history keeps recording
+	m = pressKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if !m.paused {
+		t.Fatal("p did not pause")
+	}
+	m = tickAt(t, m, source, t0, 11)
+	if m.ActiveClients() != 10 {
+		t.Errorf("paused display shows %d clients, want 10", m.ActiveClients())
+	}
+
+	// Scrub back three frames and one past the newest
+	for range 3 {
+		m = pressKey(m, tea.KeyMsg{Type: tea.KeyLeft})
+	}
+	if m.ActiveClients() != 7 {
+		t.Errorf("after 3 steps back: %d clients, want 7", m.ActiveClients())
+	}
+	if got := m.Elapsed(); got != m.lastUpdate.Sub(m.startTime) {
+		t.Errorf("paused Elapsed() = %v, want the frame's", got)
+	}
+	header := m.renderHeader()
+	if !containsAll(header, "PAUSED", "00:00:04 behind live") {
+		t.Errorf("header = %q", header)
+	}
+	m = pressKey(m, tea.KeyMsg{Type: tea.KeyShiftRight})
+	if m.ActiveClients() != 11 {
+		t.Errorf("after shift+right: %d clients, want 11 (newest)", m.ActiveClients())
+	}
+	m = pressKey(m, tea.KeyMsg{Type: tea.KeyShiftLeft})
+	if m.ActiveClients() != 1 {
+		t.Errorf("after shift+left: %d clients, want 1", m.ActiveClients())
+	}
+
+	// Resuming shows the live state again
+	m = pressKey(m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if m.paused || m.ActiveClients() != 11 {
+		t.Errorf("resumed: paused=%v, %d clients; want live 11", m.paused, m.ActiveClients())
+	}
+}
+
+func TestModel_ScrubPausesFirst(t *testing.T) {
+	source := &mockStatsSource{}
+	m := New(Config{TargetClients: 100, StatsSource: source})
+
+	// Nothing to show yet: no pause
+	m = pressKey(m, tea.KeyMsg{Type: tea.KeyLeft})
+	if m.paused {
+		t.Error("paused before any stats")
+	}
+
+	t0 := time.Now()
+	m = tickAt(t, m, source, t0, 1)
+	m = tickAt(t, m, source, t0, 2)
+	m = pressKey(m, tea.KeyMsg{Type: tea.KeyLeft})
+	if !m.paused || m.ActiveClients() != 1 {
+		t.Errorf("left: paused=%v, %d clients; want paused at 1", m.paused, m.ActiveClients())
+	}
+}
+
+func containsAll(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
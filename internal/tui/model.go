@@ -33,9 +33,10 @@ type QuitMsg struct{}
 // Model represents the TUI state.
 type Model struct {
 	// Configuration
-	targetClients int
-	streamURL     string
-	metricsAddr   string
+	targetClients   int
+	streamURL       string
+	metricsAddr     string
+	refreshInterval time.Duration
 
 	// Current state
 	stats       *stats.AggregatedStats
@@ -48,15 +49,40 @@ type Model struct {
 	width  int
 	height int
 
+	// Layout preferences (see prefs.go)
+	panels      map[string]bool // Sections rendered
+	collapsed   map[string]bool // Sections reduced to a title line
+	columnWidth int             // Two-column layer width per column
+
 	// Stats source (for fetching updates)
 	statsSource StatsSource
 
 	// Debug stats source (optional - for layered metrics)
 	debugStatsSource DebugStatsSource
 
+	// Capacity source (optional - for the ramp's projected capacity)
+	capacitySource CapacitySource
+	capacity       stats.CapacityProjection
+
+	// Compare source (optional - for the A/B origin comparison)
+	compareSource CompareSource
+	compare       *stats.OriginComparison
+
+	// SLO source (optional - for the -slo latency objective)
+	sloSource SLOSource
+	slo       stats.SLOStatus
+
 	// Origin metrics scraper (optional - for origin server metrics)
 	originScraper *metrics.OriginScraper
 
+	// Pause and scrub (see history.go). The sources' latest state is kept
+	// in live and recorded in history; the current state above shows it,
+	// or while paused the history frame at cursor.
+	live    historyFrame
+	history *history
+	paused  bool
+	cursor  int // Sequence number of the frame shown while paused
+
 	// Quit flag
 	quitting bool
 }
@@ -72,6 +98,24 @@ type DebugStatsSource interface {
 	GetDebugStats() stats.DebugStatsAggregate
 }
 
+// CompareSource provides the -compare-url origin comparison.
+// This is optional - if not provided (or it returns nil), no comparison is shown.
+type CompareSource interface {
+	OriginComparison() *stats.OriginComparison
+}
+
+// CapacitySource provides the origin capacity projected during the ramp.
+// This is optional - if not provided, no projection is shown.
+type CapacitySource interface {
+	ProjectedCapacity() stats.CapacityProjection
+}
+
+// SLOSource provides the -slo latency objective's standing.
+// This is optional - if not provided (or it is not Enabled), no SLO is shown.
+type SLOSource interface {
+	SLOStatus() stats.SLOStatus
+}
+
 // Config holds TUI configuration.
 type Config struct {
 	TargetClients    int
@@ -79,22 +123,52 @@ type Config struct {
 	MetricsAddr      string
 	StatsSource      StatsSource
 	DebugStatsSource DebugStatsSource
+	CapacitySource   CapacitySource
+	CompareSource    CompareSource
+	SLOSource        SLOSource
 	OriginScraper    *metrics.OriginScraper
+	Prefs            Prefs         // Restored layout (zero value: all panels expanded)
+	RefreshInterval  time.Duration // Redraw interval (0 = defaultRefreshInterval)
 }
 
+// defaultRefreshInterval is the redraw interval when Config leaves it unset.
+const defaultRefreshInterval = 500 * time.Millisecond
+
 // New creates a new TUI model.
 func New(cfg Config) Model {
+	columnWidth := cfg.Prefs.ColumnWidth
+	if columnWidth < minColumnWidth || columnWidth > maxColumnWidth {
+		columnWidth = defaultColumnWidth
+	}
+	collapsed := make(map[string]bool)
+	for _, name := range cfg.Prefs.Collapsed {
+		collapsed[name] = true
+	}
+	refreshInterval := cfg.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = defaultRefreshInterval
+	}
+
 	return Model{
 		targetClients:    cfg.TargetClients,
 		streamURL:        cfg.StreamURL,
 		metricsAddr:      cfg.MetricsAddr,
+		refreshInterval:  refreshInterval,
 		statsSource:      cfg.StatsSource,
 		debugStatsSource: cfg.DebugStatsSource,
+		capacitySource:   cfg.CapacitySource,
+		compareSource:    cfg.CompareSource,
+		sloSource:        cfg.SLOSource,
 		originScraper:    cfg.OriginScraper,
+		history:          &history{},
 		startTime:        time.Now(),
 		lastUpdate:       time.Now(),
 		width:            80,
 		height:           24,
+		detailedView:     cfg.Prefs.DetailedView,
+		panels:           panelSet(cfg.Prefs.Panels),
+		collapsed:        collapsed,
+		columnWidth:      columnWidth,
 	}
 }
 
@@ -106,7 +180,7 @@ func New(cfg Config) Model {
 func (m Model) Init() tea.Cmd {
 	// Note: tea.WithAltScreen() is passed when creating the program,
 	// so we don't need tea.EnterAltScreen here.
-	return tickCmd()
+	return tickCmd(m.refreshInterval)
 }
 
 // Update handles messages.
@@ -122,7 +196,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case "r":
 			// Force refresh
-			return m, tickCmd()
+			return m, tickCmd(m.refreshInterval)
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			m.toggleCollapsed(AllPanels[msg.String()[0]-'1'])
+			return m, nil
+		case "[":
+			m.columnWidth = max(m.columnWidth-2, minColumnWidth)
+			return m, nil
+		case "]":
+			m.columnWidth = min(m.columnWidth+2, maxColumnWidth)
+			return m, nil
+		case "p", " ":
+			m.togglePause()
+			return m, nil
+		case "left":
+			m.scrub(-1)
+			return m, nil
+		case "right":
+			m.scrub(1)
+			return m, nil
+		case "shift+left":
+			m.scrub(-10)
+			return m, nil
+		case "shift+right":
+			m.scrub(10)
+			return m, nil
 		}
 
 	case tea.WindowSizeMsg:
@@ -131,24 +229,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case TickMsg:
-		// Fetch latest stats
+		// Fetch latest stats. Both sources return cached snapshots, so a
+		// redraw never waits on (or triggers) aggregation.
 		if m.statsSource != nil {
-			m.stats = m.statsSource.GetAggregatedStats()
+			m.live.stats = m.statsSource.GetAggregatedStats()
 		}
 		// Fetch debug stats for layered dashboard
 		if m.debugStatsSource != nil {
 			ds := m.debugStatsSource.GetDebugStats()
-			m.debugStats = &ds
+			m.live.debugStats = &ds
+		}
+		if m.capacitySource != nil {
+			m.live.capacity = m.capacitySource.ProjectedCapacity()
 		}
-		m.lastUpdate = time.Now()
-		return m, tickCmd()
+		if m.compareSource != nil {
+			m.live.compare = m.compareSource.OriginComparison()
+		}
+		if m.sloSource != nil {
+			m.live.slo = m.sloSource.SLOStatus()
+		}
+		m.live.at = time.Time(msg)
+		m.recordLive()
+		return m, tickCmd(m.refreshInterval)
 
 	case StatsMsg:
-		m.stats = msg.Stats
+		m.live.stats = msg.Stats
 		if msg.DebugStats != nil {
-			m.debugStats = msg.DebugStats
+			m.live.debugStats = msg.DebugStats
 		}
-		m.lastUpdate = time.Now()
+		m.live.at = time.Now()
+		m.recordLive()
 		return m, nil
 
 	case QuitMsg:
@@ -165,19 +275,109 @@ func (m Model) View() string {
 		return ""
 	}
 
+	var frame string
 	if m.detailedView && m.stats != nil && len(m.stats.PerClientSummaries) > 0 {
-		return m.renderDetailedView()
+		frame = m.renderDetailedView()
+	} else {
+		frame = m.renderSummaryView()
 	}
-	return m.renderSummaryView()
+	if asciiReplacer != nil {
+		frame = asciiReplacer.Replace(frame)
+	}
+	return frame
+}
+
+// toggleCollapsed flips a panel between expanded and collapsed.
+// The map is copied because Model is passed by value.
+func (m *Model) toggleCollapsed(panel string) {
+	collapsed := make(map[string]bool, len(m.collapsed)+1)
+	for name, v := range m.collapsed {
+		collapsed[name] = v
+	}
+	collapsed[panel] = !collapsed[panel]
+	m.collapsed = collapsed
+}
+
+// recordLive keeps the live state in the history (at most one frame per
+// historyStep) and shows it unless paused.
+func (m *Model) recordLive() {
+	if m.history != nil && m.history.due(m.live.at) {
+		m.history.add(m.live)
+	}
+	if !m.paused {
+		m.show(m.live)
+	}
+}
+
+// show makes f the state the view renders.
+func (m *Model) show(f historyFrame) {
+	m.stats = f.stats
+	m.debugStats = f.debugStats
+	m.capacity = f.capacity
+	m.compare = f.compare
+	m.slo = f.slo
+	m.lastUpdate = f.at
+}
+
+// togglePause freezes the display on the newest frame, or goes back to
+// the live state. The history keeps recording while paused.
+func (m *Model) togglePause() {
+	if m.paused {
+		m.paused = false
+		m.show(m.live)
+		return
+	}
+	if m.history == nil || m.live.at.IsZero() {
+		return // Nothing shown yet
+	}
+	// Keep what is on screen, even if it was not due a frame
+	if f, _, ok := m.history.frame(m.history.newest()); !ok || m.live.at.After(f.at) {
+		m.history.add(m.live)
+	}
+	m.paused = true
+	m.cursor = m.history.newest()
+}
+
+// scrub moves the paused display n frames (historyStep each) back or
+// forward, pausing first if live.
+func (m *Model) scrub(n int) {
+	if !m.paused {
+		m.togglePause()
+		if !m.paused {
+			return
+		}
+	}
+	if f, at, ok := m.history.frame(m.cursor + n); ok {
+		m.cursor = at
+		m.show(f)
+	}
+}
+
+// Prefs returns the current layout for saving on exit.
+func (m Model) Prefs() Prefs {
+	p := Prefs{
+		DetailedView: m.detailedView,
+		ColumnWidth:  m.columnWidth,
+		Collapsed:    panelList(m.collapsed),
+	}
+	if len(m.panels) < len(AllPanels) {
+		p.Panels = panelList(m.panels)
+	}
+	return p
+}
+
+// showPanel reports whether a section is selected for rendering.
+func (m Model) showPanel(panel string) bool {
+	return m.panels == nil || m.panels[panel]
 }
 
 // =============================================================================
 // Commands
 // =============================================================================
 
-// tickCmd returns a command that sends a tick after 500ms.
-func tickCmd() tea.Cmd {
-	return tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
+// tickCmd returns a command that sends a tick after d.
+func tickCmd(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
 		return TickMsg(t)
 	})
 }
@@ -186,8 +386,12 @@ func tickCmd() tea.Cmd {
 // Accessors
 // =============================================================================
 
-// Elapsed returns the time since the test started.
+// Elapsed returns the time since the test started, or while paused the
+// run time of the frame shown.
 func (m Model) Elapsed() time.Duration {
+	if m.paused {
+		return m.lastUpdate.Sub(m.startTime)
+	}
 	return time.Since(m.startTime)
 }
 
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -64,6 +65,15 @@ func TestModel_Init(t *testing.T) {
 	}
 }
 
+func TestNew_RefreshInterval(t *testing.T) {
+	if got := New(Config{}).refreshInterval; got != defaultRefreshInterval {
+		t.Errorf("default refreshInterval = %v, want %v", got, defaultRefreshInterval)
+	}
+	if got := New(Config{RefreshInterval: 2 * time.Second}).refreshInterval; got != 2*time.Second {
+		t.Errorf("refreshInterval = %v, want 2s", got)
+	}
+}
+
 // =============================================================================
 // Tests: Update - Key Messages
 // =============================================================================
@@ -181,6 +191,78 @@ func TestModel_Update_Tick(t *testing.T) {
 	}
 }
 
+type mockCapacitySource struct {
+	projection stats.CapacityProjection
+}
+
+func (m *mockCapacitySource) ProjectedCapacity() stats.CapacityProjection {
+	return m.projection
+}
+
+func TestModel_Update_Tick_Capacity(t *testing.T) {
+	source := &mockCapacitySource{projection: stats.CapacityProjection{Clients: 1450, Threshold: 2 * time.Second}}
+	model := New(Config{
+		TargetClients:  2000,
+		StatsSource:    &mockStatsSource{stats: &stats.AggregatedStats{ActiveClients: 500}},
+		CapacitySource: source,
+	})
+
+	newModel, _ := model.Update(TickMsg(time.Now()))
+	m := newModel.(Model)
+	if m.capacity.Clients != 1450 {
+		t.Errorf("capacity = %+v, want 1450 clients", m.capacity)
+	}
+	if got := m.renderProgress(); !strings.Contains(got, "Projected capacity: ~1450 clients (P95 2s)") {
+		t.Errorf("renderProgress() missing projection:\n%s", got)
+	}
+
+	source.projection = stats.CapacityProjection{Clients: 600, Threshold: 2 * time.Second, Exceeded: true}
+	newModel, _ = m.Update(TickMsg(time.Now()))
+	if got := newModel.(Model).renderProgress(); !strings.Contains(got, "P95 over 2s at 600 clients") {
+		t.Errorf("renderProgress() missing exceeded threshold:\n%s", got)
+	}
+
+	// Nothing projected yet: nothing shown
+	source.projection = stats.CapacityProjection{Threshold: 2 * time.Second}
+	newModel, _ = m.Update(TickMsg(time.Now()))
+	if got := newModel.(Model).renderProgress(); strings.Contains(got, "capacity") || strings.Contains(got, "P95") {
+		t.Errorf("renderProgress() shows a projection without one:\n%s", got)
+	}
+}
+
+type mockSLOSource struct {
+	status stats.SLOStatus
+}
+
+func (m *mockSLOSource) SLOStatus() stats.SLOStatus {
+	return m.status
+}
+
+func TestModel_Update_Tick_SLO(t *testing.T) {
+	source := &mockSLOSource{}
+	model := New(Config{
+		TargetClients: 100,
+		StatsSource:   &mockStatsSource{stats: &stats.AggregatedStats{ActiveClients: 100}},
+		SLOSource:     source,
+	})
+	model.width = 160
+
+	// No -slo: nothing shown
+	newModel, _ := model.Update(TickMsg(time.Now()))
+	if got := newModel.(Model).renderSLO(); got != "" {
+		t.Errorf("renderSLO() = %q without -slo", got)
+	}
+
+	source.status = stats.SLOStatus{Target: 0.99, Threshold: time.Second, Segments: 1000, Misses: 5, BurnRate: 2, Window: 5 * time.Minute}
+	newModel, _ = newModel.(Model).Update(TickMsg(time.Now()))
+	m := newModel.(Model)
+	m.debugStats = &stats.DebugStatsAggregate{SegmentWallTimeP50: 400 * time.Millisecond}
+	want := "SLO: 99.50% under 1s (target 99%), burn 2.0x over 5m0s, 50% budget left"
+	if got := m.renderLatencyStats(); !strings.Contains(got, want) {
+		t.Errorf("renderLatencyStats() missing %q:\n%s", want, got)
+	}
+}
+
 // =============================================================================
 // Tests: Update - Stats Message
 // =============================================================================
@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Dashboard sections, as named by --tui-panels and the preferences file.
+// Header and footer always render.
+const (
+	PanelProgress = "progress"
+	PanelRequests = "requests"
+	PanelLatency  = "latency"
+	PanelHealth   = "health"
+	PanelOrigin   = "origin"
+	PanelHLS      = "hls"
+	PanelHTTP     = "http"
+	PanelTCP      = "tcp"
+	PanelCompare  = "compare"
+)
+
+// AllPanels lists every section in render order. Keys 1-9 toggle the
+// collapsed state of the panel at that position.
+var AllPanels = []string{
+	PanelProgress, PanelRequests, PanelLatency, PanelHealth,
+	PanelOrigin, PanelHLS, PanelHTTP, PanelTCP, PanelCompare,
+}
+
+// Column widths for the two-column layers ([ and ] adjust in steps of 2).
+const (
+	defaultColumnWidth = 42
+	minColumnWidth     = 34
+	maxColumnWidth     = 60
+)
+
+// Prefs are the layout preferences saved on exit and restored on start.
+type Prefs struct {
+	Panels       []string `json:"panels,omitempty"`    // Sections to render (empty = all)
+	Collapsed    []string `json:"collapsed,omitempty"` // Sections shown as a title line only
+	DetailedView bool     `json:"detailed_view"`       // Start in the per-client view
+	ColumnWidth  int      `json:"column_width,omitempty"`
+}
+
+// LoadPrefs reads preferences from path. A missing file is not an error
+// and yields zero Prefs (everything expanded, summary view).
+func LoadPrefs(path string) (Prefs, error) {
+	var p Prefs
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Prefs{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	// Drop panels this version doesn't know (file may be from a newer build)
+	p.Panels = knownPanels(p.Panels)
+	p.Collapsed = knownPanels(p.Collapsed)
+	return p, nil
+}
+
+// SavePrefs writes preferences to path, creating its directory.
+// The file is replaced atomically so a crash never leaves it truncated.
+func SavePrefs(path string, p Prefs) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func knownPanels(names []string) []string {
+	var out []string
+	for _, name := range names {
+		for _, known := range AllPanels {
+			if name == known {
+				out = append(out, name)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// panelSet converts a panel list to a lookup; an empty list means all.
+func panelSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(AllPanels))
+	if len(names) == 0 {
+		names = AllPanels
+	}
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// panelList converts a lookup back to AllPanels order.
+func panelList(set map[string]bool) []string {
+	var out []string
+	for _, name := range AllPanels {
+		if set[name] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
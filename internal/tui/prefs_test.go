@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+func TestLoadPrefs_Missing(t *testing.T) {
+	p, err := LoadPrefs(filepath.Join(t.TempDir(), "tui.json"))
+	if err != nil {
+		t.Fatalf("LoadPrefs() error = %v", err)
+	}
+	if !reflect.DeepEqual(p, Prefs{}) {
+		t.Errorf("LoadPrefs() = %+v, want zero Prefs", p)
+	}
+}
+
+func TestPrefs_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "tui.json")
+	want := Prefs{
+		Panels:       []string{PanelProgress, PanelHLS},
+		Collapsed:    []string{PanelHLS},
+		DetailedView: true,
+		ColumnWidth:  50,
+	}
+
+	if err := SavePrefs(path, want); err != nil {
+		t.Fatalf("SavePrefs() error = %v", err)
+	}
+	got, err := LoadPrefs(path)
+	if err != nil {
+		t.Fatalf("LoadPrefs() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadPrefs() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadPrefs_DropsUnknownPanels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui.json")
+	if err := os.WriteFile(path, []byte(`{"panels":["hls","quic"],"collapsed":["gpu"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := LoadPrefs(path)
+	if err != nil {
+		t.Fatalf("LoadPrefs() error = %v", err)
+	}
+	if !reflect.DeepEqual(p.Panels, []string{PanelHLS}) || p.Collapsed != nil {
+		t.Errorf("Panels=%v Collapsed=%v, want [hls] []", p.Panels, p.Collapsed)
+	}
+}
+
+func TestLoadPrefs_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tui.json")
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrefs(path); err == nil {
+		t.Error("LoadPrefs() should fail on invalid JSON")
+	}
+}
+
+func TestNew_AppliesPrefs(t *testing.T) {
+	m := New(Config{Prefs: Prefs{
+		Panels:       []string{PanelProgress},
+		Collapsed:    []string{PanelProgress},
+		DetailedView: true,
+		ColumnWidth:  500, // Out of range: default used
+	}})
+
+	if !m.detailedView {
+		t.Error("detailedView should come from prefs")
+	}
+	if m.columnWidth != defaultColumnWidth {
+		t.Errorf("columnWidth = %d, want %d", m.columnWidth, defaultColumnWidth)
+	}
+	if !m.showPanel(PanelProgress) || m.showPanel(PanelRequests) {
+		t.Error("only the progress panel should be shown")
+	}
+
+	got := m.Prefs()
+	want := Prefs{Panels: []string{PanelProgress}, Collapsed: []string{PanelProgress}, DetailedView: true, ColumnWidth: defaultColumnWidth}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Prefs() = %+v, want %+v", got, want)
+	}
+}
+
+func TestModel_Update_LayoutKeys(t *testing.T) {
+	model := New(Config{TargetClients: 10})
+	press := func(m Model, key string) Model {
+		next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		return next.(Model)
+	}
+
+	m := press(model, "6") // hls
+	if !m.collapsed[PanelHLS] {
+		t.Error("'6' should collapse the HLS layer")
+	}
+	if model.collapsed[PanelHLS] {
+		t.Error("toggling must not mutate the previous model")
+	}
+	if m = press(m, "6"); m.collapsed[PanelHLS] {
+		t.Error("'6' again should expand the HLS layer")
+	}
+
+	m = press(m, "]")
+	if m.columnWidth != defaultColumnWidth+2 {
+		t.Errorf("columnWidth = %d after ']', want %d", m.columnWidth, defaultColumnWidth+2)
+	}
+	for i := 0; i < 50; i++ {
+		m = press(m, "[")
+	}
+	if m.columnWidth != minColumnWidth {
+		t.Errorf("columnWidth = %d, want clamped to %d", m.columnWidth, minColumnWidth)
+	}
+}
+
+func TestView_PanelSelection(t *testing.T) {
+	m := New(Config{
+		TargetClients: 10,
+		StreamURL:     "http://example.com/stream.m3u8",
+		Prefs: Prefs{
+			Panels:    []string{PanelProgress, PanelRequests, PanelHLS, PanelTCP},
+			Collapsed: []string{PanelRequests, PanelTCP},
+		},
+	})
+	m.width = 100
+	m.stats = &stats.AggregatedStats{ActiveClients: 5}
+	m.debugStats = &stats.DebugStatsAggregate{TCPConnectCount: 10}
+
+	view := m.View()
+	for _, want := range []string{"Ramp Progress", "Request Statistics  ▸ collapsed (2 to expand)", "HLS LAYER (libavformat", "TCP LAYER  ▸ collapsed (8 to expand)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q", want)
+		}
+	}
+	for _, hidden := range []string{"Playback Health", "HTTP LAYER", "Manifest Requests"} {
+		if strings.Contains(view, hidden) {
+			t.Errorf("view contains deselected %q", hidden)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+// StatusLineConfig configures the headless status line.
+type StatusLineConfig struct {
+	Out              io.Writer
+	Interval         time.Duration
+	Overwrite        bool // Rewrite one line in place (terminals) instead of appending (CI logs)
+	TargetClients    int
+	StatsSource      StatsSource
+	DebugStatsSource DebugStatsSource // Optional: adds segment p95
+}
+
+// RunStatusLine prints a compact status line every interval until ctx is
+// cancelled. It is the --status-line alternative to the dashboard, fed by
+// the same stats sources.
+func RunStatusLine(ctx context.Context, cfg StatusLineConfig) {
+	start := time.Now()
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if cfg.Overwrite {
+				fmt.Fprintln(cfg.Out) // Leave the last line visible
+			}
+			return
+		case <-ticker.C:
+		}
+
+		var agg *stats.AggregatedStats
+		if cfg.StatsSource != nil {
+			agg = cfg.StatsSource.GetAggregatedStats()
+		}
+		var ds *stats.DebugStatsAggregate
+		if cfg.DebugStatsSource != nil {
+			d := cfg.DebugStatsSource.GetDebugStats()
+			ds = &d
+		}
+
+		line := FormatStatusLine(time.Since(start), cfg.TargetClients, agg, ds)
+		if cfg.Overwrite {
+			fmt.Fprintf(cfg.Out, "\r%s\x1b[K", line)
+		} else {
+			fmt.Fprintln(cfg.Out, line)
+		}
+	}
+}
+
+// FormatStatusLine renders one snapshot, e.g.
+//
+//	[00:05:00] clients 100/100 | 62.0/s req | 48.20 Mbps | p95 180 ms | 4.5 conn/s | err 0.12% (503:4)
+//
+// Rates are instantaneous (since the previous snapshot).
+func FormatStatusLine(elapsed time.Duration, target int, agg *stats.AggregatedStats, ds *stats.DebugStatsAggregate) string {
+	active := 0
+	if agg != nil {
+		active = agg.ActiveClients
+	}
+	parts := []string{
+		fmt.Sprintf("[%s] clients %d/%d", formatDuration(elapsed), active, target),
+	}
+	if agg == nil {
+		return parts[0]
+	}
+
+	parts = append(parts,
+		formatRate(agg.InstantManifestRate+agg.InstantSegmentRate)+" req",
+		fmt.Sprintf("%.2f Mbps", agg.InstantThroughputRate*8/1e6),
+	)
+	if ds != nil && ds.SegmentWallTimeP95 > 0 {
+		parts = append(parts, "p95 "+formatMs(ds.SegmentWallTimeP95))
+	}
+	if ds != nil && ds.InstantTCPConnectsRate > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f conn/s", ds.InstantTCPConnectsRate))
+	}
+
+	errPart := "err " + formatPercentRaw(agg.ErrorRate)
+	if codes := topErrorCodes(agg.TotalHTTPErrors, 3); codes != "" {
+		errPart += " (" + codes + ")"
+	}
+	if agg.TotalTimeouts > 0 {
+		errPart += fmt.Sprintf(" %d timeouts", agg.TotalTimeouts)
+	}
+	parts = append(parts, errPart)
+
+	return strings.Join(parts, " | ")
+}
+
+// topErrorCodes formats the n most frequent HTTP error codes as "503:4 404:1".
+func topErrorCodes(errs map[int]int64, n int) string {
+	codes := make([]int, 0, len(errs))
+	for code, count := range errs {
+		if count > 0 {
+			codes = append(codes, code)
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if errs[codes[i]] != errs[codes[j]] {
+			return errs[codes[i]] > errs[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	if len(codes) > n {
+		codes = codes[:n]
+	}
+
+	out := make([]string, len(codes))
+	for i, code := range codes {
+		out[i] = fmt.Sprintf("%d:%d", code, errs[code])
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+type fakeStatsSource struct{ agg *stats.AggregatedStats }
+
+func (f fakeStatsSource) GetAggregatedStats() *stats.AggregatedStats { return f.agg }
+
+func TestFormatStatusLine(t *testing.T) {
+	agg := &stats.AggregatedStats{
+		ActiveClients:         95,
+		InstantManifestRate:   12,
+		InstantSegmentRate:    50,
+		InstantThroughputRate: 6_025_000, // bytes/s
+		ErrorRate:             0.0012,
+		TotalHTTPErrors:       map[int]int64{503: 4, 404: 1, 500: 4, 502: 2},
+		TotalTimeouts:         3,
+	}
+	ds := &stats.DebugStatsAggregate{SegmentWallTimeP95: 180 * time.Millisecond, InstantTCPConnectsRate: 4.5}
+
+	got := FormatStatusLine(5*time.Minute, 100, agg, ds)
+	want := "[00:05:00] clients 95/100 | 62.0/s req | 48.20 Mbps | p95 180 ms | 4.5 conn/s | err 0.12% (500:4 503:4 502:2) 3 timeouts"
+	if got != want {
+		t.Errorf("FormatStatusLine() =\n  %q\nwant\n  %q", got, want)
+	}
+}
+
+func TestFormatStatusLine_NoData(t *testing.T) {
+	if got := FormatStatusLine(time.Second, 10, nil, nil); got != "[00:00:01] clients 0/10" {
+		t.Errorf("FormatStatusLine(nil) = %q", got)
+	}
+
+	// No debug stats and no errors: p95 and error codes are omitted
+	got := FormatStatusLine(time.Second, 10, &stats.AggregatedStats{ActiveClients: 10}, nil)
+	if want := "[00:00:01] clients 10/10 | 0.00/s req | 0.00 Mbps | err 0.00%"; got != want {
+		t.Errorf("FormatStatusLine() = %q, want %q", got, want)
+	}
+}
+
+func TestRunStatusLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		overwrite bool
+	}{
+		{"periodic", false},
+		{"overwrite", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+			defer cancel()
+
+			RunStatusLine(ctx, StatusLineConfig{
+				Out:           &buf,
+				Interval:      10 * time.Millisecond,
+				Overwrite:     tt.overwrite,
+				TargetClients: 5,
+				StatsSource:   fakeStatsSource{&stats.AggregatedStats{ActiveClients: 5}},
+			})
+
+			out := buf.String()
+			if n := strings.Count(out, "clients 5/5"); n < 3 {
+				t.Fatalf("printed %d status lines, want >= 3:\n%q", n, out)
+			}
+			lines := strings.Count(out, "\n")
+			if tt.overwrite {
+				if lines != 1 || !strings.HasPrefix(out, "\r") {
+					t.Errorf("overwrite mode should rewrite with \\r and end with one newline: %q", out)
+				}
+			} else if lines != strings.Count(out, "clients 5/5") {
+				t.Errorf("periodic mode should print one line per tick: %q", out)
+			}
+		})
+	}
+}
@@ -11,195 +11,339 @@ package tui
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
 // =============================================================================
-// Color Palette
+// Themes
 // =============================================================================
 
+// Theme names accepted by --tui-theme.
+const (
+	ThemeDefault      = "default"
+	ThemeHighContrast = "high-contrast" // Blue/orange instead of green/red, plus non-color cues
+	ThemeMonochrome   = "monochrome"    // No color: bold/underline/reverse only
+	ThemeASCII        = "ascii"         // Default colors, no emoji or box-drawing glyphs
+)
+
+// palette is the set of colors a theme assigns.
+type palette struct {
+	primary, secondary, accent    lipgloss.TerminalColor
+	success, warning, error, info lipgloss.TerminalColor
+	text, textMuted, textDim      lipgloss.TerminalColor
+	background, border            lipgloss.TerminalColor
+
+	// emphasize adds non-color cues so status never depends on hue alone:
+	// errors render reversed, warnings underlined.
+	emphasize bool
+}
+
 // Colors based on a modern dark theme
-var (
+var defaultPalette = palette{
 	// Primary colors
-	colorPrimary   = lipgloss.Color("#7C3AED") // Purple
-	colorSecondary = lipgloss.Color("#06B6D4") // Cyan
-	colorAccent    = lipgloss.Color("#F59E0B") // Amber
+	primary:   lipgloss.Color("#7C3AED"), // Purple
+	secondary: lipgloss.Color("#06B6D4"), // Cyan
+	accent:    lipgloss.Color("#F59E0B"), // Amber
 
 	// Status colors
-	colorSuccess = lipgloss.Color("#10B981") // Green
-	colorWarning = lipgloss.Color("#F59E0B") // Amber
-	colorError   = lipgloss.Color("#EF4444") // Red
-	colorInfo    = lipgloss.Color("#3B82F6") // Blue
+	success: lipgloss.Color("#10B981"), // Green
+	warning: lipgloss.Color("#F59E0B"), // Amber
+	error:   lipgloss.Color("#EF4444"), // Red
+	info:    lipgloss.Color("#3B82F6"), // Blue
 
 	// Neutral colors
-	colorText       = lipgloss.Color("#E5E7EB") // Light gray
-	colorTextMuted  = lipgloss.Color("#9CA3AF") // Medium gray
-	colorTextDim    = lipgloss.Color("#6B7280") // Dark gray
-	colorBackground = lipgloss.Color("#1F2937") // Dark blue-gray
-	colorBorder     = lipgloss.Color("#374151") // Border gray
-)
+	text:       lipgloss.Color("#E5E7EB"), // Light gray
+	textMuted:  lipgloss.Color("#9CA3AF"), // Medium gray
+	textDim:    lipgloss.Color("#6B7280"), // Dark gray
+	background: lipgloss.Color("#1F2937"), // Dark blue-gray
+	border:     lipgloss.Color("#374151"), // Border gray
+}
+
+// highContrastPalette uses the Okabe-Ito colors, which stay distinct under
+// the common forms of color blindness, and brighter neutrals.
+var highContrastPalette = palette{
+	primary:    lipgloss.Color("#0072B2"), // Blue
+	secondary:  lipgloss.Color("#56B4E9"), // Sky blue
+	accent:     lipgloss.Color("#F0E442"), // Yellow
+	success:    lipgloss.Color("#56B4E9"), // Sky blue
+	warning:    lipgloss.Color("#E69F00"), // Orange
+	error:      lipgloss.Color("#D55E00"), // Vermillion
+	info:       lipgloss.Color("#0072B2"), // Blue
+	text:       lipgloss.Color("#FFFFFF"),
+	textMuted:  lipgloss.Color("#D1D5DB"),
+	textDim:    lipgloss.Color("#9CA3AF"),
+	background: lipgloss.Color("#000000"),
+	border:     lipgloss.Color("#9CA3AF"),
+	emphasize:  true,
+}
+
+var monochromePalette = palette{
+	primary: lipgloss.NoColor{}, secondary: lipgloss.NoColor{}, accent: lipgloss.NoColor{},
+	success: lipgloss.NoColor{}, warning: lipgloss.NoColor{}, error: lipgloss.NoColor{}, info: lipgloss.NoColor{},
+	text: lipgloss.NoColor{}, textMuted: lipgloss.NoColor{}, textDim: lipgloss.NoColor{},
+	background: lipgloss.NoColor{}, border: lipgloss.NoColor{},
+	emphasize: true,
+}
+
+// asciiGlyphs replaces every non-ASCII glyph the dashboard draws. Each
+// replacement is padded to the glyph's cell width so columns stay aligned.
+var asciiGlyphs = map[string]string{
+	"╭": "+", "╮": "+", "╰": "+", "╯": "+", "┌": "+", "┐": "+", "└": "+", "┘": "+",
+	"─": "-", "│": "|",
+	"█": "#", "░": ".", "●": "*", "○": "o", "▸": ">", "✓": "+", "µ": "u",
+	"✅": "ok", "⚠️": "!", "⚠": "!", "🔴": "X", "🚫": "X",
+	"📺": "#", "🌐": "#", "🔌": "#", "🔀": "~", "🔄": "~",
+	"⏱️": "t", "⏩": ">>", "⏰": "!",
+	"🐢": "s", "🔥": "*", "×": "x", "·": "-", "…": ">",
+	"⏸": "=", "←": "<", "→": ">",
+}
+
+// ValidThemes lists the accepted --tui-theme values.
+var ValidThemes = []string{ThemeDefault, ThemeHighContrast, ThemeMonochrome, ThemeASCII}
+
+// asciiReplacer is non-nil when the ASCII theme is active; View applies it
+// to the rendered frame.
+var asciiReplacer *strings.Replacer
+
+// SetTheme switches all styles to the named theme. Call it before the
+// program starts: styles are package state shared by every Model.
+func SetTheme(name string) error {
+	switch name {
+	case ThemeDefault, "":
+		applyPalette(defaultPalette)
+		asciiReplacer = nil
+	case ThemeHighContrast:
+		applyPalette(highContrastPalette)
+		asciiReplacer = nil
+	case ThemeMonochrome:
+		applyPalette(monochromePalette)
+		asciiReplacer = nil
+	case ThemeASCII:
+		applyPalette(defaultPalette)
+		asciiReplacer = newASCIIReplacer()
+	default:
+		return fmt.Errorf("unknown theme %q (valid: %s)", name, strings.Join(ValidThemes, ", "))
+	}
+	return nil
+}
+
+func newASCIIReplacer() *strings.Replacer {
+	// Longest first: the replacer tries patterns in argument order, and
+	// "⚠️" (with variation selector) must win over a bare "⚠"
+	glyphs := make([]string, 0, len(asciiGlyphs))
+	for glyph := range asciiGlyphs {
+		glyphs = append(glyphs, glyph)
+	}
+	sort.Slice(glyphs, func(i, j int) bool {
+		if len(glyphs[i]) != len(glyphs[j]) {
+			return len(glyphs[i]) > len(glyphs[j])
+		}
+		return glyphs[i] < glyphs[j]
+	})
+
+	pairs := make([]string, 0, len(glyphs)*2)
+	for _, glyph := range glyphs {
+		ascii := asciiGlyphs[glyph]
+		if pad := lipgloss.Width(glyph) - len(ascii); pad > 0 {
+			ascii += strings.Repeat(" ", pad)
+		}
+		pairs = append(pairs, glyph, ascii)
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+func init() {
+	applyPalette(defaultPalette)
+}
 
 // =============================================================================
-// Base Styles
+// Styles
 // =============================================================================
 
+// Colors of the active theme
+var (
+	// Primary colors
+	colorPrimary   lipgloss.TerminalColor
+	colorSecondary lipgloss.TerminalColor
+
+	// Status colors
+	colorSuccess lipgloss.TerminalColor
+	colorWarning lipgloss.TerminalColor
+	colorError   lipgloss.TerminalColor
+	colorInfo    lipgloss.TerminalColor
+
+	// Neutral colors
+	colorText      lipgloss.TerminalColor
+	colorTextMuted lipgloss.TerminalColor
+	colorTextDim   lipgloss.TerminalColor
+	colorBorder    lipgloss.TerminalColor
+)
+
 var (
+	// Base text styles
+	baseStyle, mutedStyle, dimStyle, boldStyle lipgloss.Style
+
+	// Title styles
+	titleStyle, subtitleStyle lipgloss.Style
+
+	// Status indicator styles
+	statusOK, statusWarning, statusError, statusInfo lipgloss.Style
+
+	// Layout styles
+	boxStyle, headerStyle, sectionHeaderStyle, footerStyle lipgloss.Style
+
+	// Numeric value styles
+	valueStyle, valueGoodStyle, valueBadStyle, valueWarnStyle lipgloss.Style
+
+	// Label and unit styles (for ms, KB, etc.)
+	labelStyle, labelWideStyle, unitStyle lipgloss.Style
+
+	// Progress bar styles
+	progressBarStyle, progressBarEmptyStyle, progressPercentStyle lipgloss.Style
+
+	// Table styles
+	tableHeaderStyle, tableCellStyle, tableRowEvenStyle, tableRowOddStyle lipgloss.Style
+)
+
+// applyPalette rebuilds every style from p.
+func applyPalette(p palette) {
+	colorPrimary, colorSecondary = p.primary, p.secondary
+	colorSuccess, colorWarning, colorError, colorInfo = p.success, p.warning, p.error, p.info
+	colorText, colorTextMuted, colorTextDim, colorBorder = p.text, p.textMuted, p.textDim, p.border
+
 	// Base text styles
 	baseStyle = lipgloss.NewStyle().
-			Foreground(colorText)
+		Foreground(colorText)
 
 	mutedStyle = lipgloss.NewStyle().
-			Foreground(colorTextMuted)
+		Foreground(colorTextMuted)
 
 	dimStyle = lipgloss.NewStyle().
-			Foreground(colorTextDim)
+		Foreground(colorTextDim)
 
-	// Bold text
 	boldStyle = lipgloss.NewStyle().
-			Foreground(colorText).
-			Bold(true)
+		Foreground(colorText).
+		Bold(true)
 
 	// Title styles
 	titleStyle = lipgloss.NewStyle().
-			Foreground(colorPrimary).
-			Bold(true)
+		Foreground(colorPrimary).
+		Bold(true)
 
 	subtitleStyle = lipgloss.NewStyle().
-			Foreground(colorSecondary).
-			Bold(true)
-)
+		Foreground(colorSecondary).
+		Bold(true)
 
-// =============================================================================
-// Status Indicator Styles
-// =============================================================================
-
-var (
 	// Status indicator styles
 	statusOK = lipgloss.NewStyle().
-			Foreground(colorSuccess).
-			Bold(true)
+		Foreground(colorSuccess).
+		Bold(true)
 
 	statusWarning = lipgloss.NewStyle().
-			Foreground(colorWarning).
-			Bold(true)
+		Foreground(colorWarning).
+		Bold(true).
+		Underline(p.emphasize)
 
 	statusError = lipgloss.NewStyle().
-			Foreground(colorError).
-			Bold(true)
+		Foreground(colorError).
+		Bold(true).
+		Reverse(p.emphasize)
 
 	statusInfo = lipgloss.NewStyle().
-			Foreground(colorInfo).
-			Bold(true)
-)
+		Foreground(colorInfo).
+		Bold(true)
 
-// =============================================================================
-// Layout Styles
-// =============================================================================
-
-var (
 	// Box/panel styles
 	boxStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorBorder).
-			Padding(0, 1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBorder).
+		Padding(0, 1)
 
-	// Header style
 	headerStyle = lipgloss.NewStyle().
-			Foreground(colorText).
-			Background(colorPrimary).
-			Bold(true).
-			Padding(0, 1).
-			MarginBottom(1)
+		Foreground(colorText).
+		Background(colorPrimary).
+		Bold(true).
+		Padding(0, 1).
+		MarginBottom(1)
+	if p.emphasize {
+		// Text on a colored bar is the hardest contrast case
+		headerStyle = headerStyle.Reverse(true).Background(lipgloss.NoColor{})
+	}
 
-	// Section header style
 	sectionHeaderStyle = lipgloss.NewStyle().
-				Foreground(colorSecondary).
-				Bold(true).
-				BorderStyle(lipgloss.NormalBorder()).
-				BorderBottom(true).
-				BorderForeground(colorBorder)
+		Foreground(colorSecondary).
+		Bold(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		BorderForeground(colorBorder)
 
-	// Footer style
 	footerStyle = lipgloss.NewStyle().
-			Foreground(colorTextMuted).
-			MarginTop(1)
-)
-
-// =============================================================================
-// Value Styles
-// =============================================================================
+		Foreground(colorTextMuted).
+		MarginTop(1)
 
-var (
 	// Numeric value styles
 	valueStyle = lipgloss.NewStyle().
-			Foreground(colorText).
-			Bold(true)
+		Foreground(colorText).
+		Bold(true)
 
 	valueGoodStyle = lipgloss.NewStyle().
-			Foreground(colorSuccess).
-			Bold(true)
+		Foreground(colorSuccess).
+		Bold(true)
 
 	valueBadStyle = lipgloss.NewStyle().
-			Foreground(colorError).
-			Bold(true)
+		Foreground(colorError).
+		Bold(true).
+		Reverse(p.emphasize)
 
 	valueWarnStyle = lipgloss.NewStyle().
-			Foreground(colorWarning).
-			Bold(true)
+		Foreground(colorWarning).
+		Bold(true).
+		Underline(p.emphasize)
 
 	// Label styles
 	labelStyle = lipgloss.NewStyle().
-			Foreground(colorTextMuted).
-			Width(20)
+		Foreground(colorTextMuted).
+		Width(20)
 
 	labelWideStyle = lipgloss.NewStyle().
-			Foreground(colorTextMuted).
-			Width(25)
+		Foreground(colorTextMuted).
+		Width(25)
 
-	// Unit styles (for ms, KB, etc.)
 	unitStyle = lipgloss.NewStyle().
-			Foreground(colorTextDim)
-)
-
-// =============================================================================
-// Progress Bar Styles
-// =============================================================================
+		Foreground(colorTextDim)
 
-var (
+	// Progress bar styles
 	progressBarStyle = lipgloss.NewStyle().
-				Foreground(colorPrimary)
+		Foreground(colorPrimary)
 
 	progressBarEmptyStyle = lipgloss.NewStyle().
-				Foreground(colorBorder)
+		Foreground(colorBorder)
 
 	progressPercentStyle = lipgloss.NewStyle().
-				Foreground(colorText).
-				Bold(true)
-)
+		Foreground(colorText).
+		Bold(true)
 
-// =============================================================================
-// Table Styles
-// =============================================================================
-
-var (
+	// Table styles
 	tableHeaderStyle = lipgloss.NewStyle().
-				Foreground(colorSecondary).
-				Bold(true).
-				BorderStyle(lipgloss.NormalBorder()).
-				BorderBottom(true).
-				BorderForeground(colorBorder)
+		Foreground(colorSecondary).
+		Bold(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		BorderForeground(colorBorder)
 
 	tableCellStyle = lipgloss.NewStyle().
-			Foreground(colorText).
-			PaddingRight(2)
+		Foreground(colorText).
+		PaddingRight(2)
 
 	tableRowEvenStyle = lipgloss.NewStyle().
-				Foreground(colorText)
+		Foreground(colorText)
 
 	tableRowOddStyle = lipgloss.NewStyle().
-				Foreground(colorTextMuted)
-)
+		Foreground(colorTextMuted)
+}
 
 // =============================================================================
 // Metrics Status Indicator
@@ -3,6 +3,12 @@ package tui
 import (
 	"strings"
 	"testing"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
 )
 
 // =============================================================================
@@ -240,3 +246,95 @@ func TestFormatSpeedValue(t *testing.T) {
 		})
 	}
 }
+
+// =============================================================================
+// Tests: Themes
+// =============================================================================
+
+func TestSetTheme_Unknown(t *testing.T) {
+	t.Cleanup(func() { SetTheme(ThemeDefault) })
+
+	if err := SetTheme("solarized"); err == nil {
+		t.Error("SetTheme() should reject unknown themes")
+	}
+	for _, name := range ValidThemes {
+		if err := SetTheme(name); err != nil {
+			t.Errorf("SetTheme(%q) error = %v", name, err)
+		}
+	}
+}
+
+func TestSetTheme_Monochrome(t *testing.T) {
+	t.Cleanup(func() { SetTheme(ThemeDefault) })
+
+	if err := SetTheme(ThemeMonochrome); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := colorError.(lipgloss.NoColor); !ok {
+		t.Errorf("colorError = %v, want NoColor", colorError)
+	}
+	// Status must stay distinguishable without color
+	if !statusError.GetReverse() || !valueWarnStyle.GetUnderline() {
+		t.Error("monochrome errors/warnings need reverse/underline cues")
+	}
+
+	if err := SetTheme(ThemeDefault); err != nil {
+		t.Fatal(err)
+	}
+	if statusError.GetReverse() {
+		t.Error("default theme should not reverse errors")
+	}
+}
+
+func TestSetTheme_ASCII(t *testing.T) {
+	t.Cleanup(func() { SetTheme(ThemeDefault) })
+
+	model := New(Config{TargetClients: 10, StreamURL: "http://example.com/stream.m3u8"})
+	model.width = 100
+	model.stats = &stats.AggregatedStats{ActiveClients: 10, TotalHTTPErrors: map[int]int64{503: 2}}
+	model.debugStats = &stats.DebugStatsAggregate{
+		SegmentsDownloaded: 100,
+		HTTPOpenCount:      100,
+		TCPConnectCount:    10,
+		TCPSuccessCount:    10,
+		Discontinuities:    1,
+		AdMarkers:          2,
+		SlowestSegments: []stats.SlowSegment{
+			{URL: "http://cdn/live/segment_with_a_long_name_00001.ts", ClientID: 1, WallTime: 1200 * time.Millisecond},
+			{URL: "http://cdn/live/segment_with_a_long_name_00002.ts", ClientID: 2, WallTime: 1100 * time.Millisecond},
+			{URL: "http://cdn/live/segment_with_a_long_name_00003.ts", ClientID: 3, WallTime: 1000 * time.Millisecond},
+		},
+		HottestSegments: []stats.URLCount{
+			{URL: "http://cdn/live/seg00001.ts", Requests: 40},
+			{URL: "http://cdn/live/seg00002.ts", Requests: 30},
+		},
+	}
+
+	model.paused = true // Header and footer scrub hints
+
+	unicodeView := model.View()
+	if !strings.Contains(unicodeView, "📺") || !strings.Contains(unicodeView, "╭") {
+		t.Fatal("default view should contain emoji and rounded borders")
+	}
+	if err := SetTheme(ThemeASCII); err != nil {
+		t.Fatal(err)
+	}
+	asciiView := model.View()
+
+	for i, r := range asciiView {
+		if r > unicode.MaxASCII {
+			t.Fatalf("non-ASCII %q at byte %d in ascii theme", r, i)
+		}
+	}
+
+	// Glyphs are padded to their cell width, so the layout is unchanged
+	uLines, aLines := strings.Split(unicodeView, "\n"), strings.Split(asciiView, "\n")
+	if len(uLines) != len(aLines) {
+		t.Fatalf("ascii view has %d lines, want %d", len(aLines), len(uLines))
+	}
+	for i := range uLines {
+		if uw, aw := lipgloss.Width(uLines[i]), lipgloss.Width(aLines[i]); uw != aw {
+			t.Errorf("line %d width %d, want %d: %q", i, aw, uw, aLines[i])
+		}
+	}
+}
@@ -112,31 +112,76 @@ func (m Model) renderSummaryView() string {
 	sections = append(sections, m.renderHeader())
 
 	// Progress section
-	sections = append(sections, m.renderProgress())
+	sections = m.appendPanel(sections, PanelProgress, m.renderProgress)
 
 	// Stats sections (only if we have stats)
 	if m.stats != nil {
-		sections = append(sections, m.renderRequestStats())
-		sections = append(sections, m.renderLatencyStats())
-		sections = append(sections, m.renderHealthAndErrors())
+		sections = m.appendPanel(sections, PanelRequests, m.renderRequestStats)
+		sections = m.appendPanel(sections, PanelLatency, m.renderLatencyStats)
+		sections = m.appendPanel(sections, PanelHealth, m.renderHealthAndErrors)
 	}
 
 	// Origin metrics section (if configured)
 	if m.originScraper != nil {
-		sections = append(sections, m.renderOriginMetrics())
+		sections = m.appendPanel(sections, PanelOrigin, m.renderOriginMetrics)
 	}
 
 	// Layered debug metrics (HLS/HTTP/TCP) - Phase 7
-	if m.debugStats != nil {
+	if m.debugStats != nil && (m.showPanel(PanelHLS) || m.showPanel(PanelHTTP) || m.showPanel(PanelTCP)) {
 		sections = append(sections, m.renderDebugMetrics())
 	}
 
+	// A/B origin comparison (-compare-url)
+	if m.compare != nil {
+		sections = m.appendPanel(sections, PanelCompare, m.renderCompare)
+	}
+
 	// Footer
 	sections = append(sections, m.renderFooter())
 
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
 
+// panelTitles names each section when collapsed.
+var panelTitles = map[string]string{
+	PanelProgress: "Ramp Progress",
+	PanelRequests: "Request Statistics",
+	PanelLatency:  "Latency & Throughput",
+	PanelHealth:   "Playback Health & Errors",
+	PanelOrigin:   "Origin Server",
+	PanelHLS:      "📺 HLS LAYER",
+	PanelHTTP:     "🌐 HTTP LAYER",
+	PanelTCP:      "🔌 TCP LAYER",
+	PanelCompare:  "Origin A/B Comparison",
+}
+
+// appendPanel adds a boxed section unless it is deselected; collapsed
+// sections render as a one-line box.
+func (m Model) appendPanel(sections []string, panel string, render func() string) []string {
+	switch {
+	case !m.showPanel(panel):
+		return sections
+	case m.collapsed[panel]:
+		return append(sections, boxStyle.Width(m.width-2).Render(m.renderCollapsedTitle(panel)))
+	default:
+		return append(sections, render())
+	}
+}
+
+// renderCollapsedTitle renders a section title with the key that expands it.
+func (m Model) renderCollapsedTitle(panel string) string {
+	key := 0
+	for i, name := range AllPanels {
+		if name == panel {
+			key = i + 1
+		}
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		sectionHeaderStyle.Render(panelTitles[panel]),
+		dimStyle.Render(fmt.Sprintf("  ▸ collapsed (%d to expand)", key)),
+	)
+}
+
 // renderDetailedView renders per-client details.
 func (m Model) renderDetailedView() string {
 	var sections []string
@@ -169,6 +214,9 @@ func (m Model) renderHeader() string {
 		m.targetClients,
 		formatDuration(m.Elapsed()),
 	)
+	if m.paused {
+		header += fmt.Sprintf("│ ⏸ PAUSED, %s behind live ", formatDuration(m.live.at.Sub(m.lastUpdate)))
+	}
 
 	return headerStyle.Width(m.width).Render(header)
 }
@@ -194,6 +242,9 @@ func (m Model) renderProgress() string {
 	} else {
 		status = statusInfo.Render(fmt.Sprintf("Ramping up... %d/%d", m.ActiveClients(), m.targetClients))
 	}
+	if line := m.renderCapacity(); line != "" {
+		status += "  " + line
+	}
 
 	content := lipgloss.JoinVertical(lipgloss.Left,
 		sectionHeaderStyle.Render("Ramp Progress"),
@@ -204,6 +255,43 @@ func (m Model) renderProgress() string {
 	return boxStyle.Width(m.width - 2).Render(content)
 }
 
+// renderSLO renders the -slo latency objective: compliance so far and the
+// recent error budget burn rate. Warns while burning faster than the budget
+// allows, and turns red once the objective is missed.
+func (m Model) renderSLO() string {
+	s := m.slo
+	if !s.Enabled() {
+		return ""
+	}
+	text := "SLO: " + s.String()
+	switch {
+	case !s.Met():
+		return statusError.Render(text)
+	case s.BurnRate > 1:
+		return statusWarning.Render(text)
+	default:
+		return statusOK.Render(text)
+	}
+}
+
+// renderCapacity renders the projected origin capacity, once the ramp has
+// sampled enough latency growth for one.
+func (m Model) renderCapacity() string {
+	p := m.capacity
+	switch {
+	case p.Exceeded:
+		return statusError.Render(fmt.Sprintf("P95 over %s at %d clients", p.Threshold, p.Clients))
+	case p.Clients > 0:
+		text := fmt.Sprintf("Projected capacity: ~%d clients (P95 %s)", p.Clients, p.Threshold)
+		if m.targetClients > 0 && p.Clients < m.targetClients {
+			return statusWarning.Render(text)
+		}
+		return dimStyle.Render(text)
+	default:
+		return ""
+	}
+}
+
 // =============================================================================
 // Request Statistics
 // =============================================================================
@@ -322,7 +410,11 @@ func (m Model) renderLatencyStats() string {
 	// Note about accurate timestamps and segment sizes
 	note := dimStyle.Render("* Using accurate FFmpeg timestamps and segment sizes from origin")
 
-	content := lipgloss.JoinVertical(lipgloss.Left, threeColContent, note)
+	lines := []string{threeColContent}
+	if line := m.renderSLO(); line != "" {
+		lines = append(lines, line)
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, append(lines, note)...)
 
 	return boxStyle.Width(m.width - 2).Render(content)
 }
@@ -484,8 +576,7 @@ func (m Model) renderHealthAndErrors() string {
 	}
 
 	// Render two columns side-by-side
-	// Available width: m.width - 2 (borders) - 2 (padding) = m.width - 4
-	twoColContent := renderTwoColumns(leftCol, rightCol, m.width-4)
+	twoColContent := renderTwoColumns(leftCol, rightCol, m.columnWidth)
 
 	return boxStyle.Width(m.width - 2).Render(twoColContent)
 }
@@ -587,14 +678,24 @@ func (m Model) renderDebugMetrics() string {
 	header := m.renderDebugMetricsHeader(ds)
 	sections = append(sections, header)
 
-	// HLS Layer
-	sections = append(sections, m.renderHLSLayer(ds))
-
-	// HTTP Layer
-	sections = append(sections, m.renderHTTPLayer(ds))
-
-	// TCP Layer
-	sections = append(sections, m.renderTCPLayer(ds))
+	// Layers (each can be hidden or collapsed)
+	layers := []struct {
+		panel  string
+		render func(*stats.DebugStatsAggregate) string
+	}{
+		{PanelHLS, m.renderHLSLayer},
+		{PanelHTTP, m.renderHTTPLayer},
+		{PanelTCP, m.renderTCPLayer},
+	}
+	for _, layer := range layers {
+		switch {
+		case !m.showPanel(layer.panel):
+		case m.collapsed[layer.panel]:
+			sections = append(sections, m.renderCollapsedTitle(layer.panel))
+		default:
+			sections = append(sections, layer.render(ds))
+		}
+	}
 
 	// Join all sections
 	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
@@ -870,19 +971,116 @@ func (m Model) renderHLSLayer(ds *stats.DebugStatsAggregate) string {
 		),
 	)
 
+	// Discontinuities (shown once any client has crossed one or seen an ad marker)
+	if ds.Discontinuities > 0 || ds.AdMarkers > 0 {
+		rightCol = append(rightCol, "") // Empty line separator
+		rightCol = append(rightCol, labelStyle.Render("Discontinuities"))
+
+		// Indicator: clients between a discontinuity and their next segment (bracket
+		// column is 12 wide, hence the terse note)
+		discStyle := valueStyle
+		discNote := ""
+		if ds.ClientsRecovering > 0 {
+			discStyle = valueWarnStyle
+			discNote = fmt.Sprintf("(%d active)", ds.ClientsRecovering)
+		}
+		rightCol = append(rightCol,
+			renderMetricRow(
+				"  🔀 Crossed:",
+				formatNumberRaw(ds.Discontinuities),
+				discNote,
+				&discStyle,
+				&discStyle,
+			),
+		)
+
+		// Longer than a couple of segments means players would have rebuffered
+		recoveryStyle := valueStyle
+		if ds.RecoveryMaxMs > 4000 {
+			recoveryStyle = valueWarnStyle
+		}
+		rightCol = append(rightCol,
+			renderMetricRow(
+				"  Recovery:",
+				formatMsRaw(ds.RecoveryAvgMs)+" avg",
+				"/"+formatMsRaw(ds.RecoveryMaxMs)+" max",
+				&recoveryStyle,
+				&recoveryStyle,
+			),
+		)
+		rightCol = append(rightCol,
+			renderMetricRow(
+				"  📺 Ad breaks:",
+				formatNumberRaw(ds.AdBreaks),
+				fmt.Sprintf("(%s cues)", formatNumberRaw(ds.AdMarkers)),
+				&valueStyle,
+				&mutedStyle,
+			),
+		)
+	}
+
 	// Render two columns
-	// Available width: m.width - 2 (borders) - 2 (padding) = m.width - 4
-	twoColContent := renderTwoColumns(leftCol, rightCol, m.width-4) // Account for box borders and padding
+	twoColContent := renderTwoColumns(leftCol, rightCol, m.columnWidth)
 
 	// Combine with header and separator
 	separator := strings.Repeat("─", m.width-4)
-	content := lipgloss.JoinVertical(lipgloss.Left,
+	rows := []string{
 		sectionHeaderStyle.Render("📺 HLS LAYER (libavformat/hls.c)"),
 		separator,
 		twoColContent,
-	)
+	}
+	rows = append(rows, m.renderHotSpots(ds)...)
 
-	return content
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// hotSpotsShown is how many hot spot entries fit on a dashboard line.
+const hotSpotsShown = 3
+
+// renderHotSpots renders the slowest and most downloaded segments, one line
+// each, so slowness concentrated on a few segments (or one edge) stands out.
+// The exit summary has the full lists with URLs.
+func (m Model) renderHotSpots(ds *stats.DebugStatsAggregate) []string {
+	var lines []string
+	if len(ds.SlowestSegments) > 0 {
+		var parts []string
+		for i, seg := range ds.SlowestSegments {
+			if i == hotSpotsShown {
+				break
+			}
+			parts = append(parts, fmt.Sprintf("%s %s (client %d)",
+				segmentName(seg.URL), formatMsFromDuration(seg.WallTime), seg.ClientID))
+		}
+		lines = append(lines, m.renderHotSpotLine("  🐢 Slowest:", parts))
+	}
+	if len(ds.HottestSegments) > 0 {
+		var parts []string
+		for i, u := range ds.HottestSegments {
+			if i == hotSpotsShown {
+				break
+			}
+			parts = append(parts, fmt.Sprintf("%s ×%s", segmentName(u.URL), formatNumberRaw(u.Requests)))
+		}
+		lines = append(lines, m.renderHotSpotLine("  🔥 Hottest:", parts))
+	}
+	return lines
+}
+
+func (m Model) renderHotSpotLine(label string, parts []string) string {
+	line := strings.Join(parts, " · ")
+	if maxLen := m.width - 4 - labelColWidth; maxLen > 0 && len([]rune(line)) > maxLen {
+		line = string([]rune(line)[:maxLen-1]) + "…"
+	}
+	return lipgloss.NewStyle().Width(labelColWidth).Render(label) + mutedStyle.Render(line)
+}
+
+// segmentName shortens a segment URL to its file name.
+func segmentName(url string) string {
+	url, _, _ = strings.Cut(url, "?")
+	if i := strings.LastIndex(url, "/"); i >= 0 && i < len(url)-1 {
+		return url[i+1:]
+	}
+	return url
 }
 
 // renderHTTPLayer renders HTTP layer metrics in two-column layout (Phase 8.6).
@@ -1021,8 +1219,7 @@ func (m Model) renderHTTPLayer(ds *stats.DebugStatsAggregate) string {
 	)
 
 	// Render two columns
-	// Available width: m.width - 2 (borders) - 2 (padding) = m.width - 4
-	twoColContent := renderTwoColumns(leftCol, rightCol, m.width-4) // Account for box borders and padding
+	twoColContent := renderTwoColumns(leftCol, rightCol, m.columnWidth)
 
 	// Combine with header and separator
 	separator := strings.Repeat("─", m.width-4)
@@ -1164,8 +1361,7 @@ func (m Model) renderTCPLayer(ds *stats.DebugStatsAggregate) string {
 	)
 
 	// Render two columns
-	// Available width: m.width - 2 (borders) - 2 (padding) = m.width - 4
-	twoColContent := renderTwoColumns(leftCol, rightCol, m.width-4) // Account for box borders and padding
+	twoColContent := renderTwoColumns(leftCol, rightCol, m.columnWidth)
 
 	// Combine with header and separator
 	separator := strings.Repeat("─", m.width-4)
@@ -1202,6 +1398,13 @@ func (m Model) renderFooter() string {
 		"q: quit",
 		"d: toggle details",
 		"r: refresh",
+		"1-9: fold",
+		"[ ]: width",
+	}
+	if m.paused {
+		shortcuts = append(shortcuts, "p: live", "←/→: scrub")
+	} else {
+		shortcuts = append(shortcuts, "p: pause")
 	}
 
 	// Stream URL (truncated if needed)
@@ -1247,26 +1450,22 @@ func formatMsFromDuration(d time.Duration) string {
 
 // renderTwoColumns renders two columns side-by-side with a separator.
 // Used for layered dashboard (HLS/HTTP/TCP) to match design specification.
-// Fixed widths: left=42, right=42, separator=3, total=87.
-// Increased to 42+42 to accommodate 3-column layout within each section:
+// Default width is 42 per column (adjustable with [ and ], saved in prefs),
+// to accommodate the 3-column layout within each section:
 //   - labelColWidth (18) + valueColWidth (10) + bracketColWidth (12) = 40 chars
 //   - Plus 2 chars padding = 42 chars per section
-func renderTwoColumns(left, right []string, totalWidth int) string {
-	// Fixed column widths - increased to accommodate 3-column layout (label|value|bracket)
-	// Each section needs: 18 (label) + 10 (value) + 12 (bracket) = 40 chars, plus 2 chars padding
-	const (
-		leftColWidth  = 42
-		rightColWidth = 42
-		separatorWidth = 3 // " │ "
-	)
+func renderTwoColumns(left, right []string, colWidth int) string {
+	if colWidth <= 0 {
+		colWidth = defaultColumnWidth
+	}
 
 	// Render left column with fixed width
 	leftContent := lipgloss.JoinVertical(lipgloss.Left, left...)
-	leftStyle := lipgloss.NewStyle().Width(leftColWidth)
+	leftStyle := lipgloss.NewStyle().Width(colWidth)
 
 	// Render right column with fixed width
 	rightContent := lipgloss.JoinVertical(lipgloss.Left, right...)
-	rightStyle := lipgloss.NewStyle().Width(rightColWidth)
+	rightStyle := lipgloss.NewStyle().Width(colWidth)
 
 	// Join with separator
 	separator := mutedStyle.Render(" │ ")
@@ -1387,12 +1586,37 @@ func (m Model) renderOriginMetrics() string {
 	}
 
 	// Render two columns side-by-side
-	// Available width: m.width - 2 (borders) - 2 (padding) = m.width - 4
-	twoColContent := renderTwoColumns(leftCol, rightCol, m.width-4)
+	twoColContent := renderTwoColumns(leftCol, rightCol, m.columnWidth)
 
 	return boxStyle.Width(m.width - 2).Render(twoColContent)
 }
 
+// renderCompare renders the two origins of a -compare-url run (or the
+// cohorts of a -compare-opt run) side by side, with B's difference from A
+// colored by whether it is better. It follows the metrics update, so the
+// deltas move as the run goes.
+func (m Model) renderCompare() string {
+	c := m.compare
+	rows := []string{
+		sectionHeaderStyle.Render(c.Title()),
+		dimStyle.Render("A: " + c.A.Label()),
+		dimStyle.Render("B: " + c.B.Label()),
+		mutedStyle.Render(fmt.Sprintf("%-14s %16s %16s %10s", "", "A", "B", "B vs A")),
+	}
+	for _, r := range c.Rows() {
+		delta := fmt.Sprintf("%10s", r.Delta)
+		switch r.Better {
+		case 1:
+			delta = statusOK.Render(delta)
+		case -1:
+			delta = statusError.Render(delta)
+		}
+		rows = append(rows, fmt.Sprintf("%-14s %16s %16s ", r.Metric, r.A, r.B)+delta)
+	}
+
+	return boxStyle.Width(m.width - 2).Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
 // renderOriginMetricRow renders a single origin metric row.
 func renderOriginMetricRow(label, value, extra string) string {
 	parts := []string{
@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
+)
+
+type mockCompareSource struct {
+	comparison *stats.OriginComparison
+}
+
+func (m *mockCompareSource) OriginComparison() *stats.OriginComparison {
+	return m.comparison
+}
+
+func TestView_Compare(t *testing.T) {
+	source := &mockCompareSource{}
+	model := New(Config{
+		TargetClients: 20,
+		StreamURL:     "http://old/stream.m3u8",
+		CompareSource: source,
+	})
+	model.width = 100
+
+	// No comparison (yet): no section
+	newModel, _ := model.Update(TickMsg(time.Now()))
+	if view := newModel.(Model).View(); strings.Contains(view, "A/B Comparison") {
+		t.Error("comparison shown before there is one")
+	}
+
+	source.comparison = &stats.OriginComparison{
+		A: stats.OriginResult{Name: "A", URL: "http://old/stream.m3u8", Share: 50, Clients: 10, Requests: 1000, SegmentP95: 400 * time.Millisecond},
+		B: stats.OriginResult{Name: "B", URL: "http://new/stream.m3u8", Share: 50, Clients: 10, Requests: 1000, SegmentP95: 300 * time.Millisecond},
+	}
+	newModel, _ = newModel.(Model).Update(TickMsg(time.Now()))
+	m := newModel.(Model)
+	view := m.View()
+	for _, want := range []string{
+		"Origin A/B Comparison",
+		"B: http://new/stream.m3u8",
+		"Segment P95              400 ms           300 ms",
+		"-25.0%",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Same origin, different request options
+	source.comparison = &stats.OriginComparison{
+		A: stats.OriginResult{Name: "A", URL: "http://old/stream.m3u8", Share: 50, Clients: 10, Requests: 1000},
+		B: stats.OriginResult{Name: "B", URL: "http://old/stream.m3u8", Options: "keepalive=off", Share: 50, Clients: 10, Requests: 1500},
+	}
+	redBlack, _ := m.Update(TickMsg(time.Now()))
+	view = redBlack.(Model).View()
+	for _, want := range []string{"Red/Black Comparison", "B: http://old/stream.m3u8 [keepalive=off]"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Key 9 folds it
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("9")})
+	if view := newModel.(Model).View(); !strings.Contains(view, "Origin A/B Comparison  ▸ collapsed (9 to expand)") {
+		t.Errorf("comparison not collapsed by 9:\n%s", view)
+	}
+}
@@ -1,7 +1,9 @@
 package tui
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/stats"
 )
@@ -196,3 +198,53 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+// TestHLSLayer_Discontinuities tests the discontinuity block and its indicator
+func TestHLSLayer_Discontinuities(t *testing.T) {
+	model := New(Config{TargetClients: 10})
+	model.width = 120
+	model.height = 50
+
+	// Hidden until something happens
+	if out := model.renderHLSLayer(&stats.DebugStatsAggregate{}); strings.Contains(out, "Discontinuities") {
+		t.Error("discontinuity block shown without discontinuities")
+	}
+
+	out := model.renderHLSLayer(&stats.DebugStatsAggregate{
+		Discontinuities:        12,
+		ClientsRecovering:      3,
+		DiscontinuityRecovered: 9,
+		RecoveryAvgMs:          850,
+		RecoveryMaxMs:          2400,
+		AdMarkers:              4,
+		AdBreaks:               2,
+	})
+	for _, want := range []string{"Discontinuities", "Crossed:", "(3 active)", "Recovery:", "Ad breaks:", "(4 cues)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderHLSLayer() missing %q", want)
+		}
+	}
+}
+
+func TestHLSLayer_HotSpots(t *testing.T) {
+	model := New(Config{TargetClients: 10})
+	model.width = 120
+	model.height = 50
+
+	if out := model.renderHLSLayer(&stats.DebugStatsAggregate{}); strings.Contains(out, "Slowest") {
+		t.Error("hot spots shown without data")
+	}
+
+	out := model.renderHLSLayer(&stats.DebugStatsAggregate{
+		SlowestSegments: []stats.SlowSegment{
+			{URL: "http://edge/live/seg00042.ts?token=x", ClientID: 7, WallTime: 1800 * time.Millisecond},
+			{URL: "http://edge/live/seg00041.ts", ClientID: 3, WallTime: 900 * time.Millisecond},
+		},
+		HottestSegments: []stats.URLCount{{URL: "http://edge/live/seg00040.ts", Requests: 12}},
+	})
+	for _, want := range []string{"Slowest:", "seg00042.ts 1800 ms (client 7)", "seg00041.ts 900 ms", "Hottest:", "seg00040.ts ×12"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("renderHLSLayer() missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package swarm
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+)
+
+// Option configures a swarm. Unset options take the command's defaults
+// (see go-ffmpeg-hls-swarm -help), except where noted.
+type Option func(*options)
+
+type options struct {
+	cfg           *config.Config
+	logger        *slog.Logger
+	output        io.Writer
+	phaseHandlers []func(PhaseEvent)
+}
+
+func newOptions(streamURL string) *options {
+	cfg := config.DefaultConfig()
+	cfg.StreamURL = streamURL
+	cfg.TUIEnabled = false
+	cfg.MetricsAddrs = []string{"127.0.0.1:0"}
+	return &options{
+		cfg:    cfg,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		output: io.Discard,
+	}
+}
+
+// WithClients sets the number of clients to ramp up to (-clients).
+func WithClients(n int) Option {
+	return func(o *options) { o.cfg.Clients = n }
+}
+
+// WithRampRate sets how many clients are started per second (-ramp-rate).
+func WithRampRate(perSecond int) Option {
+	return func(o *options) { o.cfg.RampRate = perSecond }
+}
+
+// WithDuration stops the swarm after d (-duration). The default, 0, runs
+// until Stop.
+func WithDuration(d time.Duration) Option {
+	return func(o *options) { o.cfg.Duration = d }
+}
+
+// WithVariant sets which variants of a master playlist the clients fetch
+// (-variant): "all", "highest", "lowest" or "first".
+func WithVariant(variant string) Option {
+	return func(o *options) { o.cfg.Variant = variant }
+}
+
+// WithHeaders adds request headers, "Name: value" (-header).
+func WithHeaders(headers ...string) Option {
+	return func(o *options) { o.cfg.Headers = append(o.cfg.Headers, headers...) }
+}
+
+// WithUserAgent sets the clients' User-Agent (-user-agent).
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) { o.cfg.UserAgent = userAgent }
+}
+
+// WithFFmpegPath sets the FFmpeg binary (-ffmpeg).
+func WithFFmpegPath(path string) Option {
+	return func(o *options) { o.cfg.FFmpegPath = path }
+}
+
+// WithTargetDuration sets the stream's segment duration, used for stall
+// detection and latency thresholds (-target-duration).
+func WithTargetDuration(d time.Duration) Option {
+	return func(o *options) { o.cfg.TargetDuration = d }
+}
+
+// WithStats turns parsing of the clients' FFmpeg output on or off
+// (-stats). Without it Stats reports client counts only.
+func WithStats(enabled bool) Option {
+	return func(o *options) { o.cfg.StatsEnabled = enabled }
+}
+
+// WithMetricsAddrs sets where Prometheus metrics are served (-metrics).
+// The default is a random port on 127.0.0.1.
+func WithMetricsAddrs(addrs ...string) Option {
+	return func(o *options) { o.cfg.MetricsAddrs = addrs }
+}
+
+// WithLoadEstimate turns the origin load estimate, which fetches the
+// playlist before the ramp, on or off (-estimate-load).
+func WithLoadEstimate(enabled bool) Option {
+	return func(o *options) { o.cfg.EstimateLoad = enabled }
+}
+
+// WithSkipPreflight skips the host checks (file descriptors, processes,
+// FFmpeg) run before the ramp (-skip-preflight).
+func WithSkipPreflight() Option {
+	return func(o *options) { o.cfg.SkipPreflight = true }
+}
+
+// WithQuarantine parks a client that fails failures times within window
+// for cooldown, instead of restarting it endlessly (-quarantine-after,
+// -quarantine-window, -quarantine-cooldown).
+func WithQuarantine(failures int, window, cooldown time.Duration) Option {
+	return func(o *options) {
+		o.cfg.QuarantineFailures = failures
+		o.cfg.QuarantineWindow = window
+		o.cfg.QuarantineCooldown = cooldown
+	}
+}
+
+// WithMaxRestarts stops restarting a client after n restarts (0, the
+// default: never stop). The run's error then wraps ErrMaxRestarts.
+func WithMaxRestarts(n int) Option {
+	return func(o *options) { o.cfg.MaxRestarts = n }
+}
+
+// WithSLO sets a segment latency objective as TARGET:THRESHOLD, e.g.
+// "99:1s" (-slo; needs WithStats). A run missing it returns an error
+// wrapping ErrThresholdViolated.
+func WithSLO(spec string) Option {
+	return func(o *options) { o.cfg.SLO = spec }
+}
+
+// WithStrict makes Start fail on configuration warnings, settings that
+// are valid but likely to hurt the run (-strict). Otherwise they are
+// logged.
+func WithStrict() Option {
+	return func(o *options) { o.cfg.Strict = true }
+}
+
+// WithLogger sets the logger for the swarm's structured logs. The default
+// discards them.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// WithOutput sets where the human-readable output goes: preflight
+// results, the load estimate and the exit summary. The default discards
+// it.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) { o.output = w }
+}
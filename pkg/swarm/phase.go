@@ -0,0 +1,48 @@
+package swarm
+
+import (
+	"time"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/orchestrator"
+)
+
+// Phase is a stage of a swarm's lifecycle. A swarm goes starting ->
+// ramping -> steady -> draining -> stopped; Scale takes a steady swarm
+// back to ramping while it adds clients. Prometheus exports it as
+// hls_swarm_phase.
+type Phase string
+
+const (
+	PhaseStarting Phase = "starting" // Preflight and probes, before Start returns
+	PhaseRamping  Phase = "ramping"  // Clients being started at the ramp rate
+	PhaseSteady   Phase = "steady"   // Every wanted client started
+	PhaseDraining Phase = "draining" // Clients stopping
+	PhaseStopped  Phase = "stopped"  // Run over
+)
+
+// PhaseEvent is a phase change, passed to WithPhaseHandler handlers.
+type PhaseEvent struct {
+	Phase    Phase
+	Previous Phase // "" for the first event
+	Time     time.Time
+	Clients  int // Wanted clients at the change
+}
+
+// WithPhaseHandler calls fn with every phase change, from PhaseStarting to
+// PhaseStopped. Calls are made one at a time, in order, on a goroutine of
+// their own, so fn may call the Swarm (Scale when steady, say). They can
+// lag the swarm: Wait may return before the PhaseStopped call.
+func WithPhaseHandler(fn func(PhaseEvent)) Option {
+	return func(o *options) { o.phaseHandlers = append(o.phaseHandlers, fn) }
+}
+
+func phaseHandler(fn func(PhaseEvent)) func(orchestrator.PhaseEvent) {
+	return func(ev orchestrator.PhaseEvent) {
+		fn(PhaseEvent{Phase: Phase(ev.Phase), Previous: Phase(ev.Previous), Time: ev.Time, Clients: ev.Clients})
+	}
+}
+
+// Phase returns the swarm's current lifecycle phase.
+func (s *Swarm) Phase() Phase {
+	return Phase(s.orch.Phase())
+}
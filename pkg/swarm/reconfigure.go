@@ -0,0 +1,68 @@
+package swarm
+
+import (
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/orchestrator"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/process"
+)
+
+// ClientChange changes the options of running clients (see Reconfigure).
+// Nil and zero fields keep what the clients have.
+type ClientChange struct {
+	// Clients the change applies to, by ID (0 to the client count - 1);
+	// nil for every client, including ones Scale adds later
+	Clients []int
+
+	// Headers replace the clients' extra headers (WithHeaders); empty for
+	// none
+	Headers []string
+
+	// NoCache turns cache bypass headers (Cache-Control: no-cache) on or
+	// off
+	NoCache *bool
+
+	// Variant replaces the variant selection: "all" or "first"
+	Variant string
+
+	// RestartRate, if > 0, restarts the changed clients at this many per
+	// second so the change applies now; otherwise each client picks it up
+	// when it next restarts
+	RestartRate float64
+}
+
+// Reconfigure changes the options of running clients without stopping
+// the swarm. A rolling restart (ch.RestartRate) goes on in the background
+// after Reconfigure returns, and replaces one still going on.
+func (s *Swarm) Reconfigure(ch ClientChange) error {
+	err := s.orch.Reconfigure(orchestrator.ClientChange{
+		Clients:     ch.Clients,
+		Headers:     ch.Headers,
+		NoCache:     ch.NoCache,
+		Variant:     process.VariantSelection(ch.Variant),
+		RestartRate: ch.RestartRate,
+	})
+	if err != nil {
+		select {
+		case <-s.done:
+			return ErrStopped
+		default:
+		}
+	}
+	return err
+}
+
+// RollingRestart restarts every client, rate per second, so each opens
+// new connections and fetches the manifest afresh, as when a player app
+// update reaches every viewer. It goes on in the background after
+// RollingRestart returns, and replaces one still going on; the swarm
+// metrics show it as hls_swarm_rolling_restart_active.
+func (s *Swarm) RollingRestart(rate float64) error {
+	err := s.orch.RollingRestart(rate)
+	if err != nil {
+		select {
+		case <-s.done:
+			return ErrStopped
+		default:
+		}
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSwarm_Reconfigure(t *testing.T) {
+	// A fake FFmpeg that logs its arguments, one start per line
+	dir := t.TempDir()
+	starts := filepath.Join(dir, "starts")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\necho \"$*\" | tr -d '\\r\\n' >> " + starts + "\necho >> " + starts + "\nexec sleep 60\n"
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := startTestSwarm(t, WithClients(2), WithFFmpegPath(ffmpeg), WithHeaders("X-Old: 1"))
+
+	if err := s.Reconfigure(ClientChange{Variant: "lowest"}); err == nil {
+		t.Error("Reconfigure(variant lowest) = nil, want error")
+	}
+	if err := s.Reconfigure(ClientChange{Headers: []string{"X-New: 1"}, RestartRate: 50}); err != nil {
+		t.Fatalf("Reconfigure() = %v", err)
+	}
+
+	// Both clients restart with the new headers
+	var lines []string
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		data, _ := os.ReadFile(starts)
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+		if strings.Count(string(data), "X-New: 1") == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	newStarts := 0
+	for _, line := range lines {
+		if strings.Contains(line, "X-New: 1") {
+			newStarts++
+			if strings.Contains(line, "X-Old") {
+				t.Errorf("restarted client kept the old headers: %s", line)
+			}
+		}
+	}
+	if newStarts != 2 {
+		t.Errorf("%d starts with the new headers, want 2:\n%s", newStarts, strings.Join(lines, "\n"))
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	if err := s.Reconfigure(ClientChange{}); !errors.Is(err, ErrStopped) {
+		t.Errorf("Reconfigure() after Stop = %v, want ErrStopped", err)
+	}
+}
+
+func TestSwarm_RollingRestart(t *testing.T) {
+	dir := t.TempDir()
+	starts := filepath.Join(dir, "starts")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	if err := os.WriteFile(ffmpeg, []byte("#!/bin/sh\necho >> "+starts+"\nexec sleep 60\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := startTestSwarm(t, WithClients(3), WithFFmpegPath(ffmpeg))
+	waitStarts := func(want int) {
+		t.Helper()
+		n := 0
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+			data, _ := os.ReadFile(starts)
+			if n = strings.Count(string(data), "\n"); n >= want {
+				break
+			}
+		}
+		if n != want {
+			t.Fatalf("%d starts, want %d", n, want)
+		}
+	}
+	waitStarts(3)
+
+	if err := s.RollingRestart(0); err == nil {
+		t.Error("RollingRestart(0) = nil, want error")
+	}
+	if err := s.RollingRestart(50); err != nil {
+		t.Fatalf("RollingRestart() = %v", err)
+	}
+	waitStarts(6) // Every client starts a second time
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	if err := s.RollingRestart(10); !errors.Is(err, ErrStopped) {
+		t.Errorf("RollingRestart() after Stop = %v, want ErrStopped", err)
+	}
+}
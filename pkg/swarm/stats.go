@@ -0,0 +1,77 @@
+package swarm
+
+import (
+	"maps"
+	"time"
+)
+
+// Stats is a snapshot of a swarm. Totals are cumulative since Start and
+// keep the stats of clients scaled away; throughput is per second over
+// the last aggregation interval.
+//
+// The request, error and latency fields are parsed from the clients'
+// FFmpeg output; with WithStats(false) only the client counts are set.
+type Stats struct {
+	Elapsed time.Duration // Since the ramp began
+	Phase   Phase
+
+	// Clients
+	TargetClients  int // WithClients, or the last Scale
+	RunningClients int // Started and not scaled away, restarting ones included
+	ActiveClients  int // FFmpeg processes running now
+	StalledClients int
+
+	QuarantinedClients int // Parked after failing repeatedly (see WithQuarantine)
+
+	// Cumulative totals
+	ManifestRequests int64
+	SegmentRequests  int64
+	Bytes            int64
+
+	ThroughputBps float64 // Bytes per second
+
+	// Errors
+	HTTPErrors    map[int]int64 // Status code -> count
+	Timeouts      int64
+	Reconnections int64
+	ErrorRate     float64 // Errors / total requests
+
+	// Playback speed, 1.0 = realtime (below means the clients fall behind)
+	AverageSpeed float64
+
+	// Segment download wall time (0 until segments are timed)
+	SegmentP50 time.Duration
+	SegmentP95 time.Duration
+	SegmentP99 time.Duration
+}
+
+// Stats returns a snapshot of the swarm's statistics.
+func (s *Swarm) Stats() Stats {
+	st := Stats{
+		Elapsed:        time.Since(s.started),
+		Phase:          s.Phase(),
+		TargetClients:  s.orch.TargetClients(),
+		RunningClients: s.orch.RunningClients(),
+		ActiveClients:  s.orch.ClientManager().ActiveCount(),
+
+		QuarantinedClients: s.orch.ClientManager().QuarantinedCount(),
+	}
+	if agg := s.orch.GetAggregatedStats(); agg != nil {
+		st.StalledClients = agg.StalledClients
+		st.ManifestRequests = agg.TotalManifestReqs
+		st.SegmentRequests = agg.TotalSegmentReqs
+		st.Bytes = agg.TotalBytes
+		st.ThroughputBps = agg.InstantThroughputRate
+		st.HTTPErrors = maps.Clone(agg.TotalHTTPErrors)
+		st.Timeouts = agg.TotalTimeouts
+		st.Reconnections = agg.TotalReconnections
+		st.ErrorRate = agg.ErrorRate
+		st.AverageSpeed = agg.AverageSpeed
+
+		ds := s.orch.GetDebugStats()
+		st.SegmentP50 = ds.SegmentWallTimeP50
+		st.SegmentP95 = ds.SegmentWallTimeP95
+		st.SegmentP99 = ds.SegmentWallTimeP99
+	}
+	return st
+}
@@ -0,0 +1,210 @@
+// Package swarm embeds the HLS load generator in another Go program: the
+// same supervised FFmpeg clients as the go-ffmpeg-hls-swarm command,
+// driven through Start, Scale, Reconfigure, RollingRestart, Stats and
+// Stop instead of flags and signals.
+//
+//	s, err := swarm.Start(ctx, "http://origin/live/master.m3u8",
+//		swarm.WithClients(50),
+//		swarm.WithRampRate(10),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Stop(context.Background())
+//
+//	if err := s.Scale(ctx, 200); err != nil {
+//		return err
+//	}
+//	fmt.Println(s.Stats().SegmentP95)
+//
+// The dashboard, status line and signal handling are the command's and
+// stay off; the swarm writes nothing unless given WithLogger or
+// WithOutput. Its Prometheus metrics are served on a random loopback port
+// by default (see MetricsAddrs).
+//
+// The metrics are process-wide, so one swarm runs at a time: Start
+// returns ErrRunning while another is running. A Swarm's methods are safe
+// for concurrent use.
+package swarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/config"
+	"github.com/randomizedcoder/go-ffmpeg-hls-swarm/internal/orchestrator"
+)
+
+var (
+	// ErrRunning is returned by Start while another swarm is running in
+	// the process.
+	ErrRunning = errors.New("swarm: another swarm is running")
+
+	// ErrStopped is returned by Scale, Reconfigure and RollingRestart once
+	// the swarm has stopped.
+	ErrStopped = errors.New("swarm: stopped")
+)
+
+// Errors Start's (ErrConfig, ErrEnvironment) and a finished run's (Stop
+// and Wait; the others, several at once) can wrap: branch on them with
+// errors.Is.
+var (
+	// ErrConfig: the options are invalid, or don't fit the stream.
+	ErrConfig = orchestrator.ErrConfig
+
+	// ErrEnvironment: preflight, FFmpeg, the metrics listener or the
+	// origin kept the swarm from starting.
+	ErrEnvironment = orchestrator.ErrEnvironment
+
+	// ErrSafetyAbort: a guard stopped the run early.
+	ErrSafetyAbort = orchestrator.ErrSafetyAbort
+
+	// ErrMaxRestarts: clients stopped for good after WithMaxRestarts.
+	ErrMaxRestarts = orchestrator.ErrMaxRestarts
+
+	// ErrBuildFailed: a client's FFmpeg command couldn't be built.
+	ErrBuildFailed = orchestrator.ErrBuildFailed
+
+	// ErrDrainTimeout: clients were still stopping when the shutdown
+	// timed out.
+	ErrDrainTimeout = orchestrator.ErrDrainTimeout
+
+	// ErrThresholdViolated: the run missed its WithSLO objective.
+	ErrThresholdViolated = orchestrator.ErrThresholdViolated
+)
+
+// running is set from Start until the swarm's run returns.
+var running atomic.Bool
+
+// scalePoll is how often Scale checks whether the ramp has caught up.
+const scalePoll = 20 * time.Millisecond
+
+// Swarm is a running load test, returned by Start.
+type Swarm struct {
+	orch    *orchestrator.Orchestrator
+	cancel  context.CancelFunc
+	started time.Time
+
+	done chan struct{} // Closed once the run has returned
+	err  error         // The run's; set before done is closed
+}
+
+// Start starts a swarm of clients playing streamURL, ramping up at the
+// configured rate, and returns once the first client is being started.
+// Setup failures (invalid options, preflight, a variant probe or the
+// metrics listener) are returned here.
+//
+// ctx bounds the setup only: once Start returns, the swarm runs until
+// Stop, or until the WithDuration duration elapses.
+func Start(ctx context.Context, streamURL string, opts ...Option) (*Swarm, error) {
+	o := newOptions(streamURL)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := config.Validate(o.cfg); err != nil {
+		return nil, fmt.Errorf("swarm: %w: %w", ErrConfig, err)
+	}
+	for _, w := range config.Warnings(o.cfg) {
+		o.logger.Warn("config_warning", "field", w.Field, "message", w.Message, "suggestion", w.Suggestion)
+	}
+	if !running.CompareAndSwap(false, true) {
+		return nil, ErrRunning
+	}
+
+	orch := orchestrator.NewWithRegistry(o.cfg, o.logger, prometheus.NewRegistry())
+	orch.SetOutput(o.output)
+	orch.DisableSignals()
+	for _, fn := range o.phaseHandlers {
+		orch.OnPhase(phaseHandler(fn))
+	}
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	s := &Swarm{orch: orch, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		s.err = orch.Run(runCtx)
+		running.Store(false)
+	}()
+
+	select {
+	case <-orch.Started():
+		s.started = time.Now()
+		return s, nil
+	case <-s.done:
+		if s.err == nil {
+			return nil, ErrStopped
+		}
+		return nil, fmt.Errorf("swarm: %w", s.err)
+	case <-ctx.Done():
+		cancel()
+		<-s.done
+		return nil, ctx.Err()
+	}
+}
+
+// Scale changes the number of clients and waits until the swarm has
+// started (at the ramp rate) or stopped the difference. If ctx ends
+// first, the swarm keeps moving towards n and ctx's error is returned.
+//
+// Clients assigned to cohorts can't be added beyond the configured
+// number; see orchestrator.Orchestrator.Scale.
+func (s *Swarm) Scale(ctx context.Context, n int) error {
+	if err := s.orch.Scale(n); err != nil {
+		select {
+		case <-s.done:
+			return ErrStopped
+		default:
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(scalePoll)
+	defer ticker.Stop()
+	for s.orch.RunningClients() != n {
+		select {
+		case <-ticker.C:
+		case <-s.done:
+			return ErrStopped
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Stop stops the clients and waits for the run to finish, returning its
+// error (nil for a clean run). If ctx ends first, the clients are still
+// stopping and ctx's error is returned; Wait then waits for the rest.
+// Calling Stop again is safe.
+func (s *Swarm) Stop(ctx context.Context) error {
+	s.cancel()
+	select {
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait waits until the swarm stops, by Stop or at the end of its
+// duration, and returns the run's error.
+func (s *Swarm) Wait() error {
+	<-s.done
+	return s.err
+}
+
+// Done is closed once the swarm has stopped.
+func (s *Swarm) Done() <-chan struct{} {
+	return s.done
+}
+
+// MetricsAddrs returns the addresses the swarm's Prometheus metrics are
+// served on (/metrics), with random ports resolved.
+func (s *Swarm) MetricsAddrs() []string {
+	return s.orch.MetricsAddrs()
+}
@@ -0,0 +1,168 @@
+package swarm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeFFmpeg writes a stand-in for FFmpeg that plays forever, i.e. until
+// it is stopped.
+func fakeFFmpeg(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexec sleep 60\n"), 0o755); err != nil {
+		t.Fatalf("write fake ffmpeg: %v", err)
+	}
+	return path
+}
+
+func startTestSwarm(t *testing.T, opts ...Option) *Swarm {
+	t.Helper()
+	opts = append([]Option{
+		WithFFmpegPath(fakeFFmpeg(t)),
+		WithRampRate(100),
+		WithSkipPreflight(),
+		WithLoadEstimate(false),
+	}, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	s, err := Start(ctx, "http://127.0.0.1:1/live/stream.m3u8", opts...)
+	if err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	t.Cleanup(func() { s.Stop(context.Background()) })
+	return s
+}
+
+func scrapeMetrics(t *testing.T, s *Swarm) string {
+	t.Helper()
+	resp, err := http.Get("http://" + s.MetricsAddrs()[0] + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestSwarm(t *testing.T) {
+	s := startTestSwarm(t, WithClients(2))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.Scale(ctx, 4); err != nil {
+		t.Fatalf("Scale(4) = %v", err)
+	}
+	if st := s.Stats(); st.TargetClients != 4 || st.RunningClients != 4 {
+		t.Errorf("after Scale(4): target %d, running %d; want 4 and 4", st.TargetClients, st.RunningClients)
+	}
+
+	if err := s.Scale(ctx, 1); err != nil {
+		t.Fatalf("Scale(1) = %v", err)
+	}
+	if st := s.Stats(); st.TargetClients != 1 || st.RunningClients != 1 || st.Elapsed <= 0 {
+		t.Errorf("after Scale(1): %+v, want 1 client", st)
+	}
+	if body := scrapeMetrics(t, s); !strings.Contains(body, "hls_swarm_target_clients 1") {
+		t.Error("/metrics should report the scaled target of 1")
+	}
+
+	// One at a time
+	if _, err := Start(ctx, "http://127.0.0.1:1/other.m3u8"); !errors.Is(err, ErrRunning) {
+		t.Errorf("second Start() = %v, want ErrRunning", err)
+	}
+
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+	if err := s.Stop(ctx); err != nil {
+		t.Errorf("second Stop() = %v, want nil", err)
+	}
+	if err := s.Scale(ctx, 2); !errors.Is(err, ErrStopped) {
+		t.Errorf("Scale() after Stop = %v, want ErrStopped", err)
+	}
+
+	// The next swarm gets fresh metrics
+	next := startTestSwarm(t, WithClients(3))
+	if body := scrapeMetrics(t, next); !strings.Contains(body, "hls_swarm_target_clients 3") {
+		t.Error("/metrics of the next swarm should report its target of 3")
+	}
+}
+
+func TestSwarm_Duration(t *testing.T) {
+	s := startTestSwarm(t, WithClients(1), WithDuration(200*time.Millisecond))
+	select {
+	case <-s.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("swarm still running after its duration")
+	}
+	if err := s.Wait(); err != nil {
+		t.Errorf("Wait() = %v", err)
+	}
+}
+
+func TestStart_Errors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Start(ctx, ""); !errors.Is(err, ErrConfig) || !strings.Contains(err.Error(), "stream") {
+		t.Errorf("Start() without a URL = %v, want a validation error", err)
+	}
+	if _, err := Start(ctx, "http://127.0.0.1:1/live.m3u8", WithClients(-1)); err == nil {
+		t.Error("Start() with -1 clients = nil, want a validation error")
+	}
+	if _, err := Start(ctx, "http://127.0.0.1:1/live.m3u8", WithClients(600), WithStrict()); err == nil || !strings.Contains(err.Error(), "stats_loglevel") {
+		t.Errorf("Start() with a warning and WithStrict = %v, want the warning as an error", err)
+	}
+
+	_, err := Start(ctx, "http://127.0.0.1:1/live.m3u8",
+		WithFFmpegPath(filepath.Join(t.TempDir(), "missing-ffmpeg")),
+		WithLoadEstimate(false),
+	)
+	if !errors.Is(err, ErrEnvironment) || !strings.Contains(err.Error(), "preflight") {
+		t.Errorf("Start() without FFmpeg = %v, want preflight failure", err)
+	}
+
+	// A failed setup doesn't hold the process's one swarm
+	s := startTestSwarm(t, WithClients(1))
+	if err := s.Stop(ctx); err != nil {
+		t.Errorf("Stop() = %v", err)
+	}
+}
+
+func TestSwarm_Phases(t *testing.T) {
+	events := make(chan PhaseEvent, 16)
+	s := startTestSwarm(t, WithClients(2), WithPhaseHandler(func(ev PhaseEvent) { events <- ev }))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for s.Phase() != PhaseSteady && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if st := s.Stats(); st.Phase != PhaseSteady {
+		t.Fatalf("Stats().Phase = %q, want steady", st.Phase)
+	}
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() = %v", err)
+	}
+
+	want := []Phase{PhaseStarting, PhaseRamping, PhaseSteady, PhaseDraining, PhaseStopped}
+	for i, phase := range want {
+		select {
+		case ev := <-events:
+			if ev.Phase != phase || (i > 0 && ev.Previous != want[i-1]) {
+				t.Errorf("event %d = %+v, want %q", i, ev, phase)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no event %d (%q)", i, phase)
+		}
+	}
+	if body := scrapeMetrics(t, startTestSwarm(t, WithClients(1))); strings.Contains(body, `hls_swarm_phase{phase="stopped"} 1`) {
+		t.Error("the next swarm's hls_swarm_phase still shows the last run stopped")
+	}
+}